@@ -2,89 +2,63 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
-	"kb-platform-gateway/internal/api/handlers"
-	"kb-platform-gateway/internal/api/routes"
+	"kb-platform-gateway/internal/app"
+	"kb-platform-gateway/internal/buildinfo"
 	"kb-platform-gateway/internal/config"
-	"kb-platform-gateway/internal/repository"
-	"kb-platform-gateway/internal/services"
 
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "check" {
+		os.Exit(check(os.Args[2:]))
+	}
+
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
-	// Initialize logger
-	logger := zerolog.New(os.Stdout).With().Timestamp().Logger()
-	logger.Info().Msg("Starting KB Platform Gateway")
+	// Initialize logger, tagging every line with the build
+	build := buildinfo.Get()
+	logger := zerolog.New(os.Stdout).With().Timestamp().
+		Str("version", build.Version).
+		Str("git_sha", build.GitSHA).
+		Logger()
+	logger.Info().
+		Str("build_time", build.BuildTime).
+		Str("go_version", build.GoVersion).
+		Strs("features", cfg.Features()).
+		Msg("Starting KB Platform Gateway")
 
 	// Set Gin mode
 	if cfg.Server.Mode == "release" {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
-	// Create Gin router
-	router := gin.New()
-
-	// Initialize repository
-	repo, err := repository.NewPostgresRepository(&cfg.Database)
+	// Wire dependencies, handlers and routes
+	gateway, err := app.New(cfg, logger)
 	if err != nil {
-		log.Fatalf("Failed to initialize repository: %v", err)
+		log.Fatalf("Failed to initialize gateway: %v", err)
 	}
-	defer repo.Close()
-
-	// Initialize services
-	pythonCoreClient := services.NewPythonCoreClient(cfg.Services.PythonCoreHost, cfg.Services.PythonCorePort)
-	s3Client, err := services.NewS3Client(&cfg.S3)
-	if err != nil {
-		log.Fatalf("Failed to create S3 client: %v", err)
-	}
-	temporalClient, err := services.NewTemporalClient(&cfg.Temporal)
-	if err != nil {
-		log.Fatalf("Failed to create Temporal client: %v", err)
-	}
-	qdrantClient, err := services.NewQdrantClient(&cfg.Qdrant)
-	if err != nil {
-		log.Fatalf("Failed to create Qdrant client: %v", err)
-	}
-
-	// Setup middleware
-	setupMiddleware(router, cfg, logger)
-
-	// Initialize handlers with services
-	h, err := handlers.NewHandlers(repo, pythonCoreClient, s3Client, temporalClient, qdrantClient, logger)
-	if err != nil {
-		log.Fatalf("Failed to create handlers: %v", err)
-	}
-	defer func() {
-		if temporalClient != nil {
-			temporalClient.Close()
-		}
-		if qdrantClient != nil {
-			qdrantClient.Close()
-		}
-	}()
-
-	// Setup routes
-	routes.SetupRoutes(router, cfg, h, logger)
+	defer gateway.Close()
 
 	// Create HTTP server
 	srv := &http.Server{
 		Addr:           fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port),
-		Handler:        router,
+		Handler:        gateway.Router,
 		ReadTimeout:    30 * time.Second,
 		WriteTimeout:   30 * time.Second,
 		MaxHeaderBytes: 1 << 20,
@@ -101,6 +75,23 @@ func main() {
 		}
 	}()
 
+	if cfg.Server.GRPCEnabled {
+		lis, err := net.Listen("tcp", fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.GRPCPort))
+		if err != nil {
+			logger.Fatal().Err(err).Msg("Failed to listen for gRPC")
+		}
+
+		go func() {
+			logger.Info().
+				Str("host", cfg.Server.Host).
+				Int("port", cfg.Server.GRPCPort).
+				Msg("gRPC server starting")
+			if err := gateway.GRPCServer.Serve(lis); err != nil {
+				logger.Fatal().Err(err).Msg("Failed to start gRPC server")
+			}
+		}()
+	}
+
 	// Wait for interrupt signal
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -116,46 +107,38 @@ func main() {
 		logger.Error().Err(err).Msg("Server forced to shutdown")
 	}
 
+	stopped := make(chan struct{})
+	go func() {
+		gateway.GRPCServer.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		gateway.GRPCServer.Stop()
+	}
+
 	logger.Info().Msg("Server exited")
 }
 
-func setupMiddleware(router *gin.Engine, cfg *config.Config, logger zerolog.Logger) {
-	// Recovery middleware
-	router.Use(gin.Recovery())
+// check runs the startup self-test against the configured dependencies,
+// prints a report and returns the exit code: 0 if every check passed.
+func check(args []string) int {
+	flags := flag.NewFlagSet("check", flag.ExitOnError)
+	timeout := flags.Duration("timeout", 10*time.Second, "time limit for each check")
+	flags.Parse(args)
 
-	// Logger middleware
-	router.Use(func(c *gin.Context) {
-		start := time.Now()
-		path := c.Request.URL.Path
-		method := c.Request.Method
-
-		// Process request
-		c.Next()
-
-		// Log after processing
-		latency := time.Since(start)
-		status := c.Writer.Status()
+	cfg, err := config.Load()
+	if err != nil {
+		app.WriteCheckReport(os.Stdout, []app.CheckResult{{Name: "config", Err: err}})
+		return 1
+	}
 
-		logger.Info().
-			Str("method", method).
-			Str("path", path).
-			Int("status", status).
-			Dur("latency", latency).
-			Str("client_ip", c.ClientIP()).
-			Msg("Request processed")
-	})
-
-	// CORS middleware
-	router.Use(func(c *gin.Context) {
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(http.StatusNoContent)
-			return
-		}
+	checks, closeAll := app.SelfChecks(cfg)
+	defer closeAll()
 
-		c.Next()
-	})
+	if !app.WriteCheckReport(os.Stdout, app.RunChecks(context.Background(), checks, *timeout)) {
+		return 1
+	}
+	return 0
 }
@@ -0,0 +1,18 @@
+// Package docs embeds the gateway's OpenAPI specification and the Swagger UI
+// page that renders it.
+//
+// openapi.json is maintained by hand: update it together with any change to
+// routes, request or response models.
+package docs
+
+import _ "embed"
+
+// OpenAPI is the OpenAPI 3 specification served at /openapi.json.
+//
+//go:embed openapi.json
+var OpenAPI []byte
+
+// SwaggerUI is the HTML page served at /docs.
+//
+//go:embed swagger.html
+var SwaggerUI []byte
@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"net/http"
+
+	"kb-platform-gateway/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetDocumentAccessGroups restricts the chunks queries retrieve from a
+// document to members of its access groups and admins.
+func (h *Handlers) SetDocumentAccessGroups(c *gin.Context) {
+	var req models.SetAccessGroupsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "VALIDATION_ERROR",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	doc, err := h.Gateway().SetDocumentAccessGroups(c.Request.Context(), c.Param("id"), req.AccessGroups, c.GetString("username"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, doc)
+}
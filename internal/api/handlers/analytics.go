@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"kb-platform-gateway/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultLeaderboardDays  = 30
+	maxLeaderboardDays      = 365
+	defaultLeaderboardLimit = 10
+	maxLeaderboardLimit     = 100
+)
+
+// DocumentAnalytics returns how often a document was cited in answers.
+func (h *Handlers) DocumentAnalytics(c *gin.Context) {
+	analytics, err := h.Gateway().DocumentAnalytics(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, analytics)
+}
+
+// DocumentLeaderboard ranks indexed documents by the chunks cited in
+// answers over the last ?days=, most cited first or, with ?order=least,
+// least cited first.
+func (h *Handlers) DocumentLeaderboard(c *gin.Context) {
+	order := c.DefaultQuery("order", models.LeaderboardOrderMost)
+	if order != models.LeaderboardOrderMost && order != models.LeaderboardOrderLeast {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "VALIDATION_ERROR",
+				Message: "order must be most or least",
+			},
+		})
+		return
+	}
+
+	days := defaultLeaderboardDays
+	if v := c.Query("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxLeaderboardDays {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: models.ErrorDetail{
+					Code:    "VALIDATION_ERROR",
+					Message: "days must be between 1 and 365",
+				},
+			})
+			return
+		}
+		days = n
+	}
+
+	limit := defaultLeaderboardLimit
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxLeaderboardLimit {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: models.ErrorDetail{
+					Code:    "VALIDATION_ERROR",
+					Message: "limit must be between 1 and 100",
+				},
+			})
+			return
+		}
+		limit = n
+	}
+
+	since := time.Now().UTC().AddDate(0, 0, -days)
+	leaderboard, err := h.Repository.ListDocumentLeaderboard(c.Request.Context(), since, order, limit)
+	if err != nil {
+		h.Logger.Error().Err(err).Msg("Failed to list document leaderboard")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to list document leaderboard",
+			},
+		})
+		return
+	}
+
+	documents := make([]models.DocumentAnalytics, len(leaderboard))
+	for i, analytics := range leaderboard {
+		documents[i] = *analytics
+	}
+
+	c.JSON(http.StatusOK, models.DocumentLeaderboardResponse{
+		Order:     order,
+		Days:      days,
+		Documents: documents,
+	})
+}
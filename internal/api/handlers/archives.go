@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+
+	"kb-platform-gateway/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListChildDocuments lists the documents expanded from an archive.
+func (h *Handlers) ListChildDocuments(c *gin.Context) {
+	limit, offset := page(c)
+
+	documents, total, err := h.Gateway().ListChildDocuments(c.Request.Context(), c.Param("id"), limit, offset)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	docList := make([]models.Document, len(documents))
+	for i, doc := range documents {
+		docList[i] = *doc
+	}
+
+	c.JSON(http.StatusOK, models.DocumentListResponse{
+		Documents: docList,
+		Total:     total,
+		Limit:     limit,
+		Offset:    offset,
+	})
+}
+
+// RegisterArchiveEntry registers a file the archive workflow expanded and
+// returns the child document to upload it to.
+func (h *Handlers) RegisterArchiveEntry(c *gin.Context) {
+	var req models.ArchiveEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "VALIDATION_ERROR",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	doc, err := h.Gateway().RegisterArchiveEntry(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, doc)
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"net/http"
+
+	"kb-platform-gateway/internal/gateway"
+	"kb-platform-gateway/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BatchUpload registers several uploads at once, returning a presigned
+// upload URL and document for each file, or the error that refused it.
+func (h *Handlers) BatchUpload(c *gin.Context) {
+	var req models.BatchUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "VALIDATION_ERROR",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	results, err := h.Gateway().BatchUpload(c.Request.Context(), req, c.GetString("username"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	resp := models.BatchUploadResponse{Results: make([]models.BatchUploadResult, len(results))}
+	for i, result := range results {
+		resp.Results[i] = models.BatchUploadResult{
+			Filename: req.Files[i].Filename,
+			Document: result.Document,
+			Error:    batchError(result),
+		}
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// BatchCompleteUpload completes several uploads at once.
+func (h *Handlers) BatchCompleteUpload(c *gin.Context) {
+	var req models.BatchCompleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "VALIDATION_ERROR",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	results, err := h.Gateway().BatchCompleteUpload(c.Request.Context(), req.DocumentIDs)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	resp := models.BatchCompleteResponse{Results: make([]models.BatchCompleteResult, len(results))}
+	for i, result := range results {
+		resp.Results[i] = models.BatchCompleteResult{
+			DocumentID: req.DocumentIDs[i],
+			Document:   result.Document,
+			Error:      batchError(result),
+		}
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// batchError is the error body of a failed batch item, or nil.
+func batchError(result gateway.BatchResult) *models.ErrorDetail {
+	if result.Err == nil {
+		return nil
+	}
+	_, detail := errorDetail(result.Err)
+	return &detail
+}
+
+// BatchDeleteDocuments deletes several documents at once, named by ID or
+// by status, reporting the outcome for each.
+func (h *Handlers) BatchDeleteDocuments(c *gin.Context) {
+	var req models.BatchDeleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "VALIDATION_ERROR",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	results, remaining, err := h.Gateway().BatchDelete(c.Request.Context(), req)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	resp := models.BatchDeleteResponse{Results: make([]models.BatchDeleteResult, len(results)), Remaining: remaining}
+	for i, result := range results {
+		item := models.BatchDeleteResult{Error: batchError(result)}
+		if result.Document != nil {
+			item.DocumentID = result.Document.ID
+			item.Filename = result.Document.Filename
+		} else {
+			item.DocumentID = req.DocumentIDs[i]
+		}
+		resp.Results[i] = item
+		if item.Error == nil {
+			resp.Deleted++
+		} else {
+			resp.Failed++
+		}
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"kb-platform-gateway/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReindexDocument re-indexes a document, optionally with new chunking
+// options.
+func (h *Handlers) ReindexDocument(c *gin.Context) {
+	var req models.ReindexDocumentRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: models.ErrorDetail{
+					Code:    "VALIDATION_ERROR",
+					Message: "Invalid request format",
+				},
+			})
+			return
+		}
+	}
+
+	doc, err := h.Gateway().ReindexDocument(c.Request.Context(), c.Param("id"), req.Chunking)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, doc)
+}
+
+// formChunking reads the chunk_strategy, chunk_size and chunk_overlap
+// form fields of an upload, nil if none is set. It writes a 400 response
+// and reports false if a number is malformed.
+func formChunking(c *gin.Context) (*models.ChunkingOptions, bool) {
+	strategy, size, overlap := c.PostForm("chunk_strategy"), c.PostForm("chunk_size"), c.PostForm("chunk_overlap")
+	if strategy == "" && size == "" && overlap == "" {
+		return nil, true
+	}
+
+	chunking := &models.ChunkingOptions{Strategy: strategy}
+	for _, field := range []struct {
+		name  string
+		value string
+		dst   *int
+	}{
+		{"chunk_size", size, &chunking.Size},
+		{"chunk_overlap", overlap, &chunking.Overlap},
+	} {
+		if field.value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(field.value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: models.ErrorDetail{
+					Code:    "VALIDATION_ERROR",
+					Message: field.name + " must be an integer",
+				},
+			})
+			return nil, false
+		}
+		*field.dst = n
+	}
+	return chunking, true
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"net/http"
+
+	"kb-platform-gateway/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handlers) CreateCollection(c *gin.Context) {
+	var req models.CreateCollectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "VALIDATION_ERROR",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	collection, err := h.Gateway().CreateCollection(c.Request.Context(), req, c.GetString("username"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, collection)
+}
+
+func (h *Handlers) ListCollections(c *gin.Context) {
+	limit, offset := page(c)
+
+	collections, total, err := h.Gateway().ListCollections(c.Request.Context(), limit, offset)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	collectionList := make([]models.Collection, len(collections))
+	for i, collection := range collections {
+		collectionList[i] = *collection
+	}
+
+	c.JSON(http.StatusOK, models.CollectionListResponse{
+		Collections: collectionList,
+		Total:       total,
+		Limit:       limit,
+		Offset:      offset,
+	})
+}
+
+func (h *Handlers) GetCollection(c *gin.Context) {
+	collection, err := h.Gateway().GetCollection(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, collection)
+}
+
+func (h *Handlers) DeleteCollection(c *gin.Context) {
+	if err := h.Gateway().DeleteCollection(c.Request.Context(), c.Param("id"), c.GetString("username")); err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handlers) ListCollectionDocuments(c *gin.Context) {
+	limit, offset := page(c)
+
+	documents, total, err := h.Gateway().ListCollectionDocuments(c.Request.Context(), c.Param("id"), limit, offset)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	docList := make([]models.Document, len(documents))
+	for i, doc := range documents {
+		docList[i] = *doc
+	}
+
+	c.JSON(http.StatusOK, models.DocumentListResponse{
+		Documents: docList,
+		Total:     total,
+		Limit:     limit,
+		Offset:    offset,
+	})
+}
+
+// AddCollectionDocuments adds a set of documents to a collection.
+func (h *Handlers) AddCollectionDocuments(c *gin.Context) {
+	h.updateCollection(c, true)
+}
+
+// RemoveCollectionDocuments removes a set of documents from a collection.
+func (h *Handlers) RemoveCollectionDocuments(c *gin.Context) {
+	h.updateCollection(c, false)
+}
+
+func (h *Handlers) updateCollection(c *gin.Context, add bool) {
+	var req models.CollectionDocumentsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "VALIDATION_ERROR",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	update := h.Gateway().RemoveCollectionDocuments
+	if add {
+		update = h.Gateway().AddCollectionDocuments
+	}
+	updated, err := update(c.Request.Context(), c.Param("id"), req, c.GetString("username"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.CollectionUpdateResponse{Updated: updated})
+}
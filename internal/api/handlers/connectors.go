@@ -0,0 +1,329 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+
+	"kb-platform-gateway/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// connectorsEnabled writes a 503 and returns false if connectors are not
+// configured.
+func (h *Handlers) connectorsEnabled(c *gin.Context) bool {
+	if h.Connectors == nil {
+		writeError(c, featureUnavailable("Connectors are not enabled"))
+		return false
+	}
+	return true
+}
+
+// connector loads the connector named by the id parameter. Unless
+// internal, connectors of other users are reported not found. It writes
+// the error response and returns nil on failure.
+func (h *Handlers) connector(c *gin.Context, internal bool) *models.Connector {
+	connectorID := c.Param("id")
+
+	connector, err := h.Repository.GetConnector(c.Request.Context(), connectorID)
+	if err != nil {
+		h.Logger.Error().Err(err).Str("connector_id", connectorID).Msg("Failed to get connector")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to get connector",
+			},
+		})
+		return nil
+	}
+
+	if connector == nil || (!internal && connector.Username != c.GetString("username")) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "NOT_FOUND",
+				Message: "Connector not found",
+			},
+		})
+		return nil
+	}
+
+	return connector
+}
+
+func (h *Handlers) CreateConnector(c *gin.Context) {
+	if !h.connectorsEnabled(c) {
+		return
+	}
+
+	var req models.CreateConnectorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "VALIDATION_ERROR",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	if !slices.Contains(models.ConnectorProviders, req.Provider) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "VALIDATION_ERROR",
+				Message: "Unknown provider",
+				Details: map[string]string{"provider": req.Provider},
+			},
+		})
+		return
+	}
+
+	connector, err := h.Connectors.Create(c.Request.Context(), c.GetString("username"), req)
+	if err != nil {
+		h.Logger.Error().Err(err).Msg("Failed to create connector")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to create connector",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, connector)
+}
+
+func (h *Handlers) ListConnectors(c *gin.Context) {
+	if !h.connectorsEnabled(c) {
+		return
+	}
+
+	connectors, err := h.Repository.ListConnectors(c.Request.Context(), c.GetString("username"))
+	if err != nil {
+		h.Logger.Error().Err(err).Msg("Failed to list connectors")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to list connectors",
+			},
+		})
+		return
+	}
+
+	connectorList := make([]models.Connector, len(connectors))
+	for i, connector := range connectors {
+		connectorList[i] = *connector
+	}
+
+	c.JSON(http.StatusOK, models.ConnectorListResponse{
+		Connectors: connectorList,
+	})
+}
+
+func (h *Handlers) GetConnector(c *gin.Context) {
+	if !h.connectorsEnabled(c) {
+		return
+	}
+
+	connector := h.connector(c, false)
+	if connector == nil {
+		return
+	}
+
+	c.JSON(http.StatusOK, connector)
+}
+
+// UpdateConnector renames a connector, changes its folders or sync
+// interval, or relinks it with new credentials.
+func (h *Handlers) UpdateConnector(c *gin.Context) {
+	if !h.connectorsEnabled(c) {
+		return
+	}
+
+	var req models.UpdateConnectorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "VALIDATION_ERROR",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	connector := h.connector(c, false)
+	if connector == nil {
+		return
+	}
+
+	if err := h.Connectors.Update(c.Request.Context(), connector, req); err != nil {
+		h.Logger.Error().Err(err).Str("connector_id", connector.ID).Msg("Failed to update connector")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to update connector",
+			},
+		})
+		return
+	}
+
+	if req.FolderIDs != nil {
+		if err := h.Gateway().RefreshConnectorResyncSchedule(c.Request.Context(), connector); err != nil {
+			writeError(c, err)
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, connector)
+}
+
+// DeleteConnector unlinks a connector and deletes its resync schedule.
+// Documents it has ingested are kept.
+func (h *Handlers) DeleteConnector(c *gin.Context) {
+	if !h.connectorsEnabled(c) {
+		return
+	}
+
+	connector := h.connector(c, false)
+	if connector == nil {
+		return
+	}
+
+	if err := h.Gateway().DeleteResyncSchedule(c.Request.Context(), models.ResyncSourceConnector, connector.ID); err != nil {
+		writeError(c, err)
+		return
+	}
+
+	if err := h.Repository.DeleteConnector(c.Request.Context(), connector.ID); err != nil {
+		h.Logger.Error().Err(err).Str("connector_id", connector.ID).Msg("Failed to delete connector")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to delete connector",
+			},
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// SyncConnector schedules a connector to sync without waiting for its
+// interval.
+func (h *Handlers) SyncConnector(c *gin.Context) {
+	if !h.connectorsEnabled(c) {
+		return
+	}
+
+	connector := h.connector(c, false)
+	if connector == nil {
+		return
+	}
+
+	if err := h.Connectors.SyncNow(c.Request.Context(), connector); err != nil {
+		h.Logger.Error().Err(err).Str("connector_id", connector.ID).Msg("Failed to schedule connector sync")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to schedule connector sync",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, connector)
+}
+
+// GetConnectorCredentials returns a connector's unsealed OAuth
+// credentials to the sync workflow.
+func (h *Handlers) GetConnectorCredentials(c *gin.Context) {
+	if !h.connectorsEnabled(c) {
+		return
+	}
+
+	connector := h.connector(c, true)
+	if connector == nil {
+		return
+	}
+
+	credentials, err := h.Connectors.Credentials(connector)
+	if err != nil {
+		h.Logger.Error().Err(err).Str("connector_id", connector.ID).Msg("Failed to unseal connector credentials")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to get connector credentials",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, credentials)
+}
+
+// UpdateConnectorCredentials saves credentials the sync workflow has
+// refreshed.
+func (h *Handlers) UpdateConnectorCredentials(c *gin.Context) {
+	if !h.connectorsEnabled(c) {
+		return
+	}
+
+	var req models.ConnectorCredentials
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "VALIDATION_ERROR",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	connector := h.connector(c, true)
+	if connector == nil {
+		return
+	}
+
+	if err := h.Connectors.UpdateCredentials(c.Request.Context(), connector.ID, req); err != nil {
+		h.Logger.Error().Err(err).Str("connector_id", connector.ID).Msg("Failed to update connector credentials")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to update connector credentials",
+			},
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// SyncConnectorFile registers a file found by the sync workflow and
+// returns the document to upload it to, unless it is unchanged.
+func (h *Handlers) SyncConnectorFile(c *gin.Context) {
+	if !h.connectorsEnabled(c) {
+		return
+	}
+
+	var req models.ConnectorFileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "VALIDATION_ERROR",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	resp, err := h.Gateway().SyncConnectorFile(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	status := http.StatusCreated
+	if resp.Unchanged {
+		status = http.StatusOK
+	}
+	c.JSON(status, resp)
+}
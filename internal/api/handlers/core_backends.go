@@ -0,0 +1,18 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CoreBackends reports how queries were split across the core backends
+// since the gateway started, with each backend's error count and latency.
+func (h *Handlers) CoreBackends(c *gin.Context) {
+	if h.CoreRouter == nil {
+		writeError(c, featureUnavailable("Canary routing is not enabled"))
+		return
+	}
+
+	c.JSON(http.StatusOK, h.CoreRouter.BackendStats())
+}
@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"kb-platform-gateway/internal/models"
+	"kb-platform-gateway/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// validCuratedPattern reports whether pattern can be used with matchType,
+// and writes a validation error if not.
+func validCuratedPattern(c *gin.Context, matchType, pattern string) bool {
+	if err := services.ValidateCuratedPattern(matchType, pattern); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "VALIDATION_ERROR",
+				Message: err.Error(),
+			},
+		})
+		return false
+	}
+	return true
+}
+
+func (h *Handlers) CreateCuratedAnswer(c *gin.Context) {
+	var req models.CreateCuratedAnswerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "VALIDATION_ERROR",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	if req.MatchType == "" {
+		req.MatchType = models.CuratedMatchExact
+	}
+	if !validCuratedPattern(c, req.MatchType, req.Pattern) {
+		return
+	}
+
+	now := time.Now()
+	username := c.GetString("username")
+	answer := &models.CuratedAnswer{
+		ID:          generateUUID(),
+		Pattern:     req.Pattern,
+		MatchType:   req.MatchType,
+		Answer:      req.Answer,
+		DocumentIDs: req.DocumentIDs,
+		Enabled:     req.Enabled == nil || *req.Enabled,
+		CreatedBy:   username,
+		UpdatedBy:   username,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	if err := h.Repository.CreateCuratedAnswer(c.Request.Context(), answer); err != nil {
+		h.Logger.Error().Err(err).Msg("Failed to create curated answer")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to create curated answer",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, answer)
+}
+
+func (h *Handlers) ListCuratedAnswers(c *gin.Context) {
+	limit, offset := page(c)
+
+	answers, total, err := h.Repository.ListCuratedAnswers(c.Request.Context(), limit, offset)
+	if err != nil {
+		h.Logger.Error().Err(err).Msg("Failed to list curated answers")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to list curated answers",
+			},
+		})
+		return
+	}
+
+	answerList := make([]models.CuratedAnswer, len(answers))
+	for i, answer := range answers {
+		answerList[i] = *answer
+	}
+
+	c.JSON(http.StatusOK, models.CuratedAnswerListResponse{
+		Answers: answerList,
+		Total:   total,
+		Limit:   limit,
+		Offset:  offset,
+	})
+}
+
+func (h *Handlers) GetCuratedAnswer(c *gin.Context) {
+	answer, ok := h.loadCuratedAnswer(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, answer)
+}
+
+// UpdateCuratedAnswer changes the set fields of a curated answer, e.g.
+// {"enabled": false} to stop serving it.
+func (h *Handlers) UpdateCuratedAnswer(c *gin.Context) {
+	var req models.UpdateCuratedAnswerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "VALIDATION_ERROR",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	answer, ok := h.loadCuratedAnswer(c)
+	if !ok {
+		return
+	}
+
+	if req.Pattern != nil {
+		answer.Pattern = *req.Pattern
+	}
+	if req.MatchType != nil {
+		answer.MatchType = *req.MatchType
+	}
+	if req.Answer != nil {
+		if *req.Answer == "" {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: models.ErrorDetail{
+					Code:    "VALIDATION_ERROR",
+					Message: "answer must not be empty",
+				},
+			})
+			return
+		}
+		answer.Answer = *req.Answer
+	}
+	if req.DocumentIDs != nil {
+		answer.DocumentIDs = *req.DocumentIDs
+	}
+	if req.Enabled != nil {
+		answer.Enabled = *req.Enabled
+	}
+	if !validCuratedPattern(c, answer.MatchType, answer.Pattern) {
+		return
+	}
+	answer.UpdatedBy = c.GetString("username")
+	answer.UpdatedAt = time.Now()
+
+	found, err := h.Repository.UpdateCuratedAnswer(c.Request.Context(), answer)
+	if err != nil {
+		h.Logger.Error().Err(err).Str("curated_answer_id", answer.ID).Msg("Failed to update curated answer")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to update curated answer",
+			},
+		})
+		return
+	}
+	if !found {
+		curatedAnswerNotFound(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, answer)
+}
+
+func (h *Handlers) DeleteCuratedAnswer(c *gin.Context) {
+	answerID := c.Param("id")
+
+	if err := h.Repository.DeleteCuratedAnswer(c.Request.Context(), answerID); err != nil {
+		h.Logger.Error().Err(err).Str("curated_answer_id", answerID).Msg("Failed to delete curated answer")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to delete curated answer",
+			},
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// loadCuratedAnswer fetches the curated answer named by the id parameter,
+// writing an error response if it cannot.
+func (h *Handlers) loadCuratedAnswer(c *gin.Context) (*models.CuratedAnswer, bool) {
+	answerID := c.Param("id")
+
+	answer, err := h.Repository.GetCuratedAnswer(c.Request.Context(), answerID)
+	if err != nil {
+		h.Logger.Error().Err(err).Str("curated_answer_id", answerID).Msg("Failed to get curated answer")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to get curated answer",
+			},
+		})
+		return nil, false
+	}
+	if answer == nil {
+		curatedAnswerNotFound(c)
+		return nil, false
+	}
+
+	return answer, true
+}
+
+func curatedAnswerNotFound(c *gin.Context) {
+	c.JSON(http.StatusNotFound, models.ErrorResponse{
+		Error: models.ErrorDetail{
+			Code:    "NOT_FOUND",
+			Message: "Curated answer not found",
+		},
+	})
+}
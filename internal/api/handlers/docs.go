@@ -0,0 +1,17 @@
+package handlers
+
+import (
+	"net/http"
+
+	"kb-platform-gateway/internal/api/docs"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handlers) OpenAPISpec(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json", docs.OpenAPI)
+}
+
+func (h *Handlers) SwaggerUI(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", docs.SwaggerUI)
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"net/http"
+
+	"kb-platform-gateway/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListDocumentEvents returns the lifecycle of a document, oldest first:
+// upload, pipeline stages, indexing, failures, re-indexing and deletion.
+func (h *Handlers) ListDocumentEvents(c *gin.Context) {
+	limit, offset := page(c)
+
+	events, total, err := h.Gateway().ListDocumentEvents(c.Request.Context(), c.Param("id"), limit, offset)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	eventList := make([]models.DocumentEvent, len(events))
+	for i, event := range events {
+		eventList[i] = *event
+	}
+
+	c.JSON(http.StatusOK, models.DocumentEventListResponse{
+		Events: eventList,
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	})
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"net/http"
+
+	"kb-platform-gateway/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateDuplicateReport starts a scan for near-duplicate documents. The
+// report is read back with GetDuplicateReport once it completes.
+func (h *Handlers) CreateDuplicateReport(c *gin.Context) {
+	var req models.CreateDuplicateReportRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: models.ErrorDetail{
+					Code:    "VALIDATION_ERROR",
+					Message: "Invalid request format",
+				},
+			})
+			return
+		}
+	}
+
+	if h.Duplicates == nil {
+		writeError(c, featureUnavailable("Duplicate detection is not available"))
+		return
+	}
+
+	report, err := h.Duplicates.Start(c.Request.Context(), req, c.GetString("username"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, report)
+}
+
+func (h *Handlers) ListDuplicateReports(c *gin.Context) {
+	limit, offset := page(c)
+
+	reports, total, err := h.Repository.ListDuplicateReports(c.Request.Context(), limit, offset)
+	if err != nil {
+		h.Logger.Error().Err(err).Msg("Failed to list duplicate reports")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to list duplicate reports",
+			},
+		})
+		return
+	}
+
+	reportList := make([]models.DuplicateReport, len(reports))
+	for i, report := range reports {
+		reportList[i] = *report
+	}
+
+	c.JSON(http.StatusOK, models.DuplicateReportListResponse{
+		Reports: reportList,
+		Total:   total,
+		Limit:   limit,
+		Offset:  offset,
+	})
+}
+
+// GetDuplicateReport returns a duplicate report with its clusters.
+func (h *Handlers) GetDuplicateReport(c *gin.Context) {
+	reportID := c.Param("id")
+	report, err := h.Repository.GetDuplicateReport(c.Request.Context(), reportID)
+	if err != nil {
+		h.Logger.Error().Err(err).Str("report_id", reportID).Msg("Failed to get duplicate report")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to get duplicate report",
+			},
+		})
+		return
+	}
+	if report == nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "NOT_FOUND",
+				Message: "Duplicate report not found",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"net/http"
+
+	"kb-platform-gateway/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateEvaluation starts running a set of question/expected-answer pairs
+// through the query pipeline. Progress and scores are read back with
+// GetEvaluation and ListEvaluationResults.
+func (h *Handlers) CreateEvaluation(c *gin.Context) {
+	var req models.CreateEvaluationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "VALIDATION_ERROR",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	if h.Evaluations == nil {
+		writeError(c, featureUnavailable("Evaluations are not available"))
+		return
+	}
+
+	evaluation, err := h.Evaluations.Start(c.Request.Context(), req, c.GetString("username"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, evaluation)
+}
+
+func (h *Handlers) ListEvaluations(c *gin.Context) {
+	limit, offset := page(c)
+
+	evaluations, total, err := h.Repository.ListEvaluations(c.Request.Context(), limit, offset)
+	if err != nil {
+		h.Logger.Error().Err(err).Msg("Failed to list evaluations")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to list evaluations",
+			},
+		})
+		return
+	}
+
+	evaluationList := make([]models.Evaluation, len(evaluations))
+	for i, evaluation := range evaluations {
+		evaluationList[i] = *evaluation
+	}
+
+	c.JSON(http.StatusOK, models.EvaluationListResponse{
+		Evaluations: evaluationList,
+		Total:       total,
+		Limit:       limit,
+		Offset:      offset,
+	})
+}
+
+func (h *Handlers) GetEvaluation(c *gin.Context) {
+	evaluation, ok := h.loadEvaluation(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, evaluation)
+}
+
+// ListEvaluationResults returns the per-question answers and scores of an
+// evaluation, in the order the cases were submitted. Cases not yet run
+// have neither an answer nor a score.
+func (h *Handlers) ListEvaluationResults(c *gin.Context) {
+	evaluation, ok := h.loadEvaluation(c)
+	if !ok {
+		return
+	}
+
+	results, err := h.Repository.ListEvaluationResults(c.Request.Context(), evaluation.ID)
+	if err != nil {
+		h.Logger.Error().Err(err).Str("evaluation_id", evaluation.ID).Msg("Failed to list evaluation results")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to list evaluation results",
+			},
+		})
+		return
+	}
+
+	resultList := make([]models.EvaluationResult, len(results))
+	for i, result := range results {
+		resultList[i] = *result
+	}
+
+	c.JSON(http.StatusOK, models.EvaluationResultListResponse{
+		Results: resultList,
+	})
+}
+
+func (h *Handlers) loadEvaluation(c *gin.Context) (*models.Evaluation, bool) {
+	evaluationID := c.Param("id")
+	evaluation, err := h.Repository.GetEvaluation(c.Request.Context(), evaluationID)
+	if err != nil {
+		h.Logger.Error().Err(err).Str("evaluation_id", evaluationID).Msg("Failed to get evaluation")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to get evaluation",
+			},
+		})
+		return nil, false
+	}
+	if evaluation == nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "NOT_FOUND",
+				Message: "Evaluation not found",
+			},
+		})
+		return nil, false
+	}
+	return evaluation, true
+}
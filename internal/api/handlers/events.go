@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"kb-platform-gateway/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IngestEvent accepts a typed event from the Python core or a Temporal
+// worker, applies it, stores it and routes it to SSE subscribers and
+// webhooks. Redelivered events (same ID) are acknowledged but not routed
+// again.
+func (h *Handlers) IngestEvent(c *gin.Context) {
+	var req models.IngestEventRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "VALIDATION_ERROR",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	event, created, err := h.Gateway().IngestEvent(c.Request.Context(), req)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	if !created {
+		c.JSON(http.StatusOK, event)
+		return
+	}
+	c.JSON(http.StatusAccepted, event)
+}
+
+// StreamEvents streams events published on the requested topics as SSE.
+func (h *Handlers) StreamEvents(c *gin.Context) {
+	topics := c.QueryArray("topic")
+	if len(topics) == 0 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "VALIDATION_ERROR",
+				Message: "At least one topic is required",
+			},
+		})
+		return
+	}
+
+	if h.Events == nil {
+		writeError(c, featureUnavailable("Event streaming is not available"))
+		return
+	}
+
+	events, cancel := h.Events.Subscribe(topics...)
+	defer cancel()
+	h.streamEvents(c, events)
+}
+
+// streamEvents writes events as SSE until the client goes away or the
+// subscription ends, with a heartbeat comment while none arrive.
+func (h *Handlers) streamEvents(c *gin.Context, events <-chan *models.Event) {
+	liftWriteDeadline(c)
+	heartbeat := time.NewTicker(h.streamHeartbeat())
+	defer heartbeat.Stop()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-heartbeat.C:
+			return writeHeartbeat(w) == nil
+		case event, ok := <-events:
+			if !ok {
+				return false
+			}
+			c.SSEvent(event.Type, event)
+			return true
+		}
+	})
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"kb-platform-gateway/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultFreshnessDays  = 90
+	maxFreshnessDays      = 3650
+	defaultFreshnessLimit = 100
+	maxFreshnessLimit     = 1000
+)
+
+// ContentFreshness reports indexed documents curators may want to prune:
+// documents not re-indexed in the last ?days=, documents whose source URL
+// returned 404 or 410 when last checked, and documents no query retrieved
+// in the last ?days=. Each list holds up to ?limit= documents and its
+// total count.
+func (h *Handlers) ContentFreshness(c *gin.Context) {
+	days := defaultFreshnessDays
+	if v := c.Query("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxFreshnessDays {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: models.ErrorDetail{
+					Code:    "VALIDATION_ERROR",
+					Message: "days must be between 1 and 3650",
+				},
+			})
+			return
+		}
+		days = n
+	}
+
+	limit := defaultFreshnessLimit
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxFreshnessLimit {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: models.ErrorDetail{
+					Code:    "VALIDATION_ERROR",
+					Message: "limit must be between 1 and 1000",
+				},
+			})
+			return
+		}
+		limit = n
+	}
+
+	ctx := c.Request.Context()
+	now := time.Now().UTC()
+	since := now.AddDate(0, 0, -days)
+	report := models.ContentFreshnessReport{
+		Days:        days,
+		Since:       since,
+		GeneratedAt: now,
+	}
+
+	var err error
+	report.NotReindexed, err = staleDocumentList(h.Repository.ListStaleDocuments(ctx, since, limit))
+	if err != nil {
+		h.internalFreshnessError(c, err)
+		return
+	}
+	report.BrokenSources, err = staleDocumentList(h.Repository.ListBrokenSourceDocuments(ctx, limit))
+	if err != nil {
+		h.internalFreshnessError(c, err)
+		return
+	}
+	report.NeverRetrieved, err = staleDocumentList(h.Repository.ListUnretrievedDocuments(ctx, since, limit))
+	if err != nil {
+		h.internalFreshnessError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+func staleDocumentList(documents []*models.StaleDocument, total int, err error) (models.StaleDocumentList, error) {
+	if err != nil {
+		return models.StaleDocumentList{}, err
+	}
+	list := models.StaleDocumentList{
+		Documents: make([]models.StaleDocument, len(documents)),
+		Total:     total,
+	}
+	for i, doc := range documents {
+		list.Documents[i] = *doc
+	}
+	return list, nil
+}
+
+func (h *Handlers) internalFreshnessError(c *gin.Context, err error) {
+	h.Logger.Error().Err(err).Msg("Failed to build content freshness report")
+	c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+		Error: models.ErrorDetail{
+			Code:    "INTERNAL_ERROR",
+			Message: "Failed to build content freshness report",
+		},
+	})
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"kb-platform-gateway/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handlers) CreateGlossaryTerm(c *gin.Context) {
+	var req models.CreateGlossaryTermRequest
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Term) == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "VALIDATION_ERROR",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	now := time.Now()
+	term := &models.GlossaryTerm{
+		ID:         generateUUID(),
+		Term:       strings.TrimSpace(req.Term),
+		Definition: req.Definition,
+		CreatedBy:  c.GetString("username"),
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	created, err := h.Repository.CreateGlossaryTerm(c.Request.Context(), term)
+	if err != nil {
+		h.Logger.Error().Err(err).Msg("Failed to create glossary term")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to create glossary term",
+			},
+		})
+		return
+	}
+	if !created {
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "CONFLICT",
+				Message: "Glossary term already exists",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, term)
+}
+
+func (h *Handlers) ListGlossaryTerms(c *gin.Context) {
+	limit, offset := page(c)
+
+	terms, total, err := h.Repository.ListGlossaryTerms(c.Request.Context(), limit, offset)
+	if err != nil {
+		h.Logger.Error().Err(err).Msg("Failed to list glossary terms")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to list glossary terms",
+			},
+		})
+		return
+	}
+
+	termList := make([]models.GlossaryTerm, len(terms))
+	for i, term := range terms {
+		termList[i] = *term
+	}
+
+	c.JSON(http.StatusOK, models.GlossaryTermListResponse{
+		Terms:  termList,
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	})
+}
+
+func (h *Handlers) UpdateGlossaryTerm(c *gin.Context) {
+	var req models.UpdateGlossaryTermRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "VALIDATION_ERROR",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	termID := c.Param("id")
+	term, err := h.Repository.GetGlossaryTerm(c.Request.Context(), termID)
+	if err != nil {
+		h.Logger.Error().Err(err).Str("glossary_term_id", termID).Msg("Failed to get glossary term")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to get glossary term",
+			},
+		})
+		return
+	}
+	if term == nil {
+		glossaryTermNotFound(c)
+		return
+	}
+
+	term.Definition = req.Definition
+	term.UpdatedAt = time.Now()
+	found, err := h.Repository.UpdateGlossaryTerm(c.Request.Context(), term)
+	if err != nil {
+		h.Logger.Error().Err(err).Str("glossary_term_id", termID).Msg("Failed to update glossary term")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to update glossary term",
+			},
+		})
+		return
+	}
+	if !found {
+		glossaryTermNotFound(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, term)
+}
+
+func (h *Handlers) DeleteGlossaryTerm(c *gin.Context) {
+	termID := c.Param("id")
+
+	if err := h.Repository.DeleteGlossaryTerm(c.Request.Context(), termID); err != nil {
+		h.Logger.Error().Err(err).Str("glossary_term_id", termID).Msg("Failed to delete glossary term")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to delete glossary term",
+			},
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func glossaryTermNotFound(c *gin.Context) {
+	c.JSON(http.StatusNotFound, models.ErrorResponse{
+		Error: models.ErrorDetail{
+			Code:    "NOT_FOUND",
+			Message: "Glossary term not found",
+		},
+	})
+}
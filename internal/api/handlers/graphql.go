@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"strings"
+
+	"kb-platform-gateway/internal/graph"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GraphQL serves queries, mutations and subscriptions on /graphql.
+func (h *Handlers) GraphQL(c *gin.Context) {
+	h.graphQLOnce.Do(func() {
+		h.graphQL = graph.NewHandler(h.Gateway())
+	})
+
+	// Subscriptions over SSE stay open; those over WebSocket take over the
+	// connection, which clears its deadlines.
+	if strings.Contains(c.GetHeader("Accept"), "text/event-stream") {
+		liftWriteDeadline(c)
+	}
+
+	ctx := graph.WithUsername(c.Request.Context(), c.GetString("username"))
+	h.graphQL.ServeHTTP(c.Writer, c.Request.WithContext(ctx))
+}
@@ -1,11 +1,19 @@
 package handlers
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"io"
+	"mime/multipart"
 	"net/http"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"kb-platform-gateway/internal/buildinfo"
+	"kb-platform-gateway/internal/config"
+	"kb-platform-gateway/internal/gateway"
 	"kb-platform-gateway/internal/models"
 	"kb-platform-gateway/internal/repository"
 	"kb-platform-gateway/internal/services"
@@ -16,20 +24,107 @@ import (
 )
 
 type Handlers struct {
-	CoreClient   services.PythonCoreClientInterface
+	CoreClient   services.CoreServiceInterface
 	S3Client     services.S3ClientInterface
 	Temporal     services.TemporalClientInterface
 	QdrantClient services.QdrantClientInterface
-	Repository   repository.Repository
-	Logger       zerolog.Logger
+	// Redis is nil when REDIS_ENABLED is off.
+	Redis    services.RedisClientInterface
+	Webhooks services.WebhookDispatcherInterface
+	// Notifications is nil when NOTIFY_PROVIDER is unset.
+	Notifications services.NotificationServiceInterface
+	// Alerts is nil when no ops alert channel is configured and the status
+	// page is disabled.
+	Alerts services.OpsMonitorInterface
+	// StatusPage is nil when STATUS_PAGE_ENABLED is off.
+	StatusPage services.StatusPageInterface
+	// Probes answer /readyz and /startupz. When nil, they check the core
+	// and Redis only.
+	Probes services.HealthProbesInterface
+	// Migrations is nil when Qdrant or Temporal is not configured.
+	Migrations services.EmbeddingMigratorInterface
+	// CoreRouter is nil unless traffic is split across core backends.
+	CoreRouter services.CoreRouterInterface
+	// Shadow is nil when shadow traffic is disabled.
+	Shadow services.ShadowMirrorInterface
+	// Curated serves editor-pinned answers before queries reach the core.
+	Curated services.CuratedAnswersInterface
+	// Glossary highlights glossary terms in answers.
+	Glossary services.GlossaryInterface
+	// Redactions redacts answers with the enabled redaction rules.
+	Redactions services.RedactionsInterface
+	// Answers is nil when QUERY_DEDUP_WINDOW is unset.
+	Answers services.AnswerCacheInterface
+	// Summaries is nil when no CONVERSATION_SUMMARY_* threshold is set.
+	Summaries services.ConversationSummarizerInterface
+	// Conversations is nil when CONVERSATION_QUERY_MODE is off.
+	Conversations services.ConversationLocksInterface
+	// Reads shares document and conversation reads between requests.
+	Reads services.ReadCacheInterface
+	// TrashRetention is zero when TRASH_RETENTION is unset, and documents
+	// are deleted at once.
+	TrashRetention time.Duration
+	// StreamHeartbeat is how often idle SSE streams send a comment; zero
+	// means every 15 seconds.
+	StreamHeartbeat time.Duration
+	// Workers is the instance's shared worker pool, on which the items of
+	// batch requests are processed.
+	Workers *services.WorkerPool
+	// ProxyUploads is nil when UPLOAD_PROXY_ENABLED is off.
+	ProxyUploads *gateway.ProxyUploadLimits
+	// UploadPolicy is nil unless UPLOAD_MAX_FILE_SIZE,
+	// UPLOAD_ALLOWED_CONTENT_TYPES or UPLOAD_ALLOWED_EXTENSIONS is set.
+	UploadPolicy *gateway.UploadPolicy
+	// Scanner is nil when SCAN_CLAMAV_ADDR is unset.
+	Scanner services.ScannerInterface
+	// Quarantine is SCAN_QUARANTINE.
+	Quarantine bool
+	// Reviewers is DOCUMENT_REVIEWERS; uploads are indexed without review
+	// when it is empty.
+	Reviewers []string
+	// Access decides whose queries retrieve documents restricted to
+	// access groups; nil retrieves every document for everyone.
+	Access *gateway.DocumentAccess
+	// Widgets is nil when WIDGET_SIGNING_KEY is unset.
+	Widgets services.WidgetTokensInterface
+	// Impersonation is nil when IMPERSONATION_SIGNING_KEY is unset.
+	Impersonation services.ImpersonationTokensInterface
+	// OIDC is nil when OIDC_ISSUER or OIDC_CLIENT_ID is unset.
+	OIDC services.OIDCVerifierInterface
+	// Connectors is nil when CONNECTOR_ENCRYPTION_KEY is unset.
+	Connectors services.ConnectorServiceInterface
+	// Evaluations is nil when the gateway was built without one.
+	Evaluations *gateway.EvaluationRunner
+	// Duplicates is nil when the gateway was built without one.
+	Duplicates *gateway.DuplicateDetector
+	// WorkspaceImports is nil when the gateway was built without one.
+	WorkspaceImports *gateway.WorkspaceImporter
+	// Tags is nil when the gateway was built without one.
+	Tags *gateway.TagUpdater
+	// AdminUsers are the users allowed on admin endpoints.
+	AdminUsers []string
+	// Features lists the optional features enabled in the configuration.
+	Features []string
+	Events   *services.EventHub
+	// ConversationEvents carries conversation activity, which only its
+	// participants may stream, apart from Events. Nil streams none.
+	ConversationEvents *services.EventHub
+	Repository         repository.Repository
+	Logger             zerolog.Logger
+
+	graphQLOnce sync.Once
+	graphQL     http.Handler
 }
 
-func NewHandlers(repo repository.Repository, pythonCoreClient services.PythonCoreClientInterface, s3Client services.S3ClientInterface, temporalClient services.TemporalClientInterface, qdrantClient services.QdrantClientInterface, logger zerolog.Logger) (*Handlers, error) {
+func NewHandlers(repo repository.Repository, coreClient services.CoreServiceInterface, s3Client services.S3ClientInterface, temporalClient services.TemporalClientInterface, qdrantClient services.QdrantClientInterface, redisClient services.RedisClientInterface, webhooks services.WebhookDispatcherInterface, events *services.EventHub, logger zerolog.Logger) (*Handlers, error) {
 	return &Handlers{
-		CoreClient:   pythonCoreClient,
+		CoreClient:   coreClient,
 		S3Client:     s3Client,
 		Temporal:     temporalClient,
 		QdrantClient: qdrantClient,
+		Redis:        redisClient,
+		Webhooks:     webhooks,
+		Events:       events,
 		Repository:   repo,
 		Logger:       logger,
 	}, nil
@@ -42,6 +137,9 @@ func (h *Handlers) Close() {
 	if h.QdrantClient != nil {
 		h.QdrantClient.Close()
 	}
+	if h.Redis != nil {
+		h.Redis.Close()
+	}
 }
 
 func (h *Handlers) Health(c *gin.Context) {
@@ -51,22 +149,157 @@ func (h *Handlers) Health(c *gin.Context) {
 	})
 }
 
+// Ready answers the readiness probe: 503 takes the pod out of service
+// until every critical dependency is healthy.
 func (h *Handlers) Ready(c *gin.Context) {
-	deps, err := h.CoreClient.HealthCheck()
-	if err != nil {
-		c.JSON(http.StatusServiceUnavailable, models.ReadinessResponse{
-			Status:       "not_ready",
-			Dependencies: map[string]string{"python_core": err.Error()},
-		})
-		return
+	response, ok := h.probes().Ready(c.Request.Context())
+	status := http.StatusOK
+	if !ok {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, response)
+}
+
+// Started answers the startup probe, which holds back the liveness and
+// readiness probes until the schema is migrated and the critical
+// dependencies have answered once.
+func (h *Handlers) Started(c *gin.Context) {
+	response, ok := h.probes().Started(c.Request.Context())
+	status := http.StatusOK
+	if !ok {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, response)
+}
+
+func (h *Handlers) probes() services.HealthProbesInterface {
+	if h.Probes != nil {
+		return h.Probes
+	}
+	probes := make(map[string]services.HealthProbe)
+	if h.Redis != nil {
+		probes["redis"] = h.Redis.HealthCheck
 	}
+	return services.NewHealthProbes(&config.HealthConfig{Timeout: dependencyCheckTimeout}, h.CoreClient, probes, nil)
+}
 
-	c.JSON(http.StatusOK, models.ReadinessResponse{
-		Status:       "ready",
-		Dependencies: deps,
+// Version reports which build is running and what it has enabled.
+func (h *Handlers) Version(c *gin.Context) {
+	info := buildinfo.Get()
+	features := h.Features
+	if features == nil {
+		features = []string{}
+	}
+	c.JSON(http.StatusOK, models.VersionResponse{
+		Version:   info.Version,
+		GitSHA:    info.GitSHA,
+		BuildTime: info.BuildTime,
+		GoVersion: info.GoVersion,
+		Features:  features,
 	})
 }
 
+// Status serves the public status page. Public caches may keep it until
+// it is next rebuilt.
+func (h *Handlers) Status(c *gin.Context) {
+	if h.StatusPage == nil {
+		writeError(c, featureUnavailable("The status page is not enabled"))
+		return
+	}
+
+	status, ttl := h.StatusPage.Status()
+	c.Header("Cache-Control", "public, max-age="+strconv.Itoa(int(ttl.Seconds())))
+	c.JSON(http.StatusOK, status)
+}
+
+// Gateway returns the transport-independent service backed by h's
+// dependencies. The gRPC API is served from it too, so both transports
+// behave alike.
+func (h *Handlers) Gateway() *gateway.Service {
+	return &gateway.Service{
+		Repository:     h.Repository,
+		CoreClient:     h.CoreClient,
+		S3Client:       h.S3Client,
+		Temporal:       h.Temporal,
+		QdrantClient:   h.QdrantClient,
+		Webhooks:       h.Webhooks,
+		Migrations:     h.Migrations,
+		Shadow:         h.Shadow,
+		Curated:        h.Curated,
+		Glossary:       h.Glossary,
+		Redactions:     h.Redactions,
+		Answers:        h.Answers,
+		Summaries:      h.Summaries,
+		Conversations:  h.Conversations,
+		Reads:          h.Reads,
+		Events:         h.ConversationEvents,
+		PublicEvents:   h.Events,
+		Alerts:         h.Alerts,
+		Notifications:  h.Notifications,
+		ProxyUploads:   h.ProxyUploads,
+		UploadPolicy:   h.UploadPolicy,
+		Scanner:        h.Scanner,
+		Quarantine:     h.Quarantine,
+		TrashRetention: h.TrashRetention,
+		Reviewers:      h.Reviewers,
+		Access:         h.Access,
+		Batches:        h.Workers,
+		Logger:         h.Logger,
+	}
+}
+
+// writeError writes a gateway error as an ErrorResponse.
+func writeError(c *gin.Context, err error) {
+	status, detail := errorDetail(err)
+	c.JSON(status, models.ErrorResponse{Error: detail})
+}
+
+// errorDetail maps a gateway error to its HTTP status and error body.
+func errorDetail(err error) (int, models.ErrorDetail) {
+	status, code := http.StatusInternalServerError, "INTERNAL_ERROR"
+	switch gateway.KindOf(err) {
+	case gateway.KindInvalid:
+		status, code = http.StatusBadRequest, "VALIDATION_ERROR"
+	case gateway.KindNotFound:
+		status, code = http.StatusNotFound, "NOT_FOUND"
+	case gateway.KindUploadExpired:
+		status, code = http.StatusGone, "UPLOAD_URL_EXPIRED"
+	case gateway.KindConflict:
+		status, code = http.StatusConflict, "CONFLICT"
+	case gateway.KindConversationBusy:
+		status, code = http.StatusConflict, "CONVERSATION_BUSY"
+	case gateway.KindForbidden:
+		status, code = http.StatusForbidden, "AUTHORIZATION_ERROR"
+	case gateway.KindUnavailable:
+		status, code = http.StatusServiceUnavailable, "SERVICE_UNAVAILABLE"
+	case gateway.KindTooLarge:
+		status, code = http.StatusRequestEntityTooLarge, "PAYLOAD_TOO_LARGE"
+	case gateway.KindDuplicate:
+		status, code = http.StatusConflict, "DUPLICATE_DOCUMENT"
+	case gateway.KindInfected:
+		status, code = http.StatusUnprocessableEntity, "FILE_INFECTED"
+	}
+
+	return status, models.ErrorDetail{
+		Code:    code,
+		Message: gateway.MessageOf(err),
+		Details: gateway.DetailsOf(err),
+	}
+}
+
+// featureUnavailable is the error for a request to an optional feature that
+// is not configured, whose dependency is nil.
+func featureUnavailable(message string) error {
+	return &gateway.Error{Kind: gateway.KindUnavailable, Message: message}
+}
+
+// page reads the limit and offset query parameters.
+func page(c *gin.Context) (int, int) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	offset, _ := strconv.Atoi(c.Query("offset"))
+	return gateway.Page(limit, offset)
+}
+
 func (h *Handlers) UploadDocument(c *gin.Context) {
 	file, err := c.FormFile("file")
 	if err != nil {
@@ -79,91 +312,151 @@ func (h *Handlers) UploadDocument(c *gin.Context) {
 		return
 	}
 
-	documentID := generateUUID()
-	s3Key := "documents/" + documentID + "/" + file.Filename
+	// Checked before the file is read to hash it.
+	if err := h.UploadPolicy.Check(file.Filename, file.Size, file.Header.Get("Content-Type")); err != nil {
+		writeError(c, err)
+		return
+	}
 
-	uploadURL, err := h.S3Client.GeneratePresignedUploadURL(c.Request.Context(), s3Key, 15*time.Minute)
+	chunking, ok := formChunking(c)
+	if !ok {
+		return
+	}
+	processing, ok := formProcessing(c)
+	if !ok {
+		return
+	}
+	contentHash, err := fileContentHash(file)
 	if err != nil {
-		h.Logger.Error().Err(err).Msg("Failed to generate presigned URL")
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error: models.ErrorDetail{
-				Code:    "INTERNAL_ERROR",
-				Message: "Failed to generate upload URL",
-			},
-		})
+		writeError(c, err)
 		return
 	}
 
-	doc := &models.Document{
-		ID:        documentID,
-		S3Key:     s3Key,
-		Filename:  file.Filename,
-		FileSize:  file.Size,
-		Status:    "pending",
-		CreatedAt: time.Now(),
+	doc, err := h.Gateway().UploadDocument(c.Request.Context(), file.Filename, file.Size, c.GetString("username"), models.UploadOptions{
+		Metadata:    c.PostFormMap("metadata"),
+		Chunking:    chunking,
+		Processing:  processing,
+		ContentHash: contentHash,
+	})
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, doc)
+}
+
+// fileContentHash returns the hex SHA-256 of an uploaded file, by which
+// duplicate uploads are detected.
+func fileContentHash(file *multipart.FileHeader) (string, error) {
+	f, err := file.Open()
+	if err != nil {
+		return "", &gateway.Error{Kind: gateway.KindInvalid, Message: "Failed to read file", Err: err}
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", &gateway.Error{Kind: gateway.KindInvalid, Message: "Failed to read file", Err: err}
 	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
 
-	if err := h.Repository.CreateDocument(c.Request.Context(), doc); err != nil {
-		h.Logger.Error().Err(err).Msg("Failed to save document to database")
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+// CreateTextDocument ingests pasted text or markdown without a file
+// upload.
+func (h *Handlers) CreateTextDocument(c *gin.Context) {
+	var req models.CreateTextDocumentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Error: models.ErrorDetail{
-				Code:    "INTERNAL_ERROR",
-				Message: "Failed to save document",
+				Code:    "VALIDATION_ERROR",
+				Message: "Invalid request format",
 			},
 		})
 		return
 	}
 
-	// Start two-phase upload workflow
-	_, err = h.Temporal.StartUploadWorkflow(c.Request.Context(), documentID, s3Key)
+	doc, err := h.Gateway().CreateTextDocument(c.Request.Context(), req, c.GetString("username"))
 	if err != nil {
-		h.Logger.Error().Err(err).Msg("Failed to start upload workflow")
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error: models.ErrorDetail{
-				Code:    "INTERNAL_ERROR",
-				Message: "Failed to start upload workflow",
-			},
-		})
+		writeError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, models.Document{
-		ID:        doc.ID,
-		UploadURL: uploadURL,
-		S3Key:     doc.S3Key,
-		Filename:  doc.Filename,
-		FileSize:  doc.FileSize,
-		Status:    doc.Status,
-		CreatedAt: doc.CreatedAt,
-	})
+	c.JSON(http.StatusCreated, doc)
 }
 
 func (h *Handlers) ListDocuments(c *gin.Context) {
-	limit := 50
-	offset := 0
-	statusFilter := c.Query("status")
+	limit, offset := page(c)
+
+	documents, total, err := h.Gateway().ListDocuments(c.Request.Context(), limit, offset, models.DocumentFilter{
+		Status:       c.Query("status"),
+		Language:     c.Query("language"),
+		Metadata:     c.QueryMap("metadata"),
+		Tag:          c.Query("tag"),
+		CollectionID: c.Query("collection_id"),
+		Query:        c.Query("q"),
+		SortBy:       c.Query("sort_by"),
+		Order:        c.Query("order"),
+	})
+	if err != nil {
+		writeError(c, err)
+		return
+	}
 
-	if limitStr := c.Query("limit"); limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
-			limit = l
-		}
+	docList := make([]models.Document, len(documents))
+	for i, doc := range documents {
+		docList[i] = *doc
 	}
 
-	if offsetStr := c.Query("offset"); offsetStr != "" {
-		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
-			offset = o
+	c.JSON(http.StatusOK, models.DocumentListResponse{
+		Documents: docList,
+		Total:     total,
+		Limit:     limit,
+		Offset:    offset,
+	})
+}
+
+// SearchDocuments finds documents by metadata, filename, extracted title
+// and dates, for operators who know what they are looking for and need no
+// answer from the RAG pipeline.
+func (h *Handlers) SearchDocuments(c *gin.Context) {
+	limit, offset := page(c)
+
+	filter := models.DocumentFilter{
+		Status:       c.Query("status"),
+		Language:     c.Query("language"),
+		Metadata:     c.QueryMap("metadata"),
+		Tag:          c.Query("tag"),
+		CollectionID: c.Query("collection_id"),
+		Filename:     c.Query("filename"),
+		Text:         c.Query("q"),
+		SortBy:       c.Query("sort_by"),
+		Order:        c.Query("order"),
+	}
+	for _, bound := range []struct {
+		param string
+		at    **time.Time
+	}{
+		{"created_from", &filter.CreatedFrom},
+		{"created_to", &filter.CreatedTo},
+		{"indexed_from", &filter.IndexedFrom},
+		{"indexed_to", &filter.IndexedTo},
+	} {
+		v := c.Query(bound.param)
+		if v == "" {
+			continue
+		}
+		t, err := parseExportTime(v)
+		if err != nil {
+			exportValidationError(c, bound.param+" must be an RFC 3339 timestamp or YYYY-MM-DD date")
+			return
 		}
+		*bound.at = &t
 	}
 
-	documents, total, err := h.Repository.ListDocuments(c.Request.Context(), limit, offset, statusFilter)
+	documents, total, err := h.Gateway().SearchDocuments(c.Request.Context(), limit, offset, filter)
 	if err != nil {
-		h.Logger.Error().Err(err).Msg("Failed to list documents")
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error: models.ErrorDetail{
-				Code:    "INTERNAL_ERROR",
-				Message: "Failed to list documents",
-			},
-		})
+		writeError(c, err)
 		return
 	}
 
@@ -181,120 +474,155 @@ func (h *Handlers) ListDocuments(c *gin.Context) {
 }
 
 func (h *Handlers) GetDocument(c *gin.Context) {
-	documentID := c.Param("id")
-
-	doc, err := h.Repository.GetDocument(c.Request.Context(), documentID)
+	doc, err := h.Gateway().GetDocument(c.Request.Context(), c.Param("id"))
 	if err != nil {
-		h.Logger.Error().Err(err).Str("document_id", documentID).Msg("Failed to get document")
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error: models.ErrorDetail{
-				Code:    "INTERNAL_ERROR",
-				Message: "Failed to get document",
-			},
-		})
+		writeError(c, err)
 		return
 	}
 
-	if doc == nil {
-		c.JSON(http.StatusNotFound, models.ErrorResponse{
+	c.Header("ETag", documentETag(doc))
+	c.JSON(http.StatusOK, doc)
+}
+
+// UpdateDocument replaces a document's metadata. The client names the
+// version it edited, so an edit based on a stale copy is refused with 409
+// instead of overwriting someone else's change.
+func (h *Handlers) UpdateDocument(c *gin.Context) {
+	var req models.UpdateDocumentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Error: models.ErrorDetail{
-				Code:    "NOT_FOUND",
-				Message: "Document not found",
+				Code:    "VALIDATION_ERROR",
+				Message: "Invalid request format",
 			},
 		})
 		return
 	}
 
+	version, ok := documentVersion(c, req.Version)
+	if !ok {
+		return
+	}
+
+	doc, err := h.Gateway().UpdateDocument(c.Request.Context(), c.Param("id"), req, version)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.Header("ETag", documentETag(doc))
 	c.JSON(http.StatusOK, doc)
 }
 
-func (h *Handlers) DeleteDocument(c *gin.Context) {
-	documentID := c.Param("id")
+// documentETag is the ETag of a document's version, for If-Match.
+func documentETag(doc *models.Document) string {
+	return `"` + strconv.Itoa(doc.Version) + `"`
+}
 
-	doc, err := h.Repository.GetDocument(c.Request.Context(), documentID)
-	if err != nil {
-		h.Logger.Error().Err(err).Str("document_id", documentID).Msg("Failed to get document")
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error: models.ErrorDetail{
-				Code:    "INTERNAL_ERROR",
-				Message: "Failed to get document",
-			},
+// documentVersion reads the version an update is based on from the
+// If-Match header or the body, writing an error response if neither
+// names one or they disagree.
+func documentVersion(c *gin.Context, bodyVersion *int) (int, bool) {
+	fail := func(status int, code, message string) (int, bool) {
+		c.JSON(status, models.ErrorResponse{
+			Error: models.ErrorDetail{Code: code, Message: message},
 		})
-		return
+		return 0, false
 	}
 
-	if doc != nil && doc.S3Key != "" {
-		if err := h.S3Client.DeleteObject(c.Request.Context(), doc.S3Key); err != nil {
-			h.Logger.Error().Err(err).Str("s3_key", doc.S3Key).Msg("Failed to delete from S3")
+	ifMatch := c.GetHeader("If-Match")
+	if ifMatch == "" {
+		if bodyVersion == nil {
+			return fail(http.StatusPreconditionRequired, "PRECONDITION_REQUIRED", "Send the document version in If-Match or the version field")
 		}
+		return *bodyVersion, true
 	}
 
-	if err := h.QdrantClient.DeleteDocumentVectors(c.Request.Context(), documentID); err != nil {
-		h.Logger.Error().Err(err).Str("document_id", documentID).Msg("Failed to delete vectors")
+	version, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(ifMatch, "W/"), `"`))
+	if err != nil {
+		return fail(http.StatusBadRequest, "VALIDATION_ERROR", "If-Match must be a document ETag")
+	}
+	if bodyVersion != nil && *bodyVersion != version {
+		return fail(http.StatusBadRequest, "VALIDATION_ERROR", "If-Match and version disagree")
 	}
+	return version, true
+}
 
-	if err := h.Repository.DeleteDocument(c.Request.Context(), documentID); err != nil {
-		h.Logger.Error().Err(err).Str("document_id", documentID).Msg("Failed to delete document")
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error: models.ErrorDetail{
-				Code:    "INTERNAL_ERROR",
-				Message: "Failed to delete document",
-			},
-		})
+func (h *Handlers) DeleteDocument(c *gin.Context) {
+	if err := h.Gateway().DeleteDocument(c.Request.Context(), c.Param("id")); err != nil {
+		writeError(c, err)
 		return
 	}
 
 	c.Status(http.StatusNoContent)
 }
 
+// CompleteUpload signals that the file is in S3, optionally with new
+// processing options.
 func (h *Handlers) CompleteUpload(c *gin.Context) {
-	documentID := c.Param("id")
+	var req models.CompleteUploadRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: models.ErrorDetail{
+					Code:    "VALIDATION_ERROR",
+					Message: "Invalid request format",
+				},
+			})
+			return
+		}
+	}
 
-	// Signal upload completion to workflow
-	if err := h.Temporal.SignalUploadComplete(c.Request.Context(), documentID); err != nil {
-		h.Logger.Error().Err(err).Str("document_id", documentID).Msg("Failed to signal upload complete")
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error: models.ErrorDetail{
-				Code:    "INTERNAL_ERROR",
-				Message: "Failed to signal upload complete",
-			},
-		})
+	doc, err := h.Gateway().CompleteUpload(c.Request.Context(), c.Param("id"), req.Processing)
+	if err != nil {
+		writeError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, models.Document{
-		ID:     documentID,
-		Status: "indexing",
-	})
+	c.JSON(http.StatusOK, doc)
 }
 
-func (h *Handlers) ListConversations(c *gin.Context) {
-	limit := 50
-	offset := 0
-
-	userID := c.GetString("username")
-
-	if limitStr := c.Query("limit"); limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
-			limit = l
+// CancelIndexing cancels a document's indexing workflow.
+func (h *Handlers) CancelIndexing(c *gin.Context) {
+	var req models.CancelIndexingRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: models.ErrorDetail{
+					Code:    "VALIDATION_ERROR",
+					Message: "Invalid request format",
+				},
+			})
+			return
 		}
 	}
 
-	if offsetStr := c.Query("offset"); offsetStr != "" {
-		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
-			offset = o
-		}
+	doc, err := h.Gateway().CancelIndexing(c.Request.Context(), c.Param("id"), req.DeleteVectors)
+	if err != nil {
+		writeError(c, err)
+		return
 	}
 
-	conversations, total, err := h.Repository.ListConversations(c.Request.Context(), userID, limit, offset)
+	c.JSON(http.StatusOK, doc)
+}
+
+// RefreshUploadURL issues a new upload URL for a pending document.
+func (h *Handlers) RefreshUploadURL(c *gin.Context) {
+	doc, err := h.Gateway().RefreshUploadURL(c.Request.Context(), c.Param("id"))
 	if err != nil {
-		h.Logger.Error().Err(err).Msg("Failed to list conversations")
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error: models.ErrorDetail{
-				Code:    "INTERNAL_ERROR",
-				Message: "Failed to list conversations",
-			},
-		})
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, doc)
+}
+
+func (h *Handlers) ListConversations(c *gin.Context) {
+	limit, offset := page(c)
+
+	conversations, total, err := h.Gateway().ListConversations(c.Request.Context(), c.GetString("username"), limit, offset)
+	if err != nil {
+		writeError(c, err)
 		return
 	}
 
@@ -312,22 +640,9 @@ func (h *Handlers) ListConversations(c *gin.Context) {
 }
 
 func (h *Handlers) CreateConversation(c *gin.Context) {
-	now := time.Now()
-
-	conv := &models.Conversation{
-		ID:        generateUUID(),
-		CreatedAt: now,
-		UpdatedAt: now,
-	}
-
-	if err := h.Repository.CreateConversation(c.Request.Context(), conv); err != nil {
-		h.Logger.Error().Err(err).Msg("Failed to create conversation")
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error: models.ErrorDetail{
-				Code:    "INTERNAL_ERROR",
-				Message: "Failed to create conversation",
-			},
-		})
+	conv, err := h.Gateway().CreateConversation(c.Request.Context(), c.GetString("username"))
+	if err != nil {
+		writeError(c, err)
 		return
 	}
 
@@ -335,31 +650,11 @@ func (h *Handlers) CreateConversation(c *gin.Context) {
 }
 
 func (h *Handlers) GetConversationMessages(c *gin.Context) {
-	conversationID := c.Param("id")
-	limit := 50
-	offset := 0
-
-	if limitStr := c.Query("limit"); limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
-			limit = l
-		}
-	}
+	limit, offset := page(c)
 
-	if offsetStr := c.Query("offset"); offsetStr != "" {
-		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
-			offset = o
-		}
-	}
-
-	messages, err := h.Repository.GetMessagesByConversationID(c.Request.Context(), conversationID, limit, offset)
+	messages, err := h.Gateway().GetConversationMessages(c.Request.Context(), c.Param("id"), c.GetString("username"), limit, offset)
 	if err != nil {
-		h.Logger.Error().Err(err).Str("conversation_id", conversationID).Msg("Failed to get messages")
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error: models.ErrorDetail{
-				Code:    "INTERNAL_ERROR",
-				Message: "Failed to get messages",
-			},
-		})
+		writeError(c, err)
 		return
 	}
 
@@ -373,6 +668,51 @@ func (h *Handlers) GetConversationMessages(c *gin.Context) {
 	})
 }
 
+func (h *Handlers) ListConversationSummaries(c *gin.Context) {
+	summaries, err := h.Gateway().ListConversationSummaries(c.Request.Context(), c.Param("id"), c.GetString("username"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	list := make([]models.ConversationSummary, len(summaries))
+	for i, summary := range summaries {
+		list[i] = *summary
+	}
+
+	c.JSON(http.StatusOK, models.ConversationSummaryListResponse{
+		Summaries: list,
+	})
+}
+
+// ExportMessage renders an answer with its citations as a PDF in S3 and
+// returns a presigned download link.
+func (h *Handlers) ExportMessage(c *gin.Context) {
+	resp, err := h.Gateway().ExportMessage(c.Request.Context(), c.Param("id"), c.DefaultQuery("format", models.ExportFormatPDF), c.GetString("username"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// SuggestQueries completes the text typed into the search box, for
+// type-ahead.
+func (h *Handlers) SuggestQueries(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	suggestions, err := h.Gateway().SuggestQueries(c.Request.Context(), c.Query("q"), limit)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.QuerySuggestionListResponse{
+		Suggestions: suggestions,
+	})
+}
+
 func (h *Handlers) Query(c *gin.Context) {
 	var req models.QueryRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -384,34 +724,48 @@ func (h *Handlers) Query(c *gin.Context) {
 		})
 		return
 	}
+	req.Collection = c.GetString("collection")
 
-	if req.TopK == 0 {
-		req.TopK = 5
-	}
-
-	eventChan, err := h.CoreClient.Query(req.Query, req.ConversationID, req.TopK)
+	eventChan, err := h.Gateway().Query(c.Request.Context(), req, c.GetString("username"))
 	if err != nil {
-		h.Logger.Error().Err(err).Str("query", req.Query).Msg("Failed to query")
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error: models.ErrorDetail{
-				Code:    "INTERNAL_ERROR",
-				Message: "Failed to query",
-			},
-		})
+		writeError(c, err)
 		return
 	}
 
+	liftWriteDeadline(c)
 	c.Header("Content-Type", "text/event-stream")
 	c.Header("Cache-Control", "no-cache")
 	c.Header("Connection", "keep-alive")
 	c.Stream(func(w io.Writer) bool {
-		for event := range eventChan {
-			c.SSEvent("message", event)
+		heartbeat := time.NewTicker(h.streamHeartbeat())
+		defer heartbeat.Stop()
+
+		// One encoder and event for the whole stream keep the relay from
+		// allocating per chunk.
+		encoder := services.NewSSEEncoder(w)
+		var event models.SSEEvent
+		for {
+			var err error
+			select {
+			case e, ok := <-eventChan:
+				if !ok {
+					return false
+				}
+				event = e
+				err = encoder.Encode("message", &event)
+			case <-heartbeat.C:
+				err = writeHeartbeat(w)
+			}
+			if err != nil {
+				// The client is gone; the gateway stops the query when the
+				// request context is cancelled.
+				_ = c.Error(err)
+				return false
+			}
 			if flusher, ok := w.(http.Flusher); ok {
 				flusher.Flush()
 			}
 		}
-		return false
 	})
 }
 
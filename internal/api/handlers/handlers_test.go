@@ -1,17 +1,32 @@
 package handlers_test
 
 import (
+	"bufio"
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"kb-platform-gateway/internal/api/handlers"
+	"kb-platform-gateway/internal/api/middleware"
+	"kb-platform-gateway/internal/config"
+	"kb-platform-gateway/internal/gateway"
 	"kb-platform-gateway/internal/models"
+	repomocks "kb-platform-gateway/internal/repository/mocks"
+	"kb-platform-gateway/internal/services"
 	"kb-platform-gateway/internal/services/mocks"
 
 	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -23,7 +38,7 @@ func setupTestRouter() *gin.Engine {
 
 func TestHealthHandler(t *testing.T) {
 	t.Run("Health_Success", func(t *testing.T) {
-		mockCoreClient := mocks.NewMockPythonCoreClient()
+		mockCoreClient := mocks.NewMockCoreService()
 		mockS3Client := mocks.NewMockS3Client()
 		mockTemporalClient := mocks.NewMockTemporalClient()
 		mockQdrantClient := mocks.NewMockQdrantClient()
@@ -54,8 +69,8 @@ func TestHealthHandler(t *testing.T) {
 
 func TestReadyHandler(t *testing.T) {
 	t.Run("Ready_Success", func(t *testing.T) {
-		mockCoreClient := mocks.NewMockPythonCoreClient()
-		mockCoreClient.On("HealthCheck").Return(map[string]string{"python_core": "ok"}, nil)
+		mockCoreClient := mocks.NewMockCoreService()
+		mockCoreClient.On("HealthCheck", mock.Anything).Return(map[string]string{"python_core": "ok"}, nil)
 
 		mockS3Client := mocks.NewMockS3Client()
 		mockTemporalClient := mocks.NewMockTemporalClient()
@@ -86,8 +101,8 @@ func TestReadyHandler(t *testing.T) {
 	})
 
 	t.Run("Ready_PythonCoreUnavailable", func(t *testing.T) {
-		mockCoreClient := mocks.NewMockPythonCoreClient()
-		mockCoreClient.On("HealthCheck").Return(nil, assert.AnError)
+		mockCoreClient := mocks.NewMockCoreService()
+		mockCoreClient.On("HealthCheck", mock.Anything).Return(nil, assert.AnError)
 
 		mockS3Client := mocks.NewMockS3Client()
 		mockTemporalClient := mocks.NewMockTemporalClient()
@@ -120,7 +135,7 @@ func TestReadyHandler(t *testing.T) {
 
 func TestUploadDocumentHandler_NoFile(t *testing.T) {
 	t.Run("UploadDocument_NoFile_Returns400", func(t *testing.T) {
-		mockCoreClient := mocks.NewMockPythonCoreClient()
+		mockCoreClient := mocks.NewMockCoreService()
 		mockS3Client := mocks.NewMockS3Client()
 		mockTemporalClient := mocks.NewMockTemporalClient()
 		mockQdrantClient := mocks.NewMockQdrantClient()
@@ -147,18 +162,22 @@ func TestUploadDocumentHandler_NoFile(t *testing.T) {
 
 func TestCompleteUploadHandler_TemporalError(t *testing.T) {
 	t.Run("CompleteUpload_TemporalError_Returns500", func(t *testing.T) {
-		mockCoreClient := mocks.NewMockPythonCoreClient()
+		mockCoreClient := mocks.NewMockCoreService()
 		mockS3Client := mocks.NewMockS3Client()
 		mockTemporalClient := mocks.NewMockTemporalClient()
-		mockTemporalClient.On("SignalUploadComplete", mock.Anything, "test-doc-1").Return(assert.AnError)
+		mockTemporalClient.On("SignalUploadComplete", mock.Anything, "test-doc-1", mock.Anything, mock.Anything).Return(assert.AnError)
 
 		mockQdrantClient := mocks.NewMockQdrantClient()
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetDocument", mock.Anything, "test-doc-1").Return(&models.Document{ID: "test-doc-1", Status: "pending"}, nil)
+		mockS3Client.On("ObjectExists", mock.Anything, mock.Anything).Return(true, nil)
 
 		h := &handlers.Handlers{
 			CoreClient:   mockCoreClient,
 			S3Client:     mockS3Client,
 			Temporal:     mockTemporalClient,
 			QdrantClient: mockQdrantClient,
+			Repository:   mockRepo,
 		}
 
 		router := setupTestRouter()
@@ -174,32 +193,3454 @@ func TestCompleteUploadHandler_TemporalError(t *testing.T) {
 	})
 }
 
-func TestQueryHandler_ValidationError(t *testing.T) {
-	t.Run("Query_InvalidJSON_Returns400", func(t *testing.T) {
-		mockCoreClient := mocks.NewMockPythonCoreClient()
+func TestBatchUploadHandlers(t *testing.T) {
+	t.Run("BatchUpload_ReportsEachFile", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetWorkspaceSettings", mock.Anything).Return(&models.WorkspaceSettings{AllowedFileTypes: []string{"pdf"}}, nil)
+		mockRepo.On("CreateDocument", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("CreateDocumentEvent", mock.Anything, mock.Anything).Return(nil)
+		mockS3Client := mocks.NewMockS3Client()
+		mockS3Client.On("GeneratePresignedUploadURL", mock.Anything, mock.Anything, "application/pdf", mock.Anything).Return("https://s3/upload", nil)
+		mockTemporalClient := mocks.NewMockTemporalClient()
+		mockTemporalClient.On("StartUploadWorkflow", mock.Anything, mock.Anything).Return("upload-1", nil)
+
+		h := &handlers.Handlers{Repository: mockRepo, S3Client: mockS3Client, Temporal: mockTemporalClient}
+		router := setupTestRouter()
+		router.POST("/documents/batch", h.BatchUpload)
+
+		req, _ := http.NewRequest("POST", "/documents/batch", bytes.NewBufferString(`{"files":[{"filename":"a.pdf","content_type":"application/pdf"},{"filename":"b.exe"}]}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		var body models.BatchUploadResponse
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+		if assert.Len(t, body.Results, 2) {
+			assert.Equal(t, "a.pdf", body.Results[0].Filename)
+			assert.Equal(t, "https://s3/upload", body.Results[0].Document.UploadURL)
+			assert.Nil(t, body.Results[0].Error)
+			assert.Equal(t, "b.exe", body.Results[1].Filename)
+			assert.Nil(t, body.Results[1].Document)
+			assert.Equal(t, "VALIDATION_ERROR", body.Results[1].Error.Code)
+		}
+	})
+
+	t.Run("BatchUpload_NoFiles_Returns400", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		h := &handlers.Handlers{Repository: mockRepo}
+		router := setupTestRouter()
+		router.POST("/documents/batch", h.BatchUpload)
+
+		req, _ := http.NewRequest("POST", "/documents/batch", bytes.NewBufferString(`{"files":[]}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		mockRepo.AssertNotCalled(t, "CreateDocument", mock.Anything, mock.Anything)
+	})
+
+	t.Run("BatchCompleteUpload_ReportsEachDocument", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetDocument", mock.Anything, "test-doc-1").Return(&models.Document{ID: "test-doc-1", S3Key: "documents/test-doc-1/a.pdf", Status: "pending"}, nil)
+		mockRepo.On("GetDocument", mock.Anything, "test-doc-2").Return(&models.Document{ID: "test-doc-2", S3Key: "documents/test-doc-2/b.pdf", Status: "complete"}, nil)
+		mockRepo.On("SetDocumentIndexing", mock.Anything, "test-doc-1", "upload-test-doc-1").Return(nil)
 		mockS3Client := mocks.NewMockS3Client()
+		mockS3Client.On("ObjectExists", mock.Anything, "documents/test-doc-1/a.pdf").Return(true, nil)
 		mockTemporalClient := mocks.NewMockTemporalClient()
+		mockTemporalClient.On("SignalUploadComplete", mock.Anything, "test-doc-1", "documents/test-doc-1/a.pdf", (*models.ProcessingOptions)(nil)).Return(nil)
+
+		h := &handlers.Handlers{Repository: mockRepo, S3Client: mockS3Client, Temporal: mockTemporalClient}
+		router := setupTestRouter()
+		router.POST("/documents/batch/complete", h.BatchCompleteUpload)
+
+		req, _ := http.NewRequest("POST", "/documents/batch/complete", bytes.NewBufferString(`{"document_ids":["test-doc-1","test-doc-2"]}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		var body models.BatchCompleteResponse
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+		if assert.Len(t, body.Results, 2) {
+			assert.Equal(t, "indexing", body.Results[0].Document.Status)
+			assert.Equal(t, "test-doc-2", body.Results[1].DocumentID)
+			assert.Equal(t, "CONFLICT", body.Results[1].Error.Code)
+		}
+		mockTemporalClient.AssertExpectations(t)
+	})
+
+	t.Run("BatchDeleteDocuments_ReportsEachDocument", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetDocumentsByIDs", mock.Anything, []string{"test-doc-1", "test-doc-2"}).
+			Return([]*models.Document{{ID: "test-doc-1", Filename: "a.pdf", S3Key: "documents/test-doc-1/a.pdf", Status: "failed"}}, nil)
+		mockRepo.On("TrashDocuments", mock.Anything, []string{"test-doc-1"}, mock.AnythingOfType("time.Time")).Return([]string{"test-doc-1"}, nil)
+		mockRepo.On("DeleteDocument", mock.Anything, "test-doc-1").Return(nil)
+		mockRepo.On("CreateDocumentEvent", mock.Anything, mock.Anything).Return(nil)
+		mockS3Client := mocks.NewMockS3Client()
+		mockS3Client.On("DeleteObject", mock.Anything, "documents/test-doc-1/a.pdf").Return(nil)
 		mockQdrantClient := mocks.NewMockQdrantClient()
+		mockQdrantClient.On("Collection").Return("documents")
+		mockQdrantClient.On("DeleteDocumentVectors", mock.Anything, "documents", "test-doc-1").Return(nil)
 
-		h := &handlers.Handlers{
-			CoreClient:   mockCoreClient,
-			S3Client:     mockS3Client,
-			Temporal:     mockTemporalClient,
-			QdrantClient: mockQdrantClient,
+		h := &handlers.Handlers{Repository: mockRepo, S3Client: mockS3Client, QdrantClient: mockQdrantClient}
+		router := setupTestRouter()
+		router.DELETE("/documents", h.BatchDeleteDocuments)
+
+		req, _ := http.NewRequest("DELETE", "/documents", bytes.NewBufferString(`{"document_ids":["test-doc-1","test-doc-2"]}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		var body models.BatchDeleteResponse
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+		assert.Equal(t, 1, body.Deleted)
+		assert.Equal(t, 1, body.Failed)
+		if assert.Len(t, body.Results, 2) {
+			assert.Equal(t, "a.pdf", body.Results[0].Filename)
+			assert.Nil(t, body.Results[0].Error)
+			assert.Equal(t, "test-doc-2", body.Results[1].DocumentID)
+			assert.Equal(t, "NOT_FOUND", body.Results[1].Error.Code)
 		}
+		mockS3Client.AssertExpectations(t)
+	})
+}
+
+func TestMultipartUploadHandlers(t *testing.T) {
+	t.Run("CreateMultipartUpload_HidesUploadID", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetDocument", mock.Anything, "test-doc-1").Return(&models.Document{ID: "test-doc-1", S3Key: "documents/test-doc-1/big.pdf", Status: "pending"}, nil)
+		mockRepo.On("GetMultipartUpload", mock.Anything, "test-doc-1").Return(nil, nil)
+		mockRepo.On("CreateMultipartUpload", mock.Anything, mock.Anything).Return(nil)
+		mockS3Client := mocks.NewMockS3Client()
+		mockS3Client.On("CreateMultipartUpload", mock.Anything, "documents/test-doc-1/big.pdf").Return("s3-upload-id", nil)
 
+		h := &handlers.Handlers{Repository: mockRepo, S3Client: mockS3Client}
 		router := setupTestRouter()
-		router.POST("/query", h.Query)
+		router.POST("/documents/:id/multipart", h.CreateMultipartUpload)
 
-		// Invalid JSON
-		body := []byte(`{"invalid": "data"}`)
-		req, _ := http.NewRequest("POST", "/query", bytes.NewReader(body))
+		req, _ := http.NewRequest("POST", "/documents/test-doc-1/multipart", bytes.NewBufferString(`{"file_size":209715200}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusCreated, resp.Code)
+		assert.NotContains(t, resp.Body.String(), "s3-upload-id")
+		var body models.MultipartUpload
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+		assert.Equal(t, 4, body.PartCount)
+	})
+
+	t.Run("RecordMultipartPart_BadPartNumber_Returns400", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		h := &handlers.Handlers{Repository: mockRepo}
+		router := setupTestRouter()
+		router.PUT("/documents/:id/multipart/parts/:part", h.RecordMultipartPart)
+
+		req, _ := http.NewRequest("PUT", "/documents/test-doc-1/multipart/parts/first", bytes.NewBufferString(`{"etag":"a"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		mockRepo.AssertNotCalled(t, "RecordMultipartUploadPart", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("AbortMultipartUpload_NotFound", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetDocument", mock.Anything, "test-doc-1").Return(&models.Document{ID: "test-doc-1", Status: "pending"}, nil)
+		mockRepo.On("GetMultipartUpload", mock.Anything, "test-doc-1").Return(nil, nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+		router := setupTestRouter()
+		router.DELETE("/documents/:id/multipart", h.AbortMultipartUpload)
+
+		req, _ := http.NewRequest("DELETE", "/documents/test-doc-1/multipart", nil)
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+}
+
+func TestUploadContentHandler(t *testing.T) {
+	t.Run("TooLarge_Returns413", func(t *testing.T) {
+		mockS3Client := mocks.NewMockS3Client()
+		h := &handlers.Handlers{S3Client: mockS3Client, ProxyUploads: &gateway.ProxyUploadLimits{MaxSize: 4}}
+		router := setupTestRouter()
+		router.POST("/documents/:id/content", h.UploadContent)
+
+		req, _ := http.NewRequest("POST", "/documents/test-doc-1/content", bytes.NewBufferString("too long"))
+		req.Header.Set("Content-Type", "text/plain")
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, resp.Code)
+		assert.Contains(t, resp.Body.String(), "PAYLOAD_TOO_LARGE")
+		mockS3Client.AssertNotCalled(t, "StreamObject", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Disabled_Returns503", func(t *testing.T) {
+		h := &handlers.Handlers{}
+		router := setupTestRouter()
+		router.POST("/documents/:id/content", h.UploadContent)
+
+		req, _ := http.NewRequest("POST", "/documents/test-doc-1/content", bytes.NewBufferString("data"))
+		req.Header.Set("Content-Type", "text/plain")
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+	})
+}
+
+func TestTagHandlers(t *testing.T) {
+	t.Run("TagDocuments", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("AddDocumentTags", mock.Anything, []string{"test-doc-1"}, []string{"finance"}).Return([]string{"test-doc-1"}, nil)
+		mockRepo.On("GetDocument", mock.Anything, "test-doc-1").Return(&models.Document{ID: "test-doc-1", Tags: []string{"finance"}}, nil).Maybe()
+		mockQdrantClient := mocks.NewMockQdrantClient()
+		mockQdrantClient.On("SetDocumentTags", mock.Anything, "test-doc-1", []string{"finance"}).Return(nil).Maybe()
+
+		h := &handlers.Handlers{Repository: mockRepo, QdrantClient: mockQdrantClient}
+		h.Tags = gateway.NewTagUpdater(&gateway.Service{Repository: mockRepo, QdrantClient: mockQdrantClient, Logger: zerolog.Nop()})
+		defer h.Tags.Close()
+		router := setupTestRouter()
+		router.POST("/tags/apply", h.TagDocuments)
+
+		req, _ := http.NewRequest("POST", "/tags/apply", bytes.NewBufferString(`{"document_ids":["test-doc-1"],"tags":["Finance"]}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.JSONEq(t, `{"updated":1}`, resp.Body.String())
+	})
+
+	t.Run("RenameTag_Unavailable", func(t *testing.T) {
+		h := &handlers.Handlers{}
+		router := setupTestRouter()
+		router.POST("/tags/rename", h.RenameTag)
+
+		req, _ := http.NewRequest("POST", "/tags/rename", bytes.NewBufferString(`{"from":"fin","to":"finance"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+	})
+
+	t.Run("ListTags", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("ListTags", mock.Anything).Return([]models.TagCount{{Tag: "finance", Documents: 3}}, nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+		router := setupTestRouter()
+		router.GET("/tags", h.ListTags)
+
+		req, _ := http.NewRequest("GET", "/tags", nil)
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.JSONEq(t, `{"tags":[{"tag":"finance","documents":3}]}`, resp.Body.String())
+	})
+}
+
+func TestCancelIndexingHandler(t *testing.T) {
+	t.Run("CancelIndexing_DeletesVectors", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetDocument", mock.Anything, "test-doc-1").Return(&models.Document{ID: "test-doc-1", Filename: "a.pdf", Status: "indexing", WorkflowID: "index-test-doc-1"}, nil)
+		mockRepo.On("UpdateDocumentStatus", mock.Anything, "test-doc-1", "cancelled", "").Return(nil)
+		mockRepo.On("CreateDocumentEvent", mock.Anything, mock.Anything).Return(nil)
+		mockTemporalClient := mocks.NewMockTemporalClient()
+		mockTemporalClient.On("CancelWorkflow", mock.Anything, "index-test-doc-1").Return(nil)
+		mockQdrantClient := mocks.NewMockQdrantClient()
+		mockQdrantClient.On("Collection").Return("documents")
+		mockQdrantClient.On("DeleteDocumentVectors", mock.Anything, "documents", "test-doc-1").Return(nil)
+
+		h := &handlers.Handlers{Repository: mockRepo, Temporal: mockTemporalClient, QdrantClient: mockQdrantClient}
+		router := setupTestRouter()
+		router.POST("/documents/:id/cancel", h.CancelIndexing)
+
+		req, _ := http.NewRequest("POST", "/documents/test-doc-1/cancel", bytes.NewBufferString(`{"delete_vectors":true}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"status":"cancelled"`)
+		mockTemporalClient.AssertExpectations(t)
+		mockQdrantClient.AssertExpectations(t)
+	})
+
+	t.Run("CancelIndexing_NotIndexing_Returns409", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetDocument", mock.Anything, "test-doc-1").Return(&models.Document{ID: "test-doc-1", Filename: "a.pdf", Status: "complete"}, nil)
+
+		h := &handlers.Handlers{Repository: mockRepo, Temporal: mocks.NewMockTemporalClient()}
+		router := setupTestRouter()
+		router.POST("/documents/:id/cancel", h.CancelIndexing)
+
+		req, _ := http.NewRequest("POST", "/documents/test-doc-1/cancel", nil)
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusConflict, resp.Code)
+	})
+}
+
+func TestReviewHandlers(t *testing.T) {
+	setUser := func(c *gin.Context) { c.Set("username", "rita") }
+
+	t.Run("RejectDocument_Success", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetDocument", mock.Anything, "test-doc-1").Return(&models.Document{ID: "test-doc-1", Filename: "a.pdf", Status: "pending_review", UploadedBy: "alice"}, nil)
+		mockRepo.On("UpdateDocumentStatus", mock.Anything, "test-doc-1", "rejected", "Outdated policy").Return(nil)
+		mockRepo.On("CreateDocumentEvent", mock.Anything, mock.Anything).Return(nil)
+		mockTemporalClient := mocks.NewMockTemporalClient()
+		mockTemporalClient.On("CancelWorkflow", mock.Anything, "upload-test-doc-1").Return(nil)
+
+		h := &handlers.Handlers{Repository: mockRepo, Temporal: mockTemporalClient, Reviewers: []string{"rita"}}
+		router := setupTestRouter()
+		router.POST("/documents/:id/reject", setUser, h.RejectDocument)
+
+		req, _ := http.NewRequest("POST", "/documents/test-doc-1/reject", bytes.NewBufferString(`{"comment":"Outdated policy"}`))
 		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"status":"rejected"`)
+		assert.Contains(t, resp.Body.String(), `"error_message":"Outdated policy"`)
+		mockTemporalClient.AssertExpectations(t)
+	})
+
+	t.Run("ApproveDocument_NotReviewer_Returns403", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockTemporalClient := mocks.NewMockTemporalClient()
+
+		h := &handlers.Handlers{Repository: mockRepo, Temporal: mockTemporalClient, Reviewers: []string{"sam"}}
+		router := setupTestRouter()
+		router.POST("/documents/:id/approve", setUser, h.ApproveDocument)
+
+		req, _ := http.NewRequest("POST", "/documents/test-doc-1/approve", nil)
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+		assert.Contains(t, resp.Body.String(), "AUTHORIZATION_ERROR")
+		mockTemporalClient.AssertNotCalled(t, "SignalUploadComplete", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("ApproveDocument_Impersonated_Returns403", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+
+		h := &handlers.Handlers{Repository: mockRepo, Temporal: mocks.NewMockTemporalClient(), Reviewers: []string{"rita"}}
+		router := setupTestRouter()
+		router.POST("/documents/:id/approve", func(c *gin.Context) {
+			c.Set("username", "rita")
+			c.Set("impersonator", "admin")
+		}, h.ApproveDocument)
+
+		req, _ := http.NewRequest("POST", "/documents/test-doc-1/approve", nil)
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+		mockRepo.AssertNotCalled(t, "GetDocument", mock.Anything, mock.Anything)
+	})
+
+	t.Run("ApproveDocument_Disabled_Returns503", func(t *testing.T) {
+		h := &handlers.Handlers{Repository: repomocks.NewMockRepository(), Temporal: mocks.NewMockTemporalClient()}
+		router := setupTestRouter()
+		router.POST("/documents/:id/approve", setUser, h.ApproveDocument)
+
+		req, _ := http.NewRequest("POST", "/documents/test-doc-1/approve", nil)
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+	})
+}
+
+func TestCompleteUploadHandler_Expired(t *testing.T) {
+	t.Run("CompleteUpload_Expired_Returns410", func(t *testing.T) {
+		expiresAt := time.Now().Add(-time.Hour)
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetDocument", mock.Anything, "test-doc-1").Return(&models.Document{ID: "test-doc-1", Status: "pending", UploadURLExpiresAt: &expiresAt}, nil)
+		mockTemporalClient := mocks.NewMockTemporalClient()
+
+		h := &handlers.Handlers{
+			Temporal:   mockTemporalClient,
+			Repository: mockRepo,
+		}
 
+		router := setupTestRouter()
+		router.POST("/documents/:id/complete", h.CompleteUpload)
+
+		req, _ := http.NewRequest("POST", "/documents/test-doc-1/complete", nil)
 		resp := httptest.NewRecorder()
 
 		router.ServeHTTP(resp, req)
 
+		assert.Equal(t, http.StatusGone, resp.Code)
+		assert.Contains(t, resp.Body.String(), "UPLOAD_URL_EXPIRED")
+		mockTemporalClient.AssertNotCalled(t, "SignalUploadComplete", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestUpdateDocumentHandler(t *testing.T) {
+	metadata := map[string]string{"team": "finance"}
+	newRouter := func(repo *repomocks.MockRepository) *gin.Engine {
+		h := &handlers.Handlers{Repository: repo}
+		router := setupTestRouter()
+		router.PATCH("/documents/:id", h.UpdateDocument)
+		return router
+	}
+
+	t.Run("UpdateDocument_IfMatch_Returns200", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetWorkspaceSettings", mock.Anything).Return(nil, nil)
+		mockRepo.On("UpdateDocumentDetails", mock.Anything, "test-doc-1", metadata, "", 2).Return(true, nil)
+		mockRepo.On("GetDocument", mock.Anything, "test-doc-1").Return(&models.Document{ID: "test-doc-1", Filename: "a.pdf", Metadata: metadata, Version: 3}, nil)
+		mockRepo.On("CreateDocumentEvent", mock.Anything, mock.Anything).Return(nil)
+
+		req, _ := http.NewRequest("PATCH", "/documents/test-doc-1", strings.NewReader(`{"metadata":{"team":"finance"}}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", `W/"2"`)
+		resp := httptest.NewRecorder()
+
+		newRouter(mockRepo).ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, `"3"`, resp.Header().Get("ETag"))
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("UpdateDocument_StaleVersion_Returns409", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetWorkspaceSettings", mock.Anything).Return(nil, nil)
+		mockRepo.On("UpdateDocumentDetails", mock.Anything, "test-doc-1", metadata, "", 2).Return(false, nil)
+		mockRepo.On("GetDocument", mock.Anything, "test-doc-1").Return(&models.Document{ID: "test-doc-1", Filename: "a.pdf", Version: 3}, nil)
+
+		req, _ := http.NewRequest("PATCH", "/documents/test-doc-1", strings.NewReader(`{"metadata":{"team":"finance"},"version":2}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		newRouter(mockRepo).ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusConflict, resp.Code)
+		assert.Contains(t, resp.Body.String(), "CONFLICT")
+	})
+
+	t.Run("UpdateDocument_MetadataSchema_Returns400", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetWorkspaceSettings", mock.Anything).Return(&models.WorkspaceSettings{MetadataSchema: []models.MetadataField{
+			{Key: "team", Type: models.MetadataTypeString, Enum: []string{"hr", "search"}},
+		}}, nil)
+
+		req, _ := http.NewRequest("PATCH", "/documents/test-doc-1", strings.NewReader(`{"metadata":{"team":"finance"},"version":2}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		newRouter(mockRepo).ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"metadata_key":"team"`)
+		mockRepo.AssertNotCalled(t, "UpdateDocumentDetails", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("UpdateDocument_NoVersion_Returns428", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+
+		req, _ := http.NewRequest("PATCH", "/documents/test-doc-1", strings.NewReader(`{"metadata":{"team":"finance"}}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		newRouter(mockRepo).ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusPreconditionRequired, resp.Code)
+		mockRepo.AssertNotCalled(t, "UpdateDocumentDetails", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("UpdateDocument_VersionMismatch_Returns400", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+
+		req, _ := http.NewRequest("PATCH", "/documents/test-doc-1", strings.NewReader(`{"metadata":{},"version":1}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", `"2"`)
+		resp := httptest.NewRecorder()
+
+		newRouter(mockRepo).ServeHTTP(resp, req)
+
 		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		mockRepo.AssertNotCalled(t, "UpdateDocumentDetails", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestTrashHandlers(t *testing.T) {
+	t.Run("RestoreDocument_Returns200", func(t *testing.T) {
+		deletedAt := time.Now().Add(-time.Hour)
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetDocument", mock.Anything, "test-doc-1").Return(&models.Document{ID: "test-doc-1", Filename: "a.pdf", Status: "pending", DeletedAt: &deletedAt}, nil)
+		mockRepo.On("RestoreDocument", mock.Anything, "test-doc-1").Return(true, nil)
+		mockRepo.On("CreateDocumentEvent", mock.Anything, mock.Anything).Return(nil)
+
+		h := &handlers.Handlers{Repository: mockRepo, TrashRetention: 30 * 24 * time.Hour}
+		router := setupTestRouter()
+		router.POST("/documents/:id/restore", h.RestoreDocument)
+
+		req, _ := http.NewRequest("POST", "/documents/test-doc-1/restore", nil)
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.NotContains(t, resp.Body.String(), "deleted_at")
+	})
+
+	t.Run("PurgeTrash_WithoutTrash_FinishesDeletions", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("ListExpiredTrash", mock.Anything, mock.Anything, 100, 0).Return([]*models.Document{
+			{ID: "test-doc-1", FileSize: 1000},
+		}, nil)
+		mockRepo.On("DeleteDocument", mock.Anything, "test-doc-1").Return(nil)
+		mockRepo.On("CreateDocumentEvent", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("CreateTrashPurge", mock.Anything, mock.Anything).Return(nil)
+		mockQdrantClient := mocks.NewMockQdrantClient()
+		mockQdrantClient.On("Collection").Return("documents")
+		mockQdrantClient.On("DeleteDocumentVectors", mock.Anything, "documents", "test-doc-1").Return(nil)
+
+		h := &handlers.Handlers{Repository: mockRepo, QdrantClient: mockQdrantClient}
+		router := setupTestRouter()
+		router.POST("/trash/purge", h.PurgeTrash)
+
+		req, _ := http.NewRequest("POST", "/trash/purge", nil)
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"purged":1`)
+		mockRepo.AssertNotCalled(t, "GetWorkspaceSettings", mock.Anything)
+	})
+
+	t.Run("PurgeTrash_Returns200", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetWorkspaceSettings", mock.Anything).Return(nil, nil)
+		mockRepo.On("ListExpiredTrash", mock.Anything, mock.Anything, 100, 0).Return([]*models.Document{}, nil)
+		mockRepo.On("CreateTrashPurge", mock.Anything, mock.Anything).Return(nil)
+
+		h := &handlers.Handlers{Repository: mockRepo, TrashRetention: 30 * 24 * time.Hour}
+		router := setupTestRouter()
+		router.POST("/trash/purge", h.PurgeTrash)
+
+		req, _ := http.NewRequest("POST", "/trash/purge", nil)
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"purged":0`)
+	})
+}
+
+// uploadPDF posts a small PDF with the given form fields to the upload
+// handler.
+func uploadPDF(h *handlers.Handlers, fields map[string]string) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile("file", "rates.pdf")
+	_, _ = part.Write([]byte("%PDF"))
+	for name, value := range fields {
+		_ = form.WriteField(name, value)
+	}
+	_ = form.Close()
+
+	router := setupTestRouter()
+	router.POST("/documents", h.UploadDocument)
+	req, _ := http.NewRequest("POST", "/documents", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	return resp
+}
+
+func TestChunkingHandlers(t *testing.T) {
+	t.Run("UploadDocument_Chunking", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetWorkspaceSettings", mock.Anything).Return(nil, nil)
+		mockRepo.On("FindDocumentByContentHash", mock.Anything, mock.Anything).Return(nil, nil)
+		mockRepo.On("CreateDocument", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("CreateDocumentEvent", mock.Anything, mock.Anything).Return(nil)
+		mockS3Client := mocks.NewMockS3Client()
+		mockS3Client.On("GeneratePresignedUploadURL", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return("https://s3/upload", nil)
+		mockTemporalClient := mocks.NewMockTemporalClient()
+		mockTemporalClient.On("StartUploadWorkflow", mock.Anything, mock.MatchedBy(func(input services.UploadWorkflowInput) bool {
+			return input.Chunking != nil && *input.Chunking == models.ChunkingOptions{Strategy: "table", Size: 1024, Overlap: 100}
+		})).Return("upload-1", nil)
+		h := &handlers.Handlers{Repository: mockRepo, S3Client: mockS3Client, Temporal: mockTemporalClient}
+
+		resp := uploadPDF(h, map[string]string{"chunk_strategy": "table", "chunk_size": "1024", "chunk_overlap": "100"})
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"chunking":{"strategy":"table","size":1024,"overlap":100}`)
+		mockTemporalClient.AssertExpectations(t)
+	})
+
+	t.Run("UploadDocument_InvalidChunking_Returns400", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := uploadPDF(h, map[string]string{"chunk_size": "large"})
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+		resp = uploadPDF(h, map[string]string{"chunk_size": "10"})
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		mockRepo.AssertNotCalled(t, "CreateDocument", mock.Anything, mock.Anything)
+	})
+
+	t.Run("ReindexDocument_Returns202", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetDocument", mock.Anything, "test-doc-1").Return(&models.Document{ID: "test-doc-1", Filename: "a.pdf", Status: "complete"}, nil)
+		mockRepo.On("SetDocumentChunking", mock.Anything, "test-doc-1", &models.ChunkingOptions{Strategy: "sentence"}).Return(nil)
+		mockRepo.On("SetDocumentIndexing", mock.Anything, "test-doc-1", "index-1").Return(nil)
+		mockTemporalClient := mocks.NewMockTemporalClient()
+		mockTemporalClient.On("StartIndexWorkflow", mock.Anything, mock.Anything).Return("index-1", nil)
+
+		h := &handlers.Handlers{Repository: mockRepo, Temporal: mockTemporalClient}
+		router := setupTestRouter()
+		router.POST("/documents/:id/reindex", h.ReindexDocument)
+
+		req, _ := http.NewRequest("POST", "/documents/test-doc-1/reindex", bytes.NewBufferString(`{"chunking":{"strategy":"sentence"}}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusAccepted, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"status":"indexing"`)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("ReindexDocument_NoBody_Returns202", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetDocument", mock.Anything, "test-doc-1").Return(&models.Document{ID: "test-doc-1", Filename: "a.pdf", Status: "failed"}, nil)
+		mockRepo.On("SetDocumentIndexing", mock.Anything, "test-doc-1", "index-1").Return(nil)
+		mockTemporalClient := mocks.NewMockTemporalClient()
+		mockTemporalClient.On("StartIndexWorkflow", mock.Anything, services.IndexWorkflowInput{DocumentID: "test-doc-1"}).Return("index-1", nil)
+
+		h := &handlers.Handlers{Repository: mockRepo, Temporal: mockTemporalClient}
+		router := setupTestRouter()
+		router.POST("/documents/:id/reindex", h.ReindexDocument)
+
+		req, _ := http.NewRequest("POST", "/documents/test-doc-1/reindex", nil)
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusAccepted, resp.Code)
+		mockTemporalClient.AssertExpectations(t)
+	})
+}
+
+func TestDuplicateUploadHandlers(t *testing.T) {
+	sum := sha256.Sum256([]byte("%PDF"))
+	contentHash := hex.EncodeToString(sum[:])
+
+	t.Run("UploadDocument_StoresContentHash", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetWorkspaceSettings", mock.Anything).Return(nil, nil)
+		mockRepo.On("FindDocumentByContentHash", mock.Anything, contentHash).Return(nil, nil)
+		mockRepo.On("CreateDocument", mock.Anything, mock.MatchedBy(func(doc *models.Document) bool {
+			return doc.ContentHash == contentHash
+		})).Return(nil)
+		mockRepo.On("CreateDocumentEvent", mock.Anything, mock.Anything).Return(nil)
+		mockS3Client := mocks.NewMockS3Client()
+		mockS3Client.On("GeneratePresignedUploadURL", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return("https://s3/upload", nil)
+		mockTemporalClient := mocks.NewMockTemporalClient()
+		mockTemporalClient.On("StartUploadWorkflow", mock.Anything, mock.Anything).Return("upload-1", nil)
+		h := &handlers.Handlers{Repository: mockRepo, S3Client: mockS3Client, Temporal: mockTemporalClient}
+
+		resp := uploadPDF(h, nil)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"content_hash":"`+contentHash+`"`)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("UploadDocument_Duplicate_Returns409", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetWorkspaceSettings", mock.Anything).Return(nil, nil)
+		mockRepo.On("FindDocumentByContentHash", mock.Anything, contentHash).Return(&models.Document{ID: "existing-doc", Status: "complete"}, nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := uploadPDF(h, nil)
+
+		assert.Equal(t, http.StatusConflict, resp.Code)
+		assert.Contains(t, resp.Body.String(), "DUPLICATE_DOCUMENT")
+		assert.Contains(t, resp.Body.String(), `"document_id":"existing-doc"`)
+		mockRepo.AssertNotCalled(t, "CreateDocument", mock.Anything, mock.Anything)
+	})
+}
+
+func TestUploadPolicyHandlers(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy *gateway.UploadPolicy
+		detail string
+	}{
+		{"TooLarge", &gateway.UploadPolicy{MaxSize: 2}, `"rule":"max_size"`},
+		{"Extension", &gateway.UploadPolicy{Extensions: []string{"docx"}}, `"extension":"pdf"`},
+		// Form files without a type of their own are application/octet-stream.
+		{"ContentType", &gateway.UploadPolicy{ContentTypes: []string{"application/pdf"}}, `"content_type":"application/octet-stream"`},
+	}
+	for _, tt := range tests {
+		t.Run("UploadDocument_"+tt.name+"_Returns400", func(t *testing.T) {
+			mockRepo := repomocks.NewMockRepository()
+			h := &handlers.Handlers{Repository: mockRepo, UploadPolicy: tt.policy}
+
+			resp := uploadPDF(h, nil)
+
+			assert.Equal(t, http.StatusBadRequest, resp.Code)
+			assert.Contains(t, resp.Body.String(), "VALIDATION_ERROR")
+			assert.Contains(t, resp.Body.String(), `"field":"file"`)
+			assert.Contains(t, resp.Body.String(), tt.detail)
+			mockRepo.AssertNotCalled(t, "CreateDocument", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestProcessingHandlers(t *testing.T) {
+	t.Run("UploadDocument_Processing", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetWorkspaceSettings", mock.Anything).Return(nil, nil)
+		mockRepo.On("FindDocumentByContentHash", mock.Anything, mock.Anything).Return(nil, nil)
+		mockRepo.On("CreateDocument", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("CreateDocumentEvent", mock.Anything, mock.Anything).Return(nil)
+		mockS3Client := mocks.NewMockS3Client()
+		mockS3Client.On("GeneratePresignedUploadURL", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return("https://s3/upload", nil)
+		mockTemporalClient := mocks.NewMockTemporalClient()
+		mockTemporalClient.On("StartUploadWorkflow", mock.Anything, mock.MatchedBy(func(input services.UploadWorkflowInput) bool {
+			p := input.Processing
+			return p != nil && p.OCR != nil && !*p.OCR && p.ExtractTables != nil && *p.ExtractTables &&
+				len(p.Languages) == 2 && p.Languages[0] == "de" && p.Languages[1] == "en"
+		})).Return("upload-1", nil)
+		h := &handlers.Handlers{Repository: mockRepo, S3Client: mockS3Client, Temporal: mockTemporalClient}
+
+		resp := uploadPDF(h, map[string]string{"ocr": "false", "extract_tables": "true", "languages": "de, en"})
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"processing":{"ocr":false,"languages":["de","en"],"extract_tables":true}`)
+		mockTemporalClient.AssertExpectations(t)
+	})
+
+	t.Run("UploadDocument_InvalidFlag_Returns400", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := uploadPDF(h, map[string]string{"ocr": "maybe"})
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		assert.Contains(t, resp.Body.String(), "ocr must be true or false")
+		mockRepo.AssertNotCalled(t, "CreateDocument", mock.Anything, mock.Anything)
+	})
+
+	t.Run("CompleteUpload_Processing", func(t *testing.T) {
+		ocr := true
+		processing := &models.ProcessingOptions{OCR: &ocr, Languages: []string{"ja"}}
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetDocument", mock.Anything, "test-doc-1").Return(&models.Document{ID: "test-doc-1", Status: "pending"}, nil)
+		mockRepo.On("SetDocumentProcessing", mock.Anything, "test-doc-1", processing).Return(nil)
+		mockRepo.On("SetDocumentIndexing", mock.Anything, "test-doc-1", "upload-test-doc-1").Return(nil)
+		mockS3Client := mocks.NewMockS3Client()
+		mockS3Client.On("ObjectExists", mock.Anything, mock.Anything).Return(true, nil)
+		mockTemporalClient := mocks.NewMockTemporalClient()
+		mockTemporalClient.On("SignalUploadComplete", mock.Anything, "test-doc-1", mock.Anything, processing).Return(nil)
+
+		h := &handlers.Handlers{Repository: mockRepo, S3Client: mockS3Client, Temporal: mockTemporalClient}
+		router := setupTestRouter()
+		router.POST("/documents/:id/complete", h.CompleteUpload)
+
+		req, _ := http.NewRequest("POST", "/documents/test-doc-1/complete", bytes.NewBufferString(`{"processing":{"ocr":true,"languages":["JA"]}}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		mockRepo.AssertExpectations(t)
+		mockTemporalClient.AssertExpectations(t)
+	})
+}
+
+func TestSavedSearchHandlers(t *testing.T) {
+	newRouter := func(repo *repomocks.MockRepository, username string) *gin.Engine {
+		h := &handlers.Handlers{Repository: repo}
+		router := setupTestRouter()
+		setUser := func(c *gin.Context) { c.Set("username", username) }
+		router.POST("/saved-searches", setUser, h.CreateSavedSearch)
+		router.PUT("/saved-searches/:id", setUser, h.UpdateSavedSearch)
+		router.GET("/saved-searches/:id/documents", setUser, h.RunSavedSearch)
+		return router
+	}
+
+	t.Run("CreateSavedSearch_Returns201", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("CreateSavedSearch", mock.Anything, mock.Anything).Return(nil)
+
+		req, _ := http.NewRequest("POST", "/saved-searches", strings.NewReader(`{"name":"Finance","filter":{"metadata":{"team":"finance"}}}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		newRouter(mockRepo, "alice").ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusCreated, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"created_by":"alice"`)
+	})
+
+	t.Run("CreateSavedSearch_MissingName_Returns400", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+
+		req, _ := http.NewRequest("POST", "/saved-searches", strings.NewReader(`{"filter":{}}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		newRouter(mockRepo, "alice").ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		mockRepo.AssertNotCalled(t, "CreateSavedSearch", mock.Anything, mock.Anything)
+	})
+
+	t.Run("UpdateSavedSearch_NotCreator_Returns403", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetSavedSearch", mock.Anything, "search-1").Return(&models.SavedSearch{ID: "search-1", CreatedBy: "alice"}, nil)
+
+		req, _ := http.NewRequest("PUT", "/saved-searches/search-1", strings.NewReader(`{"name":"Mine now"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		newRouter(mockRepo, "bob").ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+		assert.Contains(t, resp.Body.String(), "AUTHORIZATION_ERROR")
+	})
+
+	t.Run("RunSavedSearch_Returns200", func(t *testing.T) {
+		filter := models.DocumentFilter{Query: "report"}
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetSavedSearch", mock.Anything, "search-1").Return(&models.SavedSearch{ID: "search-1", Filter: filter, CreatedBy: "alice"}, nil)
+		mockRepo.On("ListDocuments", mock.Anything, 50, 0, filter).Return([]*models.Document{{ID: "doc-1", Filename: "report.pdf"}}, 1, nil)
+
+		req, _ := http.NewRequest("GET", "/saved-searches/search-1/documents", nil)
+		resp := httptest.NewRecorder()
+
+		newRouter(mockRepo, "bob").ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"total":1`)
+	})
+}
+
+func TestCollectionHandlers(t *testing.T) {
+	newRouter := func(repo *repomocks.MockRepository, username string) *gin.Engine {
+		h := &handlers.Handlers{Repository: repo}
+		router := setupTestRouter()
+		setUser := func(c *gin.Context) { c.Set("username", username) }
+		router.POST("/collections", setUser, h.CreateCollection)
+		router.POST("/collections/:id/documents", setUser, h.AddCollectionDocuments)
+		router.POST("/collections/:id/documents/remove", setUser, h.RemoveCollectionDocuments)
+		return router
+	}
+
+	t.Run("CreateCollection_NameTaken_Returns409", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("CreateCollection", mock.Anything, mock.Anything).Return(false, nil)
+
+		req, _ := http.NewRequest("POST", "/collections", strings.NewReader(`{"name":"Contracts"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		newRouter(mockRepo, "alice").ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusConflict, resp.Code)
+	})
+
+	t.Run("AddCollectionDocuments_Returns200", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetCollection", mock.Anything, "collection-1").Return(&models.Collection{ID: "collection-1", CreatedBy: "alice"}, nil)
+		mockRepo.On("AddCollectionDocuments", mock.Anything, "collection-1", []string{"doc-1"}).Return(1, nil)
+		mockRepo.On("GetRunningEmbeddingMigration", mock.Anything).Return(nil, nil)
+		mockRepo.On("ListSnapshots", mock.Anything, 100, 0).Return([]*models.Snapshot{}, 0, nil)
+		mockRepo.On("ListDocumentCollectionIDs", mock.Anything, "doc-1").Return([]string{"collection-1"}, nil)
+
+		req, _ := http.NewRequest("POST", "/collections/collection-1/documents", strings.NewReader(`{"document_ids":["doc-1"]}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		newRouter(mockRepo, "alice").ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.JSONEq(t, `{"updated":1}`, resp.Body.String())
+	})
+
+	t.Run("RemoveCollectionDocuments_NotCreator_Returns403", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetCollection", mock.Anything, "collection-1").Return(&models.Collection{ID: "collection-1", CreatedBy: "alice"}, nil)
+
+		req, _ := http.NewRequest("POST", "/collections/collection-1/documents/remove", strings.NewReader(`{"document_ids":["doc-1"]}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		newRouter(mockRepo, "bob").ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+		mockRepo.AssertNotCalled(t, "RemoveCollectionDocuments", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestListDocumentsHandler(t *testing.T) {
+	t.Run("ListDocuments_PageAndSort", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("ListDocuments", mock.Anything, 10, 20, models.DocumentFilter{Status: "complete", SortBy: "file_size", Order: "asc"}).Return([]*models.Document{{ID: "doc-1", Filename: "small.pdf"}}, 21, nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		router := setupTestRouter()
+		router.GET("/documents", h.ListDocuments)
+
+		req, _ := http.NewRequest("GET", "/documents?limit=10&offset=20&status=complete&sort_by=file_size&order=asc", nil)
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		var body models.DocumentListResponse
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+		assert.Equal(t, 21, body.Total)
+		assert.Equal(t, 10, body.Limit)
+		assert.Equal(t, 20, body.Offset)
+		if assert.Len(t, body.Documents, 1) {
+			assert.Equal(t, "doc-1", body.Documents[0].ID)
+		}
+	})
+
+	t.Run("ListDocuments_InvalidSort_Returns400", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		router := setupTestRouter()
+		router.GET("/documents", h.ListDocuments)
+
+		req, _ := http.NewRequest("GET", "/documents?sort_by=password", nil)
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		assert.Contains(t, resp.Body.String(), "sort_by must be")
+		mockRepo.AssertNotCalled(t, "ListDocuments", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestSearchDocumentsHandler(t *testing.T) {
+	serve := func(mockRepo *repomocks.MockRepository, query string) *httptest.ResponseRecorder {
+		h := &handlers.Handlers{Repository: mockRepo}
+		router := setupTestRouter()
+		router.GET("/documents/search", h.SearchDocuments)
+
+		req, _ := http.NewRequest("GET", "/documents/search?"+query, nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Run("SearchDocuments_Success", func(t *testing.T) {
+		from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+		to := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("ListDocuments", mock.Anything, 50, 0, models.DocumentFilter{
+			Metadata:    map[string]string{"team": "legal"},
+			Filename:    "*.pdf",
+			Text:        "refund policy",
+			CreatedFrom: &from,
+			CreatedTo:   &to,
+		}).Return([]*models.Document{{ID: "doc-1", Filename: "refunds.pdf", Title: "Refund Policy"}}, 1, nil)
+
+		resp := serve(mockRepo, "q=refund+policy&filename=*.pdf&metadata[team]=legal&created_from=2026-03-01&created_to=2026-04-01")
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"title":"Refund Policy"`)
+		assert.Contains(t, resp.Body.String(), `"total":1`)
+	})
+
+	t.Run("SearchDocuments_InvalidDate_Returns400", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+
+		resp := serve(mockRepo, "q=refunds&indexed_from=March")
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		assert.Contains(t, resp.Body.String(), "indexed_from must be")
+		mockRepo.AssertNotCalled(t, "ListDocuments", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("SearchDocuments_NoCriteria_Returns400", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+
+		resp := serve(mockRepo, "status=complete")
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		mockRepo.AssertNotCalled(t, "ListDocuments", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestDisabledFeatures(t *testing.T) {
+	h := &handlers.Handlers{}
+	for name, handler := range map[string]gin.HandlerFunc{
+		"CoreBackends":        h.CoreBackends,
+		"Status":              h.Status,
+		"CreateImpersonation": h.CreateImpersonation,
+		"ShadowTraffic":       h.ShadowTraffic,
+		"CreateWidgetToken":   h.CreateWidgetToken,
+	} {
+		t.Run(name+"_Returns503", func(t *testing.T) {
+			router := setupTestRouter()
+			router.POST("/feature", handler)
+
+			req, _ := http.NewRequest("POST", "/feature", strings.NewReader(`{}`))
+			req.Header.Set("Content-Type", "application/json")
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+			var body models.ErrorResponse
+			assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+			assert.Equal(t, "SERVICE_UNAVAILABLE", body.Error.Code)
+			assert.Contains(t, body.Error.Message, "not enabled")
+		})
+	}
+}
+
+func TestQueryHandler_ConversationBusy(t *testing.T) {
+	t.Run("Query_ConversationBusy_Returns409", func(t *testing.T) {
+		locks, err := services.NewConversationLocks(&config.ConversationConfig{QueryMode: config.ConversationQueryReject}, nil)
+		assert.NoError(t, err)
+		release, err := locks.Acquire(context.Background(), "conv-1", "req-1")
+		assert.NoError(t, err)
+		defer release()
+		mockCoreClient := mocks.NewMockCoreService()
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetConversation", mock.Anything, "conv-1").Return(&models.Conversation{ID: "conv-1", CreatedBy: "alice"}, nil)
+
+		h := &handlers.Handlers{
+			Repository:    mockRepo,
+			CoreClient:    mockCoreClient,
+			Conversations: locks,
+		}
+
+		router := setupTestRouter()
+		router.POST("/query", func(c *gin.Context) { c.Set("username", "alice") }, h.Query)
+
+		req, _ := http.NewRequest("POST", "/query", strings.NewReader(`{"query":"what?","conversation_id":"conv-1"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusConflict, resp.Code)
+		var body models.ErrorResponse
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+		assert.Equal(t, "CONVERSATION_BUSY", body.Error.Code)
+		assert.Equal(t, "req-1", body.Error.Details["active_request_id"])
+		mockCoreClient.AssertNotCalled(t, "Query", mock.Anything, mock.Anything)
+	})
+}
+
+func TestQueryHandler_Streams(t *testing.T) {
+	t.Run("Query_StreamsEventsAsSSE", func(t *testing.T) {
+		upstream := make(chan models.SSEEvent, 2)
+		upstream <- models.SSEEvent{Type: "chunk", ID: "q-1", Content: "Hello"}
+		upstream <- models.SSEEvent{Type: "end", ID: "q-1", Tokens: 7}
+		close(upstream)
+		mockCoreClient := mocks.NewMockCoreService()
+		mockCoreClient.On("Query", mock.Anything, models.CoreQueryRequest{Query: "what?", TopK: gateway.DefaultTopK}).Return((<-chan models.SSEEvent)(upstream), nil)
+
+		h := &handlers.Handlers{CoreClient: mockCoreClient}
+
+		router := setupTestRouter()
+		router.POST("/query", h.Query)
+
+		// Streaming needs a real connection.
+		srv := httptest.NewServer(router)
+		defer srv.Close()
+		resp, err := srv.Client().Post(srv.URL+"/query", "application/json", strings.NewReader(`{"query":"what?"}`))
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+		assert.Equal(t, "event:message\ndata:{\"type\":\"chunk\",\"id\":\"q-1\",\"content\":\"Hello\"}\n\n"+
+			"event:message\ndata:{\"type\":\"end\",\"id\":\"q-1\",\"tokens\":7}\n\n", string(body))
+	})
+
+	t.Run("Query_HeartbeatsOutliveWriteTimeout", func(t *testing.T) {
+		upstream := make(chan models.SSEEvent)
+		go func() {
+			time.Sleep(300 * time.Millisecond)
+			upstream <- models.SSEEvent{Type: "end", ID: "q-1"}
+			close(upstream)
+		}()
+		mockCoreClient := mocks.NewMockCoreService()
+		mockCoreClient.On("Query", mock.Anything, models.CoreQueryRequest{Query: "what?", TopK: gateway.DefaultTopK}).Return((<-chan models.SSEEvent)(upstream), nil)
+
+		h := &handlers.Handlers{CoreClient: mockCoreClient, StreamHeartbeat: 50 * time.Millisecond}
+
+		router := setupTestRouter()
+		router.POST("/query", h.Query)
+
+		srv := httptest.NewUnstartedServer(router)
+		srv.Config.WriteTimeout = 100 * time.Millisecond
+		srv.Start()
+		defer srv.Close()
+		resp, err := srv.Client().Post(srv.URL+"/query", "application/json", strings.NewReader(`{"query":"what?"}`))
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		assert.NoError(t, err)
+
+		assert.True(t, strings.HasPrefix(string(body), ": ping\n\n"))
+		assert.True(t, strings.HasSuffix(string(body), "event:message\ndata:{\"type\":\"end\",\"id\":\"q-1\"}\n\n"))
+	})
+}
+
+func TestStreamEventsHandler(t *testing.T) {
+	t.Run("StreamEvents_OutlivesWriteTimeout", func(t *testing.T) {
+		hub := services.NewEventHub(1)
+		h := &handlers.Handlers{Events: hub, StreamHeartbeat: 20 * time.Millisecond}
+
+		router := setupTestRouter()
+		router.GET("/events/stream", h.StreamEvents)
+
+		srv := httptest.NewUnstartedServer(router)
+		srv.Config.WriteTimeout = 100 * time.Millisecond
+		srv.Start()
+		defer srv.Close()
+		client := srv.Client()
+		client.Timeout = 5 * time.Second
+		resp, err := client.Get(srv.URL + "/events/stream?topic=document:doc-1")
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer resp.Body.Close()
+		reader := bufio.NewReader(resp.Body)
+
+		// The first heartbeat shows the subscription is in place.
+		line, err := reader.ReadString('\n')
+		assert.NoError(t, err)
+		assert.Equal(t, ": ping\n", line)
+
+		time.Sleep(3 * srv.Config.WriteTimeout)
+		hub.Publish(&models.Event{ID: "evt-1", Type: models.EventDocumentIndexed, SubjectID: "doc-1"})
+
+		for err == nil && !strings.HasPrefix(line, "event:") {
+			line, err = reader.ReadString('\n')
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, "event:document.indexed\n", line)
+	})
+}
+
+func TestQueryHandler_ValidationError(t *testing.T) {
+	t.Run("Query_InvalidJSON_Returns400", func(t *testing.T) {
+		mockCoreClient := mocks.NewMockCoreService()
+		mockS3Client := mocks.NewMockS3Client()
+		mockTemporalClient := mocks.NewMockTemporalClient()
+		mockQdrantClient := mocks.NewMockQdrantClient()
+
+		h := &handlers.Handlers{
+			CoreClient:   mockCoreClient,
+			S3Client:     mockS3Client,
+			Temporal:     mockTemporalClient,
+			QdrantClient: mockQdrantClient,
+		}
+
+		router := setupTestRouter()
+		router.POST("/query", h.Query)
+
+		// Invalid JSON
+		body := []byte(`{"invalid": "data"}`)
+		req, _ := http.NewRequest("POST", "/query", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+}
+
+func TestCreateWebhookHandler(t *testing.T) {
+	t.Run("CreateWebhook_Success", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("CreateWebhook", mock.Anything, mock.AnythingOfType("*models.Webhook")).Return(nil)
+
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		router := setupTestRouter()
+		router.POST("/admin/webhooks", h.CreateWebhook)
+
+		body := `{"url":"https://hooks.example.com/kb","secret":"0123456789abcdef","events":["document.indexed"]}`
+		req, _ := http.NewRequest("POST", "/admin/webhooks", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusCreated, resp.Code)
+
+		var webhook models.Webhook
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &webhook))
+		assert.Equal(t, "https://hooks.example.com/kb", webhook.URL)
+		assert.Empty(t, webhook.Secret)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("CreateWebhook_UnknownEvent", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		router := setupTestRouter()
+		router.POST("/admin/webhooks", h.CreateWebhook)
+
+		body := `{"url":"https://hooks.example.com/kb","secret":"0123456789abcdef","events":["document.deleted"]}`
+		req, _ := http.NewRequest("POST", "/admin/webhooks", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		mockRepo.AssertNotCalled(t, "CreateWebhook", mock.Anything, mock.Anything)
+	})
+}
+
+func TestCreateConversationHandler_PublishesEvent(t *testing.T) {
+	mockRepo := repomocks.NewMockRepository()
+	mockRepo.On("CreateConversation", mock.Anything, mock.AnythingOfType("*models.Conversation")).Return(nil)
+	mockWebhooks := mocks.NewMockWebhookDispatcher()
+	mockWebhooks.On("Dispatch", mock.Anything, models.EventConversationCreated, mock.AnythingOfType("*models.Conversation")).Return()
+
+	h := &handlers.Handlers{Repository: mockRepo, Webhooks: mockWebhooks}
+
+	router := setupTestRouter()
+	router.POST("/conversations", h.CreateConversation)
+
+	req, _ := http.NewRequest("POST", "/conversations", nil)
+	resp := httptest.NewRecorder()
+
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusCreated, resp.Code)
+	mockWebhooks.AssertExpectations(t)
+}
+
+func TestIngestEventHandler(t *testing.T) {
+	serve := func(h *handlers.Handlers, body string) *httptest.ResponseRecorder {
+		router := setupTestRouter()
+		router.POST("/internal/v1/events", h.IngestEvent)
+
+		req, _ := http.NewRequest("POST", "/internal/v1/events", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Run("IngestEvent_Success", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("UpdateDocumentStatus", mock.Anything, "doc-1", "failed", "parse error").Return(nil)
+		mockRepo.On("CreateDocumentEvent", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("CreateEvent", mock.Anything, mock.AnythingOfType("*models.Event")).Return(true, nil)
+		mockWebhooks := mocks.NewMockWebhookDispatcher()
+		mockWebhooks.On("Dispatch", mock.Anything, models.EventDocumentFailed, mock.AnythingOfType("*models.Event")).Return()
+		mockAlerts := mocks.NewMockOpsMonitor()
+		mockAlerts.On("DocumentFailed", mock.Anything, "doc-1").Return()
+		mockNotifications := mocks.NewMockNotificationService()
+		mockNotifications.On("DocumentFailed", mock.Anything, "doc-1").Return()
+		hub := services.NewEventHub(1)
+		events, cancel := hub.Subscribe("document:doc-1")
+		defer cancel()
+
+		h := &handlers.Handlers{
+			Repository:    mockRepo,
+			Webhooks:      mockWebhooks,
+			Alerts:        mockAlerts,
+			Notifications: mockNotifications,
+			Events:        hub,
+		}
+		resp := serve(h, `{"type":"document.failed","source":"temporal","subject_id":"doc-1","data":{"error":"parse error"}}`)
+
+		assert.Equal(t, http.StatusAccepted, resp.Code)
+		var event models.Event
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &event))
+		assert.NotEmpty(t, event.ID)
+		assert.Equal(t, models.EventDocumentFailed, (<-events).Type)
+		mockWebhooks.AssertExpectations(t)
+		mockAlerts.AssertExpectations(t)
+		mockNotifications.AssertExpectations(t)
+	})
+
+	t.Run("IngestEvent_Duplicate", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("UpdateDocumentStatus", mock.Anything, "doc-1", "complete", "").Return(nil)
+		mockRepo.On("CreateDocumentEvent", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("CreateEvent", mock.Anything, mock.AnythingOfType("*models.Event")).Return(false, nil)
+		mockWebhooks := mocks.NewMockWebhookDispatcher()
+
+		h := &handlers.Handlers{Repository: mockRepo, Webhooks: mockWebhooks}
+		resp := serve(h, `{"id":"5f0c3a52-8e1b-4b9e-9f43-6f1f4f3c2a10","type":"document.indexed","source":"python-core","subject_id":"doc-1"}`)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		mockWebhooks.AssertNotCalled(t, "Dispatch", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("IngestEvent_ApplyError", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("CreateDocumentEvent", mock.Anything, mock.Anything).Return(errors.New("db down"))
+
+		h := &handlers.Handlers{Repository: mockRepo}
+		resp := serve(h, `{"type":"document.scanned","source":"python-core","subject_id":"doc-1"}`)
+
+		assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	})
+
+	t.Run("IngestEvent_MissingData", func(t *testing.T) {
+		h := &handlers.Handlers{Repository: repomocks.NewMockRepository()}
+		resp := serve(h, `{"type":"document.failed","source":"temporal","subject_id":"doc-1"}`)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+		var response models.ErrorResponse
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		assert.Equal(t, "VALIDATION_ERROR", response.Error.Code)
+		assert.Equal(t, "data.error", response.Error.Details["field"])
+	})
+
+	t.Run("IngestEvent_UnknownSource", func(t *testing.T) {
+		h := &handlers.Handlers{Repository: repomocks.NewMockRepository()}
+		resp := serve(h, `{"type":"document.indexed","source":"browser","subject_id":"doc-1"}`)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+}
+
+func TestReadyHandler_Redis(t *testing.T) {
+	t.Run("Ready_RedisHealthy", func(t *testing.T) {
+		mockCoreClient := mocks.NewMockCoreService()
+		mockCoreClient.On("HealthCheck", mock.Anything).Return(map[string]string{"python_core": "ok"}, nil)
+		mockRedis := mocks.NewMockRedisClient()
+		mockRedis.On("HealthCheck", mock.Anything).Return(nil)
+
+		h := &handlers.Handlers{CoreClient: mockCoreClient, Redis: mockRedis}
+
+		router := setupTestRouter()
+		router.GET("/readyz", h.Ready)
+
+		req, _ := http.NewRequest("GET", "/readyz", nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+
+		var response models.ReadinessResponse
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		assert.Equal(t, map[string]string{"python_core": "ok", "redis": "ok"}, response.Dependencies)
+		mockRedis.AssertExpectations(t)
+	})
+
+	t.Run("Ready_RedisUnavailable", func(t *testing.T) {
+		mockCoreClient := mocks.NewMockCoreService()
+		mockCoreClient.On("HealthCheck", mock.Anything).Return(map[string]string{"python_core": "ok"}, nil)
+		mockRedis := mocks.NewMockRedisClient()
+		mockRedis.On("HealthCheck", mock.Anything).Return(assert.AnError)
+
+		h := &handlers.Handlers{CoreClient: mockCoreClient, Redis: mockRedis}
+
+		router := setupTestRouter()
+		router.GET("/readyz", h.Ready)
+
+		req, _ := http.NewRequest("GET", "/readyz", nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+
+		var response models.ReadinessResponse
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		assert.Equal(t, "not_ready", response.Status)
+		assert.Equal(t, assert.AnError.Error(), response.Dependencies["redis"])
+	})
+}
+
+func TestAdminStatsHandler(t *testing.T) {
+	t.Run("AdminStats_Success", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetDocumentStats", mock.Anything).Return(&models.DocumentStats{
+			Total:        3,
+			ByStatus:     map[string]int{"complete": 2, "failed": 1},
+			StorageBytes: 4096,
+		}, nil)
+		today := time.Now().UTC().Format("2006-01-02")
+		mockRepo.On("CountQueriesByDay", mock.Anything, mock.AnythingOfType("time.Time")).Return([]models.DailyCount{{Date: today, Count: 5}}, nil)
+		mockRepo.On("CountActiveConversations", mock.Anything, mock.AnythingOfType("time.Time")).Return(2, nil)
+		mockRepo.On("GetTrashStats", mock.Anything).Return(&models.TrashStats{Documents: 1, Bytes: 512, ReclaimedBytes: 8192}, nil)
+
+		mockCoreClient := mocks.NewMockCoreService()
+		mockCoreClient.On("HealthCheck", mock.Anything).Return(map[string]string{"python_core": "ok"}, nil)
+		mockTemporalClient := mocks.NewMockTemporalClient()
+		mockTemporalClient.On("HealthCheck", mock.Anything).Return(assert.AnError)
+		mockQdrantClient := mocks.NewMockQdrantClient()
+		mockQdrantClient.On("CountVectors", mock.Anything).Return(uint64(120), nil)
+
+		h := &handlers.Handlers{
+			Repository:   mockRepo,
+			CoreClient:   mockCoreClient,
+			Temporal:     mockTemporalClient,
+			QdrantClient: mockQdrantClient,
+		}
+
+		router := setupTestRouter()
+		router.GET("/admin/stats", h.AdminStats)
+
+		req, _ := http.NewRequest("GET", "/admin/stats?days=3", nil)
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+
+		var stats models.AdminStatsResponse
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &stats))
+		assert.Equal(t, 3, stats.Documents.Total)
+		assert.Equal(t, int64(4096), stats.Documents.StorageBytes)
+		assert.Equal(t, uint64(120), *stats.VectorsCount)
+		assert.Equal(t, 2, stats.ActiveConversations)
+		assert.Equal(t, int64(8192), stats.Trash.ReclaimedBytes)
+		assert.Len(t, stats.QueriesPerDay, 3)
+		assert.Equal(t, models.DailyCount{Date: today, Count: 5}, stats.QueriesPerDay[2])
+		assert.Equal(t, 0, stats.QueriesPerDay[0].Count)
+		assert.Equal(t, "ok", stats.Dependencies["database"])
+		assert.Equal(t, "ok", stats.Dependencies["qdrant"])
+		assert.Equal(t, assert.AnError.Error(), stats.Dependencies["temporal"])
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("AdminStats_QdrantUnavailable", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetDocumentStats", mock.Anything).Return(&models.DocumentStats{ByStatus: map[string]int{}}, nil)
+		mockRepo.On("CountQueriesByDay", mock.Anything, mock.Anything).Return(nil, nil)
+		mockRepo.On("CountActiveConversations", mock.Anything, mock.Anything).Return(0, nil)
+		mockRepo.On("GetTrashStats", mock.Anything).Return(&models.TrashStats{}, nil)
+		mockQdrantClient := mocks.NewMockQdrantClient()
+		mockQdrantClient.On("CountVectors", mock.Anything).Return(uint64(0), assert.AnError)
+
+		h := &handlers.Handlers{Repository: mockRepo, QdrantClient: mockQdrantClient}
+
+		router := setupTestRouter()
+		router.GET("/admin/stats", h.AdminStats)
+
+		req, _ := http.NewRequest("GET", "/admin/stats", nil)
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+
+		var stats models.AdminStatsResponse
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &stats))
+		assert.Nil(t, stats.VectorsCount)
+		assert.Len(t, stats.QueriesPerDay, 7)
+		assert.Equal(t, assert.AnError.Error(), stats.Dependencies["qdrant"])
+	})
+
+	t.Run("AdminStats_InvalidDays", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		router := setupTestRouter()
+		router.GET("/admin/stats", h.AdminStats)
+
+		req, _ := http.NewRequest("GET", "/admin/stats?days=365", nil)
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		mockRepo.AssertNotCalled(t, "GetDocumentStats", mock.Anything)
+	})
+
+	t.Run("AdminStats_DatabaseError", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetDocumentStats", mock.Anything).Return(nil, assert.AnError)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		router := setupTestRouter()
+		router.GET("/admin/stats", h.AdminStats)
+
+		req, _ := http.NewRequest("GET", "/admin/stats", nil)
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	})
+}
+
+func TestQueryFeedbackHandler(t *testing.T) {
+	t.Run("QueryFeedback_Success", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("SetQueryFeedback", mock.Anything, "q-1", "alice", -1, "wrong document").Return(true, nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		router := setupTestRouter()
+		router.POST("/queries/:id/feedback", func(c *gin.Context) { c.Set("username", "alice") }, h.QueryFeedback)
+
+		req, _ := http.NewRequest("POST", "/queries/q-1/feedback", bytes.NewBufferString(`{"rating":-1,"comment":"wrong document"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusNoContent, resp.Code)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("QueryFeedback_NotFound", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("SetQueryFeedback", mock.Anything, "q-1", "bob", 1, "").Return(false, nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		router := setupTestRouter()
+		router.POST("/queries/:id/feedback", func(c *gin.Context) { c.Set("username", "bob") }, h.QueryFeedback)
+
+		req, _ := http.NewRequest("POST", "/queries/q-1/feedback", bytes.NewBufferString(`{"rating":1}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+
+	t.Run("QueryFeedback_InvalidRating", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		router := setupTestRouter()
+		router.POST("/queries/:id/feedback", h.QueryFeedback)
+
+		req, _ := http.NewRequest("POST", "/queries/q-1/feedback", bytes.NewBufferString(`{"rating":5}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		mockRepo.AssertNotCalled(t, "SetQueryFeedback", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestExportQueryLogsHandler(t *testing.T) {
+	logs := []*models.QueryLog{{
+		ID: "q-1", Username: "alice", Question: "what?", Status: models.QueryStatusCompleted,
+		LatencyMS: 120, CreatedAt: time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC),
+	}}
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("ExportQueryLogs_CSVResponse", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("ExportQueryLogs", mock.Anything, from, to, mock.Anything).Return(logs, nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		router := setupTestRouter()
+		router.GET("/admin/query-logs/export", h.ExportQueryLogs)
+
+		req, _ := http.NewRequest("GET", "/admin/query-logs/export?from=2024-01-01&to=2024-02-01", nil)
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, "text/csv", resp.Header().Get("Content-Type"))
+		assert.Contains(t, resp.Header().Get("Content-Disposition"), "query-logs-20240101-20240201.csv")
+		assert.Contains(t, resp.Body.String(), "q-1,2024-01-10T12:00:00Z,alice,,what?,completed,120")
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("ExportQueryLogs_S3", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("ExportQueryLogs", mock.Anything, from, to, mock.Anything).Return(logs, nil)
+		mockS3Client := mocks.NewMockS3Client()
+		mockS3Client.On("UploadObject", mock.Anything, mock.MatchedBy(func(key string) bool {
+			return strings.HasPrefix(key, "exports/") && strings.HasSuffix(key, "/query-logs-20240101-20240201.parquet")
+		}), mock.Anything, "application/vnd.apache.parquet").Return(nil)
+		mockS3Client.On("GeneratePresignedDownloadURL", mock.Anything, mock.Anything, time.Hour).Return("https://s3.example.com/export", nil)
+		h := &handlers.Handlers{Repository: mockRepo, S3Client: mockS3Client}
+
+		router := setupTestRouter()
+		router.GET("/admin/query-logs/export", h.ExportQueryLogs)
+
+		req, _ := http.NewRequest("GET", "/admin/query-logs/export?format=parquet&destination=s3&from=2024-01-01&to=2024-02-01", nil)
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+
+		var export models.ExportResponse
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &export))
+		assert.Equal(t, "https://s3.example.com/export", export.URL)
+		assert.Equal(t, 1, export.Rows)
+		mockS3Client.AssertExpectations(t)
+	})
+
+	t.Run("ExportQueryLogs_DatabaseError", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("ExportQueryLogs", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, assert.AnError)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		router := setupTestRouter()
+		router.GET("/admin/query-logs/export", h.ExportQueryLogs)
+
+		req, _ := http.NewRequest("GET", "/admin/query-logs/export", nil)
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusInternalServerError, resp.Code)
+		assert.Contains(t, resp.Header().Get("Content-Type"), "application/json")
+		assert.Empty(t, resp.Header().Get("Content-Disposition"))
+	})
+
+	t.Run("ExportQueryLogs_InvalidFormat", func(t *testing.T) {
+		h := &handlers.Handlers{Repository: repomocks.NewMockRepository()}
+
+		router := setupTestRouter()
+		router.GET("/admin/query-logs/export", h.ExportQueryLogs)
+
+		req, _ := http.NewRequest("GET", "/admin/query-logs/export?format=xlsx", nil)
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+}
+
+func TestListExportHandlers(t *testing.T) {
+	serve := func(h *handlers.Handlers, path string) *httptest.ResponseRecorder {
+		router := setupTestRouter()
+		router.GET("/documents/export", h.ExportDocuments)
+		router.GET("/conversations/:id/messages/export", func(c *gin.Context) { c.Set("username", "alice") }, h.ExportConversationMessages)
+
+		req, _ := http.NewRequest("GET", path, nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Run("ExportDocuments_Streamed", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("ExportDocuments", mock.Anything, models.DocumentFilter{Status: "complete", Language: "fr"}, mock.Anything).Return([]*models.Document{
+			{ID: "doc-1", Filename: "a.pdf", Status: "complete"},
+			{ID: "doc-2", Filename: "b.pdf", Status: "complete"},
+		}, nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := serve(h, "/documents/export?status=complete&language=FR")
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Header().Get("Content-Disposition"), "documents.json")
+		var documents []models.Document
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &documents))
+		if assert.Len(t, documents, 2) {
+			assert.Equal(t, "doc-2", documents[1].ID)
+		}
+	})
+
+	t.Run("ExportDocuments_Empty", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("ExportDocuments", mock.Anything, mock.Anything, mock.Anything).Return(nil, nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := serve(h, "/documents/export")
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.JSONEq(t, `[]`, resp.Body.String())
+	})
+
+	t.Run("ExportDocuments_FailsBeforeFirstRow", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("ExportDocuments", mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("database error"))
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := serve(h, "/documents/export")
+
+		assert.Equal(t, http.StatusInternalServerError, resp.Code)
+		assert.Empty(t, resp.Header().Get("Content-Disposition"))
+		assert.Contains(t, resp.Body.String(), "Failed to export documents")
+	})
+
+	t.Run("ExportDocuments_FailsMidStream", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("ExportDocuments", mock.Anything, mock.Anything, mock.Anything).Return([]*models.Document{{ID: "doc-1"}}, errors.New("connection reset"))
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := serve(h, "/documents/export")
+
+		// The status was sent with the first row, so the body is cut off.
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.True(t, strings.HasPrefix(resp.Body.String(), `[{"id":"doc-1"`))
+		assert.False(t, json.Valid(resp.Body.Bytes()))
+	})
+
+	t.Run("ExportConversationMessages_Streamed", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetConversation", mock.Anything, "conv-1").Return(&models.Conversation{ID: "conv-1", CreatedBy: "alice"}, nil)
+		mockRepo.On("ExportMessages", mock.Anything, "conv-1", mock.Anything).Return([]*models.Message{
+			{ID: "msg-1", ConversationID: "conv-1", Role: "user", Content: "Hello"},
+			{ID: "msg-2", ConversationID: "conv-1", Role: "assistant", Content: "Hi there!"},
+		}, nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := serve(h, "/conversations/conv-1/messages/export")
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Header().Get("Content-Disposition"), "conversation-conv-1-messages.json")
+		var messages []models.Message
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &messages))
+		if assert.Len(t, messages, 2) {
+			assert.Equal(t, "Hi there!", messages[1].Content)
+		}
+	})
+
+	t.Run("ExportConversationMessages_NotFound", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetConversation", mock.Anything, "conv-1").Return(nil, nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := serve(h, "/conversations/conv-1/messages/export")
+
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+		mockRepo.AssertNotCalled(t, "ExportMessages", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("ExportConversationMessages_OtherUsersConversation", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetConversation", mock.Anything, "conv-1").Return(&models.Conversation{ID: "conv-1", CreatedBy: "bob"}, nil)
+		mockRepo.On("GetConversationParticipant", mock.Anything, "conv-1", "alice").Return(nil, nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := serve(h, "/conversations/conv-1/messages/export")
+
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+		mockRepo.AssertNotCalled(t, "ExportMessages", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestExportAccessReviewHandler(t *testing.T) {
+	serve := func(h *handlers.Handlers) *httptest.ResponseRecorder {
+		router := setupTestRouter()
+		router.GET("/admin/access-review/export", h.ExportAccessReview)
+
+		req, _ := http.NewRequest("GET", "/admin/access-review/export", nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Run("ExportAccessReview_CSV", func(t *testing.T) {
+		created := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+		expired := created.AddDate(0, 1, 0)
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("ListServiceTokens", mock.Anything).Return([]*models.ServiceToken{
+			{Name: "ci", Prefix: "kbt_ab12cd34", Scopes: []string{"documents:read", "query:write"}, CreatedBy: "alice", CreatedAt: created, ExpiresAt: &expired},
+		}, nil)
+		mockRepo.On("ListAllConnectors", mock.Anything).Return([]*models.Connector{
+			{ID: "conn-1", Username: "bob", Provider: "google_drive", Name: "Policies", FolderIDs: []string{"f1", "f2"}, Status: models.ConnectorStatusIdle, CreatedAt: created},
+		}, nil)
+		h := &handlers.Handlers{Repository: mockRepo, AdminUsers: []string{"alice"}}
+
+		resp := serve(h)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, "text/csv", resp.Header().Get("Content-Type"))
+		assert.Contains(t, resp.Header().Get("Content-Disposition"), "access-review-")
+		body := resp.Body.String()
+		assert.Contains(t, body, "user,*,user,*,,,,,,active\n")
+		assert.Contains(t, body, "user,alice,admin,*,,,,,,active\n")
+		assert.Contains(t, body, "service_token,ci (kbt_ab12cd34),service_token,*,documents:read query:write,alice,2024-01-10T12:00:00Z,2024-02-10T12:00:00Z,,expired\n")
+		assert.Contains(t, body, "connector,Policies (conn-1),connector,google_drive:f1 f2,,bob,2024-01-10T12:00:00Z,,,idle\n")
+	})
+
+	t.Run("ExportAccessReview_DatabaseError", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("ListServiceTokens", mock.Anything).Return(nil, errors.New("db down"))
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := serve(h)
+
+		assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	})
+}
+
+func TestNotificationPreferencesHandler(t *testing.T) {
+	serve := func(h *handlers.Handlers, method, body string) *httptest.ResponseRecorder {
+		router := setupTestRouter()
+		setUser := func(c *gin.Context) { c.Set("username", "alice") }
+		router.GET("/notifications/preferences", setUser, h.GetNotificationPreferences)
+		router.PUT("/notifications/preferences", setUser, h.UpdateNotificationPreferences)
+
+		req, _ := http.NewRequest(method, "/notifications/preferences", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Run("GetPreferences_Defaults", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetNotificationPreferences", mock.Anything, "alice").Return(nil, nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := serve(h, "GET", "")
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		var prefs models.NotificationPreferences
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &prefs))
+		assert.Equal(t, "alice", prefs.Username)
+		assert.Empty(t, prefs.Email)
+		assert.True(t, prefs.ImportFinished)
+		assert.True(t, prefs.IndexingFailed)
+		assert.True(t, prefs.ExportReady)
+	})
+
+	t.Run("UpdatePreferences_Merges", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetNotificationPreferences", mock.Anything, "alice").Return(&models.NotificationPreferences{
+			Username:       "alice",
+			Email:          "alice@example.com",
+			ImportFinished: true,
+			IndexingFailed: true,
+			ExportReady:    true,
+		}, nil)
+		mockRepo.On("UpsertNotificationPreferences", mock.Anything, mock.MatchedBy(func(p *models.NotificationPreferences) bool {
+			return p.Email == "alice@example.com" && !p.ExportReady && p.ImportFinished && p.IndexingFailed
+		})).Return(nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := serve(h, "PUT", `{"export_ready":false}`)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("UpdatePreferences_InvalidEmail", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := serve(h, "PUT", `{"email":"not-an-address"}`)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		mockRepo.AssertNotCalled(t, "UpsertNotificationPreferences", mock.Anything, mock.Anything)
+	})
+}
+
+func TestWorkspaceSettingsHandlers(t *testing.T) {
+	serve := func(h *handlers.Handlers, method, body string) *httptest.ResponseRecorder {
+		router := setupTestRouter()
+		setUser := func(c *gin.Context) { c.Set("username", "admin") }
+		router.GET("/workspace/settings", setUser, h.GetWorkspaceSettings)
+		router.PATCH("/workspace/settings", setUser, h.UpdateWorkspaceSettings)
+
+		req, _ := http.NewRequest(method, "/workspace/settings", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Run("GetWorkspaceSettings_Defaults", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetWorkspaceSettings", mock.Anything).Return(nil, nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := serve(h, "GET", "")
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.JSONEq(t, `{"name":"Knowledge Base","logo_url":"","default_language":"","retention_days":0,"allowed_file_types":[],"metadata_schema":[]}`, resp.Body.String())
+	})
+
+	t.Run("UpdateWorkspaceSettings_KeepsUnsetFields", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetWorkspaceSettings", mock.Anything).Return(&models.WorkspaceSettings{
+			Name: "Acme Docs", DefaultLanguage: "de", AllowedFileTypes: []string{"pdf"},
+		}, nil)
+		mockRepo.On("UpsertWorkspaceSettings", mock.Anything, mock.MatchedBy(func(settings *models.WorkspaceSettings) bool {
+			return settings.Name == "Acme Docs" && settings.DefaultLanguage == "de" &&
+				settings.LogoURL == "https://cdn.example.com/logo.svg" && settings.UpdatedBy == "admin"
+		})).Return(nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := serve(h, "PATCH", `{"logo_url":"https://cdn.example.com/logo.svg"}`)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("UpdateWorkspaceSettings_InvalidFileType", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetWorkspaceSettings", mock.Anything).Return(nil, nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := serve(h, "PATCH", `{"allowed_file_types":["*.pdf"]}`)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		mockRepo.AssertNotCalled(t, "UpsertWorkspaceSettings", mock.Anything, mock.Anything)
+	})
+}
+
+func TestPromptTemplateHandlers(t *testing.T) {
+	serve := func(h *handlers.Handlers, method, path, body string) *httptest.ResponseRecorder {
+		router := setupTestRouter()
+		setUser := func(c *gin.Context) { c.Set("username", "alice") }
+		router.POST("/prompt-templates", setUser, h.CreatePromptTemplate)
+		router.GET("/prompt-templates/:id", setUser, h.GetPromptTemplate)
+		router.PUT("/prompt-templates/:id", setUser, h.UpdatePromptTemplate)
+
+		req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Run("CreatePromptTemplate_Success", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("CreatePromptTemplate", mock.Anything, mock.MatchedBy(func(tmpl *models.PromptTemplate) bool {
+			return tmpl.Name == "concise" && tmpl.Version == 1 && tmpl.CreatedBy == "alice"
+		})).Return(true, nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := serve(h, "POST", "/prompt-templates", `{"name":"concise","template":"Answer briefly: {question}"}`)
+
+		assert.Equal(t, http.StatusCreated, resp.Code)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("CreatePromptTemplate_Conflict", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("CreatePromptTemplate", mock.Anything, mock.AnythingOfType("*models.PromptTemplate")).Return(false, nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := serve(h, "POST", "/prompt-templates", `{"name":"concise","template":"Answer briefly: {question}"}`)
+
+		assert.Equal(t, http.StatusConflict, resp.Code)
+	})
+
+	t.Run("GetPromptTemplate_Version", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetPromptTemplate", mock.Anything, "tmpl-1", 1).Return(&models.PromptTemplate{ID: "tmpl-1", Version: 1}, nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := serve(h, "GET", "/prompt-templates/tmpl-1?version=1", "")
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("GetPromptTemplate_NotFound", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetPromptTemplate", mock.Anything, "missing", 0).Return(nil, nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := serve(h, "GET", "/prompt-templates/missing", "")
+
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+
+	t.Run("UpdatePromptTemplate_NewVersion", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetPromptTemplate", mock.Anything, "tmpl-1", 0).Return(&models.PromptTemplate{ID: "tmpl-1", Name: "concise", Version: 1, Template: "old"}, nil)
+		mockRepo.On("AddPromptTemplateVersion", mock.Anything, "tmpl-1", mock.AnythingOfType("*models.PromptTemplateVersion"), (*string)(nil)).
+			Run(func(args mock.Arguments) { args.Get(2).(*models.PromptTemplateVersion).Version = 2 }).
+			Return(true, nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := serve(h, "PUT", "/prompt-templates/tmpl-1", `{"template":"new"}`)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		var tmpl models.PromptTemplate
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &tmpl))
+		assert.Equal(t, 2, tmpl.Version)
+		assert.Equal(t, "new", tmpl.Template)
+	})
+}
+
+func TestEmbeddingMigrationHandlers(t *testing.T) {
+	serve := func(h *handlers.Handlers, method, path, body string) *httptest.ResponseRecorder {
+		router := setupTestRouter()
+		setUser := func(c *gin.Context) { c.Set("username", "admin") }
+		router.POST("/embedding-migrations", setUser, h.CreateEmbeddingMigration)
+		router.GET("/embedding-migrations", setUser, h.ListEmbeddingMigrations)
+		router.GET("/embedding-migrations/:id", setUser, h.GetEmbeddingMigration)
+
+		req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Run("CreateEmbeddingMigration_Success", func(t *testing.T) {
+		mockMigrations := mocks.NewMockEmbeddingMigrator()
+		mockMigrations.On("Migrate", mock.Anything, models.CreateEmbeddingMigrationRequest{EmbeddingModel: "bge-m3", VectorSize: 1024}, "admin").
+			Return(&models.EmbeddingMigration{ID: "mig-1", Status: models.MigrationStatusRunning}, nil)
+		h := &handlers.Handlers{Repository: repomocks.NewMockRepository(), Migrations: mockMigrations}
+
+		resp := serve(h, "POST", "/embedding-migrations", `{"embedding_model":"bge-m3","vector_size":1024}`)
+
+		assert.Equal(t, http.StatusAccepted, resp.Code)
+		mockMigrations.AssertExpectations(t)
+	})
+
+	t.Run("CreateEmbeddingMigration_InProgress", func(t *testing.T) {
+		mockMigrations := mocks.NewMockEmbeddingMigrator()
+		mockMigrations.On("Migrate", mock.Anything, mock.Anything, "admin").Return(nil, services.ErrMigrationInProgress)
+		h := &handlers.Handlers{Repository: repomocks.NewMockRepository(), Migrations: mockMigrations}
+
+		resp := serve(h, "POST", "/embedding-migrations", `{"embedding_model":"bge-m3","vector_size":1024}`)
+
+		assert.Equal(t, http.StatusConflict, resp.Code)
+	})
+
+	t.Run("CreateEmbeddingMigration_InvalidRequest", func(t *testing.T) {
+		h := &handlers.Handlers{Repository: repomocks.NewMockRepository(), Migrations: mocks.NewMockEmbeddingMigrator()}
+
+		resp := serve(h, "POST", "/embedding-migrations", `{"embedding_model":"bge-m3"}`)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("GetEmbeddingMigration_Success", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetEmbeddingMigration", mock.Anything, "mig-1").Return(&models.EmbeddingMigration{
+			ID: "mig-1", Status: models.MigrationStatusRunning, TotalDocuments: 10, IndexedDocuments: 4,
+		}, nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := serve(h, "GET", "/embedding-migrations/mig-1", "")
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		var migration models.EmbeddingMigration
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &migration))
+		assert.Equal(t, 4, migration.IndexedDocuments)
+	})
+
+	t.Run("GetEmbeddingMigration_NotFound", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetEmbeddingMigration", mock.Anything, "missing").Return(nil, nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := serve(h, "GET", "/embedding-migrations/missing", "")
+
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+
+	t.Run("ListEmbeddingMigrations_Success", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("ListEmbeddingMigrations", mock.Anything, 50, 0).Return([]*models.EmbeddingMigration{{ID: "mig-1"}}, 1, nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := serve(h, "GET", "/embedding-migrations", "")
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		var response models.EmbeddingMigrationListResponse
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		assert.Equal(t, 1, response.Total)
+	})
+}
+
+func TestEvaluationHandlers(t *testing.T) {
+	serve := func(h *handlers.Handlers, method, path, body string) *httptest.ResponseRecorder {
+		router := setupTestRouter()
+		setUser := func(c *gin.Context) { c.Set("username", "admin") }
+		router.POST("/evaluations", setUser, h.CreateEvaluation)
+		router.GET("/evaluations", setUser, h.ListEvaluations)
+		router.GET("/evaluations/:id", setUser, h.GetEvaluation)
+		router.GET("/evaluations/:id/results", setUser, h.ListEvaluationResults)
+
+		req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Run("CreateEvaluation_InvalidRequest", func(t *testing.T) {
+		h := &handlers.Handlers{Repository: repomocks.NewMockRepository()}
+
+		resp := serve(h, "POST", "/evaluations", `{"name":"smoke","cases":[]}`)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("CreateEvaluation_Unavailable", func(t *testing.T) {
+		h := &handlers.Handlers{Repository: repomocks.NewMockRepository()}
+
+		resp := serve(h, "POST", "/evaluations", `{"cases":[{"question":"q","expected_answer":"a"}]}`)
+
+		assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+	})
+
+	t.Run("ListEvaluations_Success", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("ListEvaluations", mock.Anything, 50, 0).Return([]*models.Evaluation{
+			{ID: "eval-1", Status: models.EvaluationStatusCompleted, TotalCases: 2},
+		}, 1, nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := serve(h, "GET", "/evaluations", "")
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		var response models.EvaluationListResponse
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		assert.Equal(t, 1, response.Total)
+		assert.Equal(t, "eval-1", response.Evaluations[0].ID)
+	})
+
+	t.Run("GetEvaluation_NotFound", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetEvaluation", mock.Anything, "missing").Return(nil, nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := serve(h, "GET", "/evaluations/missing", "")
+
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+
+	t.Run("ListEvaluationResults_Success", func(t *testing.T) {
+		score := 0.75
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetEvaluation", mock.Anything, "eval-1").Return(&models.Evaluation{ID: "eval-1"}, nil)
+		mockRepo.On("ListEvaluationResults", mock.Anything, "eval-1").Return([]*models.EvaluationResult{
+			{Position: 0, Question: "q", ExpectedAnswer: "a", Answer: "a", Score: &score},
+		}, nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := serve(h, "GET", "/evaluations/eval-1/results", "")
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		var response models.EvaluationResultListResponse
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		assert.Equal(t, 0.75, *response.Results[0].Score)
+	})
+}
+
+func TestDuplicateReportHandlers(t *testing.T) {
+	serve := func(h *handlers.Handlers, method, path, body string) *httptest.ResponseRecorder {
+		router := setupTestRouter()
+		setUser := func(c *gin.Context) { c.Set("username", "admin") }
+		router.POST("/duplicate-reports", setUser, h.CreateDuplicateReport)
+		router.GET("/duplicate-reports", setUser, h.ListDuplicateReports)
+		router.GET("/duplicate-reports/:id", setUser, h.GetDuplicateReport)
+
+		req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Run("CreateDuplicateReport_InvalidThreshold", func(t *testing.T) {
+		h := &handlers.Handlers{Repository: repomocks.NewMockRepository()}
+
+		resp := serve(h, "POST", "/duplicate-reports", `{"threshold":0.5}`)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("CreateDuplicateReport_Unavailable", func(t *testing.T) {
+		h := &handlers.Handlers{Repository: repomocks.NewMockRepository()}
+
+		resp := serve(h, "POST", "/duplicate-reports", "")
+
+		assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+	})
+
+	t.Run("ListDuplicateReports_Success", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("ListDuplicateReports", mock.Anything, 50, 0).Return([]*models.DuplicateReport{
+			{ID: "report-1", Status: models.DuplicateReportStatusCompleted, ClusterCount: 2},
+		}, 1, nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := serve(h, "GET", "/duplicate-reports", "")
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		var response models.DuplicateReportListResponse
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		assert.Equal(t, 1, response.Total)
+		assert.Equal(t, 2, response.Reports[0].ClusterCount)
+	})
+
+	t.Run("GetDuplicateReport_Success", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetDuplicateReport", mock.Anything, "report-1").Return(&models.DuplicateReport{
+			ID:     "report-1",
+			Status: models.DuplicateReportStatusCompleted,
+			Clusters: []models.DuplicateCluster{{
+				Documents:  []models.DuplicateDocument{{ID: "doc-1"}, {ID: "doc-2"}},
+				Similarity: 0.97,
+			}},
+		}, nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := serve(h, "GET", "/duplicate-reports/report-1", "")
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		var report models.DuplicateReport
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &report))
+		assert.Len(t, report.Clusters[0].Documents, 2)
+	})
+
+	t.Run("GetDuplicateReport_NotFound", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetDuplicateReport", mock.Anything, "missing").Return(nil, nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := serve(h, "GET", "/duplicate-reports/missing", "")
+
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+}
+
+func TestWorkspaceHandlers(t *testing.T) {
+	serve := func(h *handlers.Handlers, method, path, body string) *httptest.ResponseRecorder {
+		router := setupTestRouter()
+		setUser := func(c *gin.Context) { c.Set("username", "admin") }
+		router.GET("/workspace/export", setUser, h.ExportWorkspace)
+		router.POST("/workspace/imports", setUser, h.ImportWorkspace)
+		router.GET("/workspace/imports/:id", setUser, h.GetWorkspaceImport)
+
+		req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Run("ExportWorkspace_Success", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("ExportDocuments", mock.Anything, models.DocumentFilter{Status: "complete"}, mock.Anything).Return([]*models.Document{}, nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := serve(h, "GET", "/workspace/export", "")
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Header().Get("Content-Disposition"), "workspace.json")
+		var bundle models.WorkspaceBundle
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &bundle))
+		assert.Equal(t, models.WorkspaceBundleVersion, bundle.Version)
+		assert.Equal(t, "admin", bundle.ExportedBy)
+		mockRepo.AssertNotCalled(t, "ListConversations", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("ExportWorkspace_InvalidConversations", func(t *testing.T) {
+		h := &handlers.Handlers{Repository: repomocks.NewMockRepository()}
+
+		resp := serve(h, "GET", "/workspace/export?conversations=maybe", "")
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("ImportWorkspace_Unavailable", func(t *testing.T) {
+		h := &handlers.Handlers{Repository: repomocks.NewMockRepository()}
+
+		resp := serve(h, "POST", "/workspace/imports", `{"version":1}`)
+
+		assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+	})
+
+	t.Run("GetWorkspaceImport_NotFound", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetWorkspaceImport", mock.Anything, "missing").Return(nil, nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := serve(h, "GET", "/workspace/imports/missing", "")
+
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+}
+
+func TestContentFreshnessHandler(t *testing.T) {
+	serve := func(h *handlers.Handlers, path string) *httptest.ResponseRecorder {
+		router := setupTestRouter()
+		router.GET("/content-freshness", h.ContentFreshness)
+
+		req, _ := http.NewRequest("GET", path, nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Run("ContentFreshness_Success", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("ListStaleDocuments", mock.Anything, mock.Anything, 10).Return([]*models.StaleDocument{
+			{ID: "doc-old", Filename: "old.pdf"},
+		}, 3, nil)
+		mockRepo.On("ListBrokenSourceDocuments", mock.Anything, 10).Return([]*models.StaleDocument{
+			{ID: "doc-gone", SourceURL: "https://example.com/gone", SourceStatus: http.StatusNotFound},
+		}, 1, nil)
+		mockRepo.On("ListUnretrievedDocuments", mock.Anything, mock.Anything, 10).Return(nil, 0, nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := serve(h, "/content-freshness?days=30&limit=10")
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		var report models.ContentFreshnessReport
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &report))
+		assert.Equal(t, 30, report.Days)
+		assert.Equal(t, 3, report.NotReindexed.Total)
+		assert.Equal(t, "doc-old", report.NotReindexed.Documents[0].ID)
+		assert.Equal(t, http.StatusNotFound, report.BrokenSources.Documents[0].SourceStatus)
+		assert.Empty(t, report.NeverRetrieved.Documents)
+		assert.WithinDuration(t, time.Now().AddDate(0, 0, -30), report.Since, time.Minute)
+	})
+
+	t.Run("ContentFreshness_InvalidDays", func(t *testing.T) {
+		h := &handlers.Handlers{Repository: repomocks.NewMockRepository()}
+
+		resp := serve(h, "/content-freshness?days=0")
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("ContentFreshness_DatabaseError", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("ListStaleDocuments", mock.Anything, mock.Anything, 100).Return(nil, 0, errors.New("db down"))
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := serve(h, "/content-freshness")
+
+		assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	})
+}
+
+func TestDocumentAnalyticsHandlers(t *testing.T) {
+	serve := func(h *handlers.Handlers, path string) *httptest.ResponseRecorder {
+		router := setupTestRouter()
+		router.GET("/documents/leaderboard", h.DocumentLeaderboard)
+		router.GET("/documents/:id/analytics", h.DocumentAnalytics)
+
+		req, _ := http.NewRequest("GET", path, nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Run("DocumentAnalytics_Success", func(t *testing.T) {
+		score := 0.8
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetDocument", mock.Anything, "doc-1").Return(&models.Document{ID: "doc-1", Filename: "guide.pdf"}, nil)
+		mockRepo.On("GetDocumentAnalytics", mock.Anything, "doc-1").Return(&models.DocumentAnalytics{
+			DocumentID: "doc-1", HitCount: 5, QueryCount: 3, AverageScore: &score,
+		}, nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := serve(h, "/documents/doc-1/analytics")
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		var analytics models.DocumentAnalytics
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &analytics))
+		assert.Equal(t, "guide.pdf", analytics.Filename)
+		assert.Equal(t, 5, analytics.HitCount)
+	})
+
+	t.Run("DocumentAnalytics_NotFound", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetDocument", mock.Anything, "missing").Return(nil, nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := serve(h, "/documents/missing/analytics")
+
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+
+	t.Run("DocumentLeaderboard_Least", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("ListDocumentLeaderboard", mock.Anything, mock.Anything, models.LeaderboardOrderLeast, 5).Return([]*models.DocumentAnalytics{
+			{DocumentID: "doc-2", Filename: "unused.pdf"},
+		}, nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := serve(h, "/documents/leaderboard?order=least&limit=5")
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		var response models.DocumentLeaderboardResponse
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		assert.Equal(t, models.LeaderboardOrderLeast, response.Order)
+		assert.Equal(t, 30, response.Days)
+		assert.Equal(t, "doc-2", response.Documents[0].DocumentID)
+	})
+
+	t.Run("DocumentLeaderboard_InvalidOrder", func(t *testing.T) {
+		h := &handlers.Handlers{Repository: repomocks.NewMockRepository()}
+
+		resp := serve(h, "/documents/leaderboard?order=random")
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+}
+
+func TestCreateTextDocumentHandler(t *testing.T) {
+	serve := func(h *handlers.Handlers, body string) *httptest.ResponseRecorder {
+		router := setupTestRouter()
+		router.POST("/documents/text", func(c *gin.Context) { c.Set("username", "alice") }, h.CreateTextDocument)
+
+		req, _ := http.NewRequest("POST", "/documents/text", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Run("CreateTextDocument_Success", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetWorkspaceSettings", mock.Anything).Return(nil, nil)
+		mockRepo.On("CreateDocument", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("CreateDocumentEvent", mock.Anything, mock.Anything).Return(nil)
+		mockS3Client := mocks.NewMockS3Client()
+		mockS3Client.On("UploadObject", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		mockTemporalClient := mocks.NewMockTemporalClient()
+		mockTemporalClient.On("StartUploadWorkflow", mock.Anything, mock.Anything).Return("upload-1", nil)
+		mockTemporalClient.On("SignalUploadComplete", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("SetDocumentIndexing", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		h := &handlers.Handlers{Repository: mockRepo, S3Client: mockS3Client, Temporal: mockTemporalClient}
+
+		resp := serve(h, `{"title":"Standup","content":"- shipped resync schedules"}`)
+
+		assert.Equal(t, http.StatusCreated, resp.Code)
+		var doc models.Document
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &doc))
+		assert.Equal(t, "Standup.md", doc.Filename)
+		assert.Equal(t, "alice", doc.UploadedBy)
+	})
+
+	t.Run("CreateTextDocument_MissingContent", func(t *testing.T) {
+		h := &handlers.Handlers{Repository: repomocks.NewMockRepository()}
+
+		resp := serve(h, `{"title":"Standup"}`)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("CreateTextDocument_UnknownFormat", func(t *testing.T) {
+		h := &handlers.Handlers{Repository: repomocks.NewMockRepository()}
+
+		resp := serve(h, `{"title":"Standup","content":"x","format":"html"}`)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+}
+
+func TestArchiveHandlers(t *testing.T) {
+	serve := func(h *handlers.Handlers, method, path, body string) *httptest.ResponseRecorder {
+		router := setupTestRouter()
+		router.GET("/documents/:id/children", h.ListChildDocuments)
+		router.POST("/internal/documents/:id/children", h.RegisterArchiveEntry)
+
+		req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Run("ListChildDocuments_Success", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetDocument", mock.Anything, "zip-1").Return(&models.Document{ID: "zip-1", Filename: "handbooks.zip"}, nil)
+		mockRepo.On("CountChildDocuments", mock.Anything, "zip-1").Return(&models.ChildProgress{Total: 1, Complete: 1}, nil)
+		mockRepo.On("ListChildDocuments", mock.Anything, "zip-1", 50, 0).Return([]*models.Document{
+			{ID: "doc-1", Filename: "onboarding.pdf", Status: "complete", ParentID: "zip-1"},
+		}, 1, nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := serve(h, "GET", "/documents/zip-1/children", "")
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		var list models.DocumentListResponse
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &list))
+		assert.Equal(t, 1, list.Total)
+		if assert.Len(t, list.Documents, 1) {
+			assert.Equal(t, "zip-1", list.Documents[0].ParentID)
+		}
+	})
+
+	t.Run("RegisterArchiveEntry_NotArchive", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetDocument", mock.Anything, "doc-1").Return(&models.Document{ID: "doc-1", Filename: "guide.pdf"}, nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := serve(h, "POST", "/internal/documents/doc-1/children", `{"filename":"a.pdf","file_size":10}`)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("RegisterArchiveEntry_MissingFilename", func(t *testing.T) {
+		h := &handlers.Handlers{Repository: repomocks.NewMockRepository()}
+
+		resp := serve(h, "POST", "/internal/documents/zip-1/children", `{"file_size":10}`)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+}
+
+func TestDocumentEventHandlers(t *testing.T) {
+	serve := func(h *handlers.Handlers, path string) *httptest.ResponseRecorder {
+		router := setupTestRouter()
+		router.GET("/documents/:id/events", h.ListDocumentEvents)
+
+		req, _ := http.NewRequest("GET", path, nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Run("ListDocumentEvents_Success", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("ListDocumentEvents", mock.Anything, "doc-1", 2, 0).Return([]*models.DocumentEvent{
+			{ID: "e-1", DocumentID: "doc-1", Type: models.DocumentEventUploaded, Source: models.DocumentEventSourceGateway},
+			{ID: "e-2", DocumentID: "doc-1", Type: models.DocumentEventIndexed, Source: "worker"},
+		}, 3, nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := serve(h, "/documents/doc-1/events?limit=2")
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		var list models.DocumentEventListResponse
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &list))
+		assert.Equal(t, 3, list.Total)
+		assert.Equal(t, 2, list.Limit)
+		if assert.Len(t, list.Events, 2) {
+			assert.Equal(t, models.DocumentEventIndexed, list.Events[1].Type)
+		}
+	})
+
+	t.Run("ListDocumentEvents_NotFound", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("ListDocumentEvents", mock.Anything, "missing", 50, 0).Return(nil, 0, nil)
+		mockRepo.On("GetDocument", mock.Anything, "missing").Return(nil, nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := serve(h, "/documents/missing/events")
+
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+}
+
+func TestServiceTokenHandlers(t *testing.T) {
+	serve := func(h *handlers.Handlers, method, path, body string) *httptest.ResponseRecorder {
+		router := setupTestRouter()
+		setUser := func(c *gin.Context) { c.Set("username", "admin") }
+		router.POST("/service-tokens", setUser, h.CreateServiceToken)
+		router.POST("/service-tokens/:id/rotate", setUser, h.RotateServiceToken)
+
+		req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Run("CreateServiceToken_Success", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		var stored *models.ServiceToken
+		mockRepo.On("CreateServiceToken", mock.Anything, mock.MatchedBy(func(token *models.ServiceToken) bool {
+			stored = token
+			return token.Name == "docs-sync" && token.CreatedBy == "admin" && token.Token == ""
+		})).Return(true, nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := serve(h, "POST", "/service-tokens", `{"name":"docs-sync","scopes":["documents:write"]}`)
+
+		assert.Equal(t, http.StatusCreated, resp.Code)
+		var token models.ServiceToken
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &token))
+		assert.True(t, strings.HasPrefix(token.Token, middleware.ServiceTokenPrefix))
+		assert.True(t, strings.HasPrefix(token.Token, token.Prefix))
+		assert.Equal(t, middleware.HashServiceToken(token.Token), stored.TokenHash)
+		assert.NotContains(t, resp.Body.String(), stored.TokenHash)
+	})
+
+	t.Run("CreateServiceToken_UnknownScope", func(t *testing.T) {
+		h := &handlers.Handlers{Repository: repomocks.NewMockRepository()}
+
+		resp := serve(h, "POST", "/service-tokens", `{"name":"docs-sync","scopes":["admin"]}`)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("CreateServiceToken_PastExpiry", func(t *testing.T) {
+		h := &handlers.Handlers{Repository: repomocks.NewMockRepository()}
+
+		resp := serve(h, "POST", "/service-tokens", `{"name":"docs-sync","scopes":["query"],"expires_at":"2020-01-01T00:00:00Z"}`)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("CreateServiceToken_Conflict", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("CreateServiceToken", mock.Anything, mock.AnythingOfType("*models.ServiceToken")).Return(false, nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := serve(h, "POST", "/service-tokens", `{"name":"docs-sync","scopes":["query"]}`)
+
+		assert.Equal(t, http.StatusConflict, resp.Code)
+	})
+
+	t.Run("RotateServiceToken_Success", func(t *testing.T) {
+		expiresAt := time.Now().Add(time.Hour)
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetServiceToken", mock.Anything, "t-1").Return(&models.ServiceToken{
+			ID: "t-1", Name: "docs-sync", TokenHash: "old", Scopes: []string{models.ScopeQuery}, ExpiresAt: &expiresAt,
+		}, nil)
+		mockRepo.On("RotateServiceToken", mock.Anything, mock.MatchedBy(func(token *models.ServiceToken) bool {
+			return token.TokenHash != "old" && token.RotatedAt != nil && token.ExpiresAt.Equal(expiresAt)
+		})).Return(nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := serve(h, "POST", "/service-tokens/t-1/rotate", "")
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), middleware.ServiceTokenPrefix)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("RotateServiceToken_NotFound", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetServiceToken", mock.Anything, "missing").Return(nil, nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := serve(h, "POST", "/service-tokens/missing/rotate", "")
+
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+}
+
+func TestImpersonationHandlers(t *testing.T) {
+	serve := func(h *handlers.Handlers, method, path, body string) *httptest.ResponseRecorder {
+		router := setupTestRouter()
+		setUser := func(c *gin.Context) { c.Set("username", "admin") }
+		router.POST("/impersonations", setUser, h.CreateImpersonation)
+		router.GET("/impersonations", h.ListImpersonations)
+		router.GET("/impersonations/:id/requests", h.ListImpersonationRequests)
+		router.DELETE("/impersonations/:id", setUser, h.RevokeImpersonation)
+
+		req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+	tokens := services.NewImpersonationTokens(&config.ImpersonationConfig{SigningKey: "secret", TokenTTL: time.Hour})
+
+	t.Run("CreateImpersonation_Success", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("CreateImpersonationSession", mock.Anything, mock.MatchedBy(func(session *models.ImpersonationSession) bool {
+			return session.Impersonator == "admin" && session.Username == "alice" && session.Reason == "Ticket 123"
+		})).Return(nil)
+		h := &handlers.Handlers{Repository: mockRepo, Impersonation: tokens, AdminUsers: []string{"admin"}}
+
+		resp := serve(h, "POST", "/impersonations", `{"username":"alice","reason":"Ticket 123"}`)
+
+		assert.Equal(t, http.StatusCreated, resp.Code)
+		var token models.ImpersonationToken
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &token))
+		assert.True(t, strings.HasPrefix(token.Token, models.ImpersonationTokenPrefix))
+		assert.Equal(t, "alice", token.Session.Username)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("CreateImpersonation_Disabled", func(t *testing.T) {
+		h := &handlers.Handlers{Repository: repomocks.NewMockRepository()}
+
+		resp := serve(h, "POST", "/impersonations", `{"username":"alice","reason":"Ticket 123"}`)
+
+		assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+	})
+
+	t.Run("CreateImpersonation_MissingReason", func(t *testing.T) {
+		h := &handlers.Handlers{Repository: repomocks.NewMockRepository(), Impersonation: tokens}
+
+		resp := serve(h, "POST", "/impersonations", `{"username":"alice"}`)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("CreateImpersonation_Self", func(t *testing.T) {
+		h := &handlers.Handlers{Repository: repomocks.NewMockRepository(), Impersonation: tokens}
+
+		resp := serve(h, "POST", "/impersonations", `{"username":"admin","reason":"Ticket 123"}`)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("ListImpersonationRequests_Success", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetImpersonationSession", mock.Anything, "s-1").Return(&models.ImpersonationSession{ID: "s-1"}, nil)
+		mockRepo.On("ListImpersonationRequests", mock.Anything, "s-1").Return([]*models.ImpersonationRequest{
+			{SessionID: "s-1", Method: "GET", Path: "/api/v1/documents", Status: http.StatusOK},
+		}, nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := serve(h, "GET", "/impersonations/s-1/requests", "")
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"path":"/api/v1/documents"`)
+	})
+
+	t.Run("RevokeImpersonation_Success", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetImpersonationSession", mock.Anything, "s-1").Return(&models.ImpersonationSession{ID: "s-1"}, nil)
+		mockRepo.On("RevokeImpersonationSession", mock.Anything, "s-1", mock.Anything).Return(true, nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := serve(h, "DELETE", "/impersonations/s-1", "")
+
+		assert.Equal(t, http.StatusNoContent, resp.Code)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("RevokeImpersonation_NotFound", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetImpersonationSession", mock.Anything, "missing").Return(nil, nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := serve(h, "DELETE", "/impersonations/missing", "")
+
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+}
+
+func TestConnectorHandlers(t *testing.T) {
+	serve := func(h *handlers.Handlers, method, path, body string) *httptest.ResponseRecorder {
+		router := setupTestRouter()
+		setUser := func(c *gin.Context) { c.Set("username", "alice") }
+		router.POST("/connectors", setUser, h.CreateConnector)
+		router.GET("/connectors/:id", setUser, h.GetConnector)
+		router.POST("/connectors/:id/sync", setUser, h.SyncConnector)
+		router.GET("/internal/connectors/:id/credentials", h.GetConnectorCredentials)
+		router.POST("/internal/connectors/:id/files", h.SyncConnectorFile)
+
+		req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+	createBody := `{"provider":"google_drive","name":"Handbooks","folder_ids":["f-1"],"credentials":{"access_token":"a","refresh_token":"r"}}`
+
+	t.Run("CreateConnector_Disabled", func(t *testing.T) {
+		h := &handlers.Handlers{Repository: repomocks.NewMockRepository()}
+
+		resp := serve(h, "POST", "/connectors", createBody)
+
+		assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+	})
+
+	t.Run("CreateConnector_Success", func(t *testing.T) {
+		connectors := mocks.NewMockConnectorService()
+		connectors.On("Create", mock.Anything, "alice", mock.MatchedBy(func(req models.CreateConnectorRequest) bool {
+			return req.Provider == models.ConnectorProviderGoogleDrive && req.Credentials.RefreshToken == "r"
+		})).Return(&models.Connector{ID: "c-1", Username: "alice", Credentials: []byte("sealed")}, nil)
+		h := &handlers.Handlers{Repository: repomocks.NewMockRepository(), Connectors: connectors}
+
+		resp := serve(h, "POST", "/connectors", createBody)
+
+		assert.Equal(t, http.StatusCreated, resp.Code)
+		assert.NotContains(t, resp.Body.String(), "credentials")
+		connectors.AssertExpectations(t)
+	})
+
+	t.Run("CreateConnector_UnknownProvider", func(t *testing.T) {
+		h := &handlers.Handlers{Repository: repomocks.NewMockRepository(), Connectors: mocks.NewMockConnectorService()}
+
+		resp := serve(h, "POST", "/connectors", `{"provider":"dropbox","name":"x","folder_ids":["f-1"],"credentials":{"refresh_token":"r"}}`)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("CreateConnector_MissingRefreshToken", func(t *testing.T) {
+		h := &handlers.Handlers{Repository: repomocks.NewMockRepository(), Connectors: mocks.NewMockConnectorService()}
+
+		resp := serve(h, "POST", "/connectors", `{"provider":"google_drive","name":"x","folder_ids":["f-1"],"credentials":{"access_token":"a"}}`)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("GetConnector_OtherUser", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetConnector", mock.Anything, "c-1").Return(&models.Connector{ID: "c-1", Username: "bob"}, nil)
+		h := &handlers.Handlers{Repository: mockRepo, Connectors: mocks.NewMockConnectorService()}
+
+		resp := serve(h, "GET", "/connectors/c-1", "")
+
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+
+	t.Run("SyncConnector_Success", func(t *testing.T) {
+		connector := &models.Connector{ID: "c-1", Username: "alice"}
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetConnector", mock.Anything, "c-1").Return(connector, nil)
+		connectors := mocks.NewMockConnectorService()
+		connectors.On("SyncNow", mock.Anything, connector).Return(nil)
+		h := &handlers.Handlers{Repository: mockRepo, Connectors: connectors}
+
+		resp := serve(h, "POST", "/connectors/c-1/sync", "")
+
+		assert.Equal(t, http.StatusAccepted, resp.Code)
+		connectors.AssertExpectations(t)
+	})
+
+	t.Run("GetConnectorCredentials_Success", func(t *testing.T) {
+		connector := &models.Connector{ID: "c-1", Username: "bob"}
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetConnector", mock.Anything, "c-1").Return(connector, nil)
+		connectors := mocks.NewMockConnectorService()
+		connectors.On("Credentials", connector).Return(&models.ConnectorCredentials{AccessToken: "a", RefreshToken: "r"}, nil)
+		h := &handlers.Handlers{Repository: mockRepo, Connectors: connectors}
+
+		resp := serve(h, "GET", "/internal/connectors/c-1/credentials", "")
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		var credentials models.ConnectorCredentials
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &credentials))
+		assert.Equal(t, "r", credentials.RefreshToken)
+	})
+
+	t.Run("SyncConnectorFile_Unchanged", func(t *testing.T) {
+		modifiedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetConnector", mock.Anything, "c-1").Return(&models.Connector{ID: "c-1", Username: "alice"}, nil)
+		mockRepo.On("GetConnectorFile", mock.Anything, "c-1", "file-1").Return(&models.ConnectorFile{
+			ConnectorID: "c-1", ExternalID: "file-1", DocumentID: "doc-1", ModifiedAt: modifiedAt,
+		}, nil)
+		h := &handlers.Handlers{Repository: mockRepo, Connectors: mocks.NewMockConnectorService()}
+
+		resp := serve(h, "POST", "/internal/connectors/c-1/files", `{"external_id":"file-1","filename":"guide.pdf","modified_at":"2026-01-02T03:04:05Z"}`)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.JSONEq(t, `{"unchanged":true}`, resp.Body.String())
+	})
+}
+
+func TestResyncScheduleHandlers(t *testing.T) {
+	serve := func(h *handlers.Handlers, method, path, body string) *httptest.ResponseRecorder {
+		router := setupTestRouter()
+		setUser := func(c *gin.Context) { c.Set("username", "alice") }
+		router.PUT("/documents/:id/resync-schedule", setUser, h.SetDocumentResyncSchedule)
+		router.GET("/documents/:id/resync-schedule", setUser, h.GetDocumentResyncSchedule)
+		router.PUT("/connectors/:id/resync-schedule", setUser, h.SetConnectorResyncSchedule)
+		router.DELETE("/connectors/:id/resync-schedule", setUser, h.DeleteConnectorResyncSchedule)
+		router.POST("/internal/documents/:id/resync", h.ResyncDocument)
+
+		req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Run("SetDocumentResyncSchedule_MissingCron", func(t *testing.T) {
+		h := &handlers.Handlers{Repository: repomocks.NewMockRepository()}
+
+		resp := serve(h, "PUT", "/documents/doc-1/resync-schedule", `{}`)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("SetDocumentResyncSchedule_InvalidCron", func(t *testing.T) {
+		h := &handlers.Handlers{Repository: repomocks.NewMockRepository()}
+
+		resp := serve(h, "PUT", "/documents/doc-1/resync-schedule", `{"cron":"every morning"}`)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		assert.Contains(t, resp.Body.String(), "Invalid cron expression")
+	})
+
+	t.Run("GetDocumentResyncSchedule_NotFound", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetResyncSchedule", mock.Anything, models.ResyncSourceDocument, "doc-1").Return(nil, nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := serve(h, "GET", "/documents/doc-1/resync-schedule", "")
+
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+
+	t.Run("SetConnectorResyncSchedule_OtherUser", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetConnector", mock.Anything, "c-1").Return(&models.Connector{ID: "c-1", Username: "bob"}, nil)
+		mockTemporalClient := mocks.NewMockTemporalClient()
+		h := &handlers.Handlers{Repository: mockRepo, Temporal: mockTemporalClient, Connectors: mocks.NewMockConnectorService()}
+
+		resp := serve(h, "PUT", "/connectors/c-1/resync-schedule", `{"cron":"@daily"}`)
+
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+		mockTemporalClient.AssertNotCalled(t, "ScheduleConnectorSync", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("DeleteConnectorResyncSchedule_Success", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetConnector", mock.Anything, "c-1").Return(&models.Connector{ID: "c-1", Username: "alice"}, nil)
+		mockRepo.On("DeleteResyncSchedule", mock.Anything, models.ResyncSourceConnector, "c-1").Return(nil)
+		mockTemporalClient := mocks.NewMockTemporalClient()
+		mockTemporalClient.On("DeleteResyncSchedule", mock.Anything, models.ResyncSourceConnector, "c-1").Return(nil)
+		h := &handlers.Handlers{Repository: mockRepo, Temporal: mockTemporalClient, Connectors: mocks.NewMockConnectorService()}
+
+		resp := serve(h, "DELETE", "/connectors/c-1/resync-schedule", "")
+
+		assert.Equal(t, http.StatusNoContent, resp.Code)
+		mockRepo.AssertExpectations(t)
+		mockTemporalClient.AssertExpectations(t)
+	})
+
+	t.Run("ResyncDocument_Unchanged", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetResyncSchedule", mock.Anything, models.ResyncSourceDocument, "doc-1").Return(&models.ResyncSchedule{
+			SourceType: models.ResyncSourceDocument, SourceID: "doc-1", ContentHash: "abc",
+		}, nil)
+		mockRepo.On("GetDocument", mock.Anything, "doc-1").Return(&models.Document{ID: "doc-1"}, nil)
+		mockRepo.On("RecordResyncCheck", mock.Anything, models.ResyncSourceDocument, "doc-1", "abc", mock.Anything).Return(nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := serve(h, "POST", "/internal/documents/doc-1/resync", `{"content_hash":"abc"}`)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.JSONEq(t, `{"changed":false}`, resp.Body.String())
+	})
+}
+
+func TestShadowTrafficHandler(t *testing.T) {
+	serve := func(h *handlers.Handlers) *httptest.ResponseRecorder {
+		router := setupTestRouter()
+		router.GET("/shadow-traffic", h.ShadowTraffic)
+
+		req, _ := http.NewRequest("GET", "/shadow-traffic", nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Run("ShadowTraffic_Success", func(t *testing.T) {
+		shadow := mocks.NewMockShadowMirror()
+		shadow.On("Stats").Return(models.ShadowStats{
+			Percent:        5,
+			Mirrored:       20,
+			Compared:       18,
+			ShadowSlower:   4,
+			SampleSize:     18,
+			PrimaryLatency: models.LatencySummary{MeanMS: 850, P50MS: 800, P95MS: 1400},
+			ShadowLatency:  models.LatencySummary{MeanMS: 720, P50MS: 700, P95MS: 1100},
+		})
+		h := &handlers.Handlers{Shadow: shadow}
+
+		resp := serve(h)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		var stats models.ShadowStats
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &stats))
+		assert.EqualValues(t, 18, stats.Compared)
+		assert.EqualValues(t, 1100, stats.ShadowLatency.P95MS)
+	})
+
+	t.Run("ShadowTraffic_Disabled", func(t *testing.T) {
+		resp := serve(&handlers.Handlers{})
+
+		assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+	})
+}
+
+func TestCoreBackendsHandler(t *testing.T) {
+	serve := func(h *handlers.Handlers) *httptest.ResponseRecorder {
+		router := setupTestRouter()
+		router.GET("/core-backends", h.CoreBackends)
+
+		req, _ := http.NewRequest("GET", "/core-backends", nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Run("CoreBackends_Success", func(t *testing.T) {
+		coreRouter := mocks.NewMockCoreRouter()
+		coreRouter.On("BackendStats").Return(models.CoreBackendStatsResponse{
+			Backends: []models.CoreBackendStats{
+				{Name: "stable", Weight: 95, Percent: 95, Queries: 190},
+				{Name: "canary", Weight: 5, Percent: 5, Queries: 10, Errors: 1},
+			},
+		})
+		h := &handlers.Handlers{CoreRouter: coreRouter}
+
+		resp := serve(h)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		var stats models.CoreBackendStatsResponse
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &stats))
+		if assert.Len(t, stats.Backends, 2) {
+			assert.EqualValues(t, 1, stats.Backends[1].Errors)
+		}
+	})
+
+	t.Run("CoreBackends_Disabled", func(t *testing.T) {
+		resp := serve(&handlers.Handlers{})
+
+		assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+	})
+}
+
+func TestRedactionRuleHandlers(t *testing.T) {
+	setUser := func(c *gin.Context) { c.Set("username", "admin") }
+
+	t.Run("CreateRedactionRule_DefaultPlaceholder", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("CreateRedactionRule", mock.Anything, mock.MatchedBy(func(rule *models.RedactionRule) bool {
+			return rule.Type == models.RedactionKeywords && rule.Placeholder == models.DefaultRedactionPlaceholder &&
+				rule.Enabled && rule.CreatedBy == "admin"
+		})).Return(nil)
+
+		h := &handlers.Handlers{Repository: mockRepo}
+		router := setupTestRouter()
+		router.POST("/admin/redaction-rules", setUser, h.CreateRedactionRule)
+
+		req, _ := http.NewRequest("POST", "/admin/redaction-rules", bytes.NewBufferString(`{"name":"Codenames","type":"keywords","keywords":["Falcon"]}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusCreated, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"placeholder":"[REDACTED]"`)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("CreateRedactionRule_InvalidPattern_Returns400", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+
+		h := &handlers.Handlers{Repository: mockRepo}
+		router := setupTestRouter()
+		router.POST("/admin/redaction-rules", setUser, h.CreateRedactionRule)
+
+		req, _ := http.NewRequest("POST", "/admin/redaction-rules", bytes.NewBufferString(`{"name":"IDs","type":"regex","pattern":"(ID-"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		assert.Contains(t, resp.Body.String(), "not a valid regular expression")
+		mockRepo.AssertNotCalled(t, "CreateRedactionRule", mock.Anything, mock.Anything)
+	})
+
+	t.Run("UpdateRedactionRule_Disable", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetRedactionRule", mock.Anything, "rr-1").Return(&models.RedactionRule{
+			ID: "rr-1", Name: "IDs", Type: models.RedactionRegex, Pattern: `ID-\d+`, Placeholder: "[ID]", Enabled: true,
+		}, nil)
+		mockRepo.On("UpdateRedactionRule", mock.Anything, mock.MatchedBy(func(rule *models.RedactionRule) bool {
+			return !rule.Enabled && rule.Pattern == `ID-\d+` && rule.UpdatedBy == "admin"
+		})).Return(true, nil)
+
+		h := &handlers.Handlers{Repository: mockRepo}
+		router := setupTestRouter()
+		router.PUT("/admin/redaction-rules/:id", setUser, h.UpdateRedactionRule)
+
+		req, _ := http.NewRequest("PUT", "/admin/redaction-rules/rr-1", bytes.NewBufferString(`{"enabled":false}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("UpdateRedactionRule_NotFound_Returns404", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetRedactionRule", mock.Anything, "missing").Return(nil, nil)
+
+		h := &handlers.Handlers{Repository: mockRepo}
+		router := setupTestRouter()
+		router.PUT("/admin/redaction-rules/:id", setUser, h.UpdateRedactionRule)
+
+		req, _ := http.NewRequest("PUT", "/admin/redaction-rules/missing", bytes.NewBufferString(`{"enabled":false}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+}
+
+func TestConversationParticipantHandlers(t *testing.T) {
+	serve := func(h *handlers.Handlers, setUser gin.HandlerFunc, method, path, body string) *httptest.ResponseRecorder {
+		router := setupTestRouter()
+		router.GET("/conversations/:id/participants", setUser, h.ListConversationParticipants)
+		router.POST("/conversations/:id/participants", setUser, h.InviteParticipant)
+		router.DELETE("/conversations/:id/participants/:username", setUser, h.RemoveParticipant)
+		router.POST("/conversations/:id/read", setUser, h.MarkConversationRead)
+		router.GET("/conversations/:id/stream", setUser, h.StreamConversation)
+
+		req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+	asAlice := func(c *gin.Context) { c.Set("username", "alice") }
+	conv := &models.Conversation{ID: "conv-1"}
+
+	t.Run("ListConversationParticipants_Success", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetConversation", mock.Anything, "conv-1").Return(conv, nil)
+		mockRepo.On("GetConversationParticipant", mock.Anything, "conv-1", "alice").Return(&models.ConversationParticipant{
+			ConversationID: "conv-1", Username: "alice",
+		}, nil)
+		mockRepo.On("ListConversationParticipants", mock.Anything, "conv-1").Return([]*models.ConversationParticipant{
+			{ConversationID: "conv-1", Username: "alice", UnreadCount: 3},
+		}, nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := serve(h, asAlice, "GET", "/conversations/conv-1/participants", "")
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"unread_count":3`)
+	})
+
+	t.Run("InviteParticipant_Success", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetConversation", mock.Anything, "conv-1").Return(conv, nil)
+		mockRepo.On("ListConversationParticipants", mock.Anything, "conv-1").Return([]*models.ConversationParticipant{
+			{ConversationID: "conv-1", Username: "alice"},
+		}, nil)
+		mockRepo.On("AddConversationParticipant", mock.Anything, mock.MatchedBy(func(p *models.ConversationParticipant) bool {
+			return p.Username == "bob" && p.InvitedBy == "alice"
+		})).Return(true, nil)
+		mockRepo.On("GetConversationParticipant", mock.Anything, "conv-1", "bob").Return(&models.ConversationParticipant{
+			ConversationID: "conv-1", Username: "bob", InvitedBy: "alice",
+		}, nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := serve(h, asAlice, "POST", "/conversations/conv-1/participants", `{"username":"bob"}`)
+
+		assert.Equal(t, http.StatusCreated, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"invited_by":"alice"`)
+	})
+
+	t.Run("ListConversationParticipants_NotParticipant_Returns403", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetConversation", mock.Anything, "conv-1").Return(conv, nil)
+		mockRepo.On("GetConversationParticipant", mock.Anything, "conv-1", "alice").Return(nil, nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := serve(h, asAlice, "GET", "/conversations/conv-1/participants", "")
+
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+		mockRepo.AssertNotCalled(t, "ListConversationParticipants", mock.Anything, mock.Anything)
+	})
+
+	t.Run("InviteParticipant_NotPublishedToEventStream", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetConversation", mock.Anything, "conv-1").Return(conv, nil)
+		mockRepo.On("ListConversationParticipants", mock.Anything, "conv-1").Return([]*models.ConversationParticipant{
+			{ConversationID: "conv-1", Username: "alice"},
+		}, nil)
+		mockRepo.On("AddConversationParticipant", mock.Anything, mock.Anything).Return(true, nil)
+		mockRepo.On("GetConversationParticipant", mock.Anything, "conv-1", "bob").Return(&models.ConversationParticipant{
+			ConversationID: "conv-1", Username: "bob", InvitedBy: "alice",
+		}, nil)
+		h := &handlers.Handlers{
+			Repository:         mockRepo,
+			Events:             services.NewEventHub(4),
+			ConversationEvents: services.NewEventHub(4),
+		}
+		public, cancelPublic := h.Events.Subscribe("conversation", "conversation:conv-1")
+		defer cancelPublic()
+		private, cancelPrivate := h.ConversationEvents.Subscribe("conversation:conv-1")
+		defer cancelPrivate()
+
+		resp := serve(h, asAlice, "POST", "/conversations/conv-1/participants", `{"username":"bob"}`)
+
+		assert.Equal(t, http.StatusCreated, resp.Code)
+		assert.Len(t, private, 1)
+		assert.Len(t, public, 0)
+	})
+
+	t.Run("InviteParticipant_MissingUsername", func(t *testing.T) {
+		h := &handlers.Handlers{Repository: repomocks.NewMockRepository()}
+
+		resp := serve(h, asAlice, "POST", "/conversations/conv-1/participants", `{}`)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("InviteParticipant_Impersonated_Returns403", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		h := &handlers.Handlers{Repository: mockRepo}
+		impersonated := func(c *gin.Context) {
+			c.Set("username", "alice")
+			c.Set("impersonator", "admin")
+		}
+
+		resp := serve(h, impersonated, "POST", "/conversations/conv-1/participants", `{"username":"bob"}`)
+
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+		mockRepo.AssertNotCalled(t, "AddConversationParticipant", mock.Anything, mock.Anything)
+	})
+
+	t.Run("RemoveParticipant_Other_Returns403", func(t *testing.T) {
+		h := &handlers.Handlers{Repository: repomocks.NewMockRepository()}
+
+		resp := serve(h, asAlice, "DELETE", "/conversations/conv-1/participants/bob", "")
+
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+	})
+
+	t.Run("RemoveParticipant_Self", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetConversation", mock.Anything, "conv-1").Return(conv, nil)
+		mockRepo.On("RemoveConversationParticipant", mock.Anything, "conv-1", "alice").Return(true, nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := serve(h, asAlice, "DELETE", "/conversations/conv-1/participants/alice", "")
+
+		assert.Equal(t, http.StatusNoContent, resp.Code)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("MarkConversationRead_NoBody", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetConversation", mock.Anything, "conv-1").Return(conv, nil)
+		mockRepo.On("MarkConversationRead", mock.Anything, "conv-1", "alice", mock.AnythingOfType("time.Time")).Return(true, nil)
+		mockRepo.On("GetConversationParticipant", mock.Anything, "conv-1", "alice").Return(&models.ConversationParticipant{
+			ConversationID: "conv-1", Username: "alice",
+		}, nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := serve(h, asAlice, "POST", "/conversations/conv-1/read", "")
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"unread_count":0`)
+	})
+
+	t.Run("MarkConversationRead_InvalidMessageID", func(t *testing.T) {
+		h := &handlers.Handlers{Repository: repomocks.NewMockRepository()}
+
+		resp := serve(h, asAlice, "POST", "/conversations/conv-1/read", `{"message_id":"msg-1"}`)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("StreamConversation_NotParticipant_Returns403", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetConversation", mock.Anything, "conv-1").Return(conv, nil)
+		mockRepo.On("GetConversationParticipant", mock.Anything, "conv-1", "alice").Return(nil, nil)
+		h := &handlers.Handlers{Repository: mockRepo, ConversationEvents: services.NewEventHub(1)}
+
+		resp := serve(h, asAlice, "GET", "/conversations/conv-1/stream", "")
+
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+	})
+
+	t.Run("StreamConversation_Disabled_Returns503", func(t *testing.T) {
+		h := &handlers.Handlers{Repository: repomocks.NewMockRepository(), Events: services.NewEventHub(1)}
+
+		resp := serve(h, asAlice, "GET", "/conversations/conv-1/stream", "")
+
+		assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+	})
+}
+
+func TestKnowledgeBaseExportHandlers(t *testing.T) {
+	serve := func(h *handlers.Handlers, method, path, body string) *httptest.ResponseRecorder {
+		router := setupTestRouter()
+		asAlice := func(c *gin.Context) { c.Set("username", "alice") }
+		router.POST("/export", asAlice, h.CreateKnowledgeBaseExport)
+		router.GET("/export", asAlice, h.ListKnowledgeBaseExports)
+		router.GET("/export/:id", asAlice, h.GetKnowledgeBaseExport)
+		router.GET("/export/:id/stream", asAlice, h.StreamKnowledgeBaseExport)
+		router.GET("/export/:id/download", asAlice, h.DownloadKnowledgeBaseExport)
+
+		req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+	const exportID = "9b2f3c1e-0d4a-4e8b-9c6f-2a7d5e1b3f40"
+
+	t.Run("CreateKnowledgeBaseExport_InvalidFormat", func(t *testing.T) {
+		h := &handlers.Handlers{Repository: repomocks.NewMockRepository()}
+
+		resp := serve(h, "POST", "/export", `{"format":"rar"}`)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("CreateKnowledgeBaseExport_WithoutTemporal", func(t *testing.T) {
+		h := &handlers.Handlers{Repository: repomocks.NewMockRepository(), S3Client: mocks.NewMockS3Client()}
+
+		resp := serve(h, "POST", "/export", `{"format":"zip","filter":{"tag":"hr"}}`)
+
+		assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+	})
+
+	t.Run("ListKnowledgeBaseExports_OwnOnly", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("ListKnowledgeBaseExports", mock.Anything, "alice", 50, 0).Return([]*models.KnowledgeBaseExport{
+			{ID: exportID, Status: models.KnowledgeBaseExportRunning, Format: models.ExportFormatZip, CreatedBy: "alice"},
+		}, 1, nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := serve(h, "GET", "/export", "")
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"total":1`)
+		assert.NotContains(t, resp.Body.String(), "archive_key")
+	})
+
+	t.Run("GetKnowledgeBaseExport_OtherUser", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetKnowledgeBaseExport", mock.Anything, exportID).Return(&models.KnowledgeBaseExport{ID: exportID, CreatedBy: "bob"}, nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := serve(h, "GET", "/export/"+exportID, "")
+
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+
+	t.Run("DownloadKnowledgeBaseExport_StreamsArchive", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetKnowledgeBaseExport", mock.Anything, exportID).Return(&models.KnowledgeBaseExport{
+			ID: exportID, Status: models.KnowledgeBaseExportReady, Format: models.ExportFormatTar,
+			ArchiveKey: "exports/" + exportID + "/knowledge-base.tar", SizeBytes: 7, CreatedBy: "alice",
+		}, nil)
+		mockS3 := mocks.NewMockS3Client()
+		mockS3.On("GetObject", mock.Anything, "exports/"+exportID+"/knowledge-base.tar").Return(io.NopCloser(strings.NewReader("archive")), nil)
+		h := &handlers.Handlers{Repository: mockRepo, S3Client: mockS3}
+
+		resp := serve(h, "GET", "/export/"+exportID+"/download", "")
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, "application/x-tar", resp.Header().Get("Content-Type"))
+		assert.Equal(t, `attachment; filename="knowledge-base-9b2f3c1e.tar"`, resp.Header().Get("Content-Disposition"))
+		assert.Equal(t, "archive", resp.Body.String())
+	})
+
+	t.Run("DownloadKnowledgeBaseExport_NotReady", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetKnowledgeBaseExport", mock.Anything, exportID).Return(&models.KnowledgeBaseExport{
+			ID: exportID, Status: models.KnowledgeBaseExportRunning, Format: models.ExportFormatZip, CreatedBy: "alice",
+		}, nil)
+		h := &handlers.Handlers{Repository: mockRepo, S3Client: mocks.NewMockS3Client()}
+
+		resp := serve(h, "GET", "/export/"+exportID+"/download", "")
+
+		assert.Equal(t, http.StatusConflict, resp.Code)
+	})
+
+	t.Run("StreamKnowledgeBaseExport_Finished", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetKnowledgeBaseExport", mock.Anything, exportID).Return(&models.KnowledgeBaseExport{
+			ID: exportID, Status: models.KnowledgeBaseExportReady, CreatedBy: "alice",
+		}, nil)
+		h := &handlers.Handlers{Repository: mockRepo, Events: services.NewEventHub(1)}
+
+		resp := serve(h, "GET", "/export/"+exportID+"/stream", "")
+
+		assert.Equal(t, http.StatusConflict, resp.Code)
+	})
+}
+
+func TestDocumentAccessHandlers(t *testing.T) {
+	serve := func(h *handlers.Handlers, body string) *httptest.ResponseRecorder {
+		router := setupTestRouter()
+		router.PUT("/admin/documents/:id/access-groups", func(c *gin.Context) { c.Set("username", "root") }, h.SetDocumentAccessGroups)
+
+		req, _ := http.NewRequest("PUT", "/admin/documents/doc-1/access-groups", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Run("SetDocumentAccessGroups_Success", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("SetDocumentAccessGroups", mock.Anything, "doc-1", []string{"hr"}).Return(true, nil)
+		mockRepo.On("GetDocument", mock.Anything, "doc-1").Return(&models.Document{ID: "doc-1", Filename: "salaries.xlsx", AccessGroups: []string{"hr"}}, nil)
+		mockRepo.On("CreateDocumentEvent", mock.Anything, mock.Anything).Return(nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := serve(h, `{"access_groups":["HR"]}`)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		var doc models.Document
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &doc))
+		assert.Equal(t, []string{"hr"}, doc.AccessGroups)
+	})
+
+	t.Run("SetDocumentAccessGroups_MissingGroups", func(t *testing.T) {
+		h := &handlers.Handlers{Repository: repomocks.NewMockRepository()}
+
+		resp := serve(h, `{}`)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("SetDocumentAccessGroups_NotFound", func(t *testing.T) {
+		mockRepo := repomocks.NewMockRepository()
+		mockRepo.On("GetDocument", mock.Anything, "doc-1").Return(nil, nil)
+		h := &handlers.Handlers{Repository: mockRepo}
+
+		resp := serve(h, `{"access_groups":[]}`)
+
+		assert.Equal(t, http.StatusNotFound, resp.Code)
 	})
 }
@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"time"
+
+	"kb-platform-gateway/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateImpersonation mints a token with which the calling admin acts as
+// another user, to reproduce issues only that user sees. Admins cannot be
+// impersonated, and every request made with the token is recorded.
+func (h *Handlers) CreateImpersonation(c *gin.Context) {
+	if h.Impersonation == nil {
+		writeError(c, featureUnavailable("Impersonation is not enabled"))
+		return
+	}
+
+	var req models.CreateImpersonationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "VALIDATION_ERROR",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	impersonator := c.GetString("username")
+	if req.Username == impersonator {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "VALIDATION_ERROR",
+				Message: "Cannot impersonate yourself",
+			},
+		})
+		return
+	}
+	if slices.Contains(h.AdminUsers, req.Username) {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "AUTHORIZATION_ERROR",
+				Message: "Admins cannot be impersonated",
+			},
+		})
+		return
+	}
+
+	token, err := h.Impersonation.Issue(impersonator, req.Username, req.Reason)
+	if err != nil {
+		h.Logger.Error().Err(err).Msg("Failed to mint impersonation token")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to create impersonation token",
+			},
+		})
+		return
+	}
+	if err := h.Repository.CreateImpersonationSession(c.Request.Context(), &token.Session); err != nil {
+		h.Logger.Error().Err(err).Msg("Failed to create impersonation session")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to create impersonation token",
+			},
+		})
+		return
+	}
+
+	h.Logger.Info().
+		Str("session_id", token.Session.ID).
+		Str("impersonator", impersonator).
+		Str("username", req.Username).
+		Str("reason", req.Reason).
+		Msg("Impersonation started")
+
+	c.JSON(http.StatusCreated, token)
+}
+
+func (h *Handlers) ListImpersonations(c *gin.Context) {
+	limit, offset := page(c)
+
+	sessions, total, err := h.Repository.ListImpersonationSessions(c.Request.Context(), limit, offset)
+	if err != nil {
+		h.Logger.Error().Err(err).Msg("Failed to list impersonation sessions")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to list impersonation sessions",
+			},
+		})
+		return
+	}
+
+	sessionList := make([]models.ImpersonationSession, len(sessions))
+	for i, session := range sessions {
+		sessionList[i] = *session
+	}
+
+	c.JSON(http.StatusOK, models.ImpersonationSessionListResponse{
+		Sessions: sessionList,
+		Total:    total,
+		Limit:    limit,
+		Offset:   offset,
+	})
+}
+
+// ListImpersonationRequests returns the audit log of an impersonation
+// session: every request made with its token, oldest first.
+func (h *Handlers) ListImpersonationRequests(c *gin.Context) {
+	session, ok := h.loadImpersonationSession(c)
+	if !ok {
+		return
+	}
+
+	requests, err := h.Repository.ListImpersonationRequests(c.Request.Context(), session.ID)
+	if err != nil {
+		h.Logger.Error().Err(err).Str("session_id", session.ID).Msg("Failed to list impersonated requests")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to list impersonated requests",
+			},
+		})
+		return
+	}
+
+	requestList := make([]models.ImpersonationRequest, len(requests))
+	for i, request := range requests {
+		requestList[i] = *request
+	}
+
+	c.JSON(http.StatusOK, models.ImpersonationRequestListResponse{
+		Requests: requestList,
+	})
+}
+
+// RevokeImpersonation ends an impersonation session before it expires. Its
+// token stops working immediately.
+func (h *Handlers) RevokeImpersonation(c *gin.Context) {
+	session, ok := h.loadImpersonationSession(c)
+	if !ok {
+		return
+	}
+
+	if _, err := h.Repository.RevokeImpersonationSession(c.Request.Context(), session.ID, time.Now()); err != nil {
+		h.Logger.Error().Err(err).Str("session_id", session.ID).Msg("Failed to revoke impersonation session")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to revoke impersonation session",
+			},
+		})
+		return
+	}
+
+	h.Logger.Info().
+		Str("session_id", session.ID).
+		Str("revoked_by", c.GetString("username")).
+		Msg("Impersonation revoked")
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handlers) loadImpersonationSession(c *gin.Context) (*models.ImpersonationSession, bool) {
+	sessionID := c.Param("id")
+	session, err := h.Repository.GetImpersonationSession(c.Request.Context(), sessionID)
+	if err != nil {
+		h.Logger.Error().Err(err).Str("session_id", sessionID).Msg("Failed to get impersonation session")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to get impersonation session",
+			},
+		})
+		return nil, false
+	}
+	if session == nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "NOT_FOUND",
+				Message: "Impersonation session not found",
+			},
+		})
+		return nil, false
+	}
+	return session, true
+}
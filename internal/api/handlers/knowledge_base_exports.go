@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"kb-platform-gateway/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// exportContentTypes are the content types of knowledge base export
+// archives by format.
+var exportContentTypes = map[string]string{
+	models.ExportFormatZip: "application/zip",
+	models.ExportFormatTar: "application/x-tar",
+}
+
+// CreateKnowledgeBaseExport starts exporting the documents matching the
+// request's filter. Its progress is streamed by StreamKnowledgeBaseExport
+// and the archive is downloaded with DownloadKnowledgeBaseExport once it is
+// ready.
+func (h *Handlers) CreateKnowledgeBaseExport(c *gin.Context) {
+	var req models.CreateKnowledgeBaseExportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "VALIDATION_ERROR",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	exp, err := h.Gateway().CreateKnowledgeBaseExport(c.Request.Context(), req, c.GetString("username"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, exp)
+}
+
+// ListKnowledgeBaseExports returns the caller's exports, newest first.
+func (h *Handlers) ListKnowledgeBaseExports(c *gin.Context) {
+	limit, offset := page(c)
+
+	exports, total, err := h.Repository.ListKnowledgeBaseExports(c.Request.Context(), c.GetString("username"), limit, offset)
+	if err != nil {
+		h.Logger.Error().Err(err).Msg("Failed to list knowledge base exports")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to list exports",
+			},
+		})
+		return
+	}
+
+	exportList := make([]models.KnowledgeBaseExport, len(exports))
+	for i, exp := range exports {
+		exportList[i] = *exp
+	}
+
+	c.JSON(http.StatusOK, models.KnowledgeBaseExportListResponse{
+		Exports: exportList,
+		Total:   total,
+		Limit:   limit,
+		Offset:  offset,
+	})
+}
+
+func (h *Handlers) GetKnowledgeBaseExport(c *gin.Context) {
+	exp, err := h.Gateway().GetKnowledgeBaseExport(c.Request.Context(), c.Param("id"), c.GetString("username"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, exp)
+}
+
+// StreamKnowledgeBaseExport streams the progress events of a running
+// export as SSE.
+func (h *Handlers) StreamKnowledgeBaseExport(c *gin.Context) {
+	if h.Events == nil {
+		writeError(c, featureUnavailable("Event streaming is not available"))
+		return
+	}
+
+	// Subscribe before checking the export, so an export finishing in
+	// between still sends its last event.
+	exportID := c.Param("id")
+	events, cancel := h.Events.Subscribe("export:" + exportID)
+	defer cancel()
+
+	exp, err := h.Gateway().GetKnowledgeBaseExport(c.Request.Context(), exportID, c.GetString("username"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	if exp.Status != models.KnowledgeBaseExportRunning {
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "CONFLICT",
+				Message: fmt.Sprintf("Export is %s", exp.Status),
+			},
+		})
+		return
+	}
+
+	h.streamEvents(c, events)
+}
+
+// DownloadKnowledgeBaseExport streams the archive of a ready export.
+func (h *Handlers) DownloadKnowledgeBaseExport(c *gin.Context) {
+	exp, body, err := h.Gateway().OpenKnowledgeBaseExport(c.Request.Context(), c.Param("id"), c.GetString("username"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	defer body.Close()
+
+	c.Header("Content-Type", exportContentTypes[exp.Format])
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="knowledge-base-%s.%s"`, exp.ID[:8], exp.Format))
+	if exp.SizeBytes > 0 {
+		c.Header("Content-Length", strconv.FormatInt(exp.SizeBytes, 10))
+	}
+	c.Status(http.StatusOK)
+	liftWriteDeadline(c)
+	if _, err := io.Copy(c.Writer, body); err != nil {
+		// The archive is already on the wire; the body is left truncated.
+		h.Logger.Error().Err(err).Str("export_id", exp.ID).Msg("Failed to stream export archive")
+		c.Abort()
+	}
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"fmt"
+
+	"kb-platform-gateway/internal/export"
+	"kb-platform-gateway/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExportDocuments streams every document matching the ListDocuments
+// filters as a JSON array, for exports too large to page through.
+func (h *Handlers) ExportDocuments(c *gin.Context) {
+	filter := models.DocumentFilter{
+		Status:       c.Query("status"),
+		Language:     c.Query("language"),
+		Metadata:     c.QueryMap("metadata"),
+		Tag:          c.Query("tag"),
+		CollectionID: c.Query("collection_id"),
+		Query:        c.Query("q"),
+	}
+
+	h.streamJSONArray(c, "documents.json", func(write func(interface{}) error) error {
+		return h.Gateway().ExportDocuments(c.Request.Context(), filter, func(doc *models.Document) error {
+			return write(doc)
+		})
+	})
+}
+
+// ExportConversationMessages streams every message of a conversation as a
+// JSON array.
+func (h *Handlers) ExportConversationMessages(c *gin.Context) {
+	conversationID := c.Param("id")
+
+	h.streamJSONArray(c, fmt.Sprintf("conversation-%s-messages.json", conversationID), func(write func(interface{}) error) error {
+		return h.Gateway().ExportConversationMessages(c.Request.Context(), conversationID, c.GetString("username"), func(msg *models.Message) error {
+			return write(msg)
+		})
+	})
+}
+
+// streamJSONArray responds with the values run writes as a JSON array
+// attachment, encoding each as it is read rather than collecting them.
+// Errors before the first value get an error response; later ones leave
+// the body truncated, as for query log exports.
+func (h *Handlers) streamJSONArray(c *gin.Context, filename string, run func(write func(interface{}) error) error) {
+	liftWriteDeadline(c)
+	c.Header("Content-Type", "application/json; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	writer := export.NewJSONArrayWriter(c.Writer)
+	err := run(writer.Write)
+	if err == nil {
+		err = writer.Close()
+	}
+	if err == nil {
+		return
+	}
+
+	if c.Writer.Written() {
+		// Rows are already on the wire; the body is left truncated.
+		h.Logger.Error().Err(err).Str("path", c.FullPath()).Msg("Failed to stream export")
+		c.Abort()
+		return
+	}
+	c.Header("Content-Disposition", "")
+	writeError(c, err)
+}
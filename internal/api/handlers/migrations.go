@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"kb-platform-gateway/internal/models"
+	"kb-platform-gateway/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateEmbeddingMigration starts re-indexing the knowledge base with a new
+// embedding model. Progress is polled with GetEmbeddingMigration.
+func (h *Handlers) CreateEmbeddingMigration(c *gin.Context) {
+	var req models.CreateEmbeddingMigrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "VALIDATION_ERROR",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	if h.Migrations == nil {
+		writeError(c, featureUnavailable("Embedding migrations are not available"))
+		return
+	}
+
+	migration, err := h.Migrations.Migrate(c.Request.Context(), req, c.GetString("username"))
+	if errors.Is(err, services.ErrMigrationInProgress) {
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "CONFLICT",
+				Message: "An embedding migration is already running",
+			},
+		})
+		return
+	}
+	if err != nil {
+		h.Logger.Error().Err(err).Str("embedding_model", req.EmbeddingModel).Msg("Failed to start embedding migration")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to start embedding migration",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, migration)
+}
+
+func (h *Handlers) ListEmbeddingMigrations(c *gin.Context) {
+	limit := 50
+	offset := 0
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
+			limit = l
+		}
+	}
+
+	if offsetStr := c.Query("offset"); offsetStr != "" {
+		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
+			offset = o
+		}
+	}
+
+	migrations, total, err := h.Repository.ListEmbeddingMigrations(c.Request.Context(), limit, offset)
+	if err != nil {
+		h.Logger.Error().Err(err).Msg("Failed to list embedding migrations")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to list embedding migrations",
+			},
+		})
+		return
+	}
+
+	migrationList := make([]models.EmbeddingMigration, len(migrations))
+	for i, migration := range migrations {
+		migrationList[i] = *migration
+	}
+
+	c.JSON(http.StatusOK, models.EmbeddingMigrationListResponse{
+		Migrations: migrationList,
+		Total:      total,
+		Limit:      limit,
+		Offset:     offset,
+	})
+}
+
+func (h *Handlers) GetEmbeddingMigration(c *gin.Context) {
+	migrationID := c.Param("id")
+
+	migration, err := h.Repository.GetEmbeddingMigration(c.Request.Context(), migrationID)
+	if err != nil {
+		h.Logger.Error().Err(err).Str("migration_id", migrationID).Msg("Failed to get embedding migration")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to get embedding migration",
+			},
+		})
+		return
+	}
+	if migration == nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "NOT_FOUND",
+				Message: "Embedding migration not found",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, migration)
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"kb-platform-gateway/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateMultipartUpload starts a multipart upload of a pending document's
+// file, for files too large for one presigned PUT.
+func (h *Handlers) CreateMultipartUpload(c *gin.Context) {
+	var req models.CreateMultipartUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "VALIDATION_ERROR",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	upload, err := h.Gateway().CreateMultipartUpload(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, upload)
+}
+
+// GetMultipartUpload returns a multipart upload with the parts recorded so
+// far.
+func (h *Handlers) GetMultipartUpload(c *gin.Context) {
+	upload, err := h.Gateway().GetMultipartUpload(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, upload)
+}
+
+// MultipartPartURLs presigns upload URLs for parts of a multipart upload.
+func (h *Handlers) MultipartPartURLs(c *gin.Context) {
+	var req models.MultipartPartURLsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "VALIDATION_ERROR",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	resp, err := h.Gateway().MultipartPartURLs(c.Request.Context(), c.Param("id"), req.PartNumbers)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// RecordMultipartPart records the ETag of an uploaded part.
+func (h *Handlers) RecordMultipartPart(c *gin.Context) {
+	partNumber, err := strconv.Atoi(c.Param("part"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "VALIDATION_ERROR",
+				Message: "part must be a part number",
+			},
+		})
+		return
+	}
+
+	var req models.RecordMultipartPartRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "VALIDATION_ERROR",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	part, err := h.Gateway().RecordMultipartPart(c.Request.Context(), c.Param("id"), partNumber, req.ETag)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, part)
+}
+
+// CompleteMultipartUpload assembles the uploaded parts and completes the
+// document's upload.
+func (h *Handlers) CompleteMultipartUpload(c *gin.Context) {
+	doc, err := h.Gateway().CompleteMultipartUpload(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, doc)
+}
+
+// AbortMultipartUpload abandons a multipart upload.
+func (h *Handlers) AbortMultipartUpload(c *gin.Context) {
+	if err := h.Gateway().AbortMultipartUpload(c.Request.Context(), c.Param("id")); err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
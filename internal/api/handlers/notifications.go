@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"kb-platform-gateway/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetNotificationPreferences returns the caller's email notification
+// settings, or the defaults if they have not saved any.
+func (h *Handlers) GetNotificationPreferences(c *gin.Context) {
+	prefs, ok := h.loadNotificationPreferences(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, prefs)
+}
+
+// UpdateNotificationPreferences changes the fields set in the request and
+// leaves the others as they were.
+func (h *Handlers) UpdateNotificationPreferences(c *gin.Context) {
+	var req models.UpdateNotificationPreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "VALIDATION_ERROR",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	prefs, ok := h.loadNotificationPreferences(c)
+	if !ok {
+		return
+	}
+
+	if req.Email != nil {
+		prefs.Email = *req.Email
+	}
+	if req.ImportFinished != nil {
+		prefs.ImportFinished = *req.ImportFinished
+	}
+	if req.IndexingFailed != nil {
+		prefs.IndexingFailed = *req.IndexingFailed
+	}
+	if req.ExportReady != nil {
+		prefs.ExportReady = *req.ExportReady
+	}
+	prefs.UpdatedAt = time.Now()
+
+	if err := h.Repository.UpsertNotificationPreferences(c.Request.Context(), prefs); err != nil {
+		h.Logger.Error().Err(err).Str("username", prefs.Username).Msg("Failed to save notification preferences")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to save notification preferences",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, prefs)
+}
+
+func (h *Handlers) loadNotificationPreferences(c *gin.Context) (*models.NotificationPreferences, bool) {
+	username := c.GetString("username")
+	prefs, err := h.Repository.GetNotificationPreferences(c.Request.Context(), username)
+	if err != nil {
+		h.Logger.Error().Err(err).Str("username", username).Msg("Failed to get notification preferences")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to get notification preferences",
+			},
+		})
+		return nil, false
+	}
+	if prefs == nil {
+		prefs = models.DefaultNotificationPreferences(username)
+	}
+	return prefs, true
+}
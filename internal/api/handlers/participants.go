@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"net/http"
+
+	"kb-platform-gateway/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handlers) ListConversationParticipants(c *gin.Context) {
+	participants, err := h.Gateway().ListConversationParticipants(c.Request.Context(), c.Param("id"), c.GetString("username"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	list := make([]models.ConversationParticipant, len(participants))
+	for i, participant := range participants {
+		list[i] = *participant
+	}
+
+	c.JSON(http.StatusOK, models.ConversationParticipantListResponse{
+		Participants: list,
+	})
+}
+
+// InviteParticipant adds a user to a conversation as the requesting user,
+// who must not be impersonated.
+func (h *Handlers) InviteParticipant(c *gin.Context) {
+	if c.GetString("impersonator") != "" {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "AUTHORIZATION_ERROR",
+				Message: "Not available while impersonating",
+			},
+		})
+		return
+	}
+
+	var req models.InviteParticipantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "VALIDATION_ERROR",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	participant, err := h.Gateway().InviteParticipant(c.Request.Context(), c.Param("id"), c.GetString("username"), req.Username)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, participant)
+}
+
+func (h *Handlers) RemoveParticipant(c *gin.Context) {
+	if err := h.Gateway().RemoveParticipant(c.Request.Context(), c.Param("id"), c.GetString("username"), c.Param("username")); err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// MarkConversationRead records how far the requesting participant has
+// read a conversation: up to the message named, or all of it.
+func (h *Handlers) MarkConversationRead(c *gin.Context) {
+	var req models.MarkConversationReadRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: models.ErrorDetail{
+					Code:    "VALIDATION_ERROR",
+					Message: "Invalid request format",
+				},
+			})
+			return
+		}
+	}
+
+	participant, err := h.Gateway().MarkConversationRead(c.Request.Context(), c.Param("id"), c.GetString("username"), req.MessageID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, participant)
+}
+
+// StreamConversation streams a conversation's new messages and
+// participant changes as SSE to one of its participants.
+func (h *Handlers) StreamConversation(c *gin.Context) {
+	if h.ConversationEvents == nil {
+		writeError(c, featureUnavailable("Event streaming is not available"))
+		return
+	}
+
+	conversationID := c.Param("id")
+	if err := h.Gateway().CheckParticipant(c.Request.Context(), conversationID, c.GetString("username")); err != nil {
+		writeError(c, err)
+		return
+	}
+
+	events, cancel := h.ConversationEvents.Subscribe("conversation:" + conversationID)
+	defer cancel()
+	h.streamEvents(c, events)
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"kb-platform-gateway/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// formProcessing reads the ocr, languages and extract_tables form fields
+// of an upload, nil if none is set. Languages may be repeated or comma
+// separated. It writes a 400 response and reports false if a flag is not
+// a boolean.
+func formProcessing(c *gin.Context) (*models.ProcessingOptions, bool) {
+	processing := &models.ProcessingOptions{}
+	for _, field := range []struct {
+		name string
+		dst  **bool
+	}{
+		{"ocr", &processing.OCR},
+		{"extract_tables", &processing.ExtractTables},
+	} {
+		value := c.PostForm(field.name)
+		if value == "" {
+			continue
+		}
+		flag, err := strconv.ParseBool(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: models.ErrorDetail{
+					Code:    "VALIDATION_ERROR",
+					Message: field.name + " must be true or false",
+				},
+			})
+			return nil, false
+		}
+		*field.dst = &flag
+	}
+
+	for _, value := range c.PostFormArray("languages") {
+		for _, language := range strings.Split(value, ",") {
+			if language = strings.TrimSpace(language); language != "" {
+				processing.Languages = append(processing.Languages, language)
+			}
+		}
+	}
+
+	if processing.OCR == nil && processing.ExtractTables == nil && len(processing.Languages) == 0 {
+		return nil, true
+	}
+	return processing, true
+}
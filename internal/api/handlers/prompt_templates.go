@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"kb-platform-gateway/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handlers) CreatePromptTemplate(c *gin.Context) {
+	var req models.CreatePromptTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "VALIDATION_ERROR",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	now := time.Now()
+	tmpl := &models.PromptTemplate{
+		ID:          generateUUID(),
+		Name:        req.Name,
+		Description: req.Description,
+		Version:     1,
+		Template:    req.Template,
+		CreatedBy:   c.GetString("username"),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	created, err := h.Repository.CreatePromptTemplate(c.Request.Context(), tmpl)
+	if err != nil {
+		h.Logger.Error().Err(err).Msg("Failed to create prompt template")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to create prompt template",
+			},
+		})
+		return
+	}
+	if !created {
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "CONFLICT",
+				Message: "A prompt template with this name already exists",
+				Details: map[string]string{"name": req.Name},
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, tmpl)
+}
+
+func (h *Handlers) ListPromptTemplates(c *gin.Context) {
+	limit := 50
+	offset := 0
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
+			limit = l
+		}
+	}
+
+	if offsetStr := c.Query("offset"); offsetStr != "" {
+		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
+			offset = o
+		}
+	}
+
+	templates, total, err := h.Repository.ListPromptTemplates(c.Request.Context(), limit, offset)
+	if err != nil {
+		h.Logger.Error().Err(err).Msg("Failed to list prompt templates")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to list prompt templates",
+			},
+		})
+		return
+	}
+
+	templateList := make([]models.PromptTemplate, len(templates))
+	for i, tmpl := range templates {
+		templateList[i] = *tmpl
+	}
+
+	c.JSON(http.StatusOK, models.PromptTemplateListResponse{
+		Templates: templateList,
+		Total:     total,
+		Limit:     limit,
+		Offset:    offset,
+	})
+}
+
+// GetPromptTemplate returns the latest version of a template, or the one
+// selected with ?version=.
+func (h *Handlers) GetPromptTemplate(c *gin.Context) {
+	version := 0
+	if v := c.Query("version"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: models.ErrorDetail{
+					Code:    "VALIDATION_ERROR",
+					Message: "version must be a positive integer",
+				},
+			})
+			return
+		}
+		version = n
+	}
+
+	tmpl, ok := h.loadPromptTemplate(c, version)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, tmpl)
+}
+
+// UpdatePromptTemplate stores the template as a new version. Earlier
+// versions stay available to queries that pin them.
+func (h *Handlers) UpdatePromptTemplate(c *gin.Context) {
+	var req models.UpdatePromptTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "VALIDATION_ERROR",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	tmpl, ok := h.loadPromptTemplate(c, 0)
+	if !ok {
+		return
+	}
+
+	version := &models.PromptTemplateVersion{
+		Template:  req.Template,
+		CreatedBy: c.GetString("username"),
+		CreatedAt: time.Now(),
+	}
+	found, err := h.Repository.AddPromptTemplateVersion(c.Request.Context(), tmpl.ID, version, req.Description)
+	if err != nil {
+		h.Logger.Error().Err(err).Str("prompt_template_id", tmpl.ID).Msg("Failed to update prompt template")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to update prompt template",
+			},
+		})
+		return
+	}
+	if !found {
+		promptTemplateNotFound(c)
+		return
+	}
+
+	tmpl.Version = version.Version
+	tmpl.Template = version.Template
+	tmpl.UpdatedAt = version.CreatedAt
+	if req.Description != nil {
+		tmpl.Description = *req.Description
+	}
+
+	c.JSON(http.StatusOK, tmpl)
+}
+
+func (h *Handlers) DeletePromptTemplate(c *gin.Context) {
+	templateID := c.Param("id")
+
+	if err := h.Repository.DeletePromptTemplate(c.Request.Context(), templateID); err != nil {
+		h.Logger.Error().Err(err).Str("prompt_template_id", templateID).Msg("Failed to delete prompt template")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to delete prompt template",
+			},
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handlers) ListPromptTemplateVersions(c *gin.Context) {
+	tmpl, ok := h.loadPromptTemplate(c, 0)
+	if !ok {
+		return
+	}
+
+	versions, err := h.Repository.ListPromptTemplateVersions(c.Request.Context(), tmpl.ID)
+	if err != nil {
+		h.Logger.Error().Err(err).Str("prompt_template_id", tmpl.ID).Msg("Failed to list prompt template versions")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to list prompt template versions",
+			},
+		})
+		return
+	}
+
+	versionList := make([]models.PromptTemplateVersion, len(versions))
+	for i, version := range versions {
+		versionList[i] = *version
+	}
+
+	c.JSON(http.StatusOK, models.PromptTemplateVersionListResponse{
+		Versions: versionList,
+	})
+}
+
+func (h *Handlers) loadPromptTemplate(c *gin.Context, version int) (*models.PromptTemplate, bool) {
+	templateID := c.Param("id")
+	tmpl, err := h.Repository.GetPromptTemplate(c.Request.Context(), templateID, version)
+	if err != nil {
+		h.Logger.Error().Err(err).Str("prompt_template_id", templateID).Msg("Failed to get prompt template")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to get prompt template",
+			},
+		})
+		return nil, false
+	}
+	if tmpl == nil {
+		promptTemplateNotFound(c)
+		return nil, false
+	}
+	return tmpl, true
+}
+
+func promptTemplateNotFound(c *gin.Context) {
+	c.JSON(http.StatusNotFound, models.ErrorResponse{
+		Error: models.ErrorDetail{
+			Code:    "NOT_FOUND",
+			Message: "Prompt template not found",
+		},
+	})
+}
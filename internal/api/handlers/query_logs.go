@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"kb-platform-gateway/internal/export"
+	"kb-platform-gateway/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// defaultExportWindow is the time range exported when from is omitted.
+	defaultExportWindow = 30 * 24 * time.Hour
+
+	// exportURLExpiry is how long the download link of an S3 export stays
+	// valid.
+	exportURLExpiry = time.Hour
+)
+
+// QueryFeedback records the caller's rating of one of their queries. The
+// query ID is the id of the query's start and end events.
+func (h *Handlers) QueryFeedback(c *gin.Context) {
+	var req models.QueryFeedbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "VALIDATION_ERROR",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	found, err := h.Repository.SetQueryFeedback(c.Request.Context(), c.Param("id"), c.GetString("username"), req.Rating, req.Comment)
+	if err != nil {
+		h.Logger.Error().Err(err).Str("query_id", c.Param("id")).Msg("Failed to record query feedback")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to record feedback",
+			},
+		})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "NOT_FOUND",
+				Message: "Query not found",
+			},
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ExportQueryLogs exports the query history in [from, to) as CSV or
+// Parquet, either streamed in the response or uploaded to S3.
+func (h *Handlers) ExportQueryLogs(c *gin.Context) {
+	format := c.DefaultQuery("format", models.ExportFormatCSV)
+	destination := c.DefaultQuery("destination", models.ExportDestinationResponse)
+	if format != models.ExportFormatCSV && format != models.ExportFormatParquet {
+		exportValidationError(c, "format must be csv or parquet")
+		return
+	}
+	if destination != models.ExportDestinationResponse && destination != models.ExportDestinationS3 {
+		exportValidationError(c, "destination must be response or s3")
+		return
+	}
+
+	to := time.Now().UTC()
+	if v := c.Query("to"); v != "" {
+		t, err := parseExportTime(v)
+		if err != nil {
+			exportValidationError(c, "to must be an RFC 3339 timestamp or YYYY-MM-DD date")
+			return
+		}
+		to = t
+	}
+	from := to.Add(-defaultExportWindow)
+	if v := c.Query("from"); v != "" {
+		t, err := parseExportTime(v)
+		if err != nil {
+			exportValidationError(c, "from must be an RFC 3339 timestamp or YYYY-MM-DD date")
+			return
+		}
+		from = t
+	}
+	if !from.Before(to) {
+		exportValidationError(c, "from must be before to")
+		return
+	}
+
+	filename := fmt.Sprintf("query-logs-%s-%s.%s", from.Format("20060102"), to.Format("20060102"), format)
+	if destination == models.ExportDestinationS3 {
+		h.exportQueryLogsToS3(c, format, filename, from, to)
+		return
+	}
+
+	liftWriteDeadline(c)
+	c.Header("Content-Type", export.ContentType(format))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	if _, err := h.writeQueryLogs(c, format, c.Writer, from, to); err != nil {
+		h.Logger.Error().Err(err).Msg("Failed to export query logs")
+		if c.Writer.Written() {
+			// Rows are already on the wire; the body is left truncated.
+			c.Abort()
+			return
+		}
+		c.Header("Content-Type", "")
+		c.Header("Content-Disposition", "")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to export query logs",
+			},
+		})
+	}
+}
+
+// exportQueryLogsToS3 spools the export to a temporary file, uploads it
+// and responds with a presigned download link.
+func (h *Handlers) exportQueryLogsToS3(c *gin.Context, format, filename string, from, to time.Time) {
+	ctx := c.Request.Context()
+
+	fail := func(err error) {
+		h.Logger.Error().Err(err).Msg("Failed to export query logs to S3")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to export query logs",
+			},
+		})
+	}
+
+	file, err := os.CreateTemp("", "query-logs-*."+format)
+	if err != nil {
+		fail(err)
+		return
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	rows, err := h.writeQueryLogs(c, format, file, from, to)
+	if err != nil {
+		fail(err)
+		return
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		fail(err)
+		return
+	}
+
+	key := h.S3Client.ObjectKey(fmt.Sprintf("exports/%s/%s", generateUUID(), filename))
+	if err := h.S3Client.UploadObject(ctx, key, file, export.ContentType(format)); err != nil {
+		fail(err)
+		return
+	}
+
+	url, err := h.S3Client.GeneratePresignedDownloadURL(ctx, key, exportURLExpiry)
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	resp := models.ExportResponse{
+		Key:       key,
+		URL:       url,
+		Format:    format,
+		Rows:      rows,
+		ExpiresAt: time.Now().Add(exportURLExpiry),
+	}
+	if h.Notifications != nil {
+		h.Notifications.ExportReady(ctx, c.GetString("username"), &resp)
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handlers) writeQueryLogs(c *gin.Context, format string, w io.Writer, from, to time.Time) (int, error) {
+	writer, err := export.NewQueryLogWriter(format, w)
+	if err != nil {
+		return 0, err
+	}
+
+	rows := 0
+	err = h.Repository.ExportQueryLogs(c.Request.Context(), from, to, func(log *models.QueryLog) error {
+		rows++
+		return writer.Write(log)
+	})
+	if err != nil {
+		return rows, err
+	}
+
+	return rows, writer.Close()
+}
+
+func parseExportTime(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t.UTC(), nil
+	}
+	return time.Parse("2006-01-02", v)
+}
+
+func exportValidationError(c *gin.Context, message string) {
+	c.JSON(http.StatusBadRequest, models.ErrorResponse{
+		Error: models.ErrorDetail{
+			Code:    "VALIDATION_ERROR",
+			Message: message,
+		},
+	})
+}
@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"kb-platform-gateway/internal/models"
+	"kb-platform-gateway/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// validRedactionRule reports whether rule can be applied, and writes a
+// validation error if not.
+func validRedactionRule(c *gin.Context, rule *models.RedactionRule) bool {
+	if err := services.ValidateRedactionRule(rule); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "VALIDATION_ERROR",
+				Message: err.Error(),
+			},
+		})
+		return false
+	}
+	return true
+}
+
+func (h *Handlers) CreateRedactionRule(c *gin.Context) {
+	var req models.CreateRedactionRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "VALIDATION_ERROR",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	now := time.Now()
+	username := c.GetString("username")
+	rule := &models.RedactionRule{
+		ID:          generateUUID(),
+		Name:        req.Name,
+		Type:        req.Type,
+		Pattern:     req.Pattern,
+		Keywords:    req.Keywords,
+		Placeholder: req.Placeholder,
+		Enabled:     req.Enabled == nil || *req.Enabled,
+		CreatedBy:   username,
+		UpdatedBy:   username,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if rule.Placeholder == "" {
+		rule.Placeholder = models.DefaultRedactionPlaceholder
+	}
+	if !validRedactionRule(c, rule) {
+		return
+	}
+
+	if err := h.Repository.CreateRedactionRule(c.Request.Context(), rule); err != nil {
+		h.Logger.Error().Err(err).Msg("Failed to create redaction rule")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to create redaction rule",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+func (h *Handlers) ListRedactionRules(c *gin.Context) {
+	limit, offset := page(c)
+
+	rules, total, err := h.Repository.ListRedactionRules(c.Request.Context(), limit, offset)
+	if err != nil {
+		h.Logger.Error().Err(err).Msg("Failed to list redaction rules")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to list redaction rules",
+			},
+		})
+		return
+	}
+
+	ruleList := make([]models.RedactionRule, len(rules))
+	for i, rule := range rules {
+		ruleList[i] = *rule
+	}
+
+	c.JSON(http.StatusOK, models.RedactionRuleListResponse{
+		Rules:  ruleList,
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	})
+}
+
+func (h *Handlers) GetRedactionRule(c *gin.Context) {
+	rule, ok := h.loadRedactionRule(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// UpdateRedactionRule changes the set fields of a redaction rule, e.g.
+// {"enabled": false} to stop applying it.
+func (h *Handlers) UpdateRedactionRule(c *gin.Context) {
+	var req models.UpdateRedactionRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "VALIDATION_ERROR",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	rule, ok := h.loadRedactionRule(c)
+	if !ok {
+		return
+	}
+
+	if req.Name != nil {
+		rule.Name = *req.Name
+	}
+	if req.Type != nil {
+		rule.Type = *req.Type
+	}
+	if req.Pattern != nil {
+		rule.Pattern = *req.Pattern
+	}
+	if req.Keywords != nil {
+		rule.Keywords = *req.Keywords
+	}
+	if req.Placeholder != nil {
+		rule.Placeholder = *req.Placeholder
+		if rule.Placeholder == "" {
+			rule.Placeholder = models.DefaultRedactionPlaceholder
+		}
+	}
+	if req.Enabled != nil {
+		rule.Enabled = *req.Enabled
+	}
+	if !validRedactionRule(c, rule) {
+		return
+	}
+	rule.UpdatedBy = c.GetString("username")
+	rule.UpdatedAt = time.Now()
+
+	found, err := h.Repository.UpdateRedactionRule(c.Request.Context(), rule)
+	if err != nil {
+		h.Logger.Error().Err(err).Str("redaction_rule_id", rule.ID).Msg("Failed to update redaction rule")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to update redaction rule",
+			},
+		})
+		return
+	}
+	if !found {
+		redactionRuleNotFound(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+func (h *Handlers) DeleteRedactionRule(c *gin.Context) {
+	ruleID := c.Param("id")
+
+	if err := h.Repository.DeleteRedactionRule(c.Request.Context(), ruleID); err != nil {
+		h.Logger.Error().Err(err).Str("redaction_rule_id", ruleID).Msg("Failed to delete redaction rule")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to delete redaction rule",
+			},
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// loadRedactionRule fetches the redaction rule named by the id parameter,
+// writing an error response if it cannot.
+func (h *Handlers) loadRedactionRule(c *gin.Context) (*models.RedactionRule, bool) {
+	ruleID := c.Param("id")
+
+	rule, err := h.Repository.GetRedactionRule(c.Request.Context(), ruleID)
+	if err != nil {
+		h.Logger.Error().Err(err).Str("redaction_rule_id", ruleID).Msg("Failed to get redaction rule")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to get redaction rule",
+			},
+		})
+		return nil, false
+	}
+	if rule == nil {
+		redactionRuleNotFound(c)
+		return nil, false
+	}
+
+	return rule, true
+}
+
+func redactionRuleNotFound(c *gin.Context) {
+	c.JSON(http.StatusNotFound, models.ErrorResponse{
+		Error: models.ErrorDetail{
+			Code:    "NOT_FOUND",
+			Message: "Redaction rule not found",
+		},
+	})
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"net/http"
+
+	"kb-platform-gateway/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bindResyncSchedule reads a SetResyncScheduleRequest, writing a
+// validation error on failure.
+func bindResyncSchedule(c *gin.Context) (models.SetResyncScheduleRequest, bool) {
+	var req models.SetResyncScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "VALIDATION_ERROR",
+				Message: "Invalid request format",
+			},
+		})
+		return req, false
+	}
+	return req, true
+}
+
+func (h *Handlers) GetDocumentResyncSchedule(c *gin.Context) {
+	schedule, err := h.Gateway().GetResyncSchedule(c.Request.Context(), models.ResyncSourceDocument, c.Param("id"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, schedule)
+}
+
+// SetDocumentResyncSchedule re-syncs a URL-imported document from its
+// source on a cron schedule.
+func (h *Handlers) SetDocumentResyncSchedule(c *gin.Context) {
+	req, ok := bindResyncSchedule(c)
+	if !ok {
+		return
+	}
+
+	schedule, err := h.Gateway().SetDocumentResyncSchedule(c.Request.Context(), c.Param("id"), req.Cron, c.GetString("username"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, schedule)
+}
+
+func (h *Handlers) DeleteDocumentResyncSchedule(c *gin.Context) {
+	if err := h.Gateway().DeleteResyncSchedule(c.Request.Context(), models.ResyncSourceDocument, c.Param("id")); err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handlers) GetConnectorResyncSchedule(c *gin.Context) {
+	if !h.connectorsEnabled(c) {
+		return
+	}
+
+	connector := h.connector(c, false)
+	if connector == nil {
+		return
+	}
+
+	schedule, err := h.Gateway().GetResyncSchedule(c.Request.Context(), models.ResyncSourceConnector, connector.ID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, schedule)
+}
+
+// SetConnectorResyncSchedule syncs a connector on a cron schedule instead
+// of its sync interval.
+func (h *Handlers) SetConnectorResyncSchedule(c *gin.Context) {
+	if !h.connectorsEnabled(c) {
+		return
+	}
+
+	req, ok := bindResyncSchedule(c)
+	if !ok {
+		return
+	}
+
+	connector := h.connector(c, false)
+	if connector == nil {
+		return
+	}
+
+	schedule, err := h.Gateway().SetConnectorResyncSchedule(c.Request.Context(), connector, req.Cron, c.GetString("username"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, schedule)
+}
+
+// DeleteConnectorResyncSchedule returns a connector to syncing on its
+// interval.
+func (h *Handlers) DeleteConnectorResyncSchedule(c *gin.Context) {
+	if !h.connectorsEnabled(c) {
+		return
+	}
+
+	connector := h.connector(c, false)
+	if connector == nil {
+		return
+	}
+
+	if err := h.Gateway().DeleteResyncSchedule(c.Request.Context(), models.ResyncSourceConnector, connector.ID); err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ResyncDocument tells the resync workflow whether a document's source has
+// changed since its last check, and where to upload it if so.
+func (h *Handlers) ResyncDocument(c *gin.Context) {
+	var req models.ResyncDocumentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "VALIDATION_ERROR",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	resp, err := h.Gateway().ResyncDocument(c.Request.Context(), c.Param("id"), req.ContentHash)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
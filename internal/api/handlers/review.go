@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"kb-platform-gateway/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ApproveDocument lets a document awaiting review be indexed.
+func (h *Handlers) ApproveDocument(c *gin.Context) {
+	h.reviewDocument(c, h.Gateway().ApproveDocument)
+}
+
+// RejectDocument keeps a document awaiting review from being indexed.
+func (h *Handlers) RejectDocument(c *gin.Context) {
+	h.reviewDocument(c, h.Gateway().RejectDocument)
+}
+
+// reviewDocument binds the optional review comment and calls review as the
+// requesting user, who must not be impersonated.
+func (h *Handlers) reviewDocument(c *gin.Context, review func(ctx context.Context, documentID, username, comment string) (*models.Document, error)) {
+	if c.GetString("impersonator") != "" {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "AUTHORIZATION_ERROR",
+				Message: "Not available while impersonating",
+			},
+		})
+		return
+	}
+
+	var req models.ReviewDocumentRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: models.ErrorDetail{
+					Code:    "VALIDATION_ERROR",
+					Message: "Invalid request format",
+				},
+			})
+			return
+		}
+	}
+
+	doc, err := review(c.Request.Context(), c.Param("id"), c.GetString("username"), req.Comment)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, doc)
+}
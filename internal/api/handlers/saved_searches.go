@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"net/http"
+
+	"kb-platform-gateway/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handlers) CreateSavedSearch(c *gin.Context) {
+	req, ok := bindSavedSearch(c)
+	if !ok {
+		return
+	}
+
+	search, err := h.Gateway().CreateSavedSearch(c.Request.Context(), req, c.GetString("username"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, search)
+}
+
+func (h *Handlers) ListSavedSearches(c *gin.Context) {
+	limit, offset := page(c)
+
+	searches, total, err := h.Gateway().ListSavedSearches(c.Request.Context(), limit, offset)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	searchList := make([]models.SavedSearch, len(searches))
+	for i, search := range searches {
+		searchList[i] = *search
+	}
+
+	c.JSON(http.StatusOK, models.SavedSearchListResponse{
+		SavedSearches: searchList,
+		Total:         total,
+		Limit:         limit,
+		Offset:        offset,
+	})
+}
+
+func (h *Handlers) GetSavedSearch(c *gin.Context) {
+	search, err := h.Gateway().GetSavedSearch(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, search)
+}
+
+func (h *Handlers) UpdateSavedSearch(c *gin.Context) {
+	req, ok := bindSavedSearch(c)
+	if !ok {
+		return
+	}
+
+	search, err := h.Gateway().UpdateSavedSearch(c.Request.Context(), c.Param("id"), req, c.GetString("username"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, search)
+}
+
+func (h *Handlers) DeleteSavedSearch(c *gin.Context) {
+	if err := h.Gateway().DeleteSavedSearch(c.Request.Context(), c.Param("id"), c.GetString("username")); err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RunSavedSearch lists the documents matching a saved search now.
+func (h *Handlers) RunSavedSearch(c *gin.Context) {
+	limit, offset := page(c)
+
+	documents, total, err := h.Gateway().RunSavedSearch(c.Request.Context(), c.Param("id"), limit, offset)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	docList := make([]models.Document, len(documents))
+	for i, doc := range documents {
+		docList[i] = *doc
+	}
+
+	c.JSON(http.StatusOK, models.DocumentListResponse{
+		Documents: docList,
+		Total:     total,
+		Limit:     limit,
+		Offset:    offset,
+	})
+}
+
+func bindSavedSearch(c *gin.Context) (models.SavedSearchRequest, bool) {
+	var req models.SavedSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "VALIDATION_ERROR",
+				Message: "Invalid request format",
+			},
+		})
+		return req, false
+	}
+	return req, true
+}
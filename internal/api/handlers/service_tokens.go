@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"slices"
+	"time"
+
+	"kb-platform-gateway/internal/api/middleware"
+	"kb-platform-gateway/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newServiceTokenSecret returns a fresh service token and the prefix shown
+// in listings to identify it.
+func newServiceTokenSecret() (token, prefix string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token = middleware.ServiceTokenPrefix + hex.EncodeToString(b)
+	return token, token[:len(middleware.ServiceTokenPrefix)+8], nil
+}
+
+// validExpiry reports whether expiresAt, if set, is in the future, and
+// writes a validation error if not.
+func validExpiry(c *gin.Context, expiresAt *time.Time) bool {
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "VALIDATION_ERROR",
+				Message: "expires_at must be in the future",
+			},
+		})
+		return false
+	}
+	return true
+}
+
+func (h *Handlers) CreateServiceToken(c *gin.Context) {
+	var req models.CreateServiceTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "VALIDATION_ERROR",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	for _, scope := range req.Scopes {
+		if !slices.Contains(models.ServiceTokenScopes, scope) {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: models.ErrorDetail{
+					Code:    "VALIDATION_ERROR",
+					Message: "Unknown scope",
+					Details: map[string]string{"scope": scope},
+				},
+			})
+			return
+		}
+	}
+	if !validExpiry(c, req.ExpiresAt) {
+		return
+	}
+
+	secret, prefix, err := newServiceTokenSecret()
+	if err != nil {
+		h.Logger.Error().Err(err).Msg("Failed to generate service token")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to create service token",
+			},
+		})
+		return
+	}
+
+	token := &models.ServiceToken{
+		ID:        generateUUID(),
+		Name:      req.Name,
+		TokenHash: middleware.HashServiceToken(secret),
+		Prefix:    prefix,
+		Scopes:    req.Scopes,
+		CreatedBy: c.GetString("username"),
+		CreatedAt: time.Now(),
+		ExpiresAt: req.ExpiresAt,
+	}
+
+	created, err := h.Repository.CreateServiceToken(c.Request.Context(), token)
+	if err != nil {
+		h.Logger.Error().Err(err).Msg("Failed to create service token")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to create service token",
+			},
+		})
+		return
+	}
+	if !created {
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "CONFLICT",
+				Message: "A service token with this name already exists",
+				Details: map[string]string{"name": req.Name},
+			},
+		})
+		return
+	}
+
+	token.Token = secret
+	c.JSON(http.StatusCreated, token)
+}
+
+func (h *Handlers) ListServiceTokens(c *gin.Context) {
+	tokens, err := h.Repository.ListServiceTokens(c.Request.Context())
+	if err != nil {
+		h.Logger.Error().Err(err).Msg("Failed to list service tokens")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to list service tokens",
+			},
+		})
+		return
+	}
+
+	tokenList := make([]models.ServiceToken, len(tokens))
+	for i, token := range tokens {
+		tokenList[i] = *token
+	}
+
+	c.JSON(http.StatusOK, models.ServiceTokenListResponse{
+		Tokens: tokenList,
+	})
+}
+
+// RotateServiceToken replaces a token's secret. The old secret stops
+// working immediately.
+func (h *Handlers) RotateServiceToken(c *gin.Context) {
+	tokenID := c.Param("id")
+
+	var req models.RotateServiceTokenRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: models.ErrorDetail{
+					Code:    "VALIDATION_ERROR",
+					Message: "Invalid request format",
+				},
+			})
+			return
+		}
+	}
+	if !validExpiry(c, req.ExpiresAt) {
+		return
+	}
+
+	token, err := h.Repository.GetServiceToken(c.Request.Context(), tokenID)
+	if err != nil {
+		h.Logger.Error().Err(err).Str("token_id", tokenID).Msg("Failed to get service token")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to get service token",
+			},
+		})
+		return
+	}
+	if token == nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "NOT_FOUND",
+				Message: "Service token not found",
+			},
+		})
+		return
+	}
+
+	secret, prefix, err := newServiceTokenSecret()
+	if err != nil {
+		h.Logger.Error().Err(err).Msg("Failed to generate service token")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to rotate service token",
+			},
+		})
+		return
+	}
+
+	now := time.Now()
+	token.TokenHash = middleware.HashServiceToken(secret)
+	token.Prefix = prefix
+	token.RotatedAt = &now
+	if req.ExpiresAt != nil {
+		token.ExpiresAt = req.ExpiresAt
+	}
+
+	if err := h.Repository.RotateServiceToken(c.Request.Context(), token); err != nil {
+		h.Logger.Error().Err(err).Str("token_id", tokenID).Msg("Failed to rotate service token")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to rotate service token",
+			},
+		})
+		return
+	}
+
+	token.Token = secret
+	c.JSON(http.StatusOK, token)
+}
+
+func (h *Handlers) DeleteServiceToken(c *gin.Context) {
+	tokenID := c.Param("id")
+
+	if err := h.Repository.DeleteServiceToken(c.Request.Context(), tokenID); err != nil {
+		h.Logger.Error().Err(err).Str("token_id", tokenID).Msg("Failed to delete service token")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to delete service token",
+			},
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
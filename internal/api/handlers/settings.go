@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"net/http"
+
+	"kb-platform-gateway/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetWorkspaceSettings returns the workspace's branding and defaults, which
+// the frontend reads when it loads.
+func (h *Handlers) GetWorkspaceSettings(c *gin.Context) {
+	settings, err := h.Gateway().WorkspaceSettings(c.Request.Context())
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// UpdateWorkspaceSettings changes the fields set in the request and leaves
+// the others as they were.
+func (h *Handlers) UpdateWorkspaceSettings(c *gin.Context) {
+	var req models.UpdateWorkspaceSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "VALIDATION_ERROR",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	settings, err := h.Gateway().UpdateWorkspaceSettings(c.Request.Context(), req, c.GetString("username"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
@@ -0,0 +1,18 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ShadowTraffic compares the latencies of queries mirrored to the shadow
+// core with the primary core's since the gateway started.
+func (h *Handlers) ShadowTraffic(c *gin.Context) {
+	if h.Shadow == nil {
+		writeError(c, featureUnavailable("Shadow traffic is not enabled"))
+		return
+	}
+
+	c.JSON(http.StatusOK, h.Shadow.Stats())
+}
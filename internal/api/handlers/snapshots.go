@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"net/http"
+
+	"kb-platform-gateway/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateSnapshot starts taking a snapshot of the knowledge base. Queries
+// can name it in as_of once GetSnapshot reports it ready.
+func (h *Handlers) CreateSnapshot(c *gin.Context) {
+	var req models.CreateSnapshotRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "VALIDATION_ERROR",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	snapshot, err := h.Gateway().CreateSnapshot(c.Request.Context(), req, c.GetString("username"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, snapshot)
+}
+
+func (h *Handlers) ListSnapshots(c *gin.Context) {
+	limit, offset := page(c)
+
+	snapshots, total, err := h.Repository.ListSnapshots(c.Request.Context(), limit, offset)
+	if err != nil {
+		h.Logger.Error().Err(err).Msg("Failed to list snapshots")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to list snapshots",
+			},
+		})
+		return
+	}
+
+	snapshotList := make([]models.Snapshot, len(snapshots))
+	for i, snapshot := range snapshots {
+		snapshotList[i] = *snapshot
+	}
+
+	c.JSON(http.StatusOK, models.SnapshotListResponse{
+		Snapshots: snapshotList,
+		Total:     total,
+		Limit:     limit,
+		Offset:    offset,
+	})
+}
+
+func (h *Handlers) GetSnapshot(c *gin.Context) {
+	snapshot, ok := h.loadSnapshot(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, snapshot)
+}
+
+// ListSnapshotDocuments returns the documents a snapshot holds, as they
+// were when it was taken.
+func (h *Handlers) ListSnapshotDocuments(c *gin.Context) {
+	snapshot, ok := h.loadSnapshot(c)
+	if !ok {
+		return
+	}
+	limit, offset := page(c)
+
+	documents, total, err := h.Repository.ListSnapshotDocuments(c.Request.Context(), snapshot.ID, limit, offset)
+	if err != nil {
+		h.Logger.Error().Err(err).Str("snapshot_id", snapshot.ID).Msg("Failed to list snapshot documents")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to list snapshot documents",
+			},
+		})
+		return
+	}
+
+	documentList := make([]models.SnapshotDocument, len(documents))
+	for i, doc := range documents {
+		documentList[i] = *doc
+	}
+
+	c.JSON(http.StatusOK, models.SnapshotDocumentListResponse{
+		Documents: documentList,
+		Total:     total,
+		Limit:     limit,
+		Offset:    offset,
+	})
+}
+
+// DeleteSnapshot deletes a snapshot and the vectors it kept.
+func (h *Handlers) DeleteSnapshot(c *gin.Context) {
+	if err := h.Gateway().DeleteSnapshot(c.Request.Context(), c.Param("id")); err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handlers) loadSnapshot(c *gin.Context) (*models.Snapshot, bool) {
+	snapshotID := c.Param("id")
+	snapshot, err := h.Repository.GetSnapshot(c.Request.Context(), snapshotID)
+	if err != nil {
+		h.Logger.Error().Err(err).Str("snapshot_id", snapshotID).Msg("Failed to get snapshot")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to get snapshot",
+			},
+		})
+		return nil, false
+	}
+	if snapshot == nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "NOT_FOUND",
+				Message: "Snapshot not found",
+			},
+		})
+		return nil, false
+	}
+	return snapshot, true
+}
@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"kb-platform-gateway/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultStatsDays = 7
+	maxStatsDays     = 90
+
+	// activeConversationWindow is how recently a conversation must have
+	// been updated to count as active.
+	activeConversationWindow = 24 * time.Hour
+
+	// dependencyCheckTimeout bounds each dependency probe so one slow
+	// dependency cannot stall the whole response.
+	dependencyCheckTimeout = 3 * time.Second
+)
+
+// AdminStats returns the figures shown on the ops dashboard. Database
+// failures fail the request; other dependencies are reported in
+// Dependencies instead.
+func (h *Handlers) AdminStats(c *gin.Context) {
+	days := defaultStatsDays
+	if v := c.Query("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxStatsDays {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: models.ErrorDetail{
+					Code:    "VALIDATION_ERROR",
+					Message: "days must be between 1 and 90",
+				},
+			})
+			return
+		}
+		days = n
+	}
+
+	ctx := c.Request.Context()
+	now := time.Now().UTC()
+	since := now.Truncate(24*time.Hour).AddDate(0, 0, -(days - 1))
+
+	// Probe dependencies while the database queries run.
+	var wg sync.WaitGroup
+	var vectors *uint64
+	var dependencies map[string]string
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		vectors, dependencies = h.checkDependencies(ctx)
+	}()
+
+	docs, err := h.Repository.GetDocumentStats(ctx)
+	if err != nil {
+		wg.Wait()
+		h.internalStatsError(c, err)
+		return
+	}
+
+	queries, err := h.Repository.CountQueriesByDay(ctx, since)
+	if err != nil {
+		wg.Wait()
+		h.internalStatsError(c, err)
+		return
+	}
+
+	active, err := h.Repository.CountActiveConversations(ctx, now.Add(-activeConversationWindow))
+	if err != nil {
+		wg.Wait()
+		h.internalStatsError(c, err)
+		return
+	}
+
+	trash, err := h.Repository.GetTrashStats(ctx)
+	if err != nil {
+		wg.Wait()
+		h.internalStatsError(c, err)
+		return
+	}
+
+	wg.Wait()
+	dependencies["database"] = "ok"
+
+	c.JSON(http.StatusOK, models.AdminStatsResponse{
+		Documents:           *docs,
+		Trash:               *trash,
+		VectorsCount:        vectors,
+		QueriesPerDay:       fillDays(queries, since, days),
+		ActiveConversations: active,
+		Dependencies:        dependencies,
+		GeneratedAt:         now,
+	})
+}
+
+func (h *Handlers) internalStatsError(c *gin.Context, err error) {
+	h.Logger.Error().Err(err).Msg("Failed to compute admin stats")
+	c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+		Error: models.ErrorDetail{
+			Code:    "INTERNAL_ERROR",
+			Message: "Failed to compute stats",
+		},
+	})
+}
+
+// checkDependencies probes every configured dependency concurrently. It
+// returns the Qdrant vector count, which doubles as Qdrant's probe, and
+// the status of each dependency ("ok" or the error message).
+func (h *Handlers) checkDependencies(ctx context.Context) (*uint64, map[string]string) {
+	ctx, cancel := context.WithTimeout(ctx, dependencyCheckTimeout)
+	defer cancel()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	dependencies := make(map[string]string)
+	check := func(name string, probe func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			status := "ok"
+			if err := probe(); err != nil {
+				status = err.Error()
+			}
+			mu.Lock()
+			dependencies[name] = status
+			mu.Unlock()
+		}()
+	}
+
+	if h.CoreClient != nil {
+		check("python_core", func() error {
+			_, err := h.CoreClient.HealthCheck(ctx)
+			return err
+		})
+	}
+	if h.Temporal != nil {
+		check("temporal", func() error { return h.Temporal.HealthCheck(ctx) })
+	}
+	if h.Redis != nil {
+		check("redis", func() error { return h.Redis.HealthCheck(ctx) })
+	}
+
+	var vectors *uint64
+	if h.QdrantClient != nil {
+		check("qdrant", func() error {
+			count, err := h.QdrantClient.CountVectors(ctx)
+			if err == nil {
+				vectors = &count
+			}
+			return err
+		})
+	}
+
+	wg.Wait()
+	return vectors, dependencies
+}
+
+// fillDays expands counts to one entry per day from since, so charts get
+// explicit zeros for days without queries.
+func fillDays(counts []models.DailyCount, since time.Time, days int) []models.DailyCount {
+	byDate := make(map[string]int, len(counts))
+	for _, count := range counts {
+		byDate[count.Date] = count.Count
+	}
+
+	filled := make([]models.DailyCount, days)
+	for i := range filled {
+		date := since.AddDate(0, 0, i).Format("2006-01-02")
+		filled[i] = models.DailyCount{Date: date, Count: byDate[date]}
+	}
+	return filled
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultStreamHeartbeat is how often an idle SSE stream sends a comment
+// when StreamHeartbeat is unset.
+const defaultStreamHeartbeat = 15 * time.Second
+
+// liftWriteDeadline lifts the server's write timeout for a response that
+// may take longer to write, such as an SSE stream or a large export.
+func liftWriteDeadline(c *gin.Context) {
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+}
+
+// streamHeartbeat returns how often idle SSE streams send a comment.
+func (h *Handlers) streamHeartbeat() time.Duration {
+	if h.StreamHeartbeat > 0 {
+		return h.StreamHeartbeat
+	}
+	return defaultStreamHeartbeat
+}
+
+// writeHeartbeat writes an SSE comment, which clients ignore, so proxies
+// do not close an idle stream.
+func writeHeartbeat(w io.Writer) error {
+	_, err := io.WriteString(w, ": ping\n\n")
+	return err
+}
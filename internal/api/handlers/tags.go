@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"net/http"
+
+	"kb-platform-gateway/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListTags lists the tags in use with their document counts.
+func (h *Handlers) ListTags(c *gin.Context) {
+	tags, err := h.Gateway().ListTags(c.Request.Context())
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.TagListResponse{Tags: tags})
+}
+
+// TagDocuments adds tags to a set of documents.
+func (h *Handlers) TagDocuments(c *gin.Context) {
+	h.bulkTag(c, true)
+}
+
+// UntagDocuments removes tags from a set of documents.
+func (h *Handlers) UntagDocuments(c *gin.Context) {
+	h.bulkTag(c, false)
+}
+
+func (h *Handlers) bulkTag(c *gin.Context, add bool) {
+	var req models.BulkTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "VALIDATION_ERROR",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	if h.Tags == nil {
+		writeError(c, featureUnavailable("Tagging is not available"))
+		return
+	}
+
+	update := h.Tags.UntagDocuments
+	if add {
+		update = h.Tags.TagDocuments
+	}
+	resp, err := update(c.Request.Context(), req)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// RenameTag renames or merges a tag across all documents.
+func (h *Handlers) RenameTag(c *gin.Context) {
+	var req models.RenameTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "VALIDATION_ERROR",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	if h.Tags == nil {
+		writeError(c, featureUnavailable("Tagging is not available"))
+		return
+	}
+
+	resp, err := h.Tags.RenameTag(c.Request.Context(), req)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
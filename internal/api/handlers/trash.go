@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RestoreDocument takes a document out of the trash.
+func (h *Handlers) RestoreDocument(c *gin.Context) {
+	doc, err := h.Gateway().RestoreDocument(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, doc)
+}
+
+// PurgeTrash is called by the scheduled PurgeTrashWorkflow to delete the
+// documents whose trash retention has passed, and to finish deletions
+// that failed part way.
+func (h *Handlers) PurgeTrash(c *gin.Context) {
+	purge, err := h.Gateway().PurgeTrash(c.Request.Context())
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, purge)
+}
@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UploadContent streams a pending document's file in the request body
+// through the gateway to S3 and completes the upload, for clients that
+// cannot reach S3 directly.
+func (h *Handlers) UploadContent(c *gin.Context) {
+	// A large file takes longer than the server's read and write timeouts
+	// to stream; its size is bounded by the proxy's limit instead.
+	_ = http.NewResponseController(c.Writer).SetReadDeadline(time.Time{})
+	liftWriteDeadline(c)
+
+	doc, err := h.Gateway().UploadContent(c.Request.Context(), c.Param("id"), c.Request.Body, c.Request.ContentLength, c.GetHeader("Content-Type"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, doc)
+}
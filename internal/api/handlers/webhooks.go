@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"kb-platform-gateway/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// publish hands an event to the webhook dispatcher, if one is configured.
+func (h *Handlers) publish(ctx context.Context, eventType string, data interface{}) {
+	if h.Webhooks != nil {
+		h.Webhooks.Dispatch(ctx, eventType, data)
+	}
+}
+
+func (h *Handlers) CreateWebhook(c *gin.Context) {
+	var req models.CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "VALIDATION_ERROR",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	for _, event := range req.Events {
+		if !slices.Contains(models.WebhookEventTypes, event) {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: models.ErrorDetail{
+					Code:    "VALIDATION_ERROR",
+					Message: "Unknown event type",
+					Details: map[string]string{"event": event},
+				},
+			})
+			return
+		}
+	}
+
+	webhook := &models.Webhook{
+		ID:        generateUUID(),
+		URL:       req.URL,
+		Secret:    req.Secret,
+		Events:    req.Events,
+		Active:    true,
+		CreatedBy: c.GetString("username"),
+		CreatedAt: time.Now(),
+	}
+
+	if err := h.Repository.CreateWebhook(c.Request.Context(), webhook); err != nil {
+		h.Logger.Error().Err(err).Msg("Failed to create webhook")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to create webhook",
+			},
+		})
+		return
+	}
+
+	webhook.Secret = ""
+	c.JSON(http.StatusCreated, webhook)
+}
+
+func (h *Handlers) ListWebhooks(c *gin.Context) {
+	webhooks, err := h.Repository.ListWebhooks(c.Request.Context())
+	if err != nil {
+		h.Logger.Error().Err(err).Msg("Failed to list webhooks")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to list webhooks",
+			},
+		})
+		return
+	}
+
+	webhookList := make([]models.Webhook, len(webhooks))
+	for i, webhook := range webhooks {
+		webhookList[i] = *webhook
+		webhookList[i].Secret = ""
+	}
+
+	c.JSON(http.StatusOK, models.WebhookListResponse{
+		Webhooks: webhookList,
+	})
+}
+
+func (h *Handlers) DeleteWebhook(c *gin.Context) {
+	webhookID := c.Param("id")
+
+	if err := h.Repository.DeleteWebhook(c.Request.Context(), webhookID); err != nil {
+		h.Logger.Error().Err(err).Str("webhook_id", webhookID).Msg("Failed to delete webhook")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to delete webhook",
+			},
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handlers) ListWebhookDeliveries(c *gin.Context) {
+	webhookID := c.Param("id")
+	limit := 50
+	offset := 0
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
+			limit = l
+		}
+	}
+
+	if offsetStr := c.Query("offset"); offsetStr != "" {
+		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
+			offset = o
+		}
+	}
+
+	webhook, err := h.Repository.GetWebhook(c.Request.Context(), webhookID)
+	if err != nil {
+		h.Logger.Error().Err(err).Str("webhook_id", webhookID).Msg("Failed to get webhook")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to get webhook",
+			},
+		})
+		return
+	}
+
+	if webhook == nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "NOT_FOUND",
+				Message: "Webhook not found",
+			},
+		})
+		return
+	}
+
+	deliveries, total, err := h.Repository.ListWebhookDeliveries(c.Request.Context(), webhookID, limit, offset)
+	if err != nil {
+		h.Logger.Error().Err(err).Str("webhook_id", webhookID).Msg("Failed to list webhook deliveries")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to list webhook deliveries",
+			},
+		})
+		return
+	}
+
+	deliveryList := make([]models.WebhookDelivery, len(deliveries))
+	for i, delivery := range deliveries {
+		deliveryList[i] = *delivery
+	}
+
+	c.JSON(http.StatusOK, models.WebhookDeliveryListResponse{
+		Deliveries: deliveryList,
+		Total:      total,
+		Limit:      limit,
+		Offset:     offset,
+	})
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"kb-platform-gateway/internal/models"
+	"kb-platform-gateway/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateWidgetToken mints a short-lived chat widget token for a site. It is
+// meant to be called by the site's backend, which hands the token to the
+// widget it embeds.
+func (h *Handlers) CreateWidgetToken(c *gin.Context) {
+	if h.Widgets == nil {
+		writeError(c, featureUnavailable("The chat widget is not enabled"))
+		return
+	}
+
+	var req models.CreateWidgetTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "VALIDATION_ERROR",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	token, err := h.Widgets.Issue(req.Origin)
+	if errors.Is(err, services.ErrWidgetOriginNotAllowed) {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "AUTHORIZATION_ERROR",
+				Message: "Origin is not allowed to embed the chat widget",
+				Details: map[string]string{"origin": req.Origin},
+			},
+		})
+		return
+	}
+	if err != nil {
+		h.Logger.Error().Err(err).Msg("Failed to mint widget token")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to create widget token",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, token)
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"kb-platform-gateway/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExportWorkspace returns a bundle of the indexed documents, and with
+// conversations=true every conversation, to import into another gateway.
+func (h *Handlers) ExportWorkspace(c *gin.Context) {
+	conversations := false
+	if value := c.Query("conversations"); value != "" {
+		flag, err := strconv.ParseBool(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: models.ErrorDetail{
+					Code:    "VALIDATION_ERROR",
+					Message: "conversations must be true or false",
+				},
+			})
+			return
+		}
+		conversations = flag
+	}
+
+	// Collecting and writing a large workspace can outlast the server's
+	// write timeout.
+	liftWriteDeadline(c)
+	bundle, err := h.Gateway().ExportWorkspace(c.Request.Context(), conversations, c.GetString("username"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="workspace.json"`)
+	c.JSON(http.StatusOK, bundle)
+}
+
+// ImportWorkspace starts importing a bundle exported by ExportWorkspace.
+// The import's progress is read back with GetWorkspaceImport.
+func (h *Handlers) ImportWorkspace(c *gin.Context) {
+	var bundle models.WorkspaceBundle
+	if err := c.ShouldBindJSON(&bundle); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "VALIDATION_ERROR",
+				Message: "Invalid request format",
+			},
+		})
+		return
+	}
+
+	if h.WorkspaceImports == nil {
+		writeError(c, featureUnavailable("Workspace import is not available"))
+		return
+	}
+
+	imp, err := h.WorkspaceImports.Start(c.Request.Context(), &bundle, c.GetString("username"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, imp)
+}
+
+func (h *Handlers) ListWorkspaceImports(c *gin.Context) {
+	limit, offset := page(c)
+
+	imports, total, err := h.Repository.ListWorkspaceImports(c.Request.Context(), limit, offset)
+	if err != nil {
+		h.Logger.Error().Err(err).Msg("Failed to list workspace imports")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to list workspace imports",
+			},
+		})
+		return
+	}
+
+	importList := make([]models.WorkspaceImport, len(imports))
+	for i, imp := range imports {
+		importList[i] = *imp
+	}
+
+	c.JSON(http.StatusOK, models.WorkspaceImportListResponse{
+		Imports: importList,
+		Total:   total,
+		Limit:   limit,
+		Offset:  offset,
+	})
+}
+
+// GetWorkspaceImport returns a workspace import with the documents that
+// failed to import.
+func (h *Handlers) GetWorkspaceImport(c *gin.Context) {
+	importID := c.Param("id")
+	imp, err := h.Repository.GetWorkspaceImport(c.Request.Context(), importID)
+	if err != nil {
+		h.Logger.Error().Err(err).Str("import_id", importID).Msg("Failed to get workspace import")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to get workspace import",
+			},
+		})
+		return
+	}
+	if imp == nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "NOT_FOUND",
+				Message: "Workspace import not found",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, imp)
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+
+	"kb-platform-gateway/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminMiddleware restricts a route group to the configured admin users,
+// who cannot be impersonated. It must run after AuthMiddleware.
+func AdminMiddleware(adminUsers []string) gin.HandlerFunc {
+	admins := make(map[string]bool, len(adminUsers))
+	for _, user := range adminUsers {
+		admins[user] = true
+	}
+
+	return func(c *gin.Context) {
+		if c.GetString("impersonator") != "" {
+			c.JSON(http.StatusForbidden, models.ErrorResponse{
+				Error: models.ErrorDetail{
+					Code:    "AUTHORIZATION_ERROR",
+					Message: "Not available while impersonating",
+				},
+			})
+			c.Abort()
+			return
+		}
+		if !admins[c.GetString("username")] {
+			c.JSON(http.StatusForbidden, models.ErrorResponse{
+				Error: models.ErrorDetail{
+					Code:    "AUTHORIZATION_ERROR",
+					Message: "Admin privileges required",
+				},
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
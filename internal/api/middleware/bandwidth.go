@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"context"
+	"io"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+)
+
+// BandwidthLimitMiddleware throttles reading the request bodies of the
+// routes it guards to perRequest bytes per second for each request, and to
+// total bytes per second across all of them, so a bulk import cannot
+// saturate the gateway's network and starve query traffic. A limit of 0
+// disables it.
+func BandwidthLimitMiddleware(total, perRequest int) gin.HandlerFunc {
+	var shared *rate.Limiter
+	if total > 0 {
+		shared = newBandwidthLimiter(total)
+	}
+
+	return func(c *gin.Context) {
+		var limiters []*rate.Limiter
+		if shared != nil {
+			limiters = append(limiters, shared)
+		}
+		if perRequest > 0 {
+			limiters = append(limiters, newBandwidthLimiter(perRequest))
+		}
+		if len(limiters) > 0 && c.Request.Body != nil {
+			c.Request.Body = &throttledBody{
+				ReadCloser: c.Request.Body,
+				ctx:        c.Request.Context(),
+				limiters:   limiters,
+			}
+		}
+		c.Next()
+	}
+}
+
+// newBandwidthLimiter allows bytesPerSecond, with bursts of up to a
+// second's worth.
+func newBandwidthLimiter(bytesPerSecond int) *rate.Limiter {
+	return rate.NewLimiter(rate.Limit(bytesPerSecond), bytesPerSecond)
+}
+
+// throttledBody waits on every limiter for the bytes it has read before
+// returning them. Reads are capped at the smallest burst, so each can be
+// waited for.
+type throttledBody struct {
+	io.ReadCloser
+	ctx      context.Context
+	limiters []*rate.Limiter
+}
+
+func (b *throttledBody) Read(p []byte) (int, error) {
+	for _, limiter := range b.limiters {
+		if burst := limiter.Burst(); len(p) > burst {
+			p = p[:burst]
+		}
+	}
+
+	n, err := b.ReadCloser.Read(p)
+	for _, limiter := range b.limiters {
+		if n == 0 {
+			break
+		}
+		if waitErr := limiter.WaitN(b.ctx, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"kb-platform-gateway/internal/config"
+	"kb-platform-gateway/internal/gateway"
+	"kb-platform-gateway/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// DemoSessionHeader carries a guest's session ID. Guests all hold the same
+// token, so the session tells them apart: a guest without one is given a
+// new session on the response and sends it back on later requests.
+const DemoSessionHeader = "X-Demo-Session"
+
+// demoRoutes are the routes guests may call, by method and route path.
+var demoRoutes = map[string]bool{
+	"POST /api/v1/query":                     true,
+	"POST /api/v1/conversations":             true,
+	"GET /api/v1/conversations/:id/messages": true,
+}
+
+// Counter counts requests in fixed windows. Incr returns the count at key
+// including this call; the count resets ttl after its first increment.
+type Counter interface {
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+}
+
+// DemoAuthMiddleware lets requests bearing the demo token through as a
+// guest of the demo user, "<Username>:<session>", and hands every other
+// request to next. Guests may only call
+// demoRoutes and are limited to RateLimit requests per RateWindow per
+// client IP, counted in counter so instances share the limit, or in
+// memory if counter is nil. Their queries use the demo collection.
+func DemoAuthMiddleware(cfg *config.DemoConfig, counter Counter, next gin.HandlerFunc) gin.HandlerFunc {
+	if counter == nil {
+		counter = &localCounter{}
+	}
+	token := []byte(cfg.Token)
+
+	return func(c *gin.Context) {
+		provided, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || c.GetHeader("x-user-name") != "" || subtle.ConstantTimeCompare([]byte(provided), token) != 1 {
+			next(c)
+			return
+		}
+
+		if !demoRoutes[c.Request.Method+" "+c.FullPath()] {
+			c.JSON(http.StatusForbidden, models.ErrorResponse{
+				Error: models.ErrorDetail{
+					Code:    "AUTHORIZATION_ERROR",
+					Message: "Not available in demo mode",
+				},
+			})
+			c.Abort()
+			return
+		}
+
+		if cfg.RateLimit > 0 {
+			count, err := counter.Incr(c.Request.Context(), "demo:rate:"+c.ClientIP(), cfg.RateWindow)
+			// Fail open: an unavailable counter should not take the demo
+			// down.
+			if err == nil && count > int64(cfg.RateLimit) {
+				c.Header("Retry-After", strconv.Itoa(int(cfg.RateWindow.Seconds())))
+				c.JSON(http.StatusTooManyRequests, models.ErrorResponse{
+					Error: models.ErrorDetail{
+						Code:    "RATE_LIMITED",
+						Message: "Too many demo requests, try again later",
+					},
+				})
+				c.Abort()
+				return
+			}
+		}
+
+		session := c.GetHeader(DemoSessionHeader)
+		if !isUUID(session) {
+			session = uuid.New().String()
+		}
+		c.Header(DemoSessionHeader, session)
+
+		c.Set("username", cfg.Username+":"+session)
+		c.Set("demo", true)
+		c.Set("collection", cfg.Collection)
+		c.Request = c.Request.WithContext(gateway.WithGuest(c.Request.Context()))
+		c.Next()
+	}
+}
+
+// localCounter is a Counter for a single instance. All counts reset
+// together once the window of the oldest one has elapsed.
+type localCounter struct {
+	mu          sync.Mutex
+	windowStart time.Time
+	counts      map[string]int64
+}
+
+func (l *localCounter) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if l.counts == nil || now.Sub(l.windowStart) >= ttl {
+		l.windowStart = now
+		l.counts = make(map[string]int64)
+	}
+	l.counts[key]++
+	return l.counts[key], nil
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"kb-platform-gateway/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InternalAuthMiddleware guards service-to-service endpoints with a shared
+// bearer token. An empty token disables the check; the routes only pass one
+// when AUTH_INTERNAL_INSECURE allows it.
+func InternalAuthMiddleware(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.Next()
+			return
+		}
+
+		provided := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+				Error: models.ErrorDetail{
+					Code:    "AUTHENTICATION_ERROR",
+					Message: "Invalid internal token",
+				},
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
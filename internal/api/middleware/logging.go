@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+// LoggerMiddleware logs every processed request.
+func LoggerMiddleware(logger zerolog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		path := c.Request.URL.Path
+		method := c.Request.Method
+
+		// Process request
+		c.Next()
+
+		// Log after processing
+		latency := time.Since(start)
+		status := c.Writer.Status()
+
+		logger.Info().
+			Str("method", method).
+			Str("path", path).
+			Int("status", status).
+			Dur("latency", latency).
+			Str("client_ip", c.ClientIP()).
+			Str("request_id", c.GetString("request_id")).
+			Msg("Request processed")
+	}
+}
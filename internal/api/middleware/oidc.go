@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"kb-platform-gateway/internal/models"
+	"kb-platform-gateway/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+// OIDCVerifier checks tokens issued by the OpenID Connect provider. It is
+// implemented by services.OIDCVerifier.
+type OIDCVerifier interface {
+	Verify(ctx context.Context, token string) (string, error)
+}
+
+// OIDCMiddleware authenticates requests bearing a JWT from the OpenID
+// Connect provider as the user its username claim names, and hands every
+// other request to next. The user is then treated like one named in
+// x-user-name, including for admin routes.
+func OIDCMiddleware(verifier OIDCVerifier, logger zerolog.Logger, next gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		provided, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || c.GetHeader("x-user-name") != "" || strings.Count(provided, ".") != 2 {
+			next(c)
+			return
+		}
+
+		username, err := verifier.Verify(c.Request.Context(), provided)
+		if errors.Is(err, services.ErrInvalidOIDCToken) {
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+				Error: models.ErrorDetail{
+					Code:    "AUTHENTICATION_ERROR",
+					Message: "Invalid or expired OIDC token",
+				},
+			})
+			c.Abort()
+			return
+		}
+		if err != nil {
+			logger.Error().Err(err).Msg("Failed to verify OIDC token")
+			c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{
+				Error: models.ErrorDetail{
+					Code:    "SERVICE_UNAVAILABLE",
+					Message: "The identity provider is unavailable",
+				},
+			})
+			c.Abort()
+			return
+		}
+
+		c.Set("username", username)
+		c.Next()
+	}
+}
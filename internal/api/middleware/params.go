@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+
+	"kb-platform-gateway/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// IDParamMiddleware refuses requests whose :id path parameter is not a
+// UUID with 400 VALIDATION_ERROR, so a malformed ID never reaches Postgres
+// or the core service, where it would fail as an internal error. Every
+// resource the gateway serves by :id is keyed by a UUID.
+func IDParamMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if id, ok := c.Params.Get("id"); ok && !isUUID(id) {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: models.ErrorDetail{
+					Code:    "VALIDATION_ERROR",
+					Message: "id must be a UUID",
+				},
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// isUUID reports whether s is a UUID in the hyphenated form IDs are
+// stored in. uuid.Parse alone also accepts braced, URN and unhyphenated
+// forms.
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	_, err := uuid.Parse(s)
+	return err == nil
+}
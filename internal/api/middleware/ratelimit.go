@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"kb-platform-gateway/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimitMiddleware limits each client IP to limit requests per window
+// on the routes it guards, counted under name in counter so instances
+// share the limit, or in memory if counter is nil. A limit of 0 disables
+// it.
+func RateLimitMiddleware(name string, limit int, window time.Duration, counter Counter) gin.HandlerFunc {
+	if counter == nil {
+		counter = &localCounter{}
+	}
+
+	return func(c *gin.Context) {
+		if limit <= 0 {
+			c.Next()
+			return
+		}
+
+		count, err := counter.Incr(c.Request.Context(), name+":rate:"+c.ClientIP(), window)
+		// Fail open, as in demo mode.
+		if err == nil && count > int64(limit) {
+			c.Header("Retry-After", strconv.Itoa(int(window.Seconds())))
+			c.JSON(http.StatusTooManyRequests, models.ErrorResponse{
+				Error: models.ErrorDetail{
+					Code:    "RATE_LIMITED",
+					Message: "Too many requests, try again later",
+				},
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
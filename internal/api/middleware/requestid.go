@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"regexp"
+
+	"kb-platform-gateway/internal/requestid"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// validRequestID matches the request IDs accepted from callers. They are
+// logged, forwarded to the core and echoed back, so anything that could
+// forge log lines or headers is replaced.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+// RequestIDMiddleware reuses the caller's X-Request-ID (or generates one
+// when it is missing or invalid), echoes it on the response and stores it
+// on the request context.
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestid.Header)
+		if !validRequestID.MatchString(id) {
+			id = uuid.New().String()
+		}
+
+		c.Set("request_id", id)
+		c.Writer.Header().Set(requestid.Header, id)
+		c.Request = c.Request.WithContext(requestid.NewContext(c.Request.Context(), id))
+		c.Next()
+	}
+}
@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"kb-platform-gateway/internal/models"
+	"kb-platform-gateway/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SchedulerMiddleware admits requests through scheduler by class, so under
+// load batch work is delayed and shed before chat. A request not admitted
+// within queueTimeout is refused with 503 OVERLOADED. Health, docs, the
+// long-lived streams and the internal API are not scheduled.
+func SchedulerMiddleware(scheduler *services.RequestScheduler, queueTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		class, ok := requestClass(c)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), queueTimeout)
+		release, err := scheduler.Acquire(ctx, class)
+		cancel()
+		if err != nil {
+			c.Header("Retry-After", "1")
+			c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{
+				Error: models.ErrorDetail{
+					Code:    "OVERLOADED",
+					Message: "The gateway is busy, try again later",
+				},
+			})
+			c.Abort()
+			return
+		}
+		defer release()
+		c.Next()
+	}
+}
+
+// interactivePrefixes are the routes of chat.
+var interactivePrefixes = []string{
+	"/api/v1/query",
+	"/api/v1/queries/",
+	"/api/v1/conversations",
+	"/api/v1/widget/",
+}
+
+// streamRoutes are the long-lived SSE streams, which would hold a slot for
+// as long as a client watches.
+var streamRoutes = map[string]bool{
+	"/api/v1/events/stream":            true,
+	"/api/v1/conversations/:id/stream": true,
+	"/api/v1/export/:id/stream":        true,
+}
+
+// requestClass classifies a request by its route. It reports false for
+// requests that are not scheduled: unmatched routes, probes and docs, the
+// long-lived streams and GraphQL subscriptions, and worker callbacks on the
+// internal API, which Temporal already paces.
+func requestClass(c *gin.Context) (services.RequestClass, bool) {
+	path := c.FullPath()
+	switch {
+	case !strings.HasPrefix(path, "/api/") && !strings.HasPrefix(path, "/graphql"):
+		return 0, false
+	case streamRoutes[path], isSubscription(c):
+		return 0, false
+	case path == "/api/v1/documents" && c.Request.Method != http.MethodGet,
+		strings.HasPrefix(path, "/api/v1/documents/batch"),
+		path == "/api/v1/connectors/:id/sync",
+		strings.HasSuffix(path, "/export"),
+		strings.HasPrefix(path, "/api/v1/admin/"):
+		return services.ClassBatch, true
+	}
+	for _, prefix := range interactivePrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return services.ClassInteractive, true
+		}
+	}
+	return services.ClassStandard, true
+}
+
+// isSubscription reports whether a GraphQL request opens a subscription,
+// over SSE or by upgrading to a WebSocket.
+func isSubscription(c *gin.Context) bool {
+	if !strings.HasPrefix(c.FullPath(), "/graphql") {
+		return false
+	}
+	return strings.Contains(c.GetHeader("Accept"), "text/event-stream") ||
+		strings.EqualFold(c.GetHeader("Upgrade"), "websocket")
+}
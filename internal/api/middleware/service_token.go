@@ -0,0 +1,166 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"kb-platform-gateway/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+// ServiceTokenPrefix starts every service token, so they can be told apart
+// from other bearer tokens without a lookup.
+const ServiceTokenPrefix = "kbst_"
+
+// serviceTokenRoutes are the routes service tokens may call, by method and
+// route path, with the scope each requires.
+var serviceTokenRoutes = map[string]string{
+	"GET /api/v1/documents":                           models.ScopeDocumentsRead,
+	"GET /api/v1/documents/export":                    models.ScopeDocumentsRead,
+	"GET /api/v1/documents/:id":                       models.ScopeDocumentsRead,
+	"GET /api/v1/documents/:id/events":                models.ScopeDocumentsRead,
+	"GET /api/v1/documents/:id/children":              models.ScopeDocumentsRead,
+	"GET /api/v1/documents/:id/multipart":             models.ScopeDocumentsRead,
+	"POST /api/v1/documents":                          models.ScopeDocumentsWrite,
+	"POST /api/v1/documents/text":                     models.ScopeDocumentsWrite,
+	"POST /api/v1/documents/batch":                    models.ScopeDocumentsWrite,
+	"POST /api/v1/documents/batch/complete":           models.ScopeDocumentsWrite,
+	"DELETE /api/v1/documents":                        models.ScopeDocumentsWrite,
+	"POST /api/v1/documents/:id/complete":             models.ScopeDocumentsWrite,
+	"POST /api/v1/documents/:id/cancel":               models.ScopeDocumentsWrite,
+	"POST /api/v1/documents/:id/upload-url":           models.ScopeDocumentsWrite,
+	"POST /api/v1/documents/:id/content":              models.ScopeDocumentsWrite,
+	"POST /api/v1/documents/:id/multipart":            models.ScopeDocumentsWrite,
+	"DELETE /api/v1/documents/:id/multipart":          models.ScopeDocumentsWrite,
+	"POST /api/v1/documents/:id/multipart/urls":       models.ScopeDocumentsWrite,
+	"PUT /api/v1/documents/:id/multipart/parts/:part": models.ScopeDocumentsWrite,
+	"POST /api/v1/documents/:id/multipart/complete":   models.ScopeDocumentsWrite,
+	"PATCH /api/v1/documents/:id":                     models.ScopeDocumentsWrite,
+	"DELETE /api/v1/documents/:id":                    models.ScopeDocumentsWrite,
+	"POST /api/v1/documents/:id/restore":              models.ScopeDocumentsWrite,
+	"POST /api/v1/documents/:id/reindex":              models.ScopeDocumentsWrite,
+	"GET /api/v1/tags":                                models.ScopeDocumentsRead,
+	"POST /api/v1/tags/apply":                         models.ScopeDocumentsWrite,
+	"POST /api/v1/tags/remove":                        models.ScopeDocumentsWrite,
+	"POST /api/v1/tags/rename":                        models.ScopeDocumentsWrite,
+	"GET /api/v1/saved-searches":                      models.ScopeDocumentsRead,
+	"GET /api/v1/saved-searches/:id":                  models.ScopeDocumentsRead,
+	"GET /api/v1/saved-searches/:id/documents":        models.ScopeDocumentsRead,
+	"GET /api/v1/collections":                         models.ScopeDocumentsRead,
+	"GET /api/v1/collections/:id":                     models.ScopeDocumentsRead,
+	"GET /api/v1/collections/:id/documents":           models.ScopeDocumentsRead,
+	"POST /api/v1/query":                              models.ScopeQuery,
+	"GET /api/v1/query/suggest":                       models.ScopeQuery,
+	"POST /api/v1/conversations":                      models.ScopeQuery,
+	"GET /api/v1/conversations/:id/messages":          models.ScopeQuery,
+	"GET /api/v1/conversations/:id/messages/export":   models.ScopeQuery,
+	"GET /api/v1/conversations/:id/summaries":         models.ScopeQuery,
+	"POST /api/v1/widget/tokens":                      models.ScopeQuery,
+}
+
+// ServiceTokenStore looks up service tokens. It is implemented by the
+// repository.
+type ServiceTokenStore interface {
+	GetServiceTokenByHash(ctx context.Context, hash string) (*models.ServiceToken, error)
+	TouchServiceToken(ctx context.Context, id string, usedAt time.Time) error
+}
+
+// HashServiceToken returns the hash under which a service token is stored.
+func HashServiceToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// serviceToken returns the service token of a request, sent either as a
+// bearer token or, for clients that cannot set Authorization, in the
+// X-API-Key header. Any X-API-Key value is taken for a service token, so
+// a wrong key is refused rather than ignored.
+func serviceToken(c *gin.Context) (string, bool) {
+	if c.GetHeader("x-user-name") != "" {
+		return "", false
+	}
+	if provided, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok && strings.HasPrefix(provided, ServiceTokenPrefix) {
+		return provided, true
+	}
+	if provided := c.GetHeader("X-API-Key"); provided != "" {
+		return provided, true
+	}
+	return "", false
+}
+
+// ServiceTokenMiddleware authenticates requests bearing a service token as
+// "service:<name>" and hands every other request to next. Tokens may only
+// call the serviceTokenRoutes their scopes cover.
+func ServiceTokenMiddleware(store ServiceTokenStore, logger zerolog.Logger, next gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		provided, ok := serviceToken(c)
+		if !ok {
+			next(c)
+			return
+		}
+
+		ctx := c.Request.Context()
+		token, err := store.GetServiceTokenByHash(ctx, HashServiceToken(provided))
+		if err != nil {
+			logger.Error().Err(err).Msg("Failed to look up service token")
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error: models.ErrorDetail{
+					Code:    "INTERNAL_ERROR",
+					Message: "Failed to authenticate",
+				},
+			})
+			c.Abort()
+			return
+		}
+
+		now := time.Now()
+		if token == nil || token.Expired(now) {
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+				Error: models.ErrorDetail{
+					Code:    "AUTHENTICATION_ERROR",
+					Message: "Invalid or expired service token",
+				},
+			})
+			c.Abort()
+			return
+		}
+
+		scope, ok := serviceTokenRoutes[c.Request.Method+" "+c.FullPath()]
+		if !ok {
+			c.JSON(http.StatusForbidden, models.ErrorResponse{
+				Error: models.ErrorDetail{
+					Code:    "AUTHORIZATION_ERROR",
+					Message: "Not available to service tokens",
+				},
+			})
+			c.Abort()
+			return
+		}
+		if !slices.Contains(token.Scopes, scope) {
+			c.JSON(http.StatusForbidden, models.ErrorResponse{
+				Error: models.ErrorDetail{
+					Code:    "AUTHORIZATION_ERROR",
+					Message: "Service token lacks the required scope",
+					Details: map[string]string{"scope": scope},
+				},
+			})
+			c.Abort()
+			return
+		}
+
+		if err := store.TouchServiceToken(ctx, token.ID, now); err != nil {
+			logger.Warn().Err(err).Str("token_id", token.ID).Msg("Failed to record service token use")
+		}
+
+		c.Set("username", "service:"+token.Name)
+		c.Set("service_token", token.ID)
+		c.Next()
+	}
+}
@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// RequestRecorder receives the status of every served request.
+type RequestRecorder interface {
+	RecordRequest(status int)
+}
+
+// StatusRecorderMiddleware reports the response status of each request to
+// recorder, e.g. to track the error rate.
+func StatusRecorderMiddleware(recorder RequestRecorder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		recorder.RecordRequest(c.Writer.Status())
+	}
+}
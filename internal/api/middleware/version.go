@@ -0,0 +1,18 @@
+package middleware
+
+import (
+	"kb-platform-gateway/internal/buildinfo"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VersionMiddleware sends the running build's version on every response,
+// so callers can tell which build served them.
+func VersionMiddleware() gin.HandlerFunc {
+	version := buildinfo.Get().String()
+
+	return func(c *gin.Context) {
+		c.Writer.Header().Set(buildinfo.Header, version)
+		c.Next()
+	}
+}
@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"kb-platform-gateway/internal/config"
+	"kb-platform-gateway/internal/gateway"
+	"kb-platform-gateway/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WidgetTokenVerifier checks chat widget tokens. It is implemented by
+// services.WidgetTokens.
+type WidgetTokenVerifier interface {
+	Verify(token string) (*models.WidgetClaims, error)
+}
+
+// WidgetAuthMiddleware authenticates the chat widget's route group. Each
+// request must bear a widget token and come from the origin it was minted
+// for, and acts as "widget:<session_id>". An origin's widgets are limited
+// to RateLimit requests per RateWindow, counted in counter so instances
+// share the limit, or in memory if counter is nil. Their queries use the
+// widget collection. With a nil verifier the widget is disabled and every
+// request is refused.
+func WidgetAuthMiddleware(cfg *config.WidgetConfig, verifier WidgetTokenVerifier, counter Counter) gin.HandlerFunc {
+	if counter == nil {
+		counter = &localCounter{}
+	}
+
+	return func(c *gin.Context) {
+		if verifier == nil {
+			c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{
+				Error: models.ErrorDetail{
+					Code:    "SERVICE_UNAVAILABLE",
+					Message: "The chat widget is not enabled",
+				},
+			})
+			c.Abort()
+			return
+		}
+
+		provided, _ := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		claims, err := verifier.Verify(provided)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+				Error: models.ErrorDetail{
+					Code:    "AUTHENTICATION_ERROR",
+					Message: "Invalid or expired widget token",
+				},
+			})
+			c.Abort()
+			return
+		}
+
+		// Browsers always send Origin on the widget's cross-origin
+		// requests, so a missing or different one means the token was
+		// lifted off the site it was minted for.
+		origin := strings.ToLower(strings.TrimSuffix(c.GetHeader("Origin"), "/"))
+		if origin != claims.Origin {
+			c.JSON(http.StatusForbidden, models.ErrorResponse{
+				Error: models.ErrorDetail{
+					Code:    "AUTHORIZATION_ERROR",
+					Message: "Widget token is not valid for this origin",
+				},
+			})
+			c.Abort()
+			return
+		}
+
+		if cfg.RateLimit > 0 {
+			count, err := counter.Incr(c.Request.Context(), "widget:rate:"+claims.Origin, cfg.RateWindow)
+			// Fail open, as in demo mode.
+			if err == nil && count > int64(cfg.RateLimit) {
+				c.Header("Retry-After", strconv.Itoa(int(cfg.RateWindow.Seconds())))
+				c.JSON(http.StatusTooManyRequests, models.ErrorResponse{
+					Error: models.ErrorDetail{
+						Code:    "RATE_LIMITED",
+						Message: "Too many widget requests, try again later",
+					},
+				})
+				c.Abort()
+				return
+			}
+		}
+
+		c.Set("username", "widget:"+claims.SessionID)
+		c.Set("widget", claims.Origin)
+		c.Set("collection", cfg.Collection)
+		c.Request = c.Request.WithContext(gateway.WithGuest(c.Request.Context()))
+		c.Next()
+	}
+}
@@ -10,35 +10,289 @@ import (
 )
 
 func SetupRoutes(router *gin.Engine, cfg *config.Config, h *handlers.Handlers, logger zerolog.Logger) {
+	var counter middleware.Counter
+	if h.Redis != nil {
+		counter = h.Redis
+	}
+
 	authMiddleware := middleware.AuthMiddleware()
+	if h.OIDC != nil {
+		authMiddleware = middleware.OIDCMiddleware(h.OIDC, logger, authMiddleware)
+	}
+	if h.Repository != nil {
+		authMiddleware = middleware.ServiceTokenMiddleware(h.Repository, logger, authMiddleware)
+		authMiddleware = middleware.ImpersonationMiddleware(h.Impersonation, h.Repository, logger, authMiddleware)
+	}
+	if cfg.Demo.Active() {
+		authMiddleware = middleware.DemoAuthMiddleware(&cfg.Demo, counter, authMiddleware)
+	}
+
+	// Checked after authentication, so unauthenticated requests get 401
+	// whatever their path.
+	validID := middleware.IDParamMiddleware()
+
+	// Shared by the upload routes, so their total bandwidth is limited
+	// together.
+	uploadBandwidth := middleware.BandwidthLimitMiddleware(cfg.Uploads.BandwidthLimit, cfg.Uploads.RequestBandwidthLimit)
 
 	api := router.Group("/api/v1")
 	{
 		docs := api.Group("/documents")
-		docs.Use(authMiddleware)
+		docs.Use(authMiddleware, validID)
 		{
-			docs.POST("", h.UploadDocument)
+			docs.POST("", uploadBandwidth, h.UploadDocument)
+			docs.POST("/text", h.CreateTextDocument)
+			docs.POST("/batch", h.BatchUpload)
+			docs.POST("/batch/complete", h.BatchCompleteUpload)
 			docs.GET("", h.ListDocuments)
+			docs.DELETE("", h.BatchDeleteDocuments)
+			docs.GET("/leaderboard", h.DocumentLeaderboard)
+			docs.GET("/export", h.ExportDocuments)
+			docs.GET("/search", h.SearchDocuments)
 			docs.GET("/:id", h.GetDocument)
+			docs.PATCH("/:id", h.UpdateDocument)
 			docs.DELETE("/:id", h.DeleteDocument)
+			docs.POST("/:id/restore", h.RestoreDocument)
+			docs.POST("/:id/reindex", h.ReindexDocument)
 			docs.POST("/:id/complete", h.CompleteUpload)
+			docs.POST("/:id/cancel", h.CancelIndexing)
+			docs.POST("/:id/approve", h.ApproveDocument)
+			docs.POST("/:id/reject", h.RejectDocument)
+			docs.POST("/:id/upload-url", h.RefreshUploadURL)
+			docs.POST("/:id/content", uploadBandwidth, h.UploadContent)
+			docs.POST("/:id/multipart", h.CreateMultipartUpload)
+			docs.GET("/:id/multipart", h.GetMultipartUpload)
+			docs.DELETE("/:id/multipart", h.AbortMultipartUpload)
+			docs.POST("/:id/multipart/urls", h.MultipartPartURLs)
+			docs.PUT("/:id/multipart/parts/:part", h.RecordMultipartPart)
+			docs.POST("/:id/multipart/complete", h.CompleteMultipartUpload)
+			docs.GET("/:id/analytics", h.DocumentAnalytics)
+			docs.GET("/:id/events", h.ListDocumentEvents)
+			docs.GET("/:id/children", h.ListChildDocuments)
+			docs.GET("/:id/resync-schedule", h.GetDocumentResyncSchedule)
+			docs.PUT("/:id/resync-schedule", h.SetDocumentResyncSchedule)
+			docs.DELETE("/:id/resync-schedule", h.DeleteDocumentResyncSchedule)
+		}
+
+		tags := api.Group("/tags")
+		tags.Use(authMiddleware)
+		{
+			tags.GET("", h.ListTags)
+			tags.POST("/apply", h.TagDocuments)
+			tags.POST("/remove", h.UntagDocuments)
+			tags.POST("/rename", h.RenameTag)
+		}
+
+		savedSearches := api.Group("/saved-searches")
+		savedSearches.Use(authMiddleware, validID)
+		{
+			savedSearches.POST("", h.CreateSavedSearch)
+			savedSearches.GET("", h.ListSavedSearches)
+			savedSearches.GET("/:id", h.GetSavedSearch)
+			savedSearches.PUT("/:id", h.UpdateSavedSearch)
+			savedSearches.DELETE("/:id", h.DeleteSavedSearch)
+			savedSearches.GET("/:id/documents", h.RunSavedSearch)
+		}
+
+		collections := api.Group("/collections")
+		collections.Use(authMiddleware, validID)
+		{
+			collections.POST("", h.CreateCollection)
+			collections.GET("", h.ListCollections)
+			collections.GET("/:id", h.GetCollection)
+			collections.DELETE("/:id", h.DeleteCollection)
+			collections.GET("/:id/documents", h.ListCollectionDocuments)
+			collections.POST("/:id/documents", h.AddCollectionDocuments)
+			collections.POST("/:id/documents/remove", h.RemoveCollectionDocuments)
 		}
 
 		conversations := api.Group("/conversations")
-		conversations.Use(authMiddleware)
+		conversations.Use(authMiddleware, validID)
 		{
 			conversations.GET("", h.ListConversations)
 			conversations.POST("", h.CreateConversation)
 			conversations.GET("/:id/messages", h.GetConversationMessages)
+			conversations.GET("/:id/messages/export", h.ExportConversationMessages)
+			conversations.GET("/:id/summaries", h.ListConversationSummaries)
+			conversations.GET("/:id/participants", h.ListConversationParticipants)
+			conversations.POST("/:id/participants", h.InviteParticipant)
+			conversations.DELETE("/:id/participants/:username", h.RemoveParticipant)
+			conversations.POST("/:id/read", h.MarkConversationRead)
+			conversations.GET("/:id/stream", h.StreamConversation)
+		}
+
+		messages := api.Group("/messages")
+		messages.Use(authMiddleware, validID)
+		{
+			messages.POST("/:id/export", h.ExportMessage)
 		}
 
 		query := api.Group("/query")
 		query.Use(authMiddleware)
 		{
 			query.POST("", h.Query)
+			query.GET("/suggest", h.SuggestQueries)
+		}
+
+		widget := api.Group("/widget")
+		{
+			widget.POST("/tokens", authMiddleware, h.CreateWidgetToken)
+
+			// The widget's own routes take widget tokens only.
+			chat := widget.Group("")
+			chat.Use(middleware.WidgetAuthMiddleware(&cfg.Widget, h.Widgets, counter), validID)
+			{
+				chat.POST("/query", h.Query)
+				chat.POST("/conversations", h.CreateConversation)
+				chat.GET("/conversations/:id/messages", h.GetConversationMessages)
+			}
+		}
+
+		queries := api.Group("/queries")
+		queries.Use(authMiddleware, validID)
+		{
+			queries.POST("/:id/feedback", h.QueryFeedback)
+		}
+
+		notifications := api.Group("/notifications")
+		notifications.Use(authMiddleware)
+		{
+			notifications.GET("/preferences", h.GetNotificationPreferences)
+			notifications.PUT("/preferences", h.UpdateNotificationPreferences)
+		}
+
+		workspace := api.Group("/workspace")
+		workspace.Use(authMiddleware)
+		{
+			workspace.GET("/settings", h.GetWorkspaceSettings)
+			workspace.PATCH("/settings", middleware.AdminMiddleware(cfg.Auth.AdminUsers), h.UpdateWorkspaceSettings)
+		}
+
+		connectors := api.Group("/connectors")
+		connectors.Use(authMiddleware, validID)
+		{
+			connectors.POST("", h.CreateConnector)
+			connectors.GET("", h.ListConnectors)
+			connectors.GET("/:id", h.GetConnector)
+			connectors.PUT("/:id", h.UpdateConnector)
+			connectors.DELETE("/:id", h.DeleteConnector)
+			connectors.POST("/:id/sync", h.SyncConnector)
+			connectors.GET("/:id/resync-schedule", h.GetConnectorResyncSchedule)
+			connectors.PUT("/:id/resync-schedule", h.SetConnectorResyncSchedule)
+			connectors.DELETE("/:id/resync-schedule", h.DeleteConnectorResyncSchedule)
+		}
+
+		exports := api.Group("/export")
+		exports.Use(authMiddleware, validID)
+		{
+			exports.POST("", h.CreateKnowledgeBaseExport)
+			exports.GET("", h.ListKnowledgeBaseExports)
+			exports.GET("/:id", h.GetKnowledgeBaseExport)
+			exports.GET("/:id/stream", h.StreamKnowledgeBaseExport)
+			exports.GET("/:id/download", h.DownloadKnowledgeBaseExport)
+		}
+
+		events := api.Group("/events")
+		events.Use(authMiddleware)
+		{
+			events.GET("/stream", h.StreamEvents)
+		}
+
+		admin := api.Group("/admin")
+		admin.Use(authMiddleware, middleware.AdminMiddleware(cfg.Auth.AdminUsers), validID)
+		{
+			admin.POST("/webhooks", h.CreateWebhook)
+			admin.GET("/webhooks", h.ListWebhooks)
+			admin.DELETE("/webhooks/:id", h.DeleteWebhook)
+			admin.GET("/webhooks/:id/deliveries", h.ListWebhookDeliveries)
+			admin.GET("/stats", h.AdminStats)
+			admin.GET("/query-logs/export", h.ExportQueryLogs)
+			admin.GET("/access-review/export", h.ExportAccessReview)
+			admin.POST("/prompt-templates", h.CreatePromptTemplate)
+			admin.GET("/prompt-templates", h.ListPromptTemplates)
+			admin.GET("/prompt-templates/:id", h.GetPromptTemplate)
+			admin.PUT("/prompt-templates/:id", h.UpdatePromptTemplate)
+			admin.DELETE("/prompt-templates/:id", h.DeletePromptTemplate)
+			admin.GET("/prompt-templates/:id/versions", h.ListPromptTemplateVersions)
+			admin.POST("/curated-answers", h.CreateCuratedAnswer)
+			admin.GET("/curated-answers", h.ListCuratedAnswers)
+			admin.GET("/curated-answers/:id", h.GetCuratedAnswer)
+			admin.PUT("/curated-answers/:id", h.UpdateCuratedAnswer)
+			admin.DELETE("/curated-answers/:id", h.DeleteCuratedAnswer)
+			admin.POST("/glossary", h.CreateGlossaryTerm)
+			admin.GET("/glossary", h.ListGlossaryTerms)
+			admin.PUT("/glossary/:id", h.UpdateGlossaryTerm)
+			admin.DELETE("/glossary/:id", h.DeleteGlossaryTerm)
+			admin.POST("/redaction-rules", h.CreateRedactionRule)
+			admin.GET("/redaction-rules", h.ListRedactionRules)
+			admin.GET("/redaction-rules/:id", h.GetRedactionRule)
+			admin.PUT("/redaction-rules/:id", h.UpdateRedactionRule)
+			admin.DELETE("/redaction-rules/:id", h.DeleteRedactionRule)
+			admin.POST("/embedding-migrations", h.CreateEmbeddingMigration)
+			admin.GET("/embedding-migrations", h.ListEmbeddingMigrations)
+			admin.GET("/embedding-migrations/:id", h.GetEmbeddingMigration)
+			admin.POST("/evaluations", h.CreateEvaluation)
+			admin.GET("/evaluations", h.ListEvaluations)
+			admin.GET("/evaluations/:id", h.GetEvaluation)
+			admin.GET("/evaluations/:id/results", h.ListEvaluationResults)
+			admin.POST("/duplicate-reports", h.CreateDuplicateReport)
+			admin.GET("/duplicate-reports", h.ListDuplicateReports)
+			admin.GET("/duplicate-reports/:id", h.GetDuplicateReport)
+			admin.POST("/snapshots", h.CreateSnapshot)
+			admin.GET("/snapshots", h.ListSnapshots)
+			admin.GET("/snapshots/:id", h.GetSnapshot)
+			admin.GET("/snapshots/:id/documents", h.ListSnapshotDocuments)
+			admin.DELETE("/snapshots/:id", h.DeleteSnapshot)
+			admin.GET("/workspace/export", h.ExportWorkspace)
+			admin.POST("/workspace/imports", h.ImportWorkspace)
+			admin.GET("/workspace/imports", h.ListWorkspaceImports)
+			admin.GET("/workspace/imports/:id", h.GetWorkspaceImport)
+			admin.GET("/content-freshness", h.ContentFreshness)
+			admin.GET("/shadow-traffic", h.ShadowTraffic)
+			admin.GET("/core-backends", h.CoreBackends)
+			admin.POST("/service-tokens", h.CreateServiceToken)
+			admin.GET("/service-tokens", h.ListServiceTokens)
+			admin.POST("/service-tokens/:id/rotate", h.RotateServiceToken)
+			admin.DELETE("/service-tokens/:id", h.DeleteServiceToken)
+			admin.POST("/impersonations", h.CreateImpersonation)
+			admin.GET("/impersonations", h.ListImpersonations)
+			admin.GET("/impersonations/:id/requests", h.ListImpersonationRequests)
+			admin.DELETE("/impersonations/:id", h.RevokeImpersonation)
+			admin.PUT("/documents/:id/access-groups", h.SetDocumentAccessGroups)
 		}
 	}
 
+	graphQL := router.Group("/graphql")
+	graphQL.Use(authMiddleware)
+	{
+		graphQL.GET("", h.GraphQL)
+		graphQL.POST("", h.GraphQL)
+	}
+
+	// Without a token, anyone who reaches the gateway could complete
+	// uploads and read connector credentials, so the endpoints are left
+	// out unless explicitly allowed.
+	if cfg.Auth.InternalToken != "" || cfg.Auth.InternalInsecure {
+		internal := router.Group("/internal/v1")
+		internal.Use(middleware.InternalAuthMiddleware(cfg.Auth.InternalToken), validID)
+		internal.POST("/events", h.IngestEvent)
+		internal.GET("/connectors/:id/credentials", h.GetConnectorCredentials)
+		internal.PUT("/connectors/:id/credentials", h.UpdateConnectorCredentials)
+		internal.POST("/connectors/:id/files", h.SyncConnectorFile)
+		internal.POST("/documents/:id/complete", h.CompleteUpload)
+		internal.POST("/documents/:id/resync", h.ResyncDocument)
+		internal.POST("/documents/:id/children", h.RegisterArchiveEntry)
+		internal.POST("/trash/purge", h.PurgeTrash)
+	} else {
+		logger.Warn().Msg("AUTH_INTERNAL_TOKEN is not set; /internal endpoints are disabled")
+	}
+
 	router.GET("/healthz", h.Health)
 	router.GET("/readyz", h.Ready)
+	router.GET("/startupz", h.Started)
+	router.GET("/version", h.Version)
+	router.GET("/status", middleware.RateLimitMiddleware("status", cfg.StatusPage.RateLimit, cfg.StatusPage.RateWindow, counter), h.Status)
+
+	router.GET("/openapi.json", h.OpenAPISpec)
+	router.GET("/docs", h.SwaggerUI)
 }
@@ -0,0 +1,430 @@
+// Package app wires configuration, dependencies, handlers and routes into a
+// single gateway instance shared by the binary and tests.
+package app
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"time"
+
+	"kb-platform-gateway/internal/api/handlers"
+	"kb-platform-gateway/internal/api/middleware"
+	"kb-platform-gateway/internal/api/routes"
+	"kb-platform-gateway/internal/config"
+	"kb-platform-gateway/internal/gateway"
+	"kb-platform-gateway/internal/grpcserver"
+	"kb-platform-gateway/internal/repository"
+	"kb-platform-gateway/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+)
+
+// eventSubscriberBuffer is how many events an SSE subscriber may fall
+// behind before it starts missing events.
+const eventSubscriberBuffer = 64
+
+// trashScheduleTimeout bounds creating the trash purge schedule at startup.
+const trashScheduleTimeout = 10 * time.Second
+
+// Dependencies are the external stores and clients the gateway talks to.
+type Dependencies struct {
+	Repository repository.Repository
+	Core       services.CoreServiceInterface
+	S3         services.S3ClientInterface
+	Temporal   services.TemporalClientInterface
+	Qdrant     services.QdrantClientInterface
+	// Redis is optional; nil disables the features backed by it.
+	Redis services.RedisClientInterface
+	// Notifier is optional; nil disables email notifications.
+	Notifier services.NotifierInterface
+	// ShadowCore is optional; nil disables shadow traffic.
+	ShadowCore services.CoreServiceInterface
+}
+
+// App is a fully wired gateway.
+type App struct {
+	Config   *config.Config
+	Logger   zerolog.Logger
+	Deps     Dependencies
+	Handlers *handlers.Handlers
+	Router   *gin.Engine
+	// GRPCServer serves the gRPC API; main starts it when
+	// cfg.Server.GRPCEnabled is set.
+	GRPCServer *grpc.Server
+
+	closers []func()
+}
+
+// New connects to every dependency described by cfg and wires the gateway.
+// Call Close to release the connections.
+func New(cfg *config.Config, logger zerolog.Logger) (*App, error) {
+	var closers []func()
+	closeAll := func() {
+		for i := len(closers) - 1; i >= 0; i-- {
+			closers[i]()
+		}
+	}
+
+	repo, err := repository.NewPostgresRepository(&cfg.Database)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize repository: %w", err)
+	}
+	closers = append(closers, func() { repo.Close() })
+
+	coreClient, err := services.NewCoreService(&cfg.Services)
+	if err != nil {
+		closeAll()
+		return nil, fmt.Errorf("failed to create Python Core client: %w", err)
+	}
+	if closer, ok := coreClient.(io.Closer); ok {
+		closers = append(closers, func() { closer.Close() })
+	}
+
+	var shadowCore services.CoreServiceInterface
+	if cfg.Shadow.Active() {
+		client, err := services.NewCoreService(cfg.Shadow.CoreConfig(cfg.Services))
+		if err != nil {
+			closeAll()
+			return nil, fmt.Errorf("failed to create shadow core client: %w", err)
+		}
+		if closer, ok := client.(io.Closer); ok {
+			closers = append(closers, func() { closer.Close() })
+		}
+		shadowCore = client
+	}
+
+	s3Client, err := services.NewS3Client(&cfg.S3)
+	if err != nil {
+		closeAll()
+		return nil, fmt.Errorf("failed to create S3 client: %w", err)
+	}
+
+	temporalClient, err := services.NewTemporalClient(&cfg.Temporal)
+	if err != nil {
+		closeAll()
+		return nil, fmt.Errorf("failed to create Temporal client: %w", err)
+	}
+	closers = append(closers, temporalClient.Close)
+
+	qdrantClient, err := services.NewQdrantClient(&cfg.Qdrant)
+	if err != nil {
+		closeAll()
+		return nil, fmt.Errorf("failed to create Qdrant client: %w", err)
+	}
+	closers = append(closers, func() { qdrantClient.Close() })
+
+	var redisClient services.RedisClientInterface
+	if cfg.Redis.Enabled {
+		client, err := services.NewRedisClient(&cfg.Redis)
+		if err != nil {
+			closeAll()
+			return nil, fmt.Errorf("failed to create Redis client: %w", err)
+		}
+		closers = append(closers, func() { client.Close() })
+		redisClient = client
+	}
+
+	notifier, err := services.NewNotifier(&cfg.Notifications)
+	if err != nil {
+		closeAll()
+		return nil, fmt.Errorf("failed to create notifier: %w", err)
+	}
+
+	a, err := NewWithDependencies(cfg, Dependencies{
+		Repository: repo,
+		Core:       coreClient,
+		S3:         s3Client,
+		Temporal:   temporalClient,
+		Qdrant:     qdrantClient,
+		Redis:      redisClient,
+		Notifier:   notifier,
+		ShadowCore: shadowCore,
+	}, logger)
+	if err != nil {
+		closeAll()
+		return nil, err
+	}
+	a.closers = append(closers, a.closers...)
+
+	return a, nil
+}
+
+// NewWithDependencies wires the gateway around already constructed
+// dependencies, e.g. mocks in tests. The caller owns their lifecycle, but
+// must still call Close to stop the gateway's own background workers.
+func NewWithDependencies(cfg *config.Config, deps Dependencies, logger zerolog.Logger) (*App, error) {
+	webhooks := services.NewWebhookDispatcher(&cfg.Webhooks, deps.Repository, logger)
+
+	events := services.NewEventHub(eventSubscriberBuffer)
+
+	h, err := handlers.NewHandlers(deps.Repository, deps.Core, deps.S3, deps.Temporal, deps.Qdrant, deps.Redis, webhooks, events, logger)
+	if err != nil {
+		webhooks.Close()
+		return nil, fmt.Errorf("failed to create handlers: %w", err)
+	}
+	closers := []func(){webhooks.Close}
+
+	if deps.Notifier != nil {
+		notifications := services.NewNotificationService(&cfg.Notifications, deps.Repository, deps.Notifier, logger)
+		h.Notifications = notifications
+		closers = append(closers, notifications.Close)
+	}
+
+	if deps.Qdrant != nil && deps.Temporal != nil {
+		migrator := services.NewEmbeddingMigrator(cfg, deps.Repository, deps.Qdrant, deps.Temporal, logger)
+		migrator.Start()
+		h.Migrations = migrator
+		closers = append(closers, migrator.Close)
+	}
+
+	if router, ok := deps.Core.(services.CoreRouterInterface); ok {
+		h.CoreRouter = router
+	}
+
+	h.Features = cfg.Features()
+	h.ConversationEvents = services.NewEventHub(eventSubscriberBuffer)
+	h.AdminUsers = cfg.Auth.AdminUsers
+	h.Curated = services.NewCuratedAnswers(deps.Repository)
+	h.Glossary = services.NewGlossary(deps.Repository)
+	h.Redactions = services.NewRedactions(deps.Repository)
+	h.Reads = services.NewReadCache(&cfg.ReadCache)
+
+	if cfg.Dedup.Enabled() {
+		h.Answers = services.NewAnswerCache(&cfg.Dedup, deps.Repository)
+	}
+
+	if cfg.Impersonation.Enabled() {
+		h.Impersonation = services.NewImpersonationTokens(&cfg.Impersonation)
+	}
+
+	if cfg.OIDC.Enabled() {
+		h.OIDC = services.NewOIDCVerifier(&cfg.OIDC)
+	}
+
+	if cfg.Widget.Enabled() {
+		h.Widgets = services.NewWidgetTokens(&cfg.Widget)
+	}
+
+	if cfg.Summary.Enabled() && cfg.Services.PythonCoreTransport == "grpc" {
+		// The core's gRPC service cannot summarize, so every summary
+		// would fail.
+		logger.Warn().Msg("Conversation summaries are not supported over the gRPC core transport; they are disabled")
+	} else if cfg.Summary.Enabled() {
+		summaries := services.NewConversationSummarizer(&cfg.Summary, deps.Core, deps.Repository, logger)
+		h.Summaries = summaries
+		closers = append(closers, summaries.Close)
+	}
+
+	if cfg.Conversations.Enabled() {
+		conversations, err := services.NewConversationLocks(&cfg.Conversations, deps.Redis)
+		if err != nil {
+			for i := len(closers) - 1; i >= 0; i-- {
+				closers[i]()
+			}
+			return nil, fmt.Errorf("failed to create conversation locks: %w", err)
+		}
+		h.Conversations = conversations
+	}
+
+	// The purge also retries deletions that failed part way, so it is
+	// scheduled with or without the trash.
+	if deps.Temporal != nil && cfg.Trash.PurgeCron != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), trashScheduleTimeout)
+		err := deps.Temporal.ScheduleTrashPurge(ctx, cfg.Trash.PurgeCron)
+		cancel()
+		if err != nil {
+			for i := len(closers) - 1; i >= 0; i-- {
+				closers[i]()
+			}
+			return nil, fmt.Errorf("failed to schedule trash purge: %w", err)
+		}
+	}
+	if cfg.Trash.Enabled() {
+		h.TrashRetention = cfg.Trash.Retention
+	}
+	h.Workers = services.NewWorkerPool(cfg.Workers.PoolSize)
+	h.Reviewers = cfg.Review.Reviewers
+	h.Access = &gateway.DocumentAccess{Groups: cfg.Access.Groups, Admins: cfg.Auth.AdminUsers}
+	if cfg.Uploads.ProxyEnabled {
+		h.ProxyUploads = &gateway.ProxyUploadLimits{
+			MaxSize:      cfg.Uploads.ProxyMaxSize,
+			ContentTypes: cfg.Uploads.ProxyContentTypes,
+			PartSize:     cfg.Uploads.ProxyPartSize,
+			Concurrency:  cfg.Uploads.ProxyConcurrency,
+		}
+	}
+	if cfg.Uploads.PolicyEnabled() {
+		h.UploadPolicy = &gateway.UploadPolicy{
+			MaxSize:      cfg.Uploads.MaxFileSize,
+			ContentTypes: cfg.Uploads.AllowedContentTypes,
+			Extensions:   cfg.Uploads.AllowedExtensions,
+		}
+	}
+	if cfg.Scan.Enabled() {
+		h.Scanner = services.NewClamAVScanner(&cfg.Scan)
+		h.Quarantine = cfg.Scan.Quarantine
+	}
+
+	if deps.ShadowCore != nil {
+		shadow := services.NewShadowMirror(&cfg.Shadow, deps.ShadowCore, logger)
+		h.Shadow = shadow
+		closers = append(closers, shadow.Close)
+	}
+
+	if cfg.Connectors.Enabled() && deps.Temporal != nil {
+		connectors, err := services.NewConnectorService(&cfg.Connectors, deps.Repository, deps.Temporal, logger)
+		if err != nil {
+			for i := len(closers) - 1; i >= 0; i-- {
+				closers[i]()
+			}
+			return nil, fmt.Errorf("failed to create connector service: %w", err)
+		}
+		connectors.Start()
+		h.Connectors = connectors
+		closers = append(closers, connectors.Close)
+	}
+
+	sources := services.NewSourceChecker(&cfg.Freshness, deps.Repository, h.Workers, logger)
+	sources.Start()
+	closers = append(closers, sources.Close)
+
+	// Evaluations always ask the core, so the service has no Curated or
+	// Answers, and never run in a conversation, so it has no Summaries.
+	// Duplicate detection, workspace imports and tag updates need none of
+	// them either. Clients are served from h.Gateway() instead.
+	svc := &gateway.Service{
+		Repository:     deps.Repository,
+		CoreClient:     deps.Core,
+		S3Client:       deps.S3,
+		Temporal:       deps.Temporal,
+		QdrantClient:   deps.Qdrant,
+		Webhooks:       webhooks,
+		Migrations:     h.Migrations,
+		Shadow:         h.Shadow,
+		Reads:          h.Reads,
+		UploadPolicy:   h.UploadPolicy,
+		TrashRetention: h.TrashRetention,
+		Reviewers:      h.Reviewers,
+		Access:         h.Access,
+		Logger:         logger,
+	}
+	evaluations := gateway.NewEvaluationRunner(svc, &cfg.Evaluations)
+	h.Evaluations = evaluations
+	closers = append(closers, evaluations.Close)
+	duplicates := gateway.NewDuplicateDetector(svc)
+	h.Duplicates = duplicates
+	closers = append(closers, duplicates.Close)
+	workspaceImports := gateway.NewWorkspaceImporter(svc, cfg.Uploads.ProxyPartSize, cfg.Uploads.ProxyConcurrency)
+	h.WorkspaceImports = workspaceImports
+	closers = append(closers, workspaceImports.Close)
+	tags := gateway.NewTagUpdater(svc)
+	h.Tags = tags
+	closers = append(closers, tags.Close)
+
+	// The core is probed through its own health check, which also reports
+	// its dependencies.
+	probes := healthProbes(deps)
+	delete(probes, "python_core")
+	h.Probes = services.NewHealthProbes(&cfg.Health, deps.Core, probes, schemaProbe(deps.Repository))
+
+	router := gin.New()
+
+	// The status page is served from the monitor's checks, so it runs even
+	// without an alert channel.
+	if cfg.Alerts.Enabled() || cfg.StatusPage.Enabled {
+		monitor := services.NewOpsMonitor(&cfg.Alerts, services.NewAlerters(&cfg.Alerts), healthProbes(deps), deps.Repository, logger)
+		monitor.Start()
+		h.Alerts = monitor
+		closers = append(closers, monitor.Close)
+		// Registered before Recovery so recovered panics count as errors.
+		router.Use(middleware.StatusRecorderMiddleware(monitor))
+		if cfg.StatusPage.Enabled {
+			h.StatusPage = services.NewStatusPage(&cfg.StatusPage, monitor)
+		}
+	}
+
+	router.Use(gin.Recovery())
+	router.Use(middleware.RequestIDMiddleware())
+	router.Use(middleware.VersionMiddleware())
+	router.Use(middleware.LoggerMiddleware(logger))
+	router.Use(middleware.CORSMiddleware())
+	if cfg.Scheduler.Enabled() {
+		router.Use(middleware.SchedulerMiddleware(services.NewRequestScheduler(cfg.Scheduler.MaxInFlight), cfg.Scheduler.QueueTimeout))
+	}
+
+	routes.SetupRoutes(router, cfg, h, logger)
+
+	grpcServer := grpcserver.NewGRPCServer(h.Gateway())
+
+	return &App{
+		Config:     cfg,
+		Logger:     logger,
+		Deps:       deps,
+		Handlers:   h,
+		Router:     router,
+		GRPCServer: grpcServer,
+		closers:    closers,
+	}, nil
+}
+
+// Close releases the connections opened by New, in reverse order.
+func (a *App) Close() {
+	for i := len(a.closers) - 1; i >= 0; i-- {
+		a.closers[i]()
+	}
+	a.closers = nil
+}
+
+// healthProbes returns a probe for every dependency in deps, for the ops
+// monitor.
+func healthProbes(deps Dependencies) map[string]services.HealthProbe {
+	probes := make(map[string]services.HealthProbe)
+	if db, ok := deps.Repository.(interface{ DB() *sql.DB }); ok {
+		probes["database"] = func(ctx context.Context) error { return db.DB().PingContext(ctx) }
+	}
+	if deps.Core != nil {
+		probes["python_core"] = func(ctx context.Context) error {
+			_, err := deps.Core.HealthCheck(ctx)
+			return err
+		}
+	}
+	if deps.Temporal != nil {
+		probes["temporal"] = deps.Temporal.HealthCheck
+	}
+	if deps.Qdrant != nil {
+		probes["qdrant"] = func(ctx context.Context) error {
+			_, err := deps.Qdrant.CountVectors(ctx)
+			return err
+		}
+	}
+	if deps.Redis != nil {
+		probes["redis"] = deps.Redis.HealthCheck
+	}
+	return probes
+}
+
+// schemaProbe checks that the database schema is at the version the
+// gateway expects, for the startup probe. It returns nil for repositories
+// without a schema version, e.g. mocks in tests.
+func schemaProbe(repo repository.Repository) services.HealthProbe {
+	versioned, ok := repo.(interface {
+		AppliedSchemaVersion(ctx context.Context) (int, error)
+	})
+	if !ok {
+		return nil
+	}
+	return func(ctx context.Context) error {
+		version, err := versioned.AppliedSchemaVersion(ctx)
+		if err != nil {
+			return err
+		}
+		if version != repository.SchemaVersion {
+			return fmt.Errorf("version %d, expected %d", version, repository.SchemaVersion)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,1526 @@
+package app_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"kb-platform-gateway/internal/api/docs"
+	"kb-platform-gateway/internal/api/middleware"
+	"kb-platform-gateway/internal/app"
+	"kb-platform-gateway/internal/buildinfo"
+	"kb-platform-gateway/internal/config"
+	"kb-platform-gateway/internal/gateway"
+	kbgatewayv1 "kb-platform-gateway/internal/gen/kbgateway/v1"
+	"kb-platform-gateway/internal/grpcserver"
+	"kb-platform-gateway/internal/models"
+	repomocks "kb-platform-gateway/internal/repository/mocks"
+	"kb-platform-gateway/internal/services/mocks"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// conversationID is a well-formed conversation ID for routes taking :id.
+const conversationID = "6f1c2b7e-3d4a-4e5f-9a8b-1c2d3e4f5a6b"
+
+func newTestApp(t *testing.T) (*app.App, *mocks.MockCoreService) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	core := mocks.NewMockCoreService()
+	cfg := &config.Config{Auth: config.AuthConfig{AdminUsers: []string{"admin"}, InternalToken: "internal-token"}}
+	a, err := app.NewWithDependencies(cfg, app.Dependencies{
+		Repository: repomocks.NewMockRepository(),
+		Core:       core,
+		S3:         mocks.NewMockS3Client(),
+		Temporal:   mocks.NewMockTemporalClient(),
+		Qdrant:     mocks.NewMockQdrantClient(),
+	}, zerolog.Nop())
+	require.NoError(t, err)
+	t.Cleanup(a.Close)
+
+	return a, core
+}
+
+func TestNewWithDependencies(t *testing.T) {
+	t.Run("Healthz_Routed", func(t *testing.T) {
+		a, _ := newTestApp(t)
+
+		req, _ := http.NewRequest("GET", "/healthz", nil)
+		resp := httptest.NewRecorder()
+		a.Router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.NotEmpty(t, resp.Header().Get("X-Request-ID"))
+	})
+
+	t.Run("RequestID_Reused", func(t *testing.T) {
+		a, _ := newTestApp(t)
+
+		req, _ := http.NewRequest("GET", "/healthz", nil)
+		req.Header.Set("X-Request-ID", "trace-42.a_b")
+		resp := httptest.NewRecorder()
+		a.Router.ServeHTTP(resp, req)
+
+		assert.Equal(t, "trace-42.a_b", resp.Header().Get("X-Request-ID"))
+	})
+
+	t.Run("RequestID_InvalidReplaced", func(t *testing.T) {
+		for _, id := range []string{"bad id\nlevel=error", "<script>", strings.Repeat("a", 129)} {
+			a, _ := newTestApp(t)
+
+			req, _ := http.NewRequest("GET", "/healthz", nil)
+			req.Header.Set("X-Request-ID", id)
+			resp := httptest.NewRecorder()
+			a.Router.ServeHTTP(resp, req)
+
+			got := resp.Header().Get("X-Request-ID")
+			assert.NotEqual(t, id, got)
+			assert.Len(t, got, 36, "a generated UUID replaces %q", id)
+		}
+	})
+
+	t.Run("Version_Routed", func(t *testing.T) {
+		a, _ := newTestApp(t)
+
+		req, _ := http.NewRequest("GET", "/version", nil)
+		resp := httptest.NewRecorder()
+		a.Router.ServeHTTP(resp, req)
+
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.True(t, strings.HasPrefix(resp.Header().Get(buildinfo.Header), buildinfo.Version))
+		var version models.VersionResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &version))
+		assert.Equal(t, buildinfo.Version, version.Version)
+		assert.Equal(t, runtime.Version(), version.GoVersion)
+		assert.Equal(t, []string{}, version.Features)
+	})
+
+	t.Run("Readyz_UsesInjectedCore", func(t *testing.T) {
+		a, core := newTestApp(t)
+		core.On("HealthCheck", mock.Anything).Return(map[string]string{"python_core": "ok"}, nil)
+		a.Deps.Temporal.(*mocks.MockTemporalClient).On("HealthCheck", mock.Anything).Return(nil)
+		a.Deps.Qdrant.(*mocks.MockQdrantClient).On("CountVectors", mock.Anything).Return(uint64(0), nil)
+
+		req, _ := http.NewRequest("GET", "/readyz", nil)
+		resp := httptest.NewRecorder()
+		a.Router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		core.AssertExpectations(t)
+	})
+
+	t.Run("Readyz_NonCriticalDependencyDown", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		core := mocks.NewMockCoreService()
+		core.On("HealthCheck", mock.Anything).Return(map[string]string{}, nil)
+		temporal := mocks.NewMockTemporalClient()
+		temporal.On("HealthCheck", mock.Anything).Return(errors.New("temporal unreachable"))
+		qdrant := mocks.NewMockQdrantClient()
+		qdrant.On("CountVectors", mock.Anything).Return(uint64(0), nil)
+		cfg := &config.Config{Health: config.HealthConfig{NonCriticalDependencies: []string{"temporal"}}}
+		a, err := app.NewWithDependencies(cfg, app.Dependencies{
+			Repository: repomocks.NewMockRepository(),
+			Core:       core,
+			S3:         mocks.NewMockS3Client(),
+			Temporal:   temporal,
+			Qdrant:     qdrant,
+		}, zerolog.Nop())
+		require.NoError(t, err)
+		defer a.Close()
+
+		for _, path := range []string{"/readyz", "/startupz"} {
+			req, _ := http.NewRequest("GET", path, nil)
+			resp := httptest.NewRecorder()
+			a.Router.ServeHTTP(resp, req)
+
+			assert.Equal(t, http.StatusOK, resp.Code, path)
+			var readiness models.ReadinessResponse
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &readiness))
+			assert.Equal(t, "temporal unreachable", readiness.Dependencies["temporal"], path)
+		}
+	})
+
+	t.Run("Documents_RequireAuth", func(t *testing.T) {
+		a, _ := newTestApp(t)
+
+		req, _ := http.NewRequest("GET", "/api/v1/documents", nil)
+		resp := httptest.NewRecorder()
+		a.Router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	})
+
+	t.Run("Admin_RequiresAdminUser", func(t *testing.T) {
+		a, _ := newTestApp(t)
+
+		req, _ := http.NewRequest("GET", "/api/v1/admin/webhooks", nil)
+		req.Header.Set("x-user-name", "alice")
+		resp := httptest.NewRecorder()
+		a.Router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+	})
+}
+
+func TestConversationSummaries(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	newApp := func(t *testing.T, transport string) *app.App {
+		cfg := &config.Config{
+			Services: config.ServicesConfig{PythonCoreTransport: transport},
+			Summary:  config.SummaryConfig{MessageThreshold: 50, RecentMessages: 10, Timeout: time.Minute},
+		}
+		a, err := app.NewWithDependencies(cfg, app.Dependencies{
+			Repository: repomocks.NewMockRepository(),
+			Core:       mocks.NewMockCoreService(),
+			S3:         mocks.NewMockS3Client(),
+			Temporal:   mocks.NewMockTemporalClient(),
+			Qdrant:     mocks.NewMockQdrantClient(),
+		}, zerolog.Nop())
+		require.NoError(t, err)
+		t.Cleanup(a.Close)
+		return a
+	}
+
+	t.Run("Enabled", func(t *testing.T) {
+		a := newApp(t, "http")
+
+		assert.NotNil(t, a.Handlers.Summaries)
+		assert.Contains(t, a.Config.Features(), "summaries")
+	})
+
+	t.Run("DisabledOverGRPC", func(t *testing.T) {
+		// The gRPC core cannot summarize.
+		a := newApp(t, "grpc")
+
+		assert.Nil(t, a.Handlers.Summaries)
+		assert.NotContains(t, a.Config.Features(), "summaries")
+	})
+}
+
+// grpcClient serves a's gRPC API over an in-memory connection and returns
+// a client for it.
+func grpcClient(t *testing.T, a *app.App) kbgatewayv1.GatewayServiceClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	go a.GRPCServer.Serve(lis)
+	t.Cleanup(a.GRPCServer.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return kbgatewayv1.NewGatewayServiceClient(conn)
+}
+
+// fakeClamd answers one scan with reply, after reading the streamed file.
+func fakeClamd(t *testing.T, reply string) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		if _, err := r.ReadString(0); err != nil {
+			return
+		}
+		for {
+			var size uint32
+			if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+				return
+			}
+			if size == 0 {
+				break
+			}
+			if _, err := io.CopyN(io.Discard, r, int64(size)); err != nil {
+				return
+			}
+		}
+		io.WriteString(conn, reply+"\x00")
+	}()
+	return ln.Addr().String()
+}
+
+// TestGRPCAPI checks that the gRPC API is served by the same fully wired
+// service as the REST API.
+func TestGRPCAPI(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	newApp := func(t *testing.T, cfg *config.Config) (*app.App, kbgatewayv1.GatewayServiceClient) {
+		t.Helper()
+		a, err := app.NewWithDependencies(cfg, app.Dependencies{
+			Repository: repomocks.NewMockRepository(),
+			Core:       mocks.NewMockCoreService(),
+			S3:         mocks.NewMockS3Client(),
+			Temporal:   mocks.NewMockTemporalClient(),
+			Qdrant:     mocks.NewMockQdrantClient(),
+		}, zerolog.Nop())
+		require.NoError(t, err)
+		t.Cleanup(a.Close)
+		return a, grpcClient(t, a)
+	}
+	user := metadata.AppendToOutgoingContext(context.Background(), grpcserver.UserMetadataKey, "alice")
+
+	t.Run("CompleteUpload_InfectedQuarantined", func(t *testing.T) {
+		cfg := &config.Config{Scan: config.ScanConfig{
+			ClamAVAddr: fakeClamd(t, "stream: Eicar-Signature FOUND"),
+			Timeout:    5 * time.Second,
+			Quarantine: true,
+		}}
+		a, client := newApp(t, cfg)
+		repo := a.Deps.Repository.(*repomocks.MockRepository)
+		repo.On("GetDocument", mock.Anything, "doc-1").Return(&models.Document{ID: "doc-1", S3Key: "documents/doc-1/a.pdf", Filename: "a.pdf", Status: "pending", UploadedBy: "alice"}, nil)
+		repo.On("SetDocumentScan", mock.Anything, "doc-1", "quarantine/documents/doc-1/a.pdf", mock.MatchedBy(func(scan *models.DocumentScan) bool {
+			return scan.Result == models.ScanInfected && scan.Signature == "Eicar-Signature"
+		})).Return(nil)
+		repo.On("UpdateDocumentStatus", mock.Anything, "doc-1", "rejected", "Malware detected: Eicar-Signature").Return(nil)
+		repo.On("CreateDocumentEvent", mock.Anything, mock.Anything).Return(nil)
+		repo.On("ListWebhooksForEvent", mock.Anything, mock.Anything).Return(nil, nil).Maybe()
+		s3 := a.Deps.S3.(*mocks.MockS3Client)
+		s3.On("ObjectExists", mock.Anything, "documents/doc-1/a.pdf").Return(true, nil)
+		s3.On("CopyObject", mock.Anything, "documents/doc-1/a.pdf", "scanned/documents/doc-1/a.pdf").Return(nil)
+		s3.On("GetObject", mock.Anything, "scanned/documents/doc-1/a.pdf").Return(io.NopCloser(strings.NewReader("X5O!P%@AP")), nil)
+		s3.On("CopyObject", mock.Anything, "scanned/documents/doc-1/a.pdf", "quarantine/documents/doc-1/a.pdf").Return(nil)
+		s3.On("DeleteObject", mock.Anything, "scanned/documents/doc-1/a.pdf").Return(nil)
+		s3.On("DeleteObject", mock.Anything, "documents/doc-1/a.pdf").Return(nil)
+		temporal := a.Deps.Temporal.(*mocks.MockTemporalClient)
+		temporal.On("CancelWorkflow", mock.Anything, "upload-doc-1").Return(nil)
+
+		_, err := client.CompleteUpload(user, &kbgatewayv1.CompleteUploadRequest{Id: "doc-1"})
+
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		temporal.AssertNotCalled(t, "SignalUploadComplete", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		repo.AssertExpectations(t)
+		s3.AssertExpectations(t)
+	})
+
+	t.Run("Query_Redacted", func(t *testing.T) {
+		a, client := newApp(t, &config.Config{})
+		upstream := make(chan models.SSEEvent, 2)
+		upstream <- models.SSEEvent{Type: "chunk", Content: "Project Falcon ships in May"}
+		upstream <- models.SSEEvent{Type: "end", ID: "q-1"}
+		close(upstream)
+		a.Deps.Core.(*mocks.MockCoreService).On("Query", mock.Anything, mock.Anything).Return((<-chan models.SSEEvent)(upstream), nil)
+		repo := a.Deps.Repository.(*repomocks.MockRepository)
+		repo.On("ListEnabledCuratedAnswers", mock.Anything).Return(nil, nil)
+		repo.On("ListAllGlossaryTerms", mock.Anything).Return(nil, nil)
+		repo.On("ListEnabledRedactionRules", mock.Anything).Return([]*models.RedactionRule{
+			{Type: models.RedactionKeywords, Keywords: []string{"Falcon"}, Placeholder: "[CODENAME]"},
+		}, nil)
+		repo.On("CreateQueryLog", mock.Anything, mock.Anything).Return(nil)
+		repo.On("ListWebhooksForEvent", mock.Anything, mock.Anything).Return(nil, nil).Maybe()
+
+		stream, err := client.Query(user, &kbgatewayv1.QueryRequest{Query: "when does it ship?"})
+		require.NoError(t, err)
+		var answer strings.Builder
+		for {
+			event, err := stream.Recv()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			answer.WriteString(event.GetContent())
+		}
+
+		assert.Equal(t, "Project [CODENAME] ships in May", answer.String())
+	})
+
+	t.Run("Query_Curated", func(t *testing.T) {
+		a, client := newApp(t, &config.Config{})
+		repo := a.Deps.Repository.(*repomocks.MockRepository)
+		repo.On("ListEnabledCuratedAnswers", mock.Anything).Return([]*models.CuratedAnswer{
+			{ID: "cur-1", Pattern: "How do I reset my password?", MatchType: models.CuratedMatchExact, Answer: "Use the self-service portal.", Enabled: true},
+		}, nil)
+		repo.On("ListAllGlossaryTerms", mock.Anything).Return(nil, nil)
+		repo.On("ListEnabledRedactionRules", mock.Anything).Return(nil, nil)
+		repo.On("CreateQueryLog", mock.Anything, mock.Anything).Return(nil)
+		repo.On("ListWebhooksForEvent", mock.Anything, mock.Anything).Return(nil, nil).Maybe()
+
+		stream, err := client.Query(user, &kbgatewayv1.QueryRequest{Query: "How do I reset my password?"})
+		require.NoError(t, err)
+		var types []string
+		var answer strings.Builder
+		for {
+			event, err := stream.Recv()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			types = append(types, event.GetType())
+			answer.WriteString(event.GetContent())
+		}
+
+		assert.Equal(t, []string{"start", "chunk", "end"}, types)
+		assert.Equal(t, "Use the self-service portal.", answer.String())
+		a.Deps.Core.(*mocks.MockCoreService).AssertNotCalled(t, "Query", mock.Anything, mock.Anything)
+		repo.AssertCalled(t, "ListAllGlossaryTerms", mock.Anything)
+	})
+
+	t.Run("Query_SharedWithParticipants", func(t *testing.T) {
+		a, client := newApp(t, &config.Config{})
+		upstream := make(chan models.SSEEvent, 2)
+		upstream <- models.SSEEvent{Type: "chunk", Content: "Thirty days."}
+		upstream <- models.SSEEvent{Type: "end", ID: "q-1"}
+		close(upstream)
+		a.Deps.Core.(*mocks.MockCoreService).On("Query", mock.Anything, mock.Anything).Return((<-chan models.SSEEvent)(upstream), nil)
+		repo := a.Deps.Repository.(*repomocks.MockRepository)
+		repo.On("GetConversation", mock.Anything, conversationID).Return(&models.Conversation{ID: conversationID, CreatedBy: "alice"}, nil)
+		repo.On("ListEnabledCuratedAnswers", mock.Anything).Return(nil, nil)
+		repo.On("ListAllGlossaryTerms", mock.Anything).Return(nil, nil)
+		repo.On("ListEnabledRedactionRules", mock.Anything).Return(nil, nil)
+		repo.On("CreateQueryLog", mock.Anything, mock.Anything).Return(nil)
+		repo.On("ListWebhooksForEvent", mock.Anything, mock.Anything).Return(nil, nil).Maybe()
+		shared, cancel := a.Handlers.ConversationEvents.Subscribe("conversation:" + conversationID)
+		defer cancel()
+
+		stream, err := client.Query(user, &kbgatewayv1.QueryRequest{Query: "refunds?", ConversationId: conversationID})
+		require.NoError(t, err)
+		for {
+			if _, err := stream.Recv(); err != nil {
+				require.ErrorIs(t, err, io.EOF)
+				break
+			}
+		}
+
+		event := <-shared
+		assert.Equal(t, models.EventConversationMessage, event.Type)
+		assert.Equal(t, "Thirty days.", event.Data["answer"])
+	})
+
+	t.Run("Query_PreviousAnswer", func(t *testing.T) {
+		a, client := newApp(t, &config.Config{Dedup: config.DedupConfig{Window: time.Hour, Similarity: 80, MaxCandidates: 10}})
+		repo := a.Deps.Repository.(*repomocks.MockRepository)
+		repo.On("ListEnabledCuratedAnswers", mock.Anything).Return(nil, nil)
+		repo.On("ListAllGlossaryTerms", mock.Anything).Return(nil, nil)
+		repo.On("ListEnabledRedactionRules", mock.Anything).Return(nil, nil)
+		repo.On("ListAnsweredQuestions", mock.Anything, mock.Anything, mock.Anything, 10).Return([]*models.AnsweredQuestion{
+			{QueryID: "q-0", Question: "How long do refunds take?", Answer: "Thirty days."},
+		}, nil)
+		repo.On("CreateQueryLog", mock.Anything, mock.Anything).Return(nil)
+		repo.On("ListWebhooksForEvent", mock.Anything, mock.Anything).Return(nil, nil).Maybe()
+
+		stream, err := client.Query(user, &kbgatewayv1.QueryRequest{Query: "How long do refunds take?"})
+		require.NoError(t, err)
+		var answer strings.Builder
+		for {
+			event, err := stream.Recv()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			answer.WriteString(event.GetContent())
+		}
+
+		assert.Equal(t, "Thirty days.", answer.String())
+		a.Deps.Core.(*mocks.MockCoreService).AssertNotCalled(t, "Query", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Query_ConversationSummary", func(t *testing.T) {
+		a, client := newApp(t, &config.Config{Summary: config.SummaryConfig{MessageThreshold: 50, RecentMessages: 10, Timeout: time.Minute}})
+		upstream := make(chan models.SSEEvent)
+		close(upstream)
+		core := a.Deps.Core.(*mocks.MockCoreService)
+		core.On("Query", mock.Anything, mock.MatchedBy(func(req models.CoreQueryRequest) bool {
+			return req.Context != nil && req.Context.Summary == "They asked about refunds."
+		})).Return((<-chan models.SSEEvent)(upstream), nil)
+		repo := a.Deps.Repository.(*repomocks.MockRepository)
+		repo.On("GetConversation", mock.Anything, conversationID).Return(&models.Conversation{ID: conversationID, CreatedBy: "alice"}, nil)
+		repo.On("ListEnabledCuratedAnswers", mock.Anything).Return(nil, nil)
+		repo.On("ListAllGlossaryTerms", mock.Anything).Return(nil, nil)
+		repo.On("ListEnabledRedactionRules", mock.Anything).Return(nil, nil)
+		repo.On("GetLatestConversationSummary", mock.Anything, conversationID).Return(&models.ConversationSummary{
+			ConversationID: conversationID, Version: 1, Summary: "They asked about refunds.", MessageCount: 60,
+		}, nil)
+		repo.On("GetMessagesByConversationID", mock.Anything, conversationID, mock.Anything, 60).Return([]*models.Message{
+			{Role: "user", Content: "And exchanges?"},
+		}, nil)
+		repo.On("CreateQueryLog", mock.Anything, mock.Anything).Return(nil)
+		repo.On("ListWebhooksForEvent", mock.Anything, mock.Anything).Return(nil, nil).Maybe()
+
+		stream, err := client.Query(user, &kbgatewayv1.QueryRequest{Query: "How long?", ConversationId: conversationID})
+		require.NoError(t, err)
+		for {
+			if _, err := stream.Recv(); err != nil {
+				require.ErrorIs(t, err, io.EOF)
+				break
+			}
+		}
+
+		core.AssertExpectations(t)
+	})
+}
+
+func TestTrash(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{Trash: config.TrashConfig{Retention: 30 * 24 * time.Hour, PurgeCron: "0 3 * * *"}}
+
+	t.Run("SchedulesPurge", func(t *testing.T) {
+		temporal := mocks.NewMockTemporalClient()
+		temporal.On("ScheduleTrashPurge", mock.Anything, "0 3 * * *").Return(nil)
+
+		a, err := app.NewWithDependencies(cfg, app.Dependencies{
+			Repository: repomocks.NewMockRepository(),
+			Core:       mocks.NewMockCoreService(),
+			S3:         mocks.NewMockS3Client(),
+			Temporal:   temporal,
+			Qdrant:     mocks.NewMockQdrantClient(),
+		}, zerolog.Nop())
+		require.NoError(t, err)
+		defer a.Close()
+
+		temporal.AssertExpectations(t)
+		assert.Equal(t, 30*24*time.Hour, a.Handlers.TrashRetention)
+		assert.Contains(t, cfg.Features(), "trash")
+	})
+
+	t.Run("SchedulesPurgeWithoutTrash", func(t *testing.T) {
+		temporal := mocks.NewMockTemporalClient()
+		temporal.On("ScheduleTrashPurge", mock.Anything, "0 3 * * *").Return(nil)
+
+		a, err := app.NewWithDependencies(&config.Config{Trash: config.TrashConfig{PurgeCron: "0 3 * * *"}}, app.Dependencies{
+			Repository: repomocks.NewMockRepository(),
+			Core:       mocks.NewMockCoreService(),
+			S3:         mocks.NewMockS3Client(),
+			Temporal:   temporal,
+			Qdrant:     mocks.NewMockQdrantClient(),
+		}, zerolog.Nop())
+		require.NoError(t, err)
+		defer a.Close()
+
+		// The purge finishes deletions that failed part way.
+		temporal.AssertExpectations(t)
+		assert.Zero(t, a.Handlers.TrashRetention)
+	})
+
+	t.Run("ScheduleFailure", func(t *testing.T) {
+		temporal := mocks.NewMockTemporalClient()
+		temporal.On("ScheduleTrashPurge", mock.Anything, "0 3 * * *").Return(assert.AnError)
+
+		_, err := app.NewWithDependencies(cfg, app.Dependencies{
+			Repository: repomocks.NewMockRepository(),
+			Core:       mocks.NewMockCoreService(),
+			S3:         mocks.NewMockS3Client(),
+			Temporal:   temporal,
+			Qdrant:     mocks.NewMockQdrantClient(),
+		}, zerolog.Nop())
+
+		assert.ErrorContains(t, err, "trash purge")
+	})
+}
+
+func TestDemoMode(t *testing.T) {
+	newDemoApp := func(t *testing.T) (*app.App, *mocks.MockCoreService, *repomocks.MockRepository) {
+		t.Helper()
+		gin.SetMode(gin.TestMode)
+
+		core := mocks.NewMockCoreService()
+		repo := repomocks.NewMockRepository()
+		cfg := &config.Config{Demo: config.DemoConfig{
+			Enabled:    true,
+			Token:      "guest-token",
+			Username:   "demo",
+			Collection: "demo_docs",
+			RateLimit:  2,
+			RateWindow: time.Minute,
+		}}
+		a, err := app.NewWithDependencies(cfg, app.Dependencies{
+			Repository: repo,
+			Core:       core,
+			S3:         mocks.NewMockS3Client(),
+			Temporal:   mocks.NewMockTemporalClient(),
+			Qdrant:     mocks.NewMockQdrantClient(),
+		}, zerolog.Nop())
+		require.NoError(t, err)
+		t.Cleanup(a.Close)
+
+		return a, core, repo
+	}
+	serve := func(a *app.App, method, path, body string, header http.Header) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		req.Header = header
+		resp := httptest.NewRecorder()
+		a.Router.ServeHTTP(resp, req)
+		return resp
+	}
+	guest := http.Header{"Authorization": {"Bearer guest-token"}}
+	const session = "3b9f2c1d-7a4e-4f6b-8c2d-9e1f0a3b4c5d"
+	returningGuest := http.Header{"Authorization": {"Bearer guest-token"}, "X-Demo-Session": {session}}
+
+	t.Run("Query_UsesDemoCollection", func(t *testing.T) {
+		a, core, repo := newDemoApp(t)
+		upstream := make(chan models.SSEEvent)
+		close(upstream)
+		core.On("Query", mock.Anything, models.CoreQueryRequest{Query: "what?", TopK: gateway.DefaultTopK, Collection: "demo_docs", AccessGroups: []string{}}).Return((<-chan models.SSEEvent)(upstream), nil)
+		repo.On("ListEnabledCuratedAnswers", mock.Anything).Return(nil, nil)
+		repo.On("ListAllGlossaryTerms", mock.Anything).Return(nil, nil)
+		repo.On("ListEnabledRedactionRules", mock.Anything).Return(nil, nil)
+		repo.On("CreateQueryLog", mock.Anything, mock.MatchedBy(func(log *models.QueryLog) bool {
+			return strings.HasPrefix(log.Username, "demo:")
+		})).Return(nil)
+
+		// Query streams, which needs a real connection.
+		srv := httptest.NewServer(a.Router)
+		defer srv.Close()
+		req, _ := http.NewRequest("POST", srv.URL+"/api/v1/query", strings.NewReader(`{"query":"what?"}`))
+		req.Header = guest
+		resp, err := srv.Client().Do(req)
+		require.NoError(t, err)
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		core.AssertExpectations(t)
+	})
+
+	t.Run("Upload_Forbidden", func(t *testing.T) {
+		a, _, _ := newDemoApp(t)
+
+		resp := serve(a, "POST", "/api/v1/documents", `{}`, guest)
+
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+		assert.Contains(t, resp.Body.String(), "Not available in demo mode")
+	})
+
+	t.Run("WrongToken_RequiresAuth", func(t *testing.T) {
+		a, _, _ := newDemoApp(t)
+
+		resp := serve(a, "GET", "/api/v1/conversations/"+conversationID+"/messages", "", http.Header{"Authorization": {"Bearer nope"}})
+
+		assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	})
+
+	t.Run("RateLimited", func(t *testing.T) {
+		a, _, repo := newDemoApp(t)
+		repo.On("GetConversation", mock.Anything, conversationID).Return(&models.Conversation{ID: conversationID, CreatedBy: "demo:" + session}, nil)
+		repo.On("GetMessagesByConversationID", mock.Anything, conversationID, mock.Anything, mock.Anything).Return([]*models.Message{}, nil)
+		repo.On("ListEnabledRedactionRules", mock.Anything).Return(nil, nil)
+
+		for range 2 {
+			resp := serve(a, "GET", "/api/v1/conversations/"+conversationID+"/messages", "", returningGuest)
+			require.Equal(t, http.StatusOK, resp.Code)
+		}
+		resp := serve(a, "GET", "/api/v1/conversations/"+conversationID+"/messages", "", returningGuest)
+
+		assert.Equal(t, http.StatusTooManyRequests, resp.Code)
+		assert.Equal(t, "60", resp.Header().Get("Retry-After"))
+	})
+
+	t.Run("Session_Issued", func(t *testing.T) {
+		a, _, repo := newDemoApp(t)
+		repo.On("CreateConversation", mock.Anything, mock.MatchedBy(func(conv *models.Conversation) bool {
+			return strings.HasPrefix(conv.CreatedBy, "demo:")
+		})).Return(nil)
+		repo.On("ListWebhooksForEvent", mock.Anything, "conversation.created").Return(nil, nil)
+
+		resp := serve(a, "POST", "/api/v1/conversations", "", guest)
+
+		require.Equal(t, http.StatusCreated, resp.Code)
+		issued := resp.Header().Get("X-Demo-Session")
+		assert.Len(t, issued, 36)
+		var conv models.Conversation
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &conv))
+		assert.Equal(t, "demo:"+issued, conv.CreatedBy)
+	})
+
+	t.Run("Session_Reused", func(t *testing.T) {
+		a, _, repo := newDemoApp(t)
+		repo.On("CreateConversation", mock.Anything, mock.Anything).Return(nil)
+		repo.On("ListWebhooksForEvent", mock.Anything, "conversation.created").Return(nil, nil)
+
+		resp := serve(a, "POST", "/api/v1/conversations", "", returningGuest)
+
+		assert.Equal(t, session, resp.Header().Get("X-Demo-Session"))
+	})
+
+	t.Run("OtherGuestsConversation_NotFound", func(t *testing.T) {
+		a, core, repo := newDemoApp(t)
+		repo.On("GetConversation", mock.Anything, conversationID).Return(&models.Conversation{ID: conversationID, CreatedBy: "demo:0f1e2d3c-4b5a-4697-8877-665544332211"}, nil)
+		repo.On("GetConversationParticipant", mock.Anything, conversationID, "demo:"+session).Return(nil, nil)
+
+		read := serve(a, "GET", "/api/v1/conversations/"+conversationID+"/messages", "", returningGuest)
+		query := serve(a, "POST", "/api/v1/query", `{"query":"what?","conversation_id":"`+conversationID+`"}`, returningGuest)
+
+		assert.Equal(t, http.StatusNotFound, read.Code)
+		assert.Equal(t, http.StatusNotFound, query.Code)
+		repo.AssertNotCalled(t, "GetMessagesByConversationID", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		core.AssertNotCalled(t, "Query", mock.Anything, mock.Anything)
+	})
+
+	t.Run("LegacyConversation_NotFound", func(t *testing.T) {
+		// Conversations without a creator are open to users, not guests.
+		a, _, repo := newDemoApp(t)
+		repo.On("GetConversation", mock.Anything, conversationID).Return(&models.Conversation{ID: conversationID}, nil)
+		repo.On("GetConversationParticipant", mock.Anything, conversationID, "demo:"+session).Return(nil, nil)
+		repo.On("ListConversationParticipants", mock.Anything, conversationID).Return(nil, nil)
+
+		resp := serve(a, "GET", "/api/v1/conversations/"+conversationID+"/messages", "", returningGuest)
+
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+		repo.AssertNotCalled(t, "GetMessagesByConversationID", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("User_Unaffected", func(t *testing.T) {
+		a, _, repo := newDemoApp(t)
+		repo.On("ListDocuments", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]*models.Document{}, 0, nil)
+
+		resp := serve(a, "GET", "/api/v1/documents", "", http.Header{"X-User-Name": {"alice"}})
+
+		assert.NotEqual(t, http.StatusForbidden, resp.Code)
+		assert.NotEqual(t, http.StatusUnauthorized, resp.Code)
+	})
+}
+
+func TestServiceTokens(t *testing.T) {
+	newTokenApp := func(t *testing.T, token *models.ServiceToken) (*app.App, *repomocks.MockRepository) {
+		t.Helper()
+		gin.SetMode(gin.TestMode)
+
+		repo := repomocks.NewMockRepository()
+		repo.On("GetServiceTokenByHash", mock.Anything, middleware.HashServiceToken("kbst_secret")).Return(token, nil)
+		repo.On("GetServiceTokenByHash", mock.Anything, mock.Anything).Return(nil, nil)
+		repo.On("TouchServiceToken", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		cfg := &config.Config{Auth: config.AuthConfig{AdminUsers: []string{"admin"}}}
+		a, err := app.NewWithDependencies(cfg, app.Dependencies{
+			Repository: repo,
+			Core:       mocks.NewMockCoreService(),
+			S3:         mocks.NewMockS3Client(),
+			Temporal:   mocks.NewMockTemporalClient(),
+			Qdrant:     mocks.NewMockQdrantClient(),
+		}, zerolog.Nop())
+		require.NoError(t, err)
+		t.Cleanup(a.Close)
+
+		return a, repo
+	}
+	serve := func(a *app.App, method, path, bearer string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+bearer)
+		resp := httptest.NewRecorder()
+		a.Router.ServeHTTP(resp, req)
+		return resp
+	}
+	readOnly := &models.ServiceToken{ID: "t-1", Name: "ci", Scopes: []string{models.ScopeDocumentsRead}}
+
+	t.Run("ListDocuments_Authenticated", func(t *testing.T) {
+		a, repo := newTokenApp(t, readOnly)
+		repo.On("ListDocuments", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]*models.Document{}, 0, nil)
+
+		resp := serve(a, "GET", "/api/v1/documents", "kbst_secret")
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		repo.AssertCalled(t, "TouchServiceToken", mock.Anything, "t-1", mock.Anything)
+	})
+
+	t.Run("MissingScope_Forbidden", func(t *testing.T) {
+		a, _ := newTokenApp(t, readOnly)
+
+		resp := serve(a, "DELETE", "/api/v1/documents/doc-1", "kbst_secret")
+
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+		assert.Contains(t, resp.Body.String(), models.ScopeDocumentsWrite)
+	})
+
+	t.Run("Admin_Forbidden", func(t *testing.T) {
+		a, _ := newTokenApp(t, readOnly)
+
+		resp := serve(a, "GET", "/api/v1/admin/service-tokens", "kbst_secret")
+
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+	})
+
+	t.Run("Expired_Unauthorized", func(t *testing.T) {
+		expiresAt := time.Now().Add(-time.Minute)
+		a, _ := newTokenApp(t, &models.ServiceToken{
+			ID: "t-1", Name: "ci", Scopes: []string{models.ScopeDocumentsRead}, ExpiresAt: &expiresAt,
+		})
+
+		resp := serve(a, "GET", "/api/v1/documents", "kbst_secret")
+
+		assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	})
+
+	t.Run("UnknownToken_Unauthorized", func(t *testing.T) {
+		a, _ := newTokenApp(t, readOnly)
+
+		resp := serve(a, "GET", "/api/v1/documents", "kbst_other")
+
+		assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	})
+
+	t.Run("APIKeyHeader", func(t *testing.T) {
+		a, repo := newTokenApp(t, readOnly)
+		repo.On("ListDocuments", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]*models.Document{}, 0, nil)
+		serveKey := func(method, path, key string) *httptest.ResponseRecorder {
+			req, _ := http.NewRequest(method, path, nil)
+			req.Header.Set("X-API-Key", key)
+			resp := httptest.NewRecorder()
+			a.Router.ServeHTTP(resp, req)
+			return resp
+		}
+
+		assert.Equal(t, http.StatusOK, serveKey("GET", "/api/v1/documents", "kbst_secret").Code)
+		assert.Equal(t, http.StatusForbidden, serveKey("DELETE", "/api/v1/documents/doc-1", "kbst_secret").Code)
+		assert.Equal(t, http.StatusUnauthorized, serveKey("GET", "/api/v1/documents", "not-a-key").Code)
+		repo.AssertCalled(t, "TouchServiceToken", mock.Anything, "t-1", mock.Anything)
+	})
+}
+
+func TestImpersonation(t *testing.T) {
+	newImpersonationApp := func(t *testing.T) (*app.App, *repomocks.MockRepository, *models.ImpersonationSession) {
+		t.Helper()
+		gin.SetMode(gin.TestMode)
+
+		// The session minted through the admin route is what the
+		// token is later checked against.
+		session := &models.ImpersonationSession{}
+		repo := repomocks.NewMockRepository()
+		repo.On("CreateImpersonationSession", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			*session = *args.Get(1).(*models.ImpersonationSession)
+		}).Return(nil)
+		repo.On("GetImpersonationSession", mock.Anything, mock.Anything).Return(session, nil)
+		repo.On("RecordImpersonationRequest", mock.Anything, mock.Anything).Return(nil)
+		cfg := &config.Config{
+			Auth:          config.AuthConfig{AdminUsers: []string{"admin", "ops"}},
+			Impersonation: config.ImpersonationConfig{SigningKey: "impersonation-secret", TokenTTL: 30 * time.Minute},
+		}
+		a, err := app.NewWithDependencies(cfg, app.Dependencies{
+			Repository: repo,
+			Core:       mocks.NewMockCoreService(),
+			S3:         mocks.NewMockS3Client(),
+			Temporal:   mocks.NewMockTemporalClient(),
+			Qdrant:     mocks.NewMockQdrantClient(),
+		}, zerolog.Nop())
+		require.NoError(t, err)
+		t.Cleanup(a.Close)
+
+		return a, repo, session
+	}
+	mint := func(t *testing.T, a *app.App, username string) *httptest.ResponseRecorder {
+		t.Helper()
+		req, _ := http.NewRequest("POST", "/api/v1/admin/impersonations", strings.NewReader(`{"username":"`+username+`","reason":"Ticket 123: empty documents list"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("x-user-name", "admin")
+		resp := httptest.NewRecorder()
+		a.Router.ServeHTTP(resp, req)
+		return resp
+	}
+	serve := func(a *app.App, method, path, bearer string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+bearer)
+		resp := httptest.NewRecorder()
+		a.Router.ServeHTTP(resp, req)
+		return resp
+	}
+	token := func(t *testing.T, resp *httptest.ResponseRecorder) string {
+		t.Helper()
+		require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+		var minted models.ImpersonationToken
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &minted))
+		return minted.Token
+	}
+
+	t.Run("ActsAsUser_Recorded", func(t *testing.T) {
+		a, repo, _ := newImpersonationApp(t)
+		repo.On("ListDocuments", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]*models.Document{}, 0, nil)
+		bearer := token(t, mint(t, a, "alice"))
+
+		resp := serve(a, "GET", "/api/v1/documents?limit=5", bearer)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		repo.AssertCalled(t, "RecordImpersonationRequest", mock.Anything, mock.MatchedBy(func(r *models.ImpersonationRequest) bool {
+			return r.Method == "GET" && r.Path == "/api/v1/documents?limit=5" && r.Status == http.StatusOK
+		}))
+	})
+
+	t.Run("Admin_Forbidden", func(t *testing.T) {
+		a, repo, _ := newImpersonationApp(t)
+		bearer := token(t, mint(t, a, "alice"))
+
+		resp := serve(a, "GET", "/api/v1/admin/impersonations", bearer)
+
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+		repo.AssertCalled(t, "RecordImpersonationRequest", mock.Anything, mock.MatchedBy(func(r *models.ImpersonationRequest) bool {
+			return r.Status == http.StatusForbidden
+		}))
+	})
+
+	t.Run("Revoked_Unauthorized", func(t *testing.T) {
+		a, _, session := newImpersonationApp(t)
+		bearer := token(t, mint(t, a, "alice"))
+		revokedAt := time.Now()
+		session.RevokedAt = &revokedAt
+
+		resp := serve(a, "GET", "/api/v1/documents", bearer)
+
+		assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	})
+
+	t.Run("Forged_Unauthorized", func(t *testing.T) {
+		a, _, _ := newImpersonationApp(t)
+
+		resp := serve(a, "GET", "/api/v1/documents", models.ImpersonationTokenPrefix+"abc.def")
+
+		assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	})
+
+	t.Run("AdminTarget_Forbidden", func(t *testing.T) {
+		a, repo, _ := newImpersonationApp(t)
+
+		resp := mint(t, a, "ops")
+
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+		repo.AssertNotCalled(t, "CreateImpersonationSession", mock.Anything, mock.Anything)
+	})
+}
+
+func TestOIDC(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	encode := base64.RawURLEncoding.EncodeToString
+	var provider *httptest.Server
+	provider = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			json.NewEncoder(w).Encode(map[string]string{"issuer": provider.URL, "jwks_uri": provider.URL + "/keys"})
+		case "/keys":
+			json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{
+				{"kty": "EC", "kid": "k1", "crv": "P-256", "x": encode(key.X.FillBytes(make([]byte, 32))), "y": encode(key.Y.FillBytes(make([]byte, 32)))},
+			}})
+		}
+	}))
+	t.Cleanup(provider.Close)
+	sign := func(username string) string {
+		header, _ := json.Marshal(map[string]string{"alg": "ES256", "kid": "k1"})
+		claims, _ := json.Marshal(map[string]interface{}{"iss": provider.URL, "aud": "kb-gateway", "preferred_username": username, "exp": time.Now().Add(time.Hour).Unix()})
+		signed := encode(header) + "." + encode(claims)
+		digest := sha256.Sum256([]byte(signed))
+		r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+		require.NoError(t, err)
+		return signed + "." + encode(append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...))
+	}
+
+	repo := repomocks.NewMockRepository()
+	repo.On("ListDocuments", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]*models.Document{}, 0, nil)
+	repo.On("ListWebhooks", mock.Anything).Return([]*models.Webhook{}, nil)
+	cfg := &config.Config{
+		Auth: config.AuthConfig{AdminUsers: []string{"admin"}},
+		OIDC: config.OIDCConfig{Issuer: provider.URL, ClientID: "kb-gateway", UsernameClaim: "preferred_username", Timeout: time.Second},
+	}
+	a, err := app.NewWithDependencies(cfg, app.Dependencies{
+		Repository: repo,
+		Core:       mocks.NewMockCoreService(),
+		S3:         mocks.NewMockS3Client(),
+		Temporal:   mocks.NewMockTemporalClient(),
+		Qdrant:     mocks.NewMockQdrantClient(),
+	}, zerolog.Nop())
+	require.NoError(t, err)
+	t.Cleanup(a.Close)
+	serve := func(path, bearer string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		req.Header.Set("Authorization", "Bearer "+bearer)
+		resp := httptest.NewRecorder()
+		a.Router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Run("ActsAsUser", func(t *testing.T) {
+		resp := serve("/api/v1/documents", sign("alice"))
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		repo.AssertCalled(t, "ListDocuments", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("AdminUser_Admin", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, serve("/api/v1/admin/webhooks", sign("alice")).Code)
+		assert.Equal(t, http.StatusOK, serve("/api/v1/admin/webhooks", sign("admin")).Code)
+	})
+
+	t.Run("Invalid_Unauthorized", func(t *testing.T) {
+		token := sign("alice")
+
+		resp := serve("/api/v1/documents", token[:len(token)-4]+"AAAA")
+
+		assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	})
+}
+
+func TestWorkspaceSettings(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo := repomocks.NewMockRepository()
+	repo.On("GetWorkspaceSettings", mock.Anything).Return(&models.WorkspaceSettings{Name: "Acme Docs", AllowedFileTypes: []string{}}, nil)
+	repo.On("UpsertWorkspaceSettings", mock.Anything, mock.Anything).Return(nil)
+	cfg := &config.Config{Auth: config.AuthConfig{AdminUsers: []string{"admin"}}}
+	a, err := app.NewWithDependencies(cfg, app.Dependencies{
+		Repository: repo,
+		Core:       mocks.NewMockCoreService(),
+		S3:         mocks.NewMockS3Client(),
+		Temporal:   mocks.NewMockTemporalClient(),
+		Qdrant:     mocks.NewMockQdrantClient(),
+	}, zerolog.Nop())
+	require.NoError(t, err)
+	t.Cleanup(a.Close)
+	serve := func(method, user, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, "/api/v1/workspace/settings", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("x-user-name", user)
+		resp := httptest.NewRecorder()
+		a.Router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Run("Get_AnyUser", func(t *testing.T) {
+		resp := serve("GET", "alice", "")
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"name":"Acme Docs"`)
+	})
+
+	t.Run("Patch_NotAdmin", func(t *testing.T) {
+		resp := serve("PATCH", "alice", `{"name":"Mine"}`)
+
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+		repo.AssertNotCalled(t, "UpsertWorkspaceSettings", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Patch_Admin", func(t *testing.T) {
+		resp := serve("PATCH", "admin", `{"retention_days":14}`)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"retention_days":14`)
+	})
+}
+
+func TestChatWidget(t *testing.T) {
+	newWidgetApp := func(t *testing.T, widget config.WidgetConfig) (*app.App, *repomocks.MockRepository) {
+		t.Helper()
+		gin.SetMode(gin.TestMode)
+
+		repo := repomocks.NewMockRepository()
+		repo.On("GetServiceTokenByHash", mock.Anything, mock.Anything).Return(nil, nil)
+		a, err := app.NewWithDependencies(&config.Config{Widget: widget}, app.Dependencies{
+			Repository: repo,
+			Core:       mocks.NewMockCoreService(),
+			S3:         mocks.NewMockS3Client(),
+			Temporal:   mocks.NewMockTemporalClient(),
+			Qdrant:     mocks.NewMockQdrantClient(),
+		}, zerolog.Nop())
+		require.NoError(t, err)
+		t.Cleanup(a.Close)
+
+		return a, repo
+	}
+	serve := func(a *app.App, method, path, body string, header http.Header) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		req.Header = header
+		resp := httptest.NewRecorder()
+		a.Router.ServeHTTP(resp, req)
+		return resp
+	}
+	widget := config.WidgetConfig{
+		SigningKey:     "widget-secret",
+		AllowedOrigins: []string{"https://docs.example.com"},
+		TokenTTL:       15 * time.Minute,
+		RateLimit:      2,
+		RateWindow:     time.Minute,
+	}
+	mint := func(t *testing.T, a *app.App) models.WidgetToken {
+		t.Helper()
+		resp := serve(a, "POST", "/api/v1/widget/tokens", `{"origin":"https://docs.example.com"}`, http.Header{"X-User-Name": {"site-backend"}})
+		require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+		var token models.WidgetToken
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &token))
+		return token
+	}
+
+	t.Run("Messages_FromOrigin", func(t *testing.T) {
+		a, repo := newWidgetApp(t, widget)
+		repo.On("GetMessagesByConversationID", mock.Anything, conversationID, mock.Anything, mock.Anything).Return([]*models.Message{}, nil)
+		repo.On("ListEnabledRedactionRules", mock.Anything).Return(nil, nil)
+		token := mint(t, a)
+		repo.On("GetConversation", mock.Anything, conversationID).Return(&models.Conversation{ID: conversationID, CreatedBy: "widget:" + token.SessionID}, nil)
+
+		resp := serve(a, "GET", "/api/v1/widget/conversations/"+conversationID+"/messages", "", http.Header{
+			"Authorization": {"Bearer " + token.Token},
+			"Origin":        {"https://docs.example.com"},
+		})
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+	})
+
+	t.Run("OtherSessionsConversation_NotFound", func(t *testing.T) {
+		tenants := widget
+		tenants.AllowedOrigins = []string{"https://docs.example.com", "https://help.example.org"}
+		a, repo := newWidgetApp(t, tenants)
+		owner := mint(t, a)
+		repo.On("GetConversation", mock.Anything, conversationID).Return(&models.Conversation{ID: conversationID, CreatedBy: "widget:" + owner.SessionID}, nil)
+		repo.On("GetConversationParticipant", mock.Anything, conversationID, mock.Anything).Return(nil, nil)
+		resp := serve(a, "POST", "/api/v1/widget/tokens", `{"origin":"https://help.example.org"}`, http.Header{"X-User-Name": {"other-site"}})
+		require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+		var otherSite models.WidgetToken
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &otherSite))
+
+		for _, tc := range []struct {
+			name   string
+			token  models.WidgetToken
+			origin string
+		}{
+			{"SameSite", mint(t, a), "https://docs.example.com"},
+			{"OtherSite", otherSite, "https://help.example.org"},
+		} {
+			header := http.Header{"Authorization": {"Bearer " + tc.token.Token}, "Origin": {tc.origin}}
+
+			read := serve(a, "GET", "/api/v1/widget/conversations/"+conversationID+"/messages", "", header)
+			query := serve(a, "POST", "/api/v1/widget/query", `{"query":"what?","conversation_id":"`+conversationID+`"}`, header)
+
+			assert.Equal(t, http.StatusNotFound, read.Code, tc.name)
+			assert.Equal(t, http.StatusNotFound, query.Code, tc.name)
+		}
+		repo.AssertNotCalled(t, "GetMessagesByConversationID", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Mint_OriginNotAllowed", func(t *testing.T) {
+		a, _ := newWidgetApp(t, widget)
+
+		resp := serve(a, "POST", "/api/v1/widget/tokens", `{"origin":"https://evil.example.com"}`, http.Header{"X-User-Name": {"site-backend"}})
+
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+	})
+
+	t.Run("Mint_RequiresAuth", func(t *testing.T) {
+		a, _ := newWidgetApp(t, widget)
+
+		resp := serve(a, "POST", "/api/v1/widget/tokens", `{"origin":"https://docs.example.com"}`, http.Header{})
+
+		assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	})
+
+	t.Run("OtherOrigin_Forbidden", func(t *testing.T) {
+		a, _ := newWidgetApp(t, widget)
+		token := mint(t, a)
+
+		resp := serve(a, "POST", "/api/v1/widget/conversations", "", http.Header{
+			"Authorization": {"Bearer " + token.Token},
+			"Origin":        {"https://evil.example.com"},
+		})
+
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+	})
+
+	t.Run("MainAPI_Unauthorized", func(t *testing.T) {
+		a, _ := newWidgetApp(t, widget)
+		token := mint(t, a)
+
+		resp := serve(a, "GET", "/api/v1/documents", "", http.Header{
+			"Authorization": {"Bearer " + token.Token},
+			"Origin":        {"https://docs.example.com"},
+		})
+
+		assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	})
+
+	t.Run("RateLimitedPerOrigin", func(t *testing.T) {
+		a, repo := newWidgetApp(t, widget)
+		repo.On("CreateConversation", mock.Anything, mock.Anything).Return(nil)
+		repo.On("ListWebhooksForEvent", mock.Anything, "conversation.created").Return(nil, nil)
+		// Separate sessions of one site share its limit.
+		get := func() *httptest.ResponseRecorder {
+			return serve(a, "POST", "/api/v1/widget/conversations", "", http.Header{
+				"Authorization": {"Bearer " + mint(t, a).Token},
+				"Origin":        {"https://docs.example.com"},
+			})
+		}
+		for range 2 {
+			require.Equal(t, http.StatusCreated, get().Code)
+		}
+		resp := get()
+
+		assert.Equal(t, http.StatusTooManyRequests, resp.Code)
+		assert.Equal(t, "60", resp.Header().Get("Retry-After"))
+	})
+
+	t.Run("Disabled_Unavailable", func(t *testing.T) {
+		a, _ := newWidgetApp(t, config.WidgetConfig{})
+
+		resp := serve(a, "POST", "/api/v1/widget/conversations", "", http.Header{"Origin": {"https://docs.example.com"}})
+
+		assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+	})
+}
+
+func TestStatusPage(t *testing.T) {
+	newStatusApp := func(t *testing.T, statusPage config.StatusPageConfig) *app.App {
+		t.Helper()
+		gin.SetMode(gin.TestMode)
+
+		core := mocks.NewMockCoreService()
+		core.On("HealthCheck", mock.Anything).Return(map[string]string{"python_core": "ok"}, nil)
+		temporal := mocks.NewMockTemporalClient()
+		temporal.On("HealthCheck", mock.Anything).Return(nil)
+		qdrant := mocks.NewMockQdrantClient()
+		qdrant.On("CountVectors", mock.Anything).Return(uint64(0), nil)
+		cfg := &config.Config{
+			Auth:       config.AuthConfig{AdminUsers: []string{"admin"}},
+			Alerts:     config.AlertConfig{CheckInterval: time.Minute},
+			StatusPage: statusPage,
+		}
+		a, err := app.NewWithDependencies(cfg, app.Dependencies{
+			Repository: repomocks.NewMockRepository(),
+			Core:       core,
+			S3:         mocks.NewMockS3Client(),
+			Temporal:   temporal,
+			Qdrant:     qdrant,
+		}, zerolog.Nop())
+		require.NoError(t, err)
+		t.Cleanup(a.Close)
+
+		return a
+	}
+	get := func(a *app.App) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/status", nil)
+		resp := httptest.NewRecorder()
+		a.Router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Run("Status_Public", func(t *testing.T) {
+		a := newStatusApp(t, config.StatusPageConfig{
+			Enabled: true, IncidentWindow: time.Hour, CacheTTL: 30 * time.Second,
+		})
+
+		resp := get(a)
+
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, "public, max-age=30", resp.Header().Get("Cache-Control"))
+		var status models.StatusResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &status))
+		assert.Len(t, status.Components, 3)
+	})
+
+	t.Run("Status_RateLimited", func(t *testing.T) {
+		a := newStatusApp(t, config.StatusPageConfig{
+			Enabled: true, CacheTTL: 30 * time.Second, RateLimit: 2, RateWindow: time.Minute,
+		})
+
+		for i := 0; i < 2; i++ {
+			require.Equal(t, http.StatusOK, get(a).Code)
+		}
+		resp := get(a)
+
+		assert.Equal(t, http.StatusTooManyRequests, resp.Code)
+		assert.Equal(t, "60", resp.Header().Get("Retry-After"))
+	})
+
+	t.Run("Disabled_Unavailable", func(t *testing.T) {
+		a := newStatusApp(t, config.StatusPageConfig{})
+
+		assert.Equal(t, http.StatusServiceUnavailable, get(a).Code)
+	})
+}
+
+func TestUploadBandwidthLimit(t *testing.T) {
+	newUploadApp := func(t *testing.T, uploads config.UploadConfig) *app.App {
+		t.Helper()
+		gin.SetMode(gin.TestMode)
+
+		cfg := &config.Config{Uploads: uploads}
+		a, err := app.NewWithDependencies(cfg, app.Dependencies{
+			Repository: repomocks.NewMockRepository(),
+			Core:       mocks.NewMockCoreService(),
+			S3:         mocks.NewMockS3Client(),
+			Temporal:   mocks.NewMockTemporalClient(),
+			Qdrant:     mocks.NewMockQdrantClient(),
+		}, zerolog.Nop())
+		require.NoError(t, err)
+		t.Cleanup(a.Close)
+
+		return a
+	}
+	// upload posts a form of size bytes without a file, which is read in
+	// full and then refused.
+	upload := func(a *app.App, size int) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		form.WriteField("padding", strings.Repeat("x", size))
+		form.Close()
+
+		req, _ := http.NewRequest("POST", "/api/v1/documents", &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		req.Header.Set("x-user-name", "alice")
+		resp := httptest.NewRecorder()
+		a.Router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Run("RequestLimit_Throttles", func(t *testing.T) {
+		a := newUploadApp(t, config.UploadConfig{RequestBandwidthLimit: 32 << 10})
+
+		start := time.Now()
+		resp := upload(a, 48<<10)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		assert.GreaterOrEqual(t, time.Since(start), 400*time.Millisecond)
+	})
+
+	t.Run("TotalLimit_SharedAcrossUploads", func(t *testing.T) {
+		a := newUploadApp(t, config.UploadConfig{BandwidthLimit: 32 << 10})
+
+		start := time.Now()
+		upload(a, 24<<10)
+		resp := upload(a, 24<<10)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		assert.GreaterOrEqual(t, time.Since(start), 400*time.Millisecond)
+	})
+
+	t.Run("Unlimited", func(t *testing.T) {
+		a := newUploadApp(t, config.UploadConfig{})
+
+		start := time.Now()
+		resp := upload(a, 1<<20)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		assert.Less(t, time.Since(start), 400*time.Millisecond)
+	})
+}
+
+func TestRequestScheduling(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo := repomocks.NewMockRepository()
+	repo.On("ListDocuments", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]*models.Document{}, 0, nil)
+	cfg := &config.Config{Scheduler: config.SchedulerConfig{MaxInFlight: 1}}
+	a, err := app.NewWithDependencies(cfg, app.Dependencies{
+		Repository: repo,
+		Core:       mocks.NewMockCoreService(),
+		S3:         mocks.NewMockS3Client(),
+		Temporal:   mocks.NewMockTemporalClient(),
+		Qdrant:     mocks.NewMockQdrantClient(),
+	}, zerolog.Nop())
+	require.NoError(t, err)
+	t.Cleanup(a.Close)
+
+	// With one slot and no queueing, each request must give its slot back
+	// for the next to be served.
+	for range 3 {
+		req, _ := http.NewRequest("GET", "/api/v1/documents", nil)
+		req.Header.Set("x-user-name", "alice")
+		resp := httptest.NewRecorder()
+		a.Router.ServeHTTP(resp, req)
+
+		require.Equal(t, http.StatusOK, resp.Code)
+	}
+
+	// Open streams do not hold the slot.
+	const id = "550e8400-e29b-41d4-a716-446655440000"
+	opened := make(chan struct{}, 1)
+	signal := func(mock.Arguments) { opened <- struct{}{} }
+	repo.On("GetConversation", mock.Anything, id).Return(&models.Conversation{ID: id, CreatedBy: "alice"}, nil)
+	repo.On("GetConversationParticipant", mock.Anything, id, "alice").Run(signal).
+		Return(&models.ConversationParticipant{ConversationID: id, Username: "alice"}, nil)
+	repo.On("GetKnowledgeBaseExport", mock.Anything, id).Run(signal).
+		Return(&models.KnowledgeBaseExport{ID: id, Status: models.KnowledgeBaseExportRunning, CreatedBy: "alice"}, nil)
+	srv := httptest.NewServer(a.Router)
+	defer srv.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	for _, path := range []string{"/api/v1/conversations/" + id + "/stream", "/api/v1/export/" + id + "/stream"} {
+		req, _ := http.NewRequestWithContext(ctx, "GET", srv.URL+path, nil)
+		req.Header.Set("x-user-name", "alice")
+		go func() {
+			if resp, err := srv.Client().Do(req); err == nil {
+				resp.Body.Close()
+			}
+		}()
+		select {
+		case <-opened:
+		case <-time.After(time.Second):
+			t.Fatalf("%s was not served", path)
+		}
+
+		req, _ = http.NewRequest("GET", "/api/v1/documents", nil)
+		req.Header.Set("x-user-name", "alice")
+		resp := httptest.NewRecorder()
+		a.Router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code, path)
+	}
+}
+
+func TestIDParamValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo := repomocks.NewMockRepository()
+	repo.On("GetDocument", mock.Anything, "550e8400-e29b-41d4-a716-446655440000").Return(nil, nil)
+	a, err := app.NewWithDependencies(&config.Config{}, app.Dependencies{
+		Repository: repo,
+		Core:       mocks.NewMockCoreService(),
+		S3:         mocks.NewMockS3Client(),
+		Temporal:   mocks.NewMockTemporalClient(),
+		Qdrant:     mocks.NewMockQdrantClient(),
+	}, zerolog.Nop())
+	require.NoError(t, err)
+	t.Cleanup(a.Close)
+
+	serve := func(path, user string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		if user != "" {
+			req.Header.Set("x-user-name", user)
+		}
+		resp := httptest.NewRecorder()
+		a.Router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	for _, path := range []string{
+		"/api/v1/documents/not-a-uuid",
+		"/api/v1/documents/{550e8400-e29b-41d4-a716-446655440000}",
+		"/api/v1/documents/550e8400e29b41d4a716446655440000/events",
+		"/api/v1/conversations/1/messages",
+	} {
+		resp := serve(path, "alice")
+		assert.Equal(t, http.StatusBadRequest, resp.Code, path)
+		assert.Contains(t, resp.Body.String(), "VALIDATION_ERROR", path)
+	}
+	repo.AssertNotCalled(t, "GetDocument", mock.Anything, mock.Anything)
+
+	// Authentication is checked first.
+	assert.Equal(t, http.StatusUnauthorized, serve("/api/v1/documents/not-a-uuid", "").Code)
+
+	assert.Equal(t, http.StatusNotFound, serve("/api/v1/documents/550e8400-e29b-41d4-a716-446655440000", "alice").Code)
+}
+
+func TestInternalAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	serve := func(auth config.AuthConfig, token string) int {
+		a, err := app.NewWithDependencies(&config.Config{Auth: auth}, app.Dependencies{
+			Repository: repomocks.NewMockRepository(),
+			Core:       mocks.NewMockCoreService(),
+			S3:         mocks.NewMockS3Client(),
+			Temporal:   mocks.NewMockTemporalClient(),
+			Qdrant:     mocks.NewMockQdrantClient(),
+		}, zerolog.Nop())
+		require.NoError(t, err)
+		t.Cleanup(a.Close)
+
+		req, _ := http.NewRequest("POST", "/internal/v1/events", strings.NewReader(`{}`))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp := httptest.NewRecorder()
+		a.Router.ServeHTTP(resp, req)
+		return resp.Code
+	}
+
+	t.Run("Token", func(t *testing.T) {
+		auth := config.AuthConfig{InternalToken: "internal-token"}
+		assert.Equal(t, http.StatusUnauthorized, serve(auth, ""))
+		assert.Equal(t, http.StatusUnauthorized, serve(auth, "wrong"))
+		assert.Equal(t, http.StatusBadRequest, serve(auth, "internal-token"))
+	})
+
+	t.Run("NoToken_NotServed", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, serve(config.AuthConfig{}, ""))
+	})
+
+	t.Run("NoToken_Insecure", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, serve(config.AuthConfig{InternalInsecure: true}, ""))
+	})
+}
+
+func TestSelfCheck(t *testing.T) {
+	checks := []app.Check{
+		{Name: "database", Run: func(ctx context.Context) (string, error) { return "kb@localhost:5432/kb", nil }},
+		{Name: "s3", Run: func(ctx context.Context) (string, error) { return "", errors.New("access denied") }},
+		{Name: "schema", Run: func(ctx context.Context) (string, error) { return "", app.SkipCheck("database unavailable") }},
+		{Name: "qdrant", Run: func(ctx context.Context) (string, error) {
+			<-ctx.Done()
+			return "", ctx.Err()
+		}},
+	}
+
+	results := app.RunChecks(context.Background(), checks, 10*time.Millisecond)
+
+	require.Len(t, results, 4)
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, "kb@localhost:5432/kb", results[0].Detail)
+	assert.EqualError(t, results[1].Err, "access denied")
+	assert.True(t, results[2].Skipped)
+	assert.NoError(t, results[2].Err)
+	assert.Equal(t, "database unavailable", results[2].Detail)
+	assert.Error(t, results[3].Err)
+
+	var report strings.Builder
+	ok := app.WriteCheckReport(&report, results)
+
+	assert.False(t, ok)
+	assert.Regexp(t, `(?m)^  ok\s+database\s+\S+\s+kb@localhost:5432/kb$`, report.String())
+	assert.Regexp(t, `(?m)^  FAIL\s+s3\s+\S+\s+access denied$`, report.String())
+	assert.Regexp(t, `(?m)^  SKIP\s+schema\s+\S+\s+database unavailable$`, report.String())
+	assert.Contains(t, report.String(), "2 of 4 checks failed, 1 skipped")
+
+	report.Reset()
+	assert.True(t, app.WriteCheckReport(&report, results[:1]))
+	assert.Contains(t, report.String(), "All 1 checks passed")
+}
+
+// TestOpenAPISpecCoversRoutes keeps the hand-maintained spec in sync with
+// the router.
+func TestOpenAPISpecCoversRoutes(t *testing.T) {
+	var spec struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	require.NoError(t, json.Unmarshal(docs.OpenAPI, &spec))
+
+	a, _ := newTestApp(t)
+	param := regexp.MustCompile(`:(\w+)`)
+	routed := make(map[string]bool)
+	for _, route := range a.Router.Routes() {
+		path := param.ReplaceAllString(route.Path, "{$1}")
+		method := strings.ToLower(route.Method)
+		routed[method+" "+path] = true
+		assert.Contains(t, spec.Paths[path], method, "route %s %s missing from openapi.json", route.Method, route.Path)
+	}
+
+	for path, operations := range spec.Paths {
+		for method := range operations {
+			assert.True(t, routed[method+" "+path], "openapi.json documents unrouted %s %s", method, path)
+		}
+	}
+}
@@ -0,0 +1,297 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+
+	"kb-platform-gateway/internal/buildinfo"
+	"kb-platform-gateway/internal/config"
+	"kb-platform-gateway/internal/repository"
+	"kb-platform-gateway/internal/services"
+
+	"github.com/google/uuid"
+)
+
+// Check is one step of the startup self-test run by `gateway check`.
+type Check struct {
+	Name string
+	// Run returns a short description of what it verified.
+	Run func(ctx context.Context) (string, error)
+}
+
+// CheckResult is the outcome of a Check.
+type CheckResult struct {
+	Name   string
+	Detail string
+	// Err is nil if the check passed or was skipped.
+	Err      error
+	Skipped  bool
+	Duration time.Duration
+}
+
+var errCheckSkipped = errors.New("skipped")
+
+// SkipCheck returns the error a Check returns when it cannot run, because
+// a check it depends on failed.
+func SkipCheck(reason string) error {
+	return fmt.Errorf("%w: %s", errCheckSkipped, reason)
+}
+
+// SelfChecks returns the checks that verify cfg describes a working
+// deployment: every dependency is reachable, the database schema is
+// current, the S3 credentials may use the bucket and the active Qdrant
+// collection exists. The checks connect as they run; call the returned
+// function to close the connections.
+func SelfChecks(cfg *config.Config) ([]Check, func()) {
+	// A check that times out keeps running in the background, so the
+	// connections it opens and the repository are shared under a lock.
+	var (
+		mu      sync.Mutex
+		closers []func()
+		repo    atomic.Pointer[repository.PostgresRepository]
+	)
+	track := func(closer func()) {
+		mu.Lock()
+		defer mu.Unlock()
+		closers = append(closers, closer)
+	}
+	closeAll := func() {
+		mu.Lock()
+		defer mu.Unlock()
+		for i := len(closers) - 1; i >= 0; i-- {
+			closers[i]()
+		}
+		closers = nil
+	}
+
+	checks := []Check{
+		{"config", func(ctx context.Context) (string, error) {
+			var missing []string
+			for _, setting := range []struct{ name, value string }{
+				{"DB_HOST", cfg.Database.Host},
+				{"DB_NAME", cfg.Database.Database},
+				{"S3_BUCKET", cfg.S3.Bucket},
+				{"QDRANT_COLLECTION", cfg.Qdrant.Collection},
+			} {
+				if setting.value == "" {
+					missing = append(missing, setting.name)
+				}
+			}
+			if len(missing) > 0 {
+				return "", fmt.Errorf("missing %s", strings.Join(missing, ", "))
+			}
+			features := cfg.Features()
+			if len(features) == 0 {
+				return "no optional features enabled", nil
+			}
+			return "features: " + strings.Join(features, ", "), nil
+		}},
+		{"database", func(ctx context.Context) (string, error) {
+			r, err := repository.NewPostgresRepository(&cfg.Database)
+			if err != nil {
+				return "", err
+			}
+			track(func() { r.Close() })
+			repo.Store(r)
+			return fmt.Sprintf("%s@%s:%d/%s", cfg.Database.User, cfg.Database.Host, cfg.Database.Port, cfg.Database.Database), nil
+		}},
+		{"schema", func(ctx context.Context) (string, error) {
+			repo := repo.Load()
+			if repo == nil {
+				return "", SkipCheck("database unavailable")
+			}
+			version, err := repo.AppliedSchemaVersion(ctx)
+			if err != nil {
+				return "", err
+			}
+			if version != repository.SchemaVersion {
+				return "", fmt.Errorf("version %d, expected %d: apply schema.sql", version, repository.SchemaVersion)
+			}
+			return fmt.Sprintf("version %d", version), nil
+		}},
+		{"s3", func(ctx context.Context) (string, error) {
+			client, err := services.NewS3Client(&cfg.S3)
+			if err != nil {
+				return "", err
+			}
+			if err := client.CheckAccess(ctx, client.ObjectKey("gateway-check/"+uuid.New().String())); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("bucket %s is writable, readable and deletable", cfg.S3.Bucket), nil
+		}},
+		{"temporal", func(ctx context.Context) (string, error) {
+			client, err := services.NewTemporalClient(&cfg.Temporal)
+			if err != nil {
+				return "", err
+			}
+			track(client.Close)
+			if err := client.HealthCheck(ctx); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("namespace %s at %s:%d", cfg.Temporal.Namespace, cfg.Temporal.Host, cfg.Temporal.Port), nil
+		}},
+		{"qdrant", func(ctx context.Context) (string, error) {
+			// Queries use the target of the last completed embedding
+			// migration, if any.
+			collection := cfg.Qdrant.Collection
+			if repo := repo.Load(); repo != nil {
+				active, err := repo.GetActiveEmbeddingMigration(ctx)
+				if err != nil {
+					return "", err
+				}
+				if active != nil {
+					collection = active.TargetCollection
+				}
+			}
+
+			client, err := services.NewQdrantClient(&cfg.Qdrant)
+			if err != nil {
+				return "", err
+			}
+			track(func() { client.Close() })
+			exists, err := client.CollectionExists(ctx, collection)
+			if err != nil {
+				return "", err
+			}
+			if !exists {
+				return "", fmt.Errorf("collection %s does not exist", collection)
+			}
+			return "collection " + collection, nil
+		}},
+	}
+
+	if len(cfg.Services.PythonCoreBackends) == 0 {
+		checks = append(checks, coreCheck("python_core", &cfg.Services, track))
+	}
+	for _, backend := range cfg.Services.PythonCoreBackends {
+		checks = append(checks, coreCheck("python_core/"+backend.Name, cfg.Services.WithCore(backend.Host, backend.Port), track))
+	}
+	if cfg.Shadow.Active() {
+		checks = append(checks, coreCheck("shadow_core", cfg.Shadow.CoreConfig(cfg.Services), track))
+	}
+
+	if cfg.Redis.Enabled {
+		checks = append(checks, Check{"redis", func(ctx context.Context) (string, error) {
+			client, err := services.NewRedisClient(&cfg.Redis)
+			if err != nil {
+				return "", err
+			}
+			track(func() { client.Close() })
+			if err := client.HealthCheck(ctx); err != nil {
+				return "", err
+			}
+			return cfg.Redis.Addr, nil
+		}})
+	}
+
+	if cfg.Notifications.Provider != "" {
+		checks = append(checks, Check{"notifications", func(ctx context.Context) (string, error) {
+			if _, err := services.NewNotifier(&cfg.Notifications); err != nil {
+				return "", err
+			}
+			return "provider " + cfg.Notifications.Provider, nil
+		}})
+	}
+
+	return checks, closeAll
+}
+
+// coreCheck returns a check of the core deployment cfg points at.
+func coreCheck(name string, cfg *config.ServicesConfig, track func(func())) Check {
+	return Check{name, func(ctx context.Context) (string, error) {
+		client, err := services.NewCoreService(cfg)
+		if err != nil {
+			return "", err
+		}
+		if closer, ok := client.(io.Closer); ok {
+			track(func() { closer.Close() })
+		}
+		if _, err := client.HealthCheck(ctx); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s:%d", cfg.PythonCoreHost, cfg.PythonCorePort), nil
+	}}
+}
+
+// RunChecks runs checks in order, giving each up to timeout.
+func RunChecks(ctx context.Context, checks []Check, timeout time.Duration) []CheckResult {
+	results := make([]CheckResult, 0, len(checks))
+	for _, check := range checks {
+		results = append(results, runCheck(ctx, check, timeout))
+	}
+	return results
+}
+
+func runCheck(ctx context.Context, check Check, timeout time.Duration) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type outcome struct {
+		detail string
+		err    error
+	}
+	// Some clients connect without a context, so a check that hangs is
+	// abandoned rather than waited for.
+	done := make(chan outcome, 1)
+	start := time.Now()
+	go func() {
+		detail, err := check.Run(ctx)
+		done <- outcome{detail, err}
+	}()
+
+	result := CheckResult{Name: check.Name}
+	select {
+	case o := <-done:
+		result.Detail, result.Err = o.detail, o.err
+	case <-ctx.Done():
+		result.Err = fmt.Errorf("timed out after %s", timeout)
+	}
+	result.Duration = time.Since(start)
+
+	if errors.Is(result.Err, errCheckSkipped) {
+		result.Detail = strings.TrimPrefix(result.Err.Error(), errCheckSkipped.Error()+": ")
+		result.Err = nil
+		result.Skipped = true
+	}
+	return result
+}
+
+// WriteCheckReport writes a readable report of results to w and reports
+// whether every check passed.
+func WriteCheckReport(w io.Writer, results []CheckResult) bool {
+	fmt.Fprintf(w, "KB Platform Gateway self-test (%s)\n\n", buildinfo.Get())
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	failed, skipped := 0, 0
+	for _, result := range results {
+		status, detail := "ok", result.Detail
+		switch {
+		case result.Err != nil:
+			status, detail = "FAIL", result.Err.Error()
+			failed++
+		case result.Skipped:
+			status = "SKIP"
+			skipped++
+		}
+		fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\n", status, result.Name, result.Duration.Round(time.Millisecond), detail)
+	}
+	tw.Flush()
+
+	fmt.Fprintln(w)
+	switch {
+	case failed == 0 && skipped == 0:
+		fmt.Fprintf(w, "All %d checks passed\n", len(results))
+	case skipped == 0:
+		fmt.Fprintf(w, "%d of %d checks failed\n", failed, len(results))
+	default:
+		fmt.Fprintf(w, "%d of %d checks failed, %d skipped\n", failed, len(results), skipped)
+	}
+	return failed == 0 && skipped == 0
+}
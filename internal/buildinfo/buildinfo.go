@@ -0,0 +1,62 @@
+// Package buildinfo identifies the running gateway build. Release builds
+// set its variables with -ldflags, e.g.
+//
+//	go build -ldflags "-X kb-platform-gateway/internal/buildinfo.Version=v1.4.0 \
+//	  -X kb-platform-gateway/internal/buildinfo.GitSHA=$(git rev-parse HEAD) \
+//	  -X kb-platform-gateway/internal/buildinfo.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)" ./cmd
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Header is the HTTP header the version is sent in on every response.
+const Header = "X-Gateway-Version"
+
+// Set at link time. Without -ldflags, GitSHA falls back to the revision Go
+// stamps into binaries built inside a git checkout.
+var (
+	Version   = "dev"
+	GitSHA    = ""
+	BuildTime = ""
+)
+
+// Info describes the running build.
+type Info struct {
+	Version   string
+	GitSHA    string
+	BuildTime string
+	GoVersion string
+}
+
+// Get returns the running build's info.
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		GitSHA:    GitSHA,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+	}
+
+	if info.GitSHA != "" {
+		return info
+	}
+	if build, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range build.Settings {
+			if setting.Key == "vcs.revision" {
+				info.GitSHA = setting.Value
+			}
+		}
+	}
+	return info
+}
+
+// String returns the version with the first 12 characters of the commit,
+// when known, e.g. "v1.4.0+3f9c2e1a7b4d".
+func (i Info) String() string {
+	if i.GitSHA == "" {
+		return i.Version
+	}
+	return i.Version + "+" + i.GitSHA[:min(len(i.GitSHA), 12)]
+}
@@ -1,27 +1,59 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	Server   ServerConfig
-	Services ServicesConfig
-	Database DatabaseConfig
-	S3       S3Config
-	Temporal TemporalConfig
-	Qdrant   QdrantConfig
-	JWT      JWTConfig
+	Server        ServerConfig
+	Services      ServicesConfig
+	Database      DatabaseConfig
+	S3            S3Config
+	Temporal      TemporalConfig
+	Qdrant        QdrantConfig
+	Redis         RedisConfig
+	Auth          AuthConfig
+	Webhooks      WebhookConfig
+	Notifications NotificationConfig
+	Alerts        AlertConfig
+	StatusPage    StatusPageConfig
+	Health        HealthConfig
+	Migrations    MigrationConfig
+	Evaluations   EvaluationConfig
+	Freshness     FreshnessConfig
+	Workers       WorkerConfig
+	Uploads       UploadConfig
+	Scan          ScanConfig
+	Scheduler     SchedulerConfig
+	Demo          DemoConfig
+	Shadow        ShadowConfig
+	Connectors    ConnectorConfig
+	Dedup         DedupConfig
+	Summary       SummaryConfig
+	Conversations ConversationConfig
+	Widget        WidgetConfig
+	Trash         TrashConfig
+	Impersonation ImpersonationConfig
+	ReadCache     ReadCacheConfig
+	OIDC          OIDCConfig
+	Review        ReviewConfig
+	Access        AccessConfig
 }
 
 type ServerConfig struct {
 	Host string
 	Port int
 	Mode string
+	// GRPCEnabled starts the gateway's gRPC API on GRPCPort.
+	GRPCEnabled bool
+	GRPCPort    int
 }
 
 type DatabaseConfig struct {
@@ -39,6 +71,14 @@ type S3Config struct {
 	AccessKeyID     string
 	SecretAccessKey string
 	Endpoint        string // Optional for S3-compatible services
+	// KeyPrefix, ending in a slash when set, is the part of the bucket the
+	// workspace's objects are kept under. The S3 client refuses every
+	// other key, so workspaces can share a bucket without reaching each
+	// other's files.
+	KeyPrefix string
+	// LegacyKeys also lets the client reach objects stored before the key
+	// prefix was set, in the gateway's folders at the top of the bucket.
+	LegacyKeys bool
 }
 
 type TemporalConfig struct {
@@ -48,27 +88,701 @@ type TemporalConfig struct {
 }
 
 type ServicesConfig struct {
-	PythonCoreHost string
-	PythonCorePort int
+	// PythonCoreTransport selects how the gateway talks to the core:
+	// "http" (REST + SSE) or "grpc".
+	PythonCoreTransport string
+	PythonCoreHost      string
+	PythonCorePort      int
+	PythonCoreHTTP      CoreHTTPConfig
+	PythonCoreGRPC      CoreGRPCConfig
+	PythonCoreRetry     RetryConfig
+	// PythonCoreBackends splits core traffic across several deployments,
+	// e.g. a stable and a canary RAG pipeline, over PythonCoreTransport.
+	// When empty, PythonCoreHost and PythonCorePort (or PythonCoreGRPC)
+	// address the only core.
+	PythonCoreBackends []CoreBackendConfig
 }
 
-type JWTConfig struct {
-	Secret     string
-	Expiration time.Duration
+// CoreBackendConfig is one core deployment traffic is split across.
+type CoreBackendConfig struct {
+	Name string
+	Host string
+	Port int
+	// Weight is the backend's share of traffic relative to the other
+	// backends. Zero drains it.
+	Weight int
+}
+
+// WithCore returns the settings pointed at the core at host:port.
+func (c ServicesConfig) WithCore(host string, port int) *ServicesConfig {
+	c.PythonCoreHost = host
+	c.PythonCorePort = port
+	c.PythonCoreGRPC.Host = host
+	c.PythonCoreGRPC.Port = port
+	c.PythonCoreGRPC.Endpoints = nil
+	c.PythonCoreBackends = nil
+	return &c
+}
+
+// Validate rejects settings the gRPC client cannot honour: an unknown load
+// balancing policy, or TLS to static endpoints without TLSServerName, as
+// their certificates would be checked against the resolver's placeholder
+// name instead of the replicas'.
+func (c *CoreGRPCConfig) Validate() error {
+	switch c.LoadBalancingPolicy {
+	case "", "round_robin", "pick_first":
+	default:
+		return fmt.Errorf("PYTHON_CORE_GRPC_LB_POLICY must be round_robin or pick_first, not %q", c.LoadBalancingPolicy)
+	}
+	if c.TLSEnabled && len(c.Endpoints) > 0 && c.TLSServerName == "" {
+		return errors.New("PYTHON_CORE_GRPC_TLS_SERVER_NAME must be set when TLS is used with PYTHON_CORE_GRPC_ENDPOINTS")
+	}
+	return nil
+}
+
+// CoreHTTPConfig tunes the HTTP transport used for the Python Core service.
+type CoreHTTPConfig struct {
+	// TLSEnabled switches the base URL to https.
+	TLSEnabled bool
+	TLSCAFile  string
+	// HTTP2 negotiates HTTP/2 via ALPN over TLS, or uses prior-knowledge
+	// HTTP/2 (h2c) over plaintext.
+	HTTP2 bool
+
+	MaxIdleConnsPerHost int
+	// MaxConnsPerHost limits total connections per host; 0 means unlimited.
+	MaxConnsPerHost       int
+	IdleConnTimeout       time.Duration
+	DialTimeout           time.Duration
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration
+	// HealthCheckTimeout bounds readiness probes, which must fail fast.
+	HealthCheckTimeout time.Duration
+}
+
+// RetryConfig controls retries of idempotent calls to the Python Core.
+type RetryConfig struct {
+	// MaxAttempts is the total number of attempts, including the first.
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// HedgeDelay, when non-zero, sends an additional attempt if the previous
+	// one has not answered within this delay. The first success wins.
+	HedgeDelay time.Duration
+	// IdempotentMethods lists the gRPC methods that are safe to retry.
+	IdempotentMethods []string
+}
+
+// CoreGRPCConfig configures the gRPC connection to the Python Core service.
+type CoreGRPCConfig struct {
+	Host string
+	Port int
+
+	// Endpoints is an optional static list of host:port replicas. When empty,
+	// Host is resolved through DNS and every returned address is used.
+	Endpoints []string
+	// LoadBalancingPolicy is either "round_robin" or "pick_first".
+	LoadBalancingPolicy string
+	// HealthCheckEnabled makes the balancer skip replicas whose
+	// grpc.health.v1 status is not SERVING.
+	HealthCheckEnabled bool
+
+	// KeepaliveTime is the interval after which an idle connection is pinged.
+	KeepaliveTime time.Duration
+	// KeepaliveTimeout is how long to wait for a ping ack before closing.
+	KeepaliveTimeout time.Duration
+	// MinConnectTimeout bounds a single connection attempt during reconnects.
+	MinConnectTimeout time.Duration
+	// MaxBackoff caps the delay between reconnect attempts.
+	MaxBackoff time.Duration
+
+	// TLSEnabled switches the connection from plaintext to TLS. When
+	// TLSCertFile and TLSKeyFile are set, a client certificate is presented
+	// as well (mTLS).
+	TLSEnabled    bool
+	TLSCAFile     string
+	TLSCertFile   string
+	TLSKeyFile    string
+	TLSServerName string
+
+	// AuthToken is sent as a bearer token in the metadata of every call.
+	AuthToken string
+
+	// DefaultTimeout applies to unary calls whose context has no deadline.
+	DefaultTimeout time.Duration
+	// StreamTimeout applies to streaming calls whose context has no deadline.
+	StreamTimeout time.Duration
+	// MethodTimeouts overrides the defaults per method name,
+	// e.g. "GetDocument=2s,SaveMessage=5s".
+	MethodTimeouts map[string]time.Duration
+}
+
+type AuthConfig struct {
+	// AdminUsers are the x-user-name values allowed on admin endpoints.
+	AdminUsers []string
+	// InternalToken is the bearer token required on /internal endpoints.
+	// Without it they are not served, unless InternalInsecure is set.
+	InternalToken string
+	// InternalInsecure serves /internal endpoints without a token, for
+	// deployments where they are not reachable from outside the cluster.
+	InternalInsecure bool
+}
+
+// DemoConfig controls the anonymous demo mode. Requests bearing Token act
+// as a guest of Username with read-only access: they may query Collection
+// but not upload or change anything, and are rate limited per client IP.
+type DemoConfig struct {
+	Enabled bool
+	Token   string
+	// Username, followed by the guest's session, is recorded as the
+	// caller of guest requests.
+	Username string
+	// Collection is the Qdrant collection guests query; empty uses the
+	// active collection.
+	Collection string
+	// RateLimit is the number of requests a client IP may make per
+	// RateWindow.
+	RateLimit  int
+	RateWindow time.Duration
+}
+
+// Active reports whether guest tokens are accepted: demo mode must be
+// enabled and a token configured.
+func (c *DemoConfig) Active() bool {
+	return c.Enabled && c.Token != ""
+}
+
+// WidgetConfig controls the embeddable chat widget. A site's backend mints
+// short-lived widget tokens bound to the site's origin; with them the
+// widget may only query, start conversations and read their messages, and
+// each origin is rate limited.
+type WidgetConfig struct {
+	// SigningKey signs widget tokens. The widget is disabled without it.
+	SigningKey string
+	// AllowedOrigins are the origins, such as "https://docs.example.com",
+	// tokens may be minted for.
+	AllowedOrigins []string
+	// TokenTTL is how long a minted token is valid.
+	TokenTTL time.Duration
+	// Collection is the Qdrant collection the widget queries; empty uses
+	// the active collection.
+	Collection string
+	// RateLimit is the number of requests an origin's widgets may make per
+	// RateWindow, 0 for no limit.
+	RateLimit  int
+	RateWindow time.Duration
+}
+
+// Enabled reports whether a signing key is configured.
+func (c *WidgetConfig) Enabled() bool {
+	return c.SigningKey != ""
+}
+
+// WebhookConfig controls delivery of outbound webhook events.
+type WebhookConfig struct {
+	Workers        int
+	QueueSize      int
+	Timeout        time.Duration
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// NotificationConfig controls user email notifications.
+type NotificationConfig struct {
+	// Provider is "smtp", "ses" or empty to disable notifications.
+	Provider string
+	From     string
+	SMTP     SMTPConfig
+	// SESRegion defaults to the S3 region.
+	SESRegion string
+	Timeout   time.Duration
+	// LargeImportBytes is the size from which a finished import is
+	// announced to its uploader.
+	LargeImportBytes int64
+	// IndexingFailureThreshold is the number of failed indexing attempts
+	// of a document after which its uploader is notified.
+	IndexingFailureThreshold int
+}
+
+// AlertConfig controls operational alerts posted to chat webhooks.
+// Alerting is disabled unless at least one webhook URL is set.
+type AlertConfig struct {
+	SlackWebhookURL string
+	TeamsWebhookURL string
+	Timeout         time.Duration
+	// CheckInterval is how often dependencies are probed and the error
+	// rate is evaluated.
+	CheckInterval time.Duration
+	// ErrorRatePercent is the share of 5xx responses within
+	// ErrorRateWindow that raises an alert, once at least
+	// ErrorRateMinRequests requests were served.
+	ErrorRatePercent     int
+	ErrorRateWindow      time.Duration
+	ErrorRateMinRequests int
+	// DeadLetterThreshold is the number of failed indexing attempts after
+	// which a document is reported as dead-lettered.
+	DeadLetterThreshold int
+	// Cooldown is the minimum time between two error rate alerts.
+	Cooldown time.Duration
+}
+
+// Enabled reports whether any alert channel is configured.
+func (c *AlertConfig) Enabled() bool {
+	return c.SlackWebhookURL != "" || c.TeamsWebhookURL != ""
+}
+
+// StatusPageConfig controls the public status endpoint, served from the
+// ops monitor's dependency checks (see AlertConfig).
+type StatusPageConfig struct {
+	Enabled bool
+	// IncidentWindow is how long a dependency outage or error rate spike
+	// is flagged as a recent incident.
+	IncidentWindow time.Duration
+	// CacheTTL is how long a built status is served before it is rebuilt.
+	CacheTTL time.Duration
+	// RateLimit is the number of requests a client IP may make per
+	// RateWindow.
+	RateLimit  int
+	RateWindow time.Duration
+}
+
+// HealthConfig controls the /readyz and /startupz probes.
+type HealthConfig struct {
+	// Timeout bounds each probe's dependency checks.
+	Timeout time.Duration
+	// NonCriticalDependencies are reported by the probes without failing
+	// them, so that an outage of one, such as Temporal, does not take every
+	// gateway pod out of service. Names are those the probes report:
+	// database, python_core, temporal, qdrant and redis.
+	NonCriticalDependencies []string
+}
+
+// MigrationConfig controls embedding model migrations.
+type MigrationConfig struct {
+	// BatchSize is the number of documents re-indexed concurrently.
+	BatchSize int
+	// RefreshInterval is how often the active collection is reloaded, so
+	// every gateway instance follows a completed migration, and stalled
+	// migrations are resumed. Zero disables the refresh.
+	RefreshInterval time.Duration
+}
+
+// EvaluationConfig controls how evaluation runs are executed.
+type EvaluationConfig struct {
+	// Concurrency is the number of cases of one evaluation run at a time.
+	Concurrency int
+	// CaseTimeout bounds answering and scoring a single case.
+	CaseTimeout time.Duration
+}
+
+// FreshnessConfig controls the source URL checks behind the content
+// freshness report.
+type FreshnessConfig struct {
+	// SourceCheckInterval is how often each document's source URL is
+	// re-checked. Zero disables the checks.
+	SourceCheckInterval time.Duration
+	// SourceCheckTimeout bounds a single source URL request.
+	SourceCheckTimeout time.Duration
+}
+
+// WorkerConfig bounds fan-out work shared across the gateway instance.
+type WorkerConfig struct {
+	// PoolSize is the number of fan-out tasks, such as source URL checks
+	// and the items of batch requests, run at a time.
+	PoolSize int
+}
+
+// UploadConfig limits the bandwidth of files uploaded through the gateway,
+// so bulk imports leave room for query traffic. Limits are in bytes per
+// second; 0 disables them.
+type UploadConfig struct {
+	// BandwidthLimit is shared by all uploads on the instance.
+	BandwidthLimit int
+	// RequestBandwidthLimit applies to each upload on its own.
+	RequestBandwidthLimit int
+
+	// ProxyEnabled lets clients that cannot reach S3 stream files through
+	// the gateway instead of to a presigned URL.
+	ProxyEnabled bool
+	// ProxyMaxSize is the largest file, in bytes, streamed through the
+	// gateway.
+	ProxyMaxSize int64
+	// ProxyContentTypes are the content types streamed through the
+	// gateway; "type/*" allows a whole type. Empty allows any.
+	ProxyContentTypes []string
+	// ProxyPartSize and ProxyConcurrency bound the memory of each stream:
+	// at most ProxyConcurrency parts of ProxyPartSize bytes are buffered.
+	ProxyPartSize    int64
+	ProxyConcurrency int
+
+	// MaxFileSize is the largest file, in bytes, accepted however it is
+	// uploaded; 0 accepts any size.
+	MaxFileSize int64
+	// AllowedContentTypes are the content types accepted; "type/*" allows
+	// a whole type. Empty allows any.
+	AllowedContentTypes []string
+	// AllowedExtensions are the filename extensions accepted. Empty allows
+	// any.
+	AllowedExtensions []string
+}
+
+// PolicyEnabled reports whether uploaded files are restricted by size,
+// type or extension.
+func (c *UploadConfig) PolicyEnabled() bool {
+	return c.MaxFileSize > 0 || len(c.AllowedContentTypes) > 0 || len(c.AllowedExtensions) > 0
+}
+
+// ScanConfig controls scanning uploaded files for malware before they are
+// indexed.
+type ScanConfig struct {
+	// ClamAVAddr is the host:port of the clamd daemon files are streamed
+	// to. Uploads are not scanned without it.
+	ClamAVAddr string
+	// Timeout bounds scanning one file, including reading it from S3.
+	Timeout time.Duration
+	// Quarantine moves infected files under the quarantine/ prefix
+	// instead of deleting them.
+	Quarantine bool
+}
+
+// Enabled reports whether uploads are scanned.
+func (c *ScanConfig) Enabled() bool {
+	return c.ClamAVAddr != ""
+}
+
+// SchedulerConfig controls priority scheduling of API requests: chat is
+// admitted ahead of document requests, and those ahead of batch work.
+type SchedulerConfig struct {
+	// MaxInFlight is the number of requests served at a time; 0 disables
+	// scheduling.
+	MaxInFlight int
+	// QueueTimeout is how long a request waits to be admitted before it
+	// is refused.
+	QueueTimeout time.Duration
+}
+
+// Enabled reports whether requests are scheduled.
+func (c *SchedulerConfig) Enabled() bool {
+	return c.MaxInFlight > 0
+}
+
+// ShadowConfig controls mirroring of query traffic to a second, staging
+// core. Shadow responses are discarded; only their latencies are kept.
+type ShadowConfig struct {
+	// Percent of queries mirrored, 0-100. Zero disables shadowing.
+	Percent int
+	// Transport, Host and Port address the shadow core. The other core
+	// settings are shared with the primary core.
+	Transport string
+	Host      string
+	Port      int
+	// Timeout bounds a mirrored query.
+	Timeout time.Duration
+	// MaxInFlight caps concurrent mirrored queries; queries sampled beyond
+	// it are skipped.
+	MaxInFlight int
+}
+
+// Active reports whether queries are mirrored: a percentage and a shadow
+// core host must be configured.
+func (c *ShadowConfig) Active() bool {
+	return c.Percent > 0 && c.Host != ""
+}
+
+// CoreConfig returns the primary core settings pointed at the shadow core.
+func (c *ShadowConfig) CoreConfig(primary ServicesConfig) *ServicesConfig {
+	primary.PythonCoreTransport = c.Transport
+	return primary.WithCore(c.Host, c.Port)
+}
+
+// DedupConfig controls answering a question from the answer to a recent,
+// near-identical one instead of querying the core.
+type DedupConfig struct {
+	// Window is how long answers are reused. Zero disables duplicate
+	// detection.
+	Window time.Duration
+	// Similarity is the minimum word overlap between two questions, 0-100,
+	// for them to count as the same.
+	Similarity int
+	// MaxCandidates caps the recent answers compared with each question.
+	MaxCandidates int
+}
+
+// Enabled reports whether recent answers are reused.
+func (c *DedupConfig) Enabled() bool {
+	return c.Window > 0
+}
+
+// SummaryConfig controls rolling summaries of long conversations. Once a
+// conversation passes either threshold, queries send the core its latest
+// summary and the messages after it instead of the whole history.
+type SummaryConfig struct {
+	// MessageThreshold is the number of messages after which a
+	// conversation is summarized. Zero disables the message threshold.
+	MessageThreshold int
+	// TokenThreshold is the estimated number of tokens after which a
+	// conversation is summarized. Zero disables the token threshold.
+	TokenThreshold int
+	// RecentMessages is how many of the latest messages are always sent
+	// verbatim rather than summarized.
+	RecentMessages int
+	// Timeout bounds each request for a new summary.
+	Timeout time.Duration
+}
+
+// Enabled reports whether long conversations are summarized.
+func (c *SummaryConfig) Enabled() bool {
+	return c.MessageThreshold > 0 || c.TokenThreshold > 0
+}
+
+// Modes of ConversationConfig.QueryMode.
+const (
+	ConversationQueryReject = "reject"
+	ConversationQueryQueue  = "queue"
+	ConversationQueryOff    = "off"
+)
+
+// ConversationConfig controls concurrent queries in one conversation,
+// whose interleaved answers would corrupt its message order.
+type ConversationConfig struct {
+	// QueryMode is what a query does while another is in flight in its
+	// conversation: reject it, queue it behind the other, or run it
+	// anyway (off). It defaults to reject.
+	QueryMode string
+	// QueueTimeout is how long a queued query waits before it is rejected.
+	QueueTimeout time.Duration
+	// LockTTL releases the lock of a query whose gateway instance died
+	// mid-answer, when locks are shared through Redis. It should exceed
+	// the longest query.
+	LockTTL time.Duration
+}
+
+// Enabled reports whether concurrent queries in a conversation are
+// prevented.
+func (c *ConversationConfig) Enabled() bool {
+	return c.QueryMode != ConversationQueryOff
+}
+
+// ConnectorConfig controls syncing documents from external sources such
+// as Google Drive and SharePoint.
+type ConnectorConfig struct {
+	// EncryptionKey is a base64-encoded 32-byte AES key sealing the
+	// connectors' OAuth credentials. Connectors are disabled without it.
+	EncryptionKey string
+	// SyncInterval is how often a connector syncs unless it sets its own.
+	SyncInterval time.Duration
+	// PollInterval is how often due connectors are looked for.
+	PollInterval time.Duration
+}
+
+// Enabled reports whether an encryption key is configured.
+func (c *ConnectorConfig) Enabled() bool {
+	return c.EncryptionKey != ""
+}
+
+// TrashConfig controls keeping deleted documents in a trash, from which
+// they can be restored, until a scheduled purge deletes them for good.
+type TrashConfig struct {
+	// Retention is how long deleted documents stay in the trash. Zero
+	// deletes documents at once.
+	Retention time.Duration
+	// PurgeCron is the Temporal schedule of the purge.
+	PurgeCron string
+}
+
+// Enabled reports whether deleted documents go to the trash.
+func (c *TrashConfig) Enabled() bool {
+	return c.Retention > 0
+}
+
+// ImpersonationConfig controls the tokens admins mint to act as another
+// user while reproducing their issues.
+type ImpersonationConfig struct {
+	// SigningKey signs impersonation tokens. Impersonation is disabled
+	// without it.
+	SigningKey string
+	// TokenTTL is how long a minted token is valid.
+	TokenTTL time.Duration
+}
+
+// Enabled reports whether a signing key is configured.
+func (c *ImpersonationConfig) Enabled() bool {
+	return c.SigningKey != ""
+}
+
+// ReadCacheConfig controls sharing document and conversation reads
+// between concurrent requests for the same record. Concurrent reads are
+// always coalesced into one; a TTL also keeps the result for later ones.
+type ReadCacheConfig struct {
+	// TTL is how long a read is reused. Zero only coalesces concurrent
+	// reads.
+	TTL time.Duration
+	// Size caps the records kept.
+	Size int
+}
+
+// Enabled reports whether reads are kept after they complete.
+func (c *ReadCacheConfig) Enabled() bool {
+	return c.TTL > 0
+}
+
+// OIDCConfig controls accepting tokens issued by an OpenID Connect
+// provider, so clients that sign users in with it can call the gateway
+// without the identity proxy.
+type OIDCConfig struct {
+	// Issuer is the provider's issuer URL, from which its signing keys
+	// are discovered.
+	Issuer string
+	// ClientID is the audience tokens must be issued for.
+	ClientID string
+	// UsernameClaim is the claim holding the gateway username.
+	UsernameClaim string
+	// Timeout bounds fetching the provider's discovery document and keys.
+	Timeout time.Duration
+}
+
+// Enabled reports whether an issuer and client ID are configured.
+func (c *OIDCConfig) Enabled() bool {
+	return c.Issuer != "" && c.ClientID != ""
+}
+
+// ReviewConfig controls holding uploads for a reviewer's approval before
+// they are indexed, for workspaces holding regulated content.
+type ReviewConfig struct {
+	// Reviewers are the x-user-name values allowed to approve or reject
+	// documents. Uploads are indexed without review when it is empty.
+	Reviewers []string
+}
+
+// Enabled reports whether uploads wait for review.
+func (c *ReviewConfig) Enabled() bool {
+	return len(c.Reviewers) > 0
+}
+
+// AccessConfig controls which users' queries may retrieve chunks of
+// documents restricted to access groups.
+type AccessConfig struct {
+	// Groups maps access group names to the x-user-name values in them.
+	// Documents without access groups are retrieved for everyone; admins'
+	// queries retrieve every document.
+	Groups map[string][]string
+}
+
+// Enabled reports whether any access group is configured.
+func (c *AccessConfig) Enabled() bool {
+	return len(c.Groups) > 0
+}
+
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+}
+
+// Features lists the optional features this configuration turns on, for
+// the version endpoint.
+func (c *Config) Features() []string {
+	enabled := []struct {
+		name string
+		on   bool
+	}{
+		{"grpc", c.Server.GRPCEnabled},
+		{"redis", c.Redis.Enabled},
+		{"canary", len(c.Services.PythonCoreBackends) > 0},
+		{"shadow", c.Shadow.Active()},
+		{"notifications", c.Notifications.Provider != ""},
+		{"alerts", c.Alerts.Enabled()},
+		{"status_page", c.StatusPage.Enabled},
+		{"source_checks", c.Freshness.SourceCheckInterval > 0},
+		{"demo", c.Demo.Active()},
+		{"widget", c.Widget.Enabled()},
+		{"connectors", c.Connectors.Enabled()},
+		{"dedup", c.Dedup.Enabled()},
+		// The core's gRPC service cannot summarize.
+		{"summaries", c.Summary.Enabled() && c.Services.PythonCoreTransport != "grpc"},
+		{"trash", c.Trash.Enabled()},
+		{"impersonation", c.Impersonation.Enabled()},
+		{"read_cache", c.ReadCache.Enabled()},
+		{"oidc", c.OIDC.Enabled()},
+		{"review", c.Review.Enabled()},
+		{"access_groups", c.Access.Enabled()},
+		{"upload_policy", c.Uploads.PolicyEnabled()},
+		{"malware_scan", c.Scan.Enabled()},
+		{"scheduler", c.Scheduler.Enabled()},
+	}
+
+	features := []string{}
+	for _, feature := range enabled {
+		if feature.on {
+			features = append(features, feature.name)
+		}
+	}
+	return features
 }
 
 func Load() (*Config, error) {
 	_ = godotenv.Load()
 
+	methodTimeouts, err := getEnvAsDurationMap("PYTHON_CORE_GRPC_METHOD_TIMEOUTS")
+	if err != nil {
+		return nil, err
+	}
+
 	cfg := &Config{
 		Server: ServerConfig{
 			Host: getEnv("SERVER_HOST", "0.0.0.0"),
 			Port: getEnvAsInt("SERVER_PORT", 8080),
 			Mode: getEnv("GIN_MODE", "debug"),
+
+			GRPCEnabled: getEnvAsBool("SERVER_GRPC_ENABLED", false),
+			GRPCPort:    getEnvAsInt("SERVER_GRPC_PORT", 9090),
 		},
 		Services: ServicesConfig{
-			PythonCoreHost: getEnv("PYTHON_CORE_HOST", "python-llama-core"),
-			PythonCorePort: getEnvAsInt("PYTHON_CORE_PORT", 8000),
+			PythonCoreTransport: getEnv("PYTHON_CORE_TRANSPORT", "http"),
+			PythonCoreHost:      getEnv("PYTHON_CORE_HOST", "python-llama-core"),
+			PythonCorePort:      getEnvAsInt("PYTHON_CORE_PORT", 8000),
+			PythonCoreHTTP: CoreHTTPConfig{
+				TLSEnabled:            getEnvAsBool("PYTHON_CORE_HTTP_TLS_ENABLED", false),
+				TLSCAFile:             getEnv("PYTHON_CORE_HTTP_TLS_CA_FILE", ""),
+				HTTP2:                 getEnvAsBool("PYTHON_CORE_HTTP2", false),
+				MaxIdleConnsPerHost:   getEnvAsInt("PYTHON_CORE_HTTP_MAX_IDLE_CONNS_PER_HOST", 32),
+				MaxConnsPerHost:       getEnvAsInt("PYTHON_CORE_HTTP_MAX_CONNS_PER_HOST", 0),
+				IdleConnTimeout:       getEnvAsDuration("PYTHON_CORE_HTTP_IDLE_CONN_TIMEOUT", 90*time.Second),
+				DialTimeout:           getEnvAsDuration("PYTHON_CORE_HTTP_DIAL_TIMEOUT", 5*time.Second),
+				TLSHandshakeTimeout:   getEnvAsDuration("PYTHON_CORE_HTTP_TLS_HANDSHAKE_TIMEOUT", 5*time.Second),
+				ResponseHeaderTimeout: getEnvAsDuration("PYTHON_CORE_HTTP_RESPONSE_HEADER_TIMEOUT", 30*time.Second),
+				HealthCheckTimeout:    getEnvAsDuration("PYTHON_CORE_HTTP_HEALTH_CHECK_TIMEOUT", 3*time.Second),
+			},
+			PythonCoreGRPC: CoreGRPCConfig{
+				Host:                getEnv("PYTHON_CORE_GRPC_HOST", getEnv("PYTHON_CORE_HOST", "python-llama-core")),
+				Port:                getEnvAsInt("PYTHON_CORE_GRPC_PORT", 50051),
+				Endpoints:           getEnvAsSlice("PYTHON_CORE_GRPC_ENDPOINTS"),
+				LoadBalancingPolicy: getEnv("PYTHON_CORE_GRPC_LB_POLICY", "round_robin"),
+				HealthCheckEnabled:  getEnvAsBool("PYTHON_CORE_GRPC_HEALTH_CHECK", true),
+				KeepaliveTime:       getEnvAsDuration("PYTHON_CORE_GRPC_KEEPALIVE_TIME", 30*time.Second),
+				KeepaliveTimeout:    getEnvAsDuration("PYTHON_CORE_GRPC_KEEPALIVE_TIMEOUT", 10*time.Second),
+				MinConnectTimeout:   getEnvAsDuration("PYTHON_CORE_GRPC_MIN_CONNECT_TIMEOUT", 5*time.Second),
+				MaxBackoff:          getEnvAsDuration("PYTHON_CORE_GRPC_MAX_BACKOFF", 30*time.Second),
+				TLSEnabled:          getEnvAsBool("PYTHON_CORE_GRPC_TLS_ENABLED", false),
+				TLSCAFile:           getEnv("PYTHON_CORE_GRPC_TLS_CA_FILE", ""),
+				TLSCertFile:         getEnv("PYTHON_CORE_GRPC_TLS_CERT_FILE", ""),
+				TLSKeyFile:          getEnv("PYTHON_CORE_GRPC_TLS_KEY_FILE", ""),
+				TLSServerName:       getEnv("PYTHON_CORE_GRPC_TLS_SERVER_NAME", ""),
+				AuthToken:           getEnv("PYTHON_CORE_GRPC_AUTH_TOKEN", ""),
+				DefaultTimeout:      getEnvAsDuration("PYTHON_CORE_GRPC_DEFAULT_TIMEOUT", 10*time.Second),
+				StreamTimeout:       getEnvAsDuration("PYTHON_CORE_GRPC_STREAM_TIMEOUT", 5*time.Minute),
+				MethodTimeouts:      methodTimeouts,
+			},
+			PythonCoreRetry: RetryConfig{
+				MaxAttempts:       getEnvAsInt("PYTHON_CORE_RETRY_MAX_ATTEMPTS", 3),
+				InitialBackoff:    getEnvAsDuration("PYTHON_CORE_RETRY_INITIAL_BACKOFF", 100*time.Millisecond),
+				MaxBackoff:        getEnvAsDuration("PYTHON_CORE_RETRY_MAX_BACKOFF", 2*time.Second),
+				HedgeDelay:        getEnvAsDuration("PYTHON_CORE_RETRY_HEDGE_DELAY", 0),
+				IdempotentMethods: getEnvAsSliceDefault("PYTHON_CORE_RETRY_METHODS", []string{"GetDocument", "GetConversation", "GetConversationMessages"}),
+			},
+			PythonCoreBackends: getEnvAsCoreBackends("PYTHON_CORE_BACKENDS"),
 		},
 		Database: DatabaseConfig{
 			Host:     getEnv("DB_HOST", "postgres"),
@@ -84,6 +798,8 @@ func Load() (*Config, error) {
 			AccessKeyID:     getEnv("S3_ACCESS_KEY_ID", ""),
 			SecretAccessKey: getEnv("S3_SECRET_ACCESS_KEY", ""),
 			Endpoint:        getEnv("S3_ENDPOINT", ""),
+			KeyPrefix:       getEnvAsKeyPrefix("S3_KEY_PREFIX"),
+			LegacyKeys:      getEnvAsBool("S3_LEGACY_KEYS", false),
 		},
 		Temporal: TemporalConfig{
 			Host:      getEnv("TEMPORAL_HOST", "temporal"),
@@ -96,10 +812,177 @@ func Load() (*Config, error) {
 			Port:       getEnvAsInt("QDRANT_PORT", 6334), // gRPC port
 			Collection: getEnv("QDRANT_COLLECTION", "documents"),
 		},
-		JWT: JWTConfig{
-			Secret:     getEnv("JWT_SECRET", "kb-platform-secret-key"),
-			Expiration: getEnvAsDuration("JWT_EXPIRATION", 24*time.Hour),
+		Redis: RedisConfig{
+			Enabled:      getEnvAsBool("REDIS_ENABLED", false),
+			Addr:         getEnv("REDIS_ADDR", "redis:6379"),
+			Username:     getEnv("REDIS_USERNAME", ""),
+			Password:     getEnv("REDIS_PASSWORD", ""),
+			DB:           getEnvAsInt("REDIS_DB", 0),
+			KeyPrefix:    getEnv("REDIS_KEY_PREFIX", "kb:"),
+			PoolSize:     getEnvAsInt("REDIS_POOL_SIZE", 0),
+			TLSEnabled:   getEnvAsBool("REDIS_TLS_ENABLED", false),
+			DialTimeout:  getEnvAsDuration("REDIS_DIAL_TIMEOUT", 5*time.Second),
+			ReadTimeout:  getEnvAsDuration("REDIS_READ_TIMEOUT", 3*time.Second),
+			WriteTimeout: getEnvAsDuration("REDIS_WRITE_TIMEOUT", 3*time.Second),
+		},
+		Auth: AuthConfig{
+			AdminUsers:       getEnvAsSlice("AUTH_ADMIN_USERS"),
+			InternalToken:    getEnv("AUTH_INTERNAL_TOKEN", ""),
+			InternalInsecure: getEnvAsBool("AUTH_INTERNAL_INSECURE", false),
+		},
+		Demo: DemoConfig{
+			Enabled:    getEnvAsBool("DEMO_ENABLED", false),
+			Token:      getEnv("DEMO_TOKEN", ""),
+			Username:   getEnv("DEMO_USERNAME", "demo"),
+			Collection: getEnv("DEMO_COLLECTION", ""),
+			RateLimit:  getEnvAsInt("DEMO_RATE_LIMIT", 30),
+			RateWindow: getEnvAsDuration("DEMO_RATE_WINDOW", time.Minute),
+		},
+		Webhooks: WebhookConfig{
+			Workers:        getEnvAsInt("WEBHOOK_WORKERS", 4),
+			QueueSize:      getEnvAsInt("WEBHOOK_QUEUE_SIZE", 1000),
+			Timeout:        getEnvAsDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+			MaxAttempts:    getEnvAsInt("WEBHOOK_MAX_ATTEMPTS", 5),
+			InitialBackoff: getEnvAsDuration("WEBHOOK_INITIAL_BACKOFF", time.Second),
+			MaxBackoff:     getEnvAsDuration("WEBHOOK_MAX_BACKOFF", 5*time.Minute),
+		},
+		Notifications: NotificationConfig{
+			Provider: getEnv("NOTIFY_PROVIDER", ""),
+			From:     getEnv("NOTIFY_FROM", "kb-platform@localhost"),
+			SMTP: SMTPConfig{
+				Host:     getEnv("SMTP_HOST", "localhost"),
+				Port:     getEnvAsInt("SMTP_PORT", 587),
+				Username: getEnv("SMTP_USERNAME", ""),
+				Password: getEnv("SMTP_PASSWORD", ""),
+			},
+			SESRegion:                getEnv("SES_REGION", getEnv("S3_REGION", "us-east-1")),
+			Timeout:                  getEnvAsDuration("NOTIFY_TIMEOUT", 30*time.Second),
+			LargeImportBytes:         int64(getEnvAsInt("NOTIFY_LARGE_IMPORT_BYTES", 50<<20)),
+			IndexingFailureThreshold: getEnvAsInt("NOTIFY_INDEXING_FAILURE_THRESHOLD", 3),
+		},
+		Alerts: AlertConfig{
+			SlackWebhookURL:      getEnv("ALERT_SLACK_WEBHOOK_URL", ""),
+			TeamsWebhookURL:      getEnv("ALERT_TEAMS_WEBHOOK_URL", ""),
+			Timeout:              getEnvAsDuration("ALERT_TIMEOUT", 10*time.Second),
+			CheckInterval:        getEnvAsDuration("ALERT_CHECK_INTERVAL", 30*time.Second),
+			ErrorRatePercent:     getEnvAsInt("ALERT_ERROR_RATE_PERCENT", 5),
+			ErrorRateWindow:      getEnvAsDuration("ALERT_ERROR_RATE_WINDOW", 5*time.Minute),
+			ErrorRateMinRequests: getEnvAsInt("ALERT_ERROR_RATE_MIN_REQUESTS", 20),
+			DeadLetterThreshold:  getEnvAsInt("ALERT_DEAD_LETTER_THRESHOLD", 3),
+			Cooldown:             getEnvAsDuration("ALERT_COOLDOWN", 15*time.Minute),
+		},
+		StatusPage: StatusPageConfig{
+			Enabled:        getEnvAsBool("STATUS_PAGE_ENABLED", false),
+			IncidentWindow: getEnvAsDuration("STATUS_PAGE_INCIDENT_WINDOW", 24*time.Hour),
+			CacheTTL:       getEnvAsDuration("STATUS_PAGE_CACHE_TTL", 30*time.Second),
+			RateLimit:      getEnvAsInt("STATUS_PAGE_RATE_LIMIT", 60),
+			RateWindow:     getEnvAsDuration("STATUS_PAGE_RATE_WINDOW", time.Minute),
+		},
+		Health: HealthConfig{
+			Timeout:                 getEnvAsDuration("HEALTH_CHECK_TIMEOUT", 5*time.Second),
+			NonCriticalDependencies: getEnvAsSlice("HEALTH_NONCRITICAL_DEPENDENCIES"),
+		},
+		Migrations: MigrationConfig{
+			BatchSize:       getEnvAsInt("MIGRATION_BATCH_SIZE", 20),
+			RefreshInterval: getEnvAsDuration("MIGRATION_REFRESH_INTERVAL", 30*time.Second),
+		},
+		Evaluations: EvaluationConfig{
+			Concurrency: getEnvAsInt("EVAL_CONCURRENCY", 4),
+			CaseTimeout: getEnvAsDuration("EVAL_CASE_TIMEOUT", 2*time.Minute),
+		},
+		Freshness: FreshnessConfig{
+			SourceCheckInterval: getEnvAsDuration("FRESHNESS_SOURCE_CHECK_INTERVAL", 24*time.Hour),
+			SourceCheckTimeout:  getEnvAsDuration("FRESHNESS_SOURCE_CHECK_TIMEOUT", 10*time.Second),
+		},
+		Workers: WorkerConfig{
+			PoolSize: getEnvAsInt("WORKER_POOL_SIZE", 4),
+		},
+		Uploads: UploadConfig{
+			BandwidthLimit:        getEnvAsInt("UPLOAD_BANDWIDTH_LIMIT", 0),
+			RequestBandwidthLimit: getEnvAsInt("UPLOAD_REQUEST_BANDWIDTH_LIMIT", 0),
+			ProxyEnabled:          getEnvAsBool("UPLOAD_PROXY_ENABLED", false),
+			ProxyMaxSize:          int64(getEnvAsInt("UPLOAD_PROXY_MAX_SIZE", 5<<30)),
+			ProxyContentTypes:     getEnvAsSlice("UPLOAD_PROXY_CONTENT_TYPES"),
+			ProxyPartSize:         int64(getEnvAsInt("UPLOAD_PROXY_PART_SIZE", 8<<20)),
+			ProxyConcurrency:      getEnvAsInt("UPLOAD_PROXY_CONCURRENCY", 2),
+			MaxFileSize:           int64(getEnvAsInt("UPLOAD_MAX_FILE_SIZE", 0)),
+			AllowedContentTypes:   getEnvAsSlice("UPLOAD_ALLOWED_CONTENT_TYPES"),
+			AllowedExtensions:     getEnvAsSlice("UPLOAD_ALLOWED_EXTENSIONS"),
 		},
+		Scan: ScanConfig{
+			ClamAVAddr: getEnv("SCAN_CLAMAV_ADDR", ""),
+			Timeout:    getEnvAsDuration("SCAN_TIMEOUT", 5*time.Minute),
+			Quarantine: getEnvAsBool("SCAN_QUARANTINE", true),
+		},
+		Scheduler: SchedulerConfig{
+			MaxInFlight:  getEnvAsInt("SCHEDULER_MAX_IN_FLIGHT", 0),
+			QueueTimeout: getEnvAsDuration("SCHEDULER_QUEUE_TIMEOUT", 5*time.Second),
+		},
+		Shadow: ShadowConfig{
+			Percent:     getEnvAsInt("SHADOW_CORE_PERCENT", 0),
+			Transport:   getEnv("SHADOW_CORE_TRANSPORT", "http"),
+			Host:        getEnv("SHADOW_CORE_HOST", ""),
+			Port:        getEnvAsInt("SHADOW_CORE_PORT", 8000),
+			Timeout:     getEnvAsDuration("SHADOW_CORE_TIMEOUT", 2*time.Minute),
+			MaxInFlight: getEnvAsInt("SHADOW_CORE_MAX_IN_FLIGHT", 16),
+		},
+		Connectors: ConnectorConfig{
+			EncryptionKey: getEnv("CONNECTOR_ENCRYPTION_KEY", ""),
+			SyncInterval:  getEnvAsDuration("CONNECTOR_SYNC_INTERVAL", time.Hour),
+			PollInterval:  getEnvAsDuration("CONNECTOR_POLL_INTERVAL", time.Minute),
+		},
+		Dedup: DedupConfig{
+			Window:        getEnvAsDuration("QUERY_DEDUP_WINDOW", 0),
+			Similarity:    getEnvAsInt("QUERY_DEDUP_SIMILARITY", 90),
+			MaxCandidates: getEnvAsInt("QUERY_DEDUP_MAX_CANDIDATES", 200),
+		},
+		Summary: SummaryConfig{
+			MessageThreshold: getEnvAsInt("CONVERSATION_SUMMARY_MESSAGES", 0),
+			TokenThreshold:   getEnvAsInt("CONVERSATION_SUMMARY_TOKENS", 0),
+			RecentMessages:   getEnvAsInt("CONVERSATION_SUMMARY_RECENT", 6),
+			Timeout:          getEnvAsDuration("CONVERSATION_SUMMARY_TIMEOUT", time.Minute),
+		},
+		Conversations: ConversationConfig{
+			QueryMode:    getEnv("CONVERSATION_QUERY_MODE", ConversationQueryReject),
+			QueueTimeout: getEnvAsDuration("CONVERSATION_QUEUE_TIMEOUT", 30*time.Second),
+			LockTTL:      getEnvAsDuration("CONVERSATION_LOCK_TTL", 10*time.Minute),
+		},
+		Widget: WidgetConfig{
+			SigningKey:     getEnv("WIDGET_SIGNING_KEY", ""),
+			AllowedOrigins: getEnvAsSlice("WIDGET_ALLOWED_ORIGINS"),
+			TokenTTL:       getEnvAsDuration("WIDGET_TOKEN_TTL", 15*time.Minute),
+			Collection:     getEnv("WIDGET_COLLECTION", ""),
+			RateLimit:      getEnvAsInt("WIDGET_RATE_LIMIT", 300),
+			RateWindow:     getEnvAsDuration("WIDGET_RATE_WINDOW", time.Minute),
+		},
+		Trash: TrashConfig{
+			Retention: getEnvAsDuration("TRASH_RETENTION", 0),
+			PurgeCron: getEnv("TRASH_PURGE_CRON", "0 3 * * *"),
+		},
+		Impersonation: ImpersonationConfig{
+			SigningKey: getEnv("IMPERSONATION_SIGNING_KEY", ""),
+			TokenTTL:   getEnvAsDuration("IMPERSONATION_TOKEN_TTL", 30*time.Minute),
+		},
+		ReadCache: ReadCacheConfig{
+			TTL:  getEnvAsDuration("READ_CACHE_TTL", 0),
+			Size: getEnvAsInt("READ_CACHE_SIZE", 10000),
+		},
+		OIDC: OIDCConfig{
+			Issuer:        getEnv("OIDC_ISSUER", ""),
+			ClientID:      getEnv("OIDC_CLIENT_ID", ""),
+			UsernameClaim: getEnv("OIDC_USERNAME_CLAIM", "preferred_username"),
+			Timeout:       getEnvAsDuration("OIDC_TIMEOUT", 5*time.Second),
+		},
+		Review: ReviewConfig{
+			Reviewers: getEnvAsSlice("DOCUMENT_REVIEWERS"),
+		},
+		Access: AccessConfig{
+			Groups: getEnvAsGroups("ACCESS_GROUPS"),
+		},
+	}
+
+	if err := cfg.Services.PythonCoreGRPC.Validate(); err != nil {
+		return nil, err
 	}
 
 	return cfg, nil
@@ -111,6 +994,26 @@ type QdrantConfig struct {
 	Collection string
 }
 
+// RedisConfig configures the shared Redis store. Features backed by Redis
+// are disabled unless Enabled is set.
+type RedisConfig struct {
+	Enabled  bool
+	Addr     string
+	Username string
+	Password string
+	DB       int
+	// KeyPrefix namespaces every key and pub/sub channel so several
+	// services can share one Redis.
+	KeyPrefix string
+	// PoolSize is the maximum number of connections; 0 uses the client
+	// default of 10 per CPU.
+	PoolSize     int
+	TLSEnabled   bool
+	DialTimeout  time.Duration
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -142,3 +1045,97 @@ func getEnvAsBool(key string, defaultValue bool) bool {
 	}
 	return defaultValue
 }
+
+// getEnvAsDurationMap parses a comma-separated list of name=duration pairs.
+// A malformed entry is an error, so a mistyped timeout is not silently
+// replaced by the default.
+func getEnvAsDurationMap(key string) (map[string]time.Duration, error) {
+	result := make(map[string]time.Duration)
+	for _, pair := range getEnvAsSlice(key) {
+		name, value, ok := strings.Cut(pair, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("%s: %q is not a name=duration pair", key, pair)
+		}
+		duration, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", key, name, err)
+		}
+		result[name] = duration
+	}
+	return result, nil
+}
+
+// getEnvAsGroups parses a comma-separated list of name=member|member
+// entries. Group names are lowercased; entries without members are
+// skipped.
+func getEnvAsGroups(key string) map[string][]string {
+	result := make(map[string][]string)
+	for _, entry := range getEnvAsSlice(key) {
+		name, members, ok := strings.Cut(entry, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !ok || name == "" {
+			continue
+		}
+		for _, member := range strings.Split(members, "|") {
+			if member = strings.TrimSpace(member); member != "" {
+				result[name] = append(result[name], member)
+			}
+		}
+	}
+	return result
+}
+
+// getEnvAsCoreBackends parses a comma-separated list of
+// name=host:port:weight entries. Malformed entries are skipped.
+func getEnvAsCoreBackends(key string) []CoreBackendConfig {
+	var result []CoreBackendConfig
+	for _, entry := range getEnvAsSlice(key) {
+		name, address, ok := strings.Cut(entry, "=")
+		if !ok || name == "" {
+			continue
+		}
+		parts := strings.Split(address, ":")
+		if len(parts) != 3 || parts[0] == "" {
+			continue
+		}
+		port, err := strconv.Atoi(parts[1])
+		if err != nil {
+			continue
+		}
+		weight, err := strconv.Atoi(parts[2])
+		if err != nil || weight < 0 {
+			continue
+		}
+		result = append(result, CoreBackendConfig{Name: name, Host: parts[0], Port: port, Weight: weight})
+	}
+	return result
+}
+
+// getEnvAsKeyPrefix reads an S3 key prefix, trimmed of surrounding
+// slashes and ending in one, or "" if unset.
+func getEnvAsKeyPrefix(key string) string {
+	prefix := strings.Trim(strings.TrimSpace(os.Getenv(key)), "/")
+	if prefix == "" {
+		return ""
+	}
+	return prefix + "/"
+}
+
+func getEnvAsSliceDefault(key string, defaultValue []string) []string {
+	if values := getEnvAsSlice(key); len(values) > 0 {
+		return values
+	}
+	return defaultValue
+}
+
+// getEnvAsSlice parses a comma-separated list, dropping empty entries.
+func getEnvAsSlice(key string) []string {
+	var result []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
@@ -0,0 +1,64 @@
+package config_test
+
+import (
+	"testing"
+	"time"
+
+	"kb-platform-gateway/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad_CoreGRPC(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		cfg, err := config.Load()
+
+		require.NoError(t, err)
+		assert.Equal(t, "round_robin", cfg.Services.PythonCoreGRPC.LoadBalancingPolicy)
+	})
+
+	t.Run("UnknownPolicy", func(t *testing.T) {
+		t.Setenv("PYTHON_CORE_GRPC_LB_POLICY", "least_request")
+
+		_, err := config.Load()
+
+		assert.ErrorContains(t, err, "PYTHON_CORE_GRPC_LB_POLICY")
+	})
+
+	t.Run("StaticEndpointsTLS", func(t *testing.T) {
+		t.Setenv("PYTHON_CORE_GRPC_ENDPOINTS", "10.0.0.1:50051,10.0.0.2:50051")
+		t.Setenv("PYTHON_CORE_GRPC_TLS_ENABLED", "true")
+
+		_, err := config.Load()
+
+		assert.ErrorContains(t, err, "PYTHON_CORE_GRPC_TLS_SERVER_NAME")
+
+		t.Setenv("PYTHON_CORE_GRPC_TLS_SERVER_NAME", "core.internal")
+
+		cfg, err := config.Load()
+
+		require.NoError(t, err)
+		assert.Equal(t, "core.internal", cfg.Services.PythonCoreGRPC.TLSServerName)
+	})
+
+	t.Run("MethodTimeouts", func(t *testing.T) {
+		t.Setenv("PYTHON_CORE_GRPC_METHOD_TIMEOUTS", "GetDocument=2s, SaveMessage = 5s,")
+
+		cfg, err := config.Load()
+
+		require.NoError(t, err)
+		assert.Equal(t, map[string]time.Duration{"GetDocument": 2 * time.Second, "SaveMessage": 5 * time.Second},
+			cfg.Services.PythonCoreGRPC.MethodTimeouts)
+	})
+
+	t.Run("MethodTimeouts_Malformed", func(t *testing.T) {
+		for _, value := range []string{"GetDocument=2s,SaveMessage", "GetDocument=2 seconds", "=2s"} {
+			t.Setenv("PYTHON_CORE_GRPC_METHOD_TIMEOUTS", value)
+
+			_, err := config.Load()
+
+			assert.ErrorContains(t, err, "PYTHON_CORE_GRPC_METHOD_TIMEOUTS", value)
+		}
+	})
+}
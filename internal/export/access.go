@@ -0,0 +1,49 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+	"time"
+
+	"kb-platform-gateway/internal/models"
+)
+
+var accessReviewHeader = []string{
+	"subject_type", "subject", "role", "resource", "scopes", "granted_by",
+	"granted_at", "expires_at", "last_used_at", "status",
+}
+
+// WriteAccessReviewCSV writes grants as CSV, one row per grant. Scopes are
+// separated by spaces.
+func WriteAccessReviewCSV(w io.Writer, grants []models.AccessGrant) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(accessReviewHeader); err != nil {
+		return err
+	}
+	for _, grant := range grants {
+		if err := cw.Write([]string{
+			grant.SubjectType,
+			grant.Subject,
+			grant.Role,
+			grant.Resource,
+			strings.Join(grant.Scopes, " "),
+			grant.GrantedBy,
+			optionalTime(grant.GrantedAt),
+			optionalTime(grant.ExpiresAt),
+			optionalTime(grant.LastUsedAt),
+			grant.Status,
+		}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func optionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
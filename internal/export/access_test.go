@@ -0,0 +1,38 @@
+package export_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+	"time"
+
+	"kb-platform-gateway/internal/export"
+	"kb-platform-gateway/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteAccessReviewCSV(t *testing.T) {
+	created := time.Date(2024, 1, 10, 12, 0, 0, 0, time.FixedZone("CET", 3600))
+	grants := []models.AccessGrant{
+		{SubjectType: models.AccessSubjectUser, Subject: "alice", Role: "admin", Resource: "*", Status: "active"},
+		{
+			SubjectType: models.AccessSubjectServiceToken, Subject: "ci (kbt_ab12)", Role: "service_token", Resource: "*",
+			Scopes: []string{"documents:read", "query:write"}, GrantedBy: "alice", GrantedAt: &created, Status: "active",
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, export.WriteAccessReviewCSV(&buf, grants))
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, "subject_type", records[0][0])
+	assert.Equal(t, []string{"user", "alice", "admin", "*", "", "", "", "", "", "active"}, records[1])
+	assert.Equal(t, []string{
+		"service_token", "ci (kbt_ab12)", "service_token", "*", "documents:read query:write", "alice",
+		"2024-01-10T11:00:00Z", "", "", "active",
+	}, records[2])
+}
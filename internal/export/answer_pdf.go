@@ -0,0 +1,236 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+
+	"kb-platform-gateway/internal/models"
+)
+
+// PDFContentType is the MIME type of a PDF export.
+const PDFContentType = "application/pdf"
+
+// A4 page layout, in points.
+const (
+	pdfPageWidth  = 595
+	pdfPageHeight = 842
+	pdfMargin     = 56
+)
+
+// helveticaWidths are the advance widths of the printable ASCII characters
+// in Helvetica, in thousandths of the font size, from its AFM metrics.
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278, // space to /
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, // 0 to 9
+	278, 278, 584, 584, 584, 556, 1015, // : to @
+	667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, // A to M
+	722, 778, 667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, // N to Z
+	278, 278, 278, 469, 556, 333, // [ to `
+	556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, // a to m
+	556, 556, 556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, // n to z
+	334, 260, 334, 584, // { to ~
+}
+
+// winAnsi maps the characters outside Latin-1 that WinAnsiEncoding has
+// codes for.
+var winAnsi = map[rune]byte{
+	'€': 0x80, '‚': 0x82, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87,
+	'‰': 0x89, '‹': 0x8b, '‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94,
+	'•': 0x95, '–': 0x96, '—': 0x97, '™': 0x99, '›': 0x9b,
+}
+
+// pdfLine is one line of text laid out on a page.
+type pdfLine struct {
+	bold bool
+	size float64
+	text string
+}
+
+// WriteAnswerPDF renders an assistant message and the passages it cites as
+// a PDF. Text uses the standard Helvetica fonts, so characters outside
+// WinAnsiEncoding are printed as "?".
+func WriteAnswerPDF(w io.Writer, msg *models.Message) error {
+	var lines []pdfLine
+	add := func(bold bool, size float64, text string) {
+		for _, line := range wrapPDFText(text, size, bold) {
+			lines = append(lines, pdfLine{bold: bold, size: size, text: line})
+		}
+	}
+
+	add(true, 16, "Answer")
+	add(false, 9, "Conversation: "+msg.ConversationID)
+	add(false, 9, "Message: "+msg.ID)
+	add(false, 9, "Answered: "+msg.CreatedAt.UTC().Format("2006-01-02 15:04 MST"))
+	add(false, 11, "")
+	for _, paragraph := range strings.Split(msg.Content, "\n") {
+		add(false, 11, paragraph)
+	}
+
+	if len(msg.Sources) > 0 {
+		add(false, 11, "")
+		add(true, 13, "Sources")
+		for i, source := range msg.Sources {
+			add(false, 10, fmt.Sprintf("[%d] %s", i+1, describeSource(source)))
+		}
+	}
+
+	return writePDF(w, "Answer "+msg.ID, paginate(lines))
+}
+
+// describeSource names a cited passage and where it is in its document.
+func describeSource(source models.MessageSource) string {
+	name := source.Filename
+	if name == "" {
+		name = "Deleted document"
+	}
+	parts := []string{name}
+	if source.Page > 0 {
+		parts = append(parts, fmt.Sprintf("page %d", source.Page))
+	}
+	if source.EndChar > 0 {
+		parts = append(parts, fmt.Sprintf("characters %d-%d", source.StartChar, source.EndChar))
+	}
+	ref := "document " + source.DocumentID
+	if source.ChunkID != "" {
+		ref += ", chunk " + source.ChunkID
+	}
+	return fmt.Sprintf("%s (%s, score %.2f)", strings.Join(parts, ", "), ref, source.Score)
+}
+
+// wrapPDFText breaks text into lines that fit between the page margins.
+// An empty text is one blank line.
+func wrapPDFText(text string, size float64, bold bool) []string {
+	maxWidth := float64(pdfPageWidth - 2*pdfMargin)
+	var lines []string
+	var line string
+	for _, word := range strings.Fields(text) {
+		candidate := word
+		if line != "" {
+			candidate = line + " " + word
+		}
+		if line == "" || textWidth(candidate, size, bold) <= maxWidth {
+			line = candidate
+			continue
+		}
+		lines = append(lines, line)
+		line = word
+	}
+	return append(lines, line)
+}
+
+// textWidth estimates the width of text in points. Bold text is about a
+// tenth wider than regular.
+func textWidth(text string, size float64, bold bool) float64 {
+	units := 0
+	for _, r := range text {
+		if r >= ' ' && r <= '~' {
+			units += helveticaWidths[r-' ']
+		} else {
+			units += 556
+		}
+	}
+	width := float64(units) * size / 1000
+	if bold {
+		width *= 1.1
+	}
+	return width
+}
+
+// paginate splits lines into pages, leaving the line spacing at 1.4 times
+// the font size.
+func paginate(lines []pdfLine) [][]pdfLine {
+	var pages [][]pdfLine
+	var page []pdfLine
+	y := float64(pdfPageHeight - pdfMargin)
+	for _, line := range lines {
+		height := line.size * 1.4
+		if y-height < pdfMargin && len(page) > 0 {
+			pages = append(pages, page)
+			page = nil
+			y = pdfPageHeight - pdfMargin
+		}
+		page = append(page, line)
+		y -= height
+	}
+	return append(pages, page)
+}
+
+// writePDF writes a PDF with one page per entry of pages. Objects 1 to 4
+// are the catalog, the page tree and the two fonts; each page then takes
+// a page object and a content stream.
+func writePDF(w io.Writer, title string, pages [][]pdfLine) error {
+	var buf bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	for i, page := range pages {
+		var content bytes.Buffer
+		y := float64(pdfPageHeight - pdfMargin)
+		for _, line := range page {
+			y -= line.size * 1.4
+			if line.text == "" {
+				continue
+			}
+			font := "F1"
+			if line.bold {
+				font = "F2"
+			}
+			fmt.Fprintf(&content, "BT /%s %g Tf %d %g Td (%s) Tj ET\n", font, line.size, pdfMargin, y, pdfString(line.text))
+		}
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, 6+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+	}
+	object(fmt.Sprintf("<< /Title (%s) /Producer (kb-platform-gateway) >>", pdfString(title)))
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, len(offsets), xref)
+
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// pdfString encodes text as the body of a PDF literal string in
+// WinAnsiEncoding.
+func pdfString(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= ' ' && r <= '~':
+			b.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			fmt.Fprintf(&b, "\\%03o", r)
+		case winAnsi[r] != 0:
+			fmt.Fprintf(&b, "\\%03o", winAnsi[r])
+		case unicode.IsSpace(r):
+			b.WriteByte(' ')
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,75 @@
+package export_test
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"kb-platform-gateway/internal/export"
+	"kb-platform-gateway/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteAnswerPDF(t *testing.T) {
+	msg := &models.Message{
+		ID:             "msg-2",
+		ConversationID: "conv-1",
+		Role:           "assistant",
+		Content:        "Refunds (of any kind) take 30 days — see the policy.",
+		CreatedAt:      time.Date(2026, 2, 3, 11, 0, 0, 0, time.UTC),
+		Sources: []models.MessageSource{
+			{
+				Citation: models.Citation{DocumentID: "doc-1", ChunkID: "c-1", Score: 0.87, Page: 4, StartChar: 120, EndChar: 480},
+				Filename: "Policy.pdf",
+			},
+			{Citation: models.Citation{DocumentID: "doc-2", Score: 0.5}},
+		},
+	}
+
+	t.Run("Content", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, export.WriteAnswerPDF(&buf, msg))
+		pdf := buf.String()
+
+		assert.True(t, strings.HasPrefix(pdf, "%PDF-1.4\n"))
+		assert.True(t, strings.HasSuffix(pdf, "%%EOF\n"))
+		assert.Contains(t, pdf, `(Refunds \(of any kind\) take 30 days \227 see the policy.) Tj`)
+		assert.Contains(t, pdf, `([1] Policy.pdf, page 4, characters 120-480 \(document doc-1, chunk c-1, score 0.87\)) Tj`)
+		assert.Contains(t, pdf, `([2] Deleted document \(document doc-2, score 0.50\)) Tj`)
+		assert.Contains(t, pdf, "/Count 1")
+
+		// The cross-reference table must point at the objects.
+		startxref := regexp.MustCompile(`startxref\n(\d+)\n`).FindStringSubmatch(pdf)
+		require.Len(t, startxref, 2)
+		offset, err := strconv.Atoi(startxref[1])
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(pdf[offset:], "xref\n"))
+		entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllStringSubmatch(pdf, -1)
+		require.NotEmpty(t, entries)
+		for i, entry := range entries {
+			offset, err := strconv.Atoi(entry[1])
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(pdf[offset:], strconv.Itoa(i+1)+" 0 obj\n"), "object %d", i+1)
+		}
+	})
+
+	t.Run("LongAnswerSpansPages", func(t *testing.T) {
+		long := *msg
+		long.Content = strings.Repeat("A long paragraph of the answer that wraps across the page.\n", 120)
+
+		var buf bytes.Buffer
+		require.NoError(t, export.WriteAnswerPDF(&buf, &long))
+
+		count := regexp.MustCompile(`/Count (\d+)`).FindStringSubmatch(buf.String())
+		require.Len(t, count, 2)
+		pages, err := strconv.Atoi(count[1])
+		require.NoError(t, err)
+		assert.Greater(t, pages, 1)
+		assert.Equal(t, pages, strings.Count(buf.String(), "/Type /Page "))
+	})
+}
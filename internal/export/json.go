@@ -0,0 +1,43 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// JSONArrayWriter writes values as the elements of a JSON array one at a
+// time, so a long list is never held in memory. Close must be called to end
+// the array; it does not close the underlying writer.
+type JSONArrayWriter struct {
+	w     io.Writer
+	enc   *json.Encoder
+	count int
+}
+
+func NewJSONArrayWriter(w io.Writer) *JSONArrayWriter {
+	return &JSONArrayWriter{w: w, enc: json.NewEncoder(w)}
+}
+
+// Write appends v to the array. Nothing is written before the first value,
+// so a caller that fails before it can still send an error response.
+func (a *JSONArrayWriter) Write(v interface{}) error {
+	separator := ","
+	if a.count == 0 {
+		separator = "["
+	}
+	if _, err := io.WriteString(a.w, separator); err != nil {
+		return err
+	}
+	a.count++
+	return a.enc.Encode(v)
+}
+
+// Close ends the array, writing [] if no value was written.
+func (a *JSONArrayWriter) Close() error {
+	end := "]\n"
+	if a.count == 0 {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(a.w, end)
+	return err
+}
@@ -0,0 +1,39 @@
+package export_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"kb-platform-gateway/internal/export"
+	"kb-platform-gateway/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONArrayWriter(t *testing.T) {
+	t.Run("Values", func(t *testing.T) {
+		var buf bytes.Buffer
+		writer := export.NewJSONArrayWriter(&buf)
+		require.NoError(t, writer.Write(&models.Document{ID: "doc-1", Filename: "a.pdf"}))
+		require.NoError(t, writer.Write(&models.Document{ID: "doc-2", Filename: "b.pdf"}))
+		require.NoError(t, writer.Close())
+
+		var documents []models.Document
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &documents))
+		require.Len(t, documents, 2)
+		assert.Equal(t, "doc-1", documents[0].ID)
+		assert.Equal(t, "b.pdf", documents[1].Filename)
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		var buf bytes.Buffer
+		writer := export.NewJSONArrayWriter(&buf)
+		assert.Zero(t, buf.Len())
+
+		require.NoError(t, writer.Close())
+
+		assert.Equal(t, "[]\n", buf.String())
+	})
+}
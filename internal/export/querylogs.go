@@ -0,0 +1,133 @@
+// Package export serializes gateway data for offline analysis.
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"kb-platform-gateway/internal/models"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// QueryLogWriter serializes query logs to an underlying writer. Close must
+// be called to flush buffered rows; it does not close the underlying
+// writer.
+type QueryLogWriter interface {
+	Write(log *models.QueryLog) error
+	Close() error
+}
+
+// NewQueryLogWriter returns a writer for format (models.ExportFormatCSV or
+// models.ExportFormatParquet).
+func NewQueryLogWriter(format string, w io.Writer) (QueryLogWriter, error) {
+	switch format {
+	case models.ExportFormatCSV:
+		return newCSVQueryLogWriter(w)
+	case models.ExportFormatParquet:
+		return &parquetQueryLogWriter{w: parquet.NewGenericWriter[queryLogRow](w)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported export format %q", format)
+	}
+}
+
+// ContentType returns the MIME type of format.
+func ContentType(format string) string {
+	if format == models.ExportFormatParquet {
+		return "application/vnd.apache.parquet"
+	}
+	return "text/csv"
+}
+
+var csvHeader = []string{
+	"id", "created_at", "username", "conversation_id", "question", "status",
+	"latency_ms", "tokens", "feedback", "feedback_comment",
+}
+
+type csvQueryLogWriter struct {
+	w *csv.Writer
+}
+
+func newCSVQueryLogWriter(w io.Writer) (*csvQueryLogWriter, error) {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return nil, err
+	}
+	return &csvQueryLogWriter{w: cw}, nil
+}
+
+func (c *csvQueryLogWriter) Write(log *models.QueryLog) error {
+	return c.w.Write([]string{
+		log.ID,
+		log.CreatedAt.UTC().Format(time.RFC3339Nano),
+		log.Username,
+		log.ConversationID,
+		log.Question,
+		log.Status,
+		strconv.FormatInt(log.LatencyMS, 10),
+		optionalInt(log.Tokens),
+		optionalInt(log.Feedback),
+		log.FeedbackComment,
+	})
+}
+
+func (c *csvQueryLogWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func optionalInt(n *int) string {
+	if n == nil {
+		return ""
+	}
+	return strconv.Itoa(*n)
+}
+
+// queryLogRow is the Parquet schema of an exported query log.
+type queryLogRow struct {
+	ID              string    `parquet:"id"`
+	CreatedAt       time.Time `parquet:"created_at,timestamp(millisecond)"`
+	Username        string    `parquet:"username"`
+	ConversationID  string    `parquet:"conversation_id,optional"`
+	Question        string    `parquet:"question"`
+	Status          string    `parquet:"status"`
+	LatencyMS       int64     `parquet:"latency_ms"`
+	Tokens          *int64    `parquet:"tokens,optional"`
+	Feedback        *int64    `parquet:"feedback,optional"`
+	FeedbackComment string    `parquet:"feedback_comment,optional"`
+}
+
+type parquetQueryLogWriter struct {
+	w *parquet.GenericWriter[queryLogRow]
+}
+
+func (p *parquetQueryLogWriter) Write(log *models.QueryLog) error {
+	_, err := p.w.Write([]queryLogRow{{
+		ID:              log.ID,
+		CreatedAt:       log.CreatedAt.UTC(),
+		Username:        log.Username,
+		ConversationID:  log.ConversationID,
+		Question:        log.Question,
+		Status:          log.Status,
+		LatencyMS:       log.LatencyMS,
+		Tokens:          optionalInt64(log.Tokens),
+		Feedback:        optionalInt64(log.Feedback),
+		FeedbackComment: log.FeedbackComment,
+	}})
+	return err
+}
+
+func (p *parquetQueryLogWriter) Close() error {
+	return p.w.Close()
+}
+
+func optionalInt64(n *int) *int64 {
+	if n == nil {
+		return nil
+	}
+	v := int64(*n)
+	return &v
+}
@@ -0,0 +1,84 @@
+package export_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+	"time"
+
+	"kb-platform-gateway/internal/export"
+	"kb-platform-gateway/internal/models"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testLogs() []*models.QueryLog {
+	tokens, feedback := 321, -1
+	created := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+	return []*models.QueryLog{
+		{
+			ID: "q-1", Username: "alice", ConversationID: "conv-1", Question: "What is LlamaIndex, exactly?",
+			Status: models.QueryStatusCompleted, LatencyMS: 850, Tokens: &tokens, Feedback: &feedback,
+			FeedbackComment: "missed the point", CreatedAt: created,
+		},
+		{
+			ID: "q-2", Username: "bob", Question: "hello", Status: models.QueryStatusFailed,
+			LatencyMS: 12, CreatedAt: created.Add(time.Minute),
+		},
+	}
+}
+
+func writeAll(t *testing.T, format string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w, err := export.NewQueryLogWriter(format, &buf)
+	require.NoError(t, err)
+	for _, log := range testLogs() {
+		require.NoError(t, w.Write(log))
+	}
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestQueryLogWriter(t *testing.T) {
+	t.Run("CSV", func(t *testing.T) {
+		records, err := csv.NewReader(bytes.NewReader(writeAll(t, models.ExportFormatCSV))).ReadAll()
+		require.NoError(t, err)
+
+		require.Len(t, records, 3)
+		assert.Equal(t, "id", records[0][0])
+		assert.Equal(t, []string{
+			"q-1", "2024-01-10T12:00:00Z", "alice", "conv-1", "What is LlamaIndex, exactly?",
+			"completed", "850", "321", "-1", "missed the point",
+		}, records[1])
+		assert.Equal(t, "", records[2][7], "missing tokens are empty")
+	})
+
+	t.Run("Parquet", func(t *testing.T) {
+		data := writeAll(t, models.ExportFormatParquet)
+
+		type row struct {
+			ID        string    `parquet:"id"`
+			CreatedAt time.Time `parquet:"created_at,timestamp(millisecond)"`
+			Tokens    *int64    `parquet:"tokens,optional"`
+			Feedback  *int64    `parquet:"feedback,optional"`
+		}
+		rows, err := parquet.Read[row](bytes.NewReader(data), int64(len(data)))
+		require.NoError(t, err)
+
+		require.Len(t, rows, 2)
+		assert.Equal(t, "q-1", rows[0].ID)
+		assert.True(t, rows[0].CreatedAt.Equal(time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)))
+		assert.Equal(t, int64(321), *rows[0].Tokens)
+		assert.Equal(t, int64(-1), *rows[0].Feedback)
+		assert.Nil(t, rows[1].Tokens)
+	})
+
+	t.Run("UnknownFormat", func(t *testing.T) {
+		_, err := export.NewQueryLogWriter("xlsx", &bytes.Buffer{})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,244 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"kb-platform-gateway/internal/models"
+)
+
+const (
+	// maxAccessGroups is the most access groups a document may be
+	// restricted to.
+	maxAccessGroups = 20
+	// labelledSnapshotBatch is how many snapshots are loaded at a time
+	// when relabelling their vectors.
+	labelledSnapshotBatch = 100
+)
+
+// DocumentAccess decides whose queries retrieve chunks of documents
+// restricted to access groups: their members and admins. Documents without
+// access groups are retrieved for everyone.
+type DocumentAccess struct {
+	// Groups maps lowercase access group names to their members.
+	Groups map[string][]string
+	// Admins' queries retrieve every document.
+	Admins []string
+}
+
+// groupsOf returns the access groups username is in, sorted, and whether
+// the user is an admin.
+func (a *DocumentAccess) groupsOf(username string) ([]string, bool) {
+	if slices.Contains(a.Admins, username) {
+		return nil, true
+	}
+	groups := []string{}
+	for name, members := range a.Groups {
+		if slices.Contains(members, username) {
+			groups = append(groups, name)
+		}
+	}
+	sort.Strings(groups)
+	return groups, false
+}
+
+// canRead reports whether username's queries retrieve doc. A nil
+// DocumentAccess retrieves every document.
+func (a *DocumentAccess) canRead(username string, doc *models.Document) bool {
+	if a == nil || len(doc.AccessGroups) == 0 {
+		return true
+	}
+	groups, admin := a.groupsOf(username)
+	return admin || slices.ContainsFunc(groups, func(group string) bool {
+		return slices.Contains(doc.AccessGroups, group)
+	})
+}
+
+// covers reports whether username may read every document other may.
+func (a *DocumentAccess) covers(username, other string) bool {
+	groups, admin := a.groupsOf(username)
+	if admin {
+		return true
+	}
+	otherGroups, otherAdmin := a.groupsOf(other)
+	if otherAdmin {
+		return false
+	}
+	for _, group := range otherGroups {
+		if !slices.Contains(groups, group) {
+			return false
+		}
+	}
+	return true
+}
+
+// accessGroups returns the access groups whose chunks username's queries
+// may retrieve, besides chunks without any, or nil when every chunk may be
+// retrieved, with the key of what the user may read: users with the same
+// key are retrieved the same chunks.
+func (s *Service) accessGroups(username string) ([]string, string) {
+	if s.Access == nil {
+		return nil, ""
+	}
+	groups, admin := s.Access.groupsOf(username)
+	if admin {
+		return nil, "*"
+	}
+	return groups, strings.Join(groups, ",")
+}
+
+// SetDocumentAccessGroups restricts a document to the given access groups,
+// or lifts its restriction when groups is empty. The groups are copied to
+// the payload of the document's vectors, which the core filters on, in
+// every collection queries retrieve from. The vectors are relabelled
+// before the change is stored and restored if storing it fails, so a
+// failure never leaves a restriction recorded but not enforced. Changes
+// are refused while a snapshot is being taken, as its copy of the vectors
+// could miss them.
+func (s *Service) SetDocumentAccessGroups(ctx context.Context, documentID string, groups []string, username string) (*models.Document, error) {
+	if len(groups) > maxAccessGroups {
+		return nil, &Error{Kind: KindInvalid, Message: fmt.Sprintf("access_groups must list at most %d groups", maxAccessGroups)}
+	}
+	normalized := make([]string, 0, len(groups))
+	for _, group := range groups {
+		group = strings.ToLower(strings.TrimSpace(group))
+		if group == "" || len([]rune(group)) > maxTagLength {
+			return nil, &Error{Kind: KindInvalid, Message: fmt.Sprintf("Access groups must be between 1 and %d characters", maxTagLength)}
+		}
+		normalized = append(normalized, group)
+	}
+	sort.Strings(normalized)
+	normalized = slices.Compact(normalized)
+
+	doc, err := s.document(ctx, documentID)
+	if err != nil {
+		return nil, err
+	}
+
+	var collections []string
+	if s.QdrantClient != nil {
+		collections, err = s.labelledCollections(ctx, true)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.labelDocumentVectors(ctx, collections, documentID, normalized); err != nil {
+			s.Logger.Error().Err(err).Str("document_id", documentID).Msg("Failed to set access groups of document vectors")
+			s.restoreDocumentLabels(ctx, collections, doc)
+			return nil, internal("Failed to set access groups", err)
+		}
+	}
+
+	found, err := s.Repository.SetDocumentAccessGroups(ctx, documentID, normalized)
+	if err != nil || !found {
+		s.restoreDocumentLabels(ctx, collections, doc)
+	}
+	if err != nil {
+		s.Logger.Error().Err(err).Str("document_id", documentID).Msg("Failed to set document access groups")
+		return nil, internal("Failed to set access groups", err)
+	}
+	if !found {
+		return nil, &Error{Kind: KindNotFound, Message: "Document not found"}
+	}
+
+	doc, err = s.document(ctx, documentID)
+	if err != nil {
+		return nil, err
+	}
+	s.recordDocumentEvent(ctx, documentID, models.DocumentEventAccessChanged, map[string]interface{}{
+		"access_groups": doc.AccessGroups,
+		"changed_by":    username,
+	})
+	return doc, nil
+}
+
+// LabelIndexedDocument copies a document's access groups and collections
+// to the payload of the vectors an indexer has just written. Indexers label
+// vectors with the groups they were started with, so this catches changes
+// made while they ran, but not with collections. Unknown documents are
+// ignored.
+func (s *Service) LabelIndexedDocument(ctx context.Context, documentID string) error {
+	if s.QdrantClient == nil {
+		return nil
+	}
+	doc, err := s.Repository.GetDocument(ctx, documentID)
+	if err != nil {
+		s.Logger.Error().Err(err).Str("document_id", documentID).Msg("Failed to get document")
+		return internal("Failed to get document", err)
+	}
+	if doc == nil {
+		return nil
+	}
+	collections, err := s.labelledCollections(ctx, false)
+	if err != nil {
+		return err
+	}
+	if err := s.labelDocumentVectors(ctx, collections, documentID, doc.AccessGroups); err != nil {
+		s.Logger.Error().Err(err).Str("document_id", documentID).Msg("Failed to set access groups of document vectors")
+		return internal("Failed to set access groups", err)
+	}
+	return s.labelDocumentCollections(ctx, collections, documentID)
+}
+
+// labelledCollections returns the collections holding vectors queries may
+// retrieve: the active one, the target of a running migration and, with
+// snapshots, those of ready snapshots. It fails with a conflict if
+// snapshots are requested while one is being taken.
+func (s *Service) labelledCollections(ctx context.Context, snapshots bool) ([]string, error) {
+	collections := []string{s.activeCollection()}
+	if s.Migrations != nil {
+		migration, err := s.Repository.GetRunningEmbeddingMigration(ctx)
+		if err != nil {
+			s.Logger.Error().Err(err).Msg("Failed to get running embedding migration")
+			return nil, internal("Failed to get running embedding migration", err)
+		}
+		if migration != nil {
+			collections = append(collections, migration.TargetCollection)
+		}
+	}
+	if !snapshots {
+		return collections, nil
+	}
+	for offset := 0; ; offset += labelledSnapshotBatch {
+		batch, _, err := s.Repository.ListSnapshots(ctx, labelledSnapshotBatch, offset)
+		if err != nil {
+			s.Logger.Error().Err(err).Msg("Failed to list snapshots")
+			return nil, internal("Failed to list snapshots", err)
+		}
+		for _, snapshot := range batch {
+			switch snapshot.Status {
+			case models.SnapshotStatusCreating:
+				return nil, &Error{Kind: KindConflict, Message: fmt.Sprintf("Snapshot %q is being taken; try again once it is ready", snapshot.Name)}
+			case models.SnapshotStatusReady:
+				collections = append(collections, snapshot.Collection)
+			}
+		}
+		if len(batch) < labelledSnapshotBatch {
+			return collections, nil
+		}
+	}
+}
+
+// labelDocumentVectors sets the access groups of a document's vectors in
+// each of collections.
+func (s *Service) labelDocumentVectors(ctx context.Context, collections []string, documentID string, groups []string) error {
+	for _, collection := range collections {
+		if err := s.QdrantClient.SetDocumentAccessGroups(ctx, collection, documentID, groups); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restoreDocumentLabels puts back the access groups doc's vectors had
+// before a failed change. Failures are logged.
+func (s *Service) restoreDocumentLabels(ctx context.Context, collections []string, doc *models.Document) {
+	if len(collections) == 0 {
+		return
+	}
+	if err := s.labelDocumentVectors(ctx, collections, doc.ID, doc.AccessGroups); err != nil {
+		s.Logger.Error().Err(err).Str("document_id", doc.ID).Msg("Failed to restore access groups of document vectors")
+	}
+}
@@ -0,0 +1,24 @@
+package gateway
+
+import (
+	"context"
+
+	"kb-platform-gateway/internal/models"
+)
+
+// DocumentAnalytics returns how often the document was cited in answers.
+func (s *Service) DocumentAnalytics(ctx context.Context, documentID string) (*models.DocumentAnalytics, error) {
+	doc, err := s.document(ctx, documentID)
+	if err != nil {
+		return nil, err
+	}
+
+	analytics, err := s.Repository.GetDocumentAnalytics(ctx, documentID)
+	if err != nil {
+		s.Logger.Error().Err(err).Str("document_id", documentID).Msg("Failed to get document analytics")
+		return nil, internal("Failed to get document analytics", err)
+	}
+	analytics.Filename = doc.Filename
+
+	return analytics, nil
+}
@@ -0,0 +1,77 @@
+package gateway
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"kb-platform-gateway/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// answerScope identifies what an answer was retrieved with, so answers are
+// only reused for questions asked against the same collection, language,
+// prompt template version and per-document chunk cap, by users who may
+// read the same documents.
+func answerScope(collection, language, promptVersion string, maxChunksPerDocument int, access string) string {
+	scope := strings.Join([]string{collection, language, promptVersion}, "|")
+	if maxChunksPerDocument > 0 {
+		scope += "|" + strconv.Itoa(maxChunksPerDocument)
+	}
+	if access != "" {
+		scope += "|access=" + access
+	}
+	return scope
+}
+
+// previousAnswer replays an earlier answer as the event stream of a new
+// query. The end event is flagged as previously answered and names the
+// earlier query. The answer is redacted again, as rules may have changed
+// since it was given. The new query is logged like one the core answered.
+func (s *Service) previousAnswer(ctx context.Context, question, username string, previous *models.AnsweredQuestion) <-chan models.SSEEvent {
+	started := time.Now()
+	id := uuid.New().String()
+
+	answer, redacted := previous.Answer, false
+	if rules := s.redactor(ctx); rules != nil {
+		answer, redacted = rules.Redact(answer)
+	}
+
+	events := make(chan models.SSEEvent, 3)
+	events <- models.SSEEvent{Type: "start", ID: id}
+	events <- models.SSEEvent{Type: "chunk", Content: answer, Highlights: s.highlightAnswer(ctx, answer)}
+	events <- models.SSEEvent{
+		Type:               "end",
+		ID:                 id,
+		DocumentIDs:        previous.DocumentIDs,
+		PreviouslyAnswered: true,
+		AnsweredQueryID:    previous.QueryID,
+		Redacted:           redacted,
+	}
+	close(events)
+
+	s.logQuery(ctx, &models.QueryLog{
+		ID:          id,
+		Username:    username,
+		Question:    question,
+		Status:      models.QueryStatusCompleted,
+		CreatedAt:   started,
+		DocumentIDs: previous.DocumentIDs,
+	})
+	s.publish(ctx, models.EventQueryCompleted, map[string]string{
+		"id":       id,
+		"username": username,
+	})
+
+	return events
+}
+
+// rememberAnswer keeps a completed answer for later questions. Like the
+// query log, it is written even if ctx was cancelled.
+func (s *Service) rememberAnswer(ctx context.Context, answered *models.AnsweredQuestion) {
+	if err := s.Answers.Remember(context.WithoutCancel(ctx), answered); err != nil {
+		s.Logger.Error().Err(err).Str("query_id", answered.QueryID).Msg("Failed to remember answer")
+	}
+}
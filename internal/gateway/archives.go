@@ -0,0 +1,59 @@
+package gateway
+
+import (
+	"context"
+	"path"
+	"strings"
+
+	"kb-platform-gateway/internal/models"
+)
+
+// isArchive reports whether filename names a ZIP archive, which is expanded
+// into child documents rather than indexed itself.
+func isArchive(filename string) bool {
+	return strings.EqualFold(path.Ext(filename), ".zip")
+}
+
+// RegisterArchiveEntry registers a file expanded from an uploaded archive
+// as a pending child document owned by the archive's uploader and indexed
+// with the archive's options, returned with a presigned upload URL.
+// Archives nested in an archive are not expanded.
+func (s *Service) RegisterArchiveEntry(ctx context.Context, archiveID string, req models.ArchiveEntryRequest) (*models.Document, error) {
+	archive, err := s.Repository.GetDocument(ctx, archiveID)
+	if err != nil {
+		s.Logger.Error().Err(err).Str("document_id", archiveID).Msg("Failed to get document")
+		return nil, internal("Failed to get document", err)
+	}
+	if archive == nil {
+		return nil, &Error{Kind: KindNotFound, Message: "Document not found"}
+	}
+	if !isArchive(archive.Filename) || archive.ParentID != "" {
+		return nil, &Error{Kind: KindInvalid, Message: "Document is not an archive"}
+	}
+
+	filename := path.Base(req.Filename)
+	if isArchive(filename) {
+		return nil, &Error{Kind: KindInvalid, Message: "Nested archives are not expanded"}
+	}
+
+	return s.upload(ctx, filename, req.FileSize, archive.UploadedBy, archiveID, models.UploadOptions{
+		Metadata:   archive.Metadata,
+		Chunking:   archive.Chunking,
+		Processing: archive.Processing,
+	})
+}
+
+// ListChildDocuments returns the documents expanded from an archive,
+// newest first.
+func (s *Service) ListChildDocuments(ctx context.Context, archiveID string, limit, offset int) ([]*models.Document, int, error) {
+	if _, err := s.document(ctx, archiveID); err != nil {
+		return nil, 0, err
+	}
+
+	documents, total, err := s.Repository.ListChildDocuments(ctx, archiveID, limit, offset)
+	if err != nil {
+		s.Logger.Error().Err(err).Str("document_id", archiveID).Msg("Failed to list archive files")
+		return nil, 0, internal("Failed to list archive files", err)
+	}
+	return documents, total, nil
+}
@@ -0,0 +1,249 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"kb-platform-gateway/internal/models"
+	"kb-platform-gateway/internal/services"
+)
+
+// MaxBatchSize is the most files a batch upload, or documents a batch
+// complete or delete, may name.
+const MaxBatchSize = 100
+
+// BatchResult is the outcome of one item of a batch: its document, or the
+// error that refused it.
+type BatchResult struct {
+	Document *models.Document
+	Err      error
+}
+
+// BatchUpload registers a pending document with an upload URL for each
+// file, as UploadDocument does, so a client uploading many files needs one
+// round-trip. Files are registered independently and concurrently: one
+// that is refused, say for its type or metadata or as a duplicate, fails
+// only its own result. A file with the same content hash as an earlier
+// one is registered after it, so it is refused as its duplicate.
+func (s *Service) BatchUpload(ctx context.Context, req models.BatchUploadRequest, username string) ([]BatchResult, error) {
+	if err := checkBatchSize(len(req.Files), "files"); err != nil {
+		return nil, err
+	}
+	opts, err := normalizeUploadOptions(models.UploadOptions{Chunking: req.Chunking, Processing: req.Processing})
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := s.WorkspaceSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]BatchResult, len(req.Files))
+	var first, repeated []int
+	hashes := make(map[string]bool, len(req.Files))
+	fileOpts := make([]models.UploadOptions, len(req.Files))
+	for i, file := range req.Files {
+		if err := checkMetadata(settings.MetadataSchema, file.Metadata); err != nil {
+			results[i].Err = err
+			continue
+		}
+		contentHash, err := normalizeContentHash(file.ContentHash)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		fileOpts[i] = opts
+		fileOpts[i].ContentType = file.ContentType
+		fileOpts[i].ContentHash = contentHash
+		if len(file.Metadata) > 0 {
+			fileOpts[i].Metadata = file.Metadata
+		}
+		if contentHash != "" && hashes[contentHash] {
+			repeated = append(repeated, i)
+			continue
+		}
+		hashes[contentHash] = true
+		first = append(first, i)
+	}
+
+	for _, items := range [][]int{first, repeated} {
+		s.runBatch(ctx, results, items, func(ctx context.Context, i int) BatchResult {
+			file := req.Files[i]
+			doc, err := s.upload(ctx, file.Filename, file.Size, username, "", fileOpts[i])
+			return BatchResult{Document: doc, Err: err}
+		})
+	}
+	return results, nil
+}
+
+// BatchCompleteUpload completes each upload as CompleteUpload does. Each
+// is completed independently and concurrently: one whose file is missing
+// fails only its own result.
+func (s *Service) BatchCompleteUpload(ctx context.Context, documentIDs []string) ([]BatchResult, error) {
+	if err := checkBatchSize(len(documentIDs), "document_ids"); err != nil {
+		return nil, err
+	}
+
+	results := make([]BatchResult, len(documentIDs))
+	s.runBatch(ctx, results, batchItems(len(documentIDs)), func(ctx context.Context, i int) BatchResult {
+		doc, err := s.CompleteUpload(ctx, documentIDs[i], nil)
+		return BatchResult{Document: doc, Err: err}
+	})
+	return results, nil
+}
+
+// BatchDelete deletes each document as DeleteDocument does, named either
+// by ID or, up to MaxBatchSize at a time, by status. The documents are
+// tombstoned together, in a single statement, once any indexing them has
+// been stopped, so a batch is never left partly deleted: if the tombstones
+// cannot be written, none is and each result fails. Their purge, or their
+// vectors' deletion when they go to the trash, then runs concurrently and,
+// as for DeleteDocument, is left to the trash purge if it fails. A
+// document that is missing, or whose indexing cannot be stopped, fails
+// only its own result, which carries the document as it was before. It
+// also returns how many documents with the status are left to delete.
+func (s *Service) BatchDelete(ctx context.Context, req models.BatchDeleteRequest) ([]BatchResult, int, error) {
+	status := strings.TrimSpace(req.Status)
+	switch {
+	case len(req.DocumentIDs) > 0 && status != "":
+		return nil, 0, &Error{Kind: KindInvalid, Message: "Set either document_ids or status, not both"}
+	case status != "":
+		docs, total, err := s.ListDocuments(ctx, MaxBatchSize, 0, models.DocumentFilter{Status: status})
+		if err != nil {
+			return nil, 0, err
+		}
+		results := make([]BatchResult, len(docs))
+		for i, doc := range docs {
+			results[i].Document = doc
+		}
+		s.deleteDocuments(ctx, results)
+		return results, total - len(docs), nil
+	}
+
+	if err := checkBatchSize(len(req.DocumentIDs), "document_ids"); err != nil {
+		return nil, 0, err
+	}
+	seen := make(map[string]bool, len(req.DocumentIDs))
+	for _, documentID := range req.DocumentIDs {
+		if seen[documentID] {
+			return nil, 0, &Error{Kind: KindInvalid, Message: fmt.Sprintf("document_ids lists %s more than once", documentID)}
+		}
+		seen[documentID] = true
+	}
+
+	docs, err := s.Repository.GetDocumentsByIDs(ctx, req.DocumentIDs)
+	if err != nil {
+		s.Logger.Error().Err(err).Int("documents", len(req.DocumentIDs)).Msg("Failed to get documents")
+		return nil, 0, internal("Failed to get documents", err)
+	}
+	byID := make(map[string]*models.Document, len(docs))
+	for _, doc := range docs {
+		byID[doc.ID] = doc
+	}
+	results := make([]BatchResult, len(req.DocumentIDs))
+	for i, documentID := range req.DocumentIDs {
+		if results[i].Document = byID[documentID]; results[i].Document == nil {
+			results[i].Err = &Error{Kind: KindNotFound, Message: "Document not found"}
+		}
+	}
+	s.deleteDocuments(ctx, results)
+	return results, 0, nil
+}
+
+// deleteDocuments deletes the document of each result not already failed,
+// setting its error if it cannot be, as BatchDelete describes. Once the
+// tombstones are written the deletions are finished even if ctx is done.
+func (s *Service) deleteDocuments(ctx context.Context, results []BatchResult) {
+	var live []int
+	for i, result := range results {
+		if result.Err == nil && result.Document.DeletedAt == nil {
+			live = append(live, i)
+		}
+	}
+	s.runBatch(ctx, results, live, func(ctx context.Context, i int) BatchResult {
+		return BatchResult{Document: results[i].Document, Err: s.stopIndexing(ctx, results[i].Document)}
+	})
+
+	var ids []string
+	for _, i := range live {
+		if results[i].Err == nil {
+			ids = append(ids, results[i].Document.ID)
+		}
+	}
+	tombstoned := make(map[string]bool, len(ids))
+	if len(ids) > 0 {
+		trashed, err := s.Repository.TrashDocuments(ctx, ids, time.Now())
+		if err != nil {
+			s.Logger.Error().Err(err).Int("documents", len(ids)).Msg("Failed to tombstone documents")
+			for _, i := range live {
+				if results[i].Err == nil {
+					results[i].Err = internal("Failed to delete document", err)
+				}
+			}
+			return
+		}
+		for _, id := range trashed {
+			tombstoned[id] = true
+		}
+	}
+
+	// With the trash, documents trashed concurrently are left there.
+	var finish []int
+	for i, result := range results {
+		if result.Err == nil && (result.Document.DeletedAt != nil || tombstoned[result.Document.ID] || s.TrashRetention <= 0) {
+			finish = append(finish, i)
+		}
+	}
+	s.runBatch(context.WithoutCancel(ctx), results, finish, func(ctx context.Context, i int) BatchResult {
+		doc := results[i].Document
+		if doc.DeletedAt == nil && s.TrashRetention > 0 {
+			s.finishTrashing(ctx, doc.ID)
+		} else {
+			s.finishDeleting(ctx, doc)
+		}
+		return results[i]
+	})
+}
+
+// runBatch sets the result of each of items, indexes into results, to
+// what fn returns for it. Items run concurrently, as many at a time as
+// s.Batches allows, or one at a time without it. Items not started before
+// ctx is done fail with its error.
+func (s *Service) runBatch(ctx context.Context, results []BatchResult, items []int, fn func(ctx context.Context, i int) BatchResult) {
+	pool := s.Batches
+	if pool == nil {
+		pool = services.NewWorkerPool(1)
+	}
+	started := make([]bool, len(items))
+	// Failures are per item, so Run only fails when interrupted, which
+	// the items not started show.
+	_ = pool.Run(ctx, len(items), func(ctx context.Context, j int) error {
+		started[j] = true
+		results[items[j]] = fn(ctx, items[j])
+		return nil
+	})
+	for j, i := range items {
+		if !started[j] {
+			results[i].Err = internal("Batch was interrupted", ctx.Err())
+		}
+	}
+}
+
+// batchItems returns the indexes of a batch of n items.
+func batchItems(n int) []int {
+	items := make([]int, n)
+	for i := range items {
+		items[i] = i
+	}
+	return items
+}
+
+func checkBatchSize(n int, field string) error {
+	if n == 0 || n > MaxBatchSize {
+		return &Error{Kind: KindInvalid, Message: fmt.Sprintf("%s must list between 1 and %d items", field, MaxBatchSize)}
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"kb-platform-gateway/internal/models"
+	"kb-platform-gateway/internal/services"
+)
+
+// Bounds of a document's chunk size, in tokens.
+const (
+	MinChunkSize = 64
+	MaxChunkSize = 4096
+)
+
+// normalizeChunking validates chunking options and returns nil if they
+// leave every option to the indexer's defaults.
+func normalizeChunking(chunking *models.ChunkingOptions) (*models.ChunkingOptions, error) {
+	if chunking == nil {
+		return nil, nil
+	}
+
+	c := *chunking
+	c.Strategy = strings.ToLower(strings.TrimSpace(c.Strategy))
+	switch c.Strategy {
+	case "", models.ChunkingFixed, models.ChunkingSentence, models.ChunkingTable:
+	default:
+		return nil, &Error{Kind: KindInvalid, Message: "chunking.strategy must be fixed, sentence or table"}
+	}
+	if c.Size != 0 && (c.Size < MinChunkSize || c.Size > MaxChunkSize) {
+		return nil, &Error{Kind: KindInvalid, Message: fmt.Sprintf("chunking.size must be between %d and %d", MinChunkSize, MaxChunkSize)}
+	}
+	if c.Overlap < 0 {
+		return nil, &Error{Kind: KindInvalid, Message: "chunking.overlap must not be negative"}
+	}
+	if c.Overlap > 0 && c.Size == 0 {
+		return nil, &Error{Kind: KindInvalid, Message: "chunking.overlap requires chunking.size"}
+	}
+	if c.Overlap > c.Size/2 {
+		return nil, &Error{Kind: KindInvalid, Message: "chunking.overlap must be at most half of chunking.size"}
+	}
+
+	if c == (models.ChunkingOptions{}) {
+		return nil, nil
+	}
+	return &c, nil
+}
+
+// ReindexDocument re-indexes an uploaded document from scratch, e.g. after
+// the embedding model changed or indexing failed. Its vectors are deleted
+// first, so none embedded by the previous model outlive the new ones. If
+// chunking is set it replaces the document's chunking options; empty
+// options restore the indexer's defaults.
+func (s *Service) ReindexDocument(ctx context.Context, documentID string, chunking *models.ChunkingOptions) (*models.Document, error) {
+	doc, err := s.document(ctx, documentID)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case doc.DeletedAt != nil:
+		return nil, &Error{Kind: KindConflict, Message: "Document is in the trash"}
+	case isArchive(doc.Filename):
+		return nil, &Error{Kind: KindInvalid, Message: "Archives are not indexed"}
+	case doc.Status == "pending":
+		return nil, &Error{Kind: KindInvalid, Message: "Document has not been uploaded"}
+	case doc.Status == "indexing":
+		return nil, &Error{Kind: KindConflict, Message: "Document is already being indexed"}
+	case doc.Status == "pending_review":
+		return nil, &Error{Kind: KindConflict, Message: "Document is awaiting review"}
+	case doc.Status == "rejected":
+		return nil, &Error{Kind: KindInvalid, Message: "Document was rejected"}
+	}
+
+	if chunking != nil {
+		normalized, err := normalizeChunking(chunking)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.Repository.SetDocumentChunking(ctx, documentID, normalized); err != nil {
+			s.Logger.Error().Err(err).Str("document_id", documentID).Msg("Failed to set document chunking")
+			return nil, internal("Failed to update document", err)
+		}
+		doc.Chunking = normalized
+	}
+
+	if s.QdrantClient != nil {
+		if err := s.QdrantClient.DeleteDocumentVectors(ctx, s.activeCollection(), documentID); err != nil {
+			s.Logger.Error().Err(err).Str("document_id", documentID).Msg("Failed to delete vectors")
+			return nil, internal("Failed to delete document vectors", err)
+		}
+	}
+
+	workflowID, err := s.Temporal.StartIndexWorkflow(ctx, services.IndexWorkflowInput{
+		DocumentID:   documentID,
+		Chunking:     doc.Chunking,
+		Processing:   doc.Processing,
+		AccessGroups: doc.AccessGroups,
+	})
+	if err != nil {
+		s.Logger.Error().Err(err).Str("document_id", documentID).Msg("Failed to start index workflow")
+		return nil, internal("Failed to start index workflow", err)
+	}
+
+	if err := s.Repository.SetDocumentIndexing(ctx, documentID, workflowID); err != nil {
+		s.Logger.Error().Err(err).Str("document_id", documentID).Msg("Failed to update document status")
+		return nil, internal("Failed to update document status", err)
+	}
+	doc.Status, doc.ErrorMessage, doc.WorkflowID = "indexing", "", workflowID
+
+	return doc, nil
+}
@@ -0,0 +1,217 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"kb-platform-gateway/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// CreateCollection creates an empty, named collection that every user can
+// list and query.
+func (s *Service) CreateCollection(ctx context.Context, req models.CreateCollectionRequest, username string) (*models.Collection, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, &Error{Kind: KindInvalid, Message: "name must not be blank"}
+	}
+
+	collection := &models.Collection{
+		ID:          uuid.New().String(),
+		Name:        name,
+		Description: strings.TrimSpace(req.Description),
+		CreatedBy:   username,
+		CreatedAt:   time.Now(),
+	}
+	created, err := s.Repository.CreateCollection(ctx, collection)
+	if err != nil {
+		s.Logger.Error().Err(err).Msg("Failed to create collection")
+		return nil, internal("Failed to create collection", err)
+	}
+	if !created {
+		return nil, &Error{Kind: KindConflict, Message: fmt.Sprintf("A collection named %q already exists", name)}
+	}
+	return collection, nil
+}
+
+func (s *Service) GetCollection(ctx context.Context, id string) (*models.Collection, error) {
+	collection, err := s.Repository.GetCollection(ctx, id)
+	if err != nil {
+		s.Logger.Error().Err(err).Str("collection_id", id).Msg("Failed to get collection")
+		return nil, internal("Failed to get collection", err)
+	}
+	if collection == nil {
+		return nil, &Error{Kind: KindNotFound, Message: "Collection not found"}
+	}
+	return collection, nil
+}
+
+// ListCollections lists every user's collections, by name.
+func (s *Service) ListCollections(ctx context.Context, limit, offset int) ([]*models.Collection, int, error) {
+	collections, total, err := s.Repository.ListCollections(ctx, limit, offset)
+	if err != nil {
+		s.Logger.Error().Err(err).Msg("Failed to list collections")
+		return nil, 0, internal("Failed to list collections", err)
+	}
+	return collections, total, nil
+}
+
+// DeleteCollection deletes a collection, keeping its documents. Only its
+// creator may delete it.
+func (s *Service) DeleteCollection(ctx context.Context, id, username string) error {
+	if _, err := s.ownCollection(ctx, id, username); err != nil {
+		return err
+	}
+
+	if err := s.Repository.DeleteCollection(ctx, id); err != nil {
+		s.Logger.Error().Err(err).Str("collection_id", id).Msg("Failed to delete collection")
+		return internal("Failed to delete collection", err)
+	}
+	return nil
+}
+
+// AddCollectionDocuments adds documents to a collection and returns how
+// many were not already in it. Documents that do not exist or are in the
+// trash are skipped. Only the collection's creator may change it.
+func (s *Service) AddCollectionDocuments(ctx context.Context, id string, req models.CollectionDocumentsRequest, username string) (int, error) {
+	if err := checkCollectionDocuments(req); err != nil {
+		return 0, err
+	}
+	if _, err := s.ownCollection(ctx, id, username); err != nil {
+		return 0, err
+	}
+	collections, err := s.collectionLabelledCollections(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	added, err := s.Repository.AddCollectionDocuments(ctx, id, req.DocumentIDs)
+	if err != nil {
+		s.Logger.Error().Err(err).Str("collection_id", id).Msg("Failed to add documents to collection")
+		return 0, internal("Failed to add documents to collection", err)
+	}
+	if err := s.labelCollectionDocuments(ctx, collections, req.DocumentIDs); err != nil {
+		return 0, err
+	}
+	return added, nil
+}
+
+// RemoveCollectionDocuments removes documents from a collection and
+// returns how many were in it. Only the collection's creator may change
+// it.
+func (s *Service) RemoveCollectionDocuments(ctx context.Context, id string, req models.CollectionDocumentsRequest, username string) (int, error) {
+	if err := checkCollectionDocuments(req); err != nil {
+		return 0, err
+	}
+	if _, err := s.ownCollection(ctx, id, username); err != nil {
+		return 0, err
+	}
+
+	collections, err := s.collectionLabelledCollections(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	removed, err := s.Repository.RemoveCollectionDocuments(ctx, id, req.DocumentIDs)
+	if err != nil {
+		s.Logger.Error().Err(err).Str("collection_id", id).Msg("Failed to remove documents from collection")
+		return 0, internal("Failed to remove documents from collection", err)
+	}
+	if err := s.labelCollectionDocuments(ctx, collections, req.DocumentIDs); err != nil {
+		return 0, err
+	}
+	return removed, nil
+}
+
+// ListCollectionDocuments lists the documents in a collection, newest
+// first.
+func (s *Service) ListCollectionDocuments(ctx context.Context, id string, limit, offset int) ([]*models.Document, int, error) {
+	if _, err := s.GetCollection(ctx, id); err != nil {
+		return nil, 0, err
+	}
+	return s.ListDocuments(ctx, limit, offset, models.DocumentFilter{CollectionID: id})
+}
+
+// checkQueryCollection checks that a query may be restricted to a
+// collection: it must exist and hold documents.
+func (s *Service) checkQueryCollection(ctx context.Context, id string) error {
+	collection, err := s.Repository.GetCollection(ctx, id)
+	if err != nil {
+		s.Logger.Error().Err(err).Str("collection_id", id).Msg("Failed to get collection")
+		return internal("Failed to get collection", err)
+	}
+	if collection == nil {
+		return &Error{Kind: KindInvalid, Message: "Collection not found"}
+	}
+	if collection.DocumentCount == 0 {
+		return &Error{Kind: KindInvalid, Message: "Collection has no documents"}
+	}
+	return nil
+}
+
+// collectionLabelledCollections returns the Qdrant collections whose
+// vectors are labelled with the collections their document is in, or none
+// without Qdrant. Like access groups, the labels are kept in every
+// collection queries retrieve from.
+func (s *Service) collectionLabelledCollections(ctx context.Context) ([]string, error) {
+	if s.QdrantClient == nil {
+		return nil, nil
+	}
+	return s.labelledCollections(ctx, true)
+}
+
+// labelCollectionDocuments copies the collections each of documentIDs is
+// in to the payload of its vectors in each of collections, which the core
+// filters queries restricted to a collection on. Each document is labelled
+// with all of its collections, so a failed change is repaired by retrying
+// it.
+func (s *Service) labelCollectionDocuments(ctx context.Context, collections []string, documentIDs []string) error {
+	for _, documentID := range documentIDs {
+		if err := s.labelDocumentCollections(ctx, collections, documentID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// labelDocumentCollections copies the collections a document is in to the
+// payload of its vectors in each of collections.
+func (s *Service) labelDocumentCollections(ctx context.Context, collections []string, documentID string) error {
+	if len(collections) == 0 {
+		return nil
+	}
+	ids, err := s.Repository.ListDocumentCollectionIDs(ctx, documentID)
+	if err != nil {
+		s.Logger.Error().Err(err).Str("document_id", documentID).Msg("Failed to list document collections")
+		return internal("Failed to list document collections", err)
+	}
+	for _, collection := range collections {
+		if err := s.QdrantClient.SetDocumentCollections(ctx, collection, documentID, ids); err != nil {
+			s.Logger.Error().Err(err).Str("document_id", documentID).Msg("Failed to set collections of document vectors")
+			return internal("Failed to set collections of document vectors", err)
+		}
+	}
+	return nil
+}
+
+// ownCollection loads a collection that username may change.
+func (s *Service) ownCollection(ctx context.Context, id, username string) (*models.Collection, error) {
+	collection, err := s.GetCollection(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if collection.CreatedBy != username {
+		return nil, &Error{Kind: KindForbidden, Message: "Only the creator of a collection can change it"}
+	}
+	return collection, nil
+}
+
+func checkCollectionDocuments(req models.CollectionDocumentsRequest) error {
+	if len(req.DocumentIDs) == 0 || len(req.DocumentIDs) > MaxTagDocuments {
+		return &Error{Kind: KindInvalid, Message: fmt.Sprintf("document_ids must list between 1 and %d documents", MaxTagDocuments)}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package gateway
+
+import (
+	"context"
+
+	"kb-platform-gateway/internal/models"
+)
+
+// SyncConnectorFile registers a file found by a connector sync. A new or
+// changed file is uploaded through the normal two-phase pipeline as a new
+// document owned by the connector's user; the document of the previous
+// version of a changed file is deleted. A file already ingested at this
+// version, or with the same content hash, is reported unchanged.
+func (s *Service) SyncConnectorFile(ctx context.Context, connectorID string, req models.ConnectorFileRequest) (*models.ConnectorFileResponse, error) {
+	connector, err := s.Repository.GetConnector(ctx, connectorID)
+	if err != nil {
+		s.Logger.Error().Err(err).Str("connector_id", connectorID).Msg("Failed to get connector")
+		return nil, internal("Failed to get connector", err)
+	}
+	if connector == nil {
+		return nil, &Error{Kind: KindNotFound, Message: "Connector not found"}
+	}
+
+	previous, err := s.Repository.GetConnectorFile(ctx, connectorID, req.ExternalID)
+	if err != nil {
+		s.Logger.Error().Err(err).Str("connector_id", connectorID).Msg("Failed to get connector file")
+		return nil, internal("Failed to get connector file", err)
+	}
+	if previous != nil && !req.ModifiedAt.After(previous.ModifiedAt) {
+		return &models.ConnectorFileResponse{Unchanged: true}, nil
+	}
+	if previous != nil && req.ContentHash != "" && req.ContentHash == previous.ContentHash {
+		previous.ModifiedAt = req.ModifiedAt
+		if err := s.Repository.UpsertConnectorFile(ctx, previous); err != nil {
+			s.Logger.Error().Err(err).Str("connector_id", connectorID).Msg("Failed to save connector file")
+			return nil, internal("Failed to save connector file", err)
+		}
+		return &models.ConnectorFileResponse{Unchanged: true}, nil
+	}
+
+	// Synced files carry no metadata, so the metadata schema is not
+	// applied to them.
+	doc, err := s.upload(ctx, req.Filename, req.FileSize, connector.Username, "", models.UploadOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Repository.UpsertConnectorFile(ctx, &models.ConnectorFile{
+		ConnectorID: connectorID,
+		ExternalID:  req.ExternalID,
+		DocumentID:  doc.ID,
+		ModifiedAt:  req.ModifiedAt,
+		ContentHash: req.ContentHash,
+	}); err != nil {
+		s.Logger.Error().Err(err).Str("connector_id", connectorID).Msg("Failed to save connector file")
+		return nil, internal("Failed to save connector file", err)
+	}
+
+	if previous != nil {
+		if err := s.DeleteDocument(ctx, previous.DocumentID); err != nil {
+			s.Logger.Error().Err(err).Str("document_id", previous.DocumentID).Msg("Failed to delete previous version of connector file")
+		}
+	}
+
+	return &models.ConnectorFileResponse{Document: doc}, nil
+}
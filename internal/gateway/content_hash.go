@@ -0,0 +1,42 @@
+package gateway
+
+import (
+	"context"
+	"encoding/hex"
+	"strings"
+)
+
+// normalizeContentHash lowercases a hex SHA-256, refusing anything else.
+// An empty hash is left empty.
+func normalizeContentHash(hash string) (string, error) {
+	hash = strings.ToLower(strings.TrimSpace(hash))
+	if hash == "" {
+		return "", nil
+	}
+	if b, err := hex.DecodeString(hash); err != nil || len(b) != 32 {
+		return "", &Error{Kind: KindInvalid, Message: "content_hash must be a hex SHA-256"}
+	}
+	return hash, nil
+}
+
+// checkDuplicateContent refuses an upload whose content hash matches a
+// document already uploaded, naming that document, so the same file is
+// not indexed twice. Trashed, failed and cancelled documents do not count.
+func (s *Service) checkDuplicateContent(ctx context.Context, contentHash string) error {
+	if contentHash == "" {
+		return nil
+	}
+	existing, err := s.Repository.FindDocumentByContentHash(ctx, contentHash)
+	if err != nil {
+		s.Logger.Error().Err(err).Str("content_hash", contentHash).Msg("Failed to look up document by content hash")
+		return internal("Failed to check for duplicate documents", err)
+	}
+	if existing != nil {
+		return &Error{
+			Kind:    KindDuplicate,
+			Message: "A document with the same content already exists",
+			Details: map[string]string{"document_id": existing.ID},
+		}
+	}
+	return nil
+}
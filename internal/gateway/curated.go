@@ -0,0 +1,108 @@
+package gateway
+
+import (
+	"context"
+	"time"
+
+	"kb-platform-gateway/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// curatedAnswer serves a curated answer as the event stream of a query.
+// The end event is flagged as curated and names the answer. The query is
+// logged like one the core answered and, in a conversation, the question
+// and answer are saved as its messages, since the core never sees them,
+// and shared with its participants.
+func (s *Service) curatedAnswer(ctx context.Context, req models.QueryRequest, username string, curated *models.CuratedAnswer) <-chan models.SSEEvent {
+	started := time.Now()
+	id := uuid.New().String()
+
+	events := make(chan models.SSEEvent, 3)
+	events <- models.SSEEvent{Type: "start", ID: id}
+	events <- models.SSEEvent{Type: "chunk", Content: curated.Answer, Highlights: s.highlightAnswer(ctx, curated.Answer)}
+	events <- models.SSEEvent{
+		Type:            "end",
+		ID:              id,
+		DocumentIDs:     curated.DocumentIDs,
+		Curated:         true,
+		CuratedAnswerID: curated.ID,
+	}
+	close(events)
+
+	if req.ConversationID != "" {
+		s.saveCuratedMessages(ctx, req.ConversationID, id, req.Query, curated)
+	}
+	s.logQuery(ctx, &models.QueryLog{
+		ID:             id,
+		Username:       username,
+		ConversationID: req.ConversationID,
+		Question:       req.Query,
+		Status:         models.QueryStatusCompleted,
+		CreatedAt:      started,
+		DocumentIDs:    curated.DocumentIDs,
+	})
+	s.publish(ctx, models.EventQueryCompleted, map[string]string{
+		"id":              id,
+		"conversation_id": req.ConversationID,
+		"username":        username,
+	})
+	s.shareAnswer(req.ConversationID, id, username, req.Query, curated.Answer)
+
+	return events
+}
+
+// canReadCurated reports whether username may read every document a
+// curated answer was written from, so the answer reveals nothing the
+// caller's queries would not retrieve. A lookup failure counts as
+// unreadable: the query then goes to the core.
+func (s *Service) canReadCurated(ctx context.Context, username string, curated *models.CuratedAnswer) bool {
+	if s.Access == nil {
+		return true
+	}
+	for _, documentID := range curated.DocumentIDs {
+		doc, err := s.Repository.GetDocument(ctx, documentID)
+		if err != nil {
+			s.Logger.Error().Err(err).Str("document_id", documentID).Msg("Failed to get curated answer document")
+			return false
+		}
+		if doc != nil && !s.Access.canRead(username, doc) {
+			return false
+		}
+	}
+	return true
+}
+
+// saveCuratedMessages appends a question and its curated answer to a
+// conversation. Failures are logged: the answer has been given either way.
+func (s *Service) saveCuratedMessages(ctx context.Context, conversationID, queryID, question string, curated *models.CuratedAnswer) {
+	ctx = context.WithoutCancel(ctx)
+	now := time.Now()
+	messages := []*models.Message{
+		{
+			ID:             uuid.New().String(),
+			ConversationID: conversationID,
+			Role:           "user",
+			Content:        question,
+			CreatedAt:      now,
+		},
+		{
+			ID:             uuid.New().String(),
+			ConversationID: conversationID,
+			Role:           "assistant",
+			Content:        curated.Answer,
+			// A microsecond later, so the answer sorts after the question.
+			CreatedAt: now.Add(time.Microsecond),
+			Metadata: map[string]string{
+				models.MessageMetadataQueryID:         queryID,
+				models.MessageMetadataCuratedAnswerID: curated.ID,
+			},
+		},
+	}
+	for _, msg := range messages {
+		if err := s.Repository.CreateMessage(ctx, msg); err != nil {
+			s.Logger.Error().Err(err).Str("conversation_id", conversationID).Msg("Failed to save curated answer message")
+			return
+		}
+	}
+}
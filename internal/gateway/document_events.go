@@ -0,0 +1,45 @@
+package gateway
+
+import (
+	"context"
+	"time"
+
+	"kb-platform-gateway/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// recordDocumentEvent appends a gateway event to a document's timeline.
+// The timeline is informational, so failures are logged only.
+func (s *Service) recordDocumentEvent(ctx context.Context, documentID, eventType string, data map[string]interface{}) {
+	event := &models.DocumentEvent{
+		ID:         uuid.New().String(),
+		DocumentID: documentID,
+		Type:       eventType,
+		Source:     models.DocumentEventSourceGateway,
+		Data:       data,
+		OccurredAt: time.Now(),
+	}
+	if err := s.Repository.CreateDocumentEvent(context.WithoutCancel(ctx), event); err != nil {
+		s.Logger.Error().Err(err).Str("document_id", documentID).Str("event_type", eventType).Msg("Failed to record document event")
+	}
+}
+
+// ListDocumentEvents returns a document's timeline, oldest first. Deleted
+// documents keep theirs; a document with neither a record nor events is
+// not found.
+func (s *Service) ListDocumentEvents(ctx context.Context, documentID string, limit, offset int) ([]*models.DocumentEvent, int, error) {
+	events, total, err := s.Repository.ListDocumentEvents(ctx, documentID, limit, offset)
+	if err != nil {
+		s.Logger.Error().Err(err).Str("document_id", documentID).Msg("Failed to list document events")
+		return nil, 0, internal("Failed to list document events", err)
+	}
+	if total > 0 {
+		return events, total, nil
+	}
+
+	if _, err := s.document(ctx, documentID); err != nil {
+		return nil, 0, err
+	}
+	return events, total, nil
+}
@@ -0,0 +1,276 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"kb-platform-gateway/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// Bounds of a duplicate scan's similarity threshold. Below the minimum,
+// documents on the same topic start to cluster with true duplicates.
+const (
+	DefaultDuplicateThreshold = 0.95
+	MinDuplicateThreshold     = 0.8
+)
+
+const (
+	// duplicateScanBatch is how many documents a scan loads at a time.
+	duplicateScanBatch = 100
+	// duplicateCandidates is how many of its nearest documents are
+	// compared with each document.
+	duplicateCandidates = 10
+)
+
+// DuplicateDetector finds near-duplicate documents in the background. Each
+// indexed document is represented by the mean of its vectors, and
+// documents whose means have a cosine similarity of at least the threshold
+// are clustered together. One scan runs at a time, and Close interrupts it.
+type DuplicateDetector struct {
+	service *Service
+	running atomic.Bool
+
+	ctx       context.Context
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+}
+
+func NewDuplicateDetector(service *Service) *DuplicateDetector {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &DuplicateDetector{
+		service: service,
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// Close interrupts the running scan and waits for it to be recorded.
+func (d *DuplicateDetector) Close() {
+	d.closeOnce.Do(func() {
+		d.cancel()
+		d.wg.Wait()
+	})
+}
+
+// Start stores a duplicate report and runs its scan in the background.
+// The clusters are read back through the repository once it completes.
+func (d *DuplicateDetector) Start(ctx context.Context, req models.CreateDuplicateReportRequest, username string) (*models.DuplicateReport, error) {
+	s := d.service
+	if req.Threshold == 0 {
+		req.Threshold = DefaultDuplicateThreshold
+	}
+	if req.Threshold < MinDuplicateThreshold || req.Threshold > 1 {
+		return nil, &Error{Kind: KindInvalid, Message: fmt.Sprintf("threshold must be between %g and 1", MinDuplicateThreshold)}
+	}
+	if d.ctx.Err() != nil {
+		return nil, &Error{Kind: KindInternal, Message: "Duplicate detection is shutting down"}
+	}
+	if !d.running.CompareAndSwap(false, true) {
+		return nil, &Error{Kind: KindConflict, Message: "A duplicate scan is already running"}
+	}
+
+	report := &models.DuplicateReport{
+		ID:        uuid.New().String(),
+		Status:    models.DuplicateReportStatusRunning,
+		Threshold: req.Threshold,
+		CreatedBy: username,
+		CreatedAt: time.Now(),
+	}
+	if err := s.Repository.CreateDuplicateReport(ctx, report); err != nil {
+		d.running.Store(false)
+		s.Logger.Error().Err(err).Msg("Failed to create duplicate report")
+		return nil, internal("Failed to create duplicate report", err)
+	}
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		defer d.running.Store(false)
+		d.run(*report)
+	}()
+
+	return report, nil
+}
+
+func (d *DuplicateDetector) run(report models.DuplicateReport) {
+	s := d.service
+	// The outcome is recorded even when Close interrupts the scan.
+	recordCtx := context.WithoutCancel(d.ctx)
+
+	clusters, err := d.scan(&report)
+	switch {
+	case d.ctx.Err() != nil:
+		report.Status, report.Error = models.DuplicateReportStatusFailed, "Interrupted by gateway shutdown"
+	case err != nil:
+		s.Logger.Error().Err(err).Str("report_id", report.ID).Msg("Duplicate scan failed")
+		report.Status, report.Error = models.DuplicateReportStatusFailed, MessageOf(err)
+	default:
+		report.Status = models.DuplicateReportStatusCompleted
+		report.Clusters = clusters
+		report.ClusterCount = len(clusters)
+	}
+
+	now := time.Now()
+	report.CompletedAt = &now
+	if err := s.Repository.FinishDuplicateReport(recordCtx, &report); err != nil {
+		s.Logger.Error().Err(err).Str("report_id", report.ID).Msg("Failed to finish duplicate report")
+	}
+}
+
+// scan computes the centroid of every indexed document, then clusters the
+// documents whose centroids are similar. Documents without vectors are
+// skipped.
+func (d *DuplicateDetector) scan(report *models.DuplicateReport) ([]models.DuplicateCluster, error) {
+	s := d.service
+	ctx := d.ctx
+
+	documents := make(map[string]*models.Document)
+	centroids := make(map[string][]float32)
+	var order []string
+	for offset := 0; ; offset += duplicateScanBatch {
+		batch, _, err := s.Repository.ListDocuments(ctx, duplicateScanBatch, offset, models.DocumentFilter{Status: "complete"})
+		if err != nil {
+			return nil, internal("Failed to list documents", err)
+		}
+
+		for _, doc := range batch {
+			centroid, err := s.QdrantClient.DocumentCentroid(ctx, doc.ID)
+			if err != nil {
+				return nil, internal("Failed to read document vectors", err)
+			}
+			report.DocumentsScanned++
+			if centroid == nil {
+				continue
+			}
+			documents[doc.ID] = doc
+			centroids[doc.ID] = centroid
+			order = append(order, doc.ID)
+		}
+		if err := s.Repository.SetDuplicateReportProgress(ctx, report.ID, report.DocumentsScanned); err != nil {
+			s.Logger.Error().Err(err).Str("report_id", report.ID).Msg("Failed to record duplicate scan progress")
+		}
+
+		if len(batch) < duplicateScanBatch {
+			break
+		}
+	}
+
+	clusters := newDuplicateClusters()
+	for _, id := range order {
+		// The document itself is usually the nearest match.
+		candidates, err := s.QdrantClient.SimilarDocuments(ctx, centroids[id], duplicateCandidates+1)
+		if err != nil {
+			return nil, internal("Failed to search similar documents", err)
+		}
+		for _, candidate := range candidates {
+			other, ok := centroids[candidate]
+			if candidate == id || !ok {
+				continue
+			}
+			if similarity := cosine(centroids[id], other); similarity >= report.Threshold {
+				clusters.join(id, candidate, similarity)
+			}
+		}
+	}
+
+	return clusters.build(documents), nil
+}
+
+// cosine returns the cosine similarity of two normalized vectors.
+func cosine(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+	}
+	return dot
+}
+
+// duplicateClusters groups documents with a union-find, tracking the
+// lowest similarity that joined each group.
+type duplicateClusters struct {
+	parent     map[string]string
+	similarity map[string]float64
+}
+
+func newDuplicateClusters() *duplicateClusters {
+	return &duplicateClusters{
+		parent:     make(map[string]string),
+		similarity: make(map[string]float64),
+	}
+}
+
+func (c *duplicateClusters) find(id string) string {
+	parent, ok := c.parent[id]
+	if !ok {
+		c.parent[id] = id
+		c.similarity[id] = 1
+		return id
+	}
+	if parent == id {
+		return id
+	}
+	root := c.find(parent)
+	c.parent[id] = root
+	return root
+}
+
+func (c *duplicateClusters) join(a, b string, similarity float64) {
+	rootA, rootB := c.find(a), c.find(b)
+	lowest := min(c.similarity[rootA], c.similarity[rootB], similarity)
+	if rootA != rootB {
+		c.parent[rootB] = rootA
+		delete(c.similarity, rootB)
+	}
+	c.similarity[rootA] = lowest
+}
+
+// build returns the clusters with their documents oldest first, largest
+// clusters first.
+func (c *duplicateClusters) build(documents map[string]*models.Document) []models.DuplicateCluster {
+	members := make(map[string][]models.DuplicateDocument)
+	for id := range c.parent {
+		doc := documents[id]
+		root := c.find(id)
+		members[root] = append(members[root], models.DuplicateDocument{
+			ID:        doc.ID,
+			Filename:  doc.Filename,
+			FileSize:  doc.FileSize,
+			CreatedAt: doc.CreatedAt,
+		})
+	}
+
+	clusters := make([]models.DuplicateCluster, 0, len(members))
+	for root, docs := range members {
+		sort.Slice(docs, func(i, j int) bool {
+			if !docs[i].CreatedAt.Equal(docs[j].CreatedAt) {
+				return docs[i].CreatedAt.Before(docs[j].CreatedAt)
+			}
+			return docs[i].ID < docs[j].ID
+		})
+		clusters = append(clusters, models.DuplicateCluster{
+			Documents:  docs,
+			Similarity: c.similarity[root],
+		})
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		if len(clusters[i].Documents) != len(clusters[j].Documents) {
+			return len(clusters[i].Documents) > len(clusters[j].Documents)
+		}
+		if clusters[i].Similarity != clusters[j].Similarity {
+			return clusters[i].Similarity > clusters[j].Similarity
+		}
+		return clusters[i].Documents[0].ID < clusters[j].Documents[0].ID
+	})
+
+	return clusters
+}
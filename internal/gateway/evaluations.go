@@ -0,0 +1,170 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"kb-platform-gateway/internal/config"
+	"kb-platform-gateway/internal/models"
+	"kb-platform-gateway/internal/services"
+
+	"github.com/google/uuid"
+)
+
+// EvaluationRunner runs evaluations in the background. Each case is
+// answered through the regular query pipeline and the answer is scored by
+// the core's evaluator, Concurrency cases at a time. Close interrupts the
+// evaluations still running, which end as failed.
+type EvaluationRunner struct {
+	service     *Service
+	concurrency int
+	caseTimeout time.Duration
+
+	ctx       context.Context
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+}
+
+func NewEvaluationRunner(service *Service, cfg *config.EvaluationConfig) *EvaluationRunner {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &EvaluationRunner{
+		service:     service,
+		concurrency: max(cfg.Concurrency, 1),
+		caseTimeout: cfg.CaseTimeout,
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+}
+
+// Close interrupts running evaluations and waits for them to be recorded.
+func (r *EvaluationRunner) Close() {
+	r.closeOnce.Do(func() {
+		r.cancel()
+		r.wg.Wait()
+	})
+}
+
+// Start stores an evaluation of req.Cases and runs it in the background.
+// Progress and results are read back through the repository.
+func (r *EvaluationRunner) Start(ctx context.Context, req models.CreateEvaluationRequest, username string) (*models.Evaluation, error) {
+	s := r.service
+	if len(req.Cases) == 0 {
+		return nil, &Error{Kind: KindInvalid, Message: "At least one case is required"}
+	}
+	if r.ctx.Err() != nil {
+		return nil, &Error{Kind: KindInternal, Message: "Evaluations are shutting down"}
+	}
+	if req.TopK == 0 {
+		req.TopK = DefaultTopK
+	}
+
+	if req.PromptTemplateID != "" {
+		tmpl, err := s.Repository.GetPromptTemplate(ctx, req.PromptTemplateID, req.PromptTemplateVersion)
+		if err != nil {
+			s.Logger.Error().Err(err).Str("prompt_template_id", req.PromptTemplateID).Msg("Failed to get prompt template")
+			return nil, internal("Failed to get prompt template", err)
+		}
+		if tmpl == nil {
+			return nil, &Error{Kind: KindInvalid, Message: "Prompt template not found"}
+		}
+	}
+
+	now := time.Now()
+	evaluation := &models.Evaluation{
+		ID:                    uuid.New().String(),
+		Name:                  req.Name,
+		Status:                models.EvaluationStatusRunning,
+		TopK:                  req.TopK,
+		PromptTemplateID:      req.PromptTemplateID,
+		PromptTemplateVersion: req.PromptTemplateVersion,
+		TotalCases:            len(req.Cases),
+		CreatedBy:             username,
+		CreatedAt:             now,
+		UpdatedAt:             now,
+	}
+	if err := s.Repository.CreateEvaluation(ctx, evaluation, req.Cases); err != nil {
+		s.Logger.Error().Err(err).Msg("Failed to create evaluation")
+		return nil, internal("Failed to create evaluation", err)
+	}
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		r.run(evaluation, req.Cases, username)
+	}()
+
+	return evaluation, nil
+}
+
+func (r *EvaluationRunner) run(evaluation *models.Evaluation, cases []models.EvaluationCase, username string) {
+	s := r.service
+	// Results are recorded even when Close interrupts the run.
+	recordCtx := context.WithoutCancel(r.ctx)
+
+	// Failures are recorded per case, so Run only fails when interrupted,
+	// which is checked below.
+	_ = services.NewWorkerPool(r.concurrency).Run(r.ctx, len(cases), func(ctx context.Context, i int) error {
+		result := r.runCase(evaluation, i, cases[i], username)
+		if err := s.Repository.RecordEvaluationResult(recordCtx, evaluation.ID, result); err != nil {
+			s.Logger.Error().Err(err).Str("evaluation_id", evaluation.ID).Int("position", i).Msg("Failed to record evaluation result")
+		}
+		return nil
+	})
+
+	status, errorMessage := models.EvaluationStatusCompleted, ""
+	if r.ctx.Err() != nil {
+		status, errorMessage = models.EvaluationStatusFailed, "Interrupted by gateway shutdown"
+	}
+	if err := s.Repository.FinishEvaluation(recordCtx, evaluation.ID, status, errorMessage); err != nil {
+		s.Logger.Error().Err(err).Str("evaluation_id", evaluation.ID).Msg("Failed to finish evaluation")
+	}
+}
+
+// runCase answers and scores one case. Failures are reported in the
+// result's Error rather than failing the evaluation.
+func (r *EvaluationRunner) runCase(evaluation *models.Evaluation, position int, c models.EvaluationCase, username string) *models.EvaluationResult {
+	s := r.service
+	ctx := r.ctx
+	if r.caseTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.caseTimeout)
+		defer cancel()
+	}
+
+	result := &models.EvaluationResult{
+		Position:       position,
+		Question:       c.Question,
+		ExpectedAnswer: c.ExpectedAnswer,
+	}
+
+	answer, err := s.Answer(ctx, models.QueryRequest{
+		Query:                 c.Question,
+		TopK:                  evaluation.TopK,
+		PromptTemplateID:      evaluation.PromptTemplateID,
+		PromptTemplateVersion: evaluation.PromptTemplateVersion,
+	}, username)
+	if err != nil {
+		s.Logger.Error().Err(err).Str("evaluation_id", evaluation.ID).Int("position", position).Msg("Failed to answer evaluation case")
+		result.Error = MessageOf(err)
+		return result
+	}
+	result.Answer = answer
+
+	score, err := s.CoreClient.Evaluate(ctx, c.Question, c.ExpectedAnswer, answer)
+	if errors.Is(err, services.ErrEvaluationUnsupported) {
+		result.Error = "Evaluation is not supported by the configured core transport"
+		return result
+	}
+	if err != nil {
+		s.Logger.Error().Err(err).Str("evaluation_id", evaluation.ID).Int("position", position).Msg("Failed to score evaluation case")
+		result.Error = "Failed to score answer"
+		return result
+	}
+	result.Score = &score.Score
+	result.Metrics = score.Metrics
+
+	return result
+}
@@ -0,0 +1,252 @@
+package gateway
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"kb-platform-gateway/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// eventSchema describes an event type accepted by IngestEvent.
+type eventSchema struct {
+	// requiredData lists keys that must be present in the event data.
+	requiredData []string
+	// documentStatus, if set, is applied to the subject document.
+	documentStatus string
+	// documentEvent, if set, is appended to the subject document's
+	// timeline.
+	documentEvent string
+	// connectorSync ends the sync of the subject connector, failed if
+	// the data carries an error.
+	connectorSync bool
+	// documentLanguage records the language the indexer detected, if the
+	// data carries one, on the subject document.
+	documentLanguage bool
+	// documentTitle records the title the indexer extracted, if the data
+	// carries one, on the subject document.
+	documentTitle bool
+	// documentLabels copies the subject document's current access groups
+	// to the vectors the indexer wrote.
+	documentLabels bool
+	// snapshot finishes the subject snapshot, failed if the data carries
+	// an error.
+	snapshot bool
+	// export records the progress of the subject knowledge base export,
+	// or with exportDone its end, failed if the data carries an error.
+	export     bool
+	exportDone bool
+}
+
+var eventSchemas = map[string]eventSchema{
+	models.EventDocumentIndexing: {documentStatus: "indexing"},
+	models.EventDocumentScanned:  {documentEvent: models.DocumentEventScanned},
+	models.EventDocumentChunked:  {documentEvent: models.DocumentEventChunked},
+	models.EventDocumentEmbedded: {documentEvent: models.DocumentEventEmbedded},
+	models.EventDocumentIndexed:  {documentStatus: "complete", documentEvent: models.DocumentEventIndexed, documentLanguage: true, documentTitle: true, documentLabels: true},
+	models.EventDocumentFailed:   {requiredData: []string{"error"}, documentStatus: "failed", documentEvent: models.DocumentEventFailed},
+
+	models.EventDocumentReindexed:     {requiredData: []string{"migration_id"}, documentEvent: models.DocumentEventReindexed, documentLabels: true},
+	models.EventDocumentReindexFailed: {requiredData: []string{"migration_id"}, documentEvent: models.DocumentEventFailed},
+
+	models.EventDocumentExpanded: {requiredData: []string{"file_count"}, documentStatus: "complete", documentEvent: models.DocumentEventExpanded},
+
+	models.EventConnectorSynced:     {connectorSync: true},
+	models.EventConnectorSyncFailed: {requiredData: []string{"error"}, connectorSync: true},
+
+	models.EventSnapshotCreated: {requiredData: []string{"qdrant_snapshot", "vector_count"}, snapshot: true},
+	models.EventSnapshotFailed:  {requiredData: []string{"error"}, snapshot: true},
+
+	models.EventExportProgress:  {requiredData: []string{"documents_exported"}, export: true},
+	models.EventExportCompleted: {requiredData: []string{"size_bytes"}, export: true, exportDone: true},
+	models.EventExportFailed:    {requiredData: []string{"error"}, export: true, exportDone: true},
+}
+
+// IngestEvent accepts a typed event from the Python core or a Temporal
+// worker, applies it, stores it and routes it to SSE subscribers and
+// webhooks. Redelivered events (same ID) are returned unrouted with
+// created false.
+func (s *Service) IngestEvent(ctx context.Context, req models.IngestEventRequest) (*models.Event, bool, error) {
+	schema, ok := eventSchemas[req.Type]
+	if !ok {
+		return nil, false, &Error{Kind: KindInvalid, Message: "Unknown event type", Details: map[string]string{"type": req.Type}}
+	}
+
+	for _, key := range schema.requiredData {
+		if _, ok := req.Data[key]; !ok {
+			return nil, false, &Error{Kind: KindInvalid, Message: "Missing required event data", Details: map[string]string{"field": "data." + key}}
+		}
+	}
+
+	var language string
+	if schema.documentLanguage {
+		detected, _ := req.Data["language"].(string)
+		language, ok = NormalizeLanguage(detected)
+		if !ok {
+			return nil, false, &Error{Kind: KindInvalid, Message: "Invalid language", Details: map[string]string{"field": "data.language"}}
+		}
+	}
+
+	var title string
+	if schema.documentTitle {
+		extracted, _ := req.Data["title"].(string)
+		title = strings.TrimSpace(extracted)
+	}
+
+	now := time.Now()
+	event := &models.Event{
+		ID:         req.ID,
+		Type:       req.Type,
+		Source:     req.Source,
+		SubjectID:  req.SubjectID,
+		OccurredAt: req.OccurredAt,
+		ReceivedAt: now,
+		Data:       req.Data,
+	}
+	if event.ID == "" {
+		event.ID = uuid.New().String()
+	}
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = now
+	}
+
+	// Apply before storing so a failed update is retried by the sender
+	// instead of being swallowed as a duplicate.
+	if err := s.applyEvent(ctx, event, schema, language, title); err != nil {
+		return nil, false, err
+	}
+
+	created, err := s.Repository.CreateEvent(ctx, event)
+	if err != nil {
+		s.Logger.Error().Err(err).Str("event_type", event.Type).Msg("Failed to store event")
+		return nil, false, internal("Failed to store event", err)
+	}
+	if !created {
+		return event, false, nil
+	}
+
+	s.routeEvent(ctx, event)
+	return event, true, nil
+}
+
+// applyEvent carries out what schema says event does to its subject.
+func (s *Service) applyEvent(ctx context.Context, event *models.Event, schema eventSchema, language, title string) error {
+	if schema.documentStatus != "" {
+		errorMessage, _ := event.Data["error"].(string)
+		if err := s.Repository.UpdateDocumentStatus(ctx, event.SubjectID, schema.documentStatus, errorMessage); err != nil {
+			s.Logger.Error().Err(err).Str("document_id", event.SubjectID).Str("event_type", event.Type).Msg("Failed to update document status")
+			return internal("Failed to apply event", err)
+		}
+	}
+
+	if language != "" {
+		if err := s.Repository.SetDocumentLanguage(ctx, event.SubjectID, language); err != nil {
+			s.Logger.Error().Err(err).Str("document_id", event.SubjectID).Str("event_type", event.Type).Msg("Failed to set document language")
+			return internal("Failed to apply event", err)
+		}
+	}
+
+	if title != "" {
+		if err := s.Repository.SetDocumentTitle(ctx, event.SubjectID, title); err != nil {
+			s.Logger.Error().Err(err).Str("document_id", event.SubjectID).Str("event_type", event.Type).Msg("Failed to set document title")
+			return internal("Failed to apply event", err)
+		}
+	}
+
+	// Relabel before a migration's result can switch queries to the
+	// collection the vectors were written to.
+	if schema.documentLabels {
+		if err := s.LabelIndexedDocument(ctx, event.SubjectID); err != nil {
+			s.Logger.Error().Err(err).Str("document_id", event.SubjectID).Str("event_type", event.Type).Msg("Failed to label document vectors")
+			return internal("Failed to apply event", err)
+		}
+	}
+
+	if schema.connectorSync {
+		syncErr, _ := event.Data["error"].(string)
+		if err := s.Repository.FinishConnectorSync(ctx, event.SubjectID, syncErr, event.OccurredAt); err != nil {
+			s.Logger.Error().Err(err).Str("connector_id", event.SubjectID).Str("event_type", event.Type).Msg("Failed to finish connector sync")
+			return internal("Failed to apply event", err)
+		}
+	}
+
+	if schema.snapshot {
+		result := SnapshotResult{CompletedAt: event.OccurredAt}
+		result.QdrantSnapshot, _ = event.Data["qdrant_snapshot"].(string)
+		result.Error, _ = event.Data["error"].(string)
+		if vectorCount, ok := event.Data["vector_count"].(float64); ok {
+			result.VectorCount = int64(vectorCount)
+		}
+		if err := s.FinishSnapshot(ctx, event.SubjectID, result); err != nil {
+			s.Logger.Error().Err(err).Str("snapshot_id", event.SubjectID).Str("event_type", event.Type).Msg("Failed to finish snapshot")
+			return internal("Failed to apply event", err)
+		}
+	}
+
+	if schema.export {
+		progress := ExportProgress{Done: schema.exportDone, OccurredAt: event.OccurredAt}
+		progress.Error, _ = event.Data["error"].(string)
+		if exported, ok := event.Data["documents_exported"].(float64); ok {
+			progress.DocumentsExported = int(exported)
+		}
+		if size, ok := event.Data["size_bytes"].(float64); ok {
+			progress.SizeBytes = int64(size)
+		}
+		if err := s.UpdateKnowledgeBaseExport(ctx, event.SubjectID, progress); err != nil {
+			s.Logger.Error().Err(err).Str("export_id", event.SubjectID).Str("event_type", event.Type).Msg("Failed to update knowledge base export")
+			return internal("Failed to apply event", err)
+		}
+	}
+
+	if s.Migrations != nil && (event.Type == models.EventDocumentReindexed || event.Type == models.EventDocumentReindexFailed) {
+		migrationID, _ := event.Data["migration_id"].(string)
+		if err := s.Migrations.DocumentReindexed(ctx, migrationID, event.SubjectID, event.Type == models.EventDocumentReindexed); err != nil {
+			s.Logger.Error().Err(err).Str("migration_id", migrationID).Str("document_id", event.SubjectID).Msg("Failed to apply re-index result")
+			return internal("Failed to apply event", err)
+		}
+	}
+
+	// The timeline entry shares the event's ID, so redeliveries add it
+	// only once.
+	if schema.documentEvent != "" {
+		message, _ := event.Data["error"].(string)
+		if err := s.Repository.CreateDocumentEvent(ctx, &models.DocumentEvent{
+			ID:         event.ID,
+			DocumentID: event.SubjectID,
+			Type:       schema.documentEvent,
+			Source:     event.Source,
+			Message:    message,
+			Data:       event.Data,
+			OccurredAt: event.OccurredAt,
+		}); err != nil {
+			s.Logger.Error().Err(err).Str("document_id", event.SubjectID).Str("event_type", event.Type).Msg("Failed to record document event")
+			return internal("Failed to apply event", err)
+		}
+	}
+	return nil
+}
+
+// routeEvent hands a newly stored event to SSE subscribers, webhooks,
+// ops alerts and notifications.
+func (s *Service) routeEvent(ctx context.Context, event *models.Event) {
+	if s.PublicEvents != nil {
+		s.PublicEvents.Publish(event)
+	}
+	if slices.Contains(models.WebhookEventTypes, event.Type) {
+		s.publish(ctx, event.Type, event)
+	}
+	if s.Alerts != nil && event.Type == models.EventDocumentFailed {
+		s.Alerts.DocumentFailed(ctx, event.SubjectID)
+	}
+	if s.Notifications != nil {
+		switch event.Type {
+		case models.EventDocumentIndexed:
+			s.Notifications.DocumentIndexed(ctx, event.SubjectID)
+		case models.EventDocumentFailed:
+			s.Notifications.DocumentFailed(ctx, event.SubjectID)
+		}
+	}
+}
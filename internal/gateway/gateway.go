@@ -0,0 +1,1249 @@
+// Package gateway implements the gateway's use cases independently of the
+// transport, so the REST handlers and the gRPC server share one code path.
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"kb-platform-gateway/internal/models"
+	"kb-platform-gateway/internal/repository"
+	"kb-platform-gateway/internal/requestid"
+	"kb-platform-gateway/internal/services"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 100
+	DefaultTopK     = 5
+
+	uploadURLExpiry  = 15 * time.Minute
+	previewURLExpiry = time.Hour
+	// maxFilenameLength is the longest filename a document can be renamed
+	// to, in bytes.
+	maxFilenameLength = 255
+	// uploadCompleteGrace lets an upload started just before its URL
+	// expired still be completed.
+	uploadCompleteGrace = 15 * time.Minute
+
+	// MaxTextDocumentSize is the largest text document, in bytes, that can
+	// be created without a file upload.
+	MaxTextDocumentSize = 1 << 20
+)
+
+// Kind classifies an Error so transports can map it to a status code.
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindInvalid
+	KindNotFound
+	// KindUploadExpired means the document's upload URL expired before the
+	// upload was completed; the client should request a new one.
+	KindUploadExpired
+	// KindConflict means the resource was changed since the version the
+	// client based its update on; the client should reload and retry.
+	KindConflict
+	// KindConversationBusy means another query is in flight in the
+	// conversation; Details names its request ID.
+	KindConversationBusy
+	// KindForbidden means the caller may see the resource but not change
+	// it.
+	KindForbidden
+	// KindUnavailable means the feature is not enabled in this deployment.
+	KindUnavailable
+	// KindTooLarge means the request body exceeds the size allowed.
+	KindTooLarge
+	// KindDuplicate means a document with the same content already
+	// exists; Details names its document ID.
+	KindDuplicate
+	// KindInfected means the uploaded file failed the malware scan;
+	// Details names the signature found.
+	KindInfected
+)
+
+// Error is returned by Service methods. Message is safe to show to clients.
+type Error struct {
+	Kind    Kind
+	Message string
+	// Details are optional fields that are also safe to show to clients.
+	Details map[string]string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// KindOf returns the Kind of err, or KindInternal if it is not an *Error.
+func KindOf(err error) Kind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	return KindInternal
+}
+
+// DetailsOf returns the client-facing details of err, if any.
+func DetailsOf(err error) map[string]string {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Details
+	}
+	return nil
+}
+
+// MessageOf returns the client-facing message of err.
+func MessageOf(err error) string {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Message
+	}
+	return "Internal error"
+}
+
+func internal(message string, err error) error {
+	return &Error{Kind: KindInternal, Message: message, Err: err}
+}
+
+// Page clamps pagination parameters to the API limits, falling back to the
+// defaults for out-of-range values.
+func Page(limit, offset int) (int, int) {
+	if limit <= 0 || limit > MaxPageSize {
+		limit = DefaultPageSize
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return limit, offset
+}
+
+// Service holds the gateway's dependencies. It is cheap to construct.
+type Service struct {
+	Repository   repository.Repository
+	CoreClient   services.CoreServiceInterface
+	S3Client     services.S3ClientInterface
+	Temporal     services.TemporalClientInterface
+	QdrantClient services.QdrantClientInterface
+	Webhooks     services.WebhookDispatcherInterface
+	// Migrations is optional; nil queries the core's default collection.
+	Migrations services.EmbeddingMigratorInterface
+	// Shadow is optional; nil mirrors no queries.
+	Shadow services.ShadowMirrorInterface
+	// Curated is optional; nil sends every question to the core.
+	Curated services.CuratedAnswersInterface
+	// Glossary is optional; nil leaves answers without glossary highlights.
+	Glossary services.GlossaryInterface
+	// Redactions is optional; nil streams answers as the core wrote them.
+	Redactions services.RedactionsInterface
+	// Answers is optional; nil sends every question to the core.
+	Answers services.AnswerCacheInterface
+	// Summaries is optional; nil lets the core load every conversation's
+	// full history.
+	Summaries services.ConversationSummarizerInterface
+	// Conversations is optional; nil lets queries in one conversation run
+	// concurrently.
+	Conversations services.ConversationLocksInterface
+	// Reads is optional; nil reads every document and conversation
+	// separately.
+	Reads services.ReadCacheInterface
+	// Events is optional; nil streams no conversation activity to its
+	// participants. It must be private to them, not the hub anyone may
+	// subscribe to.
+	Events *services.EventHub
+	// PublicEvents is optional; nil streams ingested events to no SSE
+	// subscribers.
+	PublicEvents *services.EventHub
+	// Alerts is optional; nil raises no ops alerts for failed documents.
+	Alerts services.OpsMonitorInterface
+	// Notifications is optional; nil tells no one when their documents are
+	// indexed or fail.
+	Notifications services.NotificationServiceInterface
+	// ProxyUploads is optional; nil refuses uploads through the gateway.
+	ProxyUploads *ProxyUploadLimits
+	// UploadPolicy is optional; nil accepts files of any size and type.
+	UploadPolicy *UploadPolicy
+	// Scanner is optional; nil indexes uploads without scanning them for
+	// malware.
+	Scanner services.ScannerInterface
+	// Quarantine moves infected uploads under the quarantine/
+	// prefix instead of deleting them.
+	Quarantine bool
+	// TrashRetention is how long deleted documents stay in the trash. Zero
+	// deletes them at once.
+	TrashRetention time.Duration
+	// Reviewers may approve or reject uploads, which wait for them before
+	// they are indexed. Uploads are indexed at once when it is empty.
+	Reviewers []string
+	// Access is optional; nil retrieves every document for everyone,
+	// whatever its access groups.
+	Access *DocumentAccess
+	// Batches bounds the items of batch requests processed at a time.
+	// Without it they are processed one at a time.
+	Batches *services.WorkerPool
+	Logger  zerolog.Logger
+}
+
+func (s *Service) publish(ctx context.Context, eventType string, data interface{}) {
+	if s.Webhooks != nil {
+		s.Webhooks.Dispatch(ctx, eventType, data)
+	}
+}
+
+// UploadDocument registers a pending document, returns it with a presigned
+// upload URL and starts the two-phase upload workflow. ZIP archives are
+// expanded into child documents once uploaded, which inherit the archive's
+// metadata and indexing options. Unset options use the indexer's defaults.
+// The metadata must satisfy the workspace's metadata schema. An upload
+// with the content hash of an existing document is refused with a
+// KindDuplicate error.
+func (s *Service) UploadDocument(ctx context.Context, filename string, size int64, username string, opts models.UploadOptions) (*models.Document, error) {
+	opts, err := normalizeUploadOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkDocumentMetadata(ctx, opts.Metadata); err != nil {
+		return nil, err
+	}
+	return s.upload(ctx, filename, size, username, "", opts)
+}
+
+// upload registers a pending document, expanded from the archive parentID
+// if set, and starts its upload workflow. Only the file types allowed by
+// the workspace settings and the upload policy, and content not already
+// uploaded, are accepted.
+func (s *Service) upload(ctx context.Context, filename string, size int64, username, parentID string, opts models.UploadOptions) (*models.Document, error) {
+	if filename == "" {
+		return nil, &Error{Kind: KindInvalid, Message: "No file provided"}
+	}
+	settings, err := s.WorkspaceSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !allowsFile(settings, filename) {
+		return nil, &Error{Kind: KindInvalid, Message: "File type is not allowed"}
+	}
+	if err := s.UploadPolicy.Check(filename, size, opts.ContentType); err != nil {
+		return nil, err
+	}
+	if err := s.checkDuplicateContent(ctx, opts.ContentHash); err != nil {
+		return nil, err
+	}
+
+	documentID := uuid.New().String()
+	s3Key := s.S3Client.ObjectKey("documents/" + documentID + "/" + filename)
+
+	uploadURL, err := s.S3Client.GeneratePresignedUploadURL(ctx, s3Key, opts.ContentType, uploadURLExpiry)
+	if err != nil {
+		s.Logger.Error().Err(err).Msg("Failed to generate presigned URL")
+		return nil, internal("Failed to generate upload URL", err)
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(uploadURLExpiry)
+	doc := &models.Document{
+		ID:                 documentID,
+		S3Key:              s3Key,
+		Filename:           filename,
+		FileSize:           size,
+		Status:             "pending",
+		UploadedBy:         username,
+		CreatedAt:          now,
+		ParentID:           parentID,
+		UploadURLIssuedAt:  &now,
+		UploadURLExpiresAt: &expiresAt,
+		Metadata:           opts.Metadata,
+		ContentHash:        opts.ContentHash,
+		Version:            1,
+		Chunking:           opts.Chunking,
+		Processing:         opts.Processing,
+	}
+
+	if err := s.Repository.CreateDocument(ctx, doc); err != nil {
+		s.Logger.Error().Err(err).Msg("Failed to save document to database")
+		return nil, internal("Failed to save document", err)
+	}
+	s.recordDocumentEvent(ctx, documentID, models.DocumentEventUploaded, map[string]interface{}{
+		"filename":    filename,
+		"file_size":   size,
+		"uploaded_by": username,
+	})
+
+	// Start two-phase upload workflow
+	start := s.Temporal.StartUploadWorkflow
+	if isArchive(filename) {
+		start = s.Temporal.StartArchiveUploadWorkflow
+	}
+	if _, err := start(ctx, services.UploadWorkflowInput{
+		DocumentID: documentID,
+		S3Key:      s3Key,
+		Chunking:   opts.Chunking,
+		Processing: opts.Processing,
+	}); err != nil {
+		s.Logger.Error().Err(err).Msg("Failed to start upload workflow")
+		return nil, internal("Failed to start upload workflow", err)
+	}
+
+	doc.UploadURL = uploadURL
+	return doc, nil
+}
+
+// CreateTextDocument stores pasted text as a document and sends it through
+// the upload workflow right away, so it is indexed like an uploaded file.
+// The filename is the title with a .md or .txt extension. The metadata
+// must satisfy the workspace's metadata schema.
+func (s *Service) CreateTextDocument(ctx context.Context, req models.CreateTextDocumentRequest, username string) (*models.Document, error) {
+	format := req.Format
+	if format == "" {
+		format = models.TextFormatMarkdown
+	}
+	var ext, contentType string
+	switch format {
+	case models.TextFormatMarkdown:
+		ext, contentType = ".md", "text/markdown; charset=utf-8"
+	case models.TextFormatPlain:
+		ext, contentType = ".txt", "text/plain; charset=utf-8"
+	default:
+		return nil, &Error{Kind: KindInvalid, Message: "format must be text or markdown"}
+	}
+	if len(req.Content) > MaxTextDocumentSize {
+		return nil, &Error{Kind: KindInvalid, Message: "Content is too large"}
+	}
+	opts, err := normalizeUploadOptions(models.UploadOptions{Chunking: req.Chunking, Processing: req.Processing})
+	if err != nil {
+		return nil, err
+	}
+
+	filename := strings.NewReplacer("/", "-", "\\", "-").Replace(strings.TrimSpace(req.Title))
+	if filename == "" {
+		return nil, &Error{Kind: KindInvalid, Message: "title must not be blank"}
+	}
+	if !strings.HasSuffix(strings.ToLower(filename), ext) {
+		filename += ext
+	}
+
+	documentID := uuid.New().String()
+	s3Key := s.S3Client.ObjectKey("documents/" + documentID + "/" + filename)
+
+	if err := s.checkDocumentMetadata(ctx, req.Metadata); err != nil {
+		return nil, err
+	}
+
+	if err := s.S3Client.UploadObject(ctx, s3Key, strings.NewReader(req.Content), contentType); err != nil {
+		s.Logger.Error().Err(err).Str("s3_key", s3Key).Msg("Failed to store text document")
+		return nil, internal("Failed to store document", err)
+	}
+
+	doc := &models.Document{
+		ID:         documentID,
+		S3Key:      s3Key,
+		Filename:   filename,
+		FileSize:   int64(len(req.Content)),
+		Status:     "pending",
+		UploadedBy: username,
+		CreatedAt:  time.Now(),
+		Metadata:   req.Metadata,
+		Title:      strings.TrimSpace(req.Title),
+		Version:    1,
+		Chunking:   opts.Chunking,
+		Processing: opts.Processing,
+	}
+
+	if err := s.Repository.CreateDocument(ctx, doc); err != nil {
+		s.Logger.Error().Err(err).Msg("Failed to save document to database")
+		return nil, internal("Failed to save document", err)
+	}
+	s.recordDocumentEvent(ctx, documentID, models.DocumentEventUploaded, map[string]interface{}{
+		"filename":    filename,
+		"file_size":   doc.FileSize,
+		"uploaded_by": username,
+	})
+
+	if err := s.indexStored(ctx, doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// indexStored sends a pending document whose file is already in S3
+// through the upload workflow, completing the upload at once unless it
+// must wait for review.
+func (s *Service) indexStored(ctx context.Context, doc *models.Document) error {
+	start := s.Temporal.StartUploadWorkflow
+	if isArchive(doc.Filename) {
+		start = s.Temporal.StartArchiveUploadWorkflow
+	}
+	if _, err := start(ctx, services.UploadWorkflowInput{
+		DocumentID: doc.ID,
+		S3Key:      doc.S3Key,
+		Chunking:   doc.Chunking,
+		Processing: doc.Processing,
+	}); err != nil {
+		s.Logger.Error().Err(err).Msg("Failed to start upload workflow")
+		return internal("Failed to start upload workflow", err)
+	}
+	if s.reviewRequired() {
+		return s.requestReview(ctx, doc)
+	}
+	if err := s.Temporal.SignalUploadComplete(ctx, doc.ID, doc.S3Key, doc.Processing); err != nil {
+		s.Logger.Error().Err(err).Str("document_id", doc.ID).Msg("Failed to signal upload complete")
+		return internal("Failed to signal upload complete", err)
+	}
+
+	doc.Status, doc.WorkflowID = "indexing", services.UploadWorkflowID(doc.ID)
+	if err := s.Repository.SetDocumentIndexing(ctx, doc.ID, doc.WorkflowID); err != nil {
+		s.Logger.Error().Err(err).Str("document_id", doc.ID).Msg("Failed to update document status")
+		return internal("Failed to update document status", err)
+	}
+	return nil
+}
+
+// ListDocuments lists the documents matching filter, in the order it asks
+// for or newest first.
+func (s *Service) ListDocuments(ctx context.Context, limit, offset int, filter models.DocumentFilter) ([]*models.Document, int, error) {
+	filter, err := normalizeDocumentFilter(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	documents, total, err := s.Repository.ListDocuments(ctx, limit, offset, filter)
+	if err != nil {
+		s.Logger.Error().Err(err).Msg("Failed to list documents")
+		return nil, 0, internal("Failed to list documents", err)
+	}
+	return documents, total, nil
+}
+
+// SearchDocuments lists the documents matching filter by their
+// metadata, filename, title and dates, without the RAG pipeline. Unlike
+// ListDocuments it needs at least one search criterion, and ranks matches
+// of filter.Text by relevance unless another order is asked for.
+func (s *Service) SearchDocuments(ctx context.Context, limit, offset int, filter models.DocumentFilter) ([]*models.Document, int, error) {
+	filter, err := normalizeDocumentFilter(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	if filter.Text == "" && filter.Filename == "" && filter.Query == "" && filter.Metadata == nil &&
+		filter.CreatedFrom == nil && filter.CreatedTo == nil && filter.IndexedFrom == nil && filter.IndexedTo == nil {
+		return nil, 0, &Error{Kind: KindInvalid, Message: "Give at least one of q, filename, metadata or a date range"}
+	}
+
+	documents, total, err := s.Repository.ListDocuments(ctx, limit, offset, filter)
+	if err != nil {
+		s.Logger.Error().Err(err).Msg("Failed to search documents")
+		return nil, 0, internal("Failed to search documents", err)
+	}
+	return documents, total, nil
+}
+
+// ExportDocuments calls fn for every document matching filter, oldest
+// first, as they are read, stopping at the first error fn returns.
+func (s *Service) ExportDocuments(ctx context.Context, filter models.DocumentFilter, fn func(*models.Document) error) error {
+	filter, err := normalizeDocumentFilter(filter)
+	if err != nil {
+		return err
+	}
+
+	if err := s.Repository.ExportDocuments(ctx, filter, fn); err != nil {
+		s.Logger.Error().Err(err).Msg("Failed to export documents")
+		return internal("Failed to export documents", err)
+	}
+	return nil
+}
+
+// GetDocument returns a document, sharing the read with other requests
+// for it when Reads is set. Changes to a document go through document,
+// which always reads it afresh.
+func (s *Service) GetDocument(ctx context.Context, documentID string) (*models.Document, error) {
+	if s.Reads == nil {
+		return s.document(ctx, documentID)
+	}
+	return s.Reads.Document(ctx, documentID, func(ctx context.Context) (*models.Document, error) {
+		return s.document(ctx, documentID)
+	})
+}
+
+func (s *Service) document(ctx context.Context, documentID string) (*models.Document, error) {
+	doc, err := s.Repository.GetDocument(ctx, documentID)
+	if err != nil {
+		s.Logger.Error().Err(err).Str("document_id", documentID).Msg("Failed to get document")
+		return nil, internal("Failed to get document", err)
+	}
+
+	if doc == nil {
+		return nil, &Error{Kind: KindNotFound, Message: "Document not found"}
+	}
+
+	if isArchive(doc.Filename) && doc.ParentID == "" {
+		progress, err := s.Repository.CountChildDocuments(ctx, documentID)
+		if err != nil {
+			s.Logger.Error().Err(err).Str("document_id", documentID).Msg("Failed to count archive files")
+			return nil, internal("Failed to get document", err)
+		}
+		doc.Children = progress
+	}
+
+	return doc, nil
+}
+
+// UpdateDocument replaces a document's metadata, renames it, or both,
+// provided it is still at version. Editors that lose the race get a
+// KindConflict error rather than silently overwriting the other's change.
+// A rename keeps the file's extension, which decides how it is indexed,
+// and leaves its S3 object where it is. New metadata must satisfy the
+// workspace's metadata schema.
+func (s *Service) UpdateDocument(ctx context.Context, documentID string, req models.UpdateDocumentRequest, version int) (*models.Document, error) {
+	if req.Metadata == nil && req.Filename == "" {
+		return nil, &Error{Kind: KindInvalid, Message: "Send metadata or filename to update"}
+	}
+	if req.Filename != "" {
+		current, err := s.document(ctx, documentID)
+		if err != nil {
+			return nil, err
+		}
+		filename, err := checkFilename(req.Filename, current.Filename)
+		if err != nil {
+			return nil, err
+		}
+		req.Filename = filename
+	}
+	if req.Metadata != nil {
+		if err := s.checkDocumentMetadata(ctx, req.Metadata); err != nil {
+			return nil, err
+		}
+	}
+
+	updated, err := s.Repository.UpdateDocumentDetails(ctx, documentID, req.Metadata, req.Filename, version)
+	if err != nil {
+		s.Logger.Error().Err(err).Str("document_id", documentID).Msg("Failed to update document")
+		return nil, internal("Failed to update document", err)
+	}
+
+	doc, err := s.document(ctx, documentID)
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, &Error{Kind: KindConflict, Message: fmt.Sprintf("Document was modified (now at version %d); reload it and retry", doc.Version)}
+	}
+	data := map[string]interface{}{"version": doc.Version}
+	if req.Filename != "" {
+		data["filename"] = req.Filename
+	}
+	s.recordDocumentEvent(ctx, documentID, models.DocumentEventMetadataUpdated, data)
+
+	return doc, nil
+}
+
+// checkFilename trims a new filename for a document, refusing one that is
+// empty, longer than maxFilenameLength, names a directory or changes the
+// extension of current.
+func checkFilename(filename, current string) (string, error) {
+	filename = strings.TrimSpace(filename)
+	if filename == "" || len(filename) > maxFilenameLength || strings.ContainsAny(filename, "/\\") {
+		return "", &Error{Kind: KindInvalid, Message: fmt.Sprintf("filename must be 1 to %d bytes, without slashes", maxFilenameLength)}
+	}
+	if !strings.EqualFold(path.Ext(filename), path.Ext(current)) {
+		return "", &Error{Kind: KindInvalid, Message: "filename must keep the file's extension"}
+	}
+	return filename, nil
+}
+
+// DeleteDocument moves the document to the trash, if the trash is enabled,
+// or deletes it in steps: the record is tombstoned, so the document leaves
+// listings at once, then its S3 object, vectors and record are deleted.
+// Every step can be repeated, so if one fails the tombstone stays and the
+// trash purge finishes the deletion later. Deleting a document already in
+// the trash deletes it for good, and deleting one already gone succeeds.
+func (s *Service) DeleteDocument(ctx context.Context, documentID string) error {
+	doc, err := s.Repository.GetDocument(ctx, documentID)
+	if err != nil {
+		s.Logger.Error().Err(err).Str("document_id", documentID).Msg("Failed to get document")
+		return internal("Failed to get document", err)
+	}
+	if doc == nil {
+		return nil
+	}
+	return s.deleteDocument(ctx, doc)
+}
+
+func (s *Service) deleteDocument(ctx context.Context, doc *models.Document) error {
+	if doc.DeletedAt == nil {
+		if err := s.stopIndexing(ctx, doc); err != nil {
+			return err
+		}
+		if s.TrashRetention > 0 {
+			return s.trashDocument(ctx, doc.ID)
+		}
+		if _, err := s.Repository.TrashDocument(ctx, doc.ID, time.Now()); err != nil {
+			s.Logger.Error().Err(err).Str("document_id", doc.ID).Msg("Failed to tombstone document")
+			return internal("Failed to delete document", err)
+		}
+	}
+
+	s.finishDeleting(ctx, doc)
+	return nil
+}
+
+// CompleteUpload signals the upload workflow that the file is in S3,
+// replacing the document's processing options first if processing is set;
+// empty options restore the indexer's defaults. If the upload workflow is
+// no longer running, an indexing workflow is started instead. The document
+// is then marked indexing by that workflow, or pending_review, without
+// signalling, when uploads wait for review. It is refused unless the
+// document is pending and its file is in S3, and once its upload URL has
+// expired, as the file could not have been uploaded with it. When uploads
+// are scanned, the scanned copy of the file is indexed rather than the
+// uploaded one, which its upload URL could still overwrite, and an
+// infected file is refused with a KindInfected error and its document
+// rejected.
+func (s *Service) CompleteUpload(ctx context.Context, documentID string, processing *models.ProcessingOptions) (*models.Document, error) {
+	doc, err := s.Repository.GetDocument(ctx, documentID)
+	if err != nil {
+		s.Logger.Error().Err(err).Str("document_id", documentID).Msg("Failed to get document")
+		return nil, internal("Failed to get document", err)
+	}
+	if doc == nil {
+		return nil, &Error{Kind: KindNotFound, Message: "Document not found"}
+	}
+	if doc.Status != "pending" {
+		return nil, &Error{Kind: KindConflict, Message: "Document upload is already complete"}
+	}
+	if doc.UploadURLExpiresAt != nil && time.Now().After(doc.UploadURLExpiresAt.Add(uploadCompleteGrace)) {
+		return nil, &Error{Kind: KindUploadExpired, Message: "The upload URL has expired; request a new one and upload the file again"}
+	}
+
+	uploaded := s.uploadKey(doc.S3Key)
+	exists, err := s.S3Client.ObjectExists(ctx, uploaded)
+	if err != nil {
+		s.Logger.Error().Err(err).Str("s3_key", uploaded).Msg("Failed to check uploaded file")
+		return nil, internal("Failed to check uploaded file", err)
+	}
+	if !exists {
+		return nil, &Error{Kind: KindInvalid, Message: "The file has not been uploaded"}
+	}
+	if err := s.scanUpload(ctx, doc); err != nil {
+		return nil, err
+	}
+
+	if processing != nil {
+		normalized, err := normalizeProcessing(processing)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.Repository.SetDocumentProcessing(ctx, documentID, normalized); err != nil {
+			s.Logger.Error().Err(err).Str("document_id", documentID).Msg("Failed to set document processing options")
+			return nil, internal("Failed to update document", err)
+		}
+		doc.Processing = normalized
+	}
+
+	if s.reviewRequired() {
+		if err := s.requestReview(ctx, doc); err != nil {
+			return nil, err
+		}
+	} else if err := s.resumeUpload(ctx, doc); err != nil {
+		return nil, err
+	}
+	// The scanned copy is indexed from now on.
+	if doc.S3Key != uploaded {
+		s.deleteUploaded(ctx, uploaded)
+	}
+
+	return &models.Document{
+		ID:         documentID,
+		Status:     doc.Status,
+		Processing: doc.Processing,
+		WorkflowID: doc.WorkflowID,
+		Scan:       doc.Scan,
+	}, nil
+}
+
+// resumeUpload signals a document's upload workflow that its file is in
+// S3, or starts an indexing workflow if that one is no longer running,
+// and marks the document indexing.
+func (s *Service) resumeUpload(ctx context.Context, doc *models.Document) error {
+	workflowID := services.UploadWorkflowID(doc.ID)
+	err := s.Temporal.SignalUploadComplete(ctx, doc.ID, doc.S3Key, doc.Processing)
+	if errors.Is(err, services.ErrWorkflowNotFound) {
+		workflowID, err = s.Temporal.StartIndexWorkflow(ctx, services.IndexWorkflowInput{
+			DocumentID:   doc.ID,
+			Chunking:     doc.Chunking,
+			Processing:   doc.Processing,
+			AccessGroups: doc.AccessGroups,
+		})
+		if err != nil {
+			s.Logger.Error().Err(err).Str("document_id", doc.ID).Msg("Failed to start index workflow")
+			return internal("Failed to start index workflow", err)
+		}
+	} else if err != nil {
+		s.Logger.Error().Err(err).Str("document_id", doc.ID).Msg("Failed to signal upload complete")
+		return internal("Failed to signal upload complete", err)
+	}
+
+	if err := s.Repository.SetDocumentIndexing(ctx, doc.ID, workflowID); err != nil {
+		s.Logger.Error().Err(err).Str("document_id", doc.ID).Msg("Failed to update document status")
+		return internal("Failed to update document status", err)
+	}
+	doc.Status, doc.WorkflowID = "indexing", workflowID
+	return nil
+}
+
+// CancelIndexing cancels the workflow indexing a document and marks it
+// cancelled, deleting the vectors already written if deleteVectors is set.
+// Re-indexing the document starts over.
+func (s *Service) CancelIndexing(ctx context.Context, documentID string, deleteVectors bool) (*models.Document, error) {
+	doc, err := s.document(ctx, documentID)
+	if err != nil {
+		return nil, err
+	}
+	if doc.Status != "indexing" {
+		return nil, &Error{Kind: KindConflict, Message: "Document is not being indexed"}
+	}
+
+	workflowID := doc.WorkflowID
+	if workflowID == "" {
+		// Indexed before workflow IDs were recorded.
+		workflowID = services.UploadWorkflowID(documentID)
+	}
+	if err := s.Temporal.CancelWorkflow(ctx, workflowID); errors.Is(err, services.ErrWorkflowNotFound) {
+		return nil, &Error{Kind: KindConflict, Message: "Indexing has already finished"}
+	} else if err != nil {
+		s.Logger.Error().Err(err).Str("workflow_id", workflowID).Msg("Failed to cancel workflow")
+		return nil, internal("Failed to cancel indexing", err)
+	}
+
+	doc.Status, doc.ErrorMessage = "cancelled", ""
+	if err := s.Repository.UpdateDocumentStatus(ctx, documentID, doc.Status, ""); err != nil {
+		s.Logger.Error().Err(err).Str("document_id", documentID).Msg("Failed to update document status")
+		return nil, internal("Failed to update document status", err)
+	}
+	s.recordDocumentEvent(ctx, documentID, models.DocumentEventCancelled, map[string]interface{}{
+		"workflow_id":    workflowID,
+		"delete_vectors": deleteVectors,
+	})
+
+	if deleteVectors {
+		if err := s.QdrantClient.DeleteDocumentVectors(ctx, s.activeCollection(), documentID); err != nil {
+			s.Logger.Error().Err(err).Str("document_id", documentID).Msg("Failed to delete vectors")
+			return nil, internal("Failed to delete document vectors", err)
+		}
+	}
+
+	return doc, nil
+}
+
+// RefreshUploadURL issues a new presigned upload URL for a document still
+// awaiting its file, e.g. after the previous one expired.
+func (s *Service) RefreshUploadURL(ctx context.Context, documentID string) (*models.Document, error) {
+	doc, err := s.Repository.GetDocument(ctx, documentID)
+	if err != nil {
+		s.Logger.Error().Err(err).Str("document_id", documentID).Msg("Failed to get document")
+		return nil, internal("Failed to get document", err)
+	}
+	if doc == nil {
+		return nil, &Error{Kind: KindNotFound, Message: "Document not found"}
+	}
+	if doc.Status != "pending" || doc.S3Key == "" {
+		return nil, &Error{Kind: KindInvalid, Message: "Document is not awaiting an upload"}
+	}
+
+	uploadURL, err := s.issueUploadURL(ctx, doc)
+	if err != nil {
+		return nil, err
+	}
+
+	doc.UploadURL = uploadURL
+	return doc, nil
+}
+
+// issueUploadURL presigns an upload URL for doc's upload key and records
+// its expiry on doc and in the database.
+func (s *Service) issueUploadURL(ctx context.Context, doc *models.Document) (string, error) {
+	uploadURL, err := s.S3Client.GeneratePresignedUploadURL(ctx, s.uploadKey(doc.S3Key), "", uploadURLExpiry)
+	if err != nil {
+		s.Logger.Error().Err(err).Msg("Failed to generate presigned URL")
+		return "", internal("Failed to generate upload URL", err)
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(uploadURLExpiry)
+	if err := s.Repository.SetDocumentUploadURL(ctx, doc.ID, now, expiresAt); err != nil {
+		s.Logger.Error().Err(err).Str("document_id", doc.ID).Msg("Failed to record upload URL expiry")
+		return "", internal("Failed to record upload URL", err)
+	}
+	doc.UploadURLIssuedAt = &now
+	doc.UploadURLExpiresAt = &expiresAt
+	return uploadURL, nil
+}
+
+// ListConversations returns a page of the conversations username created
+// or takes part in, newest first. Without a username there are none,
+// rather than everyone's.
+func (s *Service) ListConversations(ctx context.Context, username string, limit, offset int) ([]*models.Conversation, int, error) {
+	if username == "" {
+		return nil, 0, nil
+	}
+	conversations, total, err := s.Repository.ListConversations(ctx, username, limit, offset)
+	if err != nil {
+		s.Logger.Error().Err(err).Msg("Failed to list conversations")
+		return nil, 0, internal("Failed to list conversations", err)
+	}
+	return conversations, total, nil
+}
+
+// CreateConversation starts a conversation created by username, who may
+// later share it with others.
+func (s *Service) CreateConversation(ctx context.Context, username string) (*models.Conversation, error) {
+	now := time.Now()
+
+	conv := &models.Conversation{
+		ID:        uuid.New().String(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		CreatedBy: username,
+	}
+
+	if err := s.Repository.CreateConversation(ctx, conv); err != nil {
+		s.Logger.Error().Err(err).Msg("Failed to create conversation")
+		return nil, internal("Failed to create conversation", err)
+	}
+
+	s.publish(ctx, models.EventConversationCreated, conv)
+
+	return conv, nil
+}
+
+// GetConversation returns a conversation, sharing the read with other
+// requests for it when Reads is set.
+func (s *Service) GetConversation(ctx context.Context, conversationID string) (*models.Conversation, error) {
+	if s.Reads == nil {
+		return s.conversation(ctx, conversationID)
+	}
+	return s.Reads.Conversation(ctx, conversationID, func(ctx context.Context) (*models.Conversation, error) {
+		return s.conversation(ctx, conversationID)
+	})
+}
+
+func (s *Service) conversation(ctx context.Context, conversationID string) (*models.Conversation, error) {
+	conv, err := s.Repository.GetConversation(ctx, conversationID)
+	if err != nil {
+		s.Logger.Error().Err(err).Str("conversation_id", conversationID).Msg("Failed to get conversation")
+		return nil, internal("Failed to get conversation", err)
+	}
+
+	if conv == nil {
+		return nil, &Error{Kind: KindNotFound, Message: "Conversation not found"}
+	}
+
+	return conv, nil
+}
+
+// GetConversationMessages returns a page of a conversation's messages to
+// its creator or a participant. Assistant messages carry the passages they
+// cite, with preview links, and text matching the enabled redaction rules
+// is replaced.
+func (s *Service) GetConversationMessages(ctx context.Context, conversationID, username string, limit, offset int) ([]*models.Message, error) {
+	if err := s.checkConversationReader(ctx, conversationID, username); err != nil {
+		return nil, err
+	}
+	messages, err := s.Repository.GetMessagesByConversationID(ctx, conversationID, limit, offset)
+	if err != nil {
+		s.Logger.Error().Err(err).Str("conversation_id", conversationID).Msg("Failed to get messages")
+		return nil, internal("Failed to get messages", err)
+	}
+	s.attachSources(ctx, messages)
+	redactMessages(s.redactor(ctx), messages...)
+	return messages, nil
+}
+
+// ExportConversationMessages calls fn for every message of a
+// conversation, oldest first, as they are read, stopping at the first
+// error fn returns. Like GetConversationMessages it is limited to the
+// conversation's creator and participants, but cited passages are not
+// attached. Assistant messages are redacted as by GetConversationMessages.
+func (s *Service) ExportConversationMessages(ctx context.Context, conversationID, username string, fn func(*models.Message) error) error {
+	if err := s.checkConversationReader(ctx, conversationID, username); err != nil {
+		return err
+	}
+
+	rules := s.redactor(ctx)
+	redact := func(msg *models.Message) error {
+		redactMessages(rules, msg)
+		return fn(msg)
+	}
+	if err := s.Repository.ExportMessages(ctx, conversationID, redact); err != nil {
+		s.Logger.Error().Err(err).Str("conversation_id", conversationID).Msg("Failed to export messages")
+		return internal("Failed to export messages", err)
+	}
+	return nil
+}
+
+// ListConversationSummaries returns the summary versions of a
+// conversation, newest first, to its creator or a participant.
+func (s *Service) ListConversationSummaries(ctx context.Context, conversationID, username string) ([]*models.ConversationSummary, error) {
+	if err := s.checkConversationReader(ctx, conversationID, username); err != nil {
+		return nil, err
+	}
+	summaries, err := s.Repository.ListConversationSummaries(ctx, conversationID)
+	if err != nil {
+		s.Logger.Error().Err(err).Str("conversation_id", conversationID).Msg("Failed to list conversation summaries")
+		return nil, internal("Failed to list conversation summaries", err)
+	}
+	return summaries, nil
+}
+
+// Query starts a RAG query and returns its event stream. Once the stream
+// ends normally a query.completed event is published and, in a
+// conversation, the answer is shared with its participants. A query naming
+// a snapshot in req.AsOf retrieves from it rather than the live knowledge
+// base. Otherwise, a question matching a curated answer is answered with
+// it, outside a confined collection and if the caller may read the
+// answer's documents. A question outside a conversation that is
+// near-identical to one answered recently is answered from the earlier
+// answer, unless req.Fresh is set. A long conversation is sent as its
+// rolling summary and newer messages. Text matching redaction rules is
+// replaced, and glossary terms in the answer are highlighted. Only the
+// creator and participants of a conversation may query in it, and only one
+// query at a time runs in a conversation; others are refused, or wait for
+// it, with a KindConversationBusy error.
+func (s *Service) Query(ctx context.Context, req models.QueryRequest, username string) (<-chan models.SSEEvent, error) {
+	if req.ConversationID != "" {
+		if err := s.checkConversationReader(ctx, req.ConversationID, username); err != nil {
+			return nil, err
+		}
+	}
+	if s.Conversations == nil || req.ConversationID == "" {
+		return s.query(ctx, req, username)
+	}
+
+	requestID := requestid.FromContext(ctx)
+	if requestID == "" {
+		requestID = uuid.New().String()
+	}
+	release, err := s.Conversations.Acquire(ctx, req.ConversationID, requestID)
+	var busy *services.ConversationBusyError
+	switch {
+	case errors.As(err, &busy):
+		return nil, &Error{
+			Kind:    KindConversationBusy,
+			Message: "Another query is in progress in this conversation",
+			Details: map[string]string{"active_request_id": busy.RequestID},
+		}
+	case ctx.Err() != nil:
+		return nil, internal("Query cancelled", ctx.Err())
+	case err != nil:
+		// Fail open: an unavailable lock store should not stop queries.
+		s.Logger.Error().Err(err).Str("conversation_id", req.ConversationID).Msg("Failed to lock conversation")
+		return s.query(ctx, req, username)
+	}
+
+	upstream, err := s.query(ctx, req, username)
+	if err != nil {
+		release()
+		return nil, err
+	}
+
+	// The lock is held until the answer has been streamed in full, and
+	// released before the stream ends so the client's next query finds
+	// the conversation free.
+	events := make(chan models.SSEEvent)
+	go func() {
+		defer close(events)
+		defer release()
+		for event := range upstream {
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				for range upstream {
+				}
+				return
+			}
+		}
+	}()
+	return events, nil
+}
+
+func (s *Service) query(ctx context.Context, req models.QueryRequest, username string) (<-chan models.SSEEvent, error) {
+	if req.Query == "" {
+		return nil, &Error{Kind: KindInvalid, Message: "Invalid request format"}
+	}
+	if req.TopK == 0 {
+		req.TopK = DefaultTopK
+	}
+	if req.MaxChunksPerDocument < 0 || req.MaxChunksPerDocument > req.TopK {
+		return nil, &Error{Kind: KindInvalid, Message: "max_chunks_per_document must be between 1 and top_k"}
+	}
+	language, ok := NormalizeLanguage(req.Language)
+	if !ok {
+		return nil, &Error{Kind: KindInvalid, Message: "Invalid language"}
+	}
+
+	collection := req.Collection
+	if req.AsOf != "" {
+		// Demo guests and widgets are confined to their collection.
+		if collection != "" {
+			return nil, &Error{Kind: KindForbidden, Message: "as_of is not available to this client"}
+		}
+		snapshot, err := s.querySnapshot(ctx, req.AsOf)
+		if err != nil {
+			return nil, err
+		}
+		collection = snapshot.Collection
+	} else if collection == "" && s.Migrations != nil {
+		collection = s.Migrations.ActiveCollection()
+	}
+
+	if req.CollectionID != "" {
+		if req.Collection != "" {
+			return nil, &Error{Kind: KindForbidden, Message: "collection_id is not available to this client"}
+		}
+		if err := s.checkQueryCollection(ctx, req.CollectionID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Curated answers reflect the live knowledge base, not a snapshot, a
+	// subset of its documents or the collection a client is confined to,
+	// and are only given to callers who may read their documents.
+	if s.Curated != nil && req.AsOf == "" && req.CollectionID == "" && req.Collection == "" {
+		curated, err := s.Curated.Find(ctx, req.Query)
+		if err != nil {
+			s.Logger.Error().Err(err).Msg("Failed to look up curated answers")
+		} else if curated != nil && s.canReadCurated(ctx, username, curated) {
+			return s.curatedAnswer(ctx, req, username, curated), nil
+		}
+	}
+
+	var prompt, promptVersion string
+	if req.PromptTemplateID != "" {
+		tmpl, err := s.Repository.GetPromptTemplate(ctx, req.PromptTemplateID, req.PromptTemplateVersion)
+		if err != nil {
+			s.Logger.Error().Err(err).Str("prompt_template_id", req.PromptTemplateID).Msg("Failed to get prompt template")
+			return nil, internal("Failed to get prompt template", err)
+		}
+		if tmpl == nil {
+			return nil, &Error{Kind: KindInvalid, Message: "Prompt template not found"}
+		}
+		prompt = tmpl.Template
+		promptVersion = fmt.Sprintf("%s@%d", tmpl.ID, tmpl.Version)
+	}
+
+	accessGroups, access := s.accessGroups(username)
+
+	// A collection's documents change independently of the knowledge base,
+	// so answers restricted to one are not reused.
+	reuse := s.Answers != nil && req.ConversationID == "" && req.CollectionID == ""
+	scope := answerScope(collection, language, promptVersion, req.MaxChunksPerDocument, access)
+	if reuse && !req.Fresh {
+		previous, err := s.Answers.Find(ctx, scope, req.Query)
+		if err != nil {
+			s.Logger.Error().Err(err).Msg("Failed to look up previous answers")
+		} else if previous != nil {
+			return s.previousAnswer(ctx, req.Query, username, previous), nil
+		}
+	}
+
+	var history *models.ConversationContext
+	if s.Summaries != nil && req.ConversationID != "" {
+		loaded, err := s.Summaries.History(ctx, req.ConversationID)
+		if err != nil {
+			s.Logger.Error().Err(err).Str("conversation_id", req.ConversationID).Msg("Failed to load conversation history")
+		} else {
+			history = loaded
+		}
+	}
+
+	coreReq := models.CoreQueryRequest{
+		Query:                req.Query,
+		ConversationID:       req.ConversationID,
+		TopK:                 req.TopK,
+		PromptTemplate:       prompt,
+		Collection:           collection,
+		Language:             language,
+		MaxChunksPerDocument: req.MaxChunksPerDocument,
+		CollectionID:         req.CollectionID,
+		AccessGroups:         accessGroups,
+		Context:              history,
+	}
+
+	started := time.Now()
+	upstream, err := s.CoreClient.Query(ctx, coreReq)
+	if errors.Is(err, services.ErrPromptTemplateUnsupported) {
+		return nil, &Error{Kind: KindInvalid, Message: "Prompt templates are not supported by the configured core transport"}
+	}
+	if err != nil {
+		s.Logger.Error().Err(err).Str("query", req.Query).Msg("Failed to query")
+		return nil, internal("Failed to query", err)
+	}
+
+	var mirrored func(time.Duration, bool)
+	if s.Shadow != nil {
+		mirrored = s.Shadow.Mirror(coreReq)
+	}
+
+	redactions := s.streamRedactor(ctx)
+	highlights := s.highlighter(ctx)
+
+	events := make(chan models.SSEEvent)
+	go func() {
+		defer close(events)
+
+		log := &models.QueryLog{
+			Username:       username,
+			ConversationID: req.ConversationID,
+			Question:       req.Query,
+			Status:         models.QueryStatusFailed,
+			CreatedAt:      started,
+		}
+		defer s.logQuery(ctx, log)
+		if mirrored != nil {
+			defer func() {
+				mirrored(time.Since(started), log.Status == models.QueryStatusCompleted)
+			}()
+		}
+
+		var end *models.SSEEvent
+		var citations []models.Citation
+		var answer strings.Builder
+		for received := range upstream {
+			// Text is redacted before it is highlighted, so highlights
+			// locate the text sent.
+			batch := []models.SSEEvent{received}
+			if redactions != nil {
+				batch = redactions.apply(received)
+			}
+			for _, event := range batch {
+				if highlights != nil {
+					highlights.apply(&event)
+				}
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					// Drain so the core client can release the stream.
+					for range upstream {
+					}
+					log.Status = models.QueryStatusCancelled
+					return
+				}
+				if log.ID == "" && event.ID != "" {
+					log.ID = event.ID
+				}
+				switch event.Type {
+				case "chunk":
+					answer.WriteString(event.Content)
+				case "sources":
+					citations = append(citations, event.Sources...)
+				case "end":
+					end = &event
+				}
+			}
+		}
+
+		if end != nil {
+			log.Status = models.QueryStatusCompleted
+			if end.Tokens > 0 {
+				log.Tokens = &end.Tokens
+			}
+			log.Citations = citations
+			log.DocumentIDs = citedDocuments(end.DocumentIDs, citations)
+			if reuse && answer.Len() > 0 {
+				if log.ID == "" {
+					log.ID = uuid.New().String()
+				}
+				s.rememberAnswer(ctx, &models.AnsweredQuestion{
+					QueryID:     log.ID,
+					Scope:       scope,
+					Question:    req.Query,
+					Answer:      answer.String(),
+					DocumentIDs: log.DocumentIDs,
+					CreatedAt:   time.Now(),
+				})
+			}
+			s.publish(ctx, models.EventQueryCompleted, map[string]string{
+				"id":              end.ID,
+				"conversation_id": req.ConversationID,
+				"username":        username,
+			})
+			s.shareAnswer(req.ConversationID, end.ID, username, req.Query, answer.String())
+		}
+	}()
+
+	return events, nil
+}
+
+// Answer runs a query to completion and returns the full answer. An error
+// event from the core is returned as an error.
+func (s *Service) Answer(ctx context.Context, req models.QueryRequest, username string) (string, error) {
+	events, err := s.Query(ctx, req, username)
+	if err != nil {
+		return "", err
+	}
+
+	var answer strings.Builder
+	for event := range events {
+		switch event.Type {
+		case "error":
+			for range events {
+			}
+			return "", &Error{Kind: KindInternal, Message: event.Message}
+		case "chunk":
+			answer.WriteString(event.Content)
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return "", internal("Query cancelled", err)
+	}
+
+	return answer.String(), nil
+}
+
+// citedDocuments merges the documents reported on the end event with those
+// of the cited chunks, keeping the first occurrence of each.
+func citedDocuments(documentIDs []string, citations []models.Citation) []string {
+	seen := make(map[string]bool, len(documentIDs)+len(citations))
+	var merged []string
+	add := func(id string) {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			merged = append(merged, id)
+		}
+	}
+	for _, id := range documentIDs {
+		add(id)
+	}
+	for _, citation := range citations {
+		add(citation.DocumentID)
+	}
+	return merged
+}
+
+// logQuery records a finished query. The log outlives the request, so it
+// is written even if ctx was cancelled.
+func (s *Service) logQuery(ctx context.Context, log *models.QueryLog) {
+	if s.Repository == nil {
+		return
+	}
+	if log.ID == "" {
+		log.ID = uuid.New().String()
+	}
+	log.LatencyMS = time.Since(log.CreatedAt).Milliseconds()
+
+	if err := s.Repository.CreateQueryLog(context.WithoutCancel(ctx), log); err != nil {
+		s.Logger.Error().Err(err).Str("query_id", log.ID).Msg("Failed to record query log")
+	}
+}
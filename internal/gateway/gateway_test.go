@@ -0,0 +1,5394 @@
+package gateway_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"kb-platform-gateway/internal/config"
+	"kb-platform-gateway/internal/gateway"
+	"kb-platform-gateway/internal/models"
+	repomocks "kb-platform-gateway/internal/repository/mocks"
+	"kb-platform-gateway/internal/requestid"
+	"kb-platform-gateway/internal/services"
+	"kb-platform-gateway/internal/services/mocks"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPage(t *testing.T) {
+	cases := []struct {
+		limit, offset         int
+		wantLimit, wantOffset int
+	}{
+		{0, 0, gateway.DefaultPageSize, 0},
+		{10, 20, 10, 20},
+		{101, -1, gateway.DefaultPageSize, 0},
+		{100, 5, 100, 5},
+	}
+
+	for _, tc := range cases {
+		limit, offset := gateway.Page(tc.limit, tc.offset)
+		assert.Equal(t, tc.wantLimit, limit)
+		assert.Equal(t, tc.wantOffset, offset)
+	}
+}
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("GetDocument_NotFound", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(nil, nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		_, err := svc.GetDocument(ctx, "doc-1")
+
+		assert.Equal(t, gateway.KindNotFound, gateway.KindOf(err))
+		assert.Equal(t, "Document not found", gateway.MessageOf(err))
+	})
+
+	t.Run("GetDocument_SharedReads", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", mock.Anything, "doc-1").Return(&models.Document{ID: "doc-1", Status: "complete"}, nil).Once()
+		svc := &gateway.Service{
+			Repository: repo,
+			Reads:      services.NewReadCache(&config.ReadCacheConfig{TTL: time.Minute, Size: 10}),
+			Logger:     zerolog.Nop(),
+		}
+
+		for range 3 {
+			doc, err := svc.GetDocument(ctx, "doc-1")
+			require.NoError(t, err)
+			assert.Equal(t, "complete", doc.Status)
+		}
+
+		repo.AssertNumberOfCalls(t, "GetDocument", 1)
+	})
+
+	t.Run("ReindexDocument_BypassesSharedReads", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", mock.Anything, "doc-1").Return(&models.Document{ID: "doc-1", Filename: "guide.pdf", Status: "complete"}, nil).Once()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", Filename: "guide.pdf", Status: "indexing"}, nil).Once()
+		svc := &gateway.Service{
+			Repository: repo,
+			Reads:      services.NewReadCache(&config.ReadCacheConfig{TTL: time.Minute, Size: 10}),
+			Logger:     zerolog.Nop(),
+		}
+		_, err := svc.GetDocument(ctx, "doc-1")
+		require.NoError(t, err)
+
+		_, err = svc.ReindexDocument(ctx, "doc-1", nil)
+
+		assert.Equal(t, gateway.KindConflict, gateway.KindOf(err))
+		repo.AssertNumberOfCalls(t, "GetDocument", 2)
+	})
+
+	t.Run("GetConversation_SharedReads", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetConversation", mock.Anything, "conv-1").Return(nil, nil).Twice()
+		svc := &gateway.Service{
+			Repository: repo,
+			Reads:      services.NewReadCache(&config.ReadCacheConfig{TTL: time.Minute, Size: 10}),
+			Logger:     zerolog.Nop(),
+		}
+
+		for range 2 {
+			_, err := svc.GetConversation(ctx, "conv-1")
+			assert.Equal(t, gateway.KindNotFound, gateway.KindOf(err))
+		}
+
+		// Conversations that are not found are not kept.
+		repo.AssertNumberOfCalls(t, "GetConversation", 2)
+	})
+
+	t.Run("ListDocuments_Language", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("ListDocuments", ctx, 50, 0, models.DocumentFilter{Status: "complete", Language: "fr"}).Return([]*models.Document{{ID: "doc-1", Language: "fr"}}, 1, nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		documents, total, err := svc.ListDocuments(ctx, 50, 0, models.DocumentFilter{Status: "complete", Language: " FR "})
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, total)
+		assert.Equal(t, "fr", documents[0].Language)
+	})
+
+	t.Run("ListDocuments_InvalidLanguage", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		_, _, err := svc.ListDocuments(ctx, 50, 0, models.DocumentFilter{Language: "fr_FR"})
+
+		assert.Equal(t, gateway.KindInvalid, gateway.KindOf(err))
+		repo.AssertNotCalled(t, "ListDocuments", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("ListDocuments_Filter", func(t *testing.T) {
+		filter := models.DocumentFilter{Metadata: map[string]string{"team": "finance"}, Query: "report"}
+		repo := repomocks.NewMockRepository()
+		repo.On("ListDocuments", ctx, 50, 0, filter).Return([]*models.Document{{ID: "doc-1"}}, 1, nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		_, total, err := svc.ListDocuments(ctx, 50, 0, models.DocumentFilter{Metadata: map[string]string{"team": "finance"}, Query: " report "})
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, total)
+	})
+
+	t.Run("ListDocuments_Sort", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("ListDocuments", ctx, 20, 40, models.DocumentFilter{SortBy: models.DocumentSortFilename, Order: models.SortDesc}).Return([]*models.Document{{ID: "doc-1"}}, 41, nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		_, total, err := svc.ListDocuments(ctx, 20, 40, models.DocumentFilter{SortBy: " Filename ", Order: "DESC"})
+
+		require.NoError(t, err)
+		assert.Equal(t, 41, total)
+	})
+
+	t.Run("ListDocuments_InvalidSort", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		for _, filter := range []models.DocumentFilter{{SortBy: "s3_key"}, {Order: "up"}} {
+			_, _, err := svc.ListDocuments(ctx, 50, 0, filter)
+
+			assert.Equal(t, gateway.KindInvalid, gateway.KindOf(err))
+		}
+		repo.AssertNotCalled(t, "ListDocuments", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("SearchDocuments_Filter", func(t *testing.T) {
+		from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+		to := from.AddDate(0, 1, 0)
+		filter := models.DocumentFilter{Text: "refund policy", Filename: "*.pdf", CreatedFrom: &from, CreatedTo: &to}
+		repo := repomocks.NewMockRepository()
+		repo.On("ListDocuments", ctx, 50, 0, filter).Return([]*models.Document{{ID: "doc-1"}}, 1, nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		_, total, err := svc.SearchDocuments(ctx, 50, 0, models.DocumentFilter{Text: " refund policy ", Filename: "*.pdf ", CreatedFrom: &from, CreatedTo: &to})
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, total)
+	})
+
+	t.Run("SearchDocuments_Invalid", func(t *testing.T) {
+		from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+		repo := repomocks.NewMockRepository()
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		for _, filter := range []models.DocumentFilter{
+			{Status: "complete"},
+			{Filename: "report", SortBy: models.DocumentSortRelevance},
+			{IndexedFrom: &from, IndexedTo: &from},
+		} {
+			_, _, err := svc.SearchDocuments(ctx, 50, 0, filter)
+
+			assert.Equal(t, gateway.KindInvalid, gateway.KindOf(err))
+		}
+		repo.AssertNotCalled(t, "ListDocuments", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("CreateSavedSearch_Success", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("CreateSavedSearch", ctx, mock.MatchedBy(func(search *models.SavedSearch) bool {
+			return search.Name == "French contracts" && search.Filter.Language == "fr" && search.CreatedBy == "alice"
+		})).Return(nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		search, err := svc.CreateSavedSearch(ctx, models.SavedSearchRequest{
+			Name:   " French contracts ",
+			Filter: models.DocumentFilter{Language: "FR"},
+		}, "alice")
+
+		require.NoError(t, err)
+		assert.NotEmpty(t, search.ID)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("CreateSavedSearch_InvalidLanguage", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		_, err := svc.CreateSavedSearch(ctx, models.SavedSearchRequest{
+			Name:   "French contracts",
+			Filter: models.DocumentFilter{Language: "fr_FR"},
+		}, "alice")
+
+		assert.Equal(t, gateway.KindInvalid, gateway.KindOf(err))
+		repo.AssertNotCalled(t, "CreateSavedSearch", mock.Anything, mock.Anything)
+	})
+
+	t.Run("UpdateSavedSearch_NotCreator", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetSavedSearch", ctx, "search-1").Return(&models.SavedSearch{ID: "search-1", Name: "Mine", CreatedBy: "alice"}, nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		_, err := svc.UpdateSavedSearch(ctx, "search-1", models.SavedSearchRequest{Name: "Ours"}, "bob")
+
+		assert.Equal(t, gateway.KindForbidden, gateway.KindOf(err))
+		repo.AssertNotCalled(t, "UpdateSavedSearch", mock.Anything, mock.Anything)
+	})
+
+	t.Run("DeleteSavedSearch_NotFound", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetSavedSearch", ctx, "missing").Return(nil, nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		err := svc.DeleteSavedSearch(ctx, "missing", "alice")
+
+		assert.Equal(t, gateway.KindNotFound, gateway.KindOf(err))
+	})
+
+	t.Run("RunSavedSearch_ListsMatchingDocuments", func(t *testing.T) {
+		filter := models.DocumentFilter{Status: "complete", Metadata: map[string]string{"team": "finance"}}
+		repo := repomocks.NewMockRepository()
+		repo.On("GetSavedSearch", ctx, "search-1").Return(&models.SavedSearch{ID: "search-1", Filter: filter, CreatedBy: "alice"}, nil)
+		repo.On("ListDocuments", ctx, 20, 0, filter).Return([]*models.Document{{ID: "doc-1"}}, 1, nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		documents, total, err := svc.RunSavedSearch(ctx, "search-1", 20, 0)
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, total)
+		assert.Equal(t, "doc-1", documents[0].ID)
+	})
+
+	t.Run("CompleteUpload_Success", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", S3Key: "documents/doc-1/a.pdf", Status: "pending"}, nil)
+		repo.On("SetDocumentIndexing", ctx, "doc-1", "upload-doc-1").Return(nil)
+		s3 := mocks.NewMockS3Client()
+		s3.On("ObjectExists", ctx, "documents/doc-1/a.pdf").Return(true, nil)
+		temporal := mocks.NewMockTemporalClient()
+		temporal.On("SignalUploadComplete", ctx, "doc-1", "documents/doc-1/a.pdf", (*models.ProcessingOptions)(nil)).Return(nil)
+		svc := &gateway.Service{Repository: repo, S3Client: s3, Temporal: temporal, Logger: zerolog.Nop()}
+
+		doc, err := svc.CompleteUpload(ctx, "doc-1", nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, "indexing", doc.Status)
+		assert.Equal(t, "upload-doc-1", doc.WorkflowID)
+		repo.AssertExpectations(t)
+		temporal.AssertNotCalled(t, "StartIndexWorkflow", mock.Anything, mock.Anything)
+	})
+
+	t.Run("CompleteUpload_UploadWorkflowGone", func(t *testing.T) {
+		chunking := &models.ChunkingOptions{Strategy: models.ChunkingSentence, Size: 256}
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", S3Key: "documents/doc-1/a.pdf", Status: "pending", Chunking: chunking}, nil)
+		repo.On("SetDocumentIndexing", ctx, "doc-1", "index-doc-1").Return(nil)
+		s3 := mocks.NewMockS3Client()
+		s3.On("ObjectExists", ctx, "documents/doc-1/a.pdf").Return(true, nil)
+		temporal := mocks.NewMockTemporalClient()
+		temporal.On("SignalUploadComplete", ctx, "doc-1", "documents/doc-1/a.pdf", (*models.ProcessingOptions)(nil)).Return(fmt.Errorf("%w: upload-doc-1", services.ErrWorkflowNotFound))
+		temporal.On("StartIndexWorkflow", ctx, services.IndexWorkflowInput{DocumentID: "doc-1", Chunking: chunking}).Return("index-doc-1", nil)
+		svc := &gateway.Service{Repository: repo, S3Client: s3, Temporal: temporal, Logger: zerolog.Nop()}
+
+		doc, err := svc.CompleteUpload(ctx, "doc-1", nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, "index-doc-1", doc.WorkflowID)
+		repo.AssertExpectations(t)
+		temporal.AssertExpectations(t)
+	})
+
+	t.Run("CompleteUpload_FileMissing", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", S3Key: "documents/doc-1/a.pdf", Status: "pending"}, nil)
+		s3 := mocks.NewMockS3Client()
+		s3.On("ObjectExists", ctx, "documents/doc-1/a.pdf").Return(false, nil)
+		temporal := mocks.NewMockTemporalClient()
+		svc := &gateway.Service{Repository: repo, S3Client: s3, Temporal: temporal, Logger: zerolog.Nop()}
+
+		_, err := svc.CompleteUpload(ctx, "doc-1", nil)
+
+		assert.Equal(t, gateway.KindInvalid, gateway.KindOf(err))
+		assert.Equal(t, "The file has not been uploaded", gateway.MessageOf(err))
+		temporal.AssertNotCalled(t, "SignalUploadComplete", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		repo.AssertNotCalled(t, "SetDocumentIndexing", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("CompleteUpload_NotPending", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", S3Key: "documents/doc-1/a.pdf", Status: "indexing"}, nil)
+		s3 := mocks.NewMockS3Client()
+		temporal := mocks.NewMockTemporalClient()
+		svc := &gateway.Service{Repository: repo, S3Client: s3, Temporal: temporal, Logger: zerolog.Nop()}
+
+		_, err := svc.CompleteUpload(ctx, "doc-1", nil)
+
+		assert.Equal(t, gateway.KindConflict, gateway.KindOf(err))
+		s3.AssertNotCalled(t, "ObjectExists", mock.Anything, mock.Anything)
+		temporal.AssertNotCalled(t, "SignalUploadComplete", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("CompleteUpload_Error", func(t *testing.T) {
+		expiresAt := time.Now().Add(10 * time.Minute)
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", Status: "pending", UploadURLExpiresAt: &expiresAt}, nil)
+		s3 := mocks.NewMockS3Client()
+		s3.On("ObjectExists", ctx, mock.Anything).Return(true, nil)
+		temporal := mocks.NewMockTemporalClient()
+		temporal.On("SignalUploadComplete", ctx, "doc-1", mock.Anything, (*models.ProcessingOptions)(nil)).Return(errors.New("connection refused"))
+		svc := &gateway.Service{Repository: repo, S3Client: s3, Temporal: temporal, Logger: zerolog.Nop()}
+
+		_, err := svc.CompleteUpload(ctx, "doc-1", nil)
+
+		assert.Equal(t, gateway.KindInternal, gateway.KindOf(err))
+		assert.Equal(t, "Failed to signal upload complete", gateway.MessageOf(err))
+		assert.ErrorContains(t, err, "connection refused")
+		repo.AssertNotCalled(t, "SetDocumentIndexing", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("CompleteUpload_WithinGrace", func(t *testing.T) {
+		expiresAt := time.Now().Add(-5 * time.Minute)
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", Status: "pending", UploadURLExpiresAt: &expiresAt}, nil)
+		repo.On("SetDocumentIndexing", ctx, "doc-1", "upload-doc-1").Return(nil)
+		s3 := mocks.NewMockS3Client()
+		s3.On("ObjectExists", ctx, mock.Anything).Return(true, nil)
+		temporal := mocks.NewMockTemporalClient()
+		temporal.On("SignalUploadComplete", ctx, "doc-1", mock.Anything, (*models.ProcessingOptions)(nil)).Return(nil)
+		svc := &gateway.Service{Repository: repo, S3Client: s3, Temporal: temporal, Logger: zerolog.Nop()}
+
+		doc, err := svc.CompleteUpload(ctx, "doc-1", nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, "indexing", doc.Status)
+	})
+
+	t.Run("CompleteUpload_Processing", func(t *testing.T) {
+		ocr := true
+		stored := &models.ProcessingOptions{OCR: &ocr, Languages: []string{"de"}}
+		processing := &models.ProcessingOptions{OCR: &ocr, Languages: []string{"DE"}}
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", Status: "pending"}, nil)
+		repo.On("SetDocumentProcessing", ctx, "doc-1", stored).Return(nil)
+		repo.On("SetDocumentIndexing", ctx, "doc-1", "upload-doc-1").Return(nil)
+		s3 := mocks.NewMockS3Client()
+		s3.On("ObjectExists", ctx, mock.Anything).Return(true, nil)
+		temporal := mocks.NewMockTemporalClient()
+		temporal.On("SignalUploadComplete", ctx, "doc-1", mock.Anything, stored).Return(nil)
+		svc := &gateway.Service{Repository: repo, S3Client: s3, Temporal: temporal, Logger: zerolog.Nop()}
+
+		doc, err := svc.CompleteUpload(ctx, "doc-1", processing)
+
+		require.NoError(t, err)
+		assert.Equal(t, stored, doc.Processing)
+		repo.AssertExpectations(t)
+		temporal.AssertExpectations(t)
+	})
+
+	t.Run("CompleteUpload_KeepsProcessing", func(t *testing.T) {
+		extract := false
+		processing := &models.ProcessingOptions{ExtractTables: &extract}
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", Status: "pending", Processing: processing}, nil)
+		repo.On("SetDocumentIndexing", ctx, "doc-1", "upload-doc-1").Return(nil)
+		s3 := mocks.NewMockS3Client()
+		s3.On("ObjectExists", ctx, mock.Anything).Return(true, nil)
+		temporal := mocks.NewMockTemporalClient()
+		temporal.On("SignalUploadComplete", ctx, "doc-1", mock.Anything, processing).Return(nil)
+		svc := &gateway.Service{Repository: repo, S3Client: s3, Temporal: temporal, Logger: zerolog.Nop()}
+
+		_, err := svc.CompleteUpload(ctx, "doc-1", nil)
+
+		require.NoError(t, err)
+		repo.AssertNotCalled(t, "SetDocumentProcessing", mock.Anything, mock.Anything, mock.Anything)
+		temporal.AssertExpectations(t)
+	})
+
+	t.Run("CompleteUpload_Expired", func(t *testing.T) {
+		expiresAt := time.Now().Add(-time.Hour)
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", Status: "pending", UploadURLExpiresAt: &expiresAt}, nil)
+		temporal := mocks.NewMockTemporalClient()
+		svc := &gateway.Service{Repository: repo, Temporal: temporal, Logger: zerolog.Nop()}
+
+		_, err := svc.CompleteUpload(ctx, "doc-1", nil)
+
+		assert.Equal(t, gateway.KindUploadExpired, gateway.KindOf(err))
+		temporal.AssertNotCalled(t, "SignalUploadComplete", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("CompleteUpload_NotFound", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(nil, nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		_, err := svc.CompleteUpload(ctx, "doc-1", nil)
+
+		assert.Equal(t, gateway.KindNotFound, gateway.KindOf(err))
+	})
+
+	t.Run("CompleteUpload_ScannedClean", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", S3Key: "documents/doc-1/a.pdf", Status: "pending"}, nil)
+		repo.On("SetDocumentScan", ctx, "doc-1", "scanned/documents/doc-1/a.pdf", mock.MatchedBy(func(scan *models.DocumentScan) bool {
+			return scan.Result == models.ScanClean && !scan.ScannedAt.IsZero()
+		})).Return(nil)
+		repo.On("CreateDocumentEvent", mock.Anything, mock.Anything).Return(nil)
+		repo.On("SetDocumentIndexing", ctx, "doc-1", "upload-doc-1").Return(nil)
+		s3 := mocks.NewMockS3Client()
+		s3.On("ObjectExists", ctx, "documents/doc-1/a.pdf").Return(true, nil)
+		s3.On("CopyObject", ctx, "documents/doc-1/a.pdf", "scanned/documents/doc-1/a.pdf").Return(nil)
+		s3.On("GetObject", ctx, "scanned/documents/doc-1/a.pdf").Return(io.NopCloser(strings.NewReader("%PDF-1.7")), nil)
+		s3.On("DeleteObject", ctx, "documents/doc-1/a.pdf").Return(nil)
+		scanner := mocks.NewMockScanner()
+		scanner.On("Scan", ctx).Return(&services.ScanResult{}, nil)
+		temporal := mocks.NewMockTemporalClient()
+		temporal.On("SignalUploadComplete", ctx, "doc-1", "scanned/documents/doc-1/a.pdf", (*models.ProcessingOptions)(nil)).Return(nil)
+		svc := &gateway.Service{Repository: repo, S3Client: s3, Temporal: temporal, Scanner: scanner, Logger: zerolog.Nop()}
+
+		doc, err := svc.CompleteUpload(ctx, "doc-1", nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, "indexing", doc.Status)
+		require.NotNil(t, doc.Scan)
+		assert.Equal(t, models.ScanClean, doc.Scan.Result)
+		repo.AssertExpectations(t)
+		// The file scanned is the one indexed; the uploaded one, which its
+		// upload URL can still overwrite, is deleted.
+		s3.AssertExpectations(t)
+		temporal.AssertExpectations(t)
+	})
+
+	t.Run("CompleteUpload_ScansLegacyKey", func(t *testing.T) {
+		// Uploaded before the key prefix was set, the file's scanned copy
+		// stays beside it.
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", S3Key: "documents/doc-1/a.pdf", Status: "pending"}, nil)
+		repo.On("SetDocumentScan", ctx, "doc-1", "scanned/documents/doc-1/a.pdf", mock.Anything).Return(nil)
+		repo.On("CreateDocumentEvent", mock.Anything, mock.Anything).Return(nil)
+		repo.On("SetDocumentIndexing", ctx, "doc-1", "upload-doc-1").Return(nil)
+		s3 := &mocks.MockS3Client{KeyPrefix: "ws-1/"}
+		s3.On("ObjectExists", ctx, "documents/doc-1/a.pdf").Return(true, nil)
+		s3.On("CopyObject", ctx, "documents/doc-1/a.pdf", "scanned/documents/doc-1/a.pdf").Return(nil)
+		s3.On("GetObject", ctx, "scanned/documents/doc-1/a.pdf").Return(io.NopCloser(strings.NewReader("%PDF-1.7")), nil)
+		s3.On("DeleteObject", ctx, "documents/doc-1/a.pdf").Return(nil)
+		scanner := mocks.NewMockScanner()
+		scanner.On("Scan", ctx).Return(&services.ScanResult{}, nil)
+		temporal := mocks.NewMockTemporalClient()
+		temporal.On("SignalUploadComplete", ctx, "doc-1", "scanned/documents/doc-1/a.pdf", (*models.ProcessingOptions)(nil)).Return(nil)
+		svc := &gateway.Service{Repository: repo, S3Client: s3, Temporal: temporal, Scanner: scanner, Logger: zerolog.Nop()}
+
+		_, err := svc.CompleteUpload(ctx, "doc-1", nil)
+
+		require.NoError(t, err)
+		s3.AssertExpectations(t)
+	})
+
+	t.Run("CompleteUpload_RescansRetriedUpload", func(t *testing.T) {
+		// A scanned document whose completion failed is completed again
+		// from the file at its upload URL, which may have changed.
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{
+			ID: "doc-1", S3Key: "ws-1/scanned/documents/doc-1/a.pdf", Status: "pending",
+			Scan: &models.DocumentScan{Result: models.ScanClean},
+		}, nil)
+		repo.On("SetDocumentScan", ctx, "doc-1", "ws-1/scanned/documents/doc-1/a.pdf", mock.Anything).Return(nil)
+		repo.On("CreateDocumentEvent", mock.Anything, mock.Anything).Return(nil)
+		repo.On("SetDocumentIndexing", ctx, "doc-1", "upload-doc-1").Return(nil)
+		s3 := &mocks.MockS3Client{KeyPrefix: "ws-1/"}
+		s3.On("ObjectExists", ctx, "ws-1/documents/doc-1/a.pdf").Return(true, nil)
+		s3.On("CopyObject", ctx, "ws-1/documents/doc-1/a.pdf", "ws-1/scanned/documents/doc-1/a.pdf").Return(nil)
+		s3.On("GetObject", ctx, "ws-1/scanned/documents/doc-1/a.pdf").Return(io.NopCloser(strings.NewReader("%PDF-1.7")), nil)
+		s3.On("DeleteObject", ctx, "ws-1/documents/doc-1/a.pdf").Return(nil)
+		scanner := mocks.NewMockScanner()
+		scanner.On("Scan", ctx).Return(&services.ScanResult{}, nil)
+		temporal := mocks.NewMockTemporalClient()
+		temporal.On("SignalUploadComplete", ctx, "doc-1", "ws-1/scanned/documents/doc-1/a.pdf", (*models.ProcessingOptions)(nil)).Return(nil)
+		svc := &gateway.Service{Repository: repo, S3Client: s3, Temporal: temporal, Scanner: scanner, Logger: zerolog.Nop()}
+
+		_, err := svc.CompleteUpload(ctx, "doc-1", nil)
+
+		require.NoError(t, err)
+		scanner.AssertExpectations(t)
+		s3.AssertExpectations(t)
+	})
+
+	t.Run("CompleteUpload_Infected", func(t *testing.T) {
+		tests := []struct {
+			name       string
+			quarantine bool
+			key        string
+		}{
+			{"quarantined", true, "ws-1/quarantine/documents/doc-1/a.pdf"},
+			{"deleted", false, ""},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				repo := repomocks.NewMockRepository()
+				repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", S3Key: "ws-1/documents/doc-1/a.pdf", Filename: "a.pdf", Status: "pending", UploadedBy: "alice"}, nil)
+				repo.On("SetDocumentScan", ctx, "doc-1", tt.key, mock.MatchedBy(func(scan *models.DocumentScan) bool {
+					return scan.Result == models.ScanInfected && scan.Signature == "Eicar-Signature"
+				})).Return(nil)
+				repo.On("UpdateDocumentStatus", ctx, "doc-1", "rejected", "Malware detected: Eicar-Signature").Return(nil)
+				repo.On("CreateDocumentEvent", mock.Anything, mock.MatchedBy(func(event *models.DocumentEvent) bool {
+					return event.Type == models.DocumentEventMalwareScanned && event.Data["signature"] == "Eicar-Signature"
+				})).Return(nil)
+				s3 := &mocks.MockS3Client{KeyPrefix: "ws-1/"}
+				s3.On("ObjectExists", ctx, "ws-1/documents/doc-1/a.pdf").Return(true, nil)
+				s3.On("CopyObject", ctx, "ws-1/documents/doc-1/a.pdf", "ws-1/scanned/documents/doc-1/a.pdf").Return(nil)
+				s3.On("GetObject", ctx, "ws-1/scanned/documents/doc-1/a.pdf").Return(io.NopCloser(strings.NewReader("X5O!P%@AP")), nil)
+				if tt.quarantine {
+					s3.On("CopyObject", ctx, "ws-1/scanned/documents/doc-1/a.pdf", tt.key).Return(nil)
+				}
+				s3.On("DeleteObject", ctx, "ws-1/scanned/documents/doc-1/a.pdf").Return(nil)
+				s3.On("DeleteObject", ctx, "ws-1/documents/doc-1/a.pdf").Return(nil)
+				scanner := mocks.NewMockScanner()
+				scanner.On("Scan", ctx).Return(&services.ScanResult{Infected: true, Signature: "Eicar-Signature"}, nil)
+				temporal := mocks.NewMockTemporalClient()
+				temporal.On("CancelWorkflow", ctx, "upload-doc-1").Return(nil)
+				webhooks := mocks.NewMockWebhookDispatcher()
+				webhooks.On("Dispatch", ctx, models.EventDocumentInfected, map[string]string{
+					"document_id": "doc-1", "filename": "a.pdf", "uploaded_by": "alice", "signature": "Eicar-Signature",
+				}).Return()
+				svc := &gateway.Service{
+					Repository: repo,
+					S3Client:   s3,
+					Temporal:   temporal,
+					Webhooks:   webhooks,
+					Scanner:    scanner,
+					Quarantine: tt.quarantine,
+					Logger:     zerolog.Nop(),
+				}
+
+				_, err := svc.CompleteUpload(ctx, "doc-1", nil)
+
+				assert.Equal(t, gateway.KindInfected, gateway.KindOf(err))
+				assert.Equal(t, map[string]string{"document_id": "doc-1", "signature": "Eicar-Signature"}, gateway.DetailsOf(err))
+				temporal.AssertNotCalled(t, "SignalUploadComplete", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+				repo.AssertNotCalled(t, "SetDocumentIndexing", mock.Anything, mock.Anything, mock.Anything)
+				repo.AssertExpectations(t)
+				s3.AssertExpectations(t)
+				webhooks.AssertExpectations(t)
+			})
+		}
+	})
+
+	t.Run("CompleteUpload_ScanFailed", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", S3Key: "documents/doc-1/a.pdf", Status: "pending"}, nil)
+		s3 := mocks.NewMockS3Client()
+		s3.On("ObjectExists", ctx, "documents/doc-1/a.pdf").Return(true, nil)
+		s3.On("CopyObject", ctx, "documents/doc-1/a.pdf", "scanned/documents/doc-1/a.pdf").Return(nil)
+		s3.On("GetObject", ctx, "scanned/documents/doc-1/a.pdf").Return(io.NopCloser(strings.NewReader("%PDF-1.7")), nil)
+		scanner := mocks.NewMockScanner()
+		scanner.On("Scan", ctx).Return(nil, errors.New("failed to connect to clamd"))
+		temporal := mocks.NewMockTemporalClient()
+		svc := &gateway.Service{Repository: repo, S3Client: s3, Temporal: temporal, Scanner: scanner, Logger: zerolog.Nop()}
+
+		_, err := svc.CompleteUpload(ctx, "doc-1", nil)
+
+		assert.Equal(t, gateway.KindInternal, gateway.KindOf(err))
+		assert.Equal(t, "Failed to scan uploaded file", gateway.MessageOf(err))
+		temporal.AssertNotCalled(t, "SignalUploadComplete", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		repo.AssertNotCalled(t, "SetDocumentScan", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("CancelIndexing_Success", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", Filename: "a.pdf", Status: "indexing", WorkflowID: "index-doc-1"}, nil)
+		repo.On("UpdateDocumentStatus", ctx, "doc-1", "cancelled", "").Return(nil)
+		repo.On("CreateDocumentEvent", mock.Anything, mock.MatchedBy(func(event *models.DocumentEvent) bool {
+			return event.Type == models.DocumentEventCancelled && event.Data["workflow_id"] == "index-doc-1"
+		})).Return(nil)
+		temporal := mocks.NewMockTemporalClient()
+		temporal.On("CancelWorkflow", ctx, "index-doc-1").Return(nil)
+		qdrant := mocks.NewMockQdrantClient()
+		qdrant.On("Collection").Return("documents")
+		qdrant.On("DeleteDocumentVectors", ctx, "documents", "doc-1").Return(nil)
+		svc := &gateway.Service{Repository: repo, Temporal: temporal, QdrantClient: qdrant, Logger: zerolog.Nop()}
+
+		doc, err := svc.CancelIndexing(ctx, "doc-1", true)
+
+		require.NoError(t, err)
+		assert.Equal(t, "cancelled", doc.Status)
+		repo.AssertExpectations(t)
+		qdrant.AssertExpectations(t)
+	})
+
+	t.Run("CancelIndexing_KeepsVectors", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", Filename: "a.pdf", Status: "indexing"}, nil)
+		repo.On("UpdateDocumentStatus", ctx, "doc-1", "cancelled", "").Return(nil)
+		repo.On("CreateDocumentEvent", mock.Anything, mock.Anything).Return(nil)
+		temporal := mocks.NewMockTemporalClient()
+		// Documents without a recorded workflow fall back to the upload
+		// workflow.
+		temporal.On("CancelWorkflow", ctx, "upload-doc-1").Return(nil)
+		qdrant := mocks.NewMockQdrantClient()
+		svc := &gateway.Service{Repository: repo, Temporal: temporal, QdrantClient: qdrant, Logger: zerolog.Nop()}
+
+		_, err := svc.CancelIndexing(ctx, "doc-1", false)
+
+		require.NoError(t, err)
+		temporal.AssertExpectations(t)
+		qdrant.AssertNotCalled(t, "DeleteDocumentVectors", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("CancelIndexing_NotIndexing", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", Filename: "a.pdf", Status: "complete"}, nil)
+		temporal := mocks.NewMockTemporalClient()
+		svc := &gateway.Service{Repository: repo, Temporal: temporal, Logger: zerolog.Nop()}
+
+		_, err := svc.CancelIndexing(ctx, "doc-1", false)
+
+		assert.Equal(t, gateway.KindConflict, gateway.KindOf(err))
+		temporal.AssertNotCalled(t, "CancelWorkflow", mock.Anything, mock.Anything)
+	})
+
+	t.Run("CancelIndexing_AlreadyFinished", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", Filename: "a.pdf", Status: "indexing", WorkflowID: "index-doc-1"}, nil)
+		temporal := mocks.NewMockTemporalClient()
+		temporal.On("CancelWorkflow", ctx, "index-doc-1").Return(fmt.Errorf("%w: index-doc-1", services.ErrWorkflowNotFound))
+		svc := &gateway.Service{Repository: repo, Temporal: temporal, Logger: zerolog.Nop()}
+
+		_, err := svc.CancelIndexing(ctx, "doc-1", false)
+
+		assert.Equal(t, gateway.KindConflict, gateway.KindOf(err))
+		assert.Equal(t, "Indexing has already finished", gateway.MessageOf(err))
+		repo.AssertNotCalled(t, "UpdateDocumentStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("RefreshUploadURL_Success", func(t *testing.T) {
+		expiresAt := time.Now().Add(-time.Hour)
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{
+			ID: "doc-1", S3Key: "documents/doc-1/a.pdf", Status: "pending", UploadURLExpiresAt: &expiresAt,
+		}, nil)
+		repo.On("SetDocumentUploadURL", ctx, "doc-1", mock.Anything, mock.MatchedBy(func(expiresAt time.Time) bool {
+			return expiresAt.After(time.Now())
+		})).Return(nil)
+		s3 := mocks.NewMockS3Client()
+		s3.On("GeneratePresignedUploadURL", ctx, "documents/doc-1/a.pdf", mock.Anything, mock.Anything).Return("https://s3/upload", nil)
+		svc := &gateway.Service{Repository: repo, S3Client: s3, Logger: zerolog.Nop()}
+
+		doc, err := svc.RefreshUploadURL(ctx, "doc-1")
+
+		require.NoError(t, err)
+		assert.Equal(t, "https://s3/upload", doc.UploadURL)
+		require.NotNil(t, doc.UploadURLExpiresAt)
+		assert.True(t, doc.UploadURLExpiresAt.After(time.Now()))
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("RefreshUploadURL_NotPending", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", S3Key: "documents/doc-1/a.pdf", Status: "complete"}, nil)
+		s3 := mocks.NewMockS3Client()
+		svc := &gateway.Service{Repository: repo, S3Client: s3, Logger: zerolog.Nop()}
+
+		_, err := svc.RefreshUploadURL(ctx, "doc-1")
+
+		assert.Equal(t, gateway.KindInvalid, gateway.KindOf(err))
+		s3.AssertNotCalled(t, "GeneratePresignedUploadURL", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("UpdateDocument_Success", func(t *testing.T) {
+		metadata := map[string]string{"team": "finance"}
+		repo := repomocks.NewMockRepository()
+		repo.On("GetWorkspaceSettings", mock.Anything).Return(nil, nil)
+		repo.On("UpdateDocumentDetails", ctx, "doc-1", metadata, "", 3).Return(true, nil)
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", Filename: "a.pdf", Metadata: metadata, Version: 4}, nil)
+		repo.On("CreateDocumentEvent", mock.Anything, mock.MatchedBy(func(event *models.DocumentEvent) bool {
+			return event.DocumentID == "doc-1" && event.Type == models.DocumentEventMetadataUpdated
+		})).Return(nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		doc, err := svc.UpdateDocument(ctx, "doc-1", models.UpdateDocumentRequest{Metadata: metadata}, 3)
+
+		require.NoError(t, err)
+		assert.Equal(t, 4, doc.Version)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("UpdateDocument_StaleVersion", func(t *testing.T) {
+		metadata := map[string]string{"team": "finance"}
+		repo := repomocks.NewMockRepository()
+		repo.On("GetWorkspaceSettings", mock.Anything).Return(nil, nil)
+		repo.On("UpdateDocumentDetails", ctx, "doc-1", metadata, "", 3).Return(false, nil)
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", Filename: "a.pdf", Version: 5}, nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		_, err := svc.UpdateDocument(ctx, "doc-1", models.UpdateDocumentRequest{Metadata: metadata}, 3)
+
+		assert.Equal(t, gateway.KindConflict, gateway.KindOf(err))
+		assert.Contains(t, gateway.MessageOf(err), "version 5")
+		repo.AssertNotCalled(t, "CreateDocumentEvent", mock.Anything, mock.Anything)
+	})
+
+	t.Run("UpdateDocument_NotFound", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetWorkspaceSettings", mock.Anything).Return(nil, nil)
+		repo.On("UpdateDocumentDetails", ctx, "missing", mock.Anything, "", 1).Return(false, nil)
+		repo.On("GetDocument", ctx, "missing").Return(nil, nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		_, err := svc.UpdateDocument(ctx, "missing", models.UpdateDocumentRequest{Metadata: map[string]string{}}, 1)
+
+		assert.Equal(t, gateway.KindNotFound, gateway.KindOf(err))
+	})
+
+	t.Run("UpdateDocument_Rename", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", Filename: "a.pdf", Version: 2}, nil).Once()
+		repo.On("UpdateDocumentDetails", ctx, "doc-1", map[string]string(nil), "Q3 report.PDF", 2).Return(true, nil)
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", Filename: "Q3 report.PDF", Version: 3}, nil)
+		repo.On("CreateDocumentEvent", mock.Anything, mock.MatchedBy(func(event *models.DocumentEvent) bool {
+			return event.Type == models.DocumentEventMetadataUpdated && event.Data["filename"] == "Q3 report.PDF"
+		})).Return(nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		doc, err := svc.UpdateDocument(ctx, "doc-1", models.UpdateDocumentRequest{Filename: "  Q3 report.PDF "}, 2)
+
+		require.NoError(t, err)
+		assert.Equal(t, "Q3 report.PDF", doc.Filename)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("UpdateDocument_RenameRefused", func(t *testing.T) {
+		for _, filename := range []string{"a.docx", "a", "dir/a.pdf", " "} {
+			repo := repomocks.NewMockRepository()
+			repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", Filename: "a.pdf", Version: 2}, nil)
+			svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+			_, err := svc.UpdateDocument(ctx, "doc-1", models.UpdateDocumentRequest{Filename: filename}, 2)
+
+			assert.Equal(t, gateway.KindInvalid, gateway.KindOf(err), filename)
+			repo.AssertNotCalled(t, "UpdateDocumentDetails", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("UpdateDocument_NothingToUpdate", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		_, err := svc.UpdateDocument(ctx, "doc-1", models.UpdateDocumentRequest{}, 2)
+
+		assert.Equal(t, gateway.KindInvalid, gateway.KindOf(err))
+	})
+
+	t.Run("DeleteDocument_RecordsEvent", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", S3Key: "uploads/doc-1", Status: "complete"}, nil)
+		repo.On("TrashDocument", ctx, "doc-1", mock.AnythingOfType("time.Time")).Return(true, nil)
+		repo.On("DeleteDocument", ctx, "doc-1").Return(nil)
+		repo.On("CreateDocumentEvent", mock.Anything, mock.MatchedBy(func(event *models.DocumentEvent) bool {
+			return event.DocumentID == "doc-1" && event.Type == models.DocumentEventDeleted &&
+				event.Source == models.DocumentEventSourceGateway
+		})).Return(nil)
+		qdrant := mocks.NewMockQdrantClient()
+		qdrant.On("Collection").Return("documents")
+		qdrant.On("DeleteDocumentVectors", ctx, "documents", "doc-1").Return(nil)
+		s3 := mocks.NewMockS3Client()
+		s3.On("DeleteObject", ctx, "uploads/doc-1").Return(nil)
+		svc := &gateway.Service{Repository: repo, QdrantClient: qdrant, S3Client: s3, Logger: zerolog.Nop()}
+
+		require.NoError(t, svc.DeleteDocument(ctx, "doc-1"))
+
+		repo.AssertExpectations(t)
+		s3.AssertExpectations(t)
+	})
+
+	t.Run("DeleteDocument_FailureLeavesTombstone", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", S3Key: "uploads/doc-1", Status: "complete"}, nil)
+		repo.On("TrashDocument", ctx, "doc-1", mock.AnythingOfType("time.Time")).Return(true, nil)
+		qdrant := mocks.NewMockQdrantClient()
+		qdrant.On("Collection").Return("documents")
+		qdrant.On("DeleteDocumentVectors", ctx, "documents", "doc-1").Return(errors.New("qdrant down"))
+		s3 := mocks.NewMockS3Client()
+		s3.On("DeleteObject", ctx, "uploads/doc-1").Return(nil)
+		svc := &gateway.Service{Repository: repo, QdrantClient: qdrant, S3Client: s3, Logger: zerolog.Nop()}
+
+		require.NoError(t, svc.DeleteDocument(ctx, "doc-1"))
+
+		// The purge finishes the deletion from the tombstone.
+		repo.AssertExpectations(t)
+		repo.AssertNotCalled(t, "DeleteDocument", mock.Anything, mock.Anything)
+		repo.AssertNotCalled(t, "CreateDocumentEvent", mock.Anything, mock.Anything)
+	})
+
+	t.Run("DeleteDocument_AlreadyGone", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(nil, nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		require.NoError(t, svc.DeleteDocument(ctx, "doc-1"))
+
+		repo.AssertNotCalled(t, "TrashDocument", mock.Anything, mock.Anything, mock.Anything)
+		repo.AssertNotCalled(t, "DeleteDocument", mock.Anything, mock.Anything)
+	})
+
+	t.Run("DeleteDocument_MovesToTrash", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", S3Key: "uploads/doc-1", Status: "complete"}, nil)
+		repo.On("TrashDocument", ctx, "doc-1", mock.AnythingOfType("time.Time")).Return(true, nil)
+		repo.On("CreateDocumentEvent", mock.Anything, mock.MatchedBy(func(event *models.DocumentEvent) bool {
+			return event.DocumentID == "doc-1" && event.Type == models.DocumentEventTrashed
+		})).Return(nil)
+		qdrant := mocks.NewMockQdrantClient()
+		qdrant.On("Collection").Return("documents")
+		qdrant.On("DeleteDocumentVectors", ctx, "documents", "doc-1").Return(nil)
+		s3 := mocks.NewMockS3Client()
+		svc := &gateway.Service{Repository: repo, QdrantClient: qdrant, S3Client: s3, TrashRetention: 24 * time.Hour, Logger: zerolog.Nop()}
+
+		require.NoError(t, svc.DeleteDocument(ctx, "doc-1"))
+
+		repo.AssertExpectations(t)
+		repo.AssertNotCalled(t, "DeleteDocument", mock.Anything, mock.Anything)
+		s3.AssertNotCalled(t, "DeleteObject", mock.Anything, mock.Anything)
+	})
+
+	t.Run("DeleteDocument_TrashesIndexingEverywhere", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", Status: "indexing", WorkflowID: "index-doc-1"}, nil)
+		repo.On("GetRunningEmbeddingMigration", ctx).Return(&models.EmbeddingMigration{ID: "mig-1", TargetCollection: "documents_m_mig1"}, nil)
+		repo.On("TrashDocument", ctx, "doc-1", mock.AnythingOfType("time.Time")).Return(true, nil)
+		repo.On("CreateDocumentEvent", mock.Anything, mock.Anything).Return(nil)
+		qdrant := mocks.NewMockQdrantClient()
+		qdrant.On("DeleteDocumentVectors", ctx, "documents", "doc-1").Return(nil).Once()
+		qdrant.On("DeleteDocumentVectors", ctx, "documents_m_mig1", "doc-1").Return(nil).Once()
+		migrations := mocks.NewMockEmbeddingMigrator()
+		migrations.On("ActiveCollection").Return("documents")
+		temporal := mocks.NewMockTemporalClient()
+		temporal.On("CancelWorkflow", ctx, "index-doc-1").Return(nil)
+		svc := &gateway.Service{Repository: repo, QdrantClient: qdrant, Migrations: migrations, Temporal: temporal, TrashRetention: 24 * time.Hour, Logger: zerolog.Nop()}
+
+		require.NoError(t, svc.DeleteDocument(ctx, "doc-1"))
+
+		// The indexer would otherwise write the vectors again, and the
+		// migration would carry them into the next active collection.
+		temporal.AssertExpectations(t)
+		qdrant.AssertExpectations(t)
+	})
+
+	t.Run("DeleteDocument_CancelFails", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", Status: "indexing"}, nil)
+		temporal := mocks.NewMockTemporalClient()
+		temporal.On("CancelWorkflow", ctx, "upload-doc-1").Return(errors.New("temporal down"))
+		svc := &gateway.Service{Repository: repo, Temporal: temporal, Logger: zerolog.Nop()}
+
+		err := svc.DeleteDocument(ctx, "doc-1")
+
+		assert.Equal(t, gateway.KindInternal, gateway.KindOf(err))
+		repo.AssertNotCalled(t, "TrashDocument", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("DeleteDocument_AlreadyTrashed", func(t *testing.T) {
+		deletedAt := time.Now().Add(-time.Hour)
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", S3Key: "uploads/doc-1", DeletedAt: &deletedAt}, nil)
+		repo.On("DeleteDocument", ctx, "doc-1").Return(nil)
+		repo.On("CreateDocumentEvent", mock.Anything, mock.Anything).Return(nil)
+		qdrant := mocks.NewMockQdrantClient()
+		qdrant.On("Collection").Return("documents")
+		qdrant.On("DeleteDocumentVectors", ctx, "documents", "doc-1").Return(nil)
+		s3 := mocks.NewMockS3Client()
+		s3.On("DeleteObject", ctx, "uploads/doc-1").Return(nil)
+		svc := &gateway.Service{Repository: repo, QdrantClient: qdrant, S3Client: s3, TrashRetention: 24 * time.Hour, Logger: zerolog.Nop()}
+
+		require.NoError(t, svc.DeleteDocument(ctx, "doc-1"))
+
+		repo.AssertExpectations(t)
+		s3.AssertExpectations(t)
+	})
+
+	t.Run("RestoreDocument_Reindexes", func(t *testing.T) {
+		deletedAt := time.Now().Add(-time.Hour)
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", Filename: "a.pdf", Status: "complete", DeletedAt: &deletedAt}, nil)
+		repo.On("RestoreDocument", ctx, "doc-1").Return(true, nil)
+		repo.On("SetDocumentIndexing", ctx, "doc-1", "index-doc-1").Return(nil)
+		repo.On("CreateDocumentEvent", mock.Anything, mock.MatchedBy(func(event *models.DocumentEvent) bool {
+			return event.Type == models.DocumentEventRestored
+		})).Return(nil)
+		temporal := mocks.NewMockTemporalClient()
+		temporal.On("StartIndexWorkflow", ctx, services.IndexWorkflowInput{DocumentID: "doc-1"}).Return("index-doc-1", nil)
+		svc := &gateway.Service{Repository: repo, Temporal: temporal, TrashRetention: 24 * time.Hour, Logger: zerolog.Nop()}
+
+		doc, err := svc.RestoreDocument(ctx, "doc-1")
+
+		require.NoError(t, err)
+		assert.Nil(t, doc.DeletedAt)
+		assert.Equal(t, "indexing", doc.Status)
+		repo.AssertExpectations(t)
+		temporal.AssertExpectations(t)
+	})
+
+	t.Run("RestoreDocument_NotTrashed", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", Filename: "a.pdf"}, nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		_, err := svc.RestoreDocument(ctx, "doc-1")
+
+		assert.Equal(t, gateway.KindConflict, gateway.KindOf(err))
+		repo.AssertNotCalled(t, "RestoreDocument", mock.Anything, mock.Anything)
+	})
+
+	t.Run("RestoreDocument_DeletionUnderWay", func(t *testing.T) {
+		deletedAt := time.Now().Add(-time.Hour)
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", Filename: "a.pdf", DeletedAt: &deletedAt}, nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		_, err := svc.RestoreDocument(ctx, "doc-1")
+
+		assert.Equal(t, gateway.KindConflict, gateway.KindOf(err))
+		repo.AssertNotCalled(t, "RestoreDocument", mock.Anything, mock.Anything)
+	})
+
+	t.Run("PurgeTrash_ReclaimsStorage", func(t *testing.T) {
+		deletedAt := time.Now().Add(-48 * time.Hour)
+		repo := repomocks.NewMockRepository()
+		repo.On("GetWorkspaceSettings", mock.Anything).Return(nil, nil)
+		repo.On("ListExpiredTrash", ctx, mock.MatchedBy(func(before time.Time) bool {
+			return time.Since(before) >= 24*time.Hour
+		}), 100, 0).Return([]*models.Document{
+			{ID: "doc-1", S3Key: "uploads/doc-1", FileSize: 1000, DeletedAt: &deletedAt},
+			{ID: "doc-2", S3Key: "uploads/doc-2", FileSize: 500, DeletedAt: &deletedAt},
+		}, nil)
+		repo.On("DeleteDocument", ctx, "doc-1").Return(nil)
+		repo.On("CreateDocumentEvent", mock.Anything, mock.Anything).Return(nil)
+		repo.On("CreateTrashPurge", mock.Anything, mock.MatchedBy(func(purge *models.TrashPurge) bool {
+			return purge.Purged == 1 && purge.Failed == 1 && purge.ReclaimedBytes == 1000
+		})).Return(nil)
+		s3 := mocks.NewMockS3Client()
+		s3.On("DeleteObject", ctx, "uploads/doc-1").Return(nil)
+		s3.On("DeleteObject", ctx, "uploads/doc-2").Return(errors.New("access denied"))
+		qdrant := mocks.NewMockQdrantClient()
+		qdrant.On("Collection").Return("documents")
+		qdrant.On("DeleteDocumentVectors", ctx, "documents", "doc-1").Return(nil)
+		svc := &gateway.Service{Repository: repo, S3Client: s3, QdrantClient: qdrant, TrashRetention: 24 * time.Hour, Logger: zerolog.Nop()}
+
+		purge, err := svc.PurgeTrash(ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, purge.Purged)
+		assert.Equal(t, 1, purge.Failed)
+		assert.Equal(t, int64(1000), purge.ReclaimedBytes)
+		// A document that could not be purged stays in the trash.
+		repo.AssertNotCalled(t, "DeleteDocument", ctx, "doc-2")
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("ListDocumentEvents_DeletedDocument", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("ListDocumentEvents", ctx, "doc-1", 50, 0).Return([]*models.DocumentEvent{
+			{ID: "e-1", DocumentID: "doc-1", Type: models.DocumentEventUploaded},
+			{ID: "e-2", DocumentID: "doc-1", Type: models.DocumentEventDeleted},
+		}, 2, nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		events, total, err := svc.ListDocumentEvents(ctx, "doc-1", 50, 0)
+
+		require.NoError(t, err)
+		assert.Equal(t, 2, total)
+		assert.Len(t, events, 2)
+		repo.AssertNotCalled(t, "GetDocument", mock.Anything, mock.Anything)
+	})
+
+	t.Run("ListDocumentEvents_NotFound", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("ListDocumentEvents", ctx, "doc-1", 50, 0).Return(nil, 0, nil)
+		repo.On("GetDocument", ctx, "doc-1").Return(nil, nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		_, _, err := svc.ListDocumentEvents(ctx, "doc-1", 50, 0)
+
+		assert.Equal(t, gateway.KindNotFound, gateway.KindOf(err))
+	})
+
+	t.Run("UploadDocument_ArchiveStartsArchiveWorkflow", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetWorkspaceSettings", mock.Anything).Return(nil, nil)
+		repo.On("CreateDocument", ctx, mock.MatchedBy(func(doc *models.Document) bool {
+			return doc.UploadURLExpiresAt != nil && doc.UploadURLExpiresAt.After(time.Now())
+		})).Return(nil)
+		repo.On("CreateDocumentEvent", mock.Anything, mock.Anything).Return(nil)
+		s3 := mocks.NewMockS3Client()
+		s3.On("GeneratePresignedUploadURL", ctx, mock.Anything, mock.Anything, mock.Anything).Return("https://s3/upload", nil)
+		temporal := mocks.NewMockTemporalClient()
+		temporal.On("StartArchiveUploadWorkflow", ctx, mock.Anything).Return("upload-1", nil)
+		svc := &gateway.Service{Repository: repo, S3Client: s3, Temporal: temporal, Logger: zerolog.Nop()}
+
+		doc, err := svc.UploadDocument(ctx, "handbooks.ZIP", 2048, "alice", models.UploadOptions{})
+
+		require.NoError(t, err)
+		assert.Equal(t, "https://s3/upload", doc.UploadURL)
+		temporal.AssertExpectations(t)
+		temporal.AssertNotCalled(t, "StartUploadWorkflow", mock.Anything, mock.Anything)
+	})
+
+	t.Run("GetDocument_ArchiveProgress", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "zip-1").Return(&models.Document{ID: "zip-1", Filename: "handbooks.zip", Status: "complete"}, nil)
+		repo.On("CountChildDocuments", ctx, "zip-1").Return(&models.ChildProgress{Total: 3, Indexing: 1, Complete: 2}, nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		doc, err := svc.GetDocument(ctx, "zip-1")
+
+		require.NoError(t, err)
+		require.NotNil(t, doc.Children)
+		assert.Equal(t, 3, doc.Children.Total)
+		assert.Equal(t, 2, doc.Children.Complete)
+	})
+
+	t.Run("RegisterArchiveEntry_Success", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetWorkspaceSettings", mock.Anything).Return(nil, nil)
+		repo.On("GetDocument", ctx, "zip-1").Return(&models.Document{ID: "zip-1", Filename: "handbooks.zip", UploadedBy: "alice"}, nil)
+		repo.On("CreateDocument", ctx, mock.MatchedBy(func(doc *models.Document) bool {
+			return doc.ParentID == "zip-1" && doc.Filename == "onboarding.pdf" && doc.UploadedBy == "alice"
+		})).Return(nil)
+		repo.On("CreateDocumentEvent", mock.Anything, mock.Anything).Return(nil)
+		s3 := mocks.NewMockS3Client()
+		s3.On("GeneratePresignedUploadURL", ctx, mock.Anything, mock.Anything, mock.Anything).Return("https://s3/upload", nil)
+		temporal := mocks.NewMockTemporalClient()
+		temporal.On("StartUploadWorkflow", ctx, mock.Anything).Return("upload-2", nil)
+		svc := &gateway.Service{Repository: repo, S3Client: s3, Temporal: temporal, Logger: zerolog.Nop()}
+
+		doc, err := svc.RegisterArchiveEntry(ctx, "zip-1", models.ArchiveEntryRequest{Filename: "hr/onboarding.pdf", FileSize: 512})
+
+		require.NoError(t, err)
+		assert.Equal(t, "zip-1", doc.ParentID)
+		assert.Equal(t, "https://s3/upload", doc.UploadURL)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("RegisterArchiveEntry_Invalid", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", Filename: "guide.pdf"}, nil)
+		repo.On("GetDocument", ctx, "zip-1").Return(&models.Document{ID: "zip-1", Filename: "handbooks.zip"}, nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		_, err := svc.RegisterArchiveEntry(ctx, "doc-1", models.ArchiveEntryRequest{Filename: "a.pdf"})
+		assert.Equal(t, gateway.KindInvalid, gateway.KindOf(err))
+
+		_, err = svc.RegisterArchiveEntry(ctx, "zip-1", models.ArchiveEntryRequest{Filename: "nested/more.zip"})
+		assert.Equal(t, gateway.KindInvalid, gateway.KindOf(err))
+		repo.AssertNotCalled(t, "CreateDocument", mock.Anything, mock.Anything)
+	})
+
+	t.Run("UploadDocument_Chunking", func(t *testing.T) {
+		chunking := &models.ChunkingOptions{Strategy: models.ChunkingTable, Size: 1024, Overlap: 128}
+		repo := repomocks.NewMockRepository()
+		repo.On("GetWorkspaceSettings", mock.Anything).Return(nil, nil)
+		repo.On("CreateDocument", ctx, mock.MatchedBy(func(doc *models.Document) bool {
+			return doc.Chunking != nil && *doc.Chunking == *chunking
+		})).Return(nil)
+		repo.On("CreateDocumentEvent", mock.Anything, mock.Anything).Return(nil)
+		s3 := mocks.NewMockS3Client()
+		s3.On("GeneratePresignedUploadURL", ctx, mock.Anything, mock.Anything, mock.Anything).Return("https://s3/upload", nil)
+		temporal := mocks.NewMockTemporalClient()
+		temporal.On("StartUploadWorkflow", ctx, mock.MatchedBy(func(input services.UploadWorkflowInput) bool {
+			return input.Chunking != nil && *input.Chunking == *chunking
+		})).Return("upload-1", nil)
+		svc := &gateway.Service{Repository: repo, S3Client: s3, Temporal: temporal, Logger: zerolog.Nop()}
+
+		doc, err := svc.UploadDocument(ctx, "rates.pdf", 2048, "alice", models.UploadOptions{
+			Chunking: &models.ChunkingOptions{Strategy: " Table ", Size: 1024, Overlap: 128},
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, chunking, doc.Chunking)
+		repo.AssertExpectations(t)
+		temporal.AssertExpectations(t)
+	})
+
+	t.Run("UploadDocument_KeyPrefix", func(t *testing.T) {
+		isPrefixed := func(key string) bool {
+			return strings.HasPrefix(key, "acme/documents/") && strings.HasSuffix(key, "/rates.pdf")
+		}
+		repo := repomocks.NewMockRepository()
+		repo.On("GetWorkspaceSettings", mock.Anything).Return(nil, nil)
+		repo.On("CreateDocument", ctx, mock.MatchedBy(func(doc *models.Document) bool {
+			return isPrefixed(doc.S3Key)
+		})).Return(nil)
+		repo.On("CreateDocumentEvent", mock.Anything, mock.Anything).Return(nil)
+		s3 := &mocks.MockS3Client{KeyPrefix: "acme/"}
+		s3.On("GeneratePresignedUploadURL", ctx, mock.MatchedBy(isPrefixed), mock.Anything, mock.Anything).Return("https://s3/upload", nil)
+		temporal := mocks.NewMockTemporalClient()
+		temporal.On("StartUploadWorkflow", ctx, mock.MatchedBy(func(input services.UploadWorkflowInput) bool {
+			return isPrefixed(input.S3Key)
+		})).Return("upload-1", nil)
+		svc := &gateway.Service{Repository: repo, S3Client: s3, Temporal: temporal, Logger: zerolog.Nop()}
+
+		_, err := svc.UploadDocument(ctx, "rates.pdf", 2048, "alice", models.UploadOptions{})
+
+		require.NoError(t, err)
+		repo.AssertExpectations(t)
+		s3.AssertExpectations(t)
+		temporal.AssertExpectations(t)
+	})
+
+	t.Run("UploadDocument_InvalidChunking", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		for name, chunking := range map[string]*models.ChunkingOptions{
+			"strategy":         {Strategy: "paragraph"},
+			"too small":        {Size: gateway.MinChunkSize - 1},
+			"too large":        {Size: gateway.MaxChunkSize + 1},
+			"negative overlap": {Size: 512, Overlap: -1},
+			"overlap alone":    {Overlap: 64},
+			"overlap too wide": {Size: 512, Overlap: 257},
+		} {
+			_, err := svc.UploadDocument(ctx, "rates.pdf", 2048, "alice", models.UploadOptions{Chunking: chunking})
+			assert.Equal(t, gateway.KindInvalid, gateway.KindOf(err), name)
+		}
+		repo.AssertNotCalled(t, "CreateDocument", mock.Anything, mock.Anything)
+	})
+
+	t.Run("UploadDocument_Processing", func(t *testing.T) {
+		ocr := false
+		repo := repomocks.NewMockRepository()
+		repo.On("GetWorkspaceSettings", mock.Anything).Return(nil, nil)
+		repo.On("CreateDocument", ctx, mock.MatchedBy(func(doc *models.Document) bool {
+			return doc.Processing != nil && *doc.Processing.OCR == false
+		})).Return(nil)
+		repo.On("CreateDocumentEvent", mock.Anything, mock.Anything).Return(nil)
+		s3 := mocks.NewMockS3Client()
+		s3.On("GeneratePresignedUploadURL", ctx, mock.Anything, mock.Anything, mock.Anything).Return("https://s3/upload", nil)
+		temporal := mocks.NewMockTemporalClient()
+		temporal.On("StartUploadWorkflow", ctx, mock.MatchedBy(func(input services.UploadWorkflowInput) bool {
+			return input.Processing != nil && !*input.Processing.OCR
+		})).Return("upload-1", nil)
+		svc := &gateway.Service{Repository: repo, S3Client: s3, Temporal: temporal, Logger: zerolog.Nop()}
+
+		doc, err := svc.UploadDocument(ctx, "born-digital.pdf", 2048, "alice", models.UploadOptions{
+			Processing: &models.ProcessingOptions{OCR: &ocr, Languages: []string{" EN ", "pt-BR", "en"}},
+		})
+
+		require.NoError(t, err)
+		require.NotNil(t, doc.Processing)
+		assert.Equal(t, []string{"en", "pt-br"}, doc.Processing.Languages)
+		assert.Nil(t, doc.Processing.ExtractTables)
+		temporal.AssertExpectations(t)
+	})
+
+	t.Run("UploadDocument_InvalidProcessing", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		for name, processing := range map[string]*models.ProcessingOptions{
+			"malformed language": {Languages: []string{"english"}},
+			"blank language":     {Languages: []string{" "}},
+			"too many languages": {Languages: []string{"en", "de", "fr", "es", "it", "nl"}},
+		} {
+			_, err := svc.UploadDocument(ctx, "scan.pdf", 2048, "alice", models.UploadOptions{Processing: processing})
+			assert.Equal(t, gateway.KindInvalid, gateway.KindOf(err), name)
+		}
+		repo.AssertNotCalled(t, "CreateDocument", mock.Anything, mock.Anything)
+	})
+
+	t.Run("UploadDocument_FileTypeNotAllowed", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetWorkspaceSettings", ctx).Return(&models.WorkspaceSettings{AllowedFileTypes: []string{"pdf", "docx"}}, nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		_, err := svc.UploadDocument(ctx, "notes.txt", 2048, "alice", models.UploadOptions{})
+
+		assert.Equal(t, gateway.KindInvalid, gateway.KindOf(err))
+		assert.Equal(t, "File type is not allowed", gateway.MessageOf(err))
+		repo.AssertNotCalled(t, "CreateDocument", mock.Anything, mock.Anything)
+	})
+
+	t.Run("UploadDocument_MetadataSchema", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetWorkspaceSettings", ctx).Return(&models.WorkspaceSettings{MetadataSchema: []models.MetadataField{
+			{Key: "team", Type: models.MetadataTypeString, Required: true, Enum: []string{"finance", "hr"}},
+			{Key: "year", Type: models.MetadataTypeInteger},
+			{Key: "review_date", Type: models.MetadataTypeDate},
+		}}, nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		for name, metadata := range map[string]map[string]string{
+			"missing required": {"year": "2025"},
+			"not in enum":      {"team": "legal"},
+			"not an integer":   {"team": "hr", "year": "2025.5"},
+			"not a date":       {"team": "hr", "review_date": "16/10/2026"},
+		} {
+			_, err := svc.UploadDocument(ctx, "rates.pdf", 2048, "alice", models.UploadOptions{Metadata: metadata})
+			assert.Equal(t, gateway.KindInvalid, gateway.KindOf(err), name)
+		}
+		repo.AssertNotCalled(t, "CreateDocument", mock.Anything, mock.Anything)
+	})
+
+	t.Run("BatchUpload_ReportsEachFile", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetWorkspaceSettings", ctx).Return(&models.WorkspaceSettings{AllowedFileTypes: []string{"pdf"}}, nil)
+		repo.On("CreateDocument", ctx, mock.Anything).Return(nil)
+		repo.On("CreateDocumentEvent", mock.Anything, mock.Anything).Return(nil)
+		s3 := mocks.NewMockS3Client()
+		s3.On("GeneratePresignedUploadURL", ctx, mock.Anything, "application/pdf", mock.Anything).Return("https://s3/upload", nil)
+		temporal := mocks.NewMockTemporalClient()
+		temporal.On("StartUploadWorkflow", ctx, mock.Anything).Return("upload-1", nil)
+		svc := &gateway.Service{Repository: repo, S3Client: s3, Temporal: temporal, Logger: zerolog.Nop()}
+
+		results, err := svc.BatchUpload(ctx, models.BatchUploadRequest{Files: []models.BatchUploadFile{
+			{Filename: "a.pdf", ContentType: "application/pdf", Size: 2048},
+			{Filename: "notes.txt", ContentType: "text/plain"},
+		}}, "alice")
+
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+		require.NoError(t, results[0].Err)
+		assert.Equal(t, "a.pdf", results[0].Document.Filename)
+		assert.Equal(t, "https://s3/upload", results[0].Document.UploadURL)
+		assert.Equal(t, gateway.KindInvalid, gateway.KindOf(results[1].Err))
+		assert.Nil(t, results[1].Document)
+		repo.AssertNumberOfCalls(t, "CreateDocument", 1)
+		s3.AssertExpectations(t)
+	})
+
+	t.Run("BatchUpload_DuplicateContent", func(t *testing.T) {
+		existingHash := strings.Repeat("ab", 32)
+		newHash := strings.Repeat("cd", 32)
+		repo := repomocks.NewMockRepository()
+		repo.On("GetWorkspaceSettings", ctx).Return(nil, nil)
+		repo.On("FindDocumentByContentHash", ctx, existingHash).Return(&models.Document{ID: "doc-1", Status: "complete"}, nil)
+		repo.On("FindDocumentByContentHash", ctx, newHash).Return(nil, nil)
+		repo.On("CreateDocument", ctx, mock.MatchedBy(func(doc *models.Document) bool {
+			return doc.ContentHash == newHash
+		})).Return(nil)
+		repo.On("CreateDocumentEvent", mock.Anything, mock.Anything).Return(nil)
+		s3 := mocks.NewMockS3Client()
+		s3.On("GeneratePresignedUploadURL", ctx, mock.Anything, mock.Anything, mock.Anything).Return("https://s3/upload", nil)
+		temporal := mocks.NewMockTemporalClient()
+		temporal.On("StartUploadWorkflow", ctx, mock.Anything).Return("upload-1", nil)
+		svc := &gateway.Service{Repository: repo, S3Client: s3, Temporal: temporal, Logger: zerolog.Nop()}
+
+		results, err := svc.BatchUpload(ctx, models.BatchUploadRequest{Files: []models.BatchUploadFile{
+			{Filename: "a.pdf", ContentHash: strings.ToUpper(existingHash)},
+			{Filename: "b.pdf", ContentHash: newHash},
+			{Filename: "c.pdf", ContentHash: "not-a-hash"},
+		}}, "alice")
+
+		require.NoError(t, err)
+		require.Len(t, results, 3)
+		assert.Equal(t, gateway.KindDuplicate, gateway.KindOf(results[0].Err))
+		assert.Equal(t, map[string]string{"document_id": "doc-1"}, gateway.DetailsOf(results[0].Err))
+		require.NoError(t, results[1].Err)
+		assert.Equal(t, newHash, results[1].Document.ContentHash)
+		assert.Equal(t, gateway.KindInvalid, gateway.KindOf(results[2].Err))
+		repo.AssertNumberOfCalls(t, "CreateDocument", 1)
+	})
+
+	t.Run("BatchUpload_RepeatedContent", func(t *testing.T) {
+		hash := strings.Repeat("cd", 32)
+		repo := repomocks.NewMockRepository()
+		repo.On("GetWorkspaceSettings", ctx).Return(nil, nil)
+		repo.On("FindDocumentByContentHash", ctx, "").Return(nil, nil).Maybe()
+		repo.On("FindDocumentByContentHash", ctx, hash).Return(nil, nil).Once()
+		repo.On("FindDocumentByContentHash", ctx, hash).Return(&models.Document{ID: "doc-1"}, nil)
+		repo.On("CreateDocument", ctx, mock.Anything).Return(nil)
+		repo.On("CreateDocumentEvent", mock.Anything, mock.Anything).Return(nil)
+		s3 := mocks.NewMockS3Client()
+		s3.On("GeneratePresignedUploadURL", ctx, mock.Anything, mock.Anything, mock.Anything).Return("https://s3/upload", nil)
+		temporal := mocks.NewMockTemporalClient()
+		temporal.On("StartUploadWorkflow", ctx, mock.Anything).Return("upload-1", nil)
+		svc := &gateway.Service{Repository: repo, S3Client: s3, Temporal: temporal, Batches: services.NewWorkerPool(4), Logger: zerolog.Nop()}
+
+		results, err := svc.BatchUpload(ctx, models.BatchUploadRequest{Files: []models.BatchUploadFile{
+			{Filename: "a.pdf", ContentHash: hash},
+			{Filename: "a-copy.pdf", ContentHash: hash},
+			{Filename: "b.pdf"},
+		}}, "alice")
+
+		// The copy is registered once the original is, so it is refused
+		// as its duplicate rather than racing it.
+		require.NoError(t, err)
+		require.Len(t, results, 3)
+		require.NoError(t, results[0].Err)
+		assert.Equal(t, "a.pdf", results[0].Document.Filename)
+		assert.Equal(t, gateway.KindDuplicate, gateway.KindOf(results[1].Err))
+		require.NoError(t, results[2].Err)
+		assert.Equal(t, "b.pdf", results[2].Document.Filename)
+	})
+
+	t.Run("BatchUpload_TooManyFiles", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		_, err := svc.BatchUpload(ctx, models.BatchUploadRequest{Files: make([]models.BatchUploadFile, gateway.MaxBatchSize+1)}, "alice")
+
+		assert.Equal(t, gateway.KindInvalid, gateway.KindOf(err))
+		repo.AssertNotCalled(t, "CreateDocument", mock.Anything, mock.Anything)
+	})
+
+	t.Run("BatchCompleteUpload_ReportsEachDocument", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", S3Key: "documents/doc-1/a.pdf", Status: "pending"}, nil)
+		repo.On("GetDocument", ctx, "doc-2").Return(nil, nil)
+		repo.On("SetDocumentIndexing", ctx, "doc-1", "upload-doc-1").Return(nil)
+		s3 := mocks.NewMockS3Client()
+		s3.On("ObjectExists", ctx, "documents/doc-1/a.pdf").Return(true, nil)
+		temporal := mocks.NewMockTemporalClient()
+		temporal.On("SignalUploadComplete", ctx, "doc-1", "documents/doc-1/a.pdf", (*models.ProcessingOptions)(nil)).Return(nil)
+		svc := &gateway.Service{Repository: repo, S3Client: s3, Temporal: temporal, Logger: zerolog.Nop()}
+
+		results, err := svc.BatchCompleteUpload(ctx, []string{"doc-1", "doc-2"})
+
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+		require.NoError(t, results[0].Err)
+		assert.Equal(t, "indexing", results[0].Document.Status)
+		assert.Equal(t, gateway.KindNotFound, gateway.KindOf(results[1].Err))
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("BatchCompleteUpload_Concurrent", func(t *testing.T) {
+		var running atomic.Int32
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, mock.Anything).Run(func(mock.Arguments) {
+			// Wait for the other document, which only arrives if both
+			// are completed at once.
+			running.Add(1)
+			for deadline := time.Now().Add(time.Second); running.Load() < 2 && time.Now().Before(deadline); {
+				time.Sleep(time.Millisecond)
+			}
+		}).Return(nil, nil)
+		svc := &gateway.Service{Repository: repo, Batches: services.NewWorkerPool(2), Logger: zerolog.Nop()}
+
+		results, err := svc.BatchCompleteUpload(ctx, []string{"doc-1", "doc-2"})
+
+		require.NoError(t, err)
+		assert.EqualValues(t, 2, running.Load())
+		for _, result := range results {
+			assert.Equal(t, gateway.KindNotFound, gateway.KindOf(result.Err))
+		}
+	})
+
+	t.Run("BatchCompleteUpload_Interrupted", func(t *testing.T) {
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+		repo := repomocks.NewMockRepository()
+		svc := &gateway.Service{Repository: repo, Batches: services.NewWorkerPool(2), Logger: zerolog.Nop()}
+
+		results, err := svc.BatchCompleteUpload(cancelled, []string{"doc-1", "doc-2"})
+
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+		for _, result := range results {
+			assert.Equal(t, gateway.KindInternal, gateway.KindOf(result.Err))
+		}
+		repo.AssertNotCalled(t, "GetDocument", mock.Anything, mock.Anything)
+	})
+
+	t.Run("BatchCompleteUpload_Empty", func(t *testing.T) {
+		svc := &gateway.Service{Logger: zerolog.Nop()}
+
+		_, err := svc.BatchCompleteUpload(ctx, nil)
+
+		assert.Equal(t, gateway.KindInvalid, gateway.KindOf(err))
+	})
+
+	t.Run("BatchDelete_ReportsEachDocument", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocumentsByIDs", ctx, []string{"doc-1", "doc-2"}).
+			Return([]*models.Document{{ID: "doc-1", Filename: "a.pdf", S3Key: "uploads/doc-1", Status: "failed"}}, nil)
+		repo.On("TrashDocuments", ctx, []string{"doc-1"}, mock.AnythingOfType("time.Time")).Return([]string{"doc-1"}, nil)
+		repo.On("DeleteDocument", mock.Anything, "doc-1").Return(nil)
+		repo.On("CreateDocumentEvent", mock.Anything, mock.Anything).Return(nil)
+		qdrant := mocks.NewMockQdrantClient()
+		qdrant.On("Collection").Return("documents")
+		qdrant.On("DeleteDocumentVectors", mock.Anything, "documents", "doc-1").Return(nil)
+		s3 := mocks.NewMockS3Client()
+		s3.On("DeleteObject", mock.Anything, "uploads/doc-1").Return(nil)
+		svc := &gateway.Service{Repository: repo, QdrantClient: qdrant, S3Client: s3, Logger: zerolog.Nop()}
+
+		results, remaining, err := svc.BatchDelete(ctx, models.BatchDeleteRequest{DocumentIDs: []string{"doc-1", "doc-2"}})
+
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+		require.NoError(t, results[0].Err)
+		assert.Equal(t, "a.pdf", results[0].Document.Filename)
+		assert.Equal(t, gateway.KindNotFound, gateway.KindOf(results[1].Err))
+		assert.Zero(t, remaining)
+		repo.AssertExpectations(t)
+		s3.AssertExpectations(t)
+	})
+
+	t.Run("BatchDelete_TombstonesTogether", func(t *testing.T) {
+		trashedAt := time.Now().Add(-time.Hour)
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocumentsByIDs", ctx, []string{"doc-1", "doc-2", "doc-3", "doc-4"}).Return([]*models.Document{
+			{ID: "doc-4", Status: "complete", DeletedAt: &trashedAt},
+			{ID: "doc-3", Status: "indexing", WorkflowID: "index-doc-3"},
+			{ID: "doc-2", Status: "indexing", WorkflowID: "index-doc-2"},
+			{ID: "doc-1", Status: "complete"},
+		}, nil)
+		repo.On("TrashDocuments", ctx, []string{"doc-1", "doc-3"}, mock.AnythingOfType("time.Time")).Return([]string{"doc-1", "doc-3"}, nil).Once()
+		for _, id := range []string{"doc-1", "doc-3", "doc-4"} {
+			repo.On("DeleteDocument", mock.Anything, id).Return(nil).Once()
+		}
+		repo.On("CreateDocumentEvent", mock.Anything, mock.Anything).Return(nil)
+		temporal := mocks.NewMockTemporalClient()
+		temporal.On("CancelWorkflow", mock.Anything, "index-doc-2").Return(errors.New("temporal down"))
+		temporal.On("CancelWorkflow", mock.Anything, "index-doc-3").Return(nil)
+		qdrant := mocks.NewMockQdrantClient()
+		qdrant.On("Collection").Return("documents")
+		qdrant.On("DeleteDocumentVectors", mock.Anything, "documents", mock.Anything).Return(nil)
+		svc := &gateway.Service{
+			Repository:   repo,
+			QdrantClient: qdrant,
+			Temporal:     temporal,
+			Batches:      services.NewWorkerPool(4),
+			Logger:       zerolog.Nop(),
+		}
+
+		results, _, err := svc.BatchDelete(ctx, models.BatchDeleteRequest{DocumentIDs: []string{"doc-1", "doc-2", "doc-3", "doc-4"}})
+
+		require.NoError(t, err)
+		require.Len(t, results, 4)
+		assert.NoError(t, results[0].Err)
+		assert.Equal(t, gateway.KindInternal, gateway.KindOf(results[1].Err), "indexing that cannot be stopped keeps the document")
+		assert.Equal(t, "doc-2", results[1].Document.ID)
+		assert.NoError(t, results[2].Err)
+		assert.NoError(t, results[3].Err, "a trashed document is deleted for good")
+		repo.AssertExpectations(t)
+		repo.AssertNotCalled(t, "DeleteDocument", mock.Anything, "doc-2")
+		temporal.AssertExpectations(t)
+	})
+
+	t.Run("BatchDelete_TombstoneFails", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocumentsByIDs", ctx, []string{"doc-1", "doc-2"}).
+			Return([]*models.Document{{ID: "doc-1", Status: "complete"}, {ID: "doc-2", Status: "failed"}}, nil)
+		repo.On("TrashDocuments", ctx, []string{"doc-1", "doc-2"}, mock.AnythingOfType("time.Time")).Return(nil, errors.New("db down"))
+		svc := &gateway.Service{Repository: repo, TrashRetention: 24 * time.Hour, Logger: zerolog.Nop()}
+
+		results, _, err := svc.BatchDelete(ctx, models.BatchDeleteRequest{DocumentIDs: []string{"doc-1", "doc-2"}})
+
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+		for _, result := range results {
+			assert.Equal(t, gateway.KindInternal, gateway.KindOf(result.Err))
+		}
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("BatchDelete_ByStatus", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("ListDocuments", ctx, gateway.MaxBatchSize, 0, models.DocumentFilter{Status: "failed"}).
+			Return([]*models.Document{{ID: "doc-1", Status: "failed"}}, 150, nil)
+		repo.On("TrashDocuments", ctx, []string{"doc-1"}, mock.AnythingOfType("time.Time")).Return([]string{"doc-1"}, nil)
+		repo.On("CreateDocumentEvent", mock.Anything, mock.Anything).Return(nil)
+		qdrant := mocks.NewMockQdrantClient()
+		qdrant.On("Collection").Return("documents")
+		qdrant.On("DeleteDocumentVectors", mock.Anything, "documents", "doc-1").Return(nil)
+		svc := &gateway.Service{Repository: repo, QdrantClient: qdrant, TrashRetention: 24 * time.Hour, Logger: zerolog.Nop()}
+
+		results, remaining, err := svc.BatchDelete(ctx, models.BatchDeleteRequest{Status: " failed "})
+
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		require.NoError(t, results[0].Err)
+		assert.Equal(t, "doc-1", results[0].Document.ID)
+		assert.Equal(t, 149, remaining)
+		repo.AssertExpectations(t)
+		qdrant.AssertExpectations(t)
+	})
+
+	t.Run("BatchDelete_RejectsAmbiguousRequests", func(t *testing.T) {
+		svc := &gateway.Service{Logger: zerolog.Nop()}
+
+		for _, req := range []models.BatchDeleteRequest{
+			{},
+			{DocumentIDs: []string{"doc-1"}, Status: "failed"},
+			{DocumentIDs: []string{"doc-1", "doc-1"}},
+		} {
+			_, _, err := svc.BatchDelete(ctx, req)
+
+			assert.Equal(t, gateway.KindInvalid, gateway.KindOf(err))
+		}
+	})
+
+	t.Run("CreateMultipartUpload_SizesParts", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", S3Key: "documents/doc-1/big.pdf", Status: "pending"}, nil)
+		repo.On("GetMultipartUpload", ctx, "doc-1").Return(nil, nil)
+		repo.On("CreateMultipartUpload", ctx, mock.Anything).Return(nil)
+		s3 := mocks.NewMockS3Client()
+		s3.On("CreateMultipartUpload", ctx, "documents/doc-1/big.pdf").Return("upload-1", nil)
+		svc := &gateway.Service{Repository: repo, S3Client: s3, Logger: zerolog.Nop()}
+
+		// 1 TiB needs parts larger than the 64 MiB default to stay within
+		// 10,000 parts.
+		upload, err := svc.CreateMultipartUpload(ctx, "doc-1", models.CreateMultipartUploadRequest{FileSize: 1 << 40})
+
+		require.NoError(t, err)
+		assert.Equal(t, "upload-1", upload.UploadID)
+		assert.Equal(t, int64(109951163), upload.PartSize)
+		assert.Equal(t, 10000, upload.PartCount)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("CreateMultipartUpload_PartSizeTooSmall", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", S3Key: "documents/doc-1/big.pdf", Status: "pending"}, nil)
+		repo.On("GetMultipartUpload", ctx, "doc-1").Return(nil, nil)
+		s3 := mocks.NewMockS3Client()
+		svc := &gateway.Service{Repository: repo, S3Client: s3, Logger: zerolog.Nop()}
+
+		_, err := svc.CreateMultipartUpload(ctx, "doc-1", models.CreateMultipartUploadRequest{FileSize: 1 << 30, PartSize: 1 << 20})
+
+		assert.Equal(t, gateway.KindInvalid, gateway.KindOf(err))
+		s3.AssertNotCalled(t, "CreateMultipartUpload", mock.Anything, mock.Anything)
+	})
+
+	t.Run("CreateMultipartUpload_AlreadyInProgress", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", S3Key: "documents/doc-1/big.pdf", Status: "pending"}, nil)
+		repo.On("GetMultipartUpload", ctx, "doc-1").Return(&models.MultipartUpload{DocumentID: "doc-1"}, nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		_, err := svc.CreateMultipartUpload(ctx, "doc-1", models.CreateMultipartUploadRequest{FileSize: 1 << 30})
+
+		assert.Equal(t, gateway.KindConflict, gateway.KindOf(err))
+	})
+
+	t.Run("MultipartPartURLs_RenewsUploadWindow", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", S3Key: "documents/doc-1/big.pdf", Status: "pending"}, nil)
+		repo.On("GetMultipartUpload", ctx, "doc-1").Return(&models.MultipartUpload{DocumentID: "doc-1", UploadID: "upload-1", PartCount: 3}, nil)
+		repo.On("SetDocumentUploadURL", ctx, "doc-1", mock.Anything, mock.Anything).Return(nil)
+		s3 := mocks.NewMockS3Client()
+		s3.On("GeneratePresignedUploadPartURL", ctx, "documents/doc-1/big.pdf", "upload-1", 1, mock.Anything).Return("https://s3/part-1", nil)
+		s3.On("GeneratePresignedUploadPartURL", ctx, "documents/doc-1/big.pdf", "upload-1", 3, mock.Anything).Return("https://s3/part-3", nil)
+		svc := &gateway.Service{Repository: repo, S3Client: s3, Logger: zerolog.Nop()}
+
+		resp, err := svc.MultipartPartURLs(ctx, "doc-1", []int{1, 3})
+
+		require.NoError(t, err)
+		assert.Equal(t, []models.MultipartPartURL{
+			{PartNumber: 1, URL: "https://s3/part-1"},
+			{PartNumber: 3, URL: "https://s3/part-3"},
+		}, resp.Parts)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("MultipartPartURLs_PartOutOfRange", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", S3Key: "documents/doc-1/big.pdf", Status: "pending"}, nil)
+		repo.On("GetMultipartUpload", ctx, "doc-1").Return(&models.MultipartUpload{DocumentID: "doc-1", UploadID: "upload-1", PartCount: 3}, nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		_, err := svc.MultipartPartURLs(ctx, "doc-1", []int{4})
+
+		assert.Equal(t, gateway.KindInvalid, gateway.KindOf(err))
+	})
+
+	t.Run("CompleteMultipartUpload_MissingParts", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", S3Key: "documents/doc-1/big.pdf", Status: "pending"}, nil)
+		repo.On("GetMultipartUpload", ctx, "doc-1").Return(&models.MultipartUpload{
+			DocumentID: "doc-1", UploadID: "upload-1", PartCount: 3,
+			Parts: []models.MultipartUploadPart{{PartNumber: 2, ETag: "b"}},
+		}, nil)
+		s3 := mocks.NewMockS3Client()
+		svc := &gateway.Service{Repository: repo, S3Client: s3, Logger: zerolog.Nop()}
+
+		_, err := svc.CompleteMultipartUpload(ctx, "doc-1")
+
+		assert.Equal(t, gateway.KindInvalid, gateway.KindOf(err))
+		assert.Equal(t, "Parts have not been uploaded: 1, 3", gateway.MessageOf(err))
+		s3.AssertNotCalled(t, "CompleteMultipartUpload", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("CompleteMultipartUpload_AssemblesAndCompletes", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", S3Key: "documents/doc-1/big.pdf", Status: "pending"}, nil)
+		repo.On("GetMultipartUpload", ctx, "doc-1").Return(&models.MultipartUpload{
+			DocumentID: "doc-1", UploadID: "upload-1", PartCount: 2,
+			Parts: []models.MultipartUploadPart{{PartNumber: 1, ETag: "a"}, {PartNumber: 2, ETag: "b"}},
+		}, nil)
+		repo.On("CompleteMultipartUpload", ctx, "doc-1", mock.Anything).Return(nil)
+		repo.On("SetDocumentIndexing", ctx, "doc-1", "upload-doc-1").Return(nil)
+		s3 := mocks.NewMockS3Client()
+		s3.On("CompleteMultipartUpload", ctx, "documents/doc-1/big.pdf", "upload-1", []services.CompletedPart{
+			{PartNumber: 1, ETag: "a"}, {PartNumber: 2, ETag: "b"},
+		}).Return(nil)
+		s3.On("ObjectExists", ctx, "documents/doc-1/big.pdf").Return(true, nil)
+		temporal := mocks.NewMockTemporalClient()
+		temporal.On("SignalUploadComplete", ctx, "doc-1", "documents/doc-1/big.pdf", (*models.ProcessingOptions)(nil)).Return(nil)
+		svc := &gateway.Service{Repository: repo, S3Client: s3, Temporal: temporal, Logger: zerolog.Nop()}
+
+		doc, err := svc.CompleteMultipartUpload(ctx, "doc-1")
+
+		require.NoError(t, err)
+		assert.Equal(t, "indexing", doc.Status)
+		repo.AssertExpectations(t)
+		s3.AssertExpectations(t)
+	})
+
+	t.Run("CompleteMultipartUpload_RetryAfterAssembly", func(t *testing.T) {
+		completedAt := time.Now()
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", S3Key: "documents/doc-1/big.pdf", Status: "pending"}, nil)
+		repo.On("GetMultipartUpload", ctx, "doc-1").Return(&models.MultipartUpload{
+			DocumentID: "doc-1", UploadID: "upload-1", PartCount: 1, CompletedAt: &completedAt,
+			Parts: []models.MultipartUploadPart{{PartNumber: 1, ETag: "a"}},
+		}, nil)
+		repo.On("SetDocumentIndexing", ctx, "doc-1", "upload-doc-1").Return(nil)
+		s3 := mocks.NewMockS3Client()
+		s3.On("ObjectExists", ctx, "documents/doc-1/big.pdf").Return(true, nil)
+		temporal := mocks.NewMockTemporalClient()
+		temporal.On("SignalUploadComplete", ctx, "doc-1", "documents/doc-1/big.pdf", (*models.ProcessingOptions)(nil)).Return(nil)
+		svc := &gateway.Service{Repository: repo, S3Client: s3, Temporal: temporal, Logger: zerolog.Nop()}
+
+		_, err := svc.CompleteMultipartUpload(ctx, "doc-1")
+
+		require.NoError(t, err)
+		s3.AssertNotCalled(t, "CompleteMultipartUpload", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("CompleteMultipartUpload_RejectedParts", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", S3Key: "documents/doc-1/big.pdf", Status: "pending"}, nil)
+		repo.On("GetMultipartUpload", ctx, "doc-1").Return(&models.MultipartUpload{
+			DocumentID: "doc-1", UploadID: "upload-1", PartCount: 1,
+			Parts: []models.MultipartUploadPart{{PartNumber: 1, ETag: "stale"}},
+		}, nil)
+		s3 := mocks.NewMockS3Client()
+		s3.On("CompleteMultipartUpload", ctx, "documents/doc-1/big.pdf", "upload-1", mock.Anything).Return(services.ErrInvalidPart)
+		svc := &gateway.Service{Repository: repo, S3Client: s3, Logger: zerolog.Nop()}
+
+		_, err := svc.CompleteMultipartUpload(ctx, "doc-1")
+
+		assert.Equal(t, gateway.KindInvalid, gateway.KindOf(err))
+		repo.AssertNotCalled(t, "CompleteMultipartUpload", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("AbortMultipartUpload", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", S3Key: "documents/doc-1/big.pdf", Status: "pending"}, nil)
+		repo.On("GetMultipartUpload", ctx, "doc-1").Return(&models.MultipartUpload{DocumentID: "doc-1", UploadID: "upload-1", PartCount: 2}, nil)
+		repo.On("DeleteMultipartUpload", ctx, "doc-1").Return(nil)
+		s3 := mocks.NewMockS3Client()
+		s3.On("AbortMultipartUpload", ctx, "documents/doc-1/big.pdf", "upload-1").Return(nil)
+		svc := &gateway.Service{Repository: repo, S3Client: s3, Logger: zerolog.Nop()}
+
+		err := svc.AbortMultipartUpload(ctx, "doc-1")
+
+		require.NoError(t, err)
+		repo.AssertExpectations(t)
+		s3.AssertExpectations(t)
+	})
+
+	t.Run("UploadContent_StreamsAndCompletes", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", S3Key: "documents/doc-1/a.pdf", Filename: "a.pdf", Status: "pending"}, nil)
+		repo.On("SetDocumentUploadURL", ctx, "doc-1", mock.Anything, mock.Anything).Return(nil)
+		repo.On("SetDocumentIndexing", ctx, "doc-1", "upload-doc-1").Return(nil)
+		s3 := mocks.NewMockS3Client()
+		s3.On("StreamObject", ctx, "documents/doc-1/a.pdf", mock.Anything, "application/pdf", int64(8<<20), 2).Return(nil)
+		s3.On("ObjectExists", ctx, "documents/doc-1/a.pdf").Return(true, nil)
+		temporal := mocks.NewMockTemporalClient()
+		temporal.On("SignalUploadComplete", ctx, "doc-1", "documents/doc-1/a.pdf", (*models.ProcessingOptions)(nil)).Return(nil)
+		svc := &gateway.Service{Repository: repo, S3Client: s3, Temporal: temporal, Logger: zerolog.Nop(), ProxyUploads: &gateway.ProxyUploadLimits{
+			MaxSize: 1 << 20, ContentTypes: []string{"application/pdf"}, PartSize: 8 << 20, Concurrency: 2,
+		}}
+
+		doc, err := svc.UploadContent(ctx, "doc-1", strings.NewReader("%PDF-1.7"), 8, "application/pdf")
+
+		require.NoError(t, err)
+		assert.Equal(t, "indexing", doc.Status)
+		repo.AssertExpectations(t)
+		s3.AssertExpectations(t)
+	})
+
+	t.Run("UploadContent_ContentTypeNotAllowed", func(t *testing.T) {
+		s3 := mocks.NewMockS3Client()
+		svc := &gateway.Service{S3Client: s3, Logger: zerolog.Nop(), ProxyUploads: &gateway.ProxyUploadLimits{
+			MaxSize: 1 << 20, ContentTypes: []string{"application/pdf", "text/*"},
+		}}
+
+		_, err := svc.UploadContent(ctx, "doc-1", strings.NewReader("<html>"), 6, "application/x-msdownload")
+
+		assert.Equal(t, gateway.KindInvalid, gateway.KindOf(err))
+		s3.AssertNotCalled(t, "StreamObject", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("UploadContent_WildcardContentType", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", Status: "indexing"}, nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop(), ProxyUploads: &gateway.ProxyUploadLimits{
+			MaxSize: 1 << 20, ContentTypes: []string{"text/*"},
+		}}
+
+		// Past the allow-list, the upload is refused for the document's
+		// status instead.
+		_, err := svc.UploadContent(ctx, "doc-1", strings.NewReader("notes"), 5, "text/markdown; charset=utf-8")
+
+		require.Error(t, err)
+		assert.Equal(t, "Document is not awaiting an upload", gateway.MessageOf(err))
+	})
+
+	t.Run("UploadContent_DeclaredTooLarge", func(t *testing.T) {
+		s3 := mocks.NewMockS3Client()
+		svc := &gateway.Service{S3Client: s3, Logger: zerolog.Nop(), ProxyUploads: &gateway.ProxyUploadLimits{MaxSize: 4}}
+
+		_, err := svc.UploadContent(ctx, "doc-1", strings.NewReader("too long"), 8, "text/plain")
+
+		assert.Equal(t, gateway.KindTooLarge, gateway.KindOf(err))
+		s3.AssertNotCalled(t, "StreamObject", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("UploadContent_StreamTooLarge", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", S3Key: "documents/doc-1/a.txt", Filename: "a.txt", Status: "pending"}, nil)
+		s3 := mocks.NewMockS3Client()
+		svc := &gateway.Service{Repository: repo, S3Client: s3, Logger: zerolog.Nop(), ProxyUploads: &gateway.ProxyUploadLimits{MaxSize: 4}}
+
+		// Without a declared length the limit is enforced while reading.
+		_, err := svc.UploadContent(ctx, "doc-1", strings.NewReader("too long"), -1, "text/plain")
+
+		assert.Equal(t, gateway.KindTooLarge, gateway.KindOf(err))
+		repo.AssertNotCalled(t, "SetDocumentUploadURL", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("UploadPolicy_Check", func(t *testing.T) {
+		policy := &gateway.UploadPolicy{MaxSize: 100, ContentTypes: []string{"application/pdf", "text/*"}, Extensions: []string{"pdf", ".MD"}}
+		tests := []struct {
+			name        string
+			filename    string
+			size        int64
+			contentType string
+			rule        string
+		}{
+			{"allowed", "a.pdf", 100, "application/pdf", ""},
+			{"unknown size and type", "notes.md", -1, "", ""},
+			{"extension case", "README.Md", 10, "text/markdown; charset=utf-8", ""},
+			{"too large", "a.pdf", 101, "application/pdf", "max_size"},
+			{"extension", "setup.exe", 10, "application/pdf", "extension"},
+			{"content type", "a.pdf", 10, "application/x-msdownload", "content_type"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				err := policy.Check(tt.filename, tt.size, tt.contentType)
+
+				if tt.rule == "" {
+					assert.NoError(t, err)
+					return
+				}
+				assert.Equal(t, gateway.KindInvalid, gateway.KindOf(err))
+				assert.Equal(t, tt.rule, gateway.DetailsOf(err)["rule"])
+				assert.Equal(t, "file", gateway.DetailsOf(err)["field"])
+			})
+		}
+
+		assert.NoError(t, (*gateway.UploadPolicy)(nil).Check("setup.exe", 1<<40, "application/x-msdownload"))
+	})
+
+	t.Run("UploadContent_PolicyContentMismatch", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", S3Key: "documents/doc-1/a.pdf", Filename: "a.pdf", Status: "pending"}, nil)
+		s3 := mocks.NewMockS3Client()
+		svc := &gateway.Service{Repository: repo, S3Client: s3, Logger: zerolog.Nop(),
+			ProxyUploads: &gateway.ProxyUploadLimits{MaxSize: 1 << 20},
+			UploadPolicy: &gateway.UploadPolicy{ContentTypes: []string{"application/pdf"}},
+		}
+
+		// A web page declared as a PDF.
+		_, err := svc.UploadContent(ctx, "doc-1", strings.NewReader("<!DOCTYPE html><html><body>"), -1, "application/pdf")
+
+		assert.Equal(t, gateway.KindInvalid, gateway.KindOf(err))
+		assert.Equal(t, "content", gateway.DetailsOf(err)["rule"])
+		assert.Equal(t, "text/html", gateway.DetailsOf(err)["detected_type"])
+		s3.AssertNotCalled(t, "StreamObject", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("UploadContent_PolicySniffsAndStreams", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", S3Key: "documents/doc-1/a.pdf", Filename: "a.pdf", Status: "pending"}, nil)
+		repo.On("SetDocumentUploadURL", ctx, "doc-1", mock.Anything, mock.Anything).Return(nil)
+		repo.On("SetDocumentIndexing", ctx, "doc-1", "upload-doc-1").Return(nil)
+		s3 := mocks.NewMockS3Client()
+		s3.On("StreamObject", ctx, "documents/doc-1/a.pdf", mock.Anything, "application/pdf", int64(0), 0).Return(nil)
+		s3.On("ObjectExists", ctx, "documents/doc-1/a.pdf").Return(true, nil)
+		temporal := mocks.NewMockTemporalClient()
+		temporal.On("SignalUploadComplete", ctx, "doc-1", "documents/doc-1/a.pdf", (*models.ProcessingOptions)(nil)).Return(nil)
+		svc := &gateway.Service{Repository: repo, S3Client: s3, Temporal: temporal, Logger: zerolog.Nop(),
+			ProxyUploads: &gateway.ProxyUploadLimits{MaxSize: 1 << 20},
+			UploadPolicy: &gateway.UploadPolicy{MaxSize: 100, Extensions: []string{"pdf"}},
+		}
+
+		doc, err := svc.UploadContent(ctx, "doc-1", strings.NewReader("%PDF-1.7"), -1, "application/pdf")
+
+		require.NoError(t, err)
+		assert.Equal(t, "indexing", doc.Status)
+		s3.AssertExpectations(t)
+	})
+
+	t.Run("UploadContent_PolicyStreamTooLarge", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", S3Key: "documents/doc-1/a.txt", Filename: "a.txt", Status: "pending"}, nil)
+		s3 := mocks.NewMockS3Client()
+		svc := &gateway.Service{Repository: repo, S3Client: s3, Logger: zerolog.Nop(),
+			ProxyUploads: &gateway.ProxyUploadLimits{MaxSize: 1 << 20},
+			UploadPolicy: &gateway.UploadPolicy{MaxSize: 4},
+		}
+
+		_, err := svc.UploadContent(ctx, "doc-1", strings.NewReader("too long"), -1, "text/plain")
+
+		assert.Equal(t, gateway.KindInvalid, gateway.KindOf(err))
+		assert.Equal(t, "max_size", gateway.DetailsOf(err)["rule"])
+		repo.AssertNotCalled(t, "SetDocumentUploadURL", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("UploadContent_Disabled", func(t *testing.T) {
+		svc := &gateway.Service{Logger: zerolog.Nop()}
+
+		_, err := svc.UploadContent(ctx, "doc-1", strings.NewReader("data"), 4, "text/plain")
+
+		assert.Equal(t, gateway.KindUnavailable, gateway.KindOf(err))
+	})
+
+	t.Run("PurgeTrash_WorkspaceRetention", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetWorkspaceSettings", ctx).Return(&models.WorkspaceSettings{RetentionDays: 7}, nil)
+		repo.On("ListExpiredTrash", ctx, mock.MatchedBy(func(before time.Time) bool {
+			return time.Since(before) >= 7*24*time.Hour && time.Since(before) < 8*24*time.Hour
+		}), 100, 0).Return([]*models.Document{}, nil)
+		repo.On("CreateTrashPurge", mock.Anything, mock.Anything).Return(nil)
+		svc := &gateway.Service{Repository: repo, TrashRetention: 24 * time.Hour, Logger: zerolog.Nop()}
+
+		_, err := svc.PurgeTrash(ctx)
+
+		require.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("UpdateWorkspaceSettings_Normalizes", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetWorkspaceSettings", ctx).Return(&models.WorkspaceSettings{Name: "Acme Docs", RetentionDays: 30, AllowedFileTypes: []string{}}, nil)
+		repo.On("UpsertWorkspaceSettings", ctx, mock.AnythingOfType("*models.WorkspaceSettings")).Return(nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+		name, language, fileTypes := " ", " FR ", []string{".PDF", "pdf", "Docx"}
+
+		settings, err := svc.UpdateWorkspaceSettings(ctx, models.UpdateWorkspaceSettingsRequest{
+			Name:             &name,
+			DefaultLanguage:  &language,
+			AllowedFileTypes: &fileTypes,
+		}, "admin")
+
+		require.NoError(t, err)
+		assert.Equal(t, models.DefaultWorkspaceSettings().Name, settings.Name)
+		assert.Equal(t, "fr", settings.DefaultLanguage)
+		assert.Equal(t, []string{"pdf", "docx"}, settings.AllowedFileTypes)
+		assert.Equal(t, 30, settings.RetentionDays)
+		assert.Equal(t, "admin", settings.UpdatedBy)
+		assert.NotNil(t, settings.UpdatedAt)
+	})
+
+	t.Run("UpdateWorkspaceSettings_MetadataSchema", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetWorkspaceSettings", ctx).Return(nil, nil)
+		repo.On("UpsertWorkspaceSettings", ctx, mock.AnythingOfType("*models.WorkspaceSettings")).Return(nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+		schema := []models.MetadataField{
+			{Key: " team ", Required: true, Enum: []string{"finance", " hr", "finance"}},
+			{Key: "year", Type: " Integer "},
+		}
+
+		settings, err := svc.UpdateWorkspaceSettings(ctx, models.UpdateWorkspaceSettingsRequest{MetadataSchema: &schema}, "admin")
+
+		require.NoError(t, err)
+		assert.Equal(t, []models.MetadataField{
+			{Key: "team", Type: models.MetadataTypeString, Required: true, Enum: []string{"finance", "hr"}},
+			{Key: "year", Type: models.MetadataTypeInteger},
+		}, settings.MetadataSchema)
+	})
+
+	t.Run("UpdateWorkspaceSettings_Invalid", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetWorkspaceSettings", ctx).Return(nil, nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+		logo, language, retention, fileTypes := "javascript:alert(1)", "english", 5000, []string{"tar.gz"}
+		duplicateKey := []models.MetadataField{{Key: "team"}, {Key: "team"}}
+		unknownType := []models.MetadataField{{Key: "team", Type: "enum"}}
+		badEnum := []models.MetadataField{{Key: "year", Type: models.MetadataTypeInteger, Enum: []string{"2025", "next"}}}
+
+		for name, req := range map[string]models.UpdateWorkspaceSettingsRequest{
+			"logo url":             {LogoURL: &logo},
+			"language":             {DefaultLanguage: &language},
+			"retention":            {RetentionDays: &retention},
+			"file types":           {AllowedFileTypes: &fileTypes},
+			"duplicate schema key": {MetadataSchema: &duplicateKey},
+			"unknown schema type":  {MetadataSchema: &unknownType},
+			"schema enum type":     {MetadataSchema: &badEnum},
+		} {
+			_, err := svc.UpdateWorkspaceSettings(ctx, req, "admin")
+			assert.Equal(t, gateway.KindInvalid, gateway.KindOf(err), name)
+		}
+		repo.AssertNotCalled(t, "UpsertWorkspaceSettings", mock.Anything, mock.Anything)
+	})
+
+	t.Run("RegisterArchiveEntry_InheritsChunking", func(t *testing.T) {
+		chunking := &models.ChunkingOptions{Strategy: models.ChunkingSentence}
+		repo := repomocks.NewMockRepository()
+		repo.On("GetWorkspaceSettings", mock.Anything).Return(nil, nil)
+		repo.On("GetDocument", ctx, "zip-1").Return(&models.Document{ID: "zip-1", Filename: "handbooks.zip", UploadedBy: "alice", Chunking: chunking}, nil)
+		repo.On("CreateDocument", ctx, mock.MatchedBy(func(doc *models.Document) bool {
+			return doc.Chunking == chunking
+		})).Return(nil)
+		repo.On("CreateDocumentEvent", mock.Anything, mock.Anything).Return(nil)
+		s3 := mocks.NewMockS3Client()
+		s3.On("GeneratePresignedUploadURL", ctx, mock.Anything, mock.Anything, mock.Anything).Return("https://s3/upload", nil)
+		temporal := mocks.NewMockTemporalClient()
+		temporal.On("StartUploadWorkflow", ctx, mock.MatchedBy(func(input services.UploadWorkflowInput) bool {
+			return input.Chunking == chunking
+		})).Return("upload-2", nil)
+		svc := &gateway.Service{Repository: repo, S3Client: s3, Temporal: temporal, Logger: zerolog.Nop()}
+
+		_, err := svc.RegisterArchiveEntry(ctx, "zip-1", models.ArchiveEntryRequest{Filename: "onboarding.pdf"})
+
+		require.NoError(t, err)
+		repo.AssertExpectations(t)
+		temporal.AssertExpectations(t)
+	})
+
+	t.Run("ReindexDocument_NewChunking", func(t *testing.T) {
+		chunking := &models.ChunkingOptions{Strategy: models.ChunkingTable, Size: 2048}
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", Filename: "rates.pdf", Status: "complete"}, nil)
+		repo.On("SetDocumentChunking", ctx, "doc-1", chunking).Return(nil)
+		repo.On("SetDocumentIndexing", ctx, "doc-1", "index-doc-1").Return(nil)
+		temporal := mocks.NewMockTemporalClient()
+		temporal.On("StartIndexWorkflow", ctx, services.IndexWorkflowInput{DocumentID: "doc-1", Chunking: chunking}).Return("index-doc-1", nil)
+		svc := &gateway.Service{Repository: repo, Temporal: temporal, Logger: zerolog.Nop()}
+
+		doc, err := svc.ReindexDocument(ctx, "doc-1", chunking)
+
+		require.NoError(t, err)
+		assert.Equal(t, "indexing", doc.Status)
+		assert.Equal(t, chunking, doc.Chunking)
+		repo.AssertExpectations(t)
+		temporal.AssertExpectations(t)
+	})
+
+	t.Run("ReindexDocument_KeepsChunking", func(t *testing.T) {
+		chunking := &models.ChunkingOptions{Strategy: models.ChunkingSentence}
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", Filename: "rates.pdf", Status: "failed", Chunking: chunking}, nil)
+		repo.On("SetDocumentIndexing", ctx, "doc-1", "index-doc-1").Return(nil)
+		temporal := mocks.NewMockTemporalClient()
+		temporal.On("StartIndexWorkflow", ctx, services.IndexWorkflowInput{DocumentID: "doc-1", Chunking: chunking}).Return("index-doc-1", nil)
+		svc := &gateway.Service{Repository: repo, Temporal: temporal, Logger: zerolog.Nop()}
+
+		_, err := svc.ReindexDocument(ctx, "doc-1", nil)
+
+		require.NoError(t, err)
+		repo.AssertNotCalled(t, "SetDocumentChunking", mock.Anything, mock.Anything, mock.Anything)
+		temporal.AssertExpectations(t)
+	})
+
+	t.Run("ReindexDocument_DeletesVectors", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", Filename: "rates.pdf", Status: "complete"}, nil)
+		repo.On("SetDocumentIndexing", ctx, "doc-1", "index-doc-1").Return(nil)
+		qdrant := mocks.NewMockQdrantClient()
+		qdrant.On("Collection").Return("documents")
+		qdrant.On("DeleteDocumentVectors", ctx, "documents", "doc-1").Return(nil)
+		temporal := mocks.NewMockTemporalClient()
+		temporal.On("StartIndexWorkflow", ctx, services.IndexWorkflowInput{DocumentID: "doc-1"}).Return("index-doc-1", nil)
+		svc := &gateway.Service{Repository: repo, QdrantClient: qdrant, Temporal: temporal, Logger: zerolog.Nop()}
+
+		_, err := svc.ReindexDocument(ctx, "doc-1", nil)
+
+		require.NoError(t, err)
+		qdrant.AssertExpectations(t)
+		temporal.AssertExpectations(t)
+	})
+
+	t.Run("ReindexDocument_DeleteVectorsFails", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", Filename: "rates.pdf", Status: "failed"}, nil)
+		qdrant := mocks.NewMockQdrantClient()
+		qdrant.On("Collection").Return("documents")
+		qdrant.On("DeleteDocumentVectors", ctx, "documents", "doc-1").Return(errors.New("qdrant down"))
+		temporal := mocks.NewMockTemporalClient()
+		svc := &gateway.Service{Repository: repo, QdrantClient: qdrant, Temporal: temporal, Logger: zerolog.Nop()}
+
+		_, err := svc.ReindexDocument(ctx, "doc-1", nil)
+
+		assert.Equal(t, gateway.KindInternal, gateway.KindOf(err))
+		temporal.AssertNotCalled(t, "StartIndexWorkflow", mock.Anything, mock.Anything)
+		repo.AssertNotCalled(t, "SetDocumentIndexing", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("ReindexDocument_StatusUpdateFails", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", Filename: "rates.pdf", Status: "complete"}, nil)
+		repo.On("SetDocumentIndexing", ctx, "doc-1", "index-doc-1").Return(errors.New("db down"))
+		temporal := mocks.NewMockTemporalClient()
+		temporal.On("StartIndexWorkflow", ctx, services.IndexWorkflowInput{DocumentID: "doc-1"}).Return("index-doc-1", nil)
+		svc := &gateway.Service{Repository: repo, Temporal: temporal, Logger: zerolog.Nop()}
+
+		doc, err := svc.ReindexDocument(ctx, "doc-1", nil)
+
+		assert.Nil(t, doc)
+		assert.Equal(t, gateway.KindInternal, gateway.KindOf(err))
+		assert.Equal(t, "Failed to update document status", gateway.MessageOf(err))
+	})
+
+	t.Run("ReindexDocument_Refused", func(t *testing.T) {
+		deletedAt := time.Now()
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "trashed").Return(&models.Document{ID: "trashed", Filename: "a.pdf", Status: "complete", DeletedAt: &deletedAt}, nil)
+		repo.On("GetDocument", ctx, "indexing").Return(&models.Document{ID: "indexing", Filename: "a.pdf", Status: "indexing"}, nil)
+		repo.On("GetDocument", ctx, "pending").Return(&models.Document{ID: "pending", Filename: "a.pdf", Status: "pending"}, nil)
+		svc := &gateway.Service{Repository: repo, Temporal: mocks.NewMockTemporalClient(), Logger: zerolog.Nop()}
+
+		_, err := svc.ReindexDocument(ctx, "trashed", nil)
+		assert.Equal(t, gateway.KindConflict, gateway.KindOf(err))
+
+		_, err = svc.ReindexDocument(ctx, "indexing", nil)
+		assert.Equal(t, gateway.KindConflict, gateway.KindOf(err))
+
+		_, err = svc.ReindexDocument(ctx, "pending", nil)
+		assert.Equal(t, gateway.KindInvalid, gateway.KindOf(err))
+	})
+
+	t.Run("CompleteUpload_Review", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", S3Key: "documents/doc-1/a.pdf", Status: "pending", UploadedBy: "alice"}, nil)
+		repo.On("UpdateDocumentStatus", ctx, "doc-1", "pending_review", "").Return(nil)
+		repo.On("CreateDocumentEvent", mock.Anything, mock.MatchedBy(func(event *models.DocumentEvent) bool {
+			return event.Type == models.DocumentEventReviewRequested
+		})).Return(nil)
+		s3 := mocks.NewMockS3Client()
+		s3.On("ObjectExists", ctx, "documents/doc-1/a.pdf").Return(true, nil)
+		temporal := mocks.NewMockTemporalClient()
+		svc := &gateway.Service{Repository: repo, S3Client: s3, Temporal: temporal, Reviewers: []string{"rita"}, Logger: zerolog.Nop()}
+
+		doc, err := svc.CompleteUpload(ctx, "doc-1", nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, "pending_review", doc.Status)
+		repo.AssertExpectations(t)
+		temporal.AssertNotCalled(t, "SignalUploadComplete", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		repo.AssertNotCalled(t, "SetDocumentIndexing", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("ApproveDocument_Success", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", Filename: "a.pdf", Status: "pending_review", UploadedBy: "alice"}, nil)
+		repo.On("SetDocumentIndexing", ctx, "doc-1", "upload-doc-1").Return(nil)
+		repo.On("CreateDocumentEvent", mock.Anything, mock.MatchedBy(func(event *models.DocumentEvent) bool {
+			return event.Type == models.DocumentEventApproved && event.Data["reviewed_by"] == "rita"
+		})).Return(nil)
+		temporal := mocks.NewMockTemporalClient()
+		temporal.On("SignalUploadComplete", ctx, "doc-1", mock.Anything, (*models.ProcessingOptions)(nil)).Return(nil)
+		svc := &gateway.Service{Repository: repo, Temporal: temporal, Reviewers: []string{"rita"}, Logger: zerolog.Nop()}
+
+		doc, err := svc.ApproveDocument(ctx, "doc-1", "rita", "")
+
+		require.NoError(t, err)
+		assert.Equal(t, "indexing", doc.Status)
+		assert.Equal(t, "upload-doc-1", doc.WorkflowID)
+		repo.AssertExpectations(t)
+		temporal.AssertExpectations(t)
+	})
+
+	t.Run("ApproveDocument_UploadWorkflowGone", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", Filename: "a.pdf", Status: "pending_review", UploadedBy: "alice"}, nil)
+		repo.On("SetDocumentIndexing", ctx, "doc-1", "index-doc-1").Return(nil)
+		repo.On("CreateDocumentEvent", mock.Anything, mock.Anything).Return(nil)
+		temporal := mocks.NewMockTemporalClient()
+		temporal.On("SignalUploadComplete", ctx, "doc-1", mock.Anything, (*models.ProcessingOptions)(nil)).Return(fmt.Errorf("%w: upload-doc-1", services.ErrWorkflowNotFound))
+		temporal.On("StartIndexWorkflow", ctx, services.IndexWorkflowInput{DocumentID: "doc-1"}).Return("index-doc-1", nil)
+		svc := &gateway.Service{Repository: repo, Temporal: temporal, Reviewers: []string{"rita"}, Logger: zerolog.Nop()}
+
+		doc, err := svc.ApproveDocument(ctx, "doc-1", "rita", "")
+
+		require.NoError(t, err)
+		assert.Equal(t, "index-doc-1", doc.WorkflowID)
+		temporal.AssertExpectations(t)
+	})
+
+	t.Run("ApproveDocument_Refused", func(t *testing.T) {
+		tests := []struct {
+			name      string
+			reviewers []string
+			username  string
+			status    string
+			kind      gateway.Kind
+			message   string
+		}{
+			{"disabled", nil, "rita", "pending_review", gateway.KindUnavailable, "Document review is not enabled"},
+			{"not a reviewer", []string{"rita"}, "bob", "pending_review", gateway.KindForbidden, "Only reviewers can review documents"},
+			{"own upload", []string{"rita", "alice"}, "alice", "pending_review", gateway.KindForbidden, "Documents cannot be approved by their uploader"},
+			{"not awaiting review", []string{"rita"}, "rita", "complete", gateway.KindConflict, "Document is not awaiting review"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				repo := repomocks.NewMockRepository()
+				repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", Filename: "a.pdf", Status: tt.status, UploadedBy: "alice"}, nil)
+				temporal := mocks.NewMockTemporalClient()
+				svc := &gateway.Service{Repository: repo, Temporal: temporal, Reviewers: tt.reviewers, Logger: zerolog.Nop()}
+
+				_, err := svc.ApproveDocument(ctx, "doc-1", tt.username, "")
+
+				assert.Equal(t, tt.kind, gateway.KindOf(err))
+				assert.Equal(t, tt.message, gateway.MessageOf(err))
+				temporal.AssertNotCalled(t, "SignalUploadComplete", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+				repo.AssertNotCalled(t, "SetDocumentIndexing", mock.Anything, mock.Anything, mock.Anything)
+			})
+		}
+	})
+
+	t.Run("RejectDocument_Success", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", Filename: "a.pdf", Status: "pending_review", UploadedBy: "alice"}, nil)
+		repo.On("UpdateDocumentStatus", ctx, "doc-1", "rejected", "Contains customer data").Return(nil)
+		repo.On("CreateDocumentEvent", mock.Anything, mock.MatchedBy(func(event *models.DocumentEvent) bool {
+			return event.Type == models.DocumentEventRejected && event.Data["comment"] == "Contains customer data"
+		})).Return(nil)
+		temporal := mocks.NewMockTemporalClient()
+		temporal.On("CancelWorkflow", ctx, "upload-doc-1").Return(nil)
+		svc := &gateway.Service{Repository: repo, Temporal: temporal, Reviewers: []string{"rita"}, Logger: zerolog.Nop()}
+
+		doc, err := svc.RejectDocument(ctx, "doc-1", "rita", "Contains customer data")
+
+		require.NoError(t, err)
+		assert.Equal(t, "rejected", doc.Status)
+		assert.Equal(t, "Contains customer data", doc.ErrorMessage)
+		repo.AssertExpectations(t)
+		temporal.AssertExpectations(t)
+	})
+
+	t.Run("RejectDocument_UploadWorkflowGone", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", Filename: "a.pdf", Status: "pending_review", UploadedBy: "rita"}, nil)
+		repo.On("UpdateDocumentStatus", ctx, "doc-1", "rejected", "").Return(nil)
+		repo.On("CreateDocumentEvent", mock.Anything, mock.Anything).Return(nil)
+		temporal := mocks.NewMockTemporalClient()
+		temporal.On("CancelWorkflow", ctx, "upload-doc-1").Return(fmt.Errorf("%w: upload-doc-1", services.ErrWorkflowNotFound))
+		svc := &gateway.Service{Repository: repo, Temporal: temporal, Reviewers: []string{"rita"}, Logger: zerolog.Nop()}
+
+		// Reviewers may reject their own uploads.
+		doc, err := svc.RejectDocument(ctx, "doc-1", "rita", "")
+
+		require.NoError(t, err)
+		assert.Equal(t, "rejected", doc.Status)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("ReindexDocument_PendingReview", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", Filename: "a.pdf", Status: "pending_review"}, nil)
+		temporal := mocks.NewMockTemporalClient()
+		svc := &gateway.Service{Repository: repo, Temporal: temporal, Logger: zerolog.Nop()}
+
+		_, err := svc.ReindexDocument(ctx, "doc-1", nil)
+
+		assert.Equal(t, gateway.KindConflict, gateway.KindOf(err))
+		assert.Equal(t, "Document is awaiting review", gateway.MessageOf(err))
+		temporal.AssertNotCalled(t, "StartIndexWorkflow", mock.Anything, mock.Anything)
+	})
+
+	t.Run("CreateTextDocument_Success", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetWorkspaceSettings", mock.Anything).Return(nil, nil)
+		repo.On("CreateDocument", ctx, mock.MatchedBy(func(doc *models.Document) bool {
+			return doc.Filename == "Standup 2026-10-16.md" && doc.FileSize == 14 &&
+				doc.UploadedBy == "alice" && doc.Metadata["team"] == "search"
+		})).Return(nil)
+		repo.On("CreateDocumentEvent", mock.Anything, mock.Anything).Return(nil)
+		s3 := mocks.NewMockS3Client()
+		s3.On("UploadObject", ctx, mock.MatchedBy(func(key string) bool {
+			return strings.HasSuffix(key, "/Standup 2026-10-16.md")
+		}), mock.Anything, "text/markdown; charset=utf-8").Return(nil)
+		temporal := mocks.NewMockTemporalClient()
+		temporal.On("StartUploadWorkflow", ctx, mock.Anything).Return("upload-1", nil)
+		temporal.On("SignalUploadComplete", ctx, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		repo.On("SetDocumentIndexing", ctx, mock.Anything, mock.Anything).Return(nil)
+		svc := &gateway.Service{Repository: repo, S3Client: s3, Temporal: temporal, Logger: zerolog.Nop()}
+
+		doc, err := svc.CreateTextDocument(ctx, models.CreateTextDocumentRequest{
+			Title:    "Standup 2026-10-16",
+			Content:  "# Notes\n- ship",
+			Metadata: map[string]string{"team": "search"},
+		}, "alice")
+
+		require.NoError(t, err)
+		assert.Equal(t, "indexing", doc.Status)
+		assert.Empty(t, doc.UploadURL)
+		repo.AssertExpectations(t)
+		s3.AssertExpectations(t)
+		temporal.AssertExpectations(t)
+	})
+
+	t.Run("CreateTextDocument_PlainTitleWithSlash", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetWorkspaceSettings", mock.Anything).Return(nil, nil)
+		repo.On("CreateDocument", ctx, mock.MatchedBy(func(doc *models.Document) bool {
+			return doc.Filename == "Q3-Q4 plan.txt"
+		})).Return(nil)
+		repo.On("CreateDocumentEvent", mock.Anything, mock.Anything).Return(nil)
+		s3 := mocks.NewMockS3Client()
+		s3.On("UploadObject", ctx, mock.Anything, mock.Anything, "text/plain; charset=utf-8").Return(nil)
+		temporal := mocks.NewMockTemporalClient()
+		temporal.On("StartUploadWorkflow", ctx, mock.Anything).Return("upload-1", nil)
+		temporal.On("SignalUploadComplete", ctx, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		repo.On("SetDocumentIndexing", ctx, mock.Anything, mock.Anything).Return(nil)
+		svc := &gateway.Service{Repository: repo, S3Client: s3, Temporal: temporal, Logger: zerolog.Nop()}
+
+		_, err := svc.CreateTextDocument(ctx, models.CreateTextDocumentRequest{
+			Title: "Q3/Q4 plan", Content: "plan", Format: models.TextFormatPlain,
+		}, "alice")
+
+		require.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("CreateTextDocument_Invalid", func(t *testing.T) {
+		s3 := mocks.NewMockS3Client()
+		svc := &gateway.Service{Repository: repomocks.NewMockRepository(), S3Client: s3, Logger: zerolog.Nop()}
+
+		for name, req := range map[string]models.CreateTextDocumentRequest{
+			"format":    {Title: "notes", Content: "x", Format: "html"},
+			"too large": {Title: "notes", Content: strings.Repeat("x", gateway.MaxTextDocumentSize+1)},
+			"blank":     {Title: "  ", Content: "x"},
+		} {
+			_, err := svc.CreateTextDocument(ctx, req, "alice")
+			assert.Equal(t, gateway.KindInvalid, gateway.KindOf(err), name)
+		}
+		s3.AssertNotCalled(t, "UploadObject", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("SyncConnectorFile_New", func(t *testing.T) {
+		modifiedAt := time.Now()
+		repo := repomocks.NewMockRepository()
+		repo.On("GetWorkspaceSettings", mock.Anything).Return(nil, nil)
+		repo.On("GetConnector", ctx, "c-1").Return(&models.Connector{ID: "c-1", Username: "alice"}, nil)
+		repo.On("GetConnectorFile", ctx, "c-1", "file-1").Return(nil, nil)
+		repo.On("CreateDocument", ctx, mock.MatchedBy(func(doc *models.Document) bool {
+			return doc.Filename == "guide.pdf" && doc.UploadedBy == "alice"
+		})).Return(nil)
+		repo.On("CreateDocumentEvent", mock.Anything, mock.Anything).Return(nil)
+		repo.On("UpsertConnectorFile", ctx, mock.MatchedBy(func(file *models.ConnectorFile) bool {
+			return file.ExternalID == "file-1" && file.DocumentID != "" && file.ModifiedAt.Equal(modifiedAt)
+		})).Return(nil)
+		s3 := mocks.NewMockS3Client()
+		s3.On("GeneratePresignedUploadURL", ctx, mock.Anything, mock.Anything, mock.Anything).Return("https://s3/upload", nil)
+		temporal := mocks.NewMockTemporalClient()
+		temporal.On("StartUploadWorkflow", ctx, mock.Anything).Return("upload-1", nil)
+		svc := &gateway.Service{Repository: repo, S3Client: s3, Temporal: temporal, Logger: zerolog.Nop()}
+
+		resp, err := svc.SyncConnectorFile(ctx, "c-1", models.ConnectorFileRequest{
+			ExternalID: "file-1", Filename: "guide.pdf", FileSize: 10, ModifiedAt: modifiedAt,
+		})
+
+		require.NoError(t, err)
+		assert.False(t, resp.Unchanged)
+		require.NotNil(t, resp.Document)
+		assert.Equal(t, "https://s3/upload", resp.Document.UploadURL)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("SyncConnectorFile_Unchanged", func(t *testing.T) {
+		modifiedAt := time.Now()
+		repo := repomocks.NewMockRepository()
+		repo.On("GetConnector", ctx, "c-1").Return(&models.Connector{ID: "c-1", Username: "alice"}, nil)
+		repo.On("GetConnectorFile", ctx, "c-1", "file-1").Return(&models.ConnectorFile{
+			ConnectorID: "c-1", ExternalID: "file-1", DocumentID: "doc-1", ModifiedAt: modifiedAt,
+		}, nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		resp, err := svc.SyncConnectorFile(ctx, "c-1", models.ConnectorFileRequest{
+			ExternalID: "file-1", Filename: "guide.pdf", ModifiedAt: modifiedAt,
+		})
+
+		require.NoError(t, err)
+		assert.True(t, resp.Unchanged)
+		assert.Nil(t, resp.Document)
+		repo.AssertNotCalled(t, "CreateDocument", mock.Anything, mock.Anything)
+	})
+
+	t.Run("SyncConnectorFile_ChangedReplacesDocument", func(t *testing.T) {
+		modifiedAt := time.Now()
+		repo := repomocks.NewMockRepository()
+		repo.On("GetWorkspaceSettings", mock.Anything).Return(nil, nil)
+		repo.On("GetConnector", ctx, "c-1").Return(&models.Connector{ID: "c-1", Username: "alice"}, nil)
+		repo.On("GetConnectorFile", ctx, "c-1", "file-1").Return(&models.ConnectorFile{
+			ConnectorID: "c-1", ExternalID: "file-1", DocumentID: "doc-old", ModifiedAt: modifiedAt.Add(-time.Hour),
+		}, nil)
+		repo.On("CreateDocument", ctx, mock.Anything).Return(nil)
+		repo.On("CreateDocumentEvent", mock.Anything, mock.Anything).Return(nil)
+		repo.On("UpsertConnectorFile", ctx, mock.Anything).Return(nil)
+		repo.On("GetDocument", ctx, "doc-old").Return(&models.Document{ID: "doc-old", S3Key: "uploads/doc-old"}, nil)
+		repo.On("TrashDocument", ctx, "doc-old", mock.AnythingOfType("time.Time")).Return(true, nil)
+		repo.On("DeleteDocument", ctx, "doc-old").Return(nil)
+		s3 := mocks.NewMockS3Client()
+		s3.On("GeneratePresignedUploadURL", ctx, mock.Anything, mock.Anything, mock.Anything).Return("https://s3/upload", nil)
+		s3.On("DeleteObject", ctx, "uploads/doc-old").Return(nil)
+		temporal := mocks.NewMockTemporalClient()
+		temporal.On("StartUploadWorkflow", ctx, mock.Anything).Return("upload-1", nil)
+		qdrant := mocks.NewMockQdrantClient()
+		qdrant.On("Collection").Return("documents")
+		qdrant.On("DeleteDocumentVectors", ctx, "documents", "doc-old").Return(nil)
+		svc := &gateway.Service{Repository: repo, S3Client: s3, Temporal: temporal, QdrantClient: qdrant, Logger: zerolog.Nop()}
+
+		resp, err := svc.SyncConnectorFile(ctx, "c-1", models.ConnectorFileRequest{
+			ExternalID: "file-1", Filename: "guide.pdf", ModifiedAt: modifiedAt,
+		})
+
+		require.NoError(t, err)
+		require.NotNil(t, resp.Document)
+		repo.AssertCalled(t, "DeleteDocument", ctx, "doc-old")
+	})
+
+	t.Run("SyncConnectorFile_ConnectorNotFound", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetConnector", ctx, "missing").Return(nil, nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		_, err := svc.SyncConnectorFile(ctx, "missing", models.ConnectorFileRequest{ExternalID: "file-1"})
+
+		assert.Equal(t, gateway.KindNotFound, gateway.KindOf(err))
+	})
+
+	t.Run("SyncConnectorFile_SameContentHash", func(t *testing.T) {
+		modifiedAt := time.Now()
+		repo := repomocks.NewMockRepository()
+		repo.On("GetConnector", ctx, "c-1").Return(&models.Connector{ID: "c-1", Username: "alice"}, nil)
+		repo.On("GetConnectorFile", ctx, "c-1", "file-1").Return(&models.ConnectorFile{
+			ConnectorID: "c-1", ExternalID: "file-1", DocumentID: "doc-1", ModifiedAt: modifiedAt.Add(-time.Hour), ContentHash: "abc",
+		}, nil)
+		repo.On("UpsertConnectorFile", ctx, mock.MatchedBy(func(file *models.ConnectorFile) bool {
+			return file.DocumentID == "doc-1" && file.ModifiedAt.Equal(modifiedAt)
+		})).Return(nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		resp, err := svc.SyncConnectorFile(ctx, "c-1", models.ConnectorFileRequest{
+			ExternalID: "file-1", Filename: "guide.pdf", ModifiedAt: modifiedAt, ContentHash: "abc",
+		})
+
+		require.NoError(t, err)
+		assert.True(t, resp.Unchanged)
+		repo.AssertNotCalled(t, "CreateDocument", mock.Anything, mock.Anything)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("SetDocumentResyncSchedule_Success", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{
+			ID: "doc-1", Metadata: map[string]string{models.DocumentSourceURLKey: "https://example.com/guide"},
+		}, nil)
+		repo.On("UpsertResyncSchedule", ctx, mock.MatchedBy(func(schedule *models.ResyncSchedule) bool {
+			return schedule.SourceType == models.ResyncSourceDocument && schedule.Cron == "0 6 * * *" && schedule.CreatedBy == "alice"
+		})).Return(nil)
+		repo.On("GetResyncSchedule", ctx, models.ResyncSourceDocument, "doc-1").Return(&models.ResyncSchedule{
+			SourceType: models.ResyncSourceDocument, SourceID: "doc-1", Cron: "0 6 * * *",
+		}, nil)
+		temporal := mocks.NewMockTemporalClient()
+		temporal.On("ScheduleDocumentResync", ctx, "0 6 * * *", services.ResyncDocumentWorkflowInput{
+			DocumentID: "doc-1", SourceURL: "https://example.com/guide",
+		}).Return(nil)
+		svc := &gateway.Service{Repository: repo, Temporal: temporal, Logger: zerolog.Nop()}
+
+		schedule, err := svc.SetDocumentResyncSchedule(ctx, "doc-1", "0 6 * * *", "alice")
+
+		require.NoError(t, err)
+		assert.Equal(t, "0 6 * * *", schedule.Cron)
+		temporal.AssertExpectations(t)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("SetDocumentResyncSchedule_InvalidCron", func(t *testing.T) {
+		svc := &gateway.Service{Repository: repomocks.NewMockRepository(), Logger: zerolog.Nop()}
+
+		for _, cron := range []string{"", "every day", "0 6 * *", "0 6 * * * *", "0 6 * * $"} {
+			_, err := svc.SetDocumentResyncSchedule(ctx, "doc-1", cron, "alice")
+			assert.Equal(t, gateway.KindInvalid, gateway.KindOf(err), cron)
+		}
+	})
+
+	t.Run("SetDocumentResyncSchedule_NoSourceURL", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1"}, nil)
+		temporal := mocks.NewMockTemporalClient()
+		svc := &gateway.Service{Repository: repo, Temporal: temporal, Logger: zerolog.Nop()}
+
+		_, err := svc.SetDocumentResyncSchedule(ctx, "doc-1", "@daily", "alice")
+
+		assert.Equal(t, gateway.KindInvalid, gateway.KindOf(err))
+		temporal.AssertNotCalled(t, "ScheduleDocumentResync", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("ResyncDocument_Unchanged", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetResyncSchedule", ctx, models.ResyncSourceDocument, "doc-1").Return(&models.ResyncSchedule{
+			SourceType: models.ResyncSourceDocument, SourceID: "doc-1", ContentHash: "abc",
+		}, nil)
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", S3Key: "documents/doc-1/guide"}, nil)
+		repo.On("RecordResyncCheck", ctx, models.ResyncSourceDocument, "doc-1", "abc", mock.Anything).Return(nil)
+		temporal := mocks.NewMockTemporalClient()
+		svc := &gateway.Service{Repository: repo, Temporal: temporal, Logger: zerolog.Nop()}
+
+		resp, err := svc.ResyncDocument(ctx, "doc-1", "abc")
+
+		require.NoError(t, err)
+		assert.False(t, resp.Changed)
+		assert.Empty(t, resp.UploadURL)
+		temporal.AssertNotCalled(t, "StartUploadWorkflow", mock.Anything, mock.Anything)
+	})
+
+	t.Run("ResyncDocument_Changed", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetResyncSchedule", ctx, models.ResyncSourceDocument, "doc-1").Return(&models.ResyncSchedule{
+			SourceType: models.ResyncSourceDocument, SourceID: "doc-1", ContentHash: "abc",
+		}, nil)
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", S3Key: "documents/doc-1/guide"}, nil)
+		repo.On("SetDocumentUploadURL", ctx, "doc-1", mock.Anything, mock.Anything).Return(nil)
+		repo.On("UpdateDocumentStatus", ctx, "doc-1", "pending", "").Return(nil)
+		repo.On("RecordResyncCheck", ctx, models.ResyncSourceDocument, "doc-1", "def", mock.Anything).Return(nil)
+		repo.On("CreateDocumentEvent", mock.Anything, mock.MatchedBy(func(event *models.DocumentEvent) bool {
+			return event.Type == models.DocumentEventResynced
+		})).Return(nil)
+		s3 := mocks.NewMockS3Client()
+		s3.On("GeneratePresignedUploadURL", ctx, "documents/doc-1/guide", mock.Anything, mock.Anything).Return("https://s3/upload", nil)
+		temporal := mocks.NewMockTemporalClient()
+		temporal.On("StartUploadWorkflow", ctx, services.UploadWorkflowInput{DocumentID: "doc-1", S3Key: "documents/doc-1/guide"}).Return("upload-doc-1", nil)
+		svc := &gateway.Service{Repository: repo, S3Client: s3, Temporal: temporal, Logger: zerolog.Nop()}
+
+		resp, err := svc.ResyncDocument(ctx, "doc-1", "def")
+
+		require.NoError(t, err)
+		assert.True(t, resp.Changed)
+		assert.Equal(t, "https://s3/upload", resp.UploadURL)
+		repo.AssertExpectations(t)
+		temporal.AssertExpectations(t)
+	})
+
+	t.Run("ResyncDocument_DeletedDocumentRemovesSchedule", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetResyncSchedule", ctx, models.ResyncSourceDocument, "doc-1").Return(&models.ResyncSchedule{
+			SourceType: models.ResyncSourceDocument, SourceID: "doc-1",
+		}, nil)
+		repo.On("GetDocument", ctx, "doc-1").Return(nil, nil)
+		repo.On("DeleteResyncSchedule", ctx, models.ResyncSourceDocument, "doc-1").Return(nil)
+		temporal := mocks.NewMockTemporalClient()
+		temporal.On("DeleteResyncSchedule", ctx, models.ResyncSourceDocument, "doc-1").Return(nil)
+		svc := &gateway.Service{Repository: repo, Temporal: temporal, Logger: zerolog.Nop()}
+
+		_, err := svc.ResyncDocument(ctx, "doc-1", "abc")
+
+		assert.Equal(t, gateway.KindNotFound, gateway.KindOf(err))
+		repo.AssertExpectations(t)
+		temporal.AssertExpectations(t)
+	})
+
+	t.Run("GetConversationMessages_Sources", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetConversation", ctx, "conv-1").Return(&models.Conversation{ID: "conv-1", CreatedBy: "alice"}, nil)
+		repo.On("GetMessagesByConversationID", ctx, "conv-1", 50, 0).Return([]*models.Message{
+			{ID: "msg-1", Role: "user", Content: "refund policy?"},
+			{ID: "msg-2", Role: "assistant", Content: "30 days.", Metadata: map[string]string{"query_id": "q-1"}},
+			{ID: "msg-3", Role: "assistant", Content: "No.", Metadata: map[string]string{"query_id": "q-2"}},
+		}, nil)
+		repo.On("ListQueryCitations", ctx, []string{"q-1", "q-2"}).Return(map[string][]models.Citation{
+			"q-1": {
+				{DocumentID: "doc-1", ChunkID: "c-1", Score: 0.9, Page: 4, StartChar: 120, EndChar: 480},
+				{DocumentID: "doc-2", ChunkID: "c-9", Score: 0.7},
+				{DocumentID: "doc-1", ChunkID: "c-2", Score: 0.6, Page: 5},
+			},
+		}, nil)
+		repo.On("GetDocumentsByIDs", ctx, []string{"doc-1", "doc-2"}).Return([]*models.Document{
+			{ID: "doc-1", Filename: "Policy.PDF", S3Key: "documents/doc-1/Policy.PDF"},
+		}, nil)
+		s3 := mocks.NewMockS3Client()
+		s3.On("GeneratePresignedDownloadURL", ctx, "documents/doc-1/Policy.PDF", time.Hour).Return("https://s3/policy", nil).Once()
+		svc := &gateway.Service{Repository: repo, S3Client: s3, Logger: zerolog.Nop()}
+
+		messages, err := svc.GetConversationMessages(ctx, "conv-1", "alice", 50, 0)
+
+		require.NoError(t, err)
+		assert.Empty(t, messages[0].Sources)
+		require.Len(t, messages[1].Sources, 3)
+		assert.Equal(t, models.MessageSource{
+			Citation:   models.Citation{DocumentID: "doc-1", ChunkID: "c-1", Score: 0.9, Page: 4, StartChar: 120, EndChar: 480},
+			Filename:   "Policy.PDF",
+			PreviewURL: "https://s3/policy#page=4",
+		}, messages[1].Sources[0])
+		// doc-2 was deleted since it was cited.
+		assert.Equal(t, models.MessageSource{Citation: models.Citation{DocumentID: "doc-2", ChunkID: "c-9", Score: 0.7}}, messages[1].Sources[1])
+		assert.Equal(t, "https://s3/policy#page=5", messages[1].Sources[2].PreviewURL)
+		assert.Empty(t, messages[2].Sources)
+		s3.AssertExpectations(t)
+	})
+
+	t.Run("GetConversationMessages_Participant", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetConversation", ctx, "conv-1").Return(&models.Conversation{ID: "conv-1", CreatedBy: "alice"}, nil)
+		repo.On("GetConversationParticipant", ctx, "conv-1", "bob").Return(&models.ConversationParticipant{ConversationID: "conv-1", Username: "bob"}, nil)
+		repo.On("GetMessagesByConversationID", ctx, "conv-1", 50, 0).Return([]*models.Message{
+			{ID: "msg-1", Role: "user", Content: "refund policy?"},
+		}, nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		messages, err := svc.GetConversationMessages(ctx, "conv-1", "bob", 50, 0)
+
+		require.NoError(t, err)
+		assert.Len(t, messages, 1)
+	})
+
+	t.Run("GetConversationMessages_OtherUsersConversation", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetConversation", ctx, "conv-1").Return(&models.Conversation{ID: "conv-1", CreatedBy: "alice"}, nil)
+		repo.On("GetConversationParticipant", ctx, "conv-1", "mallory").Return(nil, nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		_, err := svc.GetConversationMessages(ctx, "conv-1", "mallory", 50, 0)
+
+		assert.Equal(t, gateway.KindNotFound, gateway.KindOf(err))
+		repo.AssertNotCalled(t, "GetMessagesByConversationID", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("GetConversationMessages_LegacyConversation", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetConversation", ctx, "conv-1").Return(&models.Conversation{ID: "conv-1"}, nil)
+		repo.On("GetConversationParticipant", ctx, "conv-1", "bob").Return(nil, nil)
+		repo.On("ListConversationParticipants", ctx, "conv-1").Return(nil, nil)
+		repo.On("GetMessagesByConversationID", ctx, "conv-1", 50, 0).Return([]*models.Message{
+			{ID: "msg-1", Role: "user", Content: "refund policy?"},
+		}, nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		messages, err := svc.GetConversationMessages(ctx, "conv-1", "bob", 50, 0)
+
+		require.NoError(t, err)
+		assert.Len(t, messages, 1)
+	})
+
+	t.Run("GetConversationMessages_LegacyConversationGuest", func(t *testing.T) {
+		guestCtx := gateway.WithGuest(ctx)
+		repo := repomocks.NewMockRepository()
+		repo.On("GetConversation", guestCtx, "conv-1").Return(&models.Conversation{ID: "conv-1"}, nil)
+		repo.On("GetConversationParticipant", guestCtx, "conv-1", "demo:guest").Return(nil, nil)
+		repo.On("ListConversationParticipants", guestCtx, "conv-1").Return(nil, nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		_, err := svc.GetConversationMessages(guestCtx, "conv-1", "demo:guest", 50, 0)
+
+		assert.Equal(t, gateway.KindNotFound, gateway.KindOf(err))
+		repo.AssertNotCalled(t, "GetMessagesByConversationID", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("GetConversationMessages_LegacyConversationShared", func(t *testing.T) {
+		// Once shared, it is read by its participants only.
+		repo := repomocks.NewMockRepository()
+		repo.On("GetConversation", ctx, "conv-1").Return(&models.Conversation{ID: "conv-1"}, nil)
+		repo.On("GetConversationParticipant", ctx, "conv-1", "mallory").Return(nil, nil)
+		repo.On("ListConversationParticipants", ctx, "conv-1").Return([]*models.ConversationParticipant{
+			{ConversationID: "conv-1", Username: "alice"},
+		}, nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		_, err := svc.GetConversationMessages(ctx, "conv-1", "mallory", 50, 0)
+
+		assert.Equal(t, gateway.KindNotFound, gateway.KindOf(err))
+		repo.AssertNotCalled(t, "GetMessagesByConversationID", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("GetConversationMessages_CitationsFailure", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetConversation", ctx, "conv-1").Return(&models.Conversation{ID: "conv-1", CreatedBy: "alice"}, nil)
+		repo.On("GetMessagesByConversationID", ctx, "conv-1", 50, 0).Return([]*models.Message{
+			{ID: "msg-2", Role: "assistant", Content: "30 days.", Metadata: map[string]string{"query_id": "q-1"}},
+		}, nil)
+		repo.On("ListQueryCitations", ctx, []string{"q-1"}).Return(nil, errors.New("db down"))
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		messages, err := svc.GetConversationMessages(ctx, "conv-1", "alice", 50, 0)
+
+		require.NoError(t, err)
+		require.Len(t, messages, 1)
+		assert.Empty(t, messages[0].Sources)
+	})
+
+	t.Run("GetConversationMessages_Redacted", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetConversation", ctx, "conv-1").Return(&models.Conversation{ID: "conv-1", CreatedBy: "alice"}, nil)
+		repo.On("GetMessagesByConversationID", ctx, "conv-1", 50, 0).Return([]*models.Message{
+			{ID: "msg-1", Role: "user", Content: "Who runs Falcon?"},
+			{ID: "msg-2", Role: "assistant", Content: "Falcon is run by Alice.", Metadata: map[string]string{"query_id": "q-1"}},
+			{ID: "msg-3", Role: "assistant", Content: "Nothing secret."},
+		}, nil)
+		repo.On("ListQueryCitations", ctx, []string{"q-1"}).Return(map[string][]models.Citation{
+			"q-1": {{DocumentID: "doc-1", ChunkID: "c-1", Text: "Falcon launches in May."}},
+		}, nil)
+		repo.On("GetDocumentsByIDs", ctx, []string{"doc-1"}).Return(nil, nil)
+		redactions := mocks.NewMockRedactions()
+		redactions.On("Redactor", ctx).Return(services.NewRedactor([]*models.RedactionRule{
+			{Type: models.RedactionKeywords, Keywords: []string{"falcon"}},
+		}), nil)
+		svc := &gateway.Service{Repository: repo, Redactions: redactions, Logger: zerolog.Nop()}
+
+		messages, err := svc.GetConversationMessages(ctx, "conv-1", "alice", 50, 0)
+
+		require.NoError(t, err)
+		require.Len(t, messages, 3)
+		assert.Equal(t, "Who runs Falcon?", messages[0].Content, "questions are not redacted")
+		assert.False(t, messages[0].Redacted)
+		assert.Equal(t, "[REDACTED] is run by Alice.", messages[1].Content)
+		assert.Equal(t, "[REDACTED] launches in May.", messages[1].Sources[0].Text)
+		assert.True(t, messages[1].Redacted)
+		assert.False(t, messages[2].Redacted)
+	})
+
+	t.Run("ExportMessage_PDF", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetConversation", ctx, "conv-1").Return(&models.Conversation{ID: "conv-1", CreatedBy: "alice"}, nil)
+		repo.On("GetMessage", ctx, "msg-2").Return(&models.Message{
+			ID: "msg-2", ConversationID: "conv-1", Role: "assistant", Content: "30 days.",
+			Metadata: map[string]string{"query_id": "q-1"},
+		}, nil)
+		repo.On("ListQueryCitations", ctx, []string{"q-1"}).Return(map[string][]models.Citation{
+			"q-1": {{DocumentID: "doc-1", ChunkID: "c-1", Score: 0.9, Page: 4}},
+		}, nil)
+		repo.On("GetDocumentsByIDs", ctx, []string{"doc-1"}).Return([]*models.Document{
+			{ID: "doc-1", Filename: "policy.pdf", S3Key: "documents/doc-1/policy.pdf"},
+		}, nil)
+		s3 := mocks.NewMockS3Client()
+		s3.On("GeneratePresignedDownloadURL", ctx, "documents/doc-1/policy.pdf", time.Hour).Return("https://s3/policy", nil)
+		var uploaded string
+		s3.On("UploadObject", ctx, mock.MatchedBy(func(key string) bool {
+			return strings.HasPrefix(key, "exports/") && strings.HasSuffix(key, "/answer-msg-2.pdf")
+		}), mock.Anything, "application/pdf").Run(func(args mock.Arguments) {
+			body, _ := io.ReadAll(args.Get(2).(io.Reader))
+			uploaded = string(body)
+		}).Return(nil)
+		s3.On("GeneratePresignedDownloadURL", ctx, mock.MatchedBy(func(key string) bool {
+			return strings.HasPrefix(key, "exports/")
+		}), time.Hour).Return("https://s3/export", nil)
+		svc := &gateway.Service{Repository: repo, S3Client: s3, Logger: zerolog.Nop()}
+
+		resp, err := svc.ExportMessage(ctx, "msg-2", "pdf", "alice")
+
+		require.NoError(t, err)
+		assert.Equal(t, "https://s3/export", resp.URL)
+		assert.Equal(t, "pdf", resp.Format)
+		assert.True(t, strings.HasPrefix(uploaded, "%PDF-"))
+		assert.Contains(t, uploaded, "[1] policy.pdf, page 4")
+		s3.AssertExpectations(t)
+	})
+
+	t.Run("ExportMessage_UserMessage", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetMessage", ctx, "msg-1").Return(&models.Message{ID: "msg-1", ConversationID: "conv-1", Role: "user", Content: "refund policy?"}, nil)
+		repo.On("GetConversation", ctx, "conv-1").Return(&models.Conversation{ID: "conv-1", CreatedBy: "alice"}, nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		_, err := svc.ExportMessage(ctx, "msg-1", "pdf", "alice")
+
+		assert.Equal(t, gateway.KindInvalid, gateway.KindOf(err))
+	})
+
+	t.Run("ExportMessage_OtherUsersConversation", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetMessage", ctx, "msg-2").Return(&models.Message{ID: "msg-2", ConversationID: "conv-1", Role: "assistant", Content: "30 days."}, nil)
+		repo.On("GetConversation", ctx, "conv-1").Return(&models.Conversation{ID: "conv-1", CreatedBy: "alice"}, nil)
+		repo.On("GetConversationParticipant", ctx, "conv-1", "mallory").Return(nil, nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		_, err := svc.ExportMessage(ctx, "msg-2", "pdf", "mallory")
+
+		assert.Equal(t, gateway.KindNotFound, gateway.KindOf(err))
+		assert.Equal(t, "Message not found", gateway.MessageOf(err))
+	})
+
+	t.Run("ExportMessage_NotFound", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetMessage", ctx, "msg-9").Return(nil, nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		_, err := svc.ExportMessage(ctx, "msg-9", "pdf", "alice")
+
+		assert.Equal(t, gateway.KindNotFound, gateway.KindOf(err))
+	})
+
+	t.Run("ExportMessage_UnsupportedFormat", func(t *testing.T) {
+		svc := &gateway.Service{Logger: zerolog.Nop()}
+
+		_, err := svc.ExportMessage(ctx, "msg-2", "docx", "alice")
+
+		assert.Equal(t, gateway.KindInvalid, gateway.KindOf(err))
+	})
+
+	t.Run("SuggestQueries", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		suggestions := []models.QuerySuggestion{
+			{Text: "refund policy for annual plans", Source: models.SuggestionSourceQuery},
+			{Text: "Refund Policy", Source: models.SuggestionSourceDocument, DocumentID: "doc-1"},
+		}
+		repo.On("SuggestQueries", ctx, "refund", 2, gateway.DefaultSuggestions).Return(suggestions, nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		got, err := svc.SuggestQueries(ctx, "  refund ", 100)
+
+		require.NoError(t, err)
+		assert.Equal(t, suggestions, got)
+	})
+
+	t.Run("SuggestQueries_TooShort", func(t *testing.T) {
+		svc := &gateway.Service{Logger: zerolog.Nop()}
+
+		got, err := svc.SuggestQueries(ctx, "r", 5)
+
+		require.NoError(t, err)
+		assert.Empty(t, got)
+		assert.NotNil(t, got)
+	})
+
+	t.Run("SuggestQueries_Empty", func(t *testing.T) {
+		svc := &gateway.Service{Logger: zerolog.Nop()}
+
+		_, err := svc.SuggestQueries(ctx, " ", 5)
+
+		assert.Equal(t, gateway.KindInvalid, gateway.KindOf(err))
+	})
+
+	t.Run("Query_PublishesCompletion", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetConversation", mock.Anything, "conv-1").Return(&models.Conversation{ID: "conv-1", CreatedBy: "alice"}, nil)
+		repo.On("CreateQueryLog", mock.Anything, mock.Anything).Return(nil)
+		upstream := make(chan models.SSEEvent, 2)
+		upstream <- models.SSEEvent{Type: "chunk", Content: "hi"}
+		upstream <- models.SSEEvent{Type: "end", ID: "q-1"}
+		close(upstream)
+
+		core := mocks.NewMockCoreService()
+		core.On("Query", mock.Anything, models.CoreQueryRequest{Query: "what?", ConversationID: "conv-1", TopK: gateway.DefaultTopK}).Return((<-chan models.SSEEvent)(upstream), nil)
+		webhooks := mocks.NewMockWebhookDispatcher()
+		webhooks.On("Dispatch", mock.Anything, models.EventQueryCompleted, map[string]string{
+			"id": "q-1", "conversation_id": "conv-1", "username": "alice",
+		}).Return()
+		svc := &gateway.Service{CoreClient: core, Repository: repo, Webhooks: webhooks, Logger: zerolog.Nop()}
+
+		events, err := svc.Query(ctx, models.QueryRequest{Query: "what?", ConversationID: "conv-1"}, "alice")
+		require.NoError(t, err)
+
+		var received int
+		for range events {
+			received++
+		}
+
+		assert.Equal(t, 2, received)
+		webhooks.AssertExpectations(t)
+	})
+
+	t.Run("Query_OtherUsersConversation", func(t *testing.T) {
+		core := mocks.NewMockCoreService()
+		repo := repomocks.NewMockRepository()
+		repo.On("GetConversation", mock.Anything, "conv-1").Return(&models.Conversation{ID: "conv-1", CreatedBy: "alice"}, nil)
+		repo.On("GetConversationParticipant", mock.Anything, "conv-1", "mallory").Return(nil, nil)
+		svc := &gateway.Service{CoreClient: core, Repository: repo, Logger: zerolog.Nop()}
+
+		_, err := svc.Query(ctx, models.QueryRequest{Query: "what?", ConversationID: "conv-1"}, "mallory")
+
+		assert.Equal(t, gateway.KindNotFound, gateway.KindOf(err))
+		core.AssertNotCalled(t, "Query", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Query_ConversationBusy", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetConversation", mock.Anything, "conv-1").Return(&models.Conversation{ID: "conv-1", CreatedBy: "alice"}, nil)
+		repo.On("CreateQueryLog", mock.Anything, mock.Anything).Return(nil)
+		locks, err := services.NewConversationLocks(&config.ConversationConfig{QueryMode: config.ConversationQueryReject}, nil)
+		require.NoError(t, err)
+		release, err := locks.Acquire(ctx, "conv-1", "req-1")
+		require.NoError(t, err)
+		defer release()
+		core := mocks.NewMockCoreService()
+		svc := &gateway.Service{CoreClient: core, Repository: repo, Conversations: locks, Logger: zerolog.Nop()}
+
+		_, err = svc.Query(ctx, models.QueryRequest{Query: "what?", ConversationID: "conv-1"}, "alice")
+
+		assert.Equal(t, gateway.KindConversationBusy, gateway.KindOf(err))
+		assert.Equal(t, map[string]string{"active_request_id": "req-1"}, gateway.DetailsOf(err))
+		core.AssertNotCalled(t, "Query", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Query_ReleasesConversation", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetConversation", mock.Anything, "conv-1").Return(&models.Conversation{ID: "conv-1", CreatedBy: "alice"}, nil)
+		repo.On("CreateQueryLog", mock.Anything, mock.Anything).Return(nil)
+		upstream := make(chan models.SSEEvent, 1)
+		upstream <- models.SSEEvent{Type: "end", ID: "q-1"}
+		close(upstream)
+
+		locks, err := services.NewConversationLocks(&config.ConversationConfig{QueryMode: config.ConversationQueryReject}, nil)
+		require.NoError(t, err)
+		core := mocks.NewMockCoreService()
+		core.On("Query", mock.Anything, models.CoreQueryRequest{Query: "what?", ConversationID: "conv-1", TopK: gateway.DefaultTopK}).Return((<-chan models.SSEEvent)(upstream), nil)
+		svc := &gateway.Service{CoreClient: core, Repository: repo, Conversations: locks, Logger: zerolog.Nop()}
+
+		events, err := svc.Query(requestid.NewContext(ctx, "req-1"), models.QueryRequest{Query: "what?", ConversationID: "conv-1"}, "alice")
+		require.NoError(t, err)
+
+		// Held while the answer streams.
+		_, err = locks.Acquire(ctx, "conv-1", "req-2")
+		var busy *services.ConversationBusyError
+		require.ErrorAs(t, err, &busy)
+		assert.Equal(t, "req-1", busy.RequestID)
+
+		for range events {
+		}
+		release, err := locks.Acquire(ctx, "conv-1", "req-2")
+		require.NoError(t, err)
+		release()
+	})
+
+	t.Run("Query_RecordsLog", func(t *testing.T) {
+		upstream := make(chan models.SSEEvent, 3)
+		upstream <- models.SSEEvent{Type: "start", ID: "q-1"}
+		upstream <- models.SSEEvent{Type: "chunk", Content: "hi"}
+		upstream <- models.SSEEvent{Type: "end", ID: "q-1", Tokens: 42, DocumentIDs: []string{"doc-1"}}
+		close(upstream)
+
+		core := mocks.NewMockCoreService()
+		core.On("Query", mock.Anything, models.CoreQueryRequest{Query: "what?", ConversationID: "conv-1", TopK: gateway.DefaultTopK}).Return((<-chan models.SSEEvent)(upstream), nil)
+		repo := repomocks.NewMockRepository()
+		repo.On("GetConversation", mock.Anything, "conv-1").Return(&models.Conversation{ID: "conv-1", CreatedBy: "alice"}, nil)
+		repo.On("CreateQueryLog", mock.Anything, mock.MatchedBy(func(log *models.QueryLog) bool {
+			return log.ID == "q-1" && log.Username == "alice" && log.ConversationID == "conv-1" &&
+				log.Question == "what?" && log.Status == models.QueryStatusCompleted &&
+				log.Tokens != nil && *log.Tokens == 42 && log.LatencyMS >= 0 &&
+				len(log.DocumentIDs) == 1 && log.DocumentIDs[0] == "doc-1"
+		})).Return(nil)
+		svc := &gateway.Service{CoreClient: core, Repository: repo, Logger: zerolog.Nop()}
+
+		events, err := svc.Query(ctx, models.QueryRequest{Query: "what?", ConversationID: "conv-1"}, "alice")
+		require.NoError(t, err)
+		for range events {
+		}
+
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Query_RecordsCitations", func(t *testing.T) {
+		upstream := make(chan models.SSEEvent, 3)
+		upstream <- models.SSEEvent{Type: "sources", Sources: []models.Citation{
+			{DocumentID: "doc-1", ChunkID: "c-1", Score: 0.9},
+			{DocumentID: "doc-2", ChunkID: "c-7", Score: 0.7},
+			{DocumentID: "doc-1", ChunkID: "c-2", Score: 0.6},
+		}}
+		upstream <- models.SSEEvent{Type: "chunk", Content: "hi"}
+		upstream <- models.SSEEvent{Type: "end", ID: "q-1"}
+		close(upstream)
+
+		core := mocks.NewMockCoreService()
+		core.On("Query", mock.Anything, models.CoreQueryRequest{Query: "what?", TopK: gateway.DefaultTopK}).Return((<-chan models.SSEEvent)(upstream), nil)
+		repo := repomocks.NewMockRepository()
+		repo.On("CreateQueryLog", mock.Anything, mock.MatchedBy(func(log *models.QueryLog) bool {
+			return len(log.Citations) == 3 && log.Citations[1].ChunkID == "c-7" &&
+				assert.ObjectsAreEqual([]string{"doc-1", "doc-2"}, log.DocumentIDs)
+		})).Return(nil)
+		svc := &gateway.Service{CoreClient: core, Repository: repo, Logger: zerolog.Nop()}
+
+		events, err := svc.Query(ctx, models.QueryRequest{Query: "what?"}, "alice")
+		require.NoError(t, err)
+		for range events {
+		}
+
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Query_PromptTemplate", func(t *testing.T) {
+		upstream := make(chan models.SSEEvent)
+		close(upstream)
+
+		repo := repomocks.NewMockRepository()
+		repo.On("GetPromptTemplate", mock.Anything, "tmpl-1", 2).Return(&models.PromptTemplate{ID: "tmpl-1", Version: 2, Template: "Answer briefly: {question}"}, nil)
+		repo.On("CreateQueryLog", mock.Anything, mock.Anything).Return(nil)
+		core := mocks.NewMockCoreService()
+		core.On("Query", mock.Anything, models.CoreQueryRequest{Query: "what?", TopK: gateway.DefaultTopK, PromptTemplate: "Answer briefly: {question}"}).Return((<-chan models.SSEEvent)(upstream), nil)
+		svc := &gateway.Service{CoreClient: core, Repository: repo, Logger: zerolog.Nop()}
+
+		events, err := svc.Query(ctx, models.QueryRequest{Query: "what?", PromptTemplateID: "tmpl-1", PromptTemplateVersion: 2}, "alice")
+		require.NoError(t, err)
+		for range events {
+		}
+
+		core.AssertExpectations(t)
+	})
+
+	t.Run("Query_ActiveCollection", func(t *testing.T) {
+		upstream := make(chan models.SSEEvent)
+		close(upstream)
+
+		repo := repomocks.NewMockRepository()
+		repo.On("CreateQueryLog", mock.Anything, mock.Anything).Return(nil)
+		core := mocks.NewMockCoreService()
+		core.On("Query", mock.Anything, models.CoreQueryRequest{Query: "what?", TopK: gateway.DefaultTopK, Collection: "documents_bge-m3_1a2b3c4d"}).Return((<-chan models.SSEEvent)(upstream), nil)
+		migrations := mocks.NewMockEmbeddingMigrator()
+		migrations.On("ActiveCollection").Return("documents_bge-m3_1a2b3c4d")
+		svc := &gateway.Service{CoreClient: core, Repository: repo, Migrations: migrations, Logger: zerolog.Nop()}
+
+		events, err := svc.Query(ctx, models.QueryRequest{Query: "what?"}, "alice")
+		require.NoError(t, err)
+		for range events {
+		}
+
+		core.AssertExpectations(t)
+	})
+
+	t.Run("Query_RequestCollection", func(t *testing.T) {
+		upstream := make(chan models.SSEEvent)
+		close(upstream)
+
+		repo := repomocks.NewMockRepository()
+		repo.On("CreateQueryLog", mock.Anything, mock.Anything).Return(nil)
+		core := mocks.NewMockCoreService()
+		core.On("Query", mock.Anything, models.CoreQueryRequest{Query: "what?", TopK: gateway.DefaultTopK, Collection: "demo"}).Return((<-chan models.SSEEvent)(upstream), nil)
+		migrations := mocks.NewMockEmbeddingMigrator()
+		svc := &gateway.Service{CoreClient: core, Repository: repo, Migrations: migrations, Logger: zerolog.Nop()}
+
+		events, err := svc.Query(ctx, models.QueryRequest{Query: "what?", Collection: "demo"}, "demo")
+		require.NoError(t, err)
+		for range events {
+		}
+
+		core.AssertExpectations(t)
+		migrations.AssertNotCalled(t, "ActiveCollection")
+	})
+
+	t.Run("Query_AsOf", func(t *testing.T) {
+		upstream := make(chan models.SSEEvent)
+		close(upstream)
+
+		repo := repomocks.NewMockRepository()
+		repo.On("GetSnapshotByName", ctx, "2026-q3").Return(&models.Snapshot{
+			Name: "2026-q3", Status: models.SnapshotStatusReady, Collection: "documents_snapshot_1a2b3c4d",
+		}, nil)
+		repo.On("CreateQueryLog", mock.Anything, mock.Anything).Return(nil)
+		core := mocks.NewMockCoreService()
+		core.On("Query", mock.Anything, models.CoreQueryRequest{Query: "what?", TopK: gateway.DefaultTopK, Collection: "documents_snapshot_1a2b3c4d"}).Return((<-chan models.SSEEvent)(upstream), nil)
+		migrations := mocks.NewMockEmbeddingMigrator()
+		curated := mocks.NewMockCuratedAnswers()
+		svc := &gateway.Service{CoreClient: core, Repository: repo, Migrations: migrations, Curated: curated, Logger: zerolog.Nop()}
+
+		events, err := svc.Query(ctx, models.QueryRequest{Query: "what?", AsOf: "2026-q3"}, "alice")
+		require.NoError(t, err)
+		for range events {
+		}
+
+		core.AssertExpectations(t)
+		migrations.AssertNotCalled(t, "ActiveCollection")
+		curated.AssertNotCalled(t, "Find", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Query_AsOfNotReady", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetSnapshotByName", ctx, "2026-q3").Return(&models.Snapshot{Name: "2026-q3", Status: models.SnapshotStatusCreating}, nil)
+		core := mocks.NewMockCoreService()
+		svc := &gateway.Service{CoreClient: core, Repository: repo, Logger: zerolog.Nop()}
+
+		_, err := svc.Query(ctx, models.QueryRequest{Query: "what?", AsOf: "2026-q3"}, "alice")
+
+		assert.Equal(t, gateway.KindInvalid, gateway.KindOf(err))
+		assert.Equal(t, `Snapshot "2026-q3" is creating, not ready`, gateway.MessageOf(err))
+		core.AssertNotCalled(t, "Query", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Query_AsOfNotFound", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetSnapshotByName", ctx, "missing").Return(nil, nil)
+		svc := &gateway.Service{CoreClient: mocks.NewMockCoreService(), Repository: repo, Logger: zerolog.Nop()}
+
+		_, err := svc.Query(ctx, models.QueryRequest{Query: "what?", AsOf: "missing"}, "alice")
+
+		assert.Equal(t, gateway.KindInvalid, gateway.KindOf(err))
+		assert.Equal(t, "Snapshot not found", gateway.MessageOf(err))
+	})
+
+	t.Run("Query_AsOfWithCollection", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		svc := &gateway.Service{CoreClient: mocks.NewMockCoreService(), Repository: repo, Logger: zerolog.Nop()}
+
+		_, err := svc.Query(ctx, models.QueryRequest{Query: "what?", AsOf: "2026-q3", Collection: "demo"}, "demo")
+
+		assert.Equal(t, gateway.KindForbidden, gateway.KindOf(err))
+		repo.AssertNotCalled(t, "GetSnapshotByName", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Query_CollectionID", func(t *testing.T) {
+		upstream := make(chan models.SSEEvent)
+		close(upstream)
+
+		repo := repomocks.NewMockRepository()
+		repo.On("GetCollection", ctx, "collection-1").Return(&models.Collection{ID: "collection-1", Name: "Contracts", DocumentCount: 2}, nil)
+		repo.On("CreateQueryLog", mock.Anything, mock.Anything).Return(nil)
+		core := mocks.NewMockCoreService()
+		core.On("Query", mock.Anything, models.CoreQueryRequest{Query: "what?", TopK: gateway.DefaultTopK, CollectionID: "collection-1"}).Return((<-chan models.SSEEvent)(upstream), nil)
+		curated := mocks.NewMockCuratedAnswers()
+		svc := &gateway.Service{CoreClient: core, Repository: repo, Curated: curated, Logger: zerolog.Nop()}
+
+		events, err := svc.Query(ctx, models.QueryRequest{Query: "what?", CollectionID: "collection-1"}, "alice")
+		require.NoError(t, err)
+		for range events {
+		}
+
+		core.AssertExpectations(t)
+		curated.AssertNotCalled(t, "Find", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Query_CollectionIDEmpty", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetCollection", ctx, "collection-1").Return(&models.Collection{ID: "collection-1", Name: "Contracts"}, nil)
+		core := mocks.NewMockCoreService()
+		svc := &gateway.Service{CoreClient: core, Repository: repo, Logger: zerolog.Nop()}
+
+		_, err := svc.Query(ctx, models.QueryRequest{Query: "what?", CollectionID: "collection-1"}, "alice")
+
+		assert.Equal(t, gateway.KindInvalid, gateway.KindOf(err))
+		assert.Equal(t, "Collection has no documents", gateway.MessageOf(err))
+		core.AssertNotCalled(t, "Query", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Query_CollectionIDNotFound", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetCollection", ctx, "missing").Return(nil, nil)
+		svc := &gateway.Service{CoreClient: mocks.NewMockCoreService(), Repository: repo, Logger: zerolog.Nop()}
+
+		_, err := svc.Query(ctx, models.QueryRequest{Query: "what?", CollectionID: "missing"}, "alice")
+
+		assert.Equal(t, gateway.KindInvalid, gateway.KindOf(err))
+		assert.Equal(t, "Collection not found", gateway.MessageOf(err))
+	})
+
+	t.Run("Query_CollectionIDWithCollection", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		svc := &gateway.Service{CoreClient: mocks.NewMockCoreService(), Repository: repo, Logger: zerolog.Nop()}
+
+		_, err := svc.Query(ctx, models.QueryRequest{Query: "what?", CollectionID: "collection-1", Collection: "demo"}, "demo")
+
+		assert.Equal(t, gateway.KindForbidden, gateway.KindOf(err))
+		repo.AssertNotCalled(t, "GetCollection", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Query_Language", func(t *testing.T) {
+		upstream := make(chan models.SSEEvent)
+		close(upstream)
+
+		repo := repomocks.NewMockRepository()
+		repo.On("CreateQueryLog", mock.Anything, mock.Anything).Return(nil)
+		core := mocks.NewMockCoreService()
+		core.On("Query", mock.Anything, models.CoreQueryRequest{Query: "o que é?", TopK: gateway.DefaultTopK, Language: "pt-br"}).Return((<-chan models.SSEEvent)(upstream), nil)
+		svc := &gateway.Service{CoreClient: core, Repository: repo, Logger: zerolog.Nop()}
+
+		events, err := svc.Query(ctx, models.QueryRequest{Query: "o que é?", Language: "pt-BR"}, "alice")
+		require.NoError(t, err)
+		for range events {
+		}
+
+		core.AssertExpectations(t)
+	})
+
+	t.Run("Query_InvalidLanguage", func(t *testing.T) {
+		core := mocks.NewMockCoreService()
+		svc := &gateway.Service{CoreClient: core, Logger: zerolog.Nop()}
+
+		_, err := svc.Query(ctx, models.QueryRequest{Query: "what?", Language: "english!"}, "alice")
+
+		assert.Equal(t, gateway.KindInvalid, gateway.KindOf(err))
+		assert.Equal(t, "Invalid language", gateway.MessageOf(err))
+		core.AssertNotCalled(t, "Query", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Query_MaxChunksPerDocument", func(t *testing.T) {
+		upstream := make(chan models.SSEEvent)
+		close(upstream)
+
+		repo := repomocks.NewMockRepository()
+		repo.On("CreateQueryLog", mock.Anything, mock.Anything).Return(nil)
+		core := mocks.NewMockCoreService()
+		core.On("Query", mock.Anything, models.CoreQueryRequest{Query: "what?", TopK: 10, MaxChunksPerDocument: 2}).Return((<-chan models.SSEEvent)(upstream), nil)
+		svc := &gateway.Service{CoreClient: core, Repository: repo, Logger: zerolog.Nop()}
+
+		events, err := svc.Query(ctx, models.QueryRequest{Query: "what?", TopK: 10, MaxChunksPerDocument: 2}, "alice")
+		require.NoError(t, err)
+		for range events {
+		}
+
+		core.AssertExpectations(t)
+	})
+
+	t.Run("Query_MaxChunksPerDocumentOutOfRange", func(t *testing.T) {
+		core := mocks.NewMockCoreService()
+		svc := &gateway.Service{CoreClient: core, Logger: zerolog.Nop()}
+
+		// Above the default top_k of 5.
+		_, err := svc.Query(ctx, models.QueryRequest{Query: "what?", MaxChunksPerDocument: 6}, "alice")
+		assert.Equal(t, gateway.KindInvalid, gateway.KindOf(err))
+
+		_, err = svc.Query(ctx, models.QueryRequest{Query: "what?", MaxChunksPerDocument: -1}, "alice")
+		assert.Equal(t, gateway.KindInvalid, gateway.KindOf(err))
+
+		core.AssertNotCalled(t, "Query", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Query_PreviouslyAnswered", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("CreateQueryLog", mock.Anything, mock.MatchedBy(func(log *models.QueryLog) bool {
+			return log.Question == "refund policy?" && log.Status == models.QueryStatusCompleted &&
+				log.Tokens == nil && assert.ObjectsAreEqual([]string{"doc-1"}, log.DocumentIDs)
+		})).Return(nil)
+		core := mocks.NewMockCoreService()
+		answers := mocks.NewMockAnswerCache()
+		answers.On("Find", mock.Anything, "||", "refund policy?").Return(&models.AnsweredQuestion{
+			QueryID: "q-1", Question: "What is the refund policy?", Answer: "30 days.", DocumentIDs: []string{"doc-1"},
+		}, nil)
+		svc := &gateway.Service{CoreClient: core, Repository: repo, Answers: answers, Logger: zerolog.Nop()}
+
+		events, err := svc.Query(ctx, models.QueryRequest{Query: "refund policy?"}, "alice")
+		require.NoError(t, err)
+
+		var received []models.SSEEvent
+		for event := range events {
+			received = append(received, event)
+		}
+
+		require.Len(t, received, 3)
+		assert.Equal(t, "30 days.", received[1].Content)
+		end := received[2]
+		assert.Equal(t, "end", end.Type)
+		assert.True(t, end.PreviouslyAnswered)
+		assert.Equal(t, "q-1", end.AnsweredQueryID)
+		assert.Equal(t, received[0].ID, end.ID)
+		core.AssertNotCalled(t, "Query", mock.Anything, mock.Anything)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Query_Curated", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetConversation", mock.Anything, "conv-1").Return(&models.Conversation{ID: "conv-1", CreatedBy: "alice"}, nil)
+		repo.On("CreateMessage", mock.Anything, mock.MatchedBy(func(msg *models.Message) bool {
+			return msg.Role == "user" && msg.Content == "refund policy?"
+		})).Return(nil).Once()
+		repo.On("CreateMessage", mock.Anything, mock.MatchedBy(func(msg *models.Message) bool {
+			return msg.Role == "assistant" && msg.Content == "30 days." &&
+				msg.Metadata[models.MessageMetadataCuratedAnswerID] == "ca-1"
+		})).Return(nil).Once()
+		repo.On("CreateQueryLog", mock.Anything, mock.MatchedBy(func(log *models.QueryLog) bool {
+			return log.Question == "refund policy?" && log.Status == models.QueryStatusCompleted &&
+				assert.ObjectsAreEqual([]string{"doc-1"}, log.DocumentIDs)
+		})).Return(nil)
+		core := mocks.NewMockCoreService()
+		curated := mocks.NewMockCuratedAnswers()
+		curated.On("Find", mock.Anything, "refund policy?").Return(&models.CuratedAnswer{
+			ID: "ca-1", Pattern: "refund policy", MatchType: models.CuratedMatchContains,
+			Answer: "30 days.", DocumentIDs: []string{"doc-1"}, Enabled: true,
+		}, nil)
+		answers := mocks.NewMockAnswerCache()
+		svc := &gateway.Service{CoreClient: core, Repository: repo, Curated: curated, Answers: answers, Logger: zerolog.Nop()}
+
+		events, err := svc.Query(ctx, models.QueryRequest{Query: "refund policy?", ConversationID: "conv-1"}, "alice")
+		require.NoError(t, err)
+
+		var received []models.SSEEvent
+		for event := range events {
+			received = append(received, event)
+		}
+
+		require.Len(t, received, 3)
+		assert.Equal(t, "30 days.", received[1].Content)
+		end := received[2]
+		assert.Equal(t, "end", end.Type)
+		assert.True(t, end.Curated)
+		assert.Equal(t, "ca-1", end.CuratedAnswerID)
+		assert.Equal(t, []string{"doc-1"}, end.DocumentIDs)
+		assert.Equal(t, received[0].ID, end.ID)
+		core.AssertNotCalled(t, "Query", mock.Anything, mock.Anything)
+		answers.AssertNotCalled(t, "Find", mock.Anything, mock.Anything, mock.Anything)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Query_CuratedNotInConfinedCollection", func(t *testing.T) {
+		upstream := make(chan models.SSEEvent, 1)
+		upstream <- models.SSEEvent{Type: "end", ID: "q-1"}
+		close(upstream)
+
+		core := mocks.NewMockCoreService()
+		core.On("Query", mock.Anything, models.CoreQueryRequest{Query: "refund policy?", TopK: gateway.DefaultTopK, Collection: "demo"}).Return((<-chan models.SSEEvent)(upstream), nil)
+		curated := mocks.NewMockCuratedAnswers()
+		svc := &gateway.Service{CoreClient: core, Curated: curated, Logger: zerolog.Nop()}
+
+		events, err := svc.Query(ctx, models.QueryRequest{Query: "refund policy?", Collection: "demo"}, "demo:guest")
+		require.NoError(t, err)
+		for range events {
+		}
+
+		core.AssertExpectations(t)
+		curated.AssertNotCalled(t, "Find", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Query_CuratedRestrictedDocument", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", mock.Anything, "doc-1").Return(&models.Document{ID: "doc-1", AccessGroups: []string{"hr"}}, nil)
+		repo.On("CreateQueryLog", mock.Anything, mock.Anything).Return(nil)
+		curated := mocks.NewMockCuratedAnswers()
+		curated.On("Find", mock.Anything, "salary bands?").Return(&models.CuratedAnswer{
+			ID: "ca-1", Pattern: "salary bands", MatchType: models.CuratedMatchContains,
+			Answer: "See the HR handbook.", DocumentIDs: []string{"doc-1"}, Enabled: true,
+		}, nil)
+		access := &gateway.DocumentAccess{Groups: map[string][]string{"hr": {"bob"}}}
+
+		for _, tc := range []struct {
+			username string
+			curated  bool
+		}{
+			{"bob", true},
+			{"alice", false},
+		} {
+			upstream := make(chan models.SSEEvent, 1)
+			upstream <- models.SSEEvent{Type: "end", ID: "q-1"}
+			close(upstream)
+			core := mocks.NewMockCoreService()
+			core.On("Query", mock.Anything, models.CoreQueryRequest{Query: "salary bands?", TopK: gateway.DefaultTopK, AccessGroups: []string{}}).Return((<-chan models.SSEEvent)(upstream), nil)
+			svc := &gateway.Service{CoreClient: core, Repository: repo, Curated: curated, Access: access, Logger: zerolog.Nop()}
+
+			events, err := svc.Query(ctx, models.QueryRequest{Query: "salary bands?"}, tc.username)
+			require.NoError(t, err)
+			var end models.SSEEvent
+			for event := range events {
+				if event.Type == "end" {
+					end = event
+				}
+			}
+
+			assert.Equal(t, tc.curated, end.Curated, tc.username)
+			if tc.curated {
+				core.AssertNotCalled(t, "Query", mock.Anything, mock.Anything)
+			} else {
+				core.AssertExpectations(t)
+			}
+		}
+	})
+
+	t.Run("Query_CuratedLookupFailure", func(t *testing.T) {
+		upstream := make(chan models.SSEEvent, 1)
+		upstream <- models.SSEEvent{Type: "end", ID: "q-1"}
+		close(upstream)
+
+		core := mocks.NewMockCoreService()
+		core.On("Query", mock.Anything, models.CoreQueryRequest{Query: "what?", TopK: gateway.DefaultTopK}).Return((<-chan models.SSEEvent)(upstream), nil)
+		curated := mocks.NewMockCuratedAnswers()
+		curated.On("Find", mock.Anything, "what?").Return(nil, errors.New("db down"))
+		svc := &gateway.Service{CoreClient: core, Curated: curated, Logger: zerolog.Nop()}
+
+		events, err := svc.Query(ctx, models.QueryRequest{Query: "what?"}, "alice")
+		require.NoError(t, err)
+		for range events {
+		}
+
+		core.AssertExpectations(t)
+	})
+
+	t.Run("Query_Highlights", func(t *testing.T) {
+		upstream := make(chan models.SSEEvent, 5)
+		upstream <- models.SSEEvent{Type: "chunk", Content: "Run Qdr"}
+		upstream <- models.SSEEvent{Type: "chunk", Content: "ant behind the API"}
+		upstream <- models.SSEEvent{Type: "sources", Sources: []models.Citation{
+			{DocumentID: "doc-1", Text: "Qdrant stores vectors."},
+			{DocumentID: "doc-2", Text: "The API", Highlights: []models.Highlight{{Start: 4, End: 7, Term: "API", Kind: "entity"}}},
+		}}
+		upstream <- models.SSEEvent{Type: "end", ID: "q-1"}
+		close(upstream)
+
+		core := mocks.NewMockCoreService()
+		core.On("Query", mock.Anything, models.CoreQueryRequest{Query: "what?", TopK: gateway.DefaultTopK}).Return((<-chan models.SSEEvent)(upstream), nil)
+		glossary := mocks.NewMockGlossary()
+		glossary.On("Matcher", mock.Anything).Return(services.NewTermMatcher([]*models.GlossaryTerm{
+			{Term: "Qdrant", Definition: "Vector database"},
+			{Term: "API"},
+		}), nil)
+		svc := &gateway.Service{CoreClient: core, Glossary: glossary, Logger: zerolog.Nop()}
+
+		events, err := svc.Query(ctx, models.QueryRequest{Query: "what?"}, "alice")
+		require.NoError(t, err)
+
+		var received []models.SSEEvent
+		for event := range events {
+			received = append(received, event)
+		}
+
+		require.Len(t, received, 4)
+		assert.Empty(t, received[0].Highlights)
+		assert.Equal(t, []models.Highlight{
+			{Start: 4, End: 10, Term: "Qdrant", Kind: models.HighlightKindGlossary, Definition: "Vector database"},
+		}, received[1].Highlights)
+		sources := received[2].Sources
+		assert.Equal(t, []models.Highlight{{Start: 0, End: 6, Term: "Qdrant", Kind: models.HighlightKindGlossary, Definition: "Vector database"}}, sources[0].Highlights)
+		assert.Equal(t, "entity", sources[1].Highlights[0].Kind, "the core's highlights are kept")
+		assert.Equal(t, []models.Highlight{
+			{Start: 22, End: 25, Term: "API", Kind: models.HighlightKindGlossary},
+		}, received[3].Highlights)
+	})
+
+	t.Run("Query_Redacts", func(t *testing.T) {
+		upstream := make(chan models.SSEEvent, 5)
+		upstream <- models.SSEEvent{Type: "chunk", Content: "Project Fal", Highlights: []models.Highlight{{Start: 0, End: 7, Term: "Project", Kind: "entity"}}}
+		upstream <- models.SSEEvent{Type: "chunk", Content: "con uses the API"}
+		upstream <- models.SSEEvent{Type: "sources", Sources: []models.Citation{
+			{DocumentID: "doc-1", Text: "Falcon is secret."},
+			{DocumentID: "doc-2", Text: "Public.", Highlights: []models.Highlight{{Start: 0, End: 6, Term: "Public", Kind: "entity"}}},
+		}}
+		upstream <- models.SSEEvent{Type: "end", ID: "q-1"}
+		close(upstream)
+
+		core := mocks.NewMockCoreService()
+		core.On("Query", mock.Anything, models.CoreQueryRequest{Query: "what?", TopK: gateway.DefaultTopK}).Return((<-chan models.SSEEvent)(upstream), nil)
+		redactions := mocks.NewMockRedactions()
+		redactions.On("Redactor", mock.Anything).Return(services.NewRedactor([]*models.RedactionRule{
+			{Type: models.RedactionKeywords, Keywords: []string{"Project Falcon", "Falcon"}, Placeholder: "[CODENAME]"},
+		}), nil)
+		glossary := mocks.NewMockGlossary()
+		glossary.On("Matcher", mock.Anything).Return(services.NewTermMatcher([]*models.GlossaryTerm{{Term: "API"}}), nil)
+		answers := mocks.NewMockAnswerCache()
+		answers.On("Find", mock.Anything, "||", "what?").Return(nil, nil)
+		answers.On("Remember", mock.Anything, mock.MatchedBy(func(answered *models.AnsweredQuestion) bool {
+			return answered.Answer == "[CODENAME] uses the API"
+		})).Return(nil)
+		svc := &gateway.Service{CoreClient: core, Redactions: redactions, Glossary: glossary, Answers: answers, Logger: zerolog.Nop()}
+
+		events, err := svc.Query(ctx, models.QueryRequest{Query: "what?"}, "alice")
+		require.NoError(t, err)
+
+		var received []models.SSEEvent
+		var answer strings.Builder
+		for event := range events {
+			received = append(received, event)
+			answer.WriteString(event.Content)
+		}
+
+		assert.Equal(t, "[CODENAME] uses the API", answer.String())
+		require.Len(t, received, 3, "text is held back until the end")
+		assert.Equal(t, "sources", received[0].Type)
+		assert.Equal(t, "[CODENAME] is secret.", received[0].Sources[0].Text)
+		assert.Equal(t, "entity", received[0].Sources[1].Highlights[0].Kind, "unredacted sources keep their highlights")
+		assert.Equal(t, "chunk", received[1].Type)
+		assert.Empty(t, received[1].Highlights, "the core's highlights are dropped")
+		end := received[2]
+		assert.Equal(t, "end", end.Type)
+		assert.Equal(t, []models.Highlight{{Start: 20, End: 23, Term: "API", Kind: models.HighlightKindGlossary}}, end.Highlights,
+			"highlights locate the redacted text")
+		assert.True(t, end.Redacted)
+		answers.AssertExpectations(t)
+	})
+
+	t.Run("Query_RedactionRulesFailure", func(t *testing.T) {
+		upstream := make(chan models.SSEEvent, 2)
+		upstream <- models.SSEEvent{Type: "chunk", Content: "Falcon"}
+		upstream <- models.SSEEvent{Type: "end", ID: "q-1"}
+		close(upstream)
+
+		core := mocks.NewMockCoreService()
+		core.On("Query", mock.Anything, models.CoreQueryRequest{Query: "what?", TopK: gateway.DefaultTopK}).Return((<-chan models.SSEEvent)(upstream), nil)
+		redactions := mocks.NewMockRedactions()
+		redactions.On("Redactor", mock.Anything).Return(nil, errors.New("db down"))
+		svc := &gateway.Service{CoreClient: core, Redactions: redactions, Logger: zerolog.Nop()}
+
+		events, err := svc.Query(ctx, models.QueryRequest{Query: "what?"}, "alice")
+		require.NoError(t, err)
+
+		var received []models.SSEEvent
+		for event := range events {
+			received = append(received, event)
+		}
+
+		require.Len(t, received, 2)
+		assert.Equal(t, "Falcon", received[0].Content)
+		assert.False(t, received[1].Redacted)
+	})
+
+	t.Run("Query_PreviouslyAnsweredRedacted", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("CreateQueryLog", mock.Anything, mock.Anything).Return(nil)
+		answers := mocks.NewMockAnswerCache()
+		answers.On("Find", mock.Anything, "||", "who?").Return(&models.AnsweredQuestion{QueryID: "q-1", Answer: "Ask Falcon."}, nil)
+		redactions := mocks.NewMockRedactions()
+		redactions.On("Redactor", mock.Anything).Return(services.NewRedactor([]*models.RedactionRule{
+			{Type: models.RedactionKeywords, Keywords: []string{"falcon"}},
+		}), nil)
+		svc := &gateway.Service{CoreClient: mocks.NewMockCoreService(), Repository: repo, Answers: answers, Redactions: redactions, Logger: zerolog.Nop()}
+
+		events, err := svc.Query(ctx, models.QueryRequest{Query: "who?"}, "alice")
+		require.NoError(t, err)
+
+		var received []models.SSEEvent
+		for event := range events {
+			received = append(received, event)
+		}
+
+		require.Len(t, received, 3)
+		assert.Equal(t, "Ask [REDACTED].", received[1].Content)
+		assert.True(t, received[2].Redacted)
+		assert.True(t, received[2].PreviouslyAnswered)
+	})
+
+	t.Run("Query_RemembersAnswer", func(t *testing.T) {
+		upstream := make(chan models.SSEEvent, 3)
+		upstream <- models.SSEEvent{Type: "chunk", Content: "30 "}
+		upstream <- models.SSEEvent{Type: "chunk", Content: "days."}
+		upstream <- models.SSEEvent{Type: "end", ID: "q-2", DocumentIDs: []string{"doc-1"}}
+		close(upstream)
+
+		repo := repomocks.NewMockRepository()
+		repo.On("GetPromptTemplate", mock.Anything, "tmpl-1", 0).Return(&models.PromptTemplate{ID: "tmpl-1", Version: 3, Template: "{question}"}, nil)
+		repo.On("CreateQueryLog", mock.Anything, mock.Anything).Return(nil)
+		core := mocks.NewMockCoreService()
+		core.On("Query", mock.Anything, models.CoreQueryRequest{Query: "refund policy?", TopK: gateway.DefaultTopK, PromptTemplate: "{question}", Language: "en"}).Return((<-chan models.SSEEvent)(upstream), nil)
+		answers := mocks.NewMockAnswerCache()
+		answers.On("Remember", mock.Anything, mock.MatchedBy(func(answered *models.AnsweredQuestion) bool {
+			return answered.QueryID == "q-2" && answered.Scope == "|en|tmpl-1@3" &&
+				answered.Question == "refund policy?" && answered.Answer == "30 days."
+		})).Return(nil)
+		svc := &gateway.Service{CoreClient: core, Repository: repo, Answers: answers, Logger: zerolog.Nop()}
+
+		events, err := svc.Query(ctx, models.QueryRequest{Query: "refund policy?", Language: "en", PromptTemplateID: "tmpl-1", Fresh: true}, "alice")
+		require.NoError(t, err)
+		for range events {
+		}
+
+		answers.AssertNotCalled(t, "Find", mock.Anything, mock.Anything, mock.Anything)
+		answers.AssertExpectations(t)
+	})
+
+	t.Run("Query_ConversationNotDeduplicated", func(t *testing.T) {
+		upstream := make(chan models.SSEEvent, 2)
+		upstream <- models.SSEEvent{Type: "chunk", Content: "30 days."}
+		upstream <- models.SSEEvent{Type: "end", ID: "q-3"}
+		close(upstream)
+
+		repo := repomocks.NewMockRepository()
+		repo.On("GetConversation", mock.Anything, "conv-1").Return(&models.Conversation{ID: "conv-1", CreatedBy: "alice"}, nil)
+		repo.On("CreateQueryLog", mock.Anything, mock.Anything).Return(nil)
+		core := mocks.NewMockCoreService()
+		core.On("Query", mock.Anything, models.CoreQueryRequest{Query: "and for refunds?", ConversationID: "conv-1", TopK: gateway.DefaultTopK}).Return((<-chan models.SSEEvent)(upstream), nil)
+		answers := mocks.NewMockAnswerCache()
+		svc := &gateway.Service{CoreClient: core, Repository: repo, Answers: answers, Logger: zerolog.Nop()}
+
+		events, err := svc.Query(ctx, models.QueryRequest{Query: "and for refunds?", ConversationID: "conv-1"}, "alice")
+		require.NoError(t, err)
+		for range events {
+		}
+
+		answers.AssertNotCalled(t, "Find", mock.Anything, mock.Anything, mock.Anything)
+		answers.AssertNotCalled(t, "Remember", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Query_ConversationHistory", func(t *testing.T) {
+		upstream := make(chan models.SSEEvent, 1)
+		upstream <- models.SSEEvent{Type: "end", ID: "q-1"}
+		close(upstream)
+
+		history := &models.ConversationContext{
+			Summary:  "Asked about refunds.",
+			Messages: []models.Message{{Role: "user", Content: "what?"}},
+		}
+		repo := repomocks.NewMockRepository()
+		repo.On("GetConversation", mock.Anything, "conv-1").Return(&models.Conversation{ID: "conv-1", CreatedBy: "alice"}, nil)
+		repo.On("CreateQueryLog", mock.Anything, mock.Anything).Return(nil)
+		core := mocks.NewMockCoreService()
+		core.On("Query", mock.Anything, models.CoreQueryRequest{Query: "and returns?", ConversationID: "conv-1", TopK: gateway.DefaultTopK, Context: history}).Return((<-chan models.SSEEvent)(upstream), nil)
+		summaries := mocks.NewMockConversationSummarizer()
+		summaries.On("History", mock.Anything, "conv-1").Return(history, nil)
+		svc := &gateway.Service{CoreClient: core, Repository: repo, Summaries: summaries, Logger: zerolog.Nop()}
+
+		events, err := svc.Query(ctx, models.QueryRequest{Query: "and returns?", ConversationID: "conv-1"}, "alice")
+		require.NoError(t, err)
+		for range events {
+		}
+
+		core.AssertExpectations(t)
+	})
+
+	t.Run("Query_ConversationHistoryFailure", func(t *testing.T) {
+		upstream := make(chan models.SSEEvent, 1)
+		upstream <- models.SSEEvent{Type: "end", ID: "q-1"}
+		close(upstream)
+
+		repo := repomocks.NewMockRepository()
+		repo.On("GetConversation", mock.Anything, "conv-1").Return(&models.Conversation{ID: "conv-1", CreatedBy: "alice"}, nil)
+		repo.On("CreateQueryLog", mock.Anything, mock.Anything).Return(nil)
+		core := mocks.NewMockCoreService()
+		core.On("Query", mock.Anything, models.CoreQueryRequest{Query: "and returns?", ConversationID: "conv-1", TopK: gateway.DefaultTopK}).Return((<-chan models.SSEEvent)(upstream), nil)
+		summaries := mocks.NewMockConversationSummarizer()
+		summaries.On("History", mock.Anything, "conv-1").Return(nil, errors.New("db down"))
+		svc := &gateway.Service{CoreClient: core, Repository: repo, Summaries: summaries, Logger: zerolog.Nop()}
+
+		events, err := svc.Query(ctx, models.QueryRequest{Query: "and returns?", ConversationID: "conv-1"}, "alice")
+		require.NoError(t, err)
+		for range events {
+		}
+
+		core.AssertExpectations(t)
+	})
+
+	t.Run("Query_MirrorsToShadow", func(t *testing.T) {
+		upstream := make(chan models.SSEEvent, 1)
+		upstream <- models.SSEEvent{Type: "end", ID: "q-1"}
+		close(upstream)
+
+		repo := repomocks.NewMockRepository()
+		repo.On("GetConversation", mock.Anything, "conv-1").Return(&models.Conversation{ID: "conv-1", CreatedBy: "alice"}, nil)
+		repo.On("CreateQueryLog", mock.Anything, mock.Anything).Return(nil)
+		core := mocks.NewMockCoreService()
+		core.On("Query", mock.Anything, models.CoreQueryRequest{Query: "what?", ConversationID: "conv-1", TopK: gateway.DefaultTopK}).Return((<-chan models.SSEEvent)(upstream), nil)
+		var completed bool
+		shadow := mocks.NewMockShadowMirror()
+		shadow.On("Mirror", models.CoreQueryRequest{Query: "what?", ConversationID: "conv-1", TopK: gateway.DefaultTopK}).
+			Return(func(_ time.Duration, ok bool) { completed = ok })
+		svc := &gateway.Service{CoreClient: core, Repository: repo, Shadow: shadow, Logger: zerolog.Nop()}
+
+		events, err := svc.Query(ctx, models.QueryRequest{Query: "what?", ConversationID: "conv-1"}, "alice")
+		require.NoError(t, err)
+		for range events {
+		}
+
+		shadow.AssertExpectations(t)
+		assert.True(t, completed)
+	})
+
+	t.Run("Query_PromptTemplateNotFound", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetPromptTemplate", mock.Anything, "missing", 0).Return(nil, nil)
+		core := mocks.NewMockCoreService()
+		svc := &gateway.Service{CoreClient: core, Repository: repo, Logger: zerolog.Nop()}
+
+		_, err := svc.Query(ctx, models.QueryRequest{Query: "what?", PromptTemplateID: "missing"}, "alice")
+
+		assert.Equal(t, gateway.KindInvalid, gateway.KindOf(err))
+		assert.Equal(t, "Prompt template not found", gateway.MessageOf(err))
+		core.AssertNotCalled(t, "Query", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Query_RecordsFailure", func(t *testing.T) {
+		upstream := make(chan models.SSEEvent, 1)
+		upstream <- models.SSEEvent{Type: "error", Code: "STREAM_ERROR", Message: "boom"}
+		close(upstream)
+
+		core := mocks.NewMockCoreService()
+		core.On("Query", mock.Anything, models.CoreQueryRequest{Query: "what?", TopK: gateway.DefaultTopK}).Return((<-chan models.SSEEvent)(upstream), nil)
+		repo := repomocks.NewMockRepository()
+		repo.On("CreateQueryLog", mock.Anything, mock.MatchedBy(func(log *models.QueryLog) bool {
+			return log.ID != "" && log.Status == models.QueryStatusFailed && log.Tokens == nil
+		})).Return(nil)
+		svc := &gateway.Service{CoreClient: core, Repository: repo, Logger: zerolog.Nop()}
+
+		events, err := svc.Query(ctx, models.QueryRequest{Query: "what?"}, "alice")
+		require.NoError(t, err)
+		for range events {
+		}
+
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Answer_ErrorEvent", func(t *testing.T) {
+		upstream := make(chan models.SSEEvent, 2)
+		upstream <- models.SSEEvent{Type: "chunk", Content: "partial"}
+		upstream <- models.SSEEvent{Type: "error", Code: "STREAM_ERROR", Message: "boom"}
+		close(upstream)
+
+		core := mocks.NewMockCoreService()
+		core.On("Query", mock.Anything, models.CoreQueryRequest{Query: "what?", TopK: gateway.DefaultTopK}).Return((<-chan models.SSEEvent)(upstream), nil)
+		svc := &gateway.Service{CoreClient: core, Logger: zerolog.Nop()}
+
+		_, err := svc.Answer(ctx, models.QueryRequest{Query: "what?"}, "alice")
+
+		assert.Equal(t, gateway.KindInternal, gateway.KindOf(err))
+		assert.Equal(t, "boom", gateway.MessageOf(err))
+	})
+}
+
+func TestEvaluationRunner(t *testing.T) {
+	ctx := context.Background()
+
+	newRunner := func(t *testing.T, core *mocks.MockCoreService, repo *repomocks.MockRepository) *gateway.EvaluationRunner {
+		t.Helper()
+		svc := &gateway.Service{CoreClient: core, Repository: repo, Logger: zerolog.Nop()}
+		r := gateway.NewEvaluationRunner(svc, &config.EvaluationConfig{Concurrency: 2})
+		t.Cleanup(r.Close)
+		return r
+	}
+
+	answer := func(content string) <-chan models.SSEEvent {
+		events := make(chan models.SSEEvent, 2)
+		events <- models.SSEEvent{Type: "chunk", Content: content}
+		events <- models.SSEEvent{Type: "end"}
+		close(events)
+		return events
+	}
+
+	t.Run("Start_ScoresCases", func(t *testing.T) {
+		core := mocks.NewMockCoreService()
+		core.On("Query", mock.Anything, models.CoreQueryRequest{Query: "What is 2+2?", TopK: gateway.DefaultTopK}).Return(answer("4"), nil)
+		core.On("Query", mock.Anything, models.CoreQueryRequest{Query: "Capital of France?", TopK: gateway.DefaultTopK}).Return(answer("Lyon"), nil)
+		core.On("Evaluate", mock.Anything, "What is 2+2?", "4", "4").Return(&models.EvaluationScore{Score: 1, Metrics: map[string]float64{"faithfulness": 1}}, nil)
+		core.On("Evaluate", mock.Anything, "Capital of France?", "Paris", "Lyon").Return(nil, errors.New("evaluator down"))
+
+		repo := repomocks.NewMockRepository()
+		repo.On("CreateQueryLog", mock.Anything, mock.Anything).Return(nil)
+		repo.On("CreateEvaluation", mock.Anything, mock.MatchedBy(func(evaluation *models.Evaluation) bool {
+			return evaluation.TotalCases == 2 && evaluation.TopK == gateway.DefaultTopK && evaluation.CreatedBy == "admin"
+		}), mock.Anything).Return(nil)
+		repo.On("RecordEvaluationResult", mock.Anything, mock.Anything, mock.MatchedBy(func(result *models.EvaluationResult) bool {
+			return result.Position == 0 && result.Answer == "4" && *result.Score == 1 && result.Error == ""
+		})).Return(nil)
+		repo.On("RecordEvaluationResult", mock.Anything, mock.Anything, mock.MatchedBy(func(result *models.EvaluationResult) bool {
+			return result.Position == 1 && result.Answer == "Lyon" && result.Score == nil && result.Error == "Failed to score answer"
+		})).Return(nil)
+		done := make(chan struct{})
+		repo.On("FinishEvaluation", mock.Anything, mock.Anything, models.EvaluationStatusCompleted, "").Run(func(mock.Arguments) {
+			close(done)
+		}).Return(nil)
+
+		evaluation, err := newRunner(t, core, repo).Start(ctx, models.CreateEvaluationRequest{
+			Name: "smoke",
+			Cases: []models.EvaluationCase{
+				{Question: "What is 2+2?", ExpectedAnswer: "4"},
+				{Question: "Capital of France?", ExpectedAnswer: "Paris"},
+			},
+		}, "admin")
+		require.NoError(t, err)
+		assert.Equal(t, models.EvaluationStatusRunning, evaluation.Status)
+
+		<-done
+		repo.AssertNumberOfCalls(t, "RecordEvaluationResult", 2)
+	})
+
+	t.Run("Start_UnsupportedTransport", func(t *testing.T) {
+		core := mocks.NewMockCoreService()
+		core.On("Query", mock.Anything, models.CoreQueryRequest{Query: "q", TopK: gateway.DefaultTopK}).Return(answer("a"), nil)
+		core.On("Evaluate", mock.Anything, "q", "e", "a").Return(nil, services.ErrEvaluationUnsupported)
+
+		repo := repomocks.NewMockRepository()
+		repo.On("CreateQueryLog", mock.Anything, mock.Anything).Return(nil)
+		repo.On("CreateEvaluation", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		repo.On("RecordEvaluationResult", mock.Anything, mock.Anything, mock.MatchedBy(func(result *models.EvaluationResult) bool {
+			return result.Error == "Evaluation is not supported by the configured core transport"
+		})).Return(nil)
+		done := make(chan struct{})
+		repo.On("FinishEvaluation", mock.Anything, mock.Anything, models.EvaluationStatusCompleted, "").Run(func(mock.Arguments) {
+			close(done)
+		}).Return(nil)
+
+		_, err := newRunner(t, core, repo).Start(ctx, models.CreateEvaluationRequest{
+			Cases: []models.EvaluationCase{{Question: "q", ExpectedAnswer: "e"}},
+		}, "admin")
+		require.NoError(t, err)
+
+		<-done
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Start_PromptTemplateNotFound", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetPromptTemplate", ctx, "missing", 0).Return(nil, nil)
+
+		_, err := newRunner(t, mocks.NewMockCoreService(), repo).Start(ctx, models.CreateEvaluationRequest{
+			PromptTemplateID: "missing",
+			Cases:            []models.EvaluationCase{{Question: "q", ExpectedAnswer: "e"}},
+		}, "admin")
+
+		assert.Equal(t, gateway.KindInvalid, gateway.KindOf(err))
+		repo.AssertNotCalled(t, "CreateEvaluation", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestDuplicateDetector(t *testing.T) {
+	ctx := context.Background()
+
+	newDetector := func(t *testing.T, repo *repomocks.MockRepository, qdrant *mocks.MockQdrantClient) *gateway.DuplicateDetector {
+		t.Helper()
+		svc := &gateway.Service{Repository: repo, QdrantClient: qdrant, Logger: zerolog.Nop()}
+		d := gateway.NewDuplicateDetector(svc)
+		t.Cleanup(d.Close)
+		return d
+	}
+
+	t.Run("Start_ClustersDuplicates", func(t *testing.T) {
+		now := time.Now()
+		documents := []*models.Document{
+			{ID: "doc-new", Filename: "handbook-v2.pdf", CreatedAt: now},
+			{ID: "doc-old", Filename: "handbook.pdf", CreatedAt: now.Add(-time.Hour)},
+			{ID: "doc-other", Filename: "pricing.pdf", CreatedAt: now},
+			{ID: "doc-empty", Filename: "blank.pdf", CreatedAt: now},
+		}
+
+		repo := repomocks.NewMockRepository()
+		repo.On("CreateDuplicateReport", ctx, mock.MatchedBy(func(report *models.DuplicateReport) bool {
+			return report.Threshold == gateway.DefaultDuplicateThreshold && report.CreatedBy == "admin"
+		})).Return(nil)
+		repo.On("ListDocuments", mock.Anything, 100, 0, models.DocumentFilter{Status: "complete"}).Return(documents, len(documents), nil)
+		repo.On("SetDuplicateReportProgress", mock.Anything, mock.Anything, 4).Return(nil)
+		var finished *models.DuplicateReport
+		done := make(chan struct{})
+		repo.On("FinishDuplicateReport", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			finished = args.Get(1).(*models.DuplicateReport)
+			close(done)
+		}).Return(nil)
+
+		qdrant := mocks.NewMockQdrantClient()
+		qdrant.On("DocumentCentroid", mock.Anything, "doc-new").Return([]float32{0.99, 0.14106736}, nil)
+		qdrant.On("DocumentCentroid", mock.Anything, "doc-old").Return([]float32{1, 0}, nil)
+		qdrant.On("DocumentCentroid", mock.Anything, "doc-other").Return([]float32{0, 1}, nil)
+		qdrant.On("DocumentCentroid", mock.Anything, "doc-empty").Return(nil, nil)
+		qdrant.On("SimilarDocuments", mock.Anything, mock.Anything, 11).Return([]string{"doc-new", "doc-old", "doc-other", "doc-empty"}, nil)
+
+		report, err := newDetector(t, repo, qdrant).Start(ctx, models.CreateDuplicateReportRequest{}, "admin")
+		require.NoError(t, err)
+		assert.Equal(t, models.DuplicateReportStatusRunning, report.Status)
+
+		<-done
+		assert.Equal(t, models.DuplicateReportStatusCompleted, finished.Status)
+		assert.Equal(t, 4, finished.DocumentsScanned)
+		require.Len(t, finished.Clusters, 1)
+		assert.Equal(t, 1, finished.ClusterCount)
+		cluster := finished.Clusters[0]
+		require.Len(t, cluster.Documents, 2)
+		assert.Equal(t, "doc-old", cluster.Documents[0].ID)
+		assert.Equal(t, "doc-new", cluster.Documents[1].ID)
+		assert.InDelta(t, 0.99, cluster.Similarity, 1e-6)
+		qdrant.AssertNumberOfCalls(t, "SimilarDocuments", 3)
+	})
+
+	t.Run("Start_SearchFails", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("CreateDuplicateReport", ctx, mock.Anything).Return(nil)
+		repo.On("ListDocuments", mock.Anything, 100, 0, models.DocumentFilter{Status: "complete"}).Return([]*models.Document{{ID: "doc-1"}}, 1, nil)
+		repo.On("SetDuplicateReportProgress", mock.Anything, mock.Anything, 1).Return(nil)
+		done := make(chan struct{})
+		repo.On("FinishDuplicateReport", mock.Anything, mock.MatchedBy(func(report *models.DuplicateReport) bool {
+			return report.Status == models.DuplicateReportStatusFailed && report.Error == "Failed to search similar documents" && report.CompletedAt != nil
+		})).Run(func(mock.Arguments) {
+			close(done)
+		}).Return(nil)
+
+		qdrant := mocks.NewMockQdrantClient()
+		qdrant.On("DocumentCentroid", mock.Anything, "doc-1").Return([]float32{1, 0}, nil)
+		qdrant.On("SimilarDocuments", mock.Anything, mock.Anything, 11).Return(nil, errors.New("qdrant down"))
+
+		_, err := newDetector(t, repo, qdrant).Start(ctx, models.CreateDuplicateReportRequest{Threshold: 0.9}, "admin")
+		require.NoError(t, err)
+
+		<-done
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Start_AlreadyRunning", func(t *testing.T) {
+		release := make(chan struct{})
+		repo := repomocks.NewMockRepository()
+		repo.On("CreateDuplicateReport", ctx, mock.Anything).Return(nil)
+		repo.On("ListDocuments", mock.Anything, 100, 0, models.DocumentFilter{Status: "complete"}).Run(func(mock.Arguments) {
+			<-release
+		}).Return([]*models.Document{}, 0, nil)
+		repo.On("SetDuplicateReportProgress", mock.Anything, mock.Anything, 0).Return(nil)
+		done := make(chan struct{})
+		repo.On("FinishDuplicateReport", mock.Anything, mock.Anything).Run(func(mock.Arguments) {
+			close(done)
+		}).Return(nil)
+
+		d := newDetector(t, repo, mocks.NewMockQdrantClient())
+		_, err := d.Start(ctx, models.CreateDuplicateReportRequest{}, "admin")
+		require.NoError(t, err)
+
+		_, err = d.Start(ctx, models.CreateDuplicateReportRequest{}, "admin")
+		assert.Equal(t, gateway.KindConflict, gateway.KindOf(err))
+		assert.Equal(t, "A duplicate scan is already running", gateway.MessageOf(err))
+
+		close(release)
+		<-done
+		repo.AssertNumberOfCalls(t, "CreateDuplicateReport", 1)
+	})
+
+	t.Run("Start_ThresholdTooLow", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+
+		_, err := newDetector(t, repo, mocks.NewMockQdrantClient()).Start(ctx, models.CreateDuplicateReportRequest{Threshold: 0.5}, "admin")
+
+		assert.Equal(t, gateway.KindInvalid, gateway.KindOf(err))
+		repo.AssertNotCalled(t, "CreateDuplicateReport", mock.Anything, mock.Anything)
+	})
+}
+
+func TestTagUpdater(t *testing.T) {
+	ctx := context.Background()
+
+	newUpdater := func(t *testing.T, repo *repomocks.MockRepository, qdrant *mocks.MockQdrantClient) *gateway.TagUpdater {
+		t.Helper()
+		svc := &gateway.Service{Repository: repo, QdrantClient: qdrant, Logger: zerolog.Nop()}
+		u := gateway.NewTagUpdater(svc)
+		t.Cleanup(u.Close)
+		return u
+	}
+
+	t.Run("TagDocuments_UpdatesPayloads", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("AddDocumentTags", ctx, []string{"doc-1", "doc-2"}, []string{"finance", "q3"}).Return([]string{"doc-1"}, nil)
+		repo.On("GetDocument", mock.Anything, "doc-1").Return(&models.Document{ID: "doc-1", Tags: []string{"finance", "q3"}}, nil)
+		done := make(chan struct{})
+		qdrant := mocks.NewMockQdrantClient()
+		qdrant.On("SetDocumentTags", mock.Anything, "doc-1", []string{"finance", "q3"}).Run(func(mock.Arguments) {
+			close(done)
+		}).Return(nil)
+
+		resp, err := newUpdater(t, repo, qdrant).TagDocuments(ctx, models.BulkTagRequest{
+			DocumentIDs: []string{"doc-1", "doc-2"},
+			Tags:        []string{" Q3", "finance", "q3"},
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, resp.Updated)
+		<-done
+	})
+
+	t.Run("TagDocuments_InvalidTag", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+
+		_, err := newUpdater(t, repo, mocks.NewMockQdrantClient()).TagDocuments(ctx, models.BulkTagRequest{
+			DocumentIDs: []string{"doc-1"},
+			Tags:        []string{"  "},
+		})
+
+		assert.Equal(t, gateway.KindInvalid, gateway.KindOf(err))
+		repo.AssertNotCalled(t, "AddDocumentTags", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("UntagDocuments_TooManyDocuments", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+
+		_, err := newUpdater(t, repo, mocks.NewMockQdrantClient()).UntagDocuments(ctx, models.BulkTagRequest{
+			DocumentIDs: make([]string, gateway.MaxTagDocuments+1),
+			Tags:        []string{"finance"},
+		})
+
+		assert.Equal(t, gateway.KindInvalid, gateway.KindOf(err))
+	})
+
+	t.Run("RenameTag_Merges", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("RenameTag", ctx, "fin", "finance").Return([]string{"doc-1"}, nil)
+		repo.On("GetDocument", mock.Anything, "doc-1").Return(&models.Document{ID: "doc-1", Tags: []string{"finance"}}, nil)
+		done := make(chan struct{})
+		qdrant := mocks.NewMockQdrantClient()
+		qdrant.On("SetDocumentTags", mock.Anything, "doc-1", []string{"finance"}).Run(func(mock.Arguments) {
+			close(done)
+		}).Return(nil)
+
+		resp, err := newUpdater(t, repo, qdrant).RenameTag(ctx, models.RenameTagRequest{From: "Fin", To: "finance"})
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, resp.Updated)
+		<-done
+	})
+
+	t.Run("RenameTag_SameTag", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+
+		_, err := newUpdater(t, repo, mocks.NewMockQdrantClient()).RenameTag(ctx, models.RenameTagRequest{From: "Finance", To: "finance "})
+
+		assert.Equal(t, gateway.KindInvalid, gateway.KindOf(err))
+		repo.AssertNotCalled(t, "RenameTag", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestSnapshots(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("CreateSnapshot_StartsWorkflow", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("CreateSnapshot", ctx, mock.MatchedBy(func(snapshot *models.Snapshot) bool {
+			return snapshot.Name == "2026-q3" && snapshot.SourceCollection == "documents" &&
+				snapshot.ConfigVersion != "" && snapshot.CreatedBy == "admin"
+		})).Return(true, nil)
+		qdrant := mocks.NewMockQdrantClient()
+		qdrant.On("Collection").Return("documents")
+		temporal := mocks.NewMockTemporalClient()
+		temporal.On("StartSnapshotWorkflow", ctx, mock.MatchedBy(func(input services.SnapshotWorkflowInput) bool {
+			return input.SourceCollection == "documents" && strings.HasPrefix(input.Collection, "documents_snapshot_")
+		})).Return("snapshot-1", nil)
+		svc := &gateway.Service{Repository: repo, QdrantClient: qdrant, Temporal: temporal, Logger: zerolog.Nop()}
+
+		snapshot, err := svc.CreateSnapshot(ctx, models.CreateSnapshotRequest{Name: " 2026-q3 "}, "admin")
+
+		require.NoError(t, err)
+		assert.Equal(t, models.SnapshotStatusCreating, snapshot.Status)
+		temporal.AssertExpectations(t)
+	})
+
+	t.Run("CreateSnapshot_WorkflowFails", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("CreateSnapshot", ctx, mock.Anything).Return(true, nil)
+		repo.On("FinishSnapshot", ctx, mock.MatchedBy(func(snapshot *models.Snapshot) bool {
+			return snapshot.Status == models.SnapshotStatusFailed && snapshot.CompletedAt != nil
+		})).Return(nil)
+		qdrant := mocks.NewMockQdrantClient()
+		qdrant.On("Collection").Return("documents")
+		temporal := mocks.NewMockTemporalClient()
+		temporal.On("StartSnapshotWorkflow", ctx, mock.Anything).Return("", errors.New("temporal down"))
+		svc := &gateway.Service{Repository: repo, QdrantClient: qdrant, Temporal: temporal, Logger: zerolog.Nop()}
+
+		_, err := svc.CreateSnapshot(ctx, models.CreateSnapshotRequest{Name: "2026-q3"}, "admin")
+
+		assert.Equal(t, gateway.KindInternal, gateway.KindOf(err))
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("CreateSnapshot_NameTaken", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("CreateSnapshot", ctx, mock.Anything).Return(false, nil)
+		qdrant := mocks.NewMockQdrantClient()
+		qdrant.On("Collection").Return("documents")
+		temporal := mocks.NewMockTemporalClient()
+		svc := &gateway.Service{Repository: repo, QdrantClient: qdrant, Temporal: temporal, Logger: zerolog.Nop()}
+
+		_, err := svc.CreateSnapshot(ctx, models.CreateSnapshotRequest{Name: "2026-q3"}, "admin")
+
+		assert.Equal(t, gateway.KindConflict, gateway.KindOf(err))
+		assert.Equal(t, `A snapshot named "2026-q3" already exists`, gateway.MessageOf(err))
+		temporal.AssertNotCalled(t, "StartSnapshotWorkflow", mock.Anything, mock.Anything)
+	})
+
+	t.Run("CreateSnapshot_WithoutTemporal", func(t *testing.T) {
+		svc := &gateway.Service{Repository: repomocks.NewMockRepository(), QdrantClient: mocks.NewMockQdrantClient(), Logger: zerolog.Nop()}
+
+		_, err := svc.CreateSnapshot(ctx, models.CreateSnapshotRequest{Name: "2026-q3"}, "admin")
+
+		assert.Equal(t, gateway.KindUnavailable, gateway.KindOf(err))
+	})
+
+	t.Run("FinishSnapshot_Ready", func(t *testing.T) {
+		completedAt := time.Now()
+		repo := repomocks.NewMockRepository()
+		repo.On("GetSnapshot", ctx, "snap-1").Return(&models.Snapshot{ID: "snap-1", Status: models.SnapshotStatusCreating}, nil)
+		repo.On("FinishSnapshot", ctx, mock.MatchedBy(func(snapshot *models.Snapshot) bool {
+			return snapshot.Status == models.SnapshotStatusReady && snapshot.QdrantSnapshot == "documents.snapshot" &&
+				snapshot.VectorCount == 42 && snapshot.CompletedAt.Equal(completedAt)
+		})).Return(nil)
+		svc := &gateway.Service{Repository: repo, QdrantClient: mocks.NewMockQdrantClient(), Logger: zerolog.Nop()}
+
+		err := svc.FinishSnapshot(ctx, "snap-1", gateway.SnapshotResult{
+			QdrantSnapshot: "documents.snapshot", VectorCount: 42, CompletedAt: completedAt,
+		})
+
+		require.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("FinishSnapshot_FailedDiscardsVectors", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetSnapshot", ctx, "snap-1").Return(&models.Snapshot{
+			ID: "snap-1", Status: models.SnapshotStatusCreating, SourceCollection: "documents", Collection: "documents_snapshot_1a2b3c4d",
+		}, nil)
+		repo.On("FinishSnapshot", ctx, mock.MatchedBy(func(snapshot *models.Snapshot) bool {
+			return snapshot.Status == models.SnapshotStatusFailed && snapshot.Error == "copy failed"
+		})).Return(nil)
+		qdrant := mocks.NewMockQdrantClient()
+		qdrant.On("DeleteCollection", ctx, "documents_snapshot_1a2b3c4d").Return(nil)
+		qdrant.On("DeleteSnapshot", ctx, "documents", "documents.snapshot").Return(nil)
+		svc := &gateway.Service{Repository: repo, QdrantClient: qdrant, Logger: zerolog.Nop()}
+
+		err := svc.FinishSnapshot(ctx, "snap-1", gateway.SnapshotResult{
+			QdrantSnapshot: "documents.snapshot", Error: "copy failed", CompletedAt: time.Now(),
+		})
+
+		require.NoError(t, err)
+		repo.AssertExpectations(t)
+		qdrant.AssertExpectations(t)
+	})
+
+	t.Run("FinishSnapshot_AlreadyFinished", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetSnapshot", ctx, "snap-1").Return(&models.Snapshot{ID: "snap-1", Status: models.SnapshotStatusReady}, nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		require.NoError(t, svc.FinishSnapshot(ctx, "snap-1", gateway.SnapshotResult{Error: "late failure"}))
+
+		repo.AssertNotCalled(t, "FinishSnapshot", mock.Anything, mock.Anything)
+	})
+
+	t.Run("DeleteSnapshot_DropsCollection", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetSnapshot", ctx, "snap-1").Return(&models.Snapshot{
+			ID: "snap-1", Status: models.SnapshotStatusReady, SourceCollection: "documents",
+			Collection: "documents_snapshot_1a2b3c4d", QdrantSnapshot: "documents.snapshot",
+		}, nil)
+		repo.On("DeleteSnapshot", ctx, "snap-1").Return(nil)
+		qdrant := mocks.NewMockQdrantClient()
+		qdrant.On("DeleteCollection", ctx, "documents_snapshot_1a2b3c4d").Return(nil)
+		qdrant.On("DeleteSnapshot", ctx, "documents", "documents.snapshot").Return(nil)
+		svc := &gateway.Service{Repository: repo, QdrantClient: qdrant, Logger: zerolog.Nop()}
+
+		require.NoError(t, svc.DeleteSnapshot(ctx, "snap-1"))
+
+		repo.AssertExpectations(t)
+		qdrant.AssertExpectations(t)
+	})
+
+	t.Run("DeleteSnapshot_StillCreating", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetSnapshot", ctx, "snap-1").Return(&models.Snapshot{ID: "snap-1", Status: models.SnapshotStatusCreating}, nil)
+		svc := &gateway.Service{Repository: repo, QdrantClient: mocks.NewMockQdrantClient(), Logger: zerolog.Nop()}
+
+		err := svc.DeleteSnapshot(ctx, "snap-1")
+
+		assert.Equal(t, gateway.KindConflict, gateway.KindOf(err))
+		repo.AssertNotCalled(t, "DeleteSnapshot", mock.Anything, mock.Anything)
+	})
+}
+
+func TestKnowledgeBaseExports(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("CreateKnowledgeBaseExport_WritesManifestAndStartsWorkflow", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("ExportDocuments", ctx, models.DocumentFilter{Tag: "hr"}, mock.Anything).Return([]*models.Document{
+			{ID: "doc-1", Filename: "handbook.pdf", Title: "Handbook", S3Key: "documents/doc-1/handbook.pdf", Tags: []string{"hr"}},
+			{ID: "doc-2", Filename: "../notes.md", S3Key: "documents/doc-2/notes.md"},
+			{ID: "doc-3", Filename: "pending.pdf"},
+		}, nil)
+		repo.On("CreateKnowledgeBaseExport", ctx, mock.MatchedBy(func(exp *models.KnowledgeBaseExport) bool {
+			return exp.Status == models.KnowledgeBaseExportRunning && exp.DocumentCount == 2 && exp.CreatedBy == "alice"
+		})).Return(nil)
+		var manifest []models.ExportManifestEntry
+		s3 := &mocks.MockS3Client{KeyPrefix: "tenant/"}
+		s3.On("UploadObject", ctx, mock.MatchedBy(func(key string) bool {
+			return strings.HasPrefix(key, "tenant/exports/") && strings.HasSuffix(key, "/manifest.json")
+		}), mock.Anything, "application/json").Run(func(args mock.Arguments) {
+			require.NoError(t, json.NewDecoder(args.Get(2).(io.Reader)).Decode(&manifest))
+		}).Return(nil)
+		temporal := mocks.NewMockTemporalClient()
+		temporal.On("StartExportWorkflow", ctx, mock.MatchedBy(func(input services.ExportWorkflowInput) bool {
+			return input.Format == models.ExportFormatTar && strings.HasSuffix(input.ArchiveKey, "/knowledge-base.tar")
+		})).Return("export-1", nil)
+		svc := &gateway.Service{Repository: repo, S3Client: s3, Temporal: temporal, Logger: zerolog.Nop()}
+
+		exp, err := svc.CreateKnowledgeBaseExport(ctx, models.CreateKnowledgeBaseExportRequest{
+			Format: models.ExportFormatTar,
+			Filter: models.DocumentFilter{Tag: " hr "},
+		}, "alice")
+
+		require.NoError(t, err)
+		assert.Equal(t, 2, exp.DocumentCount)
+		require.Len(t, manifest, 2)
+		assert.Equal(t, "documents/doc-1/handbook.pdf", manifest[0].Path)
+		assert.Equal(t, "Handbook", manifest[0].Title)
+		assert.Equal(t, "documents/doc-2/notes.md", manifest[1].Path)
+		temporal.AssertExpectations(t)
+	})
+
+	t.Run("CreateKnowledgeBaseExport_NoDocuments", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("ExportDocuments", ctx, models.DocumentFilter{}, mock.Anything).Return([]*models.Document{}, nil)
+		s3 := mocks.NewMockS3Client()
+		temporal := mocks.NewMockTemporalClient()
+		svc := &gateway.Service{Repository: repo, S3Client: s3, Temporal: temporal, Logger: zerolog.Nop()}
+
+		_, err := svc.CreateKnowledgeBaseExport(ctx, models.CreateKnowledgeBaseExportRequest{}, "alice")
+
+		assert.Equal(t, gateway.KindInvalid, gateway.KindOf(err))
+		s3.AssertNotCalled(t, "UploadObject", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		temporal.AssertNotCalled(t, "StartExportWorkflow", mock.Anything, mock.Anything)
+	})
+
+	t.Run("CreateKnowledgeBaseExport_WorkflowFails", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("ExportDocuments", ctx, models.DocumentFilter{}, mock.Anything).Return([]*models.Document{
+			{ID: "doc-1", Filename: "a.pdf", S3Key: "documents/doc-1/a.pdf"},
+		}, nil)
+		repo.On("CreateKnowledgeBaseExport", ctx, mock.Anything).Return(nil)
+		repo.On("UpdateKnowledgeBaseExport", ctx, mock.MatchedBy(func(exp *models.KnowledgeBaseExport) bool {
+			return exp.Status == models.KnowledgeBaseExportFailed && exp.CompletedAt != nil
+		})).Return(nil)
+		s3 := mocks.NewMockS3Client()
+		s3.On("UploadObject", ctx, mock.Anything, mock.Anything, "application/json").Return(nil)
+		temporal := mocks.NewMockTemporalClient()
+		temporal.On("StartExportWorkflow", ctx, mock.Anything).Return("", errors.New("temporal down"))
+		svc := &gateway.Service{Repository: repo, S3Client: s3, Temporal: temporal, Logger: zerolog.Nop()}
+
+		_, err := svc.CreateKnowledgeBaseExport(ctx, models.CreateKnowledgeBaseExportRequest{}, "alice")
+
+		assert.Equal(t, gateway.KindInternal, gateway.KindOf(err))
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("CreateKnowledgeBaseExport_WithoutTemporal", func(t *testing.T) {
+		svc := &gateway.Service{Repository: repomocks.NewMockRepository(), S3Client: mocks.NewMockS3Client(), Logger: zerolog.Nop()}
+
+		_, err := svc.CreateKnowledgeBaseExport(ctx, models.CreateKnowledgeBaseExportRequest{}, "alice")
+
+		assert.Equal(t, gateway.KindUnavailable, gateway.KindOf(err))
+	})
+
+	t.Run("GetKnowledgeBaseExport_OtherUser", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetKnowledgeBaseExport", ctx, "exp-1").Return(&models.KnowledgeBaseExport{ID: "exp-1", CreatedBy: "alice"}, nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		_, err := svc.GetKnowledgeBaseExport(ctx, "exp-1", "bob")
+
+		assert.Equal(t, gateway.KindNotFound, gateway.KindOf(err))
+	})
+
+	t.Run("OpenKnowledgeBaseExport_NotReady", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetKnowledgeBaseExport", ctx, "exp-1").Return(&models.KnowledgeBaseExport{
+			ID: "exp-1", Status: models.KnowledgeBaseExportRunning, CreatedBy: "alice",
+		}, nil)
+		s3 := mocks.NewMockS3Client()
+		svc := &gateway.Service{Repository: repo, S3Client: s3, Logger: zerolog.Nop()}
+
+		_, _, err := svc.OpenKnowledgeBaseExport(ctx, "exp-1", "alice")
+
+		assert.Equal(t, gateway.KindConflict, gateway.KindOf(err))
+		s3.AssertNotCalled(t, "GetObject", mock.Anything, mock.Anything)
+	})
+
+	t.Run("UpdateKnowledgeBaseExport_Progress", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetKnowledgeBaseExport", ctx, "exp-1").Return(&models.KnowledgeBaseExport{
+			ID: "exp-1", Status: models.KnowledgeBaseExportRunning, DocumentCount: 10, DocumentsExported: 2,
+		}, nil)
+		repo.On("UpdateKnowledgeBaseExport", ctx, mock.MatchedBy(func(exp *models.KnowledgeBaseExport) bool {
+			return exp.Status == models.KnowledgeBaseExportRunning && exp.DocumentsExported == 5
+		})).Return(nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		require.NoError(t, svc.UpdateKnowledgeBaseExport(ctx, "exp-1", gateway.ExportProgress{DocumentsExported: 5}))
+
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("UpdateKnowledgeBaseExport_StaleProgress", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetKnowledgeBaseExport", ctx, "exp-1").Return(&models.KnowledgeBaseExport{
+			ID: "exp-1", Status: models.KnowledgeBaseExportRunning, DocumentCount: 10, DocumentsExported: 5,
+		}, nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		require.NoError(t, svc.UpdateKnowledgeBaseExport(ctx, "exp-1", gateway.ExportProgress{DocumentsExported: 3}))
+
+		repo.AssertNotCalled(t, "UpdateKnowledgeBaseExport", mock.Anything, mock.Anything)
+	})
+
+	t.Run("UpdateKnowledgeBaseExport_Completed", func(t *testing.T) {
+		completedAt := time.Now()
+		repo := repomocks.NewMockRepository()
+		repo.On("GetKnowledgeBaseExport", ctx, "exp-1").Return(&models.KnowledgeBaseExport{
+			ID: "exp-1", Status: models.KnowledgeBaseExportRunning, DocumentCount: 10, DocumentsExported: 8,
+		}, nil)
+		repo.On("UpdateKnowledgeBaseExport", ctx, mock.MatchedBy(func(exp *models.KnowledgeBaseExport) bool {
+			return exp.Status == models.KnowledgeBaseExportReady && exp.DocumentsExported == 10 &&
+				exp.SizeBytes == 4096 && exp.CompletedAt.Equal(completedAt)
+		})).Return(nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		require.NoError(t, svc.UpdateKnowledgeBaseExport(ctx, "exp-1", gateway.ExportProgress{
+			SizeBytes: 4096, Done: true, OccurredAt: completedAt,
+		}))
+
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("UpdateKnowledgeBaseExport_AlreadyFinished", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetKnowledgeBaseExport", ctx, "exp-1").Return(&models.KnowledgeBaseExport{ID: "exp-1", Status: models.KnowledgeBaseExportReady}, nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		require.NoError(t, svc.UpdateKnowledgeBaseExport(ctx, "exp-1", gateway.ExportProgress{Error: "late failure", Done: true}))
+
+		repo.AssertNotCalled(t, "UpdateKnowledgeBaseExport", mock.Anything, mock.Anything)
+	})
+}
+
+func TestCollections(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("CreateCollection_Success", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("CreateCollection", ctx, mock.MatchedBy(func(collection *models.Collection) bool {
+			return collection.Name == "Contracts" && collection.CreatedBy == "alice"
+		})).Return(true, nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		collection, err := svc.CreateCollection(ctx, models.CreateCollectionRequest{Name: " Contracts "}, "alice")
+
+		require.NoError(t, err)
+		assert.NotEmpty(t, collection.ID)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("CreateCollection_NameTaken", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("CreateCollection", ctx, mock.Anything).Return(false, nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		_, err := svc.CreateCollection(ctx, models.CreateCollectionRequest{Name: "Contracts"}, "alice")
+
+		assert.Equal(t, gateway.KindConflict, gateway.KindOf(err))
+		assert.Equal(t, `A collection named "Contracts" already exists`, gateway.MessageOf(err))
+	})
+
+	t.Run("CreateCollection_BlankName", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		_, err := svc.CreateCollection(ctx, models.CreateCollectionRequest{Name: "  "}, "alice")
+
+		assert.Equal(t, gateway.KindInvalid, gateway.KindOf(err))
+		repo.AssertNotCalled(t, "CreateCollection", mock.Anything, mock.Anything)
+	})
+
+	t.Run("AddCollectionDocuments_Success", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetCollection", ctx, "collection-1").Return(&models.Collection{ID: "collection-1", CreatedBy: "alice"}, nil)
+		repo.On("AddCollectionDocuments", ctx, "collection-1", []string{"doc-1", "doc-2"}).Return(1, nil)
+		repo.On("GetRunningEmbeddingMigration", ctx).Return(nil, nil)
+		repo.On("ListSnapshots", ctx, 100, 0).Return([]*models.Snapshot{
+			{ID: "snap-1", Status: models.SnapshotStatusReady, Collection: "documents_snapshot_1"},
+		}, 1, nil)
+		repo.On("ListDocumentCollectionIDs", ctx, "doc-1").Return([]string{"collection-1"}, nil)
+		repo.On("ListDocumentCollectionIDs", ctx, "doc-2").Return([]string{"collection-0", "collection-1"}, nil)
+		qdrant := mocks.NewMockQdrantClient()
+		for _, collection := range []string{"documents", "documents_snapshot_1"} {
+			qdrant.On("SetDocumentCollections", ctx, collection, "doc-1", []string{"collection-1"}).Return(nil).Once()
+			qdrant.On("SetDocumentCollections", ctx, collection, "doc-2", []string{"collection-0", "collection-1"}).Return(nil).Once()
+		}
+		migrations := mocks.NewMockEmbeddingMigrator()
+		migrations.On("ActiveCollection").Return("documents")
+		svc := &gateway.Service{Repository: repo, QdrantClient: qdrant, Migrations: migrations, Logger: zerolog.Nop()}
+
+		added, err := svc.AddCollectionDocuments(ctx, "collection-1", models.CollectionDocumentsRequest{DocumentIDs: []string{"doc-1", "doc-2"}}, "alice")
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, added)
+		// Every document is labelled, including those already in the
+		// collection, so retrying repairs a failed change.
+		qdrant.AssertExpectations(t)
+	})
+
+	t.Run("RemoveCollectionDocuments_Relabels", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetCollection", ctx, "collection-1").Return(&models.Collection{ID: "collection-1", CreatedBy: "alice"}, nil)
+		repo.On("ListSnapshots", ctx, 100, 0).Return([]*models.Snapshot{}, 0, nil)
+		repo.On("RemoveCollectionDocuments", ctx, "collection-1", []string{"doc-1"}).Return(1, nil)
+		repo.On("ListDocumentCollectionIDs", ctx, "doc-1").Return([]string{}, nil)
+		qdrant := mocks.NewMockQdrantClient()
+		qdrant.On("Collection").Return("documents")
+		qdrant.On("SetDocumentCollections", ctx, "documents", "doc-1", []string{}).Return(errors.New("qdrant down"))
+		svc := &gateway.Service{Repository: repo, QdrantClient: qdrant, Logger: zerolog.Nop()}
+
+		_, err := svc.RemoveCollectionDocuments(ctx, "collection-1", models.CollectionDocumentsRequest{DocumentIDs: []string{"doc-1"}}, "alice")
+
+		assert.Equal(t, gateway.KindInternal, gateway.KindOf(err))
+		qdrant.AssertExpectations(t)
+	})
+
+	t.Run("AddCollectionDocuments_NotCreator", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetCollection", ctx, "collection-1").Return(&models.Collection{ID: "collection-1", CreatedBy: "alice"}, nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		_, err := svc.AddCollectionDocuments(ctx, "collection-1", models.CollectionDocumentsRequest{DocumentIDs: []string{"doc-1"}}, "bob")
+
+		assert.Equal(t, gateway.KindForbidden, gateway.KindOf(err))
+		repo.AssertNotCalled(t, "AddCollectionDocuments", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("RemoveCollectionDocuments_TooMany", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		_, err := svc.RemoveCollectionDocuments(ctx, "collection-1", models.CollectionDocumentsRequest{
+			DocumentIDs: make([]string, gateway.MaxTagDocuments+1),
+		}, "alice")
+
+		assert.Equal(t, gateway.KindInvalid, gateway.KindOf(err))
+		repo.AssertNotCalled(t, "GetCollection", mock.Anything, mock.Anything)
+	})
+
+	t.Run("DeleteCollection_NotFound", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetCollection", ctx, "missing").Return(nil, nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		err := svc.DeleteCollection(ctx, "missing", "alice")
+
+		assert.Equal(t, gateway.KindNotFound, gateway.KindOf(err))
+		assert.Equal(t, "Collection not found", gateway.MessageOf(err))
+	})
+
+	t.Run("ListCollectionDocuments_FiltersByCollection", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetCollection", ctx, "collection-1").Return(&models.Collection{ID: "collection-1"}, nil)
+		repo.On("ListDocuments", ctx, 20, 0, models.DocumentFilter{CollectionID: "collection-1"}).Return([]*models.Document{{ID: "doc-1"}}, 1, nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		documents, total, err := svc.ListCollectionDocuments(ctx, "collection-1", 20, 0)
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, total)
+		assert.Equal(t, "doc-1", documents[0].ID)
+	})
+}
+
+func TestDocumentAccess(t *testing.T) {
+	ctx := context.Background()
+	access := &gateway.DocumentAccess{
+		Groups: map[string][]string{"hr": {"alice"}, "legal": {"carol"}},
+		Admins: []string{"root"},
+	}
+	closed := func() <-chan models.SSEEvent {
+		upstream := make(chan models.SSEEvent)
+		close(upstream)
+		return upstream
+	}
+
+	t.Run("Query_RestrictedCaller", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("CreateQueryLog", mock.Anything, mock.Anything).Return(nil)
+		core := mocks.NewMockCoreService()
+		core.On("Query", mock.Anything, models.CoreQueryRequest{Query: "what?", TopK: gateway.DefaultTopK, AccessGroups: []string{"hr"}}).Return(closed(), nil)
+		answers := mocks.NewMockAnswerCache()
+		answers.On("Find", mock.Anything, "|||access=hr", "what?").Return(nil, nil)
+		answers.On("Remember", mock.Anything, mock.Anything).Return(nil).Maybe()
+		svc := &gateway.Service{CoreClient: core, Repository: repo, Answers: answers, Access: access, Logger: zerolog.Nop()}
+
+		events, err := svc.Query(ctx, models.QueryRequest{Query: "what?"}, "alice")
+		require.NoError(t, err)
+		for range events {
+		}
+
+		core.AssertExpectations(t)
+		answers.AssertExpectations(t)
+	})
+
+	t.Run("Query_NoGroups", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("CreateQueryLog", mock.Anything, mock.Anything).Return(nil)
+		core := mocks.NewMockCoreService()
+		core.On("Query", mock.Anything, models.CoreQueryRequest{Query: "what?", TopK: gateway.DefaultTopK, AccessGroups: []string{}}).Return(closed(), nil)
+		answers := mocks.NewMockAnswerCache()
+		answers.On("Find", mock.Anything, "||", "what?").Return(nil, nil)
+		answers.On("Remember", mock.Anything, mock.Anything).Return(nil).Maybe()
+		svc := &gateway.Service{CoreClient: core, Repository: repo, Answers: answers, Access: access, Logger: zerolog.Nop()}
+
+		events, err := svc.Query(ctx, models.QueryRequest{Query: "what?"}, "bob")
+		require.NoError(t, err)
+		for range events {
+		}
+
+		core.AssertExpectations(t)
+		answers.AssertExpectations(t)
+	})
+
+	t.Run("Query_Admin", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("CreateQueryLog", mock.Anything, mock.Anything).Return(nil)
+		core := mocks.NewMockCoreService()
+		core.On("Query", mock.Anything, models.CoreQueryRequest{Query: "what?", TopK: gateway.DefaultTopK}).Return(closed(), nil)
+		answers := mocks.NewMockAnswerCache()
+		answers.On("Find", mock.Anything, "|||access=*", "what?").Return(nil, nil)
+		answers.On("Remember", mock.Anything, mock.Anything).Return(nil).Maybe()
+		svc := &gateway.Service{CoreClient: core, Repository: repo, Answers: answers, Access: access, Logger: zerolog.Nop()}
+
+		events, err := svc.Query(ctx, models.QueryRequest{Query: "what?"}, "root")
+		require.NoError(t, err)
+		for range events {
+		}
+
+		core.AssertExpectations(t)
+		answers.AssertExpectations(t)
+	})
+
+	t.Run("Query_Collection", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetCollection", ctx, "collection-1").Return(&models.Collection{ID: "collection-1", Name: "Contracts", DocumentCount: 2}, nil)
+		repo.On("CreateQueryLog", mock.Anything, mock.Anything).Return(nil)
+		core := mocks.NewMockCoreService()
+		core.On("Query", mock.Anything, models.CoreQueryRequest{Query: "what?", TopK: gateway.DefaultTopK, CollectionID: "collection-1", AccessGroups: []string{"hr"}}).Return(closed(), nil)
+		svc := &gateway.Service{CoreClient: core, Repository: repo, Access: access, Logger: zerolog.Nop()}
+
+		events, err := svc.Query(ctx, models.QueryRequest{Query: "what?", CollectionID: "collection-1"}, "alice")
+		require.NoError(t, err)
+		for range events {
+		}
+
+		core.AssertExpectations(t)
+	})
+
+	t.Run("SetDocumentAccessGroups_Success", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", Filename: "salaries.xlsx"}, nil).Once()
+		repo.On("GetRunningEmbeddingMigration", ctx).Return(&models.EmbeddingMigration{ID: "mig-1", TargetCollection: "documents_m_mig1"}, nil)
+		repo.On("ListSnapshots", ctx, 100, 0).Return([]*models.Snapshot{
+			{ID: "snap-1", Status: models.SnapshotStatusReady, Collection: "documents_snapshot_1"},
+			{ID: "snap-2", Status: models.SnapshotStatusFailed, Collection: "documents_snapshot_2"},
+		}, 2, nil)
+		repo.On("SetDocumentAccessGroups", ctx, "doc-1", []string{"hr", "legal"}).Return(true, nil)
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", Filename: "salaries.xlsx", AccessGroups: []string{"hr", "legal"}}, nil)
+		repo.On("CreateDocumentEvent", mock.Anything, mock.MatchedBy(func(event *models.DocumentEvent) bool {
+			return event.Type == models.DocumentEventAccessChanged && event.Data["changed_by"] == "root"
+		})).Return(nil)
+		qdrant := mocks.NewMockQdrantClient()
+		for _, collection := range []string{"documents", "documents_m_mig1", "documents_snapshot_1"} {
+			qdrant.On("SetDocumentAccessGroups", ctx, collection, "doc-1", []string{"hr", "legal"}).Return(nil).Once()
+		}
+		migrations := mocks.NewMockEmbeddingMigrator()
+		migrations.On("ActiveCollection").Return("documents")
+		svc := &gateway.Service{Repository: repo, QdrantClient: qdrant, Migrations: migrations, Logger: zerolog.Nop()}
+
+		doc, err := svc.SetDocumentAccessGroups(ctx, "doc-1", []string{" HR ", "legal", "hr"}, "root")
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"hr", "legal"}, doc.AccessGroups)
+		repo.AssertExpectations(t)
+		qdrant.AssertExpectations(t)
+	})
+
+	t.Run("SetDocumentAccessGroups_SnapshotInProgress", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1"}, nil)
+		repo.On("ListSnapshots", ctx, 100, 0).Return([]*models.Snapshot{
+			{ID: "snap-1", Name: "q3", Status: models.SnapshotStatusCreating, Collection: "documents_snapshot_1"},
+		}, 1, nil)
+		qdrant := mocks.NewMockQdrantClient()
+		qdrant.On("Collection").Return("documents")
+		svc := &gateway.Service{Repository: repo, QdrantClient: qdrant, Logger: zerolog.Nop()}
+
+		_, err := svc.SetDocumentAccessGroups(ctx, "doc-1", []string{"hr"}, "root")
+
+		assert.Equal(t, gateway.KindConflict, gateway.KindOf(err))
+		qdrant.AssertNotCalled(t, "SetDocumentAccessGroups", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		repo.AssertNotCalled(t, "SetDocumentAccessGroups", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("SetDocumentAccessGroups_PayloadFails", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", AccessGroups: []string{"legal"}}, nil)
+		repo.On("ListSnapshots", ctx, 100, 0).Return([]*models.Snapshot{
+			{ID: "snap-1", Status: models.SnapshotStatusReady, Collection: "documents_snapshot_1"},
+		}, 1, nil)
+		qdrant := mocks.NewMockQdrantClient()
+		qdrant.On("Collection").Return("documents")
+		qdrant.On("SetDocumentAccessGroups", ctx, "documents", "doc-1", []string{"hr"}).Return(nil)
+		qdrant.On("SetDocumentAccessGroups", ctx, "documents_snapshot_1", "doc-1", []string{"hr"}).Return(assert.AnError)
+		qdrant.On("SetDocumentAccessGroups", ctx, mock.Anything, "doc-1", []string{"legal"}).Return(nil)
+		svc := &gateway.Service{Repository: repo, QdrantClient: qdrant, Logger: zerolog.Nop()}
+
+		_, err := svc.SetDocumentAccessGroups(ctx, "doc-1", []string{"hr"}, "root")
+
+		assert.Equal(t, gateway.KindInternal, gateway.KindOf(err))
+		qdrant.AssertCalled(t, "SetDocumentAccessGroups", ctx, "documents", "doc-1", []string{"legal"})
+		repo.AssertNotCalled(t, "SetDocumentAccessGroups", mock.Anything, mock.Anything, mock.Anything)
+		repo.AssertNotCalled(t, "CreateDocumentEvent", mock.Anything, mock.Anything)
+	})
+
+	t.Run("SetDocumentAccessGroups_StoreFails", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1"}, nil)
+		repo.On("ListSnapshots", ctx, 100, 0).Return([]*models.Snapshot{}, 0, nil)
+		repo.On("SetDocumentAccessGroups", ctx, "doc-1", []string{"hr"}).Return(false, assert.AnError)
+		qdrant := mocks.NewMockQdrantClient()
+		qdrant.On("Collection").Return("documents")
+		qdrant.On("SetDocumentAccessGroups", ctx, "documents", "doc-1", []string{"hr"}).Return(nil).Once()
+		qdrant.On("SetDocumentAccessGroups", ctx, "documents", "doc-1", []string(nil)).Return(nil).Once()
+		svc := &gateway.Service{Repository: repo, QdrantClient: qdrant, Logger: zerolog.Nop()}
+
+		_, err := svc.SetDocumentAccessGroups(ctx, "doc-1", []string{"hr"}, "root")
+
+		assert.Equal(t, gateway.KindInternal, gateway.KindOf(err))
+		qdrant.AssertExpectations(t)
+	})
+
+	t.Run("SetDocumentAccessGroups_NotFound", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "missing").Return(nil, nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		_, err := svc.SetDocumentAccessGroups(ctx, "missing", []string{}, "root")
+
+		assert.Equal(t, gateway.KindNotFound, gateway.KindOf(err))
+		repo.AssertNotCalled(t, "SetDocumentAccessGroups", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("LabelIndexedDocument_LiveCollections", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", AccessGroups: []string{"hr"}}, nil)
+		repo.On("GetRunningEmbeddingMigration", ctx).Return(&models.EmbeddingMigration{ID: "mig-1", TargetCollection: "documents_m_mig1"}, nil)
+		qdrant := mocks.NewMockQdrantClient()
+		qdrant.On("SetDocumentAccessGroups", ctx, "documents", "doc-1", []string{"hr"}).Return(nil)
+		qdrant.On("SetDocumentAccessGroups", ctx, "documents_m_mig1", "doc-1", []string{"hr"}).Return(nil)
+		repo.On("ListDocumentCollectionIDs", ctx, "doc-1").Return([]string{"collection-1"}, nil)
+		qdrant.On("SetDocumentCollections", ctx, "documents", "doc-1", []string{"collection-1"}).Return(nil)
+		qdrant.On("SetDocumentCollections", ctx, "documents_m_mig1", "doc-1", []string{"collection-1"}).Return(nil)
+		migrations := mocks.NewMockEmbeddingMigrator()
+		migrations.On("ActiveCollection").Return("documents")
+		svc := &gateway.Service{Repository: repo, QdrantClient: qdrant, Migrations: migrations, Logger: zerolog.Nop()}
+
+		require.NoError(t, svc.LabelIndexedDocument(ctx, "doc-1"))
+
+		qdrant.AssertExpectations(t)
+		repo.AssertNotCalled(t, "ListSnapshots", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("SetDocumentAccessGroups_BlankGroup", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		_, err := svc.SetDocumentAccessGroups(ctx, "doc-1", []string{"hr", "  "}, "root")
+
+		assert.Equal(t, gateway.KindInvalid, gateway.KindOf(err))
+		repo.AssertNotCalled(t, "SetDocumentAccessGroups", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("CreateKnowledgeBaseExport_LeavesOutHiddenDocuments", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("ExportDocuments", ctx, models.DocumentFilter{}, mock.Anything).Return([]*models.Document{
+			{ID: "doc-1", Filename: "handbook.pdf", S3Key: "documents/doc-1/handbook.pdf"},
+			{ID: "doc-2", Filename: "salaries.xlsx", S3Key: "documents/doc-2/salaries.xlsx", AccessGroups: []string{"hr"}},
+			{ID: "doc-3", Filename: "contract.pdf", S3Key: "documents/doc-3/contract.pdf", AccessGroups: []string{"legal"}},
+		}, nil)
+		repo.On("CreateKnowledgeBaseExport", ctx, mock.MatchedBy(func(exp *models.KnowledgeBaseExport) bool {
+			return exp.DocumentCount == 2
+		})).Return(nil)
+		var manifest []models.ExportManifestEntry
+		s3 := mocks.NewMockS3Client()
+		s3.On("UploadObject", ctx, mock.Anything, mock.Anything, "application/json").Run(func(args mock.Arguments) {
+			require.NoError(t, json.NewDecoder(args.Get(2).(io.Reader)).Decode(&manifest))
+		}).Return(nil)
+		temporal := mocks.NewMockTemporalClient()
+		temporal.On("StartExportWorkflow", ctx, mock.Anything).Return("export-1", nil)
+		svc := &gateway.Service{Repository: repo, S3Client: s3, Temporal: temporal, Access: access, Logger: zerolog.Nop()}
+
+		_, err := svc.CreateKnowledgeBaseExport(ctx, models.CreateKnowledgeBaseExportRequest{}, "alice")
+
+		require.NoError(t, err)
+		require.Len(t, manifest, 2)
+		assert.Equal(t, "doc-1", manifest[0].ID)
+		assert.Equal(t, "doc-2", manifest[1].ID)
+	})
+}
+
+func TestWorkspace(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("ExportWorkspace_SkipsArchiveChildren", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("ExportDocuments", ctx, models.DocumentFilter{Status: "complete"}, mock.Anything).Return([]*models.Document{
+			{ID: "doc-1", Filename: "a.pdf", FileSize: 8, S3Key: "documents/doc-1/a.pdf", Tags: []string{"hr"}},
+			{ID: "doc-2", Filename: "b.md", ParentID: "doc-zip", S3Key: "documents/doc-zip/b.md"},
+		}, nil)
+		s3 := mocks.NewMockS3Client()
+		s3.On("GeneratePresignedDownloadURL", ctx, "documents/doc-1/a.pdf", gateway.WorkspaceExportURLTTL).Return("https://s3/doc-1", nil)
+		svc := &gateway.Service{Repository: repo, S3Client: s3, Logger: zerolog.Nop()}
+
+		bundle, err := svc.ExportWorkspace(ctx, false, "admin")
+
+		require.NoError(t, err)
+		assert.Equal(t, models.WorkspaceBundleVersion, bundle.Version)
+		assert.Equal(t, "admin", bundle.ExportedBy)
+		require.Len(t, bundle.Documents, 1)
+		assert.Equal(t, "https://s3/doc-1", bundle.Documents[0].DownloadURL)
+		assert.Equal(t, []string{"hr"}, bundle.Documents[0].Tags)
+		assert.Nil(t, bundle.Conversations)
+		repo.AssertNotCalled(t, "ListConversations", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("ExportWorkspace_Conversations", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("ExportDocuments", ctx, models.DocumentFilter{Status: "complete"}, mock.Anything).Return([]*models.Document{}, nil)
+		repo.On("ListConversations", ctx, "", 100, 0).Return([]*models.Conversation{{ID: "conv-1"}}, 1, nil)
+		repo.On("ExportMessages", ctx, "conv-1", mock.Anything).Return([]*models.Message{
+			{ID: "msg-1", ConversationID: "conv-1", Role: "user", Content: "Hi"},
+		}, nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		bundle, err := svc.ExportWorkspace(ctx, true, "admin")
+
+		require.NoError(t, err)
+		require.Len(t, bundle.Conversations, 1)
+		assert.Equal(t, "conv-1", bundle.Conversations[0].ID)
+		require.Len(t, bundle.Conversations[0].Messages, 1)
+		assert.Equal(t, "Hi", bundle.Conversations[0].Messages[0].Content)
+	})
+
+	newImporter := func(t *testing.T, repo *repomocks.MockRepository, s3 *mocks.MockS3Client, temporal *mocks.MockTemporalClient) *gateway.WorkspaceImporter {
+		t.Helper()
+		svc := &gateway.Service{Repository: repo, S3Client: s3, Temporal: temporal, Logger: zerolog.Nop()}
+		w := gateway.NewWorkspaceImporter(svc, 8<<20, 2)
+		t.Cleanup(w.Close)
+		return w
+	}
+
+	t.Run("Start_ImportsDocumentsAndConversations", func(t *testing.T) {
+		files := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/new.pdf" {
+				http.NotFound(w, r)
+				return
+			}
+			w.Header().Set("Content-Type", "application/pdf")
+			_, _ = w.Write([]byte("%PDF-1.7"))
+		}))
+		defer files.Close()
+
+		newID, existingID, missingID := uuid.New().String(), uuid.New().String(), uuid.New().String()
+		bundle := &models.WorkspaceBundle{
+			Version:      models.WorkspaceBundleVersion,
+			URLsExpireAt: time.Now().Add(time.Hour),
+			Documents: []models.WorkspaceDocument{
+				{ID: newID, Filename: "new.pdf", FileSize: 8, Tags: []string{"hr"}, UploadedBy: "alice", DownloadURL: files.URL + "/new.pdf"},
+				{ID: existingID, Filename: "old.pdf", FileSize: 8, DownloadURL: files.URL + "/old.pdf"},
+				{ID: missingID, Filename: "gone.pdf", FileSize: 8, DownloadURL: files.URL + "/gone.pdf"},
+			},
+			Conversations: []models.WorkspaceConversation{
+				{ID: "conv-1", Messages: []models.Message{{ID: "msg-1", Role: "user", Content: "Hi"}}},
+			},
+		}
+
+		repo := repomocks.NewMockRepository()
+		repo.On("CreateWorkspaceImport", ctx, mock.MatchedBy(func(imp *models.WorkspaceImport) bool {
+			return imp.DocumentsTotal == 3 && imp.CreatedBy == "admin"
+		})).Return(nil)
+		repo.On("GetDocument", mock.Anything, newID).Return(nil, nil)
+		repo.On("GetDocument", mock.Anything, existingID).Return(&models.Document{ID: existingID}, nil)
+		repo.On("GetDocument", mock.Anything, missingID).Return(nil, nil)
+		repo.On("GetWorkspaceSettings", mock.Anything).Return(nil, nil)
+		repo.On("CreateDocument", mock.Anything, mock.MatchedBy(func(doc *models.Document) bool {
+			return doc.ID == newID && doc.S3Key == "documents/"+newID+"/new.pdf" && doc.UploadedBy == "alice" && doc.Version == 1
+		})).Return(nil)
+		repo.On("AddDocumentTags", mock.Anything, []string{newID}, []string{"hr"}).Return([]string{newID}, nil)
+		repo.On("CreateDocumentEvent", mock.Anything, mock.Anything).Return(nil)
+		repo.On("SetDocumentIndexing", mock.Anything, newID, services.UploadWorkflowID(newID)).Return(nil)
+		repo.On("GetConversation", mock.Anything, "conv-1").Return(nil, nil)
+		repo.On("CreateConversation", mock.Anything, mock.MatchedBy(func(conv *models.Conversation) bool {
+			return conv.ID == "conv-1"
+		})).Return(nil)
+		repo.On("CreateMessage", mock.Anything, mock.MatchedBy(func(msg *models.Message) bool {
+			return msg.ID == "msg-1" && msg.ConversationID == "conv-1"
+		})).Return(nil)
+		var progress []int
+		var finished *models.WorkspaceImport
+		done := make(chan struct{})
+		repo.On("UpdateWorkspaceImport", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			imp := args.Get(1).(*models.WorkspaceImport)
+			if imp.Status == models.WorkspaceImportStatusRunning {
+				progress = append(progress, imp.DocumentsImported+imp.DocumentsSkipped+imp.DocumentsFailed)
+				return
+			}
+			finished = imp
+			close(done)
+		}).Return(nil)
+
+		s3 := mocks.NewMockS3Client()
+		s3.On("StreamObject", mock.Anything, "documents/"+newID+"/new.pdf", mock.Anything, "application/pdf", int64(8<<20), 2).Return(nil)
+		temporal := mocks.NewMockTemporalClient()
+		temporal.On("StartUploadWorkflow", mock.Anything, mock.MatchedBy(func(input services.UploadWorkflowInput) bool {
+			return input.DocumentID == newID
+		})).Return("upload-1", nil)
+		temporal.On("SignalUploadComplete", mock.Anything, newID, mock.Anything, (*models.ProcessingOptions)(nil)).Return(nil)
+
+		imp, err := newImporter(t, repo, s3, temporal).Start(ctx, bundle, "admin")
+		require.NoError(t, err)
+		assert.Equal(t, models.WorkspaceImportStatusRunning, imp.Status)
+
+		<-done
+		assert.Equal(t, []int{1, 2, 3}, progress)
+		assert.Equal(t, models.WorkspaceImportStatusCompleted, finished.Status)
+		assert.Equal(t, 1, finished.DocumentsImported)
+		assert.Equal(t, 1, finished.DocumentsSkipped)
+		assert.Equal(t, 1, finished.DocumentsFailed)
+		require.Len(t, finished.Failures, 1)
+		assert.Equal(t, missingID, finished.Failures[0].DocumentID)
+		assert.Equal(t, "Failed to download file: status 404", finished.Failures[0].Error)
+		assert.Equal(t, 1, finished.ConversationsImported)
+		assert.NotNil(t, finished.CompletedAt)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Start_SizeMismatch", func(t *testing.T) {
+		files := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("%PDF-1.7 and more"))
+		}))
+		defer files.Close()
+
+		id := uuid.New().String()
+		repo := repomocks.NewMockRepository()
+		repo.On("CreateWorkspaceImport", ctx, mock.Anything).Return(nil)
+		repo.On("GetDocument", mock.Anything, id).Return(nil, nil)
+		repo.On("GetWorkspaceSettings", mock.Anything).Return(nil, nil)
+		var finished *models.WorkspaceImport
+		done := make(chan struct{})
+		repo.On("UpdateWorkspaceImport", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			if imp := args.Get(1).(*models.WorkspaceImport); imp.Status != models.WorkspaceImportStatusRunning {
+				finished = imp
+				close(done)
+			}
+		}).Return(nil)
+		s3 := mocks.NewMockS3Client()
+		s3.On("DeleteObject", mock.Anything, "documents/"+id+"/a.pdf").Return(nil)
+
+		_, err := newImporter(t, repo, s3, mocks.NewMockTemporalClient()).Start(ctx, &models.WorkspaceBundle{
+			Version:      models.WorkspaceBundleVersion,
+			URLsExpireAt: time.Now().Add(time.Hour),
+			Documents:    []models.WorkspaceDocument{{ID: id, Filename: "a.pdf", FileSize: 8, DownloadURL: files.URL}},
+		}, "admin")
+		require.NoError(t, err)
+
+		<-done
+		require.Len(t, finished.Failures, 1)
+		assert.Equal(t, "Downloaded file does not match the bundle's file size", finished.Failures[0].Error)
+		s3.AssertExpectations(t)
+		repo.AssertNotCalled(t, "CreateDocument", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Start_AlreadyRunning", func(t *testing.T) {
+		release := make(chan struct{})
+		repo := repomocks.NewMockRepository()
+		repo.On("CreateWorkspaceImport", ctx, mock.Anything).Return(nil)
+		repo.On("GetConversation", mock.Anything, "conv-1").Run(func(mock.Arguments) {
+			<-release
+		}).Return(&models.Conversation{ID: "conv-1"}, nil)
+		done := make(chan struct{})
+		repo.On("UpdateWorkspaceImport", mock.Anything, mock.Anything).Run(func(mock.Arguments) {
+			close(done)
+		}).Return(nil)
+		bundle := &models.WorkspaceBundle{
+			Version:       models.WorkspaceBundleVersion,
+			Conversations: []models.WorkspaceConversation{{ID: "conv-1"}},
+		}
+
+		w := newImporter(t, repo, mocks.NewMockS3Client(), mocks.NewMockTemporalClient())
+		_, err := w.Start(ctx, bundle, "admin")
+		require.NoError(t, err)
+
+		_, err = w.Start(ctx, bundle, "admin")
+		assert.Equal(t, gateway.KindConflict, gateway.KindOf(err))
+		assert.Equal(t, "A workspace import is already running", gateway.MessageOf(err))
+
+		close(release)
+		<-done
+		repo.AssertNumberOfCalls(t, "CreateWorkspaceImport", 1)
+	})
+
+	t.Run("Start_RejectsBundle", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		w := newImporter(t, repo, mocks.NewMockS3Client(), mocks.NewMockTemporalClient())
+
+		_, err := w.Start(ctx, &models.WorkspaceBundle{Version: 2}, "admin")
+		assert.Equal(t, gateway.KindInvalid, gateway.KindOf(err))
+		assert.Equal(t, "Unsupported bundle version 2", gateway.MessageOf(err))
+
+		_, err = w.Start(ctx, &models.WorkspaceBundle{
+			Version:      models.WorkspaceBundleVersion,
+			URLsExpireAt: time.Now().Add(-time.Minute),
+			Documents:    []models.WorkspaceDocument{{ID: uuid.New().String()}},
+		}, "admin")
+		assert.Equal(t, gateway.KindInvalid, gateway.KindOf(err))
+		repo.AssertNotCalled(t, "CreateWorkspaceImport", mock.Anything, mock.Anything)
+	})
+}
+
+func TestParticipants(t *testing.T) {
+	ctx := context.Background()
+	conv := &models.Conversation{ID: "conv-1"}
+
+	t.Run("ListConversations_WithoutUsername", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		conversations, total, err := svc.ListConversations(ctx, "", 50, 0)
+
+		require.NoError(t, err)
+		assert.Empty(t, conversations)
+		assert.Zero(t, total)
+		repo.AssertNotCalled(t, "ListConversations", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("InviteParticipant_SharesConversation", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetConversation", ctx, "conv-1").Return(&models.Conversation{ID: "conv-1", CreatedBy: "alice"}, nil)
+		repo.On("ListConversationParticipants", ctx, "conv-1").Return(nil, nil)
+		repo.On("AddConversationParticipant", ctx, mock.MatchedBy(func(p *models.ConversationParticipant) bool {
+			return p.Username == "alice" && p.InvitedBy == ""
+		})).Return(true, nil).Once()
+		repo.On("AddConversationParticipant", ctx, mock.MatchedBy(func(p *models.ConversationParticipant) bool {
+			return p.Username == "bob" && p.InvitedBy == "alice"
+		})).Return(true, nil).Once()
+		repo.On("GetConversationParticipant", ctx, "conv-1", "bob").Return(&models.ConversationParticipant{
+			ConversationID: "conv-1", Username: "bob", InvitedBy: "alice",
+		}, nil)
+		hub := services.NewEventHub(4)
+		events, cancel := hub.Subscribe("conversation:conv-1")
+		defer cancel()
+		svc := &gateway.Service{Repository: repo, Events: hub, Logger: zerolog.Nop()}
+
+		participant, err := svc.InviteParticipant(ctx, "conv-1", "alice", "bob")
+
+		require.NoError(t, err)
+		assert.Equal(t, "alice", participant.InvitedBy)
+		repo.AssertExpectations(t)
+		for _, username := range []string{"alice", "bob"} {
+			event := <-events
+			assert.Equal(t, models.EventConversationParticipantAdded, event.Type)
+			assert.Equal(t, username, event.Data["username"])
+		}
+	})
+
+	t.Run("InviteParticipant_UnsharedNotCreator", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetConversation", ctx, "conv-1").Return(&models.Conversation{ID: "conv-1", CreatedBy: "alice"}, nil)
+		repo.On("ListConversationParticipants", ctx, "conv-1").Return(nil, nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		_, err := svc.InviteParticipant(ctx, "conv-1", "mallory", "mallory")
+
+		assert.Equal(t, gateway.KindForbidden, gateway.KindOf(err))
+		repo.AssertNotCalled(t, "AddConversationParticipant", mock.Anything, mock.Anything)
+	})
+
+	t.Run("InviteParticipant_LegacyConversation", func(t *testing.T) {
+		// Conversations created before creators were recorded can be
+		// shared by any user, who becomes their first participant.
+		repo := repomocks.NewMockRepository()
+		repo.On("GetConversation", ctx, "conv-1").Return(conv, nil)
+		repo.On("ListConversationParticipants", ctx, "conv-1").Return(nil, nil)
+		repo.On("AddConversationParticipant", ctx, mock.MatchedBy(func(p *models.ConversationParticipant) bool {
+			return p.Username == "alice" && p.InvitedBy == ""
+		})).Return(true, nil).Once()
+		repo.On("AddConversationParticipant", ctx, mock.MatchedBy(func(p *models.ConversationParticipant) bool {
+			return p.Username == "bob" && p.InvitedBy == "alice"
+		})).Return(true, nil).Once()
+		repo.On("GetConversationParticipant", ctx, "conv-1", "bob").Return(&models.ConversationParticipant{
+			ConversationID: "conv-1", Username: "bob", InvitedBy: "alice",
+		}, nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		participant, err := svc.InviteParticipant(ctx, "conv-1", "alice", "bob")
+
+		require.NoError(t, err)
+		assert.Equal(t, "alice", participant.InvitedBy)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("InviteParticipant_LegacyConversationGuest", func(t *testing.T) {
+		guestCtx := gateway.WithGuest(ctx)
+		repo := repomocks.NewMockRepository()
+		repo.On("GetConversation", guestCtx, "conv-1").Return(conv, nil)
+		repo.On("ListConversationParticipants", guestCtx, "conv-1").Return(nil, nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		_, err := svc.InviteParticipant(guestCtx, "conv-1", "demo:guest", "demo:guest")
+
+		assert.Equal(t, gateway.KindForbidden, gateway.KindOf(err))
+		repo.AssertNotCalled(t, "AddConversationParticipant", mock.Anything, mock.Anything)
+	})
+
+	t.Run("InviteParticipant_NotParticipant", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetConversation", ctx, "conv-1").Return(conv, nil)
+		repo.On("ListConversationParticipants", ctx, "conv-1").Return([]*models.ConversationParticipant{
+			{ConversationID: "conv-1", Username: "alice"},
+		}, nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		_, err := svc.InviteParticipant(ctx, "conv-1", "mallory", "bob")
+
+		assert.Equal(t, gateway.KindForbidden, gateway.KindOf(err))
+		repo.AssertNotCalled(t, "AddConversationParticipant", mock.Anything, mock.Anything)
+	})
+
+	t.Run("InviteParticipant_NarrowerAccess", func(t *testing.T) {
+		access := &gateway.DocumentAccess{
+			Groups: map[string][]string{"hr": {"alice", "bob"}, "legal": {"alice", "carol"}},
+			Admins: []string{"root"},
+		}
+		for _, tc := range []struct {
+			participants []string
+			username     string
+			allowed      bool
+		}{
+			{[]string{"alice"}, "bob", false},
+			{[]string{"bob"}, "alice", true},
+			{[]string{"bob"}, "root", true},
+			{[]string{"bob", "root"}, "alice", false},
+			{[]string{"carol"}, "dave", false},
+		} {
+			participants := make([]*models.ConversationParticipant, len(tc.participants))
+			for i, username := range tc.participants {
+				participants[i] = &models.ConversationParticipant{ConversationID: "conv-1", Username: username}
+			}
+			repo := repomocks.NewMockRepository()
+			repo.On("GetConversation", ctx, "conv-1").Return(conv, nil)
+			repo.On("ListConversationParticipants", ctx, "conv-1").Return(participants, nil)
+			repo.On("AddConversationParticipant", ctx, mock.Anything).Return(true, nil)
+			repo.On("GetConversationParticipant", ctx, "conv-1", tc.username).Return(&models.ConversationParticipant{
+				ConversationID: "conv-1", Username: tc.username,
+			}, nil)
+			svc := &gateway.Service{Repository: repo, Access: access, Logger: zerolog.Nop()}
+
+			_, err := svc.InviteParticipant(ctx, "conv-1", tc.participants[0], tc.username)
+
+			if tc.allowed {
+				assert.NoError(t, err, tc.username)
+			} else {
+				assert.Equal(t, gateway.KindForbidden, gateway.KindOf(err), tc.username)
+				repo.AssertNotCalled(t, "AddConversationParticipant", mock.Anything, mock.Anything)
+			}
+		}
+	})
+
+	t.Run("InviteParticipant_ConversationNotFound", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetConversation", ctx, "conv-1").Return(nil, nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		_, err := svc.InviteParticipant(ctx, "conv-1", "alice", "bob")
+
+		assert.Equal(t, gateway.KindNotFound, gateway.KindOf(err))
+	})
+
+	t.Run("RemoveParticipant_OnlySelf", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		err := svc.RemoveParticipant(ctx, "conv-1", "alice", "bob")
+
+		assert.Equal(t, gateway.KindForbidden, gateway.KindOf(err))
+		repo.AssertNotCalled(t, "RemoveConversationParticipant", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("RemoveParticipant_NotFound", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetConversation", ctx, "conv-1").Return(conv, nil)
+		repo.On("RemoveConversationParticipant", ctx, "conv-1", "bob").Return(false, nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		err := svc.RemoveParticipant(ctx, "conv-1", "bob", "bob")
+
+		assert.Equal(t, gateway.KindNotFound, gateway.KindOf(err))
+	})
+
+	t.Run("MarkConversationRead_UpToMessage", func(t *testing.T) {
+		readAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+		repo := repomocks.NewMockRepository()
+		repo.On("GetConversation", ctx, "conv-1").Return(conv, nil)
+		repo.On("GetMessage", ctx, "msg-1").Return(&models.Message{ID: "msg-1", ConversationID: "conv-1", CreatedAt: readAt}, nil)
+		repo.On("MarkConversationRead", ctx, "conv-1", "bob", readAt).Return(true, nil)
+		repo.On("GetConversationParticipant", ctx, "conv-1", "bob").Return(&models.ConversationParticipant{
+			ConversationID: "conv-1", Username: "bob", LastReadAt: &readAt, UnreadCount: 2,
+		}, nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		participant, err := svc.MarkConversationRead(ctx, "conv-1", "bob", "msg-1")
+
+		require.NoError(t, err)
+		assert.Equal(t, 2, participant.UnreadCount)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("MarkConversationRead_MessageInOtherConversation", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetConversation", ctx, "conv-1").Return(conv, nil)
+		repo.On("GetMessage", ctx, "msg-1").Return(&models.Message{ID: "msg-1", ConversationID: "conv-2"}, nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		_, err := svc.MarkConversationRead(ctx, "conv-1", "bob", "msg-1")
+
+		assert.Equal(t, gateway.KindNotFound, gateway.KindOf(err))
+		repo.AssertNotCalled(t, "MarkConversationRead", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("MarkConversationRead_NotParticipant", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetConversation", ctx, "conv-1").Return(conv, nil)
+		repo.On("MarkConversationRead", ctx, "conv-1", "mallory", mock.AnythingOfType("time.Time")).Return(false, nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		_, err := svc.MarkConversationRead(ctx, "conv-1", "mallory", "")
+
+		assert.Equal(t, gateway.KindForbidden, gateway.KindOf(err))
+	})
+
+	t.Run("Query_SharesAnswer", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetConversation", mock.Anything, "conv-1").Return(&models.Conversation{ID: "conv-1", CreatedBy: "alice"}, nil)
+		repo.On("CreateQueryLog", mock.Anything, mock.Anything).Return(nil)
+		upstream := make(chan models.SSEEvent, 3)
+		upstream <- models.SSEEvent{Type: "chunk", Content: "Thirty "}
+		upstream <- models.SSEEvent{Type: "chunk", Content: "days."}
+		upstream <- models.SSEEvent{Type: "end", ID: "q-1"}
+		close(upstream)
+
+		core := mocks.NewMockCoreService()
+		core.On("Query", mock.Anything, models.CoreQueryRequest{Query: "refunds?", ConversationID: "conv-1", TopK: gateway.DefaultTopK}).Return((<-chan models.SSEEvent)(upstream), nil)
+		hub := services.NewEventHub(4)
+		shared, cancel := hub.Subscribe("conversation:conv-1")
+		defer cancel()
+		svc := &gateway.Service{CoreClient: core, Repository: repo, Events: hub, Logger: zerolog.Nop()}
+
+		events, err := svc.Query(ctx, models.QueryRequest{Query: "refunds?", ConversationID: "conv-1"}, "alice")
+		require.NoError(t, err)
+		for range events {
+		}
+
+		event := <-shared
+		assert.Equal(t, models.EventConversationMessage, event.Type)
+		assert.Equal(t, "conv-1", event.SubjectID)
+		assert.Equal(t, map[string]interface{}{
+			"query_id": "q-1", "username": "alice", "question": "refunds?", "answer": "Thirty days.",
+		}, event.Data)
+	})
+}
+
+func TestIngestEvent(t *testing.T) {
+	ctx := context.Background()
+
+	ingest := func(t *testing.T, svc *gateway.Service, body string) (*models.Event, bool, error) {
+		t.Helper()
+		var req models.IngestEventRequest
+		require.NoError(t, json.Unmarshal([]byte(body), &req))
+		return svc.IngestEvent(ctx, req)
+	}
+
+	t.Run("Routed", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("UpdateDocumentStatus", ctx, "doc-1", "failed", "parse error").Return(nil)
+		repo.On("CreateDocumentEvent", ctx, mock.MatchedBy(func(event *models.DocumentEvent) bool {
+			return event.DocumentID == "doc-1" && event.Type == models.DocumentEventFailed &&
+				event.Source == models.EventSourceTemporal && event.Message == "parse error" && event.ID != ""
+		})).Return(nil)
+		repo.On("CreateEvent", ctx, mock.AnythingOfType("*models.Event")).Return(true, nil)
+		webhooks := mocks.NewMockWebhookDispatcher()
+		webhooks.On("Dispatch", ctx, models.EventDocumentFailed, mock.AnythingOfType("*models.Event")).Return()
+		hub := services.NewEventHub(1)
+		events, cancel := hub.Subscribe("document:doc-1")
+		defer cancel()
+		svc := &gateway.Service{Repository: repo, Webhooks: webhooks, PublicEvents: hub}
+
+		event, created, err := ingest(t, svc, `{"type":"document.failed","source":"temporal","subject_id":"doc-1","data":{"error":"parse error"}}`)
+
+		require.NoError(t, err)
+		assert.True(t, created)
+		assert.NotEmpty(t, event.ID)
+		assert.False(t, event.OccurredAt.IsZero())
+		assert.Equal(t, models.EventDocumentFailed, (<-events).Type)
+		repo.AssertExpectations(t)
+		webhooks.AssertExpectations(t)
+	})
+
+	t.Run("Duplicate", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("UpdateDocumentStatus", ctx, "doc-1", "complete", "").Return(nil)
+		repo.On("CreateDocumentEvent", ctx, mock.Anything).Return(nil)
+		repo.On("CreateEvent", ctx, mock.AnythingOfType("*models.Event")).Return(false, nil)
+		webhooks := mocks.NewMockWebhookDispatcher()
+		svc := &gateway.Service{Repository: repo, Webhooks: webhooks}
+
+		event, created, err := ingest(t, svc, `{"id":"5f0c3a52-8e1b-4b9e-9f43-6f1f4f3c2a10","type":"document.indexed","source":"python-core","subject_id":"doc-1"}`)
+
+		require.NoError(t, err)
+		assert.False(t, created)
+		assert.Equal(t, "5f0c3a52-8e1b-4b9e-9f43-6f1f4f3c2a10", event.ID)
+		webhooks.AssertNotCalled(t, "Dispatch", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("StoreError", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("CreateDocumentEvent", ctx, mock.Anything).Return(nil)
+		repo.On("CreateEvent", ctx, mock.AnythingOfType("*models.Event")).Return(false, errors.New("db down"))
+		svc := &gateway.Service{Repository: repo}
+
+		_, _, err := ingest(t, svc, `{"type":"document.scanned","source":"python-core","subject_id":"doc-1"}`)
+
+		assert.Equal(t, gateway.KindInternal, gateway.KindOf(err))
+		assert.Equal(t, "Failed to store event", gateway.MessageOf(err))
+	})
+
+	t.Run("Notifies", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("UpdateDocumentStatus", ctx, "doc-1", "complete", "").Return(nil)
+		repo.On("CreateDocumentEvent", ctx, mock.Anything).Return(nil)
+		repo.On("CreateEvent", ctx, mock.AnythingOfType("*models.Event")).Return(true, nil)
+		webhooks := mocks.NewMockWebhookDispatcher()
+		webhooks.On("Dispatch", ctx, models.EventDocumentIndexed, mock.AnythingOfType("*models.Event")).Return()
+		notifications := mocks.NewMockNotificationService()
+		notifications.On("DocumentIndexed", ctx, "doc-1").Return()
+		svc := &gateway.Service{Repository: repo, Webhooks: webhooks, Notifications: notifications}
+
+		_, created, err := ingest(t, svc, `{"type":"document.indexed","source":"python-core","subject_id":"doc-1"}`)
+
+		require.NoError(t, err)
+		assert.True(t, created)
+		notifications.AssertExpectations(t)
+	})
+
+	t.Run("IndexedLabelsVectors", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("UpdateDocumentStatus", ctx, "doc-1", "complete", "").Return(nil)
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", AccessGroups: []string{"hr"}}, nil)
+		repo.On("CreateDocumentEvent", ctx, mock.Anything).Return(nil)
+		repo.On("CreateEvent", ctx, mock.AnythingOfType("*models.Event")).Return(true, nil)
+		qdrant := mocks.NewMockQdrantClient()
+		qdrant.On("Collection").Return("documents")
+		qdrant.On("SetDocumentAccessGroups", ctx, "documents", "doc-1", []string{"hr"}).Return(nil)
+		repo.On("ListDocumentCollectionIDs", ctx, "doc-1").Return([]string{"collection-1"}, nil)
+		qdrant.On("SetDocumentCollections", ctx, "documents", "doc-1", []string{"collection-1"}).Return(nil)
+		svc := &gateway.Service{Repository: repo, QdrantClient: qdrant}
+
+		_, _, err := ingest(t, svc, `{"type":"document.indexed","source":"python-core","subject_id":"doc-1"}`)
+
+		require.NoError(t, err)
+		qdrant.AssertExpectations(t)
+	})
+
+	t.Run("IndexedLabelsVectorsError", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("UpdateDocumentStatus", ctx, "doc-1", "complete", "").Return(nil)
+		repo.On("GetDocument", ctx, "doc-1").Return(&models.Document{ID: "doc-1", AccessGroups: []string{"hr"}}, nil)
+		qdrant := mocks.NewMockQdrantClient()
+		qdrant.On("Collection").Return("documents")
+		qdrant.On("SetDocumentAccessGroups", ctx, "documents", "doc-1", []string{"hr"}).Return(errors.New("qdrant down"))
+		svc := &gateway.Service{Repository: repo, QdrantClient: qdrant}
+
+		_, _, err := ingest(t, svc, `{"type":"document.indexed","source":"python-core","subject_id":"doc-1"}`)
+
+		assert.Equal(t, gateway.KindInternal, gateway.KindOf(err))
+		assert.Equal(t, "Failed to apply event", gateway.MessageOf(err))
+		repo.AssertNotCalled(t, "CreateEvent", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Alerts", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("UpdateDocumentStatus", ctx, "doc-1", "failed", "timeout").Return(nil)
+		repo.On("CreateDocumentEvent", ctx, mock.Anything).Return(nil)
+		repo.On("CreateEvent", ctx, mock.AnythingOfType("*models.Event")).Return(true, nil)
+		webhooks := mocks.NewMockWebhookDispatcher()
+		webhooks.On("Dispatch", ctx, models.EventDocumentFailed, mock.AnythingOfType("*models.Event")).Return()
+		alerts := mocks.NewMockOpsMonitor()
+		alerts.On("DocumentFailed", ctx, "doc-1").Return()
+		svc := &gateway.Service{Repository: repo, Webhooks: webhooks, Alerts: alerts}
+
+		_, _, err := ingest(t, svc, `{"type":"document.failed","source":"temporal","subject_id":"doc-1","data":{"error":"timeout"}}`)
+
+		require.NoError(t, err)
+		alerts.AssertExpectations(t)
+	})
+
+	t.Run("Reindexed", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("CreateDocumentEvent", ctx, mock.Anything).Return(nil)
+		repo.On("CreateEvent", ctx, mock.AnythingOfType("*models.Event")).Return(true, nil)
+		migrations := mocks.NewMockEmbeddingMigrator()
+		migrations.On("DocumentReindexed", ctx, "mig-1", "doc-1", false).Return(nil)
+		svc := &gateway.Service{Repository: repo, Migrations: migrations}
+
+		_, _, err := ingest(t, svc, `{"type":"document.reindex_failed","source":"temporal","subject_id":"doc-1","data":{"migration_id":"mig-1"}}`)
+
+		require.NoError(t, err)
+		migrations.AssertExpectations(t)
+		repo.AssertNotCalled(t, "UpdateDocumentStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("ReindexedError", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		migrations := mocks.NewMockEmbeddingMigrator()
+		migrations.On("DocumentReindexed", ctx, "mig-1", "doc-1", true).Return(errors.New("db down"))
+		svc := &gateway.Service{Repository: repo, Migrations: migrations}
+
+		_, _, err := ingest(t, svc, `{"type":"document.reindexed","source":"temporal","subject_id":"doc-1","data":{"migration_id":"mig-1"}}`)
+
+		assert.Equal(t, gateway.KindInternal, gateway.KindOf(err))
+		repo.AssertNotCalled(t, "CreateEvent", mock.Anything, mock.Anything)
+	})
+
+	t.Run("PipelineStage", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("CreateDocumentEvent", ctx, mock.MatchedBy(func(event *models.DocumentEvent) bool {
+			return event.Type == models.DocumentEventChunked && event.Data["chunks"] == float64(42)
+		})).Return(nil)
+		repo.On("CreateEvent", ctx, mock.AnythingOfType("*models.Event")).Return(true, nil)
+		svc := &gateway.Service{Repository: repo}
+
+		_, _, err := ingest(t, svc, `{"type":"document.chunked","source":"python-core","subject_id":"doc-1","data":{"chunks":42}}`)
+
+		require.NoError(t, err)
+		repo.AssertExpectations(t)
+		repo.AssertNotCalled(t, "UpdateDocumentStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("DocumentEventError", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("CreateDocumentEvent", ctx, mock.Anything).Return(errors.New("db down"))
+		svc := &gateway.Service{Repository: repo}
+
+		_, _, err := ingest(t, svc, `{"type":"document.scanned","source":"python-core","subject_id":"doc-1"}`)
+
+		assert.Equal(t, gateway.KindInternal, gateway.KindOf(err))
+		repo.AssertNotCalled(t, "CreateEvent", mock.Anything, mock.Anything)
+	})
+
+	t.Run("ConnectorSyncFailed", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("FinishConnectorSync", ctx, "c-1", "token revoked", mock.Anything).Return(nil)
+		repo.On("CreateEvent", ctx, mock.AnythingOfType("*models.Event")).Return(true, nil)
+		svc := &gateway.Service{Repository: repo}
+
+		_, _, err := ingest(t, svc, `{"type":"connector.sync_failed","source":"temporal","subject_id":"c-1","data":{"error":"token revoked"}}`)
+
+		require.NoError(t, err)
+		repo.AssertExpectations(t)
+		repo.AssertNotCalled(t, "CreateDocumentEvent", mock.Anything, mock.Anything)
+	})
+
+	t.Run("SnapshotCreated", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetSnapshot", ctx, "snap-1").Return(&models.Snapshot{ID: "snap-1", Status: models.SnapshotStatusCreating}, nil)
+		repo.On("FinishSnapshot", ctx, mock.MatchedBy(func(snapshot *models.Snapshot) bool {
+			return snapshot.Status == models.SnapshotStatusReady && snapshot.QdrantSnapshot == "documents.snapshot" && snapshot.VectorCount == 42
+		})).Return(nil)
+		repo.On("CreateEvent", ctx, mock.AnythingOfType("*models.Event")).Return(true, nil)
+		svc := &gateway.Service{Repository: repo}
+
+		_, _, err := ingest(t, svc, `{"type":"snapshot.created","source":"temporal","subject_id":"snap-1","data":{"qdrant_snapshot":"documents.snapshot","vector_count":42}}`)
+
+		require.NoError(t, err)
+		repo.AssertExpectations(t)
+		repo.AssertNotCalled(t, "CreateDocumentEvent", mock.Anything, mock.Anything)
+	})
+
+	t.Run("ExportProgress", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetKnowledgeBaseExport", ctx, "exp-1").Return(&models.KnowledgeBaseExport{
+			ID: "exp-1", Status: models.KnowledgeBaseExportRunning, DocumentCount: 10,
+		}, nil)
+		repo.On("UpdateKnowledgeBaseExport", ctx, mock.MatchedBy(func(exp *models.KnowledgeBaseExport) bool {
+			return exp.Status == models.KnowledgeBaseExportRunning && exp.DocumentsExported == 4
+		})).Return(nil)
+		repo.On("CreateEvent", ctx, mock.AnythingOfType("*models.Event")).Return(true, nil)
+		hub := services.NewEventHub(1)
+		events, cancel := hub.Subscribe("export:exp-1")
+		defer cancel()
+		svc := &gateway.Service{Repository: repo, PublicEvents: hub}
+
+		_, _, err := ingest(t, svc, `{"type":"export.progress","source":"temporal","subject_id":"exp-1","data":{"documents_exported":4}}`)
+
+		require.NoError(t, err)
+		repo.AssertExpectations(t)
+		select {
+		case event := <-events:
+			assert.Equal(t, models.EventExportProgress, event.Type)
+		default:
+			t.Fatal("expected the progress event on the export's topic")
+		}
+	})
+
+	t.Run("ExportCompletedMissingSize", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		svc := &gateway.Service{Repository: repo}
+
+		_, _, err := ingest(t, svc, `{"type":"export.completed","source":"temporal","subject_id":"exp-1"}`)
+
+		assert.Equal(t, gateway.KindInvalid, gateway.KindOf(err))
+		assert.Equal(t, "data.size_bytes", gateway.DetailsOf(err)["field"])
+		repo.AssertNotCalled(t, "UpdateKnowledgeBaseExport", mock.Anything, mock.Anything)
+	})
+
+	t.Run("DocumentExpanded", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("UpdateDocumentStatus", ctx, "zip-1", "complete", "").Return(nil)
+		repo.On("CreateDocumentEvent", ctx, mock.MatchedBy(func(event *models.DocumentEvent) bool {
+			return event.Type == models.DocumentEventExpanded && event.Data["file_count"] == float64(12)
+		})).Return(nil)
+		repo.On("CreateEvent", ctx, mock.AnythingOfType("*models.Event")).Return(true, nil)
+		svc := &gateway.Service{Repository: repo}
+
+		_, _, err := ingest(t, svc, `{"type":"document.expanded","source":"temporal","subject_id":"zip-1","data":{"file_count":12}}`)
+
+		require.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("DocumentLanguage", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("UpdateDocumentStatus", ctx, "doc-1", "complete", "").Return(nil)
+		repo.On("SetDocumentLanguage", ctx, "doc-1", "de").Return(nil)
+		repo.On("CreateDocumentEvent", ctx, mock.Anything).Return(nil)
+		repo.On("CreateEvent", ctx, mock.AnythingOfType("*models.Event")).Return(true, nil)
+		svc := &gateway.Service{Repository: repo}
+
+		_, _, err := ingest(t, svc, `{"type":"document.indexed","source":"python-core","subject_id":"doc-1","data":{"language":"DE"}}`)
+
+		require.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("DocumentTitle", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("UpdateDocumentStatus", ctx, "doc-1", "complete", "").Return(nil)
+		repo.On("SetDocumentTitle", ctx, "doc-1", "Refund Policy 2026").Return(nil)
+		repo.On("CreateDocumentEvent", ctx, mock.Anything).Return(nil)
+		repo.On("CreateEvent", ctx, mock.AnythingOfType("*models.Event")).Return(true, nil)
+		svc := &gateway.Service{Repository: repo}
+
+		_, _, err := ingest(t, svc, `{"type":"document.indexed","source":"python-core","subject_id":"doc-1","data":{"title":" Refund Policy 2026 "}}`)
+
+		require.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("InvalidLanguage", func(t *testing.T) {
+		svc := &gateway.Service{Repository: repomocks.NewMockRepository()}
+
+		_, _, err := ingest(t, svc, `{"type":"document.indexed","source":"python-core","subject_id":"doc-1","data":{"language":"german"}}`)
+
+		assert.Equal(t, gateway.KindInvalid, gateway.KindOf(err))
+		assert.Equal(t, "data.language", gateway.DetailsOf(err)["field"])
+	})
+
+	t.Run("UnknownType", func(t *testing.T) {
+		svc := &gateway.Service{Repository: repomocks.NewMockRepository()}
+
+		_, _, err := ingest(t, svc, `{"type":"document.exploded","source":"temporal","subject_id":"doc-1"}`)
+
+		assert.Equal(t, gateway.KindInvalid, gateway.KindOf(err))
+		assert.Equal(t, "document.exploded", gateway.DetailsOf(err)["type"])
+	})
+
+	t.Run("MissingData", func(t *testing.T) {
+		svc := &gateway.Service{Repository: repomocks.NewMockRepository()}
+
+		_, _, err := ingest(t, svc, `{"type":"document.failed","source":"temporal","subject_id":"doc-1"}`)
+
+		assert.Equal(t, gateway.KindInvalid, gateway.KindOf(err))
+		assert.Equal(t, "data.error", gateway.DetailsOf(err)["field"])
+	})
+}
@@ -0,0 +1,71 @@
+package gateway
+
+import (
+	"context"
+
+	"kb-platform-gateway/internal/models"
+	"kb-platform-gateway/internal/services"
+)
+
+// highlighter adds glossary highlights to the events of a streamed answer.
+// Events the core already highlighted are passed through as they are.
+type highlighter struct {
+	matcher *services.TermMatcher
+	answer  []rune
+	// next is the offset in answer from which terms remain to be found.
+	next int
+}
+
+// highlighter returns a highlighter for the current glossary, or nil if
+// it is empty or cannot be loaded.
+func (s *Service) highlighter(ctx context.Context) *highlighter {
+	if s.Glossary == nil {
+		return nil
+	}
+	matcher, err := s.Glossary.Matcher(ctx)
+	if err != nil {
+		s.Logger.Error().Err(err).Msg("Failed to load glossary")
+		return nil
+	}
+	if matcher == nil {
+		return nil
+	}
+	return &highlighter{matcher: matcher}
+}
+
+// apply adds highlights to event. A term split across chunks is reported
+// on the chunk that completes it, and one that ends the answer on the end
+// event.
+func (h *highlighter) apply(event *models.SSEEvent) {
+	switch event.Type {
+	case "chunk":
+		for _, r := range event.Content {
+			h.answer = append(h.answer, r)
+		}
+		if event.Highlights != nil {
+			h.next = len(h.answer)
+			return
+		}
+		event.Highlights, h.next = h.matcher.Match(h.answer, h.next, false)
+	case "sources":
+		for i := range event.Sources {
+			source := &event.Sources[i]
+			if source.Highlights == nil && source.Text != "" {
+				source.Highlights, _ = h.matcher.Match([]rune(source.Text), 0, true)
+			}
+		}
+	case "end":
+		found, _ := h.matcher.Match(h.answer, h.next, true)
+		event.Highlights = append(event.Highlights, found...)
+	}
+}
+
+// highlightAnswer highlights a complete answer sent as a single chunk.
+func (s *Service) highlightAnswer(ctx context.Context, answer string) []models.Highlight {
+	h := s.highlighter(ctx)
+	if h == nil {
+		return nil
+	}
+	found, _ := h.matcher.Match([]rune(answer), 0, true)
+	return found
+}
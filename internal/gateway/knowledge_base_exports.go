@@ -0,0 +1,223 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"kb-platform-gateway/internal/export"
+	"kb-platform-gateway/internal/models"
+	"kb-platform-gateway/internal/services"
+
+	"github.com/google/uuid"
+)
+
+// CreateKnowledgeBaseExport writes the manifest of the documents matching
+// the request's filter that username may read to S3 and starts a workflow
+// that archives it with their files. The workflow reports its progress as
+// export events, which UpdateKnowledgeBaseExport records; once the export
+// is ready its archive is read with OpenKnowledgeBaseExport.
+func (s *Service) CreateKnowledgeBaseExport(ctx context.Context, req models.CreateKnowledgeBaseExportRequest, username string) (*models.KnowledgeBaseExport, error) {
+	if s.S3Client == nil || s.Temporal == nil {
+		return nil, &Error{Kind: KindUnavailable, Message: "Knowledge base exports are not available"}
+	}
+	format := req.Format
+	if format == "" {
+		format = models.ExportFormatZip
+	}
+	if format != models.ExportFormatZip && format != models.ExportFormatTar {
+		return nil, &Error{Kind: KindInvalid, Message: "format must be zip or tar"}
+	}
+	filter, err := normalizeDocumentFilter(req.Filter)
+	if err != nil {
+		return nil, err
+	}
+	filter.SortBy, filter.Order = "", ""
+
+	id := uuid.New().String()
+	exp := &models.KnowledgeBaseExport{
+		ID:          id,
+		Status:      models.KnowledgeBaseExportRunning,
+		Format:      format,
+		Filter:      filter,
+		ManifestKey: s.S3Client.ObjectKey(fmt.Sprintf("exports/%s/manifest.json", id)),
+		ArchiveKey:  s.S3Client.ObjectKey(fmt.Sprintf("exports/%s/knowledge-base.%s", id, format)),
+		CreatedBy:   username,
+		CreatedAt:   time.Now(),
+	}
+	if exp.DocumentCount, err = s.writeExportManifest(ctx, filter, exp.ManifestKey, username); err != nil {
+		return nil, err
+	}
+
+	if err := s.Repository.CreateKnowledgeBaseExport(ctx, exp); err != nil {
+		s.Logger.Error().Err(err).Msg("Failed to create knowledge base export")
+		return nil, internal("Failed to create export", err)
+	}
+
+	if _, err := s.Temporal.StartExportWorkflow(ctx, services.ExportWorkflowInput{
+		ExportID:    exp.ID,
+		Format:      exp.Format,
+		ManifestKey: exp.ManifestKey,
+		ArchiveKey:  exp.ArchiveKey,
+	}); err != nil {
+		s.Logger.Error().Err(err).Str("export_id", exp.ID).Msg("Failed to start export workflow")
+		now := time.Now()
+		exp.Status, exp.Error, exp.CompletedAt = models.KnowledgeBaseExportFailed, "Failed to start export workflow", &now
+		if err := s.Repository.UpdateKnowledgeBaseExport(ctx, exp); err != nil {
+			s.Logger.Error().Err(err).Str("export_id", exp.ID).Msg("Failed to update knowledge base export")
+		}
+		return nil, internal("Failed to start export", err)
+	}
+
+	return exp, nil
+}
+
+// writeExportManifest spools the manifest of the documents matching filter
+// to a temporary file and uploads it to key, returning how many documents
+// it lists. Documents without a stored file, and those restricted to access
+// groups username is not in, are left out.
+func (s *Service) writeExportManifest(ctx context.Context, filter models.DocumentFilter, key, username string) (int, error) {
+	file, err := os.CreateTemp("", "export-manifest-*.json")
+	if err != nil {
+		return 0, internal("Failed to write export manifest", err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	writer := export.NewJSONArrayWriter(file)
+	count := 0
+	err = s.Repository.ExportDocuments(ctx, filter, func(doc *models.Document) error {
+		if doc.S3Key == "" || !s.Access.canRead(username, doc) {
+			return nil
+		}
+		count++
+		return writer.Write(models.ExportManifestEntry{
+			ID:         doc.ID,
+			Path:       exportPath(doc),
+			S3Key:      doc.S3Key,
+			Filename:   doc.Filename,
+			Title:      doc.Title,
+			FileSize:   doc.FileSize,
+			Status:     doc.Status,
+			Language:   doc.Language,
+			Metadata:   doc.Metadata,
+			Tags:       doc.Tags,
+			ParentID:   doc.ParentID,
+			UploadedBy: doc.UploadedBy,
+			CreatedAt:  doc.CreatedAt,
+			IndexedAt:  doc.IndexedAt,
+		})
+	})
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		s.Logger.Error().Err(err).Msg("Failed to write export manifest")
+		return 0, internal("Failed to write export manifest", err)
+	}
+	if count == 0 {
+		return 0, &Error{Kind: KindInvalid, Message: "No documents match the filter"}
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return 0, internal("Failed to write export manifest", err)
+	}
+	if err := s.S3Client.UploadObject(ctx, key, file, "application/json"); err != nil {
+		s.Logger.Error().Err(err).Str("s3_key", key).Msg("Failed to upload export manifest")
+		return 0, internal("Failed to write export manifest", err)
+	}
+	return count, nil
+}
+
+// exportPath is where a document's file goes in an export archive: under a
+// directory named after the document, so files with the same name do not
+// collide.
+func exportPath(doc *models.Document) string {
+	name := path.Base(strings.ReplaceAll(doc.Filename, "\\", "/"))
+	if name == "." || name == ".." || name == "/" {
+		name = "file"
+	}
+	return path.Join("documents", doc.ID, name)
+}
+
+// GetKnowledgeBaseExport returns one of username's exports.
+func (s *Service) GetKnowledgeBaseExport(ctx context.Context, id, username string) (*models.KnowledgeBaseExport, error) {
+	exp, err := s.Repository.GetKnowledgeBaseExport(ctx, id)
+	if err != nil {
+		s.Logger.Error().Err(err).Str("export_id", id).Msg("Failed to get knowledge base export")
+		return nil, internal("Failed to get export", err)
+	}
+	if exp == nil || exp.CreatedBy != username {
+		return nil, &Error{Kind: KindNotFound, Message: "Export not found"}
+	}
+	return exp, nil
+}
+
+// OpenKnowledgeBaseExport opens the archive of one of username's ready
+// exports. The caller closes it.
+func (s *Service) OpenKnowledgeBaseExport(ctx context.Context, id, username string) (*models.KnowledgeBaseExport, io.ReadCloser, error) {
+	if s.S3Client == nil {
+		return nil, nil, &Error{Kind: KindUnavailable, Message: "Knowledge base exports are not available"}
+	}
+	exp, err := s.GetKnowledgeBaseExport(ctx, id, username)
+	if err != nil {
+		return nil, nil, err
+	}
+	if exp.Status != models.KnowledgeBaseExportReady {
+		return nil, nil, &Error{Kind: KindConflict, Message: fmt.Sprintf("Export is %s, not ready", exp.Status)}
+	}
+
+	body, err := s.S3Client.GetObject(ctx, exp.ArchiveKey)
+	if err != nil {
+		s.Logger.Error().Err(err).Str("export_id", id).Msg("Failed to open export archive")
+		return nil, nil, internal("Failed to open export", err)
+	}
+	return exp, body, nil
+}
+
+// ExportProgress is what a knowledge base export workflow reports. Done
+// ends the export, failed if Error is set.
+type ExportProgress struct {
+	DocumentsExported int
+	SizeBytes         int64
+	Error             string
+	Done              bool
+	OccurredAt        time.Time
+}
+
+// UpdateKnowledgeBaseExport records the progress or result of an export
+// workflow. Reports for unknown or finished exports are ignored, and
+// progress never moves back, so redelivered reports are harmless.
+func (s *Service) UpdateKnowledgeBaseExport(ctx context.Context, id string, progress ExportProgress) error {
+	exp, err := s.Repository.GetKnowledgeBaseExport(ctx, id)
+	if err != nil {
+		s.Logger.Error().Err(err).Str("export_id", id).Msg("Failed to get knowledge base export")
+		return internal("Failed to get export", err)
+	}
+	if exp == nil || exp.Status != models.KnowledgeBaseExportRunning {
+		return nil
+	}
+
+	switch {
+	case !progress.Done:
+		if progress.DocumentsExported <= exp.DocumentsExported {
+			return nil
+		}
+		exp.DocumentsExported = min(progress.DocumentsExported, exp.DocumentCount)
+	case progress.Error != "":
+		exp.Status, exp.Error, exp.CompletedAt = models.KnowledgeBaseExportFailed, progress.Error, &progress.OccurredAt
+	default:
+		exp.Status, exp.SizeBytes, exp.CompletedAt = models.KnowledgeBaseExportReady, progress.SizeBytes, &progress.OccurredAt
+		exp.DocumentsExported = exp.DocumentCount
+	}
+
+	if err := s.Repository.UpdateKnowledgeBaseExport(ctx, exp); err != nil {
+		s.Logger.Error().Err(err).Str("export_id", id).Msg("Failed to update knowledge base export")
+		return internal("Failed to update export", err)
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+package gateway
+
+import (
+	"regexp"
+	"strings"
+)
+
+// languageTag matches a lowercase BCP 47 language tag such as "en",
+// "pt-br" or "zh-hant".
+var languageTag = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{2,8})*$`)
+
+// NormalizeLanguage lowercases a language tag and reports whether it is
+// well formed. The empty tag is well formed and means any language.
+func NormalizeLanguage(language string) (string, bool) {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if language == "" {
+		return "", true
+	}
+	return language, len(language) <= 35 && languageTag.MatchString(language)
+}
@@ -0,0 +1,72 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"kb-platform-gateway/internal/export"
+	"kb-platform-gateway/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// exportURLExpiry is how long the download link of an exported answer
+// stays valid.
+const exportURLExpiry = time.Hour
+
+// ExportMessage renders an assistant message and the passages it cites in
+// format, stores the file in S3 and returns a presigned download link.
+// Only PDF is supported, and only the conversation's creator and
+// participants may export its messages.
+func (s *Service) ExportMessage(ctx context.Context, messageID, format, username string) (*models.MessageExportResponse, error) {
+	if format != models.ExportFormatPDF {
+		return nil, &Error{Kind: KindInvalid, Message: "format must be pdf"}
+	}
+
+	msg, err := s.Repository.GetMessage(ctx, messageID)
+	if err != nil {
+		s.Logger.Error().Err(err).Str("message_id", messageID).Msg("Failed to get message")
+		return nil, internal("Failed to get message", err)
+	}
+	if msg == nil {
+		return nil, &Error{Kind: KindNotFound, Message: "Message not found"}
+	}
+	if err := s.checkConversationReader(ctx, msg.ConversationID, username); err != nil {
+		if KindOf(err) == KindNotFound {
+			return nil, &Error{Kind: KindNotFound, Message: "Message not found"}
+		}
+		return nil, err
+	}
+	if msg.Role != "assistant" {
+		return nil, &Error{Kind: KindInvalid, Message: "Only assistant messages can be exported"}
+	}
+	s.attachSources(ctx, []*models.Message{msg})
+	redactMessages(s.redactor(ctx), msg)
+
+	var buf bytes.Buffer
+	if err := export.WriteAnswerPDF(&buf, msg); err != nil {
+		s.Logger.Error().Err(err).Str("message_id", messageID).Msg("Failed to render answer")
+		return nil, internal("Failed to export message", err)
+	}
+
+	key := s.S3Client.ObjectKey(fmt.Sprintf("exports/%s/answer-%s.pdf", uuid.New().String(), messageID))
+	if err := s.S3Client.UploadObject(ctx, key, bytes.NewReader(buf.Bytes()), export.PDFContentType); err != nil {
+		s.Logger.Error().Err(err).Str("s3_key", key).Msg("Failed to store exported answer")
+		return nil, internal("Failed to export message", err)
+	}
+
+	url, err := s.S3Client.GeneratePresignedDownloadURL(ctx, key, exportURLExpiry)
+	if err != nil {
+		s.Logger.Error().Err(err).Str("s3_key", key).Msg("Failed to generate presigned URL")
+		return nil, internal("Failed to export message", err)
+	}
+
+	return &models.MessageExportResponse{
+		Key:       key,
+		URL:       url,
+		Format:    format,
+		ExpiresAt: time.Now().Add(exportURLExpiry),
+	}, nil
+}
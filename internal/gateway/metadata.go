@@ -0,0 +1,131 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"kb-platform-gateway/internal/models"
+)
+
+// Bounds of the metadata schema.
+const (
+	MaxMetadataFields    = 50
+	MaxMetadataEnum      = 100
+	maxMetadataKeyLength = 100
+)
+
+// normalizeMetadataSchema trims keys and enum values and lowercases types,
+// refusing a field without a key or with an unknown type, a key declared
+// twice, or enum values its type would refuse.
+func normalizeMetadataSchema(fields []models.MetadataField) ([]models.MetadataField, error) {
+	if len(fields) > MaxMetadataFields {
+		return nil, &Error{Kind: KindInvalid, Message: fmt.Sprintf("metadata_schema must declare at most %d fields", MaxMetadataFields)}
+	}
+
+	normalized := make([]models.MetadataField, 0, len(fields))
+	seen := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		field.Key = strings.TrimSpace(field.Key)
+		if field.Key == "" || len(field.Key) > maxMetadataKeyLength {
+			return nil, &Error{Kind: KindInvalid, Message: fmt.Sprintf("metadata_schema keys must be between 1 and %d characters", maxMetadataKeyLength)}
+		}
+		if seen[field.Key] {
+			return nil, &Error{Kind: KindInvalid, Message: fmt.Sprintf("metadata_schema declares %q more than once", field.Key)}
+		}
+		seen[field.Key] = true
+
+		field.Type = strings.ToLower(strings.TrimSpace(field.Type))
+		switch field.Type {
+		case "":
+			field.Type = models.MetadataTypeString
+		case models.MetadataTypeString, models.MetadataTypeInteger, models.MetadataTypeNumber, models.MetadataTypeBoolean, models.MetadataTypeDate:
+		default:
+			return nil, &Error{Kind: KindInvalid, Message: fmt.Sprintf("metadata_schema type of %q must be string, integer, number, boolean or date", field.Key)}
+		}
+
+		if len(field.Enum) > MaxMetadataEnum {
+			return nil, &Error{Kind: KindInvalid, Message: fmt.Sprintf("metadata_schema enum of %q must list at most %d values", field.Key, MaxMetadataEnum)}
+		}
+		enum := make([]string, 0, len(field.Enum))
+		for _, value := range field.Enum {
+			value = strings.TrimSpace(value)
+			if !validMetadataValue(field.Type, value) {
+				return nil, &Error{Kind: KindInvalid, Message: fmt.Sprintf("metadata_schema enum of %q lists %q, which is not a valid %s", field.Key, value, field.Type)}
+			}
+			if !slices.Contains(enum, value) {
+				enum = append(enum, value)
+			}
+		}
+		field.Enum = nil
+		if len(enum) > 0 {
+			field.Enum = enum
+		}
+		normalized = append(normalized, field)
+	}
+	return normalized, nil
+}
+
+// checkMetadata refuses metadata missing a required key of the schema, or
+// with a value its field's type or enum does not allow.
+func checkMetadata(schema []models.MetadataField, metadata map[string]string) error {
+	for _, field := range schema {
+		value, ok := metadata[field.Key]
+		switch {
+		case !ok && field.Required:
+			return metadataError(field, fmt.Sprintf("metadata must set %q", field.Key))
+		case !ok:
+		case !validMetadataValue(field.Type, value):
+			return metadataError(field, fmt.Sprintf("metadata %q must be a %s", field.Key, describeMetadataType(field.Type)))
+		case len(field.Enum) > 0 && !slices.Contains(field.Enum, value):
+			return metadataError(field, fmt.Sprintf("metadata %q must be one of %s", field.Key, strings.Join(field.Enum, ", ")))
+		}
+	}
+	return nil
+}
+
+// checkDocumentMetadata checks metadata against the workspace's schema.
+func (s *Service) checkDocumentMetadata(ctx context.Context, metadata map[string]string) error {
+	settings, err := s.WorkspaceSettings(ctx)
+	if err != nil {
+		return err
+	}
+	return checkMetadata(settings.MetadataSchema, metadata)
+}
+
+func metadataError(field models.MetadataField, message string) error {
+	return &Error{Kind: KindInvalid, Message: message, Details: map[string]string{"metadata_key": field.Key}}
+}
+
+func validMetadataValue(fieldType, value string) bool {
+	var err error
+	switch fieldType {
+	case models.MetadataTypeInteger:
+		_, err = strconv.ParseInt(value, 10, 64)
+	case models.MetadataTypeNumber:
+		var n float64
+		n, err = strconv.ParseFloat(value, 64)
+		return err == nil && !math.IsNaN(n) && !math.IsInf(n, 0)
+	case models.MetadataTypeBoolean:
+		return value == "true" || value == "false"
+	case models.MetadataTypeDate:
+		_, err = time.Parse(time.DateOnly, value)
+	}
+	return err == nil
+}
+
+func describeMetadataType(fieldType string) string {
+	switch fieldType {
+	case models.MetadataTypeInteger:
+		return "whole number"
+	case models.MetadataTypeBoolean:
+		return "boolean, true or false"
+	case models.MetadataTypeDate:
+		return "date, YYYY-MM-DD"
+	}
+	return fieldType
+}
@@ -0,0 +1,246 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"kb-platform-gateway/internal/models"
+	"kb-platform-gateway/internal/services"
+)
+
+// S3 multipart upload limits: every part but the last must be at least
+// 5 MiB, no part may exceed 5 GiB, and an upload has at most 10,000 parts.
+const (
+	defaultPartSize = 64 << 20
+	minPartSize     = 5 << 20
+	maxPartSize     = 5 << 30
+	maxParts        = 10000
+
+	// maxPartURLs is the most part URLs presigned per request. Clients
+	// request them as they go, since each expires after uploadURLExpiry.
+	maxPartURLs = 100
+)
+
+// CreateMultipartUpload starts an S3 multipart upload of a pending
+// document's file, for files too large for one presigned PUT. The parts are
+// then uploaded to URLs from MultipartPartURLs, recorded with
+// RecordMultipartPart, and assembled by CompleteMultipartUpload.
+func (s *Service) CreateMultipartUpload(ctx context.Context, documentID string, req models.CreateMultipartUploadRequest) (*models.MultipartUpload, error) {
+	doc, err := s.document(ctx, documentID)
+	if err != nil {
+		return nil, err
+	}
+	if doc.Status != "pending" || doc.S3Key == "" {
+		return nil, &Error{Kind: KindInvalid, Message: "Document is not awaiting an upload"}
+	}
+
+	existing, err := s.Repository.GetMultipartUpload(ctx, documentID)
+	if err != nil {
+		s.Logger.Error().Err(err).Str("document_id", documentID).Msg("Failed to get multipart upload")
+		return nil, internal("Failed to get multipart upload", err)
+	}
+	if existing != nil {
+		return nil, &Error{Kind: KindConflict, Message: "A multipart upload is already in progress"}
+	}
+
+	partSize := req.PartSize
+	if partSize == 0 {
+		partSize = max(defaultPartSize, (req.FileSize+maxParts-1)/maxParts)
+	}
+	if partSize < minPartSize || partSize > maxPartSize {
+		return nil, &Error{Kind: KindInvalid, Message: fmt.Sprintf("part_size must be between %d and %d bytes", minPartSize, maxPartSize)}
+	}
+	partCount := (req.FileSize + partSize - 1) / partSize
+	if partCount > maxParts {
+		return nil, &Error{Kind: KindInvalid, Message: fmt.Sprintf("part_size must split the file into at most %d parts", maxParts)}
+	}
+
+	uploadID, err := s.S3Client.CreateMultipartUpload(ctx, s.uploadKey(doc.S3Key))
+	if err != nil {
+		s.Logger.Error().Err(err).Str("s3_key", doc.S3Key).Msg("Failed to create multipart upload")
+		return nil, internal("Failed to create multipart upload", err)
+	}
+
+	upload := &models.MultipartUpload{
+		DocumentID: documentID,
+		UploadID:   uploadID,
+		PartSize:   partSize,
+		PartCount:  int(partCount),
+		Parts:      []models.MultipartUploadPart{},
+		CreatedAt:  time.Now(),
+	}
+	if err := s.Repository.CreateMultipartUpload(ctx, upload); err != nil {
+		s.Logger.Error().Err(err).Str("document_id", documentID).Msg("Failed to save multipart upload")
+		if err := s.S3Client.AbortMultipartUpload(ctx, s.uploadKey(doc.S3Key), uploadID); err != nil {
+			s.Logger.Warn().Err(err).Str("s3_key", doc.S3Key).Msg("Failed to abort multipart upload")
+		}
+		return nil, internal("Failed to save multipart upload", err)
+	}
+
+	return upload, nil
+}
+
+// GetMultipartUpload returns a document's multipart upload with the parts
+// recorded so far, from which an interrupted upload can resume.
+func (s *Service) GetMultipartUpload(ctx context.Context, documentID string) (*models.MultipartUpload, error) {
+	_, upload, err := s.multipartUpload(ctx, documentID)
+	return upload, err
+}
+
+// MultipartPartURLs presigns upload URLs for the given parts. Each request
+// also renews the document's upload URL window, so CompleteUpload still
+// accepts an upload that outlasts a single URL.
+func (s *Service) MultipartPartURLs(ctx context.Context, documentID string, partNumbers []int) (*models.MultipartPartURLsResponse, error) {
+	doc, upload, err := s.multipartUpload(ctx, documentID)
+	if err != nil {
+		return nil, err
+	}
+	if upload.CompletedAt != nil {
+		return nil, &Error{Kind: KindConflict, Message: "Multipart upload is already complete"}
+	}
+	if len(partNumbers) == 0 || len(partNumbers) > maxPartURLs {
+		return nil, &Error{Kind: KindInvalid, Message: fmt.Sprintf("part_numbers must list between 1 and %d parts", maxPartURLs)}
+	}
+	for _, partNumber := range partNumbers {
+		if err := checkPartNumber(upload, partNumber); err != nil {
+			return nil, err
+		}
+	}
+
+	resp := &models.MultipartPartURLsResponse{Parts: make([]models.MultipartPartURL, len(partNumbers))}
+	for i, partNumber := range partNumbers {
+		url, err := s.S3Client.GeneratePresignedUploadPartURL(ctx, s.uploadKey(doc.S3Key), upload.UploadID, partNumber, uploadURLExpiry)
+		if err != nil {
+			s.Logger.Error().Err(err).Msg("Failed to generate presigned part URL")
+			return nil, internal("Failed to generate upload URL", err)
+		}
+		resp.Parts[i] = models.MultipartPartURL{PartNumber: partNumber, URL: url}
+	}
+
+	now := time.Now()
+	resp.ExpiresAt = now.Add(uploadURLExpiry)
+	if err := s.Repository.SetDocumentUploadURL(ctx, documentID, now, resp.ExpiresAt); err != nil {
+		s.Logger.Error().Err(err).Str("document_id", documentID).Msg("Failed to record upload URL expiry")
+		return nil, internal("Failed to record upload URL", err)
+	}
+
+	return resp, nil
+}
+
+// RecordMultipartPart records the ETag S3 returned for an uploaded part.
+// A part uploaded again replaces the earlier one.
+func (s *Service) RecordMultipartPart(ctx context.Context, documentID string, partNumber int, etag string) (*models.MultipartUploadPart, error) {
+	_, upload, err := s.multipartUpload(ctx, documentID)
+	if err != nil {
+		return nil, err
+	}
+	if upload.CompletedAt != nil {
+		return nil, &Error{Kind: KindConflict, Message: "Multipart upload is already complete"}
+	}
+	if err := checkPartNumber(upload, partNumber); err != nil {
+		return nil, err
+	}
+
+	part := models.MultipartUploadPart{PartNumber: partNumber, ETag: etag, UploadedAt: time.Now()}
+	if err := s.Repository.RecordMultipartUploadPart(ctx, documentID, part); err != nil {
+		s.Logger.Error().Err(err).Str("document_id", documentID).Msg("Failed to record multipart upload part")
+		return nil, internal("Failed to record part", err)
+	}
+	return &part, nil
+}
+
+// CompleteMultipartUpload assembles the recorded parts into the document's
+// file, then completes the upload as CompleteUpload does. It is refused
+// until every part is recorded. Assembly is recorded, so a call that fails
+// afterwards can be retried.
+func (s *Service) CompleteMultipartUpload(ctx context.Context, documentID string) (*models.Document, error) {
+	doc, upload, err := s.multipartUpload(ctx, documentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if upload.CompletedAt == nil {
+		recorded := make(map[int]string, len(upload.Parts))
+		for _, part := range upload.Parts {
+			recorded[part.PartNumber] = part.ETag
+		}
+		var missing []string
+		parts := make([]services.CompletedPart, 0, upload.PartCount)
+		for partNumber := 1; partNumber <= upload.PartCount; partNumber++ {
+			etag, ok := recorded[partNumber]
+			if !ok {
+				missing = append(missing, fmt.Sprint(partNumber))
+				continue
+			}
+			parts = append(parts, services.CompletedPart{PartNumber: partNumber, ETag: etag})
+		}
+		if len(missing) > 0 {
+			return nil, &Error{Kind: KindInvalid, Message: "Parts have not been uploaded: " + strings.Join(missing, ", ")}
+		}
+
+		err := s.S3Client.CompleteMultipartUpload(ctx, s.uploadKey(doc.S3Key), upload.UploadID, parts)
+		if errors.Is(err, services.ErrInvalidPart) {
+			return nil, &Error{Kind: KindInvalid, Message: "S3 rejected the uploaded parts; check their ETags and sizes and upload them again"}
+		}
+		if err != nil {
+			s.Logger.Error().Err(err).Str("s3_key", doc.S3Key).Msg("Failed to complete multipart upload")
+			return nil, internal("Failed to complete multipart upload", err)
+		}
+		if err := s.Repository.CompleteMultipartUpload(ctx, documentID, time.Now()); err != nil {
+			s.Logger.Error().Err(err).Str("document_id", documentID).Msg("Failed to mark multipart upload complete")
+			return nil, internal("Failed to complete multipart upload", err)
+		}
+	}
+
+	return s.CompleteUpload(ctx, documentID, nil)
+}
+
+// AbortMultipartUpload abandons a multipart upload, deleting the parts
+// already in S3. The document stays pending, to be uploaded again.
+func (s *Service) AbortMultipartUpload(ctx context.Context, documentID string) error {
+	doc, upload, err := s.multipartUpload(ctx, documentID)
+	if err != nil {
+		return err
+	}
+	if upload.CompletedAt != nil {
+		return &Error{Kind: KindConflict, Message: "Multipart upload is already complete"}
+	}
+
+	if err := s.S3Client.AbortMultipartUpload(ctx, s.uploadKey(doc.S3Key), upload.UploadID); err != nil {
+		s.Logger.Error().Err(err).Str("s3_key", doc.S3Key).Msg("Failed to abort multipart upload")
+		return internal("Failed to abort multipart upload", err)
+	}
+	if err := s.Repository.DeleteMultipartUpload(ctx, documentID); err != nil {
+		s.Logger.Error().Err(err).Str("document_id", documentID).Msg("Failed to delete multipart upload")
+		return internal("Failed to abort multipart upload", err)
+	}
+	return nil
+}
+
+// multipartUpload returns a document and its multipart upload, failing
+// with KindNotFound if either does not exist.
+func (s *Service) multipartUpload(ctx context.Context, documentID string) (*models.Document, *models.MultipartUpload, error) {
+	doc, err := s.document(ctx, documentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	upload, err := s.Repository.GetMultipartUpload(ctx, documentID)
+	if err != nil {
+		s.Logger.Error().Err(err).Str("document_id", documentID).Msg("Failed to get multipart upload")
+		return nil, nil, internal("Failed to get multipart upload", err)
+	}
+	if upload == nil {
+		return nil, nil, &Error{Kind: KindNotFound, Message: "Multipart upload not found"}
+	}
+	return doc, upload, nil
+}
+
+func checkPartNumber(upload *models.MultipartUpload, partNumber int) error {
+	if partNumber < 1 || partNumber > upload.PartCount {
+		return &Error{Kind: KindInvalid, Message: fmt.Sprintf("Part numbers must be between 1 and %d", upload.PartCount)}
+	}
+	return nil
+}
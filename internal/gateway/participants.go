@@ -0,0 +1,282 @@
+package gateway
+
+import (
+	"context"
+	"time"
+
+	"kb-platform-gateway/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// ListConversationParticipants returns the users taking part in a
+// conversation, with how many of its messages each has not read. Only
+// participants may list them.
+func (s *Service) ListConversationParticipants(ctx context.Context, conversationID, username string) ([]*models.ConversationParticipant, error) {
+	if err := s.CheckParticipant(ctx, conversationID, username); err != nil {
+		return nil, err
+	}
+	participants, err := s.Repository.ListConversationParticipants(ctx, conversationID)
+	if err != nil {
+		s.Logger.Error().Err(err).Str("conversation_id", conversationID).Msg("Failed to list conversation participants")
+		return nil, internal("Failed to list conversation participants", err)
+	}
+	return participants, nil
+}
+
+// InviteParticipant adds username to a conversation on behalf of inviter.
+// Only the conversation's creator may share it, becoming its first
+// participant; after that the creator and participants may invite others.
+// A conversation created before creators were recorded may be shared by
+// any user, other than a guest, until it first is.
+// Participants see every answer given in the conversation, so with Access
+// set a user may only be invited if they can read every document the
+// participants can. Inviting a participant again returns them unchanged.
+func (s *Service) InviteParticipant(ctx context.Context, conversationID, inviter, username string) (*models.ConversationParticipant, error) {
+	conv, err := s.conversation(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	participants, err := s.Repository.ListConversationParticipants(ctx, conversationID)
+	if err != nil {
+		s.Logger.Error().Err(err).Str("conversation_id", conversationID).Msg("Failed to list conversation participants")
+		return nil, internal("Failed to list conversation participants", err)
+	}
+
+	creator := conv.CreatedBy != "" && conv.CreatedBy == inviter || unclaimed(ctx, conv, inviter, participants)
+	if !creator && !hasParticipant(participants, inviter) {
+		return nil, &Error{Kind: KindForbidden, Message: "Only the creator and participants can invite others to the conversation"}
+	}
+	if s.Access != nil && !hasParticipant(participants, username) {
+		members := []string{inviter}
+		for _, p := range participants {
+			members = append(members, p.Username)
+		}
+		for _, member := range members {
+			if !s.Access.covers(username, member) {
+				return nil, &Error{Kind: KindForbidden, Message: "The user cannot read every document the participants can"}
+			}
+		}
+	}
+
+	now := time.Now()
+	if creator && !hasParticipant(participants, inviter) {
+		if _, err := s.addParticipant(ctx, &models.ConversationParticipant{
+			ConversationID: conversationID,
+			Username:       inviter,
+			JoinedAt:       now,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := s.addParticipant(ctx, &models.ConversationParticipant{
+		ConversationID: conversationID,
+		Username:       username,
+		InvitedBy:      inviter,
+		JoinedAt:       now,
+	}); err != nil {
+		return nil, err
+	}
+	return s.participant(ctx, conversationID, username)
+}
+
+// addParticipant adds a participant and announces them to the others.
+func (s *Service) addParticipant(ctx context.Context, participant *models.ConversationParticipant) (bool, error) {
+	added, err := s.Repository.AddConversationParticipant(ctx, participant)
+	if err != nil {
+		s.Logger.Error().Err(err).Str("conversation_id", participant.ConversationID).Msg("Failed to add conversation participant")
+		return false, internal("Failed to add conversation participant", err)
+	}
+	if added {
+		s.broadcast(participant.ConversationID, models.EventConversationParticipantAdded, map[string]interface{}{
+			"username":   participant.Username,
+			"invited_by": participant.InvitedBy,
+		})
+	}
+	return added, nil
+}
+
+// RemoveParticipant removes username from a conversation. Participants
+// may only remove themselves.
+func (s *Service) RemoveParticipant(ctx context.Context, conversationID, caller, username string) error {
+	if caller != username {
+		return &Error{Kind: KindForbidden, Message: "Participants can only remove themselves"}
+	}
+	if _, err := s.conversation(ctx, conversationID); err != nil {
+		return err
+	}
+	removed, err := s.Repository.RemoveConversationParticipant(ctx, conversationID, username)
+	if err != nil {
+		s.Logger.Error().Err(err).Str("conversation_id", conversationID).Msg("Failed to remove conversation participant")
+		return internal("Failed to remove conversation participant", err)
+	}
+	if !removed {
+		return &Error{Kind: KindNotFound, Message: "Participant not found"}
+	}
+	s.broadcast(conversationID, models.EventConversationParticipantRemoved, map[string]interface{}{
+		"username": username,
+	})
+	return nil
+}
+
+// MarkConversationRead records that username has read a conversation up
+// to messageID, or to its latest message if messageID is empty. Read state
+// never moves back.
+func (s *Service) MarkConversationRead(ctx context.Context, conversationID, username, messageID string) (*models.ConversationParticipant, error) {
+	if _, err := s.conversation(ctx, conversationID); err != nil {
+		return nil, err
+	}
+
+	readAt := time.Now()
+	if messageID != "" {
+		msg, err := s.Repository.GetMessage(ctx, messageID)
+		if err != nil {
+			s.Logger.Error().Err(err).Str("message_id", messageID).Msg("Failed to get message")
+			return nil, internal("Failed to get message", err)
+		}
+		if msg == nil || msg.ConversationID != conversationID {
+			return nil, &Error{Kind: KindNotFound, Message: "Message not found"}
+		}
+		readAt = msg.CreatedAt
+	}
+
+	marked, err := s.Repository.MarkConversationRead(ctx, conversationID, username, readAt)
+	if err != nil {
+		s.Logger.Error().Err(err).Str("conversation_id", conversationID).Msg("Failed to mark conversation read")
+		return nil, internal("Failed to mark conversation read", err)
+	}
+	if !marked {
+		return nil, notParticipant()
+	}
+	return s.participant(ctx, conversationID, username)
+}
+
+// checkConversationReader returns a KindNotFound error unless username
+// created the conversation or takes part in it, so conversations of others
+// are not revealed.
+func (s *Service) checkConversationReader(ctx context.Context, conversationID, username string) error {
+	conv, err := s.conversation(ctx, conversationID)
+	if err != nil {
+		return err
+	}
+	if conv.CreatedBy != "" && conv.CreatedBy == username {
+		return nil
+	}
+	participant, err := s.Repository.GetConversationParticipant(ctx, conversationID, username)
+	if err != nil {
+		s.Logger.Error().Err(err).Str("conversation_id", conversationID).Msg("Failed to get conversation participant")
+		return internal("Failed to get conversation participant", err)
+	}
+	if participant != nil {
+		return nil
+	}
+	if conv.CreatedBy == "" {
+		participants, err := s.Repository.ListConversationParticipants(ctx, conversationID)
+		if err != nil {
+			s.Logger.Error().Err(err).Str("conversation_id", conversationID).Msg("Failed to list conversation participants")
+			return internal("Failed to list conversation participants", err)
+		}
+		if unclaimed(ctx, conv, username, participants) {
+			return nil
+		}
+	}
+	return &Error{Kind: KindNotFound, Message: "Conversation not found"}
+}
+
+// guestKey marks the context of a request made by a guest.
+type guestKey struct{}
+
+// WithGuest returns a copy of ctx marking it as a request of a demo or chat
+// widget guest. Guests share one identity per session, so they only reach
+// the conversations their session created.
+func WithGuest(ctx context.Context) context.Context {
+	return context.WithValue(ctx, guestKey{}, true)
+}
+
+func isGuest(ctx context.Context) bool {
+	guest, _ := ctx.Value(guestKey{}).(bool)
+	return guest
+}
+
+// unclaimed reports whether username may read and share conv as one
+// created before creators were recorded, when every user could read every
+// conversation. That lasts until it is first shared, and never includes
+// guests.
+func unclaimed(ctx context.Context, conv *models.Conversation, username string, participants []*models.ConversationParticipant) bool {
+	return conv.CreatedBy == "" && len(participants) == 0 && username != "" && !isGuest(ctx)
+}
+
+// CheckParticipant returns a KindForbidden error unless username takes
+// part in the conversation.
+func (s *Service) CheckParticipant(ctx context.Context, conversationID, username string) error {
+	if _, err := s.conversation(ctx, conversationID); err != nil {
+		return err
+	}
+	participant, err := s.Repository.GetConversationParticipant(ctx, conversationID, username)
+	if err != nil {
+		s.Logger.Error().Err(err).Str("conversation_id", conversationID).Msg("Failed to get conversation participant")
+		return internal("Failed to get conversation participant", err)
+	}
+	if participant == nil {
+		return notParticipant()
+	}
+	return nil
+}
+
+func (s *Service) participant(ctx context.Context, conversationID, username string) (*models.ConversationParticipant, error) {
+	participant, err := s.Repository.GetConversationParticipant(ctx, conversationID, username)
+	if err != nil {
+		s.Logger.Error().Err(err).Str("conversation_id", conversationID).Msg("Failed to get conversation participant")
+		return nil, internal("Failed to get conversation participant", err)
+	}
+	if participant == nil {
+		return nil, &Error{Kind: KindNotFound, Message: "Participant not found"}
+	}
+	return participant, nil
+}
+
+// shareAnswer sends a question asked in a conversation, and its answer,
+// to the participants streaming it.
+func (s *Service) shareAnswer(conversationID, queryID, username, question, answer string) {
+	if conversationID == "" {
+		return
+	}
+	s.broadcast(conversationID, models.EventConversationMessage, map[string]interface{}{
+		"query_id": queryID,
+		"username": username,
+		"question": question,
+		"answer":   answer,
+	})
+}
+
+// broadcast publishes an event about a conversation to the SSE
+// subscribers of its topic.
+func (s *Service) broadcast(conversationID, eventType string, data map[string]interface{}) {
+	if s.Events == nil {
+		return
+	}
+	now := time.Now()
+	s.Events.Publish(&models.Event{
+		ID:         uuid.New().String(),
+		Type:       eventType,
+		Source:     models.EventSourceGateway,
+		SubjectID:  conversationID,
+		OccurredAt: now,
+		ReceivedAt: now,
+		Data:       data,
+	})
+}
+
+func hasParticipant(participants []*models.ConversationParticipant, username string) bool {
+	for _, p := range participants {
+		if p.Username == username {
+			return true
+		}
+	}
+	return false
+}
+
+func notParticipant() error {
+	return &Error{Kind: KindForbidden, Message: "Not a participant in the conversation"}
+}
@@ -0,0 +1,70 @@
+package gateway
+
+import (
+	"fmt"
+
+	"kb-platform-gateway/internal/models"
+)
+
+// MaxLanguageHints is how many languages a document's processing options
+// may name.
+const MaxLanguageHints = 5
+
+// normalizeProcessing validates processing options, lowercasing and
+// deduplicating their language hints, and returns nil if they leave every
+// option to the indexer's defaults.
+func normalizeProcessing(processing *models.ProcessingOptions) (*models.ProcessingOptions, error) {
+	if processing == nil {
+		return nil, nil
+	}
+
+	p := *processing
+	p.Languages = nil
+	seen := make(map[string]bool)
+	for _, language := range processing.Languages {
+		language, ok := NormalizeLanguage(language)
+		if !ok || language == "" {
+			return nil, &Error{Kind: KindInvalid, Message: "processing.languages must be BCP 47 language tags"}
+		}
+		if !seen[language] {
+			seen[language] = true
+			p.Languages = append(p.Languages, language)
+		}
+	}
+	if len(p.Languages) > MaxLanguageHints {
+		return nil, &Error{Kind: KindInvalid, Message: fmt.Sprintf("processing.languages must name at most %d languages", MaxLanguageHints)}
+	}
+
+	if p.OCR == nil && p.ExtractTables == nil && len(p.Languages) == 0 {
+		return nil, nil
+	}
+	return &p, nil
+}
+
+// normalizeUploadOptions validates the indexing options and content hash
+// of an upload.
+func normalizeUploadOptions(opts models.UploadOptions) (models.UploadOptions, error) {
+	chunking, err := normalizeChunking(opts.Chunking)
+	if err != nil {
+		return opts, err
+	}
+	processing, err := normalizeProcessing(opts.Processing)
+	if err != nil {
+		return opts, err
+	}
+	contentHash, err := normalizeContentHash(opts.ContentHash)
+	if err != nil {
+		return opts, err
+	}
+	metadata := opts.Metadata
+	if len(metadata) == 0 {
+		metadata = nil
+	}
+	return models.UploadOptions{
+		Metadata:    metadata,
+		Chunking:    chunking,
+		Processing:  processing,
+		ContentType: opts.ContentType,
+		ContentHash: contentHash,
+	}, nil
+}
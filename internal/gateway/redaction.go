@@ -0,0 +1,111 @@
+package gateway
+
+import (
+	"context"
+
+	"kb-platform-gateway/internal/models"
+	"kb-platform-gateway/internal/services"
+)
+
+// streamRedactor redacts the events of a streamed answer. Chunks are held
+// back until the text they end with cannot begin a match, so the events
+// sent do not line up with those received.
+type streamRedactor struct {
+	rules  *services.Redactor
+	answer string
+	// next is the offset in answer from which text remains to be sent.
+	next     int
+	redacted bool
+}
+
+// redactor returns the enabled redaction rules, or nil if there are none.
+// Rules that cannot be loaded are logged and not applied.
+func (s *Service) redactor(ctx context.Context) *services.Redactor {
+	if s.Redactions == nil {
+		return nil
+	}
+	redactor, err := s.Redactions.Redactor(ctx)
+	if err != nil {
+		s.Logger.Error().Err(err).Msg("Failed to load redaction rules")
+		return nil
+	}
+	return redactor
+}
+
+// streamRedactor returns a redactor for a streamed answer, or nil if no
+// rules apply.
+func (s *Service) streamRedactor(ctx context.Context) *streamRedactor {
+	rules := s.redactor(ctx)
+	if rules == nil {
+		return nil
+	}
+	return &streamRedactor{rules: rules}
+}
+
+// apply returns the events to send in place of event. Held-back text is
+// sent as a chunk before the end or error event, and the end event is
+// flagged if anything was redacted. Highlights from the core are dropped
+// from chunks, as redaction moves the text they locate.
+func (r *streamRedactor) apply(event models.SSEEvent) []models.SSEEvent {
+	switch event.Type {
+	case "chunk":
+		r.answer += event.Content
+		content := r.settle(false)
+		if content == "" {
+			return nil
+		}
+		event.Content = content
+		event.Highlights = nil
+	case "sources":
+		sources := make([]models.Citation, len(event.Sources))
+		for i, source := range event.Sources {
+			if text, found := r.rules.Redact(source.Text); found {
+				source.Text, source.Highlights = text, nil
+				r.redacted = true
+			}
+			sources[i] = source
+		}
+		event.Sources = sources
+	case "end", "error":
+		var events []models.SSEEvent
+		if content := r.settle(true); content != "" {
+			events = append(events, models.SSEEvent{Type: "chunk", ID: event.ID, Content: content})
+		}
+		if event.Type == "end" {
+			event.Redacted = r.redacted
+		}
+		return append(events, event)
+	}
+	return []models.SSEEvent{event}
+}
+
+// settle returns the redacted text that can be sent.
+func (r *streamRedactor) settle(final bool) string {
+	content, next, found := r.rules.RedactStream(r.answer, r.next, final)
+	r.next = next
+	r.redacted = r.redacted || found
+	return content
+}
+
+// redactMessages redacts the assistant messages among messages, and the
+// passages they cite, with rules. Questions are left as they were asked.
+func redactMessages(rules *services.Redactor, messages ...*models.Message) {
+	if rules == nil {
+		return
+	}
+	for _, msg := range messages {
+		if msg.Role != "assistant" {
+			continue
+		}
+		content, found := rules.Redact(msg.Content)
+		msg.Content = content
+		for i := range msg.Sources {
+			source := &msg.Sources[i]
+			if text, redacted := rules.Redact(source.Text); redacted {
+				source.Text, source.Highlights = text, nil
+				found = true
+			}
+		}
+		msg.Redacted = found
+	}
+}
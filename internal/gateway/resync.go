@@ -0,0 +1,222 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"kb-platform-gateway/internal/models"
+	"kb-platform-gateway/internal/services"
+
+	"go.temporal.io/api/serviceerror"
+)
+
+// cronField matches one field of a cron expression, such as "*/15",
+// "1-5" or "MON,WED".
+var cronField = regexp.MustCompile(`^[0-9A-Za-z*?/,-]+$`)
+
+// validCron reports whether expr looks like a five-field cron expression
+// or one of the @hourly, @daily, @weekly, @monthly and @yearly shorthands.
+// Temporal validates the fields themselves.
+func validCron(expr string) bool {
+	switch expr {
+	case "@hourly", "@daily", "@weekly", "@monthly", "@yearly":
+		return true
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return false
+	}
+	for _, field := range fields {
+		if !cronField.MatchString(field) {
+			return false
+		}
+	}
+	return true
+}
+
+// SetDocumentResyncSchedule re-syncs a document imported from a URL on the
+// cron schedule, replacing any schedule it had.
+func (s *Service) SetDocumentResyncSchedule(ctx context.Context, documentID, cron, username string) (*models.ResyncSchedule, error) {
+	if !validCron(cron) {
+		return nil, &Error{Kind: KindInvalid, Message: "Invalid cron expression"}
+	}
+
+	doc, err := s.document(ctx, documentID)
+	if err != nil {
+		return nil, err
+	}
+	sourceURL := doc.Metadata[models.DocumentSourceURLKey]
+	if sourceURL == "" {
+		return nil, &Error{Kind: KindInvalid, Message: "Document was not imported from a URL"}
+	}
+
+	return s.setResyncSchedule(ctx, models.ResyncSourceDocument, documentID, cron, username, func() error {
+		return s.Temporal.ScheduleDocumentResync(ctx, cron, services.ResyncDocumentWorkflowInput{
+			DocumentID: documentID,
+			SourceURL:  sourceURL,
+		})
+	})
+}
+
+// SetConnectorResyncSchedule syncs a connector on the cron schedule instead
+// of its sync interval, replacing any schedule it had. Scheduled syncs
+// list every file and skip those whose content hash is unchanged.
+func (s *Service) SetConnectorResyncSchedule(ctx context.Context, connector *models.Connector, cron, username string) (*models.ResyncSchedule, error) {
+	if !validCron(cron) {
+		return nil, &Error{Kind: KindInvalid, Message: "Invalid cron expression"}
+	}
+
+	return s.setResyncSchedule(ctx, models.ResyncSourceConnector, connector.ID, cron, username, func() error {
+		return s.Temporal.ScheduleConnectorSync(ctx, cron, connectorSyncInput(connector))
+	})
+}
+
+// RefreshConnectorResyncSchedule updates the connector's schedule, if it
+// has one, after its folders changed.
+func (s *Service) RefreshConnectorResyncSchedule(ctx context.Context, connector *models.Connector) error {
+	schedule, err := s.Repository.GetResyncSchedule(ctx, models.ResyncSourceConnector, connector.ID)
+	if err != nil {
+		s.Logger.Error().Err(err).Str("connector_id", connector.ID).Msg("Failed to get resync schedule")
+		return internal("Failed to get resync schedule", err)
+	}
+	if schedule == nil {
+		return nil
+	}
+
+	if err := s.Temporal.ScheduleConnectorSync(ctx, schedule.Cron, connectorSyncInput(connector)); err != nil {
+		s.Logger.Error().Err(err).Str("connector_id", connector.ID).Msg("Failed to update resync schedule")
+		return internal("Failed to update resync schedule", err)
+	}
+	return nil
+}
+
+func connectorSyncInput(connector *models.Connector) services.ConnectorSyncWorkflowInput {
+	return services.ConnectorSyncWorkflowInput{
+		ConnectorID: connector.ID,
+		Provider:    connector.Provider,
+		FolderIDs:   connector.FolderIDs,
+	}
+}
+
+// setResyncSchedule creates or updates the Temporal schedule with
+// schedule, then saves it.
+func (s *Service) setResyncSchedule(ctx context.Context, sourceType, sourceID, cron, username string, schedule func() error) (*models.ResyncSchedule, error) {
+	if err := schedule(); err != nil {
+		var invalid *serviceerror.InvalidArgument
+		if errors.As(err, &invalid) {
+			return nil, &Error{Kind: KindInvalid, Message: "Invalid cron expression", Err: err}
+		}
+		s.Logger.Error().Err(err).Str("source_type", sourceType).Str("source_id", sourceID).Msg("Failed to create resync schedule")
+		return nil, internal("Failed to create resync schedule", err)
+	}
+
+	now := time.Now()
+	if err := s.Repository.UpsertResyncSchedule(ctx, &models.ResyncSchedule{
+		SourceType: sourceType,
+		SourceID:   sourceID,
+		Cron:       cron,
+		CreatedBy:  username,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}); err != nil {
+		s.Logger.Error().Err(err).Str("source_type", sourceType).Str("source_id", sourceID).Msg("Failed to save resync schedule")
+		return nil, internal("Failed to save resync schedule", err)
+	}
+
+	return s.GetResyncSchedule(ctx, sourceType, sourceID)
+}
+
+func (s *Service) GetResyncSchedule(ctx context.Context, sourceType, sourceID string) (*models.ResyncSchedule, error) {
+	schedule, err := s.Repository.GetResyncSchedule(ctx, sourceType, sourceID)
+	if err != nil {
+		s.Logger.Error().Err(err).Str("source_type", sourceType).Str("source_id", sourceID).Msg("Failed to get resync schedule")
+		return nil, internal("Failed to get resync schedule", err)
+	}
+	if schedule == nil {
+		return nil, &Error{Kind: KindNotFound, Message: "Resync schedule not found"}
+	}
+	return schedule, nil
+}
+
+// DeleteResyncSchedule stops re-syncing a document or connector. Deleting
+// a schedule that does not exist succeeds.
+func (s *Service) DeleteResyncSchedule(ctx context.Context, sourceType, sourceID string) error {
+	if err := s.Temporal.DeleteResyncSchedule(ctx, sourceType, sourceID); err != nil {
+		s.Logger.Error().Err(err).Str("source_type", sourceType).Str("source_id", sourceID).Msg("Failed to delete resync schedule")
+		return internal("Failed to delete resync schedule", err)
+	}
+
+	if err := s.Repository.DeleteResyncSchedule(ctx, sourceType, sourceID); err != nil {
+		s.Logger.Error().Err(err).Str("source_type", sourceType).Str("source_id", sourceID).Msg("Failed to delete resync schedule")
+		return internal("Failed to delete resync schedule", err)
+	}
+	return nil
+}
+
+// ResyncDocument diffs the hash of a document's freshly fetched source
+// against the one recorded at the last check. If it changed, the document
+// is reset to pending and a new upload workflow started; the resync
+// workflow uploads the source to the returned URL and completes the upload
+// to re-index it. The first check always counts as a change. The schedule
+// of a document that has since been deleted is removed.
+func (s *Service) ResyncDocument(ctx context.Context, documentID, contentHash string) (*models.ResyncDocumentResponse, error) {
+	schedule, err := s.GetResyncSchedule(ctx, models.ResyncSourceDocument, documentID)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := s.Repository.GetDocument(ctx, documentID)
+	if err != nil {
+		s.Logger.Error().Err(err).Str("document_id", documentID).Msg("Failed to get document")
+		return nil, internal("Failed to get document", err)
+	}
+	if doc == nil {
+		if err := s.DeleteResyncSchedule(ctx, models.ResyncSourceDocument, documentID); err != nil {
+			return nil, err
+		}
+		return nil, &Error{Kind: KindNotFound, Message: "Document not found"}
+	}
+
+	now := time.Now()
+	if contentHash == schedule.ContentHash {
+		if err := s.Repository.RecordResyncCheck(ctx, models.ResyncSourceDocument, documentID, contentHash, now); err != nil {
+			s.Logger.Error().Err(err).Str("document_id", documentID).Msg("Failed to record resync check")
+			return nil, internal("Failed to record resync check", err)
+		}
+		return &models.ResyncDocumentResponse{Changed: false}, nil
+	}
+
+	uploadURL, err := s.issueUploadURL(ctx, doc)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.Temporal.StartUploadWorkflow(ctx, services.UploadWorkflowInput{
+		DocumentID: documentID,
+		S3Key:      s.uploadKey(doc.S3Key),
+		Chunking:   doc.Chunking,
+		Processing: doc.Processing,
+	}); err != nil {
+		s.Logger.Error().Err(err).Str("document_id", documentID).Msg("Failed to start upload workflow")
+		return nil, internal("Failed to start upload workflow", err)
+	}
+
+	if err := s.Repository.UpdateDocumentStatus(ctx, documentID, "pending", ""); err != nil {
+		s.Logger.Error().Err(err).Str("document_id", documentID).Msg("Failed to update document status")
+		return nil, internal("Failed to update document status", err)
+	}
+
+	if err := s.Repository.RecordResyncCheck(ctx, models.ResyncSourceDocument, documentID, contentHash, now); err != nil {
+		s.Logger.Error().Err(err).Str("document_id", documentID).Msg("Failed to record resync check")
+		return nil, internal("Failed to record resync check", err)
+	}
+	s.recordDocumentEvent(ctx, documentID, models.DocumentEventResynced, map[string]interface{}{
+		"content_hash": contentHash,
+	})
+
+	return &models.ResyncDocumentResponse{Changed: true, UploadURL: uploadURL}, nil
+}
@@ -0,0 +1,112 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+
+	"kb-platform-gateway/internal/models"
+	"kb-platform-gateway/internal/services"
+)
+
+// reviewRequired reports whether uploads wait for a reviewer before they
+// are indexed.
+func (s *Service) reviewRequired() bool {
+	return len(s.Reviewers) > 0
+}
+
+// isReviewer reports whether username may approve or reject documents.
+func (s *Service) isReviewer(username string) bool {
+	for _, reviewer := range s.Reviewers {
+		if reviewer == username {
+			return true
+		}
+	}
+	return false
+}
+
+// requestReview marks an uploaded document pending_review, leaving its
+// upload workflow waiting until a reviewer approves it.
+func (s *Service) requestReview(ctx context.Context, doc *models.Document) error {
+	if err := s.Repository.UpdateDocumentStatus(ctx, doc.ID, "pending_review", ""); err != nil {
+		s.Logger.Error().Err(err).Str("document_id", doc.ID).Msg("Failed to update document status")
+		return internal("Failed to update document status", err)
+	}
+	doc.Status = "pending_review"
+
+	s.recordDocumentEvent(ctx, doc.ID, models.DocumentEventReviewRequested, nil)
+	s.publish(ctx, models.EventDocumentReviewRequested, map[string]string{
+		"document_id": doc.ID,
+		"filename":    doc.Filename,
+		"uploaded_by": doc.UploadedBy,
+	})
+	return nil
+}
+
+// reviewable returns a document awaiting review, refusing callers who are
+// not reviewers.
+func (s *Service) reviewable(ctx context.Context, documentID, username string) (*models.Document, error) {
+	if !s.reviewRequired() {
+		return nil, &Error{Kind: KindUnavailable, Message: "Document review is not enabled"}
+	}
+	if !s.isReviewer(username) {
+		return nil, &Error{Kind: KindForbidden, Message: "Only reviewers can review documents"}
+	}
+
+	doc, err := s.document(ctx, documentID)
+	if err != nil {
+		return nil, err
+	}
+	if doc.Status != "pending_review" {
+		return nil, &Error{Kind: KindConflict, Message: "Document is not awaiting review"}
+	}
+	return doc, nil
+}
+
+// ApproveDocument lets a document awaiting review be indexed. Reviewers
+// cannot approve their own uploads.
+func (s *Service) ApproveDocument(ctx context.Context, documentID, username, comment string) (*models.Document, error) {
+	doc, err := s.reviewable(ctx, documentID, username)
+	if err != nil {
+		return nil, err
+	}
+	if doc.UploadedBy == username {
+		return nil, &Error{Kind: KindForbidden, Message: "Documents cannot be approved by their uploader"}
+	}
+
+	if err := s.resumeUpload(ctx, doc); err != nil {
+		return nil, err
+	}
+	s.recordDocumentEvent(ctx, documentID, models.DocumentEventApproved, map[string]interface{}{
+		"reviewed_by": username,
+		"comment":     comment,
+	})
+	return doc, nil
+}
+
+// RejectDocument stops a document awaiting review from being indexed,
+// keeping comment as its error message. The rejected document stays until
+// it is deleted.
+func (s *Service) RejectDocument(ctx context.Context, documentID, username, comment string) (*models.Document, error) {
+	doc, err := s.reviewable(ctx, documentID, username)
+	if err != nil {
+		return nil, err
+	}
+
+	// The upload workflow may already have given up waiting.
+	workflowID := services.UploadWorkflowID(documentID)
+	if err := s.Temporal.CancelWorkflow(ctx, workflowID); err != nil && !errors.Is(err, services.ErrWorkflowNotFound) {
+		s.Logger.Error().Err(err).Str("workflow_id", workflowID).Msg("Failed to cancel workflow")
+		return nil, internal("Failed to cancel upload workflow", err)
+	}
+
+	doc.Status, doc.ErrorMessage = "rejected", comment
+	if err := s.Repository.UpdateDocumentStatus(ctx, documentID, doc.Status, comment); err != nil {
+		s.Logger.Error().Err(err).Str("document_id", documentID).Msg("Failed to update document status")
+		return nil, internal("Failed to update document status", err)
+	}
+	s.recordDocumentEvent(ctx, documentID, models.DocumentEventRejected, map[string]interface{}{
+		"reviewed_by": username,
+		"comment":     comment,
+	})
+	return doc, nil
+}
@@ -0,0 +1,169 @@
+package gateway
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"kb-platform-gateway/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// normalizeDocumentFilter trims filter and lowercases its language,
+// rejecting a malformed one.
+func normalizeDocumentFilter(filter models.DocumentFilter) (models.DocumentFilter, error) {
+	language, ok := NormalizeLanguage(filter.Language)
+	if !ok {
+		return filter, &Error{Kind: KindInvalid, Message: "Invalid language"}
+	}
+	filter.Language = language
+	filter.Status = strings.TrimSpace(filter.Status)
+	filter.Query = strings.TrimSpace(filter.Query)
+	filter.Filename = strings.TrimSpace(filter.Filename)
+	filter.Text = strings.TrimSpace(filter.Text)
+	filter.Tag = strings.ToLower(strings.TrimSpace(filter.Tag))
+	filter.CollectionID = strings.TrimSpace(filter.CollectionID)
+	if len(filter.Metadata) == 0 {
+		filter.Metadata = nil
+	}
+	if filter.CreatedFrom != nil && filter.CreatedTo != nil && !filter.CreatedFrom.Before(*filter.CreatedTo) {
+		return filter, &Error{Kind: KindInvalid, Message: "created_from must be before created_to"}
+	}
+	if filter.IndexedFrom != nil && filter.IndexedTo != nil && !filter.IndexedFrom.Before(*filter.IndexedTo) {
+		return filter, &Error{Kind: KindInvalid, Message: "indexed_from must be before indexed_to"}
+	}
+	filter.SortBy = strings.ToLower(strings.TrimSpace(filter.SortBy))
+	switch filter.SortBy {
+	case "", models.DocumentSortCreatedAt, models.DocumentSortIndexedAt, models.DocumentSortFilename, models.DocumentSortFileSize:
+	case models.DocumentSortRelevance:
+		if filter.Text == "" {
+			return filter, &Error{Kind: KindInvalid, Message: "sort_by relevance needs a full-text query"}
+		}
+	default:
+		return filter, &Error{Kind: KindInvalid, Message: "sort_by must be created_at, indexed_at, filename, file_size or relevance"}
+	}
+	filter.Order = strings.ToLower(strings.TrimSpace(filter.Order))
+	if filter.Order != "" && filter.Order != models.SortAsc && filter.Order != models.SortDesc {
+		return filter, &Error{Kind: KindInvalid, Message: "order must be asc or desc"}
+	}
+	return filter, nil
+}
+
+// CreateSavedSearch saves a named document filter that every user can
+// list and run.
+func (s *Service) CreateSavedSearch(ctx context.Context, req models.SavedSearchRequest, username string) (*models.SavedSearch, error) {
+	name, filter, err := validateSavedSearch(req)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	search := &models.SavedSearch{
+		ID:        uuid.New().String(),
+		Name:      name,
+		Filter:    filter,
+		CreatedBy: username,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.Repository.CreateSavedSearch(ctx, search); err != nil {
+		s.Logger.Error().Err(err).Msg("Failed to create saved search")
+		return nil, internal("Failed to create saved search", err)
+	}
+	return search, nil
+}
+
+func (s *Service) GetSavedSearch(ctx context.Context, id string) (*models.SavedSearch, error) {
+	search, err := s.Repository.GetSavedSearch(ctx, id)
+	if err != nil {
+		s.Logger.Error().Err(err).Str("saved_search_id", id).Msg("Failed to get saved search")
+		return nil, internal("Failed to get saved search", err)
+	}
+	if search == nil {
+		return nil, &Error{Kind: KindNotFound, Message: "Saved search not found"}
+	}
+	return search, nil
+}
+
+// ListSavedSearches lists every user's saved searches, by name.
+func (s *Service) ListSavedSearches(ctx context.Context, limit, offset int) ([]*models.SavedSearch, int, error) {
+	searches, total, err := s.Repository.ListSavedSearches(ctx, limit, offset)
+	if err != nil {
+		s.Logger.Error().Err(err).Msg("Failed to list saved searches")
+		return nil, 0, internal("Failed to list saved searches", err)
+	}
+	return searches, total, nil
+}
+
+// UpdateSavedSearch replaces a saved search's name and filter. Only its
+// creator may change it.
+func (s *Service) UpdateSavedSearch(ctx context.Context, id string, req models.SavedSearchRequest, username string) (*models.SavedSearch, error) {
+	name, filter, err := validateSavedSearch(req)
+	if err != nil {
+		return nil, err
+	}
+
+	search, err := s.ownSavedSearch(ctx, id, username)
+	if err != nil {
+		return nil, err
+	}
+
+	search.Name = name
+	search.Filter = filter
+	search.UpdatedAt = time.Now()
+	found, err := s.Repository.UpdateSavedSearch(ctx, search)
+	if err != nil {
+		s.Logger.Error().Err(err).Str("saved_search_id", id).Msg("Failed to update saved search")
+		return nil, internal("Failed to update saved search", err)
+	}
+	if !found {
+		return nil, &Error{Kind: KindNotFound, Message: "Saved search not found"}
+	}
+	return search, nil
+}
+
+// DeleteSavedSearch deletes a saved search. Only its creator may delete
+// it.
+func (s *Service) DeleteSavedSearch(ctx context.Context, id, username string) error {
+	if _, err := s.ownSavedSearch(ctx, id, username); err != nil {
+		return err
+	}
+
+	if err := s.Repository.DeleteSavedSearch(ctx, id); err != nil {
+		s.Logger.Error().Err(err).Str("saved_search_id", id).Msg("Failed to delete saved search")
+		return internal("Failed to delete saved search", err)
+	}
+	return nil
+}
+
+// RunSavedSearch lists the documents currently matching a saved search,
+// newest first.
+func (s *Service) RunSavedSearch(ctx context.Context, id string, limit, offset int) ([]*models.Document, int, error) {
+	search, err := s.GetSavedSearch(ctx, id)
+	if err != nil {
+		return nil, 0, err
+	}
+	return s.ListDocuments(ctx, limit, offset, search.Filter)
+}
+
+// ownSavedSearch loads a saved search that username may change.
+func (s *Service) ownSavedSearch(ctx context.Context, id, username string) (*models.SavedSearch, error) {
+	search, err := s.GetSavedSearch(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if search.CreatedBy != username {
+		return nil, &Error{Kind: KindForbidden, Message: "Only the creator of a saved search can change it"}
+	}
+	return search, nil
+}
+
+func validateSavedSearch(req models.SavedSearchRequest) (string, models.DocumentFilter, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return "", req.Filter, &Error{Kind: KindInvalid, Message: "name must not be blank"}
+	}
+	filter, err := normalizeDocumentFilter(req.Filter)
+	return name, filter, err
+}
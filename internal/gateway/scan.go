@@ -0,0 +1,158 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"kb-platform-gateway/internal/models"
+	"kb-platform-gateway/internal/services"
+)
+
+// scannedPrefix holds the copies of uploaded files that were scanned. Upload
+// URLs cannot reach them, so the file indexed is the one that was scanned.
+const scannedPrefix = "scanned/"
+
+// uploadKey is the key a document's file is uploaded to: its S3 key, or the
+// key its scanned copy was made from.
+func (s *Service) uploadKey(key string) string {
+	prefix, name := s.S3Client.SplitKey(key)
+	if uploaded, ok := strings.CutPrefix(name, scannedPrefix); ok {
+		return prefix + uploaded
+	}
+	return key
+}
+
+// siblingKey is the key of an object in folder named after the one at key,
+// under the same key prefix, or none for a legacy key.
+func (s *Service) siblingKey(folder, key string) string {
+	prefix, name := s.S3Client.SplitKey(key)
+	return prefix + folder + name
+}
+
+// scanUpload copies a document's uploaded file out of reach of its upload
+// URL, scans the copy for malware and records the result on the document.
+// A clean copy becomes the document's file; the uploaded one is left for
+// the caller to delete once the upload is complete. An infected file is
+// quarantined, or deleted, its document rejected and a KindInfected error
+// returned. A file that cannot be scanned is not indexed; completing the
+// upload can be retried.
+func (s *Service) scanUpload(ctx context.Context, doc *models.Document) error {
+	if s.Scanner == nil {
+		return nil
+	}
+
+	uploaded := s.uploadKey(doc.S3Key)
+	pinned := s.siblingKey(scannedPrefix, uploaded)
+	if err := s.S3Client.CopyObject(ctx, uploaded, pinned); err != nil {
+		s.Logger.Error().Err(err).Str("s3_key", uploaded).Msg("Failed to copy uploaded file")
+		return internal("Failed to read uploaded file", err)
+	}
+
+	body, err := s.S3Client.GetObject(ctx, pinned)
+	if err != nil {
+		s.Logger.Error().Err(err).Str("s3_key", pinned).Msg("Failed to read uploaded file")
+		return internal("Failed to read uploaded file", err)
+	}
+	result, err := s.Scanner.Scan(ctx, body)
+	body.Close()
+	if err != nil {
+		s.Logger.Error().Err(err).Str("document_id", doc.ID).Msg("Failed to scan uploaded file")
+		return internal("Failed to scan uploaded file", err)
+	}
+
+	scan := &models.DocumentScan{Result: models.ScanClean, ScannedAt: time.Now()}
+	if result.Infected {
+		scan.Result, scan.Signature = models.ScanInfected, result.Signature
+		return s.rejectInfected(ctx, doc, uploaded, pinned, scan)
+	}
+	if err := s.Repository.SetDocumentScan(ctx, doc.ID, pinned, scan); err != nil {
+		s.Logger.Error().Err(err).Str("document_id", doc.ID).Msg("Failed to record document scan")
+		return internal("Failed to update document", err)
+	}
+	doc.S3Key, doc.Scan = pinned, scan
+	s.recordDocumentEvent(ctx, doc.ID, models.DocumentEventMalwareScanned, map[string]interface{}{
+		"result": scan.Result,
+	})
+	return nil
+}
+
+// deleteUploaded deletes the uploaded file of a document indexed from its
+// scanned copy. A failure is only logged; the trash purge deletes it with
+// the document.
+func (s *Service) deleteUploaded(ctx context.Context, key string) {
+	if err := s.S3Client.DeleteObject(ctx, key); err != nil {
+		s.Logger.Warn().Err(err).Str("s3_key", key).Msg("Failed to delete uploaded file")
+	}
+}
+
+// rejectInfected stops an infected upload from being indexed: its upload
+// workflow is cancelled, the scanned copy at pinned moved out of reach, the
+// file uploaded to uploaded deleted and the document marked rejected.
+func (s *Service) rejectInfected(ctx context.Context, doc *models.Document, uploaded, pinned string, scan *models.DocumentScan) error {
+	s.Logger.Warn().
+		Str("document_id", doc.ID).
+		Str("signature", scan.Signature).
+		Msg("Uploaded file is infected")
+
+	// The upload workflow may already have given up waiting.
+	workflowID := services.UploadWorkflowID(doc.ID)
+	if err := s.Temporal.CancelWorkflow(ctx, workflowID); err != nil && !errors.Is(err, services.ErrWorkflowNotFound) {
+		s.Logger.Error().Err(err).Str("workflow_id", workflowID).Msg("Failed to cancel workflow")
+		return internal("Failed to cancel upload workflow", err)
+	}
+
+	key, err := s.quarantine(ctx, pinned, uploaded)
+	if err != nil {
+		s.Logger.Error().Err(err).Str("s3_key", pinned).Msg("Failed to quarantine infected file")
+		return internal("Failed to quarantine infected file", err)
+	}
+	if err := s.S3Client.DeleteObject(ctx, uploaded); err != nil {
+		s.Logger.Error().Err(err).Str("s3_key", uploaded).Msg("Failed to delete infected file")
+		return internal("Failed to quarantine infected file", err)
+	}
+	if err := s.Repository.SetDocumentScan(ctx, doc.ID, key, scan); err != nil {
+		s.Logger.Error().Err(err).Str("document_id", doc.ID).Msg("Failed to record document scan")
+		return internal("Failed to update document", err)
+	}
+	message := "Malware detected: " + scan.Signature
+	if err := s.Repository.UpdateDocumentStatus(ctx, doc.ID, "rejected", message); err != nil {
+		s.Logger.Error().Err(err).Str("document_id", doc.ID).Msg("Failed to update document status")
+		return internal("Failed to update document status", err)
+	}
+	doc.Status, doc.ErrorMessage, doc.S3Key, doc.Scan = "rejected", message, key, scan
+
+	s.recordDocumentEvent(ctx, doc.ID, models.DocumentEventMalwareScanned, map[string]interface{}{
+		"result":    scan.Result,
+		"signature": scan.Signature,
+	})
+	s.publish(ctx, models.EventDocumentInfected, map[string]string{
+		"document_id": doc.ID,
+		"filename":    doc.Filename,
+		"uploaded_by": doc.UploadedBy,
+		"signature":   scan.Signature,
+	})
+	return &Error{
+		Kind:    KindInfected,
+		Message: "The file failed the malware scan",
+		Details: map[string]string{"document_id": doc.ID, "signature": scan.Signature},
+	}
+}
+
+// quarantine moves the infected object at key under the quarantine/
+// prefix, named after the uploaded key, or deletes it unless infected
+// files are quarantined, and returns the key it is now stored at, if any.
+func (s *Service) quarantine(ctx context.Context, key, uploaded string) (string, error) {
+	if !s.Quarantine {
+		return "", s.S3Client.DeleteObject(ctx, key)
+	}
+	quarantined := s.siblingKey("quarantine/", uploaded)
+	if err := s.S3Client.CopyObject(ctx, key, quarantined); err != nil {
+		return "", err
+	}
+	if err := s.S3Client.DeleteObject(ctx, key); err != nil {
+		return "", err
+	}
+	return quarantined, nil
+}
@@ -0,0 +1,145 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"kb-platform-gateway/internal/models"
+)
+
+// Bounds of the workspace settings.
+const (
+	MaxRetentionDays    = 3650
+	MaxAllowedFileTypes = 50
+)
+
+// fileType matches a file extension as stored in the allowed file types.
+var fileType = regexp.MustCompile(`^[a-z0-9]{1,10}$`)
+
+// WorkspaceSettings returns the saved workspace settings, or the defaults
+// if an admin has not saved any.
+func (s *Service) WorkspaceSettings(ctx context.Context) (*models.WorkspaceSettings, error) {
+	settings, err := s.Repository.GetWorkspaceSettings(ctx)
+	if err != nil {
+		s.Logger.Error().Err(err).Msg("Failed to get workspace settings")
+		return nil, internal("Failed to get workspace settings", err)
+	}
+	if settings == nil {
+		settings = models.DefaultWorkspaceSettings()
+	}
+	return settings, nil
+}
+
+// UpdateWorkspaceSettings changes the fields set in req and leaves the
+// others as they were. Empty values restore the defaults.
+func (s *Service) UpdateWorkspaceSettings(ctx context.Context, req models.UpdateWorkspaceSettingsRequest, username string) (*models.WorkspaceSettings, error) {
+	settings, err := s.WorkspaceSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		settings.Name = strings.TrimSpace(*req.Name)
+		if settings.Name == "" {
+			settings.Name = models.DefaultWorkspaceSettings().Name
+		}
+	}
+	if req.LogoURL != nil {
+		settings.LogoURL = strings.TrimSpace(*req.LogoURL)
+		if settings.LogoURL != "" && !isWebURL(settings.LogoURL) {
+			return nil, &Error{Kind: KindInvalid, Message: "logo_url must be an http or https URL"}
+		}
+	}
+	if req.DefaultLanguage != nil {
+		language, ok := NormalizeLanguage(*req.DefaultLanguage)
+		if !ok {
+			return nil, &Error{Kind: KindInvalid, Message: "default_language must be a BCP 47 language tag"}
+		}
+		settings.DefaultLanguage = language
+	}
+	if req.RetentionDays != nil {
+		if *req.RetentionDays < 0 || *req.RetentionDays > MaxRetentionDays {
+			return nil, &Error{Kind: KindInvalid, Message: fmt.Sprintf("retention_days must be between 0 and %d", MaxRetentionDays)}
+		}
+		settings.RetentionDays = *req.RetentionDays
+	}
+	if req.AllowedFileTypes != nil {
+		fileTypes, err := normalizeFileTypes(*req.AllowedFileTypes)
+		if err != nil {
+			return nil, err
+		}
+		settings.AllowedFileTypes = fileTypes
+	}
+	if req.MetadataSchema != nil {
+		schema, err := normalizeMetadataSchema(*req.MetadataSchema)
+		if err != nil {
+			return nil, err
+		}
+		settings.MetadataSchema = schema
+	}
+
+	now := time.Now()
+	settings.UpdatedBy, settings.UpdatedAt = username, &now
+	if err := s.Repository.UpsertWorkspaceSettings(ctx, settings); err != nil {
+		s.Logger.Error().Err(err).Msg("Failed to save workspace settings")
+		return nil, internal("Failed to save workspace settings", err)
+	}
+
+	return settings, nil
+}
+
+// normalizeFileTypes lowercases file extensions, strips their leading dot
+// and removes duplicates.
+func normalizeFileTypes(fileTypes []string) ([]string, error) {
+	normalized := make([]string, 0, len(fileTypes))
+	for _, t := range fileTypes {
+		t = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(t)), ".")
+		if !fileType.MatchString(t) {
+			return nil, &Error{Kind: KindInvalid, Message: "allowed_file_types must be file extensions such as pdf"}
+		}
+		if !slices.Contains(normalized, t) {
+			normalized = append(normalized, t)
+		}
+	}
+	if len(normalized) > MaxAllowedFileTypes {
+		return nil, &Error{Kind: KindInvalid, Message: fmt.Sprintf("allowed_file_types must list at most %d file types", MaxAllowedFileTypes)}
+	}
+	return normalized, nil
+}
+
+// allowsFile reports whether the settings allow uploading filename.
+func allowsFile(settings *models.WorkspaceSettings, filename string) bool {
+	if len(settings.AllowedFileTypes) == 0 {
+		return true
+	}
+	ext := strings.TrimPrefix(strings.ToLower(path.Ext(filename)), ".")
+	return slices.Contains(settings.AllowedFileTypes, ext)
+}
+
+// trashRetention is how long trashed documents are kept: the workspace's
+// retention days if set, else the configured trash retention. Without the
+// trash it is zero, so tombstoned documents are purged at once.
+func (s *Service) trashRetention(ctx context.Context) (time.Duration, error) {
+	if s.TrashRetention <= 0 {
+		return 0, nil
+	}
+	settings, err := s.WorkspaceSettings(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if settings.RetentionDays > 0 {
+		return time.Duration(settings.RetentionDays) * 24 * time.Hour, nil
+	}
+	return s.TrashRetention, nil
+}
+
+func isWebURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
@@ -0,0 +1,177 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"kb-platform-gateway/internal/buildinfo"
+	"kb-platform-gateway/internal/models"
+	"kb-platform-gateway/internal/services"
+
+	"github.com/google/uuid"
+)
+
+// CreateSnapshot records a snapshot of the documents indexed now and starts
+// a workflow to snapshot and copy the vectors of the active collection. The
+// snapshot can be queried once its status is ready, which FinishSnapshot
+// sets when the workflow reports back.
+func (s *Service) CreateSnapshot(ctx context.Context, req models.CreateSnapshotRequest, username string) (*models.Snapshot, error) {
+	if s.QdrantClient == nil || s.Temporal == nil {
+		return nil, &Error{Kind: KindUnavailable, Message: "Snapshots are not available"}
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, &Error{Kind: KindInvalid, Message: "name is required"}
+	}
+
+	id := uuid.New().String()
+	source := s.activeCollection()
+	snapshot := &models.Snapshot{
+		ID:               id,
+		Name:             name,
+		Description:      req.Description,
+		Status:           models.SnapshotStatusCreating,
+		SourceCollection: source,
+		Collection:       fmt.Sprintf("%s_snapshot_%s", source, id[:8]),
+		ConfigVersion:    buildinfo.Get().String(),
+		CreatedBy:        username,
+		CreatedAt:        time.Now(),
+	}
+	created, err := s.Repository.CreateSnapshot(ctx, snapshot)
+	if err != nil {
+		s.Logger.Error().Err(err).Msg("Failed to create snapshot")
+		return nil, internal("Failed to create snapshot", err)
+	}
+	if !created {
+		return nil, &Error{Kind: KindConflict, Message: fmt.Sprintf("A snapshot named %q already exists", name)}
+	}
+
+	if _, err := s.Temporal.StartSnapshotWorkflow(ctx, services.SnapshotWorkflowInput{
+		SnapshotID:       snapshot.ID,
+		SourceCollection: snapshot.SourceCollection,
+		Collection:       snapshot.Collection,
+	}); err != nil {
+		s.Logger.Error().Err(err).Str("snapshot_id", snapshot.ID).Msg("Failed to start snapshot workflow")
+		now := time.Now()
+		snapshot.Status, snapshot.Error, snapshot.CompletedAt = models.SnapshotStatusFailed, "Failed to start snapshot workflow", &now
+		if err := s.Repository.FinishSnapshot(ctx, snapshot); err != nil {
+			s.Logger.Error().Err(err).Str("snapshot_id", snapshot.ID).Msg("Failed to finish snapshot")
+		}
+		return nil, internal("Failed to start snapshot", err)
+	}
+
+	return snapshot, nil
+}
+
+// SnapshotResult is what a snapshot workflow reports. Error is set if the
+// snapshot failed; QdrantSnapshot is set if a Qdrant snapshot was taken.
+type SnapshotResult struct {
+	QdrantSnapshot string
+	VectorCount    int64
+	Error          string
+	CompletedAt    time.Time
+}
+
+// FinishSnapshot records the result of a snapshot workflow, discarding
+// what a failed one left in Qdrant. Results for unknown or finished
+// snapshots are ignored, so redelivered reports are harmless.
+func (s *Service) FinishSnapshot(ctx context.Context, id string, result SnapshotResult) error {
+	snapshot, err := s.Repository.GetSnapshot(ctx, id)
+	if err != nil {
+		s.Logger.Error().Err(err).Str("snapshot_id", id).Msg("Failed to get snapshot")
+		return internal("Failed to get snapshot", err)
+	}
+	if snapshot == nil || snapshot.Status != models.SnapshotStatusCreating {
+		return nil
+	}
+
+	snapshot.QdrantSnapshot = result.QdrantSnapshot
+	snapshot.CompletedAt = &result.CompletedAt
+	if result.Error != "" {
+		snapshot.Status, snapshot.Error = models.SnapshotStatusFailed, result.Error
+		if s.QdrantClient != nil {
+			s.discardSnapshotVectors(ctx, snapshot)
+		}
+	} else {
+		snapshot.Status, snapshot.VectorCount = models.SnapshotStatusReady, result.VectorCount
+	}
+
+	if err := s.Repository.FinishSnapshot(ctx, snapshot); err != nil {
+		s.Logger.Error().Err(err).Str("snapshot_id", id).Msg("Failed to finish snapshot")
+		return internal("Failed to finish snapshot", err)
+	}
+	return nil
+}
+
+// DeleteSnapshot deletes a snapshot with its collection and Qdrant
+// snapshot. Queries naming it fail afterwards.
+func (s *Service) DeleteSnapshot(ctx context.Context, id string) error {
+	snapshot, err := s.Repository.GetSnapshot(ctx, id)
+	if err != nil {
+		s.Logger.Error().Err(err).Str("snapshot_id", id).Msg("Failed to get snapshot")
+		return internal("Failed to get snapshot", err)
+	}
+	if snapshot == nil {
+		return &Error{Kind: KindNotFound, Message: "Snapshot not found"}
+	}
+	if snapshot.Status == models.SnapshotStatusCreating {
+		return &Error{Kind: KindConflict, Message: "Snapshot is still being taken"}
+	}
+
+	if snapshot.Status == models.SnapshotStatusReady && s.QdrantClient != nil {
+		if err := s.QdrantClient.DeleteCollection(ctx, snapshot.Collection); err != nil {
+			s.Logger.Error().Err(err).Str("collection", snapshot.Collection).Msg("Failed to delete snapshot collection")
+			return internal("Failed to delete snapshot", err)
+		}
+		if err := s.QdrantClient.DeleteSnapshot(ctx, snapshot.SourceCollection, snapshot.QdrantSnapshot); err != nil {
+			s.Logger.Warn().Err(err).Str("snapshot", snapshot.QdrantSnapshot).Msg("Failed to delete Qdrant snapshot")
+		}
+	}
+
+	if err := s.Repository.DeleteSnapshot(ctx, id); err != nil {
+		s.Logger.Error().Err(err).Str("snapshot_id", id).Msg("Failed to delete snapshot")
+		return internal("Failed to delete snapshot", err)
+	}
+	return nil
+}
+
+// discardSnapshotVectors deletes what a failed snapshot left in Qdrant.
+// Failures are logged, since the collection may never have been created.
+func (s *Service) discardSnapshotVectors(ctx context.Context, snapshot *models.Snapshot) {
+	if err := s.QdrantClient.DeleteCollection(ctx, snapshot.Collection); err != nil {
+		s.Logger.Warn().Err(err).Str("collection", snapshot.Collection).Msg("Failed to delete snapshot collection")
+	}
+	if snapshot.QdrantSnapshot != "" {
+		if err := s.QdrantClient.DeleteSnapshot(ctx, snapshot.SourceCollection, snapshot.QdrantSnapshot); err != nil {
+			s.Logger.Warn().Err(err).Str("snapshot", snapshot.QdrantSnapshot).Msg("Failed to delete Qdrant snapshot")
+		}
+	}
+}
+
+// querySnapshot returns the ready snapshot named asOf, for a query to
+// retrieve from.
+func (s *Service) querySnapshot(ctx context.Context, asOf string) (*models.Snapshot, error) {
+	snapshot, err := s.Repository.GetSnapshotByName(ctx, asOf)
+	if err != nil {
+		s.Logger.Error().Err(err).Str("as_of", asOf).Msg("Failed to get snapshot")
+		return nil, internal("Failed to get snapshot", err)
+	}
+	if snapshot == nil {
+		return nil, &Error{Kind: KindInvalid, Message: "Snapshot not found"}
+	}
+	if snapshot.Status != models.SnapshotStatusReady {
+		return nil, &Error{Kind: KindInvalid, Message: fmt.Sprintf("Snapshot %q is %s, not ready", snapshot.Name, snapshot.Status)}
+	}
+	return snapshot, nil
+}
+
+// activeCollection returns the collection queries retrieve from by
+// default.
+func (s *Service) activeCollection() string {
+	if s.Migrations != nil {
+		return s.Migrations.ActiveCollection()
+	}
+	return s.QdrantClient.Collection()
+}
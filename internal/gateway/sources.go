@@ -0,0 +1,102 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"kb-platform-gateway/internal/models"
+)
+
+// attachSources sets the sources of the assistant messages the core
+// linked to a query, from the citations recorded with that query. A
+// failure is logged and leaves the messages without sources, since they
+// are still worth returning.
+func (s *Service) attachSources(ctx context.Context, messages []*models.Message) {
+	var queryIDs []string
+	for _, msg := range messages {
+		if id := msg.Metadata[models.MessageMetadataQueryID]; msg.Role == "assistant" && id != "" {
+			queryIDs = append(queryIDs, id)
+		}
+	}
+	if len(queryIDs) == 0 {
+		return
+	}
+
+	citations, err := s.Repository.ListQueryCitations(ctx, queryIDs)
+	if err != nil {
+		s.Logger.Error().Err(err).Msg("Failed to list message citations")
+		return
+	}
+	if len(citations) == 0 {
+		return
+	}
+
+	documents := s.sourceDocuments(ctx, citations)
+	previews := make(map[string]string, len(documents))
+	for _, msg := range messages {
+		if msg.Role != "assistant" {
+			continue
+		}
+		for _, citation := range citations[msg.Metadata[models.MessageMetadataQueryID]] {
+			source := models.MessageSource{Citation: citation}
+			if doc := documents[citation.DocumentID]; doc != nil {
+				source.Filename = doc.Filename
+				source.PreviewURL = s.previewURL(ctx, doc, citation.Page, previews)
+			}
+			msg.Sources = append(msg.Sources, source)
+		}
+	}
+}
+
+// sourceDocuments looks up the documents of citations by ID. Deleted
+// documents are absent.
+func (s *Service) sourceDocuments(ctx context.Context, citations map[string][]models.Citation) map[string]*models.Document {
+	seen := make(map[string]bool)
+	var ids []string
+	for _, cited := range citations {
+		for _, citation := range cited {
+			if !seen[citation.DocumentID] {
+				seen[citation.DocumentID] = true
+				ids = append(ids, citation.DocumentID)
+			}
+		}
+	}
+
+	docs, err := s.Repository.GetDocumentsByIDs(ctx, ids)
+	if err != nil {
+		s.Logger.Error().Err(err).Msg("Failed to get cited documents")
+		return nil
+	}
+
+	documents := make(map[string]*models.Document, len(docs))
+	for _, doc := range docs {
+		documents[doc.ID] = doc
+	}
+	return documents
+}
+
+// previewURL returns a presigned download URL of doc, presigning each
+// document once per call of attachSources through previews. For a PDF the
+// URL opens the cited page.
+func (s *Service) previewURL(ctx context.Context, doc *models.Document, page int, previews map[string]string) string {
+	if s.S3Client == nil || doc.S3Key == "" {
+		return ""
+	}
+
+	url, ok := previews[doc.ID]
+	if !ok {
+		var err error
+		url, err = s.S3Client.GeneratePresignedDownloadURL(ctx, doc.S3Key, previewURLExpiry)
+		if err != nil {
+			s.Logger.Error().Err(err).Str("document_id", doc.ID).Msg("Failed to generate preview URL")
+		}
+		previews[doc.ID] = url
+	}
+
+	if url != "" && page > 0 && strings.EqualFold(path.Ext(doc.Filename), ".pdf") {
+		url += fmt.Sprintf("#page=%d", page)
+	}
+	return url
+}
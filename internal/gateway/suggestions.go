@@ -0,0 +1,50 @@
+package gateway
+
+import (
+	"context"
+	"strings"
+	"unicode/utf8"
+
+	"kb-platform-gateway/internal/models"
+)
+
+// Query suggestion limits.
+const (
+	// DefaultSuggestions is the number of suggestions returned unless the
+	// caller asks for fewer or more, up to MaxSuggestions.
+	DefaultSuggestions = 8
+	MaxSuggestions     = 20
+	// minSuggestionLength is the shortest text completed. Shorter text
+	// matches too much to be useful, or to be served from the trigram
+	// indexes.
+	minSuggestionLength = 2
+	// suggestionMinUsers is the number of distinct users who must have
+	// asked a question before it is suggested to others, so that no one's
+	// question is shown back verbatim to everyone else.
+	suggestionMinUsers = 2
+)
+
+// SuggestQueries completes text typed into the search box from popular
+// past questions and document titles.
+func (s *Service) SuggestQueries(ctx context.Context, text string, limit int) ([]models.QuerySuggestion, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, &Error{Kind: KindInvalid, Message: "q is required"}
+	}
+	if limit <= 0 || limit > MaxSuggestions {
+		limit = DefaultSuggestions
+	}
+	if utf8.RuneCountInString(text) < minSuggestionLength {
+		return []models.QuerySuggestion{}, nil
+	}
+
+	suggestions, err := s.Repository.SuggestQueries(ctx, text, suggestionMinUsers, limit)
+	if err != nil {
+		s.Logger.Error().Err(err).Msg("Failed to suggest queries")
+		return nil, internal("Failed to suggest queries", err)
+	}
+	if suggestions == nil {
+		suggestions = []models.QuerySuggestion{}
+	}
+	return suggestions, nil
+}
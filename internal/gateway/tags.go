@@ -0,0 +1,241 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"kb-platform-gateway/internal/models"
+)
+
+const (
+	// MaxTagDocuments is the most documents a bulk tag request may name.
+	MaxTagDocuments = 1000
+	// maxTagsPerRequest is the most tags a bulk tag request may name.
+	maxTagsPerRequest = 20
+	// maxTagLength is the longest tag, in characters.
+	maxTagLength = 64
+)
+
+// TagUpdater tags documents in bulk. Postgres is updated at once, and the
+// tags in the payload of each changed document's vectors are updated in the
+// background, one document at a time, so retagging hundreds of documents
+// does not wait on Qdrant. Each update reads the document's current tags,
+// so a document queued twice ends with its latest tags. Close interrupts
+// the updates still queued.
+type TagUpdater struct {
+	service *Service
+
+	mu      sync.Mutex
+	pending map[string]struct{}
+	queue   []string
+	wake    chan struct{}
+
+	ctx       context.Context
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+}
+
+func NewTagUpdater(service *Service) *TagUpdater {
+	ctx, cancel := context.WithCancel(context.Background())
+	u := &TagUpdater{
+		service: service,
+		pending: make(map[string]struct{}),
+		wake:    make(chan struct{}, 1),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+	u.wg.Add(1)
+	go u.run()
+	return u
+}
+
+// Close interrupts the payload updates and waits for the one in progress.
+func (u *TagUpdater) Close() {
+	u.closeOnce.Do(func() {
+		u.cancel()
+		u.wg.Wait()
+		if dropped := len(u.take()); dropped > 0 {
+			u.service.Logger.Warn().Int("documents", dropped).Msg("Tag payload updates dropped at shutdown")
+		}
+	})
+}
+
+// TagDocuments adds tags to the listed documents. Trashed and unknown
+// documents are skipped.
+func (u *TagUpdater) TagDocuments(ctx context.Context, req models.BulkTagRequest) (*models.TagUpdateResponse, error) {
+	s := u.service
+	tags, err := checkBulkTagRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := s.Repository.AddDocumentTags(ctx, req.DocumentIDs, tags)
+	if err != nil {
+		s.Logger.Error().Err(err).Msg("Failed to tag documents")
+		return nil, internal("Failed to tag documents", err)
+	}
+	u.enqueue(updated)
+	return &models.TagUpdateResponse{Updated: len(updated)}, nil
+}
+
+// UntagDocuments removes tags from the listed documents.
+func (u *TagUpdater) UntagDocuments(ctx context.Context, req models.BulkTagRequest) (*models.TagUpdateResponse, error) {
+	s := u.service
+	tags, err := checkBulkTagRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := s.Repository.RemoveDocumentTags(ctx, req.DocumentIDs, tags)
+	if err != nil {
+		s.Logger.Error().Err(err).Msg("Failed to untag documents")
+		return nil, internal("Failed to untag documents", err)
+	}
+	u.enqueue(updated)
+	return &models.TagUpdateResponse{Updated: len(updated)}, nil
+}
+
+// RenameTag renames a tag on every document, including those in the trash.
+// Renaming a tag to one already in use merges the two.
+func (u *TagUpdater) RenameTag(ctx context.Context, req models.RenameTagRequest) (*models.TagUpdateResponse, error) {
+	s := u.service
+	from, err := normalizeTag(req.From)
+	if err != nil {
+		return nil, err
+	}
+	to, err := normalizeTag(req.To)
+	if err != nil {
+		return nil, err
+	}
+	if from == to {
+		return nil, &Error{Kind: KindInvalid, Message: "from and to must be different tags"}
+	}
+
+	updated, err := s.Repository.RenameTag(ctx, from, to)
+	if err != nil {
+		s.Logger.Error().Err(err).Str("tag", from).Msg("Failed to rename tag")
+		return nil, internal("Failed to rename tag", err)
+	}
+	u.enqueue(updated)
+	return &models.TagUpdateResponse{Updated: len(updated)}, nil
+}
+
+// ListTags returns the tags in use with the number of documents carrying
+// each.
+func (s *Service) ListTags(ctx context.Context) ([]models.TagCount, error) {
+	tags, err := s.Repository.ListTags(ctx)
+	if err != nil {
+		s.Logger.Error().Err(err).Msg("Failed to list tags")
+		return nil, internal("Failed to list tags", err)
+	}
+	return tags, nil
+}
+
+// enqueue queues payload updates for documents not already queued. Without
+// Qdrant there are no payloads to update.
+func (u *TagUpdater) enqueue(documentIDs []string) {
+	if u.service.QdrantClient == nil || len(documentIDs) == 0 {
+		return
+	}
+
+	u.mu.Lock()
+	for _, id := range documentIDs {
+		if _, ok := u.pending[id]; !ok {
+			u.pending[id] = struct{}{}
+			u.queue = append(u.queue, id)
+		}
+	}
+	u.mu.Unlock()
+
+	select {
+	case u.wake <- struct{}{}:
+	default:
+	}
+}
+
+// take removes and returns the queued documents.
+func (u *TagUpdater) take() []string {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	queue := u.queue
+	u.queue = nil
+	clear(u.pending)
+	return queue
+}
+
+func (u *TagUpdater) run() {
+	defer u.wg.Done()
+	for {
+		select {
+		case <-u.ctx.Done():
+			return
+		case <-u.wake:
+		}
+
+		for _, id := range u.take() {
+			if u.ctx.Err() != nil {
+				// Requeued so Close reports it as dropped.
+				u.enqueue([]string{id})
+				continue
+			}
+			u.updatePayload(id)
+		}
+	}
+}
+
+// updatePayload copies a document's tags into its vectors' payload.
+// Failures are logged; the next tag change of the document retries them.
+func (u *TagUpdater) updatePayload(documentID string) {
+	s := u.service
+	doc, err := s.Repository.GetDocument(u.ctx, documentID)
+	if err != nil {
+		s.Logger.Error().Err(err).Str("document_id", documentID).Msg("Failed to get document")
+		return
+	}
+	if doc == nil {
+		return
+	}
+	if err := s.QdrantClient.SetDocumentTags(u.ctx, documentID, doc.Tags); err != nil {
+		s.Logger.Error().Err(err).Str("document_id", documentID).Msg("Failed to update tags in vector payloads")
+	}
+}
+
+// checkBulkTagRequest validates a bulk tag request and returns its tags
+// normalized.
+func checkBulkTagRequest(req models.BulkTagRequest) ([]string, error) {
+	if len(req.DocumentIDs) == 0 || len(req.DocumentIDs) > MaxTagDocuments {
+		return nil, &Error{Kind: KindInvalid, Message: fmt.Sprintf("document_ids must list between 1 and %d documents", MaxTagDocuments)}
+	}
+	if len(req.Tags) == 0 || len(req.Tags) > maxTagsPerRequest {
+		return nil, &Error{Kind: KindInvalid, Message: fmt.Sprintf("tags must list between 1 and %d tags", maxTagsPerRequest)}
+	}
+
+	seen := make(map[string]bool, len(req.Tags))
+	tags := make([]string, 0, len(req.Tags))
+	for _, tag := range req.Tags {
+		tag, err := normalizeTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	sort.Strings(tags)
+	return tags, nil
+}
+
+// normalizeTag trims and lowercases a tag, so tags differing only in case
+// or surrounding space are the same tag.
+func normalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if tag == "" || len([]rune(tag)) > maxTagLength {
+		return "", &Error{Kind: KindInvalid, Message: fmt.Sprintf("Tags must be between 1 and %d characters", maxTagLength)}
+	}
+	return tag, nil
+}
@@ -0,0 +1,219 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"kb-platform-gateway/internal/models"
+	"kb-platform-gateway/internal/services"
+
+	"github.com/google/uuid"
+)
+
+// trashPurgeBatch is how many expired documents a purge loads at a time.
+const trashPurgeBatch = 100
+
+// trashDocument moves a document to the trash. Its vectors are deleted at
+// once so answers stop citing it; restoring it re-indexes it.
+func (s *Service) trashDocument(ctx context.Context, documentID string) error {
+	trashed, err := s.Repository.TrashDocument(ctx, documentID, time.Now())
+	if err != nil {
+		s.Logger.Error().Err(err).Str("document_id", documentID).Msg("Failed to trash document")
+		return internal("Failed to delete document", err)
+	}
+	if trashed {
+		s.finishTrashing(ctx, documentID)
+	}
+	// Otherwise it was deleted or trashed concurrently.
+	return nil
+}
+
+// finishTrashing deletes the vectors of a document just moved to the
+// trash and records it. A failure to delete them is only logged.
+func (s *Service) finishTrashing(ctx context.Context, documentID string) {
+	if err := s.deleteDocumentVectors(ctx, documentID); err != nil {
+		s.Logger.Error().Err(err).Str("document_id", documentID).Msg("Failed to delete vectors")
+	}
+	s.recordDocumentEvent(ctx, documentID, models.DocumentEventTrashed, nil)
+}
+
+// RestoreDocument takes a document out of the trash and re-indexes it if
+// it had been indexed.
+func (s *Service) RestoreDocument(ctx context.Context, documentID string) (*models.Document, error) {
+	doc, err := s.document(ctx, documentID)
+	if err != nil {
+		return nil, err
+	}
+	if doc.DeletedAt == nil {
+		return nil, &Error{Kind: KindConflict, Message: "Document is not in the trash"}
+	}
+	if s.TrashRetention <= 0 {
+		// Without the trash, a tombstone marks a deletion still under way.
+		return nil, &Error{Kind: KindConflict, Message: "Document is being deleted"}
+	}
+
+	restored, err := s.Repository.RestoreDocument(ctx, documentID)
+	if err != nil {
+		s.Logger.Error().Err(err).Str("document_id", documentID).Msg("Failed to restore document")
+		return nil, internal("Failed to restore document", err)
+	}
+	if !restored {
+		return nil, &Error{Kind: KindConflict, Message: "Document is not in the trash"}
+	}
+	doc.DeletedAt = nil
+	s.recordDocumentEvent(ctx, documentID, models.DocumentEventRestored, nil)
+
+	if doc.Status == "complete" || doc.Status == "indexing" {
+		s.reindexRestored(ctx, doc)
+	}
+
+	return doc, nil
+}
+
+// reindexRestored rebuilds the vectors deleted when doc was trashed,
+// marking it failed if the indexing workflow cannot be started.
+func (s *Service) reindexRestored(ctx context.Context, doc *models.Document) {
+	workflowID, err := s.Temporal.StartIndexWorkflow(ctx, services.IndexWorkflowInput{
+		DocumentID:   doc.ID,
+		Chunking:     doc.Chunking,
+		Processing:   doc.Processing,
+		AccessGroups: doc.AccessGroups,
+	})
+	if err != nil {
+		s.Logger.Error().Err(err).Str("document_id", doc.ID).Msg("Failed to start index workflow")
+		doc.Status, doc.ErrorMessage = "failed", "Re-indexing after restore could not be started"
+		err = s.Repository.UpdateDocumentStatus(ctx, doc.ID, doc.Status, doc.ErrorMessage)
+	} else {
+		doc.Status, doc.ErrorMessage, doc.WorkflowID = "indexing", "", workflowID
+		err = s.Repository.SetDocumentIndexing(ctx, doc.ID, workflowID)
+	}
+	if err != nil {
+		s.Logger.Error().Err(err).Str("document_id", doc.ID).Msg("Failed to update document status")
+	}
+}
+
+// PurgeTrash deletes the documents trashed longer than the trash retention,
+// or the workspace's retention days if set, with their S3 objects and
+// vectors, and records the purge. A document whose object or vectors
+// cannot be deleted stays in the trash for the next purge. Without the
+// trash, it finishes the deletions DeleteDocument could not complete.
+func (s *Service) PurgeTrash(ctx context.Context) (*models.TrashPurge, error) {
+	retention, err := s.trashRetention(ctx)
+	if err != nil {
+		return nil, err
+	}
+	purge := &models.TrashPurge{
+		ID:        uuid.New().String(),
+		StartedAt: time.Now(),
+	}
+	before := purge.StartedAt.Add(-retention)
+
+	for {
+		// Purged documents leave the trash, so only those that failed
+		// are skipped.
+		documents, err := s.Repository.ListExpiredTrash(ctx, before, trashPurgeBatch, purge.Failed)
+		if err != nil {
+			s.Logger.Error().Err(err).Msg("Failed to list expired trash")
+			return nil, internal("Failed to purge trash", err)
+		}
+
+		for _, doc := range documents {
+			if err := s.purgeDocument(ctx, doc); err != nil {
+				s.Logger.Error().Err(err).Str("document_id", doc.ID).Msg("Failed to purge document")
+				purge.Failed++
+				continue
+			}
+			s.recordDocumentEvent(ctx, doc.ID, models.DocumentEventDeleted, map[string]interface{}{"purged": true})
+			purge.Purged++
+			purge.ReclaimedBytes += doc.FileSize
+		}
+
+		if len(documents) < trashPurgeBatch {
+			break
+		}
+	}
+
+	purge.FinishedAt = time.Now()
+	if err := s.Repository.CreateTrashPurge(context.WithoutCancel(ctx), purge); err != nil {
+		s.Logger.Error().Err(err).Msg("Failed to record trash purge")
+	}
+	s.Logger.Info().
+		Int("purged", purge.Purged).
+		Int("failed", purge.Failed).
+		Int64("reclaimed_bytes", purge.ReclaimedBytes).
+		Msg("Purged trash")
+
+	return purge, nil
+}
+
+// finishDeleting purges a tombstoned document and records its deletion.
+// If the purge fails the tombstone stays, and the trash purge finishes it.
+func (s *Service) finishDeleting(ctx context.Context, doc *models.Document) {
+	if err := s.purgeDocument(ctx, doc); err != nil {
+		s.Logger.Warn().Err(err).Str("document_id", doc.ID).Msg("Document deletion left to the trash purge")
+		return
+	}
+	s.recordDocumentEvent(ctx, doc.ID, models.DocumentEventDeleted, nil)
+}
+
+// purgeDocument deletes a tombstoned document's S3 objects, vectors and
+// record, stopping at the first failure. Each step succeeds if already
+// done, so a failed purge can be retried from the start.
+func (s *Service) purgeDocument(ctx context.Context, doc *models.Document) error {
+	if doc.S3Key != "" {
+		if err := s.S3Client.DeleteObject(ctx, doc.S3Key); err != nil {
+			return fmt.Errorf("failed to delete S3 object: %w", err)
+		}
+		// The uploaded file of a scanned document may have been left
+		// behind.
+		if uploaded := s.uploadKey(doc.S3Key); uploaded != doc.S3Key {
+			if err := s.S3Client.DeleteObject(ctx, uploaded); err != nil {
+				return fmt.Errorf("failed to delete S3 object: %w", err)
+			}
+		}
+	}
+	if err := s.deleteDocumentVectors(ctx, doc.ID); err != nil {
+		return fmt.Errorf("failed to delete vectors: %w", err)
+	}
+	if err := s.Repository.DeleteDocument(ctx, doc.ID); err != nil {
+		return fmt.Errorf("failed to delete document: %w", err)
+	}
+	return nil
+}
+
+// stopIndexing cancels the workflow indexing a document about to be
+// deleted, which would otherwise write its vectors again.
+func (s *Service) stopIndexing(ctx context.Context, doc *models.Document) error {
+	if doc.Status != "indexing" {
+		return nil
+	}
+	workflowID := doc.WorkflowID
+	if workflowID == "" {
+		// Indexed before workflow IDs were recorded.
+		workflowID = services.UploadWorkflowID(doc.ID)
+	}
+	// The workflow may have finished since the document was loaded.
+	if err := s.Temporal.CancelWorkflow(ctx, workflowID); err != nil && !errors.Is(err, services.ErrWorkflowNotFound) {
+		s.Logger.Error().Err(err).Str("workflow_id", workflowID).Msg("Failed to cancel workflow")
+		return internal("Failed to cancel indexing", err)
+	}
+	return nil
+}
+
+// deleteDocumentVectors deletes a document's vectors from every collection
+// live queries retrieve from, including the target of a running migration.
+// Snapshots keep theirs, so answers from them can be reproduced.
+func (s *Service) deleteDocumentVectors(ctx context.Context, documentID string) error {
+	collections, err := s.labelledCollections(ctx, false)
+	if err != nil {
+		return err
+	}
+	for _, collection := range collections {
+		if err := s.QdrantClient.DeleteDocumentVectors(ctx, collection, documentID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package gateway
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// sniffLength is how much of a file is read to detect its content type,
+// all that http.DetectContentType considers.
+const sniffLength = 512
+
+// UploadPolicy bounds the files accepted however they are uploaded. A zero
+// MaxSize accepts any size, and empty lists any type or extension.
+type UploadPolicy struct {
+	// MaxSize is the largest file, in bytes.
+	MaxSize int64
+	// ContentTypes are the media types accepted; "type/*" accepts a whole
+	// type.
+	ContentTypes []string
+	// Extensions are the filename extensions accepted, without the dot.
+	Extensions []string
+}
+
+// Check refuses a file larger than MaxSize, or whose extension or
+// declared content type is not allowed. An unknown size (negative) or
+// empty content type is not checked. A nil policy accepts any file.
+func (p *UploadPolicy) Check(filename string, size int64, contentType string) error {
+	if p == nil {
+		return nil
+	}
+	if p.MaxSize > 0 && size > p.MaxSize {
+		return p.sizeError()
+	}
+	if len(p.Extensions) > 0 {
+		ext := strings.TrimPrefix(strings.ToLower(path.Ext(filename)), ".")
+		if !slices.ContainsFunc(p.Extensions, func(allowed string) bool {
+			return strings.TrimPrefix(strings.ToLower(allowed), ".") == ext
+		}) {
+			return policyError("File extension is not allowed", map[string]string{
+				"rule":      "extension",
+				"extension": ext,
+				"allowed":   strings.Join(p.Extensions, ","),
+			})
+		}
+	}
+	if contentType != "" && !allowsContentType(p.ContentTypes, contentType) {
+		return policyError("Content type is not allowed", map[string]string{
+			"rule":         "content_type",
+			"content_type": contentType,
+			"allowed":      strings.Join(p.ContentTypes, ","),
+		})
+	}
+	return nil
+}
+
+// checkContent refuses a file whose first bytes, sniffed as
+// http.DetectContentType does, contradict its declared content type, so a
+// disallowed file cannot pass under an allowed type. Content that is not
+// recognised passes.
+func (p *UploadPolicy) checkContent(contentType string, head []byte) error {
+	if p == nil || len(head) == 0 {
+		return nil
+	}
+	declared, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		// Refused by Check when types are restricted.
+		return nil
+	}
+	detected, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+	if sniffMatches(declared, detected) {
+		return nil
+	}
+	return policyError("File content does not match its content type", map[string]string{
+		"rule":          "content",
+		"content_type":  declared,
+		"detected_type": detected,
+	})
+}
+
+// sniffMatches reports whether a sniffed media type is consistent with the
+// declared one. Sniffing only tells a few families apart: text, zip
+// containers such as Office documents, and well-known binary formats.
+func sniffMatches(declared, detected string) bool {
+	switch {
+	case detected == declared, detected == "application/octet-stream":
+		return true
+	case strings.HasPrefix(detected, "text/"):
+		return strings.HasPrefix(declared, "text/") || declared == "application/json" ||
+			strings.HasSuffix(declared, "+xml") || strings.HasSuffix(declared, "/xml")
+	case detected == "application/zip":
+		return declared == "application/x-zip-compressed" || declared == "application/epub+zip" ||
+			strings.HasPrefix(declared, "application/vnd.openxmlformats-officedocument.") ||
+			strings.HasPrefix(declared, "application/vnd.oasis.opendocument.")
+	}
+	return false
+}
+
+func (p *UploadPolicy) sizeError() error {
+	return policyError(fmt.Sprintf("File exceeds the maximum size of %d bytes", p.MaxSize), map[string]string{
+		"rule":     "max_size",
+		"max_size": strconv.FormatInt(p.MaxSize, 10),
+	})
+}
+
+func policyError(message string, details map[string]string) error {
+	details["field"] = "file"
+	return &Error{Kind: KindInvalid, Message: message, Details: details}
+}
+
+// allowsContentType reports whether contentType is on an allow-list of
+// media types, where "type/*" allows a whole type. An empty list allows
+// any.
+func allowsContentType(allowList []string, contentType string) bool {
+	if len(allowList) == 0 {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, allowed := range allowList {
+		allowed = strings.ToLower(allowed)
+		if allowed == mediaType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(allowed, "/*"); ok && strings.HasPrefix(mediaType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,138 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"kb-platform-gateway/internal/models"
+)
+
+// ProxyUploadLimits bound the files streamed through the gateway by
+// UploadContent.
+type ProxyUploadLimits struct {
+	// MaxSize is the largest file, in bytes.
+	MaxSize int64
+	// ContentTypes are the media types accepted; "type/*" accepts a whole
+	// type. Empty accepts any.
+	ContentTypes []string
+	// PartSize and Concurrency bound the memory of each upload to
+	// Concurrency parts of PartSize bytes.
+	PartSize    int64
+	Concurrency int
+}
+
+// allows reports whether contentType is on the allow-list.
+func (l *ProxyUploadLimits) allows(contentType string) bool {
+	return allowsContentType(l.ContentTypes, contentType)
+}
+
+// UploadContent streams a pending document's file from body to S3, for
+// clients that cannot reach S3 directly, then completes the upload as
+// CompleteUpload does. size is the declared length of body, or -1 if
+// unknown; a body longer than the limit is refused either way, and the
+// partial upload is discarded. With an upload policy the file must also
+// satisfy it, and its first bytes must match contentType.
+func (s *Service) UploadContent(ctx context.Context, documentID string, body io.Reader, size int64, contentType string) (*models.Document, error) {
+	limits := s.ProxyUploads
+	if limits == nil {
+		return nil, &Error{Kind: KindUnavailable, Message: "Uploads through the gateway are not enabled"}
+	}
+	if !limits.allows(contentType) {
+		return nil, &Error{Kind: KindInvalid, Message: "Content type is not allowed"}
+	}
+	if size == 0 {
+		return nil, &Error{Kind: KindInvalid, Message: "No file provided"}
+	}
+	if size > limits.MaxSize {
+		return nil, tooLarge(limits.MaxSize)
+	}
+
+	doc, err := s.document(ctx, documentID)
+	if err != nil {
+		return nil, err
+	}
+	if doc.Status != "pending" || doc.S3Key == "" {
+		return nil, &Error{Kind: KindInvalid, Message: "Document is not awaiting an upload"}
+	}
+
+	policy := s.UploadPolicy
+	maxSize := limits.MaxSize
+	if policy != nil {
+		if err := policy.Check(doc.Filename, size, contentType); err != nil {
+			return nil, err
+		}
+		if policy.MaxSize > 0 && policy.MaxSize < maxSize {
+			maxSize = policy.MaxSize
+		}
+
+		head := make([]byte, sniffLength)
+		n, err := io.ReadFull(body, head)
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, &Error{Kind: KindInvalid, Message: "Failed to read file", Err: err}
+		}
+		if err := policy.checkContent(contentType, head[:n]); err != nil {
+			return nil, err
+		}
+		body = io.MultiReader(bytes.NewReader(head[:n]), body)
+	}
+
+	limited := &limitedReader{r: body, remaining: maxSize}
+	err = s.S3Client.StreamObject(ctx, s.uploadKey(doc.S3Key), limited, contentType, limits.PartSize, limits.Concurrency)
+	if limited.exceeded {
+		if maxSize < limits.MaxSize {
+			return nil, policy.sizeError()
+		}
+		return nil, tooLarge(limits.MaxSize)
+	}
+	if err != nil {
+		s.Logger.Error().Err(err).Str("s3_key", doc.S3Key).Msg("Failed to stream upload")
+		return nil, internal("Failed to upload file", err)
+	}
+
+	// The stream may have outlasted the upload window, which only bounds
+	// presigned URLs.
+	now := time.Now()
+	if err := s.Repository.SetDocumentUploadURL(ctx, documentID, now, now.Add(uploadURLExpiry)); err != nil {
+		s.Logger.Error().Err(err).Str("document_id", documentID).Msg("Failed to record upload URL expiry")
+		return nil, internal("Failed to record upload", err)
+	}
+
+	return s.CompleteUpload(ctx, documentID, nil)
+}
+
+func tooLarge(maxSize int64) error {
+	return &Error{Kind: KindTooLarge, Message: fmt.Sprintf("File exceeds the maximum size of %d bytes", maxSize)}
+}
+
+// errTooLarge is returned by limitedReader once its limit is passed.
+var errTooLarge = errors.New("body exceeds size limit")
+
+// limitedReader fails once more than remaining bytes are read, recording
+// that it did, since the uploader does not preserve the error it returns.
+type limitedReader struct {
+	r         io.Reader
+	remaining int64
+	exceeded  bool
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.exceeded {
+		return 0, errTooLarge
+	}
+	// Read one byte past the limit to tell a body of exactly the limit
+	// from a longer one.
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.r.Read(p)
+	if int64(n) > l.remaining {
+		l.exceeded = true
+		return 0, errTooLarge
+	}
+	l.remaining -= int64(n)
+	return n, err
+}
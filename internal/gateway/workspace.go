@@ -0,0 +1,407 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"kb-platform-gateway/internal/buildinfo"
+	"kb-platform-gateway/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// WorkspaceExportURLTTL is how long the download URLs in an exported
+// workspace bundle stay valid, and so how long the bundle can be imported.
+const WorkspaceExportURLTTL = 24 * time.Hour
+
+const (
+	// workspaceConversationBatch is how many conversations an export loads
+	// at a time.
+	workspaceConversationBatch = 100
+	// workspaceDownloadTimeout bounds the download of one document's file
+	// during an import.
+	workspaceDownloadTimeout = 30 * time.Minute
+)
+
+// ExportWorkspace bundles the indexed documents, with presigned URLs to
+// download their files, and optionally every conversation with its
+// messages. Files expanded from an archive are left out, as importing the
+// archive expands them again.
+func (s *Service) ExportWorkspace(ctx context.Context, conversations bool, username string) (*models.WorkspaceBundle, error) {
+	now := time.Now()
+	bundle := &models.WorkspaceBundle{
+		Version:       models.WorkspaceBundleVersion,
+		ExportedAt:    now,
+		ExportedBy:    username,
+		ConfigVersion: buildinfo.Get().String(),
+		URLsExpireAt:  now.Add(WorkspaceExportURLTTL),
+		Documents:     []models.WorkspaceDocument{},
+	}
+
+	err := s.Repository.ExportDocuments(ctx, models.DocumentFilter{Status: "complete"}, func(doc *models.Document) error {
+		if doc.ParentID != "" || doc.S3Key == "" {
+			return nil
+		}
+		downloadURL, err := s.S3Client.GeneratePresignedDownloadURL(ctx, doc.S3Key, WorkspaceExportURLTTL)
+		if err != nil {
+			return err
+		}
+		bundle.Documents = append(bundle.Documents, models.WorkspaceDocument{
+			ID:          doc.ID,
+			Filename:    doc.Filename,
+			FileSize:    doc.FileSize,
+			Metadata:    doc.Metadata,
+			Tags:        doc.Tags,
+			Chunking:    doc.Chunking,
+			Processing:  doc.Processing,
+			UploadedBy:  doc.UploadedBy,
+			CreatedAt:   doc.CreatedAt,
+			S3Key:       doc.S3Key,
+			DownloadURL: downloadURL,
+		})
+		return nil
+	})
+	if err != nil {
+		s.Logger.Error().Err(err).Msg("Failed to export documents")
+		return nil, internal("Failed to export documents", err)
+	}
+
+	if conversations {
+		if bundle.Conversations, err = s.exportConversations(ctx); err != nil {
+			s.Logger.Error().Err(err).Msg("Failed to export conversations")
+			return nil, internal("Failed to export conversations", err)
+		}
+	}
+
+	return bundle, nil
+}
+
+func (s *Service) exportConversations(ctx context.Context) ([]models.WorkspaceConversation, error) {
+	exported := []models.WorkspaceConversation{}
+	for offset := 0; ; offset += workspaceConversationBatch {
+		batch, _, err := s.Repository.ListConversations(ctx, "", workspaceConversationBatch, offset)
+		if err != nil {
+			return nil, err
+		}
+		for _, conv := range batch {
+			messages := []models.Message{}
+			if err := s.Repository.ExportMessages(ctx, conv.ID, func(msg *models.Message) error {
+				messages = append(messages, *msg)
+				return nil
+			}); err != nil {
+				return nil, err
+			}
+			exported = append(exported, models.WorkspaceConversation{
+				ID:        conv.ID,
+				CreatedAt: conv.CreatedAt,
+				CreatedBy: conv.CreatedBy,
+				Messages:  messages,
+			})
+		}
+		if len(batch) < workspaceConversationBatch {
+			return exported, nil
+		}
+	}
+}
+
+// WorkspaceImporter imports workspace bundles exported by another gateway
+// in the background. Each document's file is downloaded into this
+// gateway's bucket and indexed like a new upload. One import runs at a
+// time, and Close interrupts it.
+type WorkspaceImporter struct {
+	service     *Service
+	httpClient  *http.Client
+	partSize    int64
+	concurrency int
+	running     atomic.Bool
+
+	ctx       context.Context
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+}
+
+// NewWorkspaceImporter returns an importer streaming files to S3 in parts
+// of partSize bytes, with at most concurrency parts in memory.
+func NewWorkspaceImporter(service *Service, partSize int64, concurrency int) *WorkspaceImporter {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &WorkspaceImporter{
+		service:     service,
+		httpClient:  &http.Client{Timeout: workspaceDownloadTimeout},
+		partSize:    partSize,
+		concurrency: concurrency,
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+}
+
+// Close interrupts the running import and waits for it to be recorded.
+func (w *WorkspaceImporter) Close() {
+	w.closeOnce.Do(func() {
+		w.cancel()
+		w.wg.Wait()
+	})
+}
+
+// Start stores a workspace import and runs it in the background. Its
+// progress is read back through the repository.
+func (w *WorkspaceImporter) Start(ctx context.Context, bundle *models.WorkspaceBundle, username string) (*models.WorkspaceImport, error) {
+	s := w.service
+	if bundle.Version != models.WorkspaceBundleVersion {
+		return nil, &Error{Kind: KindInvalid, Message: fmt.Sprintf("Unsupported bundle version %d", bundle.Version)}
+	}
+	if len(bundle.Documents) > 0 && time.Now().After(bundle.URLsExpireAt) {
+		return nil, &Error{Kind: KindInvalid, Message: "The bundle's download URLs have expired; export it again"}
+	}
+	if w.ctx.Err() != nil {
+		return nil, &Error{Kind: KindInternal, Message: "Workspace import is shutting down"}
+	}
+	if !w.running.CompareAndSwap(false, true) {
+		return nil, &Error{Kind: KindConflict, Message: "A workspace import is already running"}
+	}
+
+	imp := &models.WorkspaceImport{
+		ID:               uuid.New().String(),
+		Status:           models.WorkspaceImportStatusRunning,
+		BundleExportedAt: bundle.ExportedAt,
+		DocumentsTotal:   len(bundle.Documents),
+		CreatedBy:        username,
+		CreatedAt:        time.Now(),
+	}
+	if err := s.Repository.CreateWorkspaceImport(ctx, imp); err != nil {
+		w.running.Store(false)
+		s.Logger.Error().Err(err).Msg("Failed to create workspace import")
+		return nil, internal("Failed to create workspace import", err)
+	}
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		defer w.running.Store(false)
+		w.run(*imp, bundle)
+	}()
+
+	return imp, nil
+}
+
+func (w *WorkspaceImporter) run(imp models.WorkspaceImport, bundle *models.WorkspaceBundle) {
+	s := w.service
+	// The outcome is recorded even when Close interrupts the import.
+	recordCtx := context.WithoutCancel(w.ctx)
+
+	err := w.importBundle(&imp, bundle)
+	switch {
+	case w.ctx.Err() != nil:
+		imp.Status, imp.Error = models.WorkspaceImportStatusFailed, "Interrupted by gateway shutdown"
+	case err != nil:
+		s.Logger.Error().Err(err).Str("import_id", imp.ID).Msg("Workspace import failed")
+		imp.Status, imp.Error = models.WorkspaceImportStatusFailed, MessageOf(err)
+	default:
+		imp.Status = models.WorkspaceImportStatusCompleted
+	}
+
+	now := time.Now()
+	imp.CompletedAt = &now
+	if err := s.Repository.UpdateWorkspaceImport(recordCtx, &imp); err != nil {
+		s.Logger.Error().Err(err).Str("import_id", imp.ID).Msg("Failed to finish workspace import")
+	}
+}
+
+// importBundle imports the bundle's documents, then its conversations,
+// saving the progress after each document. A document that cannot be
+// imported is recorded as a failure and the import goes on.
+func (w *WorkspaceImporter) importBundle(imp *models.WorkspaceImport, bundle *models.WorkspaceBundle) error {
+	s := w.service
+	ctx := w.ctx
+
+	for _, doc := range bundle.Documents {
+		imported, err := w.importDocument(ctx, doc, imp.CreatedBy)
+		switch {
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case err != nil:
+			s.Logger.Error().Err(err).Str("import_id", imp.ID).Str("document_id", doc.ID).Msg("Failed to import document")
+			imp.DocumentsFailed++
+			imp.Failures = append(imp.Failures, models.WorkspaceImportFailure{
+				DocumentID: doc.ID,
+				Filename:   doc.Filename,
+				Error:      MessageOf(err),
+			})
+		case imported:
+			imp.DocumentsImported++
+		default:
+			imp.DocumentsSkipped++
+		}
+		if err := s.Repository.UpdateWorkspaceImport(ctx, imp); err != nil {
+			s.Logger.Error().Err(err).Str("import_id", imp.ID).Msg("Failed to record workspace import progress")
+		}
+	}
+
+	for _, conv := range bundle.Conversations {
+		imported, err := w.importConversation(ctx, conv)
+		if err != nil {
+			return err
+		}
+		if imported {
+			imp.ConversationsImported++
+		} else {
+			imp.ConversationsSkipped++
+		}
+	}
+	return nil
+}
+
+// importDocument copies a document's file into this gateway's bucket and
+// indexes it under the same ID. It returns false if a document with the
+// ID already exists.
+func (w *WorkspaceImporter) importDocument(ctx context.Context, doc models.WorkspaceDocument, username string) (bool, error) {
+	s := w.service
+	if _, err := uuid.Parse(doc.ID); err != nil {
+		return false, &Error{Kind: KindInvalid, Message: "Invalid document ID"}
+	}
+	if doc.Filename == "" || strings.ContainsAny(doc.Filename, `/\`) {
+		return false, &Error{Kind: KindInvalid, Message: "Invalid filename"}
+	}
+	if doc.FileSize <= 0 {
+		return false, &Error{Kind: KindInvalid, Message: "Invalid file size"}
+	}
+	if u, err := url.Parse(doc.DownloadURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") {
+		return false, &Error{Kind: KindInvalid, Message: "Invalid download URL"}
+	}
+
+	existing, err := s.Repository.GetDocument(ctx, doc.ID)
+	if err != nil {
+		return false, internal("Failed to get document", err)
+	}
+	if existing != nil {
+		return false, nil
+	}
+
+	settings, err := s.WorkspaceSettings(ctx)
+	if err != nil {
+		return false, err
+	}
+	if !allowsFile(settings, doc.Filename) {
+		return false, &Error{Kind: KindInvalid, Message: "File type is not allowed"}
+	}
+	opts, err := normalizeUploadOptions(models.UploadOptions{Chunking: doc.Chunking, Processing: doc.Processing})
+	if err != nil {
+		return false, err
+	}
+
+	s3Key := s.S3Client.ObjectKey("documents/" + doc.ID + "/" + doc.Filename)
+	if err := w.copyFile(ctx, doc, s3Key); err != nil {
+		return false, err
+	}
+
+	uploadedBy := doc.UploadedBy
+	if uploadedBy == "" {
+		uploadedBy = username
+	}
+	created := &models.Document{
+		ID:         doc.ID,
+		S3Key:      s3Key,
+		Filename:   doc.Filename,
+		FileSize:   doc.FileSize,
+		Status:     "pending",
+		UploadedBy: uploadedBy,
+		CreatedAt:  doc.CreatedAt,
+		Metadata:   doc.Metadata,
+		Version:    1,
+		Chunking:   opts.Chunking,
+		Processing: opts.Processing,
+	}
+	if created.CreatedAt.IsZero() {
+		created.CreatedAt = time.Now()
+	}
+	if err := s.Repository.CreateDocument(ctx, created); err != nil {
+		return false, internal("Failed to save document", err)
+	}
+	if len(doc.Tags) > 0 {
+		if _, err := s.Repository.AddDocumentTags(ctx, []string{doc.ID}, doc.Tags); err != nil {
+			return false, internal("Failed to tag document", err)
+		}
+	}
+	s.recordDocumentEvent(ctx, doc.ID, models.DocumentEventUploaded, map[string]interface{}{
+		"filename":    doc.Filename,
+		"file_size":   doc.FileSize,
+		"uploaded_by": uploadedBy,
+		"imported_by": username,
+	})
+
+	if err := s.indexStored(ctx, created); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// copyFile downloads a document's file and streams it to s3Key. A file
+// of another size than the bundle lists is refused and discarded.
+func (w *WorkspaceImporter) copyFile(ctx context.Context, doc models.WorkspaceDocument, s3Key string) error {
+	s := w.service
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, doc.DownloadURL, nil)
+	if err != nil {
+		return &Error{Kind: KindInvalid, Message: "Invalid download URL", Err: err}
+	}
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return &Error{Kind: KindUnavailable, Message: "Failed to download file", Err: err}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return &Error{Kind: KindUnavailable, Message: fmt.Sprintf("Failed to download file: status %d", resp.StatusCode)}
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	body := &limitedReader{r: resp.Body, remaining: doc.FileSize}
+	err = s.S3Client.StreamObject(ctx, s3Key, body, contentType, w.partSize, w.concurrency)
+	if err == nil && !body.exceeded && body.remaining == 0 {
+		return nil
+	}
+
+	if deleteErr := s.S3Client.DeleteObject(context.WithoutCancel(ctx), s3Key); deleteErr != nil {
+		s.Logger.Error().Err(deleteErr).Str("s3_key", s3Key).Msg("Failed to delete partial import")
+	}
+	if err != nil && !body.exceeded {
+		return internal("Failed to store file", err)
+	}
+	return &Error{Kind: KindInvalid, Message: "Downloaded file does not match the bundle's file size"}
+}
+
+// importConversation saves a conversation and its messages under their
+// IDs. It returns false if a conversation with the ID already exists.
+func (w *WorkspaceImporter) importConversation(ctx context.Context, conv models.WorkspaceConversation) (bool, error) {
+	s := w.service
+	existing, err := s.Repository.GetConversation(ctx, conv.ID)
+	if err != nil {
+		return false, internal("Failed to get conversation", err)
+	}
+	if existing != nil {
+		return false, nil
+	}
+
+	if err := s.Repository.CreateConversation(ctx, &models.Conversation{
+		ID:        conv.ID,
+		CreatedAt: conv.CreatedAt,
+		UpdatedAt: conv.CreatedAt,
+		CreatedBy: conv.CreatedBy,
+	}); err != nil {
+		return false, internal("Failed to save conversation", err)
+	}
+	for _, msg := range conv.Messages {
+		msg.ConversationID = conv.ID
+		if err := s.Repository.CreateMessage(ctx, &msg); err != nil {
+			return false, internal("Failed to save message", err)
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,114 @@
+package graph_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"kb-platform-gateway/internal/gateway"
+	"kb-platform-gateway/internal/graph"
+	"kb-platform-gateway/internal/models"
+	repomocks "kb-platform-gateway/internal/repository/mocks"
+	"kb-platform-gateway/internal/services/mocks"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type response struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string                 `json:"message"`
+		Extensions map[string]interface{} `json:"extensions"`
+	} `json:"errors"`
+}
+
+func execute(t *testing.T, svc *gateway.Service, query string) response {
+	t.Helper()
+
+	body, _ := json.Marshal(map[string]string{"query": query})
+	req := httptest.NewRequest("POST", "/graphql", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(graph.WithUsername(req.Context(), "alice"))
+	resp := httptest.NewRecorder()
+
+	graph.NewHandler(svc).ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+
+	var r response
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &r))
+	return r
+}
+
+func TestGraphQL(t *testing.T) {
+	t.Run("Conversation_NestedMessages", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetConversation", mock.Anything, "conv-1").Return(&models.Conversation{ID: "conv-1", MessageCount: 1, CreatedBy: "alice"}, nil)
+		repo.On("GetMessagesByConversationID", mock.Anything, "conv-1", 10, 0).Return([]*models.Message{
+			{ID: "msg-1", ConversationID: "conv-1", Role: "user", Content: "hi", Metadata: map[string]string{"lang": "en"}},
+		}, nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		r := execute(t, svc, `{ conversation(id: "conv-1") { id messageCount messages(limit: 10) { content metadata { key value } } } }`)
+
+		require.Empty(t, r.Errors)
+		assert.JSONEq(t, `{"conversation":{"id":"conv-1","messageCount":1,"messages":[{"content":"hi","metadata":[{"key":"lang","value":"en"}]}]}}`, string(r.Data))
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Document_NotFoundIsNull", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", mock.Anything, "doc-1").Return(nil, nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		r := execute(t, svc, `{ document(id: "doc-1") { id } }`)
+
+		require.Empty(t, r.Errors)
+		assert.JSONEq(t, `{"document":null}`, string(r.Data))
+	})
+
+	t.Run("Conversations_UseCaller", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("ListConversations", mock.Anything, "alice", gateway.DefaultPageSize, 0).Return(nil, 0, nil)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		r := execute(t, svc, `{ conversations { total } }`)
+
+		require.Empty(t, r.Errors)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("QueryMutation_CollectsAnswer", func(t *testing.T) {
+		events := make(chan models.SSEEvent, 4)
+		events <- models.SSEEvent{Type: "start", ID: "q-1"}
+		events <- models.SSEEvent{Type: "chunk", Content: "LlamaIndex is"}
+		events <- models.SSEEvent{Type: "chunk", Content: " a data framework"}
+		events <- models.SSEEvent{Type: "end", ID: "q-1"}
+		close(events)
+
+		core := mocks.NewMockCoreService()
+		core.On("Query", mock.Anything, models.CoreQueryRequest{Query: "What is LlamaIndex?", TopK: gateway.DefaultTopK}).Return((<-chan models.SSEEvent)(events), nil)
+		svc := &gateway.Service{CoreClient: core, Logger: zerolog.Nop()}
+
+		r := execute(t, svc, `mutation { query(input: {query: "What is LlamaIndex?"}) { id answer } }`)
+
+		require.Empty(t, r.Errors)
+		assert.JSONEq(t, `{"query":{"id":"q-1","answer":"LlamaIndex is a data framework"}}`, string(r.Data))
+	})
+
+	t.Run("ListDocuments_InternalErrorHidden", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("ListDocuments", mock.Anything, gateway.DefaultPageSize, 0, models.DocumentFilter{}).Return(nil, 0, assert.AnError)
+		svc := &gateway.Service{Repository: repo, Logger: zerolog.Nop()}
+
+		r := execute(t, svc, `{ documents { total } }`)
+
+		require.Len(t, r.Errors, 1)
+		assert.Equal(t, "Failed to list documents", r.Errors[0].Message)
+		assert.Equal(t, "INTERNAL_ERROR", r.Errors[0].Extensions["code"])
+	})
+}
@@ -0,0 +1,145 @@
+// Package graph serves the gateway's GraphQL API. Resolvers delegate to
+// gateway.Service, like the REST and gRPC transports.
+package graph
+
+//go:generate go run github.com/99designs/gqlgen generate
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"time"
+
+	"kb-platform-gateway/internal/gateway"
+	"kb-platform-gateway/internal/models"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+type Resolver struct {
+	Gateway *gateway.Service
+}
+
+// NewHandler returns the /graphql HTTP handler. Subscriptions are served
+// over WebSocket (graphql-transport-ws) and SSE.
+func NewHandler(svc *gateway.Service) http.Handler {
+	srv := handler.New(NewExecutableSchema(Config{Resolvers: &Resolver{Gateway: svc}}))
+	srv.AddTransport(transport.Websocket{KeepAlivePingInterval: 10 * time.Second})
+	srv.AddTransport(transport.SSE{})
+	srv.AddTransport(transport.GET{})
+	srv.AddTransport(transport.POST{})
+	srv.Use(extension.Introspection{})
+	return srv
+}
+
+type usernameKey struct{}
+
+// WithUsername attaches the authenticated caller to ctx.
+func WithUsername(ctx context.Context, username string) context.Context {
+	return context.WithValue(ctx, usernameKey{}, username)
+}
+
+// Username returns the caller attached by WithUsername.
+func Username(ctx context.Context) string {
+	username, _ := ctx.Value(usernameKey{}).(string)
+	return username
+}
+
+// toGraphQLError converts a gateway error, keeping internal details out of
+// the response.
+func toGraphQLError(err error) error {
+	code := "INTERNAL_ERROR"
+	switch gateway.KindOf(err) {
+	case gateway.KindInvalid:
+		code = "VALIDATION_ERROR"
+	case gateway.KindNotFound:
+		code = "NOT_FOUND"
+	case gateway.KindUploadExpired:
+		code = "UPLOAD_URL_EXPIRED"
+	case gateway.KindConflict:
+		code = "CONFLICT"
+	case gateway.KindConversationBusy:
+		code = "CONVERSATION_BUSY"
+	case gateway.KindForbidden:
+		code = "AUTHORIZATION_ERROR"
+	case gateway.KindUnavailable:
+		code = "SERVICE_UNAVAILABLE"
+	case gateway.KindTooLarge:
+		code = "PAYLOAD_TOO_LARGE"
+	case gateway.KindDuplicate:
+		code = "DUPLICATE_DOCUMENT"
+	case gateway.KindInfected:
+		code = "FILE_INFECTED"
+	}
+	extensions := map[string]interface{}{"code": code}
+	if details := gateway.DetailsOf(err); details != nil {
+		extensions["details"] = details
+	}
+	return &gqlerror.Error{
+		Message:    gateway.MessageOf(err),
+		Extensions: extensions,
+	}
+}
+
+func page(limit, offset *int) (int, int) {
+	var l, o int
+	if limit != nil {
+		l = *limit
+	}
+	if offset != nil {
+		o = *offset
+	}
+	return gateway.Page(l, o)
+}
+
+func queryRequest(input QueryInput) models.QueryRequest {
+	req := models.QueryRequest{Query: input.Query}
+	if input.ConversationID != nil {
+		req.ConversationID = *input.ConversationID
+	}
+	if input.TopK != nil {
+		req.TopK = *input.TopK
+	}
+	if input.PromptTemplateID != nil {
+		req.PromptTemplateID = *input.PromptTemplateID
+	}
+	if input.PromptTemplateVersion != nil {
+		req.PromptTemplateVersion = *input.PromptTemplateVersion
+	}
+	if input.Language != nil {
+		req.Language = *input.Language
+	}
+	if input.MaxChunksPerDocument != nil {
+		req.MaxChunksPerDocument = *input.MaxChunksPerDocument
+	}
+	if input.Fresh != nil {
+		req.Fresh = *input.Fresh
+	}
+	if input.AsOf != nil {
+		req.AsOf = *input.AsOf
+	}
+	if input.CollectionID != nil {
+		req.CollectionID = *input.CollectionID
+	}
+	return req
+}
+
+func keyValues(m map[string]string) []KeyValue {
+	kvs := make([]KeyValue, 0, len(m))
+	for k, v := range m {
+		kvs = append(kvs, KeyValue{Key: k, Value: v})
+	}
+	sort.Slice(kvs, func(i, j int) bool { return kvs[i].Key < kvs[j].Key })
+	return kvs
+}
+
+func derefAll[T any](items []*T) []T {
+	out := make([]T, len(items))
+	for i, item := range items {
+		out[i] = *item
+	}
+	return out
+}
@@ -0,0 +1,200 @@
+package graph
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.81
+
+import (
+	"context"
+	"strings"
+
+	"kb-platform-gateway/internal/gateway"
+	"kb-platform-gateway/internal/models"
+
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Messages is the resolver for the messages field.
+func (r *conversationResolver) Messages(ctx context.Context, obj *models.Conversation, limit *int, offset *int) ([]models.Message, error) {
+	l, o := page(limit, offset)
+	messages, err := r.Gateway.GetConversationMessages(ctx, obj.ID, Username(ctx), l, o)
+	if err != nil {
+		return nil, toGraphQLError(err)
+	}
+	return derefAll(messages), nil
+}
+
+// Metadata is the resolver for the metadata field.
+func (r *documentResolver) Metadata(ctx context.Context, obj *models.Document) ([]KeyValue, error) {
+	return keyValues(obj.Metadata), nil
+}
+
+// Metadata is the resolver for the metadata field.
+func (r *messageResolver) Metadata(ctx context.Context, obj *models.Message) ([]KeyValue, error) {
+	return keyValues(obj.Metadata), nil
+}
+
+// CreateConversation is the resolver for the createConversation field.
+func (r *mutationResolver) CreateConversation(ctx context.Context) (*models.Conversation, error) {
+	conv, err := r.Gateway.CreateConversation(ctx, Username(ctx))
+	if err != nil {
+		return nil, toGraphQLError(err)
+	}
+	return conv, nil
+}
+
+// DeleteDocument is the resolver for the deleteDocument field.
+func (r *mutationResolver) DeleteDocument(ctx context.Context, id string) (bool, error) {
+	if err := r.Gateway.DeleteDocument(ctx, id); err != nil {
+		return false, toGraphQLError(err)
+	}
+	return true, nil
+}
+
+// Query is the resolver for the query field.
+func (r *mutationResolver) Query(ctx context.Context, input QueryInput) (*QueryResult, error) {
+	events, err := r.Gateway.Query(ctx, queryRequest(input), Username(ctx))
+	if err != nil {
+		return nil, toGraphQLError(err)
+	}
+
+	result := &QueryResult{}
+	var answer strings.Builder
+	for event := range events {
+		switch event.Type {
+		case "error":
+			for range events {
+			}
+			return nil, &gqlerror.Error{
+				Message:    event.Message,
+				Extensions: map[string]interface{}{"code": event.Code},
+			}
+		case "chunk":
+			answer.WriteString(event.Content)
+		case "end":
+			result.PreviouslyAnswered = event.PreviouslyAnswered
+			result.Curated = event.Curated
+			result.Redacted = event.Redacted
+		}
+		if event.ID != "" {
+			id := event.ID
+			result.ID = &id
+		}
+	}
+	result.Answer = answer.String()
+
+	return result, nil
+}
+
+// Documents is the resolver for the documents field.
+func (r *queryResolver) Documents(ctx context.Context, limit *int, offset *int, status *string, language *string) (*DocumentList, error) {
+	l, o := page(limit, offset)
+	var statusFilter, languageFilter string
+	if status != nil {
+		statusFilter = *status
+	}
+	if language != nil {
+		languageFilter = *language
+	}
+
+	documents, total, err := r.Gateway.ListDocuments(ctx, l, o, models.DocumentFilter{Status: statusFilter, Language: languageFilter})
+	if err != nil {
+		return nil, toGraphQLError(err)
+	}
+
+	return &DocumentList{
+		Documents: derefAll(documents),
+		Total:     total,
+		Limit:     l,
+		Offset:    o,
+	}, nil
+}
+
+// Document is the resolver for the document field.
+func (r *queryResolver) Document(ctx context.Context, id string) (*models.Document, error) {
+	doc, err := r.Gateway.GetDocument(ctx, id)
+	if gateway.KindOf(err) == gateway.KindNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, toGraphQLError(err)
+	}
+	return doc, nil
+}
+
+// Conversations is the resolver for the conversations field.
+func (r *queryResolver) Conversations(ctx context.Context, limit *int, offset *int) (*ConversationList, error) {
+	l, o := page(limit, offset)
+	conversations, total, err := r.Gateway.ListConversations(ctx, Username(ctx), l, o)
+	if err != nil {
+		return nil, toGraphQLError(err)
+	}
+
+	return &ConversationList{
+		Conversations: derefAll(conversations),
+		Total:         total,
+		Limit:         l,
+		Offset:        o,
+	}, nil
+}
+
+// Conversation is the resolver for the conversation field.
+func (r *queryResolver) Conversation(ctx context.Context, id string) (*models.Conversation, error) {
+	conv, err := r.Gateway.GetConversation(ctx, id)
+	if gateway.KindOf(err) == gateway.KindNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, toGraphQLError(err)
+	}
+	return conv, nil
+}
+
+// Query is the resolver for the query field.
+func (r *subscriptionResolver) Query(ctx context.Context, input QueryInput) (<-chan *models.SSEEvent, error) {
+	events, err := r.Gateway.Query(ctx, queryRequest(input), Username(ctx))
+	if err != nil {
+		return nil, toGraphQLError(err)
+	}
+
+	out := make(chan *models.SSEEvent)
+	go func() {
+		defer close(out)
+		for event := range events {
+			select {
+			case out <- &event:
+			case <-ctx.Done():
+				for range events {
+				}
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// Conversation returns ConversationResolver implementation.
+func (r *Resolver) Conversation() ConversationResolver { return &conversationResolver{r} }
+
+// Document returns DocumentResolver implementation.
+func (r *Resolver) Document() DocumentResolver { return &documentResolver{r} }
+
+// Message returns MessageResolver implementation.
+func (r *Resolver) Message() MessageResolver { return &messageResolver{r} }
+
+// Mutation returns MutationResolver implementation.
+func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
+
+// Query returns QueryResolver implementation.
+func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
+
+// Subscription returns SubscriptionResolver implementation.
+func (r *Resolver) Subscription() SubscriptionResolver { return &subscriptionResolver{r} }
+
+type conversationResolver struct{ *Resolver }
+type documentResolver struct{ *Resolver }
+type messageResolver struct{ *Resolver }
+type mutationResolver struct{ *Resolver }
+type queryResolver struct{ *Resolver }
+type subscriptionResolver struct{ *Resolver }
@@ -0,0 +1,131 @@
+package grpcserver_test
+
+import (
+	"testing"
+	"time"
+
+	kbgatewayv1 "kb-platform-gateway/internal/gen/kbgateway/v1"
+	"kb-platform-gateway/internal/grpcserver"
+	"kb-platform-gateway/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestTimestampToProto(t *testing.T) {
+	created := time.Date(2026, 3, 12, 9, 30, 15, 250000000, time.UTC)
+
+	tests := []struct {
+		name string
+		t    time.Time
+		want *timestamppb.Timestamp
+	}{
+		{name: "zero", t: time.Time{}, want: nil},
+		{name: "set", t: created, want: timestamppb.New(created)},
+		{name: "other zone", t: created.In(time.FixedZone("UTC+7", 7*60*60)), want: timestamppb.New(created)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.True(t, proto.Equal(tt.want, grpcserver.TimestampToProto(tt.t)))
+		})
+	}
+}
+
+// roundTrip sends msg over the wire and decodes it into out.
+func roundTrip[T proto.Message](t *testing.T, msg T, out T) T {
+	t.Helper()
+	data, err := proto.Marshal(msg)
+	require.NoError(t, err)
+	require.NoError(t, proto.Unmarshal(data, out))
+	return out
+}
+
+func TestModelToProto(t *testing.T) {
+	created := time.Date(2026, 3, 12, 9, 30, 15, 250000000, time.UTC)
+	indexed := created.Add(90 * time.Second)
+
+	t.Run("Document", func(t *testing.T) {
+		tests := []struct {
+			name string
+			doc  *models.Document
+		}{
+			{
+				name: "all fields",
+				doc: &models.Document{
+					ID: "doc-1", UploadURL: "https://s3.example.com/upload", S3Key: "documents/doc-1/guide.pdf",
+					Filename: "guide.pdf", FileSize: 1024, Status: "complete",
+					Metadata: map[string]string{"team": "hr"}, CreatedAt: created, IndexedAt: &indexed,
+				},
+			},
+			{
+				name: "missing optional fields",
+				doc:  &models.Document{ID: "doc-1", Filename: "guide.pdf", Status: "pending", CreatedAt: created},
+			},
+			{
+				name: "zero times",
+				doc:  &models.Document{ID: "doc-1", IndexedAt: &time.Time{}},
+			},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				got := roundTrip(t, grpcserver.DocumentToProto(tt.doc), &kbgatewayv1.Document{})
+
+				assert.Equal(t, tt.doc.ID, got.GetId())
+				assert.Equal(t, tt.doc.UploadURL, got.GetUploadUrl())
+				assert.Equal(t, tt.doc.S3Key, got.GetS3Key())
+				assert.Equal(t, tt.doc.Filename, got.GetFilename())
+				assert.Equal(t, tt.doc.FileSize, got.GetFileSize())
+				assert.Equal(t, tt.doc.Status, got.GetStatus())
+				assert.Equal(t, len(tt.doc.Metadata), len(got.GetMetadata()))
+				for key, value := range tt.doc.Metadata {
+					assert.Equal(t, value, got.GetMetadata()[key])
+				}
+				if tt.doc.CreatedAt.IsZero() {
+					assert.Nil(t, got.GetCreatedAt())
+				} else {
+					assert.Equal(t, tt.doc.CreatedAt, got.GetCreatedAt().AsTime())
+				}
+				if tt.doc.IndexedAt == nil || tt.doc.IndexedAt.IsZero() {
+					assert.Nil(t, got.GetIndexedAt())
+				} else {
+					assert.Equal(t, *tt.doc.IndexedAt, got.GetIndexedAt().AsTime())
+				}
+			})
+		}
+	})
+
+	t.Run("Conversation", func(t *testing.T) {
+		got := roundTrip(t, grpcserver.ConversationToProto(&models.Conversation{
+			ID: "conv-1", MessageCount: 4, CreatedAt: created, UpdatedAt: indexed,
+		}), &kbgatewayv1.Conversation{})
+
+		assert.Equal(t, "conv-1", got.GetId())
+		assert.Equal(t, int32(4), got.GetMessageCount())
+		assert.Equal(t, created, got.GetCreatedAt().AsTime())
+		assert.Equal(t, indexed, got.GetUpdatedAt().AsTime())
+
+		got = roundTrip(t, grpcserver.ConversationToProto(&models.Conversation{ID: "conv-1"}), &kbgatewayv1.Conversation{})
+		assert.Nil(t, got.GetCreatedAt())
+		assert.Nil(t, got.GetUpdatedAt())
+	})
+
+	t.Run("Message", func(t *testing.T) {
+		got := roundTrip(t, grpcserver.MessageToProto(&models.Message{
+			ID: "msg-1", ConversationID: "conv-1", Role: "assistant", Content: "Hello",
+			Metadata: map[string]string{"query_id": "q-1"}, CreatedAt: created,
+		}), &kbgatewayv1.Message{})
+
+		assert.Equal(t, "msg-1", got.GetId())
+		assert.Equal(t, "conv-1", got.GetConversationId())
+		assert.Equal(t, "assistant", got.GetRole())
+		assert.Equal(t, "Hello", got.GetContent())
+		assert.Equal(t, "q-1", got.GetMetadata()["query_id"])
+		assert.Equal(t, created, got.GetCreatedAt().AsTime())
+
+		got = roundTrip(t, grpcserver.MessageToProto(&models.Message{ID: "msg-1"}), &kbgatewayv1.Message{})
+		assert.Nil(t, got.GetCreatedAt())
+		assert.Empty(t, got.GetMetadata())
+	})
+}
@@ -0,0 +1,10 @@
+package grpcserver
+
+// Converters from the models to the gateway's protobuf messages, exported
+// for the external test package.
+var (
+	DocumentToProto     = documentToProto
+	ConversationToProto = conversationToProto
+	MessageToProto      = messageToProto
+	TimestampToProto    = timestampToProto
+)
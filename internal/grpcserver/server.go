@@ -0,0 +1,292 @@
+// Package grpcserver exposes the gateway's API over gRPC, backed by the same
+// gateway.Service as the REST handlers.
+package grpcserver
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"kb-platform-gateway/internal/gateway"
+	kbgatewayv1 "kb-platform-gateway/internal/gen/kbgateway/v1"
+	"kb-platform-gateway/internal/models"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// UserMetadataKey carries the caller's identity, like the x-user-name
+// header on the REST API.
+const UserMetadataKey = "x-user-name"
+
+type usernameKey struct{}
+
+// Server implements kbgatewayv1.GatewayServiceServer.
+type Server struct {
+	kbgatewayv1.UnimplementedGatewayServiceServer
+	gateway *gateway.Service
+}
+
+func NewServer(svc *gateway.Service) *Server {
+	return &Server{gateway: svc}
+}
+
+// NewGRPCServer returns a grpc.Server serving the gateway API and the
+// standard health service.
+func NewGRPCServer(svc *gateway.Service, opts ...grpc.ServerOption) *grpc.Server {
+	opts = append(opts,
+		grpc.ChainUnaryInterceptor(authUnaryInterceptor),
+		grpc.ChainStreamInterceptor(authStreamInterceptor),
+	)
+	gs := grpc.NewServer(opts...)
+	kbgatewayv1.RegisterGatewayServiceServer(gs, NewServer(svc))
+	healthpb.RegisterHealthServer(gs, health.NewServer())
+	return gs
+}
+
+// authenticate requires the x-user-name metadata on gateway methods.
+func authenticate(ctx context.Context, method string) (context.Context, error) {
+	if strings.HasPrefix(method, "/grpc.health.v1.") {
+		return ctx, nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	users := md.Get(UserMetadataKey)
+	if len(users) == 0 || users[0] == "" {
+		return nil, status.Error(codes.Unauthenticated, "Missing x-user-name metadata")
+	}
+	return context.WithValue(ctx, usernameKey{}, users[0]), nil
+}
+
+func authUnaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+func authStreamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := authenticate(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+}
+
+func username(ctx context.Context) string {
+	user, _ := ctx.Value(usernameKey{}).(string)
+	return user
+}
+
+// toStatus converts a gateway error to a gRPC status error.
+func toStatus(err error) error {
+	code := codes.Internal
+	switch gateway.KindOf(err) {
+	case gateway.KindInvalid:
+		code = codes.InvalidArgument
+	case gateway.KindNotFound:
+		code = codes.NotFound
+	case gateway.KindUploadExpired:
+		code = codes.FailedPrecondition
+	case gateway.KindConflict:
+		code = codes.Aborted
+	case gateway.KindForbidden:
+		code = codes.PermissionDenied
+	case gateway.KindUnavailable:
+		// Unavailable would invite retries; the feature stays disabled.
+		code = codes.Unimplemented
+	case gateway.KindTooLarge:
+		code = codes.ResourceExhausted
+	case gateway.KindInfected:
+		code = codes.FailedPrecondition
+	case gateway.KindConversationBusy:
+		return status.Errorf(codes.Aborted, "%s (request %s)", gateway.MessageOf(err), gateway.DetailsOf(err)["active_request_id"])
+	case gateway.KindDuplicate:
+		return status.Errorf(codes.AlreadyExists, "%s (document %s)", gateway.MessageOf(err), gateway.DetailsOf(err)["document_id"])
+	}
+	return status.Error(code, gateway.MessageOf(err))
+}
+
+func (s *Server) UploadDocument(ctx context.Context, req *kbgatewayv1.UploadDocumentRequest) (*kbgatewayv1.Document, error) {
+	doc, err := s.gateway.UploadDocument(ctx, req.GetFilename(), req.GetFileSize(), username(ctx), models.UploadOptions{})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return documentToProto(doc), nil
+}
+
+func (s *Server) ListDocuments(ctx context.Context, req *kbgatewayv1.ListDocumentsRequest) (*kbgatewayv1.ListDocumentsResponse, error) {
+	limit, offset := gateway.Page(int(req.GetLimit()), int(req.GetOffset()))
+	documents, total, err := s.gateway.ListDocuments(ctx, limit, offset, models.DocumentFilter{Status: req.GetStatus()})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	resp := &kbgatewayv1.ListDocumentsResponse{
+		Documents: make([]*kbgatewayv1.Document, len(documents)),
+		Total:     int32(total),
+		Limit:     int32(limit),
+		Offset:    int32(offset),
+	}
+	for i, doc := range documents {
+		resp.Documents[i] = documentToProto(doc)
+	}
+	return resp, nil
+}
+
+func (s *Server) GetDocument(ctx context.Context, req *kbgatewayv1.GetDocumentRequest) (*kbgatewayv1.Document, error) {
+	doc, err := s.gateway.GetDocument(ctx, req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return documentToProto(doc), nil
+}
+
+func (s *Server) DeleteDocument(ctx context.Context, req *kbgatewayv1.DeleteDocumentRequest) (*emptypb.Empty, error) {
+	if err := s.gateway.DeleteDocument(ctx, req.GetId()); err != nil {
+		return nil, toStatus(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *Server) CompleteUpload(ctx context.Context, req *kbgatewayv1.CompleteUploadRequest) (*kbgatewayv1.Document, error) {
+	doc, err := s.gateway.CompleteUpload(ctx, req.GetId(), nil)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return documentToProto(doc), nil
+}
+
+func (s *Server) ListConversations(ctx context.Context, req *kbgatewayv1.ListConversationsRequest) (*kbgatewayv1.ListConversationsResponse, error) {
+	limit, offset := gateway.Page(int(req.GetLimit()), int(req.GetOffset()))
+	conversations, total, err := s.gateway.ListConversations(ctx, username(ctx), limit, offset)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	resp := &kbgatewayv1.ListConversationsResponse{
+		Conversations: make([]*kbgatewayv1.Conversation, len(conversations)),
+		Total:         int32(total),
+		Limit:         int32(limit),
+		Offset:        int32(offset),
+	}
+	for i, conv := range conversations {
+		resp.Conversations[i] = conversationToProto(conv)
+	}
+	return resp, nil
+}
+
+func (s *Server) CreateConversation(ctx context.Context, _ *kbgatewayv1.CreateConversationRequest) (*kbgatewayv1.Conversation, error) {
+	conv, err := s.gateway.CreateConversation(ctx, username(ctx))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return conversationToProto(conv), nil
+}
+
+func (s *Server) GetConversationMessages(ctx context.Context, req *kbgatewayv1.GetConversationMessagesRequest) (*kbgatewayv1.GetConversationMessagesResponse, error) {
+	limit, offset := gateway.Page(int(req.GetLimit()), int(req.GetOffset()))
+	messages, err := s.gateway.GetConversationMessages(ctx, req.GetConversationId(), username(ctx), limit, offset)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	resp := &kbgatewayv1.GetConversationMessagesResponse{
+		Messages: make([]*kbgatewayv1.Message, len(messages)),
+	}
+	for i, msg := range messages {
+		resp.Messages[i] = messageToProto(msg)
+	}
+	return resp, nil
+}
+
+func (s *Server) Query(req *kbgatewayv1.QueryRequest, stream grpc.ServerStreamingServer[kbgatewayv1.QueryEvent]) error {
+	ctx := stream.Context()
+	events, err := s.gateway.Query(ctx, models.QueryRequest{
+		Query:          req.GetQuery(),
+		ConversationID: req.GetConversationId(),
+		TopK:           int(req.GetTopK()),
+
+		PromptTemplateID:      req.GetPromptTemplateId(),
+		PromptTemplateVersion: int(req.GetPromptTemplateVersion()),
+	}, username(ctx))
+	if err != nil {
+		return toStatus(err)
+	}
+
+	for event := range events {
+		if err := stream.Send(&kbgatewayv1.QueryEvent{
+			Type:    event.Type,
+			Id:      event.ID,
+			Content: event.Content,
+			Code:    event.Code,
+			Message: event.Message,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func documentToProto(doc *models.Document) *kbgatewayv1.Document {
+	pb := &kbgatewayv1.Document{
+		Id:           doc.ID,
+		UploadUrl:    doc.UploadURL,
+		S3Key:        doc.S3Key,
+		Filename:     doc.Filename,
+		FileSize:     doc.FileSize,
+		Status:       doc.Status,
+		ErrorMessage: doc.ErrorMessage,
+		Metadata:     doc.Metadata,
+		CreatedAt:    timestampToProto(doc.CreatedAt),
+	}
+	if doc.IndexedAt != nil {
+		pb.IndexedAt = timestampToProto(*doc.IndexedAt)
+	}
+	return pb
+}
+
+func conversationToProto(conv *models.Conversation) *kbgatewayv1.Conversation {
+	return &kbgatewayv1.Conversation{
+		Id:           conv.ID,
+		CreatedAt:    timestampToProto(conv.CreatedAt),
+		UpdatedAt:    timestampToProto(conv.UpdatedAt),
+		MessageCount: int32(conv.MessageCount),
+	}
+}
+
+func messageToProto(msg *models.Message) *kbgatewayv1.Message {
+	return &kbgatewayv1.Message{
+		Id:             msg.ID,
+		ConversationId: msg.ConversationID,
+		Role:           msg.Role,
+		Content:        msg.Content,
+		CreatedAt:      timestampToProto(msg.CreatedAt),
+		Metadata:       msg.Metadata,
+	}
+}
+
+// timestampToProto leaves an unknown (zero) time unset rather than sending
+// it as year 1.
+func timestampToProto(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
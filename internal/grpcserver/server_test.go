@@ -0,0 +1,165 @@
+package grpcserver_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"kb-platform-gateway/internal/gateway"
+	kbgatewayv1 "kb-platform-gateway/internal/gen/kbgateway/v1"
+	"kb-platform-gateway/internal/grpcserver"
+	"kb-platform-gateway/internal/models"
+	repomocks "kb-platform-gateway/internal/repository/mocks"
+	"kb-platform-gateway/internal/services/mocks"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func newTestClient(t *testing.T, svc *gateway.Service) kbgatewayv1.GatewayServiceClient {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	server := grpcserver.NewGRPCServer(svc)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return kbgatewayv1.NewGatewayServiceClient(conn)
+}
+
+func userContext() context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), grpcserver.UserMetadataKey, "alice")
+}
+
+func TestGatewayServer(t *testing.T) {
+	t.Run("MissingUser_Unauthenticated", func(t *testing.T) {
+		client := newTestClient(t, &gateway.Service{Logger: zerolog.Nop()})
+
+		_, err := client.GetDocument(context.Background(), &kbgatewayv1.GetDocumentRequest{Id: "doc-1"})
+
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("GetDocument_NotFound", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", mock.Anything, "doc-1").Return(nil, nil)
+		client := newTestClient(t, &gateway.Service{Repository: repo, Logger: zerolog.Nop()})
+
+		_, err := client.GetDocument(userContext(), &kbgatewayv1.GetDocumentRequest{Id: "doc-1"})
+
+		assert.Equal(t, codes.NotFound, status.Code(err))
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("GetDocument_Success", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", mock.Anything, "doc-1").Return(&models.Document{ID: "doc-1", Filename: "a.pdf", Status: "complete"}, nil)
+		client := newTestClient(t, &gateway.Service{Repository: repo, Logger: zerolog.Nop()})
+
+		doc, err := client.GetDocument(userContext(), &kbgatewayv1.GetDocumentRequest{Id: "doc-1"})
+
+		require.NoError(t, err)
+		assert.Equal(t, "a.pdf", doc.GetFilename())
+		assert.Equal(t, "complete", doc.GetStatus())
+	})
+
+	t.Run("GetDocument_Timestamps", func(t *testing.T) {
+		createdAt := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", mock.Anything, "doc-1").Return(&models.Document{ID: "doc-1", Filename: "a.pdf", Status: "pending", CreatedAt: createdAt}, nil)
+		client := newTestClient(t, &gateway.Service{Repository: repo, Logger: zerolog.Nop()})
+
+		doc, err := client.GetDocument(userContext(), &kbgatewayv1.GetDocumentRequest{Id: "doc-1"})
+
+		require.NoError(t, err)
+		assert.Equal(t, createdAt, doc.GetCreatedAt().AsTime())
+		assert.Nil(t, doc.GetIndexedAt())
+	})
+
+	t.Run("GetConversationMessages_Timestamps", func(t *testing.T) {
+		createdAt := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+		repo := repomocks.NewMockRepository()
+		repo.On("GetConversation", mock.Anything, "conv-1").Return(&models.Conversation{ID: "conv-1", CreatedBy: "alice"}, nil)
+		repo.On("GetMessagesByConversationID", mock.Anything, "conv-1", gateway.DefaultPageSize, 0).Return([]*models.Message{
+			{ID: "m-1", ConversationID: "conv-1", Role: "user", Content: "hi", CreatedAt: createdAt},
+			{ID: "m-2", ConversationID: "conv-1", Role: "assistant", Content: "hello"},
+		}, nil)
+		client := newTestClient(t, &gateway.Service{Repository: repo, Logger: zerolog.Nop()})
+
+		resp, err := client.GetConversationMessages(userContext(), &kbgatewayv1.GetConversationMessagesRequest{ConversationId: "conv-1"})
+
+		require.NoError(t, err)
+		require.Len(t, resp.GetMessages(), 2)
+		assert.Equal(t, createdAt, resp.GetMessages()[0].GetCreatedAt().AsTime())
+		// An unknown creation time is left unset, not sent as year 1.
+		assert.Nil(t, resp.GetMessages()[1].GetCreatedAt())
+	})
+
+	t.Run("ListConversations_UsesCaller", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("ListConversations", mock.Anything, "alice", gateway.DefaultPageSize, 0).Return([]*models.Conversation{{ID: "conv-1"}}, 1, nil)
+		client := newTestClient(t, &gateway.Service{Repository: repo, Logger: zerolog.Nop()})
+
+		resp, err := client.ListConversations(userContext(), &kbgatewayv1.ListConversationsRequest{})
+
+		require.NoError(t, err)
+		assert.Equal(t, int32(1), resp.GetTotal())
+		assert.Equal(t, "conv-1", resp.GetConversations()[0].GetId())
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Query_Streams", func(t *testing.T) {
+		events := make(chan models.SSEEvent, 3)
+		events <- models.SSEEvent{Type: "start", ID: "q-1"}
+		events <- models.SSEEvent{Type: "chunk", Content: "hello"}
+		events <- models.SSEEvent{Type: "end", ID: "q-1"}
+		close(events)
+
+		core := mocks.NewMockCoreService()
+		core.On("Query", mock.Anything, models.CoreQueryRequest{Query: "what?", TopK: gateway.DefaultTopK}).Return((<-chan models.SSEEvent)(events), nil)
+		client := newTestClient(t, &gateway.Service{CoreClient: core, Logger: zerolog.Nop()})
+
+		stream, err := client.Query(userContext(), &kbgatewayv1.QueryRequest{Query: "what?"})
+		require.NoError(t, err)
+
+		var types []string
+		for {
+			event, err := stream.Recv()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			types = append(types, event.GetType())
+		}
+		assert.Equal(t, []string{"start", "chunk", "end"}, types)
+	})
+
+	t.Run("Query_EmptyQuery", func(t *testing.T) {
+		client := newTestClient(t, &gateway.Service{Logger: zerolog.Nop()})
+
+		stream, err := client.Query(userContext(), &kbgatewayv1.QueryRequest{})
+		require.NoError(t, err)
+		_, err = stream.Recv()
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
@@ -20,9 +20,320 @@ type Document struct {
 	FileSize     int64             `json:"file_size"`
 	Status       string            `json:"status"`
 	ErrorMessage string            `json:"error_message,omitempty"`
+	UploadedBy   string            `json:"uploaded_by,omitempty"`
 	CreatedAt    time.Time         `json:"created_at"`
 	IndexedAt    *time.Time        `json:"indexed_at,omitempty"`
 	Metadata     map[string]string `json:"metadata,omitempty"`
+	// Tags are lowercase labels grouping the document, in order.
+	Tags []string `json:"tags,omitempty"`
+	// Language is the language the indexer detected, as a lowercase
+	// BCP 47 tag such as "en" or "pt-br".
+	Language string `json:"language,omitempty"`
+	// Title is the title the indexer extracted from the document, or the
+	// one a text document was created with.
+	Title string `json:"title,omitempty"`
+	// ParentID is the archive a document was expanded from.
+	ParentID string `json:"parent_id,omitempty"`
+	// Children is the indexing progress of an archive's files.
+	Children *ChildProgress `json:"children,omitempty"`
+	// UploadURLIssuedAt and UploadURLExpiresAt bound the latest presigned
+	// upload URL handed out for the document. Completing the upload after
+	// it expired is refused.
+	UploadURLIssuedAt  *time.Time `json:"upload_url_issued_at,omitempty"`
+	UploadURLExpiresAt *time.Time `json:"upload_url_expires_at,omitempty"`
+	// Version counts edits to the metadata. An update must name the
+	// version it was based on, so concurrent edits are not lost.
+	Version int `json:"version"`
+	// DeletedAt is when the document was moved to the trash. Trashed
+	// documents are left out of listings and purged once the trash
+	// retention has passed.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// Chunking overrides how the indexer splits the document into chunks.
+	// Nil uses the indexer's defaults.
+	Chunking *ChunkingOptions `json:"chunking,omitempty"`
+	// Processing overrides how the indexer extracts the document's text.
+	// Nil uses the indexer's defaults.
+	Processing *ProcessingOptions `json:"processing,omitempty"`
+	// ContentHash is the hex SHA-256 of the document's file, if the
+	// uploader sent it or the gateway computed it.
+	ContentHash string `json:"content_hash,omitempty"`
+	// WorkflowID is the Temporal workflow last started to index the
+	// document.
+	WorkflowID string `json:"workflow_id,omitempty"`
+	// Scan is the result of the malware scan of the document's file, if
+	// uploads are scanned.
+	Scan *DocumentScan `json:"scan,omitempty"`
+	// AccessGroups are the lowercase access groups the document is
+	// restricted to, sorted. Queries only retrieve its chunks for their
+	// members and admins; empty leaves it to everyone.
+	AccessGroups []string `json:"access_groups,omitempty"`
+}
+
+// Malware scan results.
+const (
+	ScanClean    = "clean"
+	ScanInfected = "infected"
+)
+
+// DocumentScan is the result of scanning a document's file for malware.
+type DocumentScan struct {
+	Result string `json:"result"`
+	// Signature names the malware found in an infected file.
+	Signature string    `json:"signature,omitempty"`
+	ScannedAt time.Time `json:"scanned_at"`
+}
+
+// Chunking strategies.
+const (
+	// ChunkingFixed splits text into chunks of Size tokens.
+	ChunkingFixed = "fixed"
+	// ChunkingSentence splits at sentence boundaries, up to Size tokens.
+	ChunkingSentence = "sentence"
+	// ChunkingTable keeps each table in one chunk and splits the prose
+	// around it at sentence boundaries, suiting table-heavy PDFs.
+	ChunkingTable = "table"
+)
+
+// ChunkingOptions tells the indexer how to chunk a document. Zero fields
+// use the indexer's defaults.
+type ChunkingOptions struct {
+	Strategy string `json:"strategy,omitempty"`
+	// Size is the largest chunk, in tokens.
+	Size int `json:"size,omitempty"`
+	// Overlap is how many tokens consecutive chunks share.
+	Overlap int `json:"overlap,omitempty"`
+}
+
+// ProcessingOptions tells the indexer how to extract a document's text.
+// Nil fields use the indexer's defaults.
+type ProcessingOptions struct {
+	// OCR turns optical character recognition of scanned pages and images
+	// on or off. By default the indexer OCRs pages without a text layer.
+	OCR *bool `json:"ocr,omitempty"`
+	// Languages are BCP 47 tags of the languages the document is written
+	// in, most likely first, to guide OCR and language detection.
+	Languages []string `json:"languages,omitempty"`
+	// ExtractTables turns extracting tables as structured text on or off.
+	ExtractTables *bool `json:"extract_tables,omitempty"`
+}
+
+// UploadOptions are the metadata and indexing options an upload can set,
+// the content type its upload URL requires, if any, and the SHA-256 of its
+// file, if known.
+type UploadOptions struct {
+	Metadata    map[string]string
+	Chunking    *ChunkingOptions
+	Processing  *ProcessingOptions
+	ContentType string
+	ContentHash string
+}
+
+// CompleteUploadRequest completes an upload, replacing the document's
+// processing options if Processing is set.
+type CompleteUploadRequest struct {
+	Processing *ProcessingOptions `json:"processing,omitempty"`
+}
+
+// BatchUploadFile is one file of a batch upload. ContentType, if set, is
+// required by its upload URL. ContentHash, if set, is the hex SHA-256 of
+// the file.
+type BatchUploadFile struct {
+	Filename    string            `json:"filename"`
+	ContentType string            `json:"content_type,omitempty"`
+	Size        int64             `json:"size,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	ContentHash string            `json:"content_hash,omitempty"`
+}
+
+// BatchUploadRequest registers several uploads at once, all with the same
+// indexing options.
+type BatchUploadRequest struct {
+	Files      []BatchUploadFile  `json:"files" binding:"required"`
+	Chunking   *ChunkingOptions   `json:"chunking,omitempty"`
+	Processing *ProcessingOptions `json:"processing,omitempty"`
+}
+
+// BatchUploadResult is the outcome of one file of a batch upload: the
+// pending document with its upload URL, or the error that refused it.
+type BatchUploadResult struct {
+	Filename string       `json:"filename"`
+	Document *Document    `json:"document,omitempty"`
+	Error    *ErrorDetail `json:"error,omitempty"`
+}
+
+// BatchCompleteRequest completes several uploads at once.
+type BatchCompleteRequest struct {
+	DocumentIDs []string `json:"document_ids" binding:"required"`
+}
+
+// BatchCompleteResult is the outcome of completing one upload of a batch:
+// the document now indexing, or the error that refused it.
+type BatchCompleteResult struct {
+	DocumentID string       `json:"document_id"`
+	Document   *Document    `json:"document,omitempty"`
+	Error      *ErrorDetail `json:"error,omitempty"`
+}
+
+// BatchUploadResponse lists the results of a batch upload in the order of
+// its files.
+type BatchUploadResponse struct {
+	Results []BatchUploadResult `json:"results"`
+}
+
+// BatchCompleteResponse lists the results of a batch complete in the order
+// of its document IDs.
+type BatchCompleteResponse struct {
+	Results []BatchCompleteResult `json:"results"`
+}
+
+// BatchDeleteRequest deletes several documents at once: those listed in
+// DocumentIDs, or those with Status.
+type BatchDeleteRequest struct {
+	DocumentIDs []string `json:"document_ids,omitempty"`
+	Status      string   `json:"status,omitempty"`
+}
+
+// BatchDeleteResult is the outcome of deleting one document of a batch:
+// the error that stopped it, if any.
+type BatchDeleteResult struct {
+	DocumentID string       `json:"document_id"`
+	Filename   string       `json:"filename,omitempty"`
+	Error      *ErrorDetail `json:"error,omitempty"`
+}
+
+// BatchDeleteResponse lists the results of a batch delete, in the order of
+// its document IDs or, for a delete by status, newest first. Remaining is
+// how many documents with the status are left to delete.
+type BatchDeleteResponse struct {
+	Results   []BatchDeleteResult `json:"results"`
+	Deleted   int                 `json:"deleted"`
+	Failed    int                 `json:"failed"`
+	Remaining int                 `json:"remaining"`
+}
+
+// MultipartUpload is an S3 multipart upload of a document's file, for
+// files too large for one presigned PUT. The file is split into PartCount
+// parts of PartSize bytes, the last one shorter. Parts lists those
+// uploaded so far, so an interrupted upload can resume.
+type MultipartUpload struct {
+	DocumentID  string                `json:"document_id"`
+	UploadID    string                `json:"-"`
+	PartSize    int64                 `json:"part_size"`
+	PartCount   int                   `json:"part_count"`
+	Parts       []MultipartUploadPart `json:"parts"`
+	CreatedAt   time.Time             `json:"created_at"`
+	CompletedAt *time.Time            `json:"completed_at,omitempty"`
+}
+
+// MultipartUploadPart is an uploaded part, with the ETag S3 returned for
+// it.
+type MultipartUploadPart struct {
+	PartNumber int       `json:"part_number"`
+	ETag       string    `json:"etag"`
+	UploadedAt time.Time `json:"uploaded_at"`
+}
+
+// CreateMultipartUploadRequest starts a multipart upload of a file of
+// FileSize bytes. PartSize defaults to 64 MiB, or larger if the file needs
+// more than 10,000 parts.
+type CreateMultipartUploadRequest struct {
+	FileSize int64 `json:"file_size" binding:"required,gt=0"`
+	PartSize int64 `json:"part_size,omitempty"`
+}
+
+// MultipartPartURLsRequest asks for upload URLs for some parts of a
+// multipart upload.
+type MultipartPartURLsRequest struct {
+	PartNumbers []int `json:"part_numbers" binding:"required"`
+}
+
+// MultipartPartURL is a presigned URL to PUT one part to.
+type MultipartPartURL struct {
+	PartNumber int    `json:"part_number"`
+	URL        string `json:"url"`
+}
+
+// MultipartPartURLsResponse lists part upload URLs, all valid until
+// ExpiresAt.
+type MultipartPartURLsResponse struct {
+	Parts     []MultipartPartURL `json:"parts"`
+	ExpiresAt time.Time          `json:"expires_at"`
+}
+
+// RecordMultipartPartRequest records the ETag S3 returned for an uploaded
+// part.
+type RecordMultipartPartRequest struct {
+	ETag string `json:"etag" binding:"required"`
+}
+
+// BulkTagRequest adds Tags to, or removes them from, each of DocumentIDs.
+type BulkTagRequest struct {
+	DocumentIDs []string `json:"document_ids" binding:"required"`
+	Tags        []string `json:"tags" binding:"required"`
+}
+
+// SetAccessGroupsRequest replaces the access groups a document is
+// restricted to. An empty list lifts the restriction.
+type SetAccessGroupsRequest struct {
+	AccessGroups []string `json:"access_groups" binding:"required"`
+}
+
+// RenameTagRequest renames the tag From to To on every document. If To is
+// already in use the two tags are merged.
+type RenameTagRequest struct {
+	From string `json:"from" binding:"required"`
+	To   string `json:"to" binding:"required"`
+}
+
+// TagUpdateResponse reports how many documents a tag operation changed.
+// Their vectors' payloads are updated in the background.
+type TagUpdateResponse struct {
+	Updated int `json:"updated"`
+}
+
+// TagCount is a tag and the number of documents outside the trash that
+// carry it.
+type TagCount struct {
+	Tag       string `json:"tag"`
+	Documents int    `json:"documents"`
+}
+
+type TagListResponse struct {
+	Tags []TagCount `json:"tags"`
+}
+
+// CancelIndexingRequest cancels a document's indexing, deleting the
+// vectors already written if DeleteVectors is set.
+type CancelIndexingRequest struct {
+	DeleteVectors bool `json:"delete_vectors"`
+}
+
+// ReviewDocumentRequest approves or rejects a document awaiting review.
+// A rejection's Comment becomes the document's error message.
+type ReviewDocumentRequest struct {
+	Comment string `json:"comment" binding:"max=1000"`
+}
+
+// ReindexDocumentRequest re-indexes a document, replacing its chunking
+// options if Chunking is set.
+type ReindexDocumentRequest struct {
+	Chunking *ChunkingOptions `json:"chunking,omitempty"`
+}
+
+// ChildProgress counts the files expanded from an archive by status.
+type ChildProgress struct {
+	Total     int `json:"total"`
+	Pending   int `json:"pending"`
+	Indexing  int `json:"indexing"`
+	Complete  int `json:"complete"`
+	Failed    int `json:"failed"`
+	Cancelled int `json:"cancelled"`
+}
+
+// ArchiveEntryRequest registers a file expanded from an archive.
+type ArchiveEntryRequest struct {
+	Filename string `json:"filename" binding:"required"`
+	FileSize int64  `json:"file_size"`
 }
 
 type DocumentListResponse struct {
@@ -32,11 +343,91 @@ type DocumentListResponse struct {
 	Offset    int        `json:"offset"`
 }
 
+// DocumentFilter selects documents. Empty fields match every document.
+type DocumentFilter struct {
+	Status   string `json:"status,omitempty"`
+	Language string `json:"language,omitempty"`
+	// Metadata matches documents whose metadata has each key with its
+	// value.
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// Tag matches documents carrying it.
+	Tag string `json:"tag,omitempty"`
+	// CollectionID matches documents in the collection.
+	CollectionID string `json:"collection_id,omitempty"`
+	// Query matches documents whose filename contains it, ignoring case.
+	Query string `json:"query,omitempty"`
+	// Filename matches documents whose filename contains it, ignoring
+	// case, with * matching any run of characters.
+	Filename string `json:"filename,omitempty"`
+	// Text matches documents whose title or filename contain its words,
+	// as a web search query: "quoted phrases", or and -excluded words.
+	Text string `json:"text,omitempty"`
+	// CreatedFrom, CreatedTo, IndexedFrom and IndexedTo bound when
+	// matching documents were created and indexed, each range including
+	// its start and excluding its end.
+	CreatedFrom *time.Time `json:"created_from,omitempty"`
+	CreatedTo   *time.Time `json:"created_to,omitempty"`
+	IndexedFrom *time.Time `json:"indexed_from,omitempty"`
+	IndexedTo   *time.Time `json:"indexed_to,omitempty"`
+	// SortBy orders listed documents by one of the DocumentSort fields,
+	// relevance to Text if it is set and created_at otherwise, and Order
+	// is SortAsc or SortDesc. Order defaults to ascending for filenames
+	// and descending otherwise.
+	SortBy string `json:"sort_by,omitempty"`
+	Order  string `json:"order,omitempty"`
+}
+
+// Fields documents can be sorted by.
+const (
+	DocumentSortCreatedAt = "created_at"
+	DocumentSortIndexedAt = "indexed_at"
+	DocumentSortFilename  = "filename"
+	DocumentSortFileSize  = "file_size"
+	// DocumentSortRelevance ranks documents by how well their title and
+	// filename match the filter's Text.
+	DocumentSortRelevance = "relevance"
+)
+
+// Sort orders.
+const (
+	SortAsc  = "asc"
+	SortDesc = "desc"
+)
+
+// Text document formats.
+const (
+	TextFormatPlain    = "text"
+	TextFormatMarkdown = "markdown"
+)
+
+// CreateTextDocumentRequest ingests pasted text, such as meeting notes,
+// without a file upload. Format defaults to markdown.
+type CreateTextDocumentRequest struct {
+	Title      string             `json:"title" binding:"required,max=255"`
+	Content    string             `json:"content" binding:"required"`
+	Format     string             `json:"format,omitempty"`
+	Metadata   map[string]string  `json:"metadata,omitempty"`
+	Chunking   *ChunkingOptions   `json:"chunking,omitempty"`
+	Processing *ProcessingOptions `json:"processing,omitempty"`
+}
+
+// UpdateDocumentRequest replaces a document's metadata, renames it, or
+// both; an omitted field is left unchanged. Version is the version the
+// edit was based on; it may instead be sent in If-Match.
+type UpdateDocumentRequest struct {
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Filename string            `json:"filename,omitempty"`
+	Version  *int              `json:"version,omitempty"`
+}
+
 type Conversation struct {
 	ID           string    `json:"id"`
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 	MessageCount int       `json:"message_count,omitempty"`
+	// CreatedBy is the user who created the conversation, who may share
+	// it with others.
+	CreatedBy string `json:"created_by,omitempty"`
 }
 
 type ConversationListResponse struct {
@@ -53,16 +444,147 @@ type Message struct {
 	Content        string            `json:"content"`
 	CreatedAt      time.Time         `json:"created_at"`
 	Metadata       map[string]string `json:"metadata,omitempty"`
+	// Sources are the passages an assistant message cites.
+	Sources []MessageSource `json:"sources,omitempty"`
+	// Redacted is set on an assistant message in which redaction rules
+	// replaced text.
+	Redacted bool `json:"redacted,omitempty"`
+}
+
+// MessageMetadataQueryID is the metadata entry in which the core records
+// the query an assistant message answers.
+const MessageMetadataQueryID = "query_id"
+
+// MessageMetadataCuratedAnswerID is the metadata entry naming the curated
+// answer an assistant message was served from.
+const MessageMetadataCuratedAnswerID = "curated_answer_id"
+
+// MessageSource is a passage cited by an assistant message, deep-linked
+// for preview. PreviewURL is a presigned download URL of the document,
+// pointing at the cited page of a PDF; it is empty once the document is
+// deleted.
+type MessageSource struct {
+	Citation
+	Filename   string `json:"filename,omitempty"`
+	PreviewURL string `json:"preview_url,omitempty"`
 }
 
 type MessageListResponse struct {
 	Messages []Message `json:"messages"`
 }
 
+// ConversationSummary is one version of the rolling summary of a long
+// conversation. It covers the conversation's first MessageCount messages.
+type ConversationSummary struct {
+	ConversationID string    `json:"conversation_id"`
+	Version        int       `json:"version"`
+	Summary        string    `json:"summary"`
+	MessageCount   int       `json:"message_count"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type ConversationSummaryListResponse struct {
+	Summaries []ConversationSummary `json:"summaries"`
+}
+
+// ConversationParticipant is a user taking part in a shared conversation,
+// with how far they have read it.
+type ConversationParticipant struct {
+	ConversationID string    `json:"conversation_id"`
+	Username       string    `json:"username"`
+	InvitedBy      string    `json:"invited_by,omitempty"`
+	JoinedAt       time.Time `json:"joined_at"`
+	// LastReadAt is when the participant last marked the conversation
+	// read; the messages since are unread.
+	LastReadAt  *time.Time `json:"last_read_at,omitempty"`
+	UnreadCount int        `json:"unread_count"`
+}
+
+type InviteParticipantRequest struct {
+	Username string `json:"username" binding:"required,max=255"`
+}
+
+// MarkConversationReadRequest marks a conversation read up to and
+// including MessageID, or entirely if it is empty.
+type MarkConversationReadRequest struct {
+	MessageID string `json:"message_id" binding:"omitempty,uuid"`
+}
+
+type ConversationParticipantListResponse struct {
+	Participants []ConversationParticipant `json:"participants"`
+}
+
+// ConversationContext is the history sent with a query in a long
+// conversation in place of the core loading every message: a summary of
+// the older messages and the newer ones verbatim.
+type ConversationContext struct {
+	Summary  string    `json:"summary"`
+	Messages []Message `json:"messages"`
+}
+
+// CoreSummaryRequest asks the core to fold messages into the summary of
+// the messages before them, if any.
+type CoreSummaryRequest struct {
+	Summary  string    `json:"summary,omitempty"`
+	Messages []Message `json:"messages"`
+}
+
+type CoreSummaryResponse struct {
+	Summary string `json:"summary"`
+}
+
 type QueryRequest struct {
 	Query          string `json:"query" binding:"required"`
 	ConversationID string `json:"conversation_id,omitempty"`
 	TopK           int    `json:"top_k,omitempty"`
+	// PromptTemplateID selects a stored prompt template instead of the
+	// core's default prompt; PromptTemplateVersion pins one of its
+	// versions (default: latest).
+	PromptTemplateID      string `json:"prompt_template_id,omitempty"`
+	PromptTemplateVersion int    `json:"prompt_template_version,omitempty" binding:"min=0"`
+	// Language restricts retrieval to documents detected in that language.
+	Language string `json:"language,omitempty"`
+	// MaxChunksPerDocument caps the chunks retrieved from any one
+	// document, so answers draw from several sources; 0 leaves retrieval
+	// uncapped.
+	MaxChunksPerDocument int `json:"max_chunks_per_document,omitempty"`
+	// Fresh always asks the core, even if a near-identical question was
+	// answered recently.
+	Fresh bool `json:"fresh,omitempty"`
+	// AsOf names a snapshot to answer from instead of the live knowledge
+	// base, so the answer can be reproduced later.
+	AsOf string `json:"as_of,omitempty"`
+	// CollectionID restricts retrieval to the documents of a collection.
+	CollectionID string `json:"collection_id,omitempty"`
+	// Collection restricts retrieval to a Qdrant collection. It is set by
+	// the gateway for demo guests and widget tokens, never by clients.
+	Collection string `json:"-"`
+}
+
+// CoreQueryRequest is the query forwarded to the Python core.
+type CoreQueryRequest struct {
+	Query          string `json:"query"`
+	ConversationID string `json:"conversation_id,omitempty"`
+	TopK           int    `json:"top_k,omitempty"`
+	// PromptTemplate replaces the core's default prompt.
+	PromptTemplate string `json:"prompt_template,omitempty"`
+	// Collection is the Qdrant collection to retrieve from; empty selects
+	// the core's default.
+	Collection string `json:"collection,omitempty"`
+	// Language restricts retrieval to documents in that language.
+	Language string `json:"language,omitempty"`
+	// MaxChunksPerDocument caps the chunks retrieved from one document.
+	MaxChunksPerDocument int `json:"max_chunks_per_document,omitempty"`
+	// CollectionID restricts retrieval to chunks whose collection_ids
+	// payload lists that collection.
+	CollectionID string `json:"collection_id,omitempty"`
+	// AccessGroups restricts retrieval to chunks without access groups or
+	// labelled with one of them. Null retrieves chunks regardless of their
+	// access groups, and an empty list only chunks without any.
+	AccessGroups []string `json:"access_groups"`
+	// Context replaces the conversation history the core would load, for
+	// long conversations.
+	Context *ConversationContext `json:"context,omitempty"`
 }
 
 type ConversationRequest struct {
@@ -90,15 +612,1616 @@ type HealthResponse struct {
 	Timestamp string `json:"timestamp"`
 }
 
+// Readiness and startup probe states.
+const (
+	ReadinessReady    = "ready"
+	ReadinessDegraded = "degraded"
+	ReadinessNotReady = "not_ready"
+	StartupStarted    = "started"
+	StartupStarting   = "starting"
+)
+
+// ReadinessResponse answers the readiness and startup probes, with the
+// status of each dependency ("ok" or the error).
 type ReadinessResponse struct {
 	Status       string            `json:"status"`
 	Dependencies map[string]string `json:"dependencies"`
 }
 
+// VersionResponse identifies the running gateway build.
+type VersionResponse struct {
+	Version   string   `json:"version"`
+	GitSHA    string   `json:"git_sha"`
+	BuildTime string   `json:"build_time"`
+	GoVersion string   `json:"go_version"`
+	Features  []string `json:"features"`
+}
+
+// Public status page states, of the gateway and of its components.
+const (
+	StatusOperational = "operational"
+	StatusDegraded    = "degraded"
+	StatusOutage      = "outage"
+	StatusUnknown     = "unknown"
+)
+
+// StatusResponse is the public status page. It is coarse on purpose: it
+// carries no error messages or other internals.
+type StatusResponse struct {
+	Status     string            `json:"status"`
+	Components []ComponentStatus `json:"components"`
+	// RecentErrorSpike is set if the share of failed requests reached the
+	// alert threshold within the incident window.
+	RecentErrorSpike bool `json:"recent_error_spike"`
+	// CheckedAt is when the components were last checked.
+	CheckedAt *time.Time `json:"checked_at,omitempty"`
+}
+
+type ComponentStatus struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	// RecentIncident is set if the component was down within the incident
+	// window.
+	RecentIncident bool `json:"recent_incident"`
+}
+
 type SSEEvent struct {
 	Type    string `json:"type"`
 	ID      string `json:"id,omitempty"`
 	Content string `json:"content,omitempty"`
 	Code    string `json:"code,omitempty"`
 	Message string `json:"message,omitempty"`
+	// Tokens is the token usage the core reports on the end event.
+	Tokens int `json:"tokens,omitempty"`
+	// DocumentIDs are the documents the answer was retrieved from,
+	// reported on the end event.
+	DocumentIDs []string `json:"document_ids,omitempty"`
+	// Sources are the chunks cited in the answer, reported on sources
+	// events.
+	Sources []Citation `json:"sources,omitempty"`
+	// Highlights locate terms in the answer, as offsets from its start,
+	// on chunk events and, for terms that end the answer, the end event.
+	Highlights []Highlight `json:"highlights,omitempty"`
+	// PreviouslyAnswered is set on the end event of an answer reused from
+	// AnsweredQueryID, an earlier query with a near-identical question.
+	PreviouslyAnswered bool   `json:"previously_answered,omitempty"`
+	AnsweredQueryID    string `json:"answered_query_id,omitempty"`
+	// Curated is set on the end event of an answer served from
+	// CuratedAnswerID instead of the core.
+	Curated         bool   `json:"curated,omitempty"`
+	CuratedAnswerID string `json:"curated_answer_id,omitempty"`
+	// Redacted is set on the end event of an answer in which redaction
+	// rules replaced text.
+	Redacted bool `json:"redacted,omitempty"`
+}
+
+// AnsweredQuestion is the answer to a completed query, kept to answer
+// near-identical questions asked in the same scope without the core.
+type AnsweredQuestion struct {
+	QueryID string `json:"query_id"`
+	// Scope is the collection, language and prompt template the answer
+	// was retrieved with.
+	Scope       string    `json:"scope"`
+	Question    string    `json:"question"`
+	Answer      string    `json:"answer"`
+	DocumentIDs []string  `json:"document_ids,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Citation is a document chunk cited in an answer, with its retrieval
+// score.
+type Citation struct {
+	DocumentID string  `json:"document_id"`
+	ChunkID    string  `json:"chunk_id,omitempty"`
+	Score      float64 `json:"score"`
+	// Page is the 1-based page the chunk starts on, for paged formats.
+	Page int `json:"page,omitempty"`
+	// StartChar and EndChar are the chunk's character offsets in the
+	// document's extracted text.
+	StartChar int `json:"start_char,omitempty"`
+	EndChar   int `json:"end_char,omitempty"`
+	// Text is the chunk's text, if the core sends it. It is not stored.
+	Text string `json:"text,omitempty"`
+	// Highlights locate terms in Text.
+	Highlights []Highlight `json:"highlights,omitempty"`
+}
+
+// HighlightKindGlossary marks highlights the gateway found from the
+// glossary. The core may report other kinds, such as entities.
+const HighlightKindGlossary = "glossary"
+
+// Highlight locates a term in a text, so clients can highlight it without
+// tokenizing the text themselves. Start and End are character (Unicode
+// code point) offsets, End exclusive.
+type Highlight struct {
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	Term  string `json:"term"`
+	Kind  string `json:"kind,omitempty"`
+	// Definition is the glossary's definition of Term, if any.
+	Definition string `json:"definition,omitempty"`
+}
+
+// Webhook event types.
+const (
+	EventDocumentIndexed     = "document.indexed"
+	EventDocumentFailed      = "document.failed"
+	EventConversationCreated = "conversation.created"
+	EventQueryCompleted      = "query.completed"
+	// EventDocumentReviewRequested is published when an upload starts
+	// waiting for a reviewer.
+	EventDocumentReviewRequested = "document.review_requested"
+	// EventDocumentInfected is published when an upload is rejected for
+	// failing the malware scan.
+	EventDocumentInfected = "document.infected"
+)
+
+// WebhookEventTypes lists the events a webhook may subscribe to.
+var WebhookEventTypes = []string{
+	EventDocumentIndexed,
+	EventDocumentFailed,
+	EventConversationCreated,
+	EventQueryCompleted,
+	EventDocumentReviewRequested,
+	EventDocumentInfected,
+}
+
+type Webhook struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"`
+	Events    []string  `json:"events"`
+	Active    bool      `json:"active"`
+	CreatedBy string    `json:"created_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type CreateWebhookRequest struct {
+	URL    string   `json:"url" binding:"required,url"`
+	Secret string   `json:"secret" binding:"required,min=16"`
+	Events []string `json:"events" binding:"required,min=1"`
+}
+
+type WebhookListResponse struct {
+	Webhooks []Webhook `json:"webhooks"`
+}
+
+// WebhookEvent is the signed JSON body delivered to webhook endpoints.
+type WebhookEvent struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// Webhook delivery statuses.
+const (
+	DeliveryStatusPending   = "pending"
+	DeliveryStatusSucceeded = "succeeded"
+	DeliveryStatusFailed    = "failed"
+)
+
+type WebhookDelivery struct {
+	ID           string     `json:"id"`
+	WebhookID    string     `json:"webhook_id"`
+	EventID      string     `json:"event_id"`
+	EventType    string     `json:"event_type"`
+	Payload      string     `json:"payload"`
+	Status       string     `json:"status"`
+	Attempts     int        `json:"attempts"`
+	ResponseCode int        `json:"response_code,omitempty"`
+	LastError    string     `json:"last_error,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	DeliveredAt  *time.Time `json:"delivered_at,omitempty"`
+}
+
+type WebhookDeliveryListResponse struct {
+	Deliveries []WebhookDelivery `json:"deliveries"`
+	Total      int               `json:"total"`
+	Limit      int               `json:"limit"`
+	Offset     int               `json:"offset"`
+}
+
+// Conversation events streamed to a conversation's participants. They are
+// raised by the gateway itself and are neither stored nor sent to
+// webhooks.
+const (
+	// EventConversationMessage carries a question asked in the
+	// conversation and its answer.
+	EventConversationMessage            = "conversation.message"
+	EventConversationParticipantAdded   = "conversation.participant_added"
+	EventConversationParticipantRemoved = "conversation.participant_removed"
+	// EventSourceGateway is the source of the events the gateway raises.
+	EventSourceGateway = "gateway"
+)
+
+// Event sources allowed on the internal ingestion endpoint.
+const (
+	EventSourcePythonCore = "python-core"
+	EventSourceTemporal   = "temporal"
+)
+
+// Ingested event types.
+const (
+	EventDocumentIndexing = "document.indexing"
+	// Pipeline stages the core reports for the document timeline.
+	EventDocumentScanned  = "document.scanned"
+	EventDocumentChunked  = "document.chunked"
+	EventDocumentEmbedded = "document.embedded"
+
+	// Re-indexing of a document into the target collection of an embedding
+	// migration. The event data carries the migration_id.
+	EventDocumentReindexed     = "document.reindexed"
+	EventDocumentReindexFailed = "document.reindex_failed"
+
+	// End of the expansion of an archive into child documents. The event
+	// data carries the file_count.
+	EventDocumentExpanded = "document.expanded"
+
+	// End of a connector sync workflow. The subject is the connector.
+	EventConnectorSynced     = "connector.synced"
+	EventConnectorSyncFailed = "connector.sync_failed"
+
+	// End of a snapshot workflow. The subject is the snapshot.
+	EventSnapshotCreated = "snapshot.created"
+	EventSnapshotFailed  = "snapshot.failed"
+
+	// Progress and end of a knowledge base export workflow. The subject is
+	// the export; progress events carry the documents_exported so far.
+	EventExportProgress  = "export.progress"
+	EventExportCompleted = "export.completed"
+	EventExportFailed    = "export.failed"
+)
+
+// Event is a typed event reported by the Python core or a Temporal worker.
+type Event struct {
+	ID         string                 `json:"id"`
+	Type       string                 `json:"type"`
+	Source     string                 `json:"source"`
+	SubjectID  string                 `json:"subject_id"`
+	OccurredAt time.Time              `json:"occurred_at"`
+	ReceivedAt time.Time              `json:"received_at"`
+	Data       map[string]interface{} `json:"data,omitempty"`
+}
+
+type IngestEventRequest struct {
+	ID         string                 `json:"id,omitempty" binding:"omitempty,uuid"`
+	Type       string                 `json:"type" binding:"required"`
+	Source     string                 `json:"source" binding:"required,oneof=python-core temporal"`
+	SubjectID  string                 `json:"subject_id" binding:"required"`
+	OccurredAt time.Time              `json:"occurred_at"`
+	Data       map[string]interface{} `json:"data,omitempty"`
+}
+
+// DocumentStats summarizes the documents table.
+type DocumentStats struct {
+	Total        int            `json:"total"`
+	ByStatus     map[string]int `json:"by_status"`
+	StorageBytes int64          `json:"storage_bytes"`
+}
+
+// DailyCount is a count for one UTC day, formatted as YYYY-MM-DD.
+type DailyCount struct {
+	Date  string `json:"date"`
+	Count int    `json:"count"`
+}
+
+// TrashPurge records one purge of expired documents from the trash.
+type TrashPurge struct {
+	ID         string    `json:"id"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	// Purged counts the documents deleted for good.
+	Purged int `json:"purged"`
+	// Failed counts the documents whose S3 object or vectors could not be
+	// deleted; they stay in the trash for the next purge.
+	Failed         int   `json:"failed"`
+	ReclaimedBytes int64 `json:"reclaimed_bytes"`
+}
+
+// TrashStats summarizes the trash and the storage its purges reclaimed.
+type TrashStats struct {
+	Documents int   `json:"documents"`
+	Bytes     int64 `json:"bytes"`
+	// ReclaimedBytes totals the bytes of every purged document.
+	ReclaimedBytes int64       `json:"reclaimed_bytes"`
+	LastPurge      *TrashPurge `json:"last_purge,omitempty"`
+}
+
+type AdminStatsResponse struct {
+	Documents DocumentStats `json:"documents"`
+	Trash     TrashStats    `json:"trash"`
+	// VectorsCount is null when Qdrant could not be reached.
+	VectorsCount        *uint64           `json:"vectors_count"`
+	QueriesPerDay       []DailyCount      `json:"queries_per_day"`
+	ActiveConversations int               `json:"active_conversations"`
+	Dependencies        map[string]string `json:"dependencies"`
+	GeneratedAt         time.Time         `json:"generated_at"`
+}
+
+// Query log statuses.
+const (
+	QueryStatusCompleted = "completed"
+	QueryStatusFailed    = "failed"
+	QueryStatusCancelled = "cancelled"
+)
+
+// QueryLog records a query made through the gateway.
+type QueryLog struct {
+	ID             string `json:"id"`
+	Username       string `json:"username"`
+	ConversationID string `json:"conversation_id,omitempty"`
+	Question       string `json:"question"`
+	Status         string `json:"status"`
+	LatencyMS      int64  `json:"latency_ms"`
+	// Tokens is nil when the core did not report usage.
+	Tokens *int `json:"tokens,omitempty"`
+	// Feedback is 1 (helpful) or -1 (not helpful), nil until rated.
+	Feedback        *int      `json:"feedback,omitempty"`
+	FeedbackComment string    `json:"feedback_comment,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+	// DocumentIDs are the documents retrieved for the answer.
+	DocumentIDs []string `json:"document_ids,omitempty"`
+	// Citations are the chunks cited in the answer.
+	Citations []Citation `json:"citations,omitempty"`
+}
+
+// Query suggestion sources.
+const (
+	SuggestionSourceQuery    = "query"
+	SuggestionSourceDocument = "document"
+)
+
+// QuerySuggestion completes what a user is typing into the search box,
+// from a popular past question or a document title.
+type QuerySuggestion struct {
+	Text   string `json:"text"`
+	Source string `json:"source"`
+	// DocumentID is set for document titles.
+	DocumentID string `json:"document_id,omitempty"`
+}
+
+type QuerySuggestionListResponse struct {
+	Suggestions []QuerySuggestion `json:"suggestions"`
+}
+
+type QueryFeedbackRequest struct {
+	Rating  int    `json:"rating" binding:"required,oneof=-1 1"`
+	Comment string `json:"comment,omitempty" binding:"max=2000"`
+}
+
+// Query log export formats and destinations.
+const (
+	ExportFormatCSV     = "csv"
+	ExportFormatParquet = "parquet"
+
+	ExportDestinationResponse = "response"
+	ExportDestinationS3       = "s3"
+)
+
+// ExportResponse describes an export written to S3.
+type ExportResponse struct {
+	Key       string    `json:"key"`
+	URL       string    `json:"url"`
+	Format    string    `json:"format"`
+	Rows      int       `json:"rows"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Access review subject types.
+const (
+	AccessSubjectUser         = "user"
+	AccessSubjectServiceToken = "service_token"
+	AccessSubjectConnector    = "connector"
+)
+
+// AccessGrant is one row of an access review: a subject, the access it
+// has and to what. Users authenticate upstream, so every user shares one
+// grant and only admins are listed by name.
+type AccessGrant struct {
+	SubjectType string
+	Subject     string
+	Role        string
+	// Resource is "*" for the whole knowledge base, or the external
+	// folders a connector reads.
+	Resource   string
+	Scopes     []string
+	GrantedBy  string
+	GrantedAt  *time.Time
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	Status     string
+}
+
+// ExportFormatPDF is the format of answer exports.
+const ExportFormatPDF = "pdf"
+
+// MessageExportResponse describes an answer exported to S3.
+type MessageExportResponse struct {
+	Key       string    `json:"key"`
+	URL       string    `json:"url"`
+	Format    string    `json:"format"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Notification kinds users can opt in to.
+const (
+	NotificationImportFinished = "import_finished"
+	NotificationIndexingFailed = "indexing_failed"
+	NotificationExportReady    = "export_ready"
+)
+
+// NotificationPreferences are a user's email notification settings.
+// Nothing is sent while Email is empty.
+type NotificationPreferences struct {
+	Username       string    `json:"username"`
+	Email          string    `json:"email"`
+	ImportFinished bool      `json:"import_finished"`
+	IndexingFailed bool      `json:"indexing_failed"`
+	ExportReady    bool      `json:"export_ready"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// DefaultNotificationPreferences are the settings of a user who has not
+// saved any: every kind enabled, but no address to send to.
+func DefaultNotificationPreferences(username string) *NotificationPreferences {
+	return &NotificationPreferences{
+		Username:       username,
+		ImportFinished: true,
+		IndexingFailed: true,
+		ExportReady:    true,
+	}
+}
+
+// Enabled reports whether the user wants notifications of kind.
+func (p *NotificationPreferences) Enabled(kind string) bool {
+	if p.Email == "" {
+		return false
+	}
+	switch kind {
+	case NotificationImportFinished:
+		return p.ImportFinished
+	case NotificationIndexingFailed:
+		return p.IndexingFailed
+	case NotificationExportReady:
+		return p.ExportReady
+	}
+	return false
+}
+
+// UpdateNotificationPreferencesRequest changes the fields that are set.
+type UpdateNotificationPreferencesRequest struct {
+	Email          *string `json:"email" binding:"omitempty,email"`
+	ImportFinished *bool   `json:"import_finished"`
+	IndexingFailed *bool   `json:"indexing_failed"`
+	ExportReady    *bool   `json:"export_ready"`
+}
+
+// WorkspaceSettings are the knowledge base's branding and defaults, read by
+// the frontend when it loads. AllowedFileTypes are lowercase file
+// extensions without the dot; when set, only they can be uploaded.
+// RetentionDays, when set, is how long trashed documents are kept.
+// MetadataSchema declares the metadata keys documents must or may carry.
+type WorkspaceSettings struct {
+	Name             string          `json:"name"`
+	LogoURL          string          `json:"logo_url"`
+	DefaultLanguage  string          `json:"default_language"`
+	RetentionDays    int             `json:"retention_days"`
+	AllowedFileTypes []string        `json:"allowed_file_types"`
+	MetadataSchema   []MetadataField `json:"metadata_schema"`
+	UpdatedBy        string          `json:"updated_by,omitempty"`
+	UpdatedAt        *time.Time      `json:"updated_at,omitempty"`
+}
+
+// DefaultWorkspaceSettings are the settings before an admin saves any:
+// every file type allowed, any metadata, and the trash retention of the
+// configuration.
+func DefaultWorkspaceSettings() *WorkspaceSettings {
+	return &WorkspaceSettings{
+		Name:             "Knowledge Base",
+		AllowedFileTypes: []string{},
+		MetadataSchema:   []MetadataField{},
+	}
+}
+
+// Types of a metadata field. Metadata values are strings; the type says
+// how they must parse. Dates are YYYY-MM-DD.
+const (
+	MetadataTypeString  = "string"
+	MetadataTypeInteger = "integer"
+	MetadataTypeNumber  = "number"
+	MetadataTypeBoolean = "boolean"
+	MetadataTypeDate    = "date"
+)
+
+// MetadataField declares a document metadata key. Enum, when set, lists
+// the only values allowed. Keys the schema does not declare are allowed
+// with any value.
+type MetadataField struct {
+	Key      string   `json:"key"`
+	Type     string   `json:"type"`
+	Required bool     `json:"required,omitempty"`
+	Enum     []string `json:"enum,omitempty"`
+}
+
+// UpdateWorkspaceSettingsRequest changes the fields that are set. Empty
+// values restore the defaults.
+type UpdateWorkspaceSettingsRequest struct {
+	Name             *string          `json:"name" binding:"omitempty,max=100"`
+	LogoURL          *string          `json:"logo_url" binding:"omitempty,max=2048"`
+	DefaultLanguage  *string          `json:"default_language"`
+	RetentionDays    *int             `json:"retention_days" binding:"omitempty,min=0,max=3650"`
+	AllowedFileTypes *[]string        `json:"allowed_file_types"`
+	MetadataSchema   *[]MetadataField `json:"metadata_schema"`
+}
+
+// PromptTemplate is a named prompt that queries can use instead of the
+// core's default. Every update stores a new version; Version and Template
+// are those of the version returned.
+type PromptTemplate struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Version     int       `json:"version"`
+	Template    string    `json:"template"`
+	CreatedBy   string    `json:"created_by,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// PromptTemplateVersion is one revision of a prompt template.
+type PromptTemplateVersion struct {
+	Version   int       `json:"version"`
+	Template  string    `json:"template"`
+	CreatedBy string    `json:"created_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type CreatePromptTemplateRequest struct {
+	Name        string `json:"name" binding:"required,max=255"`
+	Description string `json:"description" binding:"max=1000"`
+	Template    string `json:"template" binding:"required,max=100000"`
+}
+
+// UpdatePromptTemplateRequest stores Template as a new version.
+type UpdatePromptTemplateRequest struct {
+	Description *string `json:"description" binding:"omitempty,max=1000"`
+	Template    string  `json:"template" binding:"required,max=100000"`
+}
+
+type PromptTemplateListResponse struct {
+	Templates []PromptTemplate `json:"templates"`
+	Total     int              `json:"total"`
+	Limit     int              `json:"limit"`
+	Offset    int              `json:"offset"`
+}
+
+// Curated answer match types.
+const (
+	// CuratedMatchExact matches questions equal to the pattern, ignoring
+	// case, punctuation and spacing.
+	CuratedMatchExact = "exact"
+	// CuratedMatchContains matches questions containing the pattern's
+	// words in order, ignoring case, punctuation and spacing.
+	CuratedMatchContains = "contains"
+	// CuratedMatchRegex matches questions against the pattern as a
+	// case-insensitive regular expression.
+	CuratedMatchRegex = "regex"
+)
+
+// CuratedAnswer is an answer pinned by an editor to questions matching
+// Pattern. Matching questions are answered with it instead of the core.
+// DocumentIDs are reported as the answer's sources.
+type CuratedAnswer struct {
+	ID          string    `json:"id"`
+	Pattern     string    `json:"pattern"`
+	MatchType   string    `json:"match_type"`
+	Answer      string    `json:"answer"`
+	DocumentIDs []string  `json:"document_ids,omitempty"`
+	Enabled     bool      `json:"enabled"`
+	CreatedBy   string    `json:"created_by,omitempty"`
+	UpdatedBy   string    `json:"updated_by,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// CreateCuratedAnswerRequest pins an answer. MatchType defaults to exact
+// and Enabled to true.
+type CreateCuratedAnswerRequest struct {
+	Pattern     string   `json:"pattern" binding:"required,max=1000"`
+	MatchType   string   `json:"match_type,omitempty"`
+	Answer      string   `json:"answer" binding:"required"`
+	DocumentIDs []string `json:"document_ids,omitempty"`
+	Enabled     *bool    `json:"enabled,omitempty"`
+}
+
+// UpdateCuratedAnswerRequest changes the set fields of a curated answer.
+type UpdateCuratedAnswerRequest struct {
+	Pattern     *string   `json:"pattern,omitempty" binding:"omitempty,max=1000"`
+	MatchType   *string   `json:"match_type,omitempty"`
+	Answer      *string   `json:"answer,omitempty"`
+	DocumentIDs *[]string `json:"document_ids,omitempty"`
+	Enabled     *bool     `json:"enabled,omitempty"`
+}
+
+type CuratedAnswerListResponse struct {
+	Answers []CuratedAnswer `json:"answers"`
+	Total   int             `json:"total"`
+	Limit   int             `json:"limit"`
+	Offset  int             `json:"offset"`
+}
+
+// Redaction rule types.
+const (
+	// RedactionRegex replaces matches of the rule's pattern, a regular
+	// expression.
+	RedactionRegex = "regex"
+	// RedactionKeywords replaces the rule's keywords wherever they appear
+	// as whole words, ignoring case.
+	RedactionKeywords = "keywords"
+)
+
+// DefaultRedactionPlaceholder replaces redacted text when a rule does not
+// name a placeholder.
+const DefaultRedactionPlaceholder = "[REDACTED]"
+
+// RedactionRule replaces the text it matches in answers with Placeholder
+// before they reach clients. A regex rule has a Pattern, a keywords rule
+// Keywords.
+type RedactionRule struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Type        string    `json:"type"`
+	Pattern     string    `json:"pattern,omitempty"`
+	Keywords    []string  `json:"keywords,omitempty"`
+	Placeholder string    `json:"placeholder"`
+	Enabled     bool      `json:"enabled"`
+	CreatedBy   string    `json:"created_by,omitempty"`
+	UpdatedBy   string    `json:"updated_by,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// CreateRedactionRuleRequest adds a redaction rule. Placeholder defaults
+// to DefaultRedactionPlaceholder and Enabled to true.
+type CreateRedactionRuleRequest struct {
+	Name        string   `json:"name" binding:"required,max=200"`
+	Type        string   `json:"type" binding:"required"`
+	Pattern     string   `json:"pattern,omitempty" binding:"max=1000"`
+	Keywords    []string `json:"keywords,omitempty" binding:"max=500,dive,max=200"`
+	Placeholder string   `json:"placeholder,omitempty" binding:"max=100"`
+	Enabled     *bool    `json:"enabled,omitempty"`
+}
+
+// UpdateRedactionRuleRequest changes the set fields of a redaction rule.
+type UpdateRedactionRuleRequest struct {
+	Name        *string   `json:"name,omitempty" binding:"omitempty,min=1,max=200"`
+	Type        *string   `json:"type,omitempty"`
+	Pattern     *string   `json:"pattern,omitempty" binding:"omitempty,max=1000"`
+	Keywords    *[]string `json:"keywords,omitempty" binding:"omitempty,max=500,dive,max=200"`
+	Placeholder *string   `json:"placeholder,omitempty" binding:"omitempty,max=100"`
+	Enabled     *bool     `json:"enabled,omitempty"`
+}
+
+type RedactionRuleListResponse struct {
+	Rules  []RedactionRule `json:"rules"`
+	Total  int             `json:"total"`
+	Limit  int             `json:"limit"`
+	Offset int             `json:"offset"`
+}
+
+// GlossaryTerm is a term highlighted wherever it appears in answers.
+// Terms are unique regardless of case.
+type GlossaryTerm struct {
+	ID         string    `json:"id"`
+	Term       string    `json:"term"`
+	Definition string    `json:"definition,omitempty"`
+	CreatedBy  string    `json:"created_by,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type CreateGlossaryTermRequest struct {
+	Term       string `json:"term" binding:"required,max=200"`
+	Definition string `json:"definition,omitempty" binding:"max=2000"`
+}
+
+// UpdateGlossaryTermRequest replaces a term's definition. To rename a
+// term, delete it and add the new one.
+type UpdateGlossaryTermRequest struct {
+	Definition string `json:"definition" binding:"max=2000"`
+}
+
+type GlossaryTermListResponse struct {
+	Terms  []GlossaryTerm `json:"terms"`
+	Total  int            `json:"total"`
+	Limit  int            `json:"limit"`
+	Offset int            `json:"offset"`
+}
+
+// SavedSearch is a named document filter, or smart folder. Saved searches
+// are shared: every user can list and run them, but only their creator
+// can change them.
+type SavedSearch struct {
+	ID        string         `json:"id"`
+	Name      string         `json:"name"`
+	Filter    DocumentFilter `json:"filter"`
+	CreatedBy string         `json:"created_by,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+// SavedSearchRequest creates a saved search or replaces its name and
+// filter.
+type SavedSearchRequest struct {
+	Name   string         `json:"name" binding:"required,max=200"`
+	Filter DocumentFilter `json:"filter"`
+}
+
+type SavedSearchListResponse struct {
+	SavedSearches []SavedSearch `json:"saved_searches"`
+	Total         int           `json:"total"`
+	Limit         int           `json:"limit"`
+	Offset        int           `json:"offset"`
+}
+
+// Collection is a named set of documents, or folder, which queries can be
+// restricted to. It holds no vectors, unlike a Qdrant collection, and a
+// document may be in any number of collections. Collections are shared
+// like saved searches: every user can list and query them, but only their
+// creator can change them.
+type Collection struct {
+	ID            string    `json:"id"`
+	Name          string    `json:"name"`
+	Description   string    `json:"description,omitempty"`
+	DocumentCount int       `json:"document_count"`
+	CreatedBy     string    `json:"created_by,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type CreateCollectionRequest struct {
+	Name        string `json:"name" binding:"required,max=200"`
+	Description string `json:"description,omitempty" binding:"max=1000"`
+}
+
+// CollectionDocumentsRequest adds DocumentIDs to, or removes them from, a
+// collection.
+type CollectionDocumentsRequest struct {
+	DocumentIDs []string `json:"document_ids" binding:"required"`
+}
+
+// CollectionUpdateResponse reports how many documents were added to or
+// removed from a collection.
+type CollectionUpdateResponse struct {
+	Updated int `json:"updated"`
+}
+
+type CollectionListResponse struct {
+	Collections []Collection `json:"collections"`
+	Total       int          `json:"total"`
+	Limit       int          `json:"limit"`
+	Offset      int          `json:"offset"`
+}
+
+type PromptTemplateVersionListResponse struct {
+	Versions []PromptTemplateVersion `json:"versions"`
+}
+
+// Embedding migration statuses.
+const (
+	MigrationStatusRunning   = "running"
+	MigrationStatusCompleted = "completed"
+	MigrationStatusFailed    = "failed"
+)
+
+// EmbeddingMigration re-indexes the knowledge base into a new Qdrant
+// collection with a different embedding model. Queries switch to
+// TargetCollection once every document has been re-indexed.
+type EmbeddingMigration struct {
+	ID               string     `json:"id"`
+	EmbeddingModel   string     `json:"embedding_model"`
+	VectorSize       int        `json:"vector_size"`
+	SourceCollection string     `json:"source_collection"`
+	TargetCollection string     `json:"target_collection"`
+	Status           string     `json:"status"`
+	TotalDocuments   int        `json:"total_documents"`
+	IndexedDocuments int        `json:"indexed_documents"`
+	FailedDocuments  int        `json:"failed_documents"`
+	Error            string     `json:"error,omitempty"`
+	CreatedBy        string     `json:"created_by,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+	CompletedAt      *time.Time `json:"completed_at,omitempty"`
+}
+
+type CreateEmbeddingMigrationRequest struct {
+	EmbeddingModel string `json:"embedding_model" binding:"required,max=255"`
+	VectorSize     int    `json:"vector_size" binding:"required,min=1,max=65536"`
+}
+
+type EmbeddingMigrationListResponse struct {
+	Migrations []EmbeddingMigration `json:"migrations"`
+	Total      int                  `json:"total"`
+	Limit      int                  `json:"limit"`
+	Offset     int                  `json:"offset"`
+}
+
+// Evaluation statuses.
+const (
+	EvaluationStatusRunning   = "running"
+	EvaluationStatusCompleted = "completed"
+	EvaluationStatusFailed    = "failed"
+)
+
+// Evaluation runs a set of questions through the query pipeline and scores
+// each answer against the expected one with the core's evaluator.
+// CompletedCases counts every case run so far, including the FailedCases
+// that could not be answered or scored. AverageScore is the mean over the
+// scored cases, set once the evaluation has finished.
+type Evaluation struct {
+	ID                    string     `json:"id"`
+	Name                  string     `json:"name,omitempty"`
+	Status                string     `json:"status"`
+	TopK                  int        `json:"top_k"`
+	PromptTemplateID      string     `json:"prompt_template_id,omitempty"`
+	PromptTemplateVersion int        `json:"prompt_template_version,omitempty"`
+	TotalCases            int        `json:"total_cases"`
+	CompletedCases        int        `json:"completed_cases"`
+	FailedCases           int        `json:"failed_cases"`
+	AverageScore          *float64   `json:"average_score,omitempty"`
+	Error                 string     `json:"error,omitempty"`
+	CreatedBy             string     `json:"created_by,omitempty"`
+	CreatedAt             time.Time  `json:"created_at"`
+	UpdatedAt             time.Time  `json:"updated_at"`
+	CompletedAt           *time.Time `json:"completed_at,omitempty"`
+}
+
+// EvaluationCase is a question and the answer it is expected to get.
+type EvaluationCase struct {
+	Question       string `json:"question" binding:"required,max=10000"`
+	ExpectedAnswer string `json:"expected_answer" binding:"required,max=100000"`
+}
+
+// EvaluationResult is the outcome of one case. Score is nil if the case
+// could not be answered or scored; Error says why.
+type EvaluationResult struct {
+	Position       int                `json:"position"`
+	Question       string             `json:"question"`
+	ExpectedAnswer string             `json:"expected_answer"`
+	Answer         string             `json:"answer,omitempty"`
+	Score          *float64           `json:"score,omitempty"`
+	Metrics        map[string]float64 `json:"metrics,omitempty"`
+	Error          string             `json:"error,omitempty"`
+	CompletedAt    *time.Time         `json:"completed_at,omitempty"`
+}
+
+type CreateEvaluationRequest struct {
+	Name                  string           `json:"name" binding:"max=255"`
+	TopK                  int              `json:"top_k" binding:"min=0,max=50"`
+	PromptTemplateID      string           `json:"prompt_template_id"`
+	PromptTemplateVersion int              `json:"prompt_template_version" binding:"min=0"`
+	Cases                 []EvaluationCase `json:"cases" binding:"required,min=1,max=500,dive"`
+}
+
+type EvaluationListResponse struct {
+	Evaluations []Evaluation `json:"evaluations"`
+	Total       int          `json:"total"`
+	Limit       int          `json:"limit"`
+	Offset      int          `json:"offset"`
+}
+
+type EvaluationResultListResponse struct {
+	Results []EvaluationResult `json:"results"`
+}
+
+// Duplicate report statuses.
+const (
+	DuplicateReportStatusRunning   = "running"
+	DuplicateReportStatusCompleted = "completed"
+	DuplicateReportStatusFailed    = "failed"
+)
+
+// DuplicateReport is a scan of the indexed documents for near-duplicates.
+// Two documents are near-duplicates if the cosine similarity of the
+// centroids of their chunk vectors is at least Threshold; Clusters groups
+// the documents linked by such pairs, largest first. Listings leave the
+// clusters out.
+type DuplicateReport struct {
+	ID               string             `json:"id"`
+	Status           string             `json:"status"`
+	Threshold        float64            `json:"threshold"`
+	DocumentsScanned int                `json:"documents_scanned"`
+	ClusterCount     int                `json:"cluster_count"`
+	Clusters         []DuplicateCluster `json:"clusters,omitempty"`
+	Error            string             `json:"error,omitempty"`
+	CreatedBy        string             `json:"created_by,omitempty"`
+	CreatedAt        time.Time          `json:"created_at"`
+	CompletedAt      *time.Time         `json:"completed_at,omitempty"`
+}
+
+// DuplicateCluster is a group of near-duplicate documents, oldest first.
+// Similarity is the lowest similarity of the pairs linking them.
+type DuplicateCluster struct {
+	Documents  []DuplicateDocument `json:"documents"`
+	Similarity float64             `json:"similarity"`
+}
+
+// DuplicateDocument identifies a document in a DuplicateCluster.
+type DuplicateDocument struct {
+	ID        string    `json:"id"`
+	Filename  string    `json:"filename"`
+	FileSize  int64     `json:"file_size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CreateDuplicateReportRequest starts a duplicate scan. Threshold defaults
+// to 0.95.
+type CreateDuplicateReportRequest struct {
+	Threshold float64 `json:"threshold" binding:"omitempty,min=0.8,max=1"`
+}
+
+type DuplicateReportListResponse struct {
+	Reports []DuplicateReport `json:"reports"`
+	Total   int               `json:"total"`
+	Limit   int               `json:"limit"`
+	Offset  int               `json:"offset"`
+}
+
+// Snapshot statuses.
+const (
+	SnapshotStatusCreating = "creating"
+	SnapshotStatusReady    = "ready"
+	SnapshotStatusFailed   = "failed"
+)
+
+// Snapshot is the knowledge base frozen at a point in time, which queries
+// can name in as_of to get answers that do not change as documents do. It
+// pairs a copy of the source collection's vectors in Collection, which
+// queries retrieve from, and a Qdrant snapshot of the source collection,
+// from which the vectors can be restored, with the documents indexed when
+// it was taken. ConfigVersion is the version of the gateway that took it,
+// so an evaluation run against the snapshot can be reproduced with the same
+// configuration.
+type Snapshot struct {
+	ID               string     `json:"id"`
+	Name             string     `json:"name"`
+	Description      string     `json:"description,omitempty"`
+	Status           string     `json:"status"`
+	SourceCollection string     `json:"source_collection"`
+	Collection       string     `json:"collection"`
+	QdrantSnapshot   string     `json:"qdrant_snapshot,omitempty"`
+	ConfigVersion    string     `json:"config_version"`
+	DocumentCount    int        `json:"document_count"`
+	VectorCount      int64      `json:"vector_count"`
+	Error            string     `json:"error,omitempty"`
+	CreatedBy        string     `json:"created_by,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	CompletedAt      *time.Time `json:"completed_at,omitempty"`
+}
+
+// SnapshotDocument is a document as it was when a snapshot was taken.
+type SnapshotDocument struct {
+	ID        string            `json:"id"`
+	Filename  string            `json:"filename"`
+	FileSize  int64             `json:"file_size"`
+	Version   int               `json:"version"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	Tags      []string          `json:"tags,omitempty"`
+	IndexedAt *time.Time        `json:"indexed_at,omitempty"`
+}
+
+// CreateSnapshotRequest takes a snapshot. Name is what queries pass in
+// as_of.
+type CreateSnapshotRequest struct {
+	Name        string `json:"name" binding:"required,max=100"`
+	Description string `json:"description,omitempty" binding:"max=1000"`
+}
+
+type SnapshotListResponse struct {
+	Snapshots []Snapshot `json:"snapshots"`
+	Total     int        `json:"total"`
+	Limit     int        `json:"limit"`
+	Offset    int        `json:"offset"`
+}
+
+type SnapshotDocumentListResponse struct {
+	Documents []SnapshotDocument `json:"documents"`
+	Total     int                `json:"total"`
+	Limit     int                `json:"limit"`
+	Offset    int                `json:"offset"`
+}
+
+// Knowledge base export statuses.
+const (
+	KnowledgeBaseExportRunning = "running"
+	KnowledgeBaseExportReady   = "ready"
+	KnowledgeBaseExportFailed  = "failed"
+)
+
+// Knowledge base export archive formats.
+const (
+	ExportFormatZip = "zip"
+	ExportFormatTar = "tar"
+)
+
+// KnowledgeBaseExport is an archive of the files of the documents matching
+// Filter, with a manifest.json of their metadata, which a worker builds in
+// S3. DocumentsExported counts the files the worker has added so far.
+type KnowledgeBaseExport struct {
+	ID                string         `json:"id"`
+	Status            string         `json:"status"`
+	Format            string         `json:"format"`
+	Filter            DocumentFilter `json:"filter"`
+	DocumentCount     int            `json:"document_count"`
+	DocumentsExported int            `json:"documents_exported"`
+	SizeBytes         int64          `json:"size_bytes,omitempty"`
+	ManifestKey       string         `json:"-"`
+	ArchiveKey        string         `json:"-"`
+	Error             string         `json:"error,omitempty"`
+	CreatedBy         string         `json:"created_by,omitempty"`
+	CreatedAt         time.Time      `json:"created_at"`
+	CompletedAt       *time.Time     `json:"completed_at,omitempty"`
+}
+
+// CreateKnowledgeBaseExportRequest exports the documents matching Filter,
+// or every document if it is empty. Format defaults to zip.
+type CreateKnowledgeBaseExportRequest struct {
+	Format string         `json:"format,omitempty" binding:"omitempty,oneof=zip tar"`
+	Filter DocumentFilter `json:"filter"`
+}
+
+type KnowledgeBaseExportListResponse struct {
+	Exports []KnowledgeBaseExport `json:"exports"`
+	Total   int                   `json:"total"`
+	Limit   int                   `json:"limit"`
+	Offset  int                   `json:"offset"`
+}
+
+// ExportManifestEntry is a document in the manifest.json of a knowledge
+// base export. Path is where its file is in the archive and S3Key where
+// the worker reads it from.
+type ExportManifestEntry struct {
+	ID         string            `json:"id"`
+	Path       string            `json:"path"`
+	S3Key      string            `json:"s3_key"`
+	Filename   string            `json:"filename"`
+	Title      string            `json:"title,omitempty"`
+	FileSize   int64             `json:"file_size"`
+	Status     string            `json:"status"`
+	Language   string            `json:"language,omitempty"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+	Tags       []string          `json:"tags,omitempty"`
+	ParentID   string            `json:"parent_id,omitempty"`
+	UploadedBy string            `json:"uploaded_by,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+	IndexedAt  *time.Time        `json:"indexed_at,omitempty"`
+}
+
+// WorkspaceBundleVersion is the format version of the workspace bundles
+// this gateway writes and reads.
+const WorkspaceBundleVersion = 1
+
+// WorkspaceBundle is an export of a workspace's content, imported into
+// another gateway to promote it between environments. Documents carry
+// presigned URLs to download their files from the exporting gateway's
+// bucket, valid until URLsExpireAt.
+type WorkspaceBundle struct {
+	Version       int                     `json:"version"`
+	ExportedAt    time.Time               `json:"exported_at"`
+	ExportedBy    string                  `json:"exported_by,omitempty"`
+	ConfigVersion string                  `json:"config_version,omitempty"`
+	URLsExpireAt  time.Time               `json:"urls_expire_at"`
+	Documents     []WorkspaceDocument     `json:"documents"`
+	Conversations []WorkspaceConversation `json:"conversations,omitempty"`
+}
+
+// WorkspaceDocument is an indexed document in a WorkspaceBundle. S3Key is
+// where the exporting gateway keeps its file.
+type WorkspaceDocument struct {
+	ID          string             `json:"id"`
+	Filename    string             `json:"filename"`
+	FileSize    int64              `json:"file_size"`
+	Metadata    map[string]string  `json:"metadata,omitempty"`
+	Tags        []string           `json:"tags,omitempty"`
+	Chunking    *ChunkingOptions   `json:"chunking,omitempty"`
+	Processing  *ProcessingOptions `json:"processing,omitempty"`
+	UploadedBy  string             `json:"uploaded_by,omitempty"`
+	CreatedAt   time.Time          `json:"created_at"`
+	S3Key       string             `json:"s3_key"`
+	DownloadURL string             `json:"download_url"`
+}
+
+// WorkspaceConversation is a conversation in a WorkspaceBundle with its
+// messages, oldest first.
+type WorkspaceConversation struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	CreatedBy string    `json:"created_by,omitempty"`
+	Messages  []Message `json:"messages"`
+}
+
+// Workspace import statuses.
+const (
+	WorkspaceImportStatusRunning   = "running"
+	WorkspaceImportStatusCompleted = "completed"
+	WorkspaceImportStatusFailed    = "failed"
+)
+
+// WorkspaceImport is the import of a WorkspaceBundle. Documents and
+// conversations keep their IDs, and those already present are skipped, so
+// a bundle can be imported again after a partial failure. Imported
+// documents are indexed like new uploads.
+type WorkspaceImport struct {
+	ID                    string                   `json:"id"`
+	Status                string                   `json:"status"`
+	BundleExportedAt      time.Time                `json:"bundle_exported_at"`
+	DocumentsTotal        int                      `json:"documents_total"`
+	DocumentsImported     int                      `json:"documents_imported"`
+	DocumentsSkipped      int                      `json:"documents_skipped"`
+	DocumentsFailed       int                      `json:"documents_failed"`
+	ConversationsImported int                      `json:"conversations_imported"`
+	ConversationsSkipped  int                      `json:"conversations_skipped"`
+	Failures              []WorkspaceImportFailure `json:"failures,omitempty"`
+	Error                 string                   `json:"error,omitempty"`
+	CreatedBy             string                   `json:"created_by,omitempty"`
+	CreatedAt             time.Time                `json:"created_at"`
+	CompletedAt           *time.Time               `json:"completed_at,omitempty"`
+}
+
+// WorkspaceImportFailure is a document a WorkspaceImport could not import.
+type WorkspaceImportFailure struct {
+	DocumentID string `json:"document_id"`
+	Filename   string `json:"filename"`
+	Error      string `json:"error"`
+}
+
+type WorkspaceImportListResponse struct {
+	Imports []WorkspaceImport `json:"imports"`
+	Total   int               `json:"total"`
+	Limit   int               `json:"limit"`
+	Offset  int               `json:"offset"`
+}
+
+// CoreEvaluationRequest asks the core's evaluator to score an answer.
+type CoreEvaluationRequest struct {
+	Question       string `json:"question"`
+	ExpectedAnswer string `json:"expected_answer"`
+	Answer         string `json:"answer"`
+}
+
+// EvaluationScore is the core evaluator's verdict on an answer: an overall
+// score and optional named metrics such as faithfulness or relevance.
+type EvaluationScore struct {
+	Score   float64            `json:"score"`
+	Metrics map[string]float64 `json:"metrics,omitempty"`
+}
+
+// DocumentSourceURLKey is the document metadata key holding the URL the
+// document was imported from.
+const DocumentSourceURLKey = "source_url"
+
+// StaleDocument is a document flagged by the content freshness report.
+// SourceStatus is the HTTP status its source URL last returned, and is
+// zero until the source has been checked.
+type StaleDocument struct {
+	ID              string     `json:"id"`
+	Filename        string     `json:"filename"`
+	UploadedBy      string     `json:"uploaded_by,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	IndexedAt       *time.Time `json:"indexed_at,omitempty"`
+	SourceURL       string     `json:"source_url,omitempty"`
+	SourceStatus    int        `json:"source_status,omitempty"`
+	SourceCheckedAt *time.Time `json:"source_checked_at,omitempty"`
+}
+
+type StaleDocumentList struct {
+	Documents []StaleDocument `json:"documents"`
+	Total     int             `json:"total"`
+}
+
+// ContentFreshnessReport lists indexed documents curators may want to
+// prune: documents not indexed since Since, documents whose source URL
+// is gone, and documents no query has retrieved since Since.
+type ContentFreshnessReport struct {
+	Days           int               `json:"days"`
+	Since          time.Time         `json:"since"`
+	NotReindexed   StaleDocumentList `json:"not_reindexed"`
+	BrokenSources  StaleDocumentList `json:"broken_sources"`
+	NeverRetrieved StaleDocumentList `json:"never_retrieved"`
+	GeneratedAt    time.Time         `json:"generated_at"`
+}
+
+// SourceCheck is a document whose source URL is due to be checked.
+type SourceCheck struct {
+	DocumentID string
+	URL        string
+}
+
+// Document leaderboard orders.
+const (
+	LeaderboardOrderMost  = "most"
+	LeaderboardOrderLeast = "least"
+)
+
+// DocumentAnalytics summarises how often a document was cited in answers.
+// HitCount counts cited chunks and QueryCount the answers citing the
+// document. LastCitedAt and AverageScore are nil until it is first cited.
+type DocumentAnalytics struct {
+	DocumentID   string     `json:"document_id"`
+	Filename     string     `json:"filename,omitempty"`
+	HitCount     int        `json:"hit_count"`
+	QueryCount   int        `json:"query_count"`
+	LastCitedAt  *time.Time `json:"last_cited_at,omitempty"`
+	AverageScore *float64   `json:"average_score,omitempty"`
+}
+
+type DocumentLeaderboardResponse struct {
+	Order     string              `json:"order"`
+	Days      int                 `json:"days"`
+	Documents []DocumentAnalytics `json:"documents"`
+}
+
+// ShadowStats compares the latencies of queries mirrored to the shadow
+// core with those of the primary core. Mirrored counts queries sent to the
+// shadow core and Skipped those sampled while MaxInFlight mirrored queries
+// were running. A query is compared when both cores completed it; the
+// latency summaries cover the latest SampleSize comparisons.
+type ShadowStats struct {
+	Percent        int            `json:"percent"`
+	Mirrored       int64          `json:"mirrored"`
+	Skipped        int64          `json:"skipped"`
+	ShadowFailed   int64          `json:"shadow_failed"`
+	Compared       int64          `json:"compared"`
+	ShadowSlower   int64          `json:"shadow_slower"`
+	SampleSize     int            `json:"sample_size"`
+	PrimaryLatency LatencySummary `json:"primary_latency"`
+	ShadowLatency  LatencySummary `json:"shadow_latency"`
+	Since          time.Time      `json:"since"`
+}
+
+// LatencySummary summarises latencies in milliseconds.
+type LatencySummary struct {
+	MeanMS float64 `json:"mean_ms"`
+	P50MS  int64   `json:"p50_ms"`
+	P95MS  int64   `json:"p95_ms"`
+}
+
+// CoreBackendStats reports the queries a core backend answered since the
+// gateway started. Errors counts queries it rejected, answered with an
+// error or did not finish; Latency covers its latest SampleSize completed
+// queries.
+type CoreBackendStats struct {
+	Name       string         `json:"name"`
+	Weight     int            `json:"weight"`
+	Percent    float64        `json:"percent"`
+	Queries    int64          `json:"queries"`
+	Errors     int64          `json:"errors"`
+	SampleSize int            `json:"sample_size"`
+	Latency    LatencySummary `json:"latency"`
+}
+
+type CoreBackendStatsResponse struct {
+	Backends []CoreBackendStats `json:"backends"`
+	Since    time.Time          `json:"since"`
+}
+
+// Document timeline event types.
+const (
+	DocumentEventUploaded  = "uploaded"
+	DocumentEventScanned   = "scanned"
+	DocumentEventChunked   = "chunked"
+	DocumentEventEmbedded  = "embedded"
+	DocumentEventIndexed   = "indexed"
+	DocumentEventFailed    = "failed"
+	DocumentEventReindexed = "reindexed"
+	DocumentEventResynced  = "resynced"
+	DocumentEventExpanded  = "expanded"
+	DocumentEventDeleted   = "deleted"
+	DocumentEventTrashed   = "trashed"
+	DocumentEventRestored  = "restored"
+	DocumentEventCancelled = "cancelled"
+	// DocumentEventMetadataUpdated records an edit of the metadata.
+	DocumentEventMetadataUpdated = "metadata_updated"
+	// DocumentEventReviewRequested, DocumentEventApproved and
+	// DocumentEventRejected record the review of an upload.
+	DocumentEventReviewRequested = "review_requested"
+	DocumentEventApproved        = "approved"
+	DocumentEventRejected        = "rejected"
+	// DocumentEventMalwareScanned records the malware scan of an upload.
+	DocumentEventMalwareScanned = "malware_scanned"
+	// DocumentEventAccessChanged records a change of the access groups.
+	DocumentEventAccessChanged = "access_changed"
+)
+
+// DocumentEventSourceGateway is the source of timeline events the gateway
+// records itself; the others come from ingested events.
+const DocumentEventSourceGateway = "gateway"
+
+// DocumentEvent is one step in the lifecycle of a document. Events outlive
+// the document, so a deleted document keeps its timeline.
+type DocumentEvent struct {
+	ID         string                 `json:"id"`
+	DocumentID string                 `json:"document_id"`
+	Type       string                 `json:"type"`
+	Source     string                 `json:"source"`
+	Message    string                 `json:"message,omitempty"`
+	Data       map[string]interface{} `json:"data,omitempty"`
+	OccurredAt time.Time              `json:"occurred_at"`
+}
+
+type DocumentEventListResponse struct {
+	Events []DocumentEvent `json:"events"`
+	Total  int             `json:"total"`
+	Limit  int             `json:"limit"`
+	Offset int             `json:"offset"`
+}
+
+// Service token scopes.
+const (
+	ScopeDocumentsRead  = "documents:read"
+	ScopeDocumentsWrite = "documents:write"
+	ScopeQuery          = "query"
+)
+
+// ServiceTokenScopes lists the scopes a service token may be granted.
+var ServiceTokenScopes = []string{ScopeDocumentsRead, ScopeDocumentsWrite, ScopeQuery}
+
+// ServiceToken authenticates a pipeline, such as a CI job syncing docs,
+// rather than a user. Only a hash of the secret is stored; Token is set
+// once, in the response that creates or rotates it.
+type ServiceToken struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Token      string     `json:"token,omitempty"`
+	TokenHash  string     `json:"-"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedBy  string     `json:"created_by,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	RotatedAt  *time.Time `json:"rotated_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// Expired reports whether the token has expired at now.
+func (t *ServiceToken) Expired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
+
+type CreateServiceTokenRequest struct {
+	Name      string     `json:"name" binding:"required,max=100"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// RotateServiceTokenRequest replaces a token's secret. ExpiresAt, if set,
+// replaces its expiry too.
+type RotateServiceTokenRequest struct {
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type ServiceTokenListResponse struct {
+	Tokens []ServiceToken `json:"tokens"`
+}
+
+// CreateWidgetTokenRequest asks for a chat widget token for a site, e.g.
+// "https://docs.example.com".
+type CreateWidgetTokenRequest struct {
+	Origin string `json:"origin" binding:"required"`
+}
+
+// WidgetToken lets an embedded chat widget on Origin query and hold
+// conversations until ExpiresAt. Its requests are made as
+// "widget:<session_id>".
+type WidgetToken struct {
+	Token     string    `json:"token"`
+	Origin    string    `json:"origin"`
+	SessionID string    `json:"session_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// WidgetClaims are what a widget token asserts about its bearer.
+type WidgetClaims struct {
+	SessionID string `json:"sid"`
+	Origin    string `json:"origin"`
+	// ExpiresAt is a Unix time in seconds.
+	ExpiresAt int64 `json:"exp"`
+}
+
+// ImpersonationTokenPrefix starts every impersonation token, so they can be
+// told apart from other bearer tokens.
+const ImpersonationTokenPrefix = "kbit_"
+
+// ImpersonationSession is an admin acting as another user to reproduce
+// their issues. Every request made with its token is recorded.
+type ImpersonationSession struct {
+	ID           string     `json:"id"`
+	Impersonator string     `json:"impersonator"`
+	Username     string     `json:"username"`
+	Reason       string     `json:"reason"`
+	CreatedAt    time.Time  `json:"created_at"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+	// Requests is the number of requests made with the session's token.
+	Requests int `json:"requests"`
+}
+
+// Active reports whether the session's token is usable at now.
+func (s *ImpersonationSession) Active(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
+
+// ImpersonationClaims are what an impersonation token asserts. Impersonation
+// is always true, so the token cannot pass for the user's own.
+type ImpersonationClaims struct {
+	SessionID     string `json:"sid"`
+	Subject       string `json:"sub"`
+	Impersonator  string `json:"act"`
+	Impersonation bool   `json:"imp"`
+	// ExpiresAt is a Unix time in seconds.
+	ExpiresAt int64 `json:"exp"`
+}
+
+type CreateImpersonationRequest struct {
+	Username string `json:"username" binding:"required,max=255"`
+	Reason   string `json:"reason" binding:"required,max=500"`
+}
+
+// ImpersonationToken is a minted impersonation token and its session. The
+// token is only returned once.
+type ImpersonationToken struct {
+	Token   string               `json:"token"`
+	Session ImpersonationSession `json:"session"`
+}
+
+type ImpersonationSessionListResponse struct {
+	Sessions []ImpersonationSession `json:"sessions"`
+	Total    int                    `json:"total"`
+	Limit    int                    `json:"limit"`
+	Offset   int                    `json:"offset"`
+}
+
+// ImpersonationRequest is a request made with an impersonation token.
+type ImpersonationRequest struct {
+	SessionID string    `json:"session_id"`
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	Status    int       `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ImpersonationRequestListResponse struct {
+	Requests []ImpersonationRequest `json:"requests"`
+}
+
+// Connector providers.
+const (
+	ConnectorProviderGoogleDrive = "google_drive"
+	ConnectorProviderSharePoint  = "sharepoint"
+)
+
+// ConnectorProviders lists the supported connector providers.
+var ConnectorProviders = []string{ConnectorProviderGoogleDrive, ConnectorProviderSharePoint}
+
+// Connector sync statuses.
+const (
+	ConnectorStatusIdle    = "idle"
+	ConnectorStatusSyncing = "syncing"
+	ConnectorStatusFailed  = "failed"
+)
+
+// Connector links an external source a user owns, such as a Google Drive,
+// to the knowledge base. Files in its folders are synced periodically by a
+// ConnectorSyncWorkflow. Credentials holds the sealed OAuth credentials and
+// is never serialised.
+type Connector struct {
+	ID                  string     `json:"id"`
+	Username            string     `json:"username"`
+	Provider            string     `json:"provider"`
+	Name                string     `json:"name"`
+	FolderIDs           []string   `json:"folder_ids"`
+	SyncIntervalMinutes int        `json:"sync_interval_minutes"`
+	Status              string     `json:"status"`
+	LastError           string     `json:"last_error,omitempty"`
+	LastSyncStartedAt   *time.Time `json:"last_sync_started_at,omitempty"`
+	LastSyncedAt        *time.Time `json:"last_synced_at,omitempty"`
+	NextSyncAt          time.Time  `json:"next_sync_at"`
+	Credentials         []byte     `json:"-"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+// ConnectorCredentials are the OAuth credentials a connector syncs with.
+// The refresh token lets workers renew the access token between syncs.
+type ConnectorCredentials struct {
+	AccessToken  string     `json:"access_token"`
+	RefreshToken string     `json:"refresh_token" binding:"required"`
+	TokenType    string     `json:"token_type,omitempty"`
+	Expiry       *time.Time `json:"expiry,omitempty"`
+}
+
+type CreateConnectorRequest struct {
+	Provider            string               `json:"provider" binding:"required"`
+	Name                string               `json:"name" binding:"required,max=100"`
+	FolderIDs           []string             `json:"folder_ids" binding:"required,min=1"`
+	Credentials         ConnectorCredentials `json:"credentials"`
+	SyncIntervalMinutes int                  `json:"sync_interval_minutes" binding:"omitempty,min=5"`
+}
+
+// UpdateConnectorRequest changes the fields that are set.
+type UpdateConnectorRequest struct {
+	Name                *string               `json:"name" binding:"omitempty,max=100"`
+	FolderIDs           []string              `json:"folder_ids" binding:"omitempty,min=1"`
+	Credentials         *ConnectorCredentials `json:"credentials"`
+	SyncIntervalMinutes *int                  `json:"sync_interval_minutes" binding:"omitempty,min=5"`
+}
+
+type ConnectorListResponse struct {
+	Connectors []Connector `json:"connectors"`
+}
+
+// ConnectorFile maps a file in a connector's source to the document it
+// was ingested as.
+type ConnectorFile struct {
+	ConnectorID string    `json:"connector_id"`
+	ExternalID  string    `json:"external_id"`
+	DocumentID  string    `json:"document_id"`
+	ModifiedAt  time.Time `json:"modified_at"`
+	ContentHash string    `json:"content_hash,omitempty"`
+}
+
+// ConnectorFileRequest reports a file found by a sync workflow.
+type ConnectorFileRequest struct {
+	ExternalID string    `json:"external_id" binding:"required"`
+	Filename   string    `json:"filename" binding:"required"`
+	FileSize   int64     `json:"file_size"`
+	ModifiedAt time.Time `json:"modified_at" binding:"required"`
+	// ContentHash is an optional hash of the file's content. A file whose
+	// hash is unchanged is not ingested again, even if it was modified.
+	ContentHash string `json:"content_hash,omitempty"`
+}
+
+// ConnectorFileResponse is the document to upload a new or changed file
+// to, or Unchanged if the file was already ingested at that version.
+type ConnectorFileResponse struct {
+	Document  *Document `json:"document,omitempty"`
+	Unchanged bool      `json:"unchanged"`
+}
+
+// Resync schedule source types.
+const (
+	ResyncSourceDocument  = "document"
+	ResyncSourceConnector = "connector"
+)
+
+// ResyncSchedule re-syncs a URL-imported document or a connector on a cron
+// schedule. ContentHash is the hash of a document's source at its last
+// check.
+type ResyncSchedule struct {
+	SourceType    string     `json:"source_type"`
+	SourceID      string     `json:"source_id"`
+	Cron          string     `json:"cron"`
+	ContentHash   string     `json:"content_hash,omitempty"`
+	CreatedBy     string     `json:"created_by,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	LastCheckedAt *time.Time `json:"last_checked_at,omitempty"`
+	LastChangedAt *time.Time `json:"last_changed_at,omitempty"`
+}
+
+type SetResyncScheduleRequest struct {
+	Cron string `json:"cron" binding:"required"`
+}
+
+// ResyncDocumentRequest reports the hash of a document's source as just
+// fetched by the resync workflow.
+type ResyncDocumentRequest struct {
+	ContentHash string `json:"content_hash" binding:"required"`
+}
+
+// ResyncDocumentResponse tells the resync workflow whether the source has
+// changed, and if so where to upload it.
+type ResyncDocumentResponse struct {
+	Changed   bool   `json:"changed"`
+	UploadURL string `json:"upload_url,omitempty"`
 }
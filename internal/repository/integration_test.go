@@ -4,6 +4,8 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
@@ -115,15 +117,20 @@ func TestPostgresRepository_Integration_CreateAndGetDocument(t *testing.T) {
 	assert.Equal(t, "test", fetched.Metadata["type"])
 
 	// 3. Update Status
-	err = repo.UpdateDocumentStatus(ctx, docID, "indexing", "")
+	err = repo.UpdateDocumentStatus(ctx, docID, "failed", "timeout")
+	require.NoError(t, err)
+	err = repo.SetDocumentIndexing(ctx, docID, "upload-"+docID)
 	require.NoError(t, err)
 
 	fetched, err = repo.GetDocument(ctx, docID)
 	require.NoError(t, err)
 	assert.Equal(t, "indexing", fetched.Status)
+	assert.Equal(t, "upload-"+docID, fetched.WorkflowID)
+	assert.Empty(t, fetched.ErrorMessage)
+	assert.Nil(t, fetched.IndexedAt)
 
 	// 4. List (filter by status)
-	list, total, err := repo.ListDocuments(ctx, 10, 0, "indexing")
+	list, total, err := repo.ListDocuments(ctx, 10, 0, models.DocumentFilter{Status: "indexing"})
 	require.NoError(t, err)
 	assert.GreaterOrEqual(t, total, 1)
 	found := false
@@ -134,6 +141,28 @@ func TestPostgresRepository_Integration_CreateAndGetDocument(t *testing.T) {
 		}
 	}
 	assert.True(t, found, "Created document should appear in list")
+
+	// 5. Language
+	require.NoError(t, repo.SetDocumentLanguage(ctx, docID, "nl"))
+
+	list, total, err = repo.ListDocuments(ctx, 10, 0, models.DocumentFilter{Status: "indexing", Language: "nl"})
+	require.NoError(t, err)
+	require.Equal(t, 1, total)
+	assert.Equal(t, docID, list[0].ID)
+	assert.Equal(t, "nl", list[0].Language)
+
+	// 6. Sort
+	list, _, err = repo.ListDocuments(ctx, 10, 0, models.DocumentFilter{Status: "indexing", SortBy: models.DocumentSortFilename, Order: models.SortAsc})
+	require.NoError(t, err)
+	assert.NotEmpty(t, list)
+
+	// 7. Export
+	var exported []string
+	require.NoError(t, repo.ExportDocuments(ctx, models.DocumentFilter{Status: "indexing", Language: "nl"}, func(d *models.Document) error {
+		exported = append(exported, d.ID)
+		return nil
+	}))
+	assert.Equal(t, []string{docID}, exported)
 }
 
 func TestPostgresRepository_Integration_ConversationsAndMessages(t *testing.T) {
@@ -146,11 +175,15 @@ func TestPostgresRepository_Integration_ConversationsAndMessages(t *testing.T) {
 		ID:        convID,
 		CreatedAt: time.Now().Truncate(time.Microsecond),
 		UpdatedAt: time.Now().Truncate(time.Microsecond),
+		CreatedBy: "alice",
 	}
 
 	// 1. Create Conversation
 	err := repo.CreateConversation(ctx, conv)
 	require.NoError(t, err)
+	got, err := repo.GetConversation(ctx, convID)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", got.CreatedBy)
 
 	// 2. Create Message
 	msgID := uuid.New().String()
@@ -170,8 +203,2006 @@ func TestPostgresRepository_Integration_ConversationsAndMessages(t *testing.T) {
 	require.Len(t, msgs, 1)
 	assert.Equal(t, msg.Content, msgs[0].Content)
 
+	fetched, err := repo.GetMessage(ctx, msgID)
+	require.NoError(t, err)
+	require.NotNil(t, fetched)
+	assert.Equal(t, convID, fetched.ConversationID)
+
+	var exported []*models.Message
+	require.NoError(t, repo.ExportMessages(ctx, convID, func(m *models.Message) error {
+		exported = append(exported, m)
+		return nil
+	}))
+	require.Len(t, exported, 1)
+	assert.Equal(t, msgID, exported[0].ID)
+
 	// Cleanup
 	repo.DeleteMessage(ctx, msgID)
 	// Usually we'd delete conversation too, but there's no DeleteConversation method in the interface?
 	// Checking the interface... Repository interface wasn't shown fully, but let's assume no delete conversation for now or check PostgresRepository.
 }
+
+func TestPostgresRepository_Integration_Stats(t *testing.T) {
+	repo := setupIntegration(t)
+	defer repo.Close()
+	ctx := context.Background()
+
+	before, err := repo.GetDocumentStats(ctx)
+	require.NoError(t, err)
+
+	docID := uuid.New().String()
+	err = repo.CreateDocument(ctx, &models.Document{
+		ID:        docID,
+		Filename:  "stats_test.pdf",
+		FileSize:  2048,
+		Status:    "pending",
+		CreatedAt: time.Now().Truncate(time.Microsecond),
+	})
+	require.NoError(t, err)
+	defer repo.DeleteDocument(ctx, docID)
+
+	after, err := repo.GetDocumentStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, before.Total+1, after.Total)
+	assert.Equal(t, before.ByStatus["pending"]+1, after.ByStatus["pending"])
+	assert.Equal(t, before.StorageBytes+2048, after.StorageBytes)
+
+	since := time.Now().Add(-time.Hour)
+	_, err = repo.CountQueriesByDay(ctx, since)
+	require.NoError(t, err)
+	_, err = repo.CountActiveConversations(ctx, since)
+	require.NoError(t, err)
+}
+
+func TestPostgresRepository_Integration_NotificationPreferences(t *testing.T) {
+	repo := setupIntegration(t)
+	defer repo.Close()
+	ctx := context.Background()
+
+	username := "notify-" + uuid.New().String()
+	prefs, err := repo.GetNotificationPreferences(ctx, username)
+	require.NoError(t, err)
+	assert.Nil(t, prefs)
+
+	saved := &models.NotificationPreferences{
+		Username:       username,
+		Email:          "user@example.com",
+		ImportFinished: true,
+		IndexingFailed: false,
+		ExportReady:    true,
+		UpdatedAt:      time.Now().Truncate(time.Microsecond),
+	}
+	require.NoError(t, repo.UpsertNotificationPreferences(ctx, saved))
+
+	saved.ExportReady = false
+	require.NoError(t, repo.UpsertNotificationPreferences(ctx, saved))
+
+	prefs, err = repo.GetNotificationPreferences(ctx, username)
+	require.NoError(t, err)
+	require.NotNil(t, prefs)
+	assert.Equal(t, "user@example.com", prefs.Email)
+	assert.True(t, prefs.ImportFinished)
+	assert.False(t, prefs.IndexingFailed)
+	assert.False(t, prefs.ExportReady)
+
+	repo.DB().ExecContext(ctx, "DELETE FROM notification_preferences WHERE username = $1", username)
+}
+
+func TestPostgresRepository_Integration_WorkspaceSettings(t *testing.T) {
+	repo := setupIntegration(t)
+	defer repo.Close()
+	ctx := context.Background()
+
+	repo.DB().ExecContext(ctx, "DELETE FROM workspace_settings")
+	settings, err := repo.GetWorkspaceSettings(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, settings)
+
+	updatedAt := time.Now().Truncate(time.Microsecond)
+	saved := &models.WorkspaceSettings{
+		Name:             "Acme Docs",
+		LogoURL:          "https://cdn.example.com/logo.svg",
+		DefaultLanguage:  "de",
+		RetentionDays:    30,
+		AllowedFileTypes: []string{"pdf", "docx"},
+		UpdatedBy:        "admin",
+		UpdatedAt:        &updatedAt,
+	}
+	require.NoError(t, repo.UpsertWorkspaceSettings(ctx, saved))
+
+	saved.AllowedFileTypes = []string{}
+	require.NoError(t, repo.UpsertWorkspaceSettings(ctx, saved))
+
+	settings, err = repo.GetWorkspaceSettings(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, settings)
+	assert.Equal(t, "Acme Docs", settings.Name)
+	assert.Equal(t, "de", settings.DefaultLanguage)
+	assert.Equal(t, 30, settings.RetentionDays)
+	assert.Equal(t, []string{}, settings.AllowedFileTypes)
+	assert.Equal(t, "admin", settings.UpdatedBy)
+	require.NotNil(t, settings.UpdatedAt)
+
+	repo.DB().ExecContext(ctx, "DELETE FROM workspace_settings")
+}
+
+func TestPostgresRepository_Integration_PromptTemplates(t *testing.T) {
+	repo := setupIntegration(t)
+	defer repo.Close()
+	ctx := context.Background()
+
+	now := time.Now().Truncate(time.Microsecond)
+	tmpl := &models.PromptTemplate{
+		ID:        uuid.New().String(),
+		Name:      "integration-" + uuid.New().String(),
+		Template:  "v1: {question}",
+		CreatedBy: "alice",
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	created, err := repo.CreatePromptTemplate(ctx, tmpl)
+	require.NoError(t, err)
+	require.True(t, created)
+	defer repo.DeletePromptTemplate(ctx, tmpl.ID)
+
+	created, err = repo.CreatePromptTemplate(ctx, &models.PromptTemplate{ID: uuid.New().String(), Name: tmpl.Name, Template: "dup", CreatedAt: now, UpdatedAt: now})
+	require.NoError(t, err)
+	assert.False(t, created, "names are unique")
+
+	description := "terse answers"
+	version := &models.PromptTemplateVersion{Template: "v2: {question}", CreatedBy: "bob", CreatedAt: now.Add(time.Minute)}
+	found, err := repo.AddPromptTemplateVersion(ctx, tmpl.ID, version, &description)
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, 2, version.Version)
+
+	latest, err := repo.GetPromptTemplate(ctx, tmpl.ID, 0)
+	require.NoError(t, err)
+	require.NotNil(t, latest)
+	assert.Equal(t, 2, latest.Version)
+	assert.Equal(t, "v2: {question}", latest.Template)
+	assert.Equal(t, description, latest.Description)
+	assert.Equal(t, "alice", latest.CreatedBy)
+
+	first, err := repo.GetPromptTemplate(ctx, tmpl.ID, 1)
+	require.NoError(t, err)
+	require.NotNil(t, first)
+	assert.Equal(t, "v1: {question}", first.Template)
+
+	versions, err := repo.ListPromptTemplateVersions(ctx, tmpl.ID)
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	assert.Equal(t, 2, versions[0].Version)
+
+	require.NoError(t, repo.DeletePromptTemplate(ctx, tmpl.ID))
+	missing, err := repo.GetPromptTemplate(ctx, tmpl.ID, 0)
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+}
+
+func TestPostgresRepository_Integration_EmbeddingMigrations(t *testing.T) {
+	repo := setupIntegration(t)
+	defer repo.Close()
+	ctx := context.Background()
+
+	docID := uuid.New().String()
+	require.NoError(t, repo.CreateDocument(ctx, &models.Document{
+		ID:        docID,
+		Filename:  "migration_test.pdf",
+		FileSize:  1,
+		Status:    "complete",
+		CreatedAt: time.Now(),
+	}))
+	defer repo.DeleteDocument(ctx, docID)
+
+	now := time.Now().Truncate(time.Microsecond)
+	migration := &models.EmbeddingMigration{
+		ID:               uuid.New().String(),
+		EmbeddingModel:   "integration-model",
+		VectorSize:       8,
+		SourceCollection: "documents",
+		TargetCollection: "documents_integration_" + uuid.New().String()[:8],
+		CreatedBy:        "admin",
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+	created, err := repo.CreateEmbeddingMigration(ctx, migration)
+	require.NoError(t, err)
+	require.True(t, created, "no other migration may be running")
+	defer repo.FinishEmbeddingMigration(ctx, migration.ID, models.MigrationStatusFailed, "stopped by test")
+	assert.GreaterOrEqual(t, migration.TotalDocuments, 1)
+
+	created, err = repo.CreateEmbeddingMigration(ctx, &models.EmbeddingMigration{
+		ID: uuid.New().String(), EmbeddingModel: "other", VectorSize: 8,
+		SourceCollection: "documents", TargetCollection: "documents_other", CreatedAt: now, UpdatedAt: now,
+	})
+	require.NoError(t, err)
+	assert.False(t, created, "only one migration runs at a time")
+
+	running, err := repo.GetRunningEmbeddingMigration(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, running)
+	assert.Equal(t, migration.ID, running.ID)
+
+	var claimed []string
+	for {
+		batch, err := repo.ClaimEmbeddingMigrationBatch(ctx, migration.ID, 100)
+		require.NoError(t, err)
+		if len(batch) == 0 {
+			break
+		}
+		claimed = append(claimed, batch...)
+	}
+	assert.Contains(t, claimed, docID)
+
+	queued, err := repo.CountQueuedEmbeddingMigrationDocuments(ctx, migration.ID)
+	require.NoError(t, err)
+	assert.Equal(t, len(claimed), queued)
+
+	recorded, err := repo.RecordEmbeddingMigrationDocument(ctx, migration.ID, docID, "indexed")
+	require.NoError(t, err)
+	assert.True(t, recorded)
+	recorded, err = repo.RecordEmbeddingMigrationDocument(ctx, migration.ID, docID, "indexed")
+	require.NoError(t, err)
+	assert.False(t, recorded, "results are recorded once")
+
+	fetched, err := repo.GetEmbeddingMigration(ctx, migration.ID)
+	require.NoError(t, err)
+	require.NotNil(t, fetched)
+	assert.Equal(t, 1, fetched.IndexedDocuments)
+	assert.Equal(t, models.MigrationStatusRunning, fetched.Status)
+
+	// Failed rather than completed, so the test never switches the
+	// database's active collection.
+	finished, err := repo.FinishEmbeddingMigration(ctx, migration.ID, models.MigrationStatusFailed, "stopped by test")
+	require.NoError(t, err)
+	assert.True(t, finished)
+
+	fetched, err = repo.GetEmbeddingMigration(ctx, migration.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.MigrationStatusFailed, fetched.Status)
+	assert.NotNil(t, fetched.CompletedAt)
+
+	running, err = repo.GetRunningEmbeddingMigration(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, running)
+}
+
+func TestPostgresRepository_Integration_Evaluations(t *testing.T) {
+	repo := setupIntegration(t)
+	defer repo.Close()
+	ctx := context.Background()
+
+	now := time.Now().Truncate(time.Microsecond)
+	evaluation := &models.Evaluation{
+		ID:         uuid.New().String(),
+		Name:       "integration",
+		Status:     models.EvaluationStatusRunning,
+		TopK:       5,
+		TotalCases: 2,
+		CreatedBy:  "admin",
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	require.NoError(t, repo.CreateEvaluation(ctx, evaluation, []models.EvaluationCase{
+		{Question: "What is 2+2?", ExpectedAnswer: "4"},
+		{Question: "Capital of France?", ExpectedAnswer: "Paris"},
+	}))
+
+	results, err := repo.ListEvaluationResults(ctx, evaluation.ID)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, "Capital of France?", results[1].Question)
+	assert.Nil(t, results[1].CompletedAt)
+
+	score := 0.5
+	require.NoError(t, repo.RecordEvaluationResult(ctx, evaluation.ID, &models.EvaluationResult{
+		Position: 0, Answer: "4", Score: &score, Metrics: map[string]float64{"correctness": 0.5},
+	}))
+	require.NoError(t, repo.RecordEvaluationResult(ctx, evaluation.ID, &models.EvaluationResult{
+		Position: 1, Answer: "Lyon", Error: "Failed to score answer",
+	}))
+	require.NoError(t, repo.FinishEvaluation(ctx, evaluation.ID, models.EvaluationStatusCompleted, ""))
+
+	got, err := repo.GetEvaluation(ctx, evaluation.ID)
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.Equal(t, models.EvaluationStatusCompleted, got.Status)
+	assert.Equal(t, 2, got.CompletedCases)
+	assert.Equal(t, 1, got.FailedCases)
+	require.NotNil(t, got.AverageScore)
+	assert.Equal(t, 0.5, *got.AverageScore)
+
+	results, err = repo.ListEvaluationResults(ctx, evaluation.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0.5, results[0].Metrics["correctness"])
+	assert.Equal(t, "Failed to score answer", results[1].Error)
+}
+
+func TestPostgresRepository_Integration_DuplicateReports(t *testing.T) {
+	repo := setupIntegration(t)
+	defer repo.Close()
+	ctx := context.Background()
+
+	report := &models.DuplicateReport{
+		ID:        uuid.New().String(),
+		Status:    models.DuplicateReportStatusRunning,
+		Threshold: 0.95,
+		CreatedBy: "admin",
+		CreatedAt: time.Now().Truncate(time.Microsecond),
+	}
+	require.NoError(t, repo.CreateDuplicateReport(ctx, report))
+	require.NoError(t, repo.SetDuplicateReportProgress(ctx, report.ID, 40))
+
+	got, err := repo.GetDuplicateReport(ctx, report.ID)
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.Equal(t, 40, got.DocumentsScanned)
+	assert.Nil(t, got.Clusters)
+	assert.Nil(t, got.CompletedAt)
+
+	completedAt := time.Now()
+	report.Status = models.DuplicateReportStatusCompleted
+	report.DocumentsScanned = 42
+	report.Clusters = []models.DuplicateCluster{{
+		Documents:  []models.DuplicateDocument{{ID: "doc-1", Filename: "a.pdf"}, {ID: "doc-2", Filename: "b.pdf"}},
+		Similarity: 0.97,
+	}}
+	report.ClusterCount = len(report.Clusters)
+	report.CompletedAt = &completedAt
+	require.NoError(t, repo.FinishDuplicateReport(ctx, report))
+
+	got, err = repo.GetDuplicateReport(ctx, report.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.DuplicateReportStatusCompleted, got.Status)
+	assert.Equal(t, 42, got.DocumentsScanned)
+	assert.Equal(t, 1, got.ClusterCount)
+	require.Len(t, got.Clusters, 1)
+	assert.Equal(t, "b.pdf", got.Clusters[0].Documents[1].Filename)
+	assert.NotNil(t, got.CompletedAt)
+
+	reports, total, err := repo.ListDuplicateReports(ctx, 100, 0)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, total, 1)
+	for _, listed := range reports {
+		assert.Nil(t, listed.Clusters)
+	}
+
+	missing, err := repo.GetDuplicateReport(ctx, uuid.New().String())
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+}
+
+func TestPostgresRepository_Integration_ContentFreshness(t *testing.T) {
+	repo := setupIntegration(t)
+	defer repo.Close()
+	ctx := context.Background()
+
+	indexedAt := time.Now().AddDate(0, 0, -200)
+	doc := &models.Document{
+		ID:        uuid.New().String(),
+		Filename:  "freshness_test.pdf",
+		FileSize:  1,
+		Status:    "complete",
+		CreatedAt: indexedAt,
+		IndexedAt: &indexedAt,
+		Metadata:  map[string]string{models.DocumentSourceURLKey: "https://example.com/freshness"},
+	}
+	require.NoError(t, repo.CreateDocument(ctx, doc))
+	defer repo.DeleteDocument(ctx, doc.ID)
+
+	since := time.Now().AddDate(0, 0, -90)
+	stale, total, err := repo.ListStaleDocuments(ctx, since, 1000)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, total, len(stale))
+	assert.True(t, containsStaleDocument(stale, doc.ID))
+
+	unretrieved, _, err := repo.ListUnretrievedDocuments(ctx, since, 1000)
+	require.NoError(t, err)
+	assert.True(t, containsStaleDocument(unretrieved, doc.ID))
+
+	require.NoError(t, repo.CreateQueryLog(ctx, &models.QueryLog{
+		ID:          uuid.New().String(),
+		Username:    "alice",
+		Question:    "freshness?",
+		Status:      models.QueryStatusCompleted,
+		CreatedAt:   time.Now(),
+		DocumentIDs: []string{doc.ID},
+	}))
+	unretrieved, _, err = repo.ListUnretrievedDocuments(ctx, since, 1000)
+	require.NoError(t, err)
+	assert.False(t, containsStaleDocument(unretrieved, doc.ID))
+
+	var claimed bool
+	for {
+		checks, err := repo.ClaimSourceChecks(ctx, time.Now(), 100)
+		require.NoError(t, err)
+		if len(checks) == 0 {
+			break
+		}
+		for _, check := range checks {
+			if check.DocumentID == doc.ID {
+				claimed = true
+				assert.Equal(t, "https://example.com/freshness", check.URL)
+			}
+		}
+	}
+	require.True(t, claimed)
+
+	require.NoError(t, repo.RecordSourceStatus(ctx, doc.ID, 404))
+	broken, _, err := repo.ListBrokenSourceDocuments(ctx, 1000)
+	require.NoError(t, err)
+	assert.True(t, containsStaleDocument(broken, doc.ID))
+}
+
+func containsStaleDocument(documents []*models.StaleDocument, id string) bool {
+	for _, doc := range documents {
+		if doc.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func TestPostgresRepository_Integration_DocumentAnalytics(t *testing.T) {
+	repo := setupIntegration(t)
+	defer repo.Close()
+	ctx := context.Background()
+
+	docID := uuid.New().String()
+	require.NoError(t, repo.CreateDocument(ctx, &models.Document{
+		ID:        docID,
+		Filename:  "analytics_test.pdf",
+		FileSize:  1,
+		Status:    "complete",
+		CreatedAt: time.Now(),
+	}))
+	defer repo.DeleteDocument(ctx, docID)
+
+	analytics, err := repo.GetDocumentAnalytics(ctx, docID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, analytics.HitCount)
+	assert.Nil(t, analytics.LastCitedAt)
+
+	require.NoError(t, repo.CreateQueryLog(ctx, &models.QueryLog{
+		ID:        uuid.New().String(),
+		Username:  "alice",
+		Question:  "analytics?",
+		Status:    models.QueryStatusCompleted,
+		CreatedAt: time.Now(),
+		Citations: []models.Citation{
+			{DocumentID: docID, ChunkID: "c-1", Score: 0.9},
+			{DocumentID: docID, ChunkID: "c-2", Score: 0.5},
+		},
+	}))
+
+	analytics, err = repo.GetDocumentAnalytics(ctx, docID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, analytics.HitCount)
+	assert.Equal(t, 1, analytics.QueryCount)
+	require.NotNil(t, analytics.LastCitedAt)
+	require.NotNil(t, analytics.AverageScore)
+	assert.InDelta(t, 0.7, *analytics.AverageScore, 1e-9)
+
+	leaderboard, err := repo.ListDocumentLeaderboard(ctx, time.Now().Add(-time.Hour), models.LeaderboardOrderMost, 1000)
+	require.NoError(t, err)
+	var found bool
+	for _, entry := range leaderboard {
+		if entry.DocumentID == docID {
+			found = true
+			assert.Equal(t, "analytics_test.pdf", entry.Filename)
+			assert.Equal(t, 2, entry.HitCount)
+		}
+	}
+	assert.True(t, found)
+}
+
+func TestPostgresRepository_Integration_DocumentEvents(t *testing.T) {
+	repo := setupIntegration(t)
+	defer repo.Close()
+	ctx := context.Background()
+
+	docID := uuid.New().String()
+	now := time.Now()
+	uploaded := &models.DocumentEvent{
+		ID:         uuid.New().String(),
+		DocumentID: docID,
+		Type:       models.DocumentEventUploaded,
+		Source:     models.DocumentEventSourceGateway,
+		Data:       map[string]interface{}{"filename": "timeline.pdf"},
+		OccurredAt: now.Add(-time.Minute),
+	}
+	require.NoError(t, repo.CreateDocumentEvent(ctx, uploaded))
+	require.NoError(t, repo.CreateDocumentEvent(ctx, &models.DocumentEvent{
+		ID:         uuid.New().String(),
+		DocumentID: docID,
+		Type:       models.DocumentEventFailed,
+		Source:     "worker",
+		Message:    "parse error",
+		OccurredAt: now,
+	}))
+	// Redelivered events are ignored.
+	require.NoError(t, repo.CreateDocumentEvent(ctx, uploaded))
+
+	events, total, err := repo.ListDocumentEvents(ctx, docID, 50, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	require.Len(t, events, 2)
+	assert.Equal(t, models.DocumentEventUploaded, events[0].Type)
+	assert.Equal(t, "timeline.pdf", events[0].Data["filename"])
+	assert.Equal(t, "parse error", events[1].Message)
+
+	events, total, err = repo.ListDocumentEvents(ctx, docID, 50, 5)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	assert.Empty(t, events)
+}
+
+func TestPostgresRepository_Integration_ServiceTokens(t *testing.T) {
+	repo := setupIntegration(t)
+	defer repo.Close()
+	ctx := context.Background()
+
+	token := &models.ServiceToken{
+		ID:        uuid.New().String(),
+		Name:      "ci-" + uuid.New().String()[:8],
+		TokenHash: uuid.New().String(),
+		Prefix:    "kbst_abcd1234",
+		Scopes:    []string{models.ScopeDocumentsRead, models.ScopeDocumentsWrite},
+		CreatedBy: "admin",
+		CreatedAt: time.Now(),
+	}
+	created, err := repo.CreateServiceToken(ctx, token)
+	require.NoError(t, err)
+	require.True(t, created)
+	defer repo.DeleteServiceToken(ctx, token.ID)
+
+	duplicate := *token
+	duplicate.ID = uuid.New().String()
+	duplicate.TokenHash = uuid.New().String()
+	created, err = repo.CreateServiceToken(ctx, &duplicate)
+	require.NoError(t, err)
+	assert.False(t, created)
+
+	found, err := repo.GetServiceTokenByHash(ctx, token.TokenHash)
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	assert.Equal(t, token.Scopes, found.Scopes)
+	assert.Nil(t, found.ExpiresAt)
+
+	now := time.Now()
+	expiresAt := now.Add(24 * time.Hour)
+	token.TokenHash = uuid.New().String()
+	token.RotatedAt = &now
+	token.ExpiresAt = &expiresAt
+	require.NoError(t, repo.RotateServiceToken(ctx, token))
+	require.NoError(t, repo.TouchServiceToken(ctx, token.ID, now))
+
+	found, err = repo.GetServiceToken(ctx, token.ID)
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	assert.Equal(t, token.TokenHash, found.TokenHash)
+	assert.NotNil(t, found.RotatedAt)
+	assert.NotNil(t, found.ExpiresAt)
+	assert.NotNil(t, found.LastUsedAt)
+
+	found, err = repo.GetServiceTokenByHash(ctx, duplicate.TokenHash)
+	require.NoError(t, err)
+	assert.Nil(t, found)
+}
+
+func TestPostgresRepository_Integration_Impersonation(t *testing.T) {
+	repo := setupIntegration(t)
+	defer repo.Close()
+	ctx := context.Background()
+
+	now := time.Now().Truncate(time.Second)
+	session := &models.ImpersonationSession{
+		ID:           uuid.New().String(),
+		Impersonator: "admin",
+		Username:     "alice",
+		Reason:       "Ticket 123",
+		CreatedAt:    now,
+		ExpiresAt:    now.Add(30 * time.Minute),
+	}
+	require.NoError(t, repo.CreateImpersonationSession(ctx, session))
+
+	for _, status := range []int{200, 404} {
+		require.NoError(t, repo.RecordImpersonationRequest(ctx, &models.ImpersonationRequest{
+			SessionID: session.ID, Method: "GET", Path: "/api/v1/documents", Status: status, CreatedAt: time.Now(),
+		}))
+	}
+
+	found, err := repo.GetImpersonationSession(ctx, session.ID)
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	assert.Equal(t, "alice", found.Username)
+	assert.Equal(t, 2, found.Requests)
+	assert.Nil(t, found.RevokedAt)
+
+	requests, err := repo.ListImpersonationRequests(ctx, session.ID)
+	require.NoError(t, err)
+	require.Len(t, requests, 2)
+	assert.Equal(t, 200, requests[0].Status)
+	assert.Equal(t, 404, requests[1].Status)
+
+	sessions, total, err := repo.ListImpersonationSessions(ctx, 100, 0)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, total, 1)
+	assert.NotEmpty(t, sessions)
+
+	revoked, err := repo.RevokeImpersonationSession(ctx, session.ID, time.Now())
+	require.NoError(t, err)
+	assert.True(t, revoked)
+	revoked, err = repo.RevokeImpersonationSession(ctx, session.ID, time.Now())
+	require.NoError(t, err)
+	assert.False(t, revoked)
+
+	found, err = repo.GetImpersonationSession(ctx, session.ID)
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	assert.NotNil(t, found.RevokedAt)
+
+	missing, err := repo.GetImpersonationSession(ctx, uuid.New().String())
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+}
+
+func TestPostgresRepository_Integration_Connectors(t *testing.T) {
+	repo := setupIntegration(t)
+	defer repo.Close()
+	ctx := context.Background()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	connector := &models.Connector{
+		ID:                  uuid.New().String(),
+		Username:            "alice",
+		Provider:            models.ConnectorProviderGoogleDrive,
+		Name:                "Handbooks",
+		FolderIDs:           []string{"folder-1"},
+		SyncIntervalMinutes: 60,
+		Status:              models.ConnectorStatusIdle,
+		NextSyncAt:          now.Add(-time.Minute),
+		Credentials:         []byte("sealed"),
+		CreatedAt:           now,
+		UpdatedAt:           now,
+	}
+	require.NoError(t, repo.CreateConnector(ctx, connector))
+	defer repo.DeleteConnector(ctx, connector.ID)
+
+	claimed, err := repo.ClaimDueConnectors(ctx, now, 1000)
+	require.NoError(t, err)
+	var found *models.Connector
+	for _, c := range claimed {
+		if c.ID == connector.ID {
+			found = c
+		}
+	}
+	require.NotNil(t, found)
+	assert.Equal(t, models.ConnectorStatusSyncing, found.Status)
+	assert.True(t, found.NextSyncAt.Equal(now.Add(time.Hour)))
+	assert.Equal(t, []byte("sealed"), found.Credentials)
+
+	require.NoError(t, repo.FinishConnectorSync(ctx, connector.ID, "", now))
+	stored, err := repo.GetConnector(ctx, connector.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ConnectorStatusIdle, stored.Status)
+	require.NotNil(t, stored.LastSyncedAt)
+
+	require.NoError(t, repo.FinishConnectorSync(ctx, connector.ID, "token revoked", now.Add(time.Minute)))
+	stored, err = repo.GetConnector(ctx, connector.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ConnectorStatusFailed, stored.Status)
+	assert.Equal(t, "token revoked", stored.LastError)
+	assert.True(t, stored.LastSyncedAt.Equal(now))
+
+	file := &models.ConnectorFile{ConnectorID: connector.ID, ExternalID: "file-1", DocumentID: uuid.New().String(), ModifiedAt: now}
+	require.NoError(t, repo.UpsertConnectorFile(ctx, file))
+	file.DocumentID = uuid.New().String()
+	file.ContentHash = "abc"
+	require.NoError(t, repo.UpsertConnectorFile(ctx, file))
+	storedFile, err := repo.GetConnectorFile(ctx, connector.ID, "file-1")
+	require.NoError(t, err)
+	require.NotNil(t, storedFile)
+	assert.Equal(t, file.DocumentID, storedFile.DocumentID)
+	assert.Equal(t, "abc", storedFile.ContentHash)
+
+	connectors, err := repo.ListConnectors(ctx, "alice")
+	require.NoError(t, err)
+	assert.NotEmpty(t, connectors)
+
+	all, err := repo.ListAllConnectors(ctx)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, len(all), len(connectors))
+}
+
+func TestPostgresRepository_Integration_ResyncSchedules(t *testing.T) {
+	repo := setupIntegration(t)
+	defer repo.Close()
+	ctx := context.Background()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	connector := &models.Connector{
+		ID:                  uuid.New().String(),
+		Username:            "alice",
+		Provider:            models.ConnectorProviderSharePoint,
+		Name:                "Policies",
+		FolderIDs:           []string{"folder-1"},
+		SyncIntervalMinutes: 60,
+		Status:              models.ConnectorStatusIdle,
+		NextSyncAt:          now.Add(-time.Minute),
+		Credentials:         []byte("sealed"),
+		CreatedAt:           now,
+		UpdatedAt:           now,
+	}
+	require.NoError(t, repo.CreateConnector(ctx, connector))
+	defer repo.DeleteConnector(ctx, connector.ID)
+
+	schedule := &models.ResyncSchedule{
+		SourceType: models.ResyncSourceConnector,
+		SourceID:   connector.ID,
+		Cron:       "0 6 * * *",
+		CreatedBy:  "alice",
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	require.NoError(t, repo.UpsertResyncSchedule(ctx, schedule))
+	defer repo.DeleteResyncSchedule(ctx, models.ResyncSourceConnector, connector.ID)
+
+	claimed, err := repo.ClaimDueConnectors(ctx, now, 1000)
+	require.NoError(t, err)
+	for _, c := range claimed {
+		assert.NotEqual(t, connector.ID, c.ID, "scheduled connectors are not claimed")
+	}
+
+	schedule.Cron = "@daily"
+	schedule.UpdatedAt = now.Add(time.Minute)
+	require.NoError(t, repo.UpsertResyncSchedule(ctx, schedule))
+
+	require.NoError(t, repo.RecordResyncCheck(ctx, models.ResyncSourceConnector, connector.ID, "abc", now))
+	require.NoError(t, repo.RecordResyncCheck(ctx, models.ResyncSourceConnector, connector.ID, "abc", now.Add(time.Hour)))
+	stored, err := repo.GetResyncSchedule(ctx, models.ResyncSourceConnector, connector.ID)
+	require.NoError(t, err)
+	require.NotNil(t, stored)
+	assert.Equal(t, "@daily", stored.Cron)
+	assert.True(t, stored.CreatedAt.Equal(now))
+	assert.Equal(t, "abc", stored.ContentHash)
+	require.NotNil(t, stored.LastChangedAt)
+	assert.True(t, stored.LastChangedAt.Equal(now))
+	assert.True(t, stored.LastCheckedAt.Equal(now.Add(time.Hour)))
+
+	require.NoError(t, repo.DeleteResyncSchedule(ctx, models.ResyncSourceConnector, connector.ID))
+	stored, err = repo.GetResyncSchedule(ctx, models.ResyncSourceConnector, connector.ID)
+	require.NoError(t, err)
+	assert.Nil(t, stored)
+}
+
+func TestPostgresRepository_Integration_ArchiveChildren(t *testing.T) {
+	repo := setupIntegration(t)
+	defer repo.Close()
+	ctx := context.Background()
+
+	now := time.Now().Truncate(time.Microsecond)
+	archive := &models.Document{ID: uuid.New().String(), Filename: "handbooks.zip", FileSize: 2048, Status: "indexing", CreatedAt: now}
+	require.NoError(t, repo.CreateDocument(ctx, archive))
+	defer repo.DeleteDocument(ctx, archive.ID)
+
+	for i, status := range []string{"cancelled", "complete", "complete", "failed"} {
+		child := &models.Document{
+			ID:        uuid.New().String(),
+			Filename:  "file.pdf",
+			FileSize:  512,
+			Status:    status,
+			CreatedAt: now.Add(time.Duration(i) * time.Second),
+			ParentID:  archive.ID,
+		}
+		require.NoError(t, repo.CreateDocument(ctx, child))
+		defer repo.DeleteDocument(ctx, child.ID)
+	}
+
+	progress, err := repo.CountChildDocuments(ctx, archive.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ChildProgress{Total: 4, Complete: 2, Failed: 1, Cancelled: 1}, *progress)
+
+	children, total, err := repo.ListChildDocuments(ctx, archive.ID, 2, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 4, total)
+	require.Len(t, children, 2)
+	assert.Equal(t, archive.ID, children[0].ParentID)
+	assert.Equal(t, "failed", children[0].Status)
+
+	fetched, err := repo.GetDocument(ctx, archive.ID)
+	require.NoError(t, err)
+	assert.Empty(t, fetched.ParentID)
+}
+
+func TestPostgresRepository_Integration_AnsweredQuestions(t *testing.T) {
+	repo := setupIntegration(t)
+	defer repo.Close()
+	ctx := context.Background()
+
+	now := time.Now().Truncate(time.Microsecond)
+	scope := "documents|" + uuid.New().String() + "|"
+	old := &models.AnsweredQuestion{QueryID: uuid.New().String(), Scope: scope, Question: "refund policy", Answer: "60 days.", CreatedAt: now.Add(-2 * time.Hour)}
+	recent := &models.AnsweredQuestion{QueryID: uuid.New().String(), Scope: scope, Question: "What is the refund policy?", Answer: "30 days.", DocumentIDs: []string{"doc-1"}, CreatedAt: now}
+	require.NoError(t, repo.CreateAnsweredQuestion(ctx, old))
+	require.NoError(t, repo.CreateAnsweredQuestion(ctx, recent))
+	// Redelivery of the same query is ignored.
+	require.NoError(t, repo.CreateAnsweredQuestion(ctx, recent))
+
+	answered, err := repo.ListAnsweredQuestions(ctx, scope, now.Add(-3*time.Hour), 10)
+	require.NoError(t, err)
+	require.Len(t, answered, 2)
+	assert.Equal(t, recent.QueryID, answered[0].QueryID)
+	assert.Equal(t, []string{"doc-1"}, answered[0].DocumentIDs)
+
+	require.NoError(t, repo.DeleteAnsweredQuestionsBefore(ctx, now.Add(-time.Hour)))
+
+	answered, err = repo.ListAnsweredQuestions(ctx, scope, now.Add(-3*time.Hour), 10)
+	require.NoError(t, err)
+	require.Len(t, answered, 1)
+	assert.Equal(t, "30 days.", answered[0].Answer)
+
+	require.NoError(t, repo.DeleteAnsweredQuestionsBefore(ctx, now.Add(time.Second)))
+}
+
+func TestPostgresRepository_Integration_ConversationSummaries(t *testing.T) {
+	repo := setupIntegration(t)
+	defer repo.Close()
+	ctx := context.Background()
+
+	now := time.Now().Truncate(time.Microsecond)
+	convID := uuid.New().String()
+	require.NoError(t, repo.CreateConversation(ctx, &models.Conversation{ID: convID, CreatedAt: now, UpdatedAt: now}))
+
+	latest, err := repo.GetLatestConversationSummary(ctx, convID)
+	require.NoError(t, err)
+	assert.Nil(t, latest)
+
+	first := &models.ConversationSummary{ConversationID: convID, Version: 1, Summary: "Greetings.", MessageCount: 8, CreatedAt: now}
+	second := &models.ConversationSummary{ConversationID: convID, Version: 2, Summary: "Greetings and refunds.", MessageCount: 12, CreatedAt: now.Add(time.Minute)}
+	require.NoError(t, repo.CreateConversationSummary(ctx, first))
+	require.NoError(t, repo.CreateConversationSummary(ctx, second))
+	// A version saved twice keeps the first summary.
+	require.NoError(t, repo.CreateConversationSummary(ctx, &models.ConversationSummary{ConversationID: convID, Version: 2, Summary: "Other.", MessageCount: 12, CreatedAt: now}))
+
+	latest, err = repo.GetLatestConversationSummary(ctx, convID)
+	require.NoError(t, err)
+	require.NotNil(t, latest)
+	assert.Equal(t, 2, latest.Version)
+	assert.Equal(t, "Greetings and refunds.", latest.Summary)
+	assert.Equal(t, 12, latest.MessageCount)
+
+	summaries, err := repo.ListConversationSummaries(ctx, convID)
+	require.NoError(t, err)
+	require.Len(t, summaries, 2)
+	assert.Equal(t, 2, summaries[0].Version)
+	assert.Equal(t, 1, summaries[1].Version)
+}
+
+func TestPostgresRepository_Integration_QueryCitations(t *testing.T) {
+	repo := setupIntegration(t)
+	defer repo.Close()
+	ctx := context.Background()
+
+	docID := uuid.New().String()
+	require.NoError(t, repo.CreateDocument(ctx, &models.Document{
+		ID:        docID,
+		Filename:  "citations_test.pdf",
+		S3Key:     "documents/" + docID + "/citations_test.pdf",
+		FileSize:  1,
+		Status:    "complete",
+		CreatedAt: time.Now(),
+	}))
+	defer repo.DeleteDocument(ctx, docID)
+
+	queryID := uuid.New().String()
+	require.NoError(t, repo.CreateQueryLog(ctx, &models.QueryLog{
+		ID:        queryID,
+		Username:  "alice",
+		Question:  "citations?",
+		Status:    models.QueryStatusCompleted,
+		CreatedAt: time.Now(),
+		Citations: []models.Citation{
+			{DocumentID: docID, ChunkID: "c-2", Score: 0.5, Page: 7},
+			{DocumentID: docID, ChunkID: "c-1", Score: 0.9, Page: 3, StartChar: 100, EndChar: 400},
+		},
+	}))
+
+	citations, err := repo.ListQueryCitations(ctx, []string{queryID, uuid.New().String()})
+	require.NoError(t, err)
+	require.Len(t, citations, 1)
+	require.Len(t, citations[queryID], 2)
+	assert.Equal(t, "c-2", citations[queryID][0].ChunkID)
+	assert.Equal(t, models.Citation{DocumentID: docID, ChunkID: "c-1", Score: 0.9, Page: 3, StartChar: 100, EndChar: 400}, citations[queryID][1])
+
+	docs, err := repo.GetDocumentsByIDs(ctx, []string{docID, uuid.New().String()})
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+	assert.Equal(t, "citations_test.pdf", docs[0].Filename)
+}
+
+func TestPostgresRepository_Integration_SuggestQueries(t *testing.T) {
+	repo := setupIntegration(t)
+	defer repo.Close()
+	ctx := context.Background()
+
+	// A marker unique to this run keeps other rows out of the matches.
+	marker := "zq" + strings.ReplaceAll(uuid.New().String(), "-", "")[:8]
+
+	docID := uuid.New().String()
+	require.NoError(t, repo.CreateDocument(ctx, &models.Document{
+		ID:        docID,
+		Filename:  marker + " handbook.pdf",
+		S3Key:     "documents/" + docID + "/handbook.pdf",
+		FileSize:  1,
+		Status:    "complete",
+		CreatedAt: time.Now(),
+	}))
+	defer repo.DeleteDocument(ctx, docID)
+
+	for _, q := range []struct{ username, question string }{
+		{"alice", marker + " vpn reset"},
+		{"bob", strings.ToUpper(marker) + " VPN reset"},
+		{"carol", "what is " + marker + " salary of bob"},
+	} {
+		require.NoError(t, repo.CreateQueryLog(ctx, &models.QueryLog{
+			ID:        uuid.New().String(),
+			Username:  q.username,
+			Question:  q.question,
+			Status:    models.QueryStatusCompleted,
+			CreatedAt: time.Now(),
+		}))
+	}
+
+	suggestions, err := repo.SuggestQueries(ctx, marker, 2, 10)
+	require.NoError(t, err)
+	require.Len(t, suggestions, 2)
+	assert.Equal(t, models.SuggestionSourceQuery, suggestions[0].Source)
+	assert.True(t, strings.EqualFold(marker+" vpn reset", suggestions[0].Text))
+	assert.Equal(t, models.QuerySuggestion{Text: marker + " handbook", Source: models.SuggestionSourceDocument, DocumentID: docID}, suggestions[1])
+
+	// Questions asked by a single user are only suggested when allowed.
+	suggestions, err = repo.SuggestQueries(ctx, marker+" salary", 1, 10)
+	require.NoError(t, err)
+	require.Len(t, suggestions, 1)
+	assert.Equal(t, "what is "+marker+" salary of bob", suggestions[0].Text)
+
+	// LIKE wildcards in the text match literally.
+	suggestions, err = repo.SuggestQueries(ctx, marker+"%", 1, 10)
+	require.NoError(t, err)
+	assert.Empty(t, suggestions)
+}
+
+func TestPostgresRepository_Integration_CuratedAnswers(t *testing.T) {
+	repo := setupIntegration(t)
+	defer repo.Close()
+	ctx := context.Background()
+
+	now := time.Now().Truncate(time.Microsecond)
+	answer := &models.CuratedAnswer{
+		ID:          uuid.New().String(),
+		Pattern:     "refund policy " + uuid.New().String(),
+		MatchType:   models.CuratedMatchContains,
+		Answer:      "30 days.",
+		DocumentIDs: []string{"doc-1"},
+		Enabled:     true,
+		CreatedBy:   "alice",
+		UpdatedBy:   "alice",
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	require.NoError(t, repo.CreateCuratedAnswer(ctx, answer))
+	defer repo.DeleteCuratedAnswer(ctx, answer.ID)
+
+	got, err := repo.GetCuratedAnswer(ctx, answer.ID)
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.Equal(t, answer.Pattern, got.Pattern)
+	assert.Equal(t, []string{"doc-1"}, got.DocumentIDs)
+
+	enabled, err := repo.ListEnabledCuratedAnswers(ctx)
+	require.NoError(t, err)
+	assert.True(t, slices.ContainsFunc(enabled, func(a *models.CuratedAnswer) bool { return a.ID == answer.ID }))
+
+	answer.Enabled = false
+	answer.UpdatedBy = "bob"
+	answer.UpdatedAt = now.Add(time.Minute)
+	found, err := repo.UpdateCuratedAnswer(ctx, answer)
+	require.NoError(t, err)
+	require.True(t, found)
+
+	enabled, err = repo.ListEnabledCuratedAnswers(ctx)
+	require.NoError(t, err)
+	assert.False(t, slices.ContainsFunc(enabled, func(a *models.CuratedAnswer) bool { return a.ID == answer.ID }))
+
+	found, err = repo.UpdateCuratedAnswer(ctx, &models.CuratedAnswer{ID: uuid.New().String(), MatchType: models.CuratedMatchExact, UpdatedAt: now})
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	require.NoError(t, repo.DeleteCuratedAnswer(ctx, answer.ID))
+	missing, err := repo.GetCuratedAnswer(ctx, answer.ID)
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+}
+
+func TestPostgresRepository_Integration_RedactionRules(t *testing.T) {
+	repo := setupIntegration(t)
+	defer repo.Close()
+	ctx := context.Background()
+
+	now := time.Now().Truncate(time.Microsecond)
+	rule := &models.RedactionRule{
+		ID:          uuid.New().String(),
+		Name:        "Codenames",
+		Type:        models.RedactionKeywords,
+		Keywords:    []string{"Falcon", "Project Falcon"},
+		Placeholder: models.DefaultRedactionPlaceholder,
+		Enabled:     true,
+		CreatedBy:   "alice",
+		UpdatedBy:   "alice",
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	require.NoError(t, repo.CreateRedactionRule(ctx, rule))
+	defer repo.DeleteRedactionRule(ctx, rule.ID)
+
+	got, err := repo.GetRedactionRule(ctx, rule.ID)
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.Equal(t, []string{"Falcon", "Project Falcon"}, got.Keywords)
+	assert.Empty(t, got.Pattern)
+
+	enabled, err := repo.ListEnabledRedactionRules(ctx)
+	require.NoError(t, err)
+	assert.True(t, slices.ContainsFunc(enabled, func(r *models.RedactionRule) bool { return r.ID == rule.ID }))
+
+	rule.Type, rule.Pattern, rule.Keywords = models.RedactionRegex, `FAL-\d+`, nil
+	rule.Enabled = false
+	rule.UpdatedBy = "bob"
+	rule.UpdatedAt = now.Add(time.Minute)
+	found, err := repo.UpdateRedactionRule(ctx, rule)
+	require.NoError(t, err)
+	require.True(t, found)
+
+	got, err = repo.GetRedactionRule(ctx, rule.ID)
+	require.NoError(t, err)
+	assert.Equal(t, `FAL-\d+`, got.Pattern)
+	assert.Empty(t, got.Keywords)
+	assert.Equal(t, "bob", got.UpdatedBy)
+
+	enabled, err = repo.ListEnabledRedactionRules(ctx)
+	require.NoError(t, err)
+	assert.False(t, slices.ContainsFunc(enabled, func(r *models.RedactionRule) bool { return r.ID == rule.ID }))
+
+	found, err = repo.UpdateRedactionRule(ctx, &models.RedactionRule{ID: uuid.New().String(), Type: models.RedactionRegex, UpdatedAt: now})
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	require.NoError(t, repo.DeleteRedactionRule(ctx, rule.ID))
+	missing, err := repo.GetRedactionRule(ctx, rule.ID)
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+}
+
+func TestPostgresRepository_Integration_Glossary(t *testing.T) {
+	repo := setupIntegration(t)
+	defer repo.Close()
+	ctx := context.Background()
+
+	now := time.Now().Truncate(time.Microsecond)
+	term := &models.GlossaryTerm{
+		ID:        uuid.New().String(),
+		Term:      "Term-" + uuid.New().String(),
+		CreatedBy: "alice",
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	created, err := repo.CreateGlossaryTerm(ctx, term)
+	require.NoError(t, err)
+	require.True(t, created)
+	defer repo.DeleteGlossaryTerm(ctx, term.ID)
+
+	created, err = repo.CreateGlossaryTerm(ctx, &models.GlossaryTerm{ID: uuid.New().String(), Term: strings.ToUpper(term.Term), CreatedAt: now, UpdatedAt: now})
+	require.NoError(t, err)
+	assert.False(t, created, "terms are unique regardless of case")
+
+	term.Definition = "A term used in tests"
+	term.UpdatedAt = now.Add(time.Minute)
+	found, err := repo.UpdateGlossaryTerm(ctx, term)
+	require.NoError(t, err)
+	require.True(t, found)
+
+	all, err := repo.ListAllGlossaryTerms(ctx)
+	require.NoError(t, err)
+	i := slices.IndexFunc(all, func(g *models.GlossaryTerm) bool { return g.ID == term.ID })
+	require.NotEqual(t, -1, i)
+	assert.Equal(t, "A term used in tests", all[i].Definition)
+	assert.Equal(t, "alice", all[i].CreatedBy)
+
+	require.NoError(t, repo.DeleteGlossaryTerm(ctx, term.ID))
+	missing, err := repo.GetGlossaryTerm(ctx, term.ID)
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+}
+
+func TestPostgresRepository_Integration_DocumentUploadURL(t *testing.T) {
+	repo := setupIntegration(t)
+	defer repo.Close()
+	ctx := context.Background()
+
+	issuedAt := time.Now().Truncate(time.Microsecond)
+	expiresAt := issuedAt.Add(15 * time.Minute)
+	docID := uuid.New().String()
+	require.NoError(t, repo.CreateDocument(ctx, &models.Document{
+		ID:                 docID,
+		Filename:           "upload_url_test.pdf",
+		FileSize:           1024,
+		Status:             "pending",
+		CreatedAt:          issuedAt,
+		UploadURLIssuedAt:  &issuedAt,
+		UploadURLExpiresAt: &expiresAt,
+	}))
+	defer repo.DeleteDocument(ctx, docID)
+
+	fetched, err := repo.GetDocument(ctx, docID)
+	require.NoError(t, err)
+	require.NotNil(t, fetched.UploadURLExpiresAt)
+	assert.True(t, expiresAt.Equal(*fetched.UploadURLExpiresAt))
+
+	reissuedAt := issuedAt.Add(time.Hour)
+	require.NoError(t, repo.SetDocumentUploadURL(ctx, docID, reissuedAt, reissuedAt.Add(15*time.Minute)))
+
+	fetched, err = repo.GetDocument(ctx, docID)
+	require.NoError(t, err)
+	assert.True(t, reissuedAt.Equal(*fetched.UploadURLIssuedAt))
+	assert.True(t, reissuedAt.Add(15*time.Minute).Equal(*fetched.UploadURLExpiresAt))
+}
+
+func TestPostgresRepository_Integration_DocumentMetadataVersion(t *testing.T) {
+	repo := setupIntegration(t)
+	defer repo.Close()
+	ctx := context.Background()
+
+	docID := uuid.New().String()
+	require.NoError(t, repo.CreateDocument(ctx, &models.Document{
+		ID:        docID,
+		Filename:  "metadata_version_test.pdf",
+		FileSize:  1024,
+		Status:    "complete",
+		CreatedAt: time.Now(),
+	}))
+	defer repo.DeleteDocument(ctx, docID)
+
+	fetched, err := repo.GetDocument(ctx, docID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, fetched.Version)
+
+	updated, err := repo.UpdateDocumentDetails(ctx, docID, map[string]string{"team": "finance"}, "", 1)
+	require.NoError(t, err)
+	assert.True(t, updated)
+
+	// A second editor still holding version 1 loses.
+	updated, err = repo.UpdateDocumentDetails(ctx, docID, map[string]string{"team": "legal"}, "", 1)
+	require.NoError(t, err)
+	assert.False(t, updated)
+
+	fetched, err = repo.GetDocument(ctx, docID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, fetched.Version)
+	assert.Equal(t, map[string]string{"team": "finance"}, fetched.Metadata)
+
+	// A rename alone leaves the metadata as it was.
+	updated, err = repo.UpdateDocumentDetails(ctx, docID, nil, "renamed.pdf", 2)
+	require.NoError(t, err)
+	assert.True(t, updated)
+
+	fetched, err = repo.GetDocument(ctx, docID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, fetched.Version)
+	assert.Equal(t, "renamed.pdf", fetched.Filename)
+	assert.Equal(t, map[string]string{"team": "finance"}, fetched.Metadata)
+}
+
+func TestPostgresRepository_Integration_SavedSearches(t *testing.T) {
+	repo := setupIntegration(t)
+	defer repo.Close()
+	ctx := context.Background()
+
+	tag := uuid.New().String()
+	docID := uuid.New().String()
+	require.NoError(t, repo.CreateDocument(ctx, &models.Document{
+		ID:        docID,
+		Filename:  "Quarterly_Report_" + tag + ".pdf",
+		FileSize:  1024,
+		Status:    "complete",
+		Metadata:  map[string]string{"team": "finance", "run": tag},
+		CreatedAt: time.Now(),
+	}))
+	defer repo.DeleteDocument(ctx, docID)
+
+	now := time.Now().Truncate(time.Microsecond)
+	search := &models.SavedSearch{
+		ID:        uuid.New().String(),
+		Name:      "Finance reports " + tag,
+		Filter:    models.DocumentFilter{Metadata: map[string]string{"run": tag}, Query: "quarterly"},
+		CreatedBy: "alice",
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	require.NoError(t, repo.CreateSavedSearch(ctx, search))
+	defer repo.DeleteSavedSearch(ctx, search.ID)
+
+	fetched, err := repo.GetSavedSearch(ctx, search.ID)
+	require.NoError(t, err)
+	require.NotNil(t, fetched)
+	assert.Equal(t, search.Filter, fetched.Filter)
+	assert.Equal(t, "alice", fetched.CreatedBy)
+
+	// The saved filter matches on metadata and filename text.
+	list, total, err := repo.ListDocuments(ctx, 10, 0, fetched.Filter)
+	require.NoError(t, err)
+	require.Equal(t, 1, total)
+	assert.Equal(t, docID, list[0].ID)
+
+	list, total, err = repo.ListDocuments(ctx, 10, 0, models.DocumentFilter{Metadata: map[string]string{"run": tag, "team": "legal"}})
+	require.NoError(t, err)
+	assert.Equal(t, 0, total)
+	assert.Empty(t, list)
+
+	search.Name = "Renamed " + tag
+	updated, err := repo.UpdateSavedSearch(ctx, search)
+	require.NoError(t, err)
+	assert.True(t, updated)
+
+	fetched, err = repo.GetSavedSearch(ctx, search.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Renamed "+tag, fetched.Name)
+
+	require.NoError(t, repo.DeleteSavedSearch(ctx, search.ID))
+	fetched, err = repo.GetSavedSearch(ctx, search.ID)
+	require.NoError(t, err)
+	assert.Nil(t, fetched)
+}
+
+func TestPostgresRepository_Integration_Chunking(t *testing.T) {
+	repo := setupIntegration(t)
+	defer repo.Close()
+	ctx := context.Background()
+
+	docID := uuid.New().String()
+	require.NoError(t, repo.CreateDocument(ctx, &models.Document{
+		ID:        docID,
+		Filename:  "chunking_test.pdf",
+		Status:    "pending",
+		CreatedAt: time.Now(),
+		Chunking:  &models.ChunkingOptions{Strategy: models.ChunkingTable, Size: 1024, Overlap: 64},
+	}))
+	defer repo.DeleteDocument(ctx, docID)
+
+	fetched, err := repo.GetDocument(ctx, docID)
+	require.NoError(t, err)
+	require.NotNil(t, fetched.Chunking)
+	assert.Equal(t, models.ChunkingOptions{Strategy: models.ChunkingTable, Size: 1024, Overlap: 64}, *fetched.Chunking)
+
+	require.NoError(t, repo.SetDocumentChunking(ctx, docID, &models.ChunkingOptions{Strategy: models.ChunkingSentence}))
+	fetched, err = repo.GetDocument(ctx, docID)
+	require.NoError(t, err)
+	require.NotNil(t, fetched.Chunking)
+	assert.Equal(t, models.ChunkingSentence, fetched.Chunking.Strategy)
+	assert.Zero(t, fetched.Chunking.Size)
+
+	require.NoError(t, repo.SetDocumentChunking(ctx, docID, nil))
+	fetched, err = repo.GetDocument(ctx, docID)
+	require.NoError(t, err)
+	assert.Nil(t, fetched.Chunking)
+}
+
+func TestPostgresRepository_Integration_Processing(t *testing.T) {
+	repo := setupIntegration(t)
+	defer repo.Close()
+	ctx := context.Background()
+
+	ocr := false
+	docID := uuid.New().String()
+	require.NoError(t, repo.CreateDocument(ctx, &models.Document{
+		ID:         docID,
+		Filename:   "processing_test.pdf",
+		Status:     "pending",
+		CreatedAt:  time.Now(),
+		Processing: &models.ProcessingOptions{OCR: &ocr, Languages: []string{"de", "en"}},
+	}))
+	defer repo.DeleteDocument(ctx, docID)
+
+	fetched, err := repo.GetDocument(ctx, docID)
+	require.NoError(t, err)
+	require.NotNil(t, fetched.Processing)
+	require.NotNil(t, fetched.Processing.OCR)
+	assert.False(t, *fetched.Processing.OCR)
+	assert.Equal(t, []string{"de", "en"}, fetched.Processing.Languages)
+	assert.Nil(t, fetched.Processing.ExtractTables)
+
+	extract := true
+	require.NoError(t, repo.SetDocumentProcessing(ctx, docID, &models.ProcessingOptions{ExtractTables: &extract}))
+	fetched, err = repo.GetDocument(ctx, docID)
+	require.NoError(t, err)
+	require.NotNil(t, fetched.Processing)
+	assert.Nil(t, fetched.Processing.OCR)
+	require.NotNil(t, fetched.Processing.ExtractTables)
+	assert.True(t, *fetched.Processing.ExtractTables)
+
+	require.NoError(t, repo.SetDocumentProcessing(ctx, docID, nil))
+	fetched, err = repo.GetDocument(ctx, docID)
+	require.NoError(t, err)
+	assert.Nil(t, fetched.Processing)
+}
+
+func TestPostgresRepository_Integration_Scan(t *testing.T) {
+	repo := setupIntegration(t)
+	defer repo.Close()
+	ctx := context.Background()
+
+	docID := uuid.New().String()
+	require.NoError(t, repo.CreateDocument(ctx, &models.Document{
+		ID:        docID,
+		Filename:  "scan_test.pdf",
+		Status:    "pending",
+		S3Key:     "documents/" + docID + "/scan_test.pdf",
+		CreatedAt: time.Now(),
+	}))
+	defer repo.DeleteDocument(ctx, docID)
+
+	fetched, err := repo.GetDocument(ctx, docID)
+	require.NoError(t, err)
+	assert.Nil(t, fetched.Scan)
+
+	scannedAt := time.Now().Truncate(time.Second)
+	quarantined := "quarantine/documents/" + docID + "/scan_test.pdf"
+	require.NoError(t, repo.SetDocumentScan(ctx, docID, quarantined, &models.DocumentScan{
+		Result:    models.ScanInfected,
+		Signature: "Eicar-Signature",
+		ScannedAt: scannedAt,
+	}))
+	fetched, err = repo.GetDocument(ctx, docID)
+	require.NoError(t, err)
+	require.NotNil(t, fetched.Scan)
+	assert.Equal(t, models.ScanInfected, fetched.Scan.Result)
+	assert.Equal(t, "Eicar-Signature", fetched.Scan.Signature)
+	assert.True(t, scannedAt.Equal(fetched.Scan.ScannedAt))
+	assert.Equal(t, quarantined, fetched.S3Key)
+
+	// A deleted file leaves the document without a key.
+	require.NoError(t, repo.SetDocumentScan(ctx, docID, "", &models.DocumentScan{Result: models.ScanInfected, ScannedAt: scannedAt}))
+	fetched, err = repo.GetDocument(ctx, docID)
+	require.NoError(t, err)
+	assert.Empty(t, fetched.S3Key)
+}
+
+func TestPostgresRepository_Integration_Trash(t *testing.T) {
+	repo := setupIntegration(t)
+	defer repo.Close()
+	ctx := context.Background()
+
+	docID := uuid.New().String()
+	require.NoError(t, repo.CreateDocument(ctx, &models.Document{
+		ID:        docID,
+		Filename:  "trash_test_" + docID + ".pdf",
+		FileSize:  2048,
+		Status:    "complete",
+		CreatedAt: time.Now(),
+	}))
+	defer repo.DeleteDocument(ctx, docID)
+
+	trashedAt := time.Now().Add(-48 * time.Hour)
+	trashed, err := repo.TrashDocument(ctx, docID, trashedAt)
+	require.NoError(t, err)
+	assert.True(t, trashed)
+
+	trashed, err = repo.TrashDocument(ctx, docID, time.Now())
+	require.NoError(t, err)
+	assert.False(t, trashed, "a trashed document cannot be trashed again")
+
+	fetched, err := repo.GetDocument(ctx, docID)
+	require.NoError(t, err)
+	require.NotNil(t, fetched.DeletedAt)
+
+	// Trashed documents are left out of listings.
+	_, total, err := repo.ListDocuments(ctx, 10, 0, models.DocumentFilter{Query: docID})
+	require.NoError(t, err)
+	assert.Equal(t, 0, total)
+
+	expired, err := repo.ListExpiredTrash(ctx, time.Now().Add(-24*time.Hour), 100, 0)
+	require.NoError(t, err)
+	found := false
+	for _, doc := range expired {
+		found = found || doc.ID == docID
+	}
+	assert.True(t, found, "document trashed two days ago should have expired")
+
+	stats, err := repo.GetTrashStats(ctx)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, stats.Bytes, int64(2048))
+
+	restored, err := repo.RestoreDocument(ctx, docID)
+	require.NoError(t, err)
+	assert.True(t, restored)
+
+	_, total, err = repo.ListDocuments(ctx, 10, 0, models.DocumentFilter{Query: docID})
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+
+	now := time.Now().Truncate(time.Microsecond)
+	purgeID := uuid.New().String()
+	require.NoError(t, repo.CreateTrashPurge(ctx, &models.TrashPurge{
+		ID: purgeID, StartedAt: now, FinishedAt: now.Add(time.Second), Purged: 1, ReclaimedBytes: 4096,
+	}))
+	defer repo.DB().ExecContext(ctx, "DELETE FROM trash_purges WHERE id = $1", purgeID)
+
+	stats, err = repo.GetTrashStats(ctx)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, stats.ReclaimedBytes, int64(4096))
+	require.NotNil(t, stats.LastPurge)
+}
+
+func TestPostgresRepository_Integration_TrashDocuments(t *testing.T) {
+	repo := setupIntegration(t)
+	defer repo.Close()
+	ctx := context.Background()
+
+	docIDs := []string{uuid.New().String(), uuid.New().String()}
+	for _, docID := range docIDs {
+		require.NoError(t, repo.CreateDocument(ctx, &models.Document{
+			ID:        docID,
+			Filename:  "trash_batch_" + docID + ".pdf",
+			Status:    "complete",
+			CreatedAt: time.Now(),
+		}))
+		defer repo.DeleteDocument(ctx, docID)
+	}
+
+	trashed, err := repo.TrashDocument(ctx, docIDs[1], time.Now())
+	require.NoError(t, err)
+	require.True(t, trashed)
+
+	// Already trashed and missing documents are left out.
+	ids, err := repo.TrashDocuments(ctx, append(docIDs, uuid.New().String()), time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, []string{docIDs[0]}, ids)
+
+	fetched, err := repo.GetDocument(ctx, docIDs[0])
+	require.NoError(t, err)
+	assert.NotNil(t, fetched.DeletedAt)
+}
+
+func TestPostgresRepository_Integration_MultipartUploads(t *testing.T) {
+	repo := setupIntegration(t)
+	defer repo.Close()
+	ctx := context.Background()
+
+	docID := uuid.New().String()
+	require.NoError(t, repo.CreateDocument(ctx, &models.Document{
+		ID:        docID,
+		Filename:  "multipart_test_" + docID + ".pdf",
+		Status:    "pending",
+		CreatedAt: time.Now(),
+	}))
+	defer repo.DeleteDocument(ctx, docID)
+
+	upload, err := repo.GetMultipartUpload(ctx, docID)
+	require.NoError(t, err)
+	assert.Nil(t, upload)
+
+	require.NoError(t, repo.CreateMultipartUpload(ctx, &models.MultipartUpload{
+		DocumentID: docID,
+		UploadID:   "upload-1",
+		PartSize:   64 << 20,
+		PartCount:  2,
+		CreatedAt:  time.Now(),
+	}))
+	require.NoError(t, repo.RecordMultipartUploadPart(ctx, docID, models.MultipartUploadPart{PartNumber: 2, ETag: "b", UploadedAt: time.Now()}))
+	require.NoError(t, repo.RecordMultipartUploadPart(ctx, docID, models.MultipartUploadPart{PartNumber: 1, ETag: "stale", UploadedAt: time.Now()}))
+	// A part uploaded again replaces the earlier one.
+	require.NoError(t, repo.RecordMultipartUploadPart(ctx, docID, models.MultipartUploadPart{PartNumber: 1, ETag: "a", UploadedAt: time.Now()}))
+
+	upload, err = repo.GetMultipartUpload(ctx, docID)
+	require.NoError(t, err)
+	require.NotNil(t, upload)
+	assert.Equal(t, "upload-1", upload.UploadID)
+	assert.Equal(t, int64(64<<20), upload.PartSize)
+	assert.Nil(t, upload.CompletedAt)
+	if assert.Len(t, upload.Parts, 2) {
+		assert.Equal(t, 1, upload.Parts[0].PartNumber)
+		assert.Equal(t, "a", upload.Parts[0].ETag)
+		assert.Equal(t, 2, upload.Parts[1].PartNumber)
+	}
+
+	require.NoError(t, repo.CompleteMultipartUpload(ctx, docID, time.Now()))
+	upload, err = repo.GetMultipartUpload(ctx, docID)
+	require.NoError(t, err)
+	assert.NotNil(t, upload.CompletedAt)
+
+	require.NoError(t, repo.DeleteMultipartUpload(ctx, docID))
+	upload, err = repo.GetMultipartUpload(ctx, docID)
+	require.NoError(t, err)
+	assert.Nil(t, upload)
+}
+
+func TestPostgresRepository_Integration_Tags(t *testing.T) {
+	repo := setupIntegration(t)
+	defer repo.Close()
+	ctx := context.Background()
+
+	tag, other := "tag-test-"+uuid.New().String(), "tag-other-"+uuid.New().String()
+	var ids []string
+	for i := 0; i < 2; i++ {
+		docID := uuid.New().String()
+		require.NoError(t, repo.CreateDocument(ctx, &models.Document{
+			ID:        docID,
+			Filename:  "tags_test_" + docID + ".pdf",
+			Status:    "complete",
+			CreatedAt: time.Now(),
+		}))
+		defer repo.DeleteDocument(ctx, docID)
+		ids = append(ids, docID)
+	}
+
+	updated, err := repo.AddDocumentTags(ctx, ids, []string{tag})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, ids, updated)
+	updated, err = repo.AddDocumentTags(ctx, ids[:1], []string{tag, other})
+	require.NoError(t, err)
+	assert.Equal(t, ids[:1], updated)
+
+	// Adding tags a document already carries changes nothing.
+	updated, err = repo.AddDocumentTags(ctx, ids[:1], []string{tag})
+	require.NoError(t, err)
+	assert.Empty(t, updated)
+
+	docs, total, err := repo.ListDocuments(ctx, 10, 0, models.DocumentFilter{Tag: other})
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	if assert.Len(t, docs, 1) {
+		assert.ElementsMatch(t, []string{tag, other}, docs[0].Tags)
+	}
+
+	// Renaming onto a tag in use merges the two.
+	updated, err = repo.RenameTag(ctx, other, tag)
+	require.NoError(t, err)
+	assert.Equal(t, ids[:1], updated)
+	doc, err := repo.GetDocument(ctx, ids[0])
+	require.NoError(t, err)
+	assert.Equal(t, []string{tag}, doc.Tags)
+
+	tags, err := repo.ListTags(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, tags, models.TagCount{Tag: tag, Documents: 2})
+
+	updated, err = repo.RemoveDocumentTags(ctx, ids, []string{tag})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, ids, updated)
+	doc, err = repo.GetDocument(ctx, ids[1])
+	require.NoError(t, err)
+	assert.Empty(t, doc.Tags)
+}
+
+func TestPostgresRepository_Integration_Snapshots(t *testing.T) {
+	repo := setupIntegration(t)
+	defer repo.Close()
+	ctx := context.Background()
+
+	docID := uuid.New().String()
+	require.NoError(t, repo.CreateDocument(ctx, &models.Document{
+		ID:        docID,
+		Filename:  "snapshot_test_" + docID + ".pdf",
+		Status:    "complete",
+		CreatedAt: time.Now(),
+	}))
+	defer repo.DeleteDocument(ctx, docID)
+
+	snapshot := &models.Snapshot{
+		ID:               uuid.New().String(),
+		Name:             "snapshot-test-" + uuid.New().String(),
+		Status:           models.SnapshotStatusCreating,
+		SourceCollection: "documents",
+		Collection:       "documents_snapshot_test",
+		ConfigVersion:    "v1.4.0",
+		CreatedAt:        time.Now(),
+	}
+	created, err := repo.CreateSnapshot(ctx, snapshot)
+	require.NoError(t, err)
+	require.True(t, created)
+	defer repo.DeleteSnapshot(ctx, snapshot.ID)
+	assert.Positive(t, snapshot.DocumentCount)
+
+	// Names are unique.
+	created, err = repo.CreateSnapshot(ctx, &models.Snapshot{
+		ID: uuid.New().String(), Name: snapshot.Name, Status: models.SnapshotStatusCreating, CreatedAt: time.Now(),
+	})
+	require.NoError(t, err)
+	assert.False(t, created)
+
+	docs, total, err := repo.ListSnapshotDocuments(ctx, snapshot.ID, snapshot.DocumentCount, 0)
+	require.NoError(t, err)
+	assert.Equal(t, snapshot.DocumentCount, total)
+	var found bool
+	for _, doc := range docs {
+		found = found || doc.ID == docID
+	}
+	assert.True(t, found, "snapshot should record the indexed document")
+
+	now := time.Now()
+	snapshot.Status, snapshot.QdrantSnapshot, snapshot.VectorCount, snapshot.CompletedAt = models.SnapshotStatusReady, "documents.snapshot", 12, &now
+	require.NoError(t, repo.FinishSnapshot(ctx, snapshot))
+	got, err := repo.GetSnapshotByName(ctx, snapshot.Name)
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.Equal(t, models.SnapshotStatusReady, got.Status)
+	assert.Equal(t, "v1.4.0", got.ConfigVersion)
+	assert.Equal(t, int64(12), got.VectorCount)
+	assert.NotNil(t, got.CompletedAt)
+
+	require.NoError(t, repo.DeleteSnapshot(ctx, snapshot.ID))
+	got, err = repo.GetSnapshot(ctx, snapshot.ID)
+	require.NoError(t, err)
+	assert.Nil(t, got)
+}
+
+func TestPostgresRepository_Integration_Collections(t *testing.T) {
+	repo := setupIntegration(t)
+	defer repo.Close()
+	ctx := context.Background()
+
+	var docIDs []string
+	for i := 0; i < 2; i++ {
+		docID := uuid.New().String()
+		require.NoError(t, repo.CreateDocument(ctx, &models.Document{
+			ID:        docID,
+			Filename:  "collection_test_" + docID + ".pdf",
+			FileSize:  1024,
+			Status:    "complete",
+			CreatedAt: time.Now(),
+		}))
+		defer repo.DeleteDocument(ctx, docID)
+		docIDs = append(docIDs, docID)
+	}
+
+	collection := &models.Collection{
+		ID:        uuid.New().String(),
+		Name:      "Contracts " + uuid.New().String(),
+		CreatedBy: "alice",
+		CreatedAt: time.Now().Truncate(time.Microsecond),
+	}
+	created, err := repo.CreateCollection(ctx, collection)
+	require.NoError(t, err)
+	require.True(t, created)
+	defer repo.DeleteCollection(ctx, collection.ID)
+
+	created, err = repo.CreateCollection(ctx, &models.Collection{ID: uuid.New().String(), Name: collection.Name, CreatedAt: time.Now()})
+	require.NoError(t, err)
+	assert.False(t, created, "collection names are unique")
+
+	// Unknown documents are skipped, and adding a document twice is a no-op.
+	added, err := repo.AddCollectionDocuments(ctx, collection.ID, append(docIDs, uuid.New().String()))
+	require.NoError(t, err)
+	assert.Equal(t, 2, added)
+	added, err = repo.AddCollectionDocuments(ctx, collection.ID, docIDs[:1])
+	require.NoError(t, err)
+	assert.Equal(t, 0, added)
+
+	fetched, err := repo.GetCollection(ctx, collection.ID)
+	require.NoError(t, err)
+	require.NotNil(t, fetched)
+	assert.Equal(t, 2, fetched.DocumentCount)
+	assert.Equal(t, "alice", fetched.CreatedBy)
+
+	list, total, err := repo.ListDocuments(ctx, 10, 0, models.DocumentFilter{CollectionID: collection.ID})
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	assert.Len(t, list, 2)
+
+	// Trashed documents stay in the collection but are left out.
+	trashed, err := repo.TrashDocument(ctx, docIDs[1], time.Now())
+	require.NoError(t, err)
+	require.True(t, trashed)
+
+	fetched, err = repo.GetCollection(ctx, collection.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, fetched.DocumentCount)
+
+	ids, err := repo.ListDocumentCollectionIDs(ctx, docIDs[0])
+	require.NoError(t, err)
+	assert.Equal(t, []string{collection.ID}, ids)
+
+	removed, err := repo.RemoveCollectionDocuments(ctx, collection.ID, docIDs[:1])
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+
+	fetched, err = repo.GetCollection(ctx, collection.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, fetched.DocumentCount)
+
+	require.NoError(t, repo.DeleteCollection(ctx, collection.ID))
+	fetched, err = repo.GetCollection(ctx, collection.ID)
+	require.NoError(t, err)
+	assert.Nil(t, fetched)
+}
+
+func TestPostgresRepository_Integration_WorkspaceImports(t *testing.T) {
+	repo := setupIntegration(t)
+	defer repo.Close()
+	ctx := context.Background()
+
+	imp := &models.WorkspaceImport{
+		ID:               uuid.New().String(),
+		Status:           models.WorkspaceImportStatusRunning,
+		BundleExportedAt: time.Now().Add(-time.Hour).Truncate(time.Microsecond),
+		DocumentsTotal:   3,
+		CreatedBy:        "admin",
+		CreatedAt:        time.Now().Truncate(time.Microsecond),
+	}
+	require.NoError(t, repo.CreateWorkspaceImport(ctx, imp))
+
+	imp.DocumentsImported = 1
+	require.NoError(t, repo.UpdateWorkspaceImport(ctx, imp))
+
+	got, err := repo.GetWorkspaceImport(ctx, imp.ID)
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.Equal(t, models.WorkspaceImportStatusRunning, got.Status)
+	assert.Equal(t, 3, got.DocumentsTotal)
+	assert.Equal(t, 1, got.DocumentsImported)
+	assert.Nil(t, got.Failures)
+	assert.Nil(t, got.CompletedAt)
+
+	completedAt := time.Now()
+	imp.Status = models.WorkspaceImportStatusCompleted
+	imp.DocumentsSkipped = 1
+	imp.DocumentsFailed = 1
+	imp.Failures = []models.WorkspaceImportFailure{{DocumentID: "doc-3", Filename: "c.pdf", Error: "Failed to download file"}}
+	imp.ConversationsImported = 2
+	imp.CompletedAt = &completedAt
+	require.NoError(t, repo.UpdateWorkspaceImport(ctx, imp))
+
+	got, err = repo.GetWorkspaceImport(ctx, imp.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.WorkspaceImportStatusCompleted, got.Status)
+	assert.Equal(t, 1, got.DocumentsFailed)
+	assert.Equal(t, 2, got.ConversationsImported)
+	require.Len(t, got.Failures, 1)
+	assert.Equal(t, "c.pdf", got.Failures[0].Filename)
+	assert.NotNil(t, got.CompletedAt)
+
+	imports, total, err := repo.ListWorkspaceImports(ctx, 100, 0)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, total, 1)
+	for _, listed := range imports {
+		assert.Nil(t, listed.Failures)
+	}
+
+	missing, err := repo.GetWorkspaceImport(ctx, uuid.New().String())
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+}
+
+func TestPostgresRepository_Integration_SchemaVersion(t *testing.T) {
+	repo := setupIntegration(t)
+	defer repo.Close()
+
+	version, err := repo.AppliedSchemaVersion(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, repository.SchemaVersion, version, "schema.sql and repository.SchemaVersion disagree")
+}
+
+func TestPostgresRepository_Integration_ConversationParticipants(t *testing.T) {
+	repo := setupIntegration(t)
+	defer repo.Close()
+	ctx := context.Background()
+
+	now := time.Now().Truncate(time.Microsecond)
+	convID := uuid.New().String()
+	require.NoError(t, repo.CreateConversation(ctx, &models.Conversation{ID: convID, CreatedAt: now, UpdatedAt: now}))
+	for i, content := range []string{"Refund policy?", "30 days."} {
+		require.NoError(t, repo.CreateMessage(ctx, &models.Message{
+			ID:             uuid.New().String(),
+			ConversationID: convID,
+			Role:           []string{"user", "assistant"}[i],
+			Content:        content,
+			CreatedAt:      now.Add(time.Duration(i) * time.Second),
+		}))
+	}
+
+	added, err := repo.AddConversationParticipant(ctx, &models.ConversationParticipant{ConversationID: convID, Username: "alice", JoinedAt: now})
+	require.NoError(t, err)
+	assert.True(t, added)
+	added, err = repo.AddConversationParticipant(ctx, &models.ConversationParticipant{ConversationID: convID, Username: "bob", InvitedBy: "alice", JoinedAt: now.Add(time.Second)})
+	require.NoError(t, err)
+	assert.True(t, added)
+	added, err = repo.AddConversationParticipant(ctx, &models.ConversationParticipant{ConversationID: convID, Username: "bob", JoinedAt: now})
+	require.NoError(t, err)
+	assert.False(t, added, "an existing participant is left as is")
+
+	marked, err := repo.MarkConversationRead(ctx, convID, "alice", now)
+	require.NoError(t, err)
+	assert.True(t, marked)
+	// Read state never moves back.
+	_, err = repo.MarkConversationRead(ctx, convID, "alice", now.Add(-time.Hour))
+	require.NoError(t, err)
+	marked, err = repo.MarkConversationRead(ctx, convID, "mallory", now)
+	require.NoError(t, err)
+	assert.False(t, marked)
+
+	participants, err := repo.ListConversationParticipants(ctx, convID)
+	require.NoError(t, err)
+	require.Len(t, participants, 2)
+	assert.Equal(t, "alice", participants[0].Username)
+	require.NotNil(t, participants[0].LastReadAt)
+	assert.True(t, now.Equal(*participants[0].LastReadAt))
+	assert.Equal(t, 1, participants[0].UnreadCount)
+	assert.Equal(t, "bob", participants[1].Username)
+	assert.Equal(t, "alice", participants[1].InvitedBy)
+	assert.Nil(t, participants[1].LastReadAt)
+	assert.Equal(t, 2, participants[1].UnreadCount)
+
+	removed, err := repo.RemoveConversationParticipant(ctx, convID, "bob")
+	require.NoError(t, err)
+	assert.True(t, removed)
+	participant, err := repo.GetConversationParticipant(ctx, convID, "bob")
+	require.NoError(t, err)
+	assert.Nil(t, participant)
+}
+
+func TestPostgresRepository_Integration_ListConversationsOfUser(t *testing.T) {
+	repo := setupIntegration(t)
+	defer repo.Close()
+	ctx := context.Background()
+
+	now := time.Now().Truncate(time.Microsecond)
+	alice, bob, carol := "alice-"+uuid.New().String(), "bob-"+uuid.New().String(), "carol-"+uuid.New().String()
+	own, shared, other := uuid.New().String(), uuid.New().String(), uuid.New().String()
+	require.NoError(t, repo.CreateConversation(ctx, &models.Conversation{ID: own, CreatedAt: now, UpdatedAt: now, CreatedBy: alice}))
+	require.NoError(t, repo.CreateConversation(ctx, &models.Conversation{ID: shared, CreatedAt: now.Add(time.Second), UpdatedAt: now, CreatedBy: bob}))
+	require.NoError(t, repo.CreateConversation(ctx, &models.Conversation{ID: other, CreatedAt: now.Add(2 * time.Second), UpdatedAt: now, CreatedBy: carol}))
+	_, err := repo.AddConversationParticipant(ctx, &models.ConversationParticipant{ConversationID: shared, Username: alice, InvitedBy: bob, JoinedAt: now})
+	require.NoError(t, err)
+
+	conversations, total, err := repo.ListConversations(ctx, alice, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	require.Len(t, conversations, 2)
+	assert.Equal(t, shared, conversations[0].ID)
+	assert.Equal(t, own, conversations[1].ID)
+
+	conversations, total, err = repo.ListConversations(ctx, carol, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	require.Len(t, conversations, 1)
+	assert.Equal(t, other, conversations[0].ID, "conversations of other users are not listed")
+}
+
+func TestPostgresRepository_Integration_DocumentSearch(t *testing.T) {
+	repo := setupIntegration(t)
+	defer repo.Close()
+	ctx := context.Background()
+
+	run := uuid.New().String()
+	march := time.Date(2026, 3, 12, 9, 0, 0, 0, time.UTC)
+	docs := []*models.Document{
+		{ID: uuid.New().String(), Filename: "q1_refunds.pdf", CreatedAt: march},
+		{ID: uuid.New().String(), Filename: "notes_100%.md", CreatedAt: march.AddDate(0, 1, 0)},
+		{ID: uuid.New().String(), Filename: "policy.docx", Title: "Refund policy and returns", CreatedAt: march.AddDate(0, 0, 1)},
+	}
+	for _, doc := range docs {
+		doc.FileSize, doc.Status, doc.Metadata = 1, "complete", map[string]string{"run": run}
+		require.NoError(t, repo.CreateDocument(ctx, doc))
+		defer repo.DeleteDocument(ctx, doc.ID)
+	}
+	require.NoError(t, repo.SetDocumentTitle(ctx, docs[0].ID, "Refunds in the first quarter"))
+
+	search := func(filter models.DocumentFilter) []string {
+		t.Helper()
+		filter.Metadata = map[string]string{"run": run}
+		list, total, err := repo.ListDocuments(ctx, 10, 0, filter)
+		require.NoError(t, err)
+		assert.Equal(t, len(list), total)
+		var ids []string
+		for _, doc := range list {
+			ids = append(ids, doc.ID)
+		}
+		return ids
+	}
+
+	// Titles are matched word for word, without stemming.
+	assert.Equal(t, []string{docs[0].ID}, search(models.DocumentFilter{Text: "refunds quarter"}))
+	assert.Equal(t, []string{docs[2].ID}, search(models.DocumentFilter{Text: `"refund policy"`}))
+	assert.ElementsMatch(t, []string{docs[0].ID, docs[2].ID}, search(models.DocumentFilter{Text: "refund OR refunds"}))
+	assert.Empty(t, search(models.DocumentFilter{Text: "refund -returns"}))
+
+	assert.Equal(t, []string{docs[0].ID}, search(models.DocumentFilter{Filename: "Q1*.PDF"}))
+	assert.Equal(t, []string{docs[1].ID}, search(models.DocumentFilter{Filename: "100%"}))
+	assert.Empty(t, search(models.DocumentFilter{Filename: "q_"}), "% and _ match literally")
+
+	from, to := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, []string{docs[2].ID, docs[0].ID}, search(models.DocumentFilter{CreatedFrom: &from, CreatedTo: &to}))
+
+	fetched, err := repo.GetDocument(ctx, docs[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Refunds in the first quarter", fetched.Title)
+}
+
+func TestPostgresRepository_Integration_KnowledgeBaseExports(t *testing.T) {
+	repo := setupIntegration(t)
+	defer repo.Close()
+	ctx := context.Background()
+
+	username := "export-test-" + uuid.New().String()
+	export := &models.KnowledgeBaseExport{
+		ID:            uuid.New().String(),
+		Status:        models.KnowledgeBaseExportRunning,
+		Format:        models.ExportFormatZip,
+		Filter:        models.DocumentFilter{Tag: "hr"},
+		DocumentCount: 3,
+		ManifestKey:   "exports/test/manifest.json",
+		ArchiveKey:    "exports/test/knowledge-base.zip",
+		CreatedBy:     username,
+		CreatedAt:     time.Now(),
+	}
+	require.NoError(t, repo.CreateKnowledgeBaseExport(ctx, export))
+
+	exports, total, err := repo.ListKnowledgeBaseExports(ctx, username, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	require.Len(t, exports, 1)
+	assert.Equal(t, "hr", exports[0].Filter.Tag)
+
+	now := time.Now()
+	export.Status, export.DocumentsExported, export.SizeBytes, export.CompletedAt = models.KnowledgeBaseExportReady, 3, 2048, &now
+	require.NoError(t, repo.UpdateKnowledgeBaseExport(ctx, export))
+
+	// Finished exports no longer change.
+	export.Status, export.Error = models.KnowledgeBaseExportFailed, "late failure"
+	require.NoError(t, repo.UpdateKnowledgeBaseExport(ctx, export))
+
+	got, err := repo.GetKnowledgeBaseExport(ctx, export.ID)
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.Equal(t, models.KnowledgeBaseExportReady, got.Status)
+	assert.Equal(t, 3, got.DocumentsExported)
+	assert.Equal(t, int64(2048), got.SizeBytes)
+	assert.Empty(t, got.Error)
+	assert.NotNil(t, got.CompletedAt)
+}
+
+func TestPostgresRepository_Integration_AccessGroups(t *testing.T) {
+	repo := setupIntegration(t)
+	defer repo.Close()
+	ctx := context.Background()
+
+	group := "group-" + uuid.New().String()
+	docs := []*models.Document{
+		{ID: uuid.New().String(), Filename: "handbook.pdf"},
+		{ID: uuid.New().String(), Filename: "salaries.xlsx"},
+	}
+	for _, doc := range docs {
+		doc.FileSize, doc.Status, doc.CreatedAt = 1, "complete", time.Now()
+		require.NoError(t, repo.CreateDocument(ctx, doc))
+		defer repo.DeleteDocument(ctx, doc.ID)
+	}
+
+	found, err := repo.SetDocumentAccessGroups(ctx, docs[1].ID, []string{group, group})
+	require.NoError(t, err)
+	assert.True(t, found)
+	found, err = repo.SetDocumentAccessGroups(ctx, uuid.New().String(), []string{group})
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	fetched, err := repo.GetDocument(ctx, docs[1].ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{group}, fetched.AccessGroups)
+
+	found, err = repo.SetDocumentAccessGroups(ctx, docs[1].ID, []string{})
+	require.NoError(t, err)
+	assert.True(t, found)
+	fetched, err = repo.GetDocument(ctx, docs[1].ID)
+	require.NoError(t, err)
+	assert.Empty(t, fetched.AccessGroups)
+}
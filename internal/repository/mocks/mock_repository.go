@@ -2,6 +2,7 @@ package mocks
 
 import (
 	"context"
+	"time"
 
 	"kb-platform-gateway/internal/models"
 	"kb-platform-gateway/internal/repository"
@@ -34,15 +35,45 @@ func (m *MockRepository) GetDocument(ctx context.Context, id string) (*models.Do
 	return args.Get(0).(*models.Document), args.Error(1)
 }
 
+func (m *MockRepository) GetDocumentsByIDs(ctx context.Context, ids []string) ([]*models.Document, error) {
+	args := m.Called(ctx, ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Document), args.Error(1)
+}
+
+// FindDocumentByContentHash mocks the FindDocumentByContentHash method.
+func (m *MockRepository) FindDocumentByContentHash(ctx context.Context, contentHash string) (*models.Document, error) {
+	args := m.Called(ctx, contentHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Document), args.Error(1)
+}
+
 // ListDocuments mocks the ListDocuments method.
-func (m *MockRepository) ListDocuments(ctx context.Context, limit, offset int, statusFilter string) ([]*models.Document, int, error) {
-	args := m.Called(ctx, limit, offset, statusFilter)
+func (m *MockRepository) ListDocuments(ctx context.Context, limit, offset int, filter models.DocumentFilter) ([]*models.Document, int, error) {
+	args := m.Called(ctx, limit, offset, filter)
 	if args.Get(0) == nil {
 		return nil, args.Int(1), args.Error(2)
 	}
 	return args.Get(0).([]*models.Document), args.Int(1), args.Error(2)
 }
 
+// ExportDocuments feeds the documents passed to Return to fn.
+func (m *MockRepository) ExportDocuments(ctx context.Context, filter models.DocumentFilter, fn func(*models.Document) error) error {
+	args := m.Called(ctx, filter, fn)
+	if documents, ok := args.Get(0).([]*models.Document); ok {
+		for _, doc := range documents {
+			if err := fn(doc); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(1)
+}
+
 // UpdateDocument mocks the UpdateDocument method.
 func (m *MockRepository) UpdateDocument(ctx context.Context, id string, updates map[string]interface{}) error {
 	args := m.Called(ctx, id, updates)
@@ -55,12 +86,117 @@ func (m *MockRepository) DeleteDocument(ctx context.Context, id string) error {
 	return args.Error(0)
 }
 
+// ListChildDocuments mocks the ListChildDocuments method.
+func (m *MockRepository) ListChildDocuments(ctx context.Context, parentID string, limit, offset int) ([]*models.Document, int, error) {
+	args := m.Called(ctx, parentID, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Int(1), args.Error(2)
+	}
+	return args.Get(0).([]*models.Document), args.Int(1), args.Error(2)
+}
+
+// CountChildDocuments mocks the CountChildDocuments method.
+func (m *MockRepository) CountChildDocuments(ctx context.Context, parentID string) (*models.ChildProgress, error) {
+	args := m.Called(ctx, parentID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ChildProgress), args.Error(1)
+}
+
 // UpdateDocumentStatus mocks the UpdateDocumentStatus method.
 func (m *MockRepository) UpdateDocumentStatus(ctx context.Context, id, status string, errorMessage string) error {
 	args := m.Called(ctx, id, status, errorMessage)
 	return args.Error(0)
 }
 
+// SetDocumentLanguage mocks the SetDocumentLanguage method.
+func (m *MockRepository) SetDocumentLanguage(ctx context.Context, id, language string) error {
+	args := m.Called(ctx, id, language)
+	return args.Error(0)
+}
+
+// SetDocumentTitle mocks the SetDocumentTitle method.
+func (m *MockRepository) SetDocumentTitle(ctx context.Context, id, title string) error {
+	args := m.Called(ctx, id, title)
+	return args.Error(0)
+}
+
+// UpdateDocumentDetails mocks the UpdateDocumentDetails method.
+func (m *MockRepository) UpdateDocumentDetails(ctx context.Context, id string, metadata map[string]string, filename string, version int) (bool, error) {
+	args := m.Called(ctx, id, metadata, filename, version)
+	return args.Bool(0), args.Error(1)
+}
+
+// SetDocumentIndexing mocks the SetDocumentIndexing method.
+func (m *MockRepository) SetDocumentIndexing(ctx context.Context, id, workflowID string) error {
+	args := m.Called(ctx, id, workflowID)
+	return args.Error(0)
+}
+
+// SetDocumentScan mocks the SetDocumentScan method.
+func (m *MockRepository) SetDocumentScan(ctx context.Context, id, s3Key string, scan *models.DocumentScan) error {
+	args := m.Called(ctx, id, s3Key, scan)
+	return args.Error(0)
+}
+
+// SetDocumentUploadURL mocks the SetDocumentUploadURL method.
+func (m *MockRepository) SetDocumentUploadURL(ctx context.Context, id string, issuedAt, expiresAt time.Time) error {
+	args := m.Called(ctx, id, issuedAt, expiresAt)
+	return args.Error(0)
+}
+
+// SetDocumentChunking mocks the SetDocumentChunking method.
+func (m *MockRepository) SetDocumentChunking(ctx context.Context, id string, chunking *models.ChunkingOptions) error {
+	args := m.Called(ctx, id, chunking)
+	return args.Error(0)
+}
+
+// SetDocumentProcessing mocks the SetDocumentProcessing method.
+func (m *MockRepository) SetDocumentProcessing(ctx context.Context, id string, processing *models.ProcessingOptions) error {
+	args := m.Called(ctx, id, processing)
+	return args.Error(0)
+}
+
+func (m *MockRepository) TrashDocument(ctx context.Context, id string, at time.Time) (bool, error) {
+	args := m.Called(ctx, id, at)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) TrashDocuments(ctx context.Context, ids []string, at time.Time) ([]string, error) {
+	args := m.Called(ctx, ids, at)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockRepository) RestoreDocument(ctx context.Context, id string) (bool, error) {
+	args := m.Called(ctx, id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) ListExpiredTrash(ctx context.Context, before time.Time, limit, offset int) ([]*models.Document, error) {
+	args := m.Called(ctx, before, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Document), args.Error(1)
+}
+
+func (m *MockRepository) CreateTrashPurge(ctx context.Context, purge *models.TrashPurge) error {
+	args := m.Called(ctx, purge)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetTrashStats(ctx context.Context) (*models.TrashStats, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TrashStats), args.Error(1)
+}
+
 // CreateConversation mocks the CreateConversation method.
 func (m *MockRepository) CreateConversation(ctx context.Context, conv *models.Conversation) error {
 	args := m.Called(ctx, conv)
@@ -97,6 +233,14 @@ func (m *MockRepository) CreateMessage(ctx context.Context, msg *models.Message)
 	return args.Error(0)
 }
 
+func (m *MockRepository) GetMessage(ctx context.Context, id string) (*models.Message, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Message), args.Error(1)
+}
+
 // GetMessagesByConversationID mocks the GetMessagesByConversationID method.
 func (m *MockRepository) GetMessagesByConversationID(ctx context.Context, conversationID string, limit, offset int) ([]*models.Message, error) {
 	args := m.Called(ctx, conversationID, limit, offset)
@@ -106,11 +250,1023 @@ func (m *MockRepository) GetMessagesByConversationID(ctx context.Context, conver
 	return args.Get(0).([]*models.Message), args.Error(1)
 }
 
+// ExportMessages feeds the messages passed to Return to fn.
+func (m *MockRepository) ExportMessages(ctx context.Context, conversationID string, fn func(*models.Message) error) error {
+	args := m.Called(ctx, conversationID, fn)
+	if messages, ok := args.Get(0).([]*models.Message); ok {
+		for _, msg := range messages {
+			if err := fn(msg); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(1)
+}
+
 // DeleteMessage mocks the DeleteMessage method.
 func (m *MockRepository) DeleteMessage(ctx context.Context, id string) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
 }
 
+// CreateWebhook mocks the CreateWebhook method.
+func (m *MockRepository) CreateWebhook(ctx context.Context, webhook *models.Webhook) error {
+	args := m.Called(ctx, webhook)
+	return args.Error(0)
+}
+
+// GetWebhook mocks the GetWebhook method.
+func (m *MockRepository) GetWebhook(ctx context.Context, id string) (*models.Webhook, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Webhook), args.Error(1)
+}
+
+// ListWebhooks mocks the ListWebhooks method.
+func (m *MockRepository) ListWebhooks(ctx context.Context) ([]*models.Webhook, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Webhook), args.Error(1)
+}
+
+// ListWebhooksForEvent mocks the ListWebhooksForEvent method.
+func (m *MockRepository) ListWebhooksForEvent(ctx context.Context, eventType string) ([]*models.Webhook, error) {
+	args := m.Called(ctx, eventType)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Webhook), args.Error(1)
+}
+
+// DeleteWebhook mocks the DeleteWebhook method.
+func (m *MockRepository) DeleteWebhook(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// CreateWebhookDelivery mocks the CreateWebhookDelivery method.
+func (m *MockRepository) CreateWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	args := m.Called(ctx, delivery)
+	return args.Error(0)
+}
+
+// UpdateWebhookDelivery mocks the UpdateWebhookDelivery method.
+func (m *MockRepository) UpdateWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	args := m.Called(ctx, delivery)
+	return args.Error(0)
+}
+
+// ListWebhookDeliveries mocks the ListWebhookDeliveries method.
+func (m *MockRepository) ListWebhookDeliveries(ctx context.Context, webhookID string, limit, offset int) ([]*models.WebhookDelivery, int, error) {
+	args := m.Called(ctx, webhookID, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Int(1), args.Error(2)
+	}
+	return args.Get(0).([]*models.WebhookDelivery), args.Int(1), args.Error(2)
+}
+
+// CreateEvent mocks the CreateEvent method.
+func (m *MockRepository) CreateEvent(ctx context.Context, event *models.Event) (bool, error) {
+	args := m.Called(ctx, event)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) GetDocumentStats(ctx context.Context) (*models.DocumentStats, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.DocumentStats), args.Error(1)
+}
+
+func (m *MockRepository) CountQueriesByDay(ctx context.Context, since time.Time) ([]models.DailyCount, error) {
+	args := m.Called(ctx, since)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.DailyCount), args.Error(1)
+}
+
+func (m *MockRepository) CountActiveConversations(ctx context.Context, since time.Time) (int, error) {
+	args := m.Called(ctx, since)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockRepository) CreateQueryLog(ctx context.Context, log *models.QueryLog) error {
+	args := m.Called(ctx, log)
+	return args.Error(0)
+}
+
+func (m *MockRepository) SetQueryFeedback(ctx context.Context, id, username string, rating int, comment string) (bool, error) {
+	args := m.Called(ctx, id, username, rating, comment)
+	return args.Bool(0), args.Error(1)
+}
+
+// ExportQueryLogs feeds the logs passed to Return to fn.
+func (m *MockRepository) ExportQueryLogs(ctx context.Context, from, to time.Time, fn func(*models.QueryLog) error) error {
+	args := m.Called(ctx, from, to, fn)
+	if logs, ok := args.Get(0).([]*models.QueryLog); ok {
+		for _, log := range logs {
+			if err := fn(log); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(1)
+}
+
+func (m *MockRepository) ListQueryCitations(ctx context.Context, queryIDs []string) (map[string][]models.Citation, error) {
+	args := m.Called(ctx, queryIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string][]models.Citation), args.Error(1)
+}
+
+func (m *MockRepository) SuggestQueries(ctx context.Context, text string, minUsers, limit int) ([]models.QuerySuggestion, error) {
+	args := m.Called(ctx, text, minUsers, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.QuerySuggestion), args.Error(1)
+}
+
+func (m *MockRepository) CountEvents(ctx context.Context, subjectID, eventType string) (int, error) {
+	args := m.Called(ctx, subjectID, eventType)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockRepository) GetNotificationPreferences(ctx context.Context, username string) (*models.NotificationPreferences, error) {
+	args := m.Called(ctx, username)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.NotificationPreferences), args.Error(1)
+}
+
+func (m *MockRepository) UpsertNotificationPreferences(ctx context.Context, prefs *models.NotificationPreferences) error {
+	args := m.Called(ctx, prefs)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetWorkspaceSettings(ctx context.Context) (*models.WorkspaceSettings, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.WorkspaceSettings), args.Error(1)
+}
+
+func (m *MockRepository) UpsertWorkspaceSettings(ctx context.Context, settings *models.WorkspaceSettings) error {
+	args := m.Called(ctx, settings)
+	return args.Error(0)
+}
+
+func (m *MockRepository) CreatePromptTemplate(ctx context.Context, tmpl *models.PromptTemplate) (bool, error) {
+	args := m.Called(ctx, tmpl)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) GetPromptTemplate(ctx context.Context, id string, version int) (*models.PromptTemplate, error) {
+	args := m.Called(ctx, id, version)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.PromptTemplate), args.Error(1)
+}
+
+func (m *MockRepository) ListPromptTemplates(ctx context.Context, limit, offset int) ([]*models.PromptTemplate, int, error) {
+	args := m.Called(ctx, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Int(1), args.Error(2)
+	}
+	return args.Get(0).([]*models.PromptTemplate), args.Int(1), args.Error(2)
+}
+
+func (m *MockRepository) AddPromptTemplateVersion(ctx context.Context, id string, version *models.PromptTemplateVersion, description *string) (bool, error) {
+	args := m.Called(ctx, id, version, description)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) ListPromptTemplateVersions(ctx context.Context, id string) ([]*models.PromptTemplateVersion, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.PromptTemplateVersion), args.Error(1)
+}
+
+func (m *MockRepository) DeletePromptTemplate(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) CreateEmbeddingMigration(ctx context.Context, migration *models.EmbeddingMigration) (bool, error) {
+	args := m.Called(ctx, migration)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) GetEmbeddingMigration(ctx context.Context, id string) (*models.EmbeddingMigration, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.EmbeddingMigration), args.Error(1)
+}
+
+func (m *MockRepository) ListEmbeddingMigrations(ctx context.Context, limit, offset int) ([]*models.EmbeddingMigration, int, error) {
+	args := m.Called(ctx, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Int(1), args.Error(2)
+	}
+	return args.Get(0).([]*models.EmbeddingMigration), args.Int(1), args.Error(2)
+}
+
+func (m *MockRepository) GetRunningEmbeddingMigration(ctx context.Context) (*models.EmbeddingMigration, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.EmbeddingMigration), args.Error(1)
+}
+
+func (m *MockRepository) GetActiveEmbeddingMigration(ctx context.Context) (*models.EmbeddingMigration, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.EmbeddingMigration), args.Error(1)
+}
+
+func (m *MockRepository) AddEmbeddingMigrationDocuments(ctx context.Context, id string) (int, error) {
+	args := m.Called(ctx, id)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockRepository) ClaimEmbeddingMigrationBatch(ctx context.Context, id string, limit int) ([]string, error) {
+	args := m.Called(ctx, id, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockRepository) CountQueuedEmbeddingMigrationDocuments(ctx context.Context, id string) (int, error) {
+	args := m.Called(ctx, id)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockRepository) RecordEmbeddingMigrationDocument(ctx context.Context, id, documentID, status string) (bool, error) {
+	args := m.Called(ctx, id, documentID, status)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) FinishEmbeddingMigration(ctx context.Context, id, status, errorMessage string) (bool, error) {
+	args := m.Called(ctx, id, status, errorMessage)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) CreateEvaluation(ctx context.Context, evaluation *models.Evaluation, cases []models.EvaluationCase) error {
+	args := m.Called(ctx, evaluation, cases)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetEvaluation(ctx context.Context, id string) (*models.Evaluation, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Evaluation), args.Error(1)
+}
+
+func (m *MockRepository) ListEvaluations(ctx context.Context, limit, offset int) ([]*models.Evaluation, int, error) {
+	args := m.Called(ctx, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Int(1), args.Error(2)
+	}
+	return args.Get(0).([]*models.Evaluation), args.Int(1), args.Error(2)
+}
+
+func (m *MockRepository) ListEvaluationResults(ctx context.Context, id string) ([]*models.EvaluationResult, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.EvaluationResult), args.Error(1)
+}
+
+func (m *MockRepository) RecordEvaluationResult(ctx context.Context, id string, result *models.EvaluationResult) error {
+	args := m.Called(ctx, id, result)
+	return args.Error(0)
+}
+
+func (m *MockRepository) FinishEvaluation(ctx context.Context, id, status, errorMessage string) error {
+	args := m.Called(ctx, id, status, errorMessage)
+	return args.Error(0)
+}
+
+func (m *MockRepository) CreateDuplicateReport(ctx context.Context, report *models.DuplicateReport) error {
+	args := m.Called(ctx, report)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetDuplicateReport(ctx context.Context, id string) (*models.DuplicateReport, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.DuplicateReport), args.Error(1)
+}
+
+func (m *MockRepository) ListDuplicateReports(ctx context.Context, limit, offset int) ([]*models.DuplicateReport, int, error) {
+	args := m.Called(ctx, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Int(1), args.Error(2)
+	}
+	return args.Get(0).([]*models.DuplicateReport), args.Int(1), args.Error(2)
+}
+
+func (m *MockRepository) SetDuplicateReportProgress(ctx context.Context, id string, scanned int) error {
+	args := m.Called(ctx, id, scanned)
+	return args.Error(0)
+}
+
+func (m *MockRepository) FinishDuplicateReport(ctx context.Context, report *models.DuplicateReport) error {
+	args := m.Called(ctx, report)
+	return args.Error(0)
+}
+
+func (m *MockRepository) CreateSnapshot(ctx context.Context, snapshot *models.Snapshot) (bool, error) {
+	args := m.Called(ctx, snapshot)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) GetSnapshot(ctx context.Context, id string) (*models.Snapshot, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Snapshot), args.Error(1)
+}
+
+func (m *MockRepository) GetSnapshotByName(ctx context.Context, name string) (*models.Snapshot, error) {
+	args := m.Called(ctx, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Snapshot), args.Error(1)
+}
+
+func (m *MockRepository) ListSnapshots(ctx context.Context, limit, offset int) ([]*models.Snapshot, int, error) {
+	args := m.Called(ctx, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Int(1), args.Error(2)
+	}
+	return args.Get(0).([]*models.Snapshot), args.Int(1), args.Error(2)
+}
+
+func (m *MockRepository) ListSnapshotDocuments(ctx context.Context, id string, limit, offset int) ([]*models.SnapshotDocument, int, error) {
+	args := m.Called(ctx, id, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Int(1), args.Error(2)
+	}
+	return args.Get(0).([]*models.SnapshotDocument), args.Int(1), args.Error(2)
+}
+
+func (m *MockRepository) FinishSnapshot(ctx context.Context, snapshot *models.Snapshot) error {
+	args := m.Called(ctx, snapshot)
+	return args.Error(0)
+}
+
+func (m *MockRepository) DeleteSnapshot(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) CreateKnowledgeBaseExport(ctx context.Context, export *models.KnowledgeBaseExport) error {
+	args := m.Called(ctx, export)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetKnowledgeBaseExport(ctx context.Context, id string) (*models.KnowledgeBaseExport, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.KnowledgeBaseExport), args.Error(1)
+}
+
+func (m *MockRepository) ListKnowledgeBaseExports(ctx context.Context, createdBy string, limit, offset int) ([]*models.KnowledgeBaseExport, int, error) {
+	args := m.Called(ctx, createdBy, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Int(1), args.Error(2)
+	}
+	return args.Get(0).([]*models.KnowledgeBaseExport), args.Int(1), args.Error(2)
+}
+
+func (m *MockRepository) UpdateKnowledgeBaseExport(ctx context.Context, export *models.KnowledgeBaseExport) error {
+	args := m.Called(ctx, export)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ListStaleDocuments(ctx context.Context, before time.Time, limit int) ([]*models.StaleDocument, int, error) {
+	args := m.Called(ctx, before, limit)
+	if args.Get(0) == nil {
+		return nil, args.Int(1), args.Error(2)
+	}
+	return args.Get(0).([]*models.StaleDocument), args.Int(1), args.Error(2)
+}
+
+func (m *MockRepository) ListBrokenSourceDocuments(ctx context.Context, limit int) ([]*models.StaleDocument, int, error) {
+	args := m.Called(ctx, limit)
+	if args.Get(0) == nil {
+		return nil, args.Int(1), args.Error(2)
+	}
+	return args.Get(0).([]*models.StaleDocument), args.Int(1), args.Error(2)
+}
+
+func (m *MockRepository) ListUnretrievedDocuments(ctx context.Context, since time.Time, limit int) ([]*models.StaleDocument, int, error) {
+	args := m.Called(ctx, since, limit)
+	if args.Get(0) == nil {
+		return nil, args.Int(1), args.Error(2)
+	}
+	return args.Get(0).([]*models.StaleDocument), args.Int(1), args.Error(2)
+}
+
+func (m *MockRepository) ClaimSourceChecks(ctx context.Context, checkedBefore time.Time, limit int) ([]models.SourceCheck, error) {
+	args := m.Called(ctx, checkedBefore, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.SourceCheck), args.Error(1)
+}
+
+func (m *MockRepository) RecordSourceStatus(ctx context.Context, documentID string, status int) error {
+	args := m.Called(ctx, documentID, status)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetDocumentAnalytics(ctx context.Context, documentID string) (*models.DocumentAnalytics, error) {
+	args := m.Called(ctx, documentID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.DocumentAnalytics), args.Error(1)
+}
+
+func (m *MockRepository) ListDocumentLeaderboard(ctx context.Context, since time.Time, order string, limit int) ([]*models.DocumentAnalytics, error) {
+	args := m.Called(ctx, since, order, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.DocumentAnalytics), args.Error(1)
+}
+
+func (m *MockRepository) CreateDocumentEvent(ctx context.Context, event *models.DocumentEvent) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ListDocumentEvents(ctx context.Context, documentID string, limit, offset int) ([]*models.DocumentEvent, int, error) {
+	args := m.Called(ctx, documentID, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Int(1), args.Error(2)
+	}
+	return args.Get(0).([]*models.DocumentEvent), args.Int(1), args.Error(2)
+}
+
+func (m *MockRepository) CreateServiceToken(ctx context.Context, token *models.ServiceToken) (bool, error) {
+	args := m.Called(ctx, token)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) GetServiceToken(ctx context.Context, id string) (*models.ServiceToken, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ServiceToken), args.Error(1)
+}
+
+func (m *MockRepository) GetServiceTokenByHash(ctx context.Context, hash string) (*models.ServiceToken, error) {
+	args := m.Called(ctx, hash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ServiceToken), args.Error(1)
+}
+
+func (m *MockRepository) ListServiceTokens(ctx context.Context) ([]*models.ServiceToken, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.ServiceToken), args.Error(1)
+}
+
+func (m *MockRepository) RotateServiceToken(ctx context.Context, token *models.ServiceToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockRepository) TouchServiceToken(ctx context.Context, id string, usedAt time.Time) error {
+	args := m.Called(ctx, id, usedAt)
+	return args.Error(0)
+}
+
+func (m *MockRepository) DeleteServiceToken(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) CreateImpersonationSession(ctx context.Context, session *models.ImpersonationSession) error {
+	args := m.Called(ctx, session)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetImpersonationSession(ctx context.Context, id string) (*models.ImpersonationSession, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ImpersonationSession), args.Error(1)
+}
+
+func (m *MockRepository) ListImpersonationSessions(ctx context.Context, limit, offset int) ([]*models.ImpersonationSession, int, error) {
+	args := m.Called(ctx, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Int(1), args.Error(2)
+	}
+	return args.Get(0).([]*models.ImpersonationSession), args.Int(1), args.Error(2)
+}
+
+func (m *MockRepository) RevokeImpersonationSession(ctx context.Context, id string, at time.Time) (bool, error) {
+	args := m.Called(ctx, id, at)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) RecordImpersonationRequest(ctx context.Context, request *models.ImpersonationRequest) error {
+	args := m.Called(ctx, request)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ListImpersonationRequests(ctx context.Context, sessionID string) ([]*models.ImpersonationRequest, error) {
+	args := m.Called(ctx, sessionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.ImpersonationRequest), args.Error(1)
+}
+
+func (m *MockRepository) CreateConnector(ctx context.Context, connector *models.Connector) error {
+	args := m.Called(ctx, connector)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetConnector(ctx context.Context, id string) (*models.Connector, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Connector), args.Error(1)
+}
+
+func (m *MockRepository) ListConnectors(ctx context.Context, username string) ([]*models.Connector, error) {
+	args := m.Called(ctx, username)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Connector), args.Error(1)
+}
+
+func (m *MockRepository) ListAllConnectors(ctx context.Context) ([]*models.Connector, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Connector), args.Error(1)
+}
+
+func (m *MockRepository) UpdateConnector(ctx context.Context, connector *models.Connector) error {
+	args := m.Called(ctx, connector)
+	return args.Error(0)
+}
+
+func (m *MockRepository) UpdateConnectorCredentials(ctx context.Context, id string, credentials []byte) error {
+	args := m.Called(ctx, id, credentials)
+	return args.Error(0)
+}
+
+func (m *MockRepository) DeleteConnector(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ClaimDueConnectors(ctx context.Context, now time.Time, limit int) ([]*models.Connector, error) {
+	args := m.Called(ctx, now, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Connector), args.Error(1)
+}
+
+func (m *MockRepository) FinishConnectorSync(ctx context.Context, id, syncErr string, at time.Time) error {
+	args := m.Called(ctx, id, syncErr, at)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetConnectorFile(ctx context.Context, connectorID, externalID string) (*models.ConnectorFile, error) {
+	args := m.Called(ctx, connectorID, externalID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ConnectorFile), args.Error(1)
+}
+
+func (m *MockRepository) UpsertConnectorFile(ctx context.Context, file *models.ConnectorFile) error {
+	args := m.Called(ctx, file)
+	return args.Error(0)
+}
+
+func (m *MockRepository) AddDocumentTags(ctx context.Context, documentIDs, tags []string) ([]string, error) {
+	args := m.Called(ctx, documentIDs, tags)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockRepository) RemoveDocumentTags(ctx context.Context, documentIDs, tags []string) ([]string, error) {
+	args := m.Called(ctx, documentIDs, tags)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockRepository) RenameTag(ctx context.Context, from, to string) ([]string, error) {
+	args := m.Called(ctx, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockRepository) ListTags(ctx context.Context) ([]models.TagCount, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.TagCount), args.Error(1)
+}
+
+func (m *MockRepository) SetDocumentAccessGroups(ctx context.Context, id string, groups []string) (bool, error) {
+	args := m.Called(ctx, id, groups)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) CreateMultipartUpload(ctx context.Context, upload *models.MultipartUpload) error {
+	args := m.Called(ctx, upload)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetMultipartUpload(ctx context.Context, documentID string) (*models.MultipartUpload, error) {
+	args := m.Called(ctx, documentID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.MultipartUpload), args.Error(1)
+}
+
+func (m *MockRepository) RecordMultipartUploadPart(ctx context.Context, documentID string, part models.MultipartUploadPart) error {
+	args := m.Called(ctx, documentID, part)
+	return args.Error(0)
+}
+
+func (m *MockRepository) CompleteMultipartUpload(ctx context.Context, documentID string, at time.Time) error {
+	args := m.Called(ctx, documentID, at)
+	return args.Error(0)
+}
+
+func (m *MockRepository) DeleteMultipartUpload(ctx context.Context, documentID string) error {
+	args := m.Called(ctx, documentID)
+	return args.Error(0)
+}
+
+func (m *MockRepository) UpsertResyncSchedule(ctx context.Context, schedule *models.ResyncSchedule) error {
+	args := m.Called(ctx, schedule)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetResyncSchedule(ctx context.Context, sourceType, sourceID string) (*models.ResyncSchedule, error) {
+	args := m.Called(ctx, sourceType, sourceID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ResyncSchedule), args.Error(1)
+}
+
+func (m *MockRepository) DeleteResyncSchedule(ctx context.Context, sourceType, sourceID string) error {
+	args := m.Called(ctx, sourceType, sourceID)
+	return args.Error(0)
+}
+
+func (m *MockRepository) RecordResyncCheck(ctx context.Context, sourceType, sourceID, contentHash string, at time.Time) error {
+	args := m.Called(ctx, sourceType, sourceID, contentHash, at)
+	return args.Error(0)
+}
+
+func (m *MockRepository) CreateAnsweredQuestion(ctx context.Context, answered *models.AnsweredQuestion) error {
+	args := m.Called(ctx, answered)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ListAnsweredQuestions(ctx context.Context, scope string, since time.Time, limit int) ([]*models.AnsweredQuestion, error) {
+	args := m.Called(ctx, scope, since, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.AnsweredQuestion), args.Error(1)
+}
+
+func (m *MockRepository) DeleteAnsweredQuestionsBefore(ctx context.Context, before time.Time) error {
+	args := m.Called(ctx, before)
+	return args.Error(0)
+}
+
+func (m *MockRepository) CreateConversationSummary(ctx context.Context, summary *models.ConversationSummary) error {
+	args := m.Called(ctx, summary)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetLatestConversationSummary(ctx context.Context, conversationID string) (*models.ConversationSummary, error) {
+	args := m.Called(ctx, conversationID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ConversationSummary), args.Error(1)
+}
+
+func (m *MockRepository) ListConversationSummaries(ctx context.Context, conversationID string) ([]*models.ConversationSummary, error) {
+	args := m.Called(ctx, conversationID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.ConversationSummary), args.Error(1)
+}
+
+func (m *MockRepository) AddConversationParticipant(ctx context.Context, participant *models.ConversationParticipant) (bool, error) {
+	args := m.Called(ctx, participant)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) GetConversationParticipant(ctx context.Context, conversationID, username string) (*models.ConversationParticipant, error) {
+	args := m.Called(ctx, conversationID, username)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ConversationParticipant), args.Error(1)
+}
+
+func (m *MockRepository) ListConversationParticipants(ctx context.Context, conversationID string) ([]*models.ConversationParticipant, error) {
+	args := m.Called(ctx, conversationID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.ConversationParticipant), args.Error(1)
+}
+
+func (m *MockRepository) RemoveConversationParticipant(ctx context.Context, conversationID, username string) (bool, error) {
+	args := m.Called(ctx, conversationID, username)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) MarkConversationRead(ctx context.Context, conversationID, username string, readAt time.Time) (bool, error) {
+	args := m.Called(ctx, conversationID, username, readAt)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) CreateCuratedAnswer(ctx context.Context, answer *models.CuratedAnswer) error {
+	args := m.Called(ctx, answer)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetCuratedAnswer(ctx context.Context, id string) (*models.CuratedAnswer, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CuratedAnswer), args.Error(1)
+}
+
+func (m *MockRepository) ListCuratedAnswers(ctx context.Context, limit, offset int) ([]*models.CuratedAnswer, int, error) {
+	args := m.Called(ctx, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Int(1), args.Error(2)
+	}
+	return args.Get(0).([]*models.CuratedAnswer), args.Int(1), args.Error(2)
+}
+
+func (m *MockRepository) ListEnabledCuratedAnswers(ctx context.Context) ([]*models.CuratedAnswer, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.CuratedAnswer), args.Error(1)
+}
+
+func (m *MockRepository) UpdateCuratedAnswer(ctx context.Context, answer *models.CuratedAnswer) (bool, error) {
+	args := m.Called(ctx, answer)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) DeleteCuratedAnswer(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) CreateGlossaryTerm(ctx context.Context, term *models.GlossaryTerm) (bool, error) {
+	args := m.Called(ctx, term)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) GetGlossaryTerm(ctx context.Context, id string) (*models.GlossaryTerm, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.GlossaryTerm), args.Error(1)
+}
+
+func (m *MockRepository) ListGlossaryTerms(ctx context.Context, limit, offset int) ([]*models.GlossaryTerm, int, error) {
+	args := m.Called(ctx, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Int(1), args.Error(2)
+	}
+	return args.Get(0).([]*models.GlossaryTerm), args.Int(1), args.Error(2)
+}
+
+func (m *MockRepository) ListAllGlossaryTerms(ctx context.Context) ([]*models.GlossaryTerm, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.GlossaryTerm), args.Error(1)
+}
+
+func (m *MockRepository) UpdateGlossaryTerm(ctx context.Context, term *models.GlossaryTerm) (bool, error) {
+	args := m.Called(ctx, term)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) DeleteGlossaryTerm(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) CreateRedactionRule(ctx context.Context, rule *models.RedactionRule) error {
+	args := m.Called(ctx, rule)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetRedactionRule(ctx context.Context, id string) (*models.RedactionRule, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.RedactionRule), args.Error(1)
+}
+
+func (m *MockRepository) ListRedactionRules(ctx context.Context, limit, offset int) ([]*models.RedactionRule, int, error) {
+	args := m.Called(ctx, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Int(1), args.Error(2)
+	}
+	return args.Get(0).([]*models.RedactionRule), args.Int(1), args.Error(2)
+}
+
+func (m *MockRepository) ListEnabledRedactionRules(ctx context.Context) ([]*models.RedactionRule, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.RedactionRule), args.Error(1)
+}
+
+func (m *MockRepository) UpdateRedactionRule(ctx context.Context, rule *models.RedactionRule) (bool, error) {
+	args := m.Called(ctx, rule)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) DeleteRedactionRule(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) CreateSavedSearch(ctx context.Context, search *models.SavedSearch) error {
+	args := m.Called(ctx, search)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetSavedSearch(ctx context.Context, id string) (*models.SavedSearch, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.SavedSearch), args.Error(1)
+}
+
+func (m *MockRepository) ListSavedSearches(ctx context.Context, limit, offset int) ([]*models.SavedSearch, int, error) {
+	args := m.Called(ctx, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Int(1), args.Error(2)
+	}
+	return args.Get(0).([]*models.SavedSearch), args.Int(1), args.Error(2)
+}
+
+func (m *MockRepository) UpdateSavedSearch(ctx context.Context, search *models.SavedSearch) (bool, error) {
+	args := m.Called(ctx, search)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) DeleteSavedSearch(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) CreateCollection(ctx context.Context, collection *models.Collection) (bool, error) {
+	args := m.Called(ctx, collection)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) GetCollection(ctx context.Context, id string) (*models.Collection, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Collection), args.Error(1)
+}
+
+func (m *MockRepository) ListCollections(ctx context.Context, limit, offset int) ([]*models.Collection, int, error) {
+	args := m.Called(ctx, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Int(1), args.Error(2)
+	}
+	return args.Get(0).([]*models.Collection), args.Int(1), args.Error(2)
+}
+
+func (m *MockRepository) DeleteCollection(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) AddCollectionDocuments(ctx context.Context, id string, documentIDs []string) (int, error) {
+	args := m.Called(ctx, id, documentIDs)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockRepository) RemoveCollectionDocuments(ctx context.Context, id string, documentIDs []string) (int, error) {
+	args := m.Called(ctx, id, documentIDs)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockRepository) ListDocumentCollectionIDs(ctx context.Context, documentID string) ([]string, error) {
+	args := m.Called(ctx, documentID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockRepository) CreateWorkspaceImport(ctx context.Context, imp *models.WorkspaceImport) error {
+	args := m.Called(ctx, imp)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetWorkspaceImport(ctx context.Context, id string) (*models.WorkspaceImport, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.WorkspaceImport), args.Error(1)
+}
+
+func (m *MockRepository) ListWorkspaceImports(ctx context.Context, limit, offset int) ([]*models.WorkspaceImport, int, error) {
+	args := m.Called(ctx, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Int(1), args.Error(2)
+	}
+	return args.Get(0).([]*models.WorkspaceImport), args.Int(1), args.Error(2)
+}
+
+func (m *MockRepository) UpdateWorkspaceImport(ctx context.Context, imp *models.WorkspaceImport) error {
+	args := m.Called(ctx, imp)
+	return args.Error(0)
+}
+
 // Ensure MockRepository implements Repository interface
 var _ repository.Repository = (*MockRepository)(nil)
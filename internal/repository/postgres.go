@@ -5,15 +5,20 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"kb-platform-gateway/internal/config"
 	"kb-platform-gateway/internal/models"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 	"github.com/rs/zerolog/log"
 )
 
+// SchemaVersion is the schema_version schema.sql records. Bump both
+// together whenever schema.sql changes.
+const SchemaVersion = 28
+
 type PostgresRepository struct {
 	db *sql.DB
 }
@@ -48,6 +53,28 @@ func (r *PostgresRepository) DB() *sql.DB {
 	return r.db
 }
 
+// AppliedSchemaVersion returns the version recorded by the last run of
+// schema.sql, or 0 if it predates schema versioning.
+func (r *PostgresRepository) AppliedSchemaVersion(ctx context.Context) (int, error) {
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, `SELECT to_regclass('schema_version') IS NOT NULL`).Scan(&exists); err != nil {
+		return 0, fmt.Errorf("failed to look up schema_version: %w", err)
+	}
+	if !exists {
+		return 0, nil
+	}
+
+	var version int
+	err := r.db.QueryRowContext(ctx, `SELECT version FROM schema_version`).Scan(&version)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get schema version: %w", err)
+	}
+	return version, nil
+}
+
 type DocumentRow struct {
 	ID           string
 	Filename     string
@@ -55,15 +82,36 @@ type DocumentRow struct {
 	Status       string
 	ErrorMessage *string
 	S3Key        *string
+	UploadedBy   *string
 	CreatedAt    time.Time
 	IndexedAt    *time.Time
 	Metadata     *string
+	ParentID     *string
+	Language     *string
+	// UploadURLIssuedAt and UploadURLExpiresAt are NULL for documents
+	// created without an upload URL, or before they were tracked.
+	UploadURLIssuedAt  *time.Time
+	UploadURLExpiresAt *time.Time
+	Version            int
+	DeletedAt          *time.Time
+	Chunking           *string
+	Processing         *string
+	WorkflowID         *string
+	Tags               []string
+	ContentHash        *string
+	ScanResult         *string
+	ScanSignature      *string
+	ScannedAt          *time.Time
+	Title              *string
+	AccessGroups       []string
 }
 
+const documentColumns = "id, filename, file_size, status, s3_key, error_message, uploaded_by, created_at, indexed_at, metadata, parent_id, language, upload_url_issued_at, upload_url_expires_at, version, deleted_at, chunking, processing, workflow_id, tags, content_hash, scan_result, scan_signature, scanned_at, title, access_groups"
+
 func (r *PostgresRepository) CreateDocument(ctx context.Context, doc *models.Document) error {
 	query := `
-		INSERT INTO documents (id, filename, file_size, status, s3_key, error_message, created_at, indexed_at, metadata)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		INSERT INTO documents (id, filename, file_size, status, s3_key, error_message, uploaded_by, created_at, indexed_at, metadata, parent_id, upload_url_issued_at, upload_url_expires_at, chunking, processing, content_hash, title)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
 	`
 
 	// Convert metadata map to JSON string
@@ -74,31 +122,31 @@ func (r *PostgresRepository) CreateDocument(ctx context.Context, doc *models.Doc
 			metadataJSON = &s
 		}
 	}
+	chunkingJSON, err := optionsJSON(doc.Chunking)
+	if err != nil {
+		return err
+	}
+	processingJSON, err := optionsJSON(doc.Processing)
+	if err != nil {
+		return err
+	}
 
-	_, err := r.db.ExecContext(ctx, query,
+	_, err = r.db.ExecContext(ctx, query,
 		doc.ID, doc.Filename, doc.FileSize, doc.Status,
-		nullString(doc.S3Key), nullString(doc.ErrorMessage),
+		nullString(doc.S3Key), nullString(doc.ErrorMessage), nullString(doc.UploadedBy),
 		doc.CreatedAt, nullTime(doc.IndexedAt),
-		metadataJSON,
+		metadataJSON, nullString(doc.ParentID),
+		nullTime(doc.UploadURLIssuedAt), nullTime(doc.UploadURLExpiresAt),
+		chunkingJSON, processingJSON, nullString(doc.ContentHash), nullString(doc.Title),
 	)
 
 	return err
 }
 
 func (r *PostgresRepository) GetDocument(ctx context.Context, id string) (*models.Document, error) {
-	query := `
-		SELECT id, filename, file_size, status, s3_key, error_message, created_at, indexed_at, metadata
-		FROM documents
-		WHERE id = $1
-	`
-
-	var row DocumentRow
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&row.ID, &row.Filename, &row.FileSize, &row.Status,
-		&row.S3Key, &row.ErrorMessage, &row.CreatedAt, &row.IndexedAt,
-		&row.Metadata,
-	)
+	query := "SELECT " + documentColumns + " FROM documents WHERE id = $1"
 
+	doc, err := scanDocument(r.db.QueryRowContext(ctx, query, id))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -107,28 +155,51 @@ func (r *PostgresRepository) GetDocument(ctx context.Context, id string) (*model
 		return nil, err
 	}
 
-	return rowToDocument(&row), nil
+	return doc, nil
 }
 
-func (r *PostgresRepository) ListDocuments(ctx context.Context, limit, offset int, statusFilter string) ([]*models.Document, int, error) {
-	query := `
-		SELECT id, filename, file_size, status, s3_key, error_message, created_at, indexed_at, metadata
-		FROM documents
-	`
+func (r *PostgresRepository) GetDocumentsByIDs(ctx context.Context, ids []string) ([]*models.Document, error) {
+	query := "SELECT " + documentColumns + " FROM documents WHERE id = ANY($1)"
 
-	var args []interface{}
-	var whereClauses []string
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-	if statusFilter != "" {
-		args = append(args, statusFilter)
-		whereClauses = append(whereClauses, fmt.Sprintf("status = $%d", len(args)))
+	var documents []*models.Document
+	for rows.Next() {
+		doc, err := scanDocument(rows)
+		if err != nil {
+			return nil, err
+		}
+		documents = append(documents, doc)
+	}
+
+	return documents, rows.Err()
+}
+
+func (r *PostgresRepository) FindDocumentByContentHash(ctx context.Context, contentHash string) (*models.Document, error) {
+	query := "SELECT " + documentColumns + ` FROM documents
+		WHERE content_hash = $1 AND deleted_at IS NULL AND status NOT IN ('failed', 'cancelled', 'rejected')
+		ORDER BY created_at ASC, id ASC
+		LIMIT 1`
+
+	doc, err := scanDocument(r.db.QueryRowContext(ctx, query, contentHash))
+	if err == sql.ErrNoRows {
+		return nil, nil
 	}
+	return doc, err
+}
 
-	if len(whereClauses) > 0 {
-		query += " WHERE " + whereClauses[0]
+func (r *PostgresRepository) ListDocuments(ctx context.Context, limit, offset int, filter models.DocumentFilter) ([]*models.Document, int, error) {
+	where, args, err := documentFilterWhere(filter)
+	if err != nil {
+		return nil, 0, err
 	}
+	query := "SELECT " + documentColumns + " FROM documents" + where
 
-	query += " ORDER BY created_at DESC LIMIT $" + fmt.Sprintf("%d", len(args)+1) + " OFFSET $" + fmt.Sprintf("%d", len(args)+2)
+	query += documentOrderBy(filter, len(args)) + " LIMIT $" + fmt.Sprintf("%d", len(args)+1) + " OFFSET $" + fmt.Sprintf("%d", len(args)+2)
 	args = append(args, limit, offset)
 
 	rows, err := r.db.QueryContext(ctx, query, args...)
@@ -139,21 +210,14 @@ func (r *PostgresRepository) ListDocuments(ctx context.Context, limit, offset in
 
 	var documents []*models.Document
 	for rows.Next() {
-		var row DocumentRow
-		if err := rows.Scan(
-			&row.ID, &row.Filename, &row.FileSize, &row.Status,
-			&row.S3Key, &row.ErrorMessage, &row.CreatedAt, &row.IndexedAt,
-			&row.Metadata,
-		); err != nil {
+		doc, err := scanDocument(rows)
+		if err != nil {
 			return nil, 0, err
 		}
-		documents = append(documents, rowToDocument(&row))
+		documents = append(documents, doc)
 	}
 
-	countQuery := "SELECT COUNT(*) FROM documents"
-	if len(whereClauses) > 0 {
-		countQuery += " WHERE " + whereClauses[0]
-	}
+	countQuery := "SELECT COUNT(*) FROM documents" + where
 
 	var total int
 	if err := r.db.QueryRowContext(ctx, countQuery, args[:len(args)-2]...).Scan(&total); err != nil {
@@ -163,6 +227,131 @@ func (r *PostgresRepository) ListDocuments(ctx context.Context, limit, offset in
 	return documents, total, nil
 }
 
+// ExportDocuments calls fn for every document matching filter, oldest
+// first, stopping at the first error. Rows are read as fn consumes them.
+func (r *PostgresRepository) ExportDocuments(ctx context.Context, filter models.DocumentFilter, fn func(*models.Document) error) error {
+	where, args, err := documentFilterWhere(filter)
+	if err != nil {
+		return err
+	}
+	query := "SELECT " + documentColumns + " FROM documents" + where + " ORDER BY created_at ASC, id ASC"
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		doc, err := scanDocument(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(doc); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// documentFilterWhere returns the WHERE clause selecting the documents
+// outside the trash that match filter, with its arguments.
+func documentFilterWhere(filter models.DocumentFilter) (string, []interface{}, error) {
+	var args []interface{}
+	whereClauses := []string{"deleted_at IS NULL"}
+
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		whereClauses = append(whereClauses, fmt.Sprintf("status = $%d", len(args)))
+	}
+	if filter.Language != "" {
+		args = append(args, filter.Language)
+		whereClauses = append(whereClauses, fmt.Sprintf("language = $%d", len(args)))
+	}
+	if len(filter.Metadata) > 0 {
+		metadataJSON, err := json.Marshal(filter.Metadata)
+		if err != nil {
+			return "", nil, err
+		}
+		args = append(args, string(metadataJSON))
+		whereClauses = append(whereClauses, fmt.Sprintf("metadata @> $%d::jsonb", len(args)))
+	}
+	if filter.Tag != "" {
+		args = append(args, filter.Tag)
+		whereClauses = append(whereClauses, fmt.Sprintf("tags @> ARRAY[$%d::text]", len(args)))
+	}
+	if filter.CollectionID != "" {
+		args = append(args, filter.CollectionID)
+		whereClauses = append(whereClauses, fmt.Sprintf("id IN (SELECT document_id FROM collection_documents WHERE collection_id = $%d)", len(args)))
+	}
+	if filter.Query != "" {
+		args = append(args, filter.Query)
+		whereClauses = append(whereClauses, fmt.Sprintf("STRPOS(LOWER(filename), LOWER($%d)) > 0", len(args)))
+	}
+	if filter.Filename != "" {
+		args = append(args, filenamePattern(filter.Filename))
+		whereClauses = append(whereClauses, fmt.Sprintf("filename ILIKE $%d", len(args)))
+	}
+	for _, bound := range []struct {
+		clause string
+		at     *time.Time
+	}{
+		{"created_at >= $%d", filter.CreatedFrom},
+		{"created_at < $%d", filter.CreatedTo},
+		{"indexed_at >= $%d", filter.IndexedFrom},
+		{"indexed_at < $%d", filter.IndexedTo},
+	} {
+		if bound.at != nil {
+			args = append(args, *bound.at)
+			whereClauses = append(whereClauses, fmt.Sprintf(bound.clause, len(args)))
+		}
+	}
+	// Text comes last, so documentOrderBy can rank by its argument.
+	if filter.Text != "" {
+		args = append(args, filter.Text)
+		whereClauses = append(whereClauses, fmt.Sprintf("%s @@ websearch_to_tsquery('simple', $%d)", documentSearchVector, len(args)))
+	}
+
+	return " WHERE " + strings.Join(whereClauses, " AND "), args, nil
+}
+
+// documentSearchVector is the full-text representation of a document's
+// title and filename, matching the expression idx_documents_search is
+// built on.
+const documentSearchVector = "to_tsvector('simple', COALESCE(title, '') || ' ' || filename)"
+
+// filenamePattern turns a filename filter into an ILIKE pattern matching
+// filenames containing it, with * as the only wildcard.
+func filenamePattern(filename string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`, "*", "%").Replace(filename)
+	return "%" + escaped + "%"
+}
+
+// documentOrderBy returns the ORDER BY clause for filter's sort, where
+// textArg is the position of the argument documentFilterWhere passes
+// filter's Text as. Documents with equal keys are ordered by id, so pages
+// do not overlap.
+func documentOrderBy(filter models.DocumentFilter, textArg int) string {
+	column, order := "created_at", "DESC"
+	if filter.Text != "" && (filter.SortBy == "" || filter.SortBy == models.DocumentSortRelevance) {
+		column = fmt.Sprintf("ts_rank(%s, websearch_to_tsquery('simple', $%d))", documentSearchVector, textArg)
+	}
+	switch filter.SortBy {
+	case models.DocumentSortIndexedAt, models.DocumentSortFileSize:
+		column = filter.SortBy
+	case models.DocumentSortFilename:
+		column, order = "filename", "ASC"
+	}
+	switch filter.Order {
+	case models.SortAsc:
+		order = "ASC"
+	case models.SortDesc:
+		order = "DESC"
+	}
+	return fmt.Sprintf(" ORDER BY %s %s NULLS LAST, id %s", column, order, order)
+}
+
 func (r *PostgresRepository) UpdateDocument(ctx context.Context, id string, updates map[string]interface{}) error {
 	setClauses := make([]string, 0, len(updates))
 	args := make([]interface{}, 0, len(updates)+1)
@@ -181,6 +370,139 @@ func (r *PostgresRepository) UpdateDocument(ctx context.Context, id string, upda
 	return err
 }
 
+func (r *PostgresRepository) ListChildDocuments(ctx context.Context, parentID string, limit, offset int) ([]*models.Document, int, error) {
+	query := "SELECT " + documentColumns + " FROM documents WHERE parent_id = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3"
+
+	rows, err := r.db.QueryContext(ctx, query, parentID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var documents []*models.Document
+	for rows.Next() {
+		doc, err := scanDocument(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		documents = append(documents, doc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM documents WHERE parent_id = $1", parentID).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	return documents, total, nil
+}
+
+func (r *PostgresRepository) CountChildDocuments(ctx context.Context, parentID string) (*models.ChildProgress, error) {
+	query := `
+		SELECT COUNT(*),
+			COUNT(*) FILTER (WHERE status = 'pending'),
+			COUNT(*) FILTER (WHERE status = 'indexing'),
+			COUNT(*) FILTER (WHERE status = 'complete'),
+			COUNT(*) FILTER (WHERE status = 'failed'),
+			COUNT(*) FILTER (WHERE status = 'cancelled')
+		FROM documents
+		WHERE parent_id = $1
+	`
+
+	var progress models.ChildProgress
+	if err := r.db.QueryRowContext(ctx, query, parentID).Scan(
+		&progress.Total, &progress.Pending, &progress.Indexing, &progress.Complete, &progress.Failed, &progress.Cancelled,
+	); err != nil {
+		return nil, err
+	}
+
+	return &progress, nil
+}
+
+func (r *PostgresRepository) SetDocumentLanguage(ctx context.Context, id, language string) error {
+	query := "UPDATE documents SET language = $1 WHERE id = $2"
+	_, err := r.db.ExecContext(ctx, query, language, id)
+	return err
+}
+
+func (r *PostgresRepository) SetDocumentTitle(ctx context.Context, id, title string) error {
+	query := "UPDATE documents SET title = $1 WHERE id = $2"
+	_, err := r.db.ExecContext(ctx, query, title, id)
+	return err
+}
+
+func (r *PostgresRepository) UpdateDocumentDetails(ctx context.Context, id string, metadata map[string]string, filename string, version int) (bool, error) {
+	var metadataJSON *string
+	if metadata != nil {
+		b, err := json.Marshal(metadata)
+		if err != nil {
+			return false, err
+		}
+		s := string(b)
+		metadataJSON = &s
+	}
+
+	query := `
+		UPDATE documents
+		SET metadata = COALESCE($1::jsonb, metadata),
+			filename = COALESCE(NULLIF($2, ''), filename),
+			version = version + 1
+		WHERE id = $3 AND version = $4`
+	result, err := r.db.ExecContext(ctx, query, metadataJSON, filename, id, version)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+func (r *PostgresRepository) SetDocumentUploadURL(ctx context.Context, id string, issuedAt, expiresAt time.Time) error {
+	query := "UPDATE documents SET upload_url_issued_at = $1, upload_url_expires_at = $2 WHERE id = $3"
+	_, err := r.db.ExecContext(ctx, query, issuedAt, expiresAt, id)
+	return err
+}
+
+func (r *PostgresRepository) SetDocumentChunking(ctx context.Context, id string, chunking *models.ChunkingOptions) error {
+	chunkingJSON, err := optionsJSON(chunking)
+	if err != nil {
+		return err
+	}
+
+	query := "UPDATE documents SET chunking = $1 WHERE id = $2"
+	_, err = r.db.ExecContext(ctx, query, chunkingJSON, id)
+	return err
+}
+
+func (r *PostgresRepository) SetDocumentProcessing(ctx context.Context, id string, processing *models.ProcessingOptions) error {
+	processingJSON, err := optionsJSON(processing)
+	if err != nil {
+		return err
+	}
+
+	query := "UPDATE documents SET processing = $1 WHERE id = $2"
+	_, err = r.db.ExecContext(ctx, query, processingJSON, id)
+	return err
+}
+
+// optionsJSON encodes a document's chunking or processing options for
+// their JSONB column, NULL if nil.
+func optionsJSON[T any](options *T) (*string, error) {
+	if options == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(options)
+	if err != nil {
+		return nil, err
+	}
+	s := string(b)
+	return &s, nil
+}
+
 func (r *PostgresRepository) DeleteDocument(ctx context.Context, id string) error {
 	query := "DELETE FROM documents WHERE id = $1"
 	_, err := r.db.ExecContext(ctx, query, id)
@@ -204,33 +526,58 @@ func (r *PostgresRepository) UpdateDocumentStatus(ctx context.Context, id, statu
 	return err
 }
 
+// SetDocumentIndexing marks a document indexing by the workflow, clearing
+// the error and indexed time of a previous run.
+func (r *PostgresRepository) SetDocumentIndexing(ctx context.Context, id, workflowID string) error {
+	query := `
+		UPDATE documents
+		SET status = 'indexing', workflow_id = $1, error_message = NULL, indexed_at = NULL
+		WHERE id = $2
+	`
+	_, err := r.db.ExecContext(ctx, query, workflowID, id)
+	return err
+}
+
+// SetDocumentScan records the malware scan of a document's file, and the
+// key it is now stored at, empty if it was deleted.
+func (r *PostgresRepository) SetDocumentScan(ctx context.Context, id, s3Key string, scan *models.DocumentScan) error {
+	query := `
+		UPDATE documents
+		SET scan_result = $1, scan_signature = $2, scanned_at = $3, s3_key = $4
+		WHERE id = $5
+	`
+	_, err := r.db.ExecContext(ctx, query, scan.Result, nullString(scan.Signature), scan.ScannedAt, nullString(s3Key), id)
+	return err
+}
+
 type ConversationRow struct {
 	ID           sql.NullString
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 	MessageCount sql.NullInt64
+	CreatedBy    sql.NullString
 }
 
 func (r *PostgresRepository) CreateConversation(ctx context.Context, conv *models.Conversation) error {
 	query := `
-		INSERT INTO conversations (id, created_at, updated_at)
-		VALUES ($1, $2, $3)
+		INSERT INTO conversations (id, created_at, updated_at, created_by)
+		VALUES ($1, $2, $3, $4)
 	`
 
-	_, err := r.db.ExecContext(ctx, query, conv.ID, conv.CreatedAt, conv.UpdatedAt)
+	_, err := r.db.ExecContext(ctx, query, conv.ID, conv.CreatedAt, conv.UpdatedAt, nullString(conv.CreatedBy))
 	return err
 }
 
 func (r *PostgresRepository) GetConversation(ctx context.Context, id string) (*models.Conversation, error) {
 	query := `
-		SELECT id, created_at, updated_at, message_count
+		SELECT id, created_at, updated_at, message_count, created_by
 		FROM conversations
 		WHERE id = $1
 	`
 
 	var row ConversationRow
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&row.ID, &row.CreatedAt, &row.UpdatedAt, &row.MessageCount,
+		&row.ID, &row.CreatedAt, &row.UpdatedAt, &row.MessageCount, &row.CreatedBy,
 	)
 
 	if err == sql.ErrNoRows {
@@ -245,6 +592,7 @@ func (r *PostgresRepository) GetConversation(ctx context.Context, id string) (*m
 		ID:        row.ID.String,
 		CreatedAt: row.CreatedAt,
 		UpdatedAt: row.UpdatedAt,
+		CreatedBy: row.CreatedBy.String,
 	}
 	if row.MessageCount.Valid {
 		conv.MessageCount = int(row.MessageCount.Int64)
@@ -253,15 +601,26 @@ func (r *PostgresRepository) GetConversation(ctx context.Context, id string) (*m
 	return conv, nil
 }
 
+// conversationsOfUser matches the conversations user $1 created or takes
+// part in; an empty user matches every conversation.
+const conversationsOfUser = `
+	$1 = ''
+	OR created_by = $1
+	OR EXISTS (
+		SELECT 1 FROM conversation_participants p
+		WHERE p.conversation_id = conversations.id AND p.username = $1
+	)`
+
 func (r *PostgresRepository) ListConversations(ctx context.Context, userID string, limit, offset int) ([]*models.Conversation, int, error) {
 	query := `
-		SELECT id, created_at, updated_at, message_count
+		SELECT id, created_at, updated_at, message_count, created_by
 		FROM conversations
+		WHERE ` + conversationsOfUser + `
 		ORDER BY created_at DESC
-		LIMIT $1 OFFSET $2
+		LIMIT $2 OFFSET $3
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, limit, offset)
+	rows, err := r.db.QueryContext(ctx, query, userID, limit, offset)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -270,7 +629,7 @@ func (r *PostgresRepository) ListConversations(ctx context.Context, userID strin
 	var conversations []*models.Conversation
 	for rows.Next() {
 		var row ConversationRow
-		if err := rows.Scan(&row.ID, &row.CreatedAt, &row.UpdatedAt, &row.MessageCount); err != nil {
+		if err := rows.Scan(&row.ID, &row.CreatedAt, &row.UpdatedAt, &row.MessageCount, &row.CreatedBy); err != nil {
 			return nil, 0, err
 		}
 
@@ -278,6 +637,7 @@ func (r *PostgresRepository) ListConversations(ctx context.Context, userID strin
 			ID:        row.ID.String,
 			CreatedAt: row.CreatedAt,
 			UpdatedAt: row.UpdatedAt,
+			CreatedBy: row.CreatedBy.String,
 		}
 		if row.MessageCount.Valid {
 			conv.MessageCount = int(row.MessageCount.Int64)
@@ -286,7 +646,7 @@ func (r *PostgresRepository) ListConversations(ctx context.Context, userID strin
 	}
 
 	var total int
-	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM conversations").Scan(&total); err != nil {
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM conversations WHERE "+conversationsOfUser, userID).Scan(&total); err != nil {
 		return nil, 0, err
 	}
 
@@ -318,6 +678,32 @@ func (r *PostgresRepository) CreateMessage(ctx context.Context, msg *models.Mess
 	return err
 }
 
+func (r *PostgresRepository) GetMessage(ctx context.Context, id string) (*models.Message, error) {
+	query := `
+		SELECT id, conversation_id, role, content, created_at, metadata
+		FROM messages
+		WHERE id = $1
+	`
+
+	var msg models.Message
+	var metadataJSON *string
+	err := r.db.QueryRowContext(ctx, query, id).Scan(&msg.ID, &msg.ConversationID, &msg.Role, &msg.Content, &msg.CreatedAt, &metadataJSON)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if metadataJSON != nil && *metadataJSON != "" {
+		if err := json.Unmarshal([]byte(*metadataJSON), &msg.Metadata); err != nil {
+			log.Error().Err(err).Str("message_id", msg.ID).Msg("Failed to parse message metadata")
+		}
+	}
+
+	return &msg, nil
+}
+
 func (r *PostgresRepository) GetMessagesByConversationID(ctx context.Context, conversationID string, limit, offset int) ([]*models.Message, error) {
 	query := `
 		SELECT id, conversation_id, role, content, created_at, metadata
@@ -353,12 +739,66 @@ func (r *PostgresRepository) GetMessagesByConversationID(ctx context.Context, co
 	return messages, nil
 }
 
+// ExportMessages calls fn for every message of a conversation, oldest
+// first, stopping at the first error. Rows are read as fn consumes them.
+func (r *PostgresRepository) ExportMessages(ctx context.Context, conversationID string, fn func(*models.Message) error) error {
+	query := `
+		SELECT id, conversation_id, role, content, created_at, metadata
+		FROM messages
+		WHERE conversation_id = $1
+		ORDER BY created_at ASC, id ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, conversationID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var msg models.Message
+		var metadataJSON *string
+		if err := rows.Scan(&msg.ID, &msg.ConversationID, &msg.Role, &msg.Content, &msg.CreatedAt, &metadataJSON); err != nil {
+			return err
+		}
+
+		if metadataJSON != nil && *metadataJSON != "" {
+			if err := json.Unmarshal([]byte(*metadataJSON), &msg.Metadata); err != nil {
+				log.Error().Err(err).Str("message_id", msg.ID).Msg("Failed to parse message metadata")
+			}
+		}
+
+		if err := fn(&msg); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
 func (r *PostgresRepository) DeleteMessage(ctx context.Context, id string) error {
 	query := "DELETE FROM messages WHERE id = $1"
 	_, err := r.db.ExecContext(ctx, query, id)
 	return err
 }
 
+// scanDocument reads a row selected with documentColumns.
+func scanDocument(scanner rowScanner) (*models.Document, error) {
+	var row DocumentRow
+	if err := scanner.Scan(
+		&row.ID, &row.Filename, &row.FileSize, &row.Status,
+		&row.S3Key, &row.ErrorMessage, &row.UploadedBy, &row.CreatedAt, &row.IndexedAt,
+		&row.Metadata, &row.ParentID, &row.Language,
+		&row.UploadURLIssuedAt, &row.UploadURLExpiresAt, &row.Version, &row.DeletedAt,
+		&row.Chunking, &row.Processing, &row.WorkflowID, pq.Array(&row.Tags),
+		&row.ContentHash, &row.ScanResult, &row.ScanSignature, &row.ScannedAt,
+		&row.Title, pq.Array(&row.AccessGroups),
+	); err != nil {
+		return nil, err
+	}
+	return rowToDocument(&row), nil
+}
+
 func rowToDocument(row *DocumentRow) *models.Document {
 	doc := &models.Document{
 		ID:        row.ID,
@@ -366,6 +806,7 @@ func rowToDocument(row *DocumentRow) *models.Document {
 		FileSize:  row.FileSize,
 		Status:    row.Status,
 		CreatedAt: row.CreatedAt,
+		Version:   row.Version,
 	}
 
 	if row.S3Key != nil {
@@ -374,15 +815,58 @@ func rowToDocument(row *DocumentRow) *models.Document {
 	if row.ErrorMessage != nil {
 		doc.ErrorMessage = *row.ErrorMessage
 	}
+	if row.UploadedBy != nil {
+		doc.UploadedBy = *row.UploadedBy
+	}
 	if row.IndexedAt != nil {
 		doc.IndexedAt = row.IndexedAt
 	}
+	if row.ParentID != nil {
+		doc.ParentID = *row.ParentID
+	}
+	if row.Language != nil {
+		doc.Language = *row.Language
+	}
+	if row.Title != nil {
+		doc.Title = *row.Title
+	}
+	if row.WorkflowID != nil {
+		doc.WorkflowID = *row.WorkflowID
+	}
+	if row.ContentHash != nil {
+		doc.ContentHash = *row.ContentHash
+	}
+	if row.ScanResult != nil && row.ScannedAt != nil {
+		doc.Scan = &models.DocumentScan{Result: *row.ScanResult, ScannedAt: *row.ScannedAt}
+		if row.ScanSignature != nil {
+			doc.Scan.Signature = *row.ScanSignature
+		}
+	}
+	doc.UploadURLIssuedAt = row.UploadURLIssuedAt
+	doc.UploadURLExpiresAt = row.UploadURLExpiresAt
+	doc.DeletedAt = row.DeletedAt
+	if len(row.Tags) > 0 {
+		doc.Tags = row.Tags
+	}
+	if len(row.AccessGroups) > 0 {
+		doc.AccessGroups = row.AccessGroups
+	}
 
 	if row.Metadata != nil && *row.Metadata != "" {
 		if err := json.Unmarshal([]byte(*row.Metadata), &doc.Metadata); err != nil {
 			log.Error().Err(err).Str("document_id", row.ID).Msg("Failed to parse document metadata")
 		}
 	}
+	if row.Chunking != nil && *row.Chunking != "" {
+		if err := json.Unmarshal([]byte(*row.Chunking), &doc.Chunking); err != nil {
+			log.Error().Err(err).Str("document_id", row.ID).Msg("Failed to parse document chunking")
+		}
+	}
+	if row.Processing != nil && *row.Processing != "" {
+		if err := json.Unmarshal([]byte(*row.Processing), &doc.Processing); err != nil {
+			log.Error().Err(err).Str("document_id", row.ID).Msg("Failed to parse document processing options")
+		}
+	}
 
 	return doc
 }
@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/lib/pq"
+)
+
+func (r *PostgresRepository) SetDocumentAccessGroups(ctx context.Context, id string, groups []string) (bool, error) {
+	query := `
+		UPDATE documents
+		SET access_groups = ARRAY(SELECT DISTINCT g FROM unnest($2::text[]) AS g ORDER BY g)
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+
+	result, err := r.db.ExecContext(ctx, query, id, pq.Array(groups))
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"kb-platform-gateway/internal/models"
+)
+
+func (r *PostgresRepository) GetDocumentAnalytics(ctx context.Context, documentID string) (*models.DocumentAnalytics, error) {
+	query := `
+		SELECT COUNT(*), COUNT(DISTINCT query_id), MAX(created_at), AVG(score)
+		FROM query_citations
+		WHERE document_id = $1
+	`
+
+	analytics := &models.DocumentAnalytics{DocumentID: documentID}
+	var lastCitedAt sql.NullTime
+	var averageScore sql.NullFloat64
+	if err := r.db.QueryRowContext(ctx, query, documentID).Scan(
+		&analytics.HitCount, &analytics.QueryCount, &lastCitedAt, &averageScore,
+	); err != nil {
+		return nil, err
+	}
+	if lastCitedAt.Valid {
+		analytics.LastCitedAt = &lastCitedAt.Time
+	}
+	if averageScore.Valid {
+		analytics.AverageScore = &averageScore.Float64
+	}
+
+	return analytics, nil
+}
+
+func (r *PostgresRepository) ListDocumentLeaderboard(ctx context.Context, since time.Time, order string, limit int) ([]*models.DocumentAnalytics, error) {
+	// Ties go to the oldest document either way, so the least used list
+	// starts with documents that had the longest chance to be cited.
+	direction := "DESC"
+	if order == models.LeaderboardOrderLeast {
+		direction = "ASC"
+	}
+	query := `
+		SELECT d.id, d.filename, COUNT(c.query_id), COUNT(DISTINCT c.query_id), MAX(c.created_at), AVG(c.score)
+		FROM documents d
+		LEFT JOIN query_citations c ON c.document_id = d.id AND c.created_at >= $1
+		WHERE d.status = 'complete' AND d.deleted_at IS NULL
+		GROUP BY d.id, d.filename, d.created_at
+		ORDER BY COUNT(c.query_id) ` + direction + `, d.created_at ASC, d.id
+		LIMIT $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, since.UTC(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var leaderboard []*models.DocumentAnalytics
+	for rows.Next() {
+		var analytics models.DocumentAnalytics
+		var lastCitedAt sql.NullTime
+		var averageScore sql.NullFloat64
+		if err := rows.Scan(
+			&analytics.DocumentID, &analytics.Filename, &analytics.HitCount, &analytics.QueryCount,
+			&lastCitedAt, &averageScore,
+		); err != nil {
+			return nil, err
+		}
+		if lastCitedAt.Valid {
+			analytics.LastCitedAt = &lastCitedAt.Time
+		}
+		if averageScore.Valid {
+			analytics.AverageScore = &averageScore.Float64
+		}
+		leaderboard = append(leaderboard, &analytics)
+	}
+
+	return leaderboard, rows.Err()
+}
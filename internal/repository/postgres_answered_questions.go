@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"kb-platform-gateway/internal/models"
+
+	"github.com/lib/pq"
+)
+
+func (r *PostgresRepository) CreateAnsweredQuestion(ctx context.Context, answered *models.AnsweredQuestion) error {
+	query := `
+		INSERT INTO answered_questions (query_id, scope, question, answer, document_ids, created_at)
+		VALUES ($1, $2, $3, $4, COALESCE($5::text[], '{}'), $6)
+		ON CONFLICT (query_id) DO NOTHING
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		answered.QueryID, answered.Scope, answered.Question, answered.Answer,
+		pq.Array(answered.DocumentIDs), answered.CreatedAt,
+	)
+	return err
+}
+
+func (r *PostgresRepository) ListAnsweredQuestions(ctx context.Context, scope string, since time.Time, limit int) ([]*models.AnsweredQuestion, error) {
+	query := `
+		SELECT query_id, scope, question, answer, document_ids, created_at
+		FROM answered_questions
+		WHERE scope = $1 AND created_at >= $2
+		ORDER BY created_at DESC
+		LIMIT $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, scope, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var answered []*models.AnsweredQuestion
+	for rows.Next() {
+		var a models.AnsweredQuestion
+		if err := rows.Scan(&a.QueryID, &a.Scope, &a.Question, &a.Answer, pq.Array(&a.DocumentIDs), &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		answered = append(answered, &a)
+	}
+
+	return answered, rows.Err()
+}
+
+func (r *PostgresRepository) DeleteAnsweredQuestionsBefore(ctx context.Context, before time.Time) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM answered_questions WHERE created_at < $1", before)
+	return err
+}
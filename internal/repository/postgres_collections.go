@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"kb-platform-gateway/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// Document counts leave out documents in the trash, which stay in their
+// collections until purged.
+const collectionColumns = `
+	c.id, c.name, c.description, c.created_by, c.created_at,
+	(SELECT COUNT(*) FROM collection_documents cd JOIN documents d ON d.id = cd.document_id
+	 WHERE cd.collection_id = c.id AND d.deleted_at IS NULL)
+`
+
+func (r *PostgresRepository) CreateCollection(ctx context.Context, collection *models.Collection) (bool, error) {
+	query := `
+		INSERT INTO collections (id, name, description, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (name) DO NOTHING
+	`
+	result, err := r.db.ExecContext(ctx, query,
+		collection.ID, collection.Name, collection.Description, nullString(collection.CreatedBy), collection.CreatedAt,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows > 0, nil
+}
+
+func (r *PostgresRepository) GetCollection(ctx context.Context, id string) (*models.Collection, error) {
+	query := "SELECT" + collectionColumns + "FROM collections c WHERE c.id = $1"
+
+	collection, err := scanCollection(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return collection, nil
+}
+
+func (r *PostgresRepository) ListCollections(ctx context.Context, limit, offset int) ([]*models.Collection, int, error) {
+	query := "SELECT" + collectionColumns + "FROM collections c ORDER BY LOWER(c.name), c.created_at LIMIT $1 OFFSET $2"
+
+	rows, err := r.db.QueryContext(ctx, query, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var collections []*models.Collection
+	for rows.Next() {
+		collection, err := scanCollection(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		collections = append(collections, collection)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM collections").Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	return collections, total, nil
+}
+
+func (r *PostgresRepository) DeleteCollection(ctx context.Context, id string) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM collections WHERE id = $1", id)
+	return err
+}
+
+func (r *PostgresRepository) AddCollectionDocuments(ctx context.Context, id string, documentIDs []string) (int, error) {
+	query := `
+		INSERT INTO collection_documents (collection_id, document_id)
+		SELECT $1, d.id FROM documents d
+		WHERE d.id = ANY($2) AND d.deleted_at IS NULL
+		ON CONFLICT DO NOTHING
+	`
+	return r.execCount(ctx, query, id, pq.Array(documentIDs))
+}
+
+func (r *PostgresRepository) RemoveCollectionDocuments(ctx context.Context, id string, documentIDs []string) (int, error) {
+	query := "DELETE FROM collection_documents WHERE collection_id = $1 AND document_id = ANY($2)"
+	return r.execCount(ctx, query, id, pq.Array(documentIDs))
+}
+
+func (r *PostgresRepository) ListDocumentCollectionIDs(ctx context.Context, documentID string) ([]string, error) {
+	query := `
+		SELECT collection_id FROM collection_documents
+		WHERE document_id = $1
+		ORDER BY collection_id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, documentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var collectionID string
+		if err := rows.Scan(&collectionID); err != nil {
+			return nil, err
+		}
+		ids = append(ids, collectionID)
+	}
+
+	return ids, rows.Err()
+}
+
+// execCount runs a statement and returns the number of rows it affected.
+func (r *PostgresRepository) execCount(ctx context.Context, query string, args ...interface{}) (int, error) {
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(rows), nil
+}
+
+// scanCollection reads a row selected with collectionColumns.
+func scanCollection(row rowScanner) (*models.Collection, error) {
+	var collection models.Collection
+	var createdBy sql.NullString
+	if err := row.Scan(
+		&collection.ID, &collection.Name, &collection.Description, &createdBy, &collection.CreatedAt, &collection.DocumentCount,
+	); err != nil {
+		return nil, err
+	}
+	collection.CreatedBy = createdBy.String
+
+	return &collection, nil
+}
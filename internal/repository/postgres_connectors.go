@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"kb-platform-gateway/internal/models"
+
+	"github.com/lib/pq"
+)
+
+const connectorColumns = `id, username, provider, name, folder_ids, sync_interval_minutes, status, last_error,
+	last_sync_started_at, last_synced_at, next_sync_at, credentials, created_at, updated_at`
+
+func (r *PostgresRepository) CreateConnector(ctx context.Context, connector *models.Connector) error {
+	query := `
+		INSERT INTO connectors (id, username, provider, name, folder_ids, sync_interval_minutes, status,
+			next_sync_at, credentials, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		connector.ID, connector.Username, connector.Provider, connector.Name, pq.Array(connector.FolderIDs),
+		connector.SyncIntervalMinutes, connector.Status, connector.NextSyncAt, connector.Credentials,
+		connector.CreatedAt, connector.UpdatedAt,
+	)
+	return err
+}
+
+func (r *PostgresRepository) GetConnector(ctx context.Context, id string) (*models.Connector, error) {
+	query := "SELECT " + connectorColumns + " FROM connectors WHERE id = $1"
+
+	connector, err := scanConnector(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return connector, nil
+}
+
+func (r *PostgresRepository) ListConnectors(ctx context.Context, username string) ([]*models.Connector, error) {
+	query := "SELECT " + connectorColumns + " FROM connectors WHERE username = $1 ORDER BY created_at DESC"
+	return r.queryConnectors(ctx, query, username)
+}
+
+func (r *PostgresRepository) ListAllConnectors(ctx context.Context) ([]*models.Connector, error) {
+	query := "SELECT " + connectorColumns + " FROM connectors ORDER BY username, created_at"
+	return r.queryConnectors(ctx, query)
+}
+
+func (r *PostgresRepository) UpdateConnector(ctx context.Context, connector *models.Connector) error {
+	query := `
+		UPDATE connectors
+		SET name = $1, folder_ids = $2, sync_interval_minutes = $3, next_sync_at = $4, updated_at = $5
+		WHERE id = $6
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		connector.Name, pq.Array(connector.FolderIDs), connector.SyncIntervalMinutes,
+		connector.NextSyncAt, connector.UpdatedAt, connector.ID,
+	)
+	return err
+}
+
+func (r *PostgresRepository) UpdateConnectorCredentials(ctx context.Context, id string, credentials []byte) error {
+	query := "UPDATE connectors SET credentials = $1, updated_at = NOW() WHERE id = $2"
+	_, err := r.db.ExecContext(ctx, query, credentials, id)
+	return err
+}
+
+func (r *PostgresRepository) DeleteConnector(ctx context.Context, id string) error {
+	query := "DELETE FROM connectors WHERE id = $1"
+	_, err := r.db.ExecContext(ctx, query, id)
+	return err
+}
+
+func (r *PostgresRepository) ClaimDueConnectors(ctx context.Context, now time.Time, limit int) ([]*models.Connector, error) {
+	query := `
+		UPDATE connectors
+		SET status = '` + models.ConnectorStatusSyncing + `',
+			last_sync_started_at = $1,
+			next_sync_at = $1 + sync_interval_minutes * INTERVAL '1 minute'
+		WHERE id IN (
+			SELECT id FROM connectors
+			WHERE next_sync_at <= $1
+				AND NOT EXISTS (
+					SELECT 1 FROM resync_schedules
+					WHERE source_type = '` + models.ResyncSourceConnector + `' AND source_id = connectors.id
+				)
+			ORDER BY next_sync_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + connectorColumns
+
+	return r.queryConnectors(ctx, query, now.UTC(), limit)
+}
+
+func (r *PostgresRepository) FinishConnectorSync(ctx context.Context, id, syncErr string, at time.Time) error {
+	query := `
+		UPDATE connectors
+		SET status = $1,
+			last_error = $2,
+			last_synced_at = CASE WHEN $2 = '' THEN $3 ELSE last_synced_at END
+		WHERE id = $4
+	`
+
+	status := models.ConnectorStatusIdle
+	if syncErr != "" {
+		status = models.ConnectorStatusFailed
+	}
+
+	_, err := r.db.ExecContext(ctx, query, status, syncErr, at, id)
+	return err
+}
+
+func (r *PostgresRepository) GetConnectorFile(ctx context.Context, connectorID, externalID string) (*models.ConnectorFile, error) {
+	query := `
+		SELECT connector_id, external_id, document_id, modified_at, content_hash
+		FROM connector_files
+		WHERE connector_id = $1 AND external_id = $2
+	`
+
+	var file models.ConnectorFile
+	err := r.db.QueryRowContext(ctx, query, connectorID, externalID).Scan(
+		&file.ConnectorID, &file.ExternalID, &file.DocumentID, &file.ModifiedAt, &file.ContentHash,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &file, nil
+}
+
+func (r *PostgresRepository) UpsertConnectorFile(ctx context.Context, file *models.ConnectorFile) error {
+	query := `
+		INSERT INTO connector_files (connector_id, external_id, document_id, modified_at, content_hash)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (connector_id, external_id)
+		DO UPDATE SET document_id = EXCLUDED.document_id, modified_at = EXCLUDED.modified_at,
+			content_hash = EXCLUDED.content_hash
+	`
+
+	_, err := r.db.ExecContext(ctx, query, file.ConnectorID, file.ExternalID, file.DocumentID, file.ModifiedAt, file.ContentHash)
+	return err
+}
+
+func (r *PostgresRepository) queryConnectors(ctx context.Context, query string, args ...interface{}) ([]*models.Connector, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var connectors []*models.Connector
+	for rows.Next() {
+		connector, err := scanConnector(rows)
+		if err != nil {
+			return nil, err
+		}
+		connectors = append(connectors, connector)
+	}
+
+	return connectors, rows.Err()
+}
+
+func scanConnector(row rowScanner) (*models.Connector, error) {
+	var connector models.Connector
+	var lastSyncStartedAt, lastSyncedAt sql.NullTime
+	if err := row.Scan(
+		&connector.ID, &connector.Username, &connector.Provider, &connector.Name, pq.Array(&connector.FolderIDs),
+		&connector.SyncIntervalMinutes, &connector.Status, &connector.LastError,
+		&lastSyncStartedAt, &lastSyncedAt, &connector.NextSyncAt, &connector.Credentials,
+		&connector.CreatedAt, &connector.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if lastSyncStartedAt.Valid {
+		connector.LastSyncStartedAt = &lastSyncStartedAt.Time
+	}
+	if lastSyncedAt.Valid {
+		connector.LastSyncedAt = &lastSyncedAt.Time
+	}
+
+	return &connector, nil
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"kb-platform-gateway/internal/models"
+)
+
+// participantColumns selects a participant with the count of messages
+// created since they last read the conversation.
+const participantColumns = `p.conversation_id, p.username, p.invited_by, p.joined_at, p.last_read_at,
+	(SELECT COUNT(*) FROM messages m
+	 WHERE m.conversation_id = p.conversation_id
+	   AND (p.last_read_at IS NULL OR m.created_at > p.last_read_at))`
+
+func (r *PostgresRepository) AddConversationParticipant(ctx context.Context, participant *models.ConversationParticipant) (bool, error) {
+	query := `
+		INSERT INTO conversation_participants (conversation_id, username, invited_by, joined_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (conversation_id, username) DO NOTHING
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		participant.ConversationID, participant.Username, nullString(participant.InvitedBy), participant.JoinedAt,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+func (r *PostgresRepository) GetConversationParticipant(ctx context.Context, conversationID, username string) (*models.ConversationParticipant, error) {
+	query := "SELECT " + participantColumns + `
+		FROM conversation_participants p
+		WHERE p.conversation_id = $1 AND p.username = $2`
+
+	participant, err := scanParticipant(r.db.QueryRowContext(ctx, query, conversationID, username))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return participant, err
+}
+
+func (r *PostgresRepository) ListConversationParticipants(ctx context.Context, conversationID string) ([]*models.ConversationParticipant, error) {
+	query := "SELECT " + participantColumns + `
+		FROM conversation_participants p
+		WHERE p.conversation_id = $1
+		ORDER BY p.joined_at, p.username`
+
+	rows, err := r.db.QueryContext(ctx, query, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var participants []*models.ConversationParticipant
+	for rows.Next() {
+		participant, err := scanParticipant(rows)
+		if err != nil {
+			return nil, err
+		}
+		participants = append(participants, participant)
+	}
+	return participants, rows.Err()
+}
+
+func (r *PostgresRepository) RemoveConversationParticipant(ctx context.Context, conversationID, username string) (bool, error) {
+	result, err := r.db.ExecContext(ctx,
+		"DELETE FROM conversation_participants WHERE conversation_id = $1 AND username = $2",
+		conversationID, username,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+func (r *PostgresRepository) MarkConversationRead(ctx context.Context, conversationID, username string, readAt time.Time) (bool, error) {
+	query := `
+		UPDATE conversation_participants
+		SET last_read_at = GREATEST(last_read_at, $1)
+		WHERE conversation_id = $2 AND username = $3
+	`
+
+	result, err := r.db.ExecContext(ctx, query, readAt, conversationID, username)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// scanParticipant reads a row selected with participantColumns.
+func scanParticipant(scanner rowScanner) (*models.ConversationParticipant, error) {
+	var p models.ConversationParticipant
+	var invitedBy sql.NullString
+	if err := scanner.Scan(&p.ConversationID, &p.Username, &invitedBy, &p.JoinedAt, &p.LastReadAt, &p.UnreadCount); err != nil {
+		return nil, err
+	}
+	p.InvitedBy = invitedBy.String
+	return &p, nil
+}
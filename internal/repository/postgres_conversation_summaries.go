@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"kb-platform-gateway/internal/models"
+)
+
+func (r *PostgresRepository) CreateConversationSummary(ctx context.Context, summary *models.ConversationSummary) error {
+	query := `
+		INSERT INTO conversation_summaries (conversation_id, version, summary, message_count, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (conversation_id, version) DO NOTHING
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		summary.ConversationID, summary.Version, summary.Summary, summary.MessageCount, summary.CreatedAt,
+	)
+	return err
+}
+
+func (r *PostgresRepository) GetLatestConversationSummary(ctx context.Context, conversationID string) (*models.ConversationSummary, error) {
+	query := `
+		SELECT conversation_id, version, summary, message_count, created_at
+		FROM conversation_summaries
+		WHERE conversation_id = $1
+		ORDER BY version DESC
+		LIMIT 1
+	`
+
+	var s models.ConversationSummary
+	err := r.db.QueryRowContext(ctx, query, conversationID).Scan(
+		&s.ConversationID, &s.Version, &s.Summary, &s.MessageCount, &s.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *PostgresRepository) ListConversationSummaries(ctx context.Context, conversationID string) ([]*models.ConversationSummary, error) {
+	query := `
+		SELECT conversation_id, version, summary, message_count, created_at
+		FROM conversation_summaries
+		WHERE conversation_id = $1
+		ORDER BY version DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var summaries []*models.ConversationSummary
+	for rows.Next() {
+		var s models.ConversationSummary
+		if err := rows.Scan(&s.ConversationID, &s.Version, &s.Summary, &s.MessageCount, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, &s)
+	}
+
+	return summaries, rows.Err()
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"kb-platform-gateway/internal/models"
+
+	"github.com/lib/pq"
+)
+
+const curatedAnswerColumns = "id, pattern, match_type, answer, document_ids, enabled, created_by, updated_by, created_at, updated_at"
+
+func (r *PostgresRepository) CreateCuratedAnswer(ctx context.Context, answer *models.CuratedAnswer) error {
+	query := `
+		INSERT INTO curated_answers (id, pattern, match_type, answer, document_ids, enabled, created_by, updated_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, COALESCE($5::text[], '{}'), $6, $7, $8, $9, $10)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		answer.ID, answer.Pattern, answer.MatchType, answer.Answer, pq.Array(answer.DocumentIDs), answer.Enabled,
+		nullString(answer.CreatedBy), nullString(answer.UpdatedBy), answer.CreatedAt, answer.UpdatedAt,
+	)
+	return err
+}
+
+func (r *PostgresRepository) GetCuratedAnswer(ctx context.Context, id string) (*models.CuratedAnswer, error) {
+	query := "SELECT " + curatedAnswerColumns + " FROM curated_answers WHERE id = $1"
+
+	answer, err := scanCuratedAnswer(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return answer, nil
+}
+
+func (r *PostgresRepository) ListCuratedAnswers(ctx context.Context, limit, offset int) ([]*models.CuratedAnswer, int, error) {
+	query := "SELECT " + curatedAnswerColumns + " FROM curated_answers ORDER BY updated_at DESC LIMIT $1 OFFSET $2"
+
+	answers, err := r.listCuratedAnswers(ctx, query, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM curated_answers").Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	return answers, total, nil
+}
+
+func (r *PostgresRepository) ListEnabledCuratedAnswers(ctx context.Context) ([]*models.CuratedAnswer, error) {
+	query := "SELECT " + curatedAnswerColumns + " FROM curated_answers WHERE enabled ORDER BY updated_at DESC"
+	return r.listCuratedAnswers(ctx, query)
+}
+
+func (r *PostgresRepository) listCuratedAnswers(ctx context.Context, query string, args ...interface{}) ([]*models.CuratedAnswer, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var answers []*models.CuratedAnswer
+	for rows.Next() {
+		answer, err := scanCuratedAnswer(rows)
+		if err != nil {
+			return nil, err
+		}
+		answers = append(answers, answer)
+	}
+
+	return answers, rows.Err()
+}
+
+func (r *PostgresRepository) UpdateCuratedAnswer(ctx context.Context, answer *models.CuratedAnswer) (bool, error) {
+	query := `
+		UPDATE curated_answers
+		SET pattern = $1, match_type = $2, answer = $3, document_ids = COALESCE($4::text[], '{}'),
+			enabled = $5, updated_by = $6, updated_at = $7
+		WHERE id = $8
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		answer.Pattern, answer.MatchType, answer.Answer, pq.Array(answer.DocumentIDs),
+		answer.Enabled, nullString(answer.UpdatedBy), answer.UpdatedAt, answer.ID,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows > 0, nil
+}
+
+func (r *PostgresRepository) DeleteCuratedAnswer(ctx context.Context, id string) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM curated_answers WHERE id = $1", id)
+	return err
+}
+
+func scanCuratedAnswer(row rowScanner) (*models.CuratedAnswer, error) {
+	var answer models.CuratedAnswer
+	var createdBy, updatedBy sql.NullString
+	if err := row.Scan(
+		&answer.ID, &answer.Pattern, &answer.MatchType, &answer.Answer, pq.Array(&answer.DocumentIDs), &answer.Enabled,
+		&createdBy, &updatedBy, &answer.CreatedAt, &answer.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	answer.CreatedBy = createdBy.String
+	answer.UpdatedBy = updatedBy.String
+
+	return &answer, nil
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+
+	"kb-platform-gateway/internal/models"
+)
+
+func (r *PostgresRepository) CreateDocumentEvent(ctx context.Context, event *models.DocumentEvent) error {
+	query := `
+		INSERT INTO document_events (id, document_id, type, source, message, data, occurred_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (id) DO NOTHING
+	`
+
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return err
+	}
+	if event.Data == nil {
+		data = []byte("{}")
+	}
+
+	_, err = r.db.ExecContext(ctx, query,
+		event.ID, event.DocumentID, event.Type, event.Source, event.Message,
+		string(data), event.OccurredAt,
+	)
+	return err
+}
+
+func (r *PostgresRepository) ListDocumentEvents(ctx context.Context, documentID string, limit, offset int) ([]*models.DocumentEvent, int, error) {
+	query := `
+		SELECT id, document_id, type, source, message, data, occurred_at, COUNT(*) OVER ()
+		FROM document_events
+		WHERE document_id = $1
+		ORDER BY occurred_at ASC, id
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, documentID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var events []*models.DocumentEvent
+	var total int
+	for rows.Next() {
+		var event models.DocumentEvent
+		var data []byte
+		if err := rows.Scan(
+			&event.ID, &event.DocumentID, &event.Type, &event.Source, &event.Message,
+			&data, &event.OccurredAt, &total,
+		); err != nil {
+			return nil, 0, err
+		}
+		if err := json.Unmarshal(data, &event.Data); err != nil {
+			return nil, 0, err
+		}
+		if len(event.Data) == 0 {
+			event.Data = nil
+		}
+		events = append(events, &event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	// COUNT(*) OVER () is unavailable past the last page.
+	if len(events) == 0 && offset > 0 {
+		if err := r.db.QueryRowContext(ctx,
+			"SELECT COUNT(*) FROM document_events WHERE document_id = $1", documentID,
+		).Scan(&total); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return events, total, nil
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"kb-platform-gateway/internal/models"
+)
+
+const duplicateReportColumns = `
+	id, status, threshold, documents_scanned, cluster_count, error,
+	created_by, created_at, completed_at
+`
+
+func (r *PostgresRepository) CreateDuplicateReport(ctx context.Context, report *models.DuplicateReport) error {
+	query := `
+		INSERT INTO duplicate_reports (id, status, threshold, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		report.ID, report.Status, report.Threshold, nullString(report.CreatedBy), report.CreatedAt,
+	)
+	return err
+}
+
+func (r *PostgresRepository) GetDuplicateReport(ctx context.Context, id string) (*models.DuplicateReport, error) {
+	query := "SELECT" + duplicateReportColumns + ", clusters FROM duplicate_reports WHERE id = $1"
+
+	var clusters []byte
+	report, err := scanDuplicateReport(r.db.QueryRowContext(ctx, query, id), &clusters)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(clusters) > 0 {
+		if err := json.Unmarshal(clusters, &report.Clusters); err != nil {
+			return nil, err
+		}
+	}
+
+	return report, nil
+}
+
+func (r *PostgresRepository) ListDuplicateReports(ctx context.Context, limit, offset int) ([]*models.DuplicateReport, int, error) {
+	query := "SELECT" + duplicateReportColumns + "FROM duplicate_reports ORDER BY created_at DESC LIMIT $1 OFFSET $2"
+
+	rows, err := r.db.QueryContext(ctx, query, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var reports []*models.DuplicateReport
+	for rows.Next() {
+		report, err := scanDuplicateReport(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		reports = append(reports, report)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM duplicate_reports").Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	return reports, total, nil
+}
+
+func (r *PostgresRepository) SetDuplicateReportProgress(ctx context.Context, id string, scanned int) error {
+	query := "UPDATE duplicate_reports SET documents_scanned = $1 WHERE id = $2"
+	_, err := r.db.ExecContext(ctx, query, scanned, id)
+	return err
+}
+
+func (r *PostgresRepository) FinishDuplicateReport(ctx context.Context, report *models.DuplicateReport) error {
+	var clusters []byte
+	if report.Clusters != nil {
+		var err error
+		if clusters, err = json.Marshal(report.Clusters); err != nil {
+			return err
+		}
+	}
+
+	query := `
+		UPDATE duplicate_reports
+		SET status = $1, documents_scanned = $2, cluster_count = $3, clusters = $4, error = $5, completed_at = $6
+		WHERE id = $7
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		report.Status, report.DocumentsScanned, report.ClusterCount, clusters, report.Error, report.CompletedAt, report.ID,
+	)
+	return err
+}
+
+// scanDuplicateReport reads a row selected with duplicateReportColumns,
+// followed by the columns in extra.
+func scanDuplicateReport(scanner rowScanner, extra ...interface{}) (*models.DuplicateReport, error) {
+	var report models.DuplicateReport
+	var createdBy sql.NullString
+	var completedAt sql.NullTime
+	dest := append([]interface{}{
+		&report.ID, &report.Status, &report.Threshold, &report.DocumentsScanned, &report.ClusterCount,
+		&report.Error, &createdBy, &report.CreatedAt, &completedAt,
+	}, extra...)
+	if err := scanner.Scan(dest...); err != nil {
+		return nil, err
+	}
+	report.CreatedBy = createdBy.String
+	if completedAt.Valid {
+		report.CompletedAt = &completedAt.Time
+	}
+	return &report, nil
+}
@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"kb-platform-gateway/internal/models"
+)
+
+const evaluationColumns = `
+	id, name, status, top_k, prompt_template_id, prompt_template_version,
+	total_cases, completed_cases, failed_cases, average_score, error,
+	created_by, created_at, updated_at, completed_at
+`
+
+func (r *PostgresRepository) CreateEvaluation(ctx context.Context, evaluation *models.Evaluation, cases []models.EvaluationCase) error {
+	casesJSON, err := json.Marshal(cases)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		WITH e AS (
+			INSERT INTO evaluations (
+				id, name, status, top_k, prompt_template_id, prompt_template_version,
+				total_cases, created_by, created_at, updated_at
+			)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $9)
+			RETURNING id
+		)
+		INSERT INTO evaluation_results (evaluation_id, position, question, expected_answer)
+		SELECT e.id, c.position - 1, c.value->>'question', c.value->>'expected_answer'
+		FROM e, jsonb_array_elements($10::jsonb) WITH ORDINALITY AS c(value, position)
+	`
+
+	_, err = r.db.ExecContext(ctx, query,
+		evaluation.ID, evaluation.Name, evaluation.Status, evaluation.TopK, evaluation.PromptTemplateID,
+		evaluation.PromptTemplateVersion, evaluation.TotalCases, evaluation.CreatedBy, evaluation.CreatedAt,
+		casesJSON,
+	)
+	return err
+}
+
+func (r *PostgresRepository) GetEvaluation(ctx context.Context, id string) (*models.Evaluation, error) {
+	query := "SELECT" + evaluationColumns + "FROM evaluations WHERE id = $1"
+
+	evaluation, err := scanEvaluation(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return evaluation, nil
+}
+
+func (r *PostgresRepository) ListEvaluations(ctx context.Context, limit, offset int) ([]*models.Evaluation, int, error) {
+	query := "SELECT" + evaluationColumns + "FROM evaluations ORDER BY created_at DESC LIMIT $1 OFFSET $2"
+
+	rows, err := r.db.QueryContext(ctx, query, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var evaluations []*models.Evaluation
+	for rows.Next() {
+		evaluation, err := scanEvaluation(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		evaluations = append(evaluations, evaluation)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM evaluations").Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	return evaluations, total, nil
+}
+
+func (r *PostgresRepository) ListEvaluationResults(ctx context.Context, id string) ([]*models.EvaluationResult, error) {
+	query := `
+		SELECT position, question, expected_answer, answer, score, metrics, error, completed_at
+		FROM evaluation_results
+		WHERE evaluation_id = $1
+		ORDER BY position
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []*models.EvaluationResult
+	for rows.Next() {
+		var result models.EvaluationResult
+		var score sql.NullFloat64
+		var metrics []byte
+		var completedAt sql.NullTime
+		if err := rows.Scan(
+			&result.Position, &result.Question, &result.ExpectedAnswer, &result.Answer,
+			&score, &metrics, &result.Error, &completedAt,
+		); err != nil {
+			return nil, err
+		}
+		if score.Valid {
+			result.Score = &score.Float64
+		}
+		if len(metrics) > 0 {
+			if err := json.Unmarshal(metrics, &result.Metrics); err != nil {
+				return nil, err
+			}
+		}
+		if completedAt.Valid {
+			result.CompletedAt = &completedAt.Time
+		}
+		results = append(results, &result)
+	}
+
+	return results, rows.Err()
+}
+
+func (r *PostgresRepository) RecordEvaluationResult(ctx context.Context, id string, result *models.EvaluationResult) error {
+	var metrics []byte
+	if result.Metrics != nil {
+		var err error
+		if metrics, err = json.Marshal(result.Metrics); err != nil {
+			return err
+		}
+	}
+
+	query := `
+		WITH r AS (
+			UPDATE evaluation_results
+			SET answer = $3, score = $4, metrics = $5, error = $6, completed_at = NOW()
+			WHERE evaluation_id = $1 AND position = $2 AND completed_at IS NULL
+			RETURNING error
+		)
+		UPDATE evaluations
+		SET completed_cases = completed_cases + (SELECT COUNT(*) FROM r),
+			failed_cases = failed_cases + (SELECT COUNT(*) FROM r WHERE error <> ''),
+			updated_at = NOW()
+		WHERE id = $1
+	`
+
+	_, err := r.db.ExecContext(ctx, query, id, result.Position, result.Answer, result.Score, metrics, result.Error)
+	return err
+}
+
+func (r *PostgresRepository) FinishEvaluation(ctx context.Context, id, status, errorMessage string) error {
+	query := `
+		UPDATE evaluations
+		SET status = $2,
+			error = $3,
+			average_score = (SELECT AVG(score) FROM evaluation_results WHERE evaluation_id = $1),
+			updated_at = NOW(),
+			completed_at = NOW()
+		WHERE id = $1
+	`
+
+	_, err := r.db.ExecContext(ctx, query, id, status, errorMessage)
+	return err
+}
+
+func scanEvaluation(row rowScanner) (*models.Evaluation, error) {
+	var evaluation models.Evaluation
+	var averageScore sql.NullFloat64
+	var createdBy sql.NullString
+	var completedAt sql.NullTime
+	if err := row.Scan(
+		&evaluation.ID, &evaluation.Name, &evaluation.Status, &evaluation.TopK, &evaluation.PromptTemplateID,
+		&evaluation.PromptTemplateVersion, &evaluation.TotalCases, &evaluation.CompletedCases,
+		&evaluation.FailedCases, &averageScore, &evaluation.Error, &createdBy, &evaluation.CreatedAt,
+		&evaluation.UpdatedAt, &completedAt,
+	); err != nil {
+		return nil, err
+	}
+	if averageScore.Valid {
+		evaluation.AverageScore = &averageScore.Float64
+	}
+	evaluation.CreatedBy = createdBy.String
+	if completedAt.Valid {
+		evaluation.CompletedAt = &completedAt.Time
+	}
+
+	return &evaluation, nil
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+
+	"kb-platform-gateway/internal/models"
+)
+
+func (r *PostgresRepository) CreateEvent(ctx context.Context, event *models.Event) (bool, error) {
+	query := `
+		INSERT INTO events (id, type, source, subject_id, data, occurred_at, received_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (id) DO NOTHING
+	`
+
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := r.db.ExecContext(ctx, query,
+		event.ID, event.Type, event.Source, event.SubjectID,
+		string(data), event.OccurredAt, event.ReceivedAt,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows > 0, nil
+}
+
+func (r *PostgresRepository) CountEvents(ctx context.Context, subjectID, eventType string) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM events WHERE subject_id = $1 AND type = $2", subjectID, eventType,
+	).Scan(&count)
+	return count, err
+}
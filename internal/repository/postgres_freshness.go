@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"kb-platform-gateway/internal/models"
+)
+
+func (r *PostgresRepository) ListStaleDocuments(ctx context.Context, before time.Time, limit int) ([]*models.StaleDocument, int, error) {
+	where := "COALESCE(d.indexed_at, d.created_at) < $2"
+	return r.listStaleDocuments(ctx, where, "COALESCE(d.indexed_at, d.created_at) ASC", limit, before.UTC())
+}
+
+func (r *PostgresRepository) ListBrokenSourceDocuments(ctx context.Context, limit int) ([]*models.StaleDocument, int, error) {
+	where := "d.source_status IN (404, 410)"
+	return r.listStaleDocuments(ctx, where, "d.source_checked_at DESC", limit)
+}
+
+func (r *PostgresRepository) ListUnretrievedDocuments(ctx context.Context, since time.Time, limit int) ([]*models.StaleDocument, int, error) {
+	where := `COALESCE(d.indexed_at, d.created_at) < $2 AND NOT EXISTS (
+		SELECT 1 FROM query_logs q
+		WHERE q.created_at >= $2 AND q.document_ids @> ARRAY[d.id]::text[]
+	)`
+	return r.listStaleDocuments(ctx, where, "COALESCE(d.indexed_at, d.created_at) ASC", limit, since.UTC())
+}
+
+// listStaleDocuments lists indexed documents matching where, whose
+// arguments are numbered from $2 after the limit.
+func (r *PostgresRepository) listStaleDocuments(ctx context.Context, where, orderBy string, limit int, args ...interface{}) ([]*models.StaleDocument, int, error) {
+	query := `
+		SELECT d.id, d.filename, d.uploaded_by, d.created_at, d.indexed_at,
+			COALESCE(d.metadata->>'` + models.DocumentSourceURLKey + `', ''), d.source_status, d.source_checked_at,
+			COUNT(*) OVER ()
+		FROM documents d
+		WHERE d.status = 'complete' AND d.deleted_at IS NULL AND ` + where + `
+		ORDER BY ` + orderBy + `, d.id
+		LIMIT $1
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, append([]interface{}{limit}, args...)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var documents []*models.StaleDocument
+	var total int
+	for rows.Next() {
+		var doc models.StaleDocument
+		var uploadedBy sql.NullString
+		var indexedAt, checkedAt sql.NullTime
+		var status sql.NullInt64
+		if err := rows.Scan(
+			&doc.ID, &doc.Filename, &uploadedBy, &doc.CreatedAt, &indexedAt,
+			&doc.SourceURL, &status, &checkedAt, &total,
+		); err != nil {
+			return nil, 0, err
+		}
+		doc.UploadedBy = uploadedBy.String
+		if indexedAt.Valid {
+			doc.IndexedAt = &indexedAt.Time
+		}
+		doc.SourceStatus = int(status.Int64)
+		if checkedAt.Valid {
+			doc.SourceCheckedAt = &checkedAt.Time
+		}
+		documents = append(documents, &doc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return documents, total, nil
+}
+
+func (r *PostgresRepository) ClaimSourceChecks(ctx context.Context, checkedBefore time.Time, limit int) ([]models.SourceCheck, error) {
+	query := `
+		UPDATE documents
+		SET source_checked_at = NOW()
+		WHERE id IN (
+			SELECT id FROM documents
+			WHERE status = 'complete' AND deleted_at IS NULL
+				AND COALESCE(metadata->>'` + models.DocumentSourceURLKey + `', '') <> ''
+				AND (source_checked_at IS NULL OR source_checked_at < $1)
+			ORDER BY source_checked_at NULLS FIRST, id
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, metadata->>'` + models.DocumentSourceURLKey + `'
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, checkedBefore.UTC(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var checks []models.SourceCheck
+	for rows.Next() {
+		var check models.SourceCheck
+		if err := rows.Scan(&check.DocumentID, &check.URL); err != nil {
+			return nil, err
+		}
+		checks = append(checks, check)
+	}
+
+	return checks, rows.Err()
+}
+
+func (r *PostgresRepository) RecordSourceStatus(ctx context.Context, documentID string, status int) error {
+	query := "UPDATE documents SET source_status = $2, source_checked_at = NOW() WHERE id = $1"
+
+	_, err := r.db.ExecContext(ctx, query, documentID, status)
+	return err
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"kb-platform-gateway/internal/models"
+)
+
+const glossaryTermColumns = "id, term, definition, created_by, created_at, updated_at"
+
+func (r *PostgresRepository) CreateGlossaryTerm(ctx context.Context, term *models.GlossaryTerm) (bool, error) {
+	query := `
+		INSERT INTO glossary_terms (id, term, definition, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT ((LOWER(term))) DO NOTHING
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		term.ID, term.Term, term.Definition, nullString(term.CreatedBy), term.CreatedAt, term.UpdatedAt,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows > 0, nil
+}
+
+func (r *PostgresRepository) GetGlossaryTerm(ctx context.Context, id string) (*models.GlossaryTerm, error) {
+	query := "SELECT " + glossaryTermColumns + " FROM glossary_terms WHERE id = $1"
+
+	term, err := scanGlossaryTerm(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return term, nil
+}
+
+func (r *PostgresRepository) ListGlossaryTerms(ctx context.Context, limit, offset int) ([]*models.GlossaryTerm, int, error) {
+	query := "SELECT " + glossaryTermColumns + " FROM glossary_terms ORDER BY LOWER(term) LIMIT $1 OFFSET $2"
+
+	terms, err := r.listGlossaryTerms(ctx, query, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM glossary_terms").Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	return terms, total, nil
+}
+
+func (r *PostgresRepository) ListAllGlossaryTerms(ctx context.Context) ([]*models.GlossaryTerm, error) {
+	return r.listGlossaryTerms(ctx, "SELECT "+glossaryTermColumns+" FROM glossary_terms")
+}
+
+func (r *PostgresRepository) listGlossaryTerms(ctx context.Context, query string, args ...interface{}) ([]*models.GlossaryTerm, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var terms []*models.GlossaryTerm
+	for rows.Next() {
+		term, err := scanGlossaryTerm(rows)
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, term)
+	}
+
+	return terms, rows.Err()
+}
+
+func (r *PostgresRepository) UpdateGlossaryTerm(ctx context.Context, term *models.GlossaryTerm) (bool, error) {
+	result, err := r.db.ExecContext(ctx,
+		"UPDATE glossary_terms SET definition = $1, updated_at = $2 WHERE id = $3",
+		term.Definition, term.UpdatedAt, term.ID,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows > 0, nil
+}
+
+func (r *PostgresRepository) DeleteGlossaryTerm(ctx context.Context, id string) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM glossary_terms WHERE id = $1", id)
+	return err
+}
+
+func scanGlossaryTerm(row rowScanner) (*models.GlossaryTerm, error) {
+	var term models.GlossaryTerm
+	var createdBy sql.NullString
+	if err := row.Scan(&term.ID, &term.Term, &term.Definition, &createdBy, &term.CreatedAt, &term.UpdatedAt); err != nil {
+		return nil, err
+	}
+	term.CreatedBy = createdBy.String
+
+	return &term, nil
+}
@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"kb-platform-gateway/internal/models"
+)
+
+const impersonationSessionColumns = `
+	s.id, s.impersonator, s.username, s.reason, s.created_at, s.expires_at, s.revoked_at,
+	(SELECT COUNT(*) FROM impersonation_requests r WHERE r.session_id = s.id)
+`
+
+func (r *PostgresRepository) CreateImpersonationSession(ctx context.Context, session *models.ImpersonationSession) error {
+	query := `
+		INSERT INTO impersonation_sessions (id, impersonator, username, reason, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		session.ID, session.Impersonator, session.Username, session.Reason, session.CreatedAt, session.ExpiresAt,
+	)
+	return err
+}
+
+func (r *PostgresRepository) GetImpersonationSession(ctx context.Context, id string) (*models.ImpersonationSession, error) {
+	query := "SELECT" + impersonationSessionColumns + "FROM impersonation_sessions s WHERE s.id = $1"
+
+	session, err := scanImpersonationSession(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return session, err
+}
+
+func (r *PostgresRepository) ListImpersonationSessions(ctx context.Context, limit, offset int) ([]*models.ImpersonationSession, int, error) {
+	query := "SELECT" + impersonationSessionColumns + "FROM impersonation_sessions s ORDER BY s.created_at DESC LIMIT $1 OFFSET $2"
+
+	rows, err := r.db.QueryContext(ctx, query, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var sessions []*models.ImpersonationSession
+	for rows.Next() {
+		session, err := scanImpersonationSession(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		sessions = append(sessions, session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM impersonation_sessions").Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	return sessions, total, nil
+}
+
+func (r *PostgresRepository) RevokeImpersonationSession(ctx context.Context, id string, at time.Time) (bool, error) {
+	result, err := r.db.ExecContext(ctx, "UPDATE impersonation_sessions SET revoked_at = $1 WHERE id = $2 AND revoked_at IS NULL", at, id)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows > 0, nil
+}
+
+func (r *PostgresRepository) RecordImpersonationRequest(ctx context.Context, request *models.ImpersonationRequest) error {
+	query := `
+		INSERT INTO impersonation_requests (session_id, method, path, status, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		request.SessionID, request.Method, request.Path, request.Status, request.CreatedAt,
+	)
+	return err
+}
+
+func (r *PostgresRepository) ListImpersonationRequests(ctx context.Context, sessionID string) ([]*models.ImpersonationRequest, error) {
+	query := `
+		SELECT session_id, method, path, status, created_at
+		FROM impersonation_requests
+		WHERE session_id = $1
+		ORDER BY created_at, id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var requests []*models.ImpersonationRequest
+	for rows.Next() {
+		var request models.ImpersonationRequest
+		if err := rows.Scan(&request.SessionID, &request.Method, &request.Path, &request.Status, &request.CreatedAt); err != nil {
+			return nil, err
+		}
+		requests = append(requests, &request)
+	}
+
+	return requests, rows.Err()
+}
+
+func scanImpersonationSession(row rowScanner) (*models.ImpersonationSession, error) {
+	var session models.ImpersonationSession
+	var revokedAt sql.NullTime
+	if err := row.Scan(
+		&session.ID, &session.Impersonator, &session.Username, &session.Reason, &session.CreatedAt,
+		&session.ExpiresAt, &revokedAt, &session.Requests,
+	); err != nil {
+		return nil, err
+	}
+	if revokedAt.Valid {
+		session.RevokedAt = &revokedAt.Time
+	}
+	return &session, nil
+}
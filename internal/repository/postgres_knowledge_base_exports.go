@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"kb-platform-gateway/internal/models"
+)
+
+const knowledgeBaseExportColumns = `
+	id, status, format, filter, document_count, documents_exported, size_bytes,
+	manifest_key, archive_key, error, created_by, created_at, completed_at
+`
+
+func (r *PostgresRepository) CreateKnowledgeBaseExport(ctx context.Context, export *models.KnowledgeBaseExport) error {
+	filter, err := json.Marshal(export.Filter)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO knowledge_base_exports (
+			id, status, format, filter, document_count, manifest_key, archive_key, created_by, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+	_, err = r.db.ExecContext(ctx, query,
+		export.ID, export.Status, export.Format, filter, export.DocumentCount,
+		export.ManifestKey, export.ArchiveKey, nullString(export.CreatedBy), export.CreatedAt,
+	)
+	return err
+}
+
+func (r *PostgresRepository) GetKnowledgeBaseExport(ctx context.Context, id string) (*models.KnowledgeBaseExport, error) {
+	query := "SELECT" + knowledgeBaseExportColumns + "FROM knowledge_base_exports WHERE id = $1"
+
+	export, err := scanKnowledgeBaseExport(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+func (r *PostgresRepository) ListKnowledgeBaseExports(ctx context.Context, createdBy string, limit, offset int) ([]*models.KnowledgeBaseExport, int, error) {
+	query := "SELECT" + knowledgeBaseExportColumns + `
+		FROM knowledge_base_exports
+		WHERE created_by = $1
+		ORDER BY created_at DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, createdBy, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var exports []*models.KnowledgeBaseExport
+	for rows.Next() {
+		export, err := scanKnowledgeBaseExport(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		exports = append(exports, export)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM knowledge_base_exports WHERE created_by = $1", createdBy).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	return exports, total, nil
+}
+
+func (r *PostgresRepository) UpdateKnowledgeBaseExport(ctx context.Context, export *models.KnowledgeBaseExport) error {
+	// Only running exports change, so a late progress report cannot undo
+	// the end of an export.
+	query := `
+		UPDATE knowledge_base_exports
+		SET status = $1, documents_exported = $2, size_bytes = $3, error = $4, completed_at = $5
+		WHERE id = $6 AND status = 'running'
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		export.Status, export.DocumentsExported, export.SizeBytes, export.Error, export.CompletedAt, export.ID,
+	)
+	return err
+}
+
+// scanKnowledgeBaseExport reads a row selected with
+// knowledgeBaseExportColumns.
+func scanKnowledgeBaseExport(scanner rowScanner) (*models.KnowledgeBaseExport, error) {
+	var export models.KnowledgeBaseExport
+	var filter []byte
+	var createdBy sql.NullString
+	var completedAt sql.NullTime
+	if err := scanner.Scan(
+		&export.ID, &export.Status, &export.Format, &filter, &export.DocumentCount, &export.DocumentsExported,
+		&export.SizeBytes, &export.ManifestKey, &export.ArchiveKey, &export.Error, &createdBy,
+		&export.CreatedAt, &completedAt,
+	); err != nil {
+		return nil, err
+	}
+	if len(filter) > 0 {
+		if err := json.Unmarshal(filter, &export.Filter); err != nil {
+			return nil, err
+		}
+	}
+	export.CreatedBy = createdBy.String
+	if completedAt.Valid {
+		export.CompletedAt = &completedAt.Time
+	}
+	return &export, nil
+}
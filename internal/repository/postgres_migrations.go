@@ -0,0 +1,245 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"kb-platform-gateway/internal/models"
+)
+
+const embeddingMigrationColumns = `
+	id, embedding_model, vector_size, source_collection, target_collection, status,
+	total_documents, indexed_documents, failed_documents, error, created_by,
+	created_at, updated_at, completed_at
+`
+
+func (r *PostgresRepository) CreateEmbeddingMigration(ctx context.Context, migration *models.EmbeddingMigration) (bool, error) {
+	// The count and the document rows come from the same snapshot, so
+	// TotalDocuments matches the documents tracked.
+	query := `
+		WITH m AS (
+			INSERT INTO embedding_migrations (
+				id, embedding_model, vector_size, source_collection, target_collection, status,
+				total_documents, created_by, created_at, updated_at
+			)
+			SELECT $1, $2, $3, $4, $5, 'running', COUNT(*), $6, $7, $7
+			FROM documents WHERE status = 'complete' AND deleted_at IS NULL
+			ON CONFLICT DO NOTHING
+			RETURNING id, total_documents
+		), d AS (
+			INSERT INTO embedding_migration_documents (migration_id, document_id)
+			SELECT m.id, documents.id FROM m, documents WHERE documents.status = 'complete'
+		)
+		SELECT total_documents FROM m
+	`
+
+	err := r.db.QueryRowContext(ctx, query,
+		migration.ID, migration.EmbeddingModel, migration.VectorSize, migration.SourceCollection,
+		migration.TargetCollection, migration.CreatedBy, migration.CreatedAt,
+	).Scan(&migration.TotalDocuments)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *PostgresRepository) GetEmbeddingMigration(ctx context.Context, id string) (*models.EmbeddingMigration, error) {
+	query := "SELECT" + embeddingMigrationColumns + "FROM embedding_migrations WHERE id = $1"
+	return r.getEmbeddingMigration(ctx, query, id)
+}
+
+func (r *PostgresRepository) ListEmbeddingMigrations(ctx context.Context, limit, offset int) ([]*models.EmbeddingMigration, int, error) {
+	query := "SELECT" + embeddingMigrationColumns + "FROM embedding_migrations ORDER BY created_at DESC LIMIT $1 OFFSET $2"
+
+	rows, err := r.db.QueryContext(ctx, query, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var migrations []*models.EmbeddingMigration
+	for rows.Next() {
+		migration, err := scanEmbeddingMigration(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		migrations = append(migrations, migration)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM embedding_migrations").Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	return migrations, total, nil
+}
+
+func (r *PostgresRepository) GetRunningEmbeddingMigration(ctx context.Context) (*models.EmbeddingMigration, error) {
+	query := "SELECT" + embeddingMigrationColumns + "FROM embedding_migrations WHERE status = 'running'"
+	return r.getEmbeddingMigration(ctx, query)
+}
+
+func (r *PostgresRepository) GetActiveEmbeddingMigration(ctx context.Context) (*models.EmbeddingMigration, error) {
+	query := "SELECT" + embeddingMigrationColumns + `
+		FROM embedding_migrations
+		WHERE status = 'completed'
+		ORDER BY completed_at DESC
+		LIMIT 1
+	`
+	return r.getEmbeddingMigration(ctx, query)
+}
+
+func (r *PostgresRepository) AddEmbeddingMigrationDocuments(ctx context.Context, id string) (int, error) {
+	query := `
+		WITH d AS (
+			INSERT INTO embedding_migration_documents (migration_id, document_id)
+			SELECT $1, id FROM documents WHERE status = 'complete' AND deleted_at IS NULL
+			ON CONFLICT DO NOTHING
+			RETURNING document_id
+		)
+		UPDATE embedding_migrations
+		SET total_documents = total_documents + (SELECT COUNT(*) FROM d), updated_at = NOW()
+		WHERE id = $1
+		RETURNING (SELECT COUNT(*) FROM d)
+	`
+
+	var added int
+	err := r.db.QueryRowContext(ctx, query, id).Scan(&added)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return added, nil
+}
+
+func (r *PostgresRepository) ClaimEmbeddingMigrationBatch(ctx context.Context, id string, limit int) ([]string, error) {
+	query := `
+		UPDATE embedding_migration_documents
+		SET status = 'queued', updated_at = NOW()
+		WHERE migration_id = $1 AND document_id IN (
+			SELECT document_id FROM embedding_migration_documents
+			WHERE migration_id = $1 AND status = 'pending'
+			ORDER BY document_id
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING document_id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, id, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var documentIDs []string
+	for rows.Next() {
+		var documentID string
+		if err := rows.Scan(&documentID); err != nil {
+			return nil, err
+		}
+		documentIDs = append(documentIDs, documentID)
+	}
+
+	return documentIDs, rows.Err()
+}
+
+func (r *PostgresRepository) CountQueuedEmbeddingMigrationDocuments(ctx context.Context, id string) (int, error) {
+	query := "SELECT COUNT(*) FROM embedding_migration_documents WHERE migration_id = $1 AND status = 'queued'"
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, id).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *PostgresRepository) RecordEmbeddingMigrationDocument(ctx context.Context, id, documentID, status string) (bool, error) {
+	query := `
+		WITH d AS (
+			UPDATE embedding_migration_documents
+			SET status = $3, updated_at = NOW()
+			WHERE migration_id = $1 AND document_id = $2 AND status = 'queued'
+			RETURNING status
+		)
+		UPDATE embedding_migrations
+		SET indexed_documents = indexed_documents + (SELECT COUNT(*) FROM d WHERE status = 'indexed'),
+			failed_documents = failed_documents + (SELECT COUNT(*) FROM d WHERE status = 'failed'),
+			updated_at = NOW()
+		WHERE id = $1 AND EXISTS (SELECT 1 FROM d)
+	`
+
+	result, err := r.db.ExecContext(ctx, query, id, documentID, status)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows > 0, nil
+}
+
+func (r *PostgresRepository) FinishEmbeddingMigration(ctx context.Context, id, status, errorMessage string) (bool, error) {
+	query := `
+		UPDATE embedding_migrations
+		SET status = $2, error = $3, updated_at = NOW(), completed_at = NOW()
+		WHERE id = $1 AND status = 'running'
+	`
+
+	result, err := r.db.ExecContext(ctx, query, id, status, errorMessage)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows > 0, nil
+}
+
+func (r *PostgresRepository) getEmbeddingMigration(ctx context.Context, query string, args ...interface{}) (*models.EmbeddingMigration, error) {
+	migration, err := scanEmbeddingMigration(r.db.QueryRowContext(ctx, query, args...))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return migration, nil
+}
+
+func scanEmbeddingMigration(row rowScanner) (*models.EmbeddingMigration, error) {
+	var migration models.EmbeddingMigration
+	var createdBy sql.NullString
+	var completedAt sql.NullTime
+	if err := row.Scan(
+		&migration.ID, &migration.EmbeddingModel, &migration.VectorSize, &migration.SourceCollection,
+		&migration.TargetCollection, &migration.Status, &migration.TotalDocuments, &migration.IndexedDocuments,
+		&migration.FailedDocuments, &migration.Error, &createdBy, &migration.CreatedAt, &migration.UpdatedAt,
+		&completedAt,
+	); err != nil {
+		return nil, err
+	}
+	migration.CreatedBy = createdBy.String
+	if completedAt.Valid {
+		migration.CompletedAt = &completedAt.Time
+	}
+
+	return &migration, nil
+}
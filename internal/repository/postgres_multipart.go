@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"kb-platform-gateway/internal/models"
+)
+
+func (r *PostgresRepository) CreateMultipartUpload(ctx context.Context, upload *models.MultipartUpload) error {
+	query := `
+		INSERT INTO multipart_uploads (document_id, upload_id, part_size, part_count, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		upload.DocumentID, upload.UploadID, upload.PartSize, upload.PartCount, upload.CreatedAt,
+	)
+	return err
+}
+
+func (r *PostgresRepository) GetMultipartUpload(ctx context.Context, documentID string) (*models.MultipartUpload, error) {
+	query := `
+		SELECT document_id, upload_id, part_size, part_count, created_at, completed_at
+		FROM multipart_uploads
+		WHERE document_id = $1
+	`
+
+	var upload models.MultipartUpload
+	var completedAt sql.NullTime
+	err := r.db.QueryRowContext(ctx, query, documentID).Scan(
+		&upload.DocumentID, &upload.UploadID, &upload.PartSize, &upload.PartCount,
+		&upload.CreatedAt, &completedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if completedAt.Valid {
+		upload.CompletedAt = &completedAt.Time
+	}
+
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT part_number, etag, uploaded_at FROM multipart_upload_parts WHERE document_id = $1 ORDER BY part_number",
+		documentID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	upload.Parts = []models.MultipartUploadPart{}
+	for rows.Next() {
+		var part models.MultipartUploadPart
+		if err := rows.Scan(&part.PartNumber, &part.ETag, &part.UploadedAt); err != nil {
+			return nil, err
+		}
+		upload.Parts = append(upload.Parts, part)
+	}
+
+	return &upload, rows.Err()
+}
+
+func (r *PostgresRepository) RecordMultipartUploadPart(ctx context.Context, documentID string, part models.MultipartUploadPart) error {
+	query := `
+		INSERT INTO multipart_upload_parts (document_id, part_number, etag, uploaded_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (document_id, part_number)
+		DO UPDATE SET etag = EXCLUDED.etag, uploaded_at = EXCLUDED.uploaded_at
+	`
+
+	_, err := r.db.ExecContext(ctx, query, documentID, part.PartNumber, part.ETag, part.UploadedAt)
+	return err
+}
+
+func (r *PostgresRepository) CompleteMultipartUpload(ctx context.Context, documentID string, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE multipart_uploads SET completed_at = $1 WHERE document_id = $2", at, documentID)
+	return err
+}
+
+func (r *PostgresRepository) DeleteMultipartUpload(ctx context.Context, documentID string) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM multipart_uploads WHERE document_id = $1", documentID)
+	return err
+}
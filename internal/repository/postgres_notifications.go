@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"kb-platform-gateway/internal/models"
+)
+
+func (r *PostgresRepository) GetNotificationPreferences(ctx context.Context, username string) (*models.NotificationPreferences, error) {
+	query := `
+		SELECT username, email, import_finished, indexing_failed, export_ready, updated_at
+		FROM notification_preferences
+		WHERE username = $1
+	`
+
+	var prefs models.NotificationPreferences
+	err := r.db.QueryRowContext(ctx, query, username).Scan(
+		&prefs.Username, &prefs.Email, &prefs.ImportFinished, &prefs.IndexingFailed,
+		&prefs.ExportReady, &prefs.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &prefs, nil
+}
+
+func (r *PostgresRepository) UpsertNotificationPreferences(ctx context.Context, prefs *models.NotificationPreferences) error {
+	query := `
+		INSERT INTO notification_preferences (username, email, import_finished, indexing_failed, export_ready, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (username) DO UPDATE
+		SET email = EXCLUDED.email,
+			import_finished = EXCLUDED.import_finished,
+			indexing_failed = EXCLUDED.indexing_failed,
+			export_ready = EXCLUDED.export_ready,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		prefs.Username, prefs.Email, prefs.ImportFinished, prefs.IndexingFailed,
+		prefs.ExportReady, prefs.UpdatedAt,
+	)
+	return err
+}
@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"kb-platform-gateway/internal/models"
+)
+
+func (r *PostgresRepository) CreatePromptTemplate(ctx context.Context, tmpl *models.PromptTemplate) (bool, error) {
+	query := `
+		WITH t AS (
+			INSERT INTO prompt_templates (id, name, description, latest_version, created_by, created_at, updated_at)
+			VALUES ($1, $2, $3, 1, $4, $5, $6)
+			ON CONFLICT (name) DO NOTHING
+			RETURNING id
+		)
+		INSERT INTO prompt_template_versions (template_id, version, template, created_by, created_at)
+		SELECT id, 1, $7, $4, $6 FROM t
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		tmpl.ID, tmpl.Name, tmpl.Description, tmpl.CreatedBy, tmpl.CreatedAt, tmpl.UpdatedAt, tmpl.Template,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows > 0, nil
+}
+
+func (r *PostgresRepository) GetPromptTemplate(ctx context.Context, id string, version int) (*models.PromptTemplate, error) {
+	query := `
+		SELECT t.id, t.name, t.description, v.version, v.template, t.created_by, t.created_at, v.created_at
+		FROM prompt_templates t
+		JOIN prompt_template_versions v ON v.template_id = t.id
+		WHERE t.id = $1 AND v.version = COALESCE(NULLIF($2::int, 0), t.latest_version)
+	`
+
+	tmpl, err := scanPromptTemplate(r.db.QueryRowContext(ctx, query, id, version))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return tmpl, nil
+}
+
+func (r *PostgresRepository) ListPromptTemplates(ctx context.Context, limit, offset int) ([]*models.PromptTemplate, int, error) {
+	query := `
+		SELECT t.id, t.name, t.description, v.version, v.template, t.created_by, t.created_at, v.created_at
+		FROM prompt_templates t
+		JOIN prompt_template_versions v ON v.template_id = t.id AND v.version = t.latest_version
+		ORDER BY t.name
+		LIMIT $1 OFFSET $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var templates []*models.PromptTemplate
+	for rows.Next() {
+		tmpl, err := scanPromptTemplate(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		templates = append(templates, tmpl)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM prompt_templates").Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	return templates, total, nil
+}
+
+func (r *PostgresRepository) AddPromptTemplateVersion(ctx context.Context, id string, version *models.PromptTemplateVersion, description *string) (bool, error) {
+	query := `
+		WITH t AS (
+			UPDATE prompt_templates
+			SET latest_version = latest_version + 1,
+				description = COALESCE($2, description),
+				updated_at = $5
+			WHERE id = $1
+			RETURNING id, latest_version
+		)
+		INSERT INTO prompt_template_versions (template_id, version, template, created_by, created_at)
+		SELECT id, latest_version, $3, $4, $5 FROM t
+		RETURNING version
+	`
+
+	err := r.db.QueryRowContext(ctx, query,
+		id, description, version.Template, version.CreatedBy, version.CreatedAt,
+	).Scan(&version.Version)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *PostgresRepository) ListPromptTemplateVersions(ctx context.Context, id string) ([]*models.PromptTemplateVersion, error) {
+	query := `
+		SELECT version, template, created_by, created_at
+		FROM prompt_template_versions
+		WHERE template_id = $1
+		ORDER BY version DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var versions []*models.PromptTemplateVersion
+	for rows.Next() {
+		var v models.PromptTemplateVersion
+		var createdBy sql.NullString
+		if err := rows.Scan(&v.Version, &v.Template, &createdBy, &v.CreatedAt); err != nil {
+			return nil, err
+		}
+		v.CreatedBy = createdBy.String
+		versions = append(versions, &v)
+	}
+
+	return versions, rows.Err()
+}
+
+func (r *PostgresRepository) DeletePromptTemplate(ctx context.Context, id string) error {
+	query := "DELETE FROM prompt_templates WHERE id = $1"
+	_, err := r.db.ExecContext(ctx, query, id)
+	return err
+}
+
+func scanPromptTemplate(row rowScanner) (*models.PromptTemplate, error) {
+	var tmpl models.PromptTemplate
+	var createdBy sql.NullString
+	if err := row.Scan(
+		&tmpl.ID, &tmpl.Name, &tmpl.Description, &tmpl.Version, &tmpl.Template,
+		&createdBy, &tmpl.CreatedAt, &tmpl.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	tmpl.CreatedBy = createdBy.String
+
+	return &tmpl, nil
+}
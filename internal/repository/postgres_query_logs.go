@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"kb-platform-gateway/internal/models"
+
+	"github.com/lib/pq"
+)
+
+func (r *PostgresRepository) CreateQueryLog(ctx context.Context, log *models.QueryLog) error {
+	citations := log.Citations
+	if citations == nil {
+		citations = []models.Citation{}
+	}
+	citationsJSON, err := json.Marshal(citations)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		WITH q AS (
+			INSERT INTO query_logs (id, username, conversation_id, question, status, latency_ms, tokens, created_at, document_ids)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, COALESCE($9::text[], '{}'))
+			RETURNING id, created_at
+		)
+		INSERT INTO query_citations (query_id, document_id, chunk_id, score, created_at, position, page, start_char, end_char)
+		SELECT q.id, c.document_id, COALESCE(c.chunk_id, ''), c.score, q.created_at, c.position - 1,
+			COALESCE(c.page, 0), COALESCE(c.start_char, 0), COALESCE(c.end_char, 0)
+		FROM q, ROWS FROM (
+			jsonb_to_recordset($10::jsonb) AS (document_id TEXT, chunk_id TEXT, score DOUBLE PRECISION, page INTEGER, start_char INTEGER, end_char INTEGER)
+		) WITH ORDINALITY AS c(document_id, chunk_id, score, page, start_char, end_char, position)
+	`
+
+	_, err = r.db.ExecContext(ctx, query,
+		log.ID, log.Username, nullString(log.ConversationID), log.Question,
+		log.Status, log.LatencyMS, log.Tokens, log.CreatedAt, pq.Array(log.DocumentIDs),
+		citationsJSON,
+	)
+	return err
+}
+
+func (r *PostgresRepository) SetQueryFeedback(ctx context.Context, id, username string, rating int, comment string) (bool, error) {
+	query := `
+		UPDATE query_logs
+		SET feedback = $1, feedback_comment = $2
+		WHERE id = $3 AND username = $4
+	`
+
+	result, err := r.db.ExecContext(ctx, query, rating, nullString(comment), id, username)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows > 0, nil
+}
+
+func (r *PostgresRepository) ExportQueryLogs(ctx context.Context, from, to time.Time, fn func(*models.QueryLog) error) error {
+	query := `
+		SELECT id, username, conversation_id, question, status, latency_ms, tokens,
+			feedback, feedback_comment, created_at
+		FROM query_logs
+		WHERE created_at >= $1 AND created_at < $2
+		ORDER BY created_at ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, from.UTC(), to.UTC())
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var log models.QueryLog
+		var conversationID, comment sql.NullString
+		var tokens, feedback sql.NullInt64
+		if err := rows.Scan(
+			&log.ID, &log.Username, &conversationID, &log.Question, &log.Status, &log.LatencyMS, &tokens,
+			&feedback, &comment, &log.CreatedAt,
+		); err != nil {
+			return err
+		}
+		log.ConversationID = conversationID.String
+		log.FeedbackComment = comment.String
+		if tokens.Valid {
+			n := int(tokens.Int64)
+			log.Tokens = &n
+		}
+		if feedback.Valid {
+			n := int(feedback.Int64)
+			log.Feedback = &n
+		}
+
+		if err := fn(&log); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (r *PostgresRepository) ListQueryCitations(ctx context.Context, queryIDs []string) (map[string][]models.Citation, error) {
+	query := `
+		SELECT query_id, document_id, chunk_id, score, page, start_char, end_char
+		FROM query_citations
+		WHERE query_id = ANY($1)
+		ORDER BY query_id, position
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(queryIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	citations := make(map[string][]models.Citation)
+	for rows.Next() {
+		var queryID string
+		var c models.Citation
+		if err := rows.Scan(&queryID, &c.DocumentID, &c.ChunkID, &c.Score, &c.Page, &c.StartChar, &c.EndChar); err != nil {
+			return nil, err
+		}
+		citations[queryID] = append(citations[queryID], c)
+	}
+
+	return citations, rows.Err()
+}
+
+// likeEscaper escapes the LIKE wildcards, with the default escape
+// character.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+func (r *PostgresRepository) SuggestQueries(ctx context.Context, text string, minUsers, limit int) ([]models.QuerySuggestion, error) {
+	// The trigram indexes on question and filename serve the ILIKE
+	// substring matches. Document titles have no popularity, so among
+	// equally good matches questions come first.
+	query := `
+		SELECT suggestion, source, document_id
+		FROM (
+			SELECT min(question) AS suggestion, 'query' AS source, '' AS document_id,
+				COUNT(*) AS popularity
+			FROM query_logs
+			WHERE status = 'completed' AND question ILIKE '%' || $1 || '%'
+			GROUP BY lower(question)
+			HAVING COUNT(DISTINCT username) >= $3
+			UNION ALL
+			SELECT regexp_replace(filename, '\.[^.]*$', ''), 'document', id, 0
+			FROM documents
+			WHERE status = 'complete' AND deleted_at IS NULL AND filename ILIKE '%' || $1 || '%'
+		) s
+		ORDER BY suggestion ILIKE $1 || '%' DESC, popularity DESC, length(suggestion), suggestion
+		LIMIT $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, likeEscaper.Replace(text), limit, minUsers)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var suggestions []models.QuerySuggestion
+	for rows.Next() {
+		var s models.QuerySuggestion
+		if err := rows.Scan(&s.Text, &s.Source, &s.DocumentID); err != nil {
+			return nil, err
+		}
+		suggestions = append(suggestions, s)
+	}
+
+	return suggestions, rows.Err()
+}
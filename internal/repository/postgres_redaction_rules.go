@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"kb-platform-gateway/internal/models"
+
+	"github.com/lib/pq"
+)
+
+const redactionRuleColumns = "id, name, type, pattern, keywords, placeholder, enabled, created_by, updated_by, created_at, updated_at"
+
+func (r *PostgresRepository) CreateRedactionRule(ctx context.Context, rule *models.RedactionRule) error {
+	query := `
+		INSERT INTO redaction_rules (id, name, type, pattern, keywords, placeholder, enabled, created_by, updated_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, COALESCE($5::text[], '{}'), $6, $7, $8, $9, $10, $11)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		rule.ID, rule.Name, rule.Type, rule.Pattern, pq.Array(rule.Keywords), rule.Placeholder, rule.Enabled,
+		nullString(rule.CreatedBy), nullString(rule.UpdatedBy), rule.CreatedAt, rule.UpdatedAt,
+	)
+	return err
+}
+
+func (r *PostgresRepository) GetRedactionRule(ctx context.Context, id string) (*models.RedactionRule, error) {
+	query := "SELECT " + redactionRuleColumns + " FROM redaction_rules WHERE id = $1"
+
+	rule, err := scanRedactionRule(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+func (r *PostgresRepository) ListRedactionRules(ctx context.Context, limit, offset int) ([]*models.RedactionRule, int, error) {
+	query := "SELECT " + redactionRuleColumns + " FROM redaction_rules ORDER BY created_at, id LIMIT $1 OFFSET $2"
+
+	rules, err := r.listRedactionRules(ctx, query, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM redaction_rules").Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	return rules, total, nil
+}
+
+func (r *PostgresRepository) ListEnabledRedactionRules(ctx context.Context) ([]*models.RedactionRule, error) {
+	return r.listRedactionRules(ctx, "SELECT "+redactionRuleColumns+" FROM redaction_rules WHERE enabled ORDER BY created_at, id")
+}
+
+func (r *PostgresRepository) listRedactionRules(ctx context.Context, query string, args ...interface{}) ([]*models.RedactionRule, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []*models.RedactionRule
+	for rows.Next() {
+		rule, err := scanRedactionRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, rows.Err()
+}
+
+func (r *PostgresRepository) UpdateRedactionRule(ctx context.Context, rule *models.RedactionRule) (bool, error) {
+	query := `
+		UPDATE redaction_rules
+		SET name = $1, type = $2, pattern = $3, keywords = COALESCE($4::text[], '{}'), placeholder = $5,
+			enabled = $6, updated_by = $7, updated_at = $8
+		WHERE id = $9
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		rule.Name, rule.Type, rule.Pattern, pq.Array(rule.Keywords), rule.Placeholder,
+		rule.Enabled, nullString(rule.UpdatedBy), rule.UpdatedAt, rule.ID,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows > 0, nil
+}
+
+func (r *PostgresRepository) DeleteRedactionRule(ctx context.Context, id string) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM redaction_rules WHERE id = $1", id)
+	return err
+}
+
+func scanRedactionRule(row rowScanner) (*models.RedactionRule, error) {
+	var rule models.RedactionRule
+	var createdBy, updatedBy sql.NullString
+	if err := row.Scan(
+		&rule.ID, &rule.Name, &rule.Type, &rule.Pattern, pq.Array(&rule.Keywords), &rule.Placeholder, &rule.Enabled,
+		&createdBy, &updatedBy, &rule.CreatedAt, &rule.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	rule.CreatedBy = createdBy.String
+	rule.UpdatedBy = updatedBy.String
+
+	return &rule, nil
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"kb-platform-gateway/internal/models"
+)
+
+func (r *PostgresRepository) UpsertResyncSchedule(ctx context.Context, schedule *models.ResyncSchedule) error {
+	query := `
+		INSERT INTO resync_schedules (source_type, source_id, cron, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (source_type, source_id)
+		DO UPDATE SET cron = EXCLUDED.cron, updated_at = EXCLUDED.updated_at
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		schedule.SourceType, schedule.SourceID, schedule.Cron,
+		nullString(schedule.CreatedBy), schedule.CreatedAt, schedule.UpdatedAt,
+	)
+	return err
+}
+
+func (r *PostgresRepository) GetResyncSchedule(ctx context.Context, sourceType, sourceID string) (*models.ResyncSchedule, error) {
+	query := `
+		SELECT source_type, source_id, cron, content_hash, created_by, created_at, updated_at,
+			last_checked_at, last_changed_at
+		FROM resync_schedules
+		WHERE source_type = $1 AND source_id = $2
+	`
+
+	var schedule models.ResyncSchedule
+	var createdBy sql.NullString
+	var lastCheckedAt, lastChangedAt sql.NullTime
+	err := r.db.QueryRowContext(ctx, query, sourceType, sourceID).Scan(
+		&schedule.SourceType, &schedule.SourceID, &schedule.Cron, &schedule.ContentHash, &createdBy,
+		&schedule.CreatedAt, &schedule.UpdatedAt, &lastCheckedAt, &lastChangedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	schedule.CreatedBy = createdBy.String
+	if lastCheckedAt.Valid {
+		schedule.LastCheckedAt = &lastCheckedAt.Time
+	}
+	if lastChangedAt.Valid {
+		schedule.LastChangedAt = &lastChangedAt.Time
+	}
+
+	return &schedule, nil
+}
+
+func (r *PostgresRepository) DeleteResyncSchedule(ctx context.Context, sourceType, sourceID string) error {
+	query := "DELETE FROM resync_schedules WHERE source_type = $1 AND source_id = $2"
+	_, err := r.db.ExecContext(ctx, query, sourceType, sourceID)
+	return err
+}
+
+func (r *PostgresRepository) RecordResyncCheck(ctx context.Context, sourceType, sourceID, contentHash string, at time.Time) error {
+	query := `
+		UPDATE resync_schedules
+		SET last_changed_at = CASE WHEN content_hash <> $1 THEN $2 ELSE last_changed_at END,
+			content_hash = $1,
+			last_checked_at = $2
+		WHERE source_type = $3 AND source_id = $4
+	`
+
+	_, err := r.db.ExecContext(ctx, query, contentHash, at, sourceType, sourceID)
+	return err
+}
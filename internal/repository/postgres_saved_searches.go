@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"kb-platform-gateway/internal/models"
+)
+
+const savedSearchColumns = "id, name, filter, created_by, created_at, updated_at"
+
+func (r *PostgresRepository) CreateSavedSearch(ctx context.Context, search *models.SavedSearch) error {
+	filterJSON, err := json.Marshal(search.Filter)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO saved_searches (id, name, filter, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	_, err = r.db.ExecContext(ctx, query,
+		search.ID, search.Name, string(filterJSON), nullString(search.CreatedBy), search.CreatedAt, search.UpdatedAt,
+	)
+	return err
+}
+
+func (r *PostgresRepository) GetSavedSearch(ctx context.Context, id string) (*models.SavedSearch, error) {
+	query := "SELECT " + savedSearchColumns + " FROM saved_searches WHERE id = $1"
+
+	search, err := scanSavedSearch(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return search, nil
+}
+
+func (r *PostgresRepository) ListSavedSearches(ctx context.Context, limit, offset int) ([]*models.SavedSearch, int, error) {
+	query := "SELECT " + savedSearchColumns + " FROM saved_searches ORDER BY LOWER(name), created_at LIMIT $1 OFFSET $2"
+
+	rows, err := r.db.QueryContext(ctx, query, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var searches []*models.SavedSearch
+	for rows.Next() {
+		search, err := scanSavedSearch(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		searches = append(searches, search)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM saved_searches").Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	return searches, total, nil
+}
+
+func (r *PostgresRepository) UpdateSavedSearch(ctx context.Context, search *models.SavedSearch) (bool, error) {
+	filterJSON, err := json.Marshal(search.Filter)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := r.db.ExecContext(ctx,
+		"UPDATE saved_searches SET name = $1, filter = $2, updated_at = $3 WHERE id = $4",
+		search.Name, string(filterJSON), search.UpdatedAt, search.ID,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows > 0, nil
+}
+
+func (r *PostgresRepository) DeleteSavedSearch(ctx context.Context, id string) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM saved_searches WHERE id = $1", id)
+	return err
+}
+
+func scanSavedSearch(row rowScanner) (*models.SavedSearch, error) {
+	var search models.SavedSearch
+	var filterJSON []byte
+	var createdBy sql.NullString
+	if err := row.Scan(&search.ID, &search.Name, &filterJSON, &createdBy, &search.CreatedAt, &search.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(filterJSON, &search.Filter); err != nil {
+		return nil, err
+	}
+	search.CreatedBy = createdBy.String
+
+	return &search, nil
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"kb-platform-gateway/internal/models"
+
+	"github.com/lib/pq"
+)
+
+const serviceTokenColumns = "id, name, token_hash, prefix, scopes, created_by, created_at, expires_at, rotated_at, last_used_at"
+
+func (r *PostgresRepository) CreateServiceToken(ctx context.Context, token *models.ServiceToken) (bool, error) {
+	query := `
+		INSERT INTO service_tokens (id, name, token_hash, prefix, scopes, created_by, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (name) DO NOTHING
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		token.ID, token.Name, token.TokenHash, token.Prefix, pq.Array(token.Scopes),
+		nullString(token.CreatedBy), token.CreatedAt, nullTime(token.ExpiresAt),
+	)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows > 0, nil
+}
+
+func (r *PostgresRepository) GetServiceToken(ctx context.Context, id string) (*models.ServiceToken, error) {
+	query := "SELECT " + serviceTokenColumns + " FROM service_tokens WHERE id = $1"
+	return r.getServiceToken(ctx, query, id)
+}
+
+func (r *PostgresRepository) GetServiceTokenByHash(ctx context.Context, hash string) (*models.ServiceToken, error) {
+	query := "SELECT " + serviceTokenColumns + " FROM service_tokens WHERE token_hash = $1"
+	return r.getServiceToken(ctx, query, hash)
+}
+
+func (r *PostgresRepository) getServiceToken(ctx context.Context, query string, arg string) (*models.ServiceToken, error) {
+	token, err := scanServiceToken(r.db.QueryRowContext(ctx, query, arg))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+func (r *PostgresRepository) ListServiceTokens(ctx context.Context) ([]*models.ServiceToken, error) {
+	query := "SELECT " + serviceTokenColumns + " FROM service_tokens ORDER BY created_at DESC"
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []*models.ServiceToken
+	for rows.Next() {
+		token, err := scanServiceToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+
+	return tokens, rows.Err()
+}
+
+func (r *PostgresRepository) RotateServiceToken(ctx context.Context, token *models.ServiceToken) error {
+	query := `
+		UPDATE service_tokens
+		SET token_hash = $1, prefix = $2, expires_at = $3, rotated_at = $4
+		WHERE id = $5
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		token.TokenHash, token.Prefix, nullTime(token.ExpiresAt), nullTime(token.RotatedAt), token.ID,
+	)
+	return err
+}
+
+func (r *PostgresRepository) TouchServiceToken(ctx context.Context, id string, usedAt time.Time) error {
+	query := "UPDATE service_tokens SET last_used_at = $1 WHERE id = $2"
+	_, err := r.db.ExecContext(ctx, query, usedAt, id)
+	return err
+}
+
+func (r *PostgresRepository) DeleteServiceToken(ctx context.Context, id string) error {
+	query := "DELETE FROM service_tokens WHERE id = $1"
+	_, err := r.db.ExecContext(ctx, query, id)
+	return err
+}
+
+func scanServiceToken(row rowScanner) (*models.ServiceToken, error) {
+	var token models.ServiceToken
+	var createdBy sql.NullString
+	var expiresAt, rotatedAt, lastUsedAt sql.NullTime
+	if err := row.Scan(
+		&token.ID, &token.Name, &token.TokenHash, &token.Prefix, pq.Array(&token.Scopes),
+		&createdBy, &token.CreatedAt, &expiresAt, &rotatedAt, &lastUsedAt,
+	); err != nil {
+		return nil, err
+	}
+	token.CreatedBy = createdBy.String
+	if expiresAt.Valid {
+		token.ExpiresAt = &expiresAt.Time
+	}
+	if rotatedAt.Valid {
+		token.RotatedAt = &rotatedAt.Time
+	}
+	if lastUsedAt.Valid {
+		token.LastUsedAt = &lastUsedAt.Time
+	}
+
+	return &token, nil
+}
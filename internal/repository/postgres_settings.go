@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"kb-platform-gateway/internal/models"
+
+	"github.com/lib/pq"
+)
+
+func (r *PostgresRepository) GetWorkspaceSettings(ctx context.Context) (*models.WorkspaceSettings, error) {
+	query := `
+		SELECT name, logo_url, default_language, retention_days, allowed_file_types, metadata_schema, updated_by, updated_at
+		FROM workspace_settings
+	`
+
+	var settings models.WorkspaceSettings
+	var schemaJSON []byte
+	var updatedBy sql.NullString
+	var updatedAt sql.NullTime
+	err := r.db.QueryRowContext(ctx, query).Scan(
+		&settings.Name, &settings.LogoURL, &settings.DefaultLanguage, &settings.RetentionDays,
+		pq.Array(&settings.AllowedFileTypes), &schemaJSON, &updatedBy, &updatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if settings.AllowedFileTypes == nil {
+		settings.AllowedFileTypes = []string{}
+	}
+	if err := json.Unmarshal(schemaJSON, &settings.MetadataSchema); err != nil {
+		return nil, err
+	}
+	if settings.MetadataSchema == nil {
+		settings.MetadataSchema = []models.MetadataField{}
+	}
+	settings.UpdatedBy = updatedBy.String
+	if updatedAt.Valid {
+		settings.UpdatedAt = &updatedAt.Time
+	}
+	return &settings, nil
+}
+
+func (r *PostgresRepository) UpsertWorkspaceSettings(ctx context.Context, settings *models.WorkspaceSettings) error {
+	schema := settings.MetadataSchema
+	if schema == nil {
+		schema = []models.MetadataField{}
+	}
+	schemaJSON, err := json.Marshal(schema)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO workspace_settings (name, logo_url, default_language, retention_days, allowed_file_types, metadata_schema, updated_by, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (singleton) DO UPDATE
+		SET name = EXCLUDED.name,
+			logo_url = EXCLUDED.logo_url,
+			default_language = EXCLUDED.default_language,
+			retention_days = EXCLUDED.retention_days,
+			allowed_file_types = EXCLUDED.allowed_file_types,
+			metadata_schema = EXCLUDED.metadata_schema,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	_, err = r.db.ExecContext(ctx, query,
+		settings.Name, settings.LogoURL, settings.DefaultLanguage, settings.RetentionDays,
+		pq.Array(settings.AllowedFileTypes), string(schemaJSON), nullString(settings.UpdatedBy), settings.UpdatedAt,
+	)
+	return err
+}
@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"kb-platform-gateway/internal/models"
+
+	"github.com/lib/pq"
+)
+
+const snapshotColumns = `
+	id, name, description, status, source_collection, collection, qdrant_snapshot,
+	config_version, document_count, vector_count, error, created_by, created_at, completed_at
+`
+
+func (r *PostgresRepository) CreateSnapshot(ctx context.Context, snapshot *models.Snapshot) (bool, error) {
+	// The snapshot and its documents are written by one statement, so they
+	// agree on which documents were indexed.
+	query := `
+		WITH s AS (
+			INSERT INTO snapshots (
+				id, name, description, status, source_collection, collection,
+				config_version, document_count, created_by, created_at
+			)
+			SELECT $1, $2, $3, $4, $5, $6, $7, COUNT(*), $8, $9
+			FROM documents
+			WHERE status = 'complete' AND deleted_at IS NULL
+			ON CONFLICT (name) DO NOTHING
+			RETURNING id, document_count
+		), d AS (
+			INSERT INTO snapshot_documents (
+				snapshot_id, document_id, filename, file_size, version, metadata, tags, indexed_at
+			)
+			SELECT s.id, doc.id, doc.filename, doc.file_size, doc.version, doc.metadata, doc.tags, doc.indexed_at
+			FROM s, documents doc
+			WHERE doc.status = 'complete' AND doc.deleted_at IS NULL
+		)
+		SELECT document_count FROM s
+	`
+
+	err := r.db.QueryRowContext(ctx, query,
+		snapshot.ID, snapshot.Name, snapshot.Description, snapshot.Status, snapshot.SourceCollection,
+		snapshot.Collection, snapshot.ConfigVersion, nullString(snapshot.CreatedBy), snapshot.CreatedAt,
+	).Scan(&snapshot.DocumentCount)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *PostgresRepository) GetSnapshot(ctx context.Context, id string) (*models.Snapshot, error) {
+	query := "SELECT" + snapshotColumns + "FROM snapshots WHERE id = $1"
+	return r.getSnapshot(ctx, query, id)
+}
+
+func (r *PostgresRepository) GetSnapshotByName(ctx context.Context, name string) (*models.Snapshot, error) {
+	query := "SELECT" + snapshotColumns + "FROM snapshots WHERE name = $1"
+	return r.getSnapshot(ctx, query, name)
+}
+
+func (r *PostgresRepository) getSnapshot(ctx context.Context, query string, arg interface{}) (*models.Snapshot, error) {
+	snapshot, err := scanSnapshot(r.db.QueryRowContext(ctx, query, arg))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+func (r *PostgresRepository) ListSnapshots(ctx context.Context, limit, offset int) ([]*models.Snapshot, int, error) {
+	query := "SELECT" + snapshotColumns + "FROM snapshots ORDER BY created_at DESC LIMIT $1 OFFSET $2"
+
+	rows, err := r.db.QueryContext(ctx, query, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var snapshots []*models.Snapshot
+	for rows.Next() {
+		snapshot, err := scanSnapshot(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM snapshots").Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	return snapshots, total, nil
+}
+
+func (r *PostgresRepository) ListSnapshotDocuments(ctx context.Context, id string, limit, offset int) ([]*models.SnapshotDocument, int, error) {
+	query := `
+		SELECT document_id, filename, file_size, version, metadata, tags, indexed_at
+		FROM snapshot_documents
+		WHERE snapshot_id = $1
+		ORDER BY filename, document_id
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, id, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var documents []*models.SnapshotDocument
+	for rows.Next() {
+		var doc models.SnapshotDocument
+		var metadata []byte
+		var indexedAt sql.NullTime
+		if err := rows.Scan(
+			&doc.ID, &doc.Filename, &doc.FileSize, &doc.Version, &metadata, pq.Array(&doc.Tags), &indexedAt,
+		); err != nil {
+			return nil, 0, err
+		}
+		if len(metadata) > 0 {
+			if err := json.Unmarshal(metadata, &doc.Metadata); err != nil {
+				return nil, 0, err
+			}
+		}
+		if indexedAt.Valid {
+			doc.IndexedAt = &indexedAt.Time
+		}
+		documents = append(documents, &doc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM snapshot_documents WHERE snapshot_id = $1", id).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	return documents, total, nil
+}
+
+func (r *PostgresRepository) FinishSnapshot(ctx context.Context, snapshot *models.Snapshot) error {
+	query := `
+		UPDATE snapshots
+		SET status = $1, qdrant_snapshot = $2, vector_count = $3, error = $4, completed_at = $5
+		WHERE id = $6
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		snapshot.Status, snapshot.QdrantSnapshot, snapshot.VectorCount, snapshot.Error, snapshot.CompletedAt, snapshot.ID,
+	)
+	return err
+}
+
+func (r *PostgresRepository) DeleteSnapshot(ctx context.Context, id string) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM snapshots WHERE id = $1", id)
+	return err
+}
+
+// scanSnapshot reads a row selected with snapshotColumns.
+func scanSnapshot(scanner rowScanner) (*models.Snapshot, error) {
+	var snapshot models.Snapshot
+	var createdBy sql.NullString
+	var completedAt sql.NullTime
+	if err := scanner.Scan(
+		&snapshot.ID, &snapshot.Name, &snapshot.Description, &snapshot.Status, &snapshot.SourceCollection,
+		&snapshot.Collection, &snapshot.QdrantSnapshot, &snapshot.ConfigVersion, &snapshot.DocumentCount, &snapshot.VectorCount,
+		&snapshot.Error, &createdBy, &snapshot.CreatedAt, &completedAt,
+	); err != nil {
+		return nil, err
+	}
+	snapshot.CreatedBy = createdBy.String
+	if completedAt.Valid {
+		snapshot.CompletedAt = &completedAt.Time
+	}
+	return &snapshot, nil
+}
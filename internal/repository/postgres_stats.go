@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"kb-platform-gateway/internal/models"
+)
+
+func (r *PostgresRepository) GetDocumentStats(ctx context.Context) (*models.DocumentStats, error) {
+	query := `
+		SELECT status, COUNT(*), COALESCE(SUM(file_size), 0)
+		FROM documents
+		WHERE deleted_at IS NULL
+		GROUP BY status
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := &models.DocumentStats{ByStatus: make(map[string]int)}
+	for rows.Next() {
+		var status string
+		var count int
+		var size int64
+		if err := rows.Scan(&status, &count, &size); err != nil {
+			return nil, err
+		}
+		stats.ByStatus[status] = count
+		stats.Total += count
+		stats.StorageBytes += size
+	}
+
+	return stats, rows.Err()
+}
+
+func (r *PostgresRepository) CountQueriesByDay(ctx context.Context, since time.Time) ([]models.DailyCount, error) {
+	query := `
+		SELECT to_char(date_trunc('day', created_at), 'YYYY-MM-DD') AS day, COUNT(*)
+		FROM messages
+		WHERE role = 'user' AND created_at >= $1
+		GROUP BY day
+		ORDER BY day ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, since.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []models.DailyCount
+	for rows.Next() {
+		var count models.DailyCount
+		if err := rows.Scan(&count.Date, &count.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, count)
+	}
+
+	return counts, rows.Err()
+}
+
+func (r *PostgresRepository) CountActiveConversations(ctx context.Context, since time.Time) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM conversations WHERE updated_at >= $1", since.UTC(),
+	).Scan(&count)
+	return count, err
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+
+	"kb-platform-gateway/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// Tags are kept sorted and without duplicates, so documents with the same
+// tags have equal arrays.
+
+func (r *PostgresRepository) AddDocumentTags(ctx context.Context, documentIDs, tags []string) ([]string, error) {
+	query := `
+		UPDATE documents
+		SET tags = ARRAY(SELECT DISTINCT t FROM unnest(tags || $2::text[]) AS t ORDER BY t)
+		WHERE id = ANY($1) AND deleted_at IS NULL AND NOT tags @> $2::text[]
+		RETURNING id
+	`
+	return r.updateTags(ctx, query, pq.Array(documentIDs), pq.Array(tags))
+}
+
+func (r *PostgresRepository) RemoveDocumentTags(ctx context.Context, documentIDs, tags []string) ([]string, error) {
+	query := `
+		UPDATE documents
+		SET tags = ARRAY(SELECT t FROM unnest(tags) AS t WHERE t <> ALL($2::text[]) ORDER BY t)
+		WHERE id = ANY($1) AND deleted_at IS NULL AND tags && $2::text[]
+		RETURNING id
+	`
+	return r.updateTags(ctx, query, pq.Array(documentIDs), pq.Array(tags))
+}
+
+func (r *PostgresRepository) RenameTag(ctx context.Context, from, to string) ([]string, error) {
+	query := `
+		UPDATE documents
+		SET tags = ARRAY(SELECT DISTINCT CASE WHEN t = $1 THEN $2 ELSE t END FROM unnest(tags) AS t ORDER BY 1)
+		WHERE tags @> ARRAY[$1::text]
+		RETURNING id
+	`
+	return r.updateTags(ctx, query, from, to)
+}
+
+// updateTags runs an UPDATE returning the IDs of the changed documents.
+func (r *PostgresRepository) updateTags(ctx context.Context, query string, args ...interface{}) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+func (r *PostgresRepository) ListTags(ctx context.Context) ([]models.TagCount, error) {
+	query := `
+		SELECT t, COUNT(*)
+		FROM documents, unnest(tags) AS t
+		WHERE deleted_at IS NULL
+		GROUP BY t
+		ORDER BY t
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []models.TagCount{}
+	for rows.Next() {
+		var tag models.TagCount
+		if err := rows.Scan(&tag.Tag, &tag.Documents); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+
+	return tags, rows.Err()
+}
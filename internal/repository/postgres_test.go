@@ -70,9 +70,9 @@ func TestDocumentRepository(t *testing.T) {
 			{ID: "doc-2", Filename: "file2.pdf", Status: "complete"},
 		}
 
-		repo.On("ListDocuments", ctx, 50, 0, "").Return(docs, 2, nil)
+		repo.On("ListDocuments", ctx, 50, 0, models.DocumentFilter{}).Return(docs, 2, nil)
 
-		result, total, err := repo.ListDocuments(ctx, 50, 0, "")
+		result, total, err := repo.ListDocuments(ctx, 50, 0, models.DocumentFilter{})
 
 		require.NoError(t, err)
 		assert.Len(t, result, 2)
@@ -85,9 +85,9 @@ func TestDocumentRepository(t *testing.T) {
 			{ID: "doc-1", Filename: "file1.pdf", Status: "pending"},
 		}
 
-		repo.On("ListDocuments", ctx, 50, 0, "pending").Return(docs, 1, nil)
+		repo.On("ListDocuments", ctx, 50, 0, models.DocumentFilter{Status: "pending"}).Return(docs, 1, nil)
 
-		result, total, err := repo.ListDocuments(ctx, 50, 0, "pending")
+		result, total, err := repo.ListDocuments(ctx, 50, 0, models.DocumentFilter{Status: "pending"})
 
 		require.NoError(t, err)
 		assert.Len(t, result, 1)
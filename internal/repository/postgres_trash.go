@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"kb-platform-gateway/internal/models"
+
+	"github.com/lib/pq"
+)
+
+func (r *PostgresRepository) TrashDocument(ctx context.Context, id string, at time.Time) (bool, error) {
+	result, err := r.db.ExecContext(ctx, "UPDATE documents SET deleted_at = $1 WHERE id = $2 AND deleted_at IS NULL", at, id)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows > 0, nil
+}
+
+func (r *PostgresRepository) TrashDocuments(ctx context.Context, ids []string, at time.Time) ([]string, error) {
+	query := "UPDATE documents SET deleted_at = $1 WHERE id = ANY($2) AND deleted_at IS NULL RETURNING id"
+
+	rows, err := r.db.QueryContext(ctx, query, at, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	trashed := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		trashed = append(trashed, id)
+	}
+
+	return trashed, rows.Err()
+}
+
+func (r *PostgresRepository) RestoreDocument(ctx context.Context, id string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, "UPDATE documents SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL", id)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows > 0, nil
+}
+
+func (r *PostgresRepository) ListExpiredTrash(ctx context.Context, before time.Time, limit, offset int) ([]*models.Document, error) {
+	query := "SELECT " + documentColumns + " FROM documents WHERE deleted_at < $1 ORDER BY deleted_at, id LIMIT $2 OFFSET $3"
+
+	rows, err := r.db.QueryContext(ctx, query, before, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var documents []*models.Document
+	for rows.Next() {
+		doc, err := scanDocument(rows)
+		if err != nil {
+			return nil, err
+		}
+		documents = append(documents, doc)
+	}
+
+	return documents, rows.Err()
+}
+
+func (r *PostgresRepository) CreateTrashPurge(ctx context.Context, purge *models.TrashPurge) error {
+	query := `
+		INSERT INTO trash_purges (id, started_at, finished_at, purged, failed, reclaimed_bytes)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		purge.ID, purge.StartedAt, purge.FinishedAt, purge.Purged, purge.Failed, purge.ReclaimedBytes,
+	)
+	return err
+}
+
+func (r *PostgresRepository) GetTrashStats(ctx context.Context) (*models.TrashStats, error) {
+	var stats models.TrashStats
+	if err := r.db.QueryRowContext(ctx, `
+		SELECT COUNT(*), COALESCE(SUM(file_size), 0)
+		FROM documents
+		WHERE deleted_at IS NOT NULL
+	`).Scan(&stats.Documents, &stats.Bytes); err != nil {
+		return nil, err
+	}
+
+	if err := r.db.QueryRowContext(ctx, "SELECT COALESCE(SUM(reclaimed_bytes), 0) FROM trash_purges").Scan(&stats.ReclaimedBytes); err != nil {
+		return nil, err
+	}
+
+	var last models.TrashPurge
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, started_at, finished_at, purged, failed, reclaimed_bytes
+		FROM trash_purges
+		ORDER BY finished_at DESC
+		LIMIT 1
+	`).Scan(&last.ID, &last.StartedAt, &last.FinishedAt, &last.Purged, &last.Failed, &last.ReclaimedBytes)
+	if err == nil {
+		stats.LastPurge = &last
+	} else if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	return &stats, nil
+}
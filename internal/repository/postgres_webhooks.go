@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"kb-platform-gateway/internal/models"
+
+	"github.com/lib/pq"
+)
+
+const webhookColumns = "id, url, secret, events, active, created_by, created_at"
+
+func (r *PostgresRepository) CreateWebhook(ctx context.Context, webhook *models.Webhook) error {
+	query := `
+		INSERT INTO webhooks (id, url, secret, events, active, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		webhook.ID, webhook.URL, webhook.Secret, pq.Array(webhook.Events),
+		webhook.Active, nullString(webhook.CreatedBy), webhook.CreatedAt,
+	)
+	return err
+}
+
+func (r *PostgresRepository) GetWebhook(ctx context.Context, id string) (*models.Webhook, error) {
+	query := "SELECT " + webhookColumns + " FROM webhooks WHERE id = $1"
+
+	webhook, err := scanWebhook(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+func (r *PostgresRepository) ListWebhooks(ctx context.Context) ([]*models.Webhook, error) {
+	query := "SELECT " + webhookColumns + " FROM webhooks ORDER BY created_at DESC"
+	return r.queryWebhooks(ctx, query)
+}
+
+func (r *PostgresRepository) ListWebhooksForEvent(ctx context.Context, eventType string) ([]*models.Webhook, error) {
+	query := "SELECT " + webhookColumns + " FROM webhooks WHERE active AND $1 = ANY(events)"
+	return r.queryWebhooks(ctx, query, eventType)
+}
+
+func (r *PostgresRepository) DeleteWebhook(ctx context.Context, id string) error {
+	query := "DELETE FROM webhooks WHERE id = $1"
+	_, err := r.db.ExecContext(ctx, query, id)
+	return err
+}
+
+func (r *PostgresRepository) queryWebhooks(ctx context.Context, query string, args ...interface{}) ([]*models.Webhook, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var webhooks []*models.Webhook
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
+	return webhooks, rows.Err()
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanWebhook(row rowScanner) (*models.Webhook, error) {
+	var webhook models.Webhook
+	var createdBy sql.NullString
+	if err := row.Scan(
+		&webhook.ID, &webhook.URL, &webhook.Secret, pq.Array(&webhook.Events),
+		&webhook.Active, &createdBy, &webhook.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+	webhook.CreatedBy = createdBy.String
+
+	return &webhook, nil
+}
+
+func (r *PostgresRepository) CreateWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	query := `
+		INSERT INTO webhook_deliveries (id, webhook_id, event_id, event_type, payload, status, attempts, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		delivery.ID, delivery.WebhookID, delivery.EventID, delivery.EventType,
+		delivery.Payload, delivery.Status, delivery.Attempts, delivery.CreatedAt,
+	)
+	return err
+}
+
+func (r *PostgresRepository) UpdateWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = $2, response_code = $3, last_error = $4, delivered_at = $5
+		WHERE id = $6
+	`
+
+	var responseCode *int
+	if delivery.ResponseCode != 0 {
+		responseCode = &delivery.ResponseCode
+	}
+
+	_, err := r.db.ExecContext(ctx, query,
+		delivery.Status, delivery.Attempts, responseCode,
+		nullString(delivery.LastError), nullTime(delivery.DeliveredAt), delivery.ID,
+	)
+	return err
+}
+
+func (r *PostgresRepository) ListWebhookDeliveries(ctx context.Context, webhookID string, limit, offset int) ([]*models.WebhookDelivery, int, error) {
+	query := `
+		SELECT id, webhook_id, event_id, event_type, payload, status, attempts,
+			response_code, last_error, created_at, delivered_at
+		FROM webhook_deliveries
+		WHERE webhook_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, webhookID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var deliveries []*models.WebhookDelivery
+	for rows.Next() {
+		var d models.WebhookDelivery
+		var responseCode sql.NullInt64
+		var lastError sql.NullString
+		if err := rows.Scan(
+			&d.ID, &d.WebhookID, &d.EventID, &d.EventType, &d.Payload, &d.Status, &d.Attempts,
+			&responseCode, &lastError, &d.CreatedAt, &d.DeliveredAt,
+		); err != nil {
+			return nil, 0, err
+		}
+		d.ResponseCode = int(responseCode.Int64)
+		d.LastError = lastError.String
+		deliveries = append(deliveries, &d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM webhook_deliveries WHERE webhook_id = $1", webhookID).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	return deliveries, total, nil
+}
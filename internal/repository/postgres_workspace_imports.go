@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"kb-platform-gateway/internal/models"
+)
+
+const workspaceImportColumns = `
+	id, status, bundle_exported_at, documents_total, documents_imported, documents_skipped,
+	documents_failed, conversations_imported, conversations_skipped, error, created_by, created_at, completed_at
+`
+
+func (r *PostgresRepository) CreateWorkspaceImport(ctx context.Context, imp *models.WorkspaceImport) error {
+	query := `
+		INSERT INTO workspace_imports (id, status, bundle_exported_at, documents_total, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		imp.ID, imp.Status, imp.BundleExportedAt, imp.DocumentsTotal, nullString(imp.CreatedBy), imp.CreatedAt,
+	)
+	return err
+}
+
+func (r *PostgresRepository) GetWorkspaceImport(ctx context.Context, id string) (*models.WorkspaceImport, error) {
+	query := "SELECT" + workspaceImportColumns + ", failures FROM workspace_imports WHERE id = $1"
+
+	var failures []byte
+	imp, err := scanWorkspaceImport(r.db.QueryRowContext(ctx, query, id), &failures)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(failures) > 0 {
+		if err := json.Unmarshal(failures, &imp.Failures); err != nil {
+			return nil, err
+		}
+	}
+
+	return imp, nil
+}
+
+func (r *PostgresRepository) ListWorkspaceImports(ctx context.Context, limit, offset int) ([]*models.WorkspaceImport, int, error) {
+	query := "SELECT" + workspaceImportColumns + "FROM workspace_imports ORDER BY created_at DESC LIMIT $1 OFFSET $2"
+
+	rows, err := r.db.QueryContext(ctx, query, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var imports []*models.WorkspaceImport
+	for rows.Next() {
+		imp, err := scanWorkspaceImport(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		imports = append(imports, imp)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM workspace_imports").Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	return imports, total, nil
+}
+
+func (r *PostgresRepository) UpdateWorkspaceImport(ctx context.Context, imp *models.WorkspaceImport) error {
+	var failures []byte
+	if imp.Failures != nil {
+		var err error
+		if failures, err = json.Marshal(imp.Failures); err != nil {
+			return err
+		}
+	}
+
+	query := `
+		UPDATE workspace_imports
+		SET status = $1, documents_imported = $2, documents_skipped = $3, documents_failed = $4,
+			conversations_imported = $5, conversations_skipped = $6, failures = $7, error = $8, completed_at = $9
+		WHERE id = $10
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		imp.Status, imp.DocumentsImported, imp.DocumentsSkipped, imp.DocumentsFailed,
+		imp.ConversationsImported, imp.ConversationsSkipped, failures, imp.Error, imp.CompletedAt, imp.ID,
+	)
+	return err
+}
+
+// scanWorkspaceImport reads a row selected with workspaceImportColumns,
+// followed by the columns in extra.
+func scanWorkspaceImport(scanner rowScanner, extra ...interface{}) (*models.WorkspaceImport, error) {
+	var imp models.WorkspaceImport
+	var createdBy sql.NullString
+	var completedAt sql.NullTime
+	dest := append([]interface{}{
+		&imp.ID, &imp.Status, &imp.BundleExportedAt, &imp.DocumentsTotal, &imp.DocumentsImported, &imp.DocumentsSkipped,
+		&imp.DocumentsFailed, &imp.ConversationsImported, &imp.ConversationsSkipped, &imp.Error, &createdBy, &imp.CreatedAt, &completedAt,
+	}, extra...)
+	if err := scanner.Scan(dest...); err != nil {
+		return nil, err
+	}
+	imp.CreatedBy = createdBy.String
+	if completedAt.Valid {
+		imp.CompletedAt = &completedAt.Time
+	}
+	return &imp, nil
+}
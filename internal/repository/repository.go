@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"kb-platform-gateway/internal/models"
 )
@@ -9,27 +10,573 @@ import (
 type DocumentRepository interface {
 	CreateDocument(ctx context.Context, doc *models.Document) error
 	GetDocument(ctx context.Context, id string) (*models.Document, error)
-	ListDocuments(ctx context.Context, limit, offset int, statusFilter string) ([]*models.Document, int, error)
+	// GetDocumentsByIDs returns the documents among ids that exist, in no
+	// particular order.
+	GetDocumentsByIDs(ctx context.Context, ids []string) ([]*models.Document, error)
+	// FindDocumentByContentHash returns the oldest document with the given
+	// content hash that is neither trashed, failed, cancelled nor
+	// rejected, or nil.
+	FindDocumentByContentHash(ctx context.Context, contentHash string) (*models.Document, error)
+	// ListDocuments returns the documents matching filter, sorted as it
+	// asks, newest first by default.
+	ListDocuments(ctx context.Context, limit, offset int, filter models.DocumentFilter) ([]*models.Document, int, error)
+	// ExportDocuments calls fn for every document matching filter, oldest
+	// first whatever its sort, stopping at the first error.
+	ExportDocuments(ctx context.Context, filter models.DocumentFilter, fn func(*models.Document) error) error
 	UpdateDocument(ctx context.Context, id string, updates map[string]interface{}) error
 	DeleteDocument(ctx context.Context, id string) error
+	// ListChildDocuments returns the documents expanded from an archive,
+	// newest first, and their total count.
+	ListChildDocuments(ctx context.Context, parentID string, limit, offset int) ([]*models.Document, int, error)
+	// CountChildDocuments counts the documents expanded from an archive by
+	// status.
+	CountChildDocuments(ctx context.Context, parentID string) (*models.ChildProgress, error)
 	UpdateDocumentStatus(ctx context.Context, id, status string, errorMessage string) error
+	// SetDocumentIndexing marks a document indexing by the workflow with
+	// the given ID.
+	SetDocumentIndexing(ctx context.Context, id, workflowID string) error
+	// SetDocumentScan records the malware scan of a document's file, and
+	// the key the file is now stored at, empty if it was deleted.
+	SetDocumentScan(ctx context.Context, id, s3Key string, scan *models.DocumentScan) error
+	// SetDocumentLanguage records the language the indexer detected.
+	SetDocumentLanguage(ctx context.Context, id, language string) error
+	// SetDocumentTitle records the title the indexer extracted.
+	SetDocumentTitle(ctx context.Context, id, title string) error
+	// UpdateDocumentDetails replaces a document's metadata, unless nil, and its
+	// filename, unless empty, and bumps its version, if its version is
+	// still version. It reports false if the document does not exist or
+	// has been updated since.
+	UpdateDocumentDetails(ctx context.Context, id string, metadata map[string]string, filename string, version int) (bool, error)
+	// SetDocumentUploadURL records when the document's latest presigned
+	// upload URL was issued and when it expires.
+	SetDocumentUploadURL(ctx context.Context, id string, issuedAt, expiresAt time.Time) error
+	// SetDocumentChunking replaces a document's chunking options; nil
+	// restores the indexer's defaults.
+	SetDocumentChunking(ctx context.Context, id string, chunking *models.ChunkingOptions) error
+	// SetDocumentProcessing replaces a document's processing options; nil
+	// restores the indexer's defaults.
+	SetDocumentProcessing(ctx context.Context, id string, processing *models.ProcessingOptions) error
+}
+
+// TrashRepository keeps deleted documents restorable until they are
+// purged.
+type TrashRepository interface {
+	// TrashDocument moves a document to the trash at the given time. It
+	// reports false if the document does not exist or is already trashed.
+	TrashDocument(ctx context.Context, id string, at time.Time) (bool, error)
+	// TrashDocuments moves documents to the trash at the given time in a
+	// single statement, so either all of them are trashed or none is. It
+	// returns the IDs of those that existed and were not already trashed.
+	TrashDocuments(ctx context.Context, ids []string, at time.Time) ([]string, error)
+	// RestoreDocument takes a document out of the trash. It reports false
+	// if the document is not in the trash.
+	RestoreDocument(ctx context.Context, id string) (bool, error)
+	// ListExpiredTrash returns documents trashed before the given time,
+	// oldest first.
+	ListExpiredTrash(ctx context.Context, before time.Time, limit, offset int) ([]*models.Document, error)
+	CreateTrashPurge(ctx context.Context, purge *models.TrashPurge) error
+	// GetTrashStats sums the trash and the bytes reclaimed by every purge.
+	GetTrashStats(ctx context.Context) (*models.TrashStats, error)
+}
+
+// MultipartUploadRepository tracks the S3 multipart uploads of large
+// files and the parts uploaded so far.
+type MultipartUploadRepository interface {
+	CreateMultipartUpload(ctx context.Context, upload *models.MultipartUpload) error
+	// GetMultipartUpload returns a document's multipart upload with its
+	// uploaded parts in order, or nil if it has none.
+	GetMultipartUpload(ctx context.Context, documentID string) (*models.MultipartUpload, error)
+	// RecordMultipartUploadPart saves a part's ETag, replacing that of a
+	// part uploaded again.
+	RecordMultipartUploadPart(ctx context.Context, documentID string, part models.MultipartUploadPart) error
+	// CompleteMultipartUpload marks the upload assembled at the given time.
+	CompleteMultipartUpload(ctx context.Context, documentID string, at time.Time) error
+	DeleteMultipartUpload(ctx context.Context, documentID string) error
+}
+
+// TagRepository applies tags to documents in bulk. Each update returns the
+// IDs of the documents it changed.
+type TagRepository interface {
+	// AddDocumentTags adds tags to the listed documents outside the trash.
+	AddDocumentTags(ctx context.Context, documentIDs, tags []string) ([]string, error)
+	// RemoveDocumentTags removes tags from the listed documents outside
+	// the trash.
+	RemoveDocumentTags(ctx context.Context, documentIDs, tags []string) ([]string, error)
+	// RenameTag replaces the tag from with to on every document, trashed
+	// or not, merging the two where a document carries both.
+	RenameTag(ctx context.Context, from, to string) ([]string, error)
+	// ListTags returns the tags of documents outside the trash with their
+	// document counts, by tag.
+	ListTags(ctx context.Context) ([]models.TagCount, error)
+}
+
+// AccessGroupRepository restricts documents to access groups, whose
+// members are the only users queries retrieve their chunks for.
+type AccessGroupRepository interface {
+	// SetDocumentAccessGroups replaces the access groups of a document
+	// outside the trash, reporting whether it exists.
+	SetDocumentAccessGroups(ctx context.Context, id string, groups []string) (bool, error)
 }
 
 type ConversationRepository interface {
 	CreateConversation(ctx context.Context, conv *models.Conversation) error
 	GetConversation(ctx context.Context, id string) (*models.Conversation, error)
+	// ListConversations returns a page of the conversations userID
+	// created or takes part in, newest first, or of every conversation if
+	// userID is empty.
 	ListConversations(ctx context.Context, userID string, limit, offset int) ([]*models.Conversation, int, error)
 	UpdateMessageCount(ctx context.Context, id string, count int) error
 }
 
 type MessageRepository interface {
 	CreateMessage(ctx context.Context, msg *models.Message) error
+	// GetMessage returns a message by ID, or nil if there is none.
+	GetMessage(ctx context.Context, id string) (*models.Message, error)
 	GetMessagesByConversationID(ctx context.Context, conversationID string, limit, offset int) ([]*models.Message, error)
+	// ExportMessages calls fn for every message of a conversation, oldest
+	// first, stopping at the first error.
+	ExportMessages(ctx context.Context, conversationID string, fn func(*models.Message) error) error
 	DeleteMessage(ctx context.Context, id string) error
 }
 
+type WebhookRepository interface {
+	CreateWebhook(ctx context.Context, webhook *models.Webhook) error
+	GetWebhook(ctx context.Context, id string) (*models.Webhook, error)
+	ListWebhooks(ctx context.Context) ([]*models.Webhook, error)
+	ListWebhooksForEvent(ctx context.Context, eventType string) ([]*models.Webhook, error)
+	DeleteWebhook(ctx context.Context, id string) error
+	CreateWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	UpdateWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	ListWebhookDeliveries(ctx context.Context, webhookID string, limit, offset int) ([]*models.WebhookDelivery, int, error)
+}
+
+type EventRepository interface {
+	// CreateEvent stores an event. It reports false, without error, when an
+	// event with the same ID was already stored.
+	CreateEvent(ctx context.Context, event *models.Event) (bool, error)
+	// CountEvents counts the stored events of eventType about subjectID.
+	CountEvents(ctx context.Context, subjectID, eventType string) (int, error)
+}
+
+type StatsRepository interface {
+	// GetDocumentStats counts documents by status and sums their size.
+	GetDocumentStats(ctx context.Context) (*models.DocumentStats, error)
+	// CountQueriesByDay counts user messages per UTC day since the given
+	// time. Days without queries are omitted.
+	CountQueriesByDay(ctx context.Context, since time.Time) ([]models.DailyCount, error)
+	// CountActiveConversations counts conversations updated since the
+	// given time.
+	CountActiveConversations(ctx context.Context, since time.Time) (int, error)
+}
+
+type QueryLogRepository interface {
+	CreateQueryLog(ctx context.Context, log *models.QueryLog) error
+	// SetQueryFeedback records the caller's rating of one of their own
+	// queries. It reports false, without error, when username has no query
+	// with that ID.
+	SetQueryFeedback(ctx context.Context, id, username string, rating int, comment string) (bool, error)
+	// ExportQueryLogs calls fn for every query logged in [from, to), oldest
+	// first, stopping at the first error.
+	ExportQueryLogs(ctx context.Context, from, to time.Time, fn func(*models.QueryLog) error) error
+	// ListQueryCitations returns the chunks cited by each of the queries,
+	// in the order they were cited. Queries without citations are absent.
+	ListQueryCitations(ctx context.Context, queryIDs []string) (map[string][]models.Citation, error)
+	// SuggestQueries returns up to limit completions of text: completed
+	// questions asked by at least minUsers distinct users, and titles of
+	// indexed documents, that contain it. Completions starting with text
+	// come first, then the most asked questions.
+	SuggestQueries(ctx context.Context, text string, minUsers, limit int) ([]models.QuerySuggestion, error)
+}
+
+type NotificationRepository interface {
+	GetNotificationPreferences(ctx context.Context, username string) (*models.NotificationPreferences, error)
+	UpsertNotificationPreferences(ctx context.Context, prefs *models.NotificationPreferences) error
+}
+
+type WorkspaceSettingsRepository interface {
+	// GetWorkspaceSettings returns nil if the settings were never saved.
+	GetWorkspaceSettings(ctx context.Context) (*models.WorkspaceSettings, error)
+	UpsertWorkspaceSettings(ctx context.Context, settings *models.WorkspaceSettings) error
+}
+
+type PromptTemplateRepository interface {
+	// CreatePromptTemplate stores tmpl as version 1. It returns false if
+	// the name is already taken.
+	CreatePromptTemplate(ctx context.Context, tmpl *models.PromptTemplate) (bool, error)
+	// GetPromptTemplate returns the given version of a template, or its
+	// latest version if version is 0.
+	GetPromptTemplate(ctx context.Context, id string, version int) (*models.PromptTemplate, error)
+	ListPromptTemplates(ctx context.Context, limit, offset int) ([]*models.PromptTemplate, int, error)
+	// AddPromptTemplateVersion stores version as the next version of the
+	// template and sets its number; a non-nil description replaces the
+	// current one. It returns false if the template does not exist.
+	AddPromptTemplateVersion(ctx context.Context, id string, version *models.PromptTemplateVersion, description *string) (bool, error)
+	ListPromptTemplateVersions(ctx context.Context, id string) ([]*models.PromptTemplateVersion, error)
+	DeletePromptTemplate(ctx context.Context, id string) error
+}
+
+type EmbeddingMigrationRepository interface {
+	// CreateEmbeddingMigration stores a running migration covering every
+	// indexed document and sets its TotalDocuments. It returns false if
+	// another migration is already running.
+	CreateEmbeddingMigration(ctx context.Context, migration *models.EmbeddingMigration) (bool, error)
+	GetEmbeddingMigration(ctx context.Context, id string) (*models.EmbeddingMigration, error)
+	ListEmbeddingMigrations(ctx context.Context, limit, offset int) ([]*models.EmbeddingMigration, int, error)
+	GetRunningEmbeddingMigration(ctx context.Context) (*models.EmbeddingMigration, error)
+	// GetActiveEmbeddingMigration returns the most recently completed
+	// migration, whose target collection serves queries.
+	GetActiveEmbeddingMigration(ctx context.Context) (*models.EmbeddingMigration, error)
+	// AddEmbeddingMigrationDocuments adds the documents indexed since the
+	// migration started and returns how many were added.
+	AddEmbeddingMigrationDocuments(ctx context.Context, id string) (int, error)
+	// ClaimEmbeddingMigrationBatch marks up to limit pending documents as
+	// queued and returns their IDs.
+	ClaimEmbeddingMigrationBatch(ctx context.Context, id string, limit int) ([]string, error)
+	CountQueuedEmbeddingMigrationDocuments(ctx context.Context, id string) (int, error)
+	// RecordEmbeddingMigrationDocument sets a queued document to indexed or
+	// failed and updates the migration's counters. It returns false if the
+	// document was not queued, e.g. for a redelivered result.
+	RecordEmbeddingMigrationDocument(ctx context.Context, id, documentID, status string) (bool, error)
+	// FinishEmbeddingMigration moves a running migration to status. It
+	// returns false if the migration was no longer running.
+	FinishEmbeddingMigration(ctx context.Context, id, status, errorMessage string) (bool, error)
+}
+
+type EvaluationRepository interface {
+	// CreateEvaluation stores a running evaluation with a pending result
+	// for each case, in order.
+	CreateEvaluation(ctx context.Context, evaluation *models.Evaluation, cases []models.EvaluationCase) error
+	GetEvaluation(ctx context.Context, id string) (*models.Evaluation, error)
+	ListEvaluations(ctx context.Context, limit, offset int) ([]*models.Evaluation, int, error)
+	ListEvaluationResults(ctx context.Context, id string) ([]*models.EvaluationResult, error)
+	// RecordEvaluationResult stores the outcome of the case at
+	// result.Position and counts it towards the evaluation's progress.
+	RecordEvaluationResult(ctx context.Context, id string, result *models.EvaluationResult) error
+	// FinishEvaluation sets the final status and average score.
+	FinishEvaluation(ctx context.Context, id, status, errorMessage string) error
+}
+
+// DuplicateReportRepository stores near-duplicate document scans.
+type DuplicateReportRepository interface {
+	CreateDuplicateReport(ctx context.Context, report *models.DuplicateReport) error
+	// GetDuplicateReport returns a report with its clusters.
+	GetDuplicateReport(ctx context.Context, id string) (*models.DuplicateReport, error)
+	// ListDuplicateReports returns reports without their clusters, newest
+	// first.
+	ListDuplicateReports(ctx context.Context, limit, offset int) ([]*models.DuplicateReport, int, error)
+	// SetDuplicateReportProgress records how many documents a running scan
+	// has scanned.
+	SetDuplicateReportProgress(ctx context.Context, id string, scanned int) error
+	// FinishDuplicateReport sets the final status, count and clusters.
+	FinishDuplicateReport(ctx context.Context, report *models.DuplicateReport) error
+}
+
+// SnapshotRepository stores snapshots of the knowledge base and the
+// documents each one holds.
+type SnapshotRepository interface {
+	// CreateSnapshot stores a snapshot with the documents indexed now,
+	// setting its DocumentCount. It returns false if a snapshot with the
+	// same name exists.
+	CreateSnapshot(ctx context.Context, snapshot *models.Snapshot) (bool, error)
+	GetSnapshot(ctx context.Context, id string) (*models.Snapshot, error)
+	GetSnapshotByName(ctx context.Context, name string) (*models.Snapshot, error)
+	// ListSnapshots returns snapshots newest first.
+	ListSnapshots(ctx context.Context, limit, offset int) ([]*models.Snapshot, int, error)
+	// ListSnapshotDocuments returns a snapshot's documents by filename.
+	ListSnapshotDocuments(ctx context.Context, id string, limit, offset int) ([]*models.SnapshotDocument, int, error)
+	// FinishSnapshot sets the final status, Qdrant snapshot and vector
+	// count.
+	FinishSnapshot(ctx context.Context, snapshot *models.Snapshot) error
+	// DeleteSnapshot deletes a snapshot and its documents.
+	DeleteSnapshot(ctx context.Context, id string) error
+}
+
+// KnowledgeBaseExportRepository stores knowledge base exports.
+type KnowledgeBaseExportRepository interface {
+	CreateKnowledgeBaseExport(ctx context.Context, export *models.KnowledgeBaseExport) error
+	GetKnowledgeBaseExport(ctx context.Context, id string) (*models.KnowledgeBaseExport, error)
+	// ListKnowledgeBaseExports returns the exports createdBy started,
+	// newest first.
+	ListKnowledgeBaseExports(ctx context.Context, createdBy string, limit, offset int) ([]*models.KnowledgeBaseExport, int, error)
+	// UpdateKnowledgeBaseExport sets the status, progress, size and error
+	// of an export that is still running.
+	UpdateKnowledgeBaseExport(ctx context.Context, export *models.KnowledgeBaseExport) error
+}
+
+type FreshnessRepository interface {
+	// ListStaleDocuments returns indexed documents last indexed before the
+	// given time, oldest first, and their total count.
+	ListStaleDocuments(ctx context.Context, before time.Time, limit int) ([]*models.StaleDocument, int, error)
+	// ListBrokenSourceDocuments returns indexed documents whose source URL
+	// last answered 404 or 410, and their total count.
+	ListBrokenSourceDocuments(ctx context.Context, limit int) ([]*models.StaleDocument, int, error)
+	// ListUnretrievedDocuments returns documents indexed before since that
+	// no query logged since then retrieved, oldest first, and their total
+	// count.
+	ListUnretrievedDocuments(ctx context.Context, since time.Time, limit int) ([]*models.StaleDocument, int, error)
+	// ClaimSourceChecks marks up to limit indexed documents with a source
+	// URL not checked since checkedBefore as checked now and returns them.
+	// Concurrent callers never claim the same document.
+	ClaimSourceChecks(ctx context.Context, checkedBefore time.Time, limit int) ([]models.SourceCheck, error)
+	// RecordSourceStatus stores the HTTP status a document's source URL
+	// returned.
+	RecordSourceStatus(ctx context.Context, documentID string, status int) error
+}
+
+type DocumentAnalyticsRepository interface {
+	// GetDocumentAnalytics summarises every citation of a document. The
+	// counts are zero if it was never cited.
+	GetDocumentAnalytics(ctx context.Context, documentID string) (*models.DocumentAnalytics, error)
+	// ListDocumentLeaderboard ranks indexed documents by the chunks cited
+	// since the given time, most cited first, or least cited first for
+	// models.LeaderboardOrderLeast.
+	ListDocumentLeaderboard(ctx context.Context, since time.Time, order string, limit int) ([]*models.DocumentAnalytics, error)
+}
+
+type DocumentEventRepository interface {
+	// CreateDocumentEvent appends an event to a document's timeline. An
+	// event with the same ID is only stored once.
+	CreateDocumentEvent(ctx context.Context, event *models.DocumentEvent) error
+	// ListDocumentEvents returns a document's timeline, oldest first, and
+	// its total length.
+	ListDocumentEvents(ctx context.Context, documentID string, limit, offset int) ([]*models.DocumentEvent, int, error)
+}
+
+type ServiceTokenRepository interface {
+	// CreateServiceToken stores a token. It returns false if a token with
+	// the same name exists.
+	CreateServiceToken(ctx context.Context, token *models.ServiceToken) (bool, error)
+	GetServiceToken(ctx context.Context, id string) (*models.ServiceToken, error)
+	// GetServiceTokenByHash returns the token whose secret hashes to hash,
+	// or nil.
+	GetServiceTokenByHash(ctx context.Context, hash string) (*models.ServiceToken, error)
+	ListServiceTokens(ctx context.Context) ([]*models.ServiceToken, error)
+	// RotateServiceToken replaces the token's secret hash, prefix and
+	// expiry, and sets its rotation time.
+	RotateServiceToken(ctx context.Context, token *models.ServiceToken) error
+	// TouchServiceToken records that the token was used at usedAt.
+	TouchServiceToken(ctx context.Context, id string, usedAt time.Time) error
+	DeleteServiceToken(ctx context.Context, id string) error
+}
+
+// ImpersonationRepository stores impersonation sessions and the requests
+// made in them.
+type ImpersonationRepository interface {
+	CreateImpersonationSession(ctx context.Context, session *models.ImpersonationSession) error
+	GetImpersonationSession(ctx context.Context, id string) (*models.ImpersonationSession, error)
+	// ListImpersonationSessions returns sessions with their request counts,
+	// newest first.
+	ListImpersonationSessions(ctx context.Context, limit, offset int) ([]*models.ImpersonationSession, int, error)
+	// RevokeImpersonationSession ends a session at the given time. It
+	// returns false if the session does not exist or was already revoked.
+	RevokeImpersonationSession(ctx context.Context, id string, at time.Time) (bool, error)
+	RecordImpersonationRequest(ctx context.Context, request *models.ImpersonationRequest) error
+	// ListImpersonationRequests returns a session's requests, oldest first.
+	ListImpersonationRequests(ctx context.Context, sessionID string) ([]*models.ImpersonationRequest, error)
+}
+
+type ConnectorRepository interface {
+	CreateConnector(ctx context.Context, connector *models.Connector) error
+	GetConnector(ctx context.Context, id string) (*models.Connector, error)
+	// ListConnectors returns the user's connectors, newest first.
+	ListConnectors(ctx context.Context, username string) ([]*models.Connector, error)
+	// ListAllConnectors returns every user's connectors, by username and
+	// then oldest first.
+	ListAllConnectors(ctx context.Context) ([]*models.Connector, error)
+	// UpdateConnector saves the connector's name, folders, sync interval
+	// and next sync time.
+	UpdateConnector(ctx context.Context, connector *models.Connector) error
+	UpdateConnectorCredentials(ctx context.Context, id string, credentials []byte) error
+	DeleteConnector(ctx context.Context, id string) error
+	// ClaimDueConnectors marks up to limit connectors whose next sync is
+	// due at now as syncing and schedules their next sync. Connectors with
+	// a resync schedule are synced by it instead and never claimed.
+	ClaimDueConnectors(ctx context.Context, now time.Time, limit int) ([]*models.Connector, error)
+	// FinishConnectorSync records the end of a sync at the given time;
+	// syncErr is empty if it succeeded.
+	FinishConnectorSync(ctx context.Context, id, syncErr string, at time.Time) error
+	GetConnectorFile(ctx context.Context, connectorID, externalID string) (*models.ConnectorFile, error)
+	UpsertConnectorFile(ctx context.Context, file *models.ConnectorFile) error
+}
+
+type ResyncScheduleRepository interface {
+	// UpsertResyncSchedule creates a schedule or changes its cron
+	// expression.
+	UpsertResyncSchedule(ctx context.Context, schedule *models.ResyncSchedule) error
+	GetResyncSchedule(ctx context.Context, sourceType, sourceID string) (*models.ResyncSchedule, error)
+	DeleteResyncSchedule(ctx context.Context, sourceType, sourceID string) error
+	// RecordResyncCheck records a check of the source at the given time
+	// and the content hash it found, which changed unless equal to the
+	// schedule's previous hash.
+	RecordResyncCheck(ctx context.Context, sourceType, sourceID, contentHash string, at time.Time) error
+}
+
+type AnsweredQuestionRepository interface {
+	CreateAnsweredQuestion(ctx context.Context, answered *models.AnsweredQuestion) error
+	// ListAnsweredQuestions returns up to limit questions answered in the
+	// scope since the given time, newest first.
+	ListAnsweredQuestions(ctx context.Context, scope string, since time.Time, limit int) ([]*models.AnsweredQuestion, error)
+	// DeleteAnsweredQuestionsBefore deletes answers older than before.
+	DeleteAnsweredQuestionsBefore(ctx context.Context, before time.Time) error
+}
+
+type ConversationSummaryRepository interface {
+	// CreateConversationSummary stores a summary version; a version that
+	// already exists is left as is.
+	CreateConversationSummary(ctx context.Context, summary *models.ConversationSummary) error
+	// GetLatestConversationSummary returns the highest summary version of a
+	// conversation, or nil if it has none.
+	GetLatestConversationSummary(ctx context.Context, conversationID string) (*models.ConversationSummary, error)
+	// ListConversationSummaries returns every summary version of a
+	// conversation, newest first.
+	ListConversationSummaries(ctx context.Context, conversationID string) ([]*models.ConversationSummary, error)
+}
+
+// ConversationParticipantRepository tracks who takes part in shared
+// conversations and how far each has read.
+type ConversationParticipantRepository interface {
+	// AddConversationParticipant adds a participant. It reports false,
+	// without error, if they already take part.
+	AddConversationParticipant(ctx context.Context, participant *models.ConversationParticipant) (bool, error)
+	// GetConversationParticipant returns a participant with their unread
+	// count, or nil if username does not take part.
+	GetConversationParticipant(ctx context.Context, conversationID, username string) (*models.ConversationParticipant, error)
+	// ListConversationParticipants returns a conversation's participants
+	// with their unread counts, in the order they joined.
+	ListConversationParticipants(ctx context.Context, conversationID string) ([]*models.ConversationParticipant, error)
+	// RemoveConversationParticipant reports false if username did not
+	// take part.
+	RemoveConversationParticipant(ctx context.Context, conversationID, username string) (bool, error)
+	// MarkConversationRead records that a participant has read the
+	// messages created up to readAt. It never moves their read state
+	// back, and reports false if username does not take part.
+	MarkConversationRead(ctx context.Context, conversationID, username string, readAt time.Time) (bool, error)
+}
+
+type CuratedAnswerRepository interface {
+	CreateCuratedAnswer(ctx context.Context, answer *models.CuratedAnswer) error
+	GetCuratedAnswer(ctx context.Context, id string) (*models.CuratedAnswer, error)
+	// ListCuratedAnswers returns curated answers, most recently updated
+	// first.
+	ListCuratedAnswers(ctx context.Context, limit, offset int) ([]*models.CuratedAnswer, int, error)
+	// ListEnabledCuratedAnswers returns every enabled curated answer, most
+	// recently updated first.
+	ListEnabledCuratedAnswers(ctx context.Context) ([]*models.CuratedAnswer, error)
+	// UpdateCuratedAnswer saves every field of answer but its creation. It
+	// returns false if the answer does not exist.
+	UpdateCuratedAnswer(ctx context.Context, answer *models.CuratedAnswer) (bool, error)
+	DeleteCuratedAnswer(ctx context.Context, id string) error
+}
+
+type GlossaryRepository interface {
+	// CreateGlossaryTerm returns false, without creating it, if the term
+	// already exists in any case.
+	CreateGlossaryTerm(ctx context.Context, term *models.GlossaryTerm) (bool, error)
+	GetGlossaryTerm(ctx context.Context, id string) (*models.GlossaryTerm, error)
+	// ListGlossaryTerms returns glossary terms in alphabetical order.
+	ListGlossaryTerms(ctx context.Context, limit, offset int) ([]*models.GlossaryTerm, int, error)
+	ListAllGlossaryTerms(ctx context.Context) ([]*models.GlossaryTerm, error)
+	// UpdateGlossaryTerm saves term's definition. It returns false if the
+	// term does not exist.
+	UpdateGlossaryTerm(ctx context.Context, term *models.GlossaryTerm) (bool, error)
+	DeleteGlossaryTerm(ctx context.Context, id string) error
+}
+
+// RedactionRuleRepository stores the rules redacting text in answers.
+type RedactionRuleRepository interface {
+	CreateRedactionRule(ctx context.Context, rule *models.RedactionRule) error
+	GetRedactionRule(ctx context.Context, id string) (*models.RedactionRule, error)
+	// ListRedactionRules returns redaction rules, oldest first.
+	ListRedactionRules(ctx context.Context, limit, offset int) ([]*models.RedactionRule, int, error)
+	// ListEnabledRedactionRules returns every enabled redaction rule,
+	// oldest first.
+	ListEnabledRedactionRules(ctx context.Context) ([]*models.RedactionRule, error)
+	// UpdateRedactionRule saves every field of rule but its creation. It
+	// returns false if the rule does not exist.
+	UpdateRedactionRule(ctx context.Context, rule *models.RedactionRule) (bool, error)
+	DeleteRedactionRule(ctx context.Context, id string) error
+}
+
+type SavedSearchRepository interface {
+	CreateSavedSearch(ctx context.Context, search *models.SavedSearch) error
+	GetSavedSearch(ctx context.Context, id string) (*models.SavedSearch, error)
+	// ListSavedSearches returns saved searches in alphabetical order.
+	ListSavedSearches(ctx context.Context, limit, offset int) ([]*models.SavedSearch, int, error)
+	// UpdateSavedSearch saves search's name and filter. It returns false if
+	// the search does not exist.
+	UpdateSavedSearch(ctx context.Context, search *models.SavedSearch) (bool, error)
+	DeleteSavedSearch(ctx context.Context, id string) error
+}
+
+// CollectionRepository stores collections, named sets of documents. Their
+// document counts and IDs leave out documents in the trash.
+type CollectionRepository interface {
+	// CreateCollection creates a collection. It returns false if the name
+	// is taken.
+	CreateCollection(ctx context.Context, collection *models.Collection) (bool, error)
+	GetCollection(ctx context.Context, id string) (*models.Collection, error)
+	// ListCollections returns collections in alphabetical order.
+	ListCollections(ctx context.Context, limit, offset int) ([]*models.Collection, int, error)
+	// DeleteCollection deletes a collection; its documents are kept.
+	DeleteCollection(ctx context.Context, id string) error
+	// AddCollectionDocuments adds the listed documents outside the trash
+	// to a collection, returning how many were not in it already.
+	AddCollectionDocuments(ctx context.Context, id string, documentIDs []string) (int, error)
+	// RemoveCollectionDocuments removes the listed documents from a
+	// collection, returning how many were in it.
+	RemoveCollectionDocuments(ctx context.Context, id string, documentIDs []string) (int, error)
+	// ListDocumentCollectionIDs returns the IDs of the collections a
+	// document is in, in order.
+	ListDocumentCollectionIDs(ctx context.Context, documentID string) ([]string, error)
+}
+
+// WorkspaceImportRepository stores imports of workspace bundles.
+type WorkspaceImportRepository interface {
+	CreateWorkspaceImport(ctx context.Context, imp *models.WorkspaceImport) error
+	// GetWorkspaceImport returns an import with its failures.
+	GetWorkspaceImport(ctx context.Context, id string) (*models.WorkspaceImport, error)
+	// ListWorkspaceImports returns imports without their failures, newest
+	// first.
+	ListWorkspaceImports(ctx context.Context, limit, offset int) ([]*models.WorkspaceImport, int, error)
+	// UpdateWorkspaceImport saves the status, counts and failures of an
+	// import.
+	UpdateWorkspaceImport(ctx context.Context, imp *models.WorkspaceImport) error
+}
+
 type Repository interface {
 	DocumentRepository
+	TrashRepository
+	MultipartUploadRepository
+	TagRepository
+	AccessGroupRepository
 	ConversationRepository
 	MessageRepository
+	WebhookRepository
+	EventRepository
+	StatsRepository
+	QueryLogRepository
+	NotificationRepository
+	WorkspaceSettingsRepository
+	PromptTemplateRepository
+	EmbeddingMigrationRepository
+	EvaluationRepository
+	DuplicateReportRepository
+	SnapshotRepository
+	KnowledgeBaseExportRepository
+	FreshnessRepository
+	DocumentAnalyticsRepository
+	DocumentEventRepository
+	ServiceTokenRepository
+	ImpersonationRepository
+	ConnectorRepository
+	ResyncScheduleRepository
+	AnsweredQuestionRepository
+	ConversationSummaryRepository
+	ConversationParticipantRepository
+	CuratedAnswerRepository
+	GlossaryRepository
+	RedactionRuleRepository
+	SavedSearchRepository
+	CollectionRepository
+	WorkspaceImportRepository
 }
@@ -0,0 +1,21 @@
+// Package requestid carries the per-request correlation ID through contexts so
+// it can be logged and forwarded to downstream services.
+package requestid
+
+import "context"
+
+// Header is the HTTP header used to accept and echo request IDs.
+const Header = "X-Request-ID"
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying the given request ID.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the request ID stored in ctx, or "" if none is set.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
@@ -0,0 +1,371 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"kb-platform-gateway/internal/config"
+	"kb-platform-gateway/internal/models"
+	"kb-platform-gateway/internal/repository"
+
+	"github.com/rs/zerolog"
+)
+
+// Alert is an operational message for the ops channel.
+type Alert struct {
+	Title string
+	Text  string
+	// Resolved marks the recovery from an earlier alert.
+	Resolved bool
+}
+
+// NewAlerters returns an alerter for every channel configured in cfg.
+func NewAlerters(cfg *config.AlertConfig) []AlerterInterface {
+	var alerters []AlerterInterface
+	if cfg.SlackWebhookURL != "" {
+		alerters = append(alerters, NewSlackAlerter(cfg.SlackWebhookURL, cfg.Timeout))
+	}
+	if cfg.TeamsWebhookURL != "" {
+		alerters = append(alerters, NewTeamsAlerter(cfg.TeamsWebhookURL, cfg.Timeout))
+	}
+	return alerters
+}
+
+// SlackAlerter posts alerts to a Slack incoming webhook.
+type SlackAlerter struct {
+	url        string
+	httpClient *http.Client
+}
+
+func NewSlackAlerter(url string, timeout time.Duration) *SlackAlerter {
+	return &SlackAlerter{url: url, httpClient: &http.Client{Timeout: timeout}}
+}
+
+func (a *SlackAlerter) Send(ctx context.Context, alert Alert) error {
+	icon := ":red_circle:"
+	if alert.Resolved {
+		icon = ":large_green_circle:"
+	}
+	return postJSON(ctx, a.httpClient, a.url, map[string]string{
+		"text": fmt.Sprintf("%s *%s*\n%s", icon, alert.Title, alert.Text),
+	})
+}
+
+// TeamsAlerter posts alerts to a Microsoft Teams incoming webhook as a
+// message card.
+type TeamsAlerter struct {
+	url        string
+	httpClient *http.Client
+}
+
+func NewTeamsAlerter(url string, timeout time.Duration) *TeamsAlerter {
+	return &TeamsAlerter{url: url, httpClient: &http.Client{Timeout: timeout}}
+}
+
+func (a *TeamsAlerter) Send(ctx context.Context, alert Alert) error {
+	color := "D93F0B"
+	if alert.Resolved {
+		color = "2EB886"
+	}
+	return postJSON(ctx, a.httpClient, a.url, map[string]string{
+		"@type":      "MessageCard",
+		"@context":   "https://schema.org/extensions",
+		"themeColor": color,
+		"summary":    alert.Title,
+		"title":      alert.Title,
+		"text":       alert.Text,
+	})
+}
+
+func postJSON(ctx context.Context, client *http.Client, url string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post alert: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("alert webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// HealthProbe checks one dependency; a nil error means healthy.
+type HealthProbe func(ctx context.Context) error
+
+// OpsHealth is what the ops monitor has observed so far.
+type OpsHealth struct {
+	// CheckedAt is when the dependencies were last probed, or zero before
+	// the first check.
+	CheckedAt    time.Time
+	Dependencies map[string]DependencyHealth
+	// LastErrorSpike is when the 5xx rate last reached the alert
+	// threshold, whether or not an alert was sent.
+	LastErrorSpike time.Time
+}
+
+// DependencyHealth is the state of a dependency at the last check.
+type DependencyHealth struct {
+	Healthy bool
+	// LastOutage is when the dependency was last found unhealthy.
+	LastOutage time.Time
+}
+
+// OpsMonitor raises ops alerts. Every CheckInterval it probes the
+// gateway's dependencies, alerting when one becomes unhealthy and again
+// when it recovers, and evaluates the 5xx rate recorded through
+// RecordRequest over fixed windows of ErrorRateWindow. Documents are
+// reported once they reach DeadLetterThreshold failed indexing attempts.
+type OpsMonitor struct {
+	alerters []AlerterInterface
+	probes   map[string]HealthProbe
+	repo     repository.Repository
+	logger   zerolog.Logger
+
+	timeout              time.Duration
+	checkInterval        time.Duration
+	errorRatePercent     int
+	errorRateWindow      time.Duration
+	errorRateMinRequests int
+	deadLetterThreshold  int
+	cooldown             time.Duration
+
+	mu             sync.Mutex
+	unhealthy      map[string]bool
+	checkedAt      time.Time
+	lastOutage     map[string]time.Time
+	windowStart    time.Time
+	requests       int
+	errors         int
+	lastErrorSpike time.Time
+	lastErrorAlert time.Time
+
+	ctx       context.Context
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+}
+
+func NewOpsMonitor(cfg *config.AlertConfig, alerters []AlerterInterface, probes map[string]HealthProbe, repo repository.Repository, logger zerolog.Logger) *OpsMonitor {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &OpsMonitor{
+		alerters:             alerters,
+		probes:               probes,
+		repo:                 repo,
+		logger:               logger,
+		timeout:              cfg.Timeout,
+		checkInterval:        cfg.CheckInterval,
+		errorRatePercent:     cfg.ErrorRatePercent,
+		errorRateWindow:      cfg.ErrorRateWindow,
+		errorRateMinRequests: max(cfg.ErrorRateMinRequests, 1),
+		deadLetterThreshold:  max(cfg.DeadLetterThreshold, 1),
+		cooldown:             cfg.Cooldown,
+		unhealthy:            make(map[string]bool),
+		lastOutage:           make(map[string]time.Time),
+		windowStart:          time.Now(),
+		ctx:                  ctx,
+		cancel:               cancel,
+	}
+}
+
+// Start runs Check right away and then every CheckInterval until Close is
+// called.
+func (m *OpsMonitor) Start() {
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		m.Check(m.ctx)
+		ticker := time.NewTicker(m.checkInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-m.ctx.Done():
+				return
+			case <-ticker.C:
+				m.Check(m.ctx)
+			}
+		}
+	}()
+}
+
+// Close stops the periodic checks and waits for alerts in flight.
+func (m *OpsMonitor) Close() {
+	m.closeOnce.Do(func() {
+		m.cancel()
+		m.wg.Wait()
+	})
+}
+
+// RecordRequest counts a served request towards the error rate.
+func (m *OpsMonitor) RecordRequest(status int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests++
+	if status >= http.StatusInternalServerError {
+		m.errors++
+	}
+}
+
+// Check probes the dependencies and evaluates the error rate once,
+// sending any resulting alerts before it returns.
+func (m *OpsMonitor) Check(ctx context.Context) {
+	m.checkDependencies(ctx)
+	m.checkErrorRate(ctx, time.Now())
+}
+
+func (m *OpsMonitor) checkDependencies(ctx context.Context) {
+	probeCtx, cancel := context.WithTimeout(ctx, m.timeout)
+	defer cancel()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	results := make(map[string]error, len(m.probes))
+	for name, probe := range m.probes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := probe(probeCtx)
+			mu.Lock()
+			results[name] = err
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	now := time.Now()
+	m.mu.Lock()
+	m.checkedAt = now
+	m.mu.Unlock()
+
+	for _, name := range slices.Sorted(maps.Keys(results)) {
+		err := results[name]
+		m.mu.Lock()
+		wasUnhealthy := m.unhealthy[name]
+		m.unhealthy[name] = err != nil
+		if err != nil {
+			m.lastOutage[name] = now
+		}
+		m.mu.Unlock()
+
+		switch {
+		case err != nil && !wasUnhealthy:
+			m.send(ctx, Alert{
+				Title: fmt.Sprintf("Dependency unhealthy: %s", name),
+				Text:  err.Error(),
+			})
+		case err == nil && wasUnhealthy:
+			m.send(ctx, Alert{
+				Title:    fmt.Sprintf("Dependency recovered: %s", name),
+				Text:     fmt.Sprintf("%s is healthy again.", name),
+				Resolved: true,
+			})
+		}
+	}
+}
+
+func (m *OpsMonitor) checkErrorRate(ctx context.Context, now time.Time) {
+	m.mu.Lock()
+	if now.Sub(m.windowStart) < m.errorRateWindow {
+		m.mu.Unlock()
+		return
+	}
+	requests, errors := m.requests, m.errors
+	m.windowStart, m.requests, m.errors = now, 0, 0
+
+	spike := requests >= m.errorRateMinRequests && errors*100 >= requests*m.errorRatePercent
+	alert := spike && now.Sub(m.lastErrorAlert) >= m.cooldown
+	if spike {
+		m.lastErrorSpike = now
+	}
+	if alert {
+		m.lastErrorAlert = now
+	}
+	m.mu.Unlock()
+
+	if alert {
+		m.send(ctx, Alert{
+			Title: "Error rate spike",
+			Text: fmt.Sprintf("%d of %d requests (%.1f%%) failed with a 5xx status in the last %s.",
+				errors, requests, float64(errors)*100/float64(requests), m.errorRateWindow),
+		})
+	}
+}
+
+// Health returns what the monitor observed at its latest checks.
+func (m *OpsMonitor) Health() OpsHealth {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	health := OpsHealth{
+		CheckedAt:      m.checkedAt,
+		Dependencies:   make(map[string]DependencyHealth, len(m.probes)),
+		LastErrorSpike: m.lastErrorSpike,
+	}
+	for name := range m.probes {
+		health.Dependencies[name] = DependencyHealth{
+			Healthy:    !m.unhealthy[name],
+			LastOutage: m.lastOutage[name],
+		}
+	}
+	return health
+}
+
+// DocumentFailed reports documentID as dead-lettered if it has just
+// reached the failure threshold. It returns immediately; the check runs in
+// the background.
+func (m *OpsMonitor) DocumentFailed(ctx context.Context, documentID string) {
+	if m.ctx.Err() != nil {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		ctx, cancel := context.WithTimeout(ctx, m.timeout)
+		defer cancel()
+
+		failures, err := m.repo.CountEvents(ctx, documentID, models.EventDocumentFailed)
+		if err != nil {
+			m.logger.Error().Err(err).Str("document_id", documentID).Msg("Failed to count document failures")
+			return
+		}
+		if failures != m.deadLetterThreshold {
+			return
+		}
+
+		text := fmt.Sprintf("Document %s has failed indexing %d times.", documentID, failures)
+		if doc, err := m.repo.GetDocument(ctx, documentID); err == nil && doc != nil {
+			text = fmt.Sprintf("Document %s (%s) has failed indexing %d times.", documentID, doc.Filename, failures)
+			if doc.ErrorMessage != "" {
+				text += "\nLast error: " + doc.ErrorMessage
+			}
+		}
+		m.send(ctx, Alert{Title: "Document dead-lettered", Text: text})
+	}()
+}
+
+func (m *OpsMonitor) send(ctx context.Context, alert Alert) {
+	for _, alerter := range m.alerters {
+		if err := alerter.Send(ctx, alert); err != nil {
+			m.logger.Error().Err(err).Str("alert", alert.Title).Msg("Failed to send ops alert")
+		}
+	}
+}
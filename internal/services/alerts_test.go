@@ -0,0 +1,196 @@
+package services_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"kb-platform-gateway/internal/config"
+	"kb-platform-gateway/internal/models"
+	repomocks "kb-platform-gateway/internal/repository/mocks"
+	"kb-platform-gateway/internal/services"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingAlerter collects the alerts it is asked to send.
+type recordingAlerter struct {
+	alerts []services.Alert
+}
+
+func (a *recordingAlerter) Send(ctx context.Context, alert services.Alert) error {
+	a.alerts = append(a.alerts, alert)
+	return nil
+}
+
+func newTestOpsMonitor(t *testing.T, probes map[string]services.HealthProbe, repo *repomocks.MockRepository) (*services.OpsMonitor, *recordingAlerter) {
+	t.Helper()
+	alerter := &recordingAlerter{}
+	m := services.NewOpsMonitor(&config.AlertConfig{
+		Timeout:              time.Second,
+		CheckInterval:        time.Hour,
+		ErrorRatePercent:     10,
+		ErrorRateWindow:      time.Nanosecond,
+		ErrorRateMinRequests: 10,
+		DeadLetterThreshold:  3,
+		Cooldown:             time.Hour,
+	}, []services.AlerterInterface{alerter}, probes, repo, zerolog.Nop())
+	t.Cleanup(m.Close)
+	return m, alerter
+}
+
+func TestAlerters(t *testing.T) {
+	t.Run("Slack_Payload", func(t *testing.T) {
+		var payload map[string]string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		}))
+		defer server.Close()
+
+		err := services.NewSlackAlerter(server.URL, time.Second).Send(t.Context(), services.Alert{Title: "Dependency unhealthy: redis", Text: "connection refused"})
+
+		require.NoError(t, err)
+		assert.Equal(t, ":red_circle: *Dependency unhealthy: redis*\nconnection refused", payload["text"])
+	})
+
+	t.Run("Teams_Payload", func(t *testing.T) {
+		var payload map[string]string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		}))
+		defer server.Close()
+
+		err := services.NewTeamsAlerter(server.URL, time.Second).Send(t.Context(), services.Alert{Title: "Dependency recovered: redis", Resolved: true})
+
+		require.NoError(t, err)
+		assert.Equal(t, "MessageCard", payload["@type"])
+		assert.Equal(t, "Dependency recovered: redis", payload["title"])
+		assert.Equal(t, "2EB886", payload["themeColor"])
+	})
+
+	t.Run("Send_ErrorStatus", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+		}))
+		defer server.Close()
+
+		err := services.NewSlackAlerter(server.URL, time.Second).Send(t.Context(), services.Alert{Title: "x"})
+
+		assert.Error(t, err)
+	})
+}
+
+func TestOpsMonitor(t *testing.T) {
+	t.Run("Check_DependencyTransitions", func(t *testing.T) {
+		var redisErr error
+		m, alerter := newTestOpsMonitor(t, map[string]services.HealthProbe{
+			"redis":    func(ctx context.Context) error { return redisErr },
+			"temporal": func(ctx context.Context) error { return nil },
+		}, repomocks.NewMockRepository())
+
+		m.Check(t.Context())
+		assert.Empty(t, alerter.alerts)
+
+		redisErr = errors.New("connection refused")
+		m.Check(t.Context())
+		m.Check(t.Context())
+		require.Len(t, alerter.alerts, 1, "a dependency that stays down is reported once")
+		assert.Equal(t, "Dependency unhealthy: redis", alerter.alerts[0].Title)
+
+		redisErr = nil
+		m.Check(t.Context())
+		require.Len(t, alerter.alerts, 2)
+		assert.Equal(t, "Dependency recovered: redis", alerter.alerts[1].Title)
+		assert.True(t, alerter.alerts[1].Resolved)
+	})
+
+	t.Run("Check_ErrorRateSpike", func(t *testing.T) {
+		m, alerter := newTestOpsMonitor(t, nil, repomocks.NewMockRepository())
+
+		for i := 0; i < 8; i++ {
+			m.RecordRequest(http.StatusOK)
+		}
+		m.RecordRequest(http.StatusBadGateway)
+		m.RecordRequest(http.StatusInternalServerError)
+		m.Check(t.Context())
+		require.Len(t, alerter.alerts, 1)
+		assert.Equal(t, "Error rate spike", alerter.alerts[0].Title)
+
+		// The cooldown suppresses the next spike.
+		for i := 0; i < 10; i++ {
+			m.RecordRequest(http.StatusInternalServerError)
+		}
+		m.Check(t.Context())
+		assert.Len(t, alerter.alerts, 1)
+	})
+
+	t.Run("Check_TooFewRequests", func(t *testing.T) {
+		m, alerter := newTestOpsMonitor(t, nil, repomocks.NewMockRepository())
+
+		m.RecordRequest(http.StatusInternalServerError)
+		m.Check(t.Context())
+
+		assert.Empty(t, alerter.alerts)
+	})
+
+	t.Run("Health", func(t *testing.T) {
+		redisErr := errors.New("connection refused")
+		m, _ := newTestOpsMonitor(t, map[string]services.HealthProbe{
+			"redis":    func(ctx context.Context) error { return redisErr },
+			"temporal": func(ctx context.Context) error { return nil },
+		}, repomocks.NewMockRepository())
+
+		health := m.Health()
+		assert.True(t, health.CheckedAt.IsZero())
+
+		for i := 0; i < 10; i++ {
+			m.RecordRequest(http.StatusInternalServerError)
+		}
+		m.Check(t.Context())
+		redisErr = nil
+		for i := 0; i < 10; i++ {
+			m.RecordRequest(http.StatusInternalServerError)
+		}
+		m.Check(t.Context())
+
+		health = m.Health()
+		assert.False(t, health.CheckedAt.IsZero())
+		assert.True(t, health.Dependencies["redis"].Healthy)
+		assert.False(t, health.Dependencies["redis"].LastOutage.IsZero(), "the outage is remembered after recovery")
+		assert.True(t, health.Dependencies["temporal"].LastOutage.IsZero())
+		assert.False(t, health.LastErrorSpike.IsZero())
+	})
+
+	t.Run("DocumentFailed_DeadLettered", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("CountEvents", mock.Anything, "doc-1", models.EventDocumentFailed).Return(3, nil)
+		repo.On("GetDocument", mock.Anything, "doc-1").Return(&models.Document{ID: "doc-1", Filename: "bad.pdf", ErrorMessage: "parse error"}, nil)
+		m, alerter := newTestOpsMonitor(t, nil, repo)
+
+		m.DocumentFailed(t.Context(), "doc-1")
+		m.Close()
+
+		require.Len(t, alerter.alerts, 1)
+		assert.Equal(t, "Document dead-lettered", alerter.alerts[0].Title)
+		assert.Contains(t, alerter.alerts[0].Text, "bad.pdf")
+	})
+
+	t.Run("DocumentFailed_BelowThreshold", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("CountEvents", mock.Anything, "doc-1", models.EventDocumentFailed).Return(1, nil)
+		m, alerter := newTestOpsMonitor(t, nil, repo)
+
+		m.DocumentFailed(t.Context(), "doc-1")
+		m.Close()
+
+		assert.Empty(t, alerter.alerts)
+	})
+}
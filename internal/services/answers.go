@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode"
+
+	"kb-platform-gateway/internal/config"
+	"kb-platform-gateway/internal/models"
+	"kb-platform-gateway/internal/repository"
+)
+
+// stopWords are left out when comparing questions, so "What is the
+// refund policy?" matches "refund policy".
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "be": true, "can": true,
+	"do": true, "does": true, "for": true, "how": true, "i": true, "in": true,
+	"is": true, "it": true, "me": true, "my": true, "of": true, "on": true,
+	"or": true, "our": true, "please": true, "s": true, "tell": true, "that": true,
+	"the": true, "this": true, "to": true, "us": true, "we": true, "what": true,
+	"whats": true, "which": true, "with": true, "you": true, "your": true,
+}
+
+// AnswerCache finds recent answers to near-identical questions, so a
+// repeated question can be answered without the core. Two questions are
+// near-identical when their sets of words, ignoring case, punctuation,
+// word order and stop words, overlap by at least the configured
+// similarity (Jaccard index).
+type AnswerCache struct {
+	repo          repository.AnsweredQuestionRepository
+	window        time.Duration
+	similarity    float64
+	maxCandidates int
+}
+
+func NewAnswerCache(cfg *config.DedupConfig, repo repository.AnsweredQuestionRepository) *AnswerCache {
+	return &AnswerCache{
+		repo:          repo,
+		window:        cfg.Window,
+		similarity:    float64(cfg.Similarity) / 100,
+		maxCandidates: max(cfg.MaxCandidates, 1),
+	}
+}
+
+// Find returns the most similar question answered in scope within the
+// window, preferring the newest among equally similar ones, or nil if none
+// is similar enough.
+func (c *AnswerCache) Find(ctx context.Context, scope, question string) (*models.AnsweredQuestion, error) {
+	candidates, err := c.repo.ListAnsweredQuestions(ctx, scope, time.Now().Add(-c.window), c.maxCandidates)
+	if err != nil {
+		return nil, err
+	}
+
+	terms := questionTerms(question)
+	var best *models.AnsweredQuestion
+	bestScore := 0.0
+	for _, candidate := range candidates {
+		score := jaccard(terms, questionTerms(candidate.Question))
+		if score >= c.similarity && score > bestScore {
+			best, bestScore = candidate, score
+		}
+	}
+	return best, nil
+}
+
+// Remember stores an answer for later questions and drops answers that
+// have left the window.
+func (c *AnswerCache) Remember(ctx context.Context, answered *models.AnsweredQuestion) error {
+	if err := c.repo.CreateAnsweredQuestion(ctx, answered); err != nil {
+		return err
+	}
+	return c.repo.DeleteAnsweredQuestionsBefore(ctx, time.Now().Add(-c.window))
+}
+
+// questionTerms returns the distinct lowercase words of a question, without
+// stop words.
+func questionTerms(question string) map[string]bool {
+	terms := make(map[string]bool)
+	for _, word := range strings.FieldsFunc(strings.ToLower(question), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if !stopWords[word] {
+			terms[word] = true
+		}
+	}
+	return terms
+}
+
+// jaccard returns the size of the intersection of a and b over the size of
+// their union, or 0 if both are empty.
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for term := range a {
+		if b[term] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
@@ -0,0 +1,74 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"kb-platform-gateway/internal/config"
+	"kb-platform-gateway/internal/models"
+	repomocks "kb-platform-gateway/internal/repository/mocks"
+	"kb-platform-gateway/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnswerCache(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.DedupConfig{Window: 24 * time.Hour, Similarity: 90, MaxCandidates: 50}
+	answered := []*models.AnsweredQuestion{
+		{QueryID: "q-3", Question: "How do I reset my VPN password?", Answer: "Use the portal."},
+		{QueryID: "q-2", Question: "What is the refund policy?", Answer: "30 days."},
+		{QueryID: "q-1", Question: "refund policy", Answer: "Older answer."},
+	}
+
+	t.Run("Find_NearIdentical", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("ListAnsweredQuestions", ctx, "docs||", mock.AnythingOfType("time.Time"), 50).Return(answered, nil)
+		cache := services.NewAnswerCache(cfg, repo)
+
+		found, err := cache.Find(ctx, "docs||", "what's the REFUND policy")
+
+		require.NoError(t, err)
+		require.NotNil(t, found)
+		assert.Equal(t, "q-2", found.QueryID)
+	})
+
+	t.Run("Find_Different", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("ListAnsweredQuestions", ctx, "docs||", mock.AnythingOfType("time.Time"), 50).Return(answered, nil)
+		cache := services.NewAnswerCache(cfg, repo)
+
+		found, err := cache.Find(ctx, "docs||", "How do I reset my email password?")
+
+		require.NoError(t, err)
+		assert.Nil(t, found)
+	})
+
+	t.Run("Find_OnlyStopWords", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("ListAnsweredQuestions", ctx, "docs||", mock.AnythingOfType("time.Time"), 50).
+			Return([]*models.AnsweredQuestion{{QueryID: "q-1", Question: "what is it?"}}, nil)
+		cache := services.NewAnswerCache(cfg, repo)
+
+		found, err := cache.Find(ctx, "docs||", "What is this?")
+
+		require.NoError(t, err)
+		assert.Nil(t, found)
+	})
+
+	t.Run("Remember_PrunesExpired", func(t *testing.T) {
+		answer := &models.AnsweredQuestion{QueryID: "q-4", Question: "refund policy", Answer: "30 days."}
+		repo := repomocks.NewMockRepository()
+		repo.On("CreateAnsweredQuestion", ctx, answer).Return(nil)
+		repo.On("DeleteAnsweredQuestionsBefore", ctx, mock.MatchedBy(func(before time.Time) bool {
+			return time.Since(before) >= 24*time.Hour
+		})).Return(nil)
+		cache := services.NewAnswerCache(cfg, repo)
+
+		require.NoError(t, cache.Remember(ctx, answer))
+		repo.AssertExpectations(t)
+	})
+}
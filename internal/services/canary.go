@@ -0,0 +1,217 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"kb-platform-gateway/internal/models"
+)
+
+// CoreBackend is a core deployment a CoreRouter splits traffic across.
+type CoreBackend struct {
+	Name string
+	// Weight is the backend's share of traffic relative to the other
+	// backends. Zero drains it.
+	Weight int
+	Client CoreServiceInterface
+}
+
+// CoreRouter splits core traffic across backends by weight, e.g. 95/5
+// between a stable and a canary RAG pipeline. Calls about a conversation
+// go to the backend its ID hashes to, so the conversation stays on one
+// pipeline as long as the weights do not change; queries outside a
+// conversation are assigned at random. Document calls and evaluations go
+// to the first backend, which also decides readiness: the others are
+// reported but a failing canary does not take the gateway down.
+type CoreRouter struct {
+	backends    []*routedBackend
+	totalWeight int
+	since       time.Time
+}
+
+type routedBackend struct {
+	CoreBackend
+
+	mu      sync.Mutex
+	queries int64
+	errors  int64
+	latency latencyWindow
+}
+
+func NewCoreRouter(backends []CoreBackend) (*CoreRouter, error) {
+	if len(backends) == 0 {
+		return nil, errors.New("no core backends configured")
+	}
+	r := &CoreRouter{since: time.Now()}
+	for _, backend := range backends {
+		r.backends = append(r.backends, &routedBackend{CoreBackend: backend})
+		r.totalWeight += backend.Weight
+	}
+	if r.totalWeight <= 0 {
+		return nil, errors.New("core backend weights must not all be zero")
+	}
+	return r, nil
+}
+
+// Close closes the backend clients that hold connections.
+func (r *CoreRouter) Close() error {
+	var errs []error
+	for _, backend := range r.backends {
+		if closer, ok := backend.Client.(io.Closer); ok {
+			errs = append(errs, closer.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// pick returns the backend for a conversation, or a random one by weight
+// if conversationID is empty.
+func (r *CoreRouter) pick(conversationID string) *routedBackend {
+	var n int
+	if conversationID == "" {
+		n = rand.IntN(r.totalWeight)
+	} else {
+		h := fnv.New32a()
+		h.Write([]byte(conversationID))
+		n = int(h.Sum32() % uint32(r.totalWeight))
+	}
+	for _, backend := range r.backends {
+		if n < backend.Weight {
+			return backend
+		}
+		n -= backend.Weight
+	}
+	return r.backends[0]
+}
+
+func (r *CoreRouter) Query(ctx context.Context, req models.CoreQueryRequest) (<-chan models.SSEEvent, error) {
+	backend := r.pick(req.ConversationID)
+	started := time.Now()
+	upstream, err := backend.Client.Query(ctx, req)
+	if err != nil {
+		backend.record(0, false)
+		return nil, err
+	}
+
+	events := make(chan models.SSEEvent)
+	go func() {
+		defer close(events)
+		ended, failed := false, false
+		for event := range upstream {
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				for range upstream {
+				}
+				// Cancelled queries say nothing about the backend.
+				return
+			}
+			switch event.Type {
+			case "end":
+				ended = true
+			case "error":
+				failed = true
+			}
+		}
+		if ctx.Err() == nil {
+			backend.record(time.Since(started), ended && !failed)
+		}
+	}()
+
+	return events, nil
+}
+
+func (r *CoreRouter) GetDocument(ctx context.Context, documentID string) (*models.Document, error) {
+	return r.backends[0].Client.GetDocument(ctx, documentID)
+}
+
+func (r *CoreRouter) DeleteDocumentVectors(ctx context.Context, documentID string) error {
+	return r.backends[0].Client.DeleteDocumentVectors(ctx, documentID)
+}
+
+func (r *CoreRouter) GetConversation(ctx context.Context, conversationID string) (*models.Conversation, error) {
+	return r.pick(conversationID).Client.GetConversation(ctx, conversationID)
+}
+
+func (r *CoreRouter) GetConversationMessages(ctx context.Context, conversationID string) ([]*models.Message, error) {
+	return r.pick(conversationID).Client.GetConversationMessages(ctx, conversationID)
+}
+
+func (r *CoreRouter) SaveMessage(ctx context.Context, conversationID string, role string, content string, metadata map[string]string) (*models.Message, error) {
+	return r.pick(conversationID).Client.SaveMessage(ctx, conversationID, role, content, metadata)
+}
+
+func (r *CoreRouter) Evaluate(ctx context.Context, question, expectedAnswer, answer string) (*models.EvaluationScore, error) {
+	return r.backends[0].Client.Evaluate(ctx, question, expectedAnswer, answer)
+}
+
+func (r *CoreRouter) Summarize(ctx context.Context, summary string, messages []*models.Message) (string, error) {
+	return r.backends[0].Client.Summarize(ctx, summary, messages)
+}
+
+// HealthCheck checks every backend, prefixing their dependencies with the
+// backend name. Only a failure of the first backend is returned.
+func (r *CoreRouter) HealthCheck(ctx context.Context) (map[string]string, error) {
+	result := make(map[string]string)
+	var firstErr error
+	for i, backend := range r.backends {
+		deps, err := backend.Client.HealthCheck(ctx)
+		if err != nil {
+			result[backend.Name] = err.Error()
+			if i == 0 {
+				firstErr = fmt.Errorf("core backend %s: %w", backend.Name, err)
+			}
+			continue
+		}
+		for name, status := range deps {
+			result[backend.Name+"."+name] = status
+		}
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return result, nil
+}
+
+// BackendStats returns the query counts and latencies of each backend.
+func (r *CoreRouter) BackendStats() models.CoreBackendStatsResponse {
+	response := models.CoreBackendStatsResponse{Since: r.since}
+	for _, backend := range r.backends {
+		response.Backends = append(response.Backends, backend.stats(r.totalWeight))
+	}
+	return response
+}
+
+// record counts a query; latency is only kept for completed ones.
+func (b *routedBackend) record(latency time.Duration, completed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.queries++
+	if !completed {
+		b.errors++
+		return
+	}
+	b.latency.add(latency)
+}
+
+func (b *routedBackend) stats(totalWeight int) models.CoreBackendStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return models.CoreBackendStats{
+		Name:       b.Name,
+		Weight:     b.Weight,
+		Percent:    float64(b.Weight) * 100 / float64(totalWeight),
+		Queries:    b.queries,
+		Errors:     b.errors,
+		SampleSize: b.latency.len(),
+		Latency:    b.latency.summary(),
+	}
+}
@@ -0,0 +1,126 @@
+package services_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"kb-platform-gateway/internal/models"
+	"kb-platform-gateway/internal/services"
+	"kb-platform-gateway/internal/services/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// answering makes core answer up to 100 queries with events.
+func answering(core *mocks.MockCoreService, events ...models.SSEEvent) {
+	for range 100 {
+		core.On("Query", mock.Anything, mock.Anything).
+			Return(shadowStream(events...), nil).Once()
+	}
+}
+
+func TestCoreRouter(t *testing.T) {
+	newRouter := func(t *testing.T, stableWeight, canaryWeight int) (*services.CoreRouter, *mocks.MockCoreService, *mocks.MockCoreService) {
+		t.Helper()
+		stable, canary := mocks.NewMockCoreService(), mocks.NewMockCoreService()
+		router, err := services.NewCoreRouter([]services.CoreBackend{
+			{Name: "stable", Weight: stableWeight, Client: stable},
+			{Name: "canary", Weight: canaryWeight, Client: canary},
+		})
+		require.NoError(t, err)
+		return router, stable, canary
+	}
+	query := func(t *testing.T, router *services.CoreRouter, conversationID string) {
+		t.Helper()
+		events, err := router.Query(t.Context(), models.CoreQueryRequest{Query: "what?", ConversationID: conversationID, TopK: 5})
+		require.NoError(t, err)
+		for range events {
+		}
+	}
+	backend := func(stats models.CoreBackendStatsResponse, name string) models.CoreBackendStats {
+		for _, b := range stats.Backends {
+			if b.Name == name {
+				return b
+			}
+		}
+		return models.CoreBackendStats{}
+	}
+
+	t.Run("Query_StickyPerConversation", func(t *testing.T) {
+		router, stable, canary := newRouter(t, 50, 50)
+		answering(stable, models.SSEEvent{Type: "end"})
+		answering(canary, models.SSEEvent{Type: "end"})
+
+		for i := range 20 {
+			conversationID := fmt.Sprintf("conv-%d", i)
+			before := backend(router.BackendStats(), "canary").Queries
+			query(t, router, conversationID)
+			onCanary := backend(router.BackendStats(), "canary").Queries > before
+
+			for range 3 {
+				before := backend(router.BackendStats(), "canary").Queries
+				query(t, router, conversationID)
+				assert.Equal(t, onCanary, backend(router.BackendStats(), "canary").Queries > before, conversationID)
+			}
+		}
+		assert.NotZero(t, backend(router.BackendStats(), "stable").Queries)
+		assert.NotZero(t, backend(router.BackendStats(), "canary").Queries)
+	})
+
+	t.Run("Query_DrainedBackend", func(t *testing.T) {
+		router, stable, canary := newRouter(t, 100, 0)
+		answering(stable, models.SSEEvent{Type: "end"})
+
+		for i := range 20 {
+			query(t, router, fmt.Sprintf("conv-%d", i))
+			query(t, router, "")
+		}
+
+		canary.AssertNotCalled(t, "Query", mock.Anything, mock.Anything)
+		stats := router.BackendStats()
+		assert.EqualValues(t, 40, backend(stats, "stable").Queries)
+		assert.Equal(t, 100.0, backend(stats, "stable").Percent)
+	})
+
+	t.Run("Query_RecordsErrors", func(t *testing.T) {
+		router, stable, _ := newRouter(t, 1, 0)
+		answering(stable, models.SSEEvent{Type: "error", Message: "boom"}, models.SSEEvent{Type: "end"})
+
+		query(t, router, "")
+
+		stats := backend(router.BackendStats(), "stable")
+		assert.EqualValues(t, 1, stats.Queries)
+		assert.EqualValues(t, 1, stats.Errors)
+		assert.Zero(t, stats.SampleSize)
+	})
+
+	t.Run("HealthCheck_CanaryFailureReported", func(t *testing.T) {
+		router, stable, canary := newRouter(t, 95, 5)
+		stable.On("HealthCheck", mock.Anything).Return(map[string]string{"python_core": "ok"}, nil)
+		canary.On("HealthCheck", mock.Anything).Return(nil, errors.New("connection refused"))
+
+		deps, err := router.HealthCheck(t.Context())
+
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"stable.python_core": "ok", "canary": "connection refused"}, deps)
+	})
+
+	t.Run("HealthCheck_StableFailure", func(t *testing.T) {
+		router, stable, canary := newRouter(t, 95, 5)
+		stable.On("HealthCheck", mock.Anything).Return(nil, errors.New("connection refused"))
+		canary.On("HealthCheck", mock.Anything).Return(map[string]string{"python_core": "ok"}, nil)
+
+		_, err := router.HealthCheck(t.Context())
+
+		assert.ErrorContains(t, err, "stable")
+	})
+
+	t.Run("New_ZeroWeights", func(t *testing.T) {
+		_, err := services.NewCoreRouter([]services.CoreBackend{{Name: "stable", Client: mocks.NewMockCoreService()}})
+
+		assert.Error(t, err)
+	})
+}
@@ -1,40 +1,104 @@
 package services
 
 import (
-	"bufio"
 	"bytes"
+	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"time"
 
+	"kb-platform-gateway/internal/config"
 	"kb-platform-gateway/internal/models"
 )
 
 type PythonCoreClient struct {
-	baseURL    string
-	httpClient *http.Client
+	baseURL            string
+	httpClient         *http.Client
+	healthCheckTimeout time.Duration
 }
 
-func NewPythonCoreClient(host string, port int) *PythonCoreClient {
+// NewPythonCoreClient creates an HTTP client for the Python Core service.
+// Idempotent requests are retried according to cfg.PythonCoreRetry.
+//
+// The client has no overall timeout because query responses are long-lived
+// streams; they are bounded by the request context instead, while connection
+// setup and time-to-first-byte are bounded by the transport.
+func NewPythonCoreClient(cfg *config.ServicesConfig) (*PythonCoreClient, error) {
+	transport, err := newCoreTransport(&cfg.PythonCoreHTTP)
+	if err != nil {
+		return nil, err
+	}
+
+	scheme := "http"
+	if cfg.PythonCoreHTTP.TLSEnabled {
+		scheme = "https"
+	}
+
 	return &PythonCoreClient{
-		baseURL: fmt.Sprintf("http://%s:%d", host, port),
+		baseURL: fmt.Sprintf("%s://%s:%d", scheme, cfg.PythonCoreHost, cfg.PythonCorePort),
 		httpClient: &http.Client{
-			Timeout: 60 * time.Second,
+			Transport: newRetryTransport(transport, &cfg.PythonCoreRetry),
 		},
-	}
+		healthCheckTimeout: cfg.PythonCoreHTTP.HealthCheckTimeout,
+	}, nil
 }
 
-func (c *PythonCoreClient) Query(query string, conversationID string, topK int) (<-chan models.SSEEvent, error) {
-	req := models.QueryRequest{
-		Query:          query,
-		ConversationID: conversationID,
-		TopK:           topK,
+// newCoreTransport builds a pooled transport dedicated to the core.
+func newCoreTransport(cfg *config.CoreHTTPConfig) (*http.Transport, error) {
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   cfg.DialTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		MaxIdleConns:          cfg.MaxIdleConnsPerHost,
+		MaxIdleConnsPerHost:   cfg.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       cfg.MaxConnsPerHost,
+		IdleConnTimeout:       cfg.IdleConnTimeout,
+		TLSHandshakeTimeout:   cfg.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: cfg.ResponseHeaderTimeout,
+		ExpectContinueTimeout: time.Second,
 	}
 
-	jsonData, _ := json.Marshal(req)
+	if cfg.TLSEnabled {
+		transport.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		if cfg.TLSCAFile != "" {
+			pool, err := loadCertPool(cfg.TLSCAFile)
+			if err != nil {
+				return nil, err
+			}
+			transport.TLSClientConfig.RootCAs = pool
+		}
+	}
 
-	httpReq, _ := http.NewRequest("POST", c.baseURL+"/api/v1/query", bytes.NewBuffer(jsonData))
+	if cfg.HTTP2 {
+		protocols := new(http.Protocols)
+		if cfg.TLSEnabled {
+			protocols.SetHTTP1(true)
+			protocols.SetHTTP2(true)
+		} else {
+			protocols.SetUnencryptedHTTP2(true)
+		}
+		transport.Protocols = protocols
+	}
+
+	return transport, nil
+}
+
+func (c *PythonCoreClient) Query(ctx context.Context, req models.CoreQueryRequest) (<-chan models.SSEEvent, error) {
+	jsonData, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode query request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/v1/query", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query request: %w", err)
+	}
 	httpReq.Header.Set("Content-Type", "application/json")
 
 	resp, err := c.httpClient.Do(httpReq)
@@ -43,6 +107,7 @@ func (c *PythonCoreClient) Query(query string, conversationID string, topK int)
 	}
 
 	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
 		return nil, fmt.Errorf("query failed with status: %d", resp.StatusCode)
 	}
 
@@ -52,36 +117,37 @@ func (c *PythonCoreClient) Query(query string, conversationID string, topK int)
 		defer resp.Body.Close()
 		defer close(eventChan)
 
-		reader := bufio.NewReader(resp.Body)
-		var buffer bytes.Buffer
+		decoder := NewSSEDecoder(resp.Body)
 
 		for {
-			line, err := reader.ReadBytes('\n')
-			if err != nil && len(line) == 0 {
-				if err.Error() != "EOF" {
-					eventChan <- models.SSEEvent{
+			msg, err := decoder.Next()
+			if err != nil {
+				if err != io.EOF && ctx.Err() == nil {
+					sendEvent(ctx, eventChan, models.SSEEvent{
 						Type:    "error",
 						Code:    "STREAM_ERROR",
 						Message: err.Error(),
-					}
+					})
 				}
 				return
 			}
 
-			if len(line) > 0 {
-				buffer.Write(line)
-
-				if bytes.HasSuffix(line, []byte("\n\n")) {
-					data := buffer.String()
-					if len(data) > 6 && data[:6] == "data: " {
-						jsonData := data[6:]
-						var event models.SSEEvent
-						if err := json.Unmarshal([]byte(jsonData), &event); err == nil {
-							eventChan <- event
-						}
-					}
-					buffer.Reset()
-				}
+			if msg.Data == "" {
+				continue
+			}
+
+			var event models.SSEEvent
+			if err := json.Unmarshal([]byte(msg.Data), &event); err != nil {
+				continue
+			}
+			if event.Type == "" && msg.Event != "" {
+				event.Type = msg.Event
+			}
+			if event.ID == "" {
+				event.ID = msg.ID
+			}
+			if !sendEvent(ctx, eventChan, event) {
+				return
 			}
 		}
 	}()
@@ -89,8 +155,30 @@ func (c *PythonCoreClient) Query(query string, conversationID string, topK int)
 	return eventChan, nil
 }
 
-func (c *PythonCoreClient) HealthCheck() (map[string]string, error) {
-	resp, err := c.httpClient.Get(c.baseURL + "/readyz")
+// sendEvent delivers event unless ctx is cancelled first, so the reader
+// goroutine does not leak when the client goes away mid-stream.
+func sendEvent(ctx context.Context, ch chan<- models.SSEEvent, event models.SSEEvent) bool {
+	select {
+	case ch <- event:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (c *PythonCoreClient) HealthCheck(ctx context.Context) (map[string]string, error) {
+	if c.healthCheckTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.healthCheckTimeout)
+		defer cancel()
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/readyz", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"kb-platform-gateway/internal/models"
+)
+
+// GetDocument retrieves a document by ID.
+func (c *PythonCoreClient) GetDocument(ctx context.Context, documentID string) (*models.Document, error) {
+	var doc models.Document
+	if err := c.doJSON(ctx, http.MethodGet, "/api/v1/documents/"+url.PathEscape(documentID), nil, &doc); err != nil {
+		return nil, fmt.Errorf("failed to get document: %w", err)
+	}
+	return &doc, nil
+}
+
+// DeleteDocumentVectors deletes the vectors indexed for a document.
+func (c *PythonCoreClient) DeleteDocumentVectors(ctx context.Context, documentID string) error {
+	if err := c.doJSON(ctx, http.MethodDelete, "/api/v1/documents/"+url.PathEscape(documentID)+"/vectors", nil, nil); err != nil {
+		return fmt.Errorf("failed to delete document vectors: %w", err)
+	}
+	return nil
+}
+
+// GetConversation retrieves a conversation by ID.
+func (c *PythonCoreClient) GetConversation(ctx context.Context, conversationID string) (*models.Conversation, error) {
+	var conv models.Conversation
+	if err := c.doJSON(ctx, http.MethodGet, "/api/v1/conversations/"+url.PathEscape(conversationID), nil, &conv); err != nil {
+		return nil, fmt.Errorf("failed to get conversation: %w", err)
+	}
+	return &conv, nil
+}
+
+// GetConversationMessages retrieves messages for a conversation.
+func (c *PythonCoreClient) GetConversationMessages(ctx context.Context, conversationID string) ([]*models.Message, error) {
+	var resp struct {
+		Messages []*models.Message `json:"messages"`
+	}
+	if err := c.doJSON(ctx, http.MethodGet, "/api/v1/conversations/"+url.PathEscape(conversationID)+"/messages", nil, &resp); err != nil {
+		return nil, fmt.Errorf("failed to get conversation messages: %w", err)
+	}
+	return resp.Messages, nil
+}
+
+// SaveMessage saves a message to a conversation.
+func (c *PythonCoreClient) SaveMessage(ctx context.Context, conversationID string, role string, content string, metadata map[string]string) (*models.Message, error) {
+	req := models.SaveMessageRequest{
+		ConversationID: conversationID,
+		Role:           role,
+		Content:        content,
+		Metadata:       metadata,
+	}
+
+	var msg models.Message
+	if err := c.doJSON(ctx, http.MethodPost, "/api/v1/conversations/"+url.PathEscape(conversationID)+"/messages", req, &msg); err != nil {
+		return nil, fmt.Errorf("failed to save message: %w", err)
+	}
+	return &msg, nil
+}
+
+// Evaluate scores an answer with the core's evaluator.
+func (c *PythonCoreClient) Evaluate(ctx context.Context, question, expectedAnswer, answer string) (*models.EvaluationScore, error) {
+	req := models.CoreEvaluationRequest{
+		Question:       question,
+		ExpectedAnswer: expectedAnswer,
+		Answer:         answer,
+	}
+
+	var score models.EvaluationScore
+	if err := c.doJSON(ctx, http.MethodPost, "/api/v1/evaluate", req, &score); err != nil {
+		return nil, fmt.Errorf("failed to evaluate answer: %w", err)
+	}
+	return &score, nil
+}
+
+// Summarize asks the core to fold messages into summary.
+func (c *PythonCoreClient) Summarize(ctx context.Context, summary string, messages []*models.Message) (string, error) {
+	req := models.CoreSummaryRequest{
+		Summary:  summary,
+		Messages: make([]models.Message, len(messages)),
+	}
+	for i, msg := range messages {
+		req.Messages[i] = *msg
+	}
+
+	var resp models.CoreSummaryResponse
+	if err := c.doJSON(ctx, http.MethodPost, "/api/v1/summarize", req, &resp); err != nil {
+		return "", fmt.Errorf("failed to summarize conversation: %w", err)
+	}
+	return resp.Summary, nil
+}
+
+// doJSON sends body (if any) as JSON and decodes a 2xx response into out
+// (if non-nil).
+func (c *PythonCoreClient) doJSON(ctx context.Context, method, path string, body any, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("request failed with status: %d", resp.StatusCode)
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,272 @@
+package services
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"kb-platform-gateway/internal/config"
+	"kb-platform-gateway/internal/models"
+	"kb-platform-gateway/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// connectorClaimBatchSize is the number of due connectors claimed at a
+// time.
+const connectorClaimBatchSize = 20
+
+// ConnectorService links external sources such as Google Drive and
+// SharePoint to the knowledge base and schedules their syncs. Every
+// PollInterval it claims the connectors whose next sync is due, so
+// gateway instances share the work, and starts a ConnectorSyncWorkflow for
+// each. The workflow ingests new and changed files through the internal
+// connector API and reports back with a connector event.
+//
+// OAuth credentials are sealed with AES-GCM before they are stored.
+type ConnectorService struct {
+	repo     repository.ConnectorRepository
+	temporal TemporalClientInterface
+	aead     cipher.AEAD
+	logger   zerolog.Logger
+
+	syncInterval time.Duration
+	pollInterval time.Duration
+
+	// wake starts a sync pass before the next poll.
+	wake chan struct{}
+
+	ctx       context.Context
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+}
+
+func NewConnectorService(cfg *config.ConnectorConfig, repo repository.ConnectorRepository, temporal TemporalClientInterface, logger zerolog.Logger) (*ConnectorService, error) {
+	key, err := base64.StdEncoding.DecodeString(cfg.EncryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid connector encryption key: %w", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("invalid connector encryption key: got %d bytes, want 32", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid connector encryption key: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("invalid connector encryption key: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &ConnectorService{
+		repo:         repo,
+		temporal:     temporal,
+		aead:         aead,
+		logger:       logger,
+		syncInterval: max(cfg.SyncInterval, time.Minute),
+		pollInterval: cfg.PollInterval,
+		wake:         make(chan struct{}, 1),
+		ctx:          ctx,
+		cancel:       cancel,
+	}, nil
+}
+
+// Start runs Sync every PollInterval, and when woken by SyncNow, until
+// Close is called. It does nothing if the poll is disabled.
+func (s *ConnectorService) Start() {
+	if s.pollInterval <= 0 {
+		return
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(s.pollInterval)
+		defer ticker.Stop()
+		for {
+			s.Sync(s.ctx)
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-ticker.C:
+			case <-s.wake:
+			}
+		}
+	}()
+}
+
+// Close stops the poll.
+func (s *ConnectorService) Close() {
+	s.closeOnce.Do(func() {
+		s.cancel()
+		s.wg.Wait()
+	})
+}
+
+// Sync starts the sync workflow of every connector that is due. A
+// connector whose workflow cannot be started is marked failed and retried
+// at its next sync.
+func (s *ConnectorService) Sync(ctx context.Context) {
+	for ctx.Err() == nil {
+		now := time.Now()
+		connectors, err := s.repo.ClaimDueConnectors(ctx, now, connectorClaimBatchSize)
+		if err != nil {
+			if ctx.Err() == nil {
+				s.logger.Error().Err(err).Msg("Failed to claim due connectors")
+			}
+			return
+		}
+
+		for _, connector := range connectors {
+			_, err := s.temporal.StartConnectorSyncWorkflow(ctx, ConnectorSyncWorkflowInput{
+				ConnectorID:   connector.ID,
+				Provider:      connector.Provider,
+				FolderIDs:     connector.FolderIDs,
+				ModifiedSince: connector.LastSyncedAt,
+			})
+			if err == nil {
+				continue
+			}
+			s.logger.Error().Err(err).Str("connector_id", connector.ID).Msg("Failed to start connector sync")
+			if err := s.repo.FinishConnectorSync(ctx, connector.ID, "failed to start sync", now); err != nil {
+				s.logger.Error().Err(err).Str("connector_id", connector.ID).Msg("Failed to record connector sync failure")
+			}
+		}
+
+		if len(connectors) < connectorClaimBatchSize {
+			return
+		}
+	}
+}
+
+func (s *ConnectorService) Create(ctx context.Context, username string, req models.CreateConnectorRequest) (*models.Connector, error) {
+	id := uuid.New().String()
+	sealed, err := s.sealFor(id, req.Credentials)
+	if err != nil {
+		return nil, err
+	}
+
+	interval := req.SyncIntervalMinutes
+	if interval == 0 {
+		interval = int(s.syncInterval / time.Minute)
+	}
+
+	now := time.Now()
+	connector := &models.Connector{
+		ID:                  id,
+		Username:            username,
+		Provider:            req.Provider,
+		Name:                req.Name,
+		FolderIDs:           req.FolderIDs,
+		SyncIntervalMinutes: interval,
+		Status:              models.ConnectorStatusIdle,
+		NextSyncAt:          now,
+		Credentials:         sealed,
+		CreatedAt:           now,
+		UpdatedAt:           now,
+	}
+	if err := s.repo.CreateConnector(ctx, connector); err != nil {
+		return nil, fmt.Errorf("failed to create connector: %w", err)
+	}
+
+	s.poke()
+	return connector, nil
+}
+
+func (s *ConnectorService) Update(ctx context.Context, connector *models.Connector, req models.UpdateConnectorRequest) error {
+	if req.Credentials != nil {
+		if err := s.UpdateCredentials(ctx, connector.ID, *req.Credentials); err != nil {
+			return err
+		}
+	}
+
+	if req.Name != nil {
+		connector.Name = *req.Name
+	}
+	if req.FolderIDs != nil {
+		connector.FolderIDs = req.FolderIDs
+	}
+	if req.SyncIntervalMinutes != nil {
+		connector.SyncIntervalMinutes = *req.SyncIntervalMinutes
+	}
+	connector.UpdatedAt = time.Now()
+
+	if err := s.repo.UpdateConnector(ctx, connector); err != nil {
+		return fmt.Errorf("failed to update connector: %w", err)
+	}
+	return nil
+}
+
+func (s *ConnectorService) SyncNow(ctx context.Context, connector *models.Connector) error {
+	connector.NextSyncAt = time.Now()
+	connector.UpdatedAt = connector.NextSyncAt
+	if err := s.repo.UpdateConnector(ctx, connector); err != nil {
+		return fmt.Errorf("failed to schedule connector sync: %w", err)
+	}
+
+	s.poke()
+	return nil
+}
+
+func (s *ConnectorService) Credentials(connector *models.Connector) (*models.ConnectorCredentials, error) {
+	nonceSize := s.aead.NonceSize()
+	if len(connector.Credentials) < nonceSize {
+		return nil, errors.New("sealed connector credentials are truncated")
+	}
+	nonce, ciphertext := connector.Credentials[:nonceSize], connector.Credentials[nonceSize:]
+
+	plaintext, err := s.aead.Open(nil, nonce, ciphertext, []byte(connector.ID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to unseal connector credentials: %w", err)
+	}
+
+	var credentials models.ConnectorCredentials
+	if err := json.Unmarshal(plaintext, &credentials); err != nil {
+		return nil, fmt.Errorf("failed to decode connector credentials: %w", err)
+	}
+	return &credentials, nil
+}
+
+func (s *ConnectorService) UpdateCredentials(ctx context.Context, connectorID string, credentials models.ConnectorCredentials) error {
+	sealed, err := s.sealFor(connectorID, credentials)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.UpdateConnectorCredentials(ctx, connectorID, sealed); err != nil {
+		return fmt.Errorf("failed to update connector credentials: %w", err)
+	}
+	return nil
+}
+
+// sealFor encrypts credentials for the connector with the given ID. The ID
+// is authenticated too, so sealed credentials cannot be moved to another
+// connector.
+func (s *ConnectorService) sealFor(connectorID string, credentials models.ConnectorCredentials) ([]byte, error) {
+	plaintext, err := json.Marshal(credentials)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode connector credentials: %w", err)
+	}
+
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to seal connector credentials: %w", err)
+	}
+	return s.aead.Seal(nonce, nonce, plaintext, []byte(connectorID)), nil
+}
+
+// poke wakes the poll, if it is not already due to run.
+func (s *ConnectorService) poke() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,96 @@
+package services_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"kb-platform-gateway/internal/config"
+	"kb-platform-gateway/internal/models"
+	repomocks "kb-platform-gateway/internal/repository/mocks"
+	"kb-platform-gateway/internal/services"
+	"kb-platform-gateway/internal/services/mocks"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnectorService(t *testing.T) {
+	cfg := &config.ConnectorConfig{
+		EncryptionKey: "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=",
+		SyncInterval:  time.Hour,
+	}
+
+	t.Run("New_InvalidKey", func(t *testing.T) {
+		_, err := services.NewConnectorService(&config.ConnectorConfig{EncryptionKey: "c2hvcnQ="}, nil, nil, zerolog.Nop())
+
+		assert.Error(t, err)
+	})
+
+	t.Run("Create_SealsCredentials", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		var stored *models.Connector
+		repo.On("CreateConnector", mock.Anything, mock.AnythingOfType("*models.Connector")).Run(func(args mock.Arguments) {
+			stored = args.Get(1).(*models.Connector)
+		}).Return(nil)
+		svc, err := services.NewConnectorService(cfg, repo, nil, zerolog.Nop())
+		require.NoError(t, err)
+
+		connector, err := svc.Create(t.Context(), "alice", models.CreateConnectorRequest{
+			Provider:    models.ConnectorProviderGoogleDrive,
+			Name:        "Handbooks",
+			FolderIDs:   []string{"folder-1"},
+			Credentials: models.ConnectorCredentials{AccessToken: "access", RefreshToken: "refresh"},
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, 60, connector.SyncIntervalMinutes)
+		assert.Equal(t, models.ConnectorStatusIdle, connector.Status)
+		assert.NotContains(t, string(stored.Credentials), "refresh")
+
+		credentials, err := svc.Credentials(stored)
+		require.NoError(t, err)
+		assert.Equal(t, "refresh", credentials.RefreshToken)
+	})
+
+	t.Run("Credentials_BoundToConnector", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		var sealed []byte
+		repo.On("UpdateConnectorCredentials", mock.Anything, "c-1", mock.Anything).Run(func(args mock.Arguments) {
+			sealed = args.Get(2).([]byte)
+		}).Return(nil)
+		svc, err := services.NewConnectorService(cfg, repo, nil, zerolog.Nop())
+		require.NoError(t, err)
+
+		require.NoError(t, svc.UpdateCredentials(t.Context(), "c-1", models.ConnectorCredentials{RefreshToken: "refresh"}))
+
+		_, err = svc.Credentials(&models.Connector{ID: "c-2", Credentials: sealed})
+		assert.Error(t, err)
+	})
+
+	t.Run("Sync_StartsWorkflows", func(t *testing.T) {
+		lastSynced := time.Now().Add(-time.Hour)
+		repo := repomocks.NewMockRepository()
+		repo.On("ClaimDueConnectors", mock.Anything, mock.Anything, mock.Anything).Return([]*models.Connector{
+			{ID: "c-1", Provider: models.ConnectorProviderGoogleDrive, FolderIDs: []string{"f-1"}, LastSyncedAt: &lastSynced},
+			{ID: "c-2", Provider: models.ConnectorProviderSharePoint, FolderIDs: []string{"f-2"}},
+		}, nil).Once()
+		repo.On("FinishConnectorSync", mock.Anything, "c-2", "failed to start sync", mock.Anything).Return(nil)
+		temporal := mocks.NewMockTemporalClient()
+		temporal.On("StartConnectorSyncWorkflow", mock.Anything, services.ConnectorSyncWorkflowInput{
+			ConnectorID: "c-1", Provider: models.ConnectorProviderGoogleDrive, FolderIDs: []string{"f-1"}, ModifiedSince: &lastSynced,
+		}).Return("connector-sync-c-1", nil)
+		temporal.On("StartConnectorSyncWorkflow", mock.Anything, mock.MatchedBy(func(input services.ConnectorSyncWorkflowInput) bool {
+			return input.ConnectorID == "c-2"
+		})).Return("", errors.New("temporal unavailable"))
+		svc, err := services.NewConnectorService(cfg, repo, temporal, zerolog.Nop())
+		require.NoError(t, err)
+
+		svc.Sync(t.Context())
+
+		repo.AssertExpectations(t)
+		temporal.AssertExpectations(t)
+	})
+}
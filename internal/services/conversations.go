@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"kb-platform-gateway/internal/config"
+)
+
+// conversationLockPoll is how often a queued query retries the lock.
+const conversationLockPoll = 100 * time.Millisecond
+
+// ConversationBusyError is returned by ConversationLocks.Acquire when
+// another query holds the conversation.
+type ConversationBusyError struct {
+	// RequestID is the request ID of the query in flight, if known.
+	RequestID string
+}
+
+func (e *ConversationBusyError) Error() string {
+	return fmt.Sprintf("conversation is busy with request %q", e.RequestID)
+}
+
+// ConversationLocks allows one query at a time in each conversation, since
+// the answers of concurrent queries interleave in its history. Locks are
+// held in Redis, so every gateway instance sees them, or in memory if
+// redis is nil.
+type ConversationLocks struct {
+	queue        bool
+	queueTimeout time.Duration
+	ttl          time.Duration
+	redis        RedisClientInterface
+
+	mu    sync.Mutex
+	local map[string]string
+}
+
+func NewConversationLocks(cfg *config.ConversationConfig, redis RedisClientInterface) (*ConversationLocks, error) {
+	switch cfg.QueryMode {
+	case "", config.ConversationQueryReject, config.ConversationQueryQueue:
+	default:
+		return nil, fmt.Errorf("unknown conversation query mode %q", cfg.QueryMode)
+	}
+
+	return &ConversationLocks{
+		queue:        cfg.QueryMode == config.ConversationQueryQueue,
+		queueTimeout: cfg.QueueTimeout,
+		ttl:          cfg.LockTTL,
+		redis:        redis,
+		local:        make(map[string]string),
+	}, nil
+}
+
+// Acquire locks conversationID for the query with requestID and returns
+// the function releasing it. If another query holds the lock, Acquire
+// returns a *ConversationBusyError naming it, in queue mode once the queue
+// timeout has passed without the lock being released.
+func (l *ConversationLocks) Acquire(ctx context.Context, conversationID, requestID string) (func(), error) {
+	var timeout <-chan time.Time
+	if l.queue {
+		timer := time.NewTimer(l.queueTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	for {
+		acquired, holder, err := l.tryAcquire(ctx, conversationID, requestID)
+		if err != nil {
+			return nil, err
+		}
+		if acquired {
+			release := context.WithoutCancel(ctx)
+			return func() { l.release(release, conversationID, requestID) }, nil
+		}
+		if !l.queue {
+			return nil, &ConversationBusyError{RequestID: holder}
+		}
+
+		select {
+		case <-time.After(conversationLockPoll):
+		case <-timeout:
+			return nil, &ConversationBusyError{RequestID: holder}
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// tryAcquire takes the lock if it is free, or returns the request ID of
+// its holder.
+func (l *ConversationLocks) tryAcquire(ctx context.Context, conversationID, requestID string) (bool, string, error) {
+	if l.redis == nil {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if holder, held := l.local[conversationID]; held {
+			return false, holder, nil
+		}
+		l.local[conversationID] = requestID
+		return true, "", nil
+	}
+
+	key := conversationLockKey(conversationID)
+	// The lock may be released between SetNX and Get, so try again once.
+	for attempt := 0; attempt < 2; attempt++ {
+		acquired, err := l.redis.SetNX(ctx, key, requestID, l.ttl)
+		if err != nil || acquired {
+			return acquired, "", err
+		}
+		holder, found, err := l.redis.Get(ctx, key)
+		if err != nil {
+			return false, "", err
+		}
+		if found {
+			return false, holder, nil
+		}
+	}
+	return false, "", nil
+}
+
+// release unlocks conversationID if requestID still holds it. A Redis lock
+// that cannot be released expires after the lock TTL.
+func (l *ConversationLocks) release(ctx context.Context, conversationID, requestID string) {
+	if l.redis == nil {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if l.local[conversationID] == requestID {
+			delete(l.local, conversationID)
+		}
+		return
+	}
+
+	key := conversationLockKey(conversationID)
+	if holder, found, err := l.redis.Get(ctx, key); err == nil && found && holder == requestID {
+		l.redis.Delete(ctx, key)
+	}
+}
+
+func conversationLockKey(conversationID string) string {
+	return "conversation:lock:" + conversationID
+}
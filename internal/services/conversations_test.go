@@ -0,0 +1,96 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"kb-platform-gateway/internal/config"
+	"kb-platform-gateway/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConversationLocks(t *testing.T) {
+	ctx := context.Background()
+	newLocks := func(t *testing.T, mode string, redis services.RedisClientInterface) *services.ConversationLocks {
+		t.Helper()
+		locks, err := services.NewConversationLocks(&config.ConversationConfig{
+			QueryMode:    mode,
+			QueueTimeout: 300 * time.Millisecond,
+			LockTTL:      time.Minute,
+		}, redis)
+		require.NoError(t, err)
+		return locks
+	}
+
+	t.Run("Reject", func(t *testing.T) {
+		locks := newLocks(t, config.ConversationQueryReject, nil)
+		release, err := locks.Acquire(ctx, "conv-1", "req-1")
+		require.NoError(t, err)
+
+		_, err = locks.Acquire(ctx, "conv-1", "req-2")
+		var busy *services.ConversationBusyError
+		require.ErrorAs(t, err, &busy)
+		assert.Equal(t, "req-1", busy.RequestID)
+
+		other, err := locks.Acquire(ctx, "conv-2", "req-3")
+		require.NoError(t, err, "other conversations are not locked")
+		other()
+
+		release()
+		release, err = locks.Acquire(ctx, "conv-1", "req-2")
+		require.NoError(t, err)
+		release()
+	})
+
+	t.Run("Queue", func(t *testing.T) {
+		locks := newLocks(t, config.ConversationQueryQueue, nil)
+		release, err := locks.Acquire(ctx, "conv-1", "req-1")
+		require.NoError(t, err)
+		time.AfterFunc(50*time.Millisecond, release)
+
+		started := time.Now()
+		release, err = locks.Acquire(ctx, "conv-1", "req-2")
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, time.Since(started), 50*time.Millisecond)
+		release()
+	})
+
+	t.Run("Queue_Timeout", func(t *testing.T) {
+		locks := newLocks(t, config.ConversationQueryQueue, nil)
+		release, err := locks.Acquire(ctx, "conv-1", "req-1")
+		require.NoError(t, err)
+		defer release()
+
+		_, err = locks.Acquire(ctx, "conv-1", "req-2")
+		var busy *services.ConversationBusyError
+		require.ErrorAs(t, err, &busy)
+		assert.Equal(t, "req-1", busy.RequestID)
+	})
+
+	t.Run("Redis_SharedAcrossInstances", func(t *testing.T) {
+		client, _ := newTestRedis(t)
+		first := newLocks(t, config.ConversationQueryReject, client)
+		second := newLocks(t, config.ConversationQueryReject, client)
+
+		release, err := first.Acquire(ctx, "conv-1", "req-1")
+		require.NoError(t, err)
+
+		_, err = second.Acquire(ctx, "conv-1", "req-2")
+		var busy *services.ConversationBusyError
+		require.ErrorAs(t, err, &busy)
+		assert.Equal(t, "req-1", busy.RequestID)
+
+		release()
+		release, err = second.Acquire(ctx, "conv-1", "req-2")
+		require.NoError(t, err)
+		release()
+	})
+
+	t.Run("NewConversationLocks_UnknownMode", func(t *testing.T) {
+		_, err := services.NewConversationLocks(&config.ConversationConfig{QueryMode: "serialize"}, nil)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"fmt"
+	"io"
+
+	"kb-platform-gateway/internal/config"
+)
+
+// NewCoreService creates the Python Core client for the configured transport.
+// With several backends configured, it returns a CoreRouter over a client
+// per backend.
+func NewCoreService(cfg *config.ServicesConfig) (CoreServiceInterface, error) {
+	if len(cfg.PythonCoreBackends) > 0 {
+		return newCoreRouter(cfg)
+	}
+
+	switch cfg.PythonCoreTransport {
+	case "", "http":
+		return NewPythonCoreClient(cfg)
+	case "grpc":
+		return NewGrpcCoreClient(&cfg.PythonCoreGRPC, &cfg.PythonCoreRetry)
+	default:
+		return nil, fmt.Errorf("unknown python core transport %q", cfg.PythonCoreTransport)
+	}
+}
+
+func newCoreRouter(cfg *config.ServicesConfig) (CoreServiceInterface, error) {
+	backends := make([]CoreBackend, 0, len(cfg.PythonCoreBackends))
+	closeAll := func() {
+		for _, backend := range backends {
+			if closer, ok := backend.Client.(io.Closer); ok {
+				closer.Close()
+			}
+		}
+	}
+
+	for _, backend := range cfg.PythonCoreBackends {
+		client, err := NewCoreService(cfg.WithCore(backend.Host, backend.Port))
+		if err != nil {
+			closeAll()
+			return nil, fmt.Errorf("core backend %s: %w", backend.Name, err)
+		}
+		backends = append(backends, CoreBackend{Name: backend.Name, Weight: backend.Weight, Client: client})
+	}
+
+	router, err := NewCoreRouter(backends)
+	if err != nil {
+		closeAll()
+		return nil, err
+	}
+	return router, nil
+}
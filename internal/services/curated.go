@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"kb-platform-gateway/internal/models"
+	"kb-platform-gateway/internal/repository"
+)
+
+// curatedMatchRank orders the match types from most to least specific.
+var curatedMatchRank = map[string]int{
+	models.CuratedMatchExact:    0,
+	models.CuratedMatchContains: 1,
+	models.CuratedMatchRegex:    2,
+}
+
+// CuratedAnswers finds the answer an editor pinned to a question. The
+// enabled answers are read on every lookup, so edits apply to the next
+// query on every instance.
+type CuratedAnswers struct {
+	repo repository.CuratedAnswerRepository
+}
+
+func NewCuratedAnswers(repo repository.CuratedAnswerRepository) *CuratedAnswers {
+	return &CuratedAnswers{repo: repo}
+}
+
+// Find returns the curated answer for question, or nil if none matches.
+// When several match, the most specific match type wins, then the most
+// recently updated answer.
+func (c *CuratedAnswers) Find(ctx context.Context, question string) (*models.CuratedAnswer, error) {
+	answers, err := c.repo.ListEnabledCuratedAnswers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	normalized := normalizeQuestion(question)
+	var best *models.CuratedAnswer
+	for _, answer := range answers {
+		if best != nil && curatedMatchRank[answer.MatchType] >= curatedMatchRank[best.MatchType] {
+			continue
+		}
+		if curatedMatch(answer, question, normalized) {
+			best = answer
+		}
+	}
+	return best, nil
+}
+
+// ValidateCuratedPattern reports why pattern cannot be used with
+// matchType, if it cannot.
+func ValidateCuratedPattern(matchType, pattern string) error {
+	switch matchType {
+	case models.CuratedMatchExact, models.CuratedMatchContains:
+		if normalizeQuestion(pattern) == "" {
+			return errors.New("pattern must contain a letter or digit")
+		}
+	case models.CuratedMatchRegex:
+		if _, err := regexp.Compile(pattern); err != nil {
+			return errors.New("pattern is not a valid regular expression")
+		}
+	default:
+		return errors.New("match_type must be exact, contains or regex")
+	}
+	return nil
+}
+
+func curatedMatch(answer *models.CuratedAnswer, question, normalized string) bool {
+	switch answer.MatchType {
+	case models.CuratedMatchExact:
+		return normalized == normalizeQuestion(answer.Pattern)
+	case models.CuratedMatchContains:
+		return strings.Contains(" "+normalized+" ", " "+normalizeQuestion(answer.Pattern)+" ")
+	case models.CuratedMatchRegex:
+		// Patterns are validated when saved, so one that fails to compile
+		// was stored by other means and never matches.
+		re, err := regexp.Compile("(?i)" + answer.Pattern)
+		return err == nil && re.MatchString(question)
+	}
+	return false
+}
+
+// normalizeQuestion lowercases question and reduces it to its words
+// separated by single spaces.
+func normalizeQuestion(question string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(question), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
@@ -0,0 +1,59 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+
+	"kb-platform-gateway/internal/models"
+	repomocks "kb-platform-gateway/internal/repository/mocks"
+	"kb-platform-gateway/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCuratedAnswers(t *testing.T) {
+	ctx := context.Background()
+	answers := []*models.CuratedAnswer{
+		{ID: "ca-4", Pattern: `^how do i (reset|change) my vpn`, MatchType: models.CuratedMatchRegex},
+		{ID: "ca-3", Pattern: "refund", MatchType: models.CuratedMatchContains},
+		{ID: "ca-2", Pattern: "What is the refund policy?", MatchType: models.CuratedMatchExact},
+		{ID: "ca-1", Pattern: "refund policy", MatchType: models.CuratedMatchContains},
+	}
+
+	cases := []struct {
+		name     string
+		question string
+		want     string
+	}{
+		{"Exact", "what is the REFUND policy", "ca-2"},
+		{"ContainsMostRecent", "is there a refund policy for annual plans", "ca-3"},
+		{"ContainsWholeWords", "where are refunds listed", ""},
+		{"Regex", "How do I change my VPN password?", "ca-4"},
+		{"NoMatch", "How do I reset my email password?", ""},
+	}
+	for _, tc := range cases {
+		t.Run("Find_"+tc.name, func(t *testing.T) {
+			repo := repomocks.NewMockRepository()
+			repo.On("ListEnabledCuratedAnswers", ctx).Return(answers, nil)
+
+			found, err := services.NewCuratedAnswers(repo).Find(ctx, tc.question)
+
+			require.NoError(t, err)
+			if tc.want == "" {
+				assert.Nil(t, found)
+				return
+			}
+			require.NotNil(t, found)
+			assert.Equal(t, tc.want, found.ID)
+		})
+	}
+
+	t.Run("ValidateCuratedPattern", func(t *testing.T) {
+		assert.NoError(t, services.ValidateCuratedPattern(models.CuratedMatchExact, "Refund policy?"))
+		assert.NoError(t, services.ValidateCuratedPattern(models.CuratedMatchRegex, `refunds?\b`))
+		assert.Error(t, services.ValidateCuratedPattern(models.CuratedMatchContains, "??"))
+		assert.Error(t, services.ValidateCuratedPattern(models.CuratedMatchRegex, "(refund"))
+		assert.Error(t, services.ValidateCuratedPattern("fuzzy", "refund"))
+	})
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"kb-platform-gateway/internal/config"
+
+	pb "github.com/disillusioners/kb-platform-proto/gen/go/kbplatform/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// Converters between the core's protobuf messages and the models, exported
+// for the external test package.
+var (
+	ConvertProtoEventToSSE          = convertProtoEventToSSE
+	ConvertProtoDocumentToModel     = convertProtoDocumentToModel
+	ConvertProtoConversationToModel = convertProtoConversationToModel
+	ConvertProtoMessageToModel      = convertProtoMessageToModel
+)
+
+// NewRetryTransport wraps base in the transport retrying core requests.
+var NewRetryTransport = newRetryTransport
+
+// The gRPC core client's dial target and service config.
+var (
+	CoreTarget        = coreTarget
+	CoreServiceConfig = coreServiceConfig
+)
+
+// CoreUnaryInterceptor and CoreStreamInterceptor apply cfg's deadlines
+// and metadata to core calls.
+func CoreUnaryInterceptor(cfg *config.CoreGRPCConfig) grpc.UnaryClientInterceptor {
+	return newCallPolicy(cfg).unaryInterceptor()
+}
+
+func CoreStreamInterceptor(cfg *config.CoreGRPCConfig) grpc.StreamClientInterceptor {
+	return newCallPolicy(cfg).streamInterceptor()
+}
+
+// NewGrpcCoreClientFrom returns a core client making its calls through
+// client and health. Its own connection is never dialled, so it stays idle.
+func NewGrpcCoreClientFrom(client pb.KBPlatformServiceClient, health healthpb.HealthClient) (*GrpcCoreClient, error) {
+	conn, err := grpc.NewClient("passthrough:///python-core", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+	return &GrpcCoreClient{conn: conn, client: client, health: health}, nil
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"kb-platform-gateway/internal/config"
+	"kb-platform-gateway/internal/repository"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	// sourceCheckBatchSize is the number of documents claimed at a time.
+	sourceCheckBatchSize = 50
+	// maxSourceCheckPoll caps how long newly imported documents wait for
+	// their first check.
+	maxSourceCheckPoll = time.Hour
+)
+
+// SourceChecker records the HTTP status of document source URLs for the
+// content freshness report. Each document's source is re-checked every
+// SourceCheckInterval; documents are claimed in the database, so gateway
+// instances share the work. Sources are requested with HEAD, falling back
+// to GET for servers that do not support it. A request that fails without
+// a response leaves the previous status in place. Sources are requested
+// on the shared worker pool.
+type SourceChecker struct {
+	repo       repository.FreshnessRepository
+	pool       *WorkerPool
+	httpClient *http.Client
+	logger     zerolog.Logger
+
+	interval time.Duration
+
+	ctx       context.Context
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+}
+
+func NewSourceChecker(cfg *config.FreshnessConfig, repo repository.FreshnessRepository, pool *WorkerPool, logger zerolog.Logger) *SourceChecker {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &SourceChecker{
+		repo:       repo,
+		pool:       pool,
+		httpClient: &http.Client{Timeout: cfg.SourceCheckTimeout},
+		logger:     logger,
+		interval:   cfg.SourceCheckInterval,
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+}
+
+// Start runs Check periodically until Close is called. It does nothing if
+// the checks are disabled.
+func (s *SourceChecker) Start() {
+	if s.interval <= 0 {
+		return
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(min(s.interval, maxSourceCheckPoll))
+		defer ticker.Stop()
+		for {
+			s.Check(s.ctx)
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Close stops the periodic checks and waits for requests in flight.
+func (s *SourceChecker) Close() {
+	s.closeOnce.Do(func() {
+		s.cancel()
+		s.wg.Wait()
+	})
+}
+
+// Check requests every source URL that is due and records the statuses.
+func (s *SourceChecker) Check(ctx context.Context) {
+	for ctx.Err() == nil {
+		checks, err := s.repo.ClaimSourceChecks(ctx, time.Now().Add(-s.interval), sourceCheckBatchSize)
+		if err != nil {
+			if ctx.Err() == nil {
+				s.logger.Error().Err(err).Msg("Failed to claim source checks")
+			}
+			return
+		}
+		if len(checks) == 0 {
+			return
+		}
+
+		// Failures are logged per document; Run only fails when ctx is
+		// done, which ends the loop.
+		_ = s.pool.Run(ctx, len(checks), func(ctx context.Context, i int) error {
+			check := checks[i]
+			status, err := s.status(ctx, check.URL)
+			if err != nil {
+				s.logger.Warn().Err(err).Str("document_id", check.DocumentID).Msg("Failed to check document source")
+				return nil
+			}
+			if err := s.repo.RecordSourceStatus(ctx, check.DocumentID, status); err != nil {
+				s.logger.Error().Err(err).Str("document_id", check.DocumentID).Msg("Failed to record source status")
+			}
+			return nil
+		})
+
+		if len(checks) < sourceCheckBatchSize {
+			return
+		}
+	}
+}
+
+func (s *SourceChecker) status(ctx context.Context, url string) (int, error) {
+	status, err := s.request(ctx, http.MethodHead, url)
+	if err != nil {
+		return 0, err
+	}
+	if status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented {
+		return s.request(ctx, http.MethodGet, url)
+	}
+	return status, nil
+}
+
+func (s *SourceChecker) request(ctx context.Context, method, url string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
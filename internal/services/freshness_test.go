@@ -0,0 +1,65 @@
+package services_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"kb-platform-gateway/internal/config"
+	"kb-platform-gateway/internal/models"
+	repomocks "kb-platform-gateway/internal/repository/mocks"
+	"kb-platform-gateway/internal/services"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSourceChecker(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/gone":
+			w.WriteHeader(http.StatusNotFound)
+		case "/no-head":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	t.Run("Check_RecordsStatuses", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("ClaimSourceChecks", mock.Anything, mock.Anything, mock.Anything).Return([]models.SourceCheck{
+			{DocumentID: "doc-ok", URL: server.URL + "/ok"},
+			{DocumentID: "doc-gone", URL: server.URL + "/gone"},
+			{DocumentID: "doc-no-head", URL: server.URL + "/no-head"},
+		}, nil).Once()
+		repo.On("RecordSourceStatus", mock.Anything, "doc-ok", http.StatusOK).Return(nil)
+		repo.On("RecordSourceStatus", mock.Anything, "doc-gone", http.StatusNotFound).Return(nil)
+		repo.On("RecordSourceStatus", mock.Anything, "doc-no-head", http.StatusOK).Return(nil)
+		checker := services.NewSourceChecker(&config.FreshnessConfig{
+			SourceCheckInterval: 24 * time.Hour,
+			SourceCheckTimeout:  time.Second,
+		}, repo, services.NewWorkerPool(2), zerolog.Nop())
+
+		checker.Check(t.Context())
+
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Check_Unreachable", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("ClaimSourceChecks", mock.Anything, mock.Anything, mock.Anything).Return([]models.SourceCheck{
+			{DocumentID: "doc-1", URL: "http://127.0.0.1:0/doc"},
+		}, nil).Once()
+		checker := services.NewSourceChecker(&config.FreshnessConfig{
+			SourceCheckInterval: 24 * time.Hour,
+			SourceCheckTimeout:  time.Second,
+		}, repo, services.NewWorkerPool(2), zerolog.Nop())
+
+		checker.Check(t.Context())
+
+		repo.AssertNotCalled(t, "RecordSourceStatus", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"unicode"
+
+	"kb-platform-gateway/internal/models"
+	"kb-platform-gateway/internal/repository"
+)
+
+// Glossary provides the glossary's terms for highlighting. Terms are read
+// on every call, so edits apply to the next answer on every instance.
+type Glossary struct {
+	repo repository.GlossaryRepository
+}
+
+func NewGlossary(repo repository.GlossaryRepository) *Glossary {
+	return &Glossary{repo: repo}
+}
+
+// Matcher returns a matcher for the glossary's terms, or nil if the
+// glossary is empty.
+func (g *Glossary) Matcher(ctx context.Context) (*TermMatcher, error) {
+	terms, err := g.repo.ListAllGlossaryTerms(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(terms) == 0 {
+		return nil, nil
+	}
+	return NewTermMatcher(terms), nil
+}
+
+type glossaryEntry struct {
+	folded []rune
+	term   *models.GlossaryTerm
+}
+
+// TermMatcher finds glossary terms in text, ignoring case. Terms only
+// match whole: "API" is not found in "APIs" or "rapid". Where terms
+// overlap, the longest wins.
+type TermMatcher struct {
+	// byFirst holds the terms by their first folded rune, longest first.
+	byFirst map[rune][]glossaryEntry
+}
+
+func NewTermMatcher(terms []*models.GlossaryTerm) *TermMatcher {
+	m := &TermMatcher{byFirst: make(map[rune][]glossaryEntry)}
+	for _, term := range terms {
+		folded := []rune(term.Term)
+		if len(folded) == 0 {
+			continue
+		}
+		for i, r := range folded {
+			folded[i] = unicode.ToLower(r)
+		}
+		m.byFirst[folded[0]] = append(m.byFirst[folded[0]], glossaryEntry{folded: folded, term: term})
+	}
+	for _, entries := range m.byFirst {
+		sort.SliceStable(entries, func(i, j int) bool {
+			return len(entries[i].folded) > len(entries[j].folded)
+		})
+	}
+	return m
+}
+
+// Match returns the terms found in text starting at offset from or later,
+// and the offset to resume from when text grows. Unless final, text may
+// continue, so a term it ends with, or the start of a longer term, is left
+// for a later call.
+func (m *TermMatcher) Match(text []rune, from int, final bool) ([]models.Highlight, int) {
+	var found []models.Highlight
+	i := from
+	for i < len(text) {
+		// No term starts inside a word.
+		if i > 0 && isWordRune(text[i-1]) && isWordRune(text[i]) {
+			i++
+			continue
+		}
+		entry, end, pending := m.longest(text, i, final)
+		if pending {
+			return found, i
+		}
+		if entry == nil {
+			i++
+			continue
+		}
+		found = append(found, models.Highlight{
+			Start:      i,
+			End:        end,
+			Term:       entry.term.Term,
+			Kind:       models.HighlightKindGlossary,
+			Definition: entry.term.Definition,
+		})
+		i = end
+	}
+	return found, i
+}
+
+// longest returns the longest term at offset i of text and its end, or
+// pending if that cannot be known until text grows.
+func (m *TermMatcher) longest(text []rune, i int, final bool) (*glossaryEntry, int, bool) {
+	entries := m.byFirst[unicode.ToLower(text[i])]
+	for e := range entries {
+		entry := &entries[e]
+		end := i + len(entry.folded)
+		if end > len(text) {
+			if !final && prefixFolded(text[i:], entry.folded) {
+				return nil, 0, true
+			}
+			continue
+		}
+		if !prefixFolded(text[i:end], entry.folded) {
+			continue
+		}
+		if isWordRune(entry.folded[len(entry.folded)-1]) {
+			if end == len(text) && !final {
+				return nil, 0, true
+			}
+			if end < len(text) && isWordRune(text[end]) {
+				continue
+			}
+		}
+		return entry, end, false
+	}
+	return nil, 0, false
+}
+
+// prefixFolded reports whether text, ignoring case, is a prefix of folded.
+func prefixFolded(text, folded []rune) bool {
+	if len(text) > len(folded) {
+		return false
+	}
+	for k, r := range text {
+		if unicode.ToLower(r) != folded[k] {
+			return false
+		}
+	}
+	return true
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,98 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+
+	"kb-platform-gateway/internal/models"
+	repomocks "kb-platform-gateway/internal/repository/mocks"
+	"kb-platform-gateway/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTermMatcher(t *testing.T) {
+	matcher := services.NewTermMatcher([]*models.GlossaryTerm{
+		{Term: "API"},
+		{Term: "API gateway", Definition: "Entry point for clients"},
+		{Term: "C++"},
+		{Term: "Qdrant"},
+	})
+	spans := func(found []models.Highlight) [][2]int {
+		var got [][2]int
+		for _, h := range found {
+			got = append(got, [2]int{h.Start, h.End})
+		}
+		return got
+	}
+
+	t.Run("WholeTermsIgnoringCase", func(t *testing.T) {
+		found, next := matcher.Match([]rune("The api, APIs and rapid QDRANT."), 0, true)
+
+		assert.Equal(t, [][2]int{{4, 7}, {24, 30}}, spans(found))
+		assert.Equal(t, "Qdrant", found[1].Term)
+		assert.Equal(t, models.HighlightKindGlossary, found[1].Kind)
+		assert.Equal(t, 31, next)
+	})
+
+	t.Run("LongestWins", func(t *testing.T) {
+		found, _ := matcher.Match([]rune("An API Gateway routes C++ calls"), 0, true)
+
+		require.Len(t, found, 2)
+		assert.Equal(t, [2]int{3, 14}, [2]int{found[0].Start, found[0].End})
+		assert.Equal(t, "Entry point for clients", found[0].Definition)
+		assert.Equal(t, [2]int{22, 25}, [2]int{found[1].Start, found[1].End})
+	})
+
+	t.Run("CharacterOffsets", func(t *testing.T) {
+		found, _ := matcher.Match([]rune("Überall Qdrant"), 0, true)
+
+		assert.Equal(t, [][2]int{{8, 14}}, spans(found))
+	})
+
+	t.Run("Streaming", func(t *testing.T) {
+		text := []rune("Use the API")
+		found, next := matcher.Match(text, 0, false)
+		assert.Empty(t, found, "the API may still become an API gateway")
+		assert.Equal(t, 8, next)
+
+		text = append(text, []rune(" to call Qdr")...)
+		found, next = matcher.Match(text, next, false)
+		assert.Equal(t, [][2]int{{8, 11}}, spans(found))
+		assert.Equal(t, 20, next)
+
+		text = append(text, []rune("ant")...)
+		found, next = matcher.Match(text, next, false)
+		assert.Empty(t, found, "Qdrant may still run into more letters")
+
+		found, _ = matcher.Match(text, next, true)
+		assert.Equal(t, [][2]int{{20, 26}}, spans(found))
+	})
+}
+
+func TestGlossary(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Matcher_Empty", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("ListAllGlossaryTerms", ctx).Return(nil, nil)
+
+		matcher, err := services.NewGlossary(repo).Matcher(ctx)
+
+		require.NoError(t, err)
+		assert.Nil(t, matcher)
+	})
+
+	t.Run("Matcher", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("ListAllGlossaryTerms", ctx).Return([]*models.GlossaryTerm{{Term: "Qdrant"}}, nil)
+
+		matcher, err := services.NewGlossary(repo).Matcher(ctx)
+
+		require.NoError(t, err)
+		require.NotNil(t, matcher)
+		found, _ := matcher.Match([]rune("qdrant"), 0, true)
+		assert.Len(t, found, 1)
+	})
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"fmt"
+
+	"kb-platform-gateway/internal/config"
+
+	"google.golang.org/grpc"
+	_ "google.golang.org/grpc/health" // enables client-side health checking
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/resolver/manual"
+)
+
+// staticCoreScheme is the resolver scheme used for a static endpoint list.
+const staticCoreScheme = "kbcore"
+
+// coreTarget returns the dial target and resolver options for the core.
+//
+// A static endpoint list is served by a manual resolver; otherwise the host is
+// resolved via DNS so that every A record behind a headless service becomes a
+// separate subchannel for the balancer. The static target's name is only a
+// placeholder, so TLS to static endpoints needs cfg.TLSServerName, which
+// cfg.Validate requires.
+func coreTarget(cfg *config.CoreGRPCConfig) (string, []grpc.DialOption) {
+	if len(cfg.Endpoints) == 0 {
+		return fmt.Sprintf("dns:///%s:%d", cfg.Host, cfg.Port), nil
+	}
+
+	addrs := make([]resolver.Address, len(cfg.Endpoints))
+	for i, endpoint := range cfg.Endpoints {
+		addrs[i] = resolver.Address{Addr: endpoint}
+	}
+
+	r := manual.NewBuilderWithScheme(staticCoreScheme)
+	r.InitialState(resolver.State{Addresses: addrs})
+
+	return staticCoreScheme + ":///python-core", []grpc.DialOption{grpc.WithResolvers(r)}
+}
+
+// coreServiceConfig builds the default service config selecting the load
+// balancing policy and, optionally, health-aware subchannel selection.
+func coreServiceConfig(cfg *config.CoreGRPCConfig) string {
+	policy := cfg.LoadBalancingPolicy
+	if policy == "" {
+		policy = "round_robin"
+	}
+
+	serviceConfig := fmt.Sprintf(`{"loadBalancingConfig":[{%q:{}}]`, policy)
+	if cfg.HealthCheckEnabled {
+		serviceConfig += `,"healthCheckConfig":{"serviceName":""}`
+	}
+	return serviceConfig + "}"
+}
@@ -2,87 +2,230 @@ package services
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"strconv"
+	"strings"
 	"time"
 
+	"kb-platform-gateway/internal/config"
+	"kb-platform-gateway/internal/models"
+
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	pb "github.com/disillusioners/kb-platform-proto/gen/go/kbplatform/v1"
 )
 
+// ErrPromptTemplateUnsupported is returned for queries with a prompt
+// template, which the core's gRPC QueryRequest cannot carry yet.
+var ErrPromptTemplateUnsupported = errors.New("prompt templates are not supported over the gRPC core transport")
+
+// ErrSummaryUnsupported is returned by Summarize, which the core's gRPC
+// service does not offer yet.
+var ErrSummaryUnsupported = errors.New("conversation summaries are not supported over the gRPC core transport")
+
+// ErrEvaluationUnsupported is returned by Evaluate, which the core's gRPC
+// service does not offer yet.
+var ErrEvaluationUnsupported = errors.New("evaluation is not supported over the gRPC core transport")
+
+// collectionMetadataKey carries the Qdrant collection of a query, which the
+// core's gRPC QueryRequest has no field for.
+const collectionMetadataKey = "x-kb-collection"
+
+// languageMetadataKey carries the language filter of a query, likewise
+// missing from QueryRequest.
+const languageMetadataKey = "x-kb-language"
+
+// maxChunksMetadataKey carries the per-document chunk cap of a query,
+// likewise missing from QueryRequest.
+const maxChunksMetadataKey = "x-kb-max-chunks-per-document"
+
+// collectionIDMetadataKey carries the collection of documents a query is
+// restricted to, likewise missing from QueryRequest.
+const collectionIDMetadataKey = "x-kb-collection-id"
+
+// accessGroupsMetadataKey carries the access groups a query may retrieve
+// chunks of, comma-separated, likewise missing from QueryRequest. Without
+// it chunks are retrieved regardless of their access groups; an empty
+// value only retrieves chunks without any.
+const accessGroupsMetadataKey = "x-kb-access-groups"
+
+// historyMetadataKey carries the JSON-encoded history of a long
+// conversation. The -bin suffix lets it hold any bytes.
+const historyMetadataKey = "x-kb-history-bin"
+
 // GrpcCoreClient is a gRPC client for the Python Core service
 type GrpcCoreClient struct {
 	conn   *grpc.ClientConn
 	client pb.KBPlatformServiceClient
+	health healthpb.HealthClient
 }
 
-// NewGrpcCoreClient creates a new gRPC client
-func NewGrpcCoreClient(host string, port int) (*GrpcCoreClient, error) {
-	addr := fmt.Sprintf("%s:%d", host, port)
+// NewGrpcCoreClient creates a new gRPC client.
+//
+// Idempotent calls are retried (and optionally hedged) according to retryCfg.
+// Requests are balanced across every core replica returned by DNS (or listed
+// in cfg.Endpoints) using the configured policy.
+//
+// The connection is established lazily on the first RPC and is re-established
+// automatically with exponential backoff if it drops. Calls wait for the
+// connection to become ready (bounded by their context) instead of failing
+// fast while the core is restarting.
+func NewGrpcCoreClient(cfg *config.CoreGRPCConfig, retryCfg *config.RetryConfig) (*GrpcCoreClient, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	target, resolverOpts := coreTarget(cfg)
+
+	backoffCfg := backoff.DefaultConfig
+	if cfg.MaxBackoff > 0 {
+		backoffCfg.MaxDelay = cfg.MaxBackoff
+	}
 
-	// Use insecure credentials for local development
-	// In production, use secure credentials
-	conn, err := grpc.Dial(addr,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-		grpc.WithBlock(),
-		grpc.WithTimeout(10*time.Second),
+	creds, err := coreTransportCredentials(cfg)
+	if err != nil {
+		return nil, err
+	}
+	policy := newCallPolicy(cfg)
+
+	opts := append(resolverOpts,
+		grpc.WithTransportCredentials(creds),
+		grpc.WithDefaultServiceConfig(coreServiceConfig(cfg)),
+		grpc.WithChainUnaryInterceptor(policy.unaryInterceptor(), newRetryPolicy(retryCfg).unaryInterceptor()),
+		grpc.WithChainStreamInterceptor(policy.streamInterceptor()),
+		grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                cfg.KeepaliveTime,
+			Timeout:             cfg.KeepaliveTimeout,
+			PermitWithoutStream: true,
+		}),
+		grpc.WithConnectParams(grpc.ConnectParams{
+			Backoff:           backoffCfg,
+			MinConnectTimeout: cfg.MinConnectTimeout,
+		}),
+		grpc.WithDefaultCallOptions(grpc.WaitForReady(true)),
 	)
+
+	conn, err := grpc.NewClient(target, opts...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to gRPC server: %w", err)
+		return nil, fmt.Errorf("failed to create gRPC client: %w", err)
 	}
 
 	return &GrpcCoreClient{
 		conn:   conn,
 		client: pb.NewKBPlatformServiceClient(conn),
+		health: healthpb.NewHealthClient(conn),
 	}, nil
 }
 
+// Connect starts connecting in the background instead of waiting for the
+// first RPC. It does not block.
+func (c *GrpcCoreClient) Connect() {
+	c.conn.Connect()
+}
+
+// State returns the current connectivity state of the underlying channel.
+func (c *GrpcCoreClient) State() connectivity.State {
+	return c.conn.GetState()
+}
+
+// IsReady reports whether the channel currently has a ready connection.
+func (c *GrpcCoreClient) IsReady() bool {
+	return c.conn.GetState() == connectivity.Ready
+}
+
 // Close closes the gRPC connection
 func (c *GrpcCoreClient) Close() error {
 	return c.conn.Close()
 }
 
-// QueryStream performs a streaming RAG query
-func (c *GrpcCoreClient) QueryStream(ctx context.Context, query string, conversationID string, topK int) (<-chan *pb.QueryResponse, error) {
-	req := &pb.QueryRequest{
-		Query:          query,
-		ConversationId: conversationID,
-		TopK:           int32(topK),
+// Query performs a streaming RAG query and converts the core's responses
+// into SSE events. A transport failure mid-stream is reported as a final
+// STREAM_ERROR event. The collection, language, chunk cap, document
+// restriction, access groups and history are sent as request metadata.
+func (c *GrpcCoreClient) Query(ctx context.Context, req models.CoreQueryRequest) (<-chan models.SSEEvent, error) {
+	if req.PromptTemplate != "" {
+		return nil, ErrPromptTemplateUnsupported
 	}
 
-	stream, err := c.client.QueryStream(ctx, req)
+	pbReq := &pb.QueryRequest{
+		Query:          req.Query,
+		ConversationId: req.ConversationID,
+		TopK:           int32(req.TopK),
+	}
+	if req.Collection != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, collectionMetadataKey, req.Collection)
+	}
+	if req.Language != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, languageMetadataKey, req.Language)
+	}
+	if req.MaxChunksPerDocument > 0 {
+		ctx = metadata.AppendToOutgoingContext(ctx, maxChunksMetadataKey, strconv.Itoa(req.MaxChunksPerDocument))
+	}
+	if req.CollectionID != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, collectionIDMetadataKey, req.CollectionID)
+	}
+	if req.AccessGroups != nil {
+		ctx = metadata.AppendToOutgoingContext(ctx, accessGroupsMetadataKey, strings.Join(req.AccessGroups, ","))
+	}
+	if req.Context != nil {
+		data, err := json.Marshal(req.Context)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode conversation history: %w", err)
+		}
+		ctx = metadata.AppendToOutgoingContext(ctx, historyMetadataKey, string(data))
+	}
+
+	stream, err := c.client.QueryStream(ctx, pbReq)
 	if err != nil {
 		return nil, fmt.Errorf("failed to start query stream: %w", err)
 	}
 
-	responseChan := make(chan *pb.QueryResponse, 100)
+	eventChan := make(chan models.SSEEvent, 100)
 
 	go func() {
-		defer close(responseChan)
+		defer close(eventChan)
 		defer stream.CloseSend()
 
+		// The response is reused for every message; its fields are
+		// copied out before the next is received.
+		resp := new(pb.QueryResponse)
 		for {
-			resp, err := stream.Recv()
+			resp.Reset()
+			err := stream.RecvMsg(resp)
 			if err == io.EOF {
 				return
 			}
 			if err != nil {
-				log.Printf("Error receiving from stream: %v", err)
+				if ctx.Err() == nil {
+					sendEvent(ctx, eventChan, models.SSEEvent{
+						Type:    "error",
+						Code:    "STREAM_ERROR",
+						Message: err.Error(),
+					})
+				}
+				return
+			}
+			if !sendEvent(ctx, eventChan, convertProtoEventToSSE(resp)) {
 				return
 			}
-			responseChan <- resp
 		}
 	}()
 
-	return responseChan, nil
+	return eventChan, nil
 }
 
 // GetDocument retrieves a document by ID
-func (c *GrpcCoreClient) GetDocument(ctx context.Context, documentID string) (*pb.Document, error) {
+func (c *GrpcCoreClient) GetDocument(ctx context.Context, documentID string) (*models.Document, error) {
 	req := &pb.GetDocumentRequest{
 		DocumentId: documentID,
 	}
@@ -92,7 +235,7 @@ func (c *GrpcCoreClient) GetDocument(ctx context.Context, documentID string) (*p
 		return nil, fmt.Errorf("failed to get document: %w", err)
 	}
 
-	return resp, nil
+	return convertProtoDocumentToModel(resp), nil
 }
 
 // DeleteDocumentVectors deletes document vectors from Qdrant
@@ -110,7 +253,7 @@ func (c *GrpcCoreClient) DeleteDocumentVectors(ctx context.Context, documentID s
 }
 
 // GetConversation retrieves a conversation by ID
-func (c *GrpcCoreClient) GetConversation(ctx context.Context, conversationID string) (*pb.Conversation, error) {
+func (c *GrpcCoreClient) GetConversation(ctx context.Context, conversationID string) (*models.Conversation, error) {
 	req := &pb.GetConversationRequest{
 		ConversationId: conversationID,
 	}
@@ -120,11 +263,11 @@ func (c *GrpcCoreClient) GetConversation(ctx context.Context, conversationID str
 		return nil, fmt.Errorf("failed to get conversation: %w", err)
 	}
 
-	return resp, nil
+	return convertProtoConversationToModel(resp), nil
 }
 
 // GetConversationMessages retrieves messages for a conversation
-func (c *GrpcCoreClient) GetConversationMessages(ctx context.Context, conversationID string) ([]*pb.Message, error) {
+func (c *GrpcCoreClient) GetConversationMessages(ctx context.Context, conversationID string) ([]*models.Message, error) {
 	req := &pb.GetConversationMessagesRequest{
 		ConversationId: conversationID,
 	}
@@ -134,11 +277,16 @@ func (c *GrpcCoreClient) GetConversationMessages(ctx context.Context, conversati
 		return nil, fmt.Errorf("failed to get conversation messages: %w", err)
 	}
 
-	return resp.Messages, nil
+	messages := make([]*models.Message, len(resp.Messages))
+	for i, msg := range resp.Messages {
+		messages[i] = convertProtoMessageToModel(msg)
+	}
+
+	return messages, nil
 }
 
 // SaveMessage saves a message to a conversation
-func (c *GrpcCoreClient) SaveMessage(ctx context.Context, conversationID string, role string, content string, metadata map[string]string) (*pb.Message, error) {
+func (c *GrpcCoreClient) SaveMessage(ctx context.Context, conversationID string, role string, content string, metadata map[string]string) (*models.Message, error) {
 	req := &pb.SaveMessageRequest{
 		ConversationId: conversationID,
 		Role:           role,
@@ -151,43 +299,102 @@ func (c *GrpcCoreClient) SaveMessage(ctx context.Context, conversationID string,
 		return nil, fmt.Errorf("failed to save message: %w", err)
 	}
 
-	return resp, nil
+	return convertProtoMessageToModel(resp), nil
+}
+
+// Evaluate always fails with ErrEvaluationUnsupported.
+func (c *GrpcCoreClient) Evaluate(ctx context.Context, question, expectedAnswer, answer string) (*models.EvaluationScore, error) {
+	return nil, ErrEvaluationUnsupported
 }
 
-// HealthCheck performs a health check on the Python Core service
-func (c *GrpcCoreClient) HealthCheck(ctx context.Context) error {
+// Summarize always fails with ErrSummaryUnsupported.
+func (c *GrpcCoreClient) Summarize(ctx context.Context, summary string, messages []*models.Message) (string, error) {
+	return "", ErrSummaryUnsupported
+}
+
+// HealthCheck performs a health check on the Python Core service using the
+// standard grpc.health.v1 protocol. If the core does not implement the health
+// service, the connectivity state of the channel is used instead.
+func (c *GrpcCoreClient) HealthCheck(ctx context.Context) (map[string]string, error) {
+	// Report a broken channel immediately rather than waiting for the
+	// probe RPC to time out while the client is reconnecting.
+	if state := c.State(); state == connectivity.TransientFailure || state == connectivity.Shutdown {
+		return nil, fmt.Errorf("health check failed: connection is %s", state)
+	}
+
 	// Create a timeout context for health check
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	// Try to get document metadata with an empty ID to check connectivity
-	// This will fail with a not found error if the service is running
-	md := metadata.New(map[string]string{
-		"health-check": "true",
-	})
-	ctx = metadata.NewOutgoingContext(ctx, md)
-
-	_, err := c.client.GetDocument(ctx, &pb.GetDocumentRequest{DocumentId: "health-check"})
-	if err != nil {
-		// Not found is expected for a health check - means service is running
-		if contains(err.Error(), "not found") || contains(err.Error(), "health-check") {
-			return nil
+	resp, err := c.health.Check(ctx, &healthpb.HealthCheckRequest{})
+	if status.Code(err) == codes.Unimplemented {
+		if state := c.State(); state != connectivity.Ready {
+			return nil, fmt.Errorf("health check failed: connection is %s", state)
 		}
-		return fmt.Errorf("health check failed: %w", err)
+		return map[string]string{"python_core": "ok"}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("health check failed: %w", err)
 	}
 
-	return nil
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		return nil, fmt.Errorf("health check failed: core is %s", resp.GetStatus())
+	}
+
+	return map[string]string{"python_core": "ok"}, nil
 }
 
-func contains(s, substr string) bool {
-	return len(s) >= len(substr) && (s == substr || len(s) > 0 && containsHelper(s, substr))
+func convertProtoEventToSSE(resp *pb.QueryResponse) models.SSEEvent {
+	return models.SSEEvent{
+		Type:    resp.GetType(),
+		ID:      resp.GetId(),
+		Content: resp.GetContent(),
+		Code:    resp.GetErrorCode(),
+		Message: resp.GetMessage(),
+	}
 }
 
-func containsHelper(s, substr string) bool {
-	for i := 0; i <= len(s)-len(substr); i++ {
-		if s[i:i+len(substr)] == substr {
-			return true
-		}
+func convertProtoDocumentToModel(doc *pb.Document) *models.Document {
+	result := &models.Document{
+		ID:           doc.GetId(),
+		Filename:     doc.GetFilename(),
+		FileSize:     doc.GetFileSize(),
+		Status:       doc.GetStatus(),
+		ErrorMessage: doc.GetErrorMessage(),
+		Metadata:     doc.GetMetadata(),
+		CreatedAt:    timestampFromProto(doc.GetCreatedAt()),
+	}
+	if indexedAt := timestampFromProto(doc.GetIndexedAt()); !indexedAt.IsZero() {
+		result.IndexedAt = &indexedAt
+	}
+	return result
+}
+
+func convertProtoConversationToModel(conv *pb.Conversation) *models.Conversation {
+	return &models.Conversation{
+		ID:           conv.GetId(),
+		MessageCount: int(conv.GetMessageCount()),
+		CreatedAt:    timestampFromProto(conv.GetCreatedAt()),
+		UpdatedAt:    timestampFromProto(conv.GetUpdatedAt()),
+	}
+}
+
+func convertProtoMessageToModel(msg *pb.Message) *models.Message {
+	return &models.Message{
+		ID:             msg.GetId(),
+		ConversationID: msg.GetConversationId(),
+		Role:           msg.GetRole(),
+		Content:        msg.GetContent(),
+		Metadata:       msg.GetMetadata(),
+		CreatedAt:      timestampFromProto(msg.GetCreatedAt()),
+	}
+}
+
+// timestampFromProto reads an unset or zero timestamp as an unknown (zero)
+// time rather than the Unix epoch, mirroring the gateway's own gRPC server.
+func timestampFromProto(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil || (ts.GetSeconds() == 0 && ts.GetNanos() == 0) {
+		return time.Time{}
 	}
-	return false
+	return ts.AsTime()
 }
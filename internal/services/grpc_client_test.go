@@ -0,0 +1,509 @@
+package services_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"kb-platform-gateway/internal/config"
+	"kb-platform-gateway/internal/models"
+	"kb-platform-gateway/internal/services"
+
+	pb "github.com/disillusioners/kb-platform-proto/gen/go/kbplatform/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestConvertProtoToModel(t *testing.T) {
+	created := time.Date(2026, 3, 12, 9, 30, 15, 250000000, time.UTC)
+	indexed := created.Add(90 * time.Second)
+
+	t.Run("Document", func(t *testing.T) {
+		tests := []struct {
+			name string
+			doc  *pb.Document
+			want *models.Document
+		}{
+			{
+				name: "all fields",
+				doc: &pb.Document{
+					Id: "doc-1", Filename: "guide.pdf", FileSize: 1024, Status: "complete", ErrorMessage: "",
+					Metadata:  map[string]string{"team": "hr"},
+					CreatedAt: timestamppb.New(created), IndexedAt: timestamppb.New(indexed),
+				},
+				want: &models.Document{
+					ID: "doc-1", Filename: "guide.pdf", FileSize: 1024, Status: "complete",
+					Metadata:  map[string]string{"team": "hr"},
+					CreatedAt: created, IndexedAt: &indexed,
+				},
+			},
+			{
+				name: "unset timestamps",
+				doc:  &pb.Document{Id: "doc-1", Status: "pending"},
+				want: &models.Document{ID: "doc-1", Status: "pending"},
+			},
+			{
+				name: "zero timestamps",
+				doc:  &pb.Document{Id: "doc-1", CreatedAt: &timestamppb.Timestamp{}, IndexedAt: &timestamppb.Timestamp{}},
+				want: &models.Document{ID: "doc-1"},
+			},
+			{
+				name: "nil message",
+				doc:  nil,
+				want: &models.Document{},
+			},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				assert.Equal(t, tt.want, services.ConvertProtoDocumentToModel(tt.doc))
+			})
+		}
+	})
+
+	t.Run("Conversation", func(t *testing.T) {
+		tests := []struct {
+			name string
+			conv *pb.Conversation
+			want *models.Conversation
+		}{
+			{
+				name: "all fields",
+				conv: &pb.Conversation{Id: "conv-1", MessageCount: 4, CreatedAt: timestamppb.New(created), UpdatedAt: timestamppb.New(indexed)},
+				want: &models.Conversation{ID: "conv-1", MessageCount: 4, CreatedAt: created, UpdatedAt: indexed},
+			},
+			{
+				name: "unset timestamps",
+				conv: &pb.Conversation{Id: "conv-1"},
+				want: &models.Conversation{ID: "conv-1"},
+			},
+			{
+				name: "zero timestamps",
+				conv: &pb.Conversation{Id: "conv-1", CreatedAt: &timestamppb.Timestamp{}, UpdatedAt: &timestamppb.Timestamp{}},
+				want: &models.Conversation{ID: "conv-1"},
+			},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				assert.Equal(t, tt.want, services.ConvertProtoConversationToModel(tt.conv))
+			})
+		}
+	})
+
+	t.Run("Message", func(t *testing.T) {
+		tests := []struct {
+			name string
+			msg  *pb.Message
+			want *models.Message
+		}{
+			{
+				name: "all fields",
+				msg: &pb.Message{
+					Id: "msg-1", ConversationId: "conv-1", Role: "assistant", Content: "Hello",
+					Metadata: map[string]string{"query_id": "q-1"}, CreatedAt: timestamppb.New(created),
+				},
+				want: &models.Message{
+					ID: "msg-1", ConversationID: "conv-1", Role: "assistant", Content: "Hello",
+					Metadata: map[string]string{"query_id": "q-1"}, CreatedAt: created,
+				},
+			},
+			{
+				name: "missing optional fields",
+				msg:  &pb.Message{Id: "msg-1", ConversationId: "conv-1", Role: "user"},
+				want: &models.Message{ID: "msg-1", ConversationID: "conv-1", Role: "user"},
+			},
+			{
+				name: "zero timestamp",
+				msg:  &pb.Message{Id: "msg-1", CreatedAt: &timestamppb.Timestamp{}},
+				want: &models.Message{ID: "msg-1"},
+			},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				assert.Equal(t, tt.want, services.ConvertProtoMessageToModel(tt.msg))
+			})
+		}
+	})
+
+	t.Run("Event", func(t *testing.T) {
+		event := services.ConvertProtoEventToSSE(&pb.QueryResponse{Type: "error", Id: "q-1", ErrorCode: "CORE_ERROR", Message: "boom"})
+
+		assert.Equal(t, models.SSEEvent{Type: "error", ID: "q-1", Code: "CORE_ERROR", Message: "boom"}, event)
+	})
+}
+
+func TestGrpcCoreBalancing(t *testing.T) {
+	t.Run("Target_DNS", func(t *testing.T) {
+		target, opts := services.CoreTarget(&config.CoreGRPCConfig{Host: "core", Port: 50051})
+
+		assert.Equal(t, "dns:///core:50051", target)
+		assert.Empty(t, opts)
+	})
+
+	t.Run("Target_StaticEndpoints", func(t *testing.T) {
+		target, opts := services.CoreTarget(&config.CoreGRPCConfig{Endpoints: []string{"10.0.0.1:50051", "10.0.0.2:50051"}})
+
+		assert.Equal(t, "kbcore:///python-core", target)
+		assert.Len(t, opts, 1)
+	})
+
+	t.Run("ServiceConfig", func(t *testing.T) {
+		assert.JSONEq(t, `{"loadBalancingConfig":[{"round_robin":{}}]}`, services.CoreServiceConfig(&config.CoreGRPCConfig{}))
+		assert.JSONEq(t,
+			`{"loadBalancingConfig":[{"pick_first":{}}],"healthCheckConfig":{"serviceName":""}}`,
+			services.CoreServiceConfig(&config.CoreGRPCConfig{LoadBalancingPolicy: "pick_first", HealthCheckEnabled: true}))
+	})
+
+	t.Run("NewClient_RejectsInvalidConfig", func(t *testing.T) {
+		for name, cfg := range map[string]config.CoreGRPCConfig{
+			"UnknownPolicy":        {Host: "core", Port: 50051, LoadBalancingPolicy: "least_request"},
+			"StaticTLSWithoutName": {Endpoints: []string{"10.0.0.1:50051"}, TLSEnabled: true},
+			"PolicyTypo":           {Endpoints: []string{"10.0.0.1:50051"}, LoadBalancingPolicy: "roundrobin"},
+		} {
+			_, err := services.NewGrpcCoreClient(&cfg, &config.RetryConfig{})
+
+			assert.Error(t, err, name)
+		}
+	})
+
+	t.Run("NewClient_StaticTLSWithServerName", func(t *testing.T) {
+		client, err := services.NewGrpcCoreClient(&config.CoreGRPCConfig{
+			Endpoints:     []string{"10.0.0.1:50051"},
+			TLSEnabled:    true,
+			TLSServerName: "core.internal",
+		}, &config.RetryConfig{})
+
+		require.NoError(t, err)
+		client.Close()
+	})
+}
+
+// fakeCoreService is a KBPlatformServiceClient recording the context and
+// request of the last call and answering with its fields, or err.
+type fakeCoreService struct {
+	ctx     context.Context
+	request any
+	err     error
+
+	document     *pb.Document
+	conversation *pb.Conversation
+	messages     []*pb.Message
+	message      *pb.Message
+	stream       *fakeQueryStream
+}
+
+func (f *fakeCoreService) record(ctx context.Context, req any) {
+	f.ctx, f.request = ctx, req
+}
+
+func (f *fakeCoreService) QueryStream(ctx context.Context, in *pb.QueryRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[pb.QueryResponse], error) {
+	f.record(ctx, in)
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.stream, nil
+}
+
+func (f *fakeCoreService) GetDocument(ctx context.Context, in *pb.GetDocumentRequest, opts ...grpc.CallOption) (*pb.Document, error) {
+	f.record(ctx, in)
+	return f.document, f.err
+}
+
+func (f *fakeCoreService) DeleteDocumentVectors(ctx context.Context, in *pb.DeleteDocumentVectorsRequest, opts ...grpc.CallOption) (*pb.DeleteDocumentVectorsResponse, error) {
+	f.record(ctx, in)
+	return &pb.DeleteDocumentVectorsResponse{}, f.err
+}
+
+func (f *fakeCoreService) GetConversation(ctx context.Context, in *pb.GetConversationRequest, opts ...grpc.CallOption) (*pb.Conversation, error) {
+	f.record(ctx, in)
+	return f.conversation, f.err
+}
+
+func (f *fakeCoreService) GetConversationMessages(ctx context.Context, in *pb.GetConversationMessagesRequest, opts ...grpc.CallOption) (*pb.GetConversationMessagesResponse, error) {
+	f.record(ctx, in)
+	return &pb.GetConversationMessagesResponse{Messages: f.messages}, f.err
+}
+
+func (f *fakeCoreService) SaveMessage(ctx context.Context, in *pb.SaveMessageRequest, opts ...grpc.CallOption) (*pb.Message, error) {
+	f.record(ctx, in)
+	return f.message, f.err
+}
+
+// fakeQueryStream streams responses, then fails with err, or ends.
+type fakeQueryStream struct {
+	grpc.ServerStreamingClient[pb.QueryResponse]
+	responses []*pb.QueryResponse
+	err       error
+}
+
+func (s *fakeQueryStream) RecvMsg(m any) error {
+	if len(s.responses) == 0 {
+		if s.err != nil {
+			return s.err
+		}
+		return io.EOF
+	}
+	next, resp := s.responses[0], m.(*pb.QueryResponse)
+	s.responses = s.responses[1:]
+	resp.Type, resp.Id, resp.Content, resp.ErrorCode, resp.Message = next.Type, next.Id, next.Content, next.ErrorCode, next.Message
+	return nil
+}
+
+func (s *fakeQueryStream) CloseSend() error { return nil }
+
+// fakeHealth answers health checks with resp, or err.
+type fakeHealth struct {
+	healthpb.HealthClient
+	resp *healthpb.HealthCheckResponse
+	err  error
+}
+
+func (h fakeHealth) Check(ctx context.Context, in *healthpb.HealthCheckRequest, opts ...grpc.CallOption) (*healthpb.HealthCheckResponse, error) {
+	return h.resp, h.err
+}
+
+func newFakeGrpcCoreClient(t *testing.T, core *fakeCoreService, health healthpb.HealthClient) *services.GrpcCoreClient {
+	t.Helper()
+	client, err := services.NewGrpcCoreClientFrom(core, health)
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func collectEvents(events <-chan models.SSEEvent) []models.SSEEvent {
+	var collected []models.SSEEvent
+	for event := range events {
+		collected = append(collected, event)
+	}
+	return collected
+}
+
+func TestGrpcCoreClient(t *testing.T) {
+	ctx := context.Background()
+	created := time.Date(2026, 3, 12, 9, 30, 0, 0, time.UTC)
+
+	t.Run("Query_MapsRequest", func(t *testing.T) {
+		core := &fakeCoreService{stream: &fakeQueryStream{responses: []*pb.QueryResponse{
+			{Type: "chunk", Content: "Hello"},
+			{Type: "end", Id: "msg-1"},
+		}}}
+		client := newFakeGrpcCoreClient(t, core, nil)
+		history := &models.ConversationContext{Summary: "Earlier.", Messages: []models.Message{{Role: "user", Content: "Hi"}}}
+
+		events, err := client.Query(ctx, models.CoreQueryRequest{
+			Query:                "What is the leave policy?",
+			ConversationID:       "conv-1",
+			TopK:                 5,
+			Collection:           "documents_v2",
+			Language:             "en",
+			MaxChunksPerDocument: 3,
+			CollectionID:         "col-1",
+			AccessGroups:         []string{"hr", "legal"},
+			Context:              history,
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, []models.SSEEvent{{Type: "chunk", Content: "Hello"}, {Type: "end", ID: "msg-1"}}, collectEvents(events))
+		req := core.request.(*pb.QueryRequest)
+		assert.Equal(t, "What is the leave policy?", req.Query)
+		assert.Equal(t, "conv-1", req.ConversationId)
+		assert.Equal(t, int32(5), req.TopK)
+		md, _ := metadata.FromOutgoingContext(core.ctx)
+		assert.Equal(t, []string{"documents_v2"}, md.Get("x-kb-collection"))
+		assert.Equal(t, []string{"en"}, md.Get("x-kb-language"))
+		assert.Equal(t, []string{"3"}, md.Get("x-kb-max-chunks-per-document"))
+		assert.Equal(t, []string{"col-1"}, md.Get("x-kb-collection-id"))
+		assert.Equal(t, []string{"hr,legal"}, md.Get("x-kb-access-groups"))
+		require.Len(t, md.Get("x-kb-history-bin"), 1)
+		var sent models.ConversationContext
+		require.NoError(t, json.Unmarshal([]byte(md.Get("x-kb-history-bin")[0]), &sent))
+		assert.Equal(t, *history, sent)
+	})
+
+	t.Run("Query_OptionalMetadata", func(t *testing.T) {
+		// Unset options are left out; an empty access group list is not.
+		core := &fakeCoreService{stream: &fakeQueryStream{}}
+		client := newFakeGrpcCoreClient(t, core, nil)
+
+		events, err := client.Query(ctx, models.CoreQueryRequest{Query: "hello", AccessGroups: []string{}})
+
+		require.NoError(t, err)
+		assert.Empty(t, collectEvents(events))
+		md, _ := metadata.FromOutgoingContext(core.ctx)
+		assert.Equal(t, metadata.MD{"x-kb-access-groups": {""}}, md)
+	})
+
+	t.Run("Query_PromptTemplateUnsupported", func(t *testing.T) {
+		core := &fakeCoreService{}
+		client := newFakeGrpcCoreClient(t, core, nil)
+
+		_, err := client.Query(ctx, models.CoreQueryRequest{Query: "hello", PromptTemplate: "Answer briefly."})
+
+		assert.ErrorIs(t, err, services.ErrPromptTemplateUnsupported)
+		assert.Nil(t, core.request, "the core is not called")
+	})
+
+	t.Run("Query_StreamNotStarted", func(t *testing.T) {
+		core := &fakeCoreService{err: status.Error(codes.Unavailable, "core is restarting")}
+		client := newFakeGrpcCoreClient(t, core, nil)
+
+		_, err := client.Query(ctx, models.CoreQueryRequest{Query: "hello"})
+
+		assert.ErrorContains(t, err, "failed to start query stream")
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+	})
+
+	t.Run("Query_StreamBroken", func(t *testing.T) {
+		core := &fakeCoreService{stream: &fakeQueryStream{
+			responses: []*pb.QueryResponse{{Type: "chunk", Content: "Hel"}},
+			err:       status.Error(codes.Unavailable, "connection reset"),
+		}}
+		client := newFakeGrpcCoreClient(t, core, nil)
+
+		events, err := client.Query(ctx, models.CoreQueryRequest{Query: "hello"})
+
+		require.NoError(t, err)
+		collected := collectEvents(events)
+		require.Len(t, collected, 2)
+		assert.Equal(t, models.SSEEvent{Type: "chunk", Content: "Hel"}, collected[0])
+		assert.Equal(t, "error", collected[1].Type)
+		assert.Equal(t, "STREAM_ERROR", collected[1].Code)
+		assert.Contains(t, collected[1].Message, "connection reset")
+	})
+
+	t.Run("GetDocument", func(t *testing.T) {
+		core := &fakeCoreService{document: &pb.Document{Id: "doc-1", Filename: "a.pdf", Status: "complete", FileSize: 2048, CreatedAt: timestamppb.New(created)}}
+		client := newFakeGrpcCoreClient(t, core, nil)
+
+		doc, err := client.GetDocument(ctx, "doc-1")
+
+		require.NoError(t, err)
+		assert.Equal(t, "doc-1", core.request.(*pb.GetDocumentRequest).DocumentId)
+		assert.Equal(t, &models.Document{ID: "doc-1", Filename: "a.pdf", Status: "complete", FileSize: 2048, CreatedAt: created}, doc)
+	})
+
+	t.Run("DeleteDocumentVectors", func(t *testing.T) {
+		core := &fakeCoreService{}
+		client := newFakeGrpcCoreClient(t, core, nil)
+
+		err := client.DeleteDocumentVectors(ctx, "doc-1")
+
+		require.NoError(t, err)
+		assert.Equal(t, "doc-1", core.request.(*pb.DeleteDocumentVectorsRequest).DocumentId)
+	})
+
+	t.Run("GetConversation", func(t *testing.T) {
+		core := &fakeCoreService{conversation: &pb.Conversation{Id: "conv-1", MessageCount: 4, CreatedAt: timestamppb.New(created), UpdatedAt: timestamppb.New(created)}}
+		client := newFakeGrpcCoreClient(t, core, nil)
+
+		conv, err := client.GetConversation(ctx, "conv-1")
+
+		require.NoError(t, err)
+		assert.Equal(t, "conv-1", core.request.(*pb.GetConversationRequest).ConversationId)
+		assert.Equal(t, &models.Conversation{ID: "conv-1", MessageCount: 4, CreatedAt: created, UpdatedAt: created}, conv)
+	})
+
+	t.Run("GetConversationMessages", func(t *testing.T) {
+		core := &fakeCoreService{messages: []*pb.Message{
+			{Id: "msg-1", ConversationId: "conv-1", Role: "user", Content: "Hi"},
+			{Id: "msg-2", ConversationId: "conv-1", Role: "assistant", Content: "Hello"},
+		}}
+		client := newFakeGrpcCoreClient(t, core, nil)
+
+		messages, err := client.GetConversationMessages(ctx, "conv-1")
+
+		require.NoError(t, err)
+		assert.Equal(t, "conv-1", core.request.(*pb.GetConversationMessagesRequest).ConversationId)
+		require.Len(t, messages, 2)
+		assert.Equal(t, "msg-1", messages[0].ID)
+		assert.Equal(t, "assistant", messages[1].Role)
+	})
+
+	t.Run("SaveMessage", func(t *testing.T) {
+		core := &fakeCoreService{message: &pb.Message{Id: "msg-1", ConversationId: "conv-1", Role: "user", Content: "Hi", Metadata: map[string]string{"source": "slack"}}}
+		client := newFakeGrpcCoreClient(t, core, nil)
+
+		msg, err := client.SaveMessage(ctx, "conv-1", "user", "Hi", map[string]string{"source": "slack"})
+
+		require.NoError(t, err)
+		req := core.request.(*pb.SaveMessageRequest)
+		assert.Equal(t, "conv-1", req.ConversationId)
+		assert.Equal(t, "user", req.Role)
+		assert.Equal(t, "Hi", req.Content)
+		assert.Equal(t, map[string]string{"source": "slack"}, req.Metadata)
+		assert.Equal(t, "msg-1", msg.ID)
+	})
+
+	t.Run("Errors_KeepStatus", func(t *testing.T) {
+		core := &fakeCoreService{err: status.Error(codes.NotFound, "no such thing")}
+		client := newFakeGrpcCoreClient(t, core, nil)
+
+		calls := map[string]func() error{
+			"failed to get document":            func() error { _, err := client.GetDocument(ctx, "doc-1"); return err },
+			"failed to delete document vectors": func() error { return client.DeleteDocumentVectors(ctx, "doc-1") },
+			"failed to get conversation":        func() error { _, err := client.GetConversation(ctx, "conv-1"); return err },
+			"failed to get conversation messages": func() error {
+				_, err := client.GetConversationMessages(ctx, "conv-1")
+				return err
+			},
+			"failed to save message": func() error { _, err := client.SaveMessage(ctx, "conv-1", "user", "Hi", nil); return err },
+		}
+		for message, call := range calls {
+			err := call()
+
+			assert.ErrorContains(t, err, message)
+			assert.Equal(t, codes.NotFound, status.Code(err), message)
+		}
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		client := newFakeGrpcCoreClient(t, &fakeCoreService{}, nil)
+
+		_, err := client.Summarize(ctx, "", nil)
+		assert.ErrorIs(t, err, services.ErrSummaryUnsupported)
+		_, err = client.Evaluate(ctx, "q", "e", "a")
+		assert.ErrorIs(t, err, services.ErrEvaluationUnsupported)
+	})
+
+	t.Run("HealthCheck_Serving", func(t *testing.T) {
+		client := newFakeGrpcCoreClient(t, &fakeCoreService{}, fakeHealth{resp: &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}})
+
+		deps, err := client.HealthCheck(ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"python_core": "ok"}, deps)
+	})
+
+	t.Run("HealthCheck_NotServing", func(t *testing.T) {
+		client := newFakeGrpcCoreClient(t, &fakeCoreService{}, fakeHealth{resp: &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_NOT_SERVING}})
+
+		_, err := client.HealthCheck(ctx)
+
+		assert.ErrorContains(t, err, "NOT_SERVING")
+	})
+
+	t.Run("HealthCheck_Fails", func(t *testing.T) {
+		client := newFakeGrpcCoreClient(t, &fakeCoreService{}, fakeHealth{err: status.Error(codes.DeadlineExceeded, "timed out")})
+
+		_, err := client.HealthCheck(ctx)
+
+		assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	})
+
+	t.Run("HealthCheck_UnimplementedFallsBackToState", func(t *testing.T) {
+		// Without the health service, a channel that is not ready is
+		// unhealthy.
+		client := newFakeGrpcCoreClient(t, &fakeCoreService{}, fakeHealth{err: status.Error(codes.Unimplemented, "unknown service")})
+
+		_, err := client.HealthCheck(ctx)
+
+		assert.ErrorContains(t, err, "connection is IDLE")
+	})
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"kb-platform-gateway/internal/config"
+	"kb-platform-gateway/internal/requestid"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+// coreTransportCredentials builds plaintext, TLS or mTLS credentials from cfg.
+func coreTransportCredentials(cfg *config.CoreGRPCConfig) (credentials.TransportCredentials, error) {
+	if !cfg.TLSEnabled {
+		return insecure.NewCredentials(), nil
+	}
+
+	tlsCfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: cfg.TLSServerName,
+	}
+
+	if cfg.TLSCAFile != "" {
+		pool, err := loadCertPool(cfg.TLSCAFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsCfg.RootCAs = pool
+	}
+
+	if cfg.TLSCertFile != "" || cfg.TLSKeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.TLSCertFile, cfg.TLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return credentials.NewTLS(tlsCfg), nil
+}
+
+// loadCertPool reads PEM certificates from path into a new pool.
+func loadCertPool(path string) (*x509.CertPool, error) {
+	caPEM, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("no certificates found in CA file %s", path)
+	}
+	return pool, nil
+}
+
+// callPolicy applies per-method deadlines and outgoing metadata to core calls.
+type callPolicy struct {
+	defaultTimeout time.Duration
+	streamTimeout  time.Duration
+	methodTimeouts map[string]time.Duration
+	authToken      string
+}
+
+func newCallPolicy(cfg *config.CoreGRPCConfig) *callPolicy {
+	return &callPolicy{
+		defaultTimeout: cfg.DefaultTimeout,
+		streamTimeout:  cfg.StreamTimeout,
+		methodTimeouts: cfg.MethodTimeouts,
+		authToken:      cfg.AuthToken,
+	}
+}
+
+// timeoutFor returns the deadline to apply to fullMethod, or 0 for none.
+func (p *callPolicy) timeoutFor(fullMethod string, streaming bool) time.Duration {
+	name := fullMethod[strings.LastIndex(fullMethod, "/")+1:]
+	if timeout, ok := p.methodTimeouts[name]; ok {
+		return timeout
+	}
+	if streaming {
+		return p.streamTimeout
+	}
+	return p.defaultTimeout
+}
+
+// outgoingContext attaches the request ID and auth token to ctx.
+func (p *callPolicy) outgoingContext(ctx context.Context) context.Context {
+	pairs := make([]string, 0, 4)
+	if id := requestid.FromContext(ctx); id != "" {
+		pairs = append(pairs, "x-request-id", id)
+	}
+	if p.authToken != "" {
+		pairs = append(pairs, "authorization", "Bearer "+p.authToken)
+	}
+	if len(pairs) == 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, pairs...)
+}
+
+func (p *callPolicy) unaryInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if _, ok := ctx.Deadline(); !ok {
+			if timeout := p.timeoutFor(method, false); timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, timeout)
+				defer cancel()
+			}
+		}
+		return invoker(p.outgoingContext(ctx), method, req, reply, cc, opts...)
+	}
+}
+
+func (p *callPolicy) streamInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		cancel := context.CancelFunc(func() {})
+		if _, ok := ctx.Deadline(); !ok {
+			if timeout := p.timeoutFor(method, true); timeout > 0 {
+				ctx, cancel = context.WithTimeout(ctx, timeout)
+			}
+		}
+
+		stream, err := streamer(p.outgoingContext(ctx), desc, cc, method, opts...)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		return &cancelOnEndStream{ClientStream: stream, cancel: cancel}, nil
+	}
+}
+
+// cancelOnEndStream releases the stream's deadline timer once the stream ends.
+type cancelOnEndStream struct {
+	grpc.ClientStream
+	cancel context.CancelFunc
+}
+
+func (s *cancelOnEndStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err != nil {
+		s.cancel()
+	}
+	return err
+}
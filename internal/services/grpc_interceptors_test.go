@@ -0,0 +1,138 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"kb-platform-gateway/internal/config"
+	"kb-platform-gateway/internal/requestid"
+	"kb-platform-gateway/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const coreMethodPrefix = "/kbplatform.v1.KBPlatformService/"
+
+// recordingInvoker is a grpc.UnaryInvoker recording the context of the
+// call and returning err.
+func recordingInvoker(ctx *context.Context, err error) grpc.UnaryInvoker {
+	return func(callCtx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		*ctx = callCtx
+		return err
+	}
+}
+
+// endedStream is a client stream whose messages have all been received.
+type endedStream struct {
+	grpc.ClientStream
+}
+
+func (endedStream) RecvMsg(m any) error { return io.EOF }
+
+func TestCoreCallPolicy(t *testing.T) {
+	cfg := &config.CoreGRPCConfig{
+		DefaultTimeout: time.Minute,
+		StreamTimeout:  time.Hour,
+		MethodTimeouts: map[string]time.Duration{"GetDocument": time.Second},
+		AuthToken:      "secret",
+	}
+
+	t.Run("Unary_MethodTimeoutAndMetadata", func(t *testing.T) {
+		var callCtx context.Context
+		ctx := requestid.NewContext(context.Background(), "req-1")
+
+		err := services.CoreUnaryInterceptor(cfg)(ctx, coreMethodPrefix+"GetDocument", nil, nil, nil, recordingInvoker(&callCtx, nil))
+
+		require.NoError(t, err)
+		deadline, ok := callCtx.Deadline()
+		require.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(time.Second), deadline, 100*time.Millisecond)
+		md, _ := metadata.FromOutgoingContext(callCtx)
+		assert.Equal(t, []string{"Bearer secret"}, md.Get("authorization"))
+		assert.Equal(t, []string{"req-1"}, md.Get("x-request-id"))
+	})
+
+	t.Run("Unary_DefaultTimeout", func(t *testing.T) {
+		var callCtx context.Context
+
+		err := services.CoreUnaryInterceptor(cfg)(context.Background(), coreMethodPrefix+"ListDocuments", nil, nil, nil, recordingInvoker(&callCtx, nil))
+
+		require.NoError(t, err)
+		deadline, ok := callCtx.Deadline()
+		require.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, 100*time.Millisecond)
+	})
+
+	t.Run("Unary_KeepsCallerDeadline", func(t *testing.T) {
+		var callCtx context.Context
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+		want, _ := ctx.Deadline()
+
+		err := services.CoreUnaryInterceptor(cfg)(ctx, coreMethodPrefix+"GetDocument", nil, nil, nil, recordingInvoker(&callCtx, nil))
+
+		require.NoError(t, err)
+		deadline, _ := callCtx.Deadline()
+		assert.Equal(t, want, deadline)
+	})
+
+	t.Run("Unary_NoTimeoutOrMetadata", func(t *testing.T) {
+		var callCtx context.Context
+
+		err := services.CoreUnaryInterceptor(&config.CoreGRPCConfig{})(context.Background(), coreMethodPrefix+"ListDocuments", nil, nil, nil, recordingInvoker(&callCtx, nil))
+
+		require.NoError(t, err)
+		_, ok := callCtx.Deadline()
+		assert.False(t, ok)
+		_, ok = metadata.FromOutgoingContext(callCtx)
+		assert.False(t, ok)
+	})
+
+	t.Run("Unary_ReturnsInvokerError", func(t *testing.T) {
+		var callCtx context.Context
+		invokeErr := errors.New("unavailable")
+
+		err := services.CoreUnaryInterceptor(cfg)(context.Background(), coreMethodPrefix+"GetDocument", nil, nil, nil, recordingInvoker(&callCtx, invokeErr))
+
+		assert.ErrorIs(t, err, invokeErr)
+		assert.Error(t, callCtx.Err(), "the deadline is released once the call returns")
+	})
+
+	t.Run("Stream_DeadlineReleasedWhenStreamEnds", func(t *testing.T) {
+		var callCtx context.Context
+		streamer := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			callCtx = ctx
+			return endedStream{}, nil
+		}
+
+		stream, err := services.CoreStreamInterceptor(cfg)(context.Background(), &grpc.StreamDesc{ServerStreams: true}, nil, coreMethodPrefix+"Query", streamer)
+
+		require.NoError(t, err)
+		deadline, ok := callCtx.Deadline()
+		require.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), deadline, 100*time.Millisecond)
+		require.NoError(t, callCtx.Err())
+		assert.Equal(t, io.EOF, stream.RecvMsg(nil))
+		assert.ErrorIs(t, callCtx.Err(), context.Canceled)
+	})
+
+	t.Run("Stream_OpenFails", func(t *testing.T) {
+		var callCtx context.Context
+		openErr := errors.New("unavailable")
+		streamer := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			callCtx = ctx
+			return nil, openErr
+		}
+
+		_, err := services.CoreStreamInterceptor(cfg)(context.Background(), &grpc.StreamDesc{ServerStreams: true}, nil, coreMethodPrefix+"Query", streamer)
+
+		assert.ErrorIs(t, err, openErr)
+		assert.ErrorIs(t, callCtx.Err(), context.Canceled)
+	})
+}
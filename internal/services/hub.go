@@ -0,0 +1,84 @@
+package services
+
+import (
+	"strings"
+	"sync"
+
+	"kb-platform-gateway/internal/models"
+)
+
+// EventTopics returns the SSE topics an event is published on: its type
+// namespace (e.g. "document") and the namespace scoped to its subject
+// (e.g. "document:<id>").
+func EventTopics(event *models.Event) []string {
+	namespace, _, _ := strings.Cut(event.Type, ".")
+	return []string{namespace, namespace + ":" + event.SubjectID}
+}
+
+// EventHub fans events out to in-process SSE subscribers by topic.
+// Subscribers that fall behind miss events rather than block publishers.
+type EventHub struct {
+	mu     sync.RWMutex
+	subs   map[string]map[chan *models.Event]struct{}
+	buffer int
+}
+
+func NewEventHub(buffer int) *EventHub {
+	return &EventHub{
+		subs:   make(map[string]map[chan *models.Event]struct{}),
+		buffer: max(buffer, 1),
+	}
+}
+
+// Subscribe returns a channel receiving events published on any of topics,
+// and a function that cancels the subscription and closes the channel.
+func (h *EventHub) Subscribe(topics ...string) (<-chan *models.Event, func()) {
+	ch := make(chan *models.Event, h.buffer)
+
+	h.mu.Lock()
+	for _, topic := range topics {
+		if h.subs[topic] == nil {
+			h.subs[topic] = make(map[chan *models.Event]struct{})
+		}
+		h.subs[topic][ch] = struct{}{}
+	}
+	h.mu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			h.mu.Lock()
+			for _, topic := range topics {
+				delete(h.subs[topic], ch)
+				if len(h.subs[topic]) == 0 {
+					delete(h.subs, topic)
+				}
+			}
+			h.mu.Unlock()
+			close(ch)
+		})
+	}
+
+	return ch, cancel
+}
+
+// Publish delivers event to every subscriber of its topics, at most once
+// per subscriber.
+func (h *EventHub) Publish(event *models.Event) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	sent := make(map[chan *models.Event]bool)
+	for _, topic := range EventTopics(event) {
+		for ch := range h.subs[topic] {
+			if sent[ch] {
+				continue
+			}
+			sent[ch] = true
+			select {
+			case ch <- event:
+			default:
+			}
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package services_test
+
+import (
+	"testing"
+
+	"kb-platform-gateway/internal/models"
+	"kb-platform-gateway/internal/services"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventHub(t *testing.T) {
+	event := &models.Event{ID: "evt-1", Type: models.EventDocumentIndexed, SubjectID: "doc-1"}
+
+	t.Run("Publish_MatchingTopics", func(t *testing.T) {
+		hub := services.NewEventHub(4)
+		all, cancelAll := hub.Subscribe("document")
+		defer cancelAll()
+		one, cancelOne := hub.Subscribe("document:doc-1", "document")
+		defer cancelOne()
+		other, cancelOther := hub.Subscribe("document:doc-2", "conversation")
+		defer cancelOther()
+
+		hub.Publish(event)
+
+		assert.Equal(t, event, <-all)
+		assert.Equal(t, event, <-one)
+		assert.Len(t, one, 0, "subscriber on several matching topics receives the event once")
+		assert.Len(t, other, 0)
+	})
+
+	t.Run("Publish_SlowSubscriberDoesNotBlock", func(t *testing.T) {
+		hub := services.NewEventHub(1)
+		ch, cancel := hub.Subscribe("document")
+		defer cancel()
+
+		hub.Publish(event)
+		hub.Publish(event)
+
+		assert.Len(t, ch, 1)
+	})
+
+	t.Run("Cancel_ClosesChannel", func(t *testing.T) {
+		hub := services.NewEventHub(1)
+		ch, cancel := hub.Subscribe("document")
+		cancel()
+		cancel()
+
+		_, ok := <-ch
+		assert.False(t, ok)
+		hub.Publish(event)
+	})
+}
@@ -0,0 +1,91 @@
+package services_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"kb-platform-gateway/internal/config"
+	"kb-platform-gateway/internal/models"
+	"kb-platform-gateway/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImpersonationTokens(t *testing.T) {
+	cfg := &config.ImpersonationConfig{SigningKey: "impersonation-secret", TokenTTL: 30 * time.Minute}
+	tokens := services.NewImpersonationTokens(cfg)
+	sign := func(claims string) string {
+		encoded := base64.RawURLEncoding.EncodeToString([]byte(claims))
+		mac := hmac.New(sha256.New, []byte(cfg.SigningKey))
+		mac.Write([]byte(encoded))
+		return models.ImpersonationTokenPrefix + encoded + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+	}
+
+	t.Run("Issue_Verify", func(t *testing.T) {
+		token, err := tokens.Issue("support", "alice", "Ticket 123")
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(token.Token, models.ImpersonationTokenPrefix))
+		assert.NotEmpty(t, token.Session.ID)
+		assert.Equal(t, "support", token.Session.Impersonator)
+		assert.Equal(t, "alice", token.Session.Username)
+		assert.Equal(t, "Ticket 123", token.Session.Reason)
+		assert.WithinDuration(t, time.Now().Add(30*time.Minute), token.Session.ExpiresAt, 2*time.Second)
+
+		claims, err := tokens.Verify(token.Token)
+
+		require.NoError(t, err)
+		assert.Equal(t, token.Session.ID, claims.SessionID)
+		assert.Equal(t, "alice", claims.Subject)
+		assert.Equal(t, "support", claims.Impersonator)
+		assert.True(t, claims.Impersonation)
+	})
+
+	t.Run("Verify_Tampered", func(t *testing.T) {
+		token, err := tokens.Issue("support", "alice", "Ticket 123")
+		require.NoError(t, err)
+		encoded, signature, _ := strings.Cut(strings.TrimPrefix(token.Token, models.ImpersonationTokenPrefix), ".")
+		claims, _ := base64.RawURLEncoding.DecodeString(encoded)
+		forged := strings.Replace(string(claims), `"alice"`, `"bob"`, 1)
+
+		_, err = tokens.Verify(models.ImpersonationTokenPrefix + base64.RawURLEncoding.EncodeToString([]byte(forged)) + "." + signature)
+
+		assert.ErrorIs(t, err, services.ErrInvalidImpersonationToken)
+	})
+
+	t.Run("Verify_OtherKey", func(t *testing.T) {
+		other := services.NewImpersonationTokens(&config.ImpersonationConfig{SigningKey: "other"})
+		token, err := other.Issue("support", "alice", "Ticket 123")
+		require.NoError(t, err)
+
+		_, err = tokens.Verify(token.Token)
+
+		assert.ErrorIs(t, err, services.ErrInvalidImpersonationToken)
+	})
+
+	t.Run("Verify_Expired", func(t *testing.T) {
+		_, err := tokens.Verify(sign(`{"sid":"s-1","sub":"alice","act":"support","imp":true,"exp":1700000000}`))
+
+		assert.ErrorIs(t, err, services.ErrInvalidImpersonationToken)
+	})
+
+	t.Run("Verify_NotFlagged", func(t *testing.T) {
+		claims := fmt.Sprintf(`{"sid":"s-1","sub":"alice","act":"support","exp":%d}`, time.Now().Add(time.Hour).Unix())
+
+		_, err := tokens.Verify(sign(claims))
+
+		assert.ErrorIs(t, err, services.ErrInvalidImpersonationToken)
+	})
+
+	t.Run("Verify_Malformed", func(t *testing.T) {
+		for _, token := range []string{"", "kbwt_abc", "kbit_abc", "kbit_!!.!!"} {
+			_, err := tokens.Verify(token)
+			assert.ErrorIs(t, err, services.ErrInvalidImpersonationToken, token)
+		}
+	})
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"io"
 	"time"
 
 	"kb-platform-gateway/internal/models"
@@ -9,18 +10,67 @@ import (
 	"go.temporal.io/api/workflowservice/v1"
 )
 
-//go:generate mockgen -destination=mocks/mock_interfaces.go -package=mocks github.com/kb-platform-gateway/internal/services S3ClientInterface,TemporalClientInterface,QdrantClientInterface,PythonCoreClientInterface
+//go:generate mockgen -destination=mocks/mock_interfaces.go -package=mocks github.com/kb-platform-gateway/internal/services S3ClientInterface,TemporalClientInterface,QdrantClientInterface,CoreServiceInterface
 
 // S3ClientInterface defines the interface for S3 operations.
 type S3ClientInterface interface {
-	// GeneratePresignedUploadURL generates a presigned URL for uploading an object.
-	GeneratePresignedUploadURL(ctx context.Context, key string, expires time.Duration) (string, error)
+	// ObjectKey returns the key an object named name is stored at, under
+	// the client's key prefix. Every key the gateway stores is built with
+	// it.
+	ObjectKey(name string) string
+
+	// SplitKey splits key into the key prefix it is stored under, empty
+	// for a key outside it, and the object's name.
+	SplitKey(key string) (prefix, name string)
+
+	// GeneratePresignedUploadURL generates a presigned URL for uploading an
+	// object. A contentType, if set, is signed into the URL, so the upload
+	// must send it and the object is stored with it.
+	GeneratePresignedUploadURL(ctx context.Context, key, contentType string, expires time.Duration) (string, error)
 
 	// GeneratePresignedDownloadURL generates a presigned URL for downloading an object.
 	GeneratePresignedDownloadURL(ctx context.Context, key string, expires time.Duration) (string, error)
 
 	// DeleteObject deletes an object from S3.
 	DeleteObject(ctx context.Context, key string) error
+
+	// UploadObject writes body to key.
+	UploadObject(ctx context.Context, key string, body io.ReadSeeker, contentType string) error
+
+	// StreamObject writes body, of unknown length, to key with bounded
+	// memory: at most concurrency parts of partSize bytes are buffered.
+	StreamObject(ctx context.Context, key string, body io.Reader, contentType string, partSize int64, concurrency int) error
+
+	// ObjectExists reports whether an object is stored at key.
+	ObjectExists(ctx context.Context, key string) (bool, error)
+
+	// GetObject opens the object at key for reading. The caller closes
+	// it.
+	GetObject(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// CopyObject copies the object at srcKey to dstKey.
+	CopyObject(ctx context.Context, srcKey, dstKey string) error
+
+	// CreateMultipartUpload starts a multipart upload to key and returns
+	// its upload ID.
+	CreateMultipartUpload(ctx context.Context, key string) (string, error)
+
+	// GeneratePresignedUploadPartURL generates a presigned URL for uploading
+	// one part of a multipart upload.
+	GeneratePresignedUploadPartURL(ctx context.Context, key, uploadID string, partNumber int, expires time.Duration) (string, error)
+
+	// CompleteMultipartUpload assembles the uploaded parts into the object.
+	CompleteMultipartUpload(ctx context.Context, key, uploadID string, parts []CompletedPart) error
+
+	// AbortMultipartUpload discards a multipart upload and its parts.
+	AbortMultipartUpload(ctx context.Context, key, uploadID string) error
+}
+
+// CompletedPart is an uploaded part of a multipart upload, identified by
+// the ETag S3 returned for it.
+type CompletedPart struct {
+	PartNumber int
+	ETag       string
 }
 
 // TemporalClientInterface defines the interface for Temporal workflow operations.
@@ -29,18 +79,55 @@ type TemporalClientInterface interface {
 	Close()
 
 	// StartUploadWorkflow starts the document upload workflow.
-	StartUploadWorkflow(ctx context.Context, documentID, s3Key string) (string, error)
+	StartUploadWorkflow(ctx context.Context, input UploadWorkflowInput) (string, error)
+
+	// StartArchiveUploadWorkflow starts the upload workflow of a ZIP
+	// archive, which expands it into child documents.
+	StartArchiveUploadWorkflow(ctx context.Context, input UploadWorkflowInput) (string, error)
 
-	// SignalUploadComplete signals that the upload is complete.
-	SignalUploadComplete(ctx context.Context, documentID string) error
+	// SignalUploadComplete signals that the upload is complete, with the
+	// key the file is now stored at and the document's processing
+	// options. It returns ErrWorkflowNotFound if the upload workflow is no
+	// longer running.
+	SignalUploadComplete(ctx context.Context, documentID, s3Key string, processing *models.ProcessingOptions) error
 
 	// StartIndexWorkflow starts the document indexing workflow.
-	StartIndexWorkflow(ctx context.Context, documentID string) (string, error)
+	StartIndexWorkflow(ctx context.Context, input IndexWorkflowInput) (string, error)
+
+	// StartReindexWorkflow starts re-indexing a document for an embedding
+	// migration.
+	StartReindexWorkflow(ctx context.Context, input ReindexWorkflowInput) (string, error)
+
+	// StartConnectorSyncWorkflow starts syncing a connector's folders.
+	StartConnectorSyncWorkflow(ctx context.Context, input ConnectorSyncWorkflowInput) (string, error)
+
+	// StartSnapshotWorkflow starts taking a snapshot of the knowledge base.
+	StartSnapshotWorkflow(ctx context.Context, input SnapshotWorkflowInput) (string, error)
+
+	// StartExportWorkflow starts building a knowledge base export.
+	StartExportWorkflow(ctx context.Context, input ExportWorkflowInput) (string, error)
+
+	// ScheduleDocumentResync creates or updates the cron schedule
+	// re-syncing a document from its source URL.
+	ScheduleDocumentResync(ctx context.Context, cron string, input ResyncDocumentWorkflowInput) error
+
+	// ScheduleConnectorSync creates or updates the cron schedule syncing a
+	// connector.
+	ScheduleConnectorSync(ctx context.Context, cron string, input ConnectorSyncWorkflowInput) error
+
+	// DeleteResyncSchedule deletes a document's or connector's cron
+	// schedule, if any.
+	DeleteResyncSchedule(ctx context.Context, sourceType, sourceID string) error
+
+	// ScheduleTrashPurge creates or updates the cron schedule purging
+	// expired documents from the trash.
+	ScheduleTrashPurge(ctx context.Context, cron string) error
 
 	// QueryWorkflowStatus queries the status of a workflow.
 	QueryWorkflowStatus(ctx context.Context, workflowID string) (*workflowservice.DescribeWorkflowExecutionResponse, error)
 
-	// CancelWorkflow cancels a workflow.
+	// CancelWorkflow cancels a workflow. It returns ErrWorkflowNotFound
+	// if the workflow is no longer running.
 	CancelWorkflow(ctx context.Context, workflowID string) error
 
 	// HealthCheck checks the health of the Temporal service.
@@ -52,15 +139,353 @@ type QdrantClientInterface interface {
 	// Close closes the Qdrant client connection.
 	Close() error
 
-	// DeleteDocumentVectors deletes all vectors associated with a document.
-	DeleteDocumentVectors(ctx context.Context, documentID string) error
+	// DeleteDocumentVectors deletes all vectors associated with a document
+	// in collection.
+	DeleteDocumentVectors(ctx context.Context, collection, documentID string) error
+
+	// SetDocumentTags sets the tags in the payload of a document's vectors.
+	SetDocumentTags(ctx context.Context, documentID string, tags []string) error
+
+	// SetDocumentAccessGroups sets the access groups in the payload of a
+	// document's vectors in collection, which the core filters retrieval
+	// on.
+	SetDocumentAccessGroups(ctx context.Context, collection, documentID string, groups []string) error
+
+	// SetDocumentCollections sets the IDs of the collections a document is
+	// in in the payload of its vectors in collection, which the core
+	// filters retrieval restricted to a collection on.
+	SetDocumentCollections(ctx context.Context, collection, documentID string, collectionIDs []string) error
+
+	// CountVectors returns the number of vectors in the collection.
+	CountVectors(ctx context.Context) (uint64, error)
+
+	// DocumentCentroid returns the normalized mean of a document's vectors,
+	// or nil if the document has none.
+	DocumentCentroid(ctx context.Context, documentID string) ([]float32, error)
+
+	// SimilarDocuments returns the IDs of up to limit documents with a
+	// vector closest to vector, most similar first.
+	SimilarDocuments(ctx context.Context, vector []float32, limit int) ([]string, error)
+
+	// CreateCollection creates an empty collection.
+	CreateCollection(ctx context.Context, name string, vectorSize uint64) error
+
+	// Collection returns the collection the other methods operate on.
+	Collection() string
+
+	// SetCollection switches the collection the other methods operate on.
+	SetCollection(name string)
+
+	// DeleteSnapshot deletes a snapshot of collection.
+	DeleteSnapshot(ctx context.Context, collection, snapshot string) error
+
+	// DeleteCollection deletes a collection.
+	DeleteCollection(ctx context.Context, name string) error
 }
 
-// PythonCoreClientInterface defines the interface for Python Core service operations.
-type PythonCoreClientInterface interface {
+// CoreServiceInterface defines the operations the gateway needs from the
+// Python Core service, independent of the transport (HTTP or gRPC).
+type CoreServiceInterface interface {
 	// Query sends a query to the RAG system and returns a stream of events.
-	Query(query string, conversationID string, topK int) (<-chan models.SSEEvent, error)
+	// The stream is closed when ctx is cancelled.
+	Query(ctx context.Context, req models.CoreQueryRequest) (<-chan models.SSEEvent, error)
+
+	// GetDocument retrieves the core's view of a document.
+	GetDocument(ctx context.Context, documentID string) (*models.Document, error)
+
+	// DeleteDocumentVectors deletes the vectors indexed for a document.
+	DeleteDocumentVectors(ctx context.Context, documentID string) error
+
+	// GetConversation retrieves a conversation by ID.
+	GetConversation(ctx context.Context, conversationID string) (*models.Conversation, error)
+
+	// GetConversationMessages retrieves the messages of a conversation.
+	GetConversationMessages(ctx context.Context, conversationID string) ([]*models.Message, error)
+
+	// SaveMessage appends a message to a conversation.
+	SaveMessage(ctx context.Context, conversationID string, role string, content string, metadata map[string]string) (*models.Message, error)
+
+	// Evaluate scores answer against expectedAnswer with the core's
+	// evaluator.
+	Evaluate(ctx context.Context, question, expectedAnswer, answer string) (*models.EvaluationScore, error)
+
+	// Summarize folds messages into summary, the summary of the messages
+	// before them (empty if there are none), and returns the new summary.
+	Summarize(ctx context.Context, summary string, messages []*models.Message) (string, error)
 
 	// HealthCheck checks the health of the Python Core service.
-	HealthCheck() (map[string]string, error)
+	HealthCheck(ctx context.Context) (map[string]string, error)
+}
+
+// CoreRouterInterface reports how traffic is split across core backends.
+type CoreRouterInterface interface {
+	// BackendStats returns the query counts and latencies of each backend.
+	BackendStats() models.CoreBackendStatsResponse
+}
+
+// RedisClientInterface is the shared fast store behind rate limiting,
+// answer caching, session storage, cross-instance event fan-out and
+// idempotency keys.
+type RedisClientInterface interface {
+	// Close closes the Redis connection pool.
+	Close() error
+
+	// HealthCheck checks that Redis is reachable.
+	HealthCheck(ctx context.Context) error
+
+	// Get returns the value stored at key; found is false if it does not exist.
+	Get(ctx context.Context, key string) (value string, found bool, err error)
+
+	// Set stores value at key. A zero ttl stores it without expiry.
+	Set(ctx context.Context, key, value string, ttl time.Duration) error
+
+	// SetNX stores value at key only if the key does not exist and reports
+	// whether it was stored.
+	SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error)
+
+	// Delete removes keys.
+	Delete(ctx context.Context, keys ...string) error
+
+	// Incr increments the counter at key and returns the new value. The
+	// ttl is applied when the counter is created, so the counter resets
+	// once it has elapsed.
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+
+	// Publish sends message to every subscriber of channel.
+	Publish(ctx context.Context, channel, message string) error
+
+	// Subscribe returns the messages published on channels. The stream is
+	// closed when ctx is cancelled.
+	Subscribe(ctx context.Context, channels ...string) (<-chan PubSubMessage, error)
+}
+
+// WebhookDispatcherInterface publishes gateway events to outbound webhooks.
+type WebhookDispatcherInterface interface {
+	// Dispatch queues an event for asynchronous delivery.
+	Dispatch(ctx context.Context, eventType string, data interface{})
 }
+
+// NotifierInterface delivers email through a provider such as SMTP or SES.
+type NotifierInterface interface {
+	// Send delivers email.
+	Send(ctx context.Context, email Email) error
+}
+
+// NotificationServiceInterface emails users about events they opted in to.
+// Every method returns immediately; emails are sent in the background.
+type NotificationServiceInterface interface {
+	// DocumentIndexed notifies the uploader of a large document that its
+	// import has finished.
+	DocumentIndexed(ctx context.Context, documentID string)
+
+	// DocumentFailed notifies the uploader of a document that keeps failing
+	// to index.
+	DocumentFailed(ctx context.Context, documentID string)
+
+	// ExportReady sends username the download link of an export.
+	ExportReady(ctx context.Context, username string, export *models.ExportResponse)
+}
+
+// AlerterInterface posts ops alerts to a chat channel.
+type AlerterInterface interface {
+	// Send posts alert.
+	Send(ctx context.Context, alert Alert) error
+}
+
+// OpsMonitorInterface collects the signals that raise ops alerts.
+type OpsMonitorInterface interface {
+	// RecordRequest counts a served request towards the error rate.
+	RecordRequest(status int)
+
+	// DocumentFailed reports a failed indexing attempt of a document.
+	DocumentFailed(ctx context.Context, documentID string)
+
+	// Health returns what the monitor observed at its latest checks.
+	Health() OpsHealth
+}
+
+// StatusPageInterface builds the public status page.
+type StatusPageInterface interface {
+	// Status returns the current status and how much longer it may be
+	// cached.
+	Status() (*models.StatusResponse, time.Duration)
+}
+
+// HealthProbesInterface answers the Kubernetes readiness and startup
+// probes.
+type HealthProbesInterface interface {
+	// Ready checks the dependencies. It reports false when a critical one
+	// is unhealthy.
+	Ready(ctx context.Context) (*models.ReadinessResponse, bool)
+
+	// Started reports whether the gateway has finished starting.
+	Started(ctx context.Context) (*models.ReadinessResponse, bool)
+}
+
+// EmbeddingMigratorInterface migrates the knowledge base to a new
+// embedding model and routes queries to the active collection.
+type EmbeddingMigratorInterface interface {
+	// Migrate starts a migration to req.EmbeddingModel.
+	Migrate(ctx context.Context, req models.CreateEmbeddingMigrationRequest, username string) (*models.EmbeddingMigration, error)
+
+	// DocumentReindexed records the re-indexing result of a document.
+	DocumentReindexed(ctx context.Context, migrationID, documentID string, success bool) error
+
+	// ActiveCollection returns the Qdrant collection queries should use.
+	ActiveCollection() string
+}
+
+// ConnectorServiceInterface manages connectors to external sources and
+// schedules their syncs.
+type ConnectorServiceInterface interface {
+	// Create links a new connector owned by username.
+	Create(ctx context.Context, username string, req models.CreateConnectorRequest) (*models.Connector, error)
+
+	// Update applies the set fields of req to connector.
+	Update(ctx context.Context, connector *models.Connector, req models.UpdateConnectorRequest) error
+
+	// SyncNow schedules connector to sync as soon as possible.
+	SyncNow(ctx context.Context, connector *models.Connector) error
+
+	// Credentials returns the connector's unsealed OAuth credentials.
+	Credentials(connector *models.Connector) (*models.ConnectorCredentials, error)
+
+	// UpdateCredentials seals and saves new credentials, e.g. after a
+	// worker has refreshed the access token.
+	UpdateCredentials(ctx context.Context, connectorID string, credentials models.ConnectorCredentials) error
+}
+
+// ShadowMirrorInterface mirrors a sample of queries to a shadow core and
+// compares its latencies with the primary core's.
+type ShadowMirrorInterface interface {
+	// Mirror sends query to the shadow core if it is sampled. The returned
+	// function reports the primary core's latency once the query has
+	// finished; it is nil if the query is not mirrored.
+	Mirror(query models.CoreQueryRequest) func(latency time.Duration, completed bool)
+
+	// Stats returns the latency comparison so far.
+	Stats() models.ShadowStats
+}
+
+// AnswerCacheInterface reuses recent answers for near-identical
+// questions.
+type AnswerCacheInterface interface {
+	// Find returns the most similar recent question answered in scope, or
+	// nil if none is similar enough.
+	Find(ctx context.Context, scope, question string) (*models.AnsweredQuestion, error)
+
+	// Remember stores a completed answer for later questions.
+	Remember(ctx context.Context, answered *models.AnsweredQuestion) error
+}
+
+// CuratedAnswersInterface finds answers editors pinned to questions.
+type CuratedAnswersInterface interface {
+	// Find returns the curated answer for question, or nil if none
+	// matches.
+	Find(ctx context.Context, question string) (*models.CuratedAnswer, error)
+}
+
+// GlossaryInterface provides the glossary's terms for highlighting.
+type GlossaryInterface interface {
+	// Matcher returns a matcher for the glossary's terms, or nil if the
+	// glossary is empty.
+	Matcher(ctx context.Context) (*TermMatcher, error)
+}
+
+// RedactionsInterface provides the redaction rules applied to answers.
+type RedactionsInterface interface {
+	// Redactor returns a redactor for the enabled rules, or nil if there
+	// are none.
+	Redactor(ctx context.Context) (*Redactor, error)
+}
+
+// ScannerInterface scans files for malware.
+type ScannerInterface interface {
+	// Scan reads body to the end and reports whether it is infected.
+	Scan(ctx context.Context, body io.Reader) (*ScanResult, error)
+}
+
+// ConversationSummarizerInterface keeps long conversations within the
+// core's context by summarizing their older messages.
+type ConversationSummarizerInterface interface {
+	// History returns the summary and newer messages to send with a query
+	// in the conversation, or nil if the core should load its history.
+	History(ctx context.Context, conversationID string) (*models.ConversationContext, error)
+}
+
+// ConversationLocksInterface allows one query at a time in each
+// conversation.
+type ConversationLocksInterface interface {
+	// Acquire locks conversationID for the query with requestID and
+	// returns the function releasing it, or a *ConversationBusyError if
+	// another query holds it.
+	Acquire(ctx context.Context, conversationID, requestID string) (release func(), err error)
+}
+
+// WidgetTokensInterface mints and verifies the short-lived tokens of the
+// embeddable chat widget.
+type WidgetTokensInterface interface {
+	// Issue mints a token for a new widget session on origin.
+	Issue(origin string) (*models.WidgetToken, error)
+
+	// Verify checks token and returns its claims.
+	Verify(token string) (*models.WidgetClaims, error)
+}
+
+// ImpersonationTokensInterface mints and verifies the tokens admins use to
+// act as another user.
+type ImpersonationTokensInterface interface {
+	// Issue mints a token for impersonator to act as username.
+	Issue(impersonator, username, reason string) (*models.ImpersonationToken, error)
+
+	// Verify checks token and returns its claims.
+	Verify(token string) (*models.ImpersonationClaims, error)
+}
+
+// OIDCVerifierInterface verifies tokens issued by an OpenID Connect
+// provider.
+type OIDCVerifierInterface interface {
+	// Verify checks token and returns the username it maps to.
+	Verify(ctx context.Context, token string) (string, error)
+}
+
+// ReadCacheInterface shares reads of the same record between requests.
+type ReadCacheInterface interface {
+	// Document returns the document with id, calling load unless its read
+	// can be shared.
+	Document(ctx context.Context, id string, load func(context.Context) (*models.Document, error)) (*models.Document, error)
+
+	// Conversation returns the conversation with id, calling load unless
+	// its read can be shared.
+	Conversation(ctx context.Context, id string, load func(context.Context) (*models.Conversation, error)) (*models.Conversation, error)
+}
+
+var (
+	_ AnswerCacheInterface            = (*AnswerCache)(nil)
+	_ CuratedAnswersInterface         = (*CuratedAnswers)(nil)
+	_ GlossaryInterface               = (*Glossary)(nil)
+	_ RedactionsInterface             = (*Redactions)(nil)
+	_ ScannerInterface                = (*ClamAVScanner)(nil)
+	_ ConversationSummarizerInterface = (*ConversationSummarizer)(nil)
+	_ ConversationLocksInterface      = (*ConversationLocks)(nil)
+	_ WidgetTokensInterface           = (*WidgetTokens)(nil)
+	_ ImpersonationTokensInterface    = (*ImpersonationTokens)(nil)
+	_ OIDCVerifierInterface           = (*OIDCVerifier)(nil)
+	_ ReadCacheInterface              = (*ReadCache)(nil)
+	_ EmbeddingMigratorInterface      = (*EmbeddingMigrator)(nil)
+	_ ShadowMirrorInterface           = (*ShadowMirror)(nil)
+	_ ConnectorServiceInterface       = (*ConnectorService)(nil)
+	_ AlerterInterface                = (*SlackAlerter)(nil)
+	_ AlerterInterface                = (*TeamsAlerter)(nil)
+	_ OpsMonitorInterface             = (*OpsMonitor)(nil)
+	_ StatusPageInterface             = (*StatusPage)(nil)
+	_ NotifierInterface               = (*SMTPNotifier)(nil)
+	_ NotifierInterface               = (*SESNotifier)(nil)
+	_ NotificationServiceInterface    = (*NotificationService)(nil)
+	_ WebhookDispatcherInterface      = (*WebhookDispatcher)(nil)
+	_ CoreServiceInterface            = (*PythonCoreClient)(nil)
+	_ CoreServiceInterface            = (*GrpcCoreClient)(nil)
+	_ CoreServiceInterface            = (*CoreRouter)(nil)
+	_ CoreRouterInterface             = (*CoreRouter)(nil)
+	_ RedisClientInterface            = (*RedisClient)(nil)
+)
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"slices"
+	"time"
+
+	"kb-platform-gateway/internal/models"
+)
+
+// latencyWindowSize is the number of latest latencies a latencyWindow
+// keeps.
+const latencyWindowSize = 1000
+
+// latencyWindow keeps the latest latencyWindowSize latencies. It is not
+// safe for concurrent use.
+type latencyWindow struct {
+	latencies []time.Duration
+	next      int
+}
+
+func (w *latencyWindow) add(latency time.Duration) {
+	if len(w.latencies) < latencyWindowSize {
+		w.latencies = append(w.latencies, latency)
+		return
+	}
+	w.latencies[w.next] = latency
+	w.next = (w.next + 1) % latencyWindowSize
+}
+
+func (w *latencyWindow) len() int {
+	return len(w.latencies)
+}
+
+func (w *latencyWindow) summary() models.LatencySummary {
+	if len(w.latencies) == 0 {
+		return models.LatencySummary{}
+	}
+	latencies := slices.Clone(w.latencies)
+	slices.Sort(latencies)
+
+	var total time.Duration
+	for _, latency := range latencies {
+		total += latency
+	}
+	percentile := func(p int) int64 {
+		return latencies[(len(latencies)-1)*p/100].Milliseconds()
+	}
+	return models.LatencySummary{
+		MeanMS: float64(total) / float64(time.Millisecond) / float64(len(latencies)),
+		P50MS:  percentile(50),
+		P95MS:  percentile(95),
+	}
+}
@@ -0,0 +1,328 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"kb-platform-gateway/internal/config"
+	"kb-platform-gateway/internal/models"
+	"kb-platform-gateway/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// ErrMigrationInProgress is returned when a migration is started while
+// another one is still running.
+var ErrMigrationInProgress = errors.New("an embedding migration is already running")
+
+// Embedding migration document statuses.
+const (
+	migrationDocumentIndexed = "indexed"
+	migrationDocumentFailed  = "failed"
+)
+
+var collectionNameInvalid = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// EmbeddingMigrator moves the knowledge base to a new embedding model. A
+// migration creates a new Qdrant collection and re-indexes the documents
+// into it in batches of BatchSize, starting the next batch once the
+// workers have reported on the previous one. When every document has been
+// re-indexed, the migration is marked completed and queries switch to the
+// new collection in a single step; if any document failed, the migration
+// fails and queries stay on the old collection.
+//
+// The active collection is the target of the latest completed migration.
+// Every RefreshInterval it is reloaded from the database, so all gateway
+// instances follow the switch, and a running migration whose batch has
+// drained is advanced, resuming after restarts or failed workflow starts.
+type EmbeddingMigrator struct {
+	repo     repository.Repository
+	qdrant   QdrantClientInterface
+	temporal TemporalClientInterface
+	logger   zerolog.Logger
+
+	baseCollection  string
+	batchSize       int
+	refreshInterval time.Duration
+
+	// mu serialises advancing migrations within this instance.
+	mu     sync.Mutex
+	active atomic.Value
+
+	ctx       context.Context
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+}
+
+func NewEmbeddingMigrator(cfg *config.Config, repo repository.Repository, qdrant QdrantClientInterface, temporal TemporalClientInterface, logger zerolog.Logger) *EmbeddingMigrator {
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &EmbeddingMigrator{
+		repo:            repo,
+		qdrant:          qdrant,
+		temporal:        temporal,
+		logger:          logger,
+		baseCollection:  cfg.Qdrant.Collection,
+		batchSize:       max(cfg.Migrations.BatchSize, 1),
+		refreshInterval: cfg.Migrations.RefreshInterval,
+		ctx:             ctx,
+		cancel:          cancel,
+	}
+	m.active.Store(cfg.Qdrant.Collection)
+	return m
+}
+
+// Start loads the active collection and keeps refreshing it every
+// RefreshInterval until Close is called. It does nothing if the refresh
+// is disabled.
+func (m *EmbeddingMigrator) Start() {
+	if m.refreshInterval <= 0 {
+		return
+	}
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		ticker := time.NewTicker(m.refreshInterval)
+		defer ticker.Stop()
+		for {
+			if err := m.Refresh(m.ctx); err != nil && m.ctx.Err() == nil {
+				m.logger.Error().Err(err).Msg("Failed to refresh embedding migrations")
+			}
+			select {
+			case <-m.ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Close stops the refresh loop.
+func (m *EmbeddingMigrator) Close() {
+	m.closeOnce.Do(func() {
+		m.cancel()
+		m.wg.Wait()
+	})
+}
+
+// ActiveCollection returns the Qdrant collection queries should use.
+func (m *EmbeddingMigrator) ActiveCollection() string {
+	return m.active.Load().(string)
+}
+
+// Refresh reloads the active collection and advances the running
+// migration, if any.
+func (m *EmbeddingMigrator) Refresh(ctx context.Context) error {
+	active, err := m.repo.GetActiveEmbeddingMigration(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get active embedding migration: %w", err)
+	}
+	if active != nil {
+		m.activate(active.TargetCollection)
+	} else {
+		m.activate(m.baseCollection)
+	}
+
+	running, err := m.repo.GetRunningEmbeddingMigration(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get running embedding migration: %w", err)
+	}
+	if running == nil {
+		return nil
+	}
+	return m.advance(ctx, running)
+}
+
+// Migrate starts re-indexing the knowledge base with req.EmbeddingModel
+// into a new collection. It returns ErrMigrationInProgress if another
+// migration is running.
+func (m *EmbeddingMigrator) Migrate(ctx context.Context, req models.CreateEmbeddingMigrationRequest, username string) (*models.EmbeddingMigration, error) {
+	now := time.Now()
+	id := uuid.New().String()
+	migration := &models.EmbeddingMigration{
+		ID:               id,
+		EmbeddingModel:   req.EmbeddingModel,
+		VectorSize:       req.VectorSize,
+		SourceCollection: m.ActiveCollection(),
+		TargetCollection: targetCollection(m.baseCollection, req.EmbeddingModel, id),
+		Status:           models.MigrationStatusRunning,
+		CreatedBy:        username,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+
+	created, err := m.repo.CreateEmbeddingMigration(ctx, migration)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embedding migration: %w", err)
+	}
+	if !created {
+		return nil, ErrMigrationInProgress
+	}
+
+	if err := m.qdrant.CreateCollection(ctx, migration.TargetCollection, uint64(req.VectorSize)); err != nil {
+		m.finish(ctx, migration, models.MigrationStatusFailed, err.Error())
+		return nil, err
+	}
+
+	// A failure here leaves the migration running; the refresh loop
+	// retries the batch.
+	if err := m.advance(ctx, migration); err != nil {
+		m.logger.Error().Err(err).Str("migration_id", id).Msg("Failed to start embedding migration batch")
+	}
+
+	return migration, nil
+}
+
+// DocumentReindexed records a worker's result for one document of a
+// migration and starts the next batch once the current one has drained.
+func (m *EmbeddingMigrator) DocumentReindexed(ctx context.Context, migrationID, documentID string, success bool) error {
+	status := migrationDocumentIndexed
+	if !success {
+		status = migrationDocumentFailed
+	}
+
+	recorded, err := m.repo.RecordEmbeddingMigrationDocument(ctx, migrationID, documentID, status)
+	if err != nil {
+		return fmt.Errorf("failed to record re-indexed document: %w", err)
+	}
+	if !recorded {
+		return nil
+	}
+
+	migration, err := m.repo.GetEmbeddingMigration(ctx, migrationID)
+	if err != nil {
+		return fmt.Errorf("failed to get embedding migration: %w", err)
+	}
+	if migration == nil || migration.Status != models.MigrationStatusRunning {
+		return nil
+	}
+	return m.advance(ctx, migration)
+}
+
+// advance starts the next batch of a running migration once no document
+// is queued, and finishes the migration when none is left.
+func (m *EmbeddingMigrator) advance(ctx context.Context, migration *models.EmbeddingMigration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for {
+		queued, err := m.repo.CountQueuedEmbeddingMigrationDocuments(ctx, migration.ID)
+		if err != nil {
+			return fmt.Errorf("failed to count queued documents: %w", err)
+		}
+		if queued > 0 {
+			return nil
+		}
+
+		batch, err := m.repo.ClaimEmbeddingMigrationBatch(ctx, migration.ID, m.batchSize)
+		if err != nil {
+			return fmt.Errorf("failed to claim documents: %w", err)
+		}
+		if len(batch) == 0 {
+			// Documents indexed into the old collection while the
+			// migration ran must be carried over before the switch.
+			added, err := m.repo.AddEmbeddingMigrationDocuments(ctx, migration.ID)
+			if err != nil {
+				return fmt.Errorf("failed to add new documents: %w", err)
+			}
+			if added > 0 {
+				continue
+			}
+			return m.complete(ctx, migration.ID)
+		}
+
+		started := 0
+		for _, documentID := range batch {
+			err := m.startReindex(ctx, migration, documentID)
+			if err == nil {
+				started++
+				continue
+			}
+			m.logger.Error().Err(err).Str("migration_id", migration.ID).Str("document_id", documentID).Msg("Failed to start reindex workflow")
+			if _, err := m.repo.RecordEmbeddingMigrationDocument(ctx, migration.ID, documentID, migrationDocumentFailed); err != nil {
+				return fmt.Errorf("failed to record re-indexed document: %w", err)
+			}
+		}
+		if started > 0 {
+			return nil
+		}
+	}
+}
+
+// startReindex starts the workflow re-indexing a document of a migration,
+// labelling its vectors with the document's current access groups.
+func (m *EmbeddingMigrator) startReindex(ctx context.Context, migration *models.EmbeddingMigration, documentID string) error {
+	doc, err := m.repo.GetDocument(ctx, documentID)
+	if err != nil {
+		return fmt.Errorf("failed to get document: %w", err)
+	}
+	var accessGroups []string
+	if doc != nil {
+		accessGroups = doc.AccessGroups
+	}
+	_, err = m.temporal.StartReindexWorkflow(ctx, ReindexWorkflowInput{
+		DocumentID:     documentID,
+		MigrationID:    migration.ID,
+		Collection:     migration.TargetCollection,
+		EmbeddingModel: migration.EmbeddingModel,
+		AccessGroups:   accessGroups,
+	})
+	return err
+}
+
+// complete finishes a migration whose documents have all been processed,
+// switching queries to its collection if none failed.
+func (m *EmbeddingMigrator) complete(ctx context.Context, migrationID string) error {
+	migration, err := m.repo.GetEmbeddingMigration(ctx, migrationID)
+	if err != nil {
+		return fmt.Errorf("failed to get embedding migration: %w", err)
+	}
+	if migration == nil {
+		return nil
+	}
+
+	if migration.FailedDocuments > 0 {
+		m.finish(ctx, migration, models.MigrationStatusFailed,
+			fmt.Sprintf("%d of %d documents failed to re-index", migration.FailedDocuments, migration.TotalDocuments))
+		return nil
+	}
+	if m.finish(ctx, migration, models.MigrationStatusCompleted, "") {
+		m.activate(migration.TargetCollection)
+		m.logger.Info().Str("migration_id", migration.ID).Str("collection", migration.TargetCollection).Msg("Embedding migration completed")
+	}
+	return nil
+}
+
+func (m *EmbeddingMigrator) finish(ctx context.Context, migration *models.EmbeddingMigration, status, errorMessage string) bool {
+	finished, err := m.repo.FinishEmbeddingMigration(ctx, migration.ID, status, errorMessage)
+	if err != nil {
+		m.logger.Error().Err(err).Str("migration_id", migration.ID).Msg("Failed to finish embedding migration")
+		return false
+	}
+	if finished {
+		migration.Status = status
+		migration.Error = errorMessage
+	}
+	return finished
+}
+
+func (m *EmbeddingMigrator) activate(collection string) {
+	if m.active.Swap(collection) != collection {
+		m.qdrant.SetCollection(collection)
+	}
+}
+
+// targetCollection names the collection of a migration after the base
+// collection and the embedding model, with a suffix unique to the
+// migration.
+func targetCollection(base, embeddingModel, migrationID string) string {
+	model := strings.Trim(collectionNameInvalid.ReplaceAllString(embeddingModel, "_"), "_")
+	return fmt.Sprintf("%s_%s_%s", base, model, migrationID[:8])
+}
@@ -0,0 +1,194 @@
+package services_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"kb-platform-gateway/internal/config"
+	"kb-platform-gateway/internal/models"
+	repomocks "kb-platform-gateway/internal/repository/mocks"
+	"kb-platform-gateway/internal/services"
+	"kb-platform-gateway/internal/services/mocks"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestMigrator(t *testing.T, repo *repomocks.MockRepository, qdrant *mocks.MockQdrantClient, temporal *mocks.MockTemporalClient) *services.EmbeddingMigrator {
+	t.Helper()
+	m := services.NewEmbeddingMigrator(&config.Config{
+		Qdrant:     config.QdrantConfig{Collection: "documents"},
+		Migrations: config.MigrationConfig{BatchSize: 2},
+	}, repo, qdrant, temporal, zerolog.Nop())
+	t.Cleanup(m.Close)
+	return m
+}
+
+func TestEmbeddingMigrator(t *testing.T) {
+	t.Run("Migrate_Success", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		qdrant := mocks.NewMockQdrantClient()
+		temporal := mocks.NewMockTemporalClient()
+		m := newTestMigrator(t, repo, qdrant, temporal)
+
+		var target string
+		repo.On("CreateEmbeddingMigration", mock.Anything, mock.MatchedBy(func(migration *models.EmbeddingMigration) bool {
+			target = migration.TargetCollection
+			return migration.EmbeddingModel == "text-embedding-3-large" &&
+				migration.SourceCollection == "documents" &&
+				strings.HasPrefix(migration.TargetCollection, "documents_text-embedding-3-large_") &&
+				migration.CreatedBy == "admin"
+		})).Return(true, nil)
+		qdrant.On("CreateCollection", mock.Anything, mock.Anything, uint64(3072)).Return(nil)
+		repo.On("CountQueuedEmbeddingMigrationDocuments", mock.Anything, mock.Anything).Return(0, nil)
+		repo.On("ClaimEmbeddingMigrationBatch", mock.Anything, mock.Anything, 2).Return([]string{"doc-1", "doc-2"}, nil)
+		repo.On("GetDocument", mock.Anything, "doc-1").Return(&models.Document{ID: "doc-1", AccessGroups: []string{"hr"}}, nil)
+		repo.On("GetDocument", mock.Anything, "doc-2").Return(&models.Document{ID: "doc-2"}, nil)
+		temporal.On("StartReindexWorkflow", mock.Anything, mock.Anything).Return("reindex", nil)
+
+		migration, err := m.Migrate(t.Context(), models.CreateEmbeddingMigrationRequest{
+			EmbeddingModel: "text-embedding-3-large",
+			VectorSize:     3072,
+		}, "admin")
+
+		require.NoError(t, err)
+		assert.Equal(t, models.MigrationStatusRunning, migration.Status)
+		qdrant.AssertCalled(t, "CreateCollection", mock.Anything, target, uint64(3072))
+		temporal.AssertCalled(t, "StartReindexWorkflow", mock.Anything, services.ReindexWorkflowInput{
+			DocumentID:     "doc-1",
+			MigrationID:    migration.ID,
+			Collection:     target,
+			EmbeddingModel: "text-embedding-3-large",
+			AccessGroups:   []string{"hr"},
+		})
+		temporal.AssertNumberOfCalls(t, "StartReindexWorkflow", 2)
+		assert.Equal(t, "documents", m.ActiveCollection())
+	})
+
+	t.Run("Migrate_InProgress", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		qdrant := mocks.NewMockQdrantClient()
+		m := newTestMigrator(t, repo, qdrant, mocks.NewMockTemporalClient())
+
+		repo.On("CreateEmbeddingMigration", mock.Anything, mock.Anything).Return(false, nil)
+
+		_, err := m.Migrate(t.Context(), models.CreateEmbeddingMigrationRequest{EmbeddingModel: "m", VectorSize: 8}, "admin")
+
+		assert.ErrorIs(t, err, services.ErrMigrationInProgress)
+		qdrant.AssertNotCalled(t, "CreateCollection", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Migrate_CollectionError", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		qdrant := mocks.NewMockQdrantClient()
+		m := newTestMigrator(t, repo, qdrant, mocks.NewMockTemporalClient())
+
+		repo.On("CreateEmbeddingMigration", mock.Anything, mock.Anything).Return(true, nil)
+		qdrant.On("CreateCollection", mock.Anything, mock.Anything, uint64(8)).Return(errors.New("qdrant down"))
+		repo.On("FinishEmbeddingMigration", mock.Anything, mock.Anything, models.MigrationStatusFailed, "qdrant down").Return(true, nil)
+
+		_, err := m.Migrate(t.Context(), models.CreateEmbeddingMigrationRequest{EmbeddingModel: "m", VectorSize: 8}, "admin")
+
+		assert.Error(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("DocumentReindexed_CompletesAndSwitches", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		qdrant := mocks.NewMockQdrantClient()
+		m := newTestMigrator(t, repo, qdrant, mocks.NewMockTemporalClient())
+
+		migration := &models.EmbeddingMigration{
+			ID:               "mig-1",
+			TargetCollection: "documents_m_mig1",
+			Status:           models.MigrationStatusRunning,
+			TotalDocuments:   1,
+			IndexedDocuments: 1,
+		}
+		repo.On("RecordEmbeddingMigrationDocument", mock.Anything, "mig-1", "doc-1", "indexed").Return(true, nil)
+		repo.On("GetEmbeddingMigration", mock.Anything, "mig-1").Return(migration, nil)
+		repo.On("CountQueuedEmbeddingMigrationDocuments", mock.Anything, "mig-1").Return(0, nil)
+		repo.On("ClaimEmbeddingMigrationBatch", mock.Anything, "mig-1", 2).Return([]string{}, nil)
+		repo.On("AddEmbeddingMigrationDocuments", mock.Anything, "mig-1").Return(0, nil)
+		repo.On("FinishEmbeddingMigration", mock.Anything, "mig-1", models.MigrationStatusCompleted, "").Return(true, nil)
+		qdrant.On("SetCollection", "documents_m_mig1").Return()
+
+		err := m.DocumentReindexed(t.Context(), "mig-1", "doc-1", true)
+
+		require.NoError(t, err)
+		assert.Equal(t, "documents_m_mig1", m.ActiveCollection())
+		qdrant.AssertExpectations(t)
+	})
+
+	t.Run("DocumentReindexed_FailuresKeepOldCollection", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		qdrant := mocks.NewMockQdrantClient()
+		m := newTestMigrator(t, repo, qdrant, mocks.NewMockTemporalClient())
+
+		migration := &models.EmbeddingMigration{
+			ID:               "mig-1",
+			TargetCollection: "documents_m_mig1",
+			Status:           models.MigrationStatusRunning,
+			TotalDocuments:   2,
+			IndexedDocuments: 1,
+			FailedDocuments:  1,
+		}
+		repo.On("RecordEmbeddingMigrationDocument", mock.Anything, "mig-1", "doc-2", "failed").Return(true, nil)
+		repo.On("GetEmbeddingMigration", mock.Anything, "mig-1").Return(migration, nil)
+		repo.On("CountQueuedEmbeddingMigrationDocuments", mock.Anything, "mig-1").Return(0, nil)
+		repo.On("ClaimEmbeddingMigrationBatch", mock.Anything, "mig-1", 2).Return([]string{}, nil)
+		repo.On("AddEmbeddingMigrationDocuments", mock.Anything, "mig-1").Return(0, nil)
+		repo.On("FinishEmbeddingMigration", mock.Anything, "mig-1", models.MigrationStatusFailed, "1 of 2 documents failed to re-index").Return(true, nil)
+
+		err := m.DocumentReindexed(t.Context(), "mig-1", "doc-2", false)
+
+		require.NoError(t, err)
+		assert.Equal(t, "documents", m.ActiveCollection())
+		qdrant.AssertNotCalled(t, "SetCollection", mock.Anything)
+	})
+
+	t.Run("DocumentReindexed_WaitsForBatch", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		m := newTestMigrator(t, repo, mocks.NewMockQdrantClient(), mocks.NewMockTemporalClient())
+
+		repo.On("RecordEmbeddingMigrationDocument", mock.Anything, "mig-1", "doc-1", "indexed").Return(true, nil)
+		repo.On("GetEmbeddingMigration", mock.Anything, "mig-1").Return(&models.EmbeddingMigration{ID: "mig-1", Status: models.MigrationStatusRunning}, nil)
+		repo.On("CountQueuedEmbeddingMigrationDocuments", mock.Anything, "mig-1").Return(1, nil)
+
+		err := m.DocumentReindexed(t.Context(), "mig-1", "doc-1", true)
+
+		require.NoError(t, err)
+		repo.AssertNotCalled(t, "ClaimEmbeddingMigrationBatch", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("DocumentReindexed_Redelivered", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		m := newTestMigrator(t, repo, mocks.NewMockQdrantClient(), mocks.NewMockTemporalClient())
+
+		repo.On("RecordEmbeddingMigrationDocument", mock.Anything, "mig-1", "doc-1", "indexed").Return(false, nil)
+
+		err := m.DocumentReindexed(t.Context(), "mig-1", "doc-1", true)
+
+		require.NoError(t, err)
+		repo.AssertNotCalled(t, "GetEmbeddingMigration", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Refresh_LoadsActiveCollection", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		qdrant := mocks.NewMockQdrantClient()
+		m := newTestMigrator(t, repo, qdrant, mocks.NewMockTemporalClient())
+
+		repo.On("GetActiveEmbeddingMigration", mock.Anything).Return(&models.EmbeddingMigration{TargetCollection: "documents_m_mig1"}, nil)
+		repo.On("GetRunningEmbeddingMigration", mock.Anything).Return(nil, nil)
+		qdrant.On("SetCollection", "documents_m_mig1").Return()
+
+		require.NoError(t, m.Refresh(t.Context()))
+		require.NoError(t, m.Refresh(t.Context()))
+
+		assert.Equal(t, "documents_m_mig1", m.ActiveCollection())
+		qdrant.AssertNumberOfCalls(t, "SetCollection", 1)
+	})
+}
@@ -2,30 +2,86 @@ package mocks
 
 import (
 	"context"
+	"io"
+	"strings"
 	"time"
 
 	"kb-platform-gateway/internal/models"
+	"kb-platform-gateway/internal/services"
 
 	"github.com/stretchr/testify/mock"
 	"go.temporal.io/api/workflowservice/v1"
 )
 
-// MockPythonCoreClient is a mock implementation of PythonCoreClientInterface.
-type MockPythonCoreClient struct {
+// MockCoreService is a mock implementation of CoreServiceInterface.
+type MockCoreService struct {
 	mock.Mock
 }
 
-func NewMockPythonCoreClient() *MockPythonCoreClient {
-	return &MockPythonCoreClient{}
+func NewMockCoreService() *MockCoreService {
+	return &MockCoreService{}
 }
 
-func (m *MockPythonCoreClient) Query(query string, conversationID string, topK int) (<-chan models.SSEEvent, error) {
-	args := m.Called(query, conversationID, topK)
+func (m *MockCoreService) Query(ctx context.Context, req models.CoreQueryRequest) (<-chan models.SSEEvent, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
 	return args.Get(0).(<-chan models.SSEEvent), args.Error(1)
 }
 
-func (m *MockPythonCoreClient) HealthCheck() (map[string]string, error) {
-	args := m.Called()
+func (m *MockCoreService) Summarize(ctx context.Context, summary string, messages []*models.Message) (string, error) {
+	args := m.Called(ctx, summary, messages)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockCoreService) Evaluate(ctx context.Context, question, expectedAnswer, answer string) (*models.EvaluationScore, error) {
+	args := m.Called(ctx, question, expectedAnswer, answer)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.EvaluationScore), args.Error(1)
+}
+
+func (m *MockCoreService) GetDocument(ctx context.Context, documentID string) (*models.Document, error) {
+	args := m.Called(ctx, documentID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Document), args.Error(1)
+}
+
+func (m *MockCoreService) DeleteDocumentVectors(ctx context.Context, documentID string) error {
+	args := m.Called(ctx, documentID)
+	return args.Error(0)
+}
+
+func (m *MockCoreService) GetConversation(ctx context.Context, conversationID string) (*models.Conversation, error) {
+	args := m.Called(ctx, conversationID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Conversation), args.Error(1)
+}
+
+func (m *MockCoreService) GetConversationMessages(ctx context.Context, conversationID string) ([]*models.Message, error) {
+	args := m.Called(ctx, conversationID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Message), args.Error(1)
+}
+
+func (m *MockCoreService) SaveMessage(ctx context.Context, conversationID string, role string, content string, metadata map[string]string) (*models.Message, error) {
+	args := m.Called(ctx, conversationID, role, content, metadata)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Message), args.Error(1)
+}
+
+func (m *MockCoreService) HealthCheck(ctx context.Context) (map[string]string, error) {
+	args := m.Called(ctx)
 	if len(args) > 0 {
 		if err := args.Error(1); err != nil {
 			return nil, err
@@ -40,14 +96,29 @@ func (m *MockPythonCoreClient) HealthCheck() (map[string]string, error) {
 // MockS3Client is a mock implementation of S3ClientInterface.
 type MockS3Client struct {
 	mock.Mock
+	// KeyPrefix is the key prefix ObjectKey and SplitKey use.
+	KeyPrefix string
 }
 
 func NewMockS3Client() *MockS3Client {
 	return &MockS3Client{}
 }
 
-func (m *MockS3Client) GeneratePresignedUploadURL(ctx context.Context, key string, expires time.Duration) (string, error) {
-	args := m.Called(ctx, key, expires)
+// ObjectKey puts name under KeyPrefix.
+func (m *MockS3Client) ObjectKey(name string) string {
+	return m.KeyPrefix + name
+}
+
+// SplitKey splits key at KeyPrefix, if it has it.
+func (m *MockS3Client) SplitKey(key string) (string, string) {
+	if name, ok := strings.CutPrefix(key, m.KeyPrefix); ok {
+		return m.KeyPrefix, name
+	}
+	return "", key
+}
+
+func (m *MockS3Client) GeneratePresignedUploadURL(ctx context.Context, key, contentType string, expires time.Duration) (string, error) {
+	args := m.Called(ctx, key, contentType, expires)
 	return args.String(0), args.Error(1)
 }
 
@@ -66,6 +137,59 @@ func (m *MockS3Client) DeleteObject(ctx context.Context, key string) error {
 	return nil
 }
 
+func (m *MockS3Client) UploadObject(ctx context.Context, key string, body io.ReadSeeker, contentType string) error {
+	args := m.Called(ctx, key, body, contentType)
+	return args.Error(0)
+}
+
+// StreamObject reads body to the end, as the uploader would, and returns
+// its error if reading fails.
+func (m *MockS3Client) StreamObject(ctx context.Context, key string, body io.Reader, contentType string, partSize int64, concurrency int) error {
+	if _, err := io.Copy(io.Discard, body); err != nil {
+		return err
+	}
+	args := m.Called(ctx, key, body, contentType, partSize, concurrency)
+	return args.Error(0)
+}
+
+func (m *MockS3Client) ObjectExists(ctx context.Context, key string) (bool, error) {
+	args := m.Called(ctx, key)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockS3Client) GetObject(ctx context.Context, key string) (io.ReadCloser, error) {
+	args := m.Called(ctx, key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(io.ReadCloser), args.Error(1)
+}
+
+func (m *MockS3Client) CopyObject(ctx context.Context, srcKey, dstKey string) error {
+	args := m.Called(ctx, srcKey, dstKey)
+	return args.Error(0)
+}
+
+func (m *MockS3Client) CreateMultipartUpload(ctx context.Context, key string) (string, error) {
+	args := m.Called(ctx, key)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockS3Client) GeneratePresignedUploadPartURL(ctx context.Context, key, uploadID string, partNumber int, expires time.Duration) (string, error) {
+	args := m.Called(ctx, key, uploadID, partNumber, expires)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockS3Client) CompleteMultipartUpload(ctx context.Context, key, uploadID string, parts []services.CompletedPart) error {
+	args := m.Called(ctx, key, uploadID, parts)
+	return args.Error(0)
+}
+
+func (m *MockS3Client) AbortMultipartUpload(ctx context.Context, key, uploadID string) error {
+	args := m.Called(ctx, key, uploadID)
+	return args.Error(0)
+}
+
 // MockTemporalClient is a mock implementation of TemporalClientInterface.
 type MockTemporalClient struct {
 	mock.Mock
@@ -79,8 +203,8 @@ func (m *MockTemporalClient) Close() {
 	m.Called()
 }
 
-func (m *MockTemporalClient) StartUploadWorkflow(ctx context.Context, documentID, s3Key string) (string, error) {
-	args := m.Called(ctx, documentID, s3Key)
+func (m *MockTemporalClient) StartUploadWorkflow(ctx context.Context, input services.UploadWorkflowInput) (string, error) {
+	args := m.Called(ctx, input)
 	if len(args) > 1 {
 		if err := args.Error(1); err != nil {
 			return "", err
@@ -92,8 +216,13 @@ func (m *MockTemporalClient) StartUploadWorkflow(ctx context.Context, documentID
 	return "", nil
 }
 
-func (m *MockTemporalClient) SignalUploadComplete(ctx context.Context, documentID string) error {
-	args := m.Called(ctx, documentID)
+func (m *MockTemporalClient) StartArchiveUploadWorkflow(ctx context.Context, input services.UploadWorkflowInput) (string, error) {
+	args := m.Called(ctx, input)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockTemporalClient) SignalUploadComplete(ctx context.Context, documentID, s3Key string, processing *models.ProcessingOptions) error {
+	args := m.Called(ctx, documentID, s3Key, processing)
 	if len(args) > 0 {
 		if err := args.Error(0); err != nil {
 			return err
@@ -102,11 +231,51 @@ func (m *MockTemporalClient) SignalUploadComplete(ctx context.Context, documentI
 	return nil
 }
 
-func (m *MockTemporalClient) StartIndexWorkflow(ctx context.Context, documentID string) (string, error) {
-	args := m.Called(ctx, documentID)
+func (m *MockTemporalClient) StartIndexWorkflow(ctx context.Context, input services.IndexWorkflowInput) (string, error) {
+	args := m.Called(ctx, input)
 	return args.String(0), args.Error(1)
 }
 
+func (m *MockTemporalClient) StartReindexWorkflow(ctx context.Context, input services.ReindexWorkflowInput) (string, error) {
+	args := m.Called(ctx, input)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockTemporalClient) StartConnectorSyncWorkflow(ctx context.Context, input services.ConnectorSyncWorkflowInput) (string, error) {
+	args := m.Called(ctx, input)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockTemporalClient) StartSnapshotWorkflow(ctx context.Context, input services.SnapshotWorkflowInput) (string, error) {
+	args := m.Called(ctx, input)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockTemporalClient) StartExportWorkflow(ctx context.Context, input services.ExportWorkflowInput) (string, error) {
+	args := m.Called(ctx, input)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockTemporalClient) ScheduleDocumentResync(ctx context.Context, cron string, input services.ResyncDocumentWorkflowInput) error {
+	args := m.Called(ctx, cron, input)
+	return args.Error(0)
+}
+
+func (m *MockTemporalClient) ScheduleConnectorSync(ctx context.Context, cron string, input services.ConnectorSyncWorkflowInput) error {
+	args := m.Called(ctx, cron, input)
+	return args.Error(0)
+}
+
+func (m *MockTemporalClient) ScheduleTrashPurge(ctx context.Context, cron string) error {
+	args := m.Called(ctx, cron)
+	return args.Error(0)
+}
+
+func (m *MockTemporalClient) DeleteResyncSchedule(ctx context.Context, sourceType, sourceID string) error {
+	args := m.Called(ctx, sourceType, sourceID)
+	return args.Error(0)
+}
+
 func (m *MockTemporalClient) QueryWorkflowStatus(ctx context.Context, workflowID string) (*workflowservice.DescribeWorkflowExecutionResponse, error) {
 	args := m.Called(ctx, workflowID)
 	if args.Get(0) == nil {
@@ -139,8 +308,8 @@ func (m *MockQdrantClient) Close() error {
 	return args.Error(0)
 }
 
-func (m *MockQdrantClient) DeleteDocumentVectors(ctx context.Context, documentID string) error {
-	args := m.Called(ctx, documentID)
+func (m *MockQdrantClient) DeleteDocumentVectors(ctx context.Context, collection, documentID string) error {
+	args := m.Called(ctx, collection, documentID)
 	if len(args) > 0 {
 		if err := args.Error(0); err != nil {
 			return err
@@ -148,3 +317,406 @@ func (m *MockQdrantClient) DeleteDocumentVectors(ctx context.Context, documentID
 	}
 	return nil
 }
+
+func (m *MockQdrantClient) SetDocumentTags(ctx context.Context, documentID string, tags []string) error {
+	args := m.Called(ctx, documentID, tags)
+	return args.Error(0)
+}
+
+func (m *MockQdrantClient) SetDocumentAccessGroups(ctx context.Context, collection, documentID string, groups []string) error {
+	args := m.Called(ctx, collection, documentID, groups)
+	return args.Error(0)
+}
+
+func (m *MockQdrantClient) SetDocumentCollections(ctx context.Context, collection, documentID string, collectionIDs []string) error {
+	args := m.Called(ctx, collection, documentID, collectionIDs)
+	return args.Error(0)
+}
+
+func (m *MockQdrantClient) CountVectors(ctx context.Context) (uint64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(uint64), args.Error(1)
+}
+
+func (m *MockQdrantClient) DocumentCentroid(ctx context.Context, documentID string) ([]float32, error) {
+	args := m.Called(ctx, documentID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]float32), args.Error(1)
+}
+
+func (m *MockQdrantClient) SimilarDocuments(ctx context.Context, vector []float32, limit int) ([]string, error) {
+	args := m.Called(ctx, vector, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockQdrantClient) CreateCollection(ctx context.Context, name string, vectorSize uint64) error {
+	args := m.Called(ctx, name, vectorSize)
+	return args.Error(0)
+}
+
+func (m *MockQdrantClient) Collection() string {
+	args := m.Called()
+	return args.String(0)
+}
+
+func (m *MockQdrantClient) SetCollection(name string) {
+	m.Called(name)
+}
+
+func (m *MockQdrantClient) DeleteSnapshot(ctx context.Context, collection, snapshot string) error {
+	args := m.Called(ctx, collection, snapshot)
+	return args.Error(0)
+}
+
+func (m *MockQdrantClient) DeleteCollection(ctx context.Context, name string) error {
+	args := m.Called(ctx, name)
+	return args.Error(0)
+}
+
+// MockRedisClient is a mock implementation of RedisClientInterface.
+type MockRedisClient struct {
+	mock.Mock
+}
+
+func NewMockRedisClient() *MockRedisClient {
+	return &MockRedisClient{}
+}
+
+func (m *MockRedisClient) Close() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+func (m *MockRedisClient) HealthCheck(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func (m *MockRedisClient) Get(ctx context.Context, key string) (string, bool, error) {
+	args := m.Called(ctx, key)
+	return args.String(0), args.Bool(1), args.Error(2)
+}
+
+func (m *MockRedisClient) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	args := m.Called(ctx, key, value, ttl)
+	return args.Error(0)
+}
+
+func (m *MockRedisClient) SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	args := m.Called(ctx, key, value, ttl)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRedisClient) Delete(ctx context.Context, keys ...string) error {
+	args := m.Called(ctx, keys)
+	return args.Error(0)
+}
+
+func (m *MockRedisClient) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	args := m.Called(ctx, key, ttl)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRedisClient) Publish(ctx context.Context, channel, message string) error {
+	args := m.Called(ctx, channel, message)
+	return args.Error(0)
+}
+
+func (m *MockRedisClient) Subscribe(ctx context.Context, channels ...string) (<-chan services.PubSubMessage, error) {
+	args := m.Called(ctx, channels)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(<-chan services.PubSubMessage), args.Error(1)
+}
+
+// MockWebhookDispatcher is a mock implementation of WebhookDispatcherInterface.
+type MockWebhookDispatcher struct {
+	mock.Mock
+}
+
+func NewMockWebhookDispatcher() *MockWebhookDispatcher {
+	return &MockWebhookDispatcher{}
+}
+
+func (m *MockWebhookDispatcher) Dispatch(ctx context.Context, eventType string, data interface{}) {
+	m.Called(ctx, eventType, data)
+}
+
+// MockNotifier is a mock implementation of NotifierInterface.
+type MockNotifier struct {
+	mock.Mock
+}
+
+func NewMockNotifier() *MockNotifier {
+	return &MockNotifier{}
+}
+
+func (m *MockNotifier) Send(ctx context.Context, email services.Email) error {
+	args := m.Called(ctx, email)
+	return args.Error(0)
+}
+
+// MockNotificationService is a mock implementation of NotificationServiceInterface.
+type MockNotificationService struct {
+	mock.Mock
+}
+
+func NewMockNotificationService() *MockNotificationService {
+	return &MockNotificationService{}
+}
+
+func (m *MockNotificationService) DocumentIndexed(ctx context.Context, documentID string) {
+	m.Called(ctx, documentID)
+}
+
+func (m *MockNotificationService) DocumentFailed(ctx context.Context, documentID string) {
+	m.Called(ctx, documentID)
+}
+
+func (m *MockNotificationService) ExportReady(ctx context.Context, username string, export *models.ExportResponse) {
+	m.Called(ctx, username, export)
+}
+
+// MockOpsMonitor is a mock implementation of OpsMonitorInterface.
+type MockOpsMonitor struct {
+	mock.Mock
+}
+
+func NewMockOpsMonitor() *MockOpsMonitor {
+	return &MockOpsMonitor{}
+}
+
+func (m *MockOpsMonitor) RecordRequest(status int) {
+	m.Called(status)
+}
+
+func (m *MockOpsMonitor) DocumentFailed(ctx context.Context, documentID string) {
+	m.Called(ctx, documentID)
+}
+
+func (m *MockOpsMonitor) Health() services.OpsHealth {
+	args := m.Called()
+	return args.Get(0).(services.OpsHealth)
+}
+
+// MockEmbeddingMigrator is a mock implementation of EmbeddingMigratorInterface.
+type MockEmbeddingMigrator struct {
+	mock.Mock
+}
+
+func NewMockEmbeddingMigrator() *MockEmbeddingMigrator {
+	return &MockEmbeddingMigrator{}
+}
+
+func (m *MockEmbeddingMigrator) Migrate(ctx context.Context, req models.CreateEmbeddingMigrationRequest, username string) (*models.EmbeddingMigration, error) {
+	args := m.Called(ctx, req, username)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.EmbeddingMigration), args.Error(1)
+}
+
+func (m *MockEmbeddingMigrator) DocumentReindexed(ctx context.Context, migrationID, documentID string, success bool) error {
+	args := m.Called(ctx, migrationID, documentID, success)
+	return args.Error(0)
+}
+
+func (m *MockEmbeddingMigrator) ActiveCollection() string {
+	args := m.Called()
+	return args.String(0)
+}
+
+// MockShadowMirror is a mock implementation of ShadowMirrorInterface.
+type MockShadowMirror struct {
+	mock.Mock
+}
+
+func NewMockShadowMirror() *MockShadowMirror {
+	return &MockShadowMirror{}
+}
+
+func (m *MockShadowMirror) Mirror(query models.CoreQueryRequest) func(latency time.Duration, completed bool) {
+	args := m.Called(query)
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).(func(time.Duration, bool))
+}
+
+func (m *MockShadowMirror) Stats() models.ShadowStats {
+	args := m.Called()
+	return args.Get(0).(models.ShadowStats)
+}
+
+// MockAnswerCache is a mock implementation of AnswerCacheInterface.
+type MockAnswerCache struct {
+	mock.Mock
+}
+
+func NewMockAnswerCache() *MockAnswerCache {
+	return &MockAnswerCache{}
+}
+
+func (m *MockAnswerCache) Find(ctx context.Context, scope, question string) (*models.AnsweredQuestion, error) {
+	args := m.Called(ctx, scope, question)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.AnsweredQuestion), args.Error(1)
+}
+
+func (m *MockAnswerCache) Remember(ctx context.Context, answered *models.AnsweredQuestion) error {
+	args := m.Called(ctx, answered)
+	return args.Error(0)
+}
+
+// MockCuratedAnswers is a mock implementation of CuratedAnswersInterface.
+type MockCuratedAnswers struct {
+	mock.Mock
+}
+
+func NewMockCuratedAnswers() *MockCuratedAnswers {
+	return &MockCuratedAnswers{}
+}
+
+func (m *MockCuratedAnswers) Find(ctx context.Context, question string) (*models.CuratedAnswer, error) {
+	args := m.Called(ctx, question)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CuratedAnswer), args.Error(1)
+}
+
+// MockGlossary is a mock implementation of GlossaryInterface.
+type MockGlossary struct {
+	mock.Mock
+}
+
+func NewMockGlossary() *MockGlossary {
+	return &MockGlossary{}
+}
+
+func (m *MockGlossary) Matcher(ctx context.Context) (*services.TermMatcher, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.TermMatcher), args.Error(1)
+}
+
+// MockRedactions is a mock implementation of RedactionsInterface.
+type MockRedactions struct {
+	mock.Mock
+}
+
+func NewMockRedactions() *MockRedactions {
+	return &MockRedactions{}
+}
+
+func (m *MockRedactions) Redactor(ctx context.Context) (*services.Redactor, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.Redactor), args.Error(1)
+}
+
+// MockScanner is a mock implementation of ScannerInterface.
+type MockScanner struct {
+	mock.Mock
+}
+
+func NewMockScanner() *MockScanner {
+	return &MockScanner{}
+}
+
+// Scan reads body to the end, as a scanner would, and returns its error
+// if reading fails.
+func (m *MockScanner) Scan(ctx context.Context, body io.Reader) (*services.ScanResult, error) {
+	if _, err := io.Copy(io.Discard, body); err != nil {
+		return nil, err
+	}
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.ScanResult), args.Error(1)
+}
+
+// MockConversationSummarizer is a mock implementation of
+// ConversationSummarizerInterface.
+type MockConversationSummarizer struct {
+	mock.Mock
+}
+
+func NewMockConversationSummarizer() *MockConversationSummarizer {
+	return &MockConversationSummarizer{}
+}
+
+func (m *MockConversationSummarizer) History(ctx context.Context, conversationID string) (*models.ConversationContext, error) {
+	args := m.Called(ctx, conversationID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ConversationContext), args.Error(1)
+}
+
+// MockCoreRouter is a mock implementation of CoreRouterInterface.
+type MockCoreRouter struct {
+	mock.Mock
+}
+
+func NewMockCoreRouter() *MockCoreRouter {
+	return &MockCoreRouter{}
+}
+
+func (m *MockCoreRouter) BackendStats() models.CoreBackendStatsResponse {
+	args := m.Called()
+	return args.Get(0).(models.CoreBackendStatsResponse)
+}
+
+// MockConnectorService is a mock implementation of ConnectorServiceInterface.
+type MockConnectorService struct {
+	mock.Mock
+}
+
+func NewMockConnectorService() *MockConnectorService {
+	return &MockConnectorService{}
+}
+
+func (m *MockConnectorService) Create(ctx context.Context, username string, req models.CreateConnectorRequest) (*models.Connector, error) {
+	args := m.Called(ctx, username, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Connector), args.Error(1)
+}
+
+func (m *MockConnectorService) Update(ctx context.Context, connector *models.Connector, req models.UpdateConnectorRequest) error {
+	args := m.Called(ctx, connector, req)
+	return args.Error(0)
+}
+
+func (m *MockConnectorService) SyncNow(ctx context.Context, connector *models.Connector) error {
+	args := m.Called(ctx, connector)
+	return args.Error(0)
+}
+
+func (m *MockConnectorService) Credentials(connector *models.Connector) (*models.ConnectorCredentials, error) {
+	args := m.Called(connector)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ConnectorCredentials), args.Error(1)
+}
+
+func (m *MockConnectorService) UpdateCredentials(ctx context.Context, connectorID string, credentials models.ConnectorCredentials) error {
+	args := m.Called(ctx, connectorID, credentials)
+	return args.Error(0)
+}
@@ -0,0 +1,7 @@
+//go:build !race
+
+package services_test
+
+// raceEnabled reports whether tests run under the race detector, whose
+// instrumentation allocates.
+const raceEnabled = false
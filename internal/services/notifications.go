@@ -0,0 +1,276 @@
+package services
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"kb-platform-gateway/internal/config"
+	"kb-platform-gateway/internal/models"
+	"kb-platform-gateway/internal/repository"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/sesv2"
+	sestypes "github.com/aws/aws-sdk-go-v2/service/sesv2/types"
+	"github.com/rs/zerolog"
+)
+
+// Email is a plain-text message to a single recipient.
+type Email struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// NewNotifier returns the notifier for the configured provider, or nil if
+// notifications are disabled.
+func NewNotifier(cfg *config.NotificationConfig) (NotifierInterface, error) {
+	switch cfg.Provider {
+	case "":
+		return nil, nil
+	case "smtp":
+		return NewSMTPNotifier(cfg), nil
+	case "ses":
+		return NewSESNotifier(cfg)
+	default:
+		return nil, fmt.Errorf("unknown notification provider %q", cfg.Provider)
+	}
+}
+
+// SMTPNotifier sends email through an SMTP relay, upgrading the connection
+// with STARTTLS when the server offers it.
+type SMTPNotifier struct {
+	from     string
+	host     string
+	addr     string
+	username string
+	password string
+}
+
+func NewSMTPNotifier(cfg *config.NotificationConfig) *SMTPNotifier {
+	return &SMTPNotifier{
+		from:     cfg.From,
+		host:     cfg.SMTP.Host,
+		addr:     net.JoinHostPort(cfg.SMTP.Host, strconv.Itoa(cfg.SMTP.Port)),
+		username: cfg.SMTP.Username,
+		password: cfg.SMTP.Password,
+	}
+}
+
+func (n *SMTPNotifier) Send(ctx context.Context, email Email) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", n.addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to smtp server: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, n.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to start smtp session: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: n.host, MinVersion: tls.VersionTLS12}); err != nil {
+			return fmt.Errorf("failed to start tls: %w", err)
+		}
+	}
+	if n.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", n.username, n.password, n.host)); err != nil {
+			return fmt.Errorf("failed to authenticate: %w", err)
+		}
+	}
+
+	if err := client.Mail(n.from); err != nil {
+		return fmt.Errorf("failed to set sender: %w", err)
+	}
+	if err := client.Rcpt(email.To); err != nil {
+		return fmt.Errorf("failed to set recipient: %w", err)
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("failed to start message: %w", err)
+	}
+	if _, err := w.Write(formatMessage(n.from, email)); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	return client.Quit()
+}
+
+// formatMessage renders email as an RFC 5322 message. The subject is
+// encoded so user-supplied text such as filenames cannot inject headers.
+func formatMessage(from string, email Email) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", email.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", email.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(email.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+// SESNotifier sends email through Amazon SES using the default AWS
+// credential chain.
+type SESNotifier struct {
+	client *sesv2.Client
+	from   string
+}
+
+func NewSESNotifier(cfg *config.NotificationConfig) (*SESNotifier, error) {
+	cfgAWS, err := awsconfig.LoadDefaultConfig(context.TODO(), awsconfig.WithRegion(cfg.SESRegion))
+	if err != nil {
+		return nil, err
+	}
+
+	return &SESNotifier{
+		client: sesv2.NewFromConfig(cfgAWS),
+		from:   cfg.From,
+	}, nil
+}
+
+func (n *SESNotifier) Send(ctx context.Context, email Email) error {
+	_, err := n.client.SendEmail(ctx, &sesv2.SendEmailInput{
+		FromEmailAddress: aws.String(n.from),
+		Destination:      &sestypes.Destination{ToAddresses: []string{email.To}},
+		Content: &sestypes.EmailContent{
+			Simple: &sestypes.Message{
+				Subject: &sestypes.Content{Data: aws.String(email.Subject), Charset: aws.String("UTF-8")},
+				Body: &sestypes.Body{
+					Text: &sestypes.Content{Data: aws.String(email.Body), Charset: aws.String("UTF-8")},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// NotificationService decides which gateway events are worth an email and
+// sends them in the background, honouring each user's preferences. Send
+// failures are logged and dropped.
+type NotificationService struct {
+	repo     repository.Repository
+	notifier NotifierInterface
+	logger   zerolog.Logger
+
+	timeout                  time.Duration
+	largeImportBytes         int64
+	indexingFailureThreshold int
+
+	wg sync.WaitGroup
+}
+
+func NewNotificationService(cfg *config.NotificationConfig, repo repository.Repository, notifier NotifierInterface, logger zerolog.Logger) *NotificationService {
+	return &NotificationService{
+		repo:                     repo,
+		notifier:                 notifier,
+		logger:                   logger,
+		timeout:                  cfg.Timeout,
+		largeImportBytes:         cfg.LargeImportBytes,
+		indexingFailureThreshold: max(cfg.IndexingFailureThreshold, 1),
+	}
+}
+
+// DocumentIndexed tells the uploader that a large import has finished.
+func (s *NotificationService) DocumentIndexed(ctx context.Context, documentID string) {
+	s.run(ctx, func(ctx context.Context) error {
+		doc, err := s.repo.GetDocument(ctx, documentID)
+		if err != nil || doc == nil || doc.UploadedBy == "" || doc.FileSize < s.largeImportBytes {
+			return err
+		}
+		return s.send(ctx, doc.UploadedBy, models.NotificationImportFinished, Email{
+			Subject: fmt.Sprintf("Import finished: %s", doc.Filename),
+			Body:    fmt.Sprintf("Your document %q (%s) has been indexed and is ready to query.\n", doc.Filename, doc.ID),
+		})
+	})
+}
+
+// DocumentFailed tells the uploader once a document has failed indexing
+// the configured number of times. Later failures are not reported again.
+func (s *NotificationService) DocumentFailed(ctx context.Context, documentID string) {
+	s.run(ctx, func(ctx context.Context) error {
+		failures, err := s.repo.CountEvents(ctx, documentID, models.EventDocumentFailed)
+		if err != nil || failures != s.indexingFailureThreshold {
+			return err
+		}
+		doc, err := s.repo.GetDocument(ctx, documentID)
+		if err != nil || doc == nil || doc.UploadedBy == "" {
+			return err
+		}
+		body := fmt.Sprintf("Indexing of your document %q (%s) has failed %d times.\n", doc.Filename, doc.ID, failures)
+		if doc.ErrorMessage != "" {
+			body += fmt.Sprintf("\nLast error: %s\n", doc.ErrorMessage)
+		}
+		return s.send(ctx, doc.UploadedBy, models.NotificationIndexingFailed, Email{
+			Subject: fmt.Sprintf("Indexing failed: %s", doc.Filename),
+			Body:    body,
+		})
+	})
+}
+
+// ExportReady sends username the download link of a finished export.
+func (s *NotificationService) ExportReady(ctx context.Context, username string, export *models.ExportResponse) {
+	s.run(ctx, func(ctx context.Context) error {
+		return s.send(ctx, username, models.NotificationExportReady, Email{
+			Subject: "Your export is ready",
+			Body: fmt.Sprintf("Your %s export of %d rows is ready:\n\n%s\n\nThe link expires at %s.\n",
+				export.Format, export.Rows, export.URL, export.ExpiresAt.UTC().Format(time.RFC1123)),
+		})
+	})
+}
+
+// Close waits for notifications in flight.
+func (s *NotificationService) Close() {
+	s.wg.Wait()
+}
+
+// run executes fn in the background, detached from the caller's
+// cancellation so it outlives the request that triggered it.
+func (s *NotificationService) run(ctx context.Context, fn func(ctx context.Context) error) {
+	ctx = context.WithoutCancel(ctx)
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ctx, cancel := context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+		if err := fn(ctx); err != nil {
+			s.logger.Error().Err(err).Msg("Failed to send notification")
+		}
+	}()
+}
+
+func (s *NotificationService) send(ctx context.Context, username, kind string, email Email) error {
+	prefs, err := s.repo.GetNotificationPreferences(ctx, username)
+	if err != nil {
+		return err
+	}
+	if prefs == nil || !prefs.Enabled(kind) {
+		return nil
+	}
+
+	email.To = prefs.Email
+	if err := s.notifier.Send(ctx, email); err != nil {
+		return fmt.Errorf("%s notification to %s: %w", kind, username, err)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"kb-platform-gateway/internal/config"
+	"kb-platform-gateway/internal/models"
+	repomocks "kb-platform-gateway/internal/repository/mocks"
+	"kb-platform-gateway/internal/services"
+	"kb-platform-gateway/internal/services/mocks"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestNotificationService(repo *repomocks.MockRepository, notifier *mocks.MockNotifier) *services.NotificationService {
+	return services.NewNotificationService(&config.NotificationConfig{
+		Timeout:                  time.Second,
+		LargeImportBytes:         1000,
+		IndexingFailureThreshold: 3,
+	}, repo, notifier, zerolog.Nop())
+}
+
+func TestNotificationService(t *testing.T) {
+	prefs := &models.NotificationPreferences{
+		Username:       "alice",
+		Email:          "alice@example.com",
+		ImportFinished: true,
+		IndexingFailed: true,
+		ExportReady:    false,
+	}
+
+	t.Run("DocumentIndexed_LargeImport", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", mock.Anything, "doc-1").Return(&models.Document{ID: "doc-1", Filename: "big.pdf", FileSize: 5000, UploadedBy: "alice"}, nil)
+		repo.On("GetNotificationPreferences", mock.Anything, "alice").Return(prefs, nil)
+		notifier := mocks.NewMockNotifier()
+		notifier.On("Send", mock.Anything, mock.MatchedBy(func(e services.Email) bool {
+			return e.To == "alice@example.com" && e.Subject == "Import finished: big.pdf"
+		})).Return(nil)
+
+		s := newTestNotificationService(repo, notifier)
+		s.DocumentIndexed(t.Context(), "doc-1")
+		s.Close()
+
+		notifier.AssertExpectations(t)
+	})
+
+	t.Run("DocumentIndexed_SmallImport", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetDocument", mock.Anything, "doc-1").Return(&models.Document{ID: "doc-1", FileSize: 10, UploadedBy: "alice"}, nil)
+		notifier := mocks.NewMockNotifier()
+
+		s := newTestNotificationService(repo, notifier)
+		s.DocumentIndexed(t.Context(), "doc-1")
+		s.Close()
+
+		notifier.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+	})
+
+	t.Run("DocumentFailed_AtThreshold", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("CountEvents", mock.Anything, "doc-1", models.EventDocumentFailed).Return(3, nil)
+		repo.On("GetDocument", mock.Anything, "doc-1").Return(&models.Document{ID: "doc-1", Filename: "bad.pdf", UploadedBy: "alice", ErrorMessage: "parse error"}, nil)
+		repo.On("GetNotificationPreferences", mock.Anything, "alice").Return(prefs, nil)
+		notifier := mocks.NewMockNotifier()
+		notifier.On("Send", mock.Anything, mock.MatchedBy(func(e services.Email) bool {
+			return e.Subject == "Indexing failed: bad.pdf"
+		})).Return(nil)
+
+		s := newTestNotificationService(repo, notifier)
+		s.DocumentFailed(t.Context(), "doc-1")
+		s.Close()
+
+		notifier.AssertExpectations(t)
+	})
+
+	t.Run("DocumentFailed_BelowThreshold", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("CountEvents", mock.Anything, "doc-1", models.EventDocumentFailed).Return(2, nil)
+		notifier := mocks.NewMockNotifier()
+
+		s := newTestNotificationService(repo, notifier)
+		s.DocumentFailed(t.Context(), "doc-1")
+		s.Close()
+
+		repo.AssertNotCalled(t, "GetDocument", mock.Anything, mock.Anything)
+		notifier.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+	})
+
+	t.Run("ExportReady_OptedOut", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetNotificationPreferences", mock.Anything, "alice").Return(prefs, nil)
+		notifier := mocks.NewMockNotifier()
+
+		s := newTestNotificationService(repo, notifier)
+		s.ExportReady(t.Context(), "alice", &models.ExportResponse{Format: "csv", URL: "https://s3/export.csv"})
+		s.Close()
+
+		notifier.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+	})
+
+	t.Run("ExportReady_NoPreferences", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetNotificationPreferences", mock.Anything, "bob").Return(nil, nil)
+		notifier := mocks.NewMockNotifier()
+
+		s := newTestNotificationService(repo, notifier)
+		s.ExportReady(t.Context(), "bob", &models.ExportResponse{Format: "csv"})
+		s.Close()
+
+		notifier.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+	})
+}
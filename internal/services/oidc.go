@@ -0,0 +1,288 @@
+package services
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"kb-platform-gateway/internal/config"
+)
+
+// ErrInvalidOIDCToken is returned for an OIDC token that is malformed, not
+// signed by the provider, issued for another client or expired.
+var ErrInvalidOIDCToken = errors.New("invalid or expired OIDC token")
+
+const (
+	// oidcLeeway tolerates clock skew between the gateway and the
+	// provider.
+	oidcLeeway = time.Minute
+	// oidcRefreshInterval is the least time between two fetches of the
+	// provider's keys, so tokens naming unknown keys cannot flood it.
+	oidcRefreshInterval = time.Minute
+)
+
+// OIDCVerifier verifies the ID and access tokens of an OpenID Connect
+// provider and maps them to gateway users. The provider's signing keys
+// are discovered from its issuer URL on first use and fetched again when
+// a token names a key they do not include, so rotated keys are picked up.
+// RS256 and ES256 signatures are supported.
+type OIDCVerifier struct {
+	issuer        string
+	clientID      string
+	usernameClaim string
+	client        *http.Client
+
+	mu        sync.Mutex
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+}
+
+func NewOIDCVerifier(cfg *config.OIDCConfig) *OIDCVerifier {
+	return &OIDCVerifier{
+		issuer:        strings.TrimSuffix(cfg.Issuer, "/"),
+		clientID:      cfg.ClientID,
+		usernameClaim: cfg.UsernameClaim,
+		client:        &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+// Verify checks token's signature, issuer, audience and validity period
+// and returns the username in its username claim. Errors other than
+// ErrInvalidOIDCToken mean the provider's keys could not be fetched.
+func (v *OIDCVerifier) Verify(ctx context.Context, token string) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", ErrInvalidOIDCToken
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return "", ErrInvalidOIDCToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", ErrInvalidOIDCToken
+	}
+
+	key, err := v.key(ctx, header.Kid)
+	if err != nil {
+		return "", err
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if !verifySignature(header.Alg, key, digest[:], signature) {
+		return "", ErrInvalidOIDCToken
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return "", ErrInvalidOIDCToken
+	}
+	if issuer, _ := claims["iss"].(string); strings.TrimSuffix(issuer, "/") != v.issuer {
+		return "", ErrInvalidOIDCToken
+	}
+	if !audienceIncludes(claims["aud"], v.clientID) {
+		return "", ErrInvalidOIDCToken
+	}
+	now := time.Now()
+	expires, ok := claims["exp"].(float64)
+	if !ok || !now.Before(time.Unix(int64(expires), 0).Add(oidcLeeway)) {
+		return "", ErrInvalidOIDCToken
+	}
+	if notBefore, ok := claims["nbf"].(float64); ok && now.Add(oidcLeeway).Before(time.Unix(int64(notBefore), 0)) {
+		return "", ErrInvalidOIDCToken
+	}
+
+	username, _ := claims[v.usernameClaim].(string)
+	if username == "" {
+		return "", ErrInvalidOIDCToken
+	}
+	return username, nil
+}
+
+// key returns the signing key with kid, or the only key if kid is empty,
+// fetching the provider's keys if they have not been fetched recently.
+func (v *OIDCVerifier) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	find := func() crypto.PublicKey {
+		if kid == "" && len(v.keys) == 1 {
+			for _, key := range v.keys {
+				return key
+			}
+		}
+		return v.keys[kid]
+	}
+	if key := find(); key != nil {
+		return key, nil
+	}
+	if v.keys != nil && time.Since(v.fetchedAt) < oidcRefreshInterval {
+		return nil, ErrInvalidOIDCToken
+	}
+
+	keys, err := v.fetchKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	v.keys, v.fetchedAt = keys, time.Now()
+	if key := find(); key != nil {
+		return key, nil
+	}
+	return nil, ErrInvalidOIDCToken
+}
+
+// fetchKeys reads the provider's discovery document and the signing keys
+// it links to. Keys of unsupported types are skipped.
+func (v *OIDCVerifier) fetchKeys(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	var discovery struct {
+		Issuer  string `json:"issuer"`
+		JWKSURI string `json:"jwks_uri"`
+	}
+	if err := v.getJSON(ctx, v.issuer+"/.well-known/openid-configuration", &discovery); err != nil {
+		return nil, fmt.Errorf("failed to discover OIDC provider: %w", err)
+	}
+	if strings.TrimSuffix(discovery.Issuer, "/") != v.issuer || discovery.JWKSURI == "" {
+		return nil, fmt.Errorf("OIDC discovery document does not match issuer %s", v.issuer)
+	}
+
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := v.getJSON(ctx, discovery.JWKSURI, &jwks); err != nil {
+		return nil, fmt.Errorf("failed to fetch OIDC signing keys: %w", err)
+	}
+	keys := make(map[string]crypto.PublicKey)
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		if key, err := jwk.publicKey(); err == nil {
+			keys[jwk.Kid] = key
+		}
+	}
+	return keys, nil
+}
+
+func (v *OIDCVerifier) getJSON(ctx context.Context, url string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned status %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// jsonWebKey is an RSA or P-256 public key in a JSON Web Key Set.
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil || len(x) != 32 || len(y) != 32 {
+			return nil, errors.New("invalid P-256 point")
+		}
+		// Rejects points that are not on the curve.
+		if _, err := ecdh.P256().NewPublicKey(slices.Concat([]byte{4}, x, y)); err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %s", k.Kty)
+}
+
+// verifySignature checks a JWS signature made with alg over digest. The
+// key must be of the type alg requires, so an RSA key cannot be used to
+// check a forged ECDSA signature or the other way around.
+func verifySignature(alg string, key crypto.PublicKey, digest, signature []byte) bool {
+	switch alg {
+	case "RS256":
+		rsaKey, ok := key.(*rsa.PublicKey)
+		return ok && rsa.VerifyPKCS1v15(rsaKey, crypto.SHA256, digest, signature) == nil
+	case "ES256":
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if !ok || len(signature) != 64 {
+			return false
+		}
+		r, s := new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])
+		return ecdsa.Verify(ecKey, digest, r, s)
+	}
+	return false
+}
+
+// audienceIncludes reports whether a token's aud claim, a string or an
+// array of strings, names clientID.
+func audienceIncludes(aud interface{}, clientID string) bool {
+	switch aud := aud.(type) {
+	case string:
+		return aud == clientID
+	case []interface{}:
+		for _, a := range aud {
+			if a == clientID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func decodeSegment(segment string, out interface{}) error {
+	payload, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(payload, out)
+}
@@ -0,0 +1,209 @@
+package services_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"kb-platform-gateway/internal/config"
+	"kb-platform-gateway/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// oidcProvider serves an OpenID Connect discovery document and key set,
+// and signs tokens with its keys.
+type oidcProvider struct {
+	server    *httptest.Server
+	rsaKey    *rsa.PrivateKey
+	ecKey     *ecdsa.PrivateKey
+	kid       atomic.Value
+	fetches   atomic.Int32
+	unhealthy atomic.Bool
+}
+
+func newOIDCProvider(t *testing.T) *oidcProvider {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	p := &oidcProvider{rsaKey: rsaKey, ecKey: ecKey}
+	p.kid.Store("rsa-1")
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		if p.unhealthy.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"issuer": p.server.URL, "jwks_uri": p.server.URL + "/keys"})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		p.fetches.Add(1)
+		encode := base64.RawURLEncoding.EncodeToString
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{
+			{"kty": "RSA", "kid": p.kid.Load().(string), "use": "sig", "n": encode(rsaKey.N.Bytes()), "e": encode(big.NewInt(int64(rsaKey.E)).Bytes())},
+			{"kty": "EC", "kid": "ec-1", "crv": "P-256", "x": encode(ecKey.X.FillBytes(make([]byte, 32))), "y": encode(ecKey.Y.FillBytes(make([]byte, 32)))},
+			{"kty": "oct", "kid": "hmac-1", "k": "c2VjcmV0"},
+		}})
+	})
+	p.server = httptest.NewServer(mux)
+	t.Cleanup(p.server.Close)
+	return p
+}
+
+// sign returns a token with claims, signed with alg by the key kid.
+func (p *oidcProvider) sign(t *testing.T, alg, kid string, claims map[string]interface{}) string {
+	t.Helper()
+	encode := func(v interface{}) string {
+		payload, err := json.Marshal(v)
+		require.NoError(t, err)
+		return base64.RawURLEncoding.EncodeToString(payload)
+	}
+	signed := encode(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"}) + "." + encode(claims)
+	digest := sha256.Sum256([]byte(signed))
+
+	var signature []byte
+	switch alg {
+	case "RS256":
+		sig, err := rsa.SignPKCS1v15(rand.Reader, p.rsaKey, crypto.SHA256, digest[:])
+		require.NoError(t, err)
+		signature = sig
+	case "ES256":
+		r, s, err := ecdsa.Sign(rand.Reader, p.ecKey, digest[:])
+		require.NoError(t, err)
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func (p *oidcProvider) claims(overrides map[string]interface{}) map[string]interface{} {
+	claims := map[string]interface{}{
+		"iss":                p.server.URL,
+		"aud":                "kb-gateway",
+		"sub":                "00u1",
+		"preferred_username": "alice",
+		"exp":                time.Now().Add(time.Hour).Unix(),
+	}
+	for name, value := range overrides {
+		if value == nil {
+			delete(claims, name)
+			continue
+		}
+		claims[name] = value
+	}
+	return claims
+}
+
+func TestOIDCVerifier(t *testing.T) {
+	ctx := context.Background()
+	newVerifier := func(p *oidcProvider) *services.OIDCVerifier {
+		return services.NewOIDCVerifier(&config.OIDCConfig{
+			Issuer:        p.server.URL + "/",
+			ClientID:      "kb-gateway",
+			UsernameClaim: "preferred_username",
+			Timeout:       time.Second,
+		})
+	}
+
+	t.Run("Verify_RS256", func(t *testing.T) {
+		p := newOIDCProvider(t)
+
+		username, err := newVerifier(p).Verify(ctx, p.sign(t, "RS256", "rsa-1", p.claims(nil)))
+
+		require.NoError(t, err)
+		assert.Equal(t, "alice", username)
+	})
+
+	t.Run("Verify_ES256_AudienceList", func(t *testing.T) {
+		p := newOIDCProvider(t)
+		token := p.sign(t, "ES256", "ec-1", p.claims(map[string]interface{}{"aud": []string{"other", "kb-gateway"}}))
+
+		username, err := newVerifier(p).Verify(ctx, token)
+
+		require.NoError(t, err)
+		assert.Equal(t, "alice", username)
+	})
+
+	t.Run("Verify_UsernameClaim", func(t *testing.T) {
+		p := newOIDCProvider(t)
+		verifier := services.NewOIDCVerifier(&config.OIDCConfig{Issuer: p.server.URL, ClientID: "kb-gateway", UsernameClaim: "email", Timeout: time.Second})
+
+		username, err := verifier.Verify(ctx, p.sign(t, "RS256", "rsa-1", p.claims(map[string]interface{}{"email": "alice@example.com"})))
+
+		require.NoError(t, err)
+		assert.Equal(t, "alice@example.com", username)
+	})
+
+	t.Run("Verify_Invalid", func(t *testing.T) {
+		p := newOIDCProvider(t)
+		verifier := newVerifier(p)
+		valid := p.sign(t, "RS256", "rsa-1", p.claims(nil))
+		header, _, _ := strings.Cut(valid, ".")
+		forgedClaims, _ := json.Marshal(p.claims(map[string]interface{}{"preferred_username": "admin"}))
+
+		for name, token := range map[string]string{
+			"WrongAudience": p.sign(t, "RS256", "rsa-1", p.claims(map[string]interface{}{"aud": "other"})),
+			"WrongIssuer":   p.sign(t, "RS256", "rsa-1", p.claims(map[string]interface{}{"iss": "https://evil.example.com"})),
+			"Expired":       p.sign(t, "RS256", "rsa-1", p.claims(map[string]interface{}{"exp": time.Now().Add(-time.Hour).Unix()})),
+			"NoExpiry":      p.sign(t, "RS256", "rsa-1", p.claims(map[string]interface{}{"exp": nil})),
+			"NotYetValid":   p.sign(t, "RS256", "rsa-1", p.claims(map[string]interface{}{"nbf": time.Now().Add(time.Hour).Unix()})),
+			"NoUsername":    p.sign(t, "RS256", "rsa-1", p.claims(map[string]interface{}{"preferred_username": nil})),
+			"AlgMismatch":   p.sign(t, "ES256", "rsa-1", p.claims(nil)),
+			"AlgNone":       p.sign(t, "none", "rsa-1", p.claims(nil)),
+			"HMACKey":       p.sign(t, "HS256", "hmac-1", p.claims(nil)),
+			"UnknownKey":    p.sign(t, "RS256", "rsa-2", p.claims(nil)),
+			"Tampered":      header + "." + base64.RawURLEncoding.EncodeToString(forgedClaims) + "." + strings.Split(valid, ".")[2],
+			"Malformed":     "not.a-token",
+		} {
+			t.Run(name, func(t *testing.T) {
+				_, err := verifier.Verify(ctx, token)
+				assert.ErrorIs(t, err, services.ErrInvalidOIDCToken)
+			})
+		}
+	})
+
+	t.Run("Verify_UnknownKey_FetchesAtMostEveryMinute", func(t *testing.T) {
+		p := newOIDCProvider(t)
+		verifier := newVerifier(p)
+		_, err := verifier.Verify(ctx, p.sign(t, "RS256", "rsa-1", p.claims(nil)))
+		require.NoError(t, err)
+
+		// Keys were just fetched, so an unknown key is refused without
+		// asking the provider again.
+		p.kid.Store("rsa-2")
+		_, err = verifier.Verify(ctx, p.sign(t, "RS256", "rsa-2", p.claims(nil)))
+		assert.ErrorIs(t, err, services.ErrInvalidOIDCToken)
+		assert.Equal(t, int32(1), p.fetches.Load())
+
+		// A known key needs no fetch.
+		_, err = verifier.Verify(ctx, p.sign(t, "ES256", "ec-1", p.claims(nil)))
+		require.NoError(t, err)
+		assert.Equal(t, int32(1), p.fetches.Load())
+	})
+
+	t.Run("Verify_ProviderUnavailable", func(t *testing.T) {
+		p := newOIDCProvider(t)
+		p.unhealthy.Store(true)
+
+		_, err := newVerifier(p).Verify(ctx, p.sign(t, "RS256", "rsa-1", p.claims(nil)))
+
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, services.ErrInvalidOIDCToken)
+	})
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// WorkerPool bounds fan-out work. Every Run on a pool shares its slots, so
+// one pool can limit several fan-outs together; tasks must not call Run on
+// the pool they run in, as they could wait for their own slot.
+type WorkerPool struct {
+	slots chan struct{}
+}
+
+// NewWorkerPool returns a pool running at most size tasks at a time, or one
+// if size is not positive.
+func NewWorkerPool(size int) *WorkerPool {
+	return &WorkerPool{slots: make(chan struct{}, max(size, 1))}
+}
+
+// Run calls fn for each index below n as slots become free, and waits for
+// the calls it started. Once ctx is done no further calls are started. A
+// failing call does not stop the others: Run returns every error fn
+// returned, in index order, joined with ctx's error if it stopped early.
+func (p *WorkerPool) Run(ctx context.Context, n int, fn func(ctx context.Context, i int) error) error {
+	// errs is not reassigned while calls write to it.
+	errs := make([]error, n, n+1)
+	var ctxErr error
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		if !p.acquire(ctx) {
+			ctxErr = ctx.Err()
+			break
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-p.slots }()
+			errs[i] = fn(ctx, i)
+		}()
+	}
+	wg.Wait()
+	return errors.Join(append(errs, ctxErr)...)
+}
+
+// acquire takes a slot, unless ctx is done first.
+func (p *WorkerPool) acquire(ctx context.Context) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	select {
+	case p.slots <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,86 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"kb-platform-gateway/internal/services"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// concurrencyProbe returns a task that takes a moment, and the highest
+// number of its calls seen running at once.
+func concurrencyProbe() (func(ctx context.Context, i int) error, *atomic.Int32) {
+	var running, peak atomic.Int32
+	return func(ctx context.Context, i int) error {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+		}
+		time.Sleep(5 * time.Millisecond)
+		return nil
+	}, &peak
+}
+
+func TestWorkerPool(t *testing.T) {
+	t.Run("Run_Bounded", func(t *testing.T) {
+		task, peak := concurrencyProbe()
+
+		err := services.NewWorkerPool(3).Run(context.Background(), 20, task)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int32(3), peak.Load())
+	})
+
+	t.Run("Run_SharesSlotsAcrossRuns", func(t *testing.T) {
+		pool := services.NewWorkerPool(2)
+		task, peak := concurrencyProbe()
+
+		done := make(chan error)
+		go func() { done <- pool.Run(context.Background(), 10, task) }()
+		assert.NoError(t, pool.Run(context.Background(), 10, task))
+		assert.NoError(t, <-done)
+
+		assert.Equal(t, int32(2), peak.Load())
+	})
+
+	t.Run("Run_CollectsErrors", func(t *testing.T) {
+		first, second := errors.New("first"), errors.New("second")
+		var calls atomic.Int32
+
+		err := services.NewWorkerPool(2).Run(context.Background(), 5, func(ctx context.Context, i int) error {
+			calls.Add(1)
+			switch i {
+			case 1:
+				return first
+			case 3:
+				return second
+			}
+			return nil
+		})
+
+		assert.ErrorIs(t, err, first)
+		assert.ErrorIs(t, err, second)
+		assert.Equal(t, "first\nsecond", err.Error())
+		assert.Equal(t, int32(5), calls.Load())
+	})
+
+	t.Run("Run_StopsWhenCancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		var calls atomic.Int32
+
+		err := services.NewWorkerPool(1).Run(ctx, 10, func(ctx context.Context, i int) error {
+			if calls.Add(1) == 2 {
+				cancel()
+			}
+			return nil
+		})
+
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, int32(2), calls.Load())
+	})
+}
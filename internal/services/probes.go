@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"kb-platform-gateway/internal/config"
+	"kb-platform-gateway/internal/models"
+)
+
+// HealthProbes answers the Kubernetes readiness and startup probes by
+// checking the dependencies on request. Dependencies listed in
+// HEALTH_NONCRITICAL_DEPENDENCIES are reported without failing either
+// probe. The liveness probe checks no dependency, so an outage never
+// restarts pods.
+type HealthProbes struct {
+	core        CoreServiceInterface
+	probes      map[string]HealthProbe
+	schema      HealthProbe
+	nonCritical map[string]bool
+	timeout     time.Duration
+	started     atomic.Bool
+}
+
+// NewHealthProbes returns probes checking core, whose own dependencies are
+// reported too, and probes. schema checks that the database schema is
+// current for the startup probe; nil skips the check.
+func NewHealthProbes(cfg *config.HealthConfig, core CoreServiceInterface, probes map[string]HealthProbe, schema HealthProbe) *HealthProbes {
+	nonCritical := make(map[string]bool, len(cfg.NonCriticalDependencies))
+	for _, name := range cfg.NonCriticalDependencies {
+		nonCritical[name] = true
+	}
+	return &HealthProbes{
+		core:        core,
+		probes:      probes,
+		schema:      schema,
+		nonCritical: nonCritical,
+		timeout:     cfg.Timeout,
+	}
+}
+
+// Ready checks every dependency. It reports false when a critical one is
+// unhealthy; an unhealthy non-critical one leaves the gateway degraded.
+func (p *HealthProbes) Ready(ctx context.Context) (*models.ReadinessResponse, bool) {
+	dependencies, critical, other := p.check(ctx, false)
+	status := models.ReadinessReady
+	switch {
+	case critical:
+		status = models.ReadinessNotReady
+	case other:
+		status = models.ReadinessDegraded
+	}
+	return &models.ReadinessResponse{Status: status, Dependencies: dependencies}, !critical
+}
+
+// Started reports whether the gateway has finished starting: the database
+// schema is at the version it expects and every critical dependency has
+// answered. Once it has, nothing is checked any more, leaving later
+// outages to the readiness probe.
+func (p *HealthProbes) Started(ctx context.Context) (*models.ReadinessResponse, bool) {
+	if p.started.Load() {
+		return &models.ReadinessResponse{Status: models.StartupStarted, Dependencies: map[string]string{}}, true
+	}
+
+	dependencies, critical, _ := p.check(ctx, true)
+	if critical {
+		return &models.ReadinessResponse{Status: models.StartupStarting, Dependencies: dependencies}, false
+	}
+	p.started.Store(true)
+	return &models.ReadinessResponse{Status: models.StartupStarted, Dependencies: dependencies}, true
+}
+
+// check runs the probes concurrently, and the schema check if schema is
+// set. It returns the status of each dependency and whether a critical
+// or a non-critical one is unhealthy. An outdated schema is always
+// critical.
+func (p *HealthProbes) check(ctx context.Context, schema bool) (map[string]string, bool, bool) {
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	dependencies := make(map[string]string)
+	var critical, other bool
+	record := func(name string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if err == nil {
+			dependencies[name] = "ok"
+			return
+		}
+		dependencies[name] = err.Error()
+		if p.nonCritical[name] && name != "schema" {
+			other = true
+		} else {
+			critical = true
+		}
+	}
+	run := func(name string, probe HealthProbe) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			record(name, probe(ctx))
+		}()
+	}
+
+	// The core's dependencies are only reported, under the gateway's own
+	// results, since the core's readiness already accounts for them.
+	var coreDependencies map[string]string
+	if p.core != nil {
+		run("python_core", func(ctx context.Context) error {
+			deps, err := p.core.HealthCheck(ctx)
+			coreDependencies = deps
+			return err
+		})
+	}
+	for name, probe := range p.probes {
+		run(name, probe)
+	}
+	if schema && p.schema != nil {
+		run("schema", p.schema)
+	}
+	wg.Wait()
+
+	for name, status := range coreDependencies {
+		if _, ok := dependencies[name]; !ok {
+			dependencies[name] = status
+		}
+	}
+	return dependencies, critical, other
+}
@@ -0,0 +1,81 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"kb-platform-gateway/internal/config"
+	"kb-platform-gateway/internal/models"
+	"kb-platform-gateway/internal/services"
+	"kb-platform-gateway/internal/services/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHealthProbes(t *testing.T) {
+	ok := func(context.Context) error { return nil }
+	down := func(context.Context) error { return errors.New("connection refused") }
+	cfg := &config.HealthConfig{NonCriticalDependencies: []string{"temporal"}}
+
+	t.Run("Ready_ReportsCoreDependencies", func(t *testing.T) {
+		core := mocks.NewMockCoreService()
+		core.On("HealthCheck", mock.Anything).Return(map[string]string{"qdrant": "ok", "llm": "ok"}, nil)
+		probes := services.NewHealthProbes(cfg, core, map[string]services.HealthProbe{"qdrant": down}, nil)
+
+		response, ready := probes.Ready(t.Context())
+
+		assert.False(t, ready)
+		assert.Equal(t, models.ReadinessNotReady, response.Status)
+		assert.Equal(t, map[string]string{
+			"python_core": "ok",
+			"qdrant":      "connection refused",
+			"llm":         "ok",
+		}, response.Dependencies)
+	})
+
+	t.Run("Ready_NonCriticalDown", func(t *testing.T) {
+		probes := services.NewHealthProbes(cfg, nil, map[string]services.HealthProbe{"database": ok, "temporal": down}, nil)
+
+		response, ready := probes.Ready(t.Context())
+
+		assert.True(t, ready)
+		assert.Equal(t, models.ReadinessDegraded, response.Status)
+		assert.Equal(t, "connection refused", response.Dependencies["temporal"])
+	})
+
+	t.Run("Started_WaitsForSchema", func(t *testing.T) {
+		schemaErr := errors.New("version 17, expected 18")
+		schemaCalls := 0
+		schema := func(context.Context) error {
+			schemaCalls++
+			return schemaErr
+		}
+		probes := services.NewHealthProbes(cfg, nil, map[string]services.HealthProbe{"database": ok, "temporal": down}, schema)
+
+		response, started := probes.Started(t.Context())
+		assert.False(t, started)
+		assert.Equal(t, models.StartupStarting, response.Status)
+		assert.Equal(t, "version 17, expected 18", response.Dependencies["schema"])
+
+		schemaErr = nil
+		response, started = probes.Started(t.Context())
+		assert.True(t, started)
+		assert.Equal(t, models.StartupStarted, response.Status)
+
+		_, started = probes.Started(t.Context())
+		assert.True(t, started)
+		assert.Equal(t, 2, schemaCalls)
+	})
+
+	t.Run("Ready_SkipsSchema", func(t *testing.T) {
+		probes := services.NewHealthProbes(cfg, nil, map[string]services.HealthProbe{"database": ok}, down)
+
+		response, ready := probes.Ready(t.Context())
+
+		assert.True(t, ready)
+		assert.Equal(t, models.ReadinessReady, response.Status)
+		assert.NotContains(t, response.Dependencies, "schema")
+	})
+}
@@ -3,6 +3,8 @@ package services
 import (
 	"context"
 	"fmt"
+	"math"
+	"sync/atomic"
 
 	"kb-platform-gateway/internal/config"
 
@@ -12,9 +14,13 @@ import (
 )
 
 type QdrantClient struct {
-	pointsClient pb.PointsClient
-	collection   string
-	conn         *grpc.ClientConn
+	pointsClient      pb.PointsClient
+	collectionsClient pb.CollectionsClient
+	snapshotsClient   pb.SnapshotsClient
+	// collection is the active collection; it changes when an embedding
+	// migration completes.
+	collection atomic.Value
+	conn       *grpc.ClientConn
 }
 
 func NewQdrantClient(cfg *config.QdrantConfig) (*QdrantClient, error) {
@@ -24,18 +30,60 @@ func NewQdrantClient(cfg *config.QdrantConfig) (*QdrantClient, error) {
 		return nil, fmt.Errorf("failed to connect to qdrant: %w", err)
 	}
 
-	return &QdrantClient{
-		pointsClient: pb.NewPointsClient(conn),
-		collection:   cfg.Collection,
-		conn:         conn,
-	}, nil
+	q := &QdrantClient{
+		pointsClient:      pb.NewPointsClient(conn),
+		collectionsClient: pb.NewCollectionsClient(conn),
+		snapshotsClient:   pb.NewSnapshotsClient(conn),
+		conn:              conn,
+	}
+	q.collection.Store(cfg.Collection)
+	return q, nil
 }
 
 func (q *QdrantClient) Close() error {
 	return q.conn.Close()
 }
 
-func (q *QdrantClient) DeleteDocumentVectors(ctx context.Context, documentID string) error {
+// Collection returns the active collection.
+func (q *QdrantClient) Collection() string {
+	return q.collection.Load().(string)
+}
+
+// SetCollection makes name the active collection.
+func (q *QdrantClient) SetCollection(name string) {
+	q.collection.Store(name)
+}
+
+// CreateCollection creates an empty collection for vectors of vectorSize
+// dimensions, compared by cosine distance.
+func (q *QdrantClient) CreateCollection(ctx context.Context, name string, vectorSize uint64) error {
+	_, err := q.collectionsClient.Create(ctx, &pb.CreateCollection{
+		CollectionName: name,
+		VectorsConfig: pb.NewVectorsConfig(&pb.VectorParams{
+			Size:     vectorSize,
+			Distance: pb.Distance_Cosine,
+		}),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create collection %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// CollectionExists reports whether the named collection exists.
+func (q *QdrantClient) CollectionExists(ctx context.Context, name string) (bool, error) {
+	resp, err := q.collectionsClient.CollectionExists(ctx, &pb.CollectionExistsRequest{
+		CollectionName: name,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to check collection %s: %w", name, err)
+	}
+
+	return resp.GetResult().GetExists(), nil
+}
+
+func (q *QdrantClient) DeleteDocumentVectors(ctx context.Context, collection, documentID string) error {
 	// Create filter for document_id using the helper function
 	filter := &pb.Filter{
 		Must: []*pb.Condition{
@@ -45,7 +93,7 @@ func (q *QdrantClient) DeleteDocumentVectors(ctx context.Context, documentID str
 
 	// Delete points matching the filter
 	_, err := q.pointsClient.Delete(ctx, &pb.DeletePoints{
-		CollectionName: q.collection,
+		CollectionName: collection,
 		Points: &pb.PointsSelector{
 			PointsSelectorOneOf: &pb.PointsSelector_Filter{
 				Filter: filter,
@@ -54,7 +102,193 @@ func (q *QdrantClient) DeleteDocumentVectors(ctx context.Context, documentID str
 	})
 
 	if err != nil {
-		return fmt.Errorf("failed to delete vectors for document %s: %w", documentID, err)
+		return fmt.Errorf("failed to delete vectors for document %s in %s: %w", documentID, collection, err)
+	}
+
+	return nil
+}
+
+func (q *QdrantClient) SetDocumentTags(ctx context.Context, documentID string, tags []string) error {
+	if err := q.setDocumentPayload(ctx, q.Collection(), documentID, "tags", tags); err != nil {
+		return fmt.Errorf("failed to set tags for document %s: %w", documentID, err)
+	}
+	return nil
+}
+
+func (q *QdrantClient) SetDocumentAccessGroups(ctx context.Context, collection, documentID string, groups []string) error {
+	if err := q.setDocumentPayload(ctx, collection, documentID, "access_groups", groups); err != nil {
+		return fmt.Errorf("failed to set access groups for document %s in %s: %w", documentID, collection, err)
+	}
+	return nil
+}
+
+func (q *QdrantClient) SetDocumentCollections(ctx context.Context, collection, documentID string, collectionIDs []string) error {
+	if err := q.setDocumentPayload(ctx, collection, documentID, "collection_ids", collectionIDs); err != nil {
+		return fmt.Errorf("failed to set collections for document %s in %s: %w", documentID, collection, err)
+	}
+	return nil
+}
+
+// setDocumentPayload sets a list of strings under key in the payload of a
+// document's vectors in collection.
+func (q *QdrantClient) setDocumentPayload(ctx context.Context, collection, documentID, key string, list []string) error {
+	values := make([]*pb.Value, len(list))
+	for i, value := range list {
+		values[i] = pb.NewValueString(value)
+	}
+
+	_, err := q.pointsClient.SetPayload(ctx, &pb.SetPayloadPoints{
+		CollectionName: collection,
+		Payload:        map[string]*pb.Value{key: pb.NewValueFromList(values...)},
+		PointsSelector: &pb.PointsSelector{
+			PointsSelectorOneOf: &pb.PointsSelector_Filter{
+				Filter: &pb.Filter{
+					Must: []*pb.Condition{
+						pb.NewMatch("document_id", documentID),
+					},
+				},
+			},
+		},
+	})
+	return err
+}
+
+func (q *QdrantClient) CountVectors(ctx context.Context) (uint64, error) {
+	resp, err := q.pointsClient.Count(ctx, &pb.CountPoints{
+		CollectionName: q.Collection(),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count vectors: %w", err)
+	}
+
+	return resp.GetResult().GetCount(), nil
+}
+
+// documentScrollPage is how many of a document's vectors DocumentCentroid
+// reads at a time.
+const documentScrollPage = 256
+
+// DocumentCentroid returns the normalized mean of a document's vectors, or
+// nil if the document has none.
+func (q *QdrantClient) DocumentCentroid(ctx context.Context, documentID string) ([]float32, error) {
+	filter := &pb.Filter{
+		Must: []*pb.Condition{
+			pb.NewMatch("document_id", documentID),
+		},
+	}
+
+	var sum []float64
+	var count int
+	var offset *pb.PointId
+	for {
+		resp, err := q.pointsClient.Scroll(ctx, &pb.ScrollPoints{
+			CollectionName: q.Collection(),
+			Filter:         filter,
+			Offset:         offset,
+			Limit:          pb.PtrOf(uint32(documentScrollPage)),
+			WithPayload:    pb.NewWithPayload(false),
+			WithVectors:    pb.NewWithVectors(true),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read vectors for document %s: %w", documentID, err)
+		}
+
+		for _, point := range resp.GetResult() {
+			vector := denseVector(point.GetVectors().GetVector())
+			if len(vector) == 0 {
+				continue
+			}
+			if sum == nil {
+				sum = make([]float64, len(vector))
+			}
+			if len(vector) != len(sum) {
+				continue
+			}
+			for i, v := range vector {
+				sum[i] += float64(v)
+			}
+			count++
+		}
+
+		offset = resp.GetNextPageOffset()
+		if offset == nil {
+			break
+		}
+	}
+	if count == 0 {
+		return nil, nil
+	}
+
+	var norm float64
+	for _, v := range sum {
+		norm += v * v
+	}
+	norm = math.Sqrt(norm)
+	if norm == 0 {
+		return nil, nil
+	}
+
+	centroid := make([]float32, len(sum))
+	for i, v := range sum {
+		centroid[i] = float32(v / norm)
+	}
+	return centroid, nil
+}
+
+// denseVector returns the values of a dense vector, including those
+// returned by servers that predate the dense field.
+func denseVector(vector *pb.VectorOutput) []float32 {
+	if dense := vector.GetDense(); dense != nil {
+		return dense.GetData()
+	}
+	return vector.GetData()
+}
+
+// SimilarDocuments returns the IDs of up to limit documents with a vector
+// closest to vector, most similar first.
+func (q *QdrantClient) SimilarDocuments(ctx context.Context, vector []float32, limit int) ([]string, error) {
+	resp, err := q.pointsClient.SearchGroups(ctx, &pb.SearchPointGroups{
+		CollectionName: q.Collection(),
+		Vector:         vector,
+		Limit:          uint32(limit),
+		WithPayload:    pb.NewWithPayload(false),
+		GroupBy:        "document_id",
+		GroupSize:      1,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search similar documents: %w", err)
+	}
+
+	groups := resp.GetResult().GetGroups()
+	ids := make([]string, 0, len(groups))
+	for _, group := range groups {
+		if id := group.GetId().GetStringValue(); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// DeleteSnapshot deletes a snapshot of collection.
+func (q *QdrantClient) DeleteSnapshot(ctx context.Context, collection, snapshot string) error {
+	_, err := q.snapshotsClient.Delete(ctx, &pb.DeleteSnapshotRequest{
+		CollectionName: collection,
+		SnapshotName:   snapshot,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete snapshot %s of collection %s: %w", snapshot, collection, err)
+	}
+
+	return nil
+}
+
+// DeleteCollection deletes a collection and its points.
+func (q *QdrantClient) DeleteCollection(ctx context.Context, name string) error {
+	_, err := q.collectionsClient.Delete(ctx, &pb.DeleteCollection{
+		CollectionName: name,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete collection %s: %w", name, err)
 	}
 
 	return nil
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"kb-platform-gateway/internal/config"
+	"kb-platform-gateway/internal/models"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// ReadCache shares document and conversation reads between requests for
+// the same record, so dashboards polling a handful of IDs cost one read
+// each rather than one per poll. Concurrent reads are coalesced into one,
+// and with a TTL the result is reused until it expires. Failed reads are
+// never kept.
+type ReadCache struct {
+	documents     readGroup[models.Document]
+	conversations readGroup[models.Conversation]
+}
+
+func NewReadCache(cfg *config.ReadCacheConfig) *ReadCache {
+	return &ReadCache{
+		documents:     newReadGroup[models.Document](cfg.TTL, cfg.Size),
+		conversations: newReadGroup[models.Conversation](cfg.TTL, cfg.Size),
+	}
+}
+
+// Document returns the document with id, calling load unless a read of it
+// is in flight or cached.
+func (c *ReadCache) Document(ctx context.Context, id string, load func(context.Context) (*models.Document, error)) (*models.Document, error) {
+	return c.documents.read(ctx, id, load)
+}
+
+// Conversation returns the conversation with id, calling load unless a
+// read of it is in flight or cached.
+func (c *ReadCache) Conversation(ctx context.Context, id string, load func(context.Context) (*models.Conversation, error)) (*models.Conversation, error) {
+	return c.conversations.read(ctx, id, load)
+}
+
+// readGroup coalesces and caches the reads of one kind of record. Callers
+// get their own copy of the record, so changing it does not change the
+// cached one.
+type readGroup[T any] struct {
+	group singleflight.Group
+	ttl   time.Duration
+	size  int
+
+	mu      sync.Mutex
+	entries map[string]readEntry[T]
+}
+
+type readEntry[T any] struct {
+	value   *T
+	expires time.Time
+}
+
+func newReadGroup[T any](ttl time.Duration, size int) readGroup[T] {
+	return readGroup[T]{
+		ttl:     ttl,
+		size:    max(size, 1),
+		entries: make(map[string]readEntry[T]),
+	}
+}
+
+func (g *readGroup[T]) read(ctx context.Context, id string, load func(context.Context) (*T, error)) (*T, error) {
+	if value, ok := g.cached(id); ok {
+		return clone(value), nil
+	}
+
+	// The shared read outlives a caller that gives up, since others may
+	// still be waiting for it.
+	ch := g.group.DoChan(id, func() (interface{}, error) {
+		value, err := load(context.WithoutCancel(ctx))
+		if err == nil && value != nil {
+			g.store(id, value)
+		}
+		return value, err
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result := <-ch:
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		return clone(result.Val.(*T)), nil
+	}
+}
+
+func (g *readGroup[T]) cached(id string) (*T, bool) {
+	if g.ttl <= 0 {
+		return nil, false
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	entry, ok := g.entries[id]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(g.entries, id)
+		return nil, false
+	}
+	return entry.value, true
+}
+
+// store keeps value until the TTL passes. When the cache is full, expired
+// entries are dropped first, and the value is not kept if none had.
+func (g *readGroup[T]) store(id string, value *T) {
+	if g.ttl <= 0 {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := time.Now()
+	if _, ok := g.entries[id]; !ok && len(g.entries) >= g.size {
+		for key, entry := range g.entries {
+			if now.After(entry.expires) {
+				delete(g.entries, key)
+			}
+		}
+		if len(g.entries) >= g.size {
+			return
+		}
+	}
+	g.entries[id] = readEntry[T]{value: clone(value), expires: now.Add(g.ttl)}
+}
+
+// clone returns a shallow copy of value.
+func clone[T any](value *T) *T {
+	if value == nil {
+		return nil
+	}
+	c := *value
+	return &c
+}
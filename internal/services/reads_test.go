@@ -0,0 +1,160 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"kb-platform-gateway/internal/config"
+	"kb-platform-gateway/internal/models"
+	"kb-platform-gateway/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadCache(t *testing.T) {
+	t.Run("Coalesces_ConcurrentReads", func(t *testing.T) {
+		reads := services.NewReadCache(&config.ReadCacheConfig{Size: 10})
+		var loads atomic.Int32
+		release := make(chan struct{})
+		load := func(context.Context) (*models.Document, error) {
+			loads.Add(1)
+			<-release
+			return &models.Document{ID: "doc-1", Status: "complete"}, nil
+		}
+
+		var wg sync.WaitGroup
+		docs := make([]*models.Document, 5)
+		for i := range docs {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				doc, err := reads.Document(context.Background(), "doc-1", load)
+				assert.NoError(t, err)
+				docs[i] = doc
+			}()
+		}
+		require.Eventually(t, func() bool { return loads.Load() == 1 }, time.Second, time.Millisecond)
+		time.Sleep(10 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		assert.Equal(t, int32(1), loads.Load())
+		for _, doc := range docs {
+			require.NotNil(t, doc)
+			assert.Equal(t, "doc-1", doc.ID)
+		}
+		// Each caller gets its own copy.
+		docs[0].Status = "indexing"
+		assert.Equal(t, "complete", docs[1].Status)
+	})
+
+	t.Run("NoTTL_ReloadsAfterRead", func(t *testing.T) {
+		reads := services.NewReadCache(&config.ReadCacheConfig{Size: 10})
+		var loads int
+		load := func(context.Context) (*models.Conversation, error) {
+			loads++
+			return &models.Conversation{ID: "conv-1"}, nil
+		}
+
+		_, err := reads.Conversation(context.Background(), "conv-1", load)
+		require.NoError(t, err)
+		_, err = reads.Conversation(context.Background(), "conv-1", load)
+		require.NoError(t, err)
+
+		assert.Equal(t, 2, loads)
+	})
+
+	t.Run("TTL_ReusesUntilExpired", func(t *testing.T) {
+		reads := services.NewReadCache(&config.ReadCacheConfig{TTL: 50 * time.Millisecond, Size: 10})
+		var loads int
+		load := func(context.Context) (*models.Document, error) {
+			loads++
+			return &models.Document{ID: "doc-1", Status: "complete"}, nil
+		}
+
+		first, err := reads.Document(context.Background(), "doc-1", load)
+		require.NoError(t, err)
+		first.Status = "failed"
+		second, err := reads.Document(context.Background(), "doc-1", load)
+		require.NoError(t, err)
+
+		assert.Equal(t, 1, loads)
+		assert.Equal(t, "complete", second.Status)
+
+		time.Sleep(60 * time.Millisecond)
+		_, err = reads.Document(context.Background(), "doc-1", load)
+		require.NoError(t, err)
+		assert.Equal(t, 2, loads)
+	})
+
+	t.Run("Error_NotCached", func(t *testing.T) {
+		reads := services.NewReadCache(&config.ReadCacheConfig{TTL: time.Minute, Size: 10})
+		var loads int
+		load := func(context.Context) (*models.Document, error) {
+			loads++
+			if loads == 1 {
+				return nil, errors.New("database unavailable")
+			}
+			return &models.Document{ID: "doc-1"}, nil
+		}
+
+		_, err := reads.Document(context.Background(), "doc-1", load)
+		require.Error(t, err)
+		doc, err := reads.Document(context.Background(), "doc-1", load)
+
+		require.NoError(t, err)
+		assert.Equal(t, "doc-1", doc.ID)
+		assert.Equal(t, 2, loads)
+	})
+
+	t.Run("Full_SkipsCaching", func(t *testing.T) {
+		reads := services.NewReadCache(&config.ReadCacheConfig{TTL: time.Minute, Size: 1})
+		loads := map[string]int{}
+		load := func(id string) func(context.Context) (*models.Document, error) {
+			return func(context.Context) (*models.Document, error) {
+				loads[id]++
+				return &models.Document{ID: id}, nil
+			}
+		}
+
+		for range 2 {
+			_, err := reads.Document(context.Background(), "doc-1", load("doc-1"))
+			require.NoError(t, err)
+			_, err = reads.Document(context.Background(), "doc-2", load("doc-2"))
+			require.NoError(t, err)
+		}
+
+		assert.Equal(t, 1, loads["doc-1"])
+		assert.Equal(t, 2, loads["doc-2"])
+	})
+
+	t.Run("CallerCancelled_ReadContinues", func(t *testing.T) {
+		reads := services.NewReadCache(&config.ReadCacheConfig{TTL: time.Minute, Size: 10})
+		release := make(chan struct{})
+		loaded := make(chan error, 1)
+		load := func(ctx context.Context) (*models.Document, error) {
+			<-release
+			loaded <- ctx.Err()
+			return &models.Document{ID: "doc-1"}, nil
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := reads.Document(ctx, "doc-1", load)
+		assert.ErrorIs(t, err, context.Canceled)
+
+		close(release)
+		assert.NoError(t, <-loaded)
+		require.Eventually(t, func() bool {
+			doc, err := reads.Document(context.Background(), "doc-1", func(context.Context) (*models.Document, error) {
+				return nil, errors.New("not cached")
+			})
+			return err == nil && doc.ID == "doc-1"
+		}, time.Second, time.Millisecond)
+	})
+}
@@ -0,0 +1,230 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"kb-platform-gateway/internal/models"
+	"kb-platform-gateway/internal/repository"
+)
+
+// redactionHoldback is how many runes at the end of a streamed answer are
+// held back, as they may begin a match the next chunk completes. Regex
+// matches longer than this can be missed when split across chunks.
+const redactionHoldback = 64
+
+// Redactions provides the enabled redaction rules for answers. Rules are
+// read on every call, so edits apply to the next answer on every
+// instance.
+type Redactions struct {
+	repo repository.RedactionRuleRepository
+}
+
+func NewRedactions(repo repository.RedactionRuleRepository) *Redactions {
+	return &Redactions{repo: repo}
+}
+
+// Redactor returns a redactor for the enabled rules, or nil if there are
+// none.
+func (r *Redactions) Redactor(ctx context.Context) (*Redactor, error) {
+	rules, err := r.repo.ListEnabledRedactionRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	redactor := NewRedactor(rules)
+	if len(redactor.patterns) == 0 {
+		return nil, nil
+	}
+	return redactor, nil
+}
+
+// ValidateRedactionRule reports why rule cannot be used, if it cannot.
+func ValidateRedactionRule(rule *models.RedactionRule) error {
+	switch rule.Type {
+	case models.RedactionRegex:
+		if rule.Pattern == "" {
+			return errors.New("pattern is required for regex rules")
+		}
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return errors.New("pattern is not a valid regular expression")
+		}
+		if re.MatchString("") {
+			return errors.New("pattern must not match empty text")
+		}
+	case models.RedactionKeywords:
+		if len(keywordList(rule.Keywords)) == 0 {
+			return errors.New("keywords are required for keywords rules")
+		}
+	default:
+		return errors.New("type must be regex or keywords")
+	}
+	return nil
+}
+
+type redactionPattern struct {
+	re          *regexp.Regexp
+	placeholder string
+	// words requires matches to be whole words.
+	words bool
+}
+
+type redactionSpan struct {
+	start, end  int
+	placeholder string
+}
+
+// Redactor replaces the text redaction rules match. Where matches of
+// different rules overlap, the earliest wins, then the longest.
+type Redactor struct {
+	patterns []redactionPattern
+	// holdback is how many runes of a streamed text are held back.
+	holdback int
+}
+
+// NewRedactor compiles rules. Rules are validated when saved, so one that
+// fails to compile was stored by other means and is skipped.
+func NewRedactor(rules []*models.RedactionRule) *Redactor {
+	r := &Redactor{holdback: redactionHoldback}
+	for _, rule := range rules {
+		if ValidateRedactionRule(rule) != nil {
+			continue
+		}
+		placeholder := rule.Placeholder
+		if placeholder == "" {
+			placeholder = models.DefaultRedactionPlaceholder
+		}
+
+		pattern := redactionPattern{placeholder: placeholder}
+		if rule.Type == models.RedactionKeywords {
+			keywords := keywordList(rule.Keywords)
+			quoted := make([]string, len(keywords))
+			for i, keyword := range keywords {
+				quoted[i] = regexp.QuoteMeta(keyword)
+				// A keyword is held back whole so it is never split.
+				if n := utf8.RuneCountInString(keyword) + 1; n > r.holdback {
+					r.holdback = n
+				}
+			}
+			pattern.re = regexp.MustCompile("(?i)(?:" + strings.Join(quoted, "|") + ")")
+			pattern.words = true
+		} else {
+			pattern.re = regexp.MustCompile(rule.Pattern)
+		}
+		r.patterns = append(r.patterns, pattern)
+	}
+	return r
+}
+
+// Redact replaces every match in text with its rule's placeholder, and
+// reports whether there were any.
+func (r *Redactor) Redact(text string) (string, bool) {
+	redacted, _, found := r.RedactStream(text, 0, true)
+	return redacted, found
+}
+
+// RedactStream redacts the part of text from byte offset from that can be
+// settled, returning it with the offset to resume from when text grows.
+// Unless final, text may continue, so its last runes, and any match
+// reaching them, are left for a later call.
+func (r *Redactor) RedactStream(text string, from int, final bool) (string, int, bool) {
+	cut := len(text)
+	if !final {
+		cut = from
+		for i, n := len(text), 0; i > from; n++ {
+			if n == r.holdback {
+				cut = i
+				break
+			}
+			_, size := utf8.DecodeLastRuneInString(text[:i])
+			i -= size
+		}
+	}
+
+	var out strings.Builder
+	found := false
+	pos := from
+	for _, span := range r.spans(text) {
+		if span.start < from {
+			continue
+		}
+		if span.start >= cut {
+			break
+		}
+		if span.end > cut {
+			// The match may grow once text continues.
+			cut = span.start
+			break
+		}
+		out.WriteString(text[pos:span.start])
+		out.WriteString(span.placeholder)
+		pos = span.end
+		found = true
+	}
+	out.WriteString(text[pos:cut])
+	return out.String(), cut, found
+}
+
+// spans returns the matches of every rule in text that do not overlap,
+// in order.
+func (r *Redactor) spans(text string) []redactionSpan {
+	var all []redactionSpan
+	for _, pattern := range r.patterns {
+		for _, loc := range pattern.re.FindAllStringIndex(text, -1) {
+			if loc[0] == loc[1] || pattern.words && !wholeWord(text, loc[0], loc[1]) {
+				continue
+			}
+			all = append(all, redactionSpan{start: loc[0], end: loc[1], placeholder: pattern.placeholder})
+		}
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		if all[i].start != all[j].start {
+			return all[i].start < all[j].start
+		}
+		return all[i].end > all[j].end
+	})
+
+	var spans []redactionSpan
+	end := 0
+	for _, span := range all {
+		if span.start < end {
+			continue
+		}
+		spans = append(spans, span)
+		end = span.end
+	}
+	return spans
+}
+
+// wholeWord reports whether text[start:end] is not part of a longer word:
+// "API" is not redacted in "APIs" or "rapid".
+func wholeWord(text string, start, end int) bool {
+	first, _ := utf8.DecodeRuneInString(text[start:end])
+	if before, _ := utf8.DecodeLastRuneInString(text[:start]); start > 0 && isWordRune(first) && isWordRune(before) {
+		return false
+	}
+	last, _ := utf8.DecodeLastRuneInString(text[start:end])
+	if after, _ := utf8.DecodeRuneInString(text[end:]); end < len(text) && isWordRune(last) && isWordRune(after) {
+		return false
+	}
+	return true
+}
+
+// keywordList returns keywords without blank entries, longest first so
+// the longest of several matching at the same place wins.
+func keywordList(keywords []string) []string {
+	var list []string
+	for _, keyword := range keywords {
+		if keyword = strings.TrimSpace(keyword); keyword != "" {
+			list = append(list, keyword)
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		return len(list[i]) > len(list[j])
+	})
+	return list
+}
@@ -0,0 +1,92 @@
+package services_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"kb-platform-gateway/internal/models"
+	repomocks "kb-platform-gateway/internal/repository/mocks"
+	"kb-platform-gateway/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactor(t *testing.T) {
+	redactor := services.NewRedactor([]*models.RedactionRule{
+		{Type: models.RedactionRegex, Pattern: `\d{3}-\d{2}-\d{4}`, Placeholder: "[SSN]"},
+		{Type: models.RedactionKeywords, Keywords: []string{"Project Falcon", "falcon", " "}},
+		{Type: models.RedactionRegex, Pattern: "(unclosed"},
+	})
+
+	t.Run("Redact", func(t *testing.T) {
+		text, found := redactor.Redact("Project falcon's lead (SSN 123-45-6789) flies FALCON jets, not falcons.")
+
+		assert.True(t, found)
+		assert.Equal(t, "[REDACTED]'s lead (SSN [SSN]) flies [REDACTED] jets, not falcons.", text)
+	})
+
+	t.Run("NoMatch", func(t *testing.T) {
+		text, found := redactor.Redact("Nothing to hide.")
+
+		assert.False(t, found)
+		assert.Equal(t, "Nothing to hide.", text)
+	})
+
+	t.Run("Streaming", func(t *testing.T) {
+		chunks := []string{"The lead of Project Fal", "con is ", "123-45-", "6789", strings.Repeat(".", 80), " Done"}
+
+		var text, out string
+		next, found := 0, false
+		for _, chunk := range chunks {
+			text += chunk
+			sent, n, redacted := redactor.RedactStream(text, next, false)
+			out += sent
+			next, found = n, found || redacted
+		}
+		assert.Less(t, next, len(text), "the end of the text is held back")
+		sent, _, redacted := redactor.RedactStream(text, next, true)
+		out += sent
+
+		assert.True(t, found || redacted)
+		assert.Equal(t, "The lead of [REDACTED] is [SSN]"+strings.Repeat(".", 80)+" Done", out)
+	})
+
+	t.Run("ValidateRedactionRule", func(t *testing.T) {
+		assert.NoError(t, services.ValidateRedactionRule(&models.RedactionRule{Type: models.RedactionRegex, Pattern: `(?i)secret\w*`}))
+		assert.NoError(t, services.ValidateRedactionRule(&models.RedactionRule{Type: models.RedactionKeywords, Keywords: []string{"falcon"}}))
+		assert.Error(t, services.ValidateRedactionRule(&models.RedactionRule{Type: models.RedactionRegex, Pattern: "(secret"}))
+		assert.Error(t, services.ValidateRedactionRule(&models.RedactionRule{Type: models.RedactionRegex, Pattern: `\d*`}), "matches empty text")
+		assert.Error(t, services.ValidateRedactionRule(&models.RedactionRule{Type: models.RedactionKeywords, Keywords: []string{" "}}))
+		assert.Error(t, services.ValidateRedactionRule(&models.RedactionRule{Type: "mask", Pattern: "secret"}))
+	})
+}
+
+func TestRedactions(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("NoRules", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("ListEnabledRedactionRules", ctx).Return(nil, nil)
+
+		redactor, err := services.NewRedactions(repo).Redactor(ctx)
+
+		require.NoError(t, err)
+		assert.Nil(t, redactor)
+	})
+
+	t.Run("Rules", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("ListEnabledRedactionRules", ctx).Return([]*models.RedactionRule{
+			{Type: models.RedactionKeywords, Keywords: []string{"falcon"}, Placeholder: "***"},
+		}, nil)
+
+		redactor, err := services.NewRedactions(repo).Redactor(ctx)
+
+		require.NoError(t, err)
+		require.NotNil(t, redactor)
+		text, _ := redactor.Redact("Falcon")
+		assert.Equal(t, "***", text)
+	})
+}
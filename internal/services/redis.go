@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"kb-platform-gateway/internal/config"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// PubSubMessage is a message received on a subscribed Redis channel.
+// Channel is reported without the configured key prefix.
+type PubSubMessage struct {
+	Channel string
+	Payload string
+}
+
+// RedisClient is the gateway's shared fast store. Every key and channel
+// is namespaced with the configured key prefix.
+type RedisClient struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisClient connects to Redis and verifies the connection.
+func NewRedisClient(cfg *config.RedisConfig) (*RedisClient, error) {
+	opts := &redis.Options{
+		Addr:         cfg.Addr,
+		Username:     cfg.Username,
+		Password:     cfg.Password,
+		DB:           cfg.DB,
+		PoolSize:     cfg.PoolSize,
+		DialTimeout:  cfg.DialTimeout,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+	}
+	if cfg.TLSEnabled {
+		opts.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+
+	r := &RedisClient{client: redis.NewClient(opts), prefix: cfg.KeyPrefix}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.DialTimeout)
+	defer cancel()
+	if err := r.HealthCheck(ctx); err != nil {
+		r.client.Close()
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+
+	return r, nil
+}
+
+func (r *RedisClient) key(key string) string {
+	return r.prefix + key
+}
+
+func (r *RedisClient) Close() error {
+	return r.client.Close()
+}
+
+func (r *RedisClient) HealthCheck(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
+}
+
+func (r *RedisClient) Get(ctx context.Context, key string) (string, bool, error) {
+	value, err := r.client.Get(ctx, r.key(key)).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get %s: %w", key, err)
+	}
+	return value, true, nil
+}
+
+func (r *RedisClient) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	if err := r.client.Set(ctx, r.key(key), value, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to set %s: %w", key, err)
+	}
+	return nil
+}
+
+func (r *RedisClient) SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	ok, err := r.client.SetNX(ctx, r.key(key), value, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to set %s: %w", key, err)
+	}
+	return ok, nil
+}
+
+func (r *RedisClient) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = r.key(key)
+	}
+	if err := r.client.Del(ctx, prefixed...).Err(); err != nil {
+		return fmt.Errorf("failed to delete keys: %w", err)
+	}
+	return nil
+}
+
+func (r *RedisClient) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	var incr *redis.IntCmd
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		incr = pipe.Incr(ctx, r.key(key))
+		if ttl > 0 {
+			pipe.ExpireNX(ctx, r.key(key), ttl)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment %s: %w", key, err)
+	}
+	return incr.Val(), nil
+}
+
+func (r *RedisClient) Publish(ctx context.Context, channel, message string) error {
+	if err := r.client.Publish(ctx, r.key(channel), message).Err(); err != nil {
+		return fmt.Errorf("failed to publish to %s: %w", channel, err)
+	}
+	return nil
+}
+
+func (r *RedisClient) Subscribe(ctx context.Context, channels ...string) (<-chan PubSubMessage, error) {
+	prefixed := make([]string, len(channels))
+	for i, channel := range channels {
+		prefixed[i] = r.key(channel)
+	}
+
+	sub := r.client.Subscribe(ctx, prefixed...)
+	// Wait for the subscription to be confirmed so messages published
+	// after Subscribe returns are not missed.
+	if _, err := sub.Receive(ctx); err != nil {
+		sub.Close()
+		return nil, fmt.Errorf("failed to subscribe: %w", err)
+	}
+
+	out := make(chan PubSubMessage)
+	go func() {
+		defer close(out)
+		defer sub.Close()
+
+		in := sub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-in:
+				if !ok {
+					return
+				}
+				select {
+				case out <- PubSubMessage{Channel: strings.TrimPrefix(msg.Channel, r.prefix), Payload: msg.Payload}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return out, nil
+}
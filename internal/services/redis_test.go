@@ -0,0 +1,125 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"kb-platform-gateway/internal/config"
+	"kb-platform-gateway/internal/services"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRedis(t *testing.T) (*services.RedisClient, *miniredis.Miniredis) {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	client, err := services.NewRedisClient(&config.RedisConfig{
+		Addr:        server.Addr(),
+		KeyPrefix:   "kb:",
+		DialTimeout: time.Second,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+
+	return client, server
+}
+
+func TestRedisClient(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("NewRedisClient_Unreachable", func(t *testing.T) {
+		server := miniredis.RunT(t)
+		addr := server.Addr()
+		server.Close()
+
+		_, err := services.NewRedisClient(&config.RedisConfig{Addr: addr, DialTimeout: time.Second})
+		assert.Error(t, err)
+	})
+
+	t.Run("GetSet_Prefixed", func(t *testing.T) {
+		client, server := newTestRedis(t)
+
+		require.NoError(t, client.Set(ctx, "answer", "42", time.Minute))
+
+		value, found, err := client.Get(ctx, "answer")
+		require.NoError(t, err)
+		assert.True(t, found)
+		assert.Equal(t, "42", value)
+		assert.True(t, server.Exists("kb:answer"))
+		assert.Equal(t, time.Minute, server.TTL("kb:answer"))
+	})
+
+	t.Run("Get_Missing", func(t *testing.T) {
+		client, _ := newTestRedis(t)
+
+		value, found, err := client.Get(ctx, "missing")
+		require.NoError(t, err)
+		assert.False(t, found)
+		assert.Empty(t, value)
+	})
+
+	t.Run("SetNX_OnlyOnce", func(t *testing.T) {
+		client, _ := newTestRedis(t)
+
+		ok, err := client.SetNX(ctx, "idem", "first", time.Hour)
+		require.NoError(t, err)
+		assert.True(t, ok)
+
+		ok, err = client.SetNX(ctx, "idem", "second", time.Hour)
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		value, _, _ := client.Get(ctx, "idem")
+		assert.Equal(t, "first", value)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		client, server := newTestRedis(t)
+		require.NoError(t, client.Set(ctx, "a", "1", 0))
+		require.NoError(t, client.Set(ctx, "b", "2", 0))
+
+		require.NoError(t, client.Delete(ctx, "a", "b"))
+		assert.False(t, server.Exists("kb:a"))
+		assert.False(t, server.Exists("kb:b"))
+	})
+
+	t.Run("Incr_ExpiresWindow", func(t *testing.T) {
+		client, server := newTestRedis(t)
+
+		for want := int64(1); want <= 3; want++ {
+			n, err := client.Incr(ctx, "rate", time.Minute)
+			require.NoError(t, err)
+			assert.Equal(t, want, n)
+		}
+		assert.Equal(t, time.Minute, server.TTL("kb:rate"))
+
+		server.FastForward(time.Minute)
+		n, err := client.Incr(ctx, "rate", time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+	})
+
+	t.Run("PublishSubscribe", func(t *testing.T) {
+		client, _ := newTestRedis(t)
+		subCtx, cancel := context.WithCancel(ctx)
+
+		messages, err := client.Subscribe(subCtx, "events")
+		require.NoError(t, err)
+		require.NoError(t, client.Publish(ctx, "events", "hello"))
+
+		select {
+		case msg := <-messages:
+			assert.Equal(t, services.PubSubMessage{Channel: "events", Payload: "hello"}, msg)
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for message")
+		}
+
+		cancel()
+		for range messages {
+		}
+	})
+}
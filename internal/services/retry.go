@@ -0,0 +1,223 @@
+package services
+
+import (
+	"context"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"time"
+
+	"kb-platform-gateway/internal/config"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// retryPolicy retries idempotent core calls with exponential backoff and full
+// jitter, optionally hedging slow attempts.
+type retryPolicy struct {
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	hedgeDelay     time.Duration
+	idempotent     map[string]bool
+}
+
+func newRetryPolicy(cfg *config.RetryConfig) *retryPolicy {
+	p := &retryPolicy{
+		maxAttempts:    cfg.MaxAttempts,
+		initialBackoff: cfg.InitialBackoff,
+		maxBackoff:     cfg.MaxBackoff,
+		hedgeDelay:     cfg.HedgeDelay,
+		idempotent:     make(map[string]bool, len(cfg.IdempotentMethods)),
+	}
+	if p.maxAttempts < 1 {
+		p.maxAttempts = 1
+	}
+	for _, method := range cfg.IdempotentMethods {
+		p.idempotent[method] = true
+	}
+	return p
+}
+
+// backoff returns the jittered delay before the given retry (1-based).
+func (p *retryPolicy) backoff(retry int) time.Duration {
+	delay := p.initialBackoff << (retry - 1)
+	if delay <= 0 || delay > p.maxBackoff {
+		delay = p.maxBackoff
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return rand.N(delay)
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func isRetryableCode(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.ResourceExhausted, codes.Aborted:
+		return true
+	default:
+		return false
+	}
+}
+
+func (p *retryPolicy) unaryInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		name := method[strings.LastIndex(method, "/")+1:]
+		if p.maxAttempts == 1 || !p.idempotent[name] {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		if msg, ok := reply.(proto.Message); ok && p.hedgeDelay > 0 {
+			return p.invokeHedged(ctx, method, req, msg, cc, invoker, opts...)
+		}
+
+		var err error
+		for attempt := 1; attempt <= p.maxAttempts; attempt++ {
+			if attempt > 1 {
+				if sleepErr := sleep(ctx, p.backoff(attempt-1)); sleepErr != nil {
+					return err
+				}
+			}
+			err = invoker(ctx, method, req, reply, cc, opts...)
+			if err == nil || !isRetryableCode(err) {
+				return err
+			}
+		}
+		return err
+	}
+}
+
+// invokeHedged races up to maxAttempts attempts, starting a new one every
+// hedgeDelay (or immediately after a retryable failure), and copies the first
+// successful reply into reply.
+func (p *retryPolicy) invokeHedged(ctx context.Context, method string, req any, reply proto.Message, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		reply proto.Message
+		err   error
+	}
+	results := make(chan result, p.maxAttempts)
+
+	launched, pending := 0, 0
+	launch := func() {
+		out := reply.ProtoReflect().New().Interface()
+		launched++
+		pending++
+		go func() {
+			err := invoker(ctx, method, req, out, cc, opts...)
+			results <- result{reply: out, err: err}
+		}()
+	}
+
+	launch()
+	timer := time.NewTimer(p.hedgeDelay)
+	defer timer.Stop()
+
+	var lastErr error
+	for {
+		select {
+		case res := <-results:
+			pending--
+			if res.err == nil {
+				proto.Reset(reply)
+				proto.Merge(reply, res.reply)
+				return nil
+			}
+			lastErr = res.err
+			if !isRetryableCode(res.err) {
+				return res.err
+			}
+			if launched < p.maxAttempts {
+				launch()
+			} else if pending == 0 {
+				return lastErr
+			}
+		case <-timer.C:
+			if launched < p.maxAttempts {
+				launch()
+				timer.Reset(p.hedgeDelay)
+			}
+		case <-ctx.Done():
+			if lastErr != nil {
+				return lastErr
+			}
+			return status.FromContextError(ctx.Err()).Err()
+		}
+	}
+}
+
+// retryTransport retries idempotent HTTP requests on transport errors and
+// 502/503/504 responses.
+type retryTransport struct {
+	base   http.RoundTripper
+	policy *retryPolicy
+}
+
+func newRetryTransport(base http.RoundTripper, cfg *config.RetryConfig) http.RoundTripper {
+	return &retryTransport{base: base, policy: newRetryPolicy(cfg)}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !isIdempotentHTTPMethod(req.Method) || (req.Body != nil && req.GetBody == nil) {
+		return t.base.RoundTrip(req)
+	}
+
+	// A RoundTripper must not modify the request, so each retry sends a
+	// clone with a fresh body.
+	attemptReq := req
+	for attempt := 1; ; attempt++ {
+		resp, err := t.base.RoundTrip(attemptReq)
+		if (err == nil && !isRetryableHTTPStatus(resp.StatusCode)) || attempt >= t.policy.maxAttempts {
+			return resp, err
+		}
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		if sleepErr := sleep(req.Context(), t.policy.backoff(attempt)); sleepErr != nil {
+			if err == nil {
+				err = sleepErr
+			}
+			return nil, err
+		}
+		attemptReq = req.Clone(req.Context())
+		if req.GetBody != nil {
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return nil, bodyErr
+			}
+			attemptReq.Body = body
+		}
+	}
+}
+
+func isIdempotentHTTPMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
+
+func isRetryableHTTPStatus(code int) bool {
+	return code == http.StatusBadGateway || code == http.StatusServiceUnavailable || code == http.StatusGatewayTimeout
+}
@@ -2,15 +2,33 @@ package services
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"slices"
+	"strings"
 	"time"
 
 	"kb-platform-gateway/internal/config"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
 )
 
+// ErrInvalidPart is returned when completing a multipart upload whose
+// parts S3 rejects: a part is missing, its ETag does not match, or a part
+// other than the last is smaller than the 5 MiB minimum.
+var ErrInvalidPart = errors.New("invalid multipart upload part")
+
+// ErrKeyOutsidePrefix is returned for an object key outside S3_KEY_PREFIX,
+// which the client never reads or writes.
+var ErrKeyOutsidePrefix = errors.New("object key is outside the key prefix")
+
 type S3Client struct {
 	client *s3.Client
 	cfg    *config.S3Config
@@ -45,13 +63,64 @@ func NewS3Client(cfg *config.S3Config) (*S3Client, error) {
 	}, nil
 }
 
-func (c *S3Client) GeneratePresignedUploadURL(ctx context.Context, key string, expires time.Duration) (string, error) {
+// legacyFolders are the folders at the top of the bucket the gateway
+// stored objects in before it had a key prefix.
+var legacyFolders = []string{"documents/", "exports/", "scanned/", "quarantine/"}
+
+// ObjectKey returns the key an object named name is stored at: name under
+// the configured key prefix.
+func (c *S3Client) ObjectKey(name string) string {
+	return c.cfg.KeyPrefix + name
+}
+
+// SplitKey splits key into the key prefix it is stored under and the
+// object's name within it. A key outside the prefix, such as a legacy key,
+// has no prefix.
+func (c *S3Client) SplitKey(key string) (prefix, name string) {
+	if name, ok := strings.CutPrefix(key, c.cfg.KeyPrefix); ok {
+		return c.cfg.KeyPrefix, name
+	}
+	return "", key
+}
+
+// checkKey refuses a key outside the configured key prefix, so a key
+// built without it, or taken from another workspace, is never touched. A
+// ".." segment, which a proxy in front of the bucket might resolve, counts
+// as outside it. With LegacyKeys, a key in one of the legacyFolders, as
+// stored before the prefix was set, is allowed too; one under another
+// workspace's prefix still is not.
+func (c *S3Client) checkKey(key string) error {
+	if c.cfg.KeyPrefix == "" {
+		return nil
+	}
+	if slices.Contains(strings.Split(key, "/"), "..") {
+		return fmt.Errorf("%w: %s", ErrKeyOutsidePrefix, key)
+	}
+	if strings.HasPrefix(key, c.cfg.KeyPrefix) {
+		return nil
+	}
+	if c.cfg.LegacyKeys && slices.ContainsFunc(legacyFolders, func(folder string) bool {
+		return strings.HasPrefix(key, folder)
+	}) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrKeyOutsidePrefix, key)
+}
+
+func (c *S3Client) GeneratePresignedUploadURL(ctx context.Context, key, contentType string, expires time.Duration) (string, error) {
+	if err := c.checkKey(key); err != nil {
+		return "", err
+	}
 	presignClient := s3.NewPresignClient(c.client)
 
-	presignResult, err := presignClient.PresignPutObject(ctx, &s3.PutObjectInput{
+	input := &s3.PutObjectInput{
 		Bucket: &c.cfg.Bucket,
 		Key:    &key,
-	}, s3.WithPresignExpires(expires))
+	}
+	if contentType != "" {
+		input.ContentType = &contentType
+	}
+	presignResult, err := presignClient.PresignPutObject(ctx, input, s3.WithPresignExpires(expires))
 
 	if err != nil {
 		return "", err
@@ -61,6 +130,9 @@ func (c *S3Client) GeneratePresignedUploadURL(ctx context.Context, key string, e
 }
 
 func (c *S3Client) GeneratePresignedDownloadURL(ctx context.Context, key string, expires time.Duration) (string, error) {
+	if err := c.checkKey(key); err != nil {
+		return "", err
+	}
 	presignClient := s3.NewPresignClient(c.client)
 
 	presignResult, err := presignClient.PresignGetObject(ctx, &s3.GetObjectInput{
@@ -76,9 +148,202 @@ func (c *S3Client) GeneratePresignedDownloadURL(ctx context.Context, key string,
 }
 
 func (c *S3Client) DeleteObject(ctx context.Context, key string) error {
+	if err := c.checkKey(key); err != nil {
+		return err
+	}
 	_, err := c.client.DeleteObject(ctx, &s3.DeleteObjectInput{
 		Bucket: &c.cfg.Bucket,
 		Key:    &key,
 	})
 	return err
 }
+
+func (c *S3Client) UploadObject(ctx context.Context, key string, body io.ReadSeeker, contentType string) error {
+	if err := c.checkKey(key); err != nil {
+		return err
+	}
+	_, err := c.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      &c.cfg.Bucket,
+		Key:         &key,
+		Body:        body,
+		ContentType: &contentType,
+	})
+	return err
+}
+
+// StreamObject writes body, of unknown length, to key in parts of partSize
+// bytes, uploading up to concurrency parts at a time. Only the parts being
+// uploaded are held in memory. A failed upload is aborted.
+func (c *S3Client) StreamObject(ctx context.Context, key string, body io.Reader, contentType string, partSize int64, concurrency int) error {
+	if err := c.checkKey(key); err != nil {
+		return err
+	}
+	uploader := manager.NewUploader(c.client, func(u *manager.Uploader) {
+		u.PartSize = partSize
+		u.Concurrency = concurrency
+	})
+
+	input := &s3.PutObjectInput{
+		Bucket: &c.cfg.Bucket,
+		Key:    &key,
+		Body:   body,
+	}
+	if contentType != "" {
+		input.ContentType = &contentType
+	}
+	_, err := uploader.Upload(ctx, input)
+	return err
+}
+
+// ObjectExists reports whether an object is stored at key.
+func (c *S3Client) ObjectExists(ctx context.Context, key string) (bool, error) {
+	if err := c.checkKey(key); err != nil {
+		return false, err
+	}
+	_, err := c.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: &c.cfg.Bucket,
+		Key:    &key,
+	})
+	var notFound *types.NotFound
+	if errors.As(err, &notFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GetObject opens the object at key for reading. The caller closes it.
+func (c *S3Client) GetObject(ctx context.Context, key string) (io.ReadCloser, error) {
+	if err := c.checkKey(key); err != nil {
+		return nil, err
+	}
+	resp, err := c.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: &c.cfg.Bucket,
+		Key:    &key,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// CopyObject copies the object at srcKey to dstKey within the bucket.
+func (c *S3Client) CopyObject(ctx context.Context, srcKey, dstKey string) error {
+	if err := c.checkKey(srcKey); err != nil {
+		return err
+	}
+	if err := c.checkKey(dstKey); err != nil {
+		return err
+	}
+	segments := strings.Split(c.cfg.Bucket+"/"+srcKey, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	source := strings.Join(segments, "/")
+	_, err := c.client.CopyObject(ctx, &s3.CopyObjectInput{
+		Bucket:     &c.cfg.Bucket,
+		Key:        &dstKey,
+		CopySource: &source,
+	})
+	return err
+}
+
+func (c *S3Client) CreateMultipartUpload(ctx context.Context, key string) (string, error) {
+	if err := c.checkKey(key); err != nil {
+		return "", err
+	}
+	resp, err := c.client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket: &c.cfg.Bucket,
+		Key:    &key,
+	})
+	if err != nil {
+		return "", err
+	}
+	return aws.ToString(resp.UploadId), nil
+}
+
+func (c *S3Client) GeneratePresignedUploadPartURL(ctx context.Context, key, uploadID string, partNumber int, expires time.Duration) (string, error) {
+	if err := c.checkKey(key); err != nil {
+		return "", err
+	}
+	presignClient := s3.NewPresignClient(c.client)
+
+	presignResult, err := presignClient.PresignUploadPart(ctx, &s3.UploadPartInput{
+		Bucket:     &c.cfg.Bucket,
+		Key:        &key,
+		UploadId:   &uploadID,
+		PartNumber: aws.Int32(int32(partNumber)),
+	}, s3.WithPresignExpires(expires))
+	if err != nil {
+		return "", err
+	}
+
+	return presignResult.URL, nil
+}
+
+func (c *S3Client) CompleteMultipartUpload(ctx context.Context, key, uploadID string, parts []CompletedPart) error {
+	if err := c.checkKey(key); err != nil {
+		return err
+	}
+	completed := make([]types.CompletedPart, len(parts))
+	for i, part := range parts {
+		completed[i] = types.CompletedPart{
+			PartNumber: aws.Int32(int32(part.PartNumber)),
+			ETag:       aws.String(part.ETag),
+		}
+	}
+	_, err := c.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          &c.cfg.Bucket,
+		Key:             &key,
+		UploadId:        &uploadID,
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: completed},
+	})
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.ErrorCode() {
+		case "InvalidPart", "InvalidPartOrder", "EntityTooSmall":
+			return fmt.Errorf("%w: %s", ErrInvalidPart, apiErr.ErrorMessage())
+		}
+	}
+	return err
+}
+
+// AbortMultipartUpload succeeds if the upload is already gone.
+func (c *S3Client) AbortMultipartUpload(ctx context.Context, key, uploadID string) error {
+	if err := c.checkKey(key); err != nil {
+		return err
+	}
+	_, err := c.client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+		Bucket:   &c.cfg.Bucket,
+		Key:      &key,
+		UploadId: &uploadID,
+	})
+	var noSuchUpload *types.NoSuchUpload
+	if errors.As(err, &noSuchUpload) {
+		return nil
+	}
+	return err
+}
+
+// CheckAccess writes, reads back and deletes a probe object at key, to
+// verify the credentials may do everything the gateway does with the
+// bucket.
+func (c *S3Client) CheckAccess(ctx context.Context, key string) error {
+	if err := c.UploadObject(ctx, key, strings.NewReader("ok"), "text/plain"); err != nil {
+		return fmt.Errorf("failed to write %s: %w", key, err)
+	}
+	_, err := c.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: &c.cfg.Bucket,
+		Key:    &key,
+	})
+	if err != nil {
+		c.DeleteObject(ctx, key)
+		return fmt.Errorf("failed to read %s: %w", key, err)
+	}
+	if err := c.DeleteObject(ctx, key); err != nil {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
+	}
+	return nil
+}
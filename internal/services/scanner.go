@@ -0,0 +1,124 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"kb-platform-gateway/internal/config"
+)
+
+// clamAVChunkSize is the size of the chunks a file is streamed to clamd
+// in.
+const clamAVChunkSize = 64 << 10
+
+// ScanResult is the verdict of a malware scan.
+type ScanResult struct {
+	Infected bool
+	// Signature names the malware found in an infected file.
+	Signature string
+}
+
+// ClamAVScanner scans files with a clamd daemon, streaming them to it with
+// the INSTREAM command so it needs no access to the bucket.
+type ClamAVScanner struct {
+	addr    string
+	timeout time.Duration
+}
+
+func NewClamAVScanner(cfg *config.ScanConfig) *ClamAVScanner {
+	return &ClamAVScanner{addr: cfg.ClamAVAddr, timeout: cfg.Timeout}
+}
+
+func (s *ClamAVScanner) Scan(ctx context.Context, body io.Reader) (*ScanResult, error) {
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to clamd: %w", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() {
+		conn.SetDeadline(time.Now())
+	})
+	defer stop()
+
+	if err := streamToClamAV(conn, body); err != nil {
+		// clamd replies before closing the connection when it refuses
+		// the stream, e.g. beyond its StreamMaxLength.
+		if reply, replyErr := readClamAVReply(conn); replyErr == nil {
+			return parseClamAVReply(reply)
+		}
+		return nil, err
+	}
+	reply, err := readClamAVReply(conn)
+	if err != nil {
+		return nil, err
+	}
+	return parseClamAVReply(reply)
+}
+
+// streamToClamAV sends body as an INSTREAM command: chunks prefixed with
+// their length, ended by an empty one.
+func streamToClamAV(conn net.Conn, body io.Reader) error {
+	if _, err := io.WriteString(conn, "zINSTREAM\x00"); err != nil {
+		return fmt.Errorf("failed to send to clamd: %w", err)
+	}
+	buf := make([]byte, 4+clamAVChunkSize)
+	for {
+		n, err := io.ReadFull(body, buf[4:])
+		if n > 0 {
+			binary.BigEndian.PutUint32(buf[:4], uint32(n))
+			if _, err := conn.Write(buf[:4+n]); err != nil {
+				return fmt.Errorf("failed to send to clamd: %w", err)
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read file: %w", err)
+		}
+	}
+	if _, err := conn.Write(make([]byte, 4)); err != nil {
+		return fmt.Errorf("failed to send to clamd: %w", err)
+	}
+	return nil
+}
+
+// readClamAVReply reads clamd's reply, which ends with a NUL.
+func readClamAVReply(conn net.Conn) (string, error) {
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && (reply == "" || !errors.Is(err, io.EOF)) {
+		return "", fmt.Errorf("failed to read clamd reply: %w", err)
+	}
+	return strings.TrimSpace(strings.TrimSuffix(reply, "\x00")), nil
+}
+
+// parseClamAVReply reads clamd's verdict: "stream: OK", "stream: <name>
+// FOUND" or "<reason> ERROR".
+func parseClamAVReply(reply string) (*ScanResult, error) {
+	verdict := strings.TrimPrefix(reply, "stream: ")
+	switch {
+	case verdict == "OK":
+		return &ScanResult{}, nil
+	case strings.HasSuffix(verdict, " FOUND"):
+		return &ScanResult{Infected: true, Signature: strings.TrimSuffix(verdict, " FOUND")}, nil
+	default:
+		return nil, fmt.Errorf("clamd: %s", reply)
+	}
+}
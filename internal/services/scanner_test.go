@@ -0,0 +1,106 @@
+package services_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"kb-platform-gateway/internal/config"
+	"kb-platform-gateway/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClamd accepts one INSTREAM command, collecting the streamed file,
+// and answers with reply.
+func fakeClamd(t *testing.T, reply string) (string, <-chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		command, err := r.ReadString(0)
+		if err != nil || command != "zINSTREAM\x00" {
+			return
+		}
+		var file strings.Builder
+		for {
+			var size uint32
+			if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+				return
+			}
+			if size == 0 {
+				break
+			}
+			if _, err := io.CopyN(&file, r, int64(size)); err != nil {
+				return
+			}
+		}
+		received <- file.String()
+		io.WriteString(conn, reply+"\x00")
+	}()
+	return ln.Addr().String(), received
+}
+
+func TestClamAVScanner(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Clean", func(t *testing.T) {
+		addr, received := fakeClamd(t, "stream: OK")
+		scanner := services.NewClamAVScanner(&config.ScanConfig{ClamAVAddr: addr, Timeout: time.Second})
+		body := strings.Repeat("a", 100<<10)
+
+		result, err := scanner.Scan(ctx, strings.NewReader(body))
+
+		require.NoError(t, err)
+		assert.False(t, result.Infected)
+		assert.Equal(t, body, <-received, "the file is streamed in chunks")
+	})
+
+	t.Run("Infected", func(t *testing.T) {
+		addr, _ := fakeClamd(t, "stream: Eicar-Signature FOUND")
+		scanner := services.NewClamAVScanner(&config.ScanConfig{ClamAVAddr: addr, Timeout: time.Second})
+
+		result, err := scanner.Scan(ctx, strings.NewReader("X5O!P%@AP"))
+
+		require.NoError(t, err)
+		assert.True(t, result.Infected)
+		assert.Equal(t, "Eicar-Signature", result.Signature)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		addr, _ := fakeClamd(t, "INSTREAM size limit exceeded. ERROR")
+		scanner := services.NewClamAVScanner(&config.ScanConfig{ClamAVAddr: addr, Timeout: time.Second})
+
+		_, err := scanner.Scan(ctx, strings.NewReader("data"))
+
+		assert.ErrorContains(t, err, "INSTREAM size limit exceeded")
+	})
+
+	t.Run("Unreachable", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		addr := ln.Addr().String()
+		ln.Close()
+		scanner := services.NewClamAVScanner(&config.ScanConfig{ClamAVAddr: addr, Timeout: time.Second})
+
+		_, err = scanner.Scan(ctx, strings.NewReader("data"))
+
+		assert.ErrorContains(t, err, "failed to connect to clamd")
+	})
+}
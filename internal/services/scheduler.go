@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"sync"
+)
+
+// RequestClass is the priority of a request; lower values are served
+// first.
+type RequestClass int
+
+const (
+	// ClassInteractive is chat: queries and conversations.
+	ClassInteractive RequestClass = iota
+	// ClassStandard is document and settings reads and edits.
+	ClassStandard
+	// ClassBatch is bulk work: uploads, exports, syncs and admin tasks.
+	ClassBatch
+
+	requestClassCount
+)
+
+// RequestScheduler admits at most its capacity of requests at a time, in
+// priority order. Lower classes may only fill part of the capacity, so
+// chat keeps headroom while a bulk import runs: standard requests three
+// quarters, batch requests half. Requests over their class's share wait,
+// and are admitted ahead of lower classes once slots free up.
+type RequestScheduler struct {
+	mu       sync.Mutex
+	capacity int
+	inFlight int
+	waiting  [requestClassCount][]chan struct{}
+}
+
+// NewRequestScheduler returns a scheduler admitting capacity requests at
+// a time, or one if capacity is not positive.
+func NewRequestScheduler(capacity int) *RequestScheduler {
+	return &RequestScheduler{capacity: max(capacity, 1)}
+}
+
+// Acquire waits until a request of class is admitted and returns the
+// function to call once it is served. If ctx is done first the request is
+// shed and ctx's error returned.
+func (s *RequestScheduler) Acquire(ctx context.Context, class RequestClass) (func(), error) {
+	s.mu.Lock()
+	if s.admits(class) {
+		s.inFlight++
+		s.mu.Unlock()
+		return s.release, nil
+	}
+	ready := make(chan struct{})
+	s.waiting[class] = append(s.waiting[class], ready)
+	s.mu.Unlock()
+
+	select {
+	case <-ready:
+		return s.release, nil
+	case <-ctx.Done():
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.dequeue(class, ready) {
+		// Admitted just as ctx ended; hand the slot on.
+		s.inFlight--
+		s.dispatch()
+	}
+	return nil, ctx.Err()
+}
+
+// InFlight returns the number of admitted requests not yet released.
+func (s *RequestScheduler) InFlight() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.inFlight
+}
+
+func (s *RequestScheduler) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inFlight--
+	s.dispatch()
+}
+
+// limit is the number of slots requests of class may fill.
+func (s *RequestScheduler) limit(class RequestClass) int {
+	switch class {
+	case ClassInteractive:
+		return s.capacity
+	case ClassStandard:
+		return max(s.capacity*3/4, 1)
+	default:
+		return max(s.capacity/2, 1)
+	}
+}
+
+// admits reports whether a new request of class may run now: its class
+// has a free slot and no request of its class or a higher one is waiting.
+func (s *RequestScheduler) admits(class RequestClass) bool {
+	for c := ClassInteractive; c <= class; c++ {
+		if len(s.waiting[c]) > 0 {
+			return false
+		}
+	}
+	return s.inFlight < s.limit(class)
+}
+
+// dispatch admits waiting requests, highest class first, while their
+// class has free slots. Lower classes have fewer slots, so once a class
+// cannot be admitted no lower one can.
+func (s *RequestScheduler) dispatch() {
+	for class := ClassInteractive; class < requestClassCount; class++ {
+		for len(s.waiting[class]) > 0 {
+			if s.inFlight >= s.limit(class) {
+				return
+			}
+			s.inFlight++
+			close(s.waiting[class][0])
+			s.waiting[class] = s.waiting[class][1:]
+		}
+	}
+}
+
+// dequeue removes a waiting request, reporting false if it was admitted
+// already.
+func (s *RequestScheduler) dequeue(class RequestClass, ready chan struct{}) bool {
+	for i, waiting := range s.waiting[class] {
+		if waiting == ready {
+			s.waiting[class] = append(s.waiting[class][:i], s.waiting[class][i+1:]...)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"kb-platform-gateway/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// acquireAsync acquires a slot in the background, returning the channel
+// its release function is sent on once admitted.
+func acquireAsync(s *services.RequestScheduler, class services.RequestClass) <-chan func() {
+	admitted := make(chan func(), 1)
+	go func() {
+		release, err := s.Acquire(context.Background(), class)
+		if err == nil {
+			admitted <- release
+		}
+	}()
+	return admitted
+}
+
+// waitFor waits until the scheduler has n requests in flight.
+func waitFor(t *testing.T, s *services.RequestScheduler, n int) {
+	t.Helper()
+	require.Eventually(t, func() bool { return s.InFlight() == n }, time.Second, time.Millisecond)
+}
+
+func TestRequestScheduler(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Acquire_ReservesHeadroomForChat", func(t *testing.T) {
+		s := services.NewRequestScheduler(4)
+		for i := 0; i < 2; i++ {
+			_, err := s.Acquire(ctx, services.ClassBatch)
+			require.NoError(t, err)
+		}
+
+		// Batch work may only fill half the slots.
+		batchCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		_, err := s.Acquire(batchCtx, services.ClassBatch)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+		_, err = s.Acquire(ctx, services.ClassStandard)
+		require.NoError(t, err)
+		_, err = s.Acquire(ctx, services.ClassInteractive)
+		require.NoError(t, err)
+		assert.Equal(t, 4, s.InFlight())
+	})
+
+	t.Run("Release_AdmitsHigherClassFirst", func(t *testing.T) {
+		s := services.NewRequestScheduler(2)
+		first, err := s.Acquire(ctx, services.ClassInteractive)
+		require.NoError(t, err)
+		_, err = s.Acquire(ctx, services.ClassInteractive)
+		require.NoError(t, err)
+
+		batch := acquireAsync(s, services.ClassBatch)
+		time.Sleep(5 * time.Millisecond)
+		chat := acquireAsync(s, services.ClassInteractive)
+		time.Sleep(5 * time.Millisecond)
+
+		first()
+
+		select {
+		case <-chat:
+		case <-time.After(time.Second):
+			t.Fatal("interactive request was not admitted")
+		}
+		select {
+		case <-batch:
+			t.Fatal("batch request was admitted ahead of its share")
+		case <-time.After(10 * time.Millisecond):
+		}
+		assert.Equal(t, 2, s.InFlight())
+	})
+
+	t.Run("Acquire_ShedsWhenWaitEnds", func(t *testing.T) {
+		s := services.NewRequestScheduler(1)
+		release, err := s.Acquire(ctx, services.ClassStandard)
+		require.NoError(t, err)
+
+		shedCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		_, err = s.Acquire(shedCtx, services.ClassStandard)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+		// The shed request no longer holds its place in the queue.
+		release()
+		waitFor(t, s, 0)
+		_, err = s.Acquire(ctx, services.ClassStandard)
+		assert.NoError(t, err)
+	})
+}
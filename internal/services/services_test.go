@@ -2,21 +2,33 @@ package services_test
 
 import (
 	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"kb-platform-gateway/internal/config"
+	"kb-platform-gateway/internal/models"
+	"kb-platform-gateway/internal/services"
 	"kb-platform-gateway/internal/services/mocks"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestS3Client(t *testing.T) {
 	t.Run("GeneratePresignedUploadURL_Success", func(t *testing.T) {
 		mockS3Client := mocks.NewMockS3Client()
 		ctx := context.Background()
-		mockS3Client.On("GeneratePresignedUploadURL", ctx, "documents/test.pdf", 15*time.Minute).Return("https://s3.example.com/upload?signature=abc", nil)
+		mockS3Client.On("GeneratePresignedUploadURL", ctx, "documents/test.pdf", "", 15*time.Minute).Return("https://s3.example.com/upload?signature=abc", nil)
 
-		url, err := mockS3Client.GeneratePresignedUploadURL(ctx, "documents/test.pdf", 15*time.Minute)
+		url, err := mockS3Client.GeneratePresignedUploadURL(ctx, "documents/test.pdf", "", 15*time.Minute)
 
 		assert.NoError(t, err)
 		assert.Equal(t, "https://s3.example.com/upload?signature=abc", url)
@@ -56,14 +68,59 @@ func TestS3Client(t *testing.T) {
 		assert.Error(t, err)
 		mockS3Client.AssertExpectations(t)
 	})
+
+	t.Run("KeyPrefix_RefusesOtherKeys", func(t *testing.T) {
+		client, err := services.NewS3Client(&config.S3Config{
+			Bucket: "kb-documents", Region: "us-east-1", Endpoint: "http://127.0.0.1:1", KeyPrefix: "acme/",
+		})
+		require.NoError(t, err)
+		ctx := context.Background()
+
+		url, err := client.GeneratePresignedDownloadURL(ctx, "acme/documents/doc-1/a.pdf", time.Minute)
+		require.NoError(t, err)
+		assert.Contains(t, url, "acme/documents/doc-1/a.pdf")
+
+		for _, key := range []string{"documents/doc-1/a.pdf", "other/documents/doc-1/a.pdf", "acme/../other/a.pdf"} {
+			_, err := client.GeneratePresignedDownloadURL(ctx, key, time.Minute)
+			assert.ErrorIs(t, err, services.ErrKeyOutsidePrefix, key)
+			assert.ErrorIs(t, client.DeleteObject(ctx, key), services.ErrKeyOutsidePrefix, key)
+		}
+	})
+
+	t.Run("KeyPrefix_LegacyKeys", func(t *testing.T) {
+		client, err := services.NewS3Client(&config.S3Config{
+			Bucket: "kb-documents", Region: "us-east-1", Endpoint: "http://127.0.0.1:1", KeyPrefix: "acme/", LegacyKeys: true,
+		})
+		require.NoError(t, err)
+		ctx := context.Background()
+
+		assert.Equal(t, "acme/exports/e-1/logs.csv", client.ObjectKey("exports/e-1/logs.csv"))
+		prefix, name := client.SplitKey("acme/documents/doc-1/a.pdf")
+		assert.Equal(t, "acme/", prefix)
+		assert.Equal(t, "documents/doc-1/a.pdf", name)
+		prefix, name = client.SplitKey("documents/doc-1/a.pdf")
+		assert.Empty(t, prefix)
+		assert.Equal(t, "documents/doc-1/a.pdf", name)
+
+		// Objects stored before the prefix was set stay reachable.
+		for _, key := range []string{"documents/doc-1/a.pdf", "scanned/documents/doc-1/a.pdf", "exports/e-1/logs.csv"} {
+			_, err := client.GeneratePresignedDownloadURL(ctx, key, time.Minute)
+			assert.NoError(t, err, key)
+		}
+		for _, key := range []string{"other/documents/doc-1/a.pdf", "gateway-check/probe", "documents/../other/a.pdf"} {
+			_, err := client.GeneratePresignedDownloadURL(ctx, key, time.Minute)
+			assert.ErrorIs(t, err, services.ErrKeyOutsidePrefix, key)
+		}
+	})
 }
 
 func TestPythonCoreClient(t *testing.T) {
 	t.Run("HealthCheck_Success", func(t *testing.T) {
-		mockClient := mocks.NewMockPythonCoreClient()
-		mockClient.On("HealthCheck").Return(map[string]string{"python_core": "ok"}, nil)
+		mockClient := mocks.NewMockCoreService()
+		ctx := context.Background()
+		mockClient.On("HealthCheck", ctx).Return(map[string]string{"python_core": "ok"}, nil)
 
-		deps, err := mockClient.HealthCheck()
+		deps, err := mockClient.HealthCheck(ctx)
 
 		assert.NoError(t, err)
 		assert.Equal(t, "ok", deps["python_core"])
@@ -71,10 +128,11 @@ func TestPythonCoreClient(t *testing.T) {
 	})
 
 	t.Run("HealthCheck_Error", func(t *testing.T) {
-		mockClient := mocks.NewMockPythonCoreClient()
-		mockClient.On("HealthCheck").Return(nil, assert.AnError)
+		mockClient := mocks.NewMockCoreService()
+		ctx := context.Background()
+		mockClient.On("HealthCheck", ctx).Return(nil, assert.AnError)
 
-		deps, err := mockClient.HealthCheck()
+		deps, err := mockClient.HealthCheck(ctx)
 
 		assert.Error(t, err)
 		assert.Nil(t, deps)
@@ -86,9 +144,10 @@ func TestTemporalClient(t *testing.T) {
 	t.Run("StartUploadWorkflow_Success", func(t *testing.T) {
 		mockClient := mocks.NewMockTemporalClient()
 		ctx := context.Background()
-		mockClient.On("StartUploadWorkflow", ctx, "doc-123", "s3://bucket/doc-123/test.pdf").Return("workflow-id-123", nil)
+		input := services.UploadWorkflowInput{DocumentID: "doc-123", S3Key: "s3://bucket/doc-123/test.pdf"}
+		mockClient.On("StartUploadWorkflow", ctx, input).Return("workflow-id-123", nil)
 
-		workflowID, err := mockClient.StartUploadWorkflow(ctx, "doc-123", "s3://bucket/doc-123/test.pdf")
+		workflowID, err := mockClient.StartUploadWorkflow(ctx, input)
 
 		assert.NoError(t, err)
 		assert.Equal(t, "workflow-id-123", workflowID)
@@ -98,9 +157,10 @@ func TestTemporalClient(t *testing.T) {
 	t.Run("StartUploadWorkflow_Error", func(t *testing.T) {
 		mockClient := mocks.NewMockTemporalClient()
 		ctx := context.Background()
-		mockClient.On("StartUploadWorkflow", ctx, "doc-123", "s3://bucket/doc-123/test.pdf").Return("", assert.AnError)
+		input := services.UploadWorkflowInput{DocumentID: "doc-123", S3Key: "s3://bucket/doc-123/test.pdf"}
+		mockClient.On("StartUploadWorkflow", ctx, input).Return("", assert.AnError)
 
-		workflowID, err := mockClient.StartUploadWorkflow(ctx, "doc-123", "s3://bucket/doc-123/test.pdf")
+		workflowID, err := mockClient.StartUploadWorkflow(ctx, input)
 
 		assert.Error(t, err)
 		assert.Empty(t, workflowID)
@@ -110,9 +170,9 @@ func TestTemporalClient(t *testing.T) {
 	t.Run("SignalUploadComplete_Success", func(t *testing.T) {
 		mockClient := mocks.NewMockTemporalClient()
 		ctx := context.Background()
-		mockClient.On("SignalUploadComplete", ctx, "doc-123").Return(nil)
+		mockClient.On("SignalUploadComplete", ctx, "doc-123", "documents/doc-123/a.pdf", (*models.ProcessingOptions)(nil)).Return(nil)
 
-		err := mockClient.SignalUploadComplete(ctx, "doc-123")
+		err := mockClient.SignalUploadComplete(ctx, "doc-123", "documents/doc-123/a.pdf", nil)
 
 		assert.NoError(t, err)
 		mockClient.AssertExpectations(t)
@@ -121,9 +181,9 @@ func TestTemporalClient(t *testing.T) {
 	t.Run("SignalUploadComplete_Error", func(t *testing.T) {
 		mockClient := mocks.NewMockTemporalClient()
 		ctx := context.Background()
-		mockClient.On("SignalUploadComplete", ctx, "doc-123").Return(assert.AnError)
+		mockClient.On("SignalUploadComplete", ctx, "doc-123", "documents/doc-123/a.pdf", (*models.ProcessingOptions)(nil)).Return(assert.AnError)
 
-		err := mockClient.SignalUploadComplete(ctx, "doc-123")
+		err := mockClient.SignalUploadComplete(ctx, "doc-123", "documents/doc-123/a.pdf", nil)
 
 		assert.Error(t, err)
 		mockClient.AssertExpectations(t)
@@ -132,9 +192,10 @@ func TestTemporalClient(t *testing.T) {
 	t.Run("StartIndexWorkflow_Success", func(t *testing.T) {
 		mockClient := mocks.NewMockTemporalClient()
 		ctx := context.Background()
-		mockClient.On("StartIndexWorkflow", ctx, "doc-123").Return("index-workflow-123", nil)
+		input := services.IndexWorkflowInput{DocumentID: "doc-123"}
+		mockClient.On("StartIndexWorkflow", ctx, input).Return("index-workflow-123", nil)
 
-		workflowID, err := mockClient.StartIndexWorkflow(ctx, "doc-123")
+		workflowID, err := mockClient.StartIndexWorkflow(ctx, input)
 
 		assert.NoError(t, err)
 		assert.Equal(t, "index-workflow-123", workflowID)
@@ -157,9 +218,9 @@ func TestQdrantClient(t *testing.T) {
 	t.Run("DeleteDocumentVectors_Success", func(t *testing.T) {
 		mockClient := mocks.NewMockQdrantClient()
 		ctx := context.Background()
-		mockClient.On("DeleteDocumentVectors", ctx, "doc-123").Return(nil)
+		mockClient.On("DeleteDocumentVectors", ctx, "documents", "doc-123").Return(nil)
 
-		err := mockClient.DeleteDocumentVectors(ctx, "doc-123")
+		err := mockClient.DeleteDocumentVectors(ctx, "documents", "doc-123")
 
 		assert.NoError(t, err)
 		mockClient.AssertExpectations(t)
@@ -168,9 +229,9 @@ func TestQdrantClient(t *testing.T) {
 	t.Run("DeleteDocumentVectors_Error", func(t *testing.T) {
 		mockClient := mocks.NewMockQdrantClient()
 		ctx := context.Background()
-		mockClient.On("DeleteDocumentVectors", ctx, "doc-123").Return(assert.AnError)
+		mockClient.On("DeleteDocumentVectors", ctx, "documents", "doc-123").Return(assert.AnError)
 
-		err := mockClient.DeleteDocumentVectors(ctx, "doc-123")
+		err := mockClient.DeleteDocumentVectors(ctx, "documents", "doc-123")
 
 		assert.Error(t, err)
 		mockClient.AssertExpectations(t)
@@ -186,3 +247,204 @@ func TestQdrantClient(t *testing.T) {
 		mockClient.AssertExpectations(t)
 	})
 }
+
+func newTestCoreServer(t *testing.T, handler http.HandlerFunc) (string, int) {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(u.Port())
+	require.NoError(t, err)
+	return u.Hostname(), port
+}
+
+// roundTripFunc is an http.RoundTripper calling itself.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestPythonCoreClientRetry(t *testing.T) {
+	newClient := func(t *testing.T, host string, port int) *services.PythonCoreClient {
+		client, err := services.NewPythonCoreClient(&config.ServicesConfig{
+			PythonCoreHost: host,
+			PythonCorePort: port,
+			PythonCoreRetry: config.RetryConfig{
+				MaxAttempts:    3,
+				InitialBackoff: time.Millisecond,
+				MaxBackoff:     5 * time.Millisecond,
+			},
+		})
+		require.NoError(t, err)
+		return client
+	}
+
+	t.Run("HealthCheck_RetriesUnavailable", func(t *testing.T) {
+		var calls atomic.Int32
+		host, port := newTestCoreServer(t, func(w http.ResponseWriter, r *http.Request) {
+			if calls.Add(1) < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			_, _ = w.Write([]byte(`{"status":"ready","dependencies":{"qdrant":"ok"}}`))
+		})
+
+		client := newClient(t, host, port)
+		deps, err := client.HealthCheck(context.Background())
+
+		require.NoError(t, err)
+		assert.Equal(t, "ok", deps["qdrant"])
+		assert.Equal(t, int32(3), calls.Load())
+	})
+
+	t.Run("HealthCheck_GivesUpAfterMaxAttempts", func(t *testing.T) {
+		var calls atomic.Int32
+		host, port := newTestCoreServer(t, func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(http.StatusBadGateway)
+		})
+
+		client := newClient(t, host, port)
+		_, err := client.HealthCheck(context.Background())
+
+		assert.Error(t, err)
+		assert.Equal(t, int32(3), calls.Load())
+	})
+
+	t.Run("Transport_LeavesRequestUnchanged", func(t *testing.T) {
+		var sent []*http.Request
+		var bodies []string
+		base := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			body, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+			sent, bodies = append(sent, r), append(bodies, string(body))
+			code := http.StatusServiceUnavailable
+			if len(sent) == 3 {
+				code = http.StatusOK
+			}
+			return &http.Response{StatusCode: code, Body: io.NopCloser(strings.NewReader(""))}, nil
+		})
+		transport := services.NewRetryTransport(base, &config.RetryConfig{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond})
+		req, err := http.NewRequest(http.MethodGet, "http://core/api/v1/health", strings.NewReader("payload"))
+		require.NoError(t, err)
+		body := req.Body
+
+		resp, err := transport.RoundTrip(req)
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, []string{"payload", "payload", "payload"}, bodies)
+		assert.True(t, body == req.Body, "the caller's request keeps its body")
+		assert.NotSame(t, req, sent[1])
+		assert.NotSame(t, req, sent[2])
+	})
+
+	t.Run("Query_NotRetried", func(t *testing.T) {
+		var calls atomic.Int32
+		host, port := newTestCoreServer(t, func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		})
+
+		client := newClient(t, host, port)
+		_, err := client.Query(context.Background(), models.CoreQueryRequest{Query: "hello", TopK: 5})
+
+		assert.Error(t, err)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+}
+
+func TestPythonCoreClientResources(t *testing.T) {
+	t.Run("Query_SendsAccessGroups", func(t *testing.T) {
+		for _, tc := range []struct {
+			groups []string
+			want   string
+		}{
+			{nil, `null`},
+			{[]string{}, `[]`},
+			{[]string{"hr", "legal"}, `["hr","legal"]`},
+		} {
+			var sent json.RawMessage
+			host, port := newTestCoreServer(t, func(w http.ResponseWriter, r *http.Request) {
+				var body map[string]json.RawMessage
+				assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+				sent = body["access_groups"]
+				w.WriteHeader(http.StatusServiceUnavailable)
+			})
+			client, err := services.NewPythonCoreClient(&config.ServicesConfig{PythonCoreHost: host, PythonCorePort: port})
+			require.NoError(t, err)
+
+			_, err = client.Query(context.Background(), models.CoreQueryRequest{Query: "hello", TopK: 5, AccessGroups: tc.groups})
+
+			assert.Error(t, err)
+			assert.JSONEq(t, tc.want, string(sent))
+		}
+	})
+
+	t.Run("GetDocument_Success", func(t *testing.T) {
+		host, port := newTestCoreServer(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/api/v1/documents/doc-123", r.URL.Path)
+			_, _ = w.Write([]byte(`{"id":"doc-123","filename":"test.pdf","status":"complete"}`))
+		})
+		client, err := services.NewPythonCoreClient(&config.ServicesConfig{PythonCoreHost: host, PythonCorePort: port})
+		require.NoError(t, err)
+
+		doc, err := client.GetDocument(context.Background(), "doc-123")
+
+		require.NoError(t, err)
+		assert.Equal(t, "test.pdf", doc.Filename)
+		assert.Equal(t, "complete", doc.Status)
+	})
+
+	t.Run("SaveMessage_NotFound", func(t *testing.T) {
+		host, port := newTestCoreServer(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, http.MethodPost, r.Method)
+			w.WriteHeader(http.StatusNotFound)
+		})
+		client, err := services.NewPythonCoreClient(&config.ServicesConfig{PythonCoreHost: host, PythonCorePort: port})
+		require.NoError(t, err)
+
+		msg, err := client.SaveMessage(context.Background(), "conv-1", "user", "hi", nil)
+
+		assert.Error(t, err)
+		assert.Nil(t, msg)
+	})
+
+	t.Run("Evaluate_Success", func(t *testing.T) {
+		host, port := newTestCoreServer(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/api/v1/evaluate", r.URL.Path)
+			var req models.CoreEvaluationRequest
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			assert.Equal(t, models.CoreEvaluationRequest{Question: "q", ExpectedAnswer: "e", Answer: "a"}, req)
+			_, _ = w.Write([]byte(`{"score":0.8,"metrics":{"faithfulness":0.9}}`))
+		})
+		client, err := services.NewPythonCoreClient(&config.ServicesConfig{PythonCoreHost: host, PythonCorePort: port})
+		require.NoError(t, err)
+
+		score, err := client.Evaluate(context.Background(), "q", "e", "a")
+
+		require.NoError(t, err)
+		assert.Equal(t, 0.8, score.Score)
+		assert.Equal(t, 0.9, score.Metrics["faithfulness"])
+	})
+
+	t.Run("Summarize_Success", func(t *testing.T) {
+		host, port := newTestCoreServer(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/api/v1/summarize", r.URL.Path)
+			var req models.CoreSummaryRequest
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			assert.Equal(t, "Asked about refunds.", req.Summary)
+			require.Len(t, req.Messages, 1)
+			assert.Equal(t, "And for returns?", req.Messages[0].Content)
+			_, _ = w.Write([]byte(`{"summary":"Asked about refunds and returns."}`))
+		})
+		client, err := services.NewPythonCoreClient(&config.ServicesConfig{PythonCoreHost: host, PythonCorePort: port})
+		require.NoError(t, err)
+
+		summary, err := client.Summarize(context.Background(), "Asked about refunds.", []*models.Message{{Role: "user", Content: "And for returns?"}})
+
+		require.NoError(t, err)
+		assert.Equal(t, "Asked about refunds and returns.", summary)
+	})
+}
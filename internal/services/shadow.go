@@ -0,0 +1,184 @@
+package services
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"kb-platform-gateway/internal/config"
+	"kb-platform-gateway/internal/models"
+
+	"github.com/rs/zerolog"
+)
+
+// ShadowMirror mirrors a sample of queries to a shadow core, such as a
+// staging deployment of a new core version, and compares its latencies with
+// the primary core's. Mirrored queries run in the background, never affect
+// the primary response, and their answers are discarded.
+type ShadowMirror struct {
+	client CoreServiceInterface
+	logger zerolog.Logger
+
+	percent  int
+	timeout  time.Duration
+	inFlight chan struct{}
+
+	// mu guards stats and the latencies of the latest comparisons, which
+	// are added in pairs.
+	mu             sync.Mutex
+	stats          models.ShadowStats
+	primaryLatency latencyWindow
+	shadowLatency  latencyWindow
+
+	ctx       context.Context
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+}
+
+// shadowResult is how one core handled a query.
+type shadowResult struct {
+	latency   time.Duration
+	completed bool
+}
+
+func NewShadowMirror(cfg *config.ShadowConfig, client CoreServiceInterface, logger zerolog.Logger) *ShadowMirror {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &ShadowMirror{
+		client:   client,
+		logger:   logger,
+		percent:  cfg.Percent,
+		timeout:  cfg.Timeout,
+		inFlight: make(chan struct{}, max(cfg.MaxInFlight, 1)),
+		stats:    models.ShadowStats{Percent: cfg.Percent, Since: time.Now()},
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Close interrupts mirrored queries and waits for them to finish.
+func (m *ShadowMirror) Close() {
+	m.closeOnce.Do(func() {
+		m.cancel()
+		m.wg.Wait()
+	})
+}
+
+// Mirror sends query to the shadow core if it is sampled. The returned
+// function reports how the primary core handled the query, and must be
+// called once it has finished; it is nil if the query is not mirrored.
+func (m *ShadowMirror) Mirror(query models.CoreQueryRequest) func(latency time.Duration, completed bool) {
+	if rand.IntN(100) >= m.percent || m.ctx.Err() != nil {
+		return nil
+	}
+	select {
+	case m.inFlight <- struct{}{}:
+	default:
+		m.mu.Lock()
+		m.stats.Skipped++
+		m.mu.Unlock()
+		return nil
+	}
+
+	m.mu.Lock()
+	m.stats.Mirrored++
+	m.mu.Unlock()
+
+	primary := make(chan shadowResult, 1)
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		shadow := m.run(query)
+		<-m.inFlight
+		// Queries interrupted by Close are not held against the shadow
+		// core.
+		if m.ctx.Err() != nil {
+			return
+		}
+
+		select {
+		case p := <-primary:
+			m.record(p, shadow)
+		case <-m.ctx.Done():
+		}
+	}()
+
+	var once sync.Once
+	return func(latency time.Duration, completed bool) {
+		once.Do(func() {
+			primary <- shadowResult{latency: latency, completed: completed}
+		})
+	}
+}
+
+// run sends query to the shadow core and waits for the answer to end.
+func (m *ShadowMirror) run(query models.CoreQueryRequest) shadowResult {
+	ctx := m.ctx
+	if m.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.timeout)
+		defer cancel()
+	}
+
+	started := time.Now()
+	events, err := m.client.Query(ctx, query)
+	if err != nil {
+		m.logger.Warn().Err(err).Msg("Shadow core query failed")
+		return shadowResult{latency: time.Since(started)}
+	}
+
+	var result shadowResult
+	for event := range events {
+		switch event.Type {
+		case "end":
+			result.completed = true
+		case "error":
+			m.logger.Warn().Str("code", event.Code).Str("message", event.Message).Msg("Shadow core returned an error")
+		}
+	}
+	result.latency = time.Since(started)
+	if ctx.Err() != nil {
+		result.completed = false
+	}
+	return result
+}
+
+func (m *ShadowMirror) record(primary, shadow shadowResult) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !shadow.completed {
+		m.stats.ShadowFailed++
+		return
+	}
+	// A comparison needs both answers; primary failures are reported by
+	// the query log.
+	if !primary.completed {
+		return
+	}
+
+	m.stats.Compared++
+	if shadow.latency > primary.latency {
+		m.stats.ShadowSlower++
+	}
+	m.primaryLatency.add(primary.latency)
+	m.shadowLatency.add(shadow.latency)
+
+	m.logger.Debug().
+		Int64("primary_latency_ms", primary.latency.Milliseconds()).
+		Int64("shadow_latency_ms", shadow.latency.Milliseconds()).
+		Msg("Shadow query compared")
+}
+
+// Stats returns the comparison so far.
+func (m *ShadowMirror) Stats() models.ShadowStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := m.stats
+	stats.SampleSize = m.primaryLatency.len()
+	stats.PrimaryLatency = m.primaryLatency.summary()
+	stats.ShadowLatency = m.shadowLatency.summary()
+	return stats
+}
@@ -0,0 +1,114 @@
+package services_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"kb-platform-gateway/internal/config"
+	"kb-platform-gateway/internal/models"
+	"kb-platform-gateway/internal/services"
+	"kb-platform-gateway/internal/services/mocks"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestShadowMirror(t *testing.T, core *mocks.MockCoreService, percent, maxInFlight int) *services.ShadowMirror {
+	t.Helper()
+	m := services.NewShadowMirror(&config.ShadowConfig{
+		Percent:     percent,
+		Timeout:     time.Minute,
+		MaxInFlight: maxInFlight,
+	}, core, zerolog.Nop())
+	t.Cleanup(m.Close)
+	return m
+}
+
+func shadowStream(events ...models.SSEEvent) <-chan models.SSEEvent {
+	ch := make(chan models.SSEEvent, len(events))
+	for _, event := range events {
+		ch <- event
+	}
+	close(ch)
+	return ch
+}
+
+func TestShadowMirror(t *testing.T) {
+	query := models.CoreQueryRequest{Query: "what?", TopK: 5, Collection: "documents"}
+
+	t.Run("Mirror_ComparesLatencies", func(t *testing.T) {
+		core := mocks.NewMockCoreService()
+		core.On("Query", mock.Anything, models.CoreQueryRequest{Query: "what?", TopK: 5, Collection: "documents"}).
+			Return(shadowStream(models.SSEEvent{Type: "chunk", Content: "42"}, models.SSEEvent{Type: "end"}), nil)
+		m := newTestShadowMirror(t, core, 100, 1)
+
+		finish := m.Mirror(query)
+		require.NotNil(t, finish)
+		finish(time.Hour, true)
+
+		require.Eventually(t, func() bool { return m.Stats().Compared == 1 }, time.Second, 5*time.Millisecond)
+		stats := m.Stats()
+		assert.Equal(t, 100, stats.Percent)
+		assert.EqualValues(t, 1, stats.Mirrored)
+		assert.EqualValues(t, 0, stats.ShadowSlower)
+		assert.Equal(t, 1, stats.SampleSize)
+		assert.EqualValues(t, time.Hour.Milliseconds(), stats.PrimaryLatency.P95MS)
+		assert.Less(t, stats.ShadowLatency.MeanMS, float64(time.Hour.Milliseconds()))
+	})
+
+	t.Run("Mirror_ShadowFailed", func(t *testing.T) {
+		core := mocks.NewMockCoreService()
+		core.On("Query", mock.Anything, mock.Anything).
+			Return(nil, errors.New("staging down"))
+		m := newTestShadowMirror(t, core, 100, 1)
+
+		m.Mirror(query)(time.Second, true)
+
+		require.Eventually(t, func() bool { return m.Stats().ShadowFailed == 1 }, time.Second, 5*time.Millisecond)
+		assert.EqualValues(t, 0, m.Stats().Compared)
+	})
+
+	t.Run("Mirror_PrimaryFailedNotCompared", func(t *testing.T) {
+		core := mocks.NewMockCoreService()
+		core.On("Query", mock.Anything, mock.Anything).
+			Return(shadowStream(models.SSEEvent{Type: "end"}), nil)
+		m := newTestShadowMirror(t, core, 100, 1)
+
+		m.Mirror(query)(time.Second, false)
+		m.Close()
+
+		stats := m.Stats()
+		assert.EqualValues(t, 1, stats.Mirrored)
+		assert.EqualValues(t, 0, stats.ShadowFailed)
+		assert.EqualValues(t, 0, stats.Compared)
+	})
+
+	t.Run("Mirror_Disabled", func(t *testing.T) {
+		core := mocks.NewMockCoreService()
+		m := newTestShadowMirror(t, core, 0, 1)
+
+		assert.Nil(t, m.Mirror(query))
+		core.AssertNotCalled(t, "Query", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Mirror_SkipsWhenFull", func(t *testing.T) {
+		upstream := make(chan models.SSEEvent)
+		core := mocks.NewMockCoreService()
+		core.On("Query", mock.Anything, mock.Anything).
+			Return((<-chan models.SSEEvent)(upstream), nil)
+		m := newTestShadowMirror(t, core, 100, 1)
+
+		finish := m.Mirror(query)
+		require.NotNil(t, finish)
+		assert.Nil(t, m.Mirror(query))
+
+		close(upstream)
+		finish(time.Second, true)
+		stats := m.Stats()
+		assert.EqualValues(t, 1, stats.Mirrored)
+		assert.EqualValues(t, 1, stats.Skipped)
+	})
+}
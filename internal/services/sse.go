@@ -0,0 +1,183 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+)
+
+// maxSSELineSize bounds a single SSE line so a misbehaving upstream cannot
+// exhaust memory.
+const maxSSELineSize = 1 << 20
+
+// ErrSSELineTooLong is returned when a line exceeds maxSSELineSize.
+var ErrSSELineTooLong = errors.New("sse: line too long")
+
+// SSEMessage is a single dispatched Server-Sent Events message.
+type SSEMessage struct {
+	// Event is the value of the last "event:" field, or "" for the default
+	// "message" event.
+	Event string
+	// Data is the concatenation of all "data:" fields joined by "\n".
+	Data string
+	// ID is the last event ID seen on the stream so far.
+	ID string
+	// Retry is the reconnection time in milliseconds from a "retry:" field,
+	// or -1 if the message did not carry a valid one.
+	Retry int
+}
+
+// SSEDecoder decodes a text/event-stream following the WHATWG parsing rules:
+// LF, CR and CRLF line endings, multi-line data, comments, event names, ids
+// and retry directives. Reads may be arbitrarily fragmented. Lines are
+// parsed in place, so the only allocations per message are the message and
+// its strings.
+type SSEDecoder struct {
+	r      *bufio.Reader
+	line   bytes.Buffer
+	data   bytes.Buffer
+	lastID string
+	// skipLF is set after a line ending in CR, whose LF, if it is a CRLF,
+	// has not been read yet. Peeking for it would block a stream that
+	// sent its last line with a bare CR.
+	skipLF bool
+}
+
+// NewSSEDecoder returns a decoder reading from r.
+func NewSSEDecoder(r io.Reader) *SSEDecoder {
+	return &SSEDecoder{r: bufio.NewReader(r)}
+}
+
+// Next returns the next dispatched message. Blocks without data are skipped
+// unless they carry a retry directive. It returns io.EOF when the stream
+// ends; a trailing message without a terminating blank line is discarded.
+func (d *SSEDecoder) Next() (*SSEMessage, error) {
+	var event string
+	d.data.Reset()
+	hasData := false
+	retry := -1
+
+	for {
+		line, err := d.readLine()
+		if err != nil {
+			return nil, err
+		}
+
+		if len(line) == 0 {
+			if hasData || retry >= 0 {
+				return &SSEMessage{Event: event, Data: d.data.String(), ID: d.lastID, Retry: retry}, nil
+			}
+			event = ""
+			continue
+		}
+
+		if line[0] == ':' {
+			continue // comment
+		}
+
+		field, value := line, []byte(nil)
+		if i := bytes.IndexByte(line, ':'); i >= 0 {
+			field, value = line[:i], line[i+1:]
+			if len(value) > 0 && value[0] == ' ' {
+				value = value[1:]
+			}
+		}
+
+		switch string(field) {
+		case "event":
+			event = string(value)
+		case "data":
+			if hasData {
+				d.data.WriteByte('\n')
+			}
+			d.data.Write(value)
+			hasData = true
+		case "id":
+			if bytes.IndexByte(value, 0) < 0 && string(value) != d.lastID {
+				d.lastID = string(value)
+			}
+		case "retry":
+			if isDigits(value) {
+				if n, err := strconv.Atoi(string(value)); err == nil {
+					retry = n
+				}
+			}
+		}
+	}
+}
+
+// readLine reads one line terminated by LF, CR or CRLF, without the
+// terminator. The line is only valid until the next call. A final
+// unterminated line is reported as io.EOF.
+func (d *SSEDecoder) readLine() ([]byte, error) {
+	d.line.Reset()
+	for {
+		b, err := d.r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if d.skipLF {
+			d.skipLF = false
+			if b == '\n' {
+				continue
+			}
+		}
+
+		switch b {
+		case '\n':
+			return d.line.Bytes(), nil
+		case '\r':
+			d.skipLF = true
+			return d.line.Bytes(), nil
+		}
+
+		if d.line.Len() >= maxSSELineSize {
+			return nil, ErrSSELineTooLong
+		}
+		d.line.WriteByte(b)
+	}
+}
+
+func isDigits(s []byte) bool {
+	for _, b := range s {
+		if b < '0' || b > '9' {
+			return false
+		}
+	}
+	return len(s) > 0
+}
+
+// SSEEncoder writes Server-Sent Events messages with JSON data, in the
+// format of gin's Context.SSEvent. It reuses one buffer and JSON encoder
+// for every message, so relaying a long stream does not allocate per event.
+type SSEEncoder struct {
+	w   io.Writer
+	buf bytes.Buffer
+	enc *json.Encoder
+}
+
+// NewSSEEncoder returns an encoder writing to w.
+func NewSSEEncoder(w io.Writer) *SSEEncoder {
+	e := &SSEEncoder{w: w}
+	e.enc = json.NewEncoder(&e.buf)
+	return e
+}
+
+// Encode writes a message named event, which must not contain line breaks,
+// with v as its data. Pass v as a pointer: boxing a struct value allocates a
+// copy of it.
+func (e *SSEEncoder) Encode(event string, v interface{}) error {
+	e.buf.Reset()
+	e.buf.WriteString("event:")
+	e.buf.WriteString(event)
+	e.buf.WriteString("\ndata:")
+	if err := e.enc.Encode(v); err != nil {
+		return err
+	}
+	e.buf.WriteByte('\n')
+	_, err := e.w.Write(e.buf.Bytes())
+	return err
+}
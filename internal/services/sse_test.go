@@ -0,0 +1,220 @@
+package services_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"kb-platform-gateway/internal/models"
+	"kb-platform-gateway/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decodeAll(t *testing.T, r io.Reader) []*services.SSEMessage {
+	t.Helper()
+	decoder := services.NewSSEDecoder(r)
+
+	var messages []*services.SSEMessage
+	for {
+		msg, err := decoder.Next()
+		if err == io.EOF {
+			return messages
+		}
+		require.NoError(t, err)
+		messages = append(messages, msg)
+	}
+}
+
+func TestSSEDecoder(t *testing.T) {
+	tests := []struct {
+		name     string
+		stream   string
+		expected []services.SSEMessage
+	}{
+		{
+			name:   "SingleDataLine",
+			stream: "data: {\"type\":\"chunk\"}\n\n",
+			expected: []services.SSEMessage{
+				{Data: `{"type":"chunk"}`, Retry: -1},
+			},
+		},
+		{
+			name:   "MultiLineData",
+			stream: "data: first\ndata: second\ndata:third\n\n",
+			expected: []services.SSEMessage{
+				{Data: "first\nsecond\nthird", Retry: -1},
+			},
+		},
+		{
+			name:   "EventNameAndID",
+			stream: "event: chunk\nid: 42\ndata: hello\n\n",
+			expected: []services.SSEMessage{
+				{Event: "chunk", ID: "42", Data: "hello", Retry: -1},
+			},
+		},
+		{
+			name:   "CommentsIgnored",
+			stream: ": keep-alive\n\n: another\ndata: x\n: inline comment\n\n",
+			expected: []services.SSEMessage{
+				{Data: "x", Retry: -1},
+			},
+		},
+		{
+			name:   "CRLFLineEndings",
+			stream: "event: end\r\ndata: a\r\ndata: b\r\n\r\n",
+			expected: []services.SSEMessage{
+				{Event: "end", Data: "a\nb", Retry: -1},
+			},
+		},
+		{
+			name:   "CROnlyLineEndings",
+			stream: "data: a\rdata: b\r\r",
+			expected: []services.SSEMessage{
+				{Data: "a\nb", Retry: -1},
+			},
+		},
+		{
+			name:   "MixedLineEndings",
+			stream: "data: a\r\r\ndata: b\n\r\n",
+			expected: []services.SSEMessage{
+				{Data: "a", Retry: -1},
+				{Data: "b", Retry: -1},
+			},
+		},
+		{
+			name:   "RetryDirective",
+			stream: "retry: 3000\n\nretry: soon\ndata: x\n\n",
+			expected: []services.SSEMessage{
+				{Retry: 3000},
+				{Data: "x", Retry: -1},
+			},
+		},
+		{
+			name:   "IDPersistsAcrossMessages",
+			stream: "id: 7\ndata: a\n\ndata: b\n\n",
+			expected: []services.SSEMessage{
+				{ID: "7", Data: "a", Retry: -1},
+				{ID: "7", Data: "b", Retry: -1},
+			},
+		},
+		{
+			name:   "EventWithoutDataNotDispatched",
+			stream: "event: ping\n\ndata: x\n\n",
+			expected: []services.SSEMessage{
+				{Data: "x", Retry: -1},
+			},
+		},
+		{
+			name:   "OnlyOneLeadingSpaceStripped",
+			stream: "data:  padded\n\n",
+			expected: []services.SSEMessage{
+				{Data: " padded", Retry: -1},
+			},
+		},
+		{
+			name:   "FieldWithoutColon",
+			stream: "data\n\n",
+			expected: []services.SSEMessage{
+				{Data: "", Retry: -1},
+			},
+		},
+		{
+			name:     "UnterminatedTrailingMessageDiscarded",
+			stream:   "data: complete\n\ndata: partial",
+			expected: []services.SSEMessage{{Data: "complete", Retry: -1}},
+		},
+		{
+			name:   "DataContainingColons",
+			stream: "data: {\"content\":\"a: b\"}\n\n",
+			expected: []services.SSEMessage{
+				{Data: `{"content":"a: b"}`, Retry: -1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, reader := range []struct {
+				name string
+				r    io.Reader
+			}{
+				{"Whole", strings.NewReader(tt.stream)},
+				{"OneByte", iotest.OneByteReader(strings.NewReader(tt.stream))},
+			} {
+				messages := decodeAll(t, reader.r)
+				require.Len(t, messages, len(tt.expected), reader.name)
+				for i, msg := range messages {
+					assert.Equal(t, tt.expected[i], *msg, reader.name)
+				}
+			}
+		})
+	}
+}
+
+func TestSSEDecoder_CRDoesNotWaitForLF(t *testing.T) {
+	// A message ended with bare CRs is dispatched before the stream sends
+	// anything more.
+	r, w := io.Pipe()
+	defer w.Close()
+	go w.Write([]byte("data: a\r\r"))
+	decoder := services.NewSSEDecoder(r)
+
+	done := make(chan *services.SSEMessage, 1)
+	go func() {
+		msg, err := decoder.Next()
+		assert.NoError(t, err)
+		done <- msg
+	}()
+
+	select {
+	case msg := <-done:
+		assert.Equal(t, "a", msg.Data)
+	case <-time.After(time.Second):
+		t.Fatal("Next blocked after a bare CR")
+	}
+}
+
+func TestSSEDecoder_LineTooLong(t *testing.T) {
+	stream := "data: " + strings.Repeat("x", 2<<20) + "\n\n"
+	decoder := services.NewSSEDecoder(strings.NewReader(stream))
+
+	_, err := decoder.Next()
+
+	assert.ErrorIs(t, err, services.ErrSSELineTooLong)
+}
+
+func TestSSEEncoder(t *testing.T) {
+	event := &models.SSEEvent{Type: "chunk", ID: "q-1", Content: "line one\nline two"}
+
+	t.Run("Format", func(t *testing.T) {
+		var buf strings.Builder
+		encoder := services.NewSSEEncoder(&buf)
+		require.NoError(t, encoder.Encode("message", event))
+		require.NoError(t, encoder.Encode("message", &models.SSEEvent{Type: "end"}))
+
+		assert.Equal(t, "event:message\ndata:{\"type\":\"chunk\",\"id\":\"q-1\",\"content\":\"line one\\nline two\"}\n\n"+
+			"event:message\ndata:{\"type\":\"end\"}\n\n", buf.String())
+
+		messages := decodeAll(t, strings.NewReader(buf.String()))
+		require.Len(t, messages, 2)
+		assert.Equal(t, "message", messages[0].Event)
+	})
+
+	t.Run("DoesNotAllocatePerEvent", func(t *testing.T) {
+		if raceEnabled {
+			t.Skip("the race detector allocates")
+		}
+		encoder := services.NewSSEEncoder(io.Discard)
+		require.NoError(t, encoder.Encode("message", event))
+
+		allocs := testing.AllocsPerRun(100, func() {
+			_ = encoder.Encode("message", event)
+		})
+
+		assert.Zero(t, allocs)
+	})
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"kb-platform-gateway/internal/config"
+	"kb-platform-gateway/internal/models"
+)
+
+// criticalDependencies are the dependencies without which no question can
+// be answered; losing one is an outage rather than degraded service.
+var criticalDependencies = map[string]bool{
+	"database":    true,
+	"python_core": true,
+}
+
+// StatusPage builds the public status page from the ops monitor's checks.
+// Dependencies are never probed on request, and a built page is served
+// for CacheTTL before it is rebuilt.
+type StatusPage struct {
+	monitor        OpsMonitorInterface
+	incidentWindow time.Duration
+	cacheTTL       time.Duration
+
+	mu      sync.Mutex
+	cached  *models.StatusResponse
+	builtAt time.Time
+}
+
+func NewStatusPage(cfg *config.StatusPageConfig, monitor OpsMonitorInterface) *StatusPage {
+	return &StatusPage{
+		monitor:        monitor,
+		incidentWindow: cfg.IncidentWindow,
+		cacheTTL:       cfg.CacheTTL,
+	}
+}
+
+// Status returns the current status and how much longer it may be cached.
+func (p *StatusPage) Status() (*models.StatusResponse, time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	if p.cached == nil || now.Sub(p.builtAt) >= p.cacheTTL {
+		p.cached = p.build(now)
+		p.builtAt = now
+	}
+	return p.cached, p.cacheTTL - now.Sub(p.builtAt)
+}
+
+func (p *StatusPage) build(now time.Time) *models.StatusResponse {
+	health := p.monitor.Health()
+	recent := func(at time.Time) bool {
+		return !at.IsZero() && now.Sub(at) < p.incidentWindow
+	}
+
+	status := &models.StatusResponse{
+		Status:           models.StatusOperational,
+		Components:       []models.ComponentStatus{},
+		RecentErrorSpike: recent(health.LastErrorSpike),
+	}
+	if health.CheckedAt.IsZero() {
+		status.Status = models.StatusUnknown
+	} else {
+		status.CheckedAt = &health.CheckedAt
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(health.Dependencies)) {
+		dependency := health.Dependencies[name]
+		component := models.ComponentStatus{
+			Name:           name,
+			Status:         models.StatusOperational,
+			RecentIncident: recent(dependency.LastOutage),
+		}
+		switch {
+		case health.CheckedAt.IsZero():
+			component.Status = models.StatusUnknown
+		case !dependency.Healthy:
+			component.Status = models.StatusOutage
+			if criticalDependencies[name] {
+				status.Status = models.StatusOutage
+			} else if status.Status == models.StatusOperational {
+				status.Status = models.StatusDegraded
+			}
+		}
+		status.Components = append(status.Components, component)
+	}
+	return status
+}
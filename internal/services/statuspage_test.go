@@ -0,0 +1,78 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"kb-platform-gateway/internal/config"
+	"kb-platform-gateway/internal/models"
+	"kb-platform-gateway/internal/services"
+	"kb-platform-gateway/internal/services/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatusPage(t *testing.T) {
+	cfg := &config.StatusPageConfig{IncidentWindow: time.Hour, CacheTTL: time.Minute}
+	now := time.Now()
+
+	t.Run("Status_Degraded", func(t *testing.T) {
+		monitor := mocks.NewMockOpsMonitor()
+		monitor.On("Health").Return(services.OpsHealth{
+			CheckedAt: now,
+			Dependencies: map[string]services.DependencyHealth{
+				"python_core": {Healthy: true, LastOutage: now.Add(-2 * time.Hour)},
+				"redis":       {Healthy: false, LastOutage: now},
+				"database":    {Healthy: true, LastOutage: now.Add(-10 * time.Minute)},
+			},
+			LastErrorSpike: now.Add(-5 * time.Minute),
+		}).Once()
+		page := services.NewStatusPage(cfg, monitor)
+
+		status, ttl := page.Status()
+
+		assert.Equal(t, models.StatusDegraded, status.Status)
+		assert.Equal(t, []models.ComponentStatus{
+			{Name: "database", Status: models.StatusOperational, RecentIncident: true},
+			{Name: "python_core", Status: models.StatusOperational},
+			{Name: "redis", Status: models.StatusOutage, RecentIncident: true},
+		}, status.Components)
+		assert.True(t, status.RecentErrorSpike)
+		require.NotNil(t, status.CheckedAt)
+		assert.InDelta(t, time.Minute, ttl, float64(time.Second))
+
+		cached, _ := page.Status()
+		assert.Same(t, status, cached, "the status is cached for CacheTTL")
+		monitor.AssertExpectations(t)
+	})
+
+	t.Run("Status_CriticalOutage", func(t *testing.T) {
+		monitor := mocks.NewMockOpsMonitor()
+		monitor.On("Health").Return(services.OpsHealth{
+			CheckedAt: now,
+			Dependencies: map[string]services.DependencyHealth{
+				"python_core": {Healthy: false, LastOutage: now},
+				"redis":       {Healthy: false, LastOutage: now},
+			},
+		})
+
+		status, _ := services.NewStatusPage(cfg, monitor).Status()
+
+		assert.Equal(t, models.StatusOutage, status.Status)
+		assert.False(t, status.RecentErrorSpike)
+	})
+
+	t.Run("Status_NotChecked", func(t *testing.T) {
+		monitor := mocks.NewMockOpsMonitor()
+		monitor.On("Health").Return(services.OpsHealth{
+			Dependencies: map[string]services.DependencyHealth{"redis": {Healthy: true}},
+		})
+
+		status, _ := services.NewStatusPage(cfg, monitor).Status()
+
+		assert.Equal(t, models.StatusUnknown, status.Status)
+		assert.Equal(t, models.StatusUnknown, status.Components[0].Status)
+		assert.Nil(t, status.CheckedAt)
+	})
+}
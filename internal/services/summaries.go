@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"kb-platform-gateway/internal/config"
+	"kb-platform-gateway/internal/models"
+	"kb-platform-gateway/internal/repository"
+
+	"github.com/rs/zerolog"
+)
+
+// maxHistoryMessages caps the messages loaded for a query's history.
+const maxHistoryMessages = 1000
+
+// charsPerToken is the rough number of characters in a token, for
+// estimating the size of a conversation.
+const charsPerToken = 4
+
+// ConversationSummarizer keeps a rolling summary of long conversations.
+// A query in a long conversation is sent the latest summary and the
+// messages after it; the summary is brought up to date in the background,
+// so the query never waits for the core to summarize.
+type ConversationSummarizer struct {
+	core   CoreServiceInterface
+	repo   repository.Repository
+	logger zerolog.Logger
+
+	messageThreshold int
+	tokenThreshold   int
+	recent           int
+	timeout          time.Duration
+
+	// mu guards summarizing, the conversations being summarized.
+	mu          sync.Mutex
+	summarizing map[string]bool
+
+	ctx       context.Context
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+}
+
+func NewConversationSummarizer(cfg *config.SummaryConfig, core CoreServiceInterface, repo repository.Repository, logger zerolog.Logger) *ConversationSummarizer {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &ConversationSummarizer{
+		core:             core,
+		repo:             repo,
+		logger:           logger,
+		messageThreshold: cfg.MessageThreshold,
+		tokenThreshold:   cfg.TokenThreshold,
+		recent:           max(cfg.RecentMessages, 1),
+		timeout:          cfg.Timeout,
+		summarizing:      make(map[string]bool),
+		ctx:              ctx,
+		cancel:           cancel,
+	}
+}
+
+// Close interrupts summaries in progress and waits for them to finish.
+func (s *ConversationSummarizer) Close() {
+	s.closeOnce.Do(func() {
+		s.cancel()
+		s.wg.Wait()
+	})
+}
+
+// History returns the history to send with a query in a conversation, or
+// nil while the conversation is short enough for the core to load all of
+// it. Once the messages after the latest summary outgrow twice the recent
+// messages, or a long conversation has no summary yet, all but the recent
+// ones are summarized in the background.
+func (s *ConversationSummarizer) History(ctx context.Context, conversationID string) (*models.ConversationContext, error) {
+	latest, err := s.repo.GetLatestConversationSummary(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	covered := 0
+	if latest != nil {
+		covered = latest.MessageCount
+	}
+
+	messages, err := s.repo.GetMessagesByConversationID(ctx, conversationID, maxHistoryMessages, covered)
+	if err != nil {
+		return nil, err
+	}
+	if latest == nil && !s.long(messages) {
+		return nil, nil
+	}
+
+	if latest == nil || len(messages) >= 2*s.recent {
+		if older := len(messages) - s.recent; older > 0 {
+			s.summarize(conversationID, latest, messages[:older])
+		}
+	}
+	if latest == nil {
+		return nil, nil
+	}
+
+	history := &models.ConversationContext{
+		Summary:  latest.Summary,
+		Messages: make([]models.Message, len(messages)),
+	}
+	for i, msg := range messages {
+		history.Messages[i] = *msg
+	}
+	return history, nil
+}
+
+// long reports whether messages pass either threshold.
+func (s *ConversationSummarizer) long(messages []*models.Message) bool {
+	if s.messageThreshold > 0 && len(messages) > s.messageThreshold {
+		return true
+	}
+	if s.tokenThreshold > 0 {
+		chars := 0
+		for _, msg := range messages {
+			chars += len(msg.Content)
+		}
+		return chars/charsPerToken > s.tokenThreshold
+	}
+	return false
+}
+
+// summarize folds messages, the ones after latest, into the next summary
+// version in the background, unless the conversation is already being
+// summarized.
+func (s *ConversationSummarizer) summarize(conversationID string, latest *models.ConversationSummary, messages []*models.Message) {
+	s.mu.Lock()
+	if s.summarizing[conversationID] || s.ctx.Err() != nil {
+		s.mu.Unlock()
+		return
+	}
+	s.summarizing[conversationID] = true
+	s.mu.Unlock()
+
+	next := &models.ConversationSummary{
+		ConversationID: conversationID,
+		Version:        1,
+		MessageCount:   len(messages),
+	}
+	previous := ""
+	if latest != nil {
+		next.Version = latest.Version + 1
+		next.MessageCount += latest.MessageCount
+		previous = latest.Summary
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer func() {
+			s.mu.Lock()
+			delete(s.summarizing, conversationID)
+			s.mu.Unlock()
+		}()
+
+		ctx, cancel := context.WithTimeout(s.ctx, s.timeout)
+		defer cancel()
+
+		summary, err := s.core.Summarize(ctx, previous, messages)
+		if err != nil {
+			s.logger.Error().Err(err).Str("conversation_id", conversationID).Msg("Failed to summarize conversation")
+			return
+		}
+		next.Summary = summary
+		next.CreatedAt = time.Now()
+		if err := s.repo.CreateConversationSummary(ctx, next); err != nil {
+			s.logger.Error().Err(err).Str("conversation_id", conversationID).Msg("Failed to save conversation summary")
+		}
+	}()
+}
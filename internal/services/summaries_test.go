@@ -0,0 +1,129 @@
+package services_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"kb-platform-gateway/internal/config"
+	"kb-platform-gateway/internal/models"
+	repomocks "kb-platform-gateway/internal/repository/mocks"
+	"kb-platform-gateway/internal/services"
+	"kb-platform-gateway/internal/services/mocks"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConversationSummarizer(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.SummaryConfig{MessageThreshold: 10, RecentMessages: 4, Timeout: time.Second}
+	messages := func(n int) []*models.Message {
+		msgs := make([]*models.Message, n)
+		for i := range msgs {
+			msgs[i] = &models.Message{ID: fmt.Sprintf("msg-%d", i), Role: "user", Content: "hi"}
+		}
+		return msgs
+	}
+
+	t.Run("History_Short", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetLatestConversationSummary", ctx, "conv-1").Return(nil, nil)
+		repo.On("GetMessagesByConversationID", ctx, "conv-1", 1000, 0).Return(messages(10), nil)
+		core := mocks.NewMockCoreService()
+		summarizer := services.NewConversationSummarizer(cfg, core, repo, zerolog.Nop())
+
+		history, err := summarizer.History(ctx, "conv-1")
+		summarizer.Close()
+
+		require.NoError(t, err)
+		assert.Nil(t, history)
+		core.AssertNotCalled(t, "Summarize", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("History_FirstSummary", func(t *testing.T) {
+		msgs := messages(12)
+		repo := repomocks.NewMockRepository()
+		repo.On("GetLatestConversationSummary", ctx, "conv-1").Return(nil, nil)
+		repo.On("GetMessagesByConversationID", ctx, "conv-1", 1000, 0).Return(msgs, nil)
+		repo.On("CreateConversationSummary", mock.Anything, mock.MatchedBy(func(s *models.ConversationSummary) bool {
+			return s.ConversationID == "conv-1" && s.Version == 1 && s.MessageCount == 8 && s.Summary == "Greetings."
+		})).Return(nil)
+		core := mocks.NewMockCoreService()
+		core.On("Summarize", mock.Anything, "", msgs[:8]).Return("Greetings.", nil)
+		summarizer := services.NewConversationSummarizer(cfg, core, repo, zerolog.Nop())
+
+		history, err := summarizer.History(ctx, "conv-1")
+		summarizer.Close()
+
+		require.NoError(t, err)
+		assert.Nil(t, history)
+		core.AssertExpectations(t)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("History_TokenThreshold", func(t *testing.T) {
+		msgs := []*models.Message{
+			{Role: "user", Content: strings.Repeat("a", 400)},
+			{Role: "assistant", Content: "ok"},
+		}
+		repo := repomocks.NewMockRepository()
+		repo.On("GetLatestConversationSummary", ctx, "conv-1").Return(nil, nil)
+		repo.On("GetMessagesByConversationID", ctx, "conv-1", 1000, 0).Return(msgs, nil)
+		repo.On("CreateConversationSummary", mock.Anything, mock.Anything).Return(nil)
+		core := mocks.NewMockCoreService()
+		core.On("Summarize", mock.Anything, "", msgs[:1]).Return("A long question.", nil)
+		summarizer := services.NewConversationSummarizer(&config.SummaryConfig{TokenThreshold: 50, RecentMessages: 1, Timeout: time.Second}, core, repo, zerolog.Nop())
+
+		_, err := summarizer.History(ctx, "conv-1")
+		summarizer.Close()
+
+		require.NoError(t, err)
+		core.AssertExpectations(t)
+	})
+
+	t.Run("History_SummaryAndNewerMessages", func(t *testing.T) {
+		repo := repomocks.NewMockRepository()
+		repo.On("GetLatestConversationSummary", ctx, "conv-1").
+			Return(&models.ConversationSummary{ConversationID: "conv-1", Version: 2, Summary: "Greetings.", MessageCount: 8}, nil)
+		repo.On("GetMessagesByConversationID", ctx, "conv-1", 1000, 8).Return(messages(5), nil)
+		core := mocks.NewMockCoreService()
+		summarizer := services.NewConversationSummarizer(cfg, core, repo, zerolog.Nop())
+
+		history, err := summarizer.History(ctx, "conv-1")
+		summarizer.Close()
+
+		require.NoError(t, err)
+		require.NotNil(t, history)
+		assert.Equal(t, "Greetings.", history.Summary)
+		assert.Len(t, history.Messages, 5)
+		core.AssertNotCalled(t, "Summarize", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("History_RollsSummary", func(t *testing.T) {
+		msgs := messages(8)
+		repo := repomocks.NewMockRepository()
+		repo.On("GetLatestConversationSummary", ctx, "conv-1").
+			Return(&models.ConversationSummary{ConversationID: "conv-1", Version: 2, Summary: "Greetings.", MessageCount: 8}, nil)
+		repo.On("GetMessagesByConversationID", ctx, "conv-1", 1000, 8).Return(msgs, nil)
+		repo.On("CreateConversationSummary", mock.Anything, mock.MatchedBy(func(s *models.ConversationSummary) bool {
+			return s.Version == 3 && s.MessageCount == 12 && s.Summary == "More greetings."
+		})).Return(nil)
+		core := mocks.NewMockCoreService()
+		core.On("Summarize", mock.Anything, "Greetings.", msgs[:4]).Return("More greetings.", nil)
+		summarizer := services.NewConversationSummarizer(cfg, core, repo, zerolog.Nop())
+
+		history, err := summarizer.History(ctx, "conv-1")
+		summarizer.Close()
+
+		require.NoError(t, err)
+		require.NotNil(t, history)
+		assert.Equal(t, "Greetings.", history.Summary)
+		assert.Len(t, history.Messages, 8)
+		repo.AssertExpectations(t)
+	})
+}
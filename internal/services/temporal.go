@@ -2,14 +2,22 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
+	"go.temporal.io/api/serviceerror"
 	"go.temporal.io/api/workflowservice/v1"
 	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/temporal"
 	"kb-platform-gateway/internal/config"
+	"kb-platform-gateway/internal/models"
 )
 
+// ErrWorkflowNotFound is returned when signalling a workflow that is not
+// running, e.g. an upload workflow that timed out waiting for its file.
+var ErrWorkflowNotFound = errors.New("workflow not found")
+
 type TemporalClient struct {
 	client client.Client
 	cfg    *config.TemporalConfig
@@ -34,13 +42,89 @@ func (tc *TemporalClient) Close() {
 	tc.client.Close()
 }
 
+// UploadWorkflowInput asks a worker to wait for a document's upload and
+// index it, processed with Processing and chunked with Chunking if set.
 type UploadWorkflowInput struct {
 	DocumentID string
 	S3Key      string
+	Chunking   *models.ChunkingOptions
+	Processing *models.ProcessingOptions
+}
+
+// UploadCompleteSignal is the payload of the upload-complete signal. Its
+// S3Key and Processing replace the ones the upload workflow was started
+// with, as a scanned file is moved out of reach of its upload URL and the
+// options may be changed when the upload is completed.
+type UploadCompleteSignal struct {
+	S3Key      string
+	Processing *models.ProcessingOptions
 }
 
+// IndexWorkflowInput asks a worker to index a document, processed with
+// Processing and chunked with Chunking if set, labelling its vectors with
+// AccessGroups.
 type IndexWorkflowInput struct {
+	DocumentID   string
+	Chunking     *models.ChunkingOptions
+	Processing   *models.ProcessingOptions
+	AccessGroups []string
+}
+
+// ReindexWorkflowInput asks a worker to embed a document with
+// EmbeddingModel into Collection, labelling its vectors with AccessGroups,
+// and report the result as a document.reindexed or document.reindex_failed
+// event.
+type ReindexWorkflowInput struct {
+	DocumentID     string
+	MigrationID    string
+	Collection     string
+	EmbeddingModel string
+	AccessGroups   []string
+}
+
+// ConnectorSyncWorkflowInput asks a worker to sync a connector's folders:
+// fetch its credentials and register every file through the internal
+// connector API, then report a connector.synced or connector.sync_failed
+// event. ModifiedSince is the start of the last successful sync, if any.
+type ConnectorSyncWorkflowInput struct {
+	ConnectorID   string
+	Provider      string
+	FolderIDs     []string
+	ModifiedSince *time.Time
+}
+
+// ResyncDocumentWorkflowInput asks a worker to fetch a document's source
+// URL and report its content hash through the internal resync API. If the
+// source changed, the worker uploads it to the returned URL and completes
+// the upload, which re-indexes the document.
+type ResyncDocumentWorkflowInput struct {
 	DocumentID string
+	SourceURL  string
+}
+
+// SnapshotWorkflowInput asks a worker to take a snapshot of the knowledge
+// base: snapshot SourceCollection in Qdrant, copy its points into
+// Collection, and report a snapshot.created event carrying the
+// qdrant_snapshot and vector_count, or a snapshot.failed event carrying the
+// error and, if one was taken, the qdrant_snapshot.
+type SnapshotWorkflowInput struct {
+	SnapshotID       string
+	SourceCollection string
+	Collection       string
+}
+
+// ExportWorkflowInput asks a worker to build a knowledge base export:
+// write an archive in Format to ArchiveKey holding the manifest.json at
+// ManifestKey and, at the path each of its entries names, the file stored
+// at its s3_key. The worker reports export.progress events carrying the
+// documents_exported as it goes, then an export.completed event carrying
+// the size_bytes of the archive or an export.failed event carrying the
+// error.
+type ExportWorkflowInput struct {
+	ExportID    string
+	Format      string
+	ManifestKey string
+	ArchiveKey  string
 }
 
 type QueryWorkflowInput struct {
@@ -49,16 +133,19 @@ type QueryWorkflowInput struct {
 	TopK           int
 }
 
-func (tc *TemporalClient) StartUploadWorkflow(ctx context.Context, documentID, s3Key string) (string, error) {
+// UploadWorkflowID is the ID of a document's upload workflow, which
+// SignalUploadComplete signals.
+func UploadWorkflowID(documentID string) string {
+	return fmt.Sprintf("upload-%s", documentID)
+}
+
+func (tc *TemporalClient) StartUploadWorkflow(ctx context.Context, input UploadWorkflowInput) (string, error) {
 	workflowOptions := client.StartWorkflowOptions{
-		ID:        fmt.Sprintf("upload-%s", documentID),
+		ID:        UploadWorkflowID(input.DocumentID),
 		TaskQueue: "indexing-queue",
 	}
 
-	we, err := tc.client.ExecuteWorkflow(ctx, workflowOptions, "UploadWorkflow", UploadWorkflowInput{
-		DocumentID: documentID,
-		S3Key:      s3Key,
-	})
+	we, err := tc.client.ExecuteWorkflow(ctx, workflowOptions, "UploadWorkflow", input)
 	if err != nil {
 		return "", fmt.Errorf("failed to start upload workflow: %w", err)
 	}
@@ -66,19 +153,45 @@ func (tc *TemporalClient) StartUploadWorkflow(ctx context.Context, documentID, s
 	return we.GetID(), nil
 }
 
-func (tc *TemporalClient) SignalUploadComplete(ctx context.Context, documentID string) error {
-	return tc.client.SignalWorkflow(ctx, fmt.Sprintf("upload-%s", documentID), "", "upload-complete", nil)
+// StartArchiveUploadWorkflow starts the upload workflow of a ZIP archive.
+// It shares the upload workflow's ID, so the archive is completed the same
+// way, and once uploaded expands the archive into child documents through
+// the internal archive API before reporting a document.expanded event.
+func (tc *TemporalClient) StartArchiveUploadWorkflow(ctx context.Context, input UploadWorkflowInput) (string, error) {
+	workflowOptions := client.StartWorkflowOptions{
+		ID:        UploadWorkflowID(input.DocumentID),
+		TaskQueue: "indexing-queue",
+	}
+
+	we, err := tc.client.ExecuteWorkflow(ctx, workflowOptions, "ArchiveUploadWorkflow", input)
+	if err != nil {
+		return "", fmt.Errorf("failed to start archive upload workflow: %w", err)
+	}
+
+	return we.GetID(), nil
+}
+
+// SignalUploadComplete tells a document's upload workflow its file is
+// uploaded. It returns ErrWorkflowNotFound if the workflow is not running.
+func (tc *TemporalClient) SignalUploadComplete(ctx context.Context, documentID, s3Key string, processing *models.ProcessingOptions) error {
+	err := tc.client.SignalWorkflow(ctx, UploadWorkflowID(documentID), "", "upload-complete", UploadCompleteSignal{
+		S3Key:      s3Key,
+		Processing: processing,
+	})
+	var notFound *serviceerror.NotFound
+	if errors.As(err, &notFound) {
+		return fmt.Errorf("%w: %s", ErrWorkflowNotFound, UploadWorkflowID(documentID))
+	}
+	return err
 }
 
-func (tc *TemporalClient) StartIndexWorkflow(ctx context.Context, documentID string) (string, error) {
+func (tc *TemporalClient) StartIndexWorkflow(ctx context.Context, input IndexWorkflowInput) (string, error) {
 	workflowOptions := client.StartWorkflowOptions{
-		ID:        fmt.Sprintf("index-%s", documentID),
+		ID:        fmt.Sprintf("index-%s", input.DocumentID),
 		TaskQueue: "indexing-queue",
 	}
 
-	we, err := tc.client.ExecuteWorkflow(ctx, workflowOptions, "IndexingWorkflow", IndexWorkflowInput{
-		DocumentID: documentID,
-	})
+	we, err := tc.client.ExecuteWorkflow(ctx, workflowOptions, "IndexingWorkflow", input)
 	if err != nil {
 		return "", fmt.Errorf("failed to start index workflow: %w", err)
 	}
@@ -86,12 +199,154 @@ func (tc *TemporalClient) StartIndexWorkflow(ctx context.Context, documentID str
 	return we.GetID(), nil
 }
 
+func (tc *TemporalClient) StartReindexWorkflow(ctx context.Context, input ReindexWorkflowInput) (string, error) {
+	workflowOptions := client.StartWorkflowOptions{
+		ID:        fmt.Sprintf("reindex-%s-%s", input.MigrationID, input.DocumentID),
+		TaskQueue: "indexing-queue",
+	}
+
+	we, err := tc.client.ExecuteWorkflow(ctx, workflowOptions, "ReindexWorkflow", input)
+	if err != nil {
+		return "", fmt.Errorf("failed to start reindex workflow: %w", err)
+	}
+
+	return we.GetID(), nil
+}
+
+// StartConnectorSyncWorkflow starts syncing a connector. A sync still
+// running for the connector is reused rather than started twice.
+func (tc *TemporalClient) StartConnectorSyncWorkflow(ctx context.Context, input ConnectorSyncWorkflowInput) (string, error) {
+	workflowOptions := client.StartWorkflowOptions{
+		ID:        fmt.Sprintf("connector-sync-%s", input.ConnectorID),
+		TaskQueue: "indexing-queue",
+	}
+
+	we, err := tc.client.ExecuteWorkflow(ctx, workflowOptions, "ConnectorSyncWorkflow", input)
+	if err != nil {
+		return "", fmt.Errorf("failed to start connector sync workflow: %w", err)
+	}
+
+	return we.GetID(), nil
+}
+
+func (tc *TemporalClient) StartSnapshotWorkflow(ctx context.Context, input SnapshotWorkflowInput) (string, error) {
+	workflowOptions := client.StartWorkflowOptions{
+		ID:        fmt.Sprintf("snapshot-%s", input.SnapshotID),
+		TaskQueue: "indexing-queue",
+	}
+
+	we, err := tc.client.ExecuteWorkflow(ctx, workflowOptions, "SnapshotWorkflow", input)
+	if err != nil {
+		return "", fmt.Errorf("failed to start snapshot workflow: %w", err)
+	}
+
+	return we.GetID(), nil
+}
+
+func (tc *TemporalClient) StartExportWorkflow(ctx context.Context, input ExportWorkflowInput) (string, error) {
+	workflowOptions := client.StartWorkflowOptions{
+		ID:        fmt.Sprintf("export-%s", input.ExportID),
+		TaskQueue: "indexing-queue",
+	}
+
+	we, err := tc.client.ExecuteWorkflow(ctx, workflowOptions, "KnowledgeBaseExportWorkflow", input)
+	if err != nil {
+		return "", fmt.Errorf("failed to start export workflow: %w", err)
+	}
+
+	return we.GetID(), nil
+}
+
+// ScheduleDocumentResync creates or updates the Temporal schedule starting
+// a ResyncDocumentWorkflow for the document on the cron expression.
+func (tc *TemporalClient) ScheduleDocumentResync(ctx context.Context, cron string, input ResyncDocumentWorkflowInput) error {
+	return tc.upsertSchedule(ctx, resyncScheduleID(models.ResyncSourceDocument, input.DocumentID), cron, &client.ScheduleWorkflowAction{
+		ID:        fmt.Sprintf("resync-%s", input.DocumentID),
+		Workflow:  "ResyncDocumentWorkflow",
+		Args:      []interface{}{input},
+		TaskQueue: "indexing-queue",
+	})
+}
+
+// ScheduleConnectorSync creates or updates the Temporal schedule starting
+// a full ConnectorSyncWorkflow for the connector on the cron expression.
+func (tc *TemporalClient) ScheduleConnectorSync(ctx context.Context, cron string, input ConnectorSyncWorkflowInput) error {
+	return tc.upsertSchedule(ctx, resyncScheduleID(models.ResyncSourceConnector, input.ConnectorID), cron, &client.ScheduleWorkflowAction{
+		ID:        fmt.Sprintf("connector-sync-%s", input.ConnectorID),
+		Workflow:  "ConnectorSyncWorkflow",
+		Args:      []interface{}{input},
+		TaskQueue: "indexing-queue",
+	})
+}
+
+// DeleteResyncSchedule deletes the Temporal schedule of a document or
+// connector. A schedule that does not exist is not an error.
+func (tc *TemporalClient) DeleteResyncSchedule(ctx context.Context, sourceType, sourceID string) error {
+	err := tc.client.ScheduleClient().GetHandle(ctx, resyncScheduleID(sourceType, sourceID)).Delete(ctx)
+	var notFound *serviceerror.NotFound
+	if err != nil && !errors.As(err, &notFound) {
+		return fmt.Errorf("failed to delete resync schedule: %w", err)
+	}
+	return nil
+}
+
+// trashPurgeScheduleID is the ID of the schedule purging the trash.
+const trashPurgeScheduleID = "trash-purge"
+
+// ScheduleTrashPurge creates or updates the Temporal schedule starting a
+// PurgeTrashWorkflow on the cron expression. The workflow purges the trash
+// through the internal trash API, which decides what has expired.
+func (tc *TemporalClient) ScheduleTrashPurge(ctx context.Context, cron string) error {
+	return tc.upsertSchedule(ctx, trashPurgeScheduleID, cron, &client.ScheduleWorkflowAction{
+		ID:        trashPurgeScheduleID,
+		Workflow:  "PurgeTrashWorkflow",
+		TaskQueue: "indexing-queue",
+	})
+}
+
+func resyncScheduleID(sourceType, sourceID string) string {
+	return fmt.Sprintf("resync-%s-%s", sourceType, sourceID)
+}
+
+// upsertSchedule creates a schedule, or replaces the spec and action of an
+// existing one. Runs that would overlap a running one are skipped.
+func (tc *TemporalClient) upsertSchedule(ctx context.Context, scheduleID, cron string, action *client.ScheduleWorkflowAction) error {
+	spec := client.ScheduleSpec{CronExpressions: []string{cron}}
+
+	_, err := tc.client.ScheduleClient().Create(ctx, client.ScheduleOptions{
+		ID:     scheduleID,
+		Spec:   spec,
+		Action: action,
+	})
+	if errors.Is(err, temporal.ErrScheduleAlreadyRunning) {
+		err = tc.client.ScheduleClient().GetHandle(ctx, scheduleID).Update(ctx, client.ScheduleUpdateOptions{
+			DoUpdate: func(in client.ScheduleUpdateInput) (*client.ScheduleUpdate, error) {
+				schedule := in.Description.Schedule
+				schedule.Spec = &spec
+				schedule.Action = action
+				return &client.ScheduleUpdate{Schedule: &schedule}, nil
+			},
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to upsert schedule %s: %w", scheduleID, err)
+	}
+	return nil
+}
+
 func (tc *TemporalClient) QueryWorkflowStatus(ctx context.Context, workflowID string) (*workflowservice.DescribeWorkflowExecutionResponse, error) {
 	return tc.client.DescribeWorkflowExecution(ctx, workflowID, "")
 }
 
+// CancelWorkflow requests cancellation of a workflow. It returns
+// ErrWorkflowNotFound if the workflow is no longer running.
 func (tc *TemporalClient) CancelWorkflow(ctx context.Context, workflowID string) error {
-	return tc.client.CancelWorkflow(ctx, workflowID, "")
+	err := tc.client.CancelWorkflow(ctx, workflowID, "")
+	var notFound *serviceerror.NotFound
+	if errors.As(err, &notFound) {
+		return fmt.Errorf("%w: %s", ErrWorkflowNotFound, workflowID)
+	}
+	return err
 }
 
 func (tc *TemporalClient) HealthCheck(ctx context.Context) error {
@@ -0,0 +1,252 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"kb-platform-gateway/internal/config"
+	"kb-platform-gateway/internal/models"
+	"kb-platform-gateway/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// Headers sent with every webhook delivery.
+const (
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
+
+// SignWebhookPayload returns the signature header value for body:
+// "t=<unix seconds>,v1=<hex HMAC-SHA256 of "<t>.<body>">".
+func SignWebhookPayload(secret string, timestamp int64, body []byte) string {
+	ts := strconv.FormatInt(timestamp, 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+type webhookJob struct {
+	// Set for fan-out jobs.
+	event *models.WebhookEvent
+
+	// Set for delivery jobs.
+	webhook  *models.Webhook
+	delivery *models.WebhookDelivery
+}
+
+// WebhookDispatcher delivers signed events to subscribed webhooks from a
+// pool of background workers. Failed deliveries are retried with
+// exponential backoff; every attempt is recorded in the delivery log.
+// Retries still scheduled when the dispatcher closes are abandoned and
+// stay pending in the log.
+type WebhookDispatcher struct {
+	repo       repository.WebhookRepository
+	httpClient *http.Client
+	logger     zerolog.Logger
+
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+
+	queue     chan *webhookJob
+	ctx       context.Context
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+}
+
+func NewWebhookDispatcher(cfg *config.WebhookConfig, repo repository.WebhookRepository, logger zerolog.Logger) *WebhookDispatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	d := &WebhookDispatcher{
+		repo:           repo,
+		httpClient:     &http.Client{Timeout: cfg.Timeout},
+		logger:         logger,
+		maxAttempts:    max(cfg.MaxAttempts, 1),
+		initialBackoff: cfg.InitialBackoff,
+		maxBackoff:     cfg.MaxBackoff,
+		queue:          make(chan *webhookJob, max(cfg.QueueSize, 1)),
+		ctx:            ctx,
+		cancel:         cancel,
+	}
+
+	for i := 0; i < max(cfg.Workers, 1); i++ {
+		d.wg.Add(1)
+		go d.work()
+	}
+
+	return d
+}
+
+// Dispatch queues eventType for delivery to every active webhook subscribed
+// to it. It never blocks; events are dropped when the queue is full.
+func (d *WebhookDispatcher) Dispatch(ctx context.Context, eventType string, data interface{}) {
+	event := &models.WebhookEvent{
+		ID:        uuid.New().String(),
+		Type:      eventType,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	}
+	if !d.enqueue(&webhookJob{event: event}) {
+		d.logger.Warn().Str("event_type", eventType).Str("event_id", event.ID).Msg("Webhook queue full, dropping event")
+	}
+}
+
+// Close stops the workers and waits for in-flight deliveries to finish.
+func (d *WebhookDispatcher) Close() {
+	d.closeOnce.Do(func() {
+		d.cancel()
+		d.wg.Wait()
+	})
+}
+
+func (d *WebhookDispatcher) enqueue(job *webhookJob) bool {
+	if d.ctx.Err() != nil {
+		return false
+	}
+	select {
+	case d.queue <- job:
+		return true
+	default:
+		return false
+	}
+}
+
+func (d *WebhookDispatcher) work() {
+	defer d.wg.Done()
+	for {
+		select {
+		case <-d.ctx.Done():
+			return
+		case job := <-d.queue:
+			if job.event != nil {
+				d.fanOut(job.event)
+			} else {
+				d.deliver(job.webhook, job.delivery)
+			}
+		}
+	}
+}
+
+// fanOut records a delivery for each subscribed webhook and attempts it.
+func (d *WebhookDispatcher) fanOut(event *models.WebhookEvent) {
+	webhooks, err := d.repo.ListWebhooksForEvent(d.ctx, event.Type)
+	if err != nil {
+		d.logger.Error().Err(err).Str("event_type", event.Type).Msg("Failed to list webhooks")
+		return
+	}
+	if len(webhooks) == 0 {
+		return
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		d.logger.Error().Err(err).Str("event_type", event.Type).Msg("Failed to encode webhook event")
+		return
+	}
+
+	for _, webhook := range webhooks {
+		delivery := &models.WebhookDelivery{
+			ID:        uuid.New().String(),
+			WebhookID: webhook.ID,
+			EventID:   event.ID,
+			EventType: event.Type,
+			Payload:   string(payload),
+			Status:    models.DeliveryStatusPending,
+			CreatedAt: time.Now(),
+		}
+		if err := d.repo.CreateWebhookDelivery(d.ctx, delivery); err != nil {
+			d.logger.Error().Err(err).Str("webhook_id", webhook.ID).Msg("Failed to record webhook delivery")
+			continue
+		}
+		d.deliver(webhook, delivery)
+	}
+}
+
+// deliver makes one attempt and schedules a retry if it failed.
+func (d *WebhookDispatcher) deliver(webhook *models.Webhook, delivery *models.WebhookDelivery) {
+	delivery.Attempts++
+	code, err := d.post(webhook, delivery)
+	delivery.ResponseCode = code
+
+	switch {
+	case err == nil:
+		now := time.Now()
+		delivery.Status = models.DeliveryStatusSucceeded
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+	case delivery.Attempts >= d.maxAttempts:
+		delivery.Status = models.DeliveryStatusFailed
+		delivery.LastError = err.Error()
+	default:
+		delivery.LastError = err.Error()
+	}
+
+	if updateErr := d.repo.UpdateWebhookDelivery(d.ctx, delivery); updateErr != nil {
+		d.logger.Error().Err(updateErr).Str("delivery_id", delivery.ID).Msg("Failed to update webhook delivery")
+	}
+
+	if err == nil || delivery.Status == models.DeliveryStatusFailed {
+		if err != nil {
+			d.logger.Warn().Err(err).Str("webhook_id", webhook.ID).Str("delivery_id", delivery.ID).Msg("Webhook delivery failed")
+		}
+		return
+	}
+
+	time.AfterFunc(d.backoff(delivery.Attempts), func() {
+		if !d.enqueue(&webhookJob{webhook: webhook, delivery: delivery}) && d.ctx.Err() == nil {
+			d.logger.Warn().Str("delivery_id", delivery.ID).Msg("Webhook queue full, dropping retry")
+		}
+	})
+}
+
+func (d *WebhookDispatcher) post(webhook *models.Webhook, delivery *models.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(d.ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, delivery.EventType)
+	req.Header.Set(WebhookDeliveryHeader, delivery.ID)
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(webhook.Secret, time.Now().Unix(), body))
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook endpoint returned status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff returns the jittered delay before the given retry (1-based).
+func (d *WebhookDispatcher) backoff(retry int) time.Duration {
+	delay := d.initialBackoff << (retry - 1)
+	if delay <= 0 || delay > d.maxBackoff {
+		delay = d.maxBackoff
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return delay/2 + rand.N(delay/2+1)
+}
@@ -0,0 +1,145 @@
+package services_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"kb-platform-gateway/internal/config"
+	"kb-platform-gateway/internal/models"
+	repomocks "kb-platform-gateway/internal/repository/mocks"
+	"kb-platform-gateway/internal/services"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestWebhookDispatcher(t *testing.T, repo *repomocks.MockRepository, maxAttempts int) *services.WebhookDispatcher {
+	t.Helper()
+	d := services.NewWebhookDispatcher(&config.WebhookConfig{
+		Workers:        1,
+		QueueSize:      10,
+		Timeout:        time.Second,
+		MaxAttempts:    maxAttempts,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     5 * time.Millisecond,
+	}, repo, zerolog.Nop())
+	t.Cleanup(d.Close)
+	return d
+}
+
+func TestWebhookDispatcher(t *testing.T) {
+	t.Run("Dispatch_SignedAndRetried", func(t *testing.T) {
+		var calls atomic.Int32
+		bodies := make(chan []byte, 2)
+		signatures := make(chan string, 2)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			bodies <- body
+			signatures <- r.Header.Get(services.WebhookSignatureHeader)
+			assert.Equal(t, models.EventConversationCreated, r.Header.Get(services.WebhookEventHeader))
+			if calls.Add(1) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		webhook := &models.Webhook{ID: "wh-1", URL: server.URL, Secret: "0123456789abcdef", Active: true}
+		done := make(chan *models.WebhookDelivery, 1)
+
+		repo := repomocks.NewMockRepository()
+		repo.On("ListWebhooksForEvent", mock.Anything, models.EventConversationCreated).Return([]*models.Webhook{webhook}, nil)
+		repo.On("CreateWebhookDelivery", mock.Anything, mock.Anything).Return(nil)
+		repo.On("UpdateWebhookDelivery", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+			delivery := args.Get(1).(*models.WebhookDelivery)
+			if delivery.Status != models.DeliveryStatusPending {
+				done <- delivery
+			}
+		})
+
+		d := newTestWebhookDispatcher(t, repo, 3)
+		d.Dispatch(context.Background(), models.EventConversationCreated, map[string]string{"id": "conv-1"})
+
+		select {
+		case delivery := <-done:
+			assert.Equal(t, models.DeliveryStatusSucceeded, delivery.Status)
+			assert.Equal(t, 2, delivery.Attempts)
+			assert.Equal(t, http.StatusOK, delivery.ResponseCode)
+			assert.NotNil(t, delivery.DeliveredAt)
+		case <-time.After(2 * time.Second):
+			t.Fatal("delivery did not complete")
+		}
+
+		body := <-bodies
+		signature := <-signatures
+		assert.Contains(t, string(body), `"type":"conversation.created"`)
+
+		parts := strings.SplitN(signature, ",", 2)
+		require.Len(t, parts, 2)
+		ts, err := strconv.ParseInt(strings.TrimPrefix(parts[0], "t="), 10, 64)
+		require.NoError(t, err)
+		assert.Equal(t, services.SignWebhookPayload(webhook.Secret, ts, body), signature)
+	})
+
+	t.Run("Dispatch_GivesUpAfterMaxAttempts", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		webhook := &models.Webhook{ID: "wh-1", URL: server.URL, Secret: "0123456789abcdef", Active: true}
+		done := make(chan *models.WebhookDelivery, 1)
+
+		repo := repomocks.NewMockRepository()
+		repo.On("ListWebhooksForEvent", mock.Anything, models.EventDocumentFailed).Return([]*models.Webhook{webhook}, nil)
+		repo.On("CreateWebhookDelivery", mock.Anything, mock.Anything).Return(nil)
+		repo.On("UpdateWebhookDelivery", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+			delivery := args.Get(1).(*models.WebhookDelivery)
+			if delivery.Status != models.DeliveryStatusPending {
+				done <- delivery
+			}
+		})
+
+		d := newTestWebhookDispatcher(t, repo, 2)
+		d.Dispatch(context.Background(), models.EventDocumentFailed, nil)
+
+		select {
+		case delivery := <-done:
+			assert.Equal(t, models.DeliveryStatusFailed, delivery.Status)
+			assert.Equal(t, 2, delivery.Attempts)
+			assert.Equal(t, http.StatusInternalServerError, delivery.ResponseCode)
+			assert.NotEmpty(t, delivery.LastError)
+		case <-time.After(2 * time.Second):
+			t.Fatal("delivery did not complete")
+		}
+	})
+
+	t.Run("Dispatch_NoSubscribers", func(t *testing.T) {
+		listed := make(chan struct{})
+		repo := repomocks.NewMockRepository()
+		repo.On("ListWebhooksForEvent", mock.Anything, models.EventQueryCompleted).Return(nil, nil).Run(func(mock.Arguments) {
+			close(listed)
+		})
+
+		d := newTestWebhookDispatcher(t, repo, 3)
+		d.Dispatch(context.Background(), models.EventQueryCompleted, nil)
+
+		select {
+		case <-listed:
+		case <-time.After(2 * time.Second):
+			t.Fatal("event was not processed")
+		}
+		d.Close()
+		repo.AssertNotCalled(t, "CreateWebhookDelivery", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"kb-platform-gateway/internal/config"
+	"kb-platform-gateway/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// WidgetTokenPrefix starts every widget token, so they can be told apart
+// from other bearer tokens.
+const WidgetTokenPrefix = "kbwt_"
+
+// ErrWidgetOriginNotAllowed is returned when a widget token is requested
+// for an origin outside WIDGET_ALLOWED_ORIGINS.
+var ErrWidgetOriginNotAllowed = errors.New("origin is not allowed to embed the chat widget")
+
+// ErrInvalidWidgetToken is returned for a widget token that is malformed,
+// not signed with the signing key or expired.
+var ErrInvalidWidgetToken = errors.New("invalid or expired widget token")
+
+// WidgetTokens mints and verifies chat widget tokens. A token is
+// "kbwt_<claims>.<signature>": its claims as base64url JSON and their
+// HMAC-SHA256 under the signing key. Tokens are not stored, so they cannot
+// be revoked before they expire; keep the TTL short.
+type WidgetTokens struct {
+	key     []byte
+	origins []string
+	ttl     time.Duration
+}
+
+func NewWidgetTokens(cfg *config.WidgetConfig) *WidgetTokens {
+	origins := make([]string, len(cfg.AllowedOrigins))
+	for i, origin := range cfg.AllowedOrigins {
+		origins[i] = NormalizeOrigin(origin)
+	}
+	return &WidgetTokens{
+		key:     []byte(cfg.SigningKey),
+		origins: origins,
+		ttl:     max(cfg.TokenTTL, time.Minute),
+	}
+}
+
+// NormalizeOrigin lowercases origin and drops a trailing slash, so
+// configured origins compare equal to those browsers send.
+func NormalizeOrigin(origin string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(origin), "/"))
+}
+
+// Issue mints a token for a new widget session on origin.
+func (w *WidgetTokens) Issue(origin string) (*models.WidgetToken, error) {
+	origin = NormalizeOrigin(origin)
+	if !slices.Contains(w.origins, origin) {
+		return nil, ErrWidgetOriginNotAllowed
+	}
+
+	expiresAt := time.Now().Add(w.ttl).Truncate(time.Second)
+	claims := models.WidgetClaims{
+		SessionID: uuid.New().String(),
+		Origin:    origin,
+		ExpiresAt: expiresAt.Unix(),
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return &models.WidgetToken{
+		Token:     WidgetTokenPrefix + encoded + "." + base64.RawURLEncoding.EncodeToString(w.sign(encoded)),
+		Origin:    origin,
+		SessionID: claims.SessionID,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// Verify checks token's signature and expiry and returns its claims.
+func (w *WidgetTokens) Verify(token string) (*models.WidgetClaims, error) {
+	rest, ok := strings.CutPrefix(token, WidgetTokenPrefix)
+	if !ok {
+		return nil, ErrInvalidWidgetToken
+	}
+	encoded, signature, ok := strings.Cut(rest, ".")
+	if !ok {
+		return nil, ErrInvalidWidgetToken
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, w.sign(encoded)) {
+		return nil, ErrInvalidWidgetToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidWidgetToken
+	}
+
+	var claims models.WidgetClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, ErrInvalidWidgetToken
+	}
+	if claims.SessionID == "" || !time.Now().Before(time.Unix(claims.ExpiresAt, 0)) {
+		return nil, ErrInvalidWidgetToken
+	}
+	return &claims, nil
+}
+
+func (w *WidgetTokens) sign(encoded string) []byte {
+	mac := hmac.New(sha256.New, w.key)
+	mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,84 @@
+package services_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"kb-platform-gateway/internal/config"
+	"kb-platform-gateway/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWidgetTokens(t *testing.T) {
+	cfg := &config.WidgetConfig{
+		SigningKey:     "widget-secret",
+		AllowedOrigins: []string{"https://Docs.Example.com/"},
+		TokenTTL:       15 * time.Minute,
+	}
+	tokens := services.NewWidgetTokens(cfg)
+
+	t.Run("Issue_Verify", func(t *testing.T) {
+		token, err := tokens.Issue("https://docs.example.com")
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(token.Token, services.WidgetTokenPrefix))
+		assert.Equal(t, "https://docs.example.com", token.Origin)
+		assert.WithinDuration(t, time.Now().Add(15*time.Minute), token.ExpiresAt, 2*time.Second)
+
+		claims, err := tokens.Verify(token.Token)
+
+		require.NoError(t, err)
+		assert.Equal(t, token.SessionID, claims.SessionID)
+		assert.Equal(t, "https://docs.example.com", claims.Origin)
+	})
+
+	t.Run("Issue_OriginNotAllowed", func(t *testing.T) {
+		_, err := tokens.Issue("https://evil.example.com")
+
+		assert.ErrorIs(t, err, services.ErrWidgetOriginNotAllowed)
+	})
+
+	t.Run("Verify_Tampered", func(t *testing.T) {
+		token, err := tokens.Issue("https://docs.example.com")
+		require.NoError(t, err)
+		encoded, signature, _ := strings.Cut(strings.TrimPrefix(token.Token, services.WidgetTokenPrefix), ".")
+		claims, _ := base64.RawURLEncoding.DecodeString(encoded)
+		forged := strings.Replace(string(claims), "docs.example.com", "evil.example.com", 1)
+
+		_, err = tokens.Verify(services.WidgetTokenPrefix + base64.RawURLEncoding.EncodeToString([]byte(forged)) + "." + signature)
+
+		assert.ErrorIs(t, err, services.ErrInvalidWidgetToken)
+	})
+
+	t.Run("Verify_OtherKey", func(t *testing.T) {
+		other := services.NewWidgetTokens(&config.WidgetConfig{SigningKey: "other", AllowedOrigins: cfg.AllowedOrigins})
+		token, err := other.Issue("https://docs.example.com")
+		require.NoError(t, err)
+
+		_, err = tokens.Verify(token.Token)
+
+		assert.ErrorIs(t, err, services.ErrInvalidWidgetToken)
+	})
+
+	t.Run("Verify_Expired", func(t *testing.T) {
+		encoded := base64.RawURLEncoding.EncodeToString([]byte(`{"sid":"s-1","origin":"https://docs.example.com","exp":1700000000}`))
+		mac := hmac.New(sha256.New, []byte(cfg.SigningKey))
+		mac.Write([]byte(encoded))
+
+		_, err := tokens.Verify(services.WidgetTokenPrefix + encoded + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)))
+
+		assert.ErrorIs(t, err, services.ErrInvalidWidgetToken)
+	})
+
+	t.Run("Verify_Malformed", func(t *testing.T) {
+		for _, token := range []string{"", "kbst_abc", "kbwt_abc", "kbwt_!!.!!"} {
+			_, err := tokens.Verify(token)
+			assert.ErrorIs(t, err, services.ErrInvalidWidgetToken, token)
+		}
+	})
+}
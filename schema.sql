@@ -3,6 +3,9 @@
 -- Enable pgcrypto for UUID generation
 CREATE EXTENSION IF NOT EXISTS "pgcrypto";
 
+-- Enable pg_trgm for query suggestions
+CREATE EXTENSION IF NOT EXISTS "pg_trgm";
+
 -- Documents table
 CREATE TABLE IF NOT EXISTS documents (
     id VARCHAR(36) PRIMARY KEY DEFAULT gen_random_uuid()::text,
@@ -11,12 +14,16 @@ CREATE TABLE IF NOT EXISTS documents (
     status VARCHAR(50) NOT NULL DEFAULT 'pending',
     s3_key VARCHAR(255),
     error_message TEXT,
+    uploaded_by VARCHAR(255),
     metadata JSONB DEFAULT '{}'::jsonb,
     created_at TIMESTAMP NOT NULL DEFAULT NOW(),
     indexed_at TIMESTAMP,
     CONSTRAINT chk_document_status CHECK (status IN ('pending', 'indexing', 'complete', 'failed'))
 );
 
+-- Added after the initial release
+ALTER TABLE documents ADD COLUMN IF NOT EXISTS uploaded_by VARCHAR(255);
+
 -- Index for status filtering (Composite index is more efficient for common queries)
 CREATE INDEX IF NOT EXISTS idx_documents_status_created_at ON documents(status, created_at DESC);
 
@@ -62,3 +69,664 @@ CREATE TRIGGER trg_update_conversation
 AFTER INSERT ON messages
 FOR EACH ROW
 EXECUTE FUNCTION update_conversation_timestamp();
+
+-- Outbound webhook subscriptions
+CREATE TABLE IF NOT EXISTS webhooks (
+    id VARCHAR(36) PRIMARY KEY DEFAULT gen_random_uuid()::text,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    events TEXT[] NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by VARCHAR(255),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Webhook delivery log
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id VARCHAR(36) PRIMARY KEY DEFAULT gen_random_uuid()::text,
+    webhook_id VARCHAR(36) NOT NULL,
+    event_id VARCHAR(36) NOT NULL,
+    event_type VARCHAR(100) NOT NULL,
+    payload TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    response_code INTEGER,
+    last_error TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    delivered_at TIMESTAMP,
+    FOREIGN KEY (webhook_id) REFERENCES webhooks(id) ON DELETE CASCADE,
+    CONSTRAINT chk_delivery_status CHECK (status IN ('pending', 'succeeded', 'failed'))
+);
+
+-- Index for the per-webhook delivery log
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook_id ON webhook_deliveries(webhook_id, created_at DESC);
+
+-- Events reported by the Python core and Temporal workers
+CREATE TABLE IF NOT EXISTS events (
+    id VARCHAR(36) PRIMARY KEY DEFAULT gen_random_uuid()::text,
+    type VARCHAR(100) NOT NULL,
+    source VARCHAR(50) NOT NULL,
+    subject_id VARCHAR(255) NOT NULL,
+    data JSONB DEFAULT '{}'::jsonb,
+    occurred_at TIMESTAMP NOT NULL,
+    received_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Index for per-subject event history
+CREATE INDEX IF NOT EXISTS idx_events_subject_id ON events(subject_id, occurred_at ASC);
+
+-- Queries made through the gateway, exported for usage analysis
+CREATE TABLE IF NOT EXISTS query_logs (
+    id VARCHAR(36) PRIMARY KEY DEFAULT gen_random_uuid()::text,
+    username VARCHAR(255) NOT NULL,
+    conversation_id VARCHAR(36),
+    question TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    latency_ms BIGINT NOT NULL,
+    tokens INTEGER,
+    feedback SMALLINT,
+    feedback_comment TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_query_status CHECK (status IN ('completed', 'failed', 'cancelled')),
+    CONSTRAINT chk_query_feedback CHECK (feedback IN (-1, 1))
+);
+
+-- Index for time-range exports
+CREATE INDEX IF NOT EXISTS idx_query_logs_created_at ON query_logs(created_at ASC);
+
+-- Per-user email notification settings
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    username VARCHAR(255) PRIMARY KEY,
+    email VARCHAR(320) NOT NULL DEFAULT '',
+    import_finished BOOLEAN NOT NULL DEFAULT TRUE,
+    indexing_failed BOOLEAN NOT NULL DEFAULT TRUE,
+    export_ready BOOLEAN NOT NULL DEFAULT TRUE,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Named prompt templates; each update adds a row to prompt_template_versions
+CREATE TABLE IF NOT EXISTS prompt_templates (
+    id VARCHAR(36) PRIMARY KEY DEFAULT gen_random_uuid()::text,
+    name VARCHAR(255) NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    latest_version INTEGER NOT NULL DEFAULT 1,
+    created_by VARCHAR(255),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS prompt_template_versions (
+    template_id VARCHAR(36) NOT NULL REFERENCES prompt_templates(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    template TEXT NOT NULL,
+    created_by VARCHAR(255),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (template_id, version)
+);
+
+-- Embedding model migrations; queries use the target collection of the
+-- latest completed migration
+CREATE TABLE IF NOT EXISTS embedding_migrations (
+    id VARCHAR(36) PRIMARY KEY DEFAULT gen_random_uuid()::text,
+    embedding_model VARCHAR(255) NOT NULL,
+    vector_size INTEGER NOT NULL,
+    source_collection VARCHAR(255) NOT NULL,
+    target_collection VARCHAR(255) NOT NULL UNIQUE,
+    status VARCHAR(50) NOT NULL DEFAULT 'running',
+    total_documents INTEGER NOT NULL DEFAULT 0,
+    indexed_documents INTEGER NOT NULL DEFAULT 0,
+    failed_documents INTEGER NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    created_by VARCHAR(255),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMP,
+    CONSTRAINT chk_embedding_migration_status CHECK (status IN ('running', 'completed', 'failed'))
+);
+
+-- At most one migration runs at a time
+CREATE UNIQUE INDEX IF NOT EXISTS idx_embedding_migrations_running ON embedding_migrations(status) WHERE status = 'running';
+CREATE INDEX IF NOT EXISTS idx_embedding_migrations_completed_at ON embedding_migrations(completed_at DESC) WHERE status = 'completed';
+
+-- Re-indexing state of each document in a migration
+CREATE TABLE IF NOT EXISTS embedding_migration_documents (
+    migration_id VARCHAR(36) NOT NULL REFERENCES embedding_migrations(id) ON DELETE CASCADE,
+    document_id VARCHAR(36) NOT NULL REFERENCES documents(id) ON DELETE CASCADE,
+    status VARCHAR(50) NOT NULL DEFAULT 'pending',
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (migration_id, document_id),
+    CONSTRAINT chk_embedding_migration_document_status CHECK (status IN ('pending', 'queued', 'indexed', 'failed'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_embedding_migration_documents_status ON embedding_migration_documents(migration_id, status);
+
+-- Evaluation runs over question/expected-answer sets
+CREATE TABLE IF NOT EXISTS evaluations (
+    id VARCHAR(36) PRIMARY KEY DEFAULT gen_random_uuid()::text,
+    name VARCHAR(255) NOT NULL DEFAULT '',
+    status VARCHAR(50) NOT NULL DEFAULT 'running',
+    top_k INTEGER NOT NULL,
+    prompt_template_id VARCHAR(36) NOT NULL DEFAULT '',
+    prompt_template_version INTEGER NOT NULL DEFAULT 0,
+    total_cases INTEGER NOT NULL,
+    completed_cases INTEGER NOT NULL DEFAULT 0,
+    failed_cases INTEGER NOT NULL DEFAULT 0,
+    average_score DOUBLE PRECISION,
+    error TEXT NOT NULL DEFAULT '',
+    created_by VARCHAR(255),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMP,
+    CONSTRAINT chk_evaluation_status CHECK (status IN ('running', 'completed', 'failed'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_evaluations_created_at ON evaluations(created_at DESC);
+
+CREATE TABLE IF NOT EXISTS evaluation_results (
+    evaluation_id VARCHAR(36) NOT NULL REFERENCES evaluations(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    question TEXT NOT NULL,
+    expected_answer TEXT NOT NULL,
+    answer TEXT NOT NULL DEFAULT '',
+    score DOUBLE PRECISION,
+    metrics JSONB,
+    error TEXT NOT NULL DEFAULT '',
+    completed_at TIMESTAMP,
+    PRIMARY KEY (evaluation_id, position)
+);
+
+-- Source URL checks for the content freshness report. The source URL is
+-- read from the document's metadata.
+ALTER TABLE documents ADD COLUMN IF NOT EXISTS source_status INTEGER;
+ALTER TABLE documents ADD COLUMN IF NOT EXISTS source_checked_at TIMESTAMP;
+
+-- Documents retrieved for each query, for the never-retrieved report
+ALTER TABLE query_logs ADD COLUMN IF NOT EXISTS document_ids TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS idx_query_logs_document_ids ON query_logs USING GIN (document_ids);
+
+-- Chunks cited in answers, for per-document retrieval analytics. Rows
+-- outlive deleted documents; reports join on documents.
+CREATE TABLE IF NOT EXISTS query_citations (
+    query_id VARCHAR(36) NOT NULL REFERENCES query_logs(id) ON DELETE CASCADE,
+    document_id VARCHAR(36) NOT NULL,
+    chunk_id VARCHAR(255) NOT NULL DEFAULT '',
+    score DOUBLE PRECISION NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_query_citations_document_id ON query_citations(document_id, created_at DESC);
+
+-- Where each cited chunk sits in its document, and its rank in the answer,
+-- for deep links from assistant messages.
+ALTER TABLE query_citations ADD COLUMN IF NOT EXISTS position INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE query_citations ADD COLUMN IF NOT EXISTS page INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE query_citations ADD COLUMN IF NOT EXISTS start_char INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE query_citations ADD COLUMN IF NOT EXISTS end_char INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_query_citations_query_id ON query_citations(query_id, position);
+
+-- Lifecycle of each document, for support. Rows outlive deleted documents
+-- so their timeline stays available.
+CREATE TABLE IF NOT EXISTS document_events (
+    id VARCHAR(36) PRIMARY KEY DEFAULT gen_random_uuid()::text,
+    document_id VARCHAR(36) NOT NULL,
+    type VARCHAR(50) NOT NULL,
+    source VARCHAR(50) NOT NULL,
+    message TEXT NOT NULL DEFAULT '',
+    data JSONB NOT NULL DEFAULT '{}'::jsonb,
+    occurred_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_document_events_document_id ON document_events(document_id, occurred_at ASC);
+
+-- Service tokens for pipelines. Only a SHA-256 hash of each secret is kept.
+CREATE TABLE IF NOT EXISTS service_tokens (
+    id VARCHAR(36) PRIMARY KEY DEFAULT gen_random_uuid()::text,
+    name VARCHAR(100) NOT NULL UNIQUE,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    prefix VARCHAR(20) NOT NULL,
+    scopes TEXT[] NOT NULL,
+    created_by VARCHAR(255),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP,
+    rotated_at TIMESTAMP,
+    last_used_at TIMESTAMP
+);
+
+-- External sources synced into the knowledge base. credentials holds the
+-- OAuth credentials sealed with CONNECTOR_ENCRYPTION_KEY.
+CREATE TABLE IF NOT EXISTS connectors (
+    id VARCHAR(36) PRIMARY KEY DEFAULT gen_random_uuid()::text,
+    username VARCHAR(255) NOT NULL,
+    provider VARCHAR(50) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    folder_ids TEXT[] NOT NULL,
+    sync_interval_minutes INTEGER NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'idle',
+    last_error TEXT NOT NULL DEFAULT '',
+    last_sync_started_at TIMESTAMP,
+    last_synced_at TIMESTAMP,
+    next_sync_at TIMESTAMP NOT NULL,
+    credentials BYTEA NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_connectors_username ON connectors(username);
+CREATE INDEX IF NOT EXISTS idx_connectors_next_sync_at ON connectors(next_sync_at);
+
+-- Files ingested by each connector, to skip unchanged files and replace
+-- the documents of changed ones.
+CREATE TABLE IF NOT EXISTS connector_files (
+    connector_id VARCHAR(36) NOT NULL REFERENCES connectors(id) ON DELETE CASCADE,
+    external_id TEXT NOT NULL,
+    document_id VARCHAR(36) NOT NULL,
+    modified_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (connector_id, external_id)
+);
+
+ALTER TABLE connector_files ADD COLUMN IF NOT EXISTS content_hash VARCHAR(128) NOT NULL DEFAULT '';
+
+-- Cron schedules re-syncing documents from their source URL, or connectors
+-- instead of their sync interval. Each row mirrors a Temporal schedule.
+CREATE TABLE IF NOT EXISTS resync_schedules (
+    source_type VARCHAR(20) NOT NULL,
+    source_id VARCHAR(36) NOT NULL,
+    cron VARCHAR(100) NOT NULL,
+    content_hash VARCHAR(128) NOT NULL DEFAULT '',
+    created_by VARCHAR(255),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_checked_at TIMESTAMP,
+    last_changed_at TIMESTAMP,
+    PRIMARY KEY (source_type, source_id)
+);
+
+-- Files expanded from an uploaded archive point at it.
+ALTER TABLE documents ADD COLUMN IF NOT EXISTS parent_id VARCHAR(36);
+
+CREATE INDEX IF NOT EXISTS idx_documents_parent_id ON documents(parent_id, created_at DESC) WHERE parent_id IS NOT NULL;
+
+-- Language detected by the indexer, for per-language listing and queries.
+ALTER TABLE documents ADD COLUMN IF NOT EXISTS language VARCHAR(35);
+
+CREATE INDEX IF NOT EXISTS idx_documents_language_created_at ON documents(language, created_at DESC);
+
+-- Recent answers, for answering near-identical questions without the core.
+CREATE TABLE IF NOT EXISTS answered_questions (
+    query_id VARCHAR(36) PRIMARY KEY,
+    scope TEXT NOT NULL,
+    question TEXT NOT NULL,
+    answer TEXT NOT NULL,
+    document_ids TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_answered_questions_scope_created_at ON answered_questions(scope, created_at DESC);
+
+-- Rolling summaries of long conversations, one row per version.
+CREATE TABLE IF NOT EXISTS conversation_summaries (
+    conversation_id VARCHAR(36) NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    summary TEXT NOT NULL,
+    message_count INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (conversation_id, version)
+);
+
+-- Trigram indexes for query suggestions
+CREATE INDEX IF NOT EXISTS idx_query_logs_question_trgm ON query_logs USING GIN (question gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_documents_filename_trgm ON documents USING GIN (filename gin_trgm_ops);
+
+-- Answers pinned by editors to question patterns, served in place of the
+-- core's answer
+CREATE TABLE IF NOT EXISTS curated_answers (
+    id VARCHAR(36) PRIMARY KEY DEFAULT gen_random_uuid()::text,
+    pattern TEXT NOT NULL,
+    match_type VARCHAR(20) NOT NULL DEFAULT 'exact',
+    answer TEXT NOT NULL,
+    document_ids TEXT[] NOT NULL DEFAULT '{}',
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by VARCHAR(255),
+    updated_by VARCHAR(255),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_curated_match_type CHECK (match_type IN ('exact', 'contains', 'regex'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_curated_answers_enabled ON curated_answers(updated_at DESC) WHERE enabled;
+
+-- Glossary terms highlighted in answers
+CREATE TABLE IF NOT EXISTS glossary_terms (
+    id VARCHAR(36) PRIMARY KEY DEFAULT gen_random_uuid()::text,
+    term VARCHAR(200) NOT NULL,
+    definition TEXT NOT NULL DEFAULT '',
+    created_by VARCHAR(255),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_glossary_terms_term ON glossary_terms(LOWER(term));
+
+-- When the presigned upload URL last handed out for a document was issued
+-- and expires; completes are refused once it has expired.
+ALTER TABLE documents ADD COLUMN IF NOT EXISTS upload_url_issued_at TIMESTAMP;
+ALTER TABLE documents ADD COLUMN IF NOT EXISTS upload_url_expires_at TIMESTAMP;
+
+-- Edits to a document's metadata, for optimistic concurrency: an update
+-- must name the version it was based on.
+ALTER TABLE documents ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+
+-- Named document filters ("smart folders"), shared with every user.
+CREATE TABLE IF NOT EXISTS saved_searches (
+    id VARCHAR(36) PRIMARY KEY DEFAULT gen_random_uuid()::text,
+    name VARCHAR(200) NOT NULL,
+    filter JSONB NOT NULL DEFAULT '{}'::jsonb,
+    created_by VARCHAR(255),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Saved searches and document listings filter on metadata.
+CREATE INDEX IF NOT EXISTS idx_documents_metadata ON documents USING GIN (metadata);
+
+-- Deleted documents stay in the trash, restorable, until a purge deletes
+-- them for good.
+ALTER TABLE documents ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+CREATE INDEX IF NOT EXISTS idx_documents_deleted_at ON documents(deleted_at) WHERE deleted_at IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS trash_purges (
+    id VARCHAR(36) PRIMARY KEY DEFAULT gen_random_uuid()::text,
+    started_at TIMESTAMP NOT NULL,
+    finished_at TIMESTAMP NOT NULL,
+    purged INTEGER NOT NULL DEFAULT 0,
+    failed INTEGER NOT NULL DEFAULT 0,
+    reclaimed_bytes BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_trash_purges_finished_at ON trash_purges(finished_at DESC);
+
+-- Per-document chunking overrides passed to the indexing workflow; NULL
+-- uses the indexer's defaults.
+ALTER TABLE documents ADD COLUMN IF NOT EXISTS chunking JSONB;
+
+-- Per-document text extraction options (OCR, language hints, table
+-- extraction) passed to the upload and indexing workflows; NULL uses the
+-- indexer's defaults.
+ALTER TABLE documents ADD COLUMN IF NOT EXISTS processing JSONB;
+
+-- Near-duplicate document scans; clusters are stored once the scan
+-- completes.
+CREATE TABLE IF NOT EXISTS duplicate_reports (
+    id VARCHAR(36) PRIMARY KEY DEFAULT gen_random_uuid()::text,
+    status VARCHAR(50) NOT NULL DEFAULT 'running',
+    threshold DOUBLE PRECISION NOT NULL,
+    documents_scanned INTEGER NOT NULL DEFAULT 0,
+    cluster_count INTEGER NOT NULL DEFAULT 0,
+    clusters JSONB,
+    error TEXT NOT NULL DEFAULT '',
+    created_by VARCHAR(255),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMP,
+    CONSTRAINT chk_duplicate_report_status CHECK (status IN ('running', 'completed', 'failed'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_duplicate_reports_created_at ON duplicate_reports(created_at DESC);
+
+-- Sessions in which an admin acts as another user, and every request made
+-- in them.
+CREATE TABLE IF NOT EXISTS impersonation_sessions (
+    id VARCHAR(36) PRIMARY KEY,
+    impersonator VARCHAR(255) NOT NULL,
+    username VARCHAR(255) NOT NULL,
+    reason TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_impersonation_sessions_created_at ON impersonation_sessions(created_at DESC);
+
+CREATE TABLE IF NOT EXISTS impersonation_requests (
+    id BIGSERIAL PRIMARY KEY,
+    session_id VARCHAR(36) NOT NULL REFERENCES impersonation_sessions(id) ON DELETE CASCADE,
+    method VARCHAR(10) NOT NULL,
+    path TEXT NOT NULL,
+    status INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_impersonation_requests_session ON impersonation_requests(session_id, created_at);
+
+-- The knowledge base's branding and defaults, read by the frontend when it
+-- loads. A single row, absent until an admin first saves the settings.
+CREATE TABLE IF NOT EXISTS workspace_settings (
+    singleton BOOLEAN PRIMARY KEY DEFAULT TRUE,
+    name VARCHAR(100) NOT NULL,
+    logo_url TEXT NOT NULL DEFAULT '',
+    default_language VARCHAR(35) NOT NULL DEFAULT '',
+    retention_days INTEGER NOT NULL DEFAULT 0,
+    allowed_file_types TEXT[] NOT NULL DEFAULT '{}',
+    updated_by VARCHAR(255),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_workspace_settings_singleton CHECK (singleton)
+);
+
+-- The Temporal workflow last started to index a document, so a stuck
+-- document can be traced to its workflow.
+ALTER TABLE documents ADD COLUMN IF NOT EXISTS workflow_id VARCHAR(255);
+
+-- Indexing can be cancelled through the API.
+ALTER TABLE documents DROP CONSTRAINT IF EXISTS chk_document_status;
+ALTER TABLE documents ADD CONSTRAINT chk_document_status CHECK (status IN ('pending', 'indexing', 'complete', 'failed', 'cancelled'));
+
+-- S3 multipart uploads of large files, with the parts uploaded so far, so
+-- an interrupted upload can resume and a failed completion be retried.
+CREATE TABLE IF NOT EXISTS multipart_uploads (
+    document_id VARCHAR(36) PRIMARY KEY REFERENCES documents(id) ON DELETE CASCADE,
+    upload_id TEXT NOT NULL,
+    part_size BIGINT NOT NULL,
+    part_count INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS multipart_upload_parts (
+    document_id VARCHAR(36) NOT NULL REFERENCES multipart_uploads(document_id) ON DELETE CASCADE,
+    part_number INTEGER NOT NULL,
+    etag TEXT NOT NULL,
+    uploaded_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (document_id, part_number)
+);
+
+-- Free-form labels for grouping documents, also copied into the payload
+-- of the document's vectors so retrieval can filter by them.
+ALTER TABLE documents ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+CREATE INDEX IF NOT EXISTS idx_documents_tags ON documents USING GIN (tags);
+
+-- Snapshots of the knowledge base that queries can name in as_of: a copy
+-- of the vectors in collection, a Qdrant snapshot of the source collection,
+-- and the documents indexed when the snapshot was taken. The documents are
+-- copied rather than referenced, so they outlive later edits and deletions.
+CREATE TABLE IF NOT EXISTS snapshots (
+    id VARCHAR(36) PRIMARY KEY,
+    name VARCHAR(100) NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    status VARCHAR(50) NOT NULL DEFAULT 'creating',
+    source_collection VARCHAR(255) NOT NULL,
+    collection VARCHAR(255) NOT NULL,
+    qdrant_snapshot TEXT NOT NULL DEFAULT '',
+    document_count INTEGER NOT NULL DEFAULT 0,
+    vector_count BIGINT NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    created_by VARCHAR(255),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMP,
+    CONSTRAINT chk_snapshot_status CHECK (status IN ('creating', 'ready', 'failed'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_snapshots_created_at ON snapshots(created_at DESC);
+
+CREATE TABLE IF NOT EXISTS snapshot_documents (
+    snapshot_id VARCHAR(36) NOT NULL REFERENCES snapshots(id) ON DELETE CASCADE,
+    document_id VARCHAR(36) NOT NULL,
+    filename VARCHAR(255) NOT NULL,
+    file_size BIGINT NOT NULL,
+    version INTEGER NOT NULL,
+    metadata JSONB,
+    tags TEXT[] NOT NULL DEFAULT '{}',
+    indexed_at TIMESTAMP,
+    PRIMARY KEY (snapshot_id, document_id)
+);
+
+-- Version of the gateway that took each snapshot.
+ALTER TABLE snapshots ADD COLUMN IF NOT EXISTS config_version VARCHAR(255) NOT NULL DEFAULT '';
+
+-- Named sets of documents, or folders, that queries can be restricted to.
+-- A document may be in any number of collections.
+CREATE TABLE IF NOT EXISTS collections (
+    id VARCHAR(36) PRIMARY KEY,
+    name VARCHAR(200) NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    created_by VARCHAR(255),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS collection_documents (
+    collection_id VARCHAR(36) NOT NULL REFERENCES collections(id) ON DELETE CASCADE,
+    document_id VARCHAR(36) NOT NULL REFERENCES documents(id) ON DELETE CASCADE,
+    added_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (collection_id, document_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_collection_documents_document_id ON collection_documents(document_id);
+
+-- Imports of workspace bundles exported by another gateway.
+CREATE TABLE IF NOT EXISTS workspace_imports (
+    id VARCHAR(36) PRIMARY KEY,
+    status VARCHAR(50) NOT NULL DEFAULT 'running',
+    bundle_exported_at TIMESTAMP NOT NULL,
+    documents_total INTEGER NOT NULL DEFAULT 0,
+    documents_imported INTEGER NOT NULL DEFAULT 0,
+    documents_skipped INTEGER NOT NULL DEFAULT 0,
+    documents_failed INTEGER NOT NULL DEFAULT 0,
+    conversations_imported INTEGER NOT NULL DEFAULT 0,
+    conversations_skipped INTEGER NOT NULL DEFAULT 0,
+    failures JSONB,
+    error TEXT NOT NULL DEFAULT '',
+    created_by VARCHAR(255),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMP,
+    CONSTRAINT chk_workspace_import_status CHECK (status IN ('running', 'completed', 'failed'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_workspace_imports_created_at ON workspace_imports(created_at DESC);
+
+-- Metadata keys documents must or may carry, with their types and allowed
+-- values, checked on upload and metadata edits.
+ALTER TABLE workspace_settings ADD COLUMN IF NOT EXISTS metadata_schema JSONB NOT NULL DEFAULT '[]'::jsonb;
+
+-- Hex SHA-256 of a document's file, when known, so the same content is not
+-- uploaded and indexed twice.
+ALTER TABLE documents ADD COLUMN IF NOT EXISTS content_hash VARCHAR(64);
+
+CREATE INDEX IF NOT EXISTS idx_documents_content_hash ON documents(content_hash) WHERE content_hash IS NOT NULL;
+
+-- Uploads can wait for a reviewer, who approves or rejects them.
+ALTER TABLE documents DROP CONSTRAINT IF EXISTS chk_document_status;
+ALTER TABLE documents ADD CONSTRAINT chk_document_status CHECK (status IN ('pending', 'pending_review', 'indexing', 'complete', 'failed', 'cancelled', 'rejected'));
+
+-- Rules replacing matching text in answers before they reach clients
+CREATE TABLE IF NOT EXISTS redaction_rules (
+    id VARCHAR(36) PRIMARY KEY DEFAULT gen_random_uuid()::text,
+    name VARCHAR(200) NOT NULL,
+    type VARCHAR(20) NOT NULL,
+    pattern TEXT NOT NULL DEFAULT '',
+    keywords TEXT[] NOT NULL DEFAULT '{}',
+    placeholder VARCHAR(100) NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by VARCHAR(255),
+    updated_by VARCHAR(255),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_redaction_rule_type CHECK (type IN ('regex', 'keywords'))
+);
+
+-- Result of the malware scan of an uploaded file: clean or infected, with
+-- the signature found in an infected one. NULL when it was not scanned.
+ALTER TABLE documents ADD COLUMN IF NOT EXISTS scan_result VARCHAR(20);
+ALTER TABLE documents ADD COLUMN IF NOT EXISTS scan_signature TEXT;
+ALTER TABLE documents ADD COLUMN IF NOT EXISTS scanned_at TIMESTAMP;
+
+-- Users taking part in a shared conversation, and when each last read it.
+CREATE TABLE IF NOT EXISTS conversation_participants (
+    conversation_id VARCHAR(36) NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+    username VARCHAR(255) NOT NULL,
+    invited_by VARCHAR(255),
+    joined_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_read_at TIMESTAMP,
+    PRIMARY KEY (conversation_id, username)
+);
+
+CREATE INDEX IF NOT EXISTS idx_conversation_participants_username ON conversation_participants(username);
+
+-- User who created a conversation, who may read and share it. Older
+-- conversations are credited to the first user who queried them.
+ALTER TABLE conversations ADD COLUMN IF NOT EXISTS created_by VARCHAR(255);
+
+UPDATE conversations c
+SET created_by = (
+    SELECT q.username FROM query_logs q
+    WHERE q.conversation_id = c.id
+    ORDER BY q.created_at
+    LIMIT 1
+)
+WHERE c.created_by IS NULL;
+
+-- Title the indexer extracted, searched with the filename by full text.
+ALTER TABLE documents ADD COLUMN IF NOT EXISTS title TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_documents_search ON documents USING GIN (to_tsvector('simple', COALESCE(title, '') || ' ' || filename));
+
+-- Archives of the documents' files with a manifest of their metadata,
+-- built in S3 by a knowledge base export workflow.
+CREATE TABLE IF NOT EXISTS knowledge_base_exports (
+    id VARCHAR(36) PRIMARY KEY,
+    status VARCHAR(20) NOT NULL,
+    format VARCHAR(10) NOT NULL,
+    filter JSONB NOT NULL DEFAULT '{}',
+    document_count INTEGER NOT NULL DEFAULT 0,
+    documents_exported INTEGER NOT NULL DEFAULT 0,
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+    manifest_key VARCHAR(1024) NOT NULL,
+    archive_key VARCHAR(1024) NOT NULL,
+    error TEXT NOT NULL DEFAULT '',
+    created_by VARCHAR(255),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMP,
+    CONSTRAINT chk_knowledge_base_export_status CHECK (status IN ('running', 'ready', 'failed')),
+    CONSTRAINT chk_knowledge_base_export_format CHECK (format IN ('zip', 'tar'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_knowledge_base_exports_created_by ON knowledge_base_exports(created_by, created_at DESC);
+
+-- Access groups a document is restricted to; queries only retrieve its
+-- chunks for their members. Empty leaves it readable by everyone.
+ALTER TABLE documents ADD COLUMN IF NOT EXISTS access_groups TEXT[] NOT NULL DEFAULT '{}';
+
+-- Queries are filtered on the access groups in the vectors' payload, not
+-- on this table, so restricted documents need no index of their own.
+DROP INDEX IF EXISTS idx_documents_restricted;
+
+-- Version of this schema, checked by `gateway check`. Keep this last, and
+-- bump it together with repository.SchemaVersion whenever the file changes.
+CREATE TABLE IF NOT EXISTS schema_version (
+    singleton BOOLEAN PRIMARY KEY DEFAULT TRUE,
+    version INTEGER NOT NULL,
+    applied_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_schema_version_singleton CHECK (singleton)
+);
+
+INSERT INTO schema_version (version) VALUES (28)
+ON CONFLICT (singleton) DO UPDATE SET version = EXCLUDED.version, applied_at = NOW();